@@ -0,0 +1,22 @@
+// Package admin serves the embedded admin dashboard.
+// The dashboard is a static page; all data is fetched from the authenticated /api/v1/admin endpoints.
+package admin
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// FileSystem returns the dashboard assets rooted at the static directory
+func FileSystem() http.FileSystem {
+	assets, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		// The directory is embedded at build time, so this only fails if the embed pattern changes
+		panic(err)
+	}
+	return http.FS(assets)
+}
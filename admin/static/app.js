@@ -0,0 +1,124 @@
+(function () {
+  "use strict";
+
+  var REFRESH_INTERVAL_MS = 5000;
+  var STORAGE_KEY = "notification-service-api-key";
+
+  var apiKeyInput = document.getElementById("api-key");
+  var statusLabel = document.getElementById("status");
+  var timer = null;
+
+  apiKeyInput.value = window.localStorage.getItem(STORAGE_KEY) || "";
+
+  document.getElementById("api-key-form").addEventListener("submit", function (event) {
+    event.preventDefault();
+    window.localStorage.setItem(STORAGE_KEY, apiKeyInput.value);
+    refresh();
+  });
+
+  function setStatus(text, isError) {
+    statusLabel.textContent = text;
+    statusLabel.className = isError ? "status error" : "status";
+  }
+
+  function cell(value, className) {
+    var td = document.createElement("td");
+    td.textContent = value === undefined || value === null ? "" : String(value);
+    if (className) {
+      td.className = className;
+    }
+    return td;
+  }
+
+  function fillTable(id, rows, render) {
+    var tbody = document.querySelector("#" + id + " tbody");
+    tbody.innerHTML = "";
+    rows.forEach(function (row) {
+      var tr = document.createElement("tr");
+      render(row).forEach(function (td) { tr.appendChild(td); });
+      tbody.appendChild(tr);
+    });
+  }
+
+  function formatTime(value) {
+    return value ? new Date(value).toLocaleString() : "";
+  }
+
+  function percent(ratio) {
+    return (ratio * 100).toFixed(1) + "%";
+  }
+
+  function fillBar(depth, capacity) {
+    var ratio = capacity > 0 ? depth / capacity : 0;
+    var td = document.createElement("td");
+    var bar = document.createElement("div");
+    var fill = document.createElement("span");
+    bar.className = ratio > 0.8 ? "bar high" : "bar";
+    fill.style.width = Math.min(100, ratio * 100) + "%";
+    bar.appendChild(fill);
+    td.appendChild(bar);
+    return td;
+  }
+
+  function render(overview) {
+    fillTable("queues", overview.queues || [], function (queue) {
+      return [cell(queue.name), cell(queue.depth), cell(queue.capacity), fillBar(queue.depth, queue.capacity)];
+    });
+
+    fillTable("failure-rates", overview.failure_rates || [], function (rate) {
+      return [
+        cell(rate.type),
+        cell(rate.notifications),
+        cell(rate.failed_notifications),
+        cell(rate.deliveries_sent),
+        cell(rate.deliveries_failed),
+        cell(percent(rate.delivery_failure_rate), rate.delivery_failure_rate > 0 ? "failed" : "")
+      ];
+    });
+
+    document.getElementById("total").textContent = "(" + overview.total_notifications + " total)";
+    fillTable("recent", overview.recent_notifications || [], function (notification) {
+      return [
+        cell(notification.id),
+        cell(notification.type),
+        cell(notification.status, notification.status),
+        cell((notification.tags || []).join(", ")),
+        cell(formatTime(notification.created_at)),
+        cell(formatTime(notification.sent_at))
+      ];
+    });
+
+    fillTable("templates", overview.templates || [], function (template) {
+      return [cell(template.name), cell(template.type), cell(template.version), cell(template.status), cell(template.id)];
+    });
+  }
+
+  function refresh() {
+    window.clearTimeout(timer);
+
+    var headers = {};
+    if (apiKeyInput.value) {
+      headers.Authorization = "Bearer " + apiKeyInput.value;
+    }
+
+    fetch("/api/v1/admin/overview", { headers: headers })
+      .then(function (response) {
+        if (!response.ok) {
+          throw new Error(response.status === 401 ? "Invalid or missing API key" : "Request failed: " + response.status);
+        }
+        return response.json();
+      })
+      .then(function (overview) {
+        render(overview);
+        setStatus("Updated " + new Date().toLocaleTimeString(), false);
+      })
+      .catch(function (error) {
+        setStatus(error.message, true);
+      })
+      .then(function () {
+        timer = window.setTimeout(refresh, REFRESH_INTERVAL_MS);
+      });
+  }
+
+  refresh();
+})();
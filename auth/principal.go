@@ -0,0 +1,31 @@
+// Package auth holds the principal of authenticated requests. The route middleware that
+// authenticates a request stores it; handlers and validators read it to act on its behalf.
+package auth
+
+import "github.com/gin-gonic/gin"
+
+// Principal context key and the principals of requests without a named key
+const (
+	// PrincipalContextKey is the gin context key holding the authenticated principal
+	PrincipalContextKey = "principal"
+	// DefaultAPIKeyPrincipal is the principal of requests authenticated with API_KEY
+	DefaultAPIKeyPrincipal = "api_key"
+	// AnonymousPrincipal is the principal of requests when no API key is configured
+	AnonymousPrincipal = "anonymous"
+	// SCIMPrincipal is the principal of requests authenticated with the SCIM bearer token
+	SCIMPrincipal = "scim"
+)
+
+// SetPrincipal records principal as the authenticated principal of the request
+func SetPrincipal(c *gin.Context, principal string) {
+	c.Set(PrincipalContextKey, principal)
+}
+
+// Principal returns the authenticated principal of the request, or AnonymousPrincipal for
+// routes without API key authentication
+func Principal(c *gin.Context) string {
+	if principal := c.GetString(PrincipalContextKey); principal != "" {
+		return principal
+	}
+	return AnonymousPrincipal
+}
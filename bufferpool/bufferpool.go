@@ -0,0 +1,70 @@
+package bufferpool
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// maxPooledBufferSize caps the capacity of buffers returned to the pool so a
+// single oversized payload does not pin a large allocation for the lifetime of the process
+const maxPooledBufferSize = 64 * 1024
+
+var buffers = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// encoder pairs a buffer with a JSON encoder writing into it so neither has to be allocated per message
+type encoder struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+var encoders = sync.Pool{
+	New: func() interface{} {
+		e := &encoder{}
+		e.enc = json.NewEncoder(&e.buf)
+		return e
+	},
+}
+
+// Get returns an empty buffer from the pool
+func Get() *bytes.Buffer {
+	return buffers.Get().(*bytes.Buffer)
+}
+
+// Put resets the buffer and returns it to the pool
+func Put(buf *bytes.Buffer) {
+	if buf == nil || buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	buffers.Put(buf)
+}
+
+// MarshalToString encodes v as JSON using a pooled buffer and encoder.
+// The output is identical to json.Marshal but skips the intermediate byte slice
+// that would otherwise be copied again when converting to a string.
+func MarshalToString(v interface{}) (string, error) {
+	e := encoders.Get().(*encoder)
+	defer func() {
+		if e.buf.Cap() <= maxPooledBufferSize {
+			e.buf.Reset()
+			encoders.Put(e)
+		}
+	}()
+
+	if err := e.enc.Encode(v); err != nil {
+		return "", err
+	}
+
+	// Encode terminates every value with a newline which json.Marshal does not
+	out := e.buf.Bytes()
+	if n := len(out); n > 0 && out[n-1] == '\n' {
+		out = out[:n-1]
+	}
+
+	return string(out), nil
+}
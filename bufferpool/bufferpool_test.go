@@ -0,0 +1,71 @@
+package bufferpool
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalToString_MatchesMarshal(t *testing.T) {
+	values := []interface{}{
+		map[string]interface{}{"subject": "Hello <b>world</b>", "count": 3},
+		struct {
+			ID    string   `json:"id"`
+			Items []string `json:"items,omitempty"`
+		}{ID: "notification-1"},
+		"plain string",
+		nil,
+	}
+
+	for _, v := range values {
+		expected, err := json.Marshal(v)
+		require.NoError(t, err)
+
+		actual, err := MarshalToString(v)
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), actual)
+	}
+}
+
+func TestMarshalToString_Error(t *testing.T) {
+	_, err := MarshalToString(map[string]interface{}{"ch": make(chan int)})
+	assert.Error(t, err)
+}
+
+func TestPut_DropsOversizedBuffers(t *testing.T) {
+	buf := Get()
+	buf.WriteString(strings.Repeat("x", maxPooledBufferSize+1))
+	Put(buf)
+
+	// The oversized buffer must not come back out of the pool
+	assert.LessOrEqual(t, Get().Cap(), maxPooledBufferSize)
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	payload := &models.EmailNotificationRequest{
+		ID:        "550e8400-e29b-41d4-a716-446655440000",
+		Type:      "email",
+		Content:   models.EmailContent{Subject: "Welcome", EmailBody: strings.Repeat("body ", 50)},
+		Recipient: "john.doe@company.com",
+		UserID:    "user-001",
+	}
+
+	b.Run("json.Marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			raw, _ := json.Marshal(payload)
+			_ = string(raw)
+		}
+	})
+
+	b.Run("MarshalToString", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = MarshalToString(payload)
+		}
+	})
+}
@@ -0,0 +1,288 @@
+// Package client is a typed Go client for the notification service API.
+//
+// Requests that fail with a transport error, 429 or a 5xx status are retried with
+// exponential backoff. POST requests carry an Idempotency-Key header that stays the
+// same across retries, so a retried send is never delivered twice.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Default client settings
+const (
+	DefaultTimeout     = 30 * time.Second
+	DefaultMaxRetries  = 3
+	DefaultBaseBackoff = 200 * time.Millisecond
+	DefaultMaxBackoff  = 5 * time.Second
+
+	idempotencyKeyHeader = "Idempotency-Key"
+)
+
+// Client calls the notification service API
+type Client struct {
+	baseURL     string
+	apiKey      string
+	httpClient  *http.Client
+	maxRetries  int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+	newKey      func() string
+	sleep       func(ctx context.Context, d time.Duration) error
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithAPIKey sets the API key sent as a Bearer token
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithHTTPClient replaces the HTTP client used for requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetries sets how often a failed request is retried and the backoff between attempts.
+// maxRetries 0 disables retries.
+func WithRetries(maxRetries int, baseBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.baseBackoff = baseBackoff
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// WithIdempotencyKeyGenerator replaces the generator of idempotency keys for POST requests
+func WithIdempotencyKeyGenerator(generate func() string) Option {
+	return func(c *Client) {
+		c.newKey = generate
+	}
+}
+
+// New creates a client for the service at baseURL, e.g. "http://localhost:8080"
+func New(baseURL string, opts ...Option) (*Client, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidBaseURL, baseURL)
+	}
+
+	c := &Client{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		httpClient:  &http.Client{Timeout: DefaultTimeout},
+		maxRetries:  DefaultMaxRetries,
+		baseBackoff: DefaultBaseBackoff,
+		maxBackoff:  DefaultMaxBackoff,
+		newKey:      func() string { return uuid.New().String() },
+		sleep:       sleepContext,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// CallOption configures a single API call
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	idempotencyKey string
+}
+
+// WithIdempotencyKey sets the idempotency key of a POST call instead of generating one.
+// Use a key derived from your own data (e.g. an order ID) to make sends idempotent across processes.
+func WithIdempotencyKey(key string) CallOption {
+	return func(o *callOptions) {
+		o.idempotencyKey = key
+	}
+}
+
+// do sends a request, retrying retryable failures, and decodes a successful response into out
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}, opts ...CallOption) error {
+	var options callOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var body []byte
+	if in != nil {
+		encoded, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = encoded
+	}
+
+	// The key is fixed before the first attempt so every retry carries the same one
+	idempotencyKey := options.idempotencyKey
+	if method == http.MethodPost && idempotencyKey == "" {
+		idempotencyKey = c.newKey()
+	}
+
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := c.attempt(ctx, method, path, body, idempotencyKey, out)
+		if err == nil {
+			return nil
+		}
+		if attempt >= c.maxRetries || !isRetryable(ctx, err) {
+			return err
+		}
+
+		wait := c.backoff(attempt)
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		if sleepErr := c.sleep(ctx, wait); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+// attempt performs a single HTTP request and returns the Retry-After delay on failure
+func (c *Client) attempt(ctx context.Context, method, path string, body []byte, idempotencyKey string, out interface{}) (time.Duration, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	if idempotencyKey != "" {
+		req.Header.Set(idempotencyKeyHeader, idempotencyKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, &transportError{err: err}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, &transportError{err: err}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return parseRetryAfter(resp.Header.Get("Retry-After")), newAPIError(resp.StatusCode, respBody)
+	}
+
+	if out != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return 0, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return 0, nil
+}
+
+// backoff returns the exponential backoff with jitter before retry number attempt+1
+func (c *Client) backoff(attempt int) time.Duration {
+	backoff := c.baseBackoff << attempt
+	if backoff <= 0 || backoff > c.maxBackoff {
+		backoff = c.maxBackoff
+	}
+	// Full jitter between half and the whole backoff spreads out retries of many clients
+	half := int64(backoff / 2)
+	if half <= 0 {
+		return backoff
+	}
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// transportError wraps failures to reach the service
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string { return e.err.Error() }
+func (e *transportError) Unwrap() error { return e.err }
+
+// isRetryable decides whether a failed attempt is worth repeating
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	switch e := err.(type) {
+	case *transportError:
+		return true
+	case *APIError:
+		switch e.StatusCode {
+		case http.StatusConflict:
+			// The first request with this idempotency key is still being processed
+			return true
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+	return false
+}
+
+// newAPIError builds an APIError from an error response body
+func newAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: statusCode, Body: string(body)}
+
+	var payload struct {
+		Error   string      `json:"error"`
+		Message string      `json:"message"`
+		Details interface{} `json:"details"`
+	}
+	if json.Unmarshal(body, &payload) == nil {
+		apiErr.Message = payload.Error
+		if payload.Message != "" {
+			apiErr.Message = strings.TrimSpace(apiErr.Message + ": " + payload.Message)
+		}
+		apiErr.Details = payload.Details
+	}
+
+	return apiErr
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,207 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClient creates a client for server that does not sleep between retries
+func newTestClient(t *testing.T, server *httptest.Server, opts ...Option) *Client {
+	t.Helper()
+	c, err := New(server.URL, append([]Option{WithAPIKey("secret")}, opts...)...)
+	require.NoError(t, err)
+	c.sleep = func(ctx context.Context, d time.Duration) error { return ctx.Err() }
+	return c
+}
+
+func TestNew_InvalidBaseURL(t *testing.T) {
+	_, err := New("localhost:8080")
+	assert.ErrorIs(t, err, ErrInvalidBaseURL)
+}
+
+func TestSendNotification_RetriesWithSameIdempotencyKey(t *testing.T) {
+	var calls atomic.Int32
+	keys := make(chan string, 3)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v1/notifications", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		keys <- r.Header.Get("Idempotency-Key")
+
+		var request models.NotificationRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, "slack", request.Type)
+
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"error":"Request in progress"}`))
+		default:
+			w.Write([]byte(`{"id":"n-1","external_id":"order-1","status":"sent"}`))
+		}
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, server)
+	response, err := c.SendNotification(context.Background(), &models.NotificationRequest{
+		Type:       "slack",
+		Content:    map[string]interface{}{"text": "hi"},
+		Recipients: []string{"user-001"},
+		ExternalID: "order-1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &SendResponse{ID: "n-1", ExternalID: "order-1", Status: "sent"}, response)
+
+	assert.Equal(t, int32(3), calls.Load())
+	first := <-keys
+	assert.NotEmpty(t, first)
+	assert.Equal(t, first, <-keys)
+	assert.Equal(t, first, <-keys)
+}
+
+func TestSendNotification_ExplicitIdempotencyKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "order-1", r.Header.Get("Idempotency-Key"))
+		w.Write([]byte(`{"id":"n-1","status":"queued"}`))
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, server)
+	_, err := c.SendNotification(context.Background(), &models.NotificationRequest{Type: "slack"}, WithIdempotencyKey("order-1"))
+	require.NoError(t, err)
+}
+
+func TestDo_DoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"Validation failed","message":"recipients are required","details":["recipients"]}`))
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, server)
+	_, err := c.CreateTemplate(context.Background(), &models.TemplateRequest{Name: "welcome"})
+	require.Error(t, err)
+	assert.True(t, IsValidationError(err))
+	assert.Equal(t, int32(1), calls.Load())
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "Validation failed: recipients are required", apiErr.Message)
+	assert.Equal(t, []interface{}{"recipients"}, apiErr.Details)
+}
+
+func TestDo_GivesUpAfterMaxRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		assert.Empty(t, r.Header.Get("Idempotency-Key"))
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, server, WithRetries(2, time.Millisecond, time.Millisecond))
+	_, err := c.GetStatus(context.Background(), "n-1")
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestDo_StopsWhenContextIsCancelled(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := newTestClient(t, server)
+	c.sleep = func(context.Context, time.Duration) error {
+		cancel()
+		return context.Canceled
+	}
+
+	_, err := c.GetStatus(ctx, "n-1")
+	require.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestGetStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/api/v1/notifications/n-1", r.URL.Path)
+		w.Write([]byte(`{"id":"n-1","status":"sent","progress":{"total_recipients":2,"sent":1,"percent_complete":50}}`))
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, server)
+	status, err := c.GetStatus(context.Background(), "n-1")
+	require.NoError(t, err)
+	assert.Equal(t, "sent", status.Status)
+	require.NotNil(t, status.Progress)
+	assert.Equal(t, 2, status.Progress.TotalRecipients)
+	assert.Equal(t, 50.0, status.Progress.PercentComplete)
+
+	_, err = c.GetStatus(context.Background(), "")
+	assert.ErrorIs(t, err, ErrEmptyID)
+}
+
+func TestCancelNotification(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		if r.URL.Path == "/api/v1/notifications/n-sent" {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"error":"notification cannot be cancelled: the notification is sent, only scheduled notifications can be cancelled"}`))
+			return
+		}
+		assert.Equal(t, "/api/v1/notifications/n-1", r.URL.Path)
+		w.Write([]byte(`{"id":"n-1","status":"cancelled","cancelled_at":"2024-06-03T14:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, server)
+	cancellation, err := c.CancelNotification(context.Background(), "n-1")
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", cancellation.Status)
+
+	_, err = c.CancelNotification(context.Background(), "n-sent")
+	assert.True(t, IsConflict(err), "got %v", err)
+}
+
+func TestRegisterDevice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/users/user-001/devices", r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"device_token":"token-1","device_type":"ios","app_version":"1.2.0"}`, string(body))
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"d-1","user_id":"user-001","device_token":"token-1","device_type":"ios","app_version":"1.2.0","is_active":true}`))
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, server)
+	device, err := c.RegisterDevice(context.Background(), "user-001", DeviceRegistration{
+		DeviceToken: "token-1",
+		DeviceType:  "ios",
+		AppVersion:  "1.2.0",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "d-1", device.ID)
+	assert.True(t, device.IsActive)
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Client errors
+var (
+	ErrInvalidBaseURL = errors.New("invalid base URL")
+	ErrEmptyID        = errors.New("id cannot be empty")
+)
+
+// APIError is returned when the service answers with a non-2xx status
+type APIError struct {
+	StatusCode int
+	Message    string
+	Details    interface{}
+	Body       string
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("notification service returned %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("notification service returned %d", e.StatusCode)
+}
+
+// IsNotFound checks whether err is an APIError with status 404
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// IsConflict checks whether err is an APIError with status 409, such as when cancelling a
+// notification that was already sent
+func IsConflict(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict
+}
+
+// IsValidationError checks whether err is an APIError with status 400 or 422
+func IsValidationError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusBadRequest || apiErr.StatusCode == http.StatusUnprocessableEntity)
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// SendResponse is returned when a notification request is accepted
+type SendResponse struct {
+	ID         string `json:"id"`
+	ExternalID string `json:"external_id,omitempty"`
+	Status     string `json:"status"`
+}
+
+// Progress reports how far the delivery of a notification has come
+type Progress struct {
+	TotalRecipients int       `json:"total_recipients"`
+	Resolved        int       `json:"resolved"`
+	Skipped         int       `json:"skipped"`
+	Queued          int       `json:"queued"`
+	Sent            int       `json:"sent"`
+	Failed          int       `json:"failed"`
+	PercentComplete float64   `json:"percent_complete"`
+	ETASeconds      *int64    `json:"eta_seconds,omitempty"`
+	StartedAt       time.Time `json:"started_at"`
+}
+
+// NotificationStatus is the current state of a notification
+type NotificationStatus struct {
+	ID         string    `json:"id"`
+	ExternalID string    `json:"external_id,omitempty"`
+	Status     string    `json:"status"`
+	Progress   *Progress `json:"progress,omitempty"`
+}
+
+// Cancellation is returned when a scheduled notification is cancelled
+type Cancellation struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+	CancelledAt time.Time  `json:"cancelled_at"`
+}
+
+// SendNotification submits a notification request. The request carries an idempotency key,
+// so retries after timeouts or server errors never send the notification twice.
+func (c *Client) SendNotification(ctx context.Context, request *models.NotificationRequest, opts ...CallOption) (*SendResponse, error) {
+	var response SendResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/notifications", request, &response, opts...); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetStatus returns the status of a notification
+func (c *Client) GetStatus(ctx context.Context, notificationID string) (*NotificationStatus, error) {
+	if notificationID == "" {
+		return nil, ErrEmptyID
+	}
+
+	var status NotificationStatus
+	if err := c.do(ctx, http.MethodGet, "/api/v1/notifications/"+url.PathEscape(notificationID), nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// CancelNotification cancels a scheduled notification before it is sent. Notifications that
+// are no longer scheduled cannot be cancelled; IsConflict reports the error returned for them.
+func (c *Client) CancelNotification(ctx context.Context, notificationID string) (*Cancellation, error) {
+	if notificationID == "" {
+		return nil, ErrEmptyID
+	}
+
+	var cancellation Cancellation
+	if err := c.do(ctx, http.MethodDelete, "/api/v1/notifications/"+url.PathEscape(notificationID), nil, &cancellation); err != nil {
+		return nil, err
+	}
+	return &cancellation, nil
+}
@@ -0,0 +1,17 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// CreateTemplate creates a custom template
+func (c *Client) CreateTemplate(ctx context.Context, request *models.TemplateRequest, opts ...CallOption) (*models.TemplateResponse, error) {
+	var response models.TemplateResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/templates", request, &response, opts...); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
@@ -0,0 +1,31 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// DeviceRegistration describes a device to register for in-app notifications
+type DeviceRegistration struct {
+	DeviceToken string `json:"device_token"`
+	DeviceType  string `json:"device_type"` // "ios", "android", "web"
+	AppVersion  string `json:"app_version,omitempty"`
+	OSVersion   string `json:"os_version,omitempty"`
+	DeviceModel string `json:"device_model,omitempty"`
+}
+
+// RegisterDevice registers a device for a user
+func (c *Client) RegisterDevice(ctx context.Context, userID string, device DeviceRegistration, opts ...CallOption) (*models.UserDeviceInfo, error) {
+	if userID == "" {
+		return nil, ErrEmptyID
+	}
+
+	var response models.UserDeviceInfo
+	if err := c.do(ctx, http.MethodPost, "/api/v1/users/"+url.PathEscape(userID)+"/devices", device, &response, opts...); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
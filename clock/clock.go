@@ -0,0 +1,35 @@
+// Package clock abstracts the current time and timers so time-dependent behaviour
+// (scheduled sends, TTLs) can be driven deterministically in tests and demos.
+package clock
+
+import "time"
+
+// Clock tells the time and runs functions after a delay
+type Clock interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a pending AfterFunc call
+type Timer interface {
+	// Stop prevents the call from running; it reports false if it already ran or was stopped
+	Stop() bool
+}
+
+// realClock uses the system time
+type realClock struct{}
+
+// Real returns the clock backed by the system time
+func Real() Clock {
+	return realClock{}
+}
+
+// Now returns the current system time
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// AfterFunc runs f in its own goroutine after d
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
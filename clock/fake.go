@@ -0,0 +1,101 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake is a Clock that only moves when told to. Timers fire synchronously from
+// Advance and Set, in order of their due time, so tests do not need to sleep.
+type Fake struct {
+	mutex  sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// fakeTimer is a pending call on a Fake clock
+type fakeTimer struct {
+	clock *Fake
+	due   time.Time
+	f     func()
+}
+
+// NewFake creates a fake clock showing start
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+// Now returns the fake time
+func (c *Fake) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// AfterFunc registers f to run once the clock has been advanced by d.
+// A non-positive d runs f on the next Advance or Set.
+func (c *Fake) AfterFunc(d time.Duration, f func()) Timer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	timer := &fakeTimer{clock: c, due: c.now.Add(d), f: f}
+	c.timers = append(c.timers, timer)
+	return timer
+}
+
+// Advance moves the clock forward by d and runs the timers that became due
+func (c *Fake) Advance(d time.Duration) {
+	c.mutex.Lock()
+	target := c.now.Add(d)
+	c.mutex.Unlock()
+
+	c.Set(target)
+}
+
+// Set moves the clock to t and runs the timers due at or before t.
+// Moving the clock backwards does not run any timers.
+func (c *Fake) Set(t time.Time) {
+	for {
+		c.mutex.Lock()
+		sort.SliceStable(c.timers, func(i, j int) bool { return c.timers[i].due.Before(c.timers[j].due) })
+
+		if len(c.timers) == 0 || c.timers[0].due.After(t) {
+			c.now = t
+			c.mutex.Unlock()
+			return
+		}
+
+		// Timers observe the clock at their own due time, like a real timer would
+		timer := c.timers[0]
+		c.timers = c.timers[1:]
+		if timer.due.After(c.now) {
+			c.now = timer.due
+		}
+		c.mutex.Unlock()
+
+		timer.f()
+	}
+}
+
+// PendingTimers returns the number of timers that have not fired or been stopped
+func (c *Fake) PendingTimers() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.timers)
+}
+
+// Stop removes the timer from its clock
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for i, pending := range c.timers {
+		if pending == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFake_AdvanceRunsDueTimersInOrder(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	c := NewFake(start)
+
+	var fired []string
+	var firedAt []time.Time
+	record := func(name string) func() {
+		return func() {
+			fired = append(fired, name)
+			firedAt = append(firedAt, c.Now())
+		}
+	}
+
+	c.AfterFunc(2*time.Hour, record("later"))
+	c.AfterFunc(time.Hour, record("sooner"))
+	stopped := c.AfterFunc(30*time.Minute, record("stopped"))
+	assert.True(t, stopped.Stop())
+	assert.False(t, stopped.Stop())
+	assert.Equal(t, 2, c.PendingTimers())
+
+	c.Advance(59 * time.Minute)
+	assert.Empty(t, fired)
+
+	c.Advance(2 * time.Hour)
+	assert.Equal(t, []string{"sooner", "later"}, fired)
+	assert.Equal(t, []time.Time{start.Add(time.Hour), start.Add(2 * time.Hour)}, firedAt)
+	assert.Equal(t, start.Add(2*time.Hour+59*time.Minute), c.Now())
+	assert.Zero(t, c.PendingTimers())
+}
+
+func TestFake_TimerScheduledFromTimer(t *testing.T) {
+	c := NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	runs := 0
+	var tick func()
+	tick = func() {
+		runs++
+		c.AfterFunc(time.Minute, tick)
+	}
+	c.AfterFunc(time.Minute, tick)
+
+	c.Advance(5 * time.Minute)
+	assert.Equal(t, 5, runs)
+	assert.Equal(t, 1, c.PendingTimers())
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// runSend handles: send -f <file.json>
+func runSend(env *commandEnv, args []string) error {
+	flags := newFlagSet("send")
+	file := flags.String("f", "", "JSON notification request file, - for stdin")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if *file == "" {
+		return fmt.Errorf("%w: -f is required", errUsage)
+	}
+
+	body, err := readJSONFile(*file)
+	if err != nil {
+		return err
+	}
+
+	resp, err := env.client.do(http.MethodPost, "/api/v1/notifications", body)
+	if err != nil {
+		return err
+	}
+	return printJSON(env.out, resp)
+}
+
+// runStatus handles: status <notification-id>
+func runStatus(env *commandEnv, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: expected a notification ID", errUsage)
+	}
+
+	resp, err := env.client.do(http.MethodGet, "/api/v1/notifications/"+url.PathEscape(args[0]), nil)
+	if err != nil {
+		return err
+	}
+	return printJSON(env.out, resp)
+}
+
+// notificationStatus is the part of the status response followed by tail
+type notificationStatus struct {
+	ID       string `json:"id"`
+	Status   string `json:"status"`
+	Progress *struct {
+		TotalRecipients int     `json:"total_recipients"`
+		Queued          int     `json:"queued"`
+		Sent            int     `json:"sent"`
+		Failed          int     `json:"failed"`
+		PercentComplete float64 `json:"percent_complete"`
+		ETASeconds      *int64  `json:"eta_seconds"`
+	} `json:"progress"`
+}
+
+// done reports whether the notification reached a state that no longer changes
+func (s *notificationStatus) done() bool {
+	switch s.Status {
+	case "failed", "cancelled":
+		return true
+	case "sent":
+		return s.Progress == nil || s.Progress.PercentComplete >= 100
+	default:
+		return false
+	}
+}
+
+// line renders the status as a single progress line
+func (s *notificationStatus) line() string {
+	if s.Progress == nil {
+		return fmt.Sprintf("status=%s", s.Status)
+	}
+
+	eta := ""
+	if s.Progress.ETASeconds != nil {
+		eta = fmt.Sprintf(" eta=%ds", *s.Progress.ETASeconds)
+	}
+	return fmt.Sprintf("status=%s recipients=%d queued=%d sent=%d failed=%d complete=%.1f%%%s",
+		s.Status, s.Progress.TotalRecipients, s.Progress.Queued, s.Progress.Sent, s.Progress.Failed, s.Progress.PercentComplete, eta)
+}
+
+// runTail handles: tail [-interval 2s] [-timeout 10m] <notification-id>
+func runTail(env *commandEnv, args []string) error {
+	flags := newFlagSet("tail")
+	interval := flags.Duration("interval", 2*time.Second, "Polling interval")
+	timeout := flags.Duration("timeout", 10*time.Minute, "Give up after this long, 0 waits forever")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if flags.NArg() != 1 || *interval <= 0 {
+		return fmt.Errorf("%w: expected a notification ID and a positive interval", errUsage)
+	}
+
+	path := "/api/v1/notifications/" + url.PathEscape(flags.Arg(0))
+	var deadline time.Time
+	if *timeout > 0 {
+		deadline = time.Now().Add(*timeout)
+	}
+
+	previous := ""
+	for {
+		var status notificationStatus
+		if err := env.client.getJSON(path, &status); err != nil {
+			return err
+		}
+
+		// Only print when something changed so the output stays readable when piped
+		if line := status.line(); line != previous {
+			fmt.Fprintf(env.out, "%s %s\n", time.Now().Format(time.RFC3339), line)
+			previous = line
+		}
+
+		if status.done() {
+			if status.Status == "failed" {
+				return fmt.Errorf("notification %s failed", status.ID)
+			}
+			return nil
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for notification %s", *timeout, status.ID)
+		}
+
+		time.Sleep(*interval)
+	}
+}
+
+// runList handles: list [-external-id id] [-tag tag] [-status status] [-type type]
+func runList(env *commandEnv, args []string) error {
+	flags := newFlagSet("list")
+	externalID := flags.String("external-id", "", "Filter by external ID")
+	tag := flags.String("tag", "", "Filter by tag")
+	status := flags.String("status", "", "Filter by status")
+	notificationType := flags.String("type", "", "Filter by notification type")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"external_id": *externalID,
+		"tag":         *tag,
+		"status":      *status,
+		"type":        *notificationType,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	resp, err := env.client.do(http.MethodGet, "/api/v1/notifications?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	return printJSON(env.out, resp)
+}
+
+// runAttempts handles: attempts <notification-id> <recipient>
+func runAttempts(env *commandEnv, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("%w: expected a notification ID and a recipient", errUsage)
+	}
+
+	path := fmt.Sprintf("/api/v1/notifications/%s/deliveries/%s/attempts", url.PathEscape(args[0]), url.PathEscape(args[1]))
+	resp, err := env.client.do(http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	return printJSON(env.out, resp)
+}
+
+// runTemplates handles the templates sub-commands
+func runTemplates(env *commandEnv, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: expected list, predefined, get, create or render", errUsage)
+	}
+
+	var (
+		resp []byte
+		err  error
+	)
+	switch sub, rest := args[0], args[1:]; sub {
+	case "list":
+		resp, err = env.client.do(http.MethodGet, "/api/v1/templates", nil)
+
+	case "predefined":
+		resp, err = env.client.do(http.MethodGet, "/api/v1/templates/predefined", nil)
+
+	case "get":
+		if len(rest) != 2 {
+			return fmt.Errorf("%w: templates get <template-id> <version>", errUsage)
+		}
+		resp, err = env.client.do(http.MethodGet, templateVersionPath(rest[0], rest[1]), nil)
+
+	case "create":
+		flags := newFlagSet("templates create")
+		file := flags.String("f", "", "JSON template file, - for stdin")
+		if err := flags.Parse(rest); err != nil {
+			return fmt.Errorf("%w: %v", errUsage, err)
+		}
+		if *file == "" {
+			return fmt.Errorf("%w: templates create -f <file.json>", errUsage)
+		}
+		body, readErr := readJSONFile(*file)
+		if readErr != nil {
+			return readErr
+		}
+		resp, err = env.client.do(http.MethodPost, "/api/v1/templates", body)
+
+	case "render":
+		flags := newFlagSet("templates render")
+		dataFile := flags.String("d", "", "JSON file with the template variables, - for stdin")
+		if err := flags.Parse(rest); err != nil {
+			return fmt.Errorf("%w: %v", errUsage, err)
+		}
+		if flags.NArg() != 2 {
+			return fmt.Errorf("%w: templates render [-d data.json] <template-id> <version>", errUsage)
+		}
+		data := json.RawMessage("{}")
+		if *dataFile != "" {
+			raw, readErr := readJSONFile(*dataFile)
+			if readErr != nil {
+				return readErr
+			}
+			data = raw
+		}
+		resp, err = env.client.do(http.MethodPost, templateVersionPath(flags.Arg(0), flags.Arg(1))+"/render", map[string]json.RawMessage{"data": data})
+
+	default:
+		return fmt.Errorf("%w: unknown templates command %q", errUsage, sub)
+	}
+
+	if err != nil {
+		return err
+	}
+	return printJSON(env.out, resp)
+}
+
+// runUsers handles the users sub-commands
+func runUsers(env *commandEnv, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: expected list, get, create, update, delete or devices", errUsage)
+	}
+
+	var (
+		resp []byte
+		err  error
+	)
+	switch sub, rest := args[0], args[1:]; sub {
+	case "list":
+		resp, err = env.client.do(http.MethodGet, "/api/v1/users/", nil)
+
+	case "get", "delete", "devices":
+		if len(rest) != 1 {
+			return fmt.Errorf("%w: users %s <user-id>", errUsage, sub)
+		}
+		path := "/api/v1/users/" + url.PathEscape(rest[0])
+		switch sub {
+		case "get":
+			resp, err = env.client.do(http.MethodGet, path, nil)
+		case "delete":
+			resp, err = env.client.do(http.MethodDelete, path, nil)
+		default:
+			resp, err = env.client.do(http.MethodGet, path+"/devices", nil)
+		}
+
+	case "create", "update":
+		flags := newFlagSet("users " + sub)
+		file := flags.String("f", "", "JSON user file, - for stdin")
+		if err := flags.Parse(rest); err != nil {
+			return fmt.Errorf("%w: %v", errUsage, err)
+		}
+		if *file == "" || (sub == "create" && flags.NArg() != 0) || (sub == "update" && flags.NArg() != 1) {
+			return fmt.Errorf("%w: users create -f <file.json> | users update -f <file.json> <user-id>", errUsage)
+		}
+		body, readErr := readJSONFile(*file)
+		if readErr != nil {
+			return readErr
+		}
+		if sub == "create" {
+			resp, err = env.client.do(http.MethodPost, "/api/v1/users/", body)
+		} else {
+			resp, err = env.client.do(http.MethodPut, "/api/v1/users/"+url.PathEscape(flags.Arg(0)), body)
+		}
+
+	default:
+		return fmt.Errorf("%w: unknown users command %q", errUsage, sub)
+	}
+
+	if err != nil {
+		return err
+	}
+	return printJSON(env.out, resp)
+}
+
+// templateVersionPath returns the API path of a template version
+func templateVersionPath(templateID, version string) string {
+	return fmt.Sprintf("/api/v1/templates/%s/versions/%s", url.PathEscape(templateID), url.PathEscape(version))
+}
+
+// newFlagSet creates a flag set that reports errors instead of exiting
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return flags
+}
+
+// readJSONFile reads a file (or stdin for "-") and checks that it contains valid JSON
+func readJSONFile(path string) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("%s does not contain valid JSON", path)
+	}
+	return data, nil
+}
+
+// printJSON pretty-prints a JSON response body
+func printJSON(w io.Writer, body []byte) error {
+	var out bytes.Buffer
+	if err := json.Indent(&out, body, "", "  "); err != nil {
+		// Not JSON, print it as-is
+		_, err = w.Write(body)
+		return err
+	}
+	out.WriteByte('\n')
+	_, err := out.WriteTo(w)
+	return err
+}
@@ -0,0 +1,126 @@
+// Command notifyctl operates the notification service through its HTTP API.
+//
+// Usage:
+//
+//	notifyctl [global flags] <command> [arguments]
+//
+// Run notifyctl help for the list of commands.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/gaurav2721/notification-service/constants"
+)
+
+// Environment variables read by notifyctl
+const (
+	serverEnvVar = "NOTIFYCTL_SERVER"
+	apiKeyEnvVar = "NOTIFYCTL_API_KEY"
+)
+
+// errUsage is returned when a command is called with invalid arguments
+var errUsage = errors.New("invalid usage")
+
+// command is a notifyctl sub-command
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(env *commandEnv, args []string) error
+}
+
+// commandEnv is what every command needs to talk to the service and print results
+type commandEnv struct {
+	client *apiClient
+	out    io.Writer
+}
+
+// commands lists all sub-commands in the order they are shown in the help
+var commands = []command{
+	{"send", "send -f <file.json>", "Send a notification from a JSON request file (- reads stdin)", runSend},
+	{"status", "status <notification-id>", "Show the status and progress of a notification", runStatus},
+	{"tail", "tail [-interval 2s] [-timeout 10m] <notification-id>", "Follow the delivery progress of a notification until it completes", runTail},
+	{"list", "list [-external-id id] [-tag tag] [-status status] [-type type]", "List notifications matching a filter", runList},
+	{"attempts", "attempts <notification-id> <recipient>", "Show the delivery attempts of a recipient", runAttempts},
+	{"templates", "templates list|predefined|get|create|render ...", "Manage and render templates", runTemplates},
+	{"users", "users list|get|create|update|delete|devices ...", "Manage users (requires ENABLE_USER_ROUTES on the server)", runUsers},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run parses the global flags, dispatches to the command and returns the exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("notifyctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { printUsage(stderr, flags) }
+
+	server := flags.String("server", envOrDefault(serverEnvVar, "http://localhost:"+constants.DefaultPort), "Base URL of the notification service (env "+serverEnvVar+")")
+	apiKey := flags.String("api-key", envOrDefault(apiKeyEnvVar, os.Getenv(constants.API_KEY)), "API key (env "+apiKeyEnvVar+" or "+constants.API_KEY+")")
+	timeout := flags.Duration("timeout", 30*time.Second, "Timeout of a single API request")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 || flags.Arg(0) == "help" {
+		printUsage(stdout, flags)
+		return 0
+	}
+
+	name := flags.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+
+		env := &commandEnv{
+			client: newAPIClient(*server, *apiKey, *timeout),
+			out:    stdout,
+		}
+		if err := cmd.run(env, flags.Args()[1:]); err != nil {
+			if errors.Is(err, errUsage) {
+				fmt.Fprintf(stderr, "%v\nusage: notifyctl %s\n", err, cmd.usage)
+				return 2
+			}
+			fmt.Fprintf(stderr, "notifyctl %s: %v\n", name, err)
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintf(stderr, "unknown command %q\n", name)
+	printUsage(stderr, flags)
+	return 2
+}
+
+// printUsage prints the global flags and the list of commands
+func printUsage(w io.Writer, flags *flag.FlagSet) {
+	fmt.Fprintln(w, "notifyctl operates the notification service through its HTTP API.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Usage: notifyctl [global flags] <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+		fmt.Fprintf(w, "  %-10s   notifyctl %s\n", "", cmd.usage)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Global flags:")
+	flags.SetOutput(w)
+	flags.PrintDefaults()
+}
+
+// envOrDefault returns the environment variable or the fallback when it is unset
+func envOrDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runCLI(t *testing.T, server *httptest.Server, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(append([]string{"-server", server.URL, "-api-key", "secret"}, args...), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestSend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v1/notifications", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"type":"slack","content":{"text":"hi"},"recipients":["user-001"]}`, string(body))
+		w.Write([]byte(`{"id":"n-1","status":"sent"}`))
+	}))
+	defer server.Close()
+
+	file := filepath.Join(t.TempDir(), "request.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{"type":"slack","content":{"text":"hi"},"recipients":["user-001"]}`), 0o600))
+
+	code, stdout, stderr := runCLI(t, server, "send", "-f", file)
+	assert.Equal(t, 0, code, stderr)
+	assert.Equal(t, "{\n  \"id\": \"n-1\",\n  \"status\": \"sent\"\n}\n", stdout)
+}
+
+func TestTail(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/notifications/n-1", r.URL.Path)
+
+		response := map[string]interface{}{"id": "n-1", "status": "sent"}
+		switch calls.Add(1) {
+		case 1:
+			response["status"] = "queued"
+		case 2:
+			response["progress"] = map[string]interface{}{"total_recipients": 2, "queued": 2, "sent": 1, "percent_complete": 50}
+		default:
+			response["progress"] = map[string]interface{}{"total_recipients": 2, "queued": 2, "sent": 2, "percent_complete": 100}
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	code, stdout, stderr := runCLI(t, server, "tail", "-interval", "1ms", "n-1")
+	assert.Equal(t, 0, code, stderr)
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "status=queued")
+	assert.Contains(t, lines[1], "sent=1 failed=0 complete=50.0%")
+	assert.Contains(t, lines[2], "sent=2 failed=0 complete=100.0%")
+}
+
+func TestTemplatesRender(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/templates/tmpl-1/versions/1/render", r.URL.Path)
+
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"data":{}}`, string(body))
+		w.Write([]byte(`{"template_id":"tmpl-1","content":{"text":"hi"}}`))
+	}))
+	defer server.Close()
+
+	code, stdout, stderr := runCLI(t, server, "templates", "render", "tmpl-1", "1")
+	assert.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, `"text": "hi"`)
+}
+
+func TestErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"notification not found"}`))
+	}))
+	defer server.Close()
+
+	code, _, stderr := runCLI(t, server, "status", "missing")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "status 404")
+	assert.Contains(t, stderr, "notification not found")
+
+	code, _, stderr = runCLI(t, server, "status")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "usage: notifyctl status")
+
+	code, _, stderr = runCLI(t, server, "templates", "unknown")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "unknown templates command")
+
+	code, _, stderr = runCLI(t, server, "frobnicate")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `unknown command "frobnicate"`)
+}
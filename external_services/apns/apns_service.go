@@ -6,36 +6,81 @@ import (
 	"crypto/ecdsa"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gaurav2721/notification-service/constants"
+	"github.com/gaurav2721/notification-service/external_services/httpclient"
+	"github.com/gaurav2721/notification-service/external_services/pushapps"
 	"github.com/gaurav2721/notification-service/models"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/sirupsen/logrus"
 )
 
-// APNSServiceImpl implements the APNSService interface
-type APNSServiceImpl struct {
+// apnsHosts are the APNS endpoints of each environment
+var apnsHosts = map[string]string{
+	models.APNSEnvironmentProduction: "https://api.push.apple.com",
+	models.APNSEnvironmentSandbox:    "https://api.sandbox.push.apple.com",
+}
+
+// credentialKey identifies the credentials of one app in one APNS environment
+type credentialKey struct {
+	app         string
+	environment string
+}
+
+// tokenRefreshInterval is how long a provider token is reused. APNS rejects tokens older than
+// an hour and ones refreshed more often than every 20 minutes.
+const tokenRefreshInterval = 50 * time.Minute
+
+// apnsCredentials sign the requests sent to one APNS environment
+type apnsCredentials struct {
 	config     *APNSConfig
 	privateKey *ecdsa.PrivateKey
-	client     *http.Client
+
+	mu       sync.Mutex
+	jwt      string
+	issuedAt time.Time
 }
 
-// NewAPNSService creates a new APNS service instance
-// It checks environment variables and returns mock service if config is incomplete
-func NewAPNSService() APNSService {
+// APNSServiceImpl implements the APNSService interface
+type APNSServiceImpl struct {
+	// defaultEnvironment receives notifications of devices without an environment
+	defaultEnvironment string
+	credentials        map[credentialKey]*apnsCredentials
+	hosts              map[string]string
+	client             *http.Client
+	// maxStreams caps the pushes of a batch in flight at once
+	maxStreams int
+}
+
+// NewAPNSService creates a new APNS service instance that connects through transport
+// It checks environment variables and the push app registry and returns mock service if
+// neither configures any credentials. An invalid transport is returned as an error.
+func NewAPNSService(transport httpclient.TransportConfig) (APNSService, error) {
 	bundleID := os.Getenv(constants.APNS_BUNDLE_ID)
 	keyID := os.Getenv(constants.APNS_KEY_ID)
 	teamID := os.Getenv(constants.APNS_TEAM_ID)
 	privateKeyPath := os.Getenv(constants.APNS_PRIVATE_KEY_PATH)
 	timeoutStr := os.Getenv(constants.APNS_TIMEOUT)
 
+	registry, err := pushapps.LoadFromEnv()
+	if err != nil {
+		logrus.WithError(err).Error("Invalid push app registry, using mock APNS service")
+		return NewMockAPNSService(), nil
+	}
+	apps := registry.APNSApps()
+
 	// Check if all required environment variables are present and non-empty
-	if bundleID == "" || keyID == "" || teamID == "" || privateKeyPath == "" {
-		return NewMockAPNSService()
+	hasDefault := bundleID != "" && keyID != "" && teamID != "" && privateKeyPath != ""
+	if !hasDefault && len(apps) == 0 {
+		return NewMockAPNSService(), nil
 	}
 
 	timeout := constants.DefaultAPNSTimeout // default timeout
@@ -45,11 +90,138 @@ func NewAPNSService() APNSService {
 		}
 	}
 
-	return &APNSServiceImpl{
-		client: &http.Client{
-			Timeout: time.Duration(timeout) * time.Second,
-		},
+	defaultEnvironment := strings.ToLower(os.Getenv(constants.APNS_ENVIRONMENT))
+	if defaultEnvironment == "" {
+		defaultEnvironment = constants.DefaultAPNSEnvironment
+	}
+	if !models.IsValidAPNSEnvironment(defaultEnvironment) {
+		logrus.WithField("environment", defaultEnvironment).Warn("Unknown APNS environment, using production")
+		defaultEnvironment = models.APNSEnvironmentProduction
+	}
+
+	client, err := httpclient.NewProviderClient("APNS", transport, time.Duration(timeout)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []*APNSConfig
+	if hasDefault {
+		// Development builds usually have their own bundle ID and may use a separate key
+		configs = appConfigs("", &pushapps.APNSCredentials{
+			BundleID:       bundleID,
+			KeyID:          keyID,
+			TeamID:         teamID,
+			PrivateKeyPath: privateKeyPath,
+			Sandbox: &pushapps.APNSCredentials{
+				BundleID:       os.Getenv(constants.APNS_SANDBOX_BUNDLE_ID),
+				KeyID:          os.Getenv(constants.APNS_SANDBOX_KEY_ID),
+				TeamID:         os.Getenv(constants.APNS_SANDBOX_TEAM_ID),
+				PrivateKeyPath: os.Getenv(constants.APNS_SANDBOX_PRIVATE_KEY_PATH),
+			},
+		}, timeout)
+	}
+	for app, credentials := range apps {
+		configs = append(configs, appConfigs(app, credentials, timeout)...)
+	}
+
+	service, err := newAPNSService(defaultEnvironment, configs, client)
+	if err != nil {
+		logrus.WithError(err).Error("Invalid APNS credentials, using mock APNS service")
+		return NewMockAPNSService(), nil
+	}
+	if streams, err := strconv.Atoi(os.Getenv(constants.APNSMaxConcurrentStreamsEnvVar)); err == nil && streams > 0 {
+		service.maxStreams = streams
+	}
+	return service, nil
+}
+
+// appConfigs returns the production and sandbox configs of an app
+func appConfigs(app string, credentials *pushapps.APNSCredentials, timeout int) []*APNSConfig {
+	config := func(environment string, credentials *pushapps.APNSCredentials) *APNSConfig {
+		return &APNSConfig{
+			App:            app,
+			BundleID:       credentials.BundleID,
+			KeyID:          credentials.KeyID,
+			TeamID:         credentials.TeamID,
+			PrivateKeyPath: credentials.PrivateKeyPath,
+			Environment:    environment,
+			Timeout:        timeout,
+		}
+	}
+	return []*APNSConfig{
+		config(models.APNSEnvironmentProduction, credentials),
+		config(models.APNSEnvironmentSandbox, credentials.SandboxCredentials()),
+	}
+}
+
+// newAPNSService creates a service that signs requests with the credentials of each config
+func newAPNSService(defaultEnvironment string, configs []*APNSConfig, client *http.Client) (*APNSServiceImpl, error) {
+	service := &APNSServiceImpl{
+		defaultEnvironment: defaultEnvironment,
+		credentials:        make(map[credentialKey]*apnsCredentials, len(configs)),
+		hosts:              apnsHosts,
+		client:             client,
+		maxStreams:         constants.DefaultAPNSMaxConcurrentStreams,
+	}
+
+	// Apps often share a signing key, so each key file is parsed once
+	keys := make(map[string]*ecdsa.PrivateKey)
+	for _, config := range configs {
+		privateKey, ok := keys[config.PrivateKeyPath]
+		if !ok {
+			var err error
+			privateKey, err = loadPrivateKey(config.PrivateKeyPath)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s %s: %v", ErrInvalidConfiguration, appName(config.App), config.Environment, err)
+			}
+			keys[config.PrivateKeyPath] = privateKey
+		}
+		key := credentialKey{app: config.App, environment: config.Environment}
+		service.credentials[key] = &apnsCredentials{config: config, privateKey: privateKey}
+	}
+	return service, nil
+}
+
+// token returns the JWT that authenticates requests signed with these credentials. It is signed
+// again once it is tokenRefreshInterval old.
+func (c *apnsCredentials) token() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.jwt != "" && now.Sub(c.issuedAt) < tokenRefreshInterval {
+		return c.jwt, nil
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": c.config.TeamID,
+		"iat": now.Unix(),
+	})
+
+	token.Header["kid"] = c.config.KeyID
+	tokenString, err := token.SignedString(c.privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT token: %w", err)
+	}
+	c.jwt, c.issuedAt = tokenString, now
+	return tokenString, nil
+}
+
+// appName names an app in log and error messages
+func appName(app string) string {
+	if app == "" {
+		return "default app"
 	}
+	return "app " + app
+}
+
+// loadPrivateKey reads the .p8 signing key downloaded from the Apple developer account
+func loadPrivateKey(path string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return jwt.ParseECPrivateKeyFromPEM(data)
 }
 
 // SendPushNotification sends a push notification to Apple devices
@@ -59,7 +231,48 @@ func (aps *APNSServiceImpl) SendPushNotification(ctx context.Context, notificati
 	if !ok {
 		return nil, ErrInvalidNotificationPayload
 	}
+	return aps.send(ctx, notif)
+}
+
+// MaxBatchSize returns how many pushes of a batch are sent at once as streams of the HTTP/2
+// connection to APNS
+func (aps *APNSServiceImpl) MaxBatchSize() int {
+	return aps.maxStreams
+}
+
+// SendPushNotificationBatch sends the pushes concurrently, at most MaxBatchSize at a time. The
+// requests are multiplexed as streams over the persistent HTTP/2 connection of each APNS host,
+// and every push gets the result of its own stream.
+func (aps *APNSServiceImpl) SendPushNotificationBatch(ctx context.Context, notifications []*models.APNSNotificationRequest) ([]interface{}, []error) {
+	responses := make([]interface{}, len(notifications))
+	errs := make([]error, len(notifications))
+
+	streams := make(chan struct{}, max(aps.maxStreams, 1))
+	var wg sync.WaitGroup
+	for i, notif := range notifications {
+		streams <- struct{}{}
+		wg.Add(1)
+		go func(i int, notif *models.APNSNotificationRequest) {
+			defer func() {
+				<-streams
+				wg.Done()
+			}()
+			response, err := aps.send(ctx, notif)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			responses[i] = response
+		}(i, notif)
+	}
+	wg.Wait()
+
+	return responses, errs
+}
 
+// send sends one push as a single request. Like before batching, a push APNS rejects has a
+// response with a failure count and the reason rather than an error.
+func (aps *APNSServiceImpl) send(ctx context.Context, notif *models.APNSNotificationRequest) (*models.APNSResponse, error) {
 	// Validate the APNS notification
 	if err := models.ValidateAPNSNotification(notif); err != nil {
 		return nil, fmt.Errorf("APNS validation failed: %w", err)
@@ -79,8 +292,17 @@ func (aps *APNSServiceImpl) SendPushNotification(ctx context.Context, notificati
 		}, nil
 	}
 
+	environment := notif.Environment
+	if environment == "" {
+		environment = aps.defaultEnvironment
+	}
+
 	// Check if config is available for JWT authentication
-	if aps.config == nil || aps.privateKey == nil {
+	credentials := aps.credentials[credentialKey{app: notif.AppID, environment: environment}]
+	if credentials == nil && notif.AppID != "" {
+		return nil, fmt.Errorf("%w: %s", pushapps.ErrUnknownApp, notif.AppID)
+	}
+	if credentials == nil {
 		// Return mock response for demo purposes when config is not available
 		return &models.APNSResponse{
 			ID:           notif.ID,
@@ -93,28 +315,30 @@ func (aps *APNSServiceImpl) SendPushNotification(ctx context.Context, notificati
 		}, nil
 	}
 
-	// Create JWT token for authentication
-	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
-		"iss": aps.config.TeamID,
-		"iat": time.Now().Unix(),
-	})
-
-	token.Header["kid"] = aps.config.KeyID
-	tokenString, err := token.SignedString(aps.privateKey)
+	// The provider token is reused until it is due for a refresh
+	tokenString, err := credentials.token()
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign JWT token: %w", err)
+		return nil, err
 	}
 
-	// Prepare notification payload
-	payload := map[string]interface{}{
-		"aps": map[string]interface{}{
-			"alert": map[string]interface{}{
-				"title": notif.Content.Title,
-				"body":  notif.Content.Body,
-			},
-			"sound": "default",
-			"badge": 1,
+	// Prepare notification payload. mutable-content lets the app's notification service
+	// extension run on arrival and report a delivery receipt for notification_id.
+	apsDictionary := map[string]interface{}{
+		"alert": map[string]interface{}{
+			"title": notif.Content.Title,
+			"body":  notif.Content.Body,
 		},
+		"sound":           "default",
+		"badge":           1,
+		"mutable-content": 1,
+	}
+	// Notification Center groups notifications with the same thread-id
+	if notif.ThreadID != "" {
+		apsDictionary["thread-id"] = notif.ThreadID
+	}
+	payload := map[string]interface{}{
+		"aps":             apsDictionary,
+		"notification_id": notif.ID,
 	}
 
 	payloadBytes, err := json.Marshal(payload)
@@ -122,43 +346,113 @@ func (aps *APNSServiceImpl) SendPushNotification(ctx context.Context, notificati
 		return nil, fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	// Send to the device token
-	successCount := 0
-	failureCount := 0
-
-	url := fmt.Sprintf("https://api.push.apple.com/3/device/%s", deviceToken)
+	response := &models.APNSResponse{
+		ID:      notif.ID,
+		Status:  "sent",
+		Channel: "apns",
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
+	url := fmt.Sprintf("%s/3/device/%s", aps.hosts[environment], deviceToken)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payloadBytes))
 	if err != nil {
-		failureCount = 1
+		response.FailureCount = 1
+		response.Reason = err.Error()
 	} else {
 		req.Header.Set("Authorization", "bearer "+tokenString)
-		req.Header.Set("apns-topic", aps.config.BundleID)
+		req.Header.Set("apns-topic", credentials.config.BundleID)
+		req.Header.Set("apns-push-type", "alert")
 		req.Header.Set("Content-Type", "application/json")
-		req.Body = ioutil.NopCloser(bytes.NewReader(payloadBytes))
 
 		resp, err := aps.client.Do(req)
 		if err != nil {
-			failureCount = 1
+			response.FailureCount = 1
+			response.Reason = err.Error()
 		} else {
 			defer resp.Body.Close()
 
+			response.StatusCode = resp.StatusCode
+			// APNS identifies every notification, accepted or not, by its apns-id
+			response.APNSID = resp.Header.Get("apns-id")
 			if resp.StatusCode == http.StatusOK {
-				successCount = 1
+				response.SuccessCount = 1
 			} else {
-				failureCount = 1
+				response.FailureCount = 1
+				response.Reason = failureReason(resp.Body)
 			}
 		}
 	}
 
-	// Return success response
-	return &models.APNSResponse{
-		ID:           notif.ID,
-		Status:       "sent",
-		Message:      fmt.Sprintf("APNS notification sent successfully. Success: %d, Failed: %d", successCount, failureCount),
-		SentAt:       time.Now(),
-		Channel:      "apns",
-		SuccessCount: successCount,
-		FailureCount: failureCount,
-	}, nil
+	response.Message = fmt.Sprintf("APNS notification sent successfully. Success: %d, Failed: %d", response.SuccessCount, response.FailureCount)
+	if response.Reason != "" {
+		response.Message = fmt.Sprintf("%s. Reason: %s", response.Message, response.Reason)
+	}
+	response.SentAt = time.Now()
+
+	return response, nil
+}
+
+// failureReason reads the reason code from an APNS error body such as {"reason":"BadDeviceToken"},
+// or returns the body itself if it has none
+func failureReason(body io.Reader) string {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return ""
+	}
+	var failure struct {
+		Reason string `json:"reason"`
+	}
+	if json.Unmarshal(data, &failure) == nil && failure.Reason != "" {
+		return failure.Reason
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// checkDeviceToken is sent by CheckConnection. APNS rejects it as a bad device token after
+// authenticating the request, so no notification is delivered.
+const checkDeviceToken = "selftest"
+
+// CheckConnection authenticates to APNS with the credentials of every app and environment
+// without delivering a notification
+func (aps *APNSServiceImpl) CheckConnection(ctx context.Context) error {
+	for key, credentials := range aps.credentials {
+		if err := aps.checkCredentials(ctx, credentials); err != nil {
+			return fmt.Errorf("%s %s: %w", appName(key.app), key.environment, err)
+		}
+	}
+	return nil
+}
+
+// checkCredentials posts an empty notification to an invalid device token, which APNS answers
+// with 400 BadDeviceToken once the JWT and topic were accepted. Other reasons, such as
+// InvalidProviderToken or BadTopic, point at the credentials.
+func (aps *APNSServiceImpl) checkCredentials(ctx context.Context, credentials *apnsCredentials) error {
+	tokenString, err := credentials.token()
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/3/device/%s", aps.hosts[credentials.config.Environment], checkDeviceToken)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(`{"aps":{}}`))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "bearer "+tokenString)
+	req.Header.Set("apns-topic", credentials.config.BundleID)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := aps.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConnectionCheckFailed, err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	var failure struct {
+		Reason string `json:"reason"`
+	}
+	json.Unmarshal(body, &failure)
+	if resp.StatusCode == http.StatusOK || failure.Reason == "BadDeviceToken" {
+		return nil
+	}
+	return fmt.Errorf("%w: status %d: %s", ErrConnectionCheckFailed, resp.StatusCode, strings.TrimSpace(string(body)))
 }
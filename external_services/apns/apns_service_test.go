@@ -2,20 +2,39 @@ package apns
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/gaurav2721/notification-service/external_services/httpclient"
+	"github.com/gaurav2721/notification-service/external_services/pushapps"
 	"github.com/gaurav2721/notification-service/models"
 )
 
 func TestNewAPNSService(t *testing.T) {
-	service := NewAPNSService()
+	service, err := NewAPNSService(httpclient.TransportConfig{})
+	if err != nil {
+		t.Fatalf("Expected APNS service to be created, got %v", err)
+	}
 	if service == nil {
 		t.Fatal("Expected APNS service to be created, got nil")
 	}
 }
 
 func TestSendPushNotification(t *testing.T) {
-	service := NewAPNSService()
+	service, err := NewAPNSService(httpclient.TransportConfig{})
+	if err != nil {
+		t.Fatalf("Expected APNS service to be created, got %v", err)
+	}
 
 	// Test with iOS device token in recipient
 	notification := &models.APNSNotificationRequest{
@@ -60,3 +79,187 @@ func TestSendPushNotification(t *testing.T) {
 		t.Errorf("Expected ErrInvalidNotificationPayload, got %v", err)
 	}
 }
+
+func writeTestKey(t *testing.T) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "AuthKey.p8")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	return path
+}
+
+func TestSendPushNotification_Environment(t *testing.T) {
+	requests := make(map[string]string)
+	newHost := func(environment string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests[environment] = r.Header.Get("apns-topic")
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+	production := newHost(models.APNSEnvironmentProduction)
+	defer production.Close()
+	sandbox := newHost(models.APNSEnvironmentSandbox)
+	defer sandbox.Close()
+
+	keyPath := writeTestKey(t)
+	service, err := newAPNSService(models.APNSEnvironmentProduction, []*APNSConfig{
+		{BundleID: "com.example.app", KeyID: "KEY", TeamID: "TEAM", PrivateKeyPath: keyPath, Environment: models.APNSEnvironmentProduction},
+		{BundleID: "com.example.app.dev", KeyID: "DEVKEY", TeamID: "TEAM", PrivateKeyPath: keyPath, Environment: models.APNSEnvironmentSandbox},
+	}, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	service.hosts = map[string]string{
+		models.APNSEnvironmentProduction: production.URL,
+		models.APNSEnvironmentSandbox:    sandbox.URL,
+	}
+
+	for _, environment := range []string{"", models.APNSEnvironmentSandbox} {
+		_, err := service.SendPushNotification(context.Background(), &models.APNSNotificationRequest{
+			ID:          "test_notification",
+			Type:        "ios_push",
+			Content:     models.APNSContent{Title: "Test Title", Body: "Test Body"},
+			Recipient:   "ios_device_token_123",
+			Environment: environment,
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if requests[models.APNSEnvironmentProduction] != "com.example.app" {
+		t.Errorf("Expected production push with production topic, got %q", requests[models.APNSEnvironmentProduction])
+	}
+	if requests[models.APNSEnvironmentSandbox] != "com.example.app.dev" {
+		t.Errorf("Expected sandbox push with sandbox topic, got %q", requests[models.APNSEnvironmentSandbox])
+	}
+}
+
+func TestSendPushNotification_ThreadID(t *testing.T) {
+	var payload struct {
+		APS map[string]interface{} `json:"aps"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload.APS = nil
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	service, err := newAPNSService(models.APNSEnvironmentProduction, []*APNSConfig{
+		{BundleID: "com.example.app", KeyID: "KEY", TeamID: "TEAM", PrivateKeyPath: writeTestKey(t), Environment: models.APNSEnvironmentProduction},
+	}, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	service.hosts = map[string]string{models.APNSEnvironmentProduction: server.URL}
+
+	notification := &models.APNSNotificationRequest{
+		ID:        "test_notification",
+		Type:      "ios_push",
+		Content:   models.APNSContent{Title: "Order #42", Body: "Out for delivery"},
+		Recipient: "ios_device_token_123",
+		ThreadID:  "order:42",
+	}
+	if _, err := service.SendPushNotification(context.Background(), notification); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if payload.APS["thread-id"] != "order:42" {
+		t.Errorf("Expected thread-id order:42, got %v", payload.APS["thread-id"])
+	}
+
+	notification.ThreadID = ""
+	if _, err := service.SendPushNotification(context.Background(), notification); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok := payload.APS["thread-id"]; ok {
+		t.Errorf("Expected no thread-id, got %v", payload.APS["thread-id"])
+	}
+}
+
+func TestNewAPNSService_InvalidKey(t *testing.T) {
+	_, err := newAPNSService(models.APNSEnvironmentProduction, []*APNSConfig{
+		{BundleID: "com.example.app", KeyID: "KEY", TeamID: "TEAM", PrivateKeyPath: filepath.Join(t.TempDir(), "missing.p8"), Environment: models.APNSEnvironmentProduction},
+	}, http.DefaultClient)
+	if !errors.Is(err, ErrInvalidConfiguration) {
+		t.Errorf("Expected ErrInvalidConfiguration, got %v", err)
+	}
+}
+
+func TestSendPushNotification_App(t *testing.T) {
+	var topic string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		topic = r.Header.Get("apns-topic")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	keyPath := writeTestKey(t)
+	configs := appConfigs("", &pushapps.APNSCredentials{BundleID: "com.example.app", KeyID: "KEY", TeamID: "TEAM", PrivateKeyPath: keyPath}, 30)
+	configs = append(configs, appConfigs("driver", &pushapps.APNSCredentials{BundleID: "com.example.driver", KeyID: "DRIVERKEY", TeamID: "TEAM", PrivateKeyPath: keyPath}, 30)...)
+	service, err := newAPNSService(models.APNSEnvironmentProduction, configs, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	service.hosts = map[string]string{models.APNSEnvironmentProduction: server.URL}
+
+	notification := &models.APNSNotificationRequest{
+		ID:        "test_notification",
+		Type:      "ios_push",
+		Content:   models.APNSContent{Title: "Test Title", Body: "Test Body"},
+		Recipient: "ios_device_token_123",
+		AppID:     "driver",
+	}
+	if _, err := service.SendPushNotification(context.Background(), notification); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if topic != "com.example.driver" {
+		t.Errorf("Expected the driver app topic, got %q", topic)
+	}
+
+	notification.AppID = "unknown"
+	if _, err := service.SendPushNotification(context.Background(), notification); !errors.Is(err, pushapps.ErrUnknownApp) {
+		t.Errorf("Expected ErrUnknownApp, got %v", err)
+	}
+}
+
+func TestCheckConnection(t *testing.T) {
+	reason := "BadDeviceToken"
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"reason":"` + reason + `"}`))
+	}))
+	defer server.Close()
+
+	service, err := newAPNSService(models.APNSEnvironmentProduction, []*APNSConfig{
+		{BundleID: "com.example.app", KeyID: "KEY", TeamID: "TEAM", PrivateKeyPath: writeTestKey(t), Environment: models.APNSEnvironmentProduction},
+	}, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	service.hosts = map[string]string{models.APNSEnvironmentProduction: server.URL}
+
+	if err := service.CheckConnection(context.Background()); err != nil {
+		t.Fatalf("Expected a rejected device token to pass the check, got %v", err)
+	}
+	if path != "/3/device/"+checkDeviceToken {
+		t.Errorf("Expected the check device token, got path %q", path)
+	}
+
+	reason = "InvalidProviderToken"
+	if err := service.CheckConnection(context.Background()); !errors.Is(err, ErrConnectionCheckFailed) {
+		t.Errorf("Expected ErrConnectionCheckFailed, got %v", err)
+	}
+}
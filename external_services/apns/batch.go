@@ -0,0 +1,43 @@
+package apns
+
+import (
+	"context"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// BatchAPNSService is implemented by APNS services that can send several pushes at once
+type BatchAPNSService interface {
+	APNSService
+
+	// MaxBatchSize is the largest number of pushes sent at once
+	MaxBatchSize() int
+
+	// SendPushNotificationBatch sends the pushes and returns a response and an error for each of
+	// them, in the order of the notifications
+	SendPushNotificationBatch(ctx context.Context, notifications []*models.APNSNotificationRequest) ([]interface{}, []error)
+}
+
+// MaxBatchSize returns the largest batch the APNS service sends at once, which is 1 for services
+// that cannot batch
+func MaxBatchSize(service APNSService) int {
+	if batcher, ok := service.(BatchAPNSService); ok && batcher.MaxBatchSize() > 1 {
+		return batcher.MaxBatchSize()
+	}
+	return 1
+}
+
+// SendBatch sends the pushes at once if the APNS service supports batches, or one by one
+// otherwise, and returns a response and an error for each of them
+func SendBatch(ctx context.Context, service APNSService, notifications []*models.APNSNotificationRequest) ([]interface{}, []error) {
+	if batcher, ok := service.(BatchAPNSService); ok {
+		return batcher.SendPushNotificationBatch(ctx, notifications)
+	}
+
+	responses := make([]interface{}, len(notifications))
+	errs := make([]error, len(notifications))
+	for i, notification := range notifications {
+		responses[i], errs[i] = service.SendPushNotification(ctx, notification)
+	}
+	return responses, errs
+}
@@ -0,0 +1,124 @@
+package apns
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+func TestSendPushNotificationBatch(t *testing.T) {
+	var inFlight, maxInFlight int32
+	var mu sync.Mutex
+	authorizations := make(map[string]bool)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 {
+			t.Errorf("Expected an HTTP/2 request, got %s", r.Proto)
+		}
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			observed := atomic.LoadInt32(&maxInFlight)
+			if current <= observed || atomic.CompareAndSwapInt32(&maxInFlight, observed, current) {
+				break
+			}
+		}
+		mu.Lock()
+		authorizations[r.Header.Get("Authorization")] = true
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+
+		token := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		w.Header().Set("apns-id", "apns-"+token)
+		if token == "bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"reason":"BadDeviceToken"}`))
+		}
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	service, err := newAPNSService(models.APNSEnvironmentProduction, []*APNSConfig{
+		{BundleID: "com.example.app", KeyID: "KEY", TeamID: "TEAM", PrivateKeyPath: writeTestKey(t), Environment: models.APNSEnvironmentProduction},
+	}, server.Client())
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	service.hosts = map[string]string{models.APNSEnvironmentProduction: server.URL}
+	service.maxStreams = 3
+	if size := MaxBatchSize(service); size != 3 {
+		t.Fatalf("Expected a batch size of 3, got %d", size)
+	}
+
+	tokens := []string{"t1", "t2", "bad", "t4", "t5", "t6", ""}
+	notifications := make([]*models.APNSNotificationRequest, len(tokens))
+	for i, token := range tokens {
+		notifications[i] = &models.APNSNotificationRequest{
+			ID:        "n1",
+			Type:      "ios_push",
+			Content:   models.APNSContent{Title: "Title", Body: "Body"},
+			Recipient: token,
+		}
+	}
+
+	responses, errs := SendBatch(context.Background(), service, notifications)
+
+	if maxInFlight < 2 || maxInFlight > 3 {
+		t.Errorf("Expected 2 to 3 concurrent streams, got %d", maxInFlight)
+	}
+	if len(authorizations) != 1 {
+		t.Errorf("Expected the provider token to be reused, got %d tokens", len(authorizations))
+	}
+	if errs[6] == nil {
+		t.Error("Expected a validation error for the push without a token")
+	}
+	for i, token := range tokens[:6] {
+		if errs[i] != nil {
+			t.Fatalf("Expected no error for %s, got %v", token, errs[i])
+		}
+		response := responses[i].(*models.APNSResponse)
+		if response.APNSID != "apns-"+token {
+			t.Errorf("Expected apns-id apns-%s, got %q", token, response.APNSID)
+		}
+	}
+	bad := responses[2].(*models.APNSResponse)
+	if bad.FailureCount != 1 || bad.Reason != "BadDeviceToken" || bad.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected the bad token to be rejected with BadDeviceToken, got %+v", bad)
+	}
+	if ok := responses[0].(*models.APNSResponse); ok.SuccessCount != 1 || ok.Reason != "" {
+		t.Errorf("Expected the first push to succeed, got %+v", ok)
+	}
+}
+
+func TestCredentialsToken_Reused(t *testing.T) {
+	service, err := newAPNSService(models.APNSEnvironmentProduction, []*APNSConfig{
+		{BundleID: "com.example.app", KeyID: "KEY", TeamID: "TEAM", PrivateKeyPath: writeTestKey(t), Environment: models.APNSEnvironmentProduction},
+	}, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	credentials := service.credentials[credentialKey{environment: models.APNSEnvironmentProduction}]
+
+	first, err := credentials.token()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	second, _ := credentials.token()
+	if first != second {
+		t.Error("Expected the token to be reused")
+	}
+
+	// A token due for a refresh is signed again
+	credentials.issuedAt = time.Now().Add(-tokenRefreshInterval)
+	refreshed, _ := credentials.token()
+	if refreshed == first {
+		t.Error("Expected a new token after the refresh interval")
+	}
+}
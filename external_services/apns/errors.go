@@ -9,6 +9,9 @@ var (
 	// ErrInvalidConfiguration indicates that APNS configuration is invalid
 	ErrInvalidConfiguration = errors.New("invalid APNS configuration")
 
+	// ErrConnectionCheckFailed indicates that APNS rejected or could not be reached by a connection check
+	ErrConnectionCheckFailed = errors.New("APNS connection check failed")
+
 	// ErrInvalidNotificationPayload indicates that notification payload is invalid
 	ErrInvalidNotificationPayload = errors.New("invalid notification payload")
 )
@@ -9,6 +9,7 @@ type APNSService interface {
 
 // APNSConfig holds configuration for APNS service
 type APNSConfig struct {
+	App            string // app ID in the push app registry; empty for the default app
 	BundleID       string
 	KeyID          string
 	TeamID         string
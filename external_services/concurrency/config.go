@@ -0,0 +1,40 @@
+package concurrency
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/gaurav2721/notification-service/constants"
+	"github.com/sirupsen/logrus"
+)
+
+// limitEnvVars maps every provider to the environment variable holding its limit
+var limitEnvVars = map[string]string{
+	ProviderEmail:      constants.EmailMaxConcurrencyEnvVar,
+	ProviderSlack:      constants.SlackMaxConcurrencyEnvVar,
+	ProviderGoogleChat: constants.GoogleChatMaxConcurrencyEnvVar,
+	ProviderIncident:   constants.IncidentMaxConcurrencyEnvVar,
+	ProviderRCS:        constants.RCSMaxConcurrencyEnvVar,
+	ProviderAPNS:       constants.APNSMaxConcurrencyEnvVar,
+	ProviderFCM:        constants.FCMMaxConcurrencyEnvVar,
+}
+
+// LoadLimitsFromEnv reads the concurrency limits of the providers from environment variables.
+// Unset or invalid limits are left out, which leaves the provider unlimited.
+func LoadLimitsFromEnv() map[string]int {
+	limits := make(map[string]int)
+	for provider, key := range limitEnvVars {
+		value := os.Getenv(key)
+		if value == "" {
+			continue
+		}
+
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			logrus.WithFields(logrus.Fields{"key": key, "value": value}).Warn("Invalid provider concurrency limit, provider is not limited")
+			continue
+		}
+		limits[provider] = limit
+	}
+	return limits
+}
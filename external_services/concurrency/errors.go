@@ -0,0 +1,9 @@
+package concurrency
+
+import "errors"
+
+// Concurrency limit errors
+var (
+	ErrUnknownProvider = errors.New("unknown provider")
+	ErrInvalidLimit    = errors.New("concurrency limit must not be negative")
+)
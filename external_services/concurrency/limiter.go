@@ -0,0 +1,168 @@
+package concurrency
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gaurav2721/notification-service/metrics"
+)
+
+// Provider names that can be limited
+const (
+	ProviderEmail      = "email"
+	ProviderSlack      = "slack"
+	ProviderGoogleChat = "google_chat"
+	ProviderIncident   = "incident"
+	ProviderRCS        = "rcs"
+	ProviderAPNS       = "apns"
+	ProviderFCM        = "fcm"
+)
+
+// Providers lists every provider a limit can be set for
+var Providers = []string{ProviderEmail, ProviderSlack, ProviderGoogleChat, ProviderIncident, ProviderRCS, ProviderAPNS, ProviderFCM}
+
+var (
+	providerRequestsInFlight = metrics.DefaultRegistry.NewGaugeVec(
+		"provider_requests_in_flight",
+		"Requests to a provider currently in flight.",
+		"provider",
+	)
+	providerConcurrencyWaitSecondsTotal = metrics.DefaultRegistry.NewCounterVec(
+		"provider_concurrency_wait_seconds_total",
+		"Time sends spent waiting for a free slot under the provider's concurrency limit.",
+		"provider",
+	)
+)
+
+// Limiter caps the number of requests in flight to each provider, independently of how many
+// workers send them. Limits can be changed while requests are in flight.
+type Limiter struct {
+	mutex sync.Mutex
+	slots map[string]*slot
+}
+
+// slot is the semaphore of one provider
+type slot struct {
+	limit    int // zero means unlimited
+	inFlight int
+
+	// changed is closed and replaced whenever a request finishes or the limit changes
+	changed chan struct{}
+}
+
+// NewLimiter creates a limiter with the given limits by provider; providers without a limit,
+// or with a limit of zero, are not limited
+func NewLimiter(limits map[string]int) (*Limiter, error) {
+	limiter := &Limiter{slots: make(map[string]*slot, len(Providers))}
+	for _, provider := range Providers {
+		limiter.slots[provider] = &slot{changed: make(chan struct{})}
+	}
+	if err := limiter.SetLimits(limits); err != nil {
+		return nil, err
+	}
+	return limiter, nil
+}
+
+// Acquire waits until a request to provider may be sent and returns the function that releases
+// its slot. It returns the context's error if ctx is done first.
+func (l *Limiter) Acquire(ctx context.Context, provider string) (release func(), err error) {
+	var start time.Time
+	for {
+		l.mutex.Lock()
+		s, ok := l.slots[provider]
+		if !ok {
+			l.mutex.Unlock()
+			return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, provider)
+		}
+		if s.limit <= 0 || s.inFlight < s.limit {
+			s.inFlight++
+			providerRequestsInFlight.Set(float64(s.inFlight), provider)
+			l.mutex.Unlock()
+
+			if !start.IsZero() {
+				providerConcurrencyWaitSecondsTotal.Add(time.Since(start).Seconds(), provider)
+			}
+			var once sync.Once
+			return func() { once.Do(func() { l.release(provider) }) }, nil
+		}
+		changed := s.changed
+		l.mutex.Unlock()
+
+		if start.IsZero() {
+			start = time.Now()
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			providerConcurrencyWaitSecondsTotal.Add(time.Since(start).Seconds(), provider)
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// release frees a slot of provider and wakes up waiting requests
+func (l *Limiter) release(provider string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	s := l.slots[provider]
+	s.inFlight--
+	providerRequestsInFlight.Set(float64(s.inFlight), provider)
+	s.notify()
+}
+
+// notify wakes up the requests waiting for the slot
+func (s *slot) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// SetLimits changes the limits of the given providers, leaving the others as they are.
+// The limits are validated as a whole, so an invalid update changes nothing.
+func (l *Limiter) SetLimits(limits map[string]int) error {
+	for provider, limit := range limits {
+		if _, ok := l.slots[provider]; !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownProvider, provider)
+		}
+		if limit < 0 {
+			return fmt.Errorf("%w: %s", ErrInvalidLimit, provider)
+		}
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for provider, limit := range limits {
+		s := l.slots[provider]
+		s.limit = limit
+		s.notify()
+	}
+	return nil
+}
+
+// ProviderStatus is the limit and the requests in flight of one provider
+type ProviderStatus struct {
+	Provider string `json:"provider"`
+	Limit    int    `json:"limit"` // zero means unlimited
+	InFlight int    `json:"in_flight"`
+}
+
+// Status returns the limit and the requests in flight of every provider, sorted by provider
+func (l *Limiter) Status() []ProviderStatus {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	status := make([]ProviderStatus, 0, len(l.slots))
+	for provider, s := range l.slots {
+		status = append(status, ProviderStatus{Provider: provider, Limit: s.limit, InFlight: s.inFlight})
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Provider < status[j].Provider })
+	return status
+}
+
+// Default is the limiter the provider services are wrapped with; its limits can be changed at
+// runtime through the admin API
+var Default, _ = NewLimiter(nil)
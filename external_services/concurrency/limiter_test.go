@@ -0,0 +1,129 @@
+package concurrency
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/external_services/apns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLimiter_InvalidLimits(t *testing.T) {
+	_, err := NewLimiter(map[string]int{"sms": 5})
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+
+	_, err = NewLimiter(map[string]int{ProviderAPNS: -1})
+	assert.ErrorIs(t, err, ErrInvalidLimit)
+}
+
+func TestLimiter_CapsRequestsInFlight(t *testing.T) {
+	limiter, err := NewLimiter(map[string]int{ProviderAPNS: 2})
+	require.NoError(t, err)
+
+	var inFlight, maxInFlight int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := limiter.Acquire(context.Background(), ProviderAPNS)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer release()
+
+			current := atomic.AddInt32(&inFlight, 1)
+			for {
+				max := atomic.LoadInt32(&maxInFlight)
+				if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), maxInFlight)
+	assert.Equal(t, []ProviderStatus{
+		{Provider: ProviderAPNS, Limit: 2},
+		{Provider: ProviderEmail},
+		{Provider: ProviderFCM},
+		{Provider: ProviderGoogleChat},
+		{Provider: ProviderIncident},
+		{Provider: ProviderRCS},
+		{Provider: ProviderSlack},
+	}, limiter.Status())
+}
+
+func TestLimiter_RaisingTheLimitWakesWaiters(t *testing.T) {
+	limiter, err := NewLimiter(map[string]int{ProviderFCM: 1})
+	require.NoError(t, err)
+
+	release, err := limiter.Acquire(context.Background(), ProviderFCM)
+	require.NoError(t, err)
+	defer release()
+
+	acquired := make(chan struct{})
+	go func() {
+		second, err := limiter.Acquire(context.Background(), ProviderFCM)
+		if err == nil {
+			defer second()
+		}
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second request was not held back")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	require.NoError(t, limiter.SetLimits(map[string]int{ProviderFCM: 2}))
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("raising the limit did not let the waiting request through")
+	}
+}
+
+func TestLimiter_AcquireHonorsContext(t *testing.T) {
+	limiter, err := NewLimiter(map[string]int{ProviderSlack: 1})
+	require.NoError(t, err)
+
+	release, err := limiter.Acquire(context.Background(), ProviderSlack)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = limiter.Acquire(ctx, ProviderSlack)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Releasing twice frees a single slot
+	release()
+	release()
+	assert.Equal(t, 0, limiter.Status()[3].InFlight)
+
+	_, err = limiter.Acquire(context.Background(), "sms")
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+}
+
+func TestLimitedAPNSService(t *testing.T) {
+	limiter, err := NewLimiter(map[string]int{ProviderAPNS: 1})
+	require.NoError(t, err)
+
+	service := NewLimitedAPNSService(apns.NewMockAPNSService(), limiter)
+	release, err := limiter.Acquire(context.Background(), ProviderAPNS)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = service.SendPushNotification(ctx, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "sends wait for a free slot")
+	release()
+}
@@ -0,0 +1,262 @@
+package concurrency
+
+import (
+	"context"
+
+	"github.com/gaurav2721/notification-service/external_services/apns"
+	"github.com/gaurav2721/notification-service/external_services/email"
+	"github.com/gaurav2721/notification-service/external_services/fcm"
+	"github.com/gaurav2721/notification-service/external_services/googlechat"
+	"github.com/gaurav2721/notification-service/external_services/incident"
+	"github.com/gaurav2721/notification-service/external_services/rcs"
+	"github.com/gaurav2721/notification-service/external_services/slack"
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// limitedEmailService wraps an EmailService with a concurrency limit
+type limitedEmailService struct {
+	inner   email.EmailService
+	limiter *Limiter
+}
+
+// NewLimitedEmailService wraps the given email service with the limiter's email limit
+func NewLimitedEmailService(inner email.EmailService, limiter *Limiter) email.EmailService {
+	return &limitedEmailService{inner: inner, limiter: limiter}
+}
+
+// SendEmail waits for a free slot before delegating to the wrapped email service
+func (s *limitedEmailService) SendEmail(ctx context.Context, notification interface{}) (interface{}, error) {
+	release, err := s.limiter.Acquire(ctx, ProviderEmail)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.inner.SendEmail(ctx, notification)
+}
+
+// MaxBatchSize returns the batch size of the wrapped email service
+func (s *limitedEmailService) MaxBatchSize() int {
+	return email.MaxBatchSize(s.inner)
+}
+
+// SendEmailBatch sends a batch as one provider call, so it takes a single slot
+func (s *limitedEmailService) SendEmailBatch(ctx context.Context, notifications []*models.EmailNotificationRequest) ([]interface{}, []error) {
+	release, err := s.limiter.Acquire(ctx, ProviderEmail)
+	if err != nil {
+		errs := make([]error, len(notifications))
+		for i := range errs {
+			errs[i] = err
+		}
+		return make([]interface{}, len(notifications)), errs
+	}
+	defer release()
+	return email.SendBatch(ctx, s.inner, notifications)
+}
+
+// limitedSlackService wraps a SlackService with a concurrency limit
+type limitedSlackService struct {
+	inner   slack.SlackService
+	limiter *Limiter
+}
+
+// NewLimitedSlackService wraps the given slack service with the limiter's slack limit
+func NewLimitedSlackService(inner slack.SlackService, limiter *Limiter) slack.SlackService {
+	return &limitedSlackService{inner: inner, limiter: limiter}
+}
+
+// SendSlackMessage waits for a free slot before delegating to the wrapped slack service
+func (s *limitedSlackService) SendSlackMessage(ctx context.Context, notification interface{}) (interface{}, error) {
+	release, err := s.limiter.Acquire(ctx, ProviderSlack)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.inner.SendSlackMessage(ctx, notification)
+}
+
+// limitedGoogleChatService wraps a GoogleChatService with a concurrency limit
+type limitedGoogleChatService struct {
+	inner   googlechat.GoogleChatService
+	limiter *Limiter
+}
+
+// NewLimitedGoogleChatService wraps the given Google Chat service with the limiter's Google Chat limit
+func NewLimitedGoogleChatService(inner googlechat.GoogleChatService, limiter *Limiter) googlechat.GoogleChatService {
+	return &limitedGoogleChatService{inner: inner, limiter: limiter}
+}
+
+// SendGoogleChatMessage waits for a free slot before delegating to the wrapped Google Chat service
+func (s *limitedGoogleChatService) SendGoogleChatMessage(ctx context.Context, notification interface{}) (interface{}, error) {
+	release, err := s.limiter.Acquire(ctx, ProviderGoogleChat)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.inner.SendGoogleChatMessage(ctx, notification)
+}
+
+// limitedIncidentService wraps an IncidentService with a concurrency limit
+type limitedIncidentService struct {
+	inner   incident.IncidentService
+	limiter *Limiter
+}
+
+// NewLimitedIncidentService wraps the given incident service with the limiter's incident limit
+func NewLimitedIncidentService(inner incident.IncidentService, limiter *Limiter) incident.IncidentService {
+	return &limitedIncidentService{inner: inner, limiter: limiter}
+}
+
+// SendIncidentEvent waits for a free slot before delegating to the wrapped incident service
+func (s *limitedIncidentService) SendIncidentEvent(ctx context.Context, notification interface{}) (interface{}, error) {
+	release, err := s.limiter.Acquire(ctx, ProviderIncident)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.inner.SendIncidentEvent(ctx, notification)
+}
+
+// limitedRCSService wraps an RCSService with a concurrency limit
+type limitedRCSService struct {
+	inner   rcs.RCSService
+	limiter *Limiter
+}
+
+// NewLimitedRCSService wraps the given RCS service with the limiter's RCS limit
+func NewLimitedRCSService(inner rcs.RCSService, limiter *Limiter) rcs.RCSService {
+	return &limitedRCSService{inner: inner, limiter: limiter}
+}
+
+// SendRCSMessage waits for a free slot before delegating to the wrapped RCS service
+func (s *limitedRCSService) SendRCSMessage(ctx context.Context, notification interface{}) (interface{}, error) {
+	release, err := s.limiter.Acquire(ctx, ProviderRCS)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.inner.SendRCSMessage(ctx, notification)
+}
+
+// limitedAPNSService wraps an APNSService with a concurrency limit
+type limitedAPNSService struct {
+	inner   apns.APNSService
+	limiter *Limiter
+}
+
+// NewLimitedAPNSService wraps the given APNS service with the limiter's APNS limit
+func NewLimitedAPNSService(inner apns.APNSService, limiter *Limiter) apns.APNSService {
+	return &limitedAPNSService{inner: inner, limiter: limiter}
+}
+
+// SendPushNotification waits for a free slot before delegating to the wrapped APNS service
+func (s *limitedAPNSService) SendPushNotification(ctx context.Context, notification interface{}) (interface{}, error) {
+	release, err := s.limiter.Acquire(ctx, ProviderAPNS)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.inner.SendPushNotification(ctx, notification)
+}
+
+// MaxBatchSize returns the batch size of the wrapped APNS service
+func (s *limitedAPNSService) MaxBatchSize() int {
+	return apns.MaxBatchSize(s.inner)
+}
+
+// SendPushNotificationBatch sends a batch under a single slot, as its pushes are multiplexed over
+// one HTTP/2 connection
+func (s *limitedAPNSService) SendPushNotificationBatch(ctx context.Context, notifications []*models.APNSNotificationRequest) ([]interface{}, []error) {
+	release, err := s.limiter.Acquire(ctx, ProviderAPNS)
+	if err != nil {
+		errs := make([]error, len(notifications))
+		for i := range errs {
+			errs[i] = err
+		}
+		return make([]interface{}, len(notifications)), errs
+	}
+	defer release()
+	return apns.SendBatch(ctx, s.inner, notifications)
+}
+
+// limitedFCMService wraps an FCMService with a concurrency limit
+type limitedFCMService struct {
+	inner   fcm.FCMService
+	limiter *Limiter
+}
+
+// NewLimitedFCMService wraps the given FCM service with the limiter's FCM limit
+func NewLimitedFCMService(inner fcm.FCMService, limiter *Limiter) fcm.FCMService {
+	return &limitedFCMService{inner: inner, limiter: limiter}
+}
+
+// SendPushNotification waits for a free slot before delegating to the wrapped FCM service
+func (s *limitedFCMService) SendPushNotification(ctx context.Context, notification interface{}) (interface{}, error) {
+	release, err := s.limiter.Acquire(ctx, ProviderFCM)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.inner.SendPushNotification(ctx, notification)
+}
+
+// MaxBatchSize returns the multicast size of the wrapped FCM service
+func (s *limitedFCMService) MaxBatchSize() int {
+	return fcm.MaxBatchSize(s.inner)
+}
+
+// SendPushNotificationBatch sends a batch under a single slot; the wrapped service sends it in as
+// few multicast requests as it can
+func (s *limitedFCMService) SendPushNotificationBatch(ctx context.Context, notifications []*models.FCMNotificationRequest) ([]interface{}, []error) {
+	release, err := s.limiter.Acquire(ctx, ProviderFCM)
+	if err != nil {
+		errs := make([]error, len(notifications))
+		for i := range errs {
+			errs[i] = err
+		}
+		return make([]interface{}, len(notifications)), errs
+	}
+	defer release()
+	return fcm.SendBatch(ctx, s.inner, notifications)
+}
+
+// SubscribeToTopic waits for a free slot before delegating to the wrapped FCM service
+func (s *limitedFCMService) SubscribeToTopic(ctx context.Context, appID, topic string, tokens []string) (*fcm.TopicManagementResult, error) {
+	topics, err := fcm.AsTopicService(s.inner)
+	if err != nil {
+		return nil, err
+	}
+	release, err := s.limiter.Acquire(ctx, ProviderFCM)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return topics.SubscribeToTopic(ctx, appID, topic, tokens)
+}
+
+// UnsubscribeFromTopic waits for a free slot before delegating to the wrapped FCM service
+func (s *limitedFCMService) UnsubscribeFromTopic(ctx context.Context, appID, topic string, tokens []string) (*fcm.TopicManagementResult, error) {
+	topics, err := fcm.AsTopicService(s.inner)
+	if err != nil {
+		return nil, err
+	}
+	release, err := s.limiter.Acquire(ctx, ProviderFCM)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return topics.UnsubscribeFromTopic(ctx, appID, topic, tokens)
+}
+
+// SendToTopic waits for a free slot before delegating to the wrapped FCM service
+func (s *limitedFCMService) SendToTopic(ctx context.Context, topic string, notification *models.FCMNotificationRequest) (interface{}, error) {
+	topics, err := fcm.AsTopicService(s.inner)
+	if err != nil {
+		return nil, err
+	}
+	release, err := s.limiter.Acquire(ctx, ProviderFCM)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return topics.SendToTopic(ctx, topic, notification)
+}
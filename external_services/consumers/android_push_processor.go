@@ -4,15 +4,23 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
+	"github.com/gaurav2721/notification-service/external_services/concurrency"
+	"github.com/gaurav2721/notification-service/external_services/delivery"
 	"github.com/gaurav2721/notification-service/external_services/fcm"
+	"github.com/gaurav2721/notification-service/logger"
 	"github.com/gaurav2721/notification-service/models"
-	"github.com/sirupsen/logrus"
 )
 
 // androidPushProcessor handles Android push notification processing
 type androidPushProcessor struct {
-	fcmService fcm.FCMService
+	fcmService      fcm.FCMService
+	deliveryService delivery.DeliveryService
+	// scheduledBatcher coalesces pushes with a batch key into multicast requests
+	scheduledBatcher *messageBatcher
+	// multicastBatcher coalesces the other pushes of a notification into multicast requests
+	multicastBatcher *messageBatcher
 }
 
 // NewAndroidPushProcessor creates a new Android push notification processor
@@ -27,25 +35,71 @@ func NewAndroidPushProcessorWithService(fcmService fcm.FCMService) NotificationP
 	}
 }
 
+// NewAndroidPushProcessorWithServices creates a new Android push processor that also archives delivery attempts
+func NewAndroidPushProcessorWithServices(fcmService fcm.FCMService, deliveryService delivery.DeliveryService) NotificationProcessor {
+	return &androidPushProcessor{
+		fcmService:      fcmService,
+		deliveryService: deliveryService,
+	}
+}
+
+// withBatching sends pushes in FCM multicast requests as large as the FCM service accepts,
+// unless batching is disabled or the FCM service cannot batch
+func (ap *androidPushProcessor) withBatching(config BatchingConfig) *androidPushProcessor {
+	size := fcm.MaxBatchSize(ap.fcmService)
+	if size <= 1 {
+		return ap
+	}
+	if config.MaxWait > 0 {
+		ap.scheduledBatcher = newMessageBatcher(size, config.MaxWait, ap.sendBatch)
+	}
+	if config.MulticastWait > 0 {
+		ap.multicastBatcher = newMessageBatcher(size, config.MulticastWait, ap.sendBatch)
+	}
+	return ap
+}
+
+// sendBatch sends a batch of pushes with the FCM service
+func (ap *androidPushProcessor) sendBatch(ctx context.Context, items []interface{}) ([]interface{}, []error) {
+	notifications := make([]*models.FCMNotificationRequest, len(items))
+	for i, item := range items {
+		notifications[i] = item.(*models.FCMNotificationRequest)
+	}
+	sampledLog.Info("Sending Android push batch", logger.Fields{"size": len(notifications)})
+	return fcm.SendBatch(ctx, ap.fcmService, notifications)
+}
+
+// send sends the push, as part of a multicast request if batching applies to it
+func (ap *androidPushProcessor) send(ctx context.Context, notification *models.FCMNotificationRequest) (interface{}, error) {
+	switch {
+	case ap.scheduledBatcher != nil && notification.BatchKey != "":
+		return ap.scheduledBatcher.Submit(ctx, notification.BatchKey, notification)
+	case ap.multicastBatcher != nil:
+		return ap.multicastBatcher.Submit(ctx, notification.ID, notification)
+	default:
+		return ap.fcmService.SendPushNotification(ctx, notification)
+	}
+}
+
 // ProcessNotification processes an Android push notification
 func (ap *androidPushProcessor) ProcessNotification(ctx context.Context, message NotificationMessage) error {
-	logrus.WithFields(logrus.Fields{
+	sampledLog.Debug("Processing Android push notification", logger.Fields{
 		"notification_id": message.ID,
 		"type":            message.Type,
 		"payload":         message.Payload,
 		"timestamp":       message.Timestamp,
-	}).Debug("Processing Android push notification")
+	})
 
 	// If no FCM service is available, just log and return
 	if ap.fcmService == nil {
-		logrus.Warn("No FCM service available, skipping Android push notification")
+		moduleLog.Warn("No FCM service available, skipping Android push notification", nil)
 		return nil
 	}
 
 	// Parse the payload directly into FCMNotificationRequest
 	var fcmNotification models.FCMNotificationRequest
 	if err := json.Unmarshal([]byte(message.Payload), &fcmNotification); err != nil {
-		logrus.WithError(err).Error("Failed to parse notification payload into FCMNotificationRequest")
+		moduleLog.Error("Failed to parse notification payload into FCMNotificationRequest", logger.Fields{"error": err.Error()})
 		return fmt.Errorf("failed to parse notification payload into FCMNotificationRequest: %w", err)
 	}
 
@@ -59,28 +113,37 @@ func (ap *androidPushProcessor) ProcessNotification(ctx context.Context, message
 		fcmNotification.Type = string(message.Type)
 	}
 
-	logrus.WithFields(logrus.Fields{
+	sampledLog.Info("Sending Android push notification", logger.Fields{
 		"notification_id": message.ID,
 		"device_token":    fcmNotification.Recipient,
 		"title":           fcmNotification.Content.Title,
 		"body":            fcmNotification.Content.Body,
-	}).Info("Sending Android push notification")
+	})
 
 	// Send push notification using the FCM service
-	response, err := ap.fcmService.SendPushNotification(ctx, &fcmNotification)
+	startedAt := time.Now()
+	response, err := ap.send(ctx, &fcmNotification)
+	recordDeliveryAttempt(ap.deliveryService, &models.DeliveryAttempt{
+		NotificationID: fcmNotification.ID,
+		UserID:         fcmNotification.UserID,
+		Recipient:      fcmNotification.Recipient,
+		Channel:        string(AndroidPushNotification),
+		Provider:       concurrency.ProviderFCM,
+		QueuedAt:       queuedTime(fcmNotification.QueuedAt),
+	}, response, err, startedAt)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{
+		moduleLog.Error("Failed to send Android push notification", logger.Fields{
 			"notification_id": message.ID,
 			"error":           err.Error(),
-		}).Error("Failed to send Android push notification")
+		})
 		return fmt.Errorf("failed to send Android push notification: %w", err)
 	}
 
 	// Log successful push notification sending
-	logrus.WithFields(logrus.Fields{
+	sampledLog.Info("Android push notification sent successfully", logger.Fields{
 		"notification_id": message.ID,
 		"response":        response,
-	}).Info("Android push notification sent successfully")
+	})
 
 	return nil
 }
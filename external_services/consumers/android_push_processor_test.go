@@ -0,0 +1,94 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockMulticastFCMService is a mock FCM service sending multicasts of up to two pushes
+type mockMulticastFCMService struct {
+	mu      sync.Mutex
+	single  int
+	batches [][]string
+}
+
+func (m *mockMulticastFCMService) SendPushNotification(ctx context.Context, notification interface{}) (interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.single++
+	return &models.FCMResponse{Status: "sent", SuccessCount: 1}, nil
+}
+
+func (m *mockMulticastFCMService) MaxBatchSize() int {
+	return 2
+}
+
+func (m *mockMulticastFCMService) SendPushNotificationBatch(ctx context.Context, notifications []*models.FCMNotificationRequest) ([]interface{}, []error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var tokens []string
+	for _, notification := range notifications {
+		tokens = append(tokens, notification.Recipient)
+	}
+	m.batches = append(m.batches, tokens)
+	return make([]interface{}, len(notifications)), make([]error, len(notifications))
+}
+
+func androidPushMessage(t *testing.T, id, token, batchKey string) NotificationMessage {
+	payload, err := json.Marshal(models.FCMNotificationRequest{
+		ID:        id,
+		Content:   models.FCMContent{Title: "Title", Body: "Body"},
+		Recipient: token,
+		BatchKey:  batchKey,
+	})
+	require.NoError(t, err)
+	return NotificationMessage{Type: AndroidPushNotification, Payload: string(payload), ID: id}
+}
+
+func TestAndroidPushProcessor_MulticastsPushesOfANotification(t *testing.T) {
+	fcmService := &mockMulticastFCMService{}
+	processor := (&androidPushProcessor{fcmService: fcmService}).withBatching(BatchingConfig{MulticastWait: time.Hour})
+	assert.Nil(t, processor.scheduledBatcher)
+
+	var wg sync.WaitGroup
+	for _, token := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(token string) {
+			defer wg.Done()
+			assert.NoError(t, processor.ProcessNotification(context.Background(), androidPushMessage(t, "n1", token, "")))
+		}(token)
+	}
+	wg.Wait()
+
+	require.Len(t, fcmService.batches, 1)
+	assert.ElementsMatch(t, []string{"a", "b"}, fcmService.batches[0])
+	assert.Zero(t, fcmService.single)
+}
+
+func TestAndroidPushProcessor_BatchesScheduledPushes(t *testing.T) {
+	fcmService := &mockMulticastFCMService{}
+	processor := (&androidPushProcessor{fcmService: fcmService}).withBatching(BatchingConfig{MaxWait: time.Hour})
+	assert.Nil(t, processor.multicastBatcher)
+
+	// Pushes without a batch key are sent one by one without a multicast wait
+	require.NoError(t, processor.ProcessNotification(context.Background(), androidPushMessage(t, "n1", "a", "")))
+	assert.Equal(t, 1, fcmService.single)
+
+	var wg sync.WaitGroup
+	for _, id := range []string{"n2", "n3"} {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			assert.NoError(t, processor.ProcessNotification(context.Background(), androidPushMessage(t, id, id, "scheduled:2024-01-01T09:00:00Z")))
+		}(id)
+	}
+	wg.Wait()
+	assert.Len(t, fcmService.batches, 1)
+}
@@ -0,0 +1,316 @@
+package consumers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gaurav2721/notification-service/external_services/slack"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/metrics"
+)
+
+// Signals the anomaly detector watches
+const (
+	SignalFailureRate = "failure_rate"
+	SignalLatency     = "latency"
+)
+
+const (
+	// minBaselineWindows is the number of windows a channel needs before its windows are scored
+	minBaselineWindows = 5
+
+	// minFailureRateStdDev and minLatencyStdDevRatio keep very steady channels from alerting on
+	// tiny changes: the standard deviation of the failure rate is at least one percentage point,
+	// the one of latency at least 5% of the mean latency
+	minFailureRateStdDev  = 0.01
+	minLatencyStdDevRatio = 0.05
+)
+
+var (
+	notificationAnomaliesTotal = metrics.DefaultRegistry.NewCounterVec(
+		"notification_anomalies_total",
+		"Spikes detected in the failure rate or latency of a channel, by notification type and signal.",
+		"type", "signal",
+	)
+	notificationAnomalyZScore = metrics.DefaultRegistry.NewGaugeVec(
+		"notification_anomaly_zscore",
+		"Z-score of the last window of a channel against its baseline, by notification type and signal.",
+		"type", "signal",
+	)
+)
+
+// AnomalyDetectionConfig configures the detection of sudden spikes in the failure rate or
+// latency of a channel. Messages are counted in windows; every window is scored against the
+// preceding normal windows.
+type AnomalyDetectionConfig struct {
+	// Window is the length of the windows messages are counted in. Zero disables detection.
+	Window time.Duration
+
+	// BaselineWindows is the number of past windows the z-score is computed against
+	BaselineWindows int
+
+	// ZScoreThreshold is the number of standard deviations above the baseline mean at which a
+	// window is anomalous
+	ZScoreThreshold float64
+
+	// MinMessages is the number of messages a window needs to be scored; quieter windows are
+	// skipped, as a few failures would look like a spike
+	MinMessages int
+
+	// AlertSlackChannel, when set, receives a Slack system alert when a spike is detected and
+	// when the channel is back to normal
+	AlertSlackChannel string
+}
+
+// windowCounts are the messages processed by a channel in the current window
+type windowCounts struct {
+	messages     int
+	failed       int
+	latencyTotal time.Duration
+}
+
+// channelBaseline keeps the scores of a channel
+type channelBaseline struct {
+	// values holds the failure rate or mean latency of the last normal windows, oldest first
+	values map[string][]float64
+	// anomalous is set for the signals that are spiking
+	anomalous map[string]bool
+}
+
+// anomalyDetector scores the failure rate and latency of every channel's windows against the
+// channel's baseline and raises an alert while they spike
+type anomalyDetector struct {
+	config       AnomalyDetectionConfig
+	slackService slack.SlackService
+
+	mu        sync.Mutex
+	current   map[NotificationType]*windowCounts
+	baselines map[NotificationType]*channelBaseline
+	now       func() time.Time
+}
+
+func newAnomalyDetector(config AnomalyDetectionConfig, slackService slack.SlackService) *anomalyDetector {
+	if config.BaselineWindows < minBaselineWindows {
+		config.BaselineWindows = minBaselineWindows
+	}
+	if config.ZScoreThreshold <= 0 {
+		config.ZScoreThreshold = 3
+	}
+	if config.MinMessages <= 0 {
+		config.MinMessages = 1
+	}
+	return &anomalyDetector{
+		config:       config,
+		slackService: slackService,
+		current:      make(map[NotificationType]*windowCounts),
+		baselines:    make(map[NotificationType]*channelBaseline),
+		now:          time.Now,
+	}
+}
+
+// track returns the middleware that counts the outcome and latency of a channel's messages
+func (d *anomalyDetector) track(notificationType NotificationType) ProcessorMiddleware {
+	d.mu.Lock()
+	d.current[notificationType] = &windowCounts{}
+	d.baselines[notificationType] = &channelBaseline{values: make(map[string][]float64), anomalous: make(map[string]bool)}
+	d.mu.Unlock()
+
+	return func(notificationType NotificationType, next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, message NotificationMessage) error {
+			start := d.now()
+			err := next(ctx, message)
+			d.observe(notificationType, err != nil, d.now().Sub(queuedAt(message.Payload, start)))
+			return err
+		}
+	}
+}
+
+// observe counts a processed message of a channel
+func (d *anomalyDetector) observe(notificationType NotificationType, failed bool, latency time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	counts, ok := d.current[notificationType]
+	if !ok {
+		return
+	}
+	counts.messages++
+	if failed {
+		counts.failed++
+	}
+	counts.latencyTotal += latency
+}
+
+// run closes a window every Window until ctx is cancelled
+func (d *anomalyDetector) run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.Window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.evaluate(ctx)
+		}
+	}
+}
+
+// anomalyChange is a signal of a channel that started or stopped spiking
+type anomalyChange struct {
+	notificationType NotificationType
+	signal           string
+	anomalous        bool
+	value, mean      float64
+	zScore           float64
+}
+
+// evaluate closes the current window of every channel, scores it and alerts on signals that
+// started or stopped spiking
+func (d *anomalyDetector) evaluate(ctx context.Context) {
+	d.mu.Lock()
+	var changes []anomalyChange
+	for notificationType, counts := range d.current {
+		window := *counts
+		*counts = windowCounts{}
+		if window.messages < d.config.MinMessages {
+			continue
+		}
+
+		values := map[string]float64{
+			SignalFailureRate: float64(window.failed) / float64(window.messages),
+			SignalLatency:     (window.latencyTotal / time.Duration(window.messages)).Seconds(),
+		}
+		for _, signal := range []string{SignalFailureRate, SignalLatency} {
+			if change, changed := d.score(notificationType, signal, values[signal]); changed {
+				changes = append(changes, change)
+			}
+		}
+	}
+	d.mu.Unlock()
+
+	// Alerts are sent without holding the lock, so slow Slack calls do not hold up workers
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].notificationType != changes[j].notificationType {
+			return changes[i].notificationType < changes[j].notificationType
+		}
+		return changes[i].signal < changes[j].signal
+	})
+	for _, change := range changes {
+		if change.anomalous {
+			d.raise(ctx, change)
+		} else {
+			d.resolve(ctx, change)
+		}
+	}
+}
+
+// score computes the z-score of a window's value against the baseline of the signal and reports
+// whether the signal started or stopped spiking. Values of normal windows join the baseline;
+// spikes do not, so a lasting incident keeps standing out. Callers must hold d.mu.
+func (d *anomalyDetector) score(notificationType NotificationType, signal string, value float64) (anomalyChange, bool) {
+	baseline := d.baselines[notificationType]
+	values := baseline.values[signal]
+
+	anomalous := false
+	var mean, zScore float64
+	if len(values) >= minBaselineWindows {
+		var stdDev float64
+		mean, stdDev = meanAndStdDev(values)
+		if signal == SignalFailureRate {
+			stdDev = math.Max(stdDev, minFailureRateStdDev)
+		} else {
+			stdDev = math.Max(stdDev, math.Max(mean*minLatencyStdDevRatio, 0.001))
+		}
+		zScore = (value - mean) / stdDev
+		anomalous = zScore > d.config.ZScoreThreshold
+		notificationAnomalyZScore.Set(zScore, string(notificationType), signal)
+	}
+
+	if !anomalous {
+		values = append(values, value)
+		if len(values) > d.config.BaselineWindows {
+			values = values[len(values)-d.config.BaselineWindows:]
+		}
+		baseline.values[signal] = values
+	}
+
+	if anomalous == baseline.anomalous[signal] {
+		return anomalyChange{}, false
+	}
+	baseline.anomalous[signal] = anomalous
+	return anomalyChange{
+		notificationType: notificationType,
+		signal:           signal,
+		anomalous:        anomalous,
+		value:            value,
+		mean:             mean,
+		zScore:           zScore,
+	}, true
+}
+
+// raise reports a spike
+func (d *anomalyDetector) raise(ctx context.Context, change anomalyChange) {
+	notificationAnomaliesTotal.Inc(string(change.notificationType), change.signal)
+	moduleLog.Warn("Anomaly detected", logger.Fields{
+		"type":     change.notificationType,
+		"signal":   change.signal,
+		"value":    change.value,
+		"baseline": change.mean,
+		"z_score":  math.Round(change.zScore*10) / 10,
+	})
+
+	text := fmt.Sprintf(":rotating_light: The %s %s spiked to %s (baseline %s, z-score %.1f).",
+		change.notificationType, signalName(change.signal), formatSignal(change.signal, change.value),
+		formatSignal(change.signal, change.mean), change.zScore)
+	d.sendAlert(ctx, text)
+}
+
+// resolve reports that a signal is back to normal
+func (d *anomalyDetector) resolve(ctx context.Context, change anomalyChange) {
+	moduleLog.Info("Anomaly resolved", logger.Fields{"type": change.notificationType, "signal": change.signal, "value": change.value})
+	d.sendAlert(ctx, fmt.Sprintf(":white_check_mark: The %s %s is back to normal at %s.",
+		change.notificationType, signalName(change.signal), formatSignal(change.signal, change.value)))
+}
+
+// sendAlert posts a system alert to Slack when an alert channel is configured
+func (d *anomalyDetector) sendAlert(ctx context.Context, text string) {
+	if err := sendSystemAlert(ctx, d.slackService, d.config.AlertSlackChannel, text); err != nil {
+		moduleLog.Error("Failed to send anomaly alert", logger.Fields{"error": err.Error()})
+	}
+}
+
+// signalName describes a signal in alerts
+func signalName(signal string) string {
+	if signal == SignalFailureRate {
+		return "failure rate"
+	}
+	return "latency"
+}
+
+// formatSignal formats a failure rate as a percentage and a latency in seconds as a duration
+func formatSignal(signal string, value float64) string {
+	if signal == SignalFailureRate {
+		return fmt.Sprintf("%.1f%%", value*100)
+	}
+	return time.Duration(value * float64(time.Second)).Round(time.Millisecond).String()
+}
+
+// meanAndStdDev returns the mean and population standard deviation of values
+func meanAndStdDev(values []float64) (float64, float64) {
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	mean := sum / float64(len(values))
+
+	var squares float64
+	for _, value := range values {
+		squares += (value - mean) * (value - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)))
+}
@@ -0,0 +1,113 @@
+package consumers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingProcessor fails the messages whose payload is "fail"
+type failingProcessor struct{}
+
+func (p *failingProcessor) ProcessNotification(ctx context.Context, message NotificationMessage) error {
+	if message.Payload == "fail" {
+		return errors.New("provider down")
+	}
+	return nil
+}
+
+func (p *failingProcessor) GetNotificationType() NotificationType {
+	return SlackNotification
+}
+
+func TestAnomalyDetector_FailureRateSpike(t *testing.T) {
+	slackService := &recordingSlackService{}
+	detector := newAnomalyDetector(AnomalyDetectionConfig{
+		Window:            time.Minute,
+		BaselineWindows:   10,
+		ZScoreThreshold:   3,
+		MinMessages:       20,
+		AlertSlackChannel: "ops-alerts",
+	}, slackService)
+	processor := WithMiddleware(&failingProcessor{}, detector.track(SlackNotification))
+
+	// window processes 100 messages of which failed fail
+	window := func(failed int) {
+		for i := 0; i < 100; i++ {
+			payload := "ok"
+			if i < failed {
+				payload = "fail"
+			}
+			_ = processor.ProcessNotification(context.Background(), NotificationMessage{Type: SlackNotification, Payload: payload})
+		}
+		detector.evaluate(context.Background())
+	}
+
+	// A channel with 1-3% failures builds its baseline without alerting
+	for i := 0; i < 6; i++ {
+		window(1 + i%3)
+	}
+	assert.Empty(t, slackService.texts)
+
+	// A jump to 30% failures is a spike, alerted once while it lasts
+	window(30)
+	window(35)
+	assert.Equal(t, 1.0, notificationAnomaliesTotal.Value("slack", SignalFailureRate))
+	assert.Greater(t, notificationAnomalyZScore.Value("slack", SignalFailureRate), 3.0)
+
+	// Quiet windows are not scored
+	for i := 0; i < 5; i++ {
+		_ = processor.ProcessNotification(context.Background(), NotificationMessage{Type: SlackNotification, Payload: "fail"})
+	}
+	detector.evaluate(context.Background())
+
+	// The spike did not join the baseline, so going back to 2% resolves it
+	window(2)
+
+	slackService.mu.Lock()
+	defer slackService.mu.Unlock()
+	require.Len(t, slackService.texts, 2)
+	assert.Contains(t, slackService.texts[0], ":rotating_light: The slack failure rate spiked to 30.0% (baseline 2.0%, z-score")
+	assert.Equal(t, ":white_check_mark: The slack failure rate is back to normal at 2.0%.", slackService.texts[1])
+}
+
+func TestAnomalyDetector_LatencySpike(t *testing.T) {
+	slackService := &recordingSlackService{}
+	detector := newAnomalyDetector(AnomalyDetectionConfig{Window: time.Minute, MinMessages: 1, AlertSlackChannel: "ops-alerts"}, slackService)
+	// queued_at stamps are in milliseconds
+	now := time.Now().Truncate(time.Millisecond)
+	detector.now = func() time.Time { return now }
+	process := detector.track(EmailNotification)(EmailNotification, func(ctx context.Context, message NotificationMessage) error {
+		return nil
+	})
+
+	// window processes a message that waited wait in the channel
+	window := func(wait time.Duration) {
+		payload := fmt.Sprintf(`{"id":"n-1","queued_at":%d}`, now.Add(-wait).UnixMilli())
+		require.NoError(t, process(context.Background(), NotificationMessage{Type: EmailNotification, Payload: payload}))
+		detector.evaluate(context.Background())
+	}
+
+	for _, wait := range []time.Duration{200, 220, 180, 210, 190} {
+		window(wait * time.Millisecond)
+	}
+	window(5 * time.Second)
+
+	slackService.mu.Lock()
+	defer slackService.mu.Unlock()
+	require.Len(t, slackService.texts, 1)
+	assert.Contains(t, slackService.texts[0], "The email latency spiked to 5s (baseline 200ms, z-score")
+	assert.Equal(t, 1.0, notificationAnomaliesTotal.Value("email", SignalLatency))
+	assert.Zero(t, notificationAnomaliesTotal.Value("email", SignalFailureRate))
+}
+
+func TestMeanAndStdDev(t *testing.T) {
+	mean, stdDev := meanAndStdDev([]float64{2, 4, 4, 4, 5, 5, 7, 9})
+	assert.Equal(t, 5.0, mean)
+	assert.Equal(t, 2.0, stdDev)
+}
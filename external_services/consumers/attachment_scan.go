@@ -0,0 +1,188 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gaurav2721/notification-service/external_services/delivery"
+	"github.com/gaurav2721/notification-service/external_services/scanner"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// Result label values of the scan metrics
+const (
+	scanResultClean    = "clean"
+	scanResultInfected = "infected"
+	scanResultError    = "error"
+)
+
+// maxScannedLinks bounds the links scanned per email
+const maxScannedLinks = 50
+
+// scannedLinkRegex matches the http and https links in an email body
+var scannedLinkRegex = regexp.MustCompile(`https?://[^\s"'<>()\[\]]+`)
+
+var (
+	attachmentScansTotal = metrics.DefaultRegistry.NewCounterVec(
+		"attachment_scans_total",
+		"Email attachments and links scanned before sending by target kind and result (clean, infected or error). Every infected result quarantines an email.",
+		"kind", "result",
+	)
+	attachmentScanSecondsTotal = metrics.DefaultRegistry.NewCounterVec(
+		"attachment_scan_seconds_total",
+		"Time spent scanning email attachments and links by target kind.",
+		"kind",
+	)
+)
+
+// AttachmentScanConfig configures scanning of email attachments and links before sending
+type AttachmentScanConfig struct {
+	// Scanner scans the targets; nil disables scanning
+	Scanner scanner.Scanner
+
+	// ScanLinks also scans the http and https links in email bodies
+	ScanLinks bool
+
+	// FailOpen sends emails whose scan failed instead of failing their delivery
+	FailOpen bool
+}
+
+// attachmentScan quarantines emails with an infected attachment or link. Quarantined emails
+// are not sent; they are archived as delivery attempts with the quarantined status.
+type attachmentScan struct {
+	config          AttachmentScanConfig
+	deliveryService delivery.DeliveryService
+}
+
+func newAttachmentScan(config AttachmentScanConfig, deliveryService delivery.DeliveryService) *attachmentScan {
+	return &attachmentScan{
+		config:          config,
+		deliveryService: deliveryService,
+	}
+}
+
+// targets returns the attachments and links of an email to scan
+func (s *attachmentScan) targets(notification *models.EmailNotificationRequest) []scanner.Target {
+	var targets []scanner.Target
+	for _, attachment := range notification.Content.Attachments {
+		targets = append(targets, scanner.Target{
+			Kind:        scanner.TargetAttachment,
+			Name:        attachment.Filename,
+			ContentType: attachment.ContentType,
+			Content:     attachment.Content,
+		})
+	}
+
+	if s.config.ScanLinks {
+		seen := make(map[string]bool)
+		for _, link := range scannedLinkRegex.FindAllString(notification.Content.EmailBody, -1) {
+			// Punctuation after a link usually ends the sentence
+			link = strings.TrimRight(link, ".,;:!?")
+			if seen[link] || len(seen) >= maxScannedLinks {
+				continue
+			}
+			seen[link] = true
+			targets = append(targets, scanner.Target{Kind: scanner.TargetURL, Name: link})
+		}
+	}
+	return targets
+}
+
+// scan scans the targets until one is infected
+func (s *attachmentScan) scan(ctx context.Context, targets []scanner.Target) (*scanner.Target, scanner.Verdict, error) {
+	for i := range targets {
+		target := &targets[i]
+		start := time.Now()
+		verdict, err := s.config.Scanner.Scan(ctx, *target)
+		attachmentScanSecondsTotal.Add(time.Since(start).Seconds(), target.Kind)
+
+		switch {
+		case err != nil:
+			attachmentScansTotal.Inc(target.Kind, scanResultError)
+			return target, verdict, err
+		case verdict.Infected:
+			attachmentScansTotal.Inc(target.Kind, scanResultInfected)
+			return target, verdict, nil
+		}
+		attachmentScansTotal.Inc(target.Kind, scanResultClean)
+	}
+	return nil, scanner.Verdict{}, nil
+}
+
+// quarantine archives an email that is held back
+func (s *attachmentScan) quarantine(notification *models.EmailNotificationRequest, target *scanner.Target, verdict scanner.Verdict) {
+	threat := verdict.Threat
+	if threat == "" {
+		threat = "threat"
+	}
+	reason := fmt.Sprintf("quarantined: %s found in %s %s", threat, target.Kind, target.Name)
+	moduleLog.Warn("Email quarantined", logger.Fields{
+		"notification_id": notification.ID,
+		"user_id":         notification.UserID,
+		"reason":          reason,
+	})
+
+	if s.deliveryService == nil {
+		return
+	}
+	err := s.deliveryService.RecordAttempt(&models.DeliveryAttempt{
+		NotificationID: notification.ID,
+		UserID:         notification.UserID,
+		Recipient:      notification.Recipient,
+		Channel:        string(EmailNotification),
+		Status:         models.DeliveryStatusQuarantined,
+		Error:          reason,
+		AttemptedAt:    time.Now(),
+	})
+	if err != nil {
+		moduleLog.Warn("Failed to archive quarantined email", logger.Fields{
+			"notification_id": notification.ID,
+			"error":           err.Error(),
+		})
+	}
+}
+
+// middleware scans the attachments and links of emails, sending clean emails and
+// quarantining infected ones
+func (s *attachmentScan) middleware() ProcessorMiddleware {
+	return func(notificationType NotificationType, next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, message NotificationMessage) error {
+			var notification models.EmailNotificationRequest
+			if err := json.Unmarshal([]byte(message.Payload), &notification); err != nil {
+				// Malformed payloads are reported by the processor
+				return next(ctx, message)
+			}
+			if notification.ID == "" {
+				notification.ID = message.ID
+			}
+
+			targets := s.targets(&notification)
+			if len(targets) == 0 {
+				return next(ctx, message)
+			}
+
+			target, verdict, err := s.scan(ctx, targets)
+			if err != nil {
+				if s.config.FailOpen {
+					sampledLog.Warn("Sending email whose scan failed", logger.Fields{
+						"notification_id": notification.ID,
+						"error":           err.Error(),
+					})
+					return next(ctx, message)
+				}
+				return fmt.Errorf("failed to scan %s %s: %w", target.Kind, target.Name, err)
+			}
+			if verdict.Infected {
+				s.quarantine(&notification, target, verdict)
+				return nil
+			}
+			return next(ctx, message)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package consumers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/external_services/delivery"
+	"github.com/gaurav2721/notification-service/external_services/scanner"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeScanner reports targets whose name is listed in infected, and fails when err is set
+type fakeScanner struct {
+	infected map[string]string
+	err      error
+	scanned  []scanner.Target
+}
+
+func (s *fakeScanner) Scan(ctx context.Context, target scanner.Target) (scanner.Verdict, error) {
+	s.scanned = append(s.scanned, target)
+	if s.err != nil {
+		return scanner.Verdict{}, s.err
+	}
+	if threat, ok := s.infected[target.Name]; ok {
+		return scanner.Verdict{Infected: true, Threat: threat}, nil
+	}
+	return scanner.Verdict{}, nil
+}
+
+func TestAttachmentScan_QuarantinesInfectedEmails(t *testing.T) {
+	fake := &fakeScanner{infected: map[string]string{"invoice.exe": "Eicar-Test-Signature"}}
+	deliveryService := delivery.NewDeliveryService()
+	scan := newAttachmentScan(AttachmentScanConfig{Scanner: fake, ScanLinks: true}, deliveryService)
+
+	var sent []string
+	process := scan.middleware()(EmailNotification, func(ctx context.Context, message NotificationMessage) error {
+		sent = append(sent, message.ID)
+		return nil
+	})
+
+	// "aGVsbG8=" is "hello"
+	clean := `{"id":"n-1","recipient":"a@example.com","content":{"subject":"Hi","email_body":"See https://example.com/report and https://example.com/report.",` +
+		`"attachments":[{"filename":"report.pdf","content":"aGVsbG8="}]}}`
+	infected := `{"id":"n-2","user_id":"user-2","recipient":"b@example.com","content":{"subject":"Hi","email_body":"Invoice attached",` +
+		`"attachments":[{"filename":"invoice.exe","content":"aGVsbG8="}]}}`
+
+	require.NoError(t, process(context.Background(), NotificationMessage{ID: "n-1", Payload: clean}))
+	require.NoError(t, process(context.Background(), NotificationMessage{ID: "n-2", Payload: infected}))
+
+	assert.Equal(t, []string{"n-1"}, sent)
+	require.Len(t, fake.scanned, 3, "the attachment and the link of the first email, the attachment of the second")
+	assert.Equal(t, scanner.Target{Kind: scanner.TargetAttachment, Name: "report.pdf", Content: []byte("hello")}, fake.scanned[0])
+	assert.Equal(t, scanner.Target{Kind: scanner.TargetURL, Name: "https://example.com/report"}, fake.scanned[1])
+	assert.Equal(t, 1.0, attachmentScansTotal.Value(scanner.TargetAttachment, scanResultInfected))
+
+	attempts, err := deliveryService.GetAttempts("n-2", "user-2")
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, models.DeliveryStatusQuarantined, attempts[0].Status)
+	assert.Equal(t, "quarantined: Eicar-Test-Signature found in attachment invoice.exe", attempts[0].Error)
+	assert.Equal(t, 1, deliveryService.GetStats("n-2").Quarantined)
+}
+
+func TestAttachmentScan_ScanFailures(t *testing.T) {
+	fake := &fakeScanner{err: errors.New("connection refused")}
+	payload := `{"id":"n-1","recipient":"a@example.com","content":{"subject":"Hi","email_body":"Body",` +
+		`"attachments":[{"filename":"report.pdf","content":"aGVsbG8="}]}}`
+
+	for _, failOpen := range []bool{false, true} {
+		scan := newAttachmentScan(AttachmentScanConfig{Scanner: fake, FailOpen: failOpen}, nil)
+		sent := 0
+		process := scan.middleware()(EmailNotification, func(ctx context.Context, message NotificationMessage) error {
+			sent++
+			return nil
+		})
+
+		err := process(context.Background(), NotificationMessage{ID: "n-1", Payload: payload})
+		if failOpen {
+			assert.NoError(t, err)
+			assert.Equal(t, 1, sent, "fail open sends the email")
+		} else {
+			assert.Error(t, err)
+			assert.Equal(t, 0, sent, "fail closed fails the delivery")
+		}
+	}
+
+	// Emails without attachments are not scanned unless links are
+	fake.scanned = nil
+	scan := newAttachmentScan(AttachmentScanConfig{Scanner: fake}, nil)
+	process := scan.middleware()(EmailNotification, func(ctx context.Context, message NotificationMessage) error { return nil })
+	require.NoError(t, process(context.Background(), NotificationMessage{ID: "n-2", Payload: `{"id":"n-2","content":{"email_body":"https://example.com"}}`}))
+	assert.Empty(t, fake.scanned)
+}
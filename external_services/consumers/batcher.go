@@ -0,0 +1,111 @@
+package consumers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BatchingConfig configures how messages that may be sent together are coalesced into batched
+// provider calls
+type BatchingConfig struct {
+	// MaxWait is how long a batch of scheduled messages waits for more messages before it is sent;
+	// zero disables batching of scheduled messages
+	MaxWait time.Duration
+
+	// MulticastWait is how long an Android push waits for the other pushes of its notification to
+	// be sent in one FCM multicast request; zero sends pushes that are not scheduled one by one
+	MulticastWait time.Duration
+}
+
+// batchSendFunc sends a batch of items in one provider call and returns a response and an error
+// for each item, in the order of the items
+type batchSendFunc func(ctx context.Context, items []interface{}) ([]interface{}, []error)
+
+// batchResult is the outcome of one item of a sent batch
+type batchResult struct {
+	response interface{}
+	err      error
+}
+
+// pendingBatch collects the items of one batch key until the batch is sent
+type pendingBatch struct {
+	ctx     context.Context
+	items   []interface{}
+	results []chan batchResult
+	timer   *time.Timer
+}
+
+// messageBatcher coalesces items submitted with the same batch key by concurrent workers into
+// batches of at most maxSize items. A batch is sent when it is full or maxWait after its first
+// item was submitted, whichever comes first.
+type messageBatcher struct {
+	maxSize int
+	maxWait time.Duration
+	send    batchSendFunc
+
+	mu      sync.Mutex
+	batches map[string]*pendingBatch
+}
+
+// newMessageBatcher creates a batcher sending batches of up to maxSize items with send
+func newMessageBatcher(maxSize int, maxWait time.Duration, send batchSendFunc) *messageBatcher {
+	return &messageBatcher{
+		maxSize: maxSize,
+		maxWait: maxWait,
+		send:    send,
+		batches: make(map[string]*pendingBatch),
+	}
+}
+
+// Submit adds the item to the pending batch of its key and waits until the batch is sent,
+// returning the item's response and error. Once submitted an item is always sent, so Submit
+// does not return early when ctx is cancelled.
+func (b *messageBatcher) Submit(ctx context.Context, key string, item interface{}) (interface{}, error) {
+	result := make(chan batchResult, 1)
+
+	b.mu.Lock()
+	batch, ok := b.batches[key]
+	if !ok {
+		batch = &pendingBatch{ctx: context.WithoutCancel(ctx)}
+		b.batches[key] = batch
+		batch.timer = time.AfterFunc(b.maxWait, func() {
+			b.flush(key, batch)
+		})
+	}
+	batch.items = append(batch.items, item)
+	batch.results = append(batch.results, result)
+	full := len(batch.items) >= b.maxSize
+	b.mu.Unlock()
+
+	if full {
+		b.flush(key, batch)
+	}
+
+	r := <-result
+	return r.response, r.err
+}
+
+// flush sends the batch unless it was already sent
+func (b *messageBatcher) flush(key string, batch *pendingBatch) {
+	b.mu.Lock()
+	if b.batches[key] != batch {
+		b.mu.Unlock()
+		return
+	}
+	delete(b.batches, key)
+	batch.timer.Stop()
+	b.mu.Unlock()
+
+	responses, errs := b.send(batch.ctx, batch.items)
+	for i, result := range batch.results {
+		var r batchResult
+		if i < len(responses) {
+			r.response = responses[i]
+		}
+		if i < len(errs) {
+			r.err = errs[i]
+		}
+		result <- r
+	}
+}
@@ -0,0 +1,113 @@
+package consumers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingBatchSend records the batches it sends and fails items named "bad"
+type recordingBatchSend struct {
+	mu      sync.Mutex
+	batches [][]interface{}
+}
+
+func (r *recordingBatchSend) send(ctx context.Context, items []interface{}) ([]interface{}, []error) {
+	r.mu.Lock()
+	r.batches = append(r.batches, items)
+	r.mu.Unlock()
+
+	responses := make([]interface{}, len(items))
+	errs := make([]error, len(items))
+	for i, item := range items {
+		if item == "bad" {
+			errs[i] = errors.New("rejected")
+			continue
+		}
+		responses[i] = fmt.Sprintf("sent %v", item)
+	}
+	return responses, errs
+}
+
+func TestMessageBatcher_SendsFullBatchesAtOnce(t *testing.T) {
+	recorder := &recordingBatchSend{}
+	// The wait is long enough that only full batches are sent during the test
+	batcher := newMessageBatcher(3, time.Hour, recorder.send)
+
+	var wg sync.WaitGroup
+	responses := make([]interface{}, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			response, err := batcher.Submit(context.Background(), "window", i)
+			assert.NoError(t, err)
+			responses[i] = response
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Len(t, recorder.batches, 1)
+	assert.ElementsMatch(t, []interface{}{0, 1, 2}, recorder.batches[0])
+	assert.Equal(t, []interface{}{"sent 0", "sent 1", "sent 2"}, responses)
+}
+
+func TestMessageBatcher_SendsPartialBatchAfterMaxWait(t *testing.T) {
+	recorder := &recordingBatchSend{}
+	batcher := newMessageBatcher(10, 20*time.Millisecond, recorder.send)
+
+	var wg sync.WaitGroup
+	errs := make(map[interface{}]error)
+	var mu sync.Mutex
+	for _, item := range []interface{}{"good", "bad"} {
+		wg.Add(1)
+		go func(item interface{}) {
+			defer wg.Done()
+			_, err := batcher.Submit(context.Background(), "window", item)
+			mu.Lock()
+			errs[item] = err
+			mu.Unlock()
+		}(item)
+	}
+	wg.Wait()
+
+	assert.Len(t, recorder.batches, 1)
+	assert.NoError(t, errs["good"])
+	assert.EqualError(t, errs["bad"], "rejected")
+}
+
+func TestMessageBatcher_KeepsKeysApart(t *testing.T) {
+	recorder := &recordingBatchSend{}
+	batcher := newMessageBatcher(10, 20*time.Millisecond, recorder.send)
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"first", "second"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			_, err := batcher.Submit(context.Background(), key, key)
+			assert.NoError(t, err)
+		}(key)
+	}
+	wg.Wait()
+
+	assert.Len(t, recorder.batches, 2)
+	assert.Empty(t, batcher.batches)
+}
+
+func TestMessageBatcher_SendsAfterContextIsCancelled(t *testing.T) {
+	recorder := &recordingBatchSend{}
+	batcher := newMessageBatcher(10, 20*time.Millisecond, recorder.send)
+
+	// The item is sent with the batch even though its worker is stopping
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	response, err := batcher.Submit(ctx, "window", "item")
+	assert.NoError(t, err)
+	assert.Equal(t, "sent item", response)
+}
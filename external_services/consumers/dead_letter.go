@@ -0,0 +1,178 @@
+package consumers
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/google/uuid"
+)
+
+// DefaultDeadLetterCapacity is how many dead letters the default queue keeps; the oldest ones
+// are dropped to make room for new ones
+const DefaultDeadLetterCapacity = 1000
+
+var (
+	// ErrDeadLetterNotFound is returned for dead letters that are not, or no longer, in the queue
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+	// ErrDeadLetterChannelFull is returned when a dead letter cannot be replayed because the
+	// channel of its notification type is full
+	ErrDeadLetterChannelFull = errors.New("notification channel is full, try again later")
+
+	// ErrDeadLetterNotReplayable is returned for dead letters read from a channel messages cannot
+	// be published to
+	ErrDeadLetterNotReplayable = errors.New("dead letter cannot be replayed")
+)
+
+var (
+	consumerDeadLetters = metrics.DefaultRegistry.NewGaugeVec(
+		"consumer_dead_letters",
+		"Messages in the dead-letter queue by notification type.",
+		"type",
+	)
+	consumerMessagesDeadLetteredTotal = metrics.DefaultRegistry.NewCounterVec(
+		"consumer_messages_dead_lettered_total",
+		"Messages moved to the dead-letter queue after their last failed attempt, by notification type.",
+		"type",
+	)
+)
+
+// DefaultDeadLetterQueue receives the messages of every channel whose retries are exhausted;
+// the admin API lists, replays and discards them
+var DefaultDeadLetterQueue = NewDeadLetterQueue(DefaultDeadLetterCapacity)
+
+// DeadLetter is a message that could not be processed within its channel's attempts
+type DeadLetter struct {
+	ID             string           `json:"id"`
+	Type           NotificationType `json:"type"`
+	Message        string           `json:"message"`
+	Error          string           `json:"error"`
+	Attempts       int              `json:"attempts"`
+	FirstFailedAt  time.Time        `json:"first_failed_at"`
+	DeadLetteredAt time.Time        `json:"dead_lettered_at"`
+
+	// channel is the channel the message was read from and is replayed to
+	channel chan string
+}
+
+// DeadLetterQueue keeps the messages whose retries are exhausted until they are replayed or
+// discarded. It is bounded; once full, the oldest dead letter is dropped for every new one.
+type DeadLetterQueue struct {
+	mu       sync.Mutex
+	capacity int
+	letters  []*DeadLetter // oldest first
+}
+
+// NewDeadLetterQueue creates a dead-letter queue keeping up to capacity messages
+func NewDeadLetterQueue(capacity int) *DeadLetterQueue {
+	if capacity <= 0 {
+		capacity = DefaultDeadLetterCapacity
+	}
+	return &DeadLetterQueue{capacity: capacity}
+}
+
+// add appends a message to the queue, dropping the oldest dead letter if the queue is full
+func (q *DeadLetterQueue) add(letter *DeadLetter) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	letter.ID = uuid.New().String()
+	if len(q.letters) >= q.capacity {
+		dropped := q.letters[0]
+		q.letters = q.letters[1:]
+		q.updateGauge(dropped.Type)
+	}
+	q.letters = append(q.letters, letter)
+	q.updateGauge(letter.Type)
+	consumerMessagesDeadLetteredTotal.Inc(string(letter.Type))
+}
+
+// List returns the dead letters of a notification type, or of every type when it is empty,
+// newest first
+func (q *DeadLetterQueue) List(notificationType NotificationType) []DeadLetter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	letters := make([]DeadLetter, 0, len(q.letters))
+	for i := len(q.letters) - 1; i >= 0; i-- {
+		if notificationType == "" || q.letters[i].Type == notificationType {
+			letters = append(letters, *q.letters[i])
+		}
+	}
+	return letters
+}
+
+// Len returns the number of dead letters in the queue
+func (q *DeadLetterQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.letters)
+}
+
+// Replay publishes a dead letter to its channel again, with a fresh set of attempts, and removes
+// it from the queue. It does not wait for room in the channel.
+func (q *DeadLetterQueue) Replay(id string) (DeadLetter, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	index := q.find(id)
+	if index < 0 {
+		return DeadLetter{}, ErrDeadLetterNotFound
+	}
+	letter := q.letters[index]
+	if letter.channel == nil {
+		return DeadLetter{}, ErrDeadLetterNotReplayable
+	}
+
+	select {
+	case letter.channel <- letter.Message:
+	default:
+		return DeadLetter{}, ErrDeadLetterChannelFull
+	}
+	q.remove(index)
+	return *letter, nil
+}
+
+// Discard removes a dead letter from the queue without replaying it
+func (q *DeadLetterQueue) Discard(id string) (DeadLetter, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	index := q.find(id)
+	if index < 0 {
+		return DeadLetter{}, ErrDeadLetterNotFound
+	}
+	letter := q.letters[index]
+	q.remove(index)
+	return *letter, nil
+}
+
+// find returns the index of the dead letter with the given ID, or -1
+func (q *DeadLetterQueue) find(id string) int {
+	for i, letter := range q.letters {
+		if letter.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// remove deletes the dead letter at index
+func (q *DeadLetterQueue) remove(index int) {
+	letter := q.letters[index]
+	q.letters = append(q.letters[:index], q.letters[index+1:]...)
+	q.updateGauge(letter.Type)
+}
+
+// updateGauge sets the dead letters metric of a notification type
+func (q *DeadLetterQueue) updateGauge(notificationType NotificationType) {
+	count := 0
+	for _, letter := range q.letters {
+		if letter.Type == notificationType {
+			count++
+		}
+	}
+	consumerDeadLetters.Set(float64(count), string(notificationType))
+}
@@ -0,0 +1,102 @@
+package consumers
+
+import (
+	"time"
+
+	"github.com/gaurav2721/notification-service/bufferpool"
+	"github.com/gaurav2721/notification-service/external_services/delivery"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// notificationDeliveryLatencySeconds is the time from posting messages to their channel to the
+// provider's acknowledgment, for monitoring provider SLAs
+var notificationDeliveryLatencySeconds = metrics.DefaultRegistry.NewHistogramVec(
+	"notification_delivery_latency_seconds",
+	"Time from enqueueing a message to its acknowledgment by the provider, by channel and provider.",
+	metrics.LatencyBuckets,
+	"channel", "provider",
+)
+
+// queuedTime returns the time of a queued_at stamp in Unix milliseconds, or nil for messages
+// that were not stamped
+func queuedTime(queuedAtMs int64) *time.Time {
+	if queuedAtMs <= 0 {
+		return nil
+	}
+	queuedAt := time.UnixMilli(queuedAtMs)
+	return &queuedAt
+}
+
+// recordDeliveryAttempt measures the latency of messages the provider acknowledged and archives
+// the outcome of a provider call when a delivery service is configured
+func recordDeliveryAttempt(
+	deliveryService delivery.DeliveryService,
+	attempt *models.DeliveryAttempt,
+	response interface{},
+	sendErr error,
+	startedAt time.Time,
+) {
+	now := time.Now()
+	attempt.AttemptedAt = startedAt
+	attempt.DurationMs = now.Sub(startedAt).Milliseconds()
+
+	if sendErr != nil {
+		attempt.Status = models.DeliveryStatusFailed
+		attempt.Error = sendErr.Error()
+	} else {
+		attempt.Status = models.DeliveryStatusSent
+	}
+
+	switch resp := response.(type) {
+	case *models.APNSResponse:
+		attempt.StatusCode = resp.StatusCode
+		attempt.ProviderMessageID = resp.APNSID
+		attempt.ProviderReason = resp.Reason
+		if resp.FailureCount > 0 {
+			attempt.Status = models.DeliveryStatusFailed
+		}
+	case *models.RCSResponse:
+		// Recipients without RCS are sent an SMS, which is told apart by the provider
+		attempt.ProviderMessageID = resp.MessageID
+		if resp.DeliveredAs == models.RCSDeliveredAsSMS {
+			attempt.Provider = models.RCSDeliveredAsSMS
+		}
+	case *models.FCMResponse:
+		attempt.StatusCode = resp.StatusCode
+		if resp.FailureCount > 0 {
+			attempt.Status = models.DeliveryStatusFailed
+		}
+	}
+
+	if attempt.QueuedAt != nil {
+		// Clocks of other hosts may run ahead; a message is never acknowledged before it was queued
+		latency := now.Sub(*attempt.QueuedAt)
+		if latency < 0 {
+			latency = 0
+		}
+		attempt.LatencyMs = latency.Milliseconds()
+		if attempt.Status == models.DeliveryStatusSent {
+			notificationDeliveryLatencySeconds.Observe(latency.Seconds(), attempt.Channel, attempt.Provider)
+		}
+	}
+
+	if deliveryService == nil {
+		return
+	}
+
+	if response != nil {
+		if raw, err := bufferpool.MarshalToString(response); err == nil {
+			attempt.ProviderResponse = raw
+		}
+	}
+
+	if err := deliveryService.RecordAttempt(attempt); err != nil {
+		moduleLog.Warn("Failed to archive delivery attempt", logger.Fields{
+			"notification_id": attempt.NotificationID,
+			"channel":         attempt.Channel,
+			"error":           err.Error(),
+		})
+	}
+}
@@ -4,15 +4,21 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
+	"github.com/gaurav2721/notification-service/external_services/concurrency"
+	"github.com/gaurav2721/notification-service/external_services/delivery"
 	"github.com/gaurav2721/notification-service/external_services/email"
+	"github.com/gaurav2721/notification-service/logger"
 	"github.com/gaurav2721/notification-service/models"
-	"github.com/sirupsen/logrus"
 )
 
 // emailProcessor handles email notification processing
 type emailProcessor struct {
-	emailService email.EmailService
+	emailService    email.EmailService
+	deliveryService delivery.DeliveryService
+	// batcher coalesces emails with a batch key into batched sends, if the email service batches
+	batcher *messageBatcher
 }
 
 // NewEmailProcessor creates a new email processor
@@ -32,25 +38,59 @@ func NewEmailProcessorWithService(emailService email.EmailService) NotificationP
 	}
 }
 
+// NewEmailProcessorWithServices creates a new email processor that also archives delivery attempts
+func NewEmailProcessorWithServices(emailService email.EmailService, deliveryService delivery.DeliveryService) NotificationProcessor {
+	return &emailProcessor{
+		emailService:    emailService,
+		deliveryService: deliveryService,
+	}
+}
+
+// withBatching coalesces emails with a batch key into batches as large as the email service
+// accepts, unless batching is disabled or the email service cannot batch
+func (ep *emailProcessor) withBatching(config BatchingConfig) *emailProcessor {
+	size := email.MaxBatchSize(ep.emailService)
+	if config.MaxWait <= 0 || size <= 1 {
+		return ep
+	}
+	ep.batcher = newMessageBatcher(size, config.MaxWait, func(ctx context.Context, items []interface{}) ([]interface{}, []error) {
+		notifications := make([]*models.EmailNotificationRequest, len(items))
+		for i, item := range items {
+			notifications[i] = item.(*models.EmailNotificationRequest)
+		}
+		sampledLog.Info("Sending email batch", logger.Fields{"size": len(notifications)})
+		return email.SendBatch(ctx, ep.emailService, notifications)
+	})
+	return ep
+}
+
+// send sends the email, as part of a batch if it has a batch key
+func (ep *emailProcessor) send(ctx context.Context, notification *models.EmailNotificationRequest) (interface{}, error) {
+	if ep.batcher != nil && notification.BatchKey != "" {
+		return ep.batcher.Submit(ctx, notification.BatchKey, notification)
+	}
+	return ep.emailService.SendEmail(ctx, notification)
+}
+
 // ProcessNotification processes an email notification
 func (ep *emailProcessor) ProcessNotification(ctx context.Context, message NotificationMessage) error {
-	logrus.WithFields(logrus.Fields{
+	sampledLog.Debug("Processing email notification", logger.Fields{
 		"notification_id": message.ID,
 		"type":            message.Type,
 		"payload":         message.Payload,
 		"timestamp":       message.Timestamp,
-	}).Debug("Processing email notification")
+	})
 
 	// Parse the payload directly into EmailNotificationRequest
 	var emailNotification models.EmailNotificationRequest
 	if err := json.Unmarshal([]byte(message.Payload), &emailNotification); err != nil {
-		logrus.WithError(err).Error("Failed to parse notification payload into EmailNotificationRequest")
+		moduleLog.Error("Failed to parse notification payload into EmailNotificationRequest", logger.Fields{"error": err.Error()})
 		return fmt.Errorf("failed to parse notification payload into EmailNotificationRequest: %w", err)
 	}
 
 	// Validate the parsed notification
 	if emailNotification.Recipient == "" {
-		logrus.Error("No recipient specified in email notification")
+		moduleLog.Error("No recipient specified in email notification", nil)
 		return fmt.Errorf("no recipient specified in email notification")
 	}
 
@@ -72,32 +112,41 @@ func (ep *emailProcessor) ProcessNotification(ctx context.Context, message Notif
 		fromEmail = emailNotification.From.Email
 	}
 
-	logrus.WithFields(logrus.Fields{
+	sampledLog.Info("Sending email notification", logger.Fields{
 		"notification_id": message.ID,
 		"to":              recipient,
 		"from":            fromEmail,
 		"subject":         emailNotification.Content.Subject,
-	}).Info("Sending email notification")
+	})
 
 	// Send email using the email service
-	response, err := ep.emailService.SendEmail(ctx, &emailNotification)
+	startedAt := time.Now()
+	response, err := ep.send(ctx, &emailNotification)
+	recordDeliveryAttempt(ep.deliveryService, &models.DeliveryAttempt{
+		NotificationID: emailNotification.ID,
+		UserID:         emailNotification.UserID,
+		Recipient:      emailNotification.Recipient,
+		Channel:        string(EmailNotification),
+		Provider:       concurrency.ProviderEmail,
+		QueuedAt:       queuedTime(emailNotification.QueuedAt),
+	}, response, err, startedAt)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{
+		moduleLog.Error("Failed to send email notification", logger.Fields{
 			"notification_id": message.ID,
 			"error":           err.Error(),
-		}).Error("Failed to send email notification")
+		})
 		return fmt.Errorf("failed to send email: %w", err)
 	}
 
 	// Log successful email sending
 	if emailResponse, ok := response.(*models.EmailResponse); ok {
-		logrus.WithFields(logrus.Fields{
+		sampledLog.Info("Email notification sent successfully", logger.Fields{
 			"notification_id": message.ID,
 			"status":          emailResponse.Status,
 			"sent_at":         emailResponse.SentAt,
-		}).Info("Email notification sent successfully")
+		})
 	} else {
-		logrus.WithField("notification_id", message.ID).Info("Email notification sent successfully")
+		sampledLog.Info("Email notification sent successfully", logger.Fields{"notification_id": message.ID})
 	}
 
 	return nil
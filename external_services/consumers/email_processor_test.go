@@ -3,9 +3,12 @@ package consumers
 import (
 	"context"
 	"encoding/json"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/gaurav2721/notification-service/external_services/concurrency"
+	"github.com/gaurav2721/notification-service/external_services/delivery"
 	"github.com/gaurav2721/notification-service/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -169,7 +172,92 @@ func TestEmailProcessor_WithCustomEmailService(t *testing.T) {
 	assert.True(t, mockEmailService.sendEmailCalled)
 }
 
+func TestEmailProcessor_BatchesEmailsWithBatchKey(t *testing.T) {
+	batchService := &mockBatchEmailService{}
+	processor := (&emailProcessor{emailService: batchService}).withBatching(BatchingConfig{MaxWait: time.Hour})
+	require.NotNil(t, processor.batcher)
+
+	message := func(id, batchKey string) NotificationMessage {
+		payload, err := json.Marshal(models.EmailNotificationRequest{
+			ID:        id,
+			Content:   models.EmailContent{Subject: "Subject", EmailBody: "Body"},
+			Recipient: id + "@example.com",
+			BatchKey:  batchKey,
+		})
+		require.NoError(t, err)
+		return NotificationMessage{Type: EmailNotification, Payload: string(payload), ID: id}
+	}
+
+	// Emails without a batch key are sent on their own
+	require.NoError(t, processor.ProcessNotification(context.Background(), message("single", "")))
+	assert.True(t, batchService.sendEmailCalled)
+
+	// Two emails fill a batch, which is sent in one call
+	var wg sync.WaitGroup
+	for _, id := range []string{"first", "second"} {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			assert.NoError(t, processor.ProcessNotification(context.Background(), message(id, "scheduled:2024-01-01T09:00:00Z")))
+		}(id)
+	}
+	wg.Wait()
+	assert.Equal(t, []int{2}, batchService.batchSizes)
+}
+
+func TestEmailProcessor_WithBatching_DisabledWithoutBatchSupport(t *testing.T) {
+	processor := (&emailProcessor{emailService: &mockEmailService{}}).withBatching(BatchingConfig{MaxWait: time.Second})
+	assert.Nil(t, processor.batcher)
+
+	processor = (&emailProcessor{emailService: &mockBatchEmailService{}}).withBatching(BatchingConfig{})
+	assert.Nil(t, processor.batcher)
+}
+
+// mockBatchEmailService is a mock email service sending batches of up to two emails
+type mockBatchEmailService struct {
+	mockEmailService
+	mu         sync.Mutex
+	batchSizes []int
+}
+
+func (m *mockBatchEmailService) MaxBatchSize() int {
+	return 2
+}
+
+func (m *mockBatchEmailService) SendEmailBatch(ctx context.Context, notifications []*models.EmailNotificationRequest) ([]interface{}, []error) {
+	m.mu.Lock()
+	m.batchSizes = append(m.batchSizes, len(notifications))
+	m.mu.Unlock()
+	return make([]interface{}, len(notifications)), make([]error, len(notifications))
+}
+
 // mockEmailService is a mock implementation for testing
+func TestEmailProcessor_RecordsDeliveryLatency(t *testing.T) {
+	deliveryService := delivery.NewDeliveryService()
+	processor := NewEmailProcessorWithServices(&mockEmailService{}, deliveryService)
+
+	queuedAt := time.Now().Add(-1500 * time.Millisecond)
+	before := notificationDeliveryLatencySeconds.Snapshot(string(EmailNotification), concurrency.ProviderEmail).Count()
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":        "latency-1",
+		"recipient": "test@example.com",
+		"content":   map[string]interface{}{"subject": "Hi", "email_body": "Body"},
+		"queued_at": queuedAt.UnixMilli(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, processor.ProcessNotification(context.Background(), NotificationMessage{Type: EmailNotification, Payload: string(payload), ID: "latency-1"}))
+
+	attempts, err := deliveryService.GetAttempts("latency-1", "test@example.com")
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, concurrency.ProviderEmail, attempts[0].Provider)
+	require.NotNil(t, attempts[0].QueuedAt)
+	assert.Equal(t, queuedAt.UnixMilli(), attempts[0].QueuedAt.UnixMilli())
+	assert.GreaterOrEqual(t, attempts[0].LatencyMs, int64(1500))
+	assert.Equal(t, before+1, notificationDeliveryLatencySeconds.Snapshot(string(EmailNotification), concurrency.ProviderEmail).Count())
+	assert.Equal(t, uint64(1), deliveryService.GetLatency("latency-1")[delivery.LatencyKey{Channel: "email", Provider: "email"}].Count())
+}
+
 type mockEmailService struct {
 	sendEmailCalled bool
 }
@@ -0,0 +1,224 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// warmupDateLayout is the layout of the start date of a warm-up schedule
+const warmupDateLayout = "2006-01-02"
+
+var emailWarmupDeferredTotal = metrics.DefaultRegistry.NewCounterVec(
+	"email_warmup_deferred_total",
+	"Emails held back to the next day because their sending domain reached its warm-up limit.",
+	"domain",
+)
+
+// WarmupSchedule ramps up the daily email volume of a new sending domain, so mailbox providers
+// build trust in it before it sends at full rate
+type WarmupSchedule struct {
+	// Domain is the sending domain, the part of the from address after the @
+	Domain string
+
+	// Start is the first day of the ramp. Days are counted in UTC.
+	Start time.Time
+
+	// DailyLimit is the number of emails the domain may send per day in the first week
+	DailyLimit int
+
+	// WeeklyGrowth is the factor the daily limit grows by every week, e.g. 2 doubles it
+	WeeklyGrowth float64
+
+	// MaxDailyLimit ends the ramp once the daily limit reaches it. Zero ramps indefinitely.
+	MaxDailyLimit int
+}
+
+// LimitOn returns the number of emails the domain may send on the day of t. ok is false once
+// the ramp has ended and the domain is no longer limited.
+func (s WarmupSchedule) LimitOn(t time.Time) (limit int, ok bool) {
+	weeks := 0
+	if days := int(t.UTC().Sub(s.Start.UTC()).Hours() / 24); days > 0 {
+		weeks = days / 7
+	}
+
+	growth := math.Max(s.WeeklyGrowth, 1)
+	scaled := float64(s.DailyLimit) * math.Pow(growth, float64(weeks))
+	if s.MaxDailyLimit > 0 && scaled >= float64(s.MaxDailyLimit) {
+		return 0, false
+	}
+	if scaled >= math.MaxInt32 {
+		return 0, false
+	}
+	return int(scaled), true
+}
+
+// ParseWarmupSchedules parses comma separated schedules of the form
+// domain:start:daily_limit:weekly_growth[:max_daily_limit], for example
+// news.example.com:2024-06-03:100:2:50000
+func ParseWarmupSchedules(value string) ([]WarmupSchedule, error) {
+	var schedules []WarmupSchedule
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) != 4 && len(parts) != 5 {
+			return nil, fmt.Errorf("invalid warm-up schedule %q: expected domain:start:daily_limit:weekly_growth[:max_daily_limit]", entry)
+		}
+
+		schedule := WarmupSchedule{Domain: strings.ToLower(strings.TrimSpace(parts[0]))}
+		if schedule.Domain == "" {
+			return nil, fmt.Errorf("invalid warm-up schedule %q: domain is required", entry)
+		}
+
+		start, err := time.Parse(warmupDateLayout, parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid warm-up schedule %q: start must be a date like 2024-06-03", entry)
+		}
+		schedule.Start = start
+
+		if schedule.DailyLimit, err = strconv.Atoi(parts[2]); err != nil || schedule.DailyLimit <= 0 {
+			return nil, fmt.Errorf("invalid warm-up schedule %q: daily limit must be a positive integer", entry)
+		}
+		if schedule.WeeklyGrowth, err = strconv.ParseFloat(parts[3], 64); err != nil || schedule.WeeklyGrowth < 1 {
+			return nil, fmt.Errorf("invalid warm-up schedule %q: weekly growth must be a number of at least 1", entry)
+		}
+		if len(parts) == 5 {
+			if schedule.MaxDailyLimit, err = strconv.Atoi(parts[4]); err != nil || schedule.MaxDailyLimit < 0 {
+				return nil, fmt.Errorf("invalid warm-up schedule %q: max daily limit must be a positive integer", entry)
+			}
+		}
+
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+// EmailWarmupConfig configures the send-rate ramps of new sending domains
+type EmailWarmupConfig struct {
+	// Schedules limits the daily volume of the listed domains; other domains are not limited
+	Schedules []WarmupSchedule
+
+	// DefaultDomain is the sending domain of emails without a from address
+	DefaultDomain string
+}
+
+// emailWarmup holds back emails of warming up domains that reached their daily limit and posts
+// them to the email channel again when the next day starts
+type emailWarmup struct {
+	schedules     map[string]WarmupSchedule
+	defaultDomain string
+	requeue       chan string
+	now           func() time.Time
+	after         func(d time.Duration, f func())
+
+	mu   sync.Mutex
+	day  string         // UTC date the counts are for
+	sent map[string]int // emails sent today by domain
+}
+
+func newEmailWarmup(config EmailWarmupConfig, requeue chan string) *emailWarmup {
+	schedules := make(map[string]WarmupSchedule, len(config.Schedules))
+	for _, schedule := range config.Schedules {
+		schedules[strings.ToLower(schedule.Domain)] = schedule
+	}
+	return &emailWarmup{
+		schedules:     schedules,
+		defaultDomain: strings.ToLower(config.DefaultDomain),
+		requeue:       requeue,
+		now:           time.Now,
+		after:         func(d time.Duration, f func()) { time.AfterFunc(d, f) },
+		sent:          make(map[string]int),
+	}
+}
+
+// allow counts an email of domain against today's limit, returning false when none is left
+func (w *emailWarmup) allow(domain string, now time.Time) bool {
+	schedule, ok := w.schedules[domain]
+	if !ok {
+		return true
+	}
+	limit, limited := schedule.LimitOn(now)
+	if !limited {
+		return true
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if day := now.UTC().Format(warmupDateLayout); day != w.day {
+		w.day = day
+		w.sent = make(map[string]int)
+	}
+	if w.sent[domain] >= limit {
+		return false
+	}
+	w.sent[domain]++
+	return true
+}
+
+// domainOf returns the sending domain of an email
+func (w *emailWarmup) domainOf(notification *models.EmailNotificationRequest) string {
+	if notification.From == nil || notification.From.Email == "" {
+		return w.defaultDomain
+	}
+	address := notification.From.Email
+	return strings.ToLower(address[strings.LastIndex(address, "@")+1:])
+}
+
+// middleware sends emails of domains within their limit and defers the others to the next day
+func (w *emailWarmup) middleware() ProcessorMiddleware {
+	return func(notificationType NotificationType, next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, message NotificationMessage) error {
+			var notification models.EmailNotificationRequest
+			if err := json.Unmarshal([]byte(message.Payload), &notification); err != nil {
+				// Malformed payloads are reported by the processor
+				return next(ctx, message)
+			}
+
+			now := w.now()
+			domain := w.domainOf(&notification)
+			if w.allow(domain, now) {
+				return next(ctx, message)
+			}
+
+			w.postpone(&notification, domain, now)
+			return nil
+		}
+	}
+}
+
+// postpone posts an email to the email channel again at the start of the next UTC day
+func (w *emailWarmup) postpone(notification *models.EmailNotificationRequest, domain string, now time.Time) {
+	emailWarmupDeferredTotal.Inc(domain)
+
+	tomorrow := now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	sampledLog.Info("Deferring email of warming up domain to the next day", logger.Fields{
+		"notification_id": notification.ID,
+		"domain":          domain,
+		"until":           tomorrow,
+	})
+
+	w.after(tomorrow.Sub(now), func() {
+		// Stamp the new queue time so the wait is not reported as a slow consumer
+		notification.SetQueuedAt(w.now())
+		payload, err := json.Marshal(notification)
+		if err != nil {
+			moduleLog.Error("Failed to requeue deferred email", logger.Fields{"notification_id": notification.ID, "error": err.Error()})
+			return
+		}
+		w.requeue <- string(payload)
+	})
+}
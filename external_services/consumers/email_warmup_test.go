@@ -0,0 +1,115 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWarmupSchedules(t *testing.T) {
+	schedules, err := ParseWarmupSchedules(" News.Example.com:2024-06-03:100:2:50000, mail.example.org:2024-07-01:500:1.5 ,")
+	require.NoError(t, err)
+	assert.Equal(t, []WarmupSchedule{
+		{Domain: "news.example.com", Start: time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC), DailyLimit: 100, WeeklyGrowth: 2, MaxDailyLimit: 50000},
+		{Domain: "mail.example.org", Start: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), DailyLimit: 500, WeeklyGrowth: 1.5},
+	}, schedules)
+
+	schedules, err = ParseWarmupSchedules("")
+	assert.NoError(t, err)
+	assert.Empty(t, schedules)
+
+	for _, invalid := range []string{
+		"example.com:2024-06-03:100",
+		":2024-06-03:100:2",
+		"example.com:June:100:2",
+		"example.com:2024-06-03:0:2",
+		"example.com:2024-06-03:100:0.5",
+		"example.com:2024-06-03:100:2:many",
+	} {
+		_, err := ParseWarmupSchedules(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestWarmupSchedule_LimitOn(t *testing.T) {
+	schedule := WarmupSchedule{
+		Domain:        "news.example.com",
+		Start:         time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC),
+		DailyLimit:    100,
+		WeeklyGrowth:  2,
+		MaxDailyLimit: 1000,
+	}
+
+	for _, tc := range []struct {
+		day     time.Time
+		limit   int
+		limited bool
+	}{
+		{time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC), 100, true},
+		{time.Date(2024, 6, 9, 23, 59, 0, 0, time.UTC), 100, true},
+		{time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC), 200, true},
+		{time.Date(2024, 6, 24, 8, 0, 0, 0, time.UTC), 800, true},
+		{time.Date(2024, 7, 1, 8, 0, 0, 0, time.UTC), 0, false},
+	} {
+		limit, limited := schedule.LimitOn(tc.day)
+		assert.Equal(t, tc.limit, limit, tc.day.String())
+		assert.Equal(t, tc.limited, limited, tc.day.String())
+	}
+}
+
+func TestEmailWarmup_DefersEmailsOverTheDailyLimit(t *testing.T) {
+	requeue := make(chan string, 1)
+	warmup := newEmailWarmup(EmailWarmupConfig{
+		Schedules: []WarmupSchedule{{
+			Domain:       "News.Example.com",
+			Start:        time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC),
+			DailyLimit:   2,
+			WeeklyGrowth: 2,
+		}},
+		DefaultDomain: "news.example.com",
+	}, requeue)
+
+	now := time.Date(2024, 6, 4, 18, 30, 0, 0, time.UTC)
+	warmup.now = func() time.Time { return now }
+	var delays []time.Duration
+	var pending []func()
+	warmup.after = func(d time.Duration, f func()) {
+		delays = append(delays, d)
+		pending = append(pending, f)
+	}
+
+	var sent []string
+	process := warmup.middleware()(EmailNotification, func(ctx context.Context, message NotificationMessage) error {
+		sent = append(sent, message.Payload)
+		return nil
+	})
+	send := func(payload string) {
+		require.NoError(t, process(context.Background(), NotificationMessage{Type: EmailNotification, Payload: payload}))
+	}
+
+	send(`{"id":"n-1","recipient":"a@example.com"}`)
+	send(`{"id":"n-2","recipient":"b@example.com","from":{"email":"news@news.example.com"}}`)
+	send(`{"id":"n-3","recipient":"c@example.com","from":{"email":"news@NEWS.example.com"},"queued_at":1717525800000}`)
+	send(`{"id":"n-4","recipient":"d@example.com","from":{"email":"support@example.com"}}`)
+
+	assert.Len(t, sent, 3, "only the third email of the warming up domain is held back")
+	assert.Equal(t, []time.Duration{5*time.Hour + 30*time.Minute}, delays)
+	assert.Equal(t, 1.0, emailWarmupDeferredTotal.Value("news.example.com"))
+
+	// At midnight the email is posted to the channel again with a fresh queue time
+	now = time.Date(2024, 6, 5, 0, 0, 0, 0, time.UTC)
+	pending[0]()
+	var requeued models.EmailNotificationRequest
+	require.NoError(t, json.Unmarshal([]byte(<-requeue), &requeued))
+	assert.Equal(t, "n-3", requeued.ID)
+	assert.Equal(t, now.UnixMilli(), requeued.QueuedAt)
+
+	// A new day starts with a fresh limit
+	send(`{"id":"n-3","recipient":"c@example.com","from":{"email":"news@news.example.com"}}`)
+	assert.Len(t, sent, 4)
+}
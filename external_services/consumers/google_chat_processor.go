@@ -0,0 +1,103 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gaurav2721/notification-service/external_services/concurrency"
+	"github.com/gaurav2721/notification-service/external_services/delivery"
+	"github.com/gaurav2721/notification-service/external_services/googlechat"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// googleChatProcessor handles Google Chat notification processing
+type googleChatProcessor struct {
+	googleChatService googlechat.GoogleChatService
+	deliveryService   delivery.DeliveryService
+}
+
+// NewGoogleChatProcessor creates a new Google Chat processor
+func NewGoogleChatProcessor() NotificationProcessor {
+	return &googleChatProcessor{}
+}
+
+// NewGoogleChatProcessorWithServices creates a new Google Chat processor that also archives delivery attempts
+func NewGoogleChatProcessorWithServices(googleChatService googlechat.GoogleChatService, deliveryService delivery.DeliveryService) NotificationProcessor {
+	return &googleChatProcessor{
+		googleChatService: googleChatService,
+		deliveryService:   deliveryService,
+	}
+}
+
+// ProcessNotification processes a Google Chat notification
+func (gp *googleChatProcessor) ProcessNotification(ctx context.Context, message NotificationMessage) error {
+	sampledLog.Debug("Processing google chat notification", logger.Fields{
+		"notification_id": message.ID,
+		"type":            message.Type,
+		"payload":         message.Payload,
+		"timestamp":       message.Timestamp,
+	})
+
+	// If no Google Chat service is available, just log and return
+	if gp.googleChatService == nil {
+		moduleLog.Warn("No google chat service available, skipping google chat notification", nil)
+		return nil
+	}
+
+	// Parse the payload directly into GoogleChatNotificationRequest
+	var chatNotification models.GoogleChatNotificationRequest
+	if err := json.Unmarshal([]byte(message.Payload), &chatNotification); err != nil {
+		moduleLog.Error("Failed to parse notification payload into GoogleChatNotificationRequest", logger.Fields{"error": err.Error()})
+		return fmt.Errorf("failed to parse notification payload into GoogleChatNotificationRequest: %w", err)
+	}
+
+	// Use the message ID if not set in the notification
+	if chatNotification.ID == "" {
+		chatNotification.ID = message.ID
+	}
+
+	// Use the message type if not set in the notification
+	if chatNotification.Type == "" {
+		chatNotification.Type = string(message.Type)
+	}
+
+	sampledLog.Info("Sending google chat notification", logger.Fields{
+		"notification_id": message.ID,
+		"thread_key":      chatNotification.ThreadKey,
+		"card":            chatNotification.Content.Card != nil,
+	})
+
+	// Send the message using the Google Chat service
+	startedAt := time.Now()
+	response, err := gp.googleChatService.SendGoogleChatMessage(ctx, &chatNotification)
+	recordDeliveryAttempt(gp.deliveryService, &models.DeliveryAttempt{
+		NotificationID: chatNotification.ID,
+		UserID:         chatNotification.UserID,
+		Recipient:      chatNotification.Recipient,
+		Channel:        string(GoogleChatNotification),
+		Provider:       concurrency.ProviderGoogleChat,
+		QueuedAt:       queuedTime(chatNotification.QueuedAt),
+	}, response, err, startedAt)
+	if err != nil {
+		moduleLog.Error("Failed to send google chat notification", logger.Fields{
+			"notification_id": message.ID,
+			"error":           err.Error(),
+		})
+		return fmt.Errorf("failed to send google chat message: %w", err)
+	}
+
+	sampledLog.Info("Google chat notification sent successfully", logger.Fields{
+		"notification_id": message.ID,
+		"response":        response,
+	})
+
+	return nil
+}
+
+// GetNotificationType returns the notification type this processor handles
+func (gp *googleChatProcessor) GetNotificationType() NotificationType {
+	return GoogleChatNotification
+}
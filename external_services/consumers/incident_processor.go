@@ -0,0 +1,103 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gaurav2721/notification-service/external_services/concurrency"
+	"github.com/gaurav2721/notification-service/external_services/delivery"
+	"github.com/gaurav2721/notification-service/external_services/incident"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// incidentProcessor handles incident notification processing
+type incidentProcessor struct {
+	incidentService incident.IncidentService
+	deliveryService delivery.DeliveryService
+}
+
+// NewIncidentProcessor creates a new incident processor
+func NewIncidentProcessor() NotificationProcessor {
+	return &incidentProcessor{}
+}
+
+// NewIncidentProcessorWithServices creates a new incident processor that also archives delivery attempts
+func NewIncidentProcessorWithServices(incidentService incident.IncidentService, deliveryService delivery.DeliveryService) NotificationProcessor {
+	return &incidentProcessor{
+		incidentService: incidentService,
+		deliveryService: deliveryService,
+	}
+}
+
+// ProcessNotification processes an incident notification
+func (ip *incidentProcessor) ProcessNotification(ctx context.Context, message NotificationMessage) error {
+	sampledLog.Debug("Processing incident notification", logger.Fields{
+		"notification_id": message.ID,
+		"type":            message.Type,
+		"payload":         message.Payload,
+		"timestamp":       message.Timestamp,
+	})
+
+	// If no incident service is available, just log and return
+	if ip.incidentService == nil {
+		moduleLog.Warn("No incident service available, skipping incident notification", nil)
+		return nil
+	}
+
+	// Parse the payload directly into IncidentNotificationRequest
+	var incidentNotification models.IncidentNotificationRequest
+	if err := json.Unmarshal([]byte(message.Payload), &incidentNotification); err != nil {
+		moduleLog.Error("Failed to parse notification payload into IncidentNotificationRequest", logger.Fields{"error": err.Error()})
+		return fmt.Errorf("failed to parse notification payload into IncidentNotificationRequest: %w", err)
+	}
+
+	// Use the message ID if not set in the notification
+	if incidentNotification.ID == "" {
+		incidentNotification.ID = message.ID
+	}
+
+	// Use the message type if not set in the notification
+	if incidentNotification.Type == "" {
+		incidentNotification.Type = string(message.Type)
+	}
+
+	sampledLog.Info("Sending incident notification", logger.Fields{
+		"notification_id": message.ID,
+		"action":          incidentNotification.Content.Action,
+		"dedupe_key":      incidentNotification.DedupeKey,
+	})
+
+	// Send the event using the incident service
+	startedAt := time.Now()
+	response, err := ip.incidentService.SendIncidentEvent(ctx, &incidentNotification)
+	recordDeliveryAttempt(ip.deliveryService, &models.DeliveryAttempt{
+		NotificationID: incidentNotification.ID,
+		UserID:         incidentNotification.UserID,
+		Recipient:      incidentNotification.Recipient,
+		Channel:        string(IncidentNotification),
+		Provider:       concurrency.ProviderIncident,
+		QueuedAt:       queuedTime(incidentNotification.QueuedAt),
+	}, response, err, startedAt)
+	if err != nil {
+		moduleLog.Error("Failed to send incident notification", logger.Fields{
+			"notification_id": message.ID,
+			"error":           err.Error(),
+		})
+		return fmt.Errorf("failed to send incident event: %w", err)
+	}
+
+	sampledLog.Info("Incident notification sent successfully", logger.Fields{
+		"notification_id": message.ID,
+		"response":        response,
+	})
+
+	return nil
+}
+
+// GetNotificationType returns the notification type this processor handles
+func (ip *incidentProcessor) GetNotificationType() NotificationType {
+	return IncidentNotification
+}
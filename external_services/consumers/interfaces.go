@@ -3,10 +3,15 @@ package consumers
 import (
 	"context"
 
+	"github.com/gaurav2721/notification-service/clock"
 	"github.com/gaurav2721/notification-service/external_services/apns"
+	"github.com/gaurav2721/notification-service/external_services/delivery"
 	"github.com/gaurav2721/notification-service/external_services/email"
 	"github.com/gaurav2721/notification-service/external_services/fcm"
+	"github.com/gaurav2721/notification-service/external_services/googlechat"
+	"github.com/gaurav2721/notification-service/external_services/incident"
 	"github.com/gaurav2721/notification-service/external_services/kafka"
+	"github.com/gaurav2721/notification-service/external_services/rcs"
 	"github.com/gaurav2721/notification-service/external_services/slack"
 )
 
@@ -16,6 +21,9 @@ type NotificationType string
 const (
 	EmailNotification       NotificationType = "email"
 	SlackNotification       NotificationType = "slack"
+	GoogleChatNotification  NotificationType = "google_chat"
+	IncidentNotification    NotificationType = "incident"
+	RCSNotification         NotificationType = "rcs"
 	IOSPushNotification     NotificationType = "ios_push"
 	AndroidPushNotification NotificationType = "android_push"
 )
@@ -53,7 +61,8 @@ type ConsumerWorkerPool interface {
 	// IsRunning returns true if the worker pool is currently running
 	IsRunning() bool
 
-	// GetChannel returns the channel this pool reads from
+	// GetChannel returns the in-process channel this pool reads from, nil when it reads broker
+	// deliveries
 	GetChannel() chan string
 }
 
@@ -85,17 +94,51 @@ type ConsumerManager interface {
 type ConsumerConfig struct {
 	EmailWorkerCount       int `json:"email_worker_count" env:"EMAIL_WORKER_COUNT" env-default:"5"`
 	SlackWorkerCount       int `json:"slack_worker_count" env:"SLACK_WORKER_COUNT" env-default:"3"`
+	GoogleChatWorkerCount  int `json:"google_chat_worker_count" env:"GOOGLE_CHAT_WORKER_COUNT" env-default:"3"`
+	IncidentWorkerCount    int `json:"incident_worker_count" env:"INCIDENT_WORKER_COUNT" env-default:"2"`
+	RCSWorkerCount         int `json:"rcs_worker_count" env:"RCS_WORKER_COUNT" env-default:"3"`
 	IOSPushWorkerCount     int `json:"ios_push_worker_count" env:"IOS_PUSH_WORKER_COUNT" env-default:"3"`
 	AndroidPushWorkerCount int `json:"android_push_worker_count" env:"ANDROID_PUSH_WORKER_COUNT" env-default:"3"`
 
 	// Service dependencies
-	EmailService email.EmailService
-	SlackService slack.SlackService
-	APNSService  apns.APNSService
-	FCMService   fcm.FCMService
+	EmailService      email.EmailService
+	SlackService      slack.SlackService
+	GoogleChatService googlechat.GoogleChatService
+	IncidentService   incident.IncidentService
+	RCSService        rcs.RCSService
+	APNSService       apns.APNSService
+	FCMService        fcm.FCMService
+
+	// Delivery service for archiving provider responses per delivery attempt
+	DeliveryService delivery.DeliveryService
 
 	// Kafka service interface for getting channels
 	KafkaService kafka.KafkaService
+
+	// Middleware wraps every channel processor, outermost first
+	Middleware []ProcessorMiddleware
+
+	// SlowConsumer configures alerts on channels whose messages wait too long
+	SlowConsumer SlowConsumerConfig
+
+	// AnomalyDetection configures alerts on sudden spikes in the failure rate or latency of channels
+	AnomalyDetection AnomalyDetectionConfig
+
+	// EmailWarmup limits the daily email volume of new sending domains
+	EmailWarmup EmailWarmupConfig
+
+	// AttachmentScan scans email attachments and links before they are sent
+	AttachmentScan AttachmentScanConfig
+	// Retry processes failed messages again with exponential backoff and dead-letters them once
+	// their attempts are exhausted
+	Retry RetryConfig
+
+	// Clock runs the backoffs of retried messages and stamps dead letters; nil uses the system
+	// clock
+	Clock clock.Clock
+
+	// Batching coalesces scheduled messages of the same batching window into batched provider calls
+	Batching BatchingConfig
 }
 
 // NotificationProcessor defines the interface for processing notifications
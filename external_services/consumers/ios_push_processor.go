@@ -4,15 +4,21 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/gaurav2721/notification-service/external_services/apns"
+	"github.com/gaurav2721/notification-service/external_services/concurrency"
+	"github.com/gaurav2721/notification-service/external_services/delivery"
+	"github.com/gaurav2721/notification-service/logger"
 	"github.com/gaurav2721/notification-service/models"
-	"github.com/sirupsen/logrus"
 )
 
 // iosPushProcessor handles iOS push notification processing
 type iosPushProcessor struct {
-	apnsService apns.APNSService
+	apnsService     apns.APNSService
+	deliveryService delivery.DeliveryService
+	// batcher coalesces pushes with a batch key into batches sent at once
+	batcher *messageBatcher
 }
 
 // NewIOSPushProcessor creates a new iOS push notification processor
@@ -27,25 +33,59 @@ func NewIOSPushProcessorWithService(apnsService apns.APNSService) NotificationPr
 	}
 }
 
+// NewIOSPushProcessorWithServices creates a new iOS push processor that also archives delivery attempts
+func NewIOSPushProcessorWithServices(apnsService apns.APNSService, deliveryService delivery.DeliveryService) NotificationProcessor {
+	return &iosPushProcessor{
+		apnsService:     apnsService,
+		deliveryService: deliveryService,
+	}
+}
+
+// withBatching sends pushes with a batch key in batches as large as the APNS service sends at
+// once, unless batching is disabled or the APNS service cannot batch
+func (ip *iosPushProcessor) withBatching(config BatchingConfig) *iosPushProcessor {
+	size := apns.MaxBatchSize(ip.apnsService)
+	if config.MaxWait <= 0 || size <= 1 {
+		return ip
+	}
+	ip.batcher = newMessageBatcher(size, config.MaxWait, func(ctx context.Context, items []interface{}) ([]interface{}, []error) {
+		notifications := make([]*models.APNSNotificationRequest, len(items))
+		for i, item := range items {
+			notifications[i] = item.(*models.APNSNotificationRequest)
+		}
+		sampledLog.Info("Sending iOS push batch", logger.Fields{"size": len(notifications)})
+		return apns.SendBatch(ctx, ip.apnsService, notifications)
+	})
+	return ip
+}
+
+// send sends the push, as part of a batch if it has a batch key
+func (ip *iosPushProcessor) send(ctx context.Context, notification *models.APNSNotificationRequest) (interface{}, error) {
+	if ip.batcher != nil && notification.BatchKey != "" {
+		return ip.batcher.Submit(ctx, notification.BatchKey, notification)
+	}
+	return ip.apnsService.SendPushNotification(ctx, notification)
+}
+
 // ProcessNotification processes an iOS push notification
 func (ip *iosPushProcessor) ProcessNotification(ctx context.Context, message NotificationMessage) error {
-	logrus.WithFields(logrus.Fields{
+	sampledLog.Debug("Processing iOS push notification", logger.Fields{
 		"notification_id": message.ID,
 		"type":            message.Type,
 		"payload":         message.Payload,
 		"timestamp":       message.Timestamp,
-	}).Debug("Processing iOS push notification")
+	})
 
 	// If no APNS service is available, just log and return
 	if ip.apnsService == nil {
-		logrus.Warn("No APNS service available, skipping iOS push notification")
+		moduleLog.Warn("No APNS service available, skipping iOS push notification", nil)
 		return nil
 	}
 
 	// Parse the payload directly into APNSNotificationRequest
 	var apnsNotification models.APNSNotificationRequest
 	if err := json.Unmarshal([]byte(message.Payload), &apnsNotification); err != nil {
-		logrus.WithError(err).Error("Failed to parse notification payload into APNSNotificationRequest")
+		moduleLog.Error("Failed to parse notification payload into APNSNotificationRequest", logger.Fields{"error": err.Error()})
 		return fmt.Errorf("failed to parse notification payload into APNSNotificationRequest: %w", err)
 	}
 
@@ -59,28 +99,37 @@ func (ip *iosPushProcessor) ProcessNotification(ctx context.Context, message Not
 		apnsNotification.Type = string(message.Type)
 	}
 
-	logrus.WithFields(logrus.Fields{
+	sampledLog.Info("Sending iOS push notification", logger.Fields{
 		"notification_id": message.ID,
 		"device_token":    apnsNotification.Recipient,
 		"title":           apnsNotification.Content.Title,
 		"body":            apnsNotification.Content.Body,
-	}).Info("Sending iOS push notification")
+	})
 
 	// Send push notification using the APNS service
-	response, err := ip.apnsService.SendPushNotification(ctx, &apnsNotification)
+	startedAt := time.Now()
+	response, err := ip.send(ctx, &apnsNotification)
+	recordDeliveryAttempt(ip.deliveryService, &models.DeliveryAttempt{
+		NotificationID: apnsNotification.ID,
+		UserID:         apnsNotification.UserID,
+		Recipient:      apnsNotification.Recipient,
+		Channel:        string(IOSPushNotification),
+		Provider:       concurrency.ProviderAPNS,
+		QueuedAt:       queuedTime(apnsNotification.QueuedAt),
+	}, response, err, startedAt)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{
+		moduleLog.Error("Failed to send iOS push notification", logger.Fields{
 			"notification_id": message.ID,
 			"error":           err.Error(),
-		}).Error("Failed to send iOS push notification")
+		})
 		return fmt.Errorf("failed to send iOS push notification: %w", err)
 	}
 
 	// Log successful push notification sending
-	logrus.WithFields(logrus.Fields{
+	sampledLog.Info("iOS push notification sent successfully", logger.Fields{
 		"notification_id": message.ID,
 		"response":        response,
-	}).Info("iOS push notification sent successfully")
+	})
 
 	return nil
 }
@@ -0,0 +1,83 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockBatchAPNSService is a mock APNS service sending batches of up to two pushes
+type mockBatchAPNSService struct {
+	mu      sync.Mutex
+	single  int
+	batches [][]string
+}
+
+func (m *mockBatchAPNSService) SendPushNotification(ctx context.Context, notification interface{}) (interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.single++
+	return &models.APNSResponse{Status: "sent", SuccessCount: 1}, nil
+}
+
+func (m *mockBatchAPNSService) MaxBatchSize() int {
+	return 2
+}
+
+func (m *mockBatchAPNSService) SendPushNotificationBatch(ctx context.Context, notifications []*models.APNSNotificationRequest) ([]interface{}, []error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var tokens []string
+	responses := make([]interface{}, len(notifications))
+	for i, notification := range notifications {
+		tokens = append(tokens, notification.Recipient)
+		responses[i] = &models.APNSResponse{Status: "sent", SuccessCount: 1, APNSID: "apns-" + notification.Recipient}
+	}
+	m.batches = append(m.batches, tokens)
+	return responses, make([]error, len(notifications))
+}
+
+func iosPushMessage(t *testing.T, id, token, batchKey string) NotificationMessage {
+	payload, err := json.Marshal(models.APNSNotificationRequest{
+		ID:        id,
+		Content:   models.APNSContent{Title: "Title", Body: "Body"},
+		Recipient: token,
+		BatchKey:  batchKey,
+	})
+	require.NoError(t, err)
+	return NotificationMessage{Type: IOSPushNotification, Payload: string(payload), ID: id}
+}
+
+func TestIOSPushProcessor_BatchesScheduledPushes(t *testing.T) {
+	apnsService := &mockBatchAPNSService{}
+	processor := (&iosPushProcessor{apnsService: apnsService}).withBatching(BatchingConfig{MaxWait: time.Hour})
+
+	// Pushes without a batch key are sent one by one
+	require.NoError(t, processor.ProcessNotification(context.Background(), iosPushMessage(t, "n1", "a", "")))
+	assert.Equal(t, 1, apnsService.single)
+
+	var wg sync.WaitGroup
+	for _, id := range []string{"n2", "n3"} {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			assert.NoError(t, processor.ProcessNotification(context.Background(), iosPushMessage(t, id, id, "scheduled:2024-01-01T09:00:00Z")))
+		}(id)
+	}
+	wg.Wait()
+
+	require.Len(t, apnsService.batches, 1)
+	assert.ElementsMatch(t, []string{"n2", "n3"}, apnsService.batches[0])
+	assert.Equal(t, 1, apnsService.single)
+}
+
+func TestIOSPushProcessor_WithoutBatching(t *testing.T) {
+	processor := (&iosPushProcessor{apnsService: &mockBatchAPNSService{}}).withBatching(BatchingConfig{})
+	assert.Nil(t, processor.batcher)
+}
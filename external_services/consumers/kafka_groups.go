@@ -0,0 +1,397 @@
+package consumers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gaurav2721/notification-service/external_services/messagebus"
+	"github.com/gaurav2721/notification-service/logger"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+const (
+	// kafkaSessionTimeout is how long the coordinator waits for heartbeats before it removes a
+	// member from its group
+	kafkaSessionTimeout = 10 * time.Second
+
+	// kafkaHeartbeatInterval is the time between heartbeats, a third of the session timeout
+	kafkaHeartbeatInterval = 3 * time.Second
+
+	// kafkaRebalanceTimeout is how long the coordinator waits for all members to rejoin
+	kafkaRebalanceTimeout = 60 * time.Second
+
+	// kafkaJoinBackoff is the delay before a member rejoins its group after an error
+	kafkaJoinBackoff = time.Second
+
+	// kafkaFetchMaxWait is how long the broker holds a fetch while no records arrive
+	kafkaFetchMaxWait = 500 * time.Millisecond
+)
+
+// KafkaGroupConfig configures the consumer groups of the Kafka message bus
+type KafkaGroupConfig struct {
+	// Brokers are the addresses of the brokers the cluster metadata is requested from
+	Brokers []string
+
+	// TopicPrefix prefixes the names of the Kafka topics, as for the transport
+	TopicPrefix string
+
+	// Group prefixes the consumer groups; each topic is consumed by the group <Group>.<topic>
+	Group string
+
+	// Prefetch is the number of unsettled messages a member holds per topic
+	Prefetch int
+
+	// MaxDeliveries is how often a message is delivered before it is moved to the dead-letter
+	// topic
+	MaxDeliveries int
+
+	// Timeout bounds connecting and waiting for the broker's replies
+	Timeout time.Duration
+}
+
+// KafkaGroups consumes the Kafka topics of the message bus in one consumer group per topic, so
+// service instances share the topic's partitions. The offset committed for a partition never
+// passes a message that is still being processed. Kafka does not count deliveries, so failed
+// messages are republished with a delivery count header and moved to the topic's dead-letter
+// topic after MaxDeliveries deliveries.
+type KafkaGroups struct {
+	config KafkaGroupConfig
+	writer *kafkago.Writer
+
+	// mu guards the failures of the groups, by Kafka topic
+	mu       sync.Mutex
+	failures map[string]error
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewKafkaGroups creates the consumer groups for the Kafka transport of the message bus. Groups
+// are joined as topics are subscribed.
+func NewKafkaGroups(config KafkaGroupConfig) *KafkaGroups {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &KafkaGroups{
+		config:   config,
+		writer:   messagebus.NewKafkaWriter(config.Brokers, config.Timeout),
+		failures: make(map[string]error),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Subscribe joins the consumer group of the topic and hands the messages of the partitions
+// assigned to this instance to handle
+func (g *KafkaGroups) Subscribe(topic string, handle func(message messagebus.Message) bool) error {
+	member := &kafkaMember{
+		groups: g,
+		name:   g.config.TopicPrefix + topic,
+		handle: handle,
+		slots:  make(chan struct{}, max(g.config.Prefetch, 1)),
+	}
+	group, err := kafkago.NewConsumerGroup(kafkago.ConsumerGroupConfig{
+		ID:                g.config.Group + "." + topic,
+		Brokers:           g.config.Brokers,
+		Topics:            []string{member.name},
+		Dialer:            &kafkago.Dialer{Timeout: g.config.Timeout},
+		HeartbeatInterval: kafkaHeartbeatInterval,
+		SessionTimeout:    kafkaSessionTimeout,
+		RebalanceTimeout:  kafkaRebalanceTimeout,
+		JoinGroupBackoff:  kafkaJoinBackoff,
+		StartOffset:       kafkago.FirstOffset,
+		Timeout:           g.config.Timeout,
+	})
+	if err != nil {
+		return fmt.Errorf("kafka: failed to create consumer group of %s: %w", member.name, err)
+	}
+	member.group = group
+	member.ctx, member.cancel = context.WithCancel(g.ctx)
+
+	g.wg.Add(1)
+	go member.run()
+	return nil
+}
+
+// Healthy returns why a consumer group does not consume messages, or nil
+func (g *KafkaGroups) Healthy() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	names := make([]string, 0, len(g.failures))
+	for name := range g.failures {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	return g.failures[names[0]]
+}
+
+// Close leaves the consumer groups once running handlers have returned. Messages that were not
+// settled are redelivered from the last committed offsets.
+func (g *KafkaGroups) Close() error {
+	g.cancel()
+	g.wg.Wait()
+	return g.writer.Close()
+}
+
+// fail records why consuming a Kafka topic stopped
+func (g *KafkaGroups) fail(name string, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.failures[name] = err
+}
+
+// reset clears the failure of a Kafka topic once its group was joined
+func (g *KafkaGroups) reset(name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.failures, name)
+}
+
+// kafkaMember consumes a Kafka topic as a member of the topic's consumer group
+type kafkaMember struct {
+	groups *KafkaGroups
+	name   string
+	group  *kafkago.ConsumerGroup
+	handle func(message messagebus.Message) bool
+
+	// slots holds a token for every unsettled message, so at most Prefetch are handed out
+	slots chan struct{}
+
+	// ctx ends when the groups are closed or a message was rejected by handle
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// run consumes the partitions assigned in every generation of the group until the member is
+// stopped. A generation ends with a rebalance, or early when consuming a partition failed, and
+// the partitions are consumed from their committed offsets again in the next one.
+func (m *kafkaMember) run() {
+	defer m.groups.wg.Done()
+
+	for {
+		generation, err := m.group.Next(m.ctx)
+		if m.ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			m.groups.fail(m.name, fmt.Errorf("kafka: consumer group of %s failed: %w", m.name, err))
+			moduleLog.Warn("Kafka consumer group failed, rejoining", logger.Fields{"topic": m.name, "error": err.Error()})
+			continue
+		}
+
+		m.groups.reset(m.name)
+		for _, assignment := range generation.Assignments[m.name] {
+			assignment := assignment
+			generation.Start(func(ctx context.Context) {
+				m.consume(ctx, generation, assignment)
+			})
+		}
+	}
+
+	// Leaving the group waits for the partitions of the current generation to stop
+	if err := m.group.Close(); err != nil {
+		moduleLog.Warn("Failed to leave Kafka consumer group", logger.Fields{"topic": m.name, "error": err.Error()})
+	}
+}
+
+// consume hands the messages of an assigned partition to handle until the generation ends.
+// Returning ends the generation for the whole member.
+func (m *kafkaMember) consume(ctx context.Context, generation *kafkago.Generation, assignment kafkago.PartitionAssignment) {
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	partition := &kafkaPartition{
+		member:     m,
+		generation: generation,
+		id:         assignment.ID,
+		ctx:        ctx,
+		stop:       stop,
+	}
+	reader := kafkago.NewReader(kafkago.ReaderConfig{
+		Brokers:       m.groups.config.Brokers,
+		Topic:         m.name,
+		Partition:     assignment.ID,
+		Dialer:        &kafkago.Dialer{Timeout: m.groups.config.Timeout},
+		QueueCapacity: cap(m.slots),
+		MaxWait:       kafkaFetchMaxWait,
+	})
+	defer reader.Close()
+	if err := reader.SetOffset(assignment.Offset); err != nil {
+		moduleLog.Warn("Failed to seek Kafka partition", logger.Fields{"topic": m.name, "partition": assignment.ID, "error": err.Error()})
+		return
+	}
+
+	for {
+		select {
+		case m.slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+
+		record, err := reader.FetchMessage(ctx)
+		if err != nil {
+			<-m.slots
+			if ctx.Err() == nil {
+				m.groups.fail(m.name, fmt.Errorf("kafka: failed to fetch %s/%d: %w", m.name, assignment.ID, err))
+				moduleLog.Warn("Failed to fetch Kafka messages, rejoining", logger.Fields{"topic": m.name, "partition": assignment.ID, "error": err.Error()})
+			}
+			return
+		}
+
+		partition.read(record.Offset)
+		if !m.handle(&kafkaMessage{partition: partition, record: record}) {
+			// Shutting down, the message stays unsettled and is redelivered after a restart
+			<-m.slots
+			m.cancel()
+			return
+		}
+	}
+}
+
+// kafkaPartition is a partition assigned to a member in one generation
+type kafkaPartition struct {
+	member     *kafkaMember
+	generation *kafkago.Generation
+	id         int
+
+	// ctx ends with the generation; stop ends it early, so the partition is read again from the
+	// committed offset
+	ctx  context.Context
+	stop context.CancelFunc
+
+	// mu serializes reads and commits, so commits never move the offset back
+	mu      sync.Mutex
+	offsets kafkaOffsets
+}
+
+// read tracks a message handed out
+func (p *kafkaPartition) read(offset int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.offsets.read(offset)
+}
+
+// settle marks a message as processed and commits the offset past all processed messages that
+// precede the first unsettled one. Commits of an ended generation fail; the member resuming at
+// the last commit redelivers the messages.
+func (p *kafkaPartition) settle(offset int64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	next, ok := p.offsets.settle(offset)
+	if !ok {
+		return nil
+	}
+	if err := p.generation.CommitOffsets(map[string]map[int]int64{p.member.name: {p.id: next}}); err != nil {
+		return fmt.Errorf("kafka: failed to commit %s/%d: %w", p.member.name, p.id, err)
+	}
+	return nil
+}
+
+// kafkaOffsets tracks the offsets of the messages read from a partition until they are settled
+type kafkaOffsets struct {
+	// unsettled holds the offsets read in ascending order, up to the first unsettled one
+	unsettled []int64
+	settled   map[int64]bool
+}
+
+// read adds the offset of a message handed out
+func (o *kafkaOffsets) read(offset int64) {
+	o.unsettled = append(o.unsettled, offset)
+}
+
+// settle marks an offset as processed. It returns the offset consumption resumes at once all
+// messages before the first unsettled one are committed, and whether it moved.
+func (o *kafkaOffsets) settle(offset int64) (next int64, moved bool) {
+	if o.settled == nil {
+		o.settled = make(map[int64]bool)
+	}
+	o.settled[offset] = true
+
+	for len(o.unsettled) > 0 && o.settled[o.unsettled[0]] {
+		delete(o.settled, o.unsettled[0])
+		next, moved = o.unsettled[0]+1, true
+		o.unsettled = o.unsettled[1:]
+	}
+	return next, moved
+}
+
+// kafkaMessage is a record handed to a consumer
+type kafkaMessage struct {
+	partition *kafkaPartition
+	record    kafkago.Message
+	settled   sync.Once
+}
+
+// Payload returns the record value
+func (m *kafkaMessage) Payload() []byte {
+	return m.record.Value
+}
+
+// Ack marks the record as processed, so the committed offset moves past it
+func (m *kafkaMessage) Ack() error {
+	var err error
+	m.settled.Do(func() {
+		err = m.partition.settle(m.record.Offset)
+		<-m.partition.member.slots
+	})
+	return err
+}
+
+// Nack republishes the record to its topic with an increased delivery count, or to the
+// dead-letter topic once it was delivered MaxDeliveries times, and marks it as processed.
+// Records of an ended generation are left to the member that resumes at the committed offset.
+func (m *kafkaMessage) Nack() error {
+	if m.partition.ctx.Err() != nil {
+		m.release()
+		return nil
+	}
+
+	groups := m.partition.member.groups
+	deliveries := kafkaDeliveryCount(m.record)
+	name := m.partition.member.name
+	if deliveries >= groups.config.MaxDeliveries {
+		name += messagebus.KafkaDeadLetterSuffix
+	} else {
+		deliveries++
+	}
+
+	ctx, cancel := context.WithTimeout(m.partition.ctx, groups.config.Timeout)
+	defer cancel()
+	if err := groups.writer.WriteMessages(ctx, kafkago.Message{
+		Topic:   name,
+		Value:   m.record.Value,
+		Headers: []kafkago.Header{{Key: messagebus.KafkaDeliveryCountHeader, Value: []byte(strconv.Itoa(deliveries))}},
+	}); err != nil {
+		// Ending the generation resumes at the committed offset, which is before this record
+		m.partition.stop()
+		m.release()
+		return fmt.Errorf("kafka: failed to republish record to %s: %w", name, err)
+	}
+	return m.Ack()
+}
+
+// release frees the slot of the record without marking it as processed
+func (m *kafkaMessage) release() {
+	m.settled.Do(func() {
+		<-m.partition.member.slots
+	})
+}
+
+// kafkaDeliveryCount returns how often a record was delivered, including this delivery
+func kafkaDeliveryCount(record kafkago.Message) int {
+	for _, header := range record.Headers {
+		if header.Key == messagebus.KafkaDeliveryCountHeader {
+			if count, err := strconv.Atoi(string(header.Value)); err == nil && count >= 1 {
+				return count
+			}
+		}
+	}
+	return 1
+}
@@ -0,0 +1,39 @@
+package consumers
+
+import (
+	"testing"
+
+	"github.com/gaurav2721/notification-service/external_services/messagebus"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKafkaOffsets_CommitsPastSettledMessagesOnly(t *testing.T) {
+	var offsets kafkaOffsets
+	for offset := int64(10); offset < 14; offset++ {
+		offsets.read(offset)
+	}
+
+	_, moved := offsets.settle(11)
+	assert.False(t, moved, "offset 10 is still being processed")
+	_, moved = offsets.settle(13)
+	assert.False(t, moved)
+
+	next, moved := offsets.settle(10)
+	assert.True(t, moved)
+	assert.Equal(t, int64(12), next, "consumption resumes at the first unsettled message")
+
+	next, moved = offsets.settle(12)
+	assert.True(t, moved)
+	assert.Equal(t, int64(14), next)
+}
+
+func TestKafkaDeliveryCount(t *testing.T) {
+	header := func(value string) kafkago.Message {
+		return kafkago.Message{Headers: []kafkago.Header{{Key: messagebus.KafkaDeliveryCountHeader, Value: []byte(value)}}}
+	}
+
+	assert.Equal(t, 1, kafkaDeliveryCount(kafkago.Message{}))
+	assert.Equal(t, 3, kafkaDeliveryCount(header("3")))
+	assert.Equal(t, 1, kafkaDeliveryCount(header("x")))
+}
@@ -0,0 +1,11 @@
+package consumers
+
+import "github.com/gaurav2721/notification-service/logger"
+
+var (
+	// moduleLog carries worker lifecycle events and failures
+	moduleLog = logger.Module("consumers")
+
+	// sampledLog carries per-message events, which are logged once every LOG_SAMPLE_EVERY occurrences
+	sampledLog = moduleLog.Sampled()
+)
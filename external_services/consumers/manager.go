@@ -9,6 +9,7 @@ import (
 	"github.com/gaurav2721/notification-service/external_services/email"
 	"github.com/gaurav2721/notification-service/external_services/fcm"
 	"github.com/gaurav2721/notification-service/external_services/kafka"
+	"github.com/gaurav2721/notification-service/external_services/messagebus"
 	"github.com/gaurav2721/notification-service/external_services/slack"
 	"github.com/sirupsen/logrus"
 )
@@ -17,6 +18,9 @@ import (
 type consumerManager struct {
 	config      ConsumerConfig
 	workerPools map[NotificationType]ConsumerWorkerPool
+	monitor     *slowConsumerMonitor
+	anomalies   *anomalyDetector
+	retries     *retryManager
 	running     bool
 	ctx         context.Context
 	cancel      context.CancelFunc
@@ -68,6 +72,16 @@ func (cm *consumerManager) Initialize(ctx context.Context) error {
 
 	logrus.Debug("Initializing consumer manager")
 
+	if cm.config.SlowConsumer.Threshold > 0 {
+		cm.monitor = newSlowConsumerMonitor(cm.config.SlowConsumer, cm.config.SlackService)
+	}
+	if cm.config.AnomalyDetection.Window > 0 {
+		cm.anomalies = newAnomalyDetector(cm.config.AnomalyDetection, cm.config.SlackService)
+	}
+	if cm.config.Retry.MaxAttempts > 0 {
+		cm.retries = newRetryManager(cm.config.Retry, cm.config.Clock)
+	}
+
 	// Create worker pools for each notification type
 	logrus.Debug("Creating email worker pool")
 	cm.createEmailWorkerPool()
@@ -75,12 +89,27 @@ func (cm *consumerManager) Initialize(ctx context.Context) error {
 	logrus.Debug("Creating slack worker pool")
 	cm.createSlackWorkerPool()
 
+	logrus.Debug("Creating Google Chat worker pool")
+	cm.createGoogleChatWorkerPool()
+
+	logrus.Debug("Creating incident worker pool")
+	cm.createIncidentWorkerPool()
+
+	logrus.Debug("Creating RCS worker pool")
+	cm.createRCSWorkerPool()
+
 	logrus.Debug("Creating iOS push worker pool")
 	cm.createIOSPushWorkerPool()
 
 	logrus.Debug("Creating Android push worker pool")
 	cm.createAndroidPushWorkerPool()
 
+	if cm.monitor != nil {
+		for _, pool := range cm.workerPools {
+			cm.monitor.watch(pool)
+		}
+	}
+
 	logrus.WithField("worker_pools", len(cm.workerPools)).Debug("Consumer manager initialized successfully")
 	return nil
 }
@@ -116,6 +145,24 @@ func (cm *consumerManager) Start(ctx context.Context) error {
 		}(notificationType, pool)
 	}
 
+	if cm.monitor != nil {
+		cm.wg.Add(1)
+		go func() {
+			defer cm.wg.Done()
+			cm.monitor.run(cm.ctx)
+		}()
+	}
+	if cm.anomalies != nil {
+		cm.wg.Add(1)
+		go func() {
+			defer cm.wg.Done()
+			cm.anomalies.run(cm.ctx)
+		}()
+	}
+	if cm.retries != nil {
+		cm.retries.start()
+	}
+
 	logrus.Debug("Consumer manager started all worker pools")
 	return nil
 }
@@ -153,6 +200,11 @@ func (cm *consumerManager) Stop() error {
 	logrus.Debug("Waiting for all worker pools to finish")
 	cm.wg.Wait()
 
+	// Messages waiting for a retry are dropped, like the messages still buffered in the channels
+	if cm.retries != nil {
+		cm.retries.stop()
+	}
+
 	logrus.Debug("Consumer manager stopped all worker pools")
 	return nil
 }
@@ -215,22 +267,75 @@ func (cm *consumerManager) UpdateWorkerCount(notificationType NotificationType,
 	return fmt.Errorf("worker pool for %s does not support updating worker count", notificationType)
 }
 
+// withMiddleware wraps a processor in the channel specific middleware and then the configured
+// middleware, tracking the age of its messages first when slow consumer detection is enabled,
+// then their outcome and latency when anomaly detection is enabled. Retries wrap all of them,
+// so every attempt is tracked and counted.
+func (cm *consumerManager) withMiddleware(processor NotificationProcessor, channelMiddleware ...ProcessorMiddleware) NotificationProcessor {
+	var middleware []ProcessorMiddleware
+	if retry := cm.retryMiddleware(processor.GetNotificationType()); retry != nil {
+		middleware = append(middleware, retry)
+	}
+	if cm.monitor != nil {
+		middleware = append(middleware, cm.monitor.track(processor.GetNotificationType()))
+	}
+	if cm.anomalies != nil {
+		middleware = append(middleware, cm.anomalies.track(processor.GetNotificationType()))
+	}
+	middleware = append(middleware, channelMiddleware...)
+	middleware = append(middleware, cm.config.Middleware...)
+	return WithMiddleware(processor, middleware...)
+}
+
+// retryMiddleware returns the retry middleware of a channel, or nil when retries are disabled or
+// the bus redelivers failed messages itself. Dead letters are replayed to the channel the
+// notification manager publishes to.
+func (cm *consumerManager) retryMiddleware(notificationType NotificationType) ProcessorMiddleware {
+	if cm.retries == nil {
+		return nil
+	}
+	if cm.config.KafkaService == nil {
+		return cm.retries.middleware(nil)
+	}
+	if _, durable := cm.config.KafkaService.(messagebus.MessageBus); durable {
+		return nil
+	}
+	return cm.retries.middleware(messagebus.ConsumerChannel(cm.config.KafkaService, string(notificationType)))
+}
+
 // createEmailWorkerPool creates the email worker pool
 func (cm *consumerManager) createEmailWorkerPool() {
 	var processor NotificationProcessor
 
 	// Use injected email service if available, otherwise create default
 	if cm.config.EmailService != nil {
-		processor = NewEmailProcessorWithService(cm.config.EmailService)
+		processor = (&emailProcessor{
+			emailService:    cm.config.EmailService,
+			deliveryService: cm.config.DeliveryService,
+		}).withBatching(cm.config.Batching)
 	} else {
 		processor = NewEmailProcessor()
 	}
 
+	// Hold back emails of warming up domains before they are counted as processed
+	var channelMiddleware []ProcessorMiddleware
+	if len(cm.config.EmailWarmup.Schedules) > 0 && cm.config.KafkaService != nil {
+		warmup := newEmailWarmup(cm.config.EmailWarmup, cm.config.KafkaService.GetEmailChannel())
+		channelMiddleware = append(channelMiddleware, warmup.middleware())
+	}
+
+	// Scan emails right before they are sent, so deferred emails are not scanned twice
+	if cm.config.AttachmentScan.Scanner != nil {
+		scan := newAttachmentScan(cm.config.AttachmentScan, cm.config.DeliveryService)
+		channelMiddleware = append(channelMiddleware, scan.middleware())
+	}
+
 	pool := NewWorkerPool(
 		EmailNotification,
-		cm.config.KafkaService.GetEmailChannel(),
-		processor,
+		messagebus.ConsumerChannel(cm.config.KafkaService, messagebus.TopicEmail),
+		cm.withMiddleware(processor, channelMiddleware...),
 		cm.config.EmailWorkerCount,
+		messagebus.Deliveries(cm.config.KafkaService, messagebus.TopicEmail),
 	)
 	cm.workerPools[EmailNotification] = pool
 }
@@ -241,36 +346,104 @@ func (cm *consumerManager) createSlackWorkerPool() {
 
 	// Use injected slack service if available, otherwise create default
 	if cm.config.SlackService != nil {
-		processor = NewSlackProcessorWithService(cm.config.SlackService)
+		processor = NewSlackProcessorWithServices(cm.config.SlackService, cm.config.DeliveryService)
 	} else {
 		processor = NewSlackProcessor()
 	}
 
 	pool := NewWorkerPool(
 		SlackNotification,
-		cm.config.KafkaService.GetSlackChannel(),
-		processor,
+		messagebus.ConsumerChannel(cm.config.KafkaService, messagebus.TopicSlack),
+		cm.withMiddleware(processor),
 		cm.config.SlackWorkerCount,
+		messagebus.Deliveries(cm.config.KafkaService, messagebus.TopicSlack),
 	)
 	cm.workerPools[SlackNotification] = pool
 }
 
+// createGoogleChatWorkerPool creates the Google Chat worker pool
+func (cm *consumerManager) createGoogleChatWorkerPool() {
+	var processor NotificationProcessor
+
+	// Use injected Google Chat service if available, otherwise create default
+	if cm.config.GoogleChatService != nil {
+		processor = NewGoogleChatProcessorWithServices(cm.config.GoogleChatService, cm.config.DeliveryService)
+	} else {
+		processor = NewGoogleChatProcessor()
+	}
+
+	pool := NewWorkerPool(
+		GoogleChatNotification,
+		messagebus.ConsumerChannel(cm.config.KafkaService, messagebus.TopicGoogleChat),
+		cm.withMiddleware(processor),
+		cm.config.GoogleChatWorkerCount,
+		messagebus.Deliveries(cm.config.KafkaService, messagebus.TopicGoogleChat),
+	)
+	cm.workerPools[GoogleChatNotification] = pool
+}
+
+// createIncidentWorkerPool creates the incident worker pool
+func (cm *consumerManager) createIncidentWorkerPool() {
+	var processor NotificationProcessor
+
+	// Use injected incident service if available, otherwise create default
+	if cm.config.IncidentService != nil {
+		processor = NewIncidentProcessorWithServices(cm.config.IncidentService, cm.config.DeliveryService)
+	} else {
+		processor = NewIncidentProcessor()
+	}
+
+	pool := NewWorkerPool(
+		IncidentNotification,
+		messagebus.ConsumerChannel(cm.config.KafkaService, messagebus.TopicIncident),
+		cm.withMiddleware(processor),
+		cm.config.IncidentWorkerCount,
+		messagebus.Deliveries(cm.config.KafkaService, messagebus.TopicIncident),
+	)
+	cm.workerPools[IncidentNotification] = pool
+}
+
+// createRCSWorkerPool creates the RCS worker pool
+func (cm *consumerManager) createRCSWorkerPool() {
+	var processor NotificationProcessor
+
+	// Use injected RCS service if available, otherwise create default
+	if cm.config.RCSService != nil {
+		processor = NewRCSProcessorWithServices(cm.config.RCSService, cm.config.DeliveryService)
+	} else {
+		processor = NewRCSProcessor()
+	}
+
+	pool := NewWorkerPool(
+		RCSNotification,
+		messagebus.ConsumerChannel(cm.config.KafkaService, messagebus.TopicRCS),
+		cm.withMiddleware(processor),
+		cm.config.RCSWorkerCount,
+		messagebus.Deliveries(cm.config.KafkaService, messagebus.TopicRCS),
+	)
+	cm.workerPools[RCSNotification] = pool
+}
+
 // createIOSPushWorkerPool creates the iOS push notification worker pool
 func (cm *consumerManager) createIOSPushWorkerPool() {
 	var processor NotificationProcessor
 
 	// Use injected APNS service if available, otherwise create default
 	if cm.config.APNSService != nil {
-		processor = NewIOSPushProcessorWithService(cm.config.APNSService)
+		processor = (&iosPushProcessor{
+			apnsService:     cm.config.APNSService,
+			deliveryService: cm.config.DeliveryService,
+		}).withBatching(cm.config.Batching)
 	} else {
 		processor = NewIOSPushProcessor()
 	}
 
 	pool := NewWorkerPool(
 		IOSPushNotification,
-		cm.config.KafkaService.GetIOSPushNotificationChannel(),
-		processor,
+		messagebus.ConsumerChannel(cm.config.KafkaService, messagebus.TopicIOSPush),
+		cm.withMiddleware(processor),
 		cm.config.IOSPushWorkerCount,
+		messagebus.Deliveries(cm.config.KafkaService, messagebus.TopicIOSPush),
 	)
 	cm.workerPools[IOSPushNotification] = pool
 }
@@ -281,16 +454,20 @@ func (cm *consumerManager) createAndroidPushWorkerPool() {
 
 	// Use injected FCM service if available, otherwise create default
 	if cm.config.FCMService != nil {
-		processor = NewAndroidPushProcessorWithService(cm.config.FCMService)
+		processor = (&androidPushProcessor{
+			fcmService:      cm.config.FCMService,
+			deliveryService: cm.config.DeliveryService,
+		}).withBatching(cm.config.Batching)
 	} else {
 		processor = NewAndroidPushProcessor()
 	}
 
 	pool := NewWorkerPool(
 		AndroidPushNotification,
-		cm.config.KafkaService.GetAndroidPushNotificationChannel(),
-		processor,
+		messagebus.ConsumerChannel(cm.config.KafkaService, messagebus.TopicAndroidPush),
+		cm.withMiddleware(processor),
 		cm.config.AndroidPushWorkerCount,
+		messagebus.Deliveries(cm.config.KafkaService, messagebus.TopicAndroidPush),
 	)
 	cm.workerPools[AndroidPushNotification] = pool
 }
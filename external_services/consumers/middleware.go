@@ -0,0 +1,111 @@
+package consumers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/metrics"
+)
+
+// Outcome label values of the consumer metrics
+const (
+	outcomeSuccess = "success"
+	outcomeError   = "error"
+)
+
+var (
+	consumerMessagesProcessedTotal = metrics.DefaultRegistry.NewCounterVec(
+		"consumer_messages_processed_total",
+		"Messages processed by the channel consumers by notification type and outcome.",
+		"type", "outcome",
+	)
+	consumerProcessingSecondsTotal = metrics.DefaultRegistry.NewCounterVec(
+		"consumer_processing_seconds_total",
+		"Time spent processing messages by notification type.",
+		"type",
+	)
+)
+
+// ProcessFunc processes a single notification message
+type ProcessFunc func(ctx context.Context, message NotificationMessage) error
+
+// ProcessorMiddleware wraps message processing with behavior shared by every channel processor,
+// such as metrics, tracing or content filters. It is given the notification type of the processor
+// it wraps and returns a ProcessFunc that usually calls next.
+type ProcessorMiddleware func(notificationType NotificationType, next ProcessFunc) ProcessFunc
+
+// chainedProcessor is a processor whose messages pass through a middleware chain
+type chainedProcessor struct {
+	NotificationProcessor
+	process ProcessFunc
+}
+
+// ProcessNotification runs the message through the middleware chain
+func (p *chainedProcessor) ProcessNotification(ctx context.Context, message NotificationMessage) error {
+	return p.process(ctx, message)
+}
+
+// WithMiddleware wraps a processor in middleware. The first middleware is the outermost one:
+// it sees every message first and the processing result last.
+func WithMiddleware(processor NotificationProcessor, middleware ...ProcessorMiddleware) NotificationProcessor {
+	if len(middleware) == 0 {
+		return processor
+	}
+
+	notificationType := processor.GetNotificationType()
+	process := processor.ProcessNotification
+	for i := len(middleware) - 1; i >= 0; i-- {
+		process = middleware[i](notificationType, process)
+	}
+
+	return &chainedProcessor{
+		NotificationProcessor: processor,
+		process:               process,
+	}
+}
+
+// DefaultMiddleware returns the middleware every channel processor runs with. Metrics come
+// first so that messages whose processing panicked are counted as errors.
+func DefaultMiddleware() []ProcessorMiddleware {
+	return []ProcessorMiddleware{MetricsMiddleware(), RecoverMiddleware()}
+}
+
+// RecoverMiddleware turns a panic while processing a message into an error,
+// so a single malformed message cannot take down a worker
+func RecoverMiddleware() ProcessorMiddleware {
+	return func(notificationType NotificationType, next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, message NotificationMessage) (err error) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					moduleLog.Error("Recovered from panic while processing notification", logger.Fields{
+						"notification_id": message.ID,
+						"type":            notificationType,
+						"panic":           fmt.Sprint(recovered),
+					})
+					err = fmt.Errorf("panic while processing %s notification: %v", notificationType, recovered)
+				}
+			}()
+			return next(ctx, message)
+		}
+	}
+}
+
+// MetricsMiddleware counts processed messages by outcome and the time spent processing them
+func MetricsMiddleware() ProcessorMiddleware {
+	return func(notificationType NotificationType, next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, message NotificationMessage) error {
+			start := time.Now()
+			err := next(ctx, message)
+
+			outcome := outcomeSuccess
+			if err != nil {
+				outcome = outcomeError
+			}
+			consumerMessagesProcessedTotal.Inc(string(notificationType), outcome)
+			consumerProcessingSecondsTotal.Add(time.Since(start).Seconds(), string(notificationType))
+			return err
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package consumers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMiddleware appends its name to calls before and after the wrapped processing
+func recordingMiddleware(name string, calls *[]string) ProcessorMiddleware {
+	return func(notificationType NotificationType, next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, message NotificationMessage) error {
+			*calls = append(*calls, name+":"+string(notificationType))
+			err := next(ctx, message)
+			*calls = append(*calls, name+":done")
+			return err
+		}
+	}
+}
+
+func TestWithMiddleware_Order(t *testing.T) {
+	processor := new(MockNotificationProcessor)
+	processor.On("GetNotificationType").Return(SlackNotification)
+	processor.On("ProcessNotification", mock.Anything, mock.Anything).Return(nil)
+
+	var calls []string
+	wrapped := WithMiddleware(processor, recordingMiddleware("outer", &calls), recordingMiddleware("inner", &calls))
+
+	require.NoError(t, wrapped.ProcessNotification(context.Background(), NotificationMessage{ID: "msg-1"}))
+	assert.Equal(t, []string{"outer:slack", "inner:slack", "inner:done", "outer:done"}, calls)
+	assert.Equal(t, SlackNotification, wrapped.GetNotificationType())
+	processor.AssertNumberOfCalls(t, "ProcessNotification", 1)
+
+	assert.Same(t, processor, WithMiddleware(processor), "no middleware leaves the processor unwrapped")
+}
+
+func TestWithMiddleware_ShortCircuit(t *testing.T) {
+	processor := new(MockNotificationProcessor)
+	processor.On("GetNotificationType").Return(EmailNotification)
+
+	errBlocked := errors.New("blocked by content filter")
+	filter := func(notificationType NotificationType, next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, message NotificationMessage) error {
+			return errBlocked
+		}
+	}
+
+	wrapped := WithMiddleware(processor, filter)
+	assert.ErrorIs(t, wrapped.ProcessNotification(context.Background(), NotificationMessage{}), errBlocked)
+	processor.AssertNotCalled(t, "ProcessNotification", mock.Anything, mock.Anything)
+}
+
+func TestDefaultMiddleware_RecoversAndCountsPanics(t *testing.T) {
+	processor := new(MockNotificationProcessor)
+	processor.On("GetNotificationType").Return(AndroidPushNotification)
+	processor.On("ProcessNotification", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		panic("malformed payload")
+	})
+
+	before := consumerMessagesProcessedTotal.Value(string(AndroidPushNotification), outcomeError)
+
+	wrapped := WithMiddleware(processor, DefaultMiddleware()...)
+	err := wrapped.ProcessNotification(context.Background(), NotificationMessage{ID: "msg-2"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "malformed payload")
+	assert.Equal(t, before+1, consumerMessagesProcessedTotal.Value(string(AndroidPushNotification), outcomeError))
+}
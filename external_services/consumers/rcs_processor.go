@@ -0,0 +1,103 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gaurav2721/notification-service/external_services/concurrency"
+	"github.com/gaurav2721/notification-service/external_services/delivery"
+	"github.com/gaurav2721/notification-service/external_services/rcs"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// rcsProcessor handles RCS notification processing
+type rcsProcessor struct {
+	rcsService      rcs.RCSService
+	deliveryService delivery.DeliveryService
+}
+
+// NewRCSProcessor creates a new RCS processor
+func NewRCSProcessor() NotificationProcessor {
+	return &rcsProcessor{}
+}
+
+// NewRCSProcessorWithServices creates a new RCS processor that also archives delivery attempts
+func NewRCSProcessorWithServices(rcsService rcs.RCSService, deliveryService delivery.DeliveryService) NotificationProcessor {
+	return &rcsProcessor{
+		rcsService:      rcsService,
+		deliveryService: deliveryService,
+	}
+}
+
+// ProcessNotification processes an RCS notification
+func (rp *rcsProcessor) ProcessNotification(ctx context.Context, message NotificationMessage) error {
+	sampledLog.Debug("Processing rcs notification", logger.Fields{
+		"notification_id": message.ID,
+		"type":            message.Type,
+		"payload":         message.Payload,
+		"timestamp":       message.Timestamp,
+	})
+
+	// If no RCS service is available, just log and return
+	if rp.rcsService == nil {
+		moduleLog.Warn("No rcs service available, skipping rcs notification", nil)
+		return nil
+	}
+
+	// Parse the payload directly into RCSNotificationRequest
+	var rcsNotification models.RCSNotificationRequest
+	if err := json.Unmarshal([]byte(message.Payload), &rcsNotification); err != nil {
+		moduleLog.Error("Failed to parse notification payload into RCSNotificationRequest", logger.Fields{"error": err.Error()})
+		return fmt.Errorf("failed to parse notification payload into RCSNotificationRequest: %w", err)
+	}
+
+	// Use the message ID if not set in the notification
+	if rcsNotification.ID == "" {
+		rcsNotification.ID = message.ID
+	}
+
+	// Use the message type if not set in the notification
+	if rcsNotification.Type == "" {
+		rcsNotification.Type = string(message.Type)
+	}
+
+	sampledLog.Info("Sending rcs notification", logger.Fields{
+		"notification_id": message.ID,
+		"card":            rcsNotification.Content.Card != nil,
+		"suggestions":     len(rcsNotification.Content.Suggestions),
+	})
+
+	// Send the message using the RCS service
+	startedAt := time.Now()
+	response, err := rp.rcsService.SendRCSMessage(ctx, &rcsNotification)
+	recordDeliveryAttempt(rp.deliveryService, &models.DeliveryAttempt{
+		NotificationID: rcsNotification.ID,
+		UserID:         rcsNotification.UserID,
+		Recipient:      rcsNotification.Recipient,
+		Channel:        string(RCSNotification),
+		Provider:       concurrency.ProviderRCS,
+		QueuedAt:       queuedTime(rcsNotification.QueuedAt),
+	}, response, err, startedAt)
+	if err != nil {
+		moduleLog.Error("Failed to send rcs notification", logger.Fields{
+			"notification_id": message.ID,
+			"error":           err.Error(),
+		})
+		return fmt.Errorf("failed to send rcs message: %w", err)
+	}
+
+	sampledLog.Info("RCS notification sent successfully", logger.Fields{
+		"notification_id": message.ID,
+		"response":        response,
+	})
+
+	return nil
+}
+
+// GetNotificationType returns the notification type this processor handles
+func (rp *rcsProcessor) GetNotificationType() NotificationType {
+	return RCSNotification
+}
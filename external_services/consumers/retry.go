@@ -0,0 +1,286 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/metrics"
+)
+
+const (
+	// defaultRetryInitialBackoff is used when no initial backoff is configured
+	defaultRetryInitialBackoff = 500 * time.Millisecond
+
+	// defaultRetryMaxBackoff is used when no maximum backoff is configured
+	defaultRetryMaxBackoff = 30 * time.Second
+)
+
+var (
+	consumerMessageRetriesTotal = metrics.DefaultRegistry.NewCounterVec(
+		"consumer_message_retries_total",
+		"Failed messages processed again after a backoff, by notification type.",
+		"type",
+	)
+	consumerRetriesPending = metrics.DefaultRegistry.NewGaugeVec(
+		"consumer_retries_pending",
+		"Failed messages waiting for their next attempt, by notification type.",
+		"type",
+	)
+)
+
+// RetryConfig configures how often failed messages are processed again before they are moved
+// to the dead-letter queue. Retries only apply to buses that forget messages once they are read;
+// durable buses redeliver failed messages and move them to their own dead-letter queue.
+type RetryConfig struct {
+	// MaxAttempts is how often a message is processed before it is dead-lettered. Zero disables
+	// retries and dead-lettering; one dead-letters messages after their first failure.
+	MaxAttempts int
+
+	// ChannelMaxAttempts overrides MaxAttempts for single notification types
+	ChannelMaxAttempts map[NotificationType]int
+
+	// InitialBackoff is the delay before the first retry. Every further retry waits twice as
+	// long, up to MaxBackoff; delays are jittered by up to half their length.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// DeadLetters receives the messages whose attempts are exhausted, DefaultDeadLetterQueue
+	// when nil
+	DeadLetters *DeadLetterQueue
+}
+
+// retryManager retries failed messages with exponential backoff and dead-letters them once
+// their attempts are exhausted. Messages wait for their next attempt in a delay queue on the
+// clock, so workers go on with the next message during the backoff.
+type retryManager struct {
+	config      RetryConfig
+	deadLetters *DeadLetterQueue
+	clock       clock.Clock
+
+	// jitter returns a random duration in [0, n); replaced in tests
+	jitter func(n int64) int64
+
+	// mu guards the delay queue: the timers of the messages waiting for their next attempt
+	mu      sync.Mutex
+	pending map[*pendingRetry]struct{}
+	stopped bool
+
+	// running counts the attempts started by the delay queue, so stop waits for them
+	running sync.WaitGroup
+}
+
+// pendingRetry is a message in the delay queue
+type pendingRetry struct {
+	notificationType NotificationType
+	timer            clock.Timer
+}
+
+// newRetryManager creates a retry manager whose backoffs run on c, filling in the defaults of
+// the config
+func newRetryManager(config RetryConfig, c clock.Clock) *retryManager {
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = defaultRetryInitialBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaultRetryMaxBackoff
+	}
+	if config.MaxBackoff < config.InitialBackoff {
+		config.MaxBackoff = config.InitialBackoff
+	}
+	deadLetters := config.DeadLetters
+	if deadLetters == nil {
+		deadLetters = DefaultDeadLetterQueue
+	}
+	if c == nil {
+		c = clock.Real()
+	}
+	return &retryManager{
+		config:      config,
+		deadLetters: deadLetters,
+		clock:       c,
+		jitter:      rand.Int63n,
+		pending:     make(map[*pendingRetry]struct{}),
+	}
+}
+
+// maxAttempts returns how often messages of a notification type are processed at most
+func (r *retryManager) maxAttempts(notificationType NotificationType) int {
+	if attempts, ok := r.config.ChannelMaxAttempts[notificationType]; ok && attempts > 0 {
+		return attempts
+	}
+	if r.config.MaxAttempts > 0 {
+		return r.config.MaxAttempts
+	}
+	return 1
+}
+
+// backoff returns the delay before the given retry, counting from one. The delay doubles with
+// every retry up to MaxBackoff, and its second half is random so that messages which failed
+// together are not retried together.
+func (r *retryManager) backoff(retry int) time.Duration {
+	delay := r.config.InitialBackoff
+	for i := 1; i < retry && delay < r.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.config.MaxBackoff {
+		delay = r.config.MaxBackoff
+	}
+
+	half := delay / 2
+	return half + time.Duration(r.jitter(int64(delay-half)+1))
+}
+
+// middleware retries the messages of a channel and dead-letters them once their attempts are
+// exhausted. Dead letters are replayed to channel; they cannot be replayed when it is nil. A
+// failed message is handed to the delay queue and counts as processed for the worker; its next
+// attempt runs once the backoff elapsed. Messages failing once ctx is done are not retried.
+func (r *retryManager) middleware(channel chan string) ProcessorMiddleware {
+	return func(notificationType NotificationType, next ProcessFunc) ProcessFunc {
+		maxAttempts := r.maxAttempts(notificationType)
+		return func(ctx context.Context, message NotificationMessage) error {
+			return r.attempt(ctx, message, notificationType, next, channel, maxAttempts, 1, time.Time{})
+		}
+	}
+}
+
+// attempt processes a message and, when it fails, schedules its next attempt or dead-letters it.
+// firstFailedAt is zero until an attempt failed.
+func (r *retryManager) attempt(
+	ctx context.Context,
+	message NotificationMessage,
+	notificationType NotificationType,
+	next ProcessFunc,
+	channel chan string,
+	maxAttempts, attempt int,
+	firstFailedAt time.Time,
+) error {
+	err := next(ctx, message)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	if firstFailedAt.IsZero() {
+		firstFailedAt = r.clock.Now()
+	}
+
+	if attempt >= maxAttempts || !isRetryable(err) {
+		r.deadLetters.add(&DeadLetter{
+			Type:           notificationType,
+			Message:        message.Payload,
+			Error:          err.Error(),
+			Attempts:       attempt,
+			FirstFailedAt:  firstFailedAt,
+			DeadLetteredAt: r.clock.Now(),
+			channel:        channel,
+		})
+		moduleLog.Error("Moved message to the dead-letter queue", logger.Fields{
+			"notification_id": message.ID,
+			"type":            notificationType,
+			"attempts":        attempt,
+			"error":           err.Error(),
+		})
+		return err
+	}
+
+	// The worker's context ends when the pool scales down; stop drops pending retries on shutdown
+	retryCtx := context.WithoutCancel(ctx)
+	delay := r.backoff(attempt)
+	scheduled := r.schedule(notificationType, delay, func() {
+		consumerMessageRetriesTotal.Inc(string(notificationType))
+		r.attempt(retryCtx, message, notificationType, next, channel, maxAttempts, attempt+1, firstFailedAt)
+	})
+	if !scheduled {
+		return err
+	}
+	sampledLog.Warn("Retrying failed message", logger.Fields{
+		"notification_id": message.ID,
+		"type":            notificationType,
+		"attempt":         attempt,
+		"delay":           delay.String(),
+		"error":           err.Error(),
+	})
+	return nil
+}
+
+// schedule adds a message to the delay queue, running retry once delay elapsed. It returns false
+// once the queue is stopped.
+func (r *retryManager) schedule(notificationType NotificationType, delay time.Duration, retry func()) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		return false
+	}
+
+	entry := &pendingRetry{notificationType: notificationType}
+	entry.timer = r.clock.AfterFunc(delay, func() {
+		r.mu.Lock()
+		if _, ok := r.pending[entry]; !ok {
+			r.mu.Unlock()
+			return
+		}
+		r.remove(entry)
+		r.running.Add(1)
+		r.mu.Unlock()
+
+		defer r.running.Done()
+		retry()
+	})
+	r.pending[entry] = struct{}{}
+	consumerRetriesPending.Set(float64(r.countPending(notificationType)), string(notificationType))
+	return true
+}
+
+// remove takes a message off the delay queue; r.mu must be held
+func (r *retryManager) remove(entry *pendingRetry) {
+	delete(r.pending, entry)
+	consumerRetriesPending.Set(float64(r.countPending(entry.notificationType)), string(entry.notificationType))
+}
+
+// countPending returns the messages of a notification type in the delay queue; r.mu must be held
+func (r *retryManager) countPending(notificationType NotificationType) int {
+	count := 0
+	for entry := range r.pending {
+		if entry.notificationType == notificationType {
+			count++
+		}
+	}
+	return count
+}
+
+// start lets the delay queue take messages again after stop
+func (r *retryManager) start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopped = false
+}
+
+// stop drops the messages waiting in the delay queue and waits for the attempts already
+// running. In-process channels lose their buffered messages on shutdown as well.
+func (r *retryManager) stop() {
+	r.mu.Lock()
+	r.stopped = true
+	dropped := len(r.pending)
+	for entry := range r.pending {
+		entry.timer.Stop()
+		r.remove(entry)
+	}
+	r.mu.Unlock()
+
+	if dropped > 0 {
+		moduleLog.Warn("Dropped messages waiting for a retry", logger.Fields{"count": dropped})
+	}
+	r.running.Wait()
+}
+
+// isRetryable reports whether processing a message again could succeed. Messages whose payload
+// cannot be parsed fail the same way every time.
+func isRetryable(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return !errors.As(err, &syntaxErr) && !errors.As(err, &typeErr)
+}
@@ -0,0 +1,176 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRetryManager creates a retry manager with a millisecond backoff and no jitter, whose
+// delay queue runs on the returned fake clock
+func newTestRetryManager(config RetryConfig) (*retryManager, *clock.Fake) {
+	config.InitialBackoff = time.Millisecond
+	config.MaxBackoff = 4 * time.Millisecond
+	if config.DeadLetters == nil {
+		config.DeadLetters = NewDeadLetterQueue(10)
+	}
+	fake := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	retries := newRetryManager(config, fake)
+	retries.jitter = func(n int64) int64 { return 0 }
+	return retries, fake
+}
+
+// failingProcess fails the first failures calls with err and counts all calls
+func failingProcess(failures int, err error, calls *int) ProcessFunc {
+	return func(ctx context.Context, message NotificationMessage) error {
+		*calls++
+		if *calls <= failures {
+			return err
+		}
+		return nil
+	}
+}
+
+func TestRetryManager_RetriesUntilSuccess(t *testing.T) {
+	retries, fake := newTestRetryManager(RetryConfig{MaxAttempts: 3})
+	calls := 0
+	process := retries.middleware(nil)(SlackNotification, failingProcess(2, errors.New("status 503"), &calls))
+
+	require.NoError(t, process(context.Background(), NotificationMessage{Payload: `{"id":"notif-1"}`}))
+	assert.Equal(t, 1, calls, "the worker does not wait for the backoff")
+	assert.Equal(t, 1, fake.PendingTimers())
+
+	fake.Advance(time.Second)
+	assert.Equal(t, 3, calls)
+	assert.Zero(t, fake.PendingTimers())
+	assert.Zero(t, retries.deadLetters.Len())
+}
+
+func TestRetryManager_DeadLettersExhaustedMessages(t *testing.T) {
+	retries, fake := newTestRetryManager(RetryConfig{
+		MaxAttempts:        5,
+		ChannelMaxAttempts: map[NotificationType]int{EmailNotification: 2},
+	})
+	channel := make(chan string, 1)
+	calls := 0
+	errSMTP := errors.New("smtp: 421 service not available")
+	process := retries.middleware(channel)(EmailNotification, failingProcess(10, errSMTP, &calls))
+
+	require.NoError(t, process(context.Background(), NotificationMessage{Payload: `{"id":"notif-2"}`}))
+	fake.Advance(time.Second)
+	assert.Equal(t, 2, calls, "the email channel overrides the max attempts")
+
+	letters := retries.deadLetters.List(EmailNotification)
+	require.Len(t, letters, 1)
+	assert.Equal(t, `{"id":"notif-2"}`, letters[0].Message)
+	assert.Equal(t, errSMTP.Error(), letters[0].Error)
+	assert.Equal(t, 2, letters[0].Attempts)
+	assert.Equal(t, time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), letters[0].FirstFailedAt)
+	assert.Equal(t, time.Date(2024, 1, 1, 9, 0, 0, 500000, time.UTC), letters[0].DeadLetteredAt, "timestamps follow the clock")
+	assert.Empty(t, retries.deadLetters.List(SlackNotification))
+
+	replayed, err := retries.deadLetters.Replay(letters[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, letters[0].ID, replayed.ID)
+	assert.Equal(t, `{"id":"notif-2"}`, <-channel)
+	assert.Zero(t, retries.deadLetters.Len())
+
+	_, err = retries.deadLetters.Replay(letters[0].ID)
+	assert.ErrorIs(t, err, ErrDeadLetterNotFound)
+}
+
+func TestRetryManager_DoesNotRetryMalformedPayloads(t *testing.T) {
+	retries, fake := newTestRetryManager(RetryConfig{MaxAttempts: 3})
+	calls := 0
+	parseErr := fmt.Errorf("failed to parse notification payload: %w", json.Unmarshal([]byte("{"), &struct{}{}))
+	process := retries.middleware(nil)(IOSPushNotification, failingProcess(10, parseErr, &calls))
+
+	require.Error(t, process(context.Background(), NotificationMessage{Payload: "{"}))
+	assert.Equal(t, 1, calls)
+	assert.Zero(t, fake.PendingTimers())
+
+	letters := retries.deadLetters.List("")
+	require.Len(t, letters, 1)
+	_, err := retries.deadLetters.Replay(letters[0].ID)
+	assert.ErrorIs(t, err, ErrDeadLetterNotReplayable)
+
+	_, err = retries.deadLetters.Discard(letters[0].ID)
+	require.NoError(t, err)
+	assert.Zero(t, retries.deadLetters.Len())
+}
+
+func TestRetryManager_Backoff(t *testing.T) {
+	retries := newRetryManager(RetryConfig{MaxAttempts: 10, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}, nil)
+	retries.jitter = func(n int64) int64 { return n - 1 }
+
+	assert.Equal(t, 100*time.Millisecond, retries.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, retries.backoff(2))
+	assert.Equal(t, 800*time.Millisecond, retries.backoff(4))
+	assert.Equal(t, time.Second, retries.backoff(5), "delays are capped at the max backoff")
+
+	retries.jitter = func(n int64) int64 { return 0 }
+	assert.Equal(t, 50*time.Millisecond, retries.backoff(1), "jitter takes up to half of the delay")
+}
+
+func TestDeadLetterQueue_DropsOldestWhenFull(t *testing.T) {
+	queue := NewDeadLetterQueue(2)
+	for _, payload := range []string{"first", "second", "third"} {
+		queue.add(&DeadLetter{Type: SlackNotification, Message: payload})
+	}
+
+	letters := queue.List("")
+	require.Len(t, letters, 2)
+	assert.Equal(t, "third", letters[0].Message, "newest first")
+	assert.Equal(t, "second", letters[1].Message)
+}
+
+func TestRetryManager_StopsRetryingOnShutdown(t *testing.T) {
+	retries, fake := newTestRetryManager(RetryConfig{MaxAttempts: 3})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	process := retries.middleware(nil)(EmailNotification, func(ctx context.Context, message NotificationMessage) error {
+		calls++
+		cancel()
+		return errors.New("connection reset")
+	})
+
+	assert.Error(t, process(ctx, NotificationMessage{}), "messages failing during shutdown are not retried")
+	assert.Zero(t, fake.PendingTimers())
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryManager_DelayQueueOutlivesWorker(t *testing.T) {
+	retries, fake := newTestRetryManager(RetryConfig{MaxAttempts: 3})
+	calls := 0
+	process := retries.middleware(nil)(SlackNotification, failingProcess(1, errors.New("status 503"), &calls))
+
+	// The worker that failed the message is drained before the retry is due
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, process(ctx, NotificationMessage{}))
+	cancel()
+	assert.Equal(t, 1.0, consumerRetriesPending.Value(string(SlackNotification)))
+
+	fake.Advance(time.Second)
+	assert.Equal(t, 2, calls)
+	assert.Zero(t, consumerRetriesPending.Value(string(SlackNotification)))
+
+	// Stopping drops the waiting messages until the queue is started again
+	failures := 0
+	failing := retries.middleware(nil)(SlackNotification, failingProcess(10, errors.New("status 503"), &failures))
+	require.NoError(t, failing(context.Background(), NotificationMessage{}))
+	retries.stop()
+	assert.Zero(t, fake.PendingTimers())
+	assert.Error(t, failing(context.Background(), NotificationMessage{}), "a stopped queue takes no messages")
+
+	retries.start()
+	require.NoError(t, failing(context.Background(), NotificationMessage{}))
+	assert.Equal(t, 1, fake.PendingTimers())
+}
@@ -4,15 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
+	"github.com/gaurav2721/notification-service/external_services/concurrency"
+	"github.com/gaurav2721/notification-service/external_services/delivery"
 	"github.com/gaurav2721/notification-service/external_services/slack"
+	"github.com/gaurav2721/notification-service/logger"
 	"github.com/gaurav2721/notification-service/models"
-	"github.com/sirupsen/logrus"
 )
 
 // slackProcessor handles slack notification processing
 type slackProcessor struct {
-	slackService slack.SlackService
+	slackService    slack.SlackService
+	deliveryService delivery.DeliveryService
 }
 
 // NewSlackProcessor creates a new slack processor
@@ -27,25 +31,33 @@ func NewSlackProcessorWithService(slackService slack.SlackService) NotificationP
 	}
 }
 
+// NewSlackProcessorWithServices creates a new slack processor that also archives delivery attempts
+func NewSlackProcessorWithServices(slackService slack.SlackService, deliveryService delivery.DeliveryService) NotificationProcessor {
+	return &slackProcessor{
+		slackService:    slackService,
+		deliveryService: deliveryService,
+	}
+}
+
 // ProcessNotification processes a slack notification
 func (sp *slackProcessor) ProcessNotification(ctx context.Context, message NotificationMessage) error {
-	logrus.WithFields(logrus.Fields{
+	sampledLog.Debug("Processing slack notification", logger.Fields{
 		"notification_id": message.ID,
 		"type":            message.Type,
 		"payload":         message.Payload,
 		"timestamp":       message.Timestamp,
-	}).Debug("Processing slack notification")
+	})
 
 	// If no slack service is available, just log and return
 	if sp.slackService == nil {
-		logrus.Warn("No slack service available, skipping slack notification")
+		moduleLog.Warn("No slack service available, skipping slack notification", nil)
 		return nil
 	}
 
 	// Parse the payload directly into SlackNotificationRequest
 	var slackNotification models.SlackNotificationRequest
 	if err := json.Unmarshal([]byte(message.Payload), &slackNotification); err != nil {
-		logrus.WithError(err).Error("Failed to parse notification payload into SlackNotificationRequest")
+		moduleLog.Error("Failed to parse notification payload into SlackNotificationRequest", logger.Fields{"error": err.Error()})
 		return fmt.Errorf("failed to parse notification payload into SlackNotificationRequest: %w", err)
 	}
 
@@ -62,27 +74,36 @@ func (sp *slackProcessor) ProcessNotification(ctx context.Context, message Notif
 	// Extract channel information for logging
 	channel := slackNotification.Recipient
 
-	logrus.WithFields(logrus.Fields{
+	sampledLog.Info("Sending slack notification", logger.Fields{
 		"notification_id": message.ID,
 		"channel":         channel,
 		"text":            slackNotification.Content.Text,
-	}).Info("Sending slack notification")
+	})
 
 	// Send slack message using the slack service
+	startedAt := time.Now()
 	response, err := sp.slackService.SendSlackMessage(ctx, &slackNotification)
+	recordDeliveryAttempt(sp.deliveryService, &models.DeliveryAttempt{
+		NotificationID: slackNotification.ID,
+		UserID:         slackNotification.UserID,
+		Recipient:      slackNotification.Recipient,
+		Channel:        string(SlackNotification),
+		Provider:       concurrency.ProviderSlack,
+		QueuedAt:       queuedTime(slackNotification.QueuedAt),
+	}, response, err, startedAt)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{
+		moduleLog.Error("Failed to send slack notification", logger.Fields{
 			"notification_id": message.ID,
 			"error":           err.Error(),
-		}).Error("Failed to send slack notification")
+		})
 		return fmt.Errorf("failed to send slack message: %w", err)
 	}
 
 	// Log successful slack sending
-	logrus.WithFields(logrus.Fields{
+	sampledLog.Info("Slack notification sent successfully", logger.Fields{
 		"notification_id": message.ID,
 		"response":        response,
-	}).Info("Slack notification sent successfully")
+	})
 
 	return nil
 }
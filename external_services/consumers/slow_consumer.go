@@ -0,0 +1,280 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gaurav2721/notification-service/external_services/slack"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultSlowConsumerCheckInterval is used when no check interval is configured
+	defaultSlowConsumerCheckInterval = 10 * time.Second
+
+	// systemAlertTimeout bounds sending a Slack system alert
+	systemAlertTimeout = 10 * time.Second
+)
+
+var (
+	consumerOldestMessageAgeSeconds = metrics.DefaultRegistry.NewGaugeVec(
+		"consumer_oldest_message_age_seconds",
+		"Age of the oldest message a channel consumer has not finished processing, by notification type.",
+		"type",
+	)
+	consumerSlowAlertsTotal = metrics.DefaultRegistry.NewCounterVec(
+		"consumer_slow_alerts_total",
+		"Times a channel consumer fell behind the slow consumer threshold, by notification type.",
+		"type",
+	)
+)
+
+// SlowConsumerConfig configures the detection of channels whose messages wait too long
+type SlowConsumerConfig struct {
+	// Threshold is the age of the oldest unprocessed message above which a channel is slow.
+	// Zero disables detection.
+	Threshold time.Duration
+
+	// CheckInterval is how often message ages are checked
+	CheckInterval time.Duration
+
+	// AlertSlackChannel, when set, receives a Slack system alert when a channel becomes slow
+	// and when it recovers
+	AlertSlackChannel string
+
+	// ScaleUpWorkers is the number of workers temporarily added to a slow channel until it
+	// recovers. Zero disables scaling.
+	ScaleUpWorkers int
+}
+
+// messageAges tracks when the messages a pool is processing were queued
+type messageAges struct {
+	mu       sync.Mutex
+	inFlight map[uint64]time.Time
+	nextID   uint64
+
+	// lastWait is how long the most recently started message waited in the channel
+	lastWait time.Duration
+}
+
+func newMessageAges() *messageAges {
+	return &messageAges{inFlight: make(map[uint64]time.Time)}
+}
+
+// start records that processing of a message queued at queuedAt began and returns its handle
+func (a *messageAges) start(queuedAt, now time.Time) uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.nextID++
+	a.inFlight[a.nextID] = queuedAt
+	a.lastWait = now.Sub(queuedAt)
+	return a.nextID
+}
+
+// done records that processing of a message finished
+func (a *messageAges) done(id uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.inFlight, id)
+}
+
+// oldest returns the age of the oldest unprocessed message. Channels deliver messages in order,
+// so it is the oldest message in flight. Between two messages it is estimated from how long the
+// last message waited, as long as backlog reports messages left in the channel.
+func (a *messageAges) oldest(now time.Time, backlog bool) time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var oldest time.Duration
+	for _, queuedAt := range a.inFlight {
+		if age := now.Sub(queuedAt); age > oldest {
+			oldest = age
+		}
+	}
+	if len(a.inFlight) == 0 && backlog {
+		oldest = a.lastWait
+	}
+	return oldest
+}
+
+// middleware records the queue time of every message while the wrapped processor handles it
+func (a *messageAges) middleware() ProcessorMiddleware {
+	return func(notificationType NotificationType, next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, message NotificationMessage) error {
+			now := time.Now()
+			id := a.start(queuedAt(message.Payload, now), now)
+			defer a.done(id)
+			return next(ctx, message)
+		}
+	}
+}
+
+// queuedAt reads the time a message was posted to its channel, defaulting to now for messages
+// that were not stamped
+func queuedAt(payload string, now time.Time) time.Time {
+	var stamp struct {
+		QueuedAt int64 `json:"queued_at"`
+	}
+	if err := json.Unmarshal([]byte(payload), &stamp); err != nil || stamp.QueuedAt <= 0 {
+		return now
+	}
+	if queued := time.UnixMilli(stamp.QueuedAt); queued.Before(now) {
+		return queued
+	}
+	return now
+}
+
+// scalableWorkerPool is a worker pool that can temporarily run additional workers
+type scalableWorkerPool interface {
+	ScaleUp(count int) error
+	ScaleDown()
+}
+
+// slowConsumerMonitor checks the age of the oldest unprocessed message of every channel and
+// raises an alert while it exceeds the threshold
+type slowConsumerMonitor struct {
+	config       SlowConsumerConfig
+	slackService slack.SlackService
+	pools        map[NotificationType]ConsumerWorkerPool
+	ages         map[NotificationType]*messageAges
+	slow         map[NotificationType]bool
+	now          func() time.Time
+}
+
+func newSlowConsumerMonitor(config SlowConsumerConfig, slackService slack.SlackService) *slowConsumerMonitor {
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = defaultSlowConsumerCheckInterval
+	}
+	return &slowConsumerMonitor{
+		config:       config,
+		slackService: slackService,
+		pools:        make(map[NotificationType]ConsumerWorkerPool),
+		ages:         make(map[NotificationType]*messageAges),
+		slow:         make(map[NotificationType]bool),
+		now:          time.Now,
+	}
+}
+
+// track returns the middleware that records message ages of a pool's channel
+func (m *slowConsumerMonitor) track(notificationType NotificationType) ProcessorMiddleware {
+	ages := newMessageAges()
+	m.ages[notificationType] = ages
+	return ages.middleware()
+}
+
+// watch adds a pool to the monitored pools
+func (m *slowConsumerMonitor) watch(pool ConsumerWorkerPool) {
+	m.pools[pool.GetNotificationType()] = pool
+}
+
+// run checks the channels every CheckInterval until ctx is cancelled
+func (m *slowConsumerMonitor) run(ctx context.Context) {
+	ticker := time.NewTicker(m.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.check(ctx)
+		}
+	}
+}
+
+// check updates the age metric of every channel and alerts on channels that became slow or
+// recovered
+func (m *slowConsumerMonitor) check(ctx context.Context) {
+	now := m.now()
+	for notificationType, pool := range m.pools {
+		ages, ok := m.ages[notificationType]
+		if !ok {
+			continue
+		}
+
+		age := ages.oldest(now, len(pool.GetChannel()) > 0)
+		consumerOldestMessageAgeSeconds.Set(age.Seconds(), string(notificationType))
+
+		slow := age > m.config.Threshold
+		if slow == m.slow[notificationType] {
+			continue
+		}
+		m.slow[notificationType] = slow
+
+		if slow {
+			m.raise(ctx, notificationType, pool, age)
+		} else {
+			m.resolve(ctx, notificationType, pool)
+		}
+	}
+}
+
+// raise alerts that a channel fell behind and adds workers to it when scaling is enabled
+func (m *slowConsumerMonitor) raise(ctx context.Context, notificationType NotificationType, pool ConsumerWorkerPool, age time.Duration) {
+	consumerSlowAlertsTotal.Inc(string(notificationType))
+	moduleLog.Warn("Slow consumer detected", logger.Fields{
+		"type":      notificationType,
+		"age":       age.Round(time.Second).String(),
+		"threshold": m.config.Threshold.String(),
+		"workers":   pool.GetWorkerCount(),
+	})
+
+	text := fmt.Sprintf(":warning: The %s consumer is falling behind: its oldest unprocessed message is %s old (threshold %s).",
+		notificationType, age.Round(time.Second), m.config.Threshold)
+
+	if scalable, ok := pool.(scalableWorkerPool); ok && m.config.ScaleUpWorkers > 0 {
+		if err := scalable.ScaleUp(m.config.ScaleUpWorkers); err != nil {
+			moduleLog.Error("Failed to scale up slow consumer", logger.Fields{"type": notificationType, "error": err.Error()})
+		} else {
+			moduleLog.Info("Scaled up slow consumer", logger.Fields{"type": notificationType, "workers": pool.GetWorkerCount()})
+			text += fmt.Sprintf(" Added %d workers until it catches up.", m.config.ScaleUpWorkers)
+		}
+	}
+
+	m.sendAlert(ctx, text)
+}
+
+// resolve reports that a channel caught up and removes the workers added to it
+func (m *slowConsumerMonitor) resolve(ctx context.Context, notificationType NotificationType, pool ConsumerWorkerPool) {
+	moduleLog.Info("Slow consumer recovered", logger.Fields{"type": notificationType})
+
+	if scalable, ok := pool.(scalableWorkerPool); ok && m.config.ScaleUpWorkers > 0 {
+		scalable.ScaleDown()
+	}
+
+	m.sendAlert(ctx, fmt.Sprintf(":white_check_mark: The %s consumer caught up.", notificationType))
+}
+
+// sendAlert posts a system alert to Slack when an alert channel is configured
+func (m *slowConsumerMonitor) sendAlert(ctx context.Context, text string) {
+	if err := sendSystemAlert(ctx, m.slackService, m.config.AlertSlackChannel, text); err != nil {
+		moduleLog.Error("Failed to send slow consumer alert", logger.Fields{"error": err.Error()})
+	}
+}
+
+// sendSystemAlert posts a system alert to a Slack channel; nothing is sent without a channel
+// or Slack service
+func sendSystemAlert(ctx context.Context, slackService slack.SlackService, slackChannel, text string) error {
+	if slackChannel == "" || slackService == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, systemAlertTimeout)
+	defer cancel()
+
+	alert := &models.SlackNotificationRequest{
+		ID:        uuid.New().String(),
+		Type:      "slack",
+		Content:   models.SlackContent{Text: text},
+		Recipient: slackChannel,
+	}
+	_, err := slackService.SendSlackMessage(ctx, alert)
+	return err
+}
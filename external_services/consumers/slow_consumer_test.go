@@ -0,0 +1,109 @@
+package consumers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSlackService records the text of every Slack message sent
+type recordingSlackService struct {
+	mu    sync.Mutex
+	texts []string
+}
+
+func (s *recordingSlackService) SendSlackMessage(ctx context.Context, notification interface{}) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.texts = append(s.texts, notification.(*models.SlackNotificationRequest).Content.Text)
+	return nil, nil
+}
+
+// blockingProcessor holds every message until release is closed
+type blockingProcessor struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingProcessor) ProcessNotification(ctx context.Context, message NotificationMessage) error {
+	p.started <- struct{}{}
+	<-p.release
+	return nil
+}
+
+func (p *blockingProcessor) GetNotificationType() NotificationType {
+	return EmailNotification
+}
+
+func TestQueuedAt(t *testing.T) {
+	now := time.UnixMilli(1_700_000_060_000)
+
+	assert.Equal(t, time.UnixMilli(1_700_000_000_000), queuedAt(`{"id":"n-1","queued_at":1700000000000}`, now))
+	assert.Equal(t, now, queuedAt(`{"id":"n-1"}`, now), "unstamped messages count from now")
+	assert.Equal(t, now, queuedAt(`{"queued_at":1800000000000}`, now), "clock skew never yields negative ages")
+	assert.Equal(t, now, queuedAt("not json", now))
+}
+
+func TestMessageAges_Oldest(t *testing.T) {
+	now := time.Now()
+	ages := newMessageAges()
+
+	assert.Zero(t, ages.oldest(now, true))
+
+	first := ages.start(now.Add(-90*time.Second), now)
+	ages.start(now.Add(-30*time.Second), now)
+	assert.Equal(t, 90*time.Second, ages.oldest(now, false))
+	assert.Equal(t, 100*time.Second, ages.oldest(now.Add(10*time.Second), false))
+
+	ages.done(first)
+	ages.done(first + 1)
+	assert.Equal(t, 30*time.Second, ages.oldest(now, true), "a backlog is as old as the last message waited")
+	assert.Zero(t, ages.oldest(now, false))
+}
+
+func TestSlowConsumerMonitor_AlertsAndScalesUp(t *testing.T) {
+	processor := &blockingProcessor{started: make(chan struct{}, 4), release: make(chan struct{})}
+	slackService := &recordingSlackService{}
+	monitor := newSlowConsumerMonitor(SlowConsumerConfig{
+		Threshold:         time.Minute,
+		AlertSlackChannel: "ops-alerts",
+		ScaleUpWorkers:    2,
+	}, slackService)
+
+	channel := make(chan string, 4)
+	pool := NewWorkerPool(EmailNotification, channel, WithMiddleware(processor, monitor.track(EmailNotification)), 1, nil)
+	monitor.watch(pool)
+	require.NoError(t, pool.Start(context.Background()))
+	defer pool.Stop()
+
+	channel <- fmt.Sprintf(`{"id":"n-1","queued_at":%d}`, time.Now().Add(-2*time.Minute).UnixMilli())
+	<-processor.started
+
+	monitor.check(context.Background())
+	assert.Equal(t, 3, pool.GetWorkerCount())
+	assert.Equal(t, 1.0, consumerSlowAlertsTotal.Value("email"))
+	assert.GreaterOrEqual(t, consumerOldestMessageAgeSeconds.Value("email"), 120.0)
+
+	// The alert is raised once while the channel stays slow
+	monitor.check(context.Background())
+	assert.Equal(t, 1.0, consumerSlowAlertsTotal.Value("email"))
+
+	close(processor.release)
+	assert.Eventually(t, func() bool {
+		monitor.check(context.Background())
+		return pool.GetWorkerCount() == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Zero(t, consumerOldestMessageAgeSeconds.Value("email"))
+
+	slackService.mu.Lock()
+	defer slackService.mu.Unlock()
+	require.Len(t, slackService.texts, 2)
+	assert.Contains(t, slackService.texts[0], "The email consumer is falling behind: its oldest unprocessed message is 2m0s old (threshold 1m0s). Added 2 workers")
+	assert.Equal(t, ":white_check_mark: The email consumer caught up.", slackService.texts[1])
+}
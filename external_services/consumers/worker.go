@@ -6,29 +6,46 @@ import (
 	"sync"
 	"time"
 
+	"github.com/gaurav2721/notification-service/external_services/messagebus"
+	"github.com/gaurav2721/notification-service/logger"
 	"github.com/google/uuid"
-	"github.com/sirupsen/logrus"
 )
 
 // worker represents a single worker that processes notifications
 type worker struct {
-	id        string
-	channel   chan string
-	processor NotificationProcessor
-	running   bool
-	ctx       context.Context
-	cancel    context.CancelFunc
-	wg        sync.WaitGroup
-	mu        sync.RWMutex
+	id         string
+	channel    chan string
+	deliveries chan messagebus.Delivery
+	processor  NotificationProcessor
+	running    bool
+	drain      chan struct{} // closed by Drain to stop after the current message
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+	mu         sync.RWMutex
 }
 
 // NewWorker creates a new worker instance
 func NewWorker(channel chan string, processor NotificationProcessor) ConsumerWorker {
+	return newWorker(channel, nil, processor)
+}
+
+// NewDeliveryWorker creates a worker that processes broker deliveries and settles every one
+// with its outcome, so durable queues keep messages until they were processed
+func NewDeliveryWorker(deliveries chan messagebus.Delivery, processor NotificationProcessor) ConsumerWorker {
+	return newWorker(nil, deliveries, processor)
+}
+
+// newWorker creates a worker that can also be drained. It reads from channel or deliveries,
+// whichever is not nil.
+func newWorker(channel chan string, deliveries chan messagebus.Delivery, processor NotificationProcessor) *worker {
 	return &worker{
-		id:        uuid.New().String(),
-		channel:   channel,
-		processor: processor,
-		running:   false,
+		id:         uuid.New().String(),
+		channel:    channel,
+		deliveries: deliveries,
+		processor:  processor,
+		running:    false,
+		drain:      make(chan struct{}),
 	}
 }
 
@@ -47,10 +64,10 @@ func (w *worker) Start(ctx context.Context) error {
 
 	go w.processLoop()
 
-	logrus.WithFields(logrus.Fields{
+	moduleLog.Debug("Worker started", logger.Fields{
 		"worker_id": w.id,
 		"type":      w.processor.GetNotificationType(),
-	}).Debug("Worker started")
+	})
 	return nil
 }
 
@@ -71,10 +88,30 @@ func (w *worker) Stop() error {
 	// Wait for the worker to finish processing
 	w.wg.Wait()
 
-	logrus.WithField("worker_id", w.id).Debug("Worker stopped")
+	moduleLog.Debug("Worker stopped", logger.Fields{"worker_id": w.id})
 	return nil
 }
 
+// Drain stops the worker once it finished the message it is processing. Unlike Stop, it does
+// not cancel the context the message is processed with.
+func (w *worker) Drain() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.running {
+		return
+	}
+
+	w.running = false
+	close(w.drain)
+	w.wg.Wait()
+	if w.cancel != nil {
+		w.cancel()
+	}
+
+	moduleLog.Debug("Worker drained", logger.Fields{"worker_id": w.id})
+}
+
 // IsRunning returns true if the worker is currently running
 func (w *worker) IsRunning() bool {
 	w.mu.RLock()
@@ -91,48 +128,64 @@ func (w *worker) GetWorkerID() string {
 func (w *worker) processLoop() {
 	defer w.wg.Done()
 
-	logrus.WithFields(logrus.Fields{
+	moduleLog.Debug("Worker processing loop started", logger.Fields{
 		"worker_id": w.id,
 		"type":      w.processor.GetNotificationType(),
-	}).Debug("Worker processing loop started")
+	})
 
 	for {
 		select {
 		case <-w.ctx.Done():
-			logrus.WithField("worker_id", w.id).Debug("Worker received shutdown signal")
+			moduleLog.Debug("Worker received shutdown signal", logger.Fields{"worker_id": w.id})
+			return
+
+		case <-w.drain:
 			return
 
 		case message, ok := <-w.channel:
 			if !ok {
-				logrus.WithField("worker_id", w.id).Debug("Worker: channel closed")
+				moduleLog.Debug("Worker: channel closed", logger.Fields{"worker_id": w.id})
 				return
 			}
+			w.handle(message)
 
-			logrus.WithFields(logrus.Fields{
-				"worker_id": w.id,
-				"message":   message,
-			}).Debug("Worker received message from channel")
-
-			// Process the notification
-			if err := w.processMessage(message); err != nil {
-				logrus.WithFields(logrus.Fields{
-					"worker_id": w.id,
-					"error":     err.Error(),
-				}).Error("Worker error processing message")
-				// Continue processing other messages even if one fails
+		case delivery, ok := <-w.deliveries:
+			if !ok {
+				moduleLog.Debug("Worker: delivery channel closed", logger.Fields{"worker_id": w.id})
+				return
 			}
+			delivery.Settle(w.handle(delivery.Payload()))
 		}
 	}
 }
 
+// handle processes a message received by the worker and returns the outcome
+func (w *worker) handle(message string) error {
+	sampledLog.Debug("Worker received message from channel", logger.Fields{
+		"worker_id": w.id,
+		"message":   message,
+	})
+
+	// Process the notification
+	err := w.processMessage(message)
+	if err != nil {
+		moduleLog.Error("Worker error processing message", logger.Fields{
+			"worker_id": w.id,
+			"error":     err.Error(),
+		})
+		// Continue processing other messages even if one fails
+	}
+	return err
+}
+
 // processMessage processes a single notification message
 func (w *worker) processMessage(message string) error {
 	start := time.Now()
 
-	logrus.WithFields(logrus.Fields{
+	sampledLog.Debug("Worker starting to process message", logger.Fields{
 		"worker_id": w.id,
 		"message":   message,
-	}).Debug("Worker starting to process message")
+	})
 
 	// Parse the message into NotificationMessage
 	// This is a simplified version - in a real implementation,
@@ -144,33 +197,33 @@ func (w *worker) processMessage(message string) error {
 		Timestamp: time.Now().Unix(),
 	}
 
-	logrus.WithFields(logrus.Fields{
+	sampledLog.Debug("Worker created notification message", logger.Fields{
 		"worker_id":       w.id,
 		"notification_id": notificationMsg.ID,
 		"type":            notificationMsg.Type,
 		"payload":         notificationMsg.Payload,
-	}).Debug("Worker created notification message")
+	})
 
 	// Process the notification using the processor
-	logrus.WithFields(logrus.Fields{
+	sampledLog.Debug("Worker calling ProcessNotification", logger.Fields{
 		"worker_id":       w.id,
 		"notification_id": notificationMsg.ID,
 		"type":            notificationMsg.Type,
-	}).Debug("Worker calling ProcessNotification")
+	})
 
 	if err := w.processor.ProcessNotification(w.ctx, notificationMsg); err != nil {
-		logrus.WithFields(logrus.Fields{
+		moduleLog.Error("Worker ProcessNotification failed", logger.Fields{
 			"worker_id":       w.id,
 			"notification_id": notificationMsg.ID,
 			"error":           err.Error(),
-		}).Error("Worker ProcessNotification failed")
+		})
 		return fmt.Errorf("failed to process notification: %w", err)
 	}
 
-	logrus.WithFields(logrus.Fields{
+	sampledLog.Debug("Worker processed notification successfully", logger.Fields{
 		"worker_id":       w.id,
 		"notification_id": notificationMsg.ID,
 		"duration":        time.Since(start),
-	}).Debug("Worker processed notification successfully")
+	})
 	return nil
 }
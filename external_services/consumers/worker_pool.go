@@ -5,14 +5,18 @@ import (
 	"fmt"
 	"log"
 	"sync"
+
+	"github.com/gaurav2721/notification-service/external_services/messagebus"
 )
 
 // workerPool represents a pool of workers for a specific notification type
 type workerPool struct {
 	notificationType NotificationType
 	channel          chan string
+	deliveries       chan messagebus.Delivery
 	processor        NotificationProcessor
 	workers          []ConsumerWorker
+	extraWorkers     []*worker // started by ScaleUp on top of workerCount
 	workerCount      int
 	running          bool
 	ctx              context.Context
@@ -21,16 +25,20 @@ type workerPool struct {
 	mu               sync.RWMutex
 }
 
-// NewWorkerPool creates a new worker pool for a specific notification type
+// NewWorkerPool creates a new worker pool for a specific notification type. Its workers read the
+// in-process channel, or, when deliveries is not nil, the broker deliveries, which they settle
+// with the outcome of every message.
 func NewWorkerPool(
 	notificationType NotificationType,
 	channel chan string,
 	processor NotificationProcessor,
 	workerCount int,
+	deliveries chan messagebus.Delivery,
 ) ConsumerWorkerPool {
 	return &workerPool{
 		notificationType: notificationType,
 		channel:          channel,
+		deliveries:       deliveries,
 		processor:        processor,
 		workerCount:      workerCount,
 		workers:          make([]ConsumerWorker, 0, workerCount),
@@ -52,7 +60,7 @@ func (wp *workerPool) Start(ctx context.Context) error {
 
 	// Create and start workers
 	for i := 0; i < wp.workerCount; i++ {
-		worker := NewWorker(wp.channel, wp.processor)
+		worker := newWorker(wp.channel, wp.deliveries, wp.processor)
 		wp.workers = append(wp.workers, worker)
 
 		wp.wg.Add(1)
@@ -87,9 +95,13 @@ func (wp *workerPool) Stop() error {
 			log.Printf("Error stopping worker %s: %v", worker.GetWorkerID(), err)
 		}
 	}
+	for _, extra := range wp.extraWorkers {
+		extra.Stop()
+	}
 
 	// Wait for all workers to finish
 	wp.wg.Wait()
+	wp.extraWorkers = nil
 	return nil
 }
 
@@ -97,7 +109,7 @@ func (wp *workerPool) Stop() error {
 func (wp *workerPool) GetWorkerCount() int {
 	wp.mu.RLock()
 	defer wp.mu.RUnlock()
-	return len(wp.workers)
+	return len(wp.workers) + len(wp.extraWorkers)
 }
 
 // GetNotificationType returns the type of notifications this pool handles
@@ -130,3 +142,34 @@ func (wp *workerPool) UpdateWorkerCount(count int) error {
 	wp.workers = make([]ConsumerWorker, 0, count)
 	return nil
 }
+
+// ScaleUp starts count workers in addition to the configured worker count, until ScaleDown
+func (wp *workerPool) ScaleUp(count int) error {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if !wp.running {
+		return fmt.Errorf("worker pool for %s is not running", wp.notificationType)
+	}
+
+	for i := 0; i < count; i++ {
+		extra := newWorker(wp.channel, wp.deliveries, wp.processor)
+		if err := extra.Start(wp.ctx); err != nil {
+			return err
+		}
+		wp.extraWorkers = append(wp.extraWorkers, extra)
+	}
+	return nil
+}
+
+// ScaleDown stops the workers started by ScaleUp once they finished their current message
+func (wp *workerPool) ScaleDown() {
+	wp.mu.Lock()
+	extraWorkers := wp.extraWorkers
+	wp.extraWorkers = nil
+	wp.mu.Unlock()
+
+	for _, extra := range extraWorkers {
+		extra.Drain()
+	}
+}
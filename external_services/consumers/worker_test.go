@@ -2,9 +2,11 @@ package consumers
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/gaurav2721/notification-service/external_services/messagebus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -59,3 +61,49 @@ func TestWorkerProcessNotification(t *testing.T) {
 	err = worker.Stop()
 	assert.NoError(t, err)
 }
+
+func TestWorkerSettlesMessages(t *testing.T) {
+	mockProcessor := new(MockNotificationProcessor)
+	mockProcessor.On("GetNotificationType").Return(SlackNotification)
+	mockProcessor.On("ProcessNotification", mock.Anything, mock.MatchedBy(func(message NotificationMessage) bool {
+		return message.Payload == "sent"
+	})).Return(nil)
+	mockProcessor.On("ProcessNotification", mock.Anything, mock.Anything).Return(errors.New("slack: rate limited"))
+
+	settled := make(chan string, 2)
+	deliveries := make(chan messagebus.Delivery, 2)
+	worker := NewDeliveryWorker(deliveries, mockProcessor)
+
+	assert.NoError(t, worker.Start(context.Background()))
+	defer worker.Stop()
+
+	deliveries <- messagebus.Delivery{Topic: messagebus.TopicSlack, Message: &settledMessage{payload: "sent", settled: settled}}
+	deliveries <- messagebus.Delivery{Topic: messagebus.TopicSlack, Message: &settledMessage{payload: "failed", settled: settled}}
+
+	for _, expected := range []string{"sent: acked", "failed: nacked"} {
+		select {
+		case message := <-settled:
+			assert.Equal(t, expected, message)
+		case <-time.After(time.Second):
+			t.Fatalf("message %q was not settled", expected)
+		}
+	}
+}
+
+// settledMessage is a broker message reporting how it was settled
+type settledMessage struct {
+	payload string
+	settled chan string
+}
+
+func (m *settledMessage) Payload() []byte { return []byte(m.payload) }
+
+func (m *settledMessage) Ack() error {
+	m.settled <- m.payload + ": acked"
+	return nil
+}
+
+func (m *settledMessage) Nack() error {
+	m.settled <- m.payload + ": nacked"
+	return nil
+}
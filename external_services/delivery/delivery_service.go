@@ -0,0 +1,270 @@
+package delivery
+
+import (
+	"container/list"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/constants"
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// deliveryService implements DeliveryService with an in-memory archive
+type deliveryService struct {
+	attempts             map[string][]*models.DeliveryAttempt // notificationID -> attempts
+	attemptCounts        map[string]map[string]int            // notificationID -> channel|recipient -> attempts made
+	lastStatus           map[string]map[string]string         // notificationID -> channel|recipient -> latest status
+	stats                map[string]*models.DeliveryStats     // notificationID -> latest outcome counts
+	maxAttemptsPerRecord int
+	mutex                sync.RWMutex
+	clock                clock.Clock
+
+	// recent orders notifications by their latest attempt, so the least recently attempted
+	// notification is dropped once maxNotifications are archived
+	recent           *list.List
+	recentElements   map[string]*list.Element
+	maxNotifications int
+
+	// latency holds the enqueue to acknowledgment latency of the sent messages of every
+	// notification, kept apart from the archive like stats
+	latency map[string]map[LatencyKey]*metrics.Histogram
+}
+
+// NewDeliveryService creates a new in-memory delivery archive.
+// The number of attempts retained per notification is read from DELIVERY_ARCHIVE_MAX_ATTEMPTS,
+// the number of notifications from DELIVERY_ARCHIVE_MAX_NOTIFICATIONS.
+func NewDeliveryService() DeliveryService {
+	return &deliveryService{
+		attempts:             make(map[string][]*models.DeliveryAttempt),
+		attemptCounts:        make(map[string]map[string]int),
+		lastStatus:           make(map[string]map[string]string),
+		stats:                make(map[string]*models.DeliveryStats),
+		maxAttemptsPerRecord: positiveEnvInt(constants.DeliveryArchiveMaxAttemptsEnvVar, constants.DefaultDeliveryArchiveMaxAttempts),
+		latency:              make(map[string]map[LatencyKey]*metrics.Histogram),
+		recent:               list.New(),
+		recentElements:       make(map[string]*list.Element),
+		maxNotifications:     positiveEnvInt(constants.DeliveryArchiveMaxNotificationsEnvVar, constants.DefaultDeliveryArchiveMaxNotifications),
+		clock:                clock.Real(),
+	}
+}
+
+// SetClock replaces the clock attempts and receipts without a time are stamped with
+func (s *deliveryService) SetClock(c clock.Clock) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.clock = c
+}
+
+// positiveEnvInt reads a positive integer from an environment variable
+func positiveEnvInt(name string, defaultValue int) int {
+	if value := os.Getenv(name); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// RecordAttempt archives a single delivery attempt.
+// The attempt number is assigned per notification, channel and recipient.
+func (s *deliveryService) RecordAttempt(attempt *models.DeliveryAttempt) error {
+	if attempt == nil || attempt.NotificationID == "" || attempt.Recipient == "" {
+		return ErrInvalidAttempt
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.touch(attempt.NotificationID)
+	counts, ok := s.attemptCounts[attempt.NotificationID]
+	if !ok {
+		counts = make(map[string]int)
+		s.attemptCounts[attempt.NotificationID] = counts
+	}
+	statuses, ok := s.lastStatus[attempt.NotificationID]
+	if !ok {
+		statuses = make(map[string]string)
+		s.lastStatus[attempt.NotificationID] = statuses
+	}
+	recipientKey := attempt.Channel + "|" + attempt.Recipient
+	counts[recipientKey]++
+	attempt.Attempt = counts[recipientKey]
+	s.updateStats(attempt.NotificationID, statuses[recipientKey], attempt.Status)
+	statuses[recipientKey] = attempt.Status
+	if attempt.QueuedAt != nil && attempt.Status == models.DeliveryStatusSent {
+		s.observeLatency(attempt)
+	}
+
+	if attempt.AttemptedAt.IsZero() {
+		attempt.AttemptedAt = s.clock.Now()
+	}
+	attempt.ProviderResponse = Redact(attempt.ProviderResponse)
+	attempt.Error = Redact(attempt.Error)
+
+	records := append(s.attempts[attempt.NotificationID], attempt)
+	// Drop the oldest attempts once the archive for a notification is full
+	if len(records) > s.maxAttemptsPerRecord {
+		records = records[len(records)-s.maxAttemptsPerRecord:]
+	}
+	s.attempts[attempt.NotificationID] = records
+
+	return nil
+}
+
+// RecordReceipt marks the latest sent attempt of a recipient as delivered to the device.
+// Repeated receipts are ignored, since apps and delivery data imports may report a push twice.
+func (s *deliveryService) RecordReceipt(receipt *models.DeliveryReceipt) error {
+	if receipt == nil || receipt.NotificationID == "" || receipt.Recipient == "" {
+		return ErrInvalidReceipt
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	statuses := s.lastStatus[receipt.NotificationID]
+	recipientKey := receipt.Channel + "|" + receipt.Recipient
+	switch statuses[recipientKey] {
+	case models.DeliveryStatusDelivered:
+		return nil
+	case models.DeliveryStatusSent:
+	default:
+		return ErrNoSentAttempt
+	}
+	s.updateStats(receipt.NotificationID, models.DeliveryStatusSent, models.DeliveryStatusDelivered)
+	statuses[recipientKey] = models.DeliveryStatusDelivered
+
+	deliveredAt := s.clock.Now()
+	if receipt.DeliveredAt != nil {
+		deliveredAt = *receipt.DeliveredAt
+	}
+
+	// Update the latest archived attempt unless it was already dropped. The attempt is replaced
+	// rather than modified because callers of GetAttempts may still hold it.
+	records := s.attempts[receipt.NotificationID]
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].Channel == receipt.Channel && records[i].Recipient == receipt.Recipient {
+			delivered := *records[i]
+			delivered.Status = models.DeliveryStatusDelivered
+			delivered.DeliveredAt = &deliveredAt
+			records[i] = &delivered
+			break
+		}
+	}
+
+	return nil
+}
+
+// GetAttempts returns all archived attempts of a notification for a recipient
+func (s *deliveryService) GetAttempts(notificationID string, recipient string) ([]*models.DeliveryAttempt, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var attempts []*models.DeliveryAttempt
+	for _, attempt := range s.attempts[notificationID] {
+		if attempt.MatchesRecipient(recipient) {
+			attempts = append(attempts, attempt)
+		}
+	}
+
+	if len(attempts) == 0 {
+		return nil, ErrAttemptsNotFound
+	}
+
+	return attempts, nil
+}
+
+// GetStats returns the number of recipients whose latest attempt was sent, delivered or failed.
+// Counters are kept separately from the archive so they stay exact after old attempts are dropped.
+func (s *deliveryService) GetStats(notificationID string) models.DeliveryStats {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if stats, ok := s.stats[notificationID]; ok {
+		return *stats
+	}
+	return models.DeliveryStats{}
+}
+
+// observeLatency adds the latency of a sent attempt to the histogram of its channel and provider
+func (s *deliveryService) observeLatency(attempt *models.DeliveryAttempt) {
+	histograms, ok := s.latency[attempt.NotificationID]
+	if !ok {
+		histograms = make(map[LatencyKey]*metrics.Histogram)
+		s.latency[attempt.NotificationID] = histograms
+	}
+	key := LatencyKey{Channel: attempt.Channel, Provider: attempt.Provider}
+	histogram, ok := histograms[key]
+	if !ok {
+		histogram = metrics.NewHistogram(metrics.LatencyBuckets)
+		histograms[key] = histogram
+	}
+	histogram.Observe(float64(attempt.LatencyMs) / 1000)
+}
+
+// GetLatency returns copies of the latency histograms of the sent messages of a notification
+func (s *deliveryService) GetLatency(notificationID string) map[LatencyKey]*metrics.Histogram {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	histograms := make(map[LatencyKey]*metrics.Histogram, len(s.latency[notificationID]))
+	for key, histogram := range s.latency[notificationID] {
+		histograms[key] = histogram.Clone()
+	}
+	return histograms
+}
+
+// touch marks a notification as the most recently attempted one, and drops the least recently
+// attempted notifications beyond maxNotifications
+func (s *deliveryService) touch(notificationID string) {
+	if element, ok := s.recentElements[notificationID]; ok {
+		s.recent.MoveToFront(element)
+		return
+	}
+	s.recentElements[notificationID] = s.recent.PushFront(notificationID)
+
+	for s.recent.Len() > s.maxNotifications {
+		oldest := s.recent.Remove(s.recent.Back()).(string)
+		delete(s.recentElements, oldest)
+		delete(s.attempts, oldest)
+		delete(s.attemptCounts, oldest)
+		delete(s.lastStatus, oldest)
+		delete(s.stats, oldest)
+		delete(s.latency, oldest)
+	}
+}
+
+// updateStats moves a recipient from its previous outcome to the new one
+func (s *deliveryService) updateStats(notificationID, previous, current string) {
+	stats, ok := s.stats[notificationID]
+	if !ok {
+		stats = &models.DeliveryStats{}
+		s.stats[notificationID] = stats
+	}
+
+	switch previous {
+	case models.DeliveryStatusSent:
+		stats.Sent--
+	case models.DeliveryStatusDelivered:
+		stats.Sent--
+		stats.Delivered--
+	case models.DeliveryStatusFailed:
+		stats.Failed--
+	case models.DeliveryStatusQuarantined:
+		stats.Quarantined--
+	}
+
+	switch current {
+	case models.DeliveryStatusSent:
+		stats.Sent++
+	case models.DeliveryStatusDelivered:
+		stats.Sent++
+		stats.Delivered++
+	case models.DeliveryStatusFailed:
+		stats.Failed++
+	case models.DeliveryStatusQuarantined:
+		stats.Quarantined++
+	}
+}
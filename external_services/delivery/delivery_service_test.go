@@ -0,0 +1,278 @@
+package delivery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeliveryService_RecordAndGetAttempts(t *testing.T) {
+	service := NewDeliveryService()
+
+	for i := 0; i < 2; i++ {
+		err := service.RecordAttempt(&models.DeliveryAttempt{
+			NotificationID: "notification-1",
+			UserID:         "user-001",
+			Recipient:      "john.doe@company.com",
+			Channel:        "email",
+			Status:         models.DeliveryStatusFailed,
+			Error:          "dial tcp: connection refused",
+		})
+		require.NoError(t, err)
+	}
+
+	// Lookup by user ID
+	attempts, err := service.GetAttempts("notification-1", "user-001")
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	assert.Equal(t, 1, attempts[0].Attempt)
+	assert.Equal(t, 2, attempts[1].Attempt)
+	assert.False(t, attempts[0].AttemptedAt.IsZero())
+
+	// Lookup by provider address
+	attempts, err = service.GetAttempts("notification-1", "john.doe@company.com")
+	require.NoError(t, err)
+	assert.Len(t, attempts, 2)
+
+	// Unknown recipient
+	_, err = service.GetAttempts("notification-1", "user-999")
+	assert.ErrorIs(t, err, ErrAttemptsNotFound)
+}
+
+func TestDeliveryService_GetStats(t *testing.T) {
+	service := NewDeliveryService()
+
+	record := func(recipient, status string) {
+		require.NoError(t, service.RecordAttempt(&models.DeliveryAttempt{
+			NotificationID: "notification-1",
+			Recipient:      recipient,
+			Channel:        "email",
+			Status:         status,
+		}))
+	}
+
+	record("a@company.com", models.DeliveryStatusSent)
+	record("b@company.com", models.DeliveryStatusFailed)
+	record("c@company.com", models.DeliveryStatusFailed)
+	// A retry that succeeds moves the recipient from failed to sent
+	record("c@company.com", models.DeliveryStatusSent)
+
+	assert.Equal(t, models.DeliveryStats{Sent: 2, Failed: 1}, service.GetStats("notification-1"))
+	assert.Equal(t, models.DeliveryStats{}, service.GetStats("notification-2"))
+}
+
+func TestDeliveryService_StampsWithClock(t *testing.T) {
+	service := NewDeliveryService()
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	service.SetClock(fakeClock)
+
+	require.NoError(t, service.RecordAttempt(&models.DeliveryAttempt{
+		NotificationID: "notification-1",
+		Recipient:      "ios_token_1",
+		Channel:        "ios_push",
+		Status:         models.DeliveryStatusSent,
+	}))
+	fakeClock.Advance(5 * time.Second)
+	require.NoError(t, service.RecordReceipt(&models.DeliveryReceipt{NotificationID: "notification-1", Channel: "ios_push", Recipient: "ios_token_1"}))
+
+	attempts, err := service.GetAttempts("notification-1", "ios_token_1")
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), attempts[0].AttemptedAt)
+	require.NotNil(t, attempts[0].DeliveredAt)
+	assert.Equal(t, time.Date(2024, 1, 1, 9, 0, 5, 0, time.UTC), *attempts[0].DeliveredAt)
+}
+
+func TestDeliveryService_RecordAttempt_Invalid(t *testing.T) {
+	service := NewDeliveryService()
+
+	assert.ErrorIs(t, service.RecordAttempt(nil), ErrInvalidAttempt)
+	assert.ErrorIs(t, service.RecordAttempt(&models.DeliveryAttempt{Recipient: "user-001"}), ErrInvalidAttempt)
+	assert.ErrorIs(t, service.RecordAttempt(&models.DeliveryAttempt{NotificationID: "notification-1"}), ErrInvalidAttempt)
+}
+
+func TestDeliveryService_RecordAttempt_RedactsResponse(t *testing.T) {
+	service := NewDeliveryService()
+
+	err := service.RecordAttempt(&models.DeliveryAttempt{
+		NotificationID:   "notification-1",
+		Recipient:        "john.doe@company.com",
+		Channel:          "email",
+		ProviderResponse: `{"to":"john.doe@company.com","auth":"Bearer abc.def.ghi"}`,
+	})
+	require.NoError(t, err)
+
+	attempts, err := service.GetAttempts("notification-1", "john.doe@company.com")
+	require.NoError(t, err)
+	assert.NotContains(t, attempts[0].ProviderResponse, "john.doe@company.com")
+	assert.Contains(t, attempts[0].ProviderResponse, "j***@company.com")
+	assert.NotContains(t, attempts[0].ProviderResponse, "abc.def.ghi")
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "empty",
+			input:    "",
+			expected: "",
+		},
+		{
+			name:     "email address",
+			input:    "550 mailbox unavailable: jane.smith@company.com",
+			expected: "550 mailbox unavailable: j***@company.com",
+		},
+		{
+			name:     "phone number",
+			input:    "The 'To' number +14155550123 is not a valid mobile number",
+			expected: "The 'To' number +*********23 is not a valid mobile number",
+		},
+		{
+			name:     "numbers that are not phone numbers are kept",
+			input:    `{"code":21614,"offset":"+05:30","count":+123}`,
+			expected: `{"code":21614,"offset":"+05:30","count":+123}`,
+		},
+		{
+			name:     "server key",
+			input:    "Authorization: key=AAAAabc123",
+			expected: "Authorization: key=[REDACTED]",
+		},
+		{
+			name:     "device token",
+			input:    `{"reason":"BadDeviceToken","token":"a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90"}`,
+			expected: `{"reason":"BadDeviceToken","token":"a1b2c3d4..."}`,
+		},
+		{
+			name:     "notification IDs are kept",
+			input:    `{"id":"550e8400-e29b-41d4-a716-446655440000"}`,
+			expected: `{"id":"550e8400-e29b-41d4-a716-446655440000"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Redact(tt.input))
+		})
+	}
+}
+
+func TestDeliveryService_RecordReceipt(t *testing.T) {
+	service := NewDeliveryService()
+
+	record := func(recipient, status string) {
+		require.NoError(t, service.RecordAttempt(&models.DeliveryAttempt{
+			NotificationID: "notification-1",
+			UserID:         "user-001",
+			Recipient:      recipient,
+			Channel:        "ios_push",
+			Status:         status,
+		}))
+	}
+	record("ios_token_1", models.DeliveryStatusSent)
+	record("ios_token_2", models.DeliveryStatusFailed)
+
+	deliveredAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	receipt := &models.DeliveryReceipt{NotificationID: "notification-1", Channel: "ios_push", Recipient: "ios_token_1", DeliveredAt: &deliveredAt}
+	require.NoError(t, service.RecordReceipt(receipt))
+	// A repeated receipt is ignored
+	require.NoError(t, service.RecordReceipt(receipt))
+
+	assert.Equal(t, models.DeliveryStats{Sent: 1, Failed: 1, Delivered: 1}, service.GetStats("notification-1"))
+
+	attempts, err := service.GetAttempts("notification-1", "ios_token_1")
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, models.DeliveryStatusDelivered, attempts[0].Status)
+	require.NotNil(t, attempts[0].DeliveredAt)
+	assert.Equal(t, deliveredAt, *attempts[0].DeliveredAt)
+
+	// Only sent pushes can be confirmed
+	err = service.RecordReceipt(&models.DeliveryReceipt{NotificationID: "notification-1", Channel: "ios_push", Recipient: "ios_token_2"})
+	assert.ErrorIs(t, err, ErrNoSentAttempt)
+	err = service.RecordReceipt(&models.DeliveryReceipt{NotificationID: "notification-1", Channel: "android_push", Recipient: "ios_token_1"})
+	assert.ErrorIs(t, err, ErrNoSentAttempt)
+	assert.ErrorIs(t, service.RecordReceipt(&models.DeliveryReceipt{NotificationID: "notification-1"}), ErrInvalidReceipt)
+}
+
+func TestDeliveryService_GetLatency(t *testing.T) {
+	service := NewDeliveryService()
+	queuedAt := time.Now()
+
+	record := func(recipient, channel, provider, status string, latencyMs int64, queued bool) {
+		attempt := &models.DeliveryAttempt{
+			NotificationID: "notification-1",
+			Recipient:      recipient,
+			Channel:        channel,
+			Provider:       provider,
+			Status:         status,
+			LatencyMs:      latencyMs,
+		}
+		if queued {
+			attempt.QueuedAt = &queuedAt
+		}
+		require.NoError(t, service.RecordAttempt(attempt))
+	}
+	record("a@company.com", "email", "email", models.DeliveryStatusSent, 200, true)
+	record("b@company.com", "email", "email", models.DeliveryStatusSent, 400, true)
+	record("ios_token_1", "ios_push", "apns", models.DeliveryStatusSent, 50, true)
+	// Failed and unstamped messages have no acknowledgment latency
+	record("c@company.com", "email", "email", models.DeliveryStatusFailed, 900, true)
+	record("d@company.com", "email", "email", models.DeliveryStatusSent, 0, false)
+
+	latency := service.GetLatency("notification-1")
+	require.Len(t, latency, 2)
+	email := latency[LatencyKey{Channel: "email", Provider: "email"}]
+	require.NotNil(t, email)
+	assert.Equal(t, uint64(2), email.Count())
+	assert.InDelta(t, 0.6, email.Sum(), 1e-9)
+	assert.Equal(t, uint64(1), latency[LatencyKey{Channel: "ios_push", Provider: "apns"}].Count())
+
+	// Histograms are copies
+	email.Observe(1)
+	assert.Equal(t, uint64(2), service.GetLatency("notification-1")[LatencyKey{Channel: "email", Provider: "email"}].Count())
+	assert.Empty(t, service.GetLatency("unknown"))
+}
+
+func TestDeliveryService_DropsLeastRecentlyAttemptedNotifications(t *testing.T) {
+	t.Setenv("DELIVERY_ARCHIVE_MAX_NOTIFICATIONS", "2")
+	service := NewDeliveryService()
+
+	record := func(notificationID string) {
+		require.NoError(t, service.RecordAttempt(&models.DeliveryAttempt{
+			NotificationID: notificationID,
+			Recipient:      "john.doe@company.com",
+			Channel:        "email",
+			Status:         models.DeliveryStatusSent,
+		}))
+	}
+
+	record("notification-1")
+	record("notification-2")
+	record("notification-1")
+	record("notification-3")
+
+	// notification-2 was attempted least recently
+	_, err := service.GetAttempts("notification-2", "john.doe@company.com")
+	assert.ErrorIs(t, err, ErrAttemptsNotFound)
+	attempts, err := service.GetAttempts("notification-1", "john.doe@company.com")
+	require.NoError(t, err)
+	assert.Len(t, attempts, 2)
+
+	// Counters of a dropped notification are dropped with it
+	assert.Equal(t, models.DeliveryStats{}, service.GetStats("notification-2"))
+	assert.Empty(t, service.GetLatency("notification-2"))
+	assert.Equal(t, 1, service.GetStats("notification-1").Sent)
+
+	// Attempt numbers start over for a dropped notification
+	record("notification-2")
+	attempts, err = service.GetAttempts("notification-2", "john.doe@company.com")
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempts[0].Attempt)
+}
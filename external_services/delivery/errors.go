@@ -0,0 +1,11 @@
+package delivery
+
+import "errors"
+
+// Delivery service errors
+var (
+	ErrInvalidAttempt   = errors.New("invalid delivery attempt")
+	ErrAttemptsNotFound = errors.New("no delivery attempts found")
+	ErrInvalidReceipt   = errors.New("invalid delivery receipt")
+	ErrNoSentAttempt    = errors.New("no sent delivery attempt to confirm")
+)
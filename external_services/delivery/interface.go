@@ -0,0 +1,35 @@
+package delivery
+
+import (
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// LatencyKey identifies the channel and provider of a latency histogram
+type LatencyKey struct {
+	Channel  string
+	Provider string
+}
+
+// DeliveryService interface defines methods for archiving provider delivery attempts
+type DeliveryService interface {
+	// SetClock replaces the clock attempts and receipts without a time are stamped with
+	SetClock(c clock.Clock)
+
+	// RecordAttempt archives a single delivery attempt
+	RecordAttempt(attempt *models.DeliveryAttempt) error
+
+	// GetAttempts returns all archived attempts of a notification for a recipient (user ID or provider address)
+	GetAttempts(notificationID string, recipient string) ([]*models.DeliveryAttempt, error)
+
+	// RecordReceipt marks the latest sent attempt of a recipient as delivered to the device
+	RecordReceipt(receipt *models.DeliveryReceipt) error
+
+	// GetStats returns the number of recipients whose latest attempt was sent or failed
+	GetStats(notificationID string) models.DeliveryStats
+
+	// GetLatency returns the histograms of the enqueue to acknowledgment latency, in seconds, of
+	// the sent messages of a notification by channel and provider
+	GetLatency(notificationID string) map[LatencyKey]*metrics.Histogram
+}
@@ -0,0 +1,44 @@
+package delivery
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	emailPattern  = regexp.MustCompile(`([a-zA-Z0-9._%+\-])[a-zA-Z0-9._%+\-]*@([a-zA-Z0-9.\-]+\.[a-zA-Z]{2,})`)
+	phonePattern  = regexp.MustCompile(`\+[1-9][0-9]{6,14}\b`)
+	bearerPattern = regexp.MustCompile(`(?i)(bearer|key=|token[=:]\s*)\s*[A-Za-z0-9\-_.=]+`)
+	tokenPattern  = regexp.MustCompile(`[A-Za-z0-9_:\-]{40,}`)
+)
+
+// Redact masks personal data and credentials in a raw provider response so it can be archived safely.
+// Email addresses keep their first character and domain, E.164 phone numbers their last two
+// digits, credentials are removed and long opaque tokens (device tokens, message IDs) keep only
+// their first 8 characters.
+func Redact(raw string) string {
+	if raw == "" {
+		return raw
+	}
+
+	redacted := emailPattern.ReplaceAllString(raw, "$1***@$2")
+	redacted = phonePattern.ReplaceAllStringFunc(redacted, func(match string) string {
+		return "+" + strings.Repeat("*", len(match)-3) + match[len(match)-2:]
+	})
+	redacted = bearerPattern.ReplaceAllStringFunc(redacted, func(match string) string {
+		lower := strings.ToLower(match)
+		switch {
+		case strings.HasPrefix(lower, "bearer"):
+			return "bearer [REDACTED]"
+		case strings.HasPrefix(lower, "key="):
+			return "key=[REDACTED]"
+		default:
+			return match[:strings.IndexAny(match, "=:")+1] + "[REDACTED]"
+		}
+	})
+	redacted = tokenPattern.ReplaceAllStringFunc(redacted, func(match string) string {
+		return match[:8] + "..."
+	})
+
+	return redacted
+}
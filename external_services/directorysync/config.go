@@ -0,0 +1,130 @@
+package directorysync
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gaurav2721/notification-service/constants"
+)
+
+// Conflict modes, deciding what happens to a user not synced from the directory whose email
+// address an entry has
+const (
+	// ConflictLink links the user to the entry, which then keeps it up to date
+	ConflictLink = "link"
+	// ConflictSkip leaves the user alone and reports the entry as a conflict
+	ConflictSkip = "skip"
+)
+
+// Config selects the directory, the entries synced from it and how their attributes map to users
+type Config struct {
+	// URL of the LDAP server, ldap:// or ldaps://; empty disables the sync
+	URL string
+	// StartTLS upgrades ldap:// connections to TLS before binding
+	StartTLS     bool
+	BindDN       string
+	BindPassword string
+
+	// BaseDN and UserFilter select the entries of users
+	BaseDN     string
+	UserFilter string
+
+	// GroupFilter selects groups below GroupBaseDN, or BaseDN, whose GroupMemberAttribute lists
+	// the DNs of their members. Without it the groups are read from the memberOf attribute of
+	// users, as Active Directory and OpenLDAP with the memberof overlay provide it.
+	GroupBaseDN          string
+	GroupFilter          string
+	GroupMemberAttribute string
+
+	// Attributes mapped to the email address, full name and phone number of users
+	EmailAttribute string
+	NameAttribute  string
+	PhoneAttribute string
+
+	// ConflictMode is ConflictLink or ConflictSkip
+	ConflictMode string
+
+	// PageSize is how many entries the server returns per page of a search
+	PageSize int
+
+	// Interval between syncs; 0 syncs once at startup
+	Interval time.Duration
+
+	// Timeout bounds connecting and every LDAP operation
+	Timeout time.Duration
+}
+
+// DefaultConfig returns the sync configuration used when no environment overrides are set
+func DefaultConfig() Config {
+	return Config{
+		UserFilter:           constants.DefaultLDAPUserFilter,
+		GroupMemberAttribute: constants.DefaultLDAPGroupMemberAttribute,
+		EmailAttribute:       constants.DefaultLDAPEmailAttribute,
+		NameAttribute:        constants.DefaultLDAPNameAttribute,
+		PhoneAttribute:       constants.DefaultLDAPPhoneAttribute,
+		ConflictMode:         constants.DefaultLDAPConflictMode,
+		PageSize:             constants.DefaultLDAPPageSize,
+		Interval:             time.Duration(constants.DefaultLDAPSyncIntervalMinutes) * time.Minute,
+		Timeout:              time.Duration(constants.DefaultLDAPTimeoutSeconds) * time.Second,
+	}
+}
+
+// LoadConfigFromEnv reads the sync configuration from environment variables
+func LoadConfigFromEnv() Config {
+	config := DefaultConfig()
+	config.URL = strings.TrimSpace(os.Getenv(constants.LDAPURLEnvVar))
+	config.StartTLS, _ = strconv.ParseBool(os.Getenv(constants.LDAPStartTLSEnvVar))
+	config.BindDN = os.Getenv(constants.LDAPBindDNEnvVar)
+	config.BindPassword = os.Getenv(constants.LDAPBindPasswordEnvVar)
+	config.BaseDN = os.Getenv(constants.LDAPBaseDNEnvVar)
+	config.GroupBaseDN = os.Getenv(constants.LDAPGroupBaseDNEnvVar)
+	config.GroupFilter = os.Getenv(constants.LDAPGroupFilterEnvVar)
+
+	for key, value := range map[string]*string{
+		constants.LDAPUserFilterEnvVar:           &config.UserFilter,
+		constants.LDAPGroupMemberAttributeEnvVar: &config.GroupMemberAttribute,
+		constants.LDAPEmailAttributeEnvVar:       &config.EmailAttribute,
+		constants.LDAPNameAttributeEnvVar:        &config.NameAttribute,
+		constants.LDAPPhoneAttributeEnvVar:       &config.PhoneAttribute,
+		constants.LDAPConflictModeEnvVar:         &config.ConflictMode,
+	} {
+		if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+			*value = v
+		}
+	}
+	config.ConflictMode = strings.ToLower(config.ConflictMode)
+
+	if size, err := strconv.Atoi(os.Getenv(constants.LDAPPageSizeEnvVar)); err == nil && size > 0 {
+		config.PageSize = size
+	}
+	if minutes, err := strconv.Atoi(os.Getenv(constants.LDAPSyncIntervalMinutesEnvVar)); err == nil && minutes >= 0 {
+		config.Interval = time.Duration(minutes) * time.Minute
+	}
+	if seconds, err := strconv.Atoi(os.Getenv(constants.LDAPTimeoutSecondsEnvVar)); err == nil && seconds > 0 {
+		config.Timeout = time.Duration(seconds) * time.Second
+	}
+	return config
+}
+
+// Enabled reports whether a directory is configured
+func (c Config) Enabled() bool {
+	return c.URL != ""
+}
+
+// Validate checks the settings a sync needs
+func (c Config) Validate() error {
+	switch {
+	case c.BaseDN == "":
+		return fmt.Errorf("%w: a base DN is required", ErrInvalidConfiguration)
+	case c.EmailAttribute == "":
+		return fmt.Errorf("%w: an email attribute is required", ErrInvalidConfiguration)
+	case c.ConflictMode != ConflictLink && c.ConflictMode != ConflictSkip:
+		return fmt.Errorf("%w: conflict mode %q, expected link or skip", ErrInvalidConfiguration, c.ConflictMode)
+	case c.GroupFilter != "" && c.GroupMemberAttribute == "":
+		return fmt.Errorf("%w: a group member attribute is required with a group filter", ErrInvalidConfiguration)
+	}
+	return nil
+}
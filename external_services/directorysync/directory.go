@@ -0,0 +1,221 @@
+package directorysync
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gaurav2721/notification-service/external_services/ldap"
+)
+
+// userAccountControlDisabled is the ACCOUNTDISABLE flag of the Active Directory
+// userAccountControl attribute
+const userAccountControlDisabled = 0x2
+
+// groupSearchMembers is how many member DNs a group search selects groups by, keeping its
+// filter short
+const groupSearchMembers = 100
+
+// DirectoryUser is a user entry of the directory with its mapped attributes
+type DirectoryUser struct {
+	DN          string
+	Email       string
+	FullName    string
+	PhoneNumber string
+	// Groups are the names of the groups the user is a member of, sorted
+	Groups []string
+	// Disabled is set for Active Directory accounts that were disabled
+	Disabled bool
+}
+
+// Directory lists the users of a directory
+type Directory interface {
+	Users(ctx context.Context) ([]DirectoryUser, error)
+}
+
+// LDAPDirectory reads users and groups from an LDAP server, connecting for every sync
+type LDAPDirectory struct {
+	config Config
+}
+
+// NewLDAPDirectory creates a directory reading from the server of config
+func NewLDAPDirectory(config Config) *LDAPDirectory {
+	return &LDAPDirectory{config: config}
+}
+
+// Users binds, searches the users and their groups and maps their attributes
+func (d *LDAPDirectory) Users(ctx context.Context) ([]DirectoryUser, error) {
+	conn, err := ldap.Dial(d.config.URL, d.config.Timeout, ldap.DialOptions{StartTLS: d.config.StartTLS})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.Bind(ctx, d.config.BindDN, d.config.BindPassword); err != nil {
+		return nil, err
+	}
+
+	attributes := []string{d.config.EmailAttribute, "memberOf", "userAccountControl"}
+	for _, attribute := range []string{d.config.NameAttribute, d.config.PhoneAttribute} {
+		if attribute != "" {
+			attributes = append(attributes, attribute)
+		}
+	}
+	entries, err := conn.Search(ctx, ldap.SearchRequest{
+		BaseDN:     d.config.BaseDN,
+		Scope:      ldap.ScopeWholeSubtree,
+		Filter:     d.config.UserFilter,
+		Attributes: attributes,
+		PageSize:   d.config.PageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var groupsByMember map[string][]string
+	if d.config.GroupFilter != "" {
+		dns := make([]string, 0, len(entries))
+		for i := range entries {
+			dns = append(dns, entries[i].DN)
+		}
+		if groupsByMember, err = d.groupsByMember(ctx, conn, dns); err != nil {
+			return nil, err
+		}
+	}
+
+	users := make([]DirectoryUser, 0, len(entries))
+	for i := range entries {
+		entry := &entries[i]
+		user := DirectoryUser{
+			DN:          entry.DN,
+			Email:       strings.TrimSpace(entry.Value(d.config.EmailAttribute)),
+			FullName:    strings.TrimSpace(entry.Value(d.config.NameAttribute)),
+			PhoneNumber: strings.TrimSpace(entry.Value(d.config.PhoneAttribute)),
+			Disabled:    accountDisabled(entry.Value("userAccountControl")),
+		}
+		if groupsByMember != nil {
+			user.Groups = groupsByMember[strings.ToLower(entry.DN)]
+		} else {
+			for _, groupDN := range entry.Values("memberOf") {
+				user.Groups = append(user.Groups, rdnValue(groupDN))
+			}
+		}
+		user.Groups = uniqueSorted(user.Groups)
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// groupsByMember searches the groups with any of the members and returns their names by the
+// lowercased DNs of their members
+func (d *LDAPDirectory) groupsByMember(ctx context.Context, conn *ldap.Conn, memberDNs []string) (map[string][]string, error) {
+	baseDN := d.config.GroupBaseDN
+	if baseDN == "" {
+		baseDN = d.config.BaseDN
+	}
+
+	groupsByMember := make(map[string][]string)
+	for start := 0; start < len(memberDNs); start += groupSearchMembers {
+		end := start + groupSearchMembers
+		if end > len(memberDNs) {
+			end = len(memberDNs)
+		}
+		var filter strings.Builder
+		filter.WriteString("(&" + d.config.GroupFilter + "(|")
+		for _, dn := range memberDNs[start:end] {
+			filter.WriteString("(" + d.config.GroupMemberAttribute + "=" + ldap.EscapeFilter(dn) + ")")
+		}
+		filter.WriteString("))")
+
+		groups, err := conn.Search(ctx, ldap.SearchRequest{
+			BaseDN:     baseDN,
+			Scope:      ldap.ScopeWholeSubtree,
+			Filter:     filter.String(),
+			Attributes: []string{"cn", d.config.GroupMemberAttribute},
+			PageSize:   d.config.PageSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// Groups with members in several batches are found once per batch; Users removes the
+		// duplicate names
+		for i := range groups {
+			name := groups[i].Value("cn")
+			if name == "" {
+				name = rdnValue(groups[i].DN)
+			}
+			for _, member := range groups[i].Values(d.config.GroupMemberAttribute) {
+				key := strings.ToLower(member)
+				groupsByMember[key] = append(groupsByMember[key], name)
+			}
+		}
+	}
+	return groupsByMember, nil
+}
+
+// accountDisabled reports whether a userAccountControl value has the ACCOUNTDISABLE flag
+func accountDisabled(userAccountControl string) bool {
+	flags, err := strconv.ParseInt(userAccountControl, 10, 64)
+	return err == nil && flags&userAccountControlDisabled != 0
+}
+
+// rdnValue returns the value of the first RDN of a DN, e.g. "Engineering" for
+// "CN=Engineering,OU=Groups,DC=example,DC=com"
+func rdnValue(dn string) string {
+	rdn := dn
+	for i := 0; i < len(dn); i++ {
+		if dn[i] == '\\' {
+			i++
+			continue
+		}
+		if dn[i] == ',' || dn[i] == '+' {
+			rdn = dn[:i]
+			break
+		}
+	}
+	if i := strings.IndexByte(rdn, '='); i >= 0 {
+		rdn = rdn[i+1:]
+	}
+	return strings.TrimSpace(unescapeDNValue(rdn))
+}
+
+// unescapeDNValue removes the backslash escapes of a DN attribute value (RFC 4514)
+func unescapeDNValue(value string) string {
+	if !strings.Contains(value, "\\") {
+		return value
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 >= len(value) {
+			b.WriteByte(value[i])
+			continue
+		}
+		if i+2 < len(value) {
+			if n, err := strconv.ParseUint(value[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(value[i+1])
+		i++
+	}
+	return b.String()
+}
+
+// uniqueSorted sorts names and removes duplicates
+func uniqueSorted(names []string) []string {
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	unique := names[:1]
+	for _, name := range names[1:] {
+		if name != unique[len(unique)-1] {
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,11 @@
+package directorysync
+
+import "errors"
+
+// Directory sync errors
+var (
+	ErrInvalidConfiguration = errors.New("invalid LDAP directory sync configuration")
+	// ErrEmptyDirectory stops a sync that found no users, which usually is a misconfigured
+	// filter, before it deactivates every synced user
+	ErrEmptyDirectory = errors.New("the directory returned no users")
+)
@@ -0,0 +1,381 @@
+// Package directorysync keeps the users of the user service in sync with an LDAP directory such
+// as Active Directory: it creates users for new entries, updates their email address, name,
+// phone number and groups, and deactivates users whose entries were removed or disabled
+package directorysync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaurav2721/notification-service/external_services/user"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/google/uuid"
+)
+
+var log = logger.Module("directory_sync")
+
+// Outcome label values of the sync metrics
+const (
+	outcomeCreated     = "created"
+	outcomeUpdated     = "updated"
+	outcomeLinked      = "linked"
+	outcomeDeactivated = "deactivated"
+	outcomeSkipped     = "skipped"
+	outcomeConflict    = "conflict"
+)
+
+var (
+	directorySyncUsersTotal = metrics.DefaultRegistry.NewCounterVec(
+		"directory_sync_users_total",
+		"Users handled by the LDAP directory sync by outcome.",
+		"outcome",
+	)
+	directorySyncLastSuccess = metrics.DefaultRegistry.NewGaugeVec(
+		"directory_sync_last_success_timestamp_seconds",
+		"Unix time of the last successful LDAP directory sync.",
+	)
+)
+
+// Conflict is a directory entry the sync left alone because its email address belongs to
+// another user
+type Conflict struct {
+	DN     string `json:"dn"`
+	Email  string `json:"email"`
+	Reason string `json:"reason"`
+}
+
+// Status reports the outcome of a sync
+type Status struct {
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  time.Time  `json:"finished_at"`
+	Error       string     `json:"error,omitempty"`
+	Created     int        `json:"created"`
+	Updated     int        `json:"updated"`
+	Linked      int        `json:"linked"`
+	Unchanged   int        `json:"unchanged"`
+	Deactivated int        `json:"deactivated"`
+	Skipped     int        `json:"skipped"`
+	Conflicts   []Conflict `json:"conflicts,omitempty"`
+}
+
+// Syncer syncs the users of a directory into the user service
+type Syncer struct {
+	users     user.UserService
+	directory Directory
+	config    Config
+	now       func() time.Time
+
+	mutex  sync.Mutex
+	status *Status
+}
+
+// NewSyncer creates a syncer; config supplies the conflict mode and the interval
+func NewSyncer(users user.UserService, directory Directory, config Config) *Syncer {
+	return &Syncer{
+		users:     users,
+		directory: directory,
+		config:    config,
+		now:       time.Now,
+	}
+}
+
+// Status returns the outcome of the last sync, nil before the first one
+func (s *Syncer) Status() *Status {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.status
+}
+
+// Run syncs at once and then every interval until ctx is done
+func (s *Syncer) Run(ctx context.Context) {
+	s.syncAndLog(ctx)
+	if s.config.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.syncAndLog(ctx)
+		}
+	}
+}
+
+// syncAndLog runs a sync and logs its outcome
+func (s *Syncer) syncAndLog(ctx context.Context) {
+	status, err := s.Sync(ctx)
+	if err != nil {
+		log.Error("LDAP directory sync failed", logger.Fields{"error": err.Error()})
+		return
+	}
+	log.Info("LDAP directory sync finished", logger.Fields{
+		"created":     status.Created,
+		"updated":     status.Updated,
+		"linked":      status.Linked,
+		"deactivated": status.Deactivated,
+		"skipped":     status.Skipped,
+		"conflicts":   len(status.Conflicts),
+	})
+	for _, conflict := range status.Conflicts {
+		log.Warn("LDAP directory entry conflicts with an existing user", logger.Fields{
+			"dn":     conflict.DN,
+			"email":  conflict.Email,
+			"reason": conflict.Reason,
+		})
+	}
+}
+
+// Sync reads the directory once and applies it to the users. Syncs do not overlap; a sync
+// started while another one runs waits for it.
+func (s *Syncer) Sync(ctx context.Context) (*Status, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	status := &Status{StartedAt: s.now()}
+	err := s.sync(ctx, status)
+	status.FinishedAt = s.now()
+	if err != nil {
+		status.Error = err.Error()
+	} else {
+		directorySyncLastSuccess.Set(float64(status.FinishedAt.Unix()))
+	}
+	s.status = status
+	return status, err
+}
+
+// sync applies the directory entries to the users, recording the outcome in status
+func (s *Syncer) sync(ctx context.Context, status *Status) error {
+	entries, err := s.directory.Users(ctx)
+	if err != nil {
+		return fmt.Errorf("read directory: %w", err)
+	}
+	if len(entries) == 0 {
+		return ErrEmptyDirectory
+	}
+
+	users, err := s.users.ListUsers(user.UserFilter{})
+	if err != nil {
+		return fmt.Errorf("list users: %w", err)
+	}
+	byDN := make(map[string]*models.User)
+	byEmail := make(map[string]*models.User)
+	for i := range users {
+		u := &users[i]
+		if u.DirectoryDN != "" {
+			byDN[strings.ToLower(u.DirectoryDN)] = u
+		}
+		if u.Email != "" {
+			byEmail[strings.ToLower(u.Email)] = u
+		}
+	}
+
+	// Entries sharing an email address cannot be told apart, so none of them is synced
+	inDirectory := make(map[string]bool, len(entries))
+	emailCount := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		inDirectory[strings.ToLower(entry.DN)] = true
+		emailCount[strings.ToLower(entry.Email)]++
+	}
+
+	handled := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		email := strings.ToLower(entry.Email)
+		if entry.Email == "" || models.ValidateRecipientAddress(models.RecipientEmail, entry.Email) != nil {
+			s.record(status, outcomeSkipped)
+			log.Debug("Skipping LDAP directory entry without a valid email address", logger.Fields{"dn": entry.DN})
+			continue
+		}
+		if emailCount[email] > 1 {
+			s.conflict(status, entry, "the email address is used by several directory entries")
+			continue
+		}
+
+		existing := byDN[strings.ToLower(entry.DN)]
+		linking := false
+		if existing == nil {
+			owner := byEmail[email]
+			switch {
+			case owner == nil:
+			case owner.DirectoryDN == "" && s.config.ConflictMode == ConflictSkip:
+				s.conflict(status, entry, "the email address belongs to a user not synced from the directory")
+				continue
+			case owner.DirectoryDN != "" && inDirectory[strings.ToLower(owner.DirectoryDN)]:
+				s.conflict(status, entry, "the email address belongs to the user of "+owner.DirectoryDN)
+				continue
+			default:
+				// Unlinked users are linked; users linked to an entry that is gone follow the
+				// entry to its new DN
+				existing = owner
+				linking = true
+			}
+		} else if owner := byEmail[email]; owner != nil && owner.ID != existing.ID {
+			s.conflict(status, entry, "the new email address belongs to user "+owner.ID)
+			handled[existing.ID] = true
+			continue
+		}
+
+		if existing == nil {
+			if entry.Disabled {
+				s.record(status, outcomeSkipped)
+				continue
+			}
+			if err := s.create(entry); err != nil {
+				return err
+			}
+			s.record(status, outcomeCreated)
+			continue
+		}
+
+		handled[existing.ID] = true
+		changed, err := s.update(existing, entry)
+		if err != nil {
+			return err
+		}
+		switch {
+		case linking:
+			s.record(status, outcomeLinked)
+		case changed:
+			s.record(status, outcomeUpdated)
+		default:
+			status.Unchanged++
+		}
+	}
+
+	// Users whose entries were removed from the directory, or no longer match the filter
+	for i := range users {
+		u := &users[i]
+		if u.DirectoryDN == "" || !u.IsActive || handled[u.ID] || inDirectory[strings.ToLower(u.DirectoryDN)] {
+			continue
+		}
+		if err := s.users.DeleteUser(u.ID); err != nil {
+			return fmt.Errorf("deactivate user %s: %w", u.ID, err)
+		}
+		s.record(status, outcomeDeactivated)
+	}
+	return nil
+}
+
+// create adds a user for a directory entry
+func (s *Syncer) create(entry DirectoryUser) error {
+	u := &models.User{
+		ID:          uuid.New().String(),
+		Email:       entry.Email,
+		FullName:    entry.FullName,
+		PhoneNumber: s.phoneNumber(entry),
+		DirectoryDN: entry.DN,
+		Groups:      entry.Groups,
+		IsActive:    true,
+	}
+	if err := s.users.CreateUser(u); err != nil {
+		return fmt.Errorf("create user for %s: %w", entry.DN, err)
+	}
+	return nil
+}
+
+// update applies a directory entry to a copy of the user and stores it when anything changed
+func (s *Syncer) update(existing *models.User, entry DirectoryUser) (bool, error) {
+	u := *existing
+	u.SecondaryContacts = append([]models.ContactPoint(nil), existing.SecondaryContacts...)
+
+	changed := false
+	if u.Email != entry.Email {
+		u.ReplacePrimaryContact(models.ContactEmail, entry.Email)
+		changed = true
+	}
+	if entry.FullName != "" && u.FullName != entry.FullName {
+		u.FullName = entry.FullName
+		changed = true
+	}
+	if phone := s.phoneNumber(entry); phone != "" && u.PhoneNumber != phone {
+		u.ReplacePrimaryContact(models.ContactPhone, phone)
+		changed = true
+	}
+	if !equalStrings(u.Groups, entry.Groups) {
+		u.Groups = entry.Groups
+		changed = true
+	}
+	if u.DirectoryDN != entry.DN {
+		u.DirectoryDN = entry.DN
+		changed = true
+	}
+	if u.IsActive == entry.Disabled {
+		u.IsActive = !entry.Disabled
+		changed = true
+	}
+	if !changed {
+		return false, nil
+	}
+
+	if err := s.users.UpdateUser(&u); err != nil {
+		return false, fmt.Errorf("update user %s: %w", u.ID, err)
+	}
+	return true, nil
+}
+
+// phoneNumber returns the phone number of an entry in E.164 form, empty when it has none or
+// it cannot be used
+func (s *Syncer) phoneNumber(entry DirectoryUser) string {
+	if entry.PhoneNumber == "" {
+		return ""
+	}
+	phone := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '(', ')', '.':
+			return -1
+		}
+		return r
+	}, entry.PhoneNumber)
+	if err := models.ValidateRecipientAddress(models.RecipientPhone, phone); err != nil {
+		log.Warn("Ignoring phone number of LDAP directory entry that is not in E.164 format", logger.Fields{"dn": entry.DN})
+		return ""
+	}
+	return phone
+}
+
+// record counts a user towards an outcome
+func (s *Syncer) record(status *Status, outcome string) {
+	switch outcome {
+	case outcomeCreated:
+		status.Created++
+	case outcomeUpdated:
+		status.Updated++
+	case outcomeLinked:
+		status.Linked++
+	case outcomeDeactivated:
+		status.Deactivated++
+	case outcomeSkipped:
+		status.Skipped++
+	}
+	directorySyncUsersTotal.Inc(outcome)
+}
+
+// conflict reports an entry the sync left alone
+func (s *Syncer) conflict(status *Status, entry DirectoryUser, reason string) {
+	status.Conflicts = append(status.Conflicts, Conflict{DN: entry.DN, Email: entry.Email, Reason: reason})
+	directorySyncUsersTotal.Inc(outcomeConflict)
+}
+
+// equalStrings reports whether two slices hold the same strings in the same order
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,185 @@
+package directorysync
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/external_services/user"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDirectory returns a fixed list of users
+type fakeDirectory struct {
+	users []DirectoryUser
+	err   error
+}
+
+func (d *fakeDirectory) Users(ctx context.Context) ([]DirectoryUser, error) {
+	return d.users, d.err
+}
+
+func newTestSyncer(directory Directory, conflictMode string) (*Syncer, user.UserService) {
+	users := user.NewUserService()
+	config := DefaultConfig()
+	config.ConflictMode = conflictMode
+	return NewSyncer(users, directory, config), users
+}
+
+func findUser(t *testing.T, users user.UserService, email string) *models.User {
+	t.Helper()
+	all, err := users.ListUsers(user.UserFilter{})
+	require.NoError(t, err)
+	for i := range all {
+		if all[i].Email == email {
+			return &all[i]
+		}
+	}
+	t.Fatalf("no user with email %s", email)
+	return nil
+}
+
+func TestSyncer_CreatesAndLinksUsers(t *testing.T) {
+	directory := &fakeDirectory{users: []DirectoryUser{
+		{DN: "CN=New User,OU=People,DC=example,DC=com", Email: "new.user@example.com", FullName: "New User", PhoneNumber: "+1 (555) 010-2030", Groups: []string{"Engineering"}},
+		{DN: "CN=John Doe,OU=People,DC=example,DC=com", Email: "john.doe@company.com", FullName: "Johnathan Doe", Groups: []string{"Admins", "Engineering"}},
+	}}
+	syncer, users := newTestSyncer(directory, ConflictLink)
+
+	status, err := syncer.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Created)
+	assert.Equal(t, 1, status.Linked)
+	assert.Empty(t, status.Conflicts)
+
+	created := findUser(t, users, "new.user@example.com")
+	assert.True(t, created.IsActive)
+	assert.Equal(t, "New User", created.FullName)
+	assert.Equal(t, "+15550102030", created.PhoneNumber)
+	assert.Equal(t, []string{"Engineering"}, created.Groups)
+
+	linked := findUser(t, users, "john.doe@company.com")
+	assert.Equal(t, "user-001", linked.ID)
+	assert.Equal(t, "CN=John Doe,OU=People,DC=example,DC=com", linked.DirectoryDN)
+	assert.Equal(t, "Johnathan Doe", linked.FullName)
+	assert.Equal(t, []string{"Admins", "Engineering"}, linked.Groups)
+
+	// A second sync of the same directory changes nothing
+	status, err = syncer.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, status.Unchanged)
+	assert.Zero(t, status.Created+status.Updated+status.Linked+status.Deactivated)
+	assert.Same(t, status, syncer.Status())
+}
+
+func TestSyncer_SkipModeReportsConflicts(t *testing.T) {
+	directory := &fakeDirectory{users: []DirectoryUser{
+		{DN: "CN=John Doe,OU=People,DC=example,DC=com", Email: "John.Doe@company.com", FullName: "Johnathan Doe"},
+	}}
+	syncer, users := newTestSyncer(directory, ConflictSkip)
+
+	status, err := syncer.Sync(context.Background())
+	require.NoError(t, err)
+	require.Len(t, status.Conflicts, 1)
+	assert.Equal(t, "CN=John Doe,OU=People,DC=example,DC=com", status.Conflicts[0].DN)
+
+	existing, err := users.GetUserByID("user-001")
+	require.NoError(t, err)
+	assert.Empty(t, existing.DirectoryDN)
+	assert.NotEqual(t, "Johnathan Doe", existing.FullName)
+}
+
+func TestSyncer_UpdatesMovesAndDeactivates(t *testing.T) {
+	directory := &fakeDirectory{users: []DirectoryUser{
+		{DN: "CN=A,OU=People,DC=example,DC=com", Email: "a@example.com", FullName: "A"},
+		{DN: "CN=B,OU=People,DC=example,DC=com", Email: "b@example.com", FullName: "B"},
+		{DN: "CN=C,OU=People,DC=example,DC=com", Email: "c@example.com", FullName: "C"},
+	}}
+	syncer, users := newTestSyncer(directory, ConflictLink)
+	_, err := syncer.Sync(context.Background())
+	require.NoError(t, err)
+	b := findUser(t, users, "b@example.com")
+
+	directory.users = []DirectoryUser{
+		// A changed its email address and was disabled
+		{DN: "CN=A,OU=People,DC=example,DC=com", Email: "a.new@example.com", FullName: "A", Disabled: true},
+		// B moved to another OU
+		{DN: "CN=B,OU=Former,DC=example,DC=com", Email: "b@example.com", FullName: "B"},
+		// C was removed
+	}
+	status, err := syncer.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Updated)
+	assert.Equal(t, 1, status.Linked)
+	assert.Equal(t, 1, status.Deactivated)
+
+	a := findUser(t, users, "a.new@example.com")
+	assert.False(t, a.IsActive)
+
+	moved, err := users.GetUserByID(b.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "CN=B,OU=Former,DC=example,DC=com", moved.DirectoryDN)
+	assert.True(t, moved.IsActive)
+
+	assert.False(t, findUser(t, users, "c@example.com").IsActive)
+}
+
+func TestSyncer_SkipsUnusableEntries(t *testing.T) {
+	directory := &fakeDirectory{users: []DirectoryUser{
+		{DN: "CN=No Mail,DC=example,DC=com"},
+		{DN: "CN=Bad Mail,DC=example,DC=com", Email: "not an address"},
+		{DN: "CN=Disabled,DC=example,DC=com", Email: "disabled@example.com", Disabled: true},
+		{DN: "CN=Twin 1,DC=example,DC=com", Email: "twin@example.com"},
+		{DN: "CN=Twin 2,DC=example,DC=com", Email: "twin@example.com"},
+		{DN: "CN=Bad Phone,DC=example,DC=com", Email: "phone@example.com", PhoneNumber: "555-0100"},
+	}}
+	syncer, users := newTestSyncer(directory, ConflictLink)
+
+	status, err := syncer.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, status.Skipped)
+	assert.Len(t, status.Conflicts, 2)
+	assert.Equal(t, 1, status.Created)
+	assert.Empty(t, findUser(t, users, "phone@example.com").PhoneNumber)
+}
+
+func TestSyncer_FailedReadsChangeNothing(t *testing.T) {
+	syncer, users := newTestSyncer(&fakeDirectory{users: []DirectoryUser{
+		{DN: "CN=A,DC=example,DC=com", Email: "a@example.com"},
+	}}, ConflictLink)
+	_, err := syncer.Sync(context.Background())
+	require.NoError(t, err)
+
+	syncer.directory = &fakeDirectory{}
+	status, err := syncer.Sync(context.Background())
+	assert.ErrorIs(t, err, ErrEmptyDirectory)
+	assert.NotEmpty(t, status.Error)
+	assert.True(t, findUser(t, users, "a@example.com").IsActive)
+
+	syncer.directory = &fakeDirectory{err: errors.New("connection refused")}
+	_, err = syncer.Sync(context.Background())
+	assert.Error(t, err)
+	assert.True(t, findUser(t, users, "a@example.com").IsActive)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	config := DefaultConfig()
+	config.BaseDN = "DC=example,DC=com"
+	assert.NoError(t, config.Validate())
+
+	config.ConflictMode = "merge"
+	assert.ErrorIs(t, config.Validate(), ErrInvalidConfiguration)
+
+	config = DefaultConfig()
+	assert.ErrorIs(t, config.Validate(), ErrInvalidConfiguration)
+}
+
+func TestRDNValue(t *testing.T) {
+	assert.Equal(t, "Engineering", rdnValue("CN=Engineering,OU=Groups,DC=example,DC=com"))
+	assert.Equal(t, "Sales, EMEA", rdnValue(`CN=Sales\, EMEA,OU=Groups,DC=example,DC=com`))
+	assert.Equal(t, "Ops", rdnValue("cn=Ops"))
+	assert.True(t, accountDisabled("514"))
+	assert.False(t, accountDisabled("512"))
+}
@@ -0,0 +1,131 @@
+package domaincheck
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net"
+	"strings"
+)
+
+// DKIM keys shorter than minDKIMKeyBits are ignored by receivers; shorter than
+// recommendedDKIMKeyBits they are considered weak
+const (
+	minDKIMKeyBits         = 1024
+	recommendedDKIMKeyBits = 2048
+)
+
+// DKIMReport lists the DKIM keys found for the checked selectors
+type DKIMReport struct {
+	Selectors []string  `json:"selectors"`
+	Keys      []DKIMKey `json:"keys"`
+}
+
+// DKIMKey is the public key published for a selector
+type DKIMKey struct {
+	Selector string `json:"selector"`
+	Record   string `json:"record"`
+	KeyType  string `json:"key_type"`
+	// KeyBits is the length of RSA keys
+	KeyBits int `json:"key_bits,omitempty"`
+	// Revoked is set for keys with an empty public key
+	Revoked bool `json:"revoked,omitempty"`
+}
+
+// dkim looks up the DKIM keys of domain for every selector
+func (d *domainCheck) dkim(domain string, selectors []string) DKIMReport {
+	report := DKIMReport{Selectors: selectors, Keys: []DKIMKey{}}
+	for _, selector := range selectors {
+		name := selector + "._domainkey." + domain
+		records, err := d.resolver.LookupTXT(d.ctx, name)
+		var dnsErr *net.DNSError
+		if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+			d.report(CheckDKIM, SeverityError, "DNS lookup of %s failed: %v", name, err)
+			continue
+		}
+		if len(records) == 0 {
+			// Selectors are guessed, so the ones without a key are not problems
+			continue
+		}
+		// Long keys are split into several strings of one record
+		record := strings.Join(records, "")
+		tags := parseTags(record)
+		if version, ok := tags["v"]; ok && version != "DKIM1" {
+			d.report(CheckDKIM, SeverityError, "the DKIM record of selector %s has version %q instead of DKIM1", selector, version)
+			continue
+		}
+
+		key := DKIMKey{Selector: selector, Record: record, KeyType: strings.ToLower(tags["k"])}
+		if key.KeyType == "" {
+			key.KeyType = "rsa"
+		}
+		publicKey, ok := tags["p"]
+		switch {
+		case !ok:
+			d.report(CheckDKIM, SeverityError, "the DKIM record of selector %s has no public key (p=)", selector)
+			continue
+		case publicKey == "":
+			key.Revoked = true
+			d.report(CheckDKIM, SeverityWarning, "the DKIM key of selector %s is revoked", selector)
+		case key.KeyType == "rsa":
+			key.KeyBits = d.rsaKeyBits(selector, publicKey)
+		case key.KeyType != "ed25519":
+			d.report(CheckDKIM, SeverityError, "the DKIM key of selector %s has unknown key type %q", selector, key.KeyType)
+		}
+		report.Keys = append(report.Keys, key)
+	}
+
+	if len(report.Keys) == 0 {
+		d.report(CheckDKIM, SeverityWarning, "no DKIM key was found for the selectors %s; pass the selector your provider signs with",
+			strings.Join(selectors, ", "))
+	}
+	return report
+}
+
+// rsaKeyBits returns the length of an RSA public key and reports keys that are unreadable or short
+func (d *domainCheck) rsaKeyBits(selector, publicKey string) int {
+	der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(publicKey), ""))
+	if err != nil {
+		d.report(CheckDKIM, SeverityError, "the DKIM key of selector %s is not valid base64", selector)
+		return 0
+	}
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		// Some keys are published as PKCS #1 instead of SubjectPublicKeyInfo
+		if rsaKey, rsaErr := x509.ParsePKCS1PublicKey(der); rsaErr == nil {
+			parsed = rsaKey
+		} else {
+			d.report(CheckDKIM, SeverityError, "the DKIM key of selector %s is not a valid public key", selector)
+			return 0
+		}
+	}
+	rsaKey, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		d.report(CheckDKIM, SeverityError, "the DKIM key of selector %s is not an RSA key", selector)
+		return 0
+	}
+
+	bits := rsaKey.N.BitLen()
+	switch {
+	case bits < minDKIMKeyBits:
+		d.report(CheckDKIM, SeverityError, "the DKIM key of selector %s has %d bits; receivers ignore keys shorter than %d bits", selector, bits, minDKIMKeyBits)
+	case bits < recommendedDKIMKeyBits:
+		d.report(CheckDKIM, SeverityWarning, "the DKIM key of selector %s has %d bits; %d bit keys are recommended", selector, bits, recommendedDKIMKeyBits)
+	}
+	return bits
+}
+
+// parseTags parses a tag list such as "v=DKIM1; k=rsa; p=..." into its tags; names are
+// lowercased, values are trimmed
+func parseTags(record string) map[string]string {
+	tags := make(map[string]string)
+	for _, part := range strings.Split(record, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		tags[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(value)
+	}
+	return tags
+}
@@ -0,0 +1,105 @@
+package domaincheck
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DMARCReport describes the DMARC record that applies to a domain
+type DMARCReport struct {
+	Record string `json:"record,omitempty"`
+	// Domain is where the record was found: the checked domain, or its organizational domain
+	Domain           string   `json:"domain,omitempty"`
+	Policy           string   `json:"policy,omitempty"`
+	SubdomainPolicy  string   `json:"subdomain_policy,omitempty"`
+	Percent          int      `json:"percent,omitempty"`
+	SPFAlignment     string   `json:"spf_alignment,omitempty"`
+	DKIMAlignment    string   `json:"dkim_alignment,omitempty"`
+	AggregateReports []string `json:"aggregate_reports,omitempty"`
+}
+
+// dmarc checks the DMARC record of domain, or of its organizational domain when it has none
+func (d *domainCheck) dmarc(domain string) DMARCReport {
+	var report DMARCReport
+	found := domain
+	records, ok := d.lookupTXT(CheckDMARC, "_dmarc."+domain, "v=DMARC1")
+	if !ok {
+		return report
+	}
+	if organizational := organizationalDomain(domain); len(records) == 0 && organizational != domain {
+		found = organizational
+		if records, ok = d.lookupTXT(CheckDMARC, "_dmarc."+organizational, "v=DMARC1"); !ok {
+			return report
+		}
+	}
+
+	switch len(records) {
+	case 0:
+		d.report(CheckDMARC, SeverityError, "%s has no DMARC record; large mailbox providers require one from bulk senders", domain)
+		return report
+	case 1:
+	default:
+		d.report(CheckDMARC, SeverityError, "_dmarc.%s has %d DMARC records; receivers ignore all of them", found, len(records))
+		return report
+	}
+
+	report.Record = records[0]
+	report.Domain = found
+	tags := parseTags(report.Record)
+	report.Policy = strings.ToLower(tags["p"])
+	report.SubdomainPolicy = strings.ToLower(tags["sp"])
+	report.SPFAlignment = alignment(tags["aspf"])
+	report.DKIMAlignment = alignment(tags["adkim"])
+	report.Percent = 100
+	if pct, ok := tags["pct"]; ok {
+		if percent, err := strconv.Atoi(pct); err == nil && percent >= 0 && percent <= 100 {
+			report.Percent = percent
+		} else {
+			d.report(CheckDMARC, SeverityWarning, "the DMARC pct tag %q is not a number between 0 and 100", pct)
+		}
+	}
+	for _, uri := range strings.Split(tags["rua"], ",") {
+		if uri = strings.TrimSpace(uri); uri != "" {
+			report.AggregateReports = append(report.AggregateReports, uri)
+		}
+	}
+
+	policy := report.Policy
+	if found != domain && report.SubdomainPolicy != "" {
+		policy = report.SubdomainPolicy
+	}
+	switch policy {
+	case "reject", "quarantine":
+		if report.Percent < 100 {
+			d.report(CheckDMARC, SeverityWarning, "the DMARC policy %s only applies to %d%% of failing email", policy, report.Percent)
+		}
+	case "none":
+		d.report(CheckDMARC, SeverityWarning, "the DMARC policy is none, which only monitors; move to quarantine or reject once reports show all email passes")
+	case "":
+		d.report(CheckDMARC, SeverityError, "the DMARC record has no policy (p=); receivers ignore it")
+	default:
+		d.report(CheckDMARC, SeverityError, "the DMARC policy %q is not none, quarantine or reject", policy)
+	}
+	if len(report.AggregateReports) == 0 {
+		d.report(CheckDMARC, SeverityWarning, "the DMARC record has no rua tag, so no aggregate reports are sent to show which email fails")
+	}
+	return report
+}
+
+// alignment describes a DMARC alignment mode; relaxed is the default
+func alignment(mode string) string {
+	if strings.EqualFold(strings.TrimSpace(mode), "s") {
+		return "strict"
+	}
+	return "relaxed"
+}
+
+// organizationalDomain approximates the organizational domain of a domain by its last two
+// labels, e.g. mail.example.com is part of example.com
+func organizationalDomain(domain string) string {
+	labels := strings.Split(domain, ".")
+	if len(labels) <= 2 {
+		return domain
+	}
+	return strings.Join(labels[len(labels)-2:], ".")
+}
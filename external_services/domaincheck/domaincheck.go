@@ -0,0 +1,210 @@
+// Package domaincheck checks the SPF, DKIM and DMARC records of email sending domains in DNS
+// and reports the problems that would make receivers reject or junk their email
+package domaincheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gaurav2721/notification-service/constants"
+)
+
+// Check names reported with problems
+const (
+	CheckSPF   = "spf"
+	CheckDKIM  = "dkim"
+	CheckDMARC = "dmarc"
+)
+
+// Problem severities. Errors make receivers fail or reject email; warnings weaken deliverability.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// maxSelectors bounds the number of DKIM selectors looked up in one check
+const maxSelectors = 20
+
+var (
+	// domainPattern matches domain names such as example.com
+	domainPattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+	// selectorPattern matches DKIM selectors such as s1 or google
+	selectorPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9._-]{0,62})$`)
+)
+
+// Resolver looks up TXT records; net.Resolver implements it
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// Config holds the DKIM selectors checked when a check names none, and the time DNS lookups may take
+type Config struct {
+	DKIMSelectors []string
+	Timeout       time.Duration
+}
+
+// LoadConfigFromEnv reads the domain check configuration from environment variables
+func LoadConfigFromEnv() Config {
+	config := Config{
+		DKIMSelectors: splitList(constants.DefaultEmailDKIMSelectors),
+		Timeout:       time.Duration(constants.DefaultDomainCheckTimeoutSeconds) * time.Second,
+	}
+	if selectors := splitList(os.Getenv(constants.EmailDKIMSelectorsEnvVar)); len(selectors) > 0 {
+		config.DKIMSelectors = selectors
+	}
+	return config
+}
+
+// Problem is something wrong with the records of a domain
+type Problem struct {
+	Check    string `json:"check"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// Report is the outcome of checking a domain. Healthy is set when there are no errors.
+type Report struct {
+	Domain    string      `json:"domain"`
+	Healthy   bool        `json:"healthy"`
+	SPF       SPFReport   `json:"spf"`
+	DKIM      DKIMReport  `json:"dkim"`
+	DMARC     DMARCReport `json:"dmarc"`
+	Problems  []Problem   `json:"problems"`
+	CheckedAt time.Time   `json:"checked_at"`
+	// DurationMs is how long the DNS lookups took
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// Checker checks sending domains against DNS
+type Checker struct {
+	config   Config
+	resolver Resolver
+	now      func() time.Time
+}
+
+// NewChecker creates a checker using resolver; nil uses the system resolver
+func NewChecker(config Config, resolver Resolver) *Checker {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = time.Duration(constants.DefaultDomainCheckTimeoutSeconds) * time.Second
+	}
+	return &Checker{config: config, resolver: resolver, now: time.Now}
+}
+
+// Check looks up the SPF, DKIM and DMARC records of domain and reports their problems. DKIM keys
+// are looked up for the given selectors, or the configured ones when there are none.
+func (c *Checker) Check(ctx context.Context, domain string, selectors []string) (*Report, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if !domainPattern.MatchString(domain) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidDomain, domain)
+	}
+	if len(selectors) == 0 {
+		selectors = c.config.DKIMSelectors
+	}
+	if len(selectors) > maxSelectors {
+		return nil, fmt.Errorf("%w: at most %d selectors can be checked", ErrInvalidSelector, maxSelectors)
+	}
+	normalized := make([]string, 0, len(selectors))
+	for _, selector := range selectors {
+		selector = strings.ToLower(strings.TrimSpace(selector))
+		if !selectorPattern.MatchString(selector) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSelector, selector)
+		}
+		normalized = append(normalized, selector)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	start := c.now()
+	check := &domainCheck{ctx: ctx, resolver: c.resolver}
+	report := &Report{
+		Domain:    domain,
+		SPF:       check.spf(domain),
+		DKIM:      check.dkim(domain, normalized),
+		DMARC:     check.dmarc(domain),
+		CheckedAt: start,
+	}
+
+	// DMARC passes only when SPF or DKIM passes for the domain of the From address
+	if report.DMARC.Record != "" && report.SPF.Record == "" && len(report.DKIM.Keys) == 0 {
+		check.report(CheckDMARC, SeverityError, "DMARC cannot pass: the domain has neither an SPF record nor a DKIM key to align with")
+	}
+
+	report.Problems = check.problems
+	if report.Problems == nil {
+		report.Problems = []Problem{}
+	}
+	report.Healthy = true
+	for _, problem := range report.Problems {
+		if problem.Severity == SeverityError {
+			report.Healthy = false
+		}
+	}
+	report.DurationMs = c.now().Sub(start).Milliseconds()
+	return report, nil
+}
+
+// domainCheck collects the problems found while checking a domain
+type domainCheck struct {
+	ctx      context.Context
+	resolver Resolver
+	problems []Problem
+}
+
+// report records a problem
+func (d *domainCheck) report(check, severity, format string, args ...interface{}) {
+	d.problems = append(d.problems, Problem{Check: check, Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+// lookupTXT returns the TXT records of name that start with prefix, compared case-insensitively.
+// A name that does not exist has no records; other failures are reported under check.
+func (d *domainCheck) lookupTXT(check, name, prefix string) ([]string, bool) {
+	records, err := d.resolver.LookupTXT(d.ctx, name)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return nil, true
+		}
+		d.report(check, SeverityError, "DNS lookup of %s failed: %v", name, err)
+		return nil, false
+	}
+
+	var matching []string
+	for _, record := range records {
+		if hasTagPrefix(record, prefix) {
+			matching = append(matching, record)
+		}
+	}
+	return matching, true
+}
+
+// hasTagPrefix reports whether a record starts with prefix, such as v=spf1, followed by the end
+// of the record or a separator
+func hasTagPrefix(record, prefix string) bool {
+	record = strings.TrimSpace(record)
+	if len(record) < len(prefix) || !strings.EqualFold(record[:len(prefix)], prefix) {
+		return false
+	}
+	rest := record[len(prefix):]
+	return rest == "" || rest[0] == ' ' || rest[0] == ';' || rest[0] == '\t'
+}
+
+// splitList splits a comma separated list, trimming spaces and dropping empty entries
+func splitList(value string) []string {
+	var list []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	return list
+}
@@ -0,0 +1,153 @@
+package domaincheck
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// weakDKIMKey is a 512 bit RSA public key
+const weakDKIMKey = "MFwwDQYJKoZIhvcNAQEBBQADSwAwSAJBAMCuxCSeRSSpE6OezGmvnvgrqaasmoY8X3qE6MexYsg/IEH3jCcGq9tRa/BQCpnZi0QUmksb04GAZE6vqd3OmSkCAwEAAQ=="
+
+// fakeResolver answers TXT lookups from a map; names that are missing do not exist
+type fakeResolver map[string][]string
+
+func (r fakeResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	records, ok := r[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	if len(records) == 1 && records[0] == "SERVFAIL" {
+		return nil, &net.DNSError{Err: "server misbehaving", Name: name}
+	}
+	return records, nil
+}
+
+// problemMessages returns the severity and message of the problems of a check
+func problemMessages(report *Report, check string) []string {
+	var messages []string
+	for _, problem := range report.Problems {
+		if problem.Check == check {
+			messages = append(messages, problem.Severity+": "+problem.Message)
+		}
+	}
+	return messages
+}
+
+func TestCheck_HealthyDomain(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	encoded := base64.StdEncoding.EncodeToString(der)
+
+	checker := NewChecker(Config{DKIMSelectors: []string{"s1", "s2"}}, fakeResolver{
+		"acme.com":               {"google-site-verification=abc", "v=spf1 include:_spf.google.com ip4:203.0.113.0/24 -all"},
+		"_spf.google.com":        {"v=spf1 include:_netblocks.google.com ~all"},
+		"_netblocks.google.com":  {"v=spf1 ip4:35.190.247.0/24 ip6:2001:4860:4000::/36 ~all"},
+		"s1._domainkey.acme.com": {"v=DKIM1; k=rsa; p=" + encoded[:100], encoded[100:]},
+		"_dmarc.acme.com":        {"v=DMARC1; p=reject; adkim=s; rua=mailto:dmarc@acme.com"},
+	})
+
+	report, err := checker.Check(context.Background(), " Acme.COM. ", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "acme.com", report.Domain)
+	assert.True(t, report.Healthy)
+	assert.Empty(t, report.Problems)
+
+	assert.Equal(t, "-all", report.SPF.All)
+	assert.Equal(t, []string{"_spf.google.com", "_netblocks.google.com"}, report.SPF.Includes)
+	assert.Equal(t, 2, report.SPF.Lookups)
+
+	assert.Equal(t, []string{"s1", "s2"}, report.DKIM.Selectors)
+	require.Len(t, report.DKIM.Keys, 1)
+	assert.Equal(t, "s1", report.DKIM.Keys[0].Selector)
+	assert.Equal(t, "rsa", report.DKIM.Keys[0].KeyType)
+	assert.Equal(t, 2048, report.DKIM.Keys[0].KeyBits)
+
+	assert.Equal(t, "acme.com", report.DMARC.Domain)
+	assert.Equal(t, "reject", report.DMARC.Policy)
+	assert.Equal(t, 100, report.DMARC.Percent)
+	assert.Equal(t, "strict", report.DMARC.DKIMAlignment)
+	assert.Equal(t, "relaxed", report.DMARC.SPFAlignment)
+	assert.Equal(t, []string{"mailto:dmarc@acme.com"}, report.DMARC.AggregateReports)
+}
+
+func TestCheck_ReportsProblems(t *testing.T) {
+	checker := NewChecker(Config{DKIMSelectors: []string{"default"}}, fakeResolver{
+		"mail.acme.com":                {"v=spf1 ptr include:a.acme.com include:b.acme.com mx a a:x.acme.com ip4:300.1.1.1 foo:bar +all"},
+		"a.acme.com":                   {"v=spf1 exists:%{i}.acme.com a mx redirect=b.acme.com"},
+		"b.acme.com":                   {"v=spf1 mx a a a ~all"},
+		"s1._domainkey.mail.acme.com":  {"v=DKIM1; p=" + weakDKIMKey},
+		"old._domainkey.mail.acme.com": {"v=DKIM1; k=rsa; p="},
+		"bad._domainkey.mail.acme.com": {"SERVFAIL"},
+		"_dmarc.acme.com":              {"v=DMARC1; p=quarantine; sp=none; pct=50"},
+		"_dmarc.mail.acme.com":         {"v=spf1 -all"},
+	})
+
+	report, err := checker.Check(context.Background(), "mail.acme.com", []string{"s1", "old", "bad"})
+	require.NoError(t, err)
+	assert.False(t, report.Healthy)
+
+	assert.Equal(t, "+all", report.SPF.All)
+	assert.Equal(t, 14, report.SPF.Lookups)
+	assert.Equal(t, []string{
+		"warning: the SPF record uses the ptr mechanism, which is deprecated and ignored by some receivers",
+		"warning: the SPF record includes b.acme.com more than once, which loops or wastes lookups",
+		"error: invalid ip4 mechanism \"ip4:300.1.1.1\" in the SPF record",
+		"error: unknown mechanism \"foo:bar\" in the SPF record",
+		"error: the SPF record takes 14 DNS lookups; receivers fail records taking more than 10",
+		"error: the SPF record ends with +all, which allows any server to send as mail.acme.com",
+	}, problemMessages(report, CheckSPF))
+
+	require.Len(t, report.DKIM.Keys, 2)
+	assert.Equal(t, 512, report.DKIM.Keys[0].KeyBits)
+	assert.True(t, report.DKIM.Keys[1].Revoked)
+	assert.Equal(t, []string{
+		"error: the DKIM key of selector s1 has 512 bits; receivers ignore keys shorter than 1024 bits",
+		"warning: the DKIM key of selector old is revoked",
+		"error: DNS lookup of bad._domainkey.mail.acme.com failed: lookup bad._domainkey.mail.acme.com: server misbehaving",
+	}, problemMessages(report, CheckDKIM))
+
+	// The record of the organizational domain applies, with its subdomain policy
+	assert.Equal(t, "acme.com", report.DMARC.Domain)
+	assert.Equal(t, "quarantine", report.DMARC.Policy)
+	assert.Equal(t, "none", report.DMARC.SubdomainPolicy)
+	assert.Equal(t, 50, report.DMARC.Percent)
+	assert.Equal(t, []string{
+		"warning: the DMARC policy is none, which only monitors; move to quarantine or reject once reports show all email passes",
+		"warning: the DMARC record has no rua tag, so no aggregate reports are sent to show which email fails",
+	}, problemMessages(report, CheckDMARC))
+}
+
+func TestCheck_MissingRecords(t *testing.T) {
+	checker := NewChecker(Config{DKIMSelectors: []string{"s1"}}, fakeResolver{
+		"acme.io":        {"v=spf1 include:spf.acme.io", "v=spf1 -all"},
+		"_dmarc.acme.io": {"v=DMARC1; p=reject; rua=mailto:d@acme.io"},
+	})
+
+	report, err := checker.Check(context.Background(), "acme.io", nil)
+	require.NoError(t, err)
+	assert.False(t, report.Healthy)
+	assert.Empty(t, report.SPF.Record)
+	assert.Equal(t, []string{"error: acme.io has 2 SPF records; receivers reject domains with more than one"}, problemMessages(report, CheckSPF))
+	assert.Equal(t, []string{"warning: no DKIM key was found for the selectors s1; pass the selector your provider signs with"}, problemMessages(report, CheckDKIM))
+	assert.Equal(t, []string{"error: DMARC cannot pass: the domain has neither an SPF record nor a DKIM key to align with"}, problemMessages(report, CheckDMARC))
+
+	report, err = checker.Check(context.Background(), "acme.net", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"error: acme.net has no DMARC record; large mailbox providers require one from bulk senders"}, problemMessages(report, CheckDMARC))
+
+	_, err = checker.Check(context.Background(), "https://acme.com", nil)
+	assert.True(t, errors.Is(err, ErrInvalidDomain))
+	_, err = checker.Check(context.Background(), "acme.com", []string{"s1", "../x"})
+	assert.True(t, errors.Is(err, ErrInvalidSelector))
+}
@@ -0,0 +1,9 @@
+package domaincheck
+
+import "errors"
+
+// Domain check errors
+var (
+	ErrInvalidDomain   = errors.New("invalid domain")
+	ErrInvalidSelector = errors.New("invalid DKIM selector")
+)
@@ -0,0 +1,164 @@
+package domaincheck
+
+import (
+	"net"
+	"strings"
+)
+
+// maxSPFLookups is the number of DNS lookups an SPF evaluation may take (RFC 7208, section 4.6.4);
+// records needing more fail with a permanent error
+const maxSPFLookups = 10
+
+// SPFReport describes the SPF record of a domain
+type SPFReport struct {
+	Record string `json:"record,omitempty"`
+	// All is the qualified all mechanism ending the record, such as -all or ~all
+	All string `json:"all,omitempty"`
+	// Includes lists the domains included, directly or through other includes and redirects
+	Includes []string `json:"includes,omitempty"`
+	// Lookups is the number of DNS lookups evaluating the record takes
+	Lookups int `json:"dns_lookups"`
+}
+
+// spf checks the SPF record of domain
+func (d *domainCheck) spf(domain string) SPFReport {
+	var report SPFReport
+	records, ok := d.lookupTXT(CheckSPF, domain, "v=spf1")
+	if !ok {
+		return report
+	}
+	switch len(records) {
+	case 0:
+		d.report(CheckSPF, SeverityError, "%s has no SPF record; receivers cannot tell which servers may send its email", domain)
+		return report
+	case 1:
+	default:
+		d.report(CheckSPF, SeverityError, "%s has %d SPF records; receivers reject domains with more than one", domain, len(records))
+		return report
+	}
+
+	report.Record = records[0]
+	walk := &spfWalk{check: d, seen: map[string]bool{domain: true}}
+	report.All = walk.record(report.Record, true)
+	report.Includes = walk.includes
+	report.Lookups = walk.lookups
+
+	if report.Lookups > maxSPFLookups {
+		d.report(CheckSPF, SeverityError, "the SPF record takes %d DNS lookups; receivers fail records taking more than %d", report.Lookups, maxSPFLookups)
+	}
+	switch report.All {
+	case "+all":
+		d.report(CheckSPF, SeverityError, "the SPF record ends with +all, which allows any server to send as %s", domain)
+	case "?all":
+		d.report(CheckSPF, SeverityWarning, "the SPF record ends with ?all, which makes no statement about other servers; use ~all or -all")
+	case "":
+		d.report(CheckSPF, SeverityWarning, "the SPF record does not end with an all mechanism; add ~all or -all")
+	}
+	return report
+}
+
+// spfWalk follows the includes and redirects of an SPF record, counting DNS lookups
+type spfWalk struct {
+	check    *domainCheck
+	seen     map[string]bool
+	includes []string
+	lookups  int
+}
+
+// record walks the terms of an SPF record and returns its all mechanism, or the one
+// of the record it redirects to. Problems of included records are only reported for top, the
+// record of the checked domain, and for lookups that fail.
+func (w *spfWalk) record(record string, top bool) string {
+	all := ""
+	redirect := ""
+	for _, term := range strings.Fields(record)[1:] {
+		// Modifiers are name=value, where the name has no ':' or '/'; explanations and unknown
+		// modifiers do not change the result
+		if modifier, value, ok := strings.Cut(term, "="); ok && !strings.ContainsAny(modifier, ":/") {
+			if strings.EqualFold(modifier, "redirect") {
+				redirect = value
+			}
+			continue
+		}
+
+		qualifier := "+"
+		if strings.ContainsAny(term[:1], "+-~?") {
+			qualifier, term = term[:1], term[1:]
+		}
+		name, argument := strings.ToLower(term), ""
+		if i := strings.IndexAny(term, ":/"); i >= 0 {
+			name, argument = strings.ToLower(term[:i]), term[i:]
+		}
+
+		switch name {
+		case "all":
+			all = qualifier + "all"
+		case "ip4", "ip6":
+			if !validNetwork(name, strings.TrimPrefix(argument, ":")) && top {
+				w.check.report(CheckSPF, SeverityError, "invalid %s mechanism %q in the SPF record", name, term)
+			}
+		case "a", "mx", "exists":
+			w.lookups++
+		case "ptr":
+			w.lookups++
+			if top {
+				w.check.report(CheckSPF, SeverityWarning, "the SPF record uses the ptr mechanism, which is deprecated and ignored by some receivers")
+			}
+		case "include":
+			w.lookups++
+			w.follow(strings.TrimPrefix(argument, ":"), "include")
+		default:
+			if top {
+				w.check.report(CheckSPF, SeverityError, "unknown mechanism %q in the SPF record", term)
+			}
+		}
+	}
+
+	// A redirect only applies when the record has no all mechanism
+	if redirect != "" && all == "" {
+		w.lookups++
+		return w.follow(redirect, "redirect")
+	}
+	return all
+}
+
+// follow walks the SPF record of an included or redirected domain and returns its all mechanism
+func (w *spfWalk) follow(domain, kind string) string {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	if strings.Contains(domain, "%") {
+		// Macros expand per message, so the record cannot be followed
+		return ""
+	}
+	if w.seen[domain] {
+		w.check.report(CheckSPF, SeverityWarning, "the SPF record includes %s more than once, which loops or wastes lookups", domain)
+		return ""
+	}
+	w.seen[domain] = true
+	w.includes = append(w.includes, domain)
+
+	records, ok := w.check.lookupTXT(CheckSPF, domain, "v=spf1")
+	if !ok {
+		return ""
+	}
+	if len(records) != 1 {
+		w.check.report(CheckSPF, SeverityError, "the %s domain %s has %d SPF records instead of one", kind, domain, len(records))
+		return ""
+	}
+	return w.record(records[0], false)
+}
+
+// validNetwork reports whether the argument of an ip4 or ip6 mechanism is an address or network
+// of its family
+func validNetwork(mechanism, network string) bool {
+	var ip net.IP
+	if strings.Contains(network, "/") {
+		parsed, _, err := net.ParseCIDR(network)
+		if err != nil {
+			return false
+		}
+		ip = parsed
+	} else {
+		ip = net.ParseIP(network)
+	}
+	return ip != nil && (mechanism == "ip4") == (ip.To4() != nil)
+}
@@ -0,0 +1,44 @@
+package email
+
+import (
+	"context"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// BatchEmailService is implemented by email services that can send several emails in one
+// provider call
+type BatchEmailService interface {
+	EmailService
+
+	// MaxBatchSize is the largest number of emails the provider accepts in one batch
+	MaxBatchSize() int
+
+	// SendEmailBatch sends the emails and returns a response and an error for each of them,
+	// in the order of the notifications
+	SendEmailBatch(ctx context.Context, notifications []*models.EmailNotificationRequest) ([]interface{}, []error)
+}
+
+// MaxBatchSize returns the largest batch the email service sends in one provider call,
+// which is 1 for services that cannot batch
+func MaxBatchSize(service EmailService) int {
+	if batcher, ok := service.(BatchEmailService); ok && batcher.MaxBatchSize() > 1 {
+		return batcher.MaxBatchSize()
+	}
+	return 1
+}
+
+// SendBatch sends the emails in one batch if the email service supports batches, or one by one
+// otherwise, and returns a response and an error for each of them
+func SendBatch(ctx context.Context, service EmailService, notifications []*models.EmailNotificationRequest) ([]interface{}, []error) {
+	if batcher, ok := service.(BatchEmailService); ok {
+		return batcher.SendEmailBatch(ctx, notifications)
+	}
+
+	responses := make([]interface{}, len(notifications))
+	errs := make([]error, len(notifications))
+	for i, notification := range notifications {
+		responses[i], errs[i] = service.SendEmail(ctx, notification)
+	}
+	return responses, errs
+}
@@ -0,0 +1,69 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/gomail.v2"
+)
+
+// singleEmailService sends emails one at a time and counts them
+type singleEmailService struct {
+	sent int
+}
+
+func (s *singleEmailService) SendEmail(ctx context.Context, notification interface{}) (interface{}, error) {
+	notif := notification.(*models.EmailNotificationRequest)
+	if notif.Recipient == "" {
+		return nil, errors.New("no recipient")
+	}
+	s.sent++
+	return notif.ID, nil
+}
+
+func batchNotification(id, recipient string) *models.EmailNotificationRequest {
+	return &models.EmailNotificationRequest{
+		ID:        id,
+		Type:      "email",
+		Content:   models.EmailContent{Subject: "Subject", EmailBody: "Body"},
+		Recipient: recipient,
+	}
+}
+
+func TestSendBatch_FallsBackToSingleSends(t *testing.T) {
+	service := &singleEmailService{}
+	assert.Equal(t, 1, MaxBatchSize(service))
+
+	responses, errs := SendBatch(context.Background(), service, []*models.EmailNotificationRequest{
+		batchNotification("a", "a@example.com"),
+		batchNotification("b", ""),
+		batchNotification("c", "c@example.com"),
+	})
+
+	assert.Equal(t, 2, service.sent)
+	assert.Equal(t, []interface{}{"a", nil, "c"}, responses)
+	assert.NoError(t, errs[0])
+	assert.Error(t, errs[1])
+	assert.NoError(t, errs[2])
+}
+
+func TestEmailServiceImpl_SendEmailBatch_UnreachableServer(t *testing.T) {
+	// Nothing listens on port 1, so dialing fails at once
+	service := &EmailServiceImpl{dialer: gomail.NewDialer("127.0.0.1", 1, "user", "password"), batchSize: 10}
+	assert.Equal(t, 10, MaxBatchSize(service))
+
+	invalid := batchNotification("a", "not-an-email")
+	responses, errs := SendBatch(context.Background(), service, []*models.EmailNotificationRequest{
+		invalid,
+		batchNotification("b", "b@example.com"),
+		batchNotification("c", "c@example.com"),
+	})
+
+	assert.Equal(t, []interface{}{nil, nil, nil}, responses)
+	assert.ErrorContains(t, errs[0], "email validation failed")
+	assert.ErrorContains(t, errs[1], "failed to send email")
+	assert.ErrorContains(t, errs[2], "failed to send email")
+}
@@ -0,0 +1,51 @@
+package email
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// defaultDarkModeCSS keeps emails without their own dark variant readable in dark mode
+const defaultDarkModeCSS = `body, table, td { background-color: #121212 !important; color: #e8e8e8 !important; }
+a { color: #8ab4f8 !important; }`
+
+var (
+	headTagPattern = regexp.MustCompile(`(?i)<head(\s[^>]*)?>`)
+	htmlTagPattern = regexp.MustCompile(`(?i)<html(\s[^>]*)?>`)
+)
+
+// applyColorScheme adds the dark-mode hints of scheme to an HTML body: meta tags and a style
+// sheet in its head, which is created when the body has none. Light bodies ask clients not to
+// invert their colors; auto bodies declare dark support and get darkModeCSS, or default styles,
+// in a prefers-color-scheme media query.
+func applyColorScheme(htmlBody, scheme, darkModeCSS string) string {
+	var hints string
+	switch scheme {
+	case models.EmailColorSchemeLight:
+		hints = `<meta name="color-scheme" content="light only">` +
+			`<meta name="supported-color-schemes" content="light">` +
+			`<style>:root { color-scheme: light only; supported-color-schemes: light; }</style>`
+	case models.EmailColorSchemeAuto:
+		if darkModeCSS == "" {
+			darkModeCSS = defaultDarkModeCSS
+		}
+		// Markup could close the style element early; requests with it are rejected on validation
+		darkModeCSS = strings.ReplaceAll(darkModeCSS, "<", "")
+		hints = `<meta name="color-scheme" content="light dark">` +
+			`<meta name="supported-color-schemes" content="light dark">` +
+			`<style>:root { color-scheme: light dark; supported-color-schemes: light dark; }` +
+			"\n@media (prefers-color-scheme: dark) {\n" + darkModeCSS + "\n}</style>"
+	default:
+		return htmlBody
+	}
+
+	if location := headTagPattern.FindStringIndex(htmlBody); location != nil {
+		return htmlBody[:location[1]] + hints + htmlBody[location[1]:]
+	}
+	if location := htmlTagPattern.FindStringIndex(htmlBody); location != nil {
+		return htmlBody[:location[1]] + "<head>" + hints + "</head>" + htmlBody[location[1]:]
+	}
+	return "<!DOCTYPE html><html><head>" + hints + "</head><body>" + htmlBody + "</body></html>"
+}
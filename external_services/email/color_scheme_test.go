@@ -0,0 +1,45 @@
+package email
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyColorScheme(t *testing.T) {
+	// Bodies without a color scheme are left as they are
+	assert.Equal(t, "<p>Hello</p>", applyColorScheme("<p>Hello</p>", "", ""))
+
+	light := applyColorScheme("<p>Hello</p>", models.EmailColorSchemeLight, "")
+	assert.Equal(t, `<!DOCTYPE html><html><head><meta name="color-scheme" content="light only">`+
+		`<meta name="supported-color-schemes" content="light">`+
+		`<style>:root { color-scheme: light only; supported-color-schemes: light; }</style>`+
+		`</head><body><p>Hello</p></body></html>`, light)
+
+	// Hints go into an existing head, or a head created in the html element
+	auto := applyColorScheme(`<HTML><Head lang="en"><title>News</title></Head><body>Hi</body></HTML>`, models.EmailColorSchemeAuto, ".brand { color: #fff; }")
+	assert.Contains(t, auto, `<Head lang="en"><meta name="color-scheme" content="light dark">`)
+	assert.Contains(t, auto, "@media (prefers-color-scheme: dark) {\n.brand { color: #fff; }\n}</style><title>News</title>")
+
+	auto = applyColorScheme("<html><body>Hi</body></html>", models.EmailColorSchemeAuto, "")
+	assert.Contains(t, auto, `<html><head><meta name="color-scheme" content="light dark">`)
+	assert.Contains(t, auto, defaultDarkModeCSS+"\n}</style></head><body>Hi</body></html>")
+
+	// Markup cannot close the style element
+	auto = applyColorScheme("<p>Hi</p>", models.EmailColorSchemeAuto, "</style><script>")
+	assert.NotContains(t, auto, "<script>")
+	assert.Equal(t, 1, strings.Count(auto, "</style>"))
+}
+
+func TestRenderBodies_ColorScheme(t *testing.T) {
+	htmlBody, textBody := renderBodies(models.EmailContent{
+		EmailBody:   "# News",
+		RenderMode:  models.EmailRenderModeMarkdown,
+		ColorScheme: models.EmailColorSchemeAuto,
+	})
+	assert.Contains(t, htmlBody, `<meta name="color-scheme" content="light dark">`)
+	assert.Contains(t, htmlBody, "<body><h1>News</h1></body>")
+	assert.Equal(t, "News", textBody)
+}
@@ -3,18 +3,22 @@ package email
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"time"
 
 	"github.com/gaurav2721/notification-service/constants"
+	"github.com/gaurav2721/notification-service/htmltext"
+	"github.com/gaurav2721/notification-service/markdown"
 	"github.com/gaurav2721/notification-service/models"
 	"gopkg.in/gomail.v2"
 )
 
 // EmailServiceImpl implements the EmailService interface
 type EmailServiceImpl struct {
-	dialer *gomail.Dialer
+	dialer    *gomail.Dialer
+	batchSize int
 }
 
 // NewEmailService creates a new email service instance
@@ -37,8 +41,14 @@ func NewEmailService() EmailService {
 
 	dialer := gomail.NewDialer(host, port, username, password)
 
+	batchSize, _ := strconv.Atoi(os.Getenv(constants.EmailBatchSizeEnvVar))
+	if batchSize <= 0 {
+		batchSize = constants.DefaultEmailBatchSize
+	}
+
 	return &EmailServiceImpl{
-		dialer: dialer,
+		dialer:    dialer,
+		batchSize: batchSize,
 	}
 }
 
@@ -50,6 +60,70 @@ func (es *EmailServiceImpl) SendEmail(ctx context.Context, notification interfac
 		return nil, ErrEmailSendFailed
 	}
 
+	m, err := buildMessage(notif)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send email
+	if err := es.dialer.DialAndSend(m); err != nil {
+		return nil, fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return sentResponse(notif), nil
+}
+
+// MaxBatchSize returns how many emails are sent over one SMTP connection
+func (es *EmailServiceImpl) MaxBatchSize() int {
+	return es.batchSize
+}
+
+// SendEmailBatch sends the emails over one SMTP connection. After a failed email the connection
+// is dialed again, as the server may have left the transaction open.
+func (es *EmailServiceImpl) SendEmailBatch(ctx context.Context, notifications []*models.EmailNotificationRequest) ([]interface{}, []error) {
+	responses := make([]interface{}, len(notifications))
+	errs := make([]error, len(notifications))
+
+	var sender gomail.SendCloser
+	var dialErr error
+	defer func() {
+		if sender != nil {
+			sender.Close()
+		}
+	}()
+
+	for i, notif := range notifications {
+		m, err := buildMessage(notif)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+
+		if sender == nil && dialErr == nil {
+			if sender, dialErr = es.dialer.Dial(); dialErr != nil {
+				sender = nil
+			}
+		}
+		// The server is unreachable, so the rest of the batch is not tried either
+		if dialErr != nil {
+			errs[i] = fmt.Errorf("failed to send email: %w", dialErr)
+			continue
+		}
+
+		if err := gomail.Send(sender, m); err != nil {
+			errs[i] = fmt.Errorf("failed to send email: %w", err)
+			sender.Close()
+			sender = nil
+			continue
+		}
+		responses[i] = sentResponse(notif)
+	}
+
+	return responses, errs
+}
+
+// buildMessage validates an email notification and creates its message
+func buildMessage(notif *models.EmailNotificationRequest) (*gomail.Message, error) {
 	// Validate the email notification
 	if err := models.ValidateEmailNotification(notif); err != nil {
 		return nil, fmt.Errorf("email validation failed: %w", err)
@@ -69,22 +143,73 @@ func (es *EmailServiceImpl) SendEmail(ctx context.Context, notification interfac
 
 	// Extract subject and body from content
 	subject := notif.Content.Subject
-	body := notif.Content.EmailBody
+	htmlBody, textBody := renderBodies(notif.Content)
 
 	m.SetHeader("Subject", subject)
-	m.SetBody("text/html", body)
+	if textBody != "" {
+		// Clients that cannot show HTML fall back to the plain text part
+		m.SetBody("text/plain", textBody)
+		m.AddAlternative("text/html", htmlBody)
+	} else {
+		m.SetBody("text/html", htmlBody)
+	}
 
-	// Send email
-	if err := es.dialer.DialAndSend(m); err != nil {
-		return nil, fmt.Errorf("failed to send email: %w", err)
+	for _, attachment := range notif.Content.Attachments {
+		m.Attach(attachment.Filename, attachmentSettings(attachment)...)
 	}
 
-	// Return success response
+	return m, nil
+}
+
+// sentResponse is the response of a sent email
+func sentResponse(notif *models.EmailNotificationRequest) *models.EmailResponse {
 	return &models.EmailResponse{
 		ID:      notif.ID,
 		Status:  "sent",
 		Message: "Email sent successfully",
 		SentAt:  time.Now(),
 		Channel: "email",
-	}, nil
+	}
+}
+
+// CheckConnection connects and authenticates to the SMTP server without sending an email
+func (es *EmailServiceImpl) CheckConnection(ctx context.Context) error {
+	closer, err := es.dialer.Dial()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSMTPConnectionFailed, err)
+	}
+	return closer.Close()
+}
+
+// renderBodies returns the HTML body of an email and its plain text alternative. Every email gets
+// one: Markdown bodies are converted to both, and other bodies without a text body have it
+// generated from their markup. Both get the reason of the notification in their footer, and the
+// HTML body gets the dark-mode hints of its color scheme.
+func renderBodies(content models.EmailContent) (htmlBody, textBody string) {
+	if content.RenderMode == models.EmailRenderModeMarkdown {
+		htmlBody, textBody = markdown.ToHTML(content.EmailBody), markdown.ToText(content.EmailBody)
+	} else {
+		htmlBody, textBody = content.EmailBody, htmltext.ToText(content.EmailBody)
+	}
+	if content.TextBody != "" {
+		textBody = content.TextBody
+	}
+	htmlBody, textBody = addReasonFooter(htmlBody, textBody, content.Reason)
+	return applyColorScheme(htmlBody, content.ColorScheme, content.DarkModeCSS), textBody
+}
+
+// attachmentSettings writes an attachment from memory with its content type, if given
+func attachmentSettings(attachment models.EmailAttachment) []gomail.FileSetting {
+	settings := []gomail.FileSetting{
+		gomail.SetCopyFunc(func(w io.Writer) error {
+			_, err := w.Write(attachment.Content)
+			return err
+		}),
+	}
+	if attachment.ContentType != "" {
+		settings = append(settings, gomail.SetHeader(map[string][]string{
+			"Content-Type": {attachment.ContentType},
+		}))
+	}
+	return settings
 }
@@ -342,3 +342,40 @@ func TestIsValidEmail(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderBodies(t *testing.T) {
+	// HTML bodies get a plain text alternative generated from their markup
+	htmlBody, textBody := renderBodies(models.EmailContent{EmailBody: "<p>Hello</p>"})
+	assert.Equal(t, "<p>Hello</p>", htmlBody)
+	assert.Equal(t, "Hello", textBody)
+
+	htmlBody, textBody = renderBodies(models.EmailContent{EmailBody: "<p>Hello <b>Jane</b></p>", TextBody: "Hello Jane!"})
+	assert.Equal(t, "<p>Hello <b>Jane</b></p>", htmlBody)
+	assert.Equal(t, "Hello Jane!", textBody)
+
+	htmlBody, textBody = renderBodies(models.EmailContent{
+		EmailBody:  "Hello **Jane**\n\n- [Track order](https://example.com/orders/1)",
+		RenderMode: models.EmailRenderModeMarkdown,
+	})
+	assert.Equal(t, "<p>Hello <strong>Jane</strong></p>\n<ul>\n<li><a href=\"https://example.com/orders/1\">Track order</a></li>\n</ul>", htmlBody)
+	assert.Equal(t, "Hello Jane\n\n- Track order (https://example.com/orders/1)", textBody)
+}
+
+func TestRenderBodies_ReasonFooter(t *testing.T) {
+	reason := "You received this because you subscribed to <order> updates"
+	htmlBody, textBody := renderBodies(models.EmailContent{EmailBody: "<p>Hello</p>", Reason: reason})
+	assert.Equal(t, `<p>Hello</p><p class="notification-reason" style="margin-top:24px;font-size:12px;color:#6b7280">`+
+		`You received this because you subscribed to &lt;order&gt; updates</p>`, htmlBody)
+	assert.Equal(t, "Hello\n\n"+reason, textBody)
+
+	// Documents keep the footer inside their body, also with dark-mode hints
+	htmlBody, textBody = renderBodies(models.EmailContent{
+		EmailBody:   "<html><body><p>Hello</p></BODY></html>",
+		TextBody:    "Hello!",
+		ColorScheme: models.EmailColorSchemeLight,
+		Reason:      reason,
+	})
+	assert.Contains(t, htmlBody, `<body><p>Hello</p><p class="notification-reason"`)
+	assert.Contains(t, htmlBody, "updates</p></BODY></html>")
+	assert.Equal(t, "Hello!\n\n"+reason, textBody)
+}
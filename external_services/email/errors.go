@@ -6,4 +6,5 @@ import "errors"
 var (
 	ErrEmailSendFailed       = errors.New("failed to send email")
 	ErrEmailTemplateNotFound = errors.New("email template not found")
+	ErrSMTPConnectionFailed  = errors.New("failed to connect to the SMTP server")
 )
@@ -61,6 +61,10 @@ func (es *MockEmailServiceImpl) SendEmail(ctx context.Context, notification inte
 		"status":    "mock_sent",
 		"channel":   "email",
 	}
+	if htmlBody, textBody := renderBodies(notif.Content); textBody != "" {
+		notificationData["html_body"] = htmlBody
+		notificationData["text_body"] = textBody
+	}
 
 	// Convert to JSON
 	jsonData, err := json.MarshalIndent(notificationData, "", "  ")
@@ -0,0 +1,32 @@
+package email
+
+import (
+	"html"
+	"regexp"
+)
+
+// bodyCloseTagPattern matches the closing body tag of an HTML document
+var bodyCloseTagPattern = regexp.MustCompile(`(?i)</body\s*>`)
+
+// addReasonFooter adds the reason of a notification to the footer of both bodies: a muted
+// paragraph at the end of the HTML body, inside its body element if it has one, and a last
+// paragraph of the plain text body
+func addReasonFooter(htmlBody, textBody, reason string) (string, string) {
+	if reason == "" {
+		return htmlBody, textBody
+	}
+
+	footer := `<p class="notification-reason" style="margin-top:24px;font-size:12px;color:#6b7280">` +
+		html.EscapeString(reason) + `</p>`
+	if locations := bodyCloseTagPattern.FindAllStringIndex(htmlBody, -1); len(locations) > 0 {
+		at := locations[len(locations)-1][0]
+		htmlBody = htmlBody[:at] + footer + htmlBody[at:]
+	} else {
+		htmlBody += footer
+	}
+
+	if textBody != "" {
+		textBody += "\n\n"
+	}
+	return htmlBody, textBody + reason
+}
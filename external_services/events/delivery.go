@@ -0,0 +1,92 @@
+package events
+
+import (
+	"time"
+
+	"github.com/gaurav2721/notification-service/external_services/delivery"
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// publishingDeliveryService wraps a DeliveryService and publishes a delivery event for every
+// recorded attempt and receipt
+type publishingDeliveryService struct {
+	delivery.DeliveryService
+	publisher *Publisher
+}
+
+// NewPublishingDeliveryService wraps the given delivery service so that recorded attempts and
+// receipts are published as delivery.status_changed events
+func NewPublishingDeliveryService(inner delivery.DeliveryService, publisher *Publisher) delivery.DeliveryService {
+	return &publishingDeliveryService{DeliveryService: inner, publisher: publisher}
+}
+
+// RecordAttempt archives the attempt and publishes its outcome
+func (s *publishingDeliveryService) RecordAttempt(attempt *models.DeliveryAttempt) error {
+	if err := s.DeliveryService.RecordAttempt(attempt); err != nil {
+		return err
+	}
+
+	// The archive numbered the attempt and redacted its error
+	s.publisher.Publish(models.NotificationEvent{
+		EventType:      models.EventDeliveryStatusChanged,
+		OccurredAt:     attempt.AttemptedAt,
+		NotificationID: attempt.NotificationID,
+		Status:         attempt.Status,
+		UserID:         attempt.UserID,
+		Recipient:      attempt.Recipient,
+		Channel:        attempt.Channel,
+		Attempt:        attempt.Attempt,
+		Error:          attempt.Error,
+	})
+	return nil
+}
+
+// RecordReceipt marks the push as delivered and publishes it, unless the push was already
+// reported as delivered before
+func (s *publishingDeliveryService) RecordReceipt(receipt *models.DeliveryReceipt) error {
+	latest := s.latestAttempt(receipt)
+	if err := s.DeliveryService.RecordReceipt(receipt); err != nil {
+		return err
+	}
+	if latest != nil && latest.Status == models.DeliveryStatusDelivered {
+		return nil
+	}
+
+	var deliveredAt time.Time
+	if receipt.DeliveredAt != nil {
+		deliveredAt = *receipt.DeliveredAt
+	}
+	event := models.NotificationEvent{
+		EventType:      models.EventDeliveryStatusChanged,
+		OccurredAt:     deliveredAt,
+		NotificationID: receipt.NotificationID,
+		FromStatus:     models.DeliveryStatusSent,
+		Status:         models.DeliveryStatusDelivered,
+		Recipient:      receipt.Recipient,
+		Channel:        receipt.Channel,
+	}
+	if latest != nil {
+		event.UserID = latest.UserID
+		event.Attempt = latest.Attempt
+	}
+	s.publisher.Publish(event)
+	return nil
+}
+
+// latestAttempt returns the latest archived attempt the receipt refers to, or nil
+func (s *publishingDeliveryService) latestAttempt(receipt *models.DeliveryReceipt) *models.DeliveryAttempt {
+	if receipt == nil {
+		return nil
+	}
+
+	attempts, err := s.DeliveryService.GetAttempts(receipt.NotificationID, receipt.Recipient)
+	if err != nil {
+		return nil
+	}
+	for i := len(attempts) - 1; i >= 0; i-- {
+		if attempts[i].Channel == receipt.Channel {
+			return attempts[i]
+		}
+	}
+	return nil
+}
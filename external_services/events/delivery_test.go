@@ -0,0 +1,46 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/external_services/delivery"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishingDeliveryService(t *testing.T) {
+	channel := make(chan string, 10)
+	service := NewPublishingDeliveryService(delivery.NewDeliveryService(), NewPublisher(channel))
+	attemptedAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	for _, status := range []string{models.DeliveryStatusFailed, models.DeliveryStatusSent} {
+		require.NoError(t, service.RecordAttempt(&models.DeliveryAttempt{
+			NotificationID: "n-1",
+			UserID:         "user-1",
+			Recipient:      "device-1",
+			Channel:        "ios_push",
+			Status:         status,
+			AttemptedAt:    attemptedAt,
+		}))
+	}
+	receipt := &models.DeliveryReceipt{NotificationID: "n-1", Channel: "ios_push", Recipient: "device-1"}
+	require.NoError(t, service.RecordReceipt(receipt))
+	require.NoError(t, service.RecordReceipt(receipt), "repeated receipts are accepted")
+	assert.Error(t, service.RecordReceipt(&models.DeliveryReceipt{NotificationID: "n-1", Channel: "ios_push", Recipient: "device-2"}))
+
+	events := readEvents(t, channel)
+	require.Len(t, events, 3, "repeated and rejected receipts are not published")
+	for i, status := range []string{"failed", "sent", "delivered"} {
+		assert.Equal(t, models.EventDeliveryStatusChanged, events[i].EventType)
+		assert.Equal(t, status, events[i].Status)
+		assert.Equal(t, "user-1", events[i].UserID)
+		assert.Equal(t, "device-1", events[i].Recipient)
+	}
+	assert.Equal(t, 1, events[0].Attempt)
+	assert.Equal(t, 2, events[1].Attempt)
+	assert.Equal(t, attemptedAt, events[1].OccurredAt)
+	assert.Equal(t, "sent", events[2].FromStatus)
+	assert.Equal(t, 2, events[2].Attempt)
+}
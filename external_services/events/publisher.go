@@ -0,0 +1,83 @@
+// Package events publishes notification lifecycle events to the notification-events topic, so
+// analytics pipelines and data warehouses can consume delivery data without calling the API.
+package events
+
+import (
+	"encoding/json"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/google/uuid"
+)
+
+// moduleLog carries dropped events
+var moduleLog = logger.Module("events")
+
+var (
+	eventsPublishedTotal = metrics.DefaultRegistry.NewCounterVec(
+		"notification_events_published_total",
+		"Notification lifecycle events handed to the notification-events topic by event type.",
+		"event_type",
+	)
+	eventsDroppedTotal = metrics.DefaultRegistry.NewCounterVec(
+		"notification_events_dropped_total",
+		"Notification lifecycle events dropped because the notification-events topic was full, by event type.",
+		"event_type",
+	)
+)
+
+// Publisher hands notification lifecycle events to the channel of the notification-events topic.
+// Publishing never blocks: events are dropped when the channel is full, so a slow broker does
+// not hold up sending notifications. A nil Publisher drops all events.
+type Publisher struct {
+	channel chan string
+	clock   clock.Clock
+}
+
+// NewPublisher creates a publisher writing to the channel of the notification-events topic
+func NewPublisher(channel chan string) *Publisher {
+	return &Publisher{channel: channel, clock: clock.Real()}
+}
+
+// SetClock replaces the clock events are timestamped with
+func (p *Publisher) SetClock(c clock.Clock) {
+	p.clock = c
+}
+
+// Publish stamps the event with the schema version, an event ID and, unless set, the time it
+// occurred, and hands it to the notification-events topic
+func (p *Publisher) Publish(event models.NotificationEvent) {
+	if p == nil {
+		return
+	}
+
+	event.SchemaVersion = models.NotificationEventSchemaVersion
+	event.EventID = uuid.New().String()
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = p.clock.Now()
+	}
+	event.OccurredAt = event.OccurredAt.UTC()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		moduleLog.Error("Failed to encode notification event", logger.Fields{
+			"event_type":      event.EventType,
+			"notification_id": event.NotificationID,
+			"error":           err.Error(),
+		})
+		return
+	}
+
+	select {
+	case p.channel <- string(payload):
+		eventsPublishedTotal.Inc(event.EventType)
+	default:
+		eventsDroppedTotal.Inc(event.EventType)
+		moduleLog.Warn("Dropping notification event, the notification-events topic is full", logger.Fields{
+			"event_type":      event.EventType,
+			"notification_id": event.NotificationID,
+		})
+	}
+}
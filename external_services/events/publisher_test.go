@@ -0,0 +1,68 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readEvents decodes the events buffered on the channel
+func readEvents(t *testing.T, channel chan string) []models.NotificationEvent {
+	var events []models.NotificationEvent
+	for len(channel) > 0 {
+		var event models.NotificationEvent
+		require.NoError(t, json.Unmarshal([]byte(<-channel), &event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestPublisher_StampsEvents(t *testing.T) {
+	channel := make(chan string, 2)
+	publisher := NewPublisher(channel)
+	berlin := time.FixedZone("CET", 60*60)
+	publisher.SetClock(clock.NewFake(time.Date(2024, 1, 1, 10, 0, 0, 0, berlin)))
+
+	publisher.Publish(models.NotificationEvent{
+		EventType:      models.EventNotificationStatusChanged,
+		NotificationID: "n-1",
+		Status:         "pending",
+	})
+	publisher.Publish(models.NotificationEvent{
+		EventType:      models.EventNotificationStatusChanged,
+		NotificationID: "n-1",
+		OccurredAt:     time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC),
+		FromStatus:     "pending",
+		Status:         "sent",
+	})
+
+	events := readEvents(t, channel)
+	require.Len(t, events, 2)
+	assert.Equal(t, models.NotificationEventSchemaVersion, events[0].SchemaVersion)
+	assert.NotEmpty(t, events[0].EventID)
+	assert.NotEqual(t, events[0].EventID, events[1].EventID)
+	assert.Equal(t, time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), events[0].OccurredAt, "timestamps are in UTC")
+	assert.Equal(t, time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC), events[1].OccurredAt)
+	assert.Equal(t, "sent", events[1].Status)
+}
+
+func TestPublisher_DropsEventsWhenFull(t *testing.T) {
+	channel := make(chan string, 1)
+	publisher := NewPublisher(channel)
+	dropped := eventsDroppedTotal.Value(models.EventDeliveryStatusChanged)
+
+	for i := 0; i < 3; i++ {
+		publisher.Publish(models.NotificationEvent{EventType: models.EventDeliveryStatusChanged, NotificationID: "n-1", Status: "sent"})
+	}
+
+	assert.Len(t, channel, 1)
+	assert.Equal(t, dropped+2, eventsDroppedTotal.Value(models.EventDeliveryStatusChanged))
+
+	var nilPublisher *Publisher
+	assert.NotPanics(t, func() { nilPublisher.Publish(models.NotificationEvent{}) })
+}
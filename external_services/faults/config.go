@@ -0,0 +1,77 @@
+package faults
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gaurav2721/notification-service/constants"
+)
+
+// Provider names that can be targeted by fault injection
+const (
+	ProviderEmail      = "email"
+	ProviderSlack      = "slack"
+	ProviderGoogleChat = "google_chat"
+	ProviderIncident   = "incident"
+	ProviderRCS        = "rcs"
+	ProviderAPNS       = "apns"
+	ProviderFCM        = "fcm"
+)
+
+// FaultConfig holds configuration for the fault injection layer
+type FaultConfig struct {
+	Enabled       bool
+	FailureRate   float64       // percentage of sends to fail, 0-100
+	Latency       time.Duration // latency added to every send
+	LatencyJitter time.Duration // random extra latency added on top of Latency
+	Providers     []string      // providers to inject faults into, empty means all
+}
+
+// LoadFaultConfigFromEnv reads the fault injection configuration from environment variables.
+// Fault injection is disabled unless FAULT_INJECTION_ENABLED is set to true.
+func LoadFaultConfigFromEnv() *FaultConfig {
+	config := &FaultConfig{}
+
+	enabled, err := strconv.ParseBool(os.Getenv(constants.FAULT_INJECTION_ENABLED))
+	if err != nil || !enabled {
+		return config
+	}
+	config.Enabled = true
+
+	if rate, err := strconv.ParseFloat(os.Getenv(constants.FAULT_INJECTION_FAILURE_RATE), 64); err == nil {
+		config.FailureRate = rate
+	}
+	if latency, err := strconv.Atoi(os.Getenv(constants.FAULT_INJECTION_LATENCY_MS)); err == nil && latency > 0 {
+		config.Latency = time.Duration(latency) * time.Millisecond
+	}
+	if jitter, err := strconv.Atoi(os.Getenv(constants.FAULT_INJECTION_JITTER_MS)); err == nil && jitter > 0 {
+		config.LatencyJitter = time.Duration(jitter) * time.Millisecond
+	}
+	if providers := os.Getenv(constants.FAULT_INJECTION_PROVIDERS); providers != "" {
+		for _, provider := range strings.Split(providers, ",") {
+			if provider = strings.TrimSpace(strings.ToLower(provider)); provider != "" {
+				config.Providers = append(config.Providers, provider)
+			}
+		}
+	}
+
+	return config
+}
+
+// AppliesTo checks whether faults should be injected into the given provider
+func (c *FaultConfig) AppliesTo(provider string) bool {
+	if !c.Enabled {
+		return false
+	}
+	if len(c.Providers) == 0 {
+		return true
+	}
+	for _, p := range c.Providers {
+		if p == provider {
+			return true
+		}
+	}
+	return false
+}
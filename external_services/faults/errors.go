@@ -0,0 +1,9 @@
+package faults
+
+import "errors"
+
+// Fault injection errors
+var (
+	ErrInjectedFailure    = errors.New("injected provider failure")
+	ErrInvalidFailureRate = errors.New("failure rate must be between 0 and 100")
+)
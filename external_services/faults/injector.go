@@ -0,0 +1,74 @@
+package faults
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Injector decides whether a provider call should be delayed or failed
+type Injector struct {
+	config *FaultConfig
+	rng    *rand.Rand
+	mu     sync.Mutex
+}
+
+// NewInjector creates a new fault injector
+func NewInjector(config *FaultConfig) (*Injector, error) {
+	if config.FailureRate < 0 || config.FailureRate > 100 {
+		return nil, ErrInvalidFailureRate
+	}
+
+	return &Injector{
+		config: config,
+		rng:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+// Inject adds the configured latency and returns ErrInjectedFailure for the configured share of calls
+func (i *Injector) Inject(ctx context.Context, provider string) error {
+	if !i.config.AppliesTo(provider) {
+		return nil
+	}
+
+	if delay := i.delay(); delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if i.shouldFail() {
+		logrus.WithField("provider", provider).Debug("Injecting provider failure")
+		return fmt.Errorf("%s: %w", provider, ErrInjectedFailure)
+	}
+
+	return nil
+}
+
+// delay returns the latency to add to the current call
+func (i *Injector) delay() time.Duration {
+	delay := i.config.Latency
+	if i.config.LatencyJitter > 0 {
+		i.mu.Lock()
+		delay += time.Duration(i.rng.Int63n(int64(i.config.LatencyJitter)))
+		i.mu.Unlock()
+	}
+	return delay
+}
+
+// shouldFail decides whether the current call should fail
+func (i *Injector) shouldFail() bool {
+	if i.config.FailureRate <= 0 {
+		return false
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.rng.Float64()*100 < i.config.FailureRate
+}
@@ -0,0 +1,102 @@
+package faults
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/external_services/email"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewInjector_InvalidFailureRate(t *testing.T) {
+	_, err := NewInjector(&FaultConfig{Enabled: true, FailureRate: 150})
+	assert.ErrorIs(t, err, ErrInvalidFailureRate)
+
+	_, err = NewInjector(&FaultConfig{Enabled: true, FailureRate: -1})
+	assert.ErrorIs(t, err, ErrInvalidFailureRate)
+}
+
+func TestInjector_Inject(t *testing.T) {
+	t.Run("always fails at 100 percent", func(t *testing.T) {
+		injector, err := NewInjector(&FaultConfig{Enabled: true, FailureRate: 100})
+		require.NoError(t, err)
+
+		for i := 0; i < 10; i++ {
+			assert.ErrorIs(t, injector.Inject(context.Background(), ProviderEmail), ErrInjectedFailure)
+		}
+	})
+
+	t.Run("never fails at 0 percent", func(t *testing.T) {
+		injector, err := NewInjector(&FaultConfig{Enabled: true, FailureRate: 0})
+		require.NoError(t, err)
+
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, injector.Inject(context.Background(), ProviderEmail))
+		}
+	})
+
+	t.Run("skips providers that are not targeted", func(t *testing.T) {
+		injector, err := NewInjector(&FaultConfig{Enabled: true, FailureRate: 100, Providers: []string{ProviderSlack}})
+		require.NoError(t, err)
+
+		assert.NoError(t, injector.Inject(context.Background(), ProviderEmail))
+		assert.ErrorIs(t, injector.Inject(context.Background(), ProviderSlack), ErrInjectedFailure)
+	})
+
+	t.Run("adds latency", func(t *testing.T) {
+		injector, err := NewInjector(&FaultConfig{Enabled: true, Latency: 20 * time.Millisecond})
+		require.NoError(t, err)
+
+		start := time.Now()
+		assert.NoError(t, injector.Inject(context.Background(), ProviderAPNS))
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	})
+
+	t.Run("respects context cancellation", func(t *testing.T) {
+		injector, err := NewInjector(&FaultConfig{Enabled: true, Latency: time.Second})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.ErrorIs(t, injector.Inject(ctx, ProviderFCM), context.Canceled)
+	})
+}
+
+func TestFaultyEmailService_SendEmail(t *testing.T) {
+	injector, err := NewInjector(&FaultConfig{Enabled: true, FailureRate: 100})
+	require.NoError(t, err)
+
+	service := NewFaultyEmailService(email.NewMockEmailService(), injector)
+	_, err = service.SendEmail(context.Background(), &models.EmailNotificationRequest{
+		ID:        "test-123",
+		Type:      "email",
+		Content:   models.EmailContent{Subject: "Subject", EmailBody: "Body"},
+		Recipient: "test@example.com",
+	})
+	assert.ErrorIs(t, err, ErrInjectedFailure)
+}
+
+func TestLoadFaultConfigFromEnv(t *testing.T) {
+	os.Setenv("FAULT_INJECTION_ENABLED", "true")
+	os.Setenv("FAULT_INJECTION_FAILURE_RATE", "12.5")
+	os.Setenv("FAULT_INJECTION_LATENCY_MS", "250")
+	os.Setenv("FAULT_INJECTION_PROVIDERS", "email, APNS")
+	defer func() {
+		os.Unsetenv("FAULT_INJECTION_ENABLED")
+		os.Unsetenv("FAULT_INJECTION_FAILURE_RATE")
+		os.Unsetenv("FAULT_INJECTION_LATENCY_MS")
+		os.Unsetenv("FAULT_INJECTION_PROVIDERS")
+	}()
+
+	config := LoadFaultConfigFromEnv()
+	assert.True(t, config.Enabled)
+	assert.Equal(t, 12.5, config.FailureRate)
+	assert.Equal(t, 250*time.Millisecond, config.Latency)
+	assert.True(t, config.AppliesTo(ProviderEmail))
+	assert.True(t, config.AppliesTo(ProviderAPNS))
+	assert.False(t, config.AppliesTo(ProviderSlack))
+}
@@ -0,0 +1,183 @@
+package faults
+
+import (
+	"context"
+
+	"github.com/gaurav2721/notification-service/external_services/apns"
+	"github.com/gaurav2721/notification-service/external_services/email"
+	"github.com/gaurav2721/notification-service/external_services/fcm"
+	"github.com/gaurav2721/notification-service/external_services/googlechat"
+	"github.com/gaurav2721/notification-service/external_services/incident"
+	"github.com/gaurav2721/notification-service/external_services/rcs"
+	"github.com/gaurav2721/notification-service/external_services/slack"
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// faultyEmailService wraps an EmailService with fault injection
+type faultyEmailService struct {
+	inner    email.EmailService
+	injector *Injector
+}
+
+// NewFaultyEmailService wraps the given email service with fault injection
+func NewFaultyEmailService(inner email.EmailService, injector *Injector) email.EmailService {
+	return &faultyEmailService{inner: inner, injector: injector}
+}
+
+// SendEmail injects faults before delegating to the wrapped email service
+func (s *faultyEmailService) SendEmail(ctx context.Context, notification interface{}) (interface{}, error) {
+	if err := s.injector.Inject(ctx, ProviderEmail); err != nil {
+		return nil, err
+	}
+	return s.inner.SendEmail(ctx, notification)
+}
+
+// faultySlackService wraps a SlackService with fault injection
+type faultySlackService struct {
+	inner    slack.SlackService
+	injector *Injector
+}
+
+// NewFaultySlackService wraps the given slack service with fault injection
+func NewFaultySlackService(inner slack.SlackService, injector *Injector) slack.SlackService {
+	return &faultySlackService{inner: inner, injector: injector}
+}
+
+// SendSlackMessage injects faults before delegating to the wrapped slack service
+func (s *faultySlackService) SendSlackMessage(ctx context.Context, notification interface{}) (interface{}, error) {
+	if err := s.injector.Inject(ctx, ProviderSlack); err != nil {
+		return nil, err
+	}
+	return s.inner.SendSlackMessage(ctx, notification)
+}
+
+// faultyGoogleChatService wraps a GoogleChatService with fault injection
+type faultyGoogleChatService struct {
+	inner    googlechat.GoogleChatService
+	injector *Injector
+}
+
+// NewFaultyGoogleChatService wraps the given Google Chat service with fault injection
+func NewFaultyGoogleChatService(inner googlechat.GoogleChatService, injector *Injector) googlechat.GoogleChatService {
+	return &faultyGoogleChatService{inner: inner, injector: injector}
+}
+
+// SendGoogleChatMessage injects faults before delegating to the wrapped Google Chat service
+func (s *faultyGoogleChatService) SendGoogleChatMessage(ctx context.Context, notification interface{}) (interface{}, error) {
+	if err := s.injector.Inject(ctx, ProviderGoogleChat); err != nil {
+		return nil, err
+	}
+	return s.inner.SendGoogleChatMessage(ctx, notification)
+}
+
+// faultyIncidentService wraps an IncidentService with fault injection
+type faultyIncidentService struct {
+	inner    incident.IncidentService
+	injector *Injector
+}
+
+// NewFaultyIncidentService wraps the given incident service with fault injection
+func NewFaultyIncidentService(inner incident.IncidentService, injector *Injector) incident.IncidentService {
+	return &faultyIncidentService{inner: inner, injector: injector}
+}
+
+// SendIncidentEvent injects faults before delegating to the wrapped incident service
+func (s *faultyIncidentService) SendIncidentEvent(ctx context.Context, notification interface{}) (interface{}, error) {
+	if err := s.injector.Inject(ctx, ProviderIncident); err != nil {
+		return nil, err
+	}
+	return s.inner.SendIncidentEvent(ctx, notification)
+}
+
+// faultyRCSService wraps an RCSService with fault injection
+type faultyRCSService struct {
+	inner    rcs.RCSService
+	injector *Injector
+}
+
+// NewFaultyRCSService wraps the given RCS service with fault injection
+func NewFaultyRCSService(inner rcs.RCSService, injector *Injector) rcs.RCSService {
+	return &faultyRCSService{inner: inner, injector: injector}
+}
+
+// SendRCSMessage injects faults before delegating to the wrapped RCS service
+func (s *faultyRCSService) SendRCSMessage(ctx context.Context, notification interface{}) (interface{}, error) {
+	if err := s.injector.Inject(ctx, ProviderRCS); err != nil {
+		return nil, err
+	}
+	return s.inner.SendRCSMessage(ctx, notification)
+}
+
+// faultyAPNSService wraps an APNSService with fault injection
+type faultyAPNSService struct {
+	inner    apns.APNSService
+	injector *Injector
+}
+
+// NewFaultyAPNSService wraps the given APNS service with fault injection
+func NewFaultyAPNSService(inner apns.APNSService, injector *Injector) apns.APNSService {
+	return &faultyAPNSService{inner: inner, injector: injector}
+}
+
+// SendPushNotification injects faults before delegating to the wrapped APNS service
+func (s *faultyAPNSService) SendPushNotification(ctx context.Context, notification interface{}) (interface{}, error) {
+	if err := s.injector.Inject(ctx, ProviderAPNS); err != nil {
+		return nil, err
+	}
+	return s.inner.SendPushNotification(ctx, notification)
+}
+
+// faultyFCMService wraps an FCMService with fault injection
+type faultyFCMService struct {
+	inner    fcm.FCMService
+	injector *Injector
+}
+
+// NewFaultyFCMService wraps the given FCM service with fault injection
+func NewFaultyFCMService(inner fcm.FCMService, injector *Injector) fcm.FCMService {
+	return &faultyFCMService{inner: inner, injector: injector}
+}
+
+// SendPushNotification injects faults before delegating to the wrapped FCM service
+func (s *faultyFCMService) SendPushNotification(ctx context.Context, notification interface{}) (interface{}, error) {
+	if err := s.injector.Inject(ctx, ProviderFCM); err != nil {
+		return nil, err
+	}
+	return s.inner.SendPushNotification(ctx, notification)
+}
+
+// SubscribeToTopic injects faults before delegating to the wrapped FCM service
+func (s *faultyFCMService) SubscribeToTopic(ctx context.Context, appID, topic string, tokens []string) (*fcm.TopicManagementResult, error) {
+	topics, err := fcm.AsTopicService(s.inner)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.injector.Inject(ctx, ProviderFCM); err != nil {
+		return nil, err
+	}
+	return topics.SubscribeToTopic(ctx, appID, topic, tokens)
+}
+
+// UnsubscribeFromTopic injects faults before delegating to the wrapped FCM service
+func (s *faultyFCMService) UnsubscribeFromTopic(ctx context.Context, appID, topic string, tokens []string) (*fcm.TopicManagementResult, error) {
+	topics, err := fcm.AsTopicService(s.inner)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.injector.Inject(ctx, ProviderFCM); err != nil {
+		return nil, err
+	}
+	return topics.UnsubscribeFromTopic(ctx, appID, topic, tokens)
+}
+
+// SendToTopic injects faults before delegating to the wrapped FCM service
+func (s *faultyFCMService) SendToTopic(ctx context.Context, topic string, notification *models.FCMNotificationRequest) (interface{}, error) {
+	topics, err := fcm.AsTopicService(s.inner)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.injector.Inject(ctx, ProviderFCM); err != nil {
+		return nil, err
+	}
+	return topics.SendToTopic(ctx, topic, notification)
+}
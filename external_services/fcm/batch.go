@@ -0,0 +1,47 @@
+package fcm
+
+import (
+	"context"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// MaxMulticastTokens is the largest number of device tokens FCM accepts in one request
+const MaxMulticastTokens = 500
+
+// BatchFCMService is implemented by FCM services that can send a push to several device tokens
+// in one multicast request
+type BatchFCMService interface {
+	FCMService
+
+	// MaxBatchSize is the largest number of pushes sent in one multicast request
+	MaxBatchSize() int
+
+	// SendPushNotificationBatch sends the pushes and returns a response and an error for each of
+	// them, in the order of the notifications
+	SendPushNotificationBatch(ctx context.Context, notifications []*models.FCMNotificationRequest) ([]interface{}, []error)
+}
+
+// MaxBatchSize returns the largest batch the FCM service sends in one request, which is 1 for
+// services that cannot batch
+func MaxBatchSize(service FCMService) int {
+	if batcher, ok := service.(BatchFCMService); ok && batcher.MaxBatchSize() > 1 {
+		return batcher.MaxBatchSize()
+	}
+	return 1
+}
+
+// SendBatch sends the pushes in multicast requests if the FCM service supports them, or one by
+// one otherwise, and returns a response and an error for each of them
+func SendBatch(ctx context.Context, service FCMService, notifications []*models.FCMNotificationRequest) ([]interface{}, []error) {
+	if batcher, ok := service.(BatchFCMService); ok {
+		return batcher.SendPushNotificationBatch(ctx, notifications)
+	}
+
+	responses := make([]interface{}, len(notifications))
+	errs := make([]error, len(notifications))
+	for i, notification := range notifications {
+		responses[i], errs[i] = service.SendPushNotification(ctx, notification)
+	}
+	return responses, errs
+}
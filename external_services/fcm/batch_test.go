@@ -0,0 +1,114 @@
+package fcm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/external_services/pushapps"
+	"github.com/gaurav2721/notification-service/models"
+)
+
+func TestSendPushNotificationBatch(t *testing.T) {
+	var mu sync.Mutex
+	var requests []FCMRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request FCMRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		mu.Lock()
+		requests = append(requests, request)
+		mu.Unlock()
+
+		results := make([]Result, len(request.RegistrationIDs))
+		for i, token := range request.RegistrationIDs {
+			if token == "stale" {
+				results[i].Error = "NotRegistered"
+			}
+		}
+		json.NewEncoder(w).Encode(FCMResponse{Results: results})
+	}))
+	defer server.Close()
+
+	service := &FCMServiceImpl{
+		config:   &FCMConfig{ServerKey: "key", Timeout: 30, BatchSize: 2},
+		endpoint: server.URL,
+		client:   http.DefaultClient,
+	}
+	if size := MaxBatchSize(service); size != 2 {
+		t.Fatalf("Expected a batch size of 2, got %d", size)
+	}
+
+	push := func(id, token string) *models.FCMNotificationRequest {
+		return &models.FCMNotificationRequest{
+			ID:        id,
+			Type:      "android_push",
+			Content:   models.FCMContent{Title: "Title", Body: "Body"},
+			Recipient: token,
+		}
+	}
+	notifications := []*models.FCMNotificationRequest{
+		push("n1", "a"),
+		push("n1", "stale"),
+		push("n2", "c"),
+		push("n1", "d"),
+		push("n1", ""),
+	}
+
+	responses, errs := SendBatch(context.Background(), service, notifications)
+
+	// n1 is split into requests of two tokens, n2 has different data and a request of its own
+	if len(requests) != 3 {
+		t.Fatalf("Expected 3 requests, got %d", len(requests))
+	}
+	if got := strings.Join(requests[0].RegistrationIDs, ","); got != "a,stale" {
+		t.Errorf("Expected the first request to hold a,stale, got %s", got)
+	}
+	if errs[4] == nil {
+		t.Error("Expected a validation error for the push without a token")
+	}
+	for i := 0; i < 4; i++ {
+		if errs[i] != nil {
+			t.Errorf("Expected no error for push %d, got %v", i, errs[i])
+		}
+	}
+	if response := responses[1].(*models.FCMResponse); response.FailureCount != 1 || !strings.Contains(response.Message, "NotRegistered") {
+		t.Errorf("Expected the stale token to fail with NotRegistered, got %+v", response)
+	}
+	if response := responses[3].(*models.FCMResponse); response.SuccessCount != 1 || response.ID != "n1" {
+		t.Errorf("Expected push 3 to succeed, got %+v", response)
+	}
+}
+
+func TestSendPushNotificationBatch_UnknownApp(t *testing.T) {
+	service := &FCMServiceImpl{
+		config: &FCMConfig{ServerKey: "key", Timeout: 30, BatchSize: 100},
+		client: http.DefaultClient,
+	}
+	notification := &models.FCMNotificationRequest{
+		ID:        "n1",
+		Type:      "android_push",
+		Content:   models.FCMContent{Title: "Title", Body: "Body"},
+		Recipient: "token",
+		AppID:     "unknown",
+	}
+
+	_, errs := service.SendPushNotificationBatch(context.Background(), []*models.FCMNotificationRequest{notification})
+	if !errors.Is(errs[0], pushapps.ErrUnknownApp) {
+		t.Errorf("Expected ErrUnknownApp, got %v", errs[0])
+	}
+}
+
+func TestMaxBatchSize_CappedAtMulticastLimit(t *testing.T) {
+	service := &FCMServiceImpl{config: &FCMConfig{ServerKey: "key", BatchSize: 1000}}
+	if size := MaxBatchSize(service); size != MaxMulticastTokens {
+		t.Errorf("Expected a batch size of %d, got %d", MaxMulticastTokens, size)
+	}
+	if size := MaxBatchSize(NewMockFCMService()); size != 1 {
+		t.Errorf("Expected the mock service not to batch, got %d", size)
+	}
+}
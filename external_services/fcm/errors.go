@@ -14,4 +14,10 @@ var (
 
 	// ErrInvalidServerKey indicates that FCM server key is invalid
 	ErrInvalidServerKey = errors.New("invalid FCM server key")
+
+	// ErrInvalidTopic indicates that a topic name is not accepted by FCM
+	ErrInvalidTopic = errors.New("invalid FCM topic")
+
+	// ErrTopicsNotSupported indicates that the FCM service cannot manage or send to topics
+	ErrTopicsNotSupported = errors.New("FCM topics are not supported by the configured FCM service")
 )
@@ -12,13 +12,26 @@ import (
 	"time"
 
 	"github.com/gaurav2721/notification-service/constants"
+	"github.com/gaurav2721/notification-service/external_services/httpclient"
+	"github.com/gaurav2721/notification-service/external_services/pushapps"
 	"github.com/gaurav2721/notification-service/models"
+	"github.com/sirupsen/logrus"
 )
 
+// fcmEndpoint is the FCM legacy HTTP API
+const fcmEndpoint = "https://fcm.googleapis.com/fcm/send"
+
+// iidEndpoint is the Instance ID API managing topic subscriptions
+const iidEndpoint = "https://iid.googleapis.com/iid/v1"
+
 // FCMServiceImpl implements the FCMService interface
 type FCMServiceImpl struct {
 	config *FCMConfig
-	client *http.Client
+	// appServerKeys holds the server keys of the apps in the push app registry
+	appServerKeys map[string]string
+	endpoint      string
+	iidEndpoint   string
+	client        *http.Client
 }
 
 // FCMRequest represents the FCM API request structure
@@ -27,8 +40,10 @@ type FCMRequest struct {
 	RegistrationIDs []string               `json:"registration_ids,omitempty"`
 	Data            map[string]interface{} `json:"data,omitempty"`
 	Notification    *FCMNotification       `json:"notification,omitempty"`
+	CollapseKey     string                 `json:"collapse_key,omitempty"`
 	Priority        string                 `json:"priority,omitempty"`
 	TTL             int                    `json:"time_to_live,omitempty"`
+	DryRun          bool                   `json:"dry_run,omitempty"`
 }
 
 // FCMNotification represents the notification payload
@@ -37,10 +52,13 @@ type FCMNotification struct {
 	Body  string `json:"body,omitempty"`
 	Sound string `json:"sound,omitempty"`
 	Badge string `json:"badge,omitempty"`
+	Tag   string `json:"tag,omitempty"` // Replaces the displayed notification with the same tag
 }
 
 // FCMResponse represents the FCM API response structure
 type FCMResponse struct {
+	MessageID    int64    `json:"message_id,omitempty"` // Set for topic messages
+	Error        string   `json:"error,omitempty"`      // Set for failed topic messages
 	MulticastID  int64    `json:"multicast_id"`
 	Success      int      `json:"success"`
 	Failure      int      `json:"failure"`
@@ -61,15 +79,27 @@ type Result struct {
 //   - FCM_TIMEOUT: Request timeout in seconds (mandatory, must be > 0)
 //   - FCM_BATCH_SIZE: Number of tokens to send in a single request (mandatory, must be > 0)
 //
-// If any of these variables are missing, empty, or invalid, the service will use mock implementation
-func NewFCMService() FCMService {
+// If any of these variables are missing, empty, or invalid, the service will use mock implementation.
+// FCM_SERVER_KEY may be left empty when the push app registry configures Android apps; devices
+// without an app ID are then simulated.
+func NewFCMService(transport httpclient.TransportConfig) (FCMService, error) {
 	serverKey := os.Getenv(constants.FCM_SERVER_KEY)
 	timeoutStr := os.Getenv(constants.FCM_TIMEOUT)
 	batchSizeStr := os.Getenv(constants.FCM_BATCH_SIZE)
 
+	registry, err := pushapps.LoadFromEnv()
+	if err != nil {
+		logrus.WithError(err).Error("Invalid push app registry, using mock FCM service")
+		return NewMockFCMService(), nil
+	}
+	appServerKeys := make(map[string]string)
+	for app, credentials := range registry.FCMApps() {
+		appServerKeys[app] = credentials.ServerKey
+	}
+
 	// Check if all required environment variables are present and non-empty
-	if serverKey == "" || timeoutStr == "" || batchSizeStr == "" {
-		return NewMockFCMService()
+	if (serverKey == "" && len(appServerKeys) == 0) || timeoutStr == "" || batchSizeStr == "" {
+		return NewMockFCMService(), nil
 	}
 
 	// Parse timeout - must be a valid positive integer
@@ -84,16 +114,22 @@ func NewFCMService() FCMService {
 		batchSize = constants.DefaultFCMBatchSize
 	}
 
+	client, err := httpclient.NewProviderClient("FCM", transport, time.Duration(timeout)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
 	return &FCMServiceImpl{
 		config: &FCMConfig{
 			ServerKey: serverKey,
 			Timeout:   timeout,
 			BatchSize: batchSize,
 		},
-		client: &http.Client{
-			Timeout: time.Duration(timeout) * time.Second,
-		},
-	}
+		appServerKeys: appServerKeys,
+		endpoint:      fcmEndpoint,
+		iidEndpoint:   iidEndpoint,
+		client:        client,
+	}, nil
 }
 
 // SendPushNotification sends a push notification to Android devices via FCM
@@ -123,20 +159,15 @@ func (fcm *FCMServiceImpl) SendPushNotification(ctx context.Context, notificatio
 		}, nil
 	}
 
-	// Prepare notification payload
-	fcmNotification := &FCMNotification{
-		Title: notif.Content.Title,
-		Body:  notif.Content.Body,
-		Sound: "default",
-	}
+	fcmNotification, data := pushPayload(notif)
 
-	// Prepare data payload
-	data := make(map[string]interface{})
-	data["notification_id"] = notif.ID
-	data["type"] = notif.Type
+	serverKey, err := fcm.serverKey(notif.AppID)
+	if err != nil {
+		return nil, err
+	}
 
 	// Check if config is available
-	if fcm.config == nil {
+	if serverKey == "" {
 		// Return mock response for demo purposes when config is not available
 		return &models.FCMResponse{
 			ID:           notif.ID,
@@ -150,66 +181,277 @@ func (fcm *FCMServiceImpl) SendPushNotification(ctx context.Context, notificatio
 	}
 
 	// Send notification to single device token
-	success, failure, err := fcm.sendBatch(ctx, []string{deviceToken}, fcmNotification, data)
+	success, failure, statusCode, err := fcm.sendBatch(ctx, serverKey, []string{deviceToken}, fcmNotification, data, notif.CollapseKey)
+	reason := ""
 	if err != nil {
 		failure = 1
 		success = 0
+		reason = err.Error()
 	}
 
 	// Return success response
+	return sentResponse(notif.ID, success, failure, statusCode, reason), nil
+}
+
+// MaxBatchSize returns how many device tokens are sent in one multicast request
+func (fcm *FCMServiceImpl) MaxBatchSize() int {
+	if fcm.config == nil || fcm.config.BatchSize <= 0 {
+		return 1
+	}
+	return min(fcm.config.BatchSize, MaxMulticastTokens)
+}
+
+// multicastKey is what pushes must have in common to be sent in one multicast request
+type multicastKey struct {
+	appID            string
+	id               string
+	notificationType string
+	title            string
+	body             string
+}
+
+// SendPushNotificationBatch sends pushes with the same app and content in multicast requests of
+// up to MaxBatchSize device tokens. Like SendPushNotification, a push FCM did not deliver has a
+// response with a failure count rather than an error.
+func (fcm *FCMServiceImpl) SendPushNotificationBatch(ctx context.Context, notifications []*models.FCMNotificationRequest) ([]interface{}, []error) {
+	responses := make([]interface{}, len(notifications))
+	errs := make([]error, len(notifications))
+
+	groups := make(map[multicastKey][]int)
+	var keys []multicastKey
+	for i, notif := range notifications {
+		if err := models.ValidateFCMNotification(notif); err != nil {
+			errs[i] = fmt.Errorf("FCM validation failed: %w", err)
+			continue
+		}
+		key := multicastKey{notif.AppID, notif.ID, notif.Type, notif.Content.Title, notif.Content.Body}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	batchSize := fcm.MaxBatchSize()
+	for _, key := range keys {
+		indexes := groups[key]
+		serverKey, err := fcm.serverKey(key.appID)
+		if err != nil {
+			for _, i := range indexes {
+				errs[i] = err
+			}
+			continue
+		}
+		for start := 0; start < len(indexes); start += batchSize {
+			batch := indexes[start:min(start+batchSize, len(indexes))]
+			fcm.sendMulticast(ctx, serverKey, notifications, batch, responses)
+		}
+	}
+
+	return responses, errs
+}
+
+// sendMulticast sends the pushes at the given indexes, which share their app and content, in one
+// request and stores a response for each of them
+func (fcm *FCMServiceImpl) sendMulticast(ctx context.Context, serverKey string, notifications []*models.FCMNotificationRequest, indexes []int, responses []interface{}) {
+	first := notifications[indexes[0]]
+
+	if serverKey == "" {
+		for _, i := range indexes {
+			responses[i] = &models.FCMResponse{
+				ID:           notifications[i].ID,
+				Status:       "demo_mode",
+				Message:      "FCM notification simulated (no config provided)",
+				SentAt:       time.Now(),
+				Channel:      "fcm",
+				SuccessCount: 1,
+			}
+		}
+		return
+	}
+
+	tokens := make([]string, len(indexes))
+	for j, i := range indexes {
+		tokens[j] = notifications[i].Recipient
+	}
+
+	fcmNotification, data := pushPayload(first)
+	fcmResp, statusCode, err := fcm.post(ctx, serverKey, &FCMRequest{
+		RegistrationIDs: tokens,
+		Notification:    fcmNotification,
+		Data:            data,
+		CollapseKey:     first.CollapseKey,
+		Priority:        "high",
+		TTL:             86400, // 24 hours
+	})
+
+	// FCM returns the results in the order of the tokens
+	for j, i := range indexes {
+		success, failure, reason := 1, 0, ""
+		if err != nil {
+			success, failure, reason = 0, 1, err.Error()
+		} else if j < len(fcmResp.Results) && fcmResp.Results[j].Error != "" {
+			success, failure, reason = 0, 1, fcmResp.Results[j].Error
+		}
+		responses[i] = sentResponse(notifications[i].ID, success, failure, statusCode, reason)
+	}
+}
+
+// pushPayload returns the notification and data payloads of a push. Pushes with a collapse key
+// replace the displayed notification of the same thread.
+func pushPayload(notif *models.FCMNotificationRequest) (*FCMNotification, map[string]interface{}) {
+	// Prepare notification payload
+	fcmNotification := &FCMNotification{
+		Title: notif.Content.Title,
+		Body:  notif.Content.Body,
+		Sound: "default",
+		Tag:   notif.CollapseKey,
+	}
+
+	// Prepare data payload
+	data := make(map[string]interface{})
+	data["notification_id"] = notif.ID
+	data["type"] = notif.Type
+	if notif.CollapseKey != "" {
+		data["thread_key"] = notif.CollapseKey
+	}
+
+	return fcmNotification, data
+}
+
+// sentResponse is the response of a push handed to FCM, with the reason of a failure if any
+func sentResponse(id string, success, failure, statusCode int, reason string) *models.FCMResponse {
+	message := fmt.Sprintf("FCM notification sent successfully. Success: %d, Failed: %d", success, failure)
+	if reason != "" {
+		message = fmt.Sprintf("%s. Reason: %s", message, reason)
+	}
 	return &models.FCMResponse{
-		ID:           notif.ID,
+		ID:           id,
 		Status:       "sent",
-		Message:      fmt.Sprintf("FCM notification sent successfully. Success: %d, Failed: %d", success, failure),
+		Message:      message,
 		SentAt:       time.Now(),
 		Channel:      "fcm",
 		SuccessCount: success,
 		FailureCount: failure,
-	}, nil
+		StatusCode:   statusCode,
+	}
+}
+
+// serverKey returns the server key of an app; the default app uses FCM_SERVER_KEY and has no
+// key when the service is not configured
+func (fcm *FCMServiceImpl) serverKey(app string) (string, error) {
+	if app == "" {
+		if fcm.config == nil {
+			return "", nil
+		}
+		return fcm.config.ServerKey, nil
+	}
+	serverKey, ok := fcm.appServerKeys[app]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", pushapps.ErrUnknownApp, app)
+	}
+	return serverKey, nil
 }
 
 // sendBatch sends a batch of notifications to FCM
-func (fcm *FCMServiceImpl) sendBatch(ctx context.Context, tokens []string, notification *FCMNotification, data map[string]interface{}) (success, failure int, err error) {
-	request := &FCMRequest{
+func (fcm *FCMServiceImpl) sendBatch(ctx context.Context, serverKey string, tokens []string, notification *FCMNotification, data map[string]interface{}, collapseKey string) (success, failure, statusCode int, err error) {
+	fcmResp, statusCode, err := fcm.post(ctx, serverKey, &FCMRequest{
 		RegistrationIDs: tokens,
 		Notification:    notification,
 		Data:            data,
+		CollapseKey:     collapseKey,
 		Priority:        "high",
 		TTL:             86400, // 24 hours
+	})
+	if err != nil {
+		return 0, len(tokens), statusCode, err
 	}
+	return fcmResp.Success, fcmResp.Failure, statusCode, nil
+}
 
+// post sends a request to the FCM API and returns its response
+func (fcm *FCMServiceImpl) post(ctx context.Context, serverKey string, request *FCMRequest) (*FCMResponse, int, error) {
 	requestBytes, err := json.Marshal(request)
 	if err != nil {
-		return 0, len(tokens), fmt.Errorf("failed to marshal FCM request: %w", err)
+		return nil, 0, fmt.Errorf("failed to marshal FCM request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://fcm.googleapis.com/fcm/send", bytes.NewReader(requestBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", fcm.endpoint, bytes.NewReader(requestBytes))
 	if err != nil {
-		return 0, len(tokens), fmt.Errorf("failed to create HTTP request: %w", err)
+		return nil, 0, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "key="+fcm.config.ServerKey)
+	req.Header.Set("Authorization", "key="+serverKey)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := fcm.client.Do(req)
 	if err != nil {
-		return 0, len(tokens), fmt.Errorf("failed to send FCM request: %w", err)
+		return nil, 0, fmt.Errorf("failed to send FCM request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return 0, len(tokens), fmt.Errorf("failed to read response body: %w", err)
+		return nil, resp.StatusCode, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return 0, len(tokens), fmt.Errorf("FCM API error: %s", string(body))
+		return nil, resp.StatusCode, fmt.Errorf("FCM API error: %s", string(body))
 	}
 
 	var fcmResp FCMResponse
 	if err := json.Unmarshal(body, &fcmResp); err != nil {
-		return 0, len(tokens), fmt.Errorf("failed to unmarshal FCM response: %w", err)
+		return nil, resp.StatusCode, fmt.Errorf("failed to unmarshal FCM response: %w", err)
+	}
+
+	return &fcmResp, resp.StatusCode, nil
+}
+
+// CheckConnection authenticates to FCM with every configured server key. The request is a dry
+// run, so FCM validates the key without delivering a notification.
+func (fcm *FCMServiceImpl) CheckConnection(ctx context.Context) error {
+	serverKeys := make(map[string]string, len(fcm.appServerKeys)+1)
+	if fcm.config != nil && fcm.config.ServerKey != "" {
+		serverKeys["default app"] = fcm.config.ServerKey
+	}
+	for app, serverKey := range fcm.appServerKeys {
+		serverKeys["app "+app] = serverKey
+	}
+
+	for name, serverKey := range serverKeys {
+		if err := fcm.checkServerKey(ctx, serverKey); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// checkServerKey sends a dry run request; FCM answers 401 when the server key is invalid
+func (fcm *FCMServiceImpl) checkServerKey(ctx context.Context, serverKey string) error {
+	requestBytes, err := json.Marshal(&FCMRequest{To: "selftest", DryRun: true})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", fcm.endpoint, bytes.NewReader(requestBytes))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "key="+serverKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := fcm.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach FCM: %w", err)
 	}
+	defer resp.Body.Close()
 
-	return fcmResp.Success, fcmResp.Failure, nil
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized:
+		return ErrInvalidServerKey
+	default:
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("FCM API error: status %d: %s", resp.StatusCode, string(body))
+	}
 }
@@ -2,20 +2,32 @@ package fcm
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/gaurav2721/notification-service/external_services/httpclient"
+	"github.com/gaurav2721/notification-service/external_services/pushapps"
 	"github.com/gaurav2721/notification-service/models"
 )
 
 func TestNewFCMService(t *testing.T) {
-	service := NewFCMService()
+	service, err := NewFCMService(httpclient.TransportConfig{})
+	if err != nil {
+		t.Fatalf("Expected FCM service to be created, got %v", err)
+	}
 	if service == nil {
 		t.Fatal("Expected FCM service to be created, got nil")
 	}
 }
 
 func TestSendPushNotification(t *testing.T) {
-	service := NewFCMService()
+	service, err := NewFCMService(httpclient.TransportConfig{})
+	if err != nil {
+		t.Fatalf("Expected FCM service to be created, got %v", err)
+	}
 
 	// Test with Android device token in recipient
 	notification := &models.FCMNotificationRequest{
@@ -60,3 +72,125 @@ func TestSendPushNotification(t *testing.T) {
 		t.Errorf("Expected ErrInvalidNotificationPayload, got %v", err)
 	}
 }
+
+func TestSendPushNotification_App(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.Write([]byte(`{"success": 1}`))
+	}))
+	defer server.Close()
+
+	service := &FCMServiceImpl{
+		config:        &FCMConfig{ServerKey: "default-key", Timeout: 30, BatchSize: 100},
+		appServerKeys: map[string]string{"driver": "driver-key"},
+		endpoint:      server.URL,
+		client:        http.DefaultClient,
+	}
+
+	notification := &models.FCMNotificationRequest{
+		ID:        "test_notification",
+		Type:      "android_push",
+		Content:   models.FCMContent{Title: "Test Title", Body: "Test Body"},
+		Recipient: "android_device_token_123",
+		AppID:     "driver",
+	}
+	if _, err := service.SendPushNotification(context.Background(), notification); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if authorization != "key=driver-key" {
+		t.Errorf("Expected the driver app server key, got %q", authorization)
+	}
+
+	notification.AppID = ""
+	if _, err := service.SendPushNotification(context.Background(), notification); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if authorization != "key=default-key" {
+		t.Errorf("Expected the default server key, got %q", authorization)
+	}
+
+	notification.AppID = "unknown"
+	if _, err := service.SendPushNotification(context.Background(), notification); !errors.Is(err, pushapps.ErrUnknownApp) {
+		t.Errorf("Expected ErrUnknownApp, got %v", err)
+	}
+}
+
+func TestSendPushNotification_CollapseKey(t *testing.T) {
+	var request FCMRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = FCMRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		w.Write([]byte(`{"success": 1}`))
+	}))
+	defer server.Close()
+
+	service := &FCMServiceImpl{
+		config:   &FCMConfig{ServerKey: "default-key", Timeout: 30, BatchSize: 100},
+		endpoint: server.URL,
+		client:   http.DefaultClient,
+	}
+
+	notification := &models.FCMNotificationRequest{
+		ID:          "test_notification",
+		Type:        "android_push",
+		Content:     models.FCMContent{Title: "Order #42", Body: "Out for delivery"},
+		Recipient:   "android_device_token_123",
+		CollapseKey: "order:42",
+	}
+	if _, err := service.SendPushNotification(context.Background(), notification); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if request.CollapseKey != "order:42" || request.Notification.Tag != "order:42" || request.Data["thread_key"] != "order:42" {
+		t.Errorf("Expected the thread key as collapse key, tag and data, got %+v", request)
+	}
+
+	if _, errs := service.SendPushNotificationBatch(context.Background(), []*models.FCMNotificationRequest{notification}); errs[0] != nil {
+		t.Fatalf("Expected no error, got %v", errs[0])
+	}
+	if request.CollapseKey != "order:42" {
+		t.Errorf("Expected the thread key as collapse key of the multicast request, got %q", request.CollapseKey)
+	}
+
+	notification.CollapseKey = ""
+	if _, err := service.SendPushNotification(context.Background(), notification); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if request.CollapseKey != "" || request.Notification.Tag != "" {
+		t.Errorf("Expected no collapse key, got %+v", request)
+	}
+}
+
+func TestCheckConnection(t *testing.T) {
+	var dryRun bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request FCMRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		dryRun = request.DryRun
+		if r.Header.Get("Authorization") != "key=valid-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"failure": 1, "results": [{"error": "InvalidRegistration"}]}`))
+	}))
+	defer server.Close()
+
+	service := &FCMServiceImpl{
+		config:   &FCMConfig{ServerKey: "valid-key", Timeout: 30, BatchSize: 100},
+		endpoint: server.URL,
+		client:   http.DefaultClient,
+	}
+	if err := service.CheckConnection(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !dryRun {
+		t.Error("Expected the check to be a dry run")
+	}
+
+	service.appServerKeys = map[string]string{"driver": "revoked-key"}
+	if err := service.CheckConnection(context.Background()); !errors.Is(err, ErrInvalidServerKey) {
+		t.Errorf("Expected ErrInvalidServerKey, got %v", err)
+	}
+}
@@ -84,3 +84,61 @@ func (fcm *MockFCMServiceImpl) SendPushNotification(ctx context.Context, notific
 
 	return response, nil
 }
+
+// SubscribeToTopic reports every device token as subscribed
+func (fcm *MockFCMServiceImpl) SubscribeToTopic(ctx context.Context, appID, topic string, tokens []string) (*TopicManagementResult, error) {
+	if err := ValidateTopic(topic); err != nil {
+		return nil, err
+	}
+	return &TopicManagementResult{SuccessCount: len(tokens)}, nil
+}
+
+// UnsubscribeFromTopic reports every device token as unsubscribed
+func (fcm *MockFCMServiceImpl) UnsubscribeFromTopic(ctx context.Context, appID, topic string, tokens []string) (*TopicManagementResult, error) {
+	if err := ValidateTopic(topic); err != nil {
+		return nil, err
+	}
+	return &TopicManagementResult{SuccessCount: len(tokens)}, nil
+}
+
+// SendToTopic writes the topic notification to file instead of sending it
+func (fcm *MockFCMServiceImpl) SendToTopic(ctx context.Context, topic string, notification *models.FCMNotificationRequest) (interface{}, error) {
+	if notification == nil {
+		return nil, ErrInvalidNotificationPayload
+	}
+	if err := ValidateTopic(topic); err != nil {
+		return nil, err
+	}
+
+	jsonData, err := json.MarshalIndent(map[string]interface{}{
+		"timestamp": time.Now().Format(time.RFC3339),
+		"id":        notification.ID,
+		"content":   notification.Content,
+		"topic":     topicDestination(topic),
+		"app_id":    notification.AppID,
+		"status":    "mock_sent",
+		"channel":   "fcm",
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal notification data: %w", err)
+	}
+
+	file, err := os.OpenFile(fcm.outputPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(fmt.Sprintf("=== FCM TOPIC NOTIFICATION ===\n%s\n\n", string(jsonData))); err != nil {
+		return nil, fmt.Errorf("failed to write to output file: %w", err)
+	}
+
+	return &models.FCMResponse{
+		ID:           notification.ID,
+		Status:       "mock_sent",
+		Message:      "FCM topic notification written to file (mock mode)",
+		SentAt:       time.Now(),
+		Channel:      "fcm",
+		SuccessCount: 1,
+	}, nil
+}
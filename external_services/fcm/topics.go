@@ -0,0 +1,219 @@
+package fcm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// MaxTopicManagementTokens is the largest number of device tokens FCM subscribes to or
+// unsubscribes from a topic in one request
+const MaxTopicManagementTokens = 1000
+
+// topicPattern matches the topic names FCM accepts
+var topicPattern = regexp.MustCompile(`^[a-zA-Z0-9-_.~%]{1,900}$`)
+
+// TopicService is implemented by FCM services that support topic messaging
+type TopicService interface {
+	// SubscribeToTopic subscribes the device tokens of an app to a topic; an empty app ID is
+	// the default app
+	SubscribeToTopic(ctx context.Context, appID, topic string, tokens []string) (*TopicManagementResult, error)
+
+	// UnsubscribeFromTopic unsubscribes the device tokens of an app from a topic
+	UnsubscribeFromTopic(ctx context.Context, appID, topic string, tokens []string) (*TopicManagementResult, error)
+
+	// SendToTopic sends a push to every device subscribed to the topic in the notification's app
+	SendToTopic(ctx context.Context, topic string, notification *models.FCMNotificationRequest) (interface{}, error)
+}
+
+// AsTopicService returns the topic messaging of an FCM service, or ErrTopicsNotSupported if the
+// service has none
+func AsTopicService(service FCMService) (TopicService, error) {
+	if topics, ok := service.(TopicService); ok {
+		return topics, nil
+	}
+	return nil, ErrTopicsNotSupported
+}
+
+// TopicManagementResult is the outcome of subscribing or unsubscribing device tokens
+type TopicManagementResult struct {
+	SuccessCount int                    `json:"success_count"`
+	FailureCount int                    `json:"failure_count"`
+	Errors       []TopicManagementError `json:"errors,omitempty"`
+}
+
+// TopicManagementError is the reason a device token could not be subscribed or unsubscribed
+type TopicManagementError struct {
+	Token string `json:"token"`
+	Error string `json:"error"`
+}
+
+// Add adds the counts and errors of another result
+func (r *TopicManagementResult) Add(other *TopicManagementResult) {
+	r.SuccessCount += other.SuccessCount
+	r.FailureCount += other.FailureCount
+	r.Errors = append(r.Errors, other.Errors...)
+}
+
+// ValidateTopic checks that FCM accepts the topic name. The /topics/ prefix is optional.
+func ValidateTopic(topic string) error {
+	if !topicPattern.MatchString(strings.TrimPrefix(topic, "/topics/")) {
+		return fmt.Errorf("%w: %q must be 1 to 900 letters, digits or -_.~%% characters", ErrInvalidTopic, topic)
+	}
+	return nil
+}
+
+// topicDestination is the "to" value addressing a topic
+func topicDestination(topic string) string {
+	return "/topics/" + strings.TrimPrefix(topic, "/topics/")
+}
+
+// SubscribeToTopic subscribes the device tokens to a topic in requests of up to
+// MaxTopicManagementTokens tokens
+func (fcm *FCMServiceImpl) SubscribeToTopic(ctx context.Context, appID, topic string, tokens []string) (*TopicManagementResult, error) {
+	return fcm.manageTopic(ctx, "batchAdd", appID, topic, tokens)
+}
+
+// UnsubscribeFromTopic unsubscribes the device tokens from a topic in requests of up to
+// MaxTopicManagementTokens tokens
+func (fcm *FCMServiceImpl) UnsubscribeFromTopic(ctx context.Context, appID, topic string, tokens []string) (*TopicManagementResult, error) {
+	return fcm.manageTopic(ctx, "batchRemove", appID, topic, tokens)
+}
+
+// manageTopic runs a batchAdd or batchRemove operation of the Instance ID API
+func (fcm *FCMServiceImpl) manageTopic(ctx context.Context, operation, appID, topic string, tokens []string) (*TopicManagementResult, error) {
+	if err := ValidateTopic(topic); err != nil {
+		return nil, err
+	}
+	serverKey, err := fcm.serverKey(appID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TopicManagementResult{}
+	if serverKey == "" {
+		// Simulated like pushes when the service is not configured
+		result.SuccessCount = len(tokens)
+		return result, nil
+	}
+
+	for start := 0; start < len(tokens); start += MaxTopicManagementTokens {
+		batch := tokens[start:min(start+MaxTopicManagementTokens, len(tokens))]
+		batchResult, err := fcm.postTopicManagement(ctx, operation, serverKey, topic, batch)
+		if err != nil {
+			return nil, err
+		}
+		result.Add(batchResult)
+	}
+	return result, nil
+}
+
+// postTopicManagement sends one Instance ID API request; its results are in the order of the tokens
+func (fcm *FCMServiceImpl) postTopicManagement(ctx context.Context, operation, serverKey, topic string, tokens []string) (*TopicManagementResult, error) {
+	requestBytes, err := json.Marshal(map[string]interface{}{
+		"to":                  topicDestination(topic),
+		"registration_tokens": tokens,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal topic request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", fcm.iidEndpoint+":"+operation, bytes.NewReader(requestBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Authorization", "key="+serverKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := fcm.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send topic request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, ErrInvalidServerKey
+	default:
+		return nil, fmt.Errorf("FCM topic API error: status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var topicResp struct {
+		Results []struct {
+			Error string `json:"error"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(body, &topicResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal topic response: %w", err)
+	}
+
+	result := &TopicManagementResult{}
+	for i, token := range tokens {
+		if i < len(topicResp.Results) && topicResp.Results[i].Error != "" {
+			result.FailureCount++
+			result.Errors = append(result.Errors, TopicManagementError{Token: token, Error: topicResp.Results[i].Error})
+			continue
+		}
+		result.SuccessCount++
+	}
+	return result, nil
+}
+
+// SendToTopic sends a push to the devices subscribed to the topic. The notification needs no
+// recipient.
+func (fcm *FCMServiceImpl) SendToTopic(ctx context.Context, topic string, notification *models.FCMNotificationRequest) (interface{}, error) {
+	if notification == nil {
+		return nil, ErrInvalidNotificationPayload
+	}
+	if err := ValidateTopic(topic); err != nil {
+		return nil, err
+	}
+	serverKey, err := fcm.serverKey(notification.AppID)
+	if err != nil {
+		return nil, err
+	}
+
+	if serverKey == "" {
+		return &models.FCMResponse{
+			ID:           notification.ID,
+			Status:       "demo_mode",
+			Message:      "FCM topic notification simulated (no config provided)",
+			SentAt:       time.Now(),
+			Channel:      "fcm",
+			SuccessCount: 1,
+		}, nil
+	}
+
+	fcmNotification, data := pushPayload(notification)
+	fcmResp, statusCode, err := fcm.post(ctx, serverKey, &FCMRequest{
+		To:           topicDestination(topic),
+		Notification: fcmNotification,
+		Data:         data,
+		CollapseKey:  notification.CollapseKey,
+		Priority:     "high",
+		TTL:          86400, // 24 hours
+	})
+	if err != nil {
+		return nil, err
+	}
+	if fcmResp.Error != "" {
+		return nil, fmt.Errorf("%w: %s", ErrFCMSendFailed, fcmResp.Error)
+	}
+
+	response := sentResponse(notification.ID, 1, 0, statusCode, "")
+	response.Message = fmt.Sprintf("FCM topic notification sent to %s", topicDestination(topic))
+	return response, nil
+}
@@ -0,0 +1,105 @@
+package fcm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+func TestValidateTopic(t *testing.T) {
+	for _, topic := range []string{"news", "/topics/news", "sports-2024_eu.~%"} {
+		if err := ValidateTopic(topic); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", topic, err)
+		}
+	}
+	for _, topic := range []string{"", "/topics/", "news alerts", "news/today"} {
+		if err := ValidateTopic(topic); !errors.Is(err, ErrInvalidTopic) {
+			t.Errorf("Expected %q to be invalid, got %v", topic, err)
+		}
+	}
+}
+
+func TestSubscribeToTopic(t *testing.T) {
+	var path, authorization string
+	var request struct {
+		To     string   `json:"to"`
+		Tokens []string `json:"registration_tokens"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		authorization = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Write([]byte(`{"results": [{}, {"error": "NOT_FOUND"}]}`))
+	}))
+	defer server.Close()
+
+	service := &FCMServiceImpl{
+		config:      &FCMConfig{ServerKey: "key", Timeout: 30, BatchSize: 100},
+		iidEndpoint: server.URL + "/iid/v1",
+		client:      http.DefaultClient,
+	}
+
+	result, err := service.SubscribeToTopic(context.Background(), "", "news", []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if path != "/iid/v1:batchAdd" || authorization != "key=key" || request.To != "/topics/news" {
+		t.Errorf("Unexpected request to %s with %q for %s", path, authorization, request.To)
+	}
+	if result.SuccessCount != 1 || result.FailureCount != 1 || result.Errors[0] != (TopicManagementError{Token: "b", Error: "NOT_FOUND"}) {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	if _, err := service.UnsubscribeFromTopic(context.Background(), "", "news", []string{"a"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if path != "/iid/v1:batchRemove" {
+		t.Errorf("Expected a batchRemove request, got %s", path)
+	}
+
+	if _, err := service.SubscribeToTopic(context.Background(), "", "bad topic", []string{"a"}); !errors.Is(err, ErrInvalidTopic) {
+		t.Errorf("Expected ErrInvalidTopic, got %v", err)
+	}
+}
+
+func TestSendToTopic(t *testing.T) {
+	var request FCMRequest
+	response := `{"message_id": 42}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	service := &FCMServiceImpl{
+		config:   &FCMConfig{ServerKey: "key", Timeout: 30, BatchSize: 100},
+		endpoint: server.URL,
+		client:   http.DefaultClient,
+	}
+	notification := &models.FCMNotificationRequest{
+		ID:      "n1",
+		Type:    "android_push",
+		Content: models.FCMContent{Title: "Title", Body: "Body"},
+	}
+
+	result, err := service.SendToTopic(context.Background(), "news", notification)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if request.To != "/topics/news" || request.Notification.Title != "Title" {
+		t.Errorf("Unexpected request %+v", request)
+	}
+	if fcmResponse := result.(*models.FCMResponse); fcmResponse.SuccessCount != 1 {
+		t.Errorf("Expected a successful response, got %+v", fcmResponse)
+	}
+
+	response = `{"error": "TopicsMessageRateExceeded"}`
+	if _, err := service.SendToTopic(context.Background(), "news", notification); !errors.Is(err, ErrFCMSendFailed) {
+		t.Errorf("Expected ErrFCMSendFailed, got %v", err)
+	}
+}
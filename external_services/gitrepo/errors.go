@@ -0,0 +1,9 @@
+package gitrepo
+
+import "errors"
+
+// Repository errors
+var (
+	ErrInvalidConfiguration = errors.New("invalid git repository configuration")
+	ErrCommandFailed        = errors.New("git command failed")
+)
@@ -0,0 +1,196 @@
+// Package gitrepo keeps a shallow checkout of a branch of a Git repository up to date, using the
+// git command line client, e.g. to sync templates kept in a repository
+package gitrepo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaurav2721/notification-service/constants"
+)
+
+// Config selects the repository, branch and directory to check out
+type Config struct {
+	// URL of the repository; empty disables the checkout. HTTPS credentials can be part of the
+	// URL; SSH uses the keys of the environment, e.g. GIT_SSH_COMMAND.
+	URL    string
+	Branch string
+
+	// Path is the directory within the repository that is used, e.g. the one holding templates
+	Path string
+
+	// CheckoutDir is where the repository is checked out; it must be empty or a checkout
+	CheckoutDir string
+
+	// Timeout bounds every git command
+	Timeout time.Duration
+}
+
+// LoadConfigFromEnv reads the repository configuration from environment variables
+func LoadConfigFromEnv() Config {
+	config := Config{
+		URL:         strings.TrimSpace(os.Getenv(constants.TemplatesGitURLEnvVar)),
+		Branch:      constants.DefaultTemplatesGitBranch,
+		Path:        constants.DefaultTemplatesGitPath,
+		CheckoutDir: os.Getenv(constants.TemplatesGitCheckoutDirEnvVar),
+		Timeout:     time.Duration(constants.DefaultTemplatesGitTimeoutSeconds) * time.Second,
+	}
+	if branch := strings.TrimSpace(os.Getenv(constants.TemplatesGitBranchEnvVar)); branch != "" {
+		config.Branch = branch
+	}
+	// An empty path uses the root of the repository, so it is only the default when unset
+	if path, ok := os.LookupEnv(constants.TemplatesGitPathEnvVar); ok {
+		config.Path = strings.TrimSpace(path)
+	}
+	if config.CheckoutDir == "" {
+		config.CheckoutDir = filepath.Join(os.TempDir(), "notification-service-templates")
+	}
+	if seconds, err := strconv.Atoi(os.Getenv(constants.TemplatesGitTimeoutSecondsEnvVar)); err == nil && seconds > 0 {
+		config.Timeout = time.Duration(seconds) * time.Second
+	}
+	return config
+}
+
+// Repository is a shallow checkout of one branch of a repository
+type Repository struct {
+	config Config
+	git    string
+
+	// mu serializes the git commands run in the checkout
+	mu sync.Mutex
+}
+
+// New returns the repository of config, or nil when no URL is configured. The git command line
+// client must be installed.
+func New(config Config) (*Repository, error) {
+	if config.URL == "" {
+		return nil, nil
+	}
+	if config.Branch == "" || strings.HasPrefix(config.Branch, "-") || strings.ContainsAny(config.Branch, " \t\n:~^?*[\\") {
+		return nil, fmt.Errorf("%w: invalid branch %q", ErrInvalidConfiguration, config.Branch)
+	}
+	path := filepath.Clean(filepath.FromSlash(config.Path))
+	if filepath.IsAbs(path) || path == ".." || strings.HasPrefix(path, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("%w: path %q must be relative to the root of the repository", ErrInvalidConfiguration, config.Path)
+	}
+	config.Path = path
+	if config.CheckoutDir == "" {
+		return nil, fmt.Errorf("%w: a checkout directory is required", ErrInvalidConfiguration)
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = time.Duration(constants.DefaultTemplatesGitTimeoutSeconds) * time.Second
+	}
+
+	git, err := exec.LookPath("git")
+	if err != nil {
+		return nil, fmt.Errorf("%w: the git client is not installed: %v", ErrInvalidConfiguration, err)
+	}
+	return &Repository{config: config, git: git}, nil
+}
+
+// URL returns the URL of the repository without credentials, for logs and status reports
+func (r *Repository) URL() string {
+	return redactURL(r.config.URL)
+}
+
+// Branch returns the branch that is checked out
+func (r *Repository) Branch() string {
+	return r.config.Branch
+}
+
+// Path returns the directory within the repository that is used
+func (r *Repository) Path() string {
+	return filepath.ToSlash(r.config.Path)
+}
+
+// Dir returns the directory of the checkout that Path refers to
+func (r *Repository) Dir() string {
+	return filepath.Join(r.config.CheckoutDir, r.config.Path)
+}
+
+// Pull checks out the latest commit of the branch, cloning the repository on first use, and
+// returns the SHA of the commit. Local changes to the checkout are discarded.
+func (r *Repository) Pull(ctx context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	dir := r.config.CheckoutDir
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		// The configured URL is fetched rather than origin, so a changed URL takes effect
+		if _, err := r.run(ctx, dir, "fetch", "--depth", "1", "--", r.config.URL, r.config.Branch); err != nil {
+			return "", err
+		}
+		if _, err := r.run(ctx, dir, "reset", "--hard", "FETCH_HEAD"); err != nil {
+			return "", err
+		}
+		if _, err := r.run(ctx, dir, "clean", "-ffdx"); err != nil {
+			return "", err
+		}
+	} else {
+		// A directory that is not a checkout is only used when it is empty, so a misconfigured
+		// checkout directory never overwrites other files
+		if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+			return "", fmt.Errorf("%w: checkout directory %s is not empty and not a git checkout", ErrInvalidConfiguration, dir)
+		}
+		if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+			return "", fmt.Errorf("failed to create checkout directory: %w", err)
+		}
+		if _, err := r.run(ctx, "", "clone", "--depth", "1", "--single-branch", "--branch", r.config.Branch, "--", r.config.URL, dir); err != nil {
+			return "", err
+		}
+	}
+
+	commit, err := r.run(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(commit), nil
+}
+
+// run runs a git command in dir and returns its output. Prompts for credentials are disabled,
+// as nobody could answer them.
+func (r *Repository) run(ctx context.Context, dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, r.git, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		// The output may quote the URL, which can hold credentials
+		output := strings.TrimSpace(strings.ReplaceAll(stderr.String(), r.config.URL, r.URL()))
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return "", fmt.Errorf("%w: git %s: %v: %s", ErrCommandFailed, args[0], err, output)
+	}
+	return stdout.String(), nil
+}
+
+// redactURL removes the password or token from a repository URL
+func redactURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.User == nil {
+		return raw
+	}
+	if _, hasPassword := parsed.User.Password(); hasPassword {
+		parsed.User = url.UserPassword(parsed.User.Username(), "redacted")
+	} else if parsed.Scheme == "https" || parsed.Scheme == "http" {
+		// A lone user name of an HTTPS URL is usually a token
+		parsed.User = url.User("redacted")
+	}
+	return parsed.String()
+}
@@ -0,0 +1,54 @@
+// Package googleauth gets OAuth access tokens for Google APIs from the JSON key file of a
+// service account, through golang.org/x/oauth2/google
+package googleauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// Service account errors
+var (
+	ErrInvalidCredentials = errors.New("invalid service account credentials")
+	ErrAuthFailed         = errors.New("service account authentication failed")
+)
+
+// TokenSource gets access tokens of a service account and caches them until shortly before
+// they expire
+type TokenSource struct {
+	source oauth2.TokenSource
+}
+
+// LoadTokenSource reads the JSON key file of a service account whose tokens grant scope.
+// Tokens are requested with client.
+func LoadTokenSource(path, scope string, client *http.Client) (*TokenSource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	credentials, err := google.CredentialsFromJSON(ctx, data, scope)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	return &TokenSource{source: credentials.TokenSource}, nil
+}
+
+// Token returns an access token for the scope of the token source. A token is requested once
+// the cached one is about to expire; ctx only bounds waiting for it.
+func (s *TokenSource) Token(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	token, err := s.source.Token()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrAuthFailed, err)
+	}
+	return token.AccessToken, nil
+}
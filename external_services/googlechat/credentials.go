@@ -0,0 +1,35 @@
+package googlechat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gaurav2721/notification-service/external_services/googleauth"
+)
+
+// chatBotScope lets a service account post messages as a Chat app
+const chatBotScope = "https://www.googleapis.com/auth/chat.bot"
+
+// tokenSource gets access tokens for the Chat API, with the errors of this package
+type tokenSource struct {
+	source *googleauth.TokenSource
+}
+
+// loadTokenSource reads the JSON key file of a service account
+func loadTokenSource(path string, client *http.Client) (*tokenSource, error) {
+	source, err := googleauth.LoadTokenSource(path, chatBotScope, client)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	return &tokenSource{source: source}, nil
+}
+
+// Token returns an access token for the Chat API
+func (s *tokenSource) Token(ctx context.Context) (string, error) {
+	token, err := s.source.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrGoogleChatAuthFailed, err)
+	}
+	return token, nil
+}
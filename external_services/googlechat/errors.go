@@ -0,0 +1,13 @@
+package googlechat
+
+import "errors"
+
+// Google Chat service errors
+var (
+	ErrGoogleChatSendFailed = errors.New("failed to send google chat message")
+	// ErrCredentialsMissing is returned for messages to a space name, which are posted through
+	// the Chat API as a Chat app, when no service account credentials are configured
+	ErrCredentialsMissing   = errors.New("google chat credentials are missing, spaces can only be posted to through their webhook URL")
+	ErrInvalidCredentials   = errors.New("invalid google chat service account credentials")
+	ErrGoogleChatAuthFailed = errors.New("google chat authentication failed")
+)
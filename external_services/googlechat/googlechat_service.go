@@ -0,0 +1,278 @@
+package googlechat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gaurav2721/notification-service/constants"
+	"github.com/gaurav2721/notification-service/external_services/httpclient"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultAPIBaseURL is the Google Chat API messages of space names are posted to
+const defaultAPIBaseURL = "https://chat.googleapis.com"
+
+// maxErrorBodyBytes bounds the part of an error response read for its message
+const maxErrorBodyBytes = 4096
+
+// GoogleChatServiceImpl implements the GoogleChatService interface. Messages to the incoming
+// webhook URL of a space are posted to it; messages to a space name are posted through the Chat
+// API as the Chat app of the service account credentials.
+type GoogleChatServiceImpl struct {
+	client     *http.Client
+	tokens     *tokenSource
+	apiBaseURL string
+}
+
+// Enabled reports whether Google Chat is turned on with GOOGLE_CHAT_ENABLED
+func Enabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(constants.GOOGLE_CHAT_ENABLED))
+	return enabled
+}
+
+// NewGoogleChatService creates a new Google Chat service instance that connects through transport
+// It returns the mock service unless Google Chat is enabled, and an error for an invalid transport
+func NewGoogleChatService(transport httpclient.TransportConfig) (GoogleChatService, error) {
+	if !Enabled() {
+		return NewMockGoogleChatService(), nil
+	}
+
+	client, err := httpclient.NewProviderClient("Google Chat", transport, constants.DefaultGoogleChatTimeout*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	service := &GoogleChatServiceImpl{client: client, apiBaseURL: defaultAPIBaseURL}
+	if path := os.Getenv(constants.GOOGLE_CHAT_CREDENTIALS_FILE); path != "" {
+		// Webhooks work without credentials, so the service keeps running without them
+		service.tokens, err = loadTokenSource(path, client)
+		if err != nil {
+			logrus.WithError(err).Error("Invalid Google Chat credentials, only space webhooks can be posted to")
+		}
+	}
+	return service, nil
+}
+
+// SendGoogleChatMessage sends a Google Chat notification
+func (gs *GoogleChatServiceImpl) SendGoogleChatMessage(ctx context.Context, notification interface{}) (interface{}, error) {
+	// Type assertion to get the notification
+	notif, ok := notification.(*models.GoogleChatNotificationRequest)
+	if !ok {
+		return nil, ErrGoogleChatSendFailed
+	}
+
+	// Validate the Google Chat notification
+	if err := models.ValidateGoogleChatNotification(notif); err != nil {
+		return nil, fmt.Errorf("google chat validation failed: %w", err)
+	}
+
+	body, err := json.Marshal(buildMessage(notif))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrGoogleChatSendFailed, err)
+	}
+
+	endpoint, err := gs.endpoint(notif)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrGoogleChatSendFailed, err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	if !models.IsGoogleChatWebhookURL(notif.Recipient) {
+		token, err := gs.tokens.Token(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := gs.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrGoogleChatSendFailed, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, responseError(resp)
+	}
+
+	var message struct {
+		Name   string `json:"name"`
+		Thread struct {
+			Name string `json:"name"`
+		} `json:"thread"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&message); err != nil {
+		return nil, fmt.Errorf("%w: invalid response: %v", ErrGoogleChatSendFailed, err)
+	}
+
+	// Return success response
+	return &models.GoogleChatResponse{
+		ID:          notif.ID,
+		Status:      "sent",
+		Message:     "Google Chat message sent successfully",
+		SentAt:      time.Now(),
+		Channel:     "google_chat",
+		MessageName: message.Name,
+		ThreadName:  message.Thread.Name,
+	}, nil
+}
+
+// CheckConnection gets an access token for the service account without posting a message.
+// Webhooks cannot be checked without posting, so the check passes without credentials.
+func (gs *GoogleChatServiceImpl) CheckConnection(ctx context.Context) error {
+	if gs.tokens == nil {
+		return nil
+	}
+	_, err := gs.tokens.Token(ctx)
+	return err
+}
+
+// endpoint returns the URL a notification is posted to. Messages with a thread key reply in
+// the thread of the key, which Google Chat starts with the first of them.
+func (gs *GoogleChatServiceImpl) endpoint(notif *models.GoogleChatNotificationRequest) (string, error) {
+	var endpoint *url.URL
+	var err error
+	if models.IsGoogleChatWebhookURL(notif.Recipient) {
+		endpoint, err = url.Parse(notif.Recipient)
+	} else {
+		if gs.tokens == nil {
+			return "", ErrCredentialsMissing
+		}
+		endpoint, err = url.Parse(gs.apiBaseURL + "/v1/" + notif.Recipient + "/messages")
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrGoogleChatSendFailed, err)
+	}
+
+	if notif.ThreadKey != "" {
+		query := endpoint.Query()
+		query.Set("messageReplyOption", "REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD")
+		endpoint.RawQuery = query.Encode()
+	}
+	return endpoint.String(), nil
+}
+
+// responseError describes a failed response of the Chat API
+func responseError(resp *http.Response) error {
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+			Status  string `json:"status"`
+		} `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	message := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &body) == nil && body.Error.Message != "" {
+		message = body.Error.Status + ": " + body.Error.Message
+	}
+
+	cause := ErrGoogleChatSendFailed
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		cause = ErrGoogleChatAuthFailed
+	}
+	return fmt.Errorf("%w: status %d: %s", cause, resp.StatusCode, message)
+}
+
+// message is the Chat API message posted for a notification
+type message struct {
+	Text    string   `json:"text,omitempty"`
+	CardsV2 []cardV2 `json:"cardsV2,omitempty"`
+	Thread  *thread  `json:"thread,omitempty"`
+}
+
+type thread struct {
+	ThreadKey string `json:"threadKey"`
+}
+
+type cardV2 struct {
+	CardID string `json:"cardId"`
+	Card   card   `json:"card"`
+}
+
+type card struct {
+	Header   *cardHeader   `json:"header,omitempty"`
+	Sections []cardSection `json:"sections,omitempty"`
+}
+
+type cardHeader struct {
+	Title     string `json:"title"`
+	Subtitle  string `json:"subtitle,omitempty"`
+	ImageURL  string `json:"imageUrl,omitempty"`
+	ImageType string `json:"imageType,omitempty"`
+}
+
+type cardSection struct {
+	Header  string   `json:"header,omitempty"`
+	Widgets []widget `json:"widgets"`
+}
+
+type widget struct {
+	TextParagraph *textParagraph `json:"textParagraph,omitempty"`
+	ButtonList    *buttonList    `json:"buttonList,omitempty"`
+}
+
+type textParagraph struct {
+	Text string `json:"text"`
+}
+
+type buttonList struct {
+	Buttons []button `json:"buttons"`
+}
+
+type button struct {
+	Text    string  `json:"text"`
+	OnClick onClick `json:"onClick"`
+}
+
+type onClick struct {
+	OpenLink openLink `json:"openLink"`
+}
+
+type openLink struct {
+	URL string `json:"url"`
+}
+
+// buildMessage converts a notification into a Chat API message. The card becomes a cardsV2
+// card with a section per card section and the buttons in a last section.
+func buildMessage(notif *models.GoogleChatNotificationRequest) *message {
+	msg := &message{Text: notif.Content.Text}
+	if notif.ThreadKey != "" {
+		msg.Thread = &thread{ThreadKey: notif.ThreadKey}
+	}
+
+	source := notif.Content.Card
+	if source == nil {
+		return msg
+	}
+	c := card{Header: &cardHeader{Title: source.Title, Subtitle: source.Subtitle, ImageURL: source.ImageURL}}
+	if source.ImageURL != "" {
+		c.Header.ImageType = "CIRCLE"
+	}
+	for _, section := range source.Sections {
+		c.Sections = append(c.Sections, cardSection{
+			Header:  section.Header,
+			Widgets: []widget{{TextParagraph: &textParagraph{Text: section.Text}}},
+		})
+	}
+	if len(source.Buttons) > 0 {
+		buttons := make([]button, 0, len(source.Buttons))
+		for _, b := range source.Buttons {
+			buttons = append(buttons, button{Text: b.Text, OnClick: onClick{OpenLink: openLink{URL: b.URL}}})
+		}
+		c.Sections = append(c.Sections, cardSection{Widgets: []widget{{ButtonList: &buttonList{Buttons: buttons}}}})
+	}
+	msg.CardsV2 = []cardV2{{CardID: "notification-" + notif.ID, Card: c}}
+	return msg
+}
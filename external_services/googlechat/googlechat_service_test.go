@@ -0,0 +1,140 @@
+package googlechat
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestCredentials(t *testing.T, tokenURI string) string {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	data, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"client_email":   "notifier@project.iam.gserviceaccount.com",
+		"private_key_id": "key-1",
+		"private_key":    string(keyPEM),
+		"token_uri":      tokenURI,
+	})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	return path
+}
+
+func TestSendGoogleChatMessageToSpace(t *testing.T) {
+	tokenRequests := 0
+	var posted map[string]interface{}
+	var postedQuery url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			tokenRequests++
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.Form.Get("grant_type"))
+			assert.NotEmpty(t, r.Form.Get("assertion"))
+			w.Write([]byte(`{"access_token":"access-token","expires_in":3600}`))
+		case "/v1/spaces/AAAA/messages":
+			assert.Equal(t, "Bearer access-token", r.Header.Get("Authorization"))
+			postedQuery = r.URL.Query()
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&posted))
+			w.Write([]byte(`{"name":"spaces/AAAA/messages/m1","thread":{"name":"spaces/AAAA/threads/t1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tokens, err := loadTokenSource(writeTestCredentials(t, server.URL+"/token"), server.Client())
+	require.NoError(t, err)
+	service := &GoogleChatServiceImpl{client: server.Client(), tokens: tokens, apiBaseURL: server.URL}
+
+	notification := &models.GoogleChatNotificationRequest{
+		ID:   "notif-1",
+		Type: "google_chat",
+		Content: models.GoogleChatContent{
+			Text: "Deployment finished",
+			Card: &models.GoogleChatCard{
+				Title:    "Deploy #42",
+				Sections: []models.GoogleChatCardSection{{Header: "Status", Text: "<b>Succeeded</b>"}},
+				Buttons:  []models.GoogleChatCardButton{{Text: "Open", URL: "https://ci.example.com/42"}},
+			},
+		},
+		Recipient: "spaces/AAAA",
+		ThreadKey: "deploy-42",
+	}
+
+	for i := 0; i < 2; i++ {
+		result, err := service.SendGoogleChatMessage(context.Background(), notification)
+		require.NoError(t, err)
+		response := result.(*models.GoogleChatResponse)
+		assert.Equal(t, "spaces/AAAA/messages/m1", response.MessageName)
+		assert.Equal(t, "spaces/AAAA/threads/t1", response.ThreadName)
+	}
+	assert.Equal(t, 1, tokenRequests, "the access token is cached")
+
+	assert.Equal(t, "REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD", postedQuery.Get("messageReplyOption"))
+	assert.Equal(t, "Deployment finished", posted["text"])
+	assert.Equal(t, map[string]interface{}{"threadKey": "deploy-42"}, posted["thread"])
+	card := posted["cardsV2"].([]interface{})[0].(map[string]interface{})["card"].(map[string]interface{})
+	assert.Equal(t, "Deploy #42", card["header"].(map[string]interface{})["title"])
+	assert.Len(t, card["sections"], 2, "a section per card section and one for the buttons")
+}
+
+func TestSendGoogleChatMessageErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error":{"code":403,"message":"The caller does not have permission","status":"PERMISSION_DENIED"}}`))
+	}))
+	defer server.Close()
+
+	notification := &models.GoogleChatNotificationRequest{
+		ID:        "notif-1",
+		Type:      "google_chat",
+		Content:   models.GoogleChatContent{Text: "Hello"},
+		Recipient: "spaces/AAAA",
+	}
+
+	// Space names need service account credentials
+	service := &GoogleChatServiceImpl{client: server.Client(), apiBaseURL: server.URL}
+	_, err := service.SendGoogleChatMessage(context.Background(), notification)
+	assert.True(t, errors.Is(err, ErrCredentialsMissing), "got %v", err)
+
+	// Rejected requests are authentication failures
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token":"access-token","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+	service.tokens, err = loadTokenSource(writeTestCredentials(t, tokenServer.URL), tokenServer.Client())
+	require.NoError(t, err)
+	_, err = service.SendGoogleChatMessage(context.Background(), notification)
+	assert.True(t, errors.Is(err, ErrGoogleChatAuthFailed), "got %v", err)
+	assert.Contains(t, err.Error(), "PERMISSION_DENIED")
+
+	_, err = service.SendGoogleChatMessage(context.Background(), "invalid")
+	assert.Equal(t, ErrGoogleChatSendFailed, err)
+}
+
+func TestLoadTokenSourceInvalidCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"client_email":"notifier@project.iam.gserviceaccount.com"}`), 0600))
+
+	_, err := loadTokenSource(path, http.DefaultClient)
+	assert.True(t, errors.Is(err, ErrInvalidCredentials), "got %v", err)
+}
@@ -0,0 +1,8 @@
+package googlechat
+
+import "context"
+
+// GoogleChatService interface defines methods for Google Chat notifications
+type GoogleChatService interface {
+	SendGoogleChatMessage(ctx context.Context, notification interface{}) (interface{}, error)
+}
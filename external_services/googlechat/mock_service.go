@@ -0,0 +1,85 @@
+package googlechat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// MockGoogleChatServiceImpl implements the GoogleChatService interface for testing/mock purposes
+type MockGoogleChatServiceImpl struct {
+	outputPath string
+}
+
+// NewMockGoogleChatService creates a new mock Google Chat service instance
+func NewMockGoogleChatService() GoogleChatService {
+	// Create output directory if it doesn't exist
+	outputDir := "output"
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		panic(fmt.Sprintf("failed to create output directory: %v", err))
+	}
+
+	return &MockGoogleChatServiceImpl{
+		outputPath: filepath.Join(outputDir, "google_chat.txt"),
+	}
+}
+
+// SendGoogleChatMessage writes Google Chat notification to file instead of sending actual message
+func (gs *MockGoogleChatServiceImpl) SendGoogleChatMessage(ctx context.Context, notification interface{}) (interface{}, error) {
+	// Type assertion to get the notification
+	notif, ok := notification.(*models.GoogleChatNotificationRequest)
+	if !ok {
+		return nil, ErrGoogleChatSendFailed
+	}
+
+	// Validate the Google Chat notification
+	if err := models.ValidateGoogleChatNotification(notif); err != nil {
+		return nil, fmt.Errorf("google chat validation failed: %w", err)
+	}
+
+	// Create mock response
+	response := &models.GoogleChatResponse{
+		ID:      notif.ID,
+		Status:  "mock_sent",
+		Message: "Google Chat notification written to file (mock mode)",
+		SentAt:  time.Now(),
+		Channel: "google_chat",
+	}
+
+	// Prepare notification data for file output
+	notificationData := map[string]interface{}{
+		"timestamp":  time.Now().Format(time.RFC3339),
+		"id":         notif.ID,
+		"content":    notif.Content,
+		"recipient":  notif.Recipient,
+		"status":     "mock_sent",
+		"thread_key": notif.ThreadKey,
+		"channel":    "google_chat",
+	}
+
+	// Convert to JSON
+	jsonData, err := json.MarshalIndent(notificationData, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal notification data: %w", err)
+	}
+
+	// Write to file
+	file, err := os.OpenFile(gs.outputPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output file: %w", err)
+	}
+	defer file.Close()
+
+	// Add separator and newline
+	output := fmt.Sprintf("=== GOOGLE CHAT NOTIFICATION ===\n%s\n\n", string(jsonData))
+	if _, err := file.WriteString(output); err != nil {
+		return nil, fmt.Errorf("failed to write to output file: %w", err)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,30 @@
+package httpclient
+
+import "os"
+
+// TransportConfig holds the outbound proxy and TLS settings of a provider client.
+// The zero value uses the proxy from HTTP_PROXY/HTTPS_PROXY/NO_PROXY and the system CA pool.
+type TransportConfig struct {
+	ProxyURL       string // http://, https:// or socks5:// proxy used instead of the environment proxy
+	CABundlePath   string // PEM file with CA certificates trusted in addition to the system pool
+	ClientCertPath string // PEM client certificate for mutual TLS
+	ClientKeyPath  string // PEM private key of the client certificate
+}
+
+// EnvKeys names the environment variables a provider reads its TransportConfig from
+type EnvKeys struct {
+	ProxyURL       string
+	CABundlePath   string
+	ClientCertPath string
+	ClientKeyPath  string
+}
+
+// LoadTransportConfigFromEnv reads a provider's transport configuration from environment variables
+func LoadTransportConfigFromEnv(keys EnvKeys) TransportConfig {
+	return TransportConfig{
+		ProxyURL:       os.Getenv(keys.ProxyURL),
+		CABundlePath:   os.Getenv(keys.CABundlePath),
+		ClientCertPath: os.Getenv(keys.ClientCertPath),
+		ClientKeyPath:  os.Getenv(keys.ClientKeyPath),
+	}
+}
@@ -0,0 +1,10 @@
+package httpclient
+
+import "errors"
+
+// Transport configuration errors
+var (
+	ErrInvalidProxyURL      = errors.New("invalid proxy URL")
+	ErrInvalidCABundle      = errors.New("CA bundle contains no PEM certificates")
+	ErrIncompleteClientCert = errors.New("client certificate and key must be configured together")
+)
@@ -0,0 +1,99 @@
+// Package httpclient builds the HTTP clients used by the provider integrations,
+// applying per-provider proxy, custom CA and mutual TLS settings.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// NewTransport creates an HTTP transport with the proxy and TLS settings of config.
+// It starts from a clone of http.DefaultTransport so connection pooling and timeouts stay the same.
+func NewTransport(config TransportConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if config.ProxyURL != "" {
+		proxyURL, err := url.Parse(config.ProxyURL)
+		if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidProxyURL, config.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return transport, nil
+}
+
+// NewClient creates an HTTP client with the given timeout and the transport built from config
+func NewClient(config TransportConfig, timeout time.Duration) (*http.Client, error) {
+	transport, err := NewTransport(config)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}, nil
+}
+
+// NewProviderClient creates the HTTP client of a provider integration. An invalid proxy or TLS
+// configuration is returned as an error instead of falling back to a direct connection, which
+// could bypass a mandatory proxy, so the service fails to start rather than run misconfigured.
+func NewProviderClient(provider string, config TransportConfig, timeout time.Duration) (*http.Client, error) {
+	client, err := NewClient(config, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s proxy or TLS configuration: %w", provider, err)
+	}
+	return client, nil
+}
+
+// newTLSConfig returns the TLS settings for custom CAs and client certificates, or nil when none are set
+func newTLSConfig(config TransportConfig) (*tls.Config, error) {
+	if config.CABundlePath == "" && config.ClientCertPath == "" && config.ClientKeyPath == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if config.CABundlePath != "" {
+		pem, err := os.ReadFile(config.CABundlePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+
+		// Custom CAs extend the system pool so public endpoints keep working behind a TLS-inspecting proxy
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCABundle, config.CABundlePath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.ClientCertPath != "" || config.ClientKeyPath != "" {
+		if config.ClientCertPath == "" || config.ClientKeyPath == "" {
+			return nil, ErrIncompleteClientCert
+		}
+		certificate, err := tls.LoadX509KeyPair(config.ClientCertPath, config.ClientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,157 @@
+package httpclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writePEM writes a PEM block to a file in the test's temp dir
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return path
+}
+
+func TestNewClient_UsesConfiguredProxy(t *testing.T) {
+	proxied := make(chan string, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy receives the absolute URL of the target
+		proxied <- r.URL.String()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+
+	client, err := NewClient(TransportConfig{ProxyURL: proxy.URL}, time.Second)
+	require.NoError(t, err)
+
+	resp, err := client.Get("http://fcm.example.invalid/send")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, "http://fcm.example.invalid/send", <-proxied)
+}
+
+func TestNewClient_TrustsCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// Without the bundle the test server's self-signed certificate is rejected
+	client, err := NewClient(TransportConfig{}, time.Second)
+	require.NoError(t, err)
+	_, err = client.Get(server.URL)
+	require.Error(t, err)
+
+	bundle := writePEM(t, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+	client, err = NewClient(TransportConfig{CABundlePath: bundle}, time.Second)
+	require.NoError(t, err)
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestNewClient_NegotiatesHTTP2(t *testing.T) {
+	// APNS only accepts HTTP/2, which also multiplexes concurrent requests over one connection
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	bundle := writePEM(t, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+	client, err := NewClient(TransportConfig{CABundlePath: bundle}, time.Second)
+	require.NoError(t, err)
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, 2, resp.ProtoMajor)
+}
+
+func TestNewClient_PresentsClientCertificate(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "notification-service"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Len(t, r.TLS.PeerCertificates, 1)
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	client, err := NewClient(TransportConfig{
+		CABundlePath:   writePEM(t, "ca.pem", "CERTIFICATE", server.Certificate().Raw),
+		ClientCertPath: writePEM(t, "client.pem", "CERTIFICATE", certDER),
+		ClientKeyPath:  writePEM(t, "client-key.pem", "EC PRIVATE KEY", keyDER),
+	}, time.Second)
+	require.NoError(t, err)
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestNewTransport_InvalidConfig(t *testing.T) {
+	_, err := NewTransport(TransportConfig{ProxyURL: "proxy.internal:3128"})
+	assert.ErrorIs(t, err, ErrInvalidProxyURL)
+
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0o600))
+	_, err = NewTransport(TransportConfig{CABundlePath: notPEM})
+	assert.ErrorIs(t, err, ErrInvalidCABundle)
+
+	_, err = NewTransport(TransportConfig{ClientCertPath: "client.pem"})
+	assert.ErrorIs(t, err, ErrIncompleteClientCert)
+}
+
+func TestNewProviderClient_InvalidConfig(t *testing.T) {
+	client, err := NewProviderClient("APNS", TransportConfig{ProxyURL: "proxy.internal:3128"}, time.Second)
+	assert.Nil(t, client)
+	assert.ErrorIs(t, err, ErrInvalidProxyURL)
+	assert.Contains(t, err.Error(), "invalid APNS proxy or TLS configuration")
+}
+
+func TestLoadTransportConfigFromEnv(t *testing.T) {
+	t.Setenv("TEST_HTTP_PROXY", "http://proxy.internal:3128")
+	t.Setenv("TEST_CA_BUNDLE", "/etc/ssl/corp-ca.pem")
+
+	config := LoadTransportConfigFromEnv(EnvKeys{
+		ProxyURL:       "TEST_HTTP_PROXY",
+		CABundlePath:   "TEST_CA_BUNDLE",
+		ClientCertPath: "TEST_TLS_CERT_FILE",
+		ClientKeyPath:  "TEST_TLS_KEY_FILE",
+	})
+	assert.Equal(t, TransportConfig{ProxyURL: "http://proxy.internal:3128", CABundlePath: "/etc/ssl/corp-ca.pem"}, config)
+}
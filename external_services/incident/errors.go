@@ -0,0 +1,11 @@
+package incident
+
+import "errors"
+
+// Incident service errors
+var (
+	ErrIncidentSendFailed = errors.New("failed to send incident event")
+	ErrIncidentAuthFailed = errors.New("incident provider authentication failed")
+	// ErrIncidentRateLimited is returned when the provider throttles events; they can be retried later
+	ErrIncidentRateLimited = errors.New("incident provider rate limit exceeded")
+)
@@ -0,0 +1,98 @@
+package incident
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gaurav2721/notification-service/constants"
+	"github.com/gaurav2721/notification-service/external_services/httpclient"
+	"github.com/sirupsen/logrus"
+)
+
+// Incident providers that can be chosen with INCIDENT_PROVIDER
+const (
+	ProviderPagerDuty = "pagerduty"
+	ProviderOpsgenie  = "opsgenie"
+)
+
+// defaultSource is the source of alerts whose notification does not name one
+const defaultSource = "notification-service"
+
+// maxErrorBodyBytes bounds the part of an error response read for its message
+const maxErrorBodyBytes = 4096
+
+// Provider returns the incident provider chosen with INCIDENT_PROVIDER, empty when none is
+func Provider() string {
+	return strings.ToLower(strings.TrimSpace(os.Getenv(constants.INCIDENT_PROVIDER)))
+}
+
+// Enabled reports whether an incident provider is chosen with INCIDENT_PROVIDER
+func Enabled() bool {
+	return Provider() != ""
+}
+
+// NewIncidentService creates a new incident service instance for the provider chosen with
+// INCIDENT_PROVIDER that connects through transport. It returns the mock service unless a
+// provider is chosen and configured, and an error for an invalid transport.
+func NewIncidentService(transport httpclient.TransportConfig) (IncidentService, error) {
+	provider := Provider()
+	if provider == "" {
+		return NewMockIncidentService(), nil
+	}
+	if provider != ProviderPagerDuty && provider != ProviderOpsgenie {
+		logrus.WithField("provider", provider).Error("Unknown incident provider, expected pagerduty or opsgenie, using mock incident service")
+		return NewMockIncidentService(), nil
+	}
+
+	client, err := httpclient.NewProviderClient("incident", transport, constants.DefaultIncidentTimeout*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	if provider == ProviderPagerDuty {
+		// Events are authorized by the integration key of each recipient
+		return &PagerDutyServiceImpl{client: client, eventsURL: defaultPagerDutyEventsURL}, nil
+	}
+
+	apiKey := os.Getenv(constants.OPSGENIE_API_KEY)
+	if apiKey == "" {
+		logrus.Error("OPSGENIE_API_KEY is not set, using mock incident service")
+		return NewMockIncidentService(), nil
+	}
+	apiURL := strings.TrimSuffix(os.Getenv(constants.OPSGENIE_API_URL), "/")
+	if apiURL == "" {
+		apiURL = defaultOpsgenieAPIURL
+	}
+	return &OpsgenieServiceImpl{client: client, apiURL: apiURL, apiKey: apiKey}, nil
+}
+
+// responseError describes a failed response of PagerDuty or Opsgenie. Both put a message in
+// the body, PagerDuty adds a list of errors.
+func responseError(resp *http.Response) error {
+	var body struct {
+		Message string   `json:"message"`
+		Errors  []string `json:"errors"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	message := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &body) == nil && body.Message != "" {
+		message = body.Message
+		if len(body.Errors) > 0 {
+			message += ": " + strings.Join(body.Errors, ", ")
+		}
+	}
+
+	cause := ErrIncidentSendFailed
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		cause = ErrIncidentAuthFailed
+	case http.StatusTooManyRequests:
+		cause = ErrIncidentRateLimited
+	}
+	return fmt.Errorf("%w: status %d: %s", cause, resp.StatusCode, message)
+}
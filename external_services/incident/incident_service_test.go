@@ -0,0 +1,154 @@
+package incident
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func triggerNotification() *models.IncidentNotificationRequest {
+	return &models.IncidentNotificationRequest{
+		ID:   "notif-1",
+		Type: "incident",
+		Content: models.IncidentContent{
+			Action:   models.IncidentActionTrigger,
+			Summary:  "Checkout error rate above 5%",
+			Severity: models.IncidentSeverityCritical,
+			Source:   "prometheus",
+			Details:  map[string]interface{}{"error_rate": 0.07, "region": "eu-west-1"},
+			Link:     "https://grafana.company.com/d/checkout",
+		},
+		Recipient: "R0UTINGKEY0123456789",
+		DedupeKey: "checkout:error-rate",
+	}
+}
+
+func TestPagerDutyTriggerAndResolve(t *testing.T) {
+	var events []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		events = append(events, event)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"status":"success","message":"Event processed","dedup_key":"checkout:error-rate"}`))
+	}))
+	defer server.Close()
+
+	service := &PagerDutyServiceImpl{client: server.Client(), eventsURL: server.URL}
+	notification := triggerNotification()
+	result, err := service.SendIncidentEvent(context.Background(), notification)
+	require.NoError(t, err)
+	response := result.(*models.IncidentResponse)
+	assert.Equal(t, ProviderPagerDuty, response.Provider)
+	assert.Equal(t, "checkout:error-rate", response.DedupeKey)
+
+	notification.Content = models.IncidentContent{Action: models.IncidentActionResolve}
+	_, err = service.SendIncidentEvent(context.Background(), notification)
+	require.NoError(t, err)
+
+	require.Len(t, events, 2)
+	assert.Equal(t, "R0UTINGKEY0123456789", events[0]["routing_key"])
+	assert.Equal(t, "trigger", events[0]["event_action"])
+	assert.Equal(t, "checkout:error-rate", events[0]["dedup_key"])
+	assert.Equal(t, map[string]interface{}{
+		"summary":        "Checkout error rate above 5%",
+		"source":         "prometheus",
+		"severity":       "critical",
+		"custom_details": map[string]interface{}{"error_rate": 0.07, "region": "eu-west-1"},
+	}, events[0]["payload"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"href": "https://grafana.company.com/d/checkout"}}, events[0]["links"])
+
+	assert.Equal(t, "resolve", events[1]["event_action"])
+	assert.Equal(t, "checkout:error-rate", events[1]["dedup_key"])
+	assert.NotContains(t, events[1], "payload", "resolve events only name the alert")
+}
+
+func TestPagerDutyErrors(t *testing.T) {
+	status := http.StatusBadRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`{"status":"invalid event","message":"Event object is invalid","errors":["Length of 'routing_key' is incorrect (should be 32 characters)"]}`))
+	}))
+	defer server.Close()
+
+	service := &PagerDutyServiceImpl{client: server.Client(), eventsURL: server.URL}
+	_, err := service.SendIncidentEvent(context.Background(), triggerNotification())
+	assert.True(t, errors.Is(err, ErrIncidentSendFailed), "got %v", err)
+	assert.Contains(t, err.Error(), "routing_key")
+
+	status = http.StatusTooManyRequests
+	_, err = service.SendIncidentEvent(context.Background(), triggerNotification())
+	assert.True(t, errors.Is(err, ErrIncidentRateLimited), "got %v", err)
+
+	_, err = service.SendIncidentEvent(context.Background(), "invalid")
+	assert.Equal(t, ErrIncidentSendFailed, err)
+}
+
+func TestOpsgenieTriggerAndResolve(t *testing.T) {
+	var created map[string]interface{}
+	var closedPath, closedQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GenieKey api-key", r.Header.Get("Authorization"))
+		switch {
+		case r.URL.Path == "/v2/alerts":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+		case strings.HasSuffix(r.URL.Path, "/close"):
+			closedPath = r.URL.EscapedPath()
+			closedQuery = r.URL.RawQuery
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"result":"Request will be processed","took":0.1,"requestId":"req-1"}`))
+	}))
+	defer server.Close()
+
+	service := &OpsgenieServiceImpl{client: server.Client(), apiURL: server.URL, apiKey: "api-key"}
+	notification := triggerNotification()
+	notification.Recipient = "platform-oncall"
+	notification.Content.Summary = strings.Repeat("x", 150)
+
+	result, err := service.SendIncidentEvent(context.Background(), notification)
+	require.NoError(t, err)
+	response := result.(*models.IncidentResponse)
+	assert.Equal(t, "req-1", response.RequestID)
+	assert.Equal(t, "platform-oncall:checkout:error-rate", response.DedupeKey)
+
+	assert.Equal(t, "platform-oncall:checkout:error-rate", created["alias"])
+	assert.Equal(t, "P1", created["priority"])
+	assert.Len(t, created["message"], maxOpsgenieMessageLength)
+	assert.Equal(t, strings.Repeat("x", 150)+"\n\nhttps://grafana.company.com/d/checkout", created["description"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "platform-oncall", "type": "team"}}, created["responders"])
+	assert.Equal(t, map[string]interface{}{"error_rate": "0.07", "region": "eu-west-1"}, created["details"])
+
+	notification.Content = models.IncidentContent{Action: models.IncidentActionResolve}
+	_, err = service.SendIncidentEvent(context.Background(), notification)
+	require.NoError(t, err)
+	assert.Equal(t, "/v2/alerts/platform-oncall:checkout:error-rate/close", closedPath)
+	assert.Equal(t, "identifierType=alias", closedQuery)
+}
+
+func TestOpsgenieAuthFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message":"Could not authenticate","took":0.0,"requestId":"req-2"}`))
+	}))
+	defer server.Close()
+
+	service := &OpsgenieServiceImpl{client: server.Client(), apiURL: server.URL, apiKey: "wrong"}
+	_, err := service.SendIncidentEvent(context.Background(), triggerNotification())
+	assert.True(t, errors.Is(err, ErrIncidentAuthFailed), "got %v", err)
+	assert.Contains(t, err.Error(), "Could not authenticate")
+
+	err = service.CheckConnection(context.Background())
+	assert.True(t, errors.Is(err, ErrIncidentAuthFailed), "got %v", err)
+}
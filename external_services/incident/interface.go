@@ -0,0 +1,8 @@
+package incident
+
+import "context"
+
+// IncidentService interface defines methods for incident notifications
+type IncidentService interface {
+	SendIncidentEvent(ctx context.Context, notification interface{}) (interface{}, error)
+}
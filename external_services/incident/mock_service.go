@@ -0,0 +1,86 @@
+package incident
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// MockIncidentServiceImpl implements the IncidentService interface for testing/mock purposes
+type MockIncidentServiceImpl struct {
+	outputPath string
+}
+
+// NewMockIncidentService creates a new mock incident service instance
+func NewMockIncidentService() IncidentService {
+	// Create output directory if it doesn't exist
+	outputDir := "output"
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		panic(fmt.Sprintf("failed to create output directory: %v", err))
+	}
+
+	return &MockIncidentServiceImpl{
+		outputPath: filepath.Join(outputDir, "incident.txt"),
+	}
+}
+
+// SendIncidentEvent writes incident notification to file instead of alerting a provider
+func (is *MockIncidentServiceImpl) SendIncidentEvent(ctx context.Context, notification interface{}) (interface{}, error) {
+	// Type assertion to get the notification
+	notif, ok := notification.(*models.IncidentNotificationRequest)
+	if !ok {
+		return nil, ErrIncidentSendFailed
+	}
+
+	// Validate the incident notification
+	if err := models.ValidateIncidentNotification(notif); err != nil {
+		return nil, fmt.Errorf("incident validation failed: %w", err)
+	}
+
+	// Create mock response
+	response := &models.IncidentResponse{
+		ID:        notif.ID,
+		Status:    "mock_sent",
+		Message:   "Incident notification written to file (mock mode)",
+		SentAt:    time.Now(),
+		Channel:   "incident",
+		DedupeKey: notif.DedupeKey,
+	}
+
+	// Prepare notification data for file output
+	notificationData := map[string]interface{}{
+		"timestamp":  time.Now().Format(time.RFC3339),
+		"id":         notif.ID,
+		"content":    notif.Content,
+		"recipient":  notif.Recipient,
+		"status":     "mock_sent",
+		"dedupe_key": notif.DedupeKey,
+		"channel":    "incident",
+	}
+
+	// Convert to JSON
+	jsonData, err := json.MarshalIndent(notificationData, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal notification data: %w", err)
+	}
+
+	// Write to file
+	file, err := os.OpenFile(is.outputPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output file: %w", err)
+	}
+	defer file.Close()
+
+	// Add separator and newline
+	output := fmt.Sprintf("=== INCIDENT NOTIFICATION ===\n%s\n\n", string(jsonData))
+	if _, err := file.WriteString(output); err != nil {
+		return nil, fmt.Errorf("failed to write to output file: %w", err)
+	}
+
+	return response, nil
+}
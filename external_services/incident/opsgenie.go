@@ -0,0 +1,196 @@
+package incident
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// defaultOpsgenieAPIURL is the Opsgenie API of the US region; accounts in the EU region set
+// OPSGENIE_API_URL to https://api.eu.opsgenie.com
+const defaultOpsgenieAPIURL = "https://api.opsgenie.com"
+
+// maxOpsgenieMessageLength is the longest alert message Opsgenie keeps; longer summaries are
+// cut and given in full in the description
+const maxOpsgenieMessageLength = 130
+
+// opsgeniePriorities maps incident severities to Opsgenie priorities
+var opsgeniePriorities = map[string]string{
+	models.IncidentSeverityCritical: "P1",
+	models.IncidentSeverityError:    "P2",
+	models.IncidentSeverityWarning:  "P3",
+	models.IncidentSeverityInfo:     "P5",
+}
+
+// OpsgenieServiceImpl implements the IncidentService interface with the Opsgenie Alert API.
+// The recipient of a notification is the team the alert is assigned to; the alert is known by
+// an alias of the team and the dedupe key, so teams do not share alerts.
+type OpsgenieServiceImpl struct {
+	client *http.Client
+	apiURL string
+	apiKey string
+}
+
+// opsgenieAlert is the body of a create alert request
+type opsgenieAlert struct {
+	Message     string              `json:"message"`
+	Alias       string              `json:"alias"`
+	Description string              `json:"description,omitempty"`
+	Responders  []opsgenieResponder `json:"responders"`
+	Details     map[string]string   `json:"details,omitempty"`
+	Source      string              `json:"source"`
+	Priority    string              `json:"priority"`
+}
+
+type opsgenieResponder struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// SendIncidentEvent creates, acknowledges or closes the Opsgenie alert of an incident notification
+func (og *OpsgenieServiceImpl) SendIncidentEvent(ctx context.Context, notification interface{}) (interface{}, error) {
+	// Type assertion to get the notification
+	notif, ok := notification.(*models.IncidentNotificationRequest)
+	if !ok {
+		return nil, ErrIncidentSendFailed
+	}
+
+	// Validate the incident notification
+	if err := models.ValidateIncidentNotification(notif); err != nil {
+		return nil, fmt.Errorf("incident validation failed: %w", err)
+	}
+
+	alias := opsgenieAlias(notif)
+	var endpoint string
+	var payload interface{}
+	switch notif.Content.Action {
+	case models.IncidentActionTrigger:
+		endpoint = og.apiURL + "/v2/alerts"
+		payload = buildOpsgenieAlert(notif, alias)
+	case models.IncidentActionAcknowledge:
+		endpoint = og.apiURL + "/v2/alerts/" + url.PathEscape(alias) + "/acknowledge?identifierType=alias"
+		payload = map[string]string{"source": defaultSource}
+	case models.IncidentActionResolve:
+		endpoint = og.apiURL + "/v2/alerts/" + url.PathEscape(alias) + "/close?identifierType=alias"
+		payload = map[string]string{"source": defaultSource}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIncidentSendFailed, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIncidentSendFailed, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+og.apiKey)
+
+	resp, err := og.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIncidentSendFailed, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, responseError(resp)
+	}
+
+	// Requests are processed asynchronously; the request ID looks up their outcome
+	var result struct {
+		RequestID string `json:"requestId"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("%w: invalid response: %v", ErrIncidentSendFailed, err)
+	}
+
+	// Return success response
+	return &models.IncidentResponse{
+		ID:        notif.ID,
+		Status:    "sent",
+		Message:   "Opsgenie alert " + notif.Content.Action + " accepted",
+		SentAt:    time.Now(),
+		Channel:   "incident",
+		Provider:  ProviderOpsgenie,
+		DedupeKey: alias,
+		RequestID: result.RequestID,
+	}, nil
+}
+
+// CheckConnection gets the Opsgenie account of the API key without creating an alert
+func (og *OpsgenieServiceImpl) CheckConnection(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, og.apiURL+"/v2/account", nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrIncidentSendFailed, err)
+	}
+	req.Header.Set("Authorization", "GenieKey "+og.apiKey)
+
+	resp, err := og.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrIncidentSendFailed, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// opsgenieAlias returns the alias of the alert of a notification
+func opsgenieAlias(notif *models.IncidentNotificationRequest) string {
+	return notif.Recipient + ":" + notif.DedupeKey
+}
+
+// buildOpsgenieAlert converts a triggering notification into an alert for the recipient team.
+// Opsgenie only keeps string details, so other values are encoded as JSON.
+func buildOpsgenieAlert(notif *models.IncidentNotificationRequest, alias string) *opsgenieAlert {
+	source := notif.Content.Source
+	if source == "" {
+		source = defaultSource
+	}
+	alert := &opsgenieAlert{
+		Message:    truncate(notif.Content.Summary, maxOpsgenieMessageLength),
+		Alias:      alias,
+		Responders: []opsgenieResponder{{Name: notif.Recipient, Type: "team"}},
+		Source:     source,
+		Priority:   opsgeniePriorities[notif.Content.Severity],
+	}
+	if alert.Message != notif.Content.Summary {
+		alert.Description = notif.Content.Summary
+	}
+	if notif.Content.Link != "" {
+		if alert.Description != "" {
+			alert.Description += "\n\n"
+		}
+		alert.Description += notif.Content.Link
+	}
+
+	if len(notif.Content.Details) > 0 {
+		alert.Details = make(map[string]string, len(notif.Content.Details))
+		for key, value := range notif.Content.Details {
+			switch value := value.(type) {
+			case string:
+				alert.Details[key] = value
+			default:
+				encoded, _ := json.Marshal(value)
+				alert.Details[key] = string(encoded)
+			}
+		}
+	}
+	return alert
+}
+
+// truncate cuts s to at most max characters
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:max])
+}
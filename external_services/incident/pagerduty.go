@@ -0,0 +1,131 @@
+package incident
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// defaultPagerDutyEventsURL is the PagerDuty Events API v2 endpoint
+const defaultPagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDutyServiceImpl implements the IncidentService interface with the PagerDuty Events API
+// v2. The recipient of a notification is the integration key of the service it alerts, and
+// the dedupe key becomes the dedup_key of the alert.
+type PagerDutyServiceImpl struct {
+	client    *http.Client
+	eventsURL string
+}
+
+// pagerDutyEvent is an event of the Events API v2
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+	Links       []pagerDutyLink   `json:"links,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+type pagerDutyLink struct {
+	Href string `json:"href"`
+	Text string `json:"text,omitempty"`
+}
+
+// SendIncidentEvent sends the action of an incident notification to PagerDuty
+func (ps *PagerDutyServiceImpl) SendIncidentEvent(ctx context.Context, notification interface{}) (interface{}, error) {
+	// Type assertion to get the notification
+	notif, ok := notification.(*models.IncidentNotificationRequest)
+	if !ok {
+		return nil, ErrIncidentSendFailed
+	}
+
+	// Validate the incident notification
+	if err := models.ValidateIncidentNotification(notif); err != nil {
+		return nil, fmt.Errorf("incident validation failed: %w", err)
+	}
+
+	body, err := json.Marshal(buildPagerDutyEvent(notif))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIncidentSendFailed, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ps.eventsURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIncidentSendFailed, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := ps.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIncidentSendFailed, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, responseError(resp)
+	}
+
+	var result struct {
+		Status   string `json:"status"`
+		Message  string `json:"message"`
+		DedupKey string `json:"dedup_key"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("%w: invalid response: %v", ErrIncidentSendFailed, err)
+	}
+
+	// Return success response
+	return &models.IncidentResponse{
+		ID:        notif.ID,
+		Status:    "sent",
+		Message:   "PagerDuty event " + notif.Content.Action + " accepted",
+		SentAt:    time.Now(),
+		Channel:   "incident",
+		Provider:  ProviderPagerDuty,
+		DedupeKey: result.DedupKey,
+	}, nil
+}
+
+// CheckConnection passes without sending an event. The Events API has no way to check an
+// integration key other than triggering an alert with it.
+func (ps *PagerDutyServiceImpl) CheckConnection(ctx context.Context) error {
+	return nil
+}
+
+// buildPagerDutyEvent converts a notification into an Events API v2 event. Only triggers carry
+// a payload; acknowledge and resolve events just name the alert.
+func buildPagerDutyEvent(notif *models.IncidentNotificationRequest) *pagerDutyEvent {
+	event := &pagerDutyEvent{
+		RoutingKey:  notif.Recipient,
+		EventAction: notif.Content.Action,
+		DedupKey:    notif.DedupeKey,
+	}
+	if notif.Content.Action != models.IncidentActionTrigger {
+		return event
+	}
+
+	source := notif.Content.Source
+	if source == "" {
+		source = defaultSource
+	}
+	event.Payload = &pagerDutyPayload{
+		Summary:       notif.Content.Summary,
+		Source:        source,
+		Severity:      notif.Content.Severity,
+		CustomDetails: notif.Content.Details,
+	}
+	if notif.Content.Link != "" {
+		event.Links = []pagerDutyLink{{Href: notif.Content.Link}}
+	}
+	return event
+}
@@ -4,7 +4,40 @@ package kafka
 type KafkaService interface {
 	GetEmailChannel() chan string
 	GetSlackChannel() chan string
+	GetGoogleChatChannel() chan string
+	GetIncidentChannel() chan string
+	GetRCSChannel() chan string
 	GetIOSPushNotificationChannel() chan string
 	GetAndroidPushNotificationChannel() chan string
+	GetNotificationEventsChannel() chan string
 	Close()
 }
+
+// QueueStats describes the fill level of a notification channel
+type QueueStats struct {
+	Name     string `json:"name"`
+	Depth    int    `json:"depth"`
+	Capacity int    `json:"capacity"`
+}
+
+// GetQueueStats returns the number of buffered messages and the capacity of every channel
+func GetQueueStats(k KafkaService) []QueueStats {
+	channels := []struct {
+		name    string
+		channel chan string
+	}{
+		{"email", k.GetEmailChannel()},
+		{"slack", k.GetSlackChannel()},
+		{"google_chat", k.GetGoogleChatChannel()},
+		{"incident", k.GetIncidentChannel()},
+		{"rcs", k.GetRCSChannel()},
+		{"ios_push", k.GetIOSPushNotificationChannel()},
+		{"android_push", k.GetAndroidPushNotificationChannel()},
+	}
+
+	stats := make([]QueueStats, 0, len(channels))
+	for _, c := range channels {
+		stats = append(stats, QueueStats{Name: c.name, Depth: len(c.channel), Capacity: cap(c.channel)})
+	}
+	return stats
+}
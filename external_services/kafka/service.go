@@ -13,8 +13,12 @@ import (
 type kafkaServiceImpl struct {
 	emailChannel                   chan string
 	slackChannel                   chan string
+	googleChatChannel              chan string
+	incidentChannel                chan string
+	rcsChannel                     chan string
 	iosPushNotificationChannel     chan string
 	androidPushNotificationChannel chan string
+	notificationEventsChannel      chan string
 	mu                             sync.RWMutex
 	closed                         bool
 }
@@ -26,21 +30,33 @@ func NewKafkaService() (KafkaService, error) {
 	// Read buffer sizes from environment variables with defaults
 	emailBufferSize := getEnvAsInt(constants.EmailChannelBufferSizeEnvVar, constants.DefaultEmailChannelBufferSize)
 	slackBufferSize := getEnvAsInt(constants.SlackChannelBufferSizeEnvVar, constants.DefaultSlackChannelBufferSize)
+	googleChatBufferSize := getEnvAsInt(constants.GoogleChatChannelBufferSizeEnvVar, constants.DefaultGoogleChatChannelBufferSize)
+	incidentBufferSize := getEnvAsInt(constants.IncidentChannelBufferSizeEnvVar, constants.DefaultIncidentChannelBufferSize)
+	rcsBufferSize := getEnvAsInt(constants.RCSChannelBufferSizeEnvVar, constants.DefaultRCSChannelBufferSize)
 	iosPushBufferSize := getEnvAsInt(constants.IOSPushChannelBufferSizeEnvVar, constants.DefaultIOSPushChannelBufferSize)
 	androidPushBufferSize := getEnvAsInt(constants.AndroidPushChannelBufferSizeEnvVar, constants.DefaultAndroidPushChannelBufferSize)
+	eventsBufferSize := getEnvAsInt(constants.NotificationEventsBufferSizeEnvVar, constants.DefaultNotificationEventsBufferSize)
 
 	logrus.WithFields(logrus.Fields{
-		"email_buffer_size":   emailBufferSize,
-		"slack_buffer_size":   slackBufferSize,
-		"ios_buffer_size":     iosPushBufferSize,
-		"android_buffer_size": androidPushBufferSize,
+		"email_buffer_size":       emailBufferSize,
+		"slack_buffer_size":       slackBufferSize,
+		"google_chat_buffer_size": googleChatBufferSize,
+		"incident_buffer_size":    incidentBufferSize,
+		"rcs_buffer_size":         rcsBufferSize,
+		"ios_buffer_size":         iosPushBufferSize,
+		"android_buffer_size":     androidPushBufferSize,
+		"events_buffer_size":      eventsBufferSize,
 	}).Debug("Kafka service buffer sizes configured")
 
 	service := &kafkaServiceImpl{
 		emailChannel:                   make(chan string, emailBufferSize),
 		slackChannel:                   make(chan string, slackBufferSize),
+		googleChatChannel:              make(chan string, googleChatBufferSize),
+		incidentChannel:                make(chan string, incidentBufferSize),
+		rcsChannel:                     make(chan string, rcsBufferSize),
 		iosPushNotificationChannel:     make(chan string, iosPushBufferSize),
 		androidPushNotificationChannel: make(chan string, androidPushBufferSize),
+		notificationEventsChannel:      make(chan string, eventsBufferSize),
 		closed:                         false,
 	}
 
@@ -62,6 +78,27 @@ func (k *kafkaServiceImpl) GetSlackChannel() chan string {
 	return k.slackChannel
 }
 
+// GetGoogleChatChannel returns the Google Chat notification channel
+func (k *kafkaServiceImpl) GetGoogleChatChannel() chan string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.googleChatChannel
+}
+
+// GetIncidentChannel returns the incident notification channel
+func (k *kafkaServiceImpl) GetIncidentChannel() chan string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.incidentChannel
+}
+
+// GetRCSChannel returns the RCS notification channel
+func (k *kafkaServiceImpl) GetRCSChannel() chan string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.rcsChannel
+}
+
 // GetIOSPushNotificationChannel returns the iOS push notification channel
 func (k *kafkaServiceImpl) GetIOSPushNotificationChannel() chan string {
 	k.mu.RLock()
@@ -76,6 +113,13 @@ func (k *kafkaServiceImpl) GetAndroidPushNotificationChannel() chan string {
 	return k.androidPushNotificationChannel
 }
 
+// GetNotificationEventsChannel returns the channel of the notification-events topic
+func (k *kafkaServiceImpl) GetNotificationEventsChannel() chan string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.notificationEventsChannel
+}
+
 // Close closes all channels and marks the service as closed
 func (k *kafkaServiceImpl) Close() {
 	k.mu.Lock()
@@ -92,8 +136,12 @@ func (k *kafkaServiceImpl) Close() {
 	// Close all channels
 	close(k.emailChannel)
 	close(k.slackChannel)
+	close(k.googleChatChannel)
+	close(k.incidentChannel)
+	close(k.rcsChannel)
 	close(k.iosPushNotificationChannel)
 	close(k.androidPushNotificationChannel)
+	close(k.notificationEventsChannel)
 
 	logrus.Debug("Kafka service closed successfully")
 }
@@ -163,3 +163,41 @@ func TestGetEnvAsInt(t *testing.T) {
 		t.Errorf("Expected 100, got %d", result)
 	}
 }
+
+func TestGetQueueStats(t *testing.T) {
+	service, err := NewKafkaService()
+	if err != nil {
+		t.Fatalf("Failed to create KafkaService: %v", err)
+	}
+	defer service.Close()
+
+	service.GetEmailChannel() <- "first"
+	service.GetEmailChannel() <- "second"
+	service.GetSlackChannel() <- "only"
+
+	stats := GetQueueStats(service)
+	if len(stats) != 7 {
+		t.Fatalf("Expected 7 queues, got %d", len(stats))
+	}
+
+	capacities := map[string]int{
+		"email":        cap(service.GetEmailChannel()),
+		"slack":        cap(service.GetSlackChannel()),
+		"google_chat":  cap(service.GetGoogleChatChannel()),
+		"incident":     cap(service.GetIncidentChannel()),
+		"rcs":          cap(service.GetRCSChannel()),
+		"ios_push":     cap(service.GetIOSPushNotificationChannel()),
+		"android_push": cap(service.GetAndroidPushNotificationChannel()),
+	}
+	depths := map[string]int{}
+	for _, queue := range stats {
+		depths[queue.Name] = queue.Depth
+		if queue.Capacity != capacities[queue.Name] {
+			t.Errorf("Expected capacity %d for %s, got %d", capacities[queue.Name], queue.Name, queue.Capacity)
+		}
+	}
+
+	if depths["email"] != 2 || depths["slack"] != 1 || depths["ios_push"] != 0 || depths["android_push"] != 0 {
+		t.Errorf("Unexpected queue depths: %v", depths)
+	}
+}
@@ -0,0 +1,177 @@
+// Package ldap reads directories such as Active Directory and OpenLDAP through
+// github.com/go-ldap/ldap/v3: simple binds and paged searches over ldap://, ldap:// upgraded with
+// StartTLS, or ldaps://
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+// Search scopes
+const (
+	ScopeBaseObject   = goldap.ScopeBaseObject
+	ScopeSingleLevel  = goldap.ScopeSingleLevel
+	ScopeWholeSubtree = goldap.ScopeWholeSubtree
+)
+
+// Conn is a connection to an LDAP server
+type Conn struct {
+	conn *goldap.Conn
+}
+
+// DialOptions secures a connection
+type DialOptions struct {
+	// StartTLS upgrades ldap:// connections to TLS before binding
+	StartTLS bool
+}
+
+// Dial connects to the server of an ldap:// or ldaps:// URL. The default ports are 389 and 636;
+// timeout bounds connecting and every operation.
+func Dial(rawURL string, timeout time.Duration, options DialOptions) (*Conn, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Hostname() == "" {
+		return nil, ErrInvalidURL
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "ldap" && scheme != "ldaps" {
+		return nil, ErrInvalidURL
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName: u.Hostname(),
+		MinVersion: tls.VersionTLS12,
+	}
+	conn, err := goldap.DialURL(u.String(),
+		goldap.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		goldap.DialWithTLSConfig(tlsConfig),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: connect to %s: %w", u.Host, err)
+	}
+	conn.SetTimeout(timeout)
+
+	if options.StartTLS && scheme == "ldap" {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("ldap: start TLS with %s: %w", u.Host, err)
+		}
+	}
+	return &Conn{conn: conn}, nil
+}
+
+// NewConn uses an established connection to an LDAP server
+func NewConn(conn net.Conn, timeout time.Duration) *Conn {
+	ldapConn := goldap.NewConn(conn, false)
+	ldapConn.SetTimeout(timeout)
+	ldapConn.Start()
+	return &Conn{conn: ldapConn}
+}
+
+// Close unbinds and closes the connection
+func (c *Conn) Close() error {
+	c.conn.Unbind()
+	return c.conn.Close()
+}
+
+// Bind authenticates with a DN and password. An empty DN binds anonymously; an empty password
+// is rejected for other DNs, as servers treat it as an unauthenticated bind that always succeeds.
+func (c *Conn) Bind(ctx context.Context, dn, password string) error {
+	if dn == "" {
+		return nil
+	}
+	defer c.abortOnDone(ctx)()
+	return c.conn.Bind(dn, password)
+}
+
+// SearchRequest selects the entries and attributes of a search
+type SearchRequest struct {
+	BaseDN string
+	Scope  int
+	// Filter is the string representation of the filter (RFC 4515), e.g. (objectClass=person)
+	Filter     string
+	Attributes []string
+	// PageSize requests the results in pages of this many entries; 0 requests them at once
+	PageSize int
+}
+
+// Entry is an entry returned by a search
+type Entry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// Values returns the values of an attribute; attribute names are case-insensitive
+func (e *Entry) Values(name string) []string {
+	if values, ok := e.Attributes[name]; ok {
+		return values
+	}
+	for attribute, values := range e.Attributes {
+		if strings.EqualFold(attribute, name) {
+			return values
+		}
+	}
+	return nil
+}
+
+// Value returns the first value of an attribute, empty when the entry has none
+func (e *Entry) Value(name string) string {
+	if values := e.Values(name); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Search returns the entries matching a search request. Continuation references to other
+// servers are not followed.
+func (c *Conn) Search(ctx context.Context, request SearchRequest) ([]Entry, error) {
+	if _, err := goldap.CompileFilter(request.Filter); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFilter, err)
+	}
+	defer c.abortOnDone(ctx)()
+
+	search := goldap.NewSearchRequest(request.BaseDN, request.Scope, goldap.NeverDerefAliases, 0, 0, false,
+		request.Filter, request.Attributes, nil)
+	var result *goldap.SearchResult
+	var err error
+	if request.PageSize > 0 {
+		result, err = c.conn.SearchWithPaging(search, uint32(request.PageSize))
+	} else {
+		result, err = c.conn.Search(search)
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(result.Entries))
+	for _, found := range result.Entries {
+		entry := Entry{DN: found.DN, Attributes: make(map[string][]string, len(found.Attributes))}
+		for _, attribute := range found.Attributes {
+			entry.Attributes[attribute.Name] = append(entry.Attributes[attribute.Name], attribute.Values...)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// EscapeFilter escapes a value for use in a filter (RFC 4515), e.g. a DN containing parentheses
+func EscapeFilter(value string) string {
+	return goldap.EscapeFilter(value)
+}
+
+// abortOnDone closes the connection when ctx is done before the returned function is called,
+// which fails the running operation
+func (c *Conn) abortOnDone(ctx context.Context) func() {
+	stop := context.AfterFunc(ctx, func() { c.conn.Close() })
+	return func() { stop() }
+}
@@ -0,0 +1,206 @@
+package ldap
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer answers binds for cn=admin/secret and serves entries in pages of two
+type fakeServer struct {
+	conn    net.Conn
+	entries []Entry
+}
+
+func newFakeServer(t *testing.T, entries []Entry) *Conn {
+	t.Helper()
+	client, server := net.Pipe()
+	fake := &fakeServer{conn: server, entries: entries}
+	go fake.serve()
+	t.Cleanup(func() { server.Close() })
+	return NewConn(client, time.Second)
+}
+
+func (s *fakeServer) serve() {
+	for {
+		message, err := ber.ReadPacket(s.conn)
+		if err != nil {
+			return
+		}
+		id := message.Children[0].Value.(int64)
+		op := message.Children[1]
+		switch op.Tag {
+		case goldap.ApplicationBindRequest:
+			code := goldap.LDAPResultSuccess
+			if op.Children[1].Data.String() != "cn=admin" || op.Children[2].Data.String() != "secret" {
+				code = goldap.LDAPResultInvalidCredentials
+			}
+			s.reply(id, result(goldap.ApplicationBindResponse, code, ""))
+		case goldap.ApplicationSearchRequest:
+			s.search(id, op, message)
+		case goldap.ApplicationUnbindRequest:
+			s.conn.Close()
+			return
+		}
+	}
+}
+
+func (s *fakeServer) search(id int64, op, message *ber.Packet) {
+	if op.Children[0].Data.String() == "ou=missing" {
+		s.reply(id, result(goldap.ApplicationSearchResultDone, goldap.LDAPResultNoSuchObject, "no such base"))
+		return
+	}
+
+	// The paged results control carries the index of the next entry as its cookie
+	var paging *goldap.ControlPaging
+	if len(message.Children) > 2 {
+		control, _ := goldap.DecodeControl(message.Children[2].Children[0])
+		paging, _ = control.(*goldap.ControlPaging)
+	}
+	start := 0
+	if paging != nil && len(paging.Cookie) > 0 {
+		start = int(paging.Cookie[0] - '0')
+	}
+	end := len(s.entries)
+	if paging != nil && start+2 < end {
+		end = start + 2
+	}
+
+	for _, entry := range s.entries[start:end] {
+		packet := ber.Encode(ber.ClassApplication, ber.TypeConstructed, goldap.ApplicationSearchResultEntry, nil, "")
+		packet.AppendChild(octetString(entry.DN))
+		attributes := ber.NewSequence("")
+		for name, values := range entry.Attributes {
+			attribute := ber.NewSequence("")
+			attribute.AppendChild(octetString(name))
+			set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+			for _, value := range values {
+				set.AppendChild(octetString(value))
+			}
+			attribute.AppendChild(set)
+			attributes.AppendChild(attribute)
+		}
+		packet.AppendChild(attributes)
+		s.reply(id, packet)
+	}
+
+	var controls []*ber.Packet
+	if paging != nil {
+		response := goldap.NewControlPaging(0)
+		if end < len(s.entries) {
+			response.SetCookie([]byte{byte('0' + end)})
+		}
+		controls = append(controls, response.Encode())
+	}
+	s.reply(id, result(goldap.ApplicationSearchResultDone, goldap.LDAPResultSuccess, ""), controls...)
+}
+
+func (s *fakeServer) reply(id int64, op *ber.Packet, controls ...*ber.Packet) {
+	message := ber.NewSequence("")
+	message.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+	message.AppendChild(op)
+	if len(controls) > 0 {
+		packet := ber.Encode(ber.ClassContext, ber.TypeConstructed, 0, nil, "")
+		for _, control := range controls {
+			packet.AppendChild(control)
+		}
+		message.AppendChild(packet)
+	}
+	s.conn.Write(message.Bytes())
+}
+
+func result(op ber.Tag, code int, message string) *ber.Packet {
+	packet := ber.Encode(ber.ClassApplication, ber.TypeConstructed, op, nil, "")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), ""))
+	packet.AppendChild(octetString(""))
+	packet.AppendChild(octetString(message))
+	return packet
+}
+
+func octetString(value string) *ber.Packet {
+	return ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "")
+}
+
+func testEntries() []Entry {
+	entries := make([]Entry, 5)
+	for i := range entries {
+		name := string(rune('a' + i))
+		entries[i] = Entry{
+			DN:         "uid=" + name + ",ou=people,dc=company,dc=com",
+			Attributes: map[string][]string{"mail": {name + "@company.com"}, "memberOf": {"cn=staff", "cn=ops"}},
+		}
+	}
+	return entries
+}
+
+func TestConn_Bind(t *testing.T) {
+	conn := newFakeServer(t, nil)
+	ctx := context.Background()
+
+	err := conn.Bind(ctx, "cn=admin", "wrong")
+	assert.True(t, goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials))
+
+	// An empty password is never sent, as it would bind unauthenticated
+	err = conn.Bind(ctx, "cn=admin", "")
+	assert.True(t, goldap.IsErrorWithCode(err, goldap.ErrorEmptyPassword))
+
+	require.NoError(t, conn.Bind(ctx, "cn=admin", "secret"))
+	require.NoError(t, conn.Close())
+}
+
+func TestConn_Search_Paged(t *testing.T) {
+	conn := newFakeServer(t, testEntries())
+	entries, err := conn.Search(context.Background(), SearchRequest{
+		BaseDN:     "ou=people,dc=company,dc=com",
+		Scope:      ScopeWholeSubtree,
+		Filter:     "(mail=*)",
+		Attributes: []string{"mail", "memberOf"},
+		PageSize:   2,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 5)
+	assert.Equal(t, "uid=a,ou=people,dc=company,dc=com", entries[0].DN)
+	assert.Equal(t, "e@company.com", entries[4].Value("MAIL"))
+	assert.Equal(t, []string{"cn=staff", "cn=ops"}, entries[4].Values("memberof"))
+	assert.Empty(t, entries[4].Value("mobile"))
+}
+
+func TestConn_Search_Unpaged(t *testing.T) {
+	conn := newFakeServer(t, testEntries())
+	entries, err := conn.Search(context.Background(), SearchRequest{BaseDN: "dc=company,dc=com", Filter: "(mail=*)"})
+	require.NoError(t, err)
+	assert.Len(t, entries, 5)
+}
+
+func TestConn_Search_Errors(t *testing.T) {
+	conn := newFakeServer(t, testEntries())
+	_, err := conn.Search(context.Background(), SearchRequest{BaseDN: "ou=missing", Filter: "(mail=*)"})
+	assert.True(t, goldap.IsErrorWithCode(err, goldap.LDAPResultNoSuchObject))
+	assert.Contains(t, err.Error(), "no such base")
+
+	_, err = conn.Search(context.Background(), SearchRequest{BaseDN: "dc=company,dc=com", Filter: "(mail=*"})
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = conn.Search(ctx, SearchRequest{BaseDN: "dc=company,dc=com", Filter: "(mail=*)"})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestEscapeFilter(t *testing.T) {
+	assert.Equal(t, `CN=Sales \28EU\29\2a,DC=company,DC=com`, EscapeFilter("CN=Sales (EU)*,DC=company,DC=com"))
+}
+
+func TestDial_InvalidURL(t *testing.T) {
+	for _, url := range []string{"", "http://ldap.company.com", "ldap://", "ldap.company.com:389"} {
+		_, err := Dial(url, time.Second, DialOptions{})
+		assert.True(t, errors.Is(err, ErrInvalidURL), "url %q", url)
+	}
+}
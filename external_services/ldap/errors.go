@@ -0,0 +1,10 @@
+package ldap
+
+import "errors"
+
+// LDAP client errors. Operations the server answers with a result code other than success
+// return a *ldap.Error of github.com/go-ldap/ldap/v3 carrying the code.
+var (
+	ErrInvalidURL    = errors.New("invalid LDAP URL, expected ldap://host[:port] or ldaps://host[:port]")
+	ErrInvalidFilter = errors.New("invalid LDAP search filter")
+)
@@ -0,0 +1,352 @@
+// Package messagebus carries notification messages from the notification manager to the
+// channel consumers. Besides the in-process channels of the kafka package, messages can be
+// routed through NATS JetStream, RabbitMQ, Amazon SQS or Kafka so that they survive restarts and
+// are shared between service instances.
+package messagebus
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gaurav2721/notification-service/external_services/kafka"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/metrics"
+)
+
+// Topics of the notification channels
+const (
+	TopicEmail       = "email"
+	TopicSlack       = "slack"
+	TopicGoogleChat  = "google_chat"
+	TopicIncident    = "incident"
+	TopicRCS         = "rcs"
+	TopicIOSPush     = "ios_push"
+	TopicAndroidPush = "android_push"
+)
+
+// TopicNotificationEvents carries notification lifecycle events for analytics pipelines. The
+// service only publishes to it; it is consumed outside the service.
+const TopicNotificationEvents = "notification-events"
+
+// Topics lists every notification channel topic
+var Topics = []string{TopicEmail, TopicSlack, TopicGoogleChat, TopicIncident, TopicRCS, TopicIOSPush, TopicAndroidPush}
+
+// PublishTopics lists every topic the service publishes to
+var PublishTopics = []string{TopicEmail, TopicSlack, TopicGoogleChat, TopicIncident, TopicRCS, TopicIOSPush, TopicAndroidPush, TopicNotificationEvents}
+
+const (
+	// publishAttempts is how often a message is offered to the broker before it is dropped
+	publishAttempts = 3
+
+	// publishRetryDelay is the delay before the first retry, doubled for every further attempt
+	publishRetryDelay = 100 * time.Millisecond
+)
+
+// moduleLog carries broker connection events and publish failures
+var moduleLog = logger.Module("messagebus")
+
+// Outcome label values of the settled messages metric
+const (
+	outcomeAcked  = "acked"
+	outcomeNacked = "nacked"
+)
+
+var busMessagesSettledTotal = metrics.DefaultRegistry.NewCounterVec(
+	"message_bus_messages_settled_total",
+	"Broker messages settled after processing by topic and outcome; nacked messages are redelivered.",
+	"topic", "outcome",
+)
+
+// MessageBus is a kafka.KafkaService whose consumers read broker deliveries instead of the
+// channels messages are published to. The Get*Channel methods return the channels the
+// notification manager publishes to; Deliveries returns the channel the consumers of a topic
+// read from.
+type MessageBus interface {
+	kafka.KafkaService
+
+	// Deliveries returns the channel the consumers of a topic read from. Every delivery stays on
+	// the broker until it is settled.
+	Deliveries(topic string) chan Delivery
+
+	// Healthy returns why messages are not received from the broker, or nil while they are
+	Healthy() error
+}
+
+// Delivery is a message received from a broker together with the handle that settles it, so
+// consumers settle exactly the delivery they processed
+type Delivery struct {
+	Topic   string
+	Message Message
+}
+
+// Payload returns the message content
+func (d Delivery) Payload() string {
+	return string(d.Message.Payload())
+}
+
+// Settle acknowledges a processed message, or returns it to the broker when processing failed.
+// Failed messages are redelivered and moved to the dead-letter queue once they were delivered
+// too often.
+func (d Delivery) Settle(err error) {
+	if err == nil {
+		err = d.Message.Ack()
+		busMessagesSettledTotal.Inc(d.Topic, outcomeAcked)
+	} else {
+		err = d.Message.Nack()
+		busMessagesSettledTotal.Inc(d.Topic, outcomeNacked)
+	}
+	if err != nil {
+		moduleLog.Warn("Failed to settle message, the broker redelivers it", logger.Fields{
+			"topic": d.Topic,
+			"error": err.Error(),
+		})
+	}
+}
+
+// Message is a message received from a broker. It stays on the broker until it is acknowledged.
+type Message interface {
+	// Payload returns the message content
+	Payload() []byte
+
+	// Ack removes the message from the broker
+	Ack() error
+
+	// Nack returns the message to the broker for redelivery. Messages delivered too often are
+	// moved to the topic's dead-letter queue instead.
+	Nack() error
+}
+
+// Transport moves messages through an external broker
+type Transport interface {
+	// Publish stores a message on the broker, returning once the broker has accepted it
+	Publish(topic string, payload []byte) error
+
+	// Subscribe delivers the messages of a topic to handle, one at a time. Accepted messages are
+	// settled later through Ack or Nack; a rejected message is returned to the broker right away
+	// and ends the subscription.
+	Subscribe(topic string, handle func(message Message) bool) error
+
+	// Close disconnects from the broker once running handlers have returned. Messages that were
+	// not acknowledged are redelivered by the broker.
+	Close() error
+
+	// Healthy returns why messages are not consumed, e.g. while the connection to the broker is
+	// re-established, or nil while every subscription receives messages
+	Healthy() error
+}
+
+// Subscriber consumes topics for a transport that only publishes itself. The Kafka transport
+// hands its subscriptions to the consumer groups of the consumers package.
+type Subscriber interface {
+	// Subscribe delivers the messages of a topic to handle, like Transport.Subscribe
+	Subscribe(topic string, handle func(message Message) bool) error
+
+	// Close stops consuming once running handlers have returned
+	Close() error
+
+	// Healthy returns why messages are not consumed, or nil while every subscription receives
+	// messages
+	Healthy() error
+}
+
+// consumerHealth records why a transport does not consume messages, for Healthy. Failures are
+// recorded by topic, or for the whole connection under an empty topic.
+type consumerHealth struct {
+	mu       sync.Mutex
+	failures map[string]error
+}
+
+// fail records why consuming a topic stopped
+func (h *consumerHealth) fail(topic string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.failures == nil {
+		h.failures = make(map[string]error)
+	}
+	h.failures[topic] = err
+}
+
+// reset clears the failure of a topic once consuming it resumed
+func (h *consumerHealth) reset(topic string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.failures, topic)
+}
+
+// resetAll clears every failure, e.g. once a new connection was established
+func (h *consumerHealth) resetAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures = nil
+}
+
+// Healthy returns why consuming stopped, or nil
+func (h *consumerHealth) Healthy() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err, ok := h.failures[""]; ok {
+		return err
+	}
+	topics := make([]string, 0, len(h.failures))
+	for topic := range h.failures {
+		topics = append(topics, topic)
+	}
+	if len(topics) == 0 {
+		return nil
+	}
+	sort.Strings(topics)
+	return h.failures[topics[0]]
+}
+
+// ConsumerChannel returns the in-process channel the consumers of a topic read from, or nil for
+// a MessageBus, whose consumers read Deliveries instead
+func ConsumerChannel(bus kafka.KafkaService, topic string) chan string {
+	if _, ok := bus.(MessageBus); ok {
+		return nil
+	}
+	return producerChannel(bus, topic)
+}
+
+// Deliveries returns the channel of broker deliveries the consumers of a topic read from, or nil
+// for buses that do not implement MessageBus and forget messages once they are read
+func Deliveries(bus kafka.KafkaService, topic string) chan Delivery {
+	if messageBus, ok := bus.(MessageBus); ok {
+		return messageBus.Deliveries(topic)
+	}
+	return nil
+}
+
+// CheckHealth returns why the consumers of a bus do not receive messages. Buses that do not
+// implement MessageBus are read from in-process channels and are always healthy.
+func CheckHealth(bus kafka.KafkaService) error {
+	if messageBus, ok := bus.(MessageBus); ok {
+		return messageBus.Healthy()
+	}
+	return nil
+}
+
+// producerChannel returns the channel messages of a topic are published to
+func producerChannel(bus kafka.KafkaService, topic string) chan string {
+	switch topic {
+	case TopicEmail:
+		return bus.GetEmailChannel()
+	case TopicSlack:
+		return bus.GetSlackChannel()
+	case TopicGoogleChat:
+		return bus.GetGoogleChatChannel()
+	case TopicIncident:
+		return bus.GetIncidentChannel()
+	case TopicRCS:
+		return bus.GetRCSChannel()
+	case TopicIOSPush:
+		return bus.GetIOSPushNotificationChannel()
+	case TopicAndroidPush:
+		return bus.GetAndroidPushNotificationChannel()
+	case TopicNotificationEvents:
+		return bus.GetNotificationEventsChannel()
+	}
+	return nil
+}
+
+// brokerBus forwards the messages published to in-process channels through a broker and
+// hands the messages received from the broker to the consumers as deliveries
+type brokerBus struct {
+	kafka.KafkaService
+	transport Transport
+	consumers map[string]chan Delivery
+	forwarded sync.WaitGroup
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewBrokerBus routes the channels of local through the transport. The publish channels of
+// local buffer messages until they are forwarded. Delivery channels are unbuffered, so messages
+// stay on the broker until a consumer is ready for them.
+func NewBrokerBus(local kafka.KafkaService, transport Transport) (MessageBus, error) {
+	bus := &brokerBus{
+		KafkaService: local,
+		transport:    transport,
+		consumers:    make(map[string]chan Delivery, len(Topics)),
+		done:         make(chan struct{}),
+	}
+
+	for _, topic := range Topics {
+		topic := topic
+		consumer := make(chan Delivery)
+		bus.consumers[topic] = consumer
+
+		if err := transport.Subscribe(topic, func(message Message) bool {
+			select {
+			case consumer <- Delivery{Topic: topic, Message: message}:
+				return true
+			case <-bus.done:
+				// Shutting down, leave the message on the broker
+				return false
+			}
+		}); err != nil {
+			transport.Close()
+			return nil, fmt.Errorf("failed to subscribe to %s messages: %w", topic, err)
+		}
+	}
+
+	for _, topic := range PublishTopics {
+		bus.forwarded.Add(1)
+		go bus.forward(topic, producerChannel(local, topic))
+	}
+
+	return bus, nil
+}
+
+// Deliveries returns the channel the consumers of a topic read from
+func (b *brokerBus) Deliveries(topic string) chan Delivery {
+	return b.consumers[topic]
+}
+
+// Healthy returns why the transport does not consume messages
+func (b *brokerBus) Healthy() error {
+	return b.transport.Healthy()
+}
+
+// forward publishes the messages of a channel until it is closed. Messages the broker does not
+// accept after publishAttempts attempts are logged and dropped.
+func (b *brokerBus) forward(topic string, channel chan string) {
+	defer b.forwarded.Done()
+
+	for message := range channel {
+		var err error
+		delay := publishRetryDelay
+		for attempt := 1; attempt <= publishAttempts; attempt++ {
+			if err = b.transport.Publish(topic, []byte(message)); err == nil {
+				break
+			}
+			if attempt < publishAttempts {
+				time.Sleep(delay)
+				delay *= 2
+			}
+		}
+		if err != nil {
+			moduleLog.Error("Dropping message the broker did not accept", logger.Fields{
+				"topic": topic,
+				"error": err.Error(),
+			})
+		}
+	}
+}
+
+// Close publishes the messages still buffered, disconnects from the broker and closes all channels
+func (b *brokerBus) Close() {
+	b.closeOnce.Do(func() {
+		b.KafkaService.Close()
+		b.forwarded.Wait()
+		close(b.done)
+
+		if err := b.transport.Close(); err != nil {
+			moduleLog.Warn("Failed to close broker connection", logger.Fields{"error": err.Error()})
+		}
+		for _, consumer := range b.consumers {
+			close(consumer)
+		}
+	})
+}
@@ -0,0 +1,267 @@
+package messagebus
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gaurav2721/notification-service/constants"
+	"github.com/gaurav2721/notification-service/external_services/kafka"
+	"github.com/gaurav2721/notification-service/logger"
+)
+
+// Message bus backends
+const (
+	BackendMemory   = "memory"
+	BackendNATS     = "nats"
+	BackendRabbitMQ = "rabbitmq"
+	BackendSQS      = "sqs"
+	BackendKafka    = "kafka"
+)
+
+// Config selects and configures the message bus backend
+type Config struct {
+	// Backend is memory, nats, rabbitmq, sqs or kafka. The memory backend keeps messages in in-process
+	// channels only; the broker backends persist them until a consumer has processed them.
+	Backend string
+
+	// Prefetch is the number of unacknowledged messages a consumer holds per topic
+	Prefetch int
+
+	// Timeout bounds connecting to the broker and waiting for its acknowledgements
+	Timeout time.Duration
+
+	// MaxDeliveries is how often a message that fails processing is delivered before it is
+	// moved to the dead-letter queue of its topic
+	MaxDeliveries int
+
+	NATSURL     string
+	NATSStream  string
+	RabbitMQURL string
+
+	// AWSRegion is the region of the SQS queues and SNS topics. Credentials are resolved by the
+	// default credential chain of the AWS SDK.
+	AWSRegion      string
+	SQSEndpoint    string
+	SQSQueuePrefix string
+
+	// SNSTopicPrefix, when set, publishes messages to the SNS topics <prefix>-<topic>
+	// subscribed by the SQS queues
+	SNSTopicPrefix string
+	SNSEndpoint    string
+
+	// SQSVisibilityTimeout hides received messages from other consumers while they are processed
+	SQSVisibilityTimeout time.Duration
+
+	// SQSMaxReceiveCount moves messages received that often to the dead-letter queue. It
+	// defaults to MaxDeliveries.
+	SQSMaxReceiveCount int
+
+	// KafkaBrokers are the addresses of the brokers the cluster metadata is requested from
+	KafkaBrokers           []string
+	KafkaTopicPrefix       string
+	KafkaConsumerGroup     string
+	KafkaTopicPartitions   int
+	KafkaReplicationFactor int
+
+	// KafkaConsumerGroups consume the Kafka topics. They are not read from the environment but
+	// set by the caller, since the consumer groups live in the consumers package.
+	KafkaConsumerGroups Subscriber
+}
+
+// DefaultConfig returns the message bus configuration used when no environment overrides are set
+func DefaultConfig() Config {
+	return Config{
+		Backend:       constants.DefaultMessageBus,
+		Prefetch:      constants.DefaultMessageBusPrefetch,
+		Timeout:       time.Duration(constants.DefaultMessageBusTimeoutMs) * time.Millisecond,
+		MaxDeliveries: constants.DefaultMessageBusMaxDeliveries,
+		NATSURL:       constants.DefaultNATSURL,
+		NATSStream:    constants.DefaultNATSStream,
+		RabbitMQURL:   constants.DefaultRabbitMQURL,
+
+		AWSRegion:            constants.DefaultAWSRegion,
+		SQSQueuePrefix:       constants.DefaultSQSQueuePrefix,
+		SQSVisibilityTimeout: time.Duration(constants.DefaultSQSVisibilityTimeoutSeconds) * time.Second,
+		SQSMaxReceiveCount:   constants.DefaultSQSMaxReceiveCount,
+
+		KafkaBrokers:           []string{constants.DefaultKafkaBrokers},
+		KafkaTopicPrefix:       constants.DefaultKafkaTopicPrefix,
+		KafkaConsumerGroup:     constants.DefaultKafkaConsumerGroup,
+		KafkaTopicPartitions:   constants.DefaultKafkaTopicPartitions,
+		KafkaReplicationFactor: constants.DefaultKafkaReplicationFactor,
+	}
+}
+
+// LoadConfigFromEnv reads the message bus configuration from environment variables
+func LoadConfigFromEnv() Config {
+	config := DefaultConfig()
+
+	if backend := os.Getenv(constants.MessageBusEnvVar); backend != "" {
+		config.Backend = strings.ToLower(strings.TrimSpace(backend))
+	}
+	if prefetch := getEnvAsInt(constants.MessageBusPrefetchEnvVar); prefetch > 0 {
+		config.Prefetch = prefetch
+	}
+	if timeoutMs := getEnvAsInt(constants.MessageBusTimeoutMsEnvVar); timeoutMs > 0 {
+		config.Timeout = time.Duration(timeoutMs) * time.Millisecond
+	}
+	if deliveries := getEnvAsInt(constants.MessageBusMaxDeliveriesEnvVar); deliveries > 0 {
+		config.MaxDeliveries = deliveries
+		config.SQSMaxReceiveCount = deliveries
+	}
+	if natsURL := os.Getenv(constants.NATSURLEnvVar); natsURL != "" {
+		config.NATSURL = natsURL
+	}
+	if stream := os.Getenv(constants.NATSStreamEnvVar); stream != "" {
+		config.NATSStream = stream
+	}
+	if rabbitURL := os.Getenv(constants.RabbitMQURLEnvVar); rabbitURL != "" {
+		config.RabbitMQURL = rabbitURL
+	}
+
+	if region := os.Getenv(constants.AWSRegionEnvVar); region != "" {
+		config.AWSRegion = region
+	}
+	config.SQSEndpoint = os.Getenv(constants.SQSEndpointEnvVar)
+	if prefix := os.Getenv(constants.SQSQueuePrefixEnvVar); prefix != "" {
+		config.SQSQueuePrefix = prefix
+	}
+	if seconds := getEnvAsInt(constants.SQSVisibilityTimeoutSecondsEnvVar); seconds > 0 {
+		config.SQSVisibilityTimeout = time.Duration(seconds) * time.Second
+	}
+	if count := getEnvAsInt(constants.SQSMaxReceiveCountEnvVar); count > 0 {
+		config.SQSMaxReceiveCount = count
+	}
+	config.SNSTopicPrefix = os.Getenv(constants.SNSTopicPrefixEnvVar)
+	config.SNSEndpoint = os.Getenv(constants.SNSEndpointEnvVar)
+
+	if brokers := splitList(os.Getenv(constants.KafkaBrokersEnvVar)); len(brokers) > 0 {
+		config.KafkaBrokers = brokers
+	}
+	if prefix, ok := os.LookupEnv(constants.KafkaTopicPrefixEnvVar); ok {
+		config.KafkaTopicPrefix = prefix
+	}
+	if group := os.Getenv(constants.KafkaConsumerGroupEnvVar); group != "" {
+		config.KafkaConsumerGroup = group
+	}
+	if partitions := getEnvAsInt(constants.KafkaTopicPartitionsEnvVar); partitions > 0 {
+		config.KafkaTopicPartitions = partitions
+	}
+	if replicas := getEnvAsInt(constants.KafkaReplicationFactorEnvVar); replicas > 0 {
+		config.KafkaReplicationFactor = replicas
+	}
+
+	return config
+}
+
+// New creates the message bus of the configured backend. Broker backends publish through the
+// in-process channels of a kafka.KafkaService, which buffer messages until they are forwarded.
+func New(config Config) (kafka.KafkaService, error) {
+	if config.Backend == "" || config.Backend == BackendMemory {
+		return kafka.NewKafkaService()
+	}
+
+	transport, err := newTransport(config)
+	if err != nil {
+		return nil, err
+	}
+
+	local, err := kafka.NewKafkaService()
+	if err != nil {
+		transport.Close()
+		return nil, err
+	}
+
+	moduleLog.Info("Routing notification messages through broker", logger.Fields{"backend": config.Backend})
+	return NewBrokerBus(local, transport)
+}
+
+// CheckConnection connects to the broker of the configured backend and disconnects again
+// without consuming any messages. The memory backend has no broker to connect to.
+func CheckConnection(config Config) error {
+	if config.Backend == "" || config.Backend == BackendMemory {
+		return nil
+	}
+
+	transport, err := newTransport(config)
+	if err != nil {
+		return err
+	}
+	return transport.Close()
+}
+
+// newTransport connects to the broker of a broker backend
+func newTransport(config Config) (Transport, error) {
+	switch config.Backend {
+	case BackendNATS:
+		return NewNATSTransport(NATSConfig{
+			URL:           config.NATSURL,
+			Stream:        config.NATSStream,
+			Prefetch:      config.Prefetch,
+			MaxDeliveries: config.MaxDeliveries,
+			Timeout:       config.Timeout,
+		})
+	case BackendRabbitMQ:
+		return NewRabbitMQTransport(RabbitMQConfig{
+			URL:           config.RabbitMQURL,
+			Prefetch:      config.Prefetch,
+			MaxDeliveries: config.MaxDeliveries,
+			Timeout:       config.Timeout,
+		})
+	case BackendSQS:
+		return NewSQSTransport(SQSConfig{
+			Region:            config.AWSRegion,
+			Endpoint:          config.SQSEndpoint,
+			QueuePrefix:       config.SQSQueuePrefix,
+			SNSTopicPrefix:    config.SNSTopicPrefix,
+			SNSEndpoint:       config.SNSEndpoint,
+			VisibilityTimeout: config.SQSVisibilityTimeout,
+			MaxReceiveCount:   config.SQSMaxReceiveCount,
+			Prefetch:          config.Prefetch,
+			Timeout:           config.Timeout,
+		})
+	case BackendKafka:
+		return NewKafkaTransport(KafkaConfig{
+			Brokers:           config.KafkaBrokers,
+			TopicPrefix:       config.KafkaTopicPrefix,
+			Partitions:        config.KafkaTopicPartitions,
+			ReplicationFactor: config.KafkaReplicationFactor,
+			ConsumerGroups:    config.KafkaConsumerGroups,
+			Timeout:           config.Timeout,
+		})
+	default:
+		return nil, fmt.Errorf("unknown message bus backend %q", config.Backend)
+	}
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvAsInt reads an integer environment variable, returning 0 when unset or invalid
+func getEnvAsInt(key string) int {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return 0
+	}
+
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		moduleLog.Warn("Invalid integer environment variable, using default", logger.Fields{
+			"key":   key,
+			"value": valueStr,
+		})
+		return 0
+	}
+	return value
+}
@@ -0,0 +1,214 @@
+package messagebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gaurav2721/notification-service/logger"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+const (
+	// KafkaDeadLetterSuffix is appended to a topic name to name its dead-letter topic
+	KafkaDeadLetterSuffix = ".dlq"
+
+	// KafkaDeliveryCountHeader counts the deliveries of a republished record; records without
+	// it are delivered for the first time
+	KafkaDeliveryCountHeader = "delivery-count"
+
+	// kafkaLeaderPollInterval is the delay between metadata requests while new topics get leaders
+	kafkaLeaderPollInterval = 100 * time.Millisecond
+)
+
+// KafkaConfig configures the Kafka transport
+type KafkaConfig struct {
+	// Brokers are the addresses of the brokers the cluster metadata is requested from, e.g.
+	// localhost:9092
+	Brokers []string
+
+	// TopicPrefix prefixes the names of the topics notification messages are published to.
+	// Dead-letter topics append .dlq to the topic name.
+	TopicPrefix string
+
+	// Partitions and ReplicationFactor of the topics created on startup
+	Partitions        int
+	ReplicationFactor int
+
+	// ConsumerGroups consume the subscribed topics. The consumer groups live in the consumers
+	// package, see consumers.NewKafkaGroups; without them the transport only publishes.
+	ConsumerGroups Subscriber
+
+	// Timeout bounds connecting and waiting for the broker's replies
+	Timeout time.Duration
+}
+
+// kafkaTransport publishes messages to one Kafka topic per notification topic. Its subscriptions
+// are consumed by the consumer groups of the config, one group per topic, so service instances
+// share the topic's partitions.
+type kafkaTransport struct {
+	config KafkaConfig
+	writer *kafkago.Writer
+}
+
+// NewKafkaTransport connects to Kafka and creates the topics of all notification topics and
+// their dead-letter topics that do not exist yet
+func NewKafkaTransport(config KafkaConfig) (Transport, error) {
+	if len(config.Brokers) == 0 {
+		return nil, errors.New("kafka: no brokers configured")
+	}
+
+	topics := make([]string, 0, len(PublishTopics)+len(Topics))
+	for _, topic := range PublishTopics {
+		topics = append(topics, config.TopicPrefix+topic)
+	}
+	for _, topic := range Topics {
+		topics = append(topics, config.TopicPrefix+topic+KafkaDeadLetterSuffix)
+	}
+	if err := createKafkaTopics(config, topics); err != nil {
+		return nil, err
+	}
+
+	moduleLog.Info("Connected to Kafka", logger.Fields{"topics": len(topics), "brokers": strings.Join(config.Brokers, ",")})
+	return &kafkaTransport{
+		config: config,
+		writer: NewKafkaWriter(config.Brokers, config.Timeout),
+	}, nil
+}
+
+// NewKafkaWriter returns a writer that appends every message to the partitions of its topic in
+// turn and returns once all in-sync replicas have it
+func NewKafkaWriter(brokers []string, timeout time.Duration) *kafkago.Writer {
+	return &kafkago.Writer{
+		Addr:         kafkago.TCP(brokers...),
+		Balancer:     &kafkago.RoundRobin{},
+		RequiredAcks: kafkago.RequireAll,
+		// Publishes wait for their message, so it is written right away instead of in a batch
+		BatchSize:    1,
+		WriteTimeout: timeout,
+		ReadTimeout:  timeout,
+		// Failed publishes are retried by the caller
+		MaxAttempts: 1,
+		Transport:   &kafkago.Transport{DialTimeout: timeout},
+	}
+}
+
+// createKafkaTopics creates the topics that do not exist through the controller and waits until
+// all their partitions have a leader
+func createKafkaTopics(config KafkaConfig, topics []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	defer cancel()
+	dialer := &kafkago.Dialer{Timeout: config.Timeout}
+
+	var conn *kafkago.Conn
+	var err error
+	for _, broker := range config.Brokers {
+		if conn, err = dialer.DialContext(ctx, "tcp", broker); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("kafka: no broker reachable: %w", err)
+	}
+	defer conn.Close()
+
+	controller, err := conn.Controller()
+	if err != nil {
+		return fmt.Errorf("kafka: failed to find controller: %w", err)
+	}
+	controllerConn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
+	if err != nil {
+		return fmt.Errorf("kafka: failed to connect to controller: %w", err)
+	}
+	defer controllerConn.Close()
+
+	configs := make([]kafkago.TopicConfig, 0, len(topics))
+	for _, topic := range topics {
+		configs = append(configs, kafkago.TopicConfig{
+			Topic:             topic,
+			NumPartitions:     config.Partitions,
+			ReplicationFactor: config.ReplicationFactor,
+		})
+	}
+	// Topics that exist already are left as they are
+	if err := controllerConn.CreateTopics(configs...); err != nil {
+		return fmt.Errorf("kafka: failed to create topics: %w", err)
+	}
+
+	for {
+		unavailable, err := kafkaTopicsWithoutLeader(conn, topics)
+		if err == nil && len(unavailable) == 0 {
+			return nil
+		}
+		if ctx.Err() != nil {
+			if err != nil {
+				return fmt.Errorf("kafka: failed to read topic metadata: %w", err)
+			}
+			return fmt.Errorf("kafka: topics without leader: %s", strings.Join(unavailable, ", "))
+		}
+		time.Sleep(kafkaLeaderPollInterval)
+	}
+}
+
+// kafkaTopicsWithoutLeader returns the topics that are missing or have a partition without leader
+func kafkaTopicsWithoutLeader(conn *kafkago.Conn, topics []string) ([]string, error) {
+	partitions, err := conn.ReadPartitions(topics...)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool, len(topics))
+	leaderless := make(map[string]bool)
+	for _, partition := range partitions {
+		found[partition.Topic] = true
+		if partition.Leader.Host == "" {
+			leaderless[partition.Topic] = true
+		}
+	}
+	var unavailable []string
+	for _, topic := range topics {
+		if !found[topic] || leaderless[topic] {
+			unavailable = append(unavailable, topic)
+		}
+	}
+	return unavailable, nil
+}
+
+// Publish appends a message to the topic, waiting until all in-sync replicas have it
+func (t *kafkaTransport) Publish(topic string, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), t.config.Timeout)
+	defer cancel()
+	return t.writer.WriteMessages(ctx, kafkago.Message{Topic: t.config.TopicPrefix + topic, Value: payload})
+}
+
+// Subscribe hands the topic to its consumer group
+func (t *kafkaTransport) Subscribe(topic string, handle func(message Message) bool) error {
+	if t.config.ConsumerGroups == nil {
+		return fmt.Errorf("kafka: no consumer groups to consume %s", topic)
+	}
+	return t.config.ConsumerGroups.Subscribe(topic, handle)
+}
+
+// Healthy returns why the consumer groups do not consume messages
+func (t *kafkaTransport) Healthy() error {
+	if t.config.ConsumerGroups == nil {
+		return nil
+	}
+	return t.config.ConsumerGroups.Healthy()
+}
+
+// Close leaves the consumer groups and disconnects from Kafka. Messages that were not
+// acknowledged are redelivered from the last committed offsets.
+func (t *kafkaTransport) Close() error {
+	if t.config.ConsumerGroups != nil {
+		if err := t.config.ConsumerGroups.Close(); err != nil {
+			t.writer.Close()
+			return err
+		}
+	}
+	return t.writer.Close()
+}
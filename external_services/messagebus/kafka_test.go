@@ -0,0 +1,23 @@
+package messagebus
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewKafkaTransport_NoBrokerReachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+
+	_, err = NewKafkaTransport(KafkaConfig{Brokers: []string{address}, Timeout: time.Second})
+	assert.ErrorContains(t, err, "no broker reachable")
+
+	_, err = NewKafkaTransport(KafkaConfig{Timeout: time.Second})
+	assert.ErrorContains(t, err, "no brokers configured")
+}
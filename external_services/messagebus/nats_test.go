@@ -0,0 +1,139 @@
+package messagebus
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jetStreamOptions configures an in-process NATS server with JetStream enabled
+func jetStreamOptions(t *testing.T) *server.Options {
+	return &server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		Username:  "notifier",
+		Password:  "secret",
+		NoLog:     true,
+		NoSigs:    true,
+	}
+}
+
+// runJetStream starts an in-process NATS server
+func runJetStream(t *testing.T, opts *server.Options) *server.Server {
+	t.Helper()
+	s, err := server.NewServer(opts)
+	require.NoError(t, err)
+	go s.Start()
+	require.True(t, s.ReadyForConnections(5*time.Second), "NATS server did not start")
+	t.Cleanup(s.Shutdown)
+	return s
+}
+
+func newTestNATSTransport(t *testing.T, s *server.Server) Transport {
+	t.Helper()
+	transport, err := NewNATSTransport(NATSConfig{
+		URL:           "nats://notifier:secret@" + s.Addr().String(),
+		Stream:        "NOTIFICATIONS",
+		Prefetch:      4,
+		MaxDeliveries: 2,
+		Timeout:       2 * time.Second,
+	})
+	require.NoError(t, err)
+	return transport
+}
+
+func receiveMessage(t *testing.T, received chan Message) Message {
+	t.Helper()
+	select {
+	case message := <-received:
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+		return nil
+	}
+}
+
+func TestNATSTransport_PublishAndConsume(t *testing.T) {
+	s := runJetStream(t, jetStreamOptions(t))
+	transport := newTestNATSTransport(t, s)
+
+	received := make(chan Message, 4)
+	require.NoError(t, transport.Subscribe(TopicIOSPush, func(message Message) bool {
+		received <- message
+		return true
+	}))
+
+	require.NoError(t, transport.Publish(TopicIOSPush, []byte(`{"id":"n-1"}`)))
+	require.NoError(t, transport.Publish(TopicIOSPush, []byte(`{"id":"n-2"}`)))
+
+	first := receiveMessage(t, received)
+	assert.Equal(t, `{"id":"n-1"}`, string(first.Payload()))
+	require.NoError(t, first.Ack())
+
+	second := receiveMessage(t, received)
+	assert.Equal(t, `{"id":"n-2"}`, string(second.Payload()))
+	require.NoError(t, second.Nack())
+
+	redelivered := receiveMessage(t, received)
+	assert.Equal(t, `{"id":"n-2"}`, string(redelivered.Payload()))
+	require.NoError(t, redelivered.Nack(), "the last delivery moves the message to the dead-letter subject")
+	require.NoError(t, transport.Healthy())
+	require.NoError(t, transport.Close())
+
+	conn, err := nats.Connect("nats://notifier:secret@" + s.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	js, err := conn.JetStream()
+	require.NoError(t, err)
+
+	dead, err := js.GetLastMsg("NOTIFICATIONS", "notifications.dead.ios_push")
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":"n-2"}`, string(dead.Data))
+
+	info, err := js.ConsumerInfo("NOTIFICATIONS", "notification-service-ios-push")
+	require.NoError(t, err)
+	assert.Zero(t, info.NumPending, "acknowledged and dead-lettered messages leave the work queue")
+	assert.Zero(t, info.NumAckPending)
+}
+
+func TestNATSTransport_ReconnectsAfterServerRestart(t *testing.T) {
+	opts := jetStreamOptions(t)
+	s := runJetStream(t, opts)
+	transport := newTestNATSTransport(t, s)
+	defer transport.Close()
+
+	received := make(chan Message, 4)
+	require.NoError(t, transport.Subscribe(TopicEmail, func(message Message) bool {
+		received <- message
+		return true
+	}))
+
+	port := s.Addr().(*net.TCPAddr).Port
+	s.Shutdown()
+	assert.Eventually(t, func() bool { return transport.Healthy() != nil }, 5*time.Second, 10*time.Millisecond,
+		"the transport is unhealthy while the server is gone")
+
+	// The restarted server listens on the same port and keeps the stream
+	opts.Port = port
+	runJetStream(t, opts)
+	assert.Eventually(t, func() bool { return transport.Healthy() == nil }, 10*time.Second, 50*time.Millisecond)
+
+	require.NoError(t, transport.Publish(TopicEmail, []byte(`{"id":"n-1"}`)))
+	message := receiveMessage(t, received)
+	assert.Equal(t, `{"id":"n-1"}`, string(message.Payload()))
+	require.NoError(t, message.Ack())
+}
+
+func TestNewNATSTransport_ConnectRejected(t *testing.T) {
+	s := runJetStream(t, jetStreamOptions(t))
+
+	_, err := NewNATSTransport(NATSConfig{URL: "nats://notifier:wrong@" + s.Addr().String(), Stream: "NOTIFICATIONS", Timeout: time.Second})
+	assert.ErrorContains(t, err, "Authorization Violation")
+}
@@ -0,0 +1,433 @@
+package messagebus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/gaurav2721/notification-service/constants"
+	"github.com/gaurav2721/notification-service/logger"
+)
+
+const (
+	// sqsMaxBatch is the largest number of messages SQS returns from one receive
+	sqsMaxBatch = 10
+
+	// sqsWaitTime is how long a receive long-polls for messages
+	sqsWaitTime = 20 * time.Second
+
+	// sqsDeadLetterSuffix is appended to a topic queue name to name its dead-letter queue
+	sqsDeadLetterSuffix = "-dlq"
+)
+
+// SQSConfig configures the Amazon SQS transport. Credentials are resolved by the default
+// credential chain of the AWS SDK: environment variables, shared config files, and the roles of
+// ECS tasks, EC2 instances and EKS service accounts.
+type SQSConfig struct {
+	// Region of the queues, e.g. eu-west-1
+	Region string
+
+	// Endpoint overrides https://sqs.<region>.amazonaws.com, e.g. for LocalStack
+	Endpoint string
+
+	// QueuePrefix names the queues <prefix>-<topic> and their dead-letter queues <prefix>-<topic>-dlq
+	QueuePrefix string
+
+	// SNSTopicPrefix, when set, publishes messages to the SNS topics <prefix>-<topic>, which
+	// deliver them to the queue of their topic and to any other subscriber, e.g. the queue of
+	// a service consuming the notification-events topic
+	SNSTopicPrefix string
+
+	// SNSEndpoint overrides https://sns.<region>.amazonaws.com, e.g. for LocalStack
+	SNSEndpoint string
+
+	// VisibilityTimeout is how long a received message stays hidden from other consumers. It is
+	// extended until the message was processed, so it only needs to cover a heartbeat interval.
+	VisibilityTimeout time.Duration
+
+	// MaxReceiveCount is how often a message is received before it moves to the dead-letter queue
+	MaxReceiveCount int
+
+	// Prefetch is the number of messages received at a time, at most 10
+	Prefetch int
+
+	// Timeout bounds every SQS and SNS API call except the long-polling receives
+	Timeout time.Duration
+}
+
+// sqsTransport publishes messages to one standard queue per topic, each with a dead-letter queue
+// that receives messages after MaxReceiveCount failed deliveries. With SNS topics configured,
+// messages are published to the topic subscribed by the queue instead.
+type sqsTransport struct {
+	consumerHealth
+	config SQSConfig
+	sqs    *sqs.Client
+	sns    *sns.Client
+	queues map[string]string // queue URL by topic
+	topics map[string]string // SNS topic ARN by topic, empty without SNS
+
+	// inFlight holds the received messages whose visibility timeout the heartbeat extends
+	inFlightMu    sync.Mutex
+	inFlight      map[*sqsReceivedMessage]struct{}
+	heartbeatDone chan struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewSQSTransport creates the queues of all topics and their dead-letter queues unless they
+// exist, and the SNS topics delivering to them when configured
+func NewSQSTransport(config SQSConfig) (Transport, error) {
+	if config.Prefetch <= 0 || config.Prefetch > sqsMaxBatch {
+		config.Prefetch = sqsMaxBatch
+	}
+	if config.VisibilityTimeout < 2*time.Second {
+		config.VisibilityTimeout = time.Duration(constants.DefaultSQSVisibilityTimeoutSeconds) * time.Second
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	awsConfig, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(config.Region))
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("sqs: failed to load AWS configuration: %w", err)
+	}
+
+	transport := &sqsTransport{
+		config: config,
+		sqs: sqs.NewFromConfig(awsConfig, func(o *sqs.Options) {
+			if config.Endpoint != "" {
+				o.BaseEndpoint = aws.String(config.Endpoint)
+			}
+		}),
+		queues:   make(map[string]string, len(PublishTopics)),
+		topics:   make(map[string]string, len(PublishTopics)),
+		inFlight: make(map[*sqsReceivedMessage]struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+
+		heartbeatDone: make(chan struct{}),
+	}
+	if config.SNSTopicPrefix != "" {
+		transport.sns = sns.NewFromConfig(awsConfig, func(o *sns.Options) {
+			if config.SNSEndpoint != "" {
+				o.BaseEndpoint = aws.String(config.SNSEndpoint)
+			}
+		})
+	}
+
+	for _, topic := range PublishTopics {
+		name := strings.ReplaceAll(topic, "_", "-")
+		queueURL, err := transport.createQueue(config.QueuePrefix + "-" + name)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		transport.queues[topic] = queueURL
+
+		if transport.sns != nil {
+			topicARN, err := transport.createTopic(config.SNSTopicPrefix+"-"+name, queueURL)
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			transport.topics[topic] = topicARN
+		}
+	}
+
+	go transport.heartbeat()
+	moduleLog.Info("Connected to Amazon SQS", logger.Fields{
+		"region":           config.Region,
+		"queue_prefix":     config.QueuePrefix,
+		"sns_topic_prefix": config.SNSTopicPrefix,
+	})
+	return transport, nil
+}
+
+// createQueue creates a queue whose redrive policy moves messages to its dead-letter queue
+func (t *sqsTransport) createQueue(name string) (string, error) {
+	ctx, cancel := context.WithTimeout(t.ctx, t.config.Timeout)
+	defer cancel()
+
+	deadLetter, err := t.sqs.CreateQueue(ctx, &sqs.CreateQueueInput{QueueName: aws.String(name + sqsDeadLetterSuffix)})
+	if err != nil {
+		return "", fmt.Errorf("sqs: failed to create queue %s: %w", name+sqsDeadLetterSuffix, err)
+	}
+	deadLetterARN, err := t.queueARN(ctx, aws.ToString(deadLetter.QueueUrl))
+	if err != nil {
+		return "", fmt.Errorf("sqs: failed to look up queue %s: %w", name+sqsDeadLetterSuffix, err)
+	}
+
+	redrivePolicy, _ := json.Marshal(map[string]string{
+		"deadLetterTargetArn": deadLetterARN,
+		"maxReceiveCount":     strconv.Itoa(t.config.MaxReceiveCount),
+	})
+	queue, err := t.sqs.CreateQueue(ctx, &sqs.CreateQueueInput{
+		QueueName: aws.String(name),
+		Attributes: map[string]string{
+			string(sqstypes.QueueAttributeNameVisibilityTimeout): strconv.Itoa(int(t.config.VisibilityTimeout.Seconds())),
+			string(sqstypes.QueueAttributeNameRedrivePolicy):     string(redrivePolicy),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("sqs: failed to create queue %s: %w", name, err)
+	}
+	return aws.ToString(queue.QueueUrl), nil
+}
+
+// queueARN looks up the ARN of a queue
+func (t *sqsTransport) queueARN(ctx context.Context, queueURL string) (string, error) {
+	attributes, err := t.sqs.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(queueURL),
+		AttributeNames: []sqstypes.QueueAttributeName{sqstypes.QueueAttributeNameQueueArn},
+	})
+	if err != nil {
+		return "", err
+	}
+	return attributes.Attributes[string(sqstypes.QueueAttributeNameQueueArn)], nil
+}
+
+// createTopic creates an SNS topic and subscribes the queue to it with raw message delivery, so
+// the queue receives the published payloads unchanged. The queue policy allows the topic, and
+// only the topic, to send messages to the queue.
+func (t *sqsTransport) createTopic(name, queueURL string) (string, error) {
+	ctx, cancel := context.WithTimeout(t.ctx, t.config.Timeout)
+	defer cancel()
+
+	topic, err := t.sns.CreateTopic(ctx, &sns.CreateTopicInput{Name: aws.String(name)})
+	if err != nil {
+		return "", fmt.Errorf("sns: failed to create topic %s: %w", name, err)
+	}
+	topicARN := aws.ToString(topic.TopicArn)
+
+	queueARN, err := t.queueARN(ctx, queueURL)
+	if err != nil {
+		return "", fmt.Errorf("sqs: failed to look up queue %s: %w", queueURL, err)
+	}
+	policy, _ := json.Marshal(map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{{
+			"Effect":    "Allow",
+			"Principal": map[string]string{"Service": "sns.amazonaws.com"},
+			"Action":    "sqs:SendMessage",
+			"Resource":  queueARN,
+			"Condition": map[string]interface{}{"ArnEquals": map[string]string{"aws:SourceArn": topicARN}},
+		}},
+	})
+	if _, err := t.sqs.SetQueueAttributes(ctx, &sqs.SetQueueAttributesInput{
+		QueueUrl:   aws.String(queueURL),
+		Attributes: map[string]string{string(sqstypes.QueueAttributeNamePolicy): string(policy)},
+	}); err != nil {
+		return "", fmt.Errorf("sqs: failed to allow topic %s to send to queue %s: %w", name, queueURL, err)
+	}
+
+	// Subscribing again with the same attributes returns the existing subscription
+	if _, err := t.sns.Subscribe(ctx, &sns.SubscribeInput{
+		TopicArn:   aws.String(topicARN),
+		Protocol:   aws.String("sqs"),
+		Endpoint:   aws.String(queueARN),
+		Attributes: map[string]string{"RawMessageDelivery": "true"},
+	}); err != nil {
+		return "", fmt.Errorf("sns: failed to subscribe queue %s to topic %s: %w", queueURL, name, err)
+	}
+	return topicARN, nil
+}
+
+// Publish sends a message to the topic's SNS topic when configured, or else to its queue
+func (t *sqsTransport) Publish(topic string, payload []byte) error {
+	queueURL, ok := t.queues[topic]
+	if !ok {
+		return fmt.Errorf("sqs: unknown topic %s", topic)
+	}
+
+	ctx, cancel := context.WithTimeout(t.ctx, t.config.Timeout)
+	defer cancel()
+
+	if topicARN, ok := t.topics[topic]; ok {
+		if _, err := t.sns.Publish(ctx, &sns.PublishInput{
+			TopicArn: aws.String(topicARN),
+			Message:  aws.String(string(payload)),
+		}); err != nil {
+			return fmt.Errorf("sns: failed to publish message to %s: %w", topic, err)
+		}
+		return nil
+	}
+
+	if _, err := t.sqs.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(queueURL),
+		MessageBody: aws.String(string(payload)),
+	}); err != nil {
+		return fmt.Errorf("sqs: failed to publish message to %s: %w", topic, err)
+	}
+	return nil
+}
+
+// Subscribe starts long-polling the topic's queue
+func (t *sqsTransport) Subscribe(topic string, handle func(message Message) bool) error {
+	queueURL, ok := t.queues[topic]
+	if !ok {
+		return fmt.Errorf("sqs: unknown topic %s", topic)
+	}
+
+	t.wg.Add(1)
+	go t.receive(topic, queueURL, handle)
+	return nil
+}
+
+// receive hands the messages of a queue to handle until the transport is closed
+func (t *sqsTransport) receive(topic, queueURL string, handle func(message Message) bool) {
+	defer t.wg.Done()
+
+	for t.ctx.Err() == nil {
+		ctx, cancel := context.WithTimeout(t.ctx, t.config.Timeout+sqsWaitTime)
+		response, err := t.sqs.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(queueURL),
+			MaxNumberOfMessages: int32(t.config.Prefetch),
+			WaitTimeSeconds:     int32(sqsWaitTime.Seconds()),
+		})
+		cancel()
+		if err != nil {
+			if t.ctx.Err() != nil {
+				return
+			}
+			t.fail(topic, fmt.Errorf("sqs: failed to receive %s messages: %w", topic, err))
+			moduleLog.Warn("Failed to receive SQS messages", logger.Fields{"topic": topic, "error": err.Error()})
+			select {
+			case <-time.After(time.Second):
+			case <-t.ctx.Done():
+			}
+			continue
+		}
+		t.reset(topic)
+
+		received := make([]*sqsReceivedMessage, len(response.Messages))
+		for i, message := range response.Messages {
+			received[i] = &sqsReceivedMessage{
+				transport:     t,
+				topic:         topic,
+				queueURL:      queueURL,
+				body:          aws.ToString(message.Body),
+				receiptHandle: aws.ToString(message.ReceiptHandle),
+			}
+			t.trackVisibility(received[i])
+		}
+		for i, message := range received {
+			if !handle(message) {
+				// Make the rest of the batch visible again right away
+				for _, rest := range received[i:] {
+					rest.Nack()
+				}
+				return
+			}
+		}
+	}
+}
+
+// sqsReceivedMessage is a message received from a queue. Its visibility timeout is extended
+// until it is deleted or made visible again.
+type sqsReceivedMessage struct {
+	transport     *sqsTransport
+	topic         string
+	queueURL      string
+	body          string
+	receiptHandle string
+}
+
+// Payload returns the message content
+func (m *sqsReceivedMessage) Payload() []byte {
+	return []byte(m.body)
+}
+
+// Ack deletes the message from its queue
+func (m *sqsReceivedMessage) Ack() error {
+	m.transport.untrackVisibility(m)
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.transport.config.Timeout)
+	defer cancel()
+	_, err := m.transport.sqs.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(m.queueURL),
+		ReceiptHandle: aws.String(m.receiptHandle),
+	})
+	return err
+}
+
+// Nack makes the message visible again. The queue's redrive policy moves it to the dead-letter
+// queue once it was received MaxReceiveCount times.
+func (m *sqsReceivedMessage) Nack() error {
+	m.transport.untrackVisibility(m)
+	return m.transport.setVisibility(m, 0)
+}
+
+// trackVisibility keeps extending the visibility timeout of a received message
+func (t *sqsTransport) trackVisibility(message *sqsReceivedMessage) {
+	t.inFlightMu.Lock()
+	defer t.inFlightMu.Unlock()
+	t.inFlight[message] = struct{}{}
+}
+
+// untrackVisibility stops extending the visibility timeout of a settled message
+func (t *sqsTransport) untrackVisibility(message *sqsReceivedMessage) {
+	t.inFlightMu.Lock()
+	defer t.inFlightMu.Unlock()
+	delete(t.inFlight, message)
+}
+
+// heartbeat extends the visibility timeout of the messages in flight every half timeout, so
+// messages stay hidden from other instances for as long as they are being processed
+func (t *sqsTransport) heartbeat() {
+	ticker := time.NewTicker(t.config.VisibilityTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.inFlightMu.Lock()
+			messages := make([]*sqsReceivedMessage, 0, len(t.inFlight))
+			for message := range t.inFlight {
+				messages = append(messages, message)
+			}
+			t.inFlightMu.Unlock()
+
+			for _, message := range messages {
+				if err := t.setVisibility(message, t.config.VisibilityTimeout); err != nil {
+					moduleLog.Warn("Failed to extend SQS message visibility", logger.Fields{
+						"topic": message.topic,
+						"error": err.Error(),
+					})
+				}
+			}
+		case <-t.heartbeatDone:
+			return
+		}
+	}
+}
+
+// setVisibility changes the visibility timeout of a received message
+func (t *sqsTransport) setVisibility(message *sqsReceivedMessage, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), t.config.Timeout)
+	defer cancel()
+	_, err := t.sqs.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(message.queueURL),
+		ReceiptHandle:     aws.String(message.receiptHandle),
+		VisibilityTimeout: int32(timeout.Seconds()),
+	})
+	return err
+}
+
+// Close stops receiving messages once running handlers have returned. Messages that were not
+// settled become visible again when their visibility timeout expires.
+func (t *sqsTransport) Close() error {
+	t.cancel()
+	t.wg.Wait()
+	close(t.heartbeatDone)
+	return nil
+}
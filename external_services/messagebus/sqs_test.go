@@ -0,0 +1,306 @@
+package messagebus
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSQS answers the SQS JSON protocol actions used by the transport
+type fakeSQS struct {
+	mu         sync.Mutex
+	attributes map[string]map[string]string // queue attributes by queue name
+	messages   map[string][]string          // visible messages by queue URL
+	nextHandle int
+	deleted    []string
+	visibility map[string]int // last visibility timeout by receipt handle
+}
+
+func newFakeSQS(t *testing.T) (*fakeSQS, *httptest.Server) {
+	sqs := &fakeSQS{
+		attributes: make(map[string]map[string]string),
+		messages:   make(map[string][]string),
+		visibility: make(map[string]int),
+	}
+	server := httptest.NewServer(sqs)
+	t.Cleanup(server.Close)
+	return sqs, server
+}
+
+func (f *fakeSQS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&request)
+	queueURL, _ := request["QueueUrl"].(string)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"com.amazon.coral.service#InvalidClientTokenId","message":"The security token included in the request is invalid."}`))
+		return
+	}
+
+	var response interface{} = map[string]string{}
+	switch strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "AmazonSQS.") {
+	case "CreateQueue":
+		name := request["QueueName"].(string)
+		attributes := map[string]string{"QueueArn": "arn:aws:sqs:eu-west-1:123456789012:" + name}
+		if requested, ok := request["Attributes"].(map[string]interface{}); ok {
+			for key, value := range requested {
+				attributes[key] = value.(string)
+			}
+		}
+		f.attributes[name] = attributes
+		response = map[string]string{"QueueUrl": "http://sqs.local/123456789012/" + name}
+
+	case "SetQueueAttributes":
+		name := queueURL[strings.LastIndex(queueURL, "/")+1:]
+		for key, value := range request["Attributes"].(map[string]interface{}) {
+			f.attributes[name][key] = value.(string)
+		}
+
+	case "GetQueueAttributes":
+		name := queueURL[strings.LastIndex(queueURL, "/")+1:]
+		response = map[string]interface{}{"Attributes": map[string]string{"QueueArn": f.attributes[name]["QueueArn"]}}
+
+	case "SendMessage":
+		f.messages[queueURL] = append(f.messages[queueURL], request["MessageBody"].(string))
+
+	case "ReceiveMessage":
+		var messages []map[string]string
+		for _, body := range f.messages[queueURL] {
+			f.nextHandle++
+			messages = append(messages, map[string]string{"Body": body, "ReceiptHandle": "handle-" + strconv.Itoa(f.nextHandle)})
+		}
+		f.messages[queueURL] = nil
+		if len(messages) == 0 {
+			// Stand in for long polling without holding up the test
+			f.mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			f.mu.Lock()
+		}
+		response = map[string]interface{}{"Messages": messages}
+
+	case "DeleteMessage":
+		f.deleted = append(f.deleted, request["ReceiptHandle"].(string))
+
+	case "ChangeMessageVisibility":
+		f.visibility[request["ReceiptHandle"].(string)] = int(request["VisibilityTimeout"].(float64))
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// fakeSNS answers the SNS query protocol actions used by the transport and delivers published
+// messages to the queues of a fakeSQS
+type fakeSNS struct {
+	sqs           *fakeSQS
+	subscriptions map[string][]string // subscribed queue ARNs by topic ARN
+	attributes    map[string]string   // subscription attributes by queue ARN
+}
+
+func newFakeSNS(t *testing.T, sqs *fakeSQS) (*fakeSNS, *httptest.Server) {
+	sns := &fakeSNS{sqs: sqs, subscriptions: make(map[string][]string), attributes: make(map[string]string)}
+	server := httptest.NewServer(sns)
+	t.Cleanup(server.Close)
+	return sns, server
+}
+
+func (f *fakeSNS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	action := r.Form.Get("Action")
+
+	f.sqs.mu.Lock()
+	defer f.sqs.mu.Unlock()
+
+	var result string
+	switch action {
+	case "CreateTopic":
+		result = "<TopicArn>arn:aws:sns:eu-west-1:123456789012:" + r.Form.Get("Name") + "</TopicArn>"
+
+	case "Subscribe":
+		topicARN, queueARN := r.Form.Get("TopicArn"), r.Form.Get("Endpoint")
+		f.subscriptions[topicARN] = append(f.subscriptions[topicARN], queueARN)
+		f.attributes[queueARN] = r.Form.Get("Attributes.entry.1.key") + "=" + r.Form.Get("Attributes.entry.1.value")
+		result = "<SubscriptionArn>" + topicARN + ":subscription</SubscriptionArn>"
+
+	case "Publish":
+		for _, queueARN := range f.subscriptions[r.Form.Get("TopicArn")] {
+			queueURL := "http://sqs.local/123456789012/" + queueARN[strings.LastIndex(queueARN, ":")+1:]
+			f.sqs.messages[queueURL] = append(f.sqs.messages[queueURL], r.Form.Get("Message"))
+		}
+		result = "<MessageId>message-1</MessageId>"
+	}
+
+	w.Header().Set("Content-Type", "text/xml")
+	fmt.Fprintf(w, "<%[1]sResponse><%[1]sResult>%[2]s</%[1]sResult></%[1]sResponse>", action, result)
+}
+
+// useAWSCredentials makes the default credential chain resolve the access key ID from the environment
+func useAWSCredentials(t *testing.T, accessKeyID string) {
+	t.Setenv("AWS_ACCESS_KEY_ID", accessKeyID)
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_CONFIG_FILE", t.TempDir()+"/config")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", t.TempDir()+"/credentials")
+}
+
+func testSQSConfig(t *testing.T, endpoint string) SQSConfig {
+	useAWSCredentials(t, "AKID")
+	return SQSConfig{
+		Region:            "eu-west-1",
+		Endpoint:          endpoint,
+		QueuePrefix:       "notifications",
+		VisibilityTimeout: 45 * time.Second,
+		MaxReceiveCount:   3,
+		Timeout:           2 * time.Second,
+	}
+}
+
+func TestNewSQSTransport_CreatesQueuesWithRedrivePolicy(t *testing.T) {
+	sqs, server := newFakeSQS(t)
+
+	transport, err := NewSQSTransport(testSQSConfig(t, server.URL))
+	require.NoError(t, err)
+	defer transport.Close()
+
+	sqs.mu.Lock()
+	defer sqs.mu.Unlock()
+	assert.Len(t, sqs.attributes, 2*len(PublishTopics))
+	assert.Contains(t, sqs.attributes, "notifications-notification-events")
+	assert.Contains(t, sqs.attributes, "notifications-android-push-dlq")
+
+	attributes := sqs.attributes["notifications-email"]
+	assert.Equal(t, "45", attributes["VisibilityTimeout"])
+	assert.JSONEq(t, `{"deadLetterTargetArn":"arn:aws:sqs:eu-west-1:123456789012:notifications-email-dlq","maxReceiveCount":"3"}`,
+		attributes["RedrivePolicy"])
+}
+
+func TestSQSTransport_PublishAndReceive(t *testing.T) {
+	sqs, server := newFakeSQS(t)
+
+	config := testSQSConfig(t, server.URL)
+	config.VisibilityTimeout = 2 * time.Second
+	transport, err := NewSQSTransport(config)
+	require.NoError(t, err)
+
+	received := make(chan Message, 2)
+	require.NoError(t, transport.Subscribe(TopicSlack, func(message Message) bool {
+		received <- message
+		return true
+	}))
+
+	require.NoError(t, transport.Publish(TopicSlack, []byte(`{"id":"n-1"}`)))
+	require.NoError(t, transport.Publish(TopicSlack, []byte(`{"id":"n-2"}`)))
+
+	var messages []Message
+	for len(messages) < 2 {
+		select {
+		case message := <-received:
+			messages = append(messages, message)
+		case <-time.After(2 * time.Second):
+			t.Fatal("no message received")
+		}
+	}
+	assert.Equal(t, `{"id":"n-1"}`, string(messages[0].Payload()))
+
+	// Messages in flight stay hidden until they are settled
+	assert.Eventually(t, func() bool {
+		sqs.mu.Lock()
+		defer sqs.mu.Unlock()
+		return sqs.visibility["handle-1"] == 2 && sqs.visibility["handle-2"] == 2
+	}, 3*time.Second, 50*time.Millisecond)
+
+	require.NoError(t, messages[0].Ack())
+	require.NoError(t, messages[1].Nack())
+	require.NoError(t, transport.Close())
+
+	sqs.mu.Lock()
+	assert.Equal(t, []string{"handle-1"}, sqs.deleted)
+	assert.Equal(t, 0, sqs.visibility["handle-2"], "failed messages become visible again right away")
+	sqs.mu.Unlock()
+
+	assert.ErrorContains(t, transport.Publish("sms", nil), "unknown topic")
+}
+
+func TestSQSTransport_RejectedMessagesBecomeVisible(t *testing.T) {
+	sqs, server := newFakeSQS(t)
+
+	transport, err := NewSQSTransport(testSQSConfig(t, server.URL))
+	require.NoError(t, err)
+	defer transport.Close()
+
+	require.NoError(t, transport.Publish(TopicEmail, []byte(`{"id":"n-1"}`)))
+	require.NoError(t, transport.Publish(TopicEmail, []byte(`{"id":"n-2"}`)))
+
+	rejected := make(chan struct{})
+	require.NoError(t, transport.Subscribe(TopicEmail, func(message Message) bool {
+		close(rejected)
+		return false
+	}))
+	<-rejected
+
+	assert.Eventually(t, func() bool {
+		sqs.mu.Lock()
+		defer sqs.mu.Unlock()
+		return len(sqs.visibility) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	sqs.mu.Lock()
+	defer sqs.mu.Unlock()
+	assert.Equal(t, map[string]int{"handle-1": 0, "handle-2": 0}, sqs.visibility)
+	assert.Empty(t, sqs.deleted)
+}
+
+func TestNewSQSTransport_APIError(t *testing.T) {
+	_, server := newFakeSQS(t)
+
+	config := testSQSConfig(t, server.URL)
+	useAWSCredentials(t, "unknown")
+	_, err := NewSQSTransport(config)
+	assert.ErrorContains(t, err, "InvalidClientTokenId: The security token included in the request is invalid.")
+}
+
+func TestSQSTransport_PublishesThroughSNS(t *testing.T) {
+	sqs, sqsServer := newFakeSQS(t)
+	sns, snsServer := newFakeSNS(t, sqs)
+
+	config := testSQSConfig(t, sqsServer.URL)
+	config.SNSTopicPrefix = "notification-topics"
+	config.SNSEndpoint = snsServer.URL
+	transport, err := NewSQSTransport(config)
+	require.NoError(t, err)
+	defer transport.Close()
+
+	sqs.mu.Lock()
+	queueARN := "arn:aws:sqs:eu-west-1:123456789012:notifications-notification-events"
+	topicARN := "arn:aws:sns:eu-west-1:123456789012:notification-topics-notification-events"
+	assert.Equal(t, []string{queueARN}, sns.subscriptions[topicARN])
+	assert.Equal(t, "RawMessageDelivery=true", sns.attributes[queueARN])
+	assert.Contains(t, sqs.attributes["notifications-notification-events"]["Policy"], `"aws:SourceArn":"`+topicARN+`"`)
+	sqs.mu.Unlock()
+
+	received := make(chan Message, 1)
+	require.NoError(t, transport.Subscribe(TopicEmail, func(message Message) bool {
+		received <- message
+		return true
+	}))
+	require.NoError(t, transport.Publish(TopicEmail, []byte(`{"id":"n-1"}`)))
+
+	select {
+	case message := <-received:
+		assert.Equal(t, `{"id":"n-1"}`, string(message.Payload()))
+		require.NoError(t, message.Ack())
+	case <-time.After(2 * time.Second):
+		t.Fatal("no message received")
+	}
+}
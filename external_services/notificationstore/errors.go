@@ -0,0 +1,9 @@
+package notificationstore
+
+import "errors"
+
+// Notification store errors
+var (
+	ErrInvalidConfiguration = errors.New("invalid notification storage configuration")
+	ErrNotFound             = errors.New("notification not found in the notification store")
+)
@@ -0,0 +1,272 @@
+package notificationstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// schema creates the notifications table and the indexes of the queries; it is applied when
+// the store is created
+const schema = `
+CREATE TABLE IF NOT EXISTS notifications (
+	id          TEXT PRIMARY KEY,
+	external_id TEXT NOT NULL DEFAULT '',
+	type        TEXT NOT NULL,
+	status      TEXT NOT NULL,
+	tenant      TEXT NOT NULL DEFAULT '',
+	tags        JSONB NOT NULL DEFAULT '[]',
+	created_at  TIMESTAMPTZ NOT NULL,
+	updated_at  TIMESTAMPTZ NOT NULL,
+	record      JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS notifications_created_at_idx ON notifications (created_at);
+CREATE INDEX IF NOT EXISTS notifications_external_id_idx ON notifications (external_id, created_at) WHERE external_id <> '';
+CREATE INDEX IF NOT EXISTS notifications_status_idx ON notifications (status, created_at);
+CREATE INDEX IF NOT EXISTS notifications_tags_idx ON notifications USING GIN (tags);
+`
+
+// saveStatement upserts a document. Concurrent saves of one notification may arrive out of
+// order, so an older snapshot never replaces a newer one.
+const saveStatement = `
+INSERT INTO notifications (id, external_id, type, status, tenant, tags, created_at, updated_at, record)
+VALUES ($1, $2, $3, $4, $5, $6::jsonb, $7, $8, $9::jsonb)
+ON CONFLICT (id) DO UPDATE SET
+	external_id = EXCLUDED.external_id,
+	type        = EXCLUDED.type,
+	status      = EXCLUDED.status,
+	tenant      = EXCLUDED.tenant,
+	tags        = EXCLUDED.tags,
+	created_at  = EXCLUDED.created_at,
+	updated_at  = EXCLUDED.updated_at,
+	record      = EXCLUDED.record
+WHERE notifications.updated_at <= EXCLUDED.updated_at`
+
+// PostgresStore keeps notification documents in the notifications table of a PostgreSQL
+// database, connected to through the database/sql adapter of pgx
+type PostgresStore struct {
+	db     *sql.DB
+	config Config
+}
+
+// NewPostgresStore connects to the database of config and creates the notifications table
+// if it does not exist. The URL takes the parameters libpq does, such as sslmode and
+// connect_timeout.
+func NewPostgresStore(config Config) (*PostgresStore, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("%w: a PostgreSQL URL is required", ErrInvalidConfiguration)
+	}
+	connConfig, err := pgx.ParseConfig(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfiguration, err)
+	}
+	db := stdlib.OpenDB(*connConfig)
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	db.SetMaxIdleConns(config.MaxOpenConns)
+
+	store := &PostgresStore{db: db, config: config}
+	ctx, cancel := store.context(context.Background())
+	defer cancel()
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create notifications table: %w", err)
+	}
+	return store, nil
+}
+
+// context bounds an operation by the configured timeout
+func (s *PostgresStore) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.config.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.config.Timeout)
+}
+
+// Save implements Store
+func (s *PostgresStore) Save(ctx context.Context, document Document) error {
+	tags := document.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	encodedTags, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+	_, err = s.db.ExecContext(ctx, saveStatement,
+		document.ID,
+		document.ExternalID,
+		document.Type,
+		document.Status,
+		document.Tenant,
+		string(encodedTags),
+		document.CreatedAt,
+		document.UpdatedAt,
+		string(document.Data),
+	)
+	if err != nil {
+		return fmt.Errorf("save notification %s: %w", document.ID, err)
+	}
+	return nil
+}
+
+// Load implements Store
+func (s *PostgresStore) Load(ctx context.Context, id string) (*Document, error) {
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, selectColumns+" WHERE id = $1", id)
+	if err != nil {
+		return nil, fmt.Errorf("load notification %s: %w", id, err)
+	}
+	documents, err := scanDocuments(rows)
+	if err != nil {
+		return nil, fmt.Errorf("load notification %s: %w", id, err)
+	}
+	if len(documents) == 0 {
+		return nil, ErrNotFound
+	}
+	return &documents[0], nil
+}
+
+// Find implements Store
+func (s *PostgresStore) Find(ctx context.Context, query Query) ([]Document, error) {
+	statement, args, err := findStatement(query)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+	rows, err := s.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("find notifications: %w", err)
+	}
+	documents, err := scanDocuments(rows)
+	if err != nil {
+		return nil, fmt.Errorf("find notifications: %w", err)
+	}
+	return documents, nil
+}
+
+// Delete implements Store
+func (s *PostgresStore) Delete(ctx context.Context, id string) error {
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM notifications WHERE id = $1", id); err != nil {
+		return fmt.Errorf("delete notification %s: %w", id, err)
+	}
+	return nil
+}
+
+// CountByStatus implements Store
+func (s *PostgresStore) CountByStatus(ctx context.Context) (map[string]int, error) {
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT status, count(*) FROM notifications GROUP BY status")
+	if err != nil {
+		return nil, fmt.Errorf("count notifications: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("count notifications: %w", err)
+		}
+		counts[status] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("count notifications: %w", err)
+	}
+	return counts, nil
+}
+
+// Close implements Store
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
+
+// selectColumns selects the documents of the notifications table
+const selectColumns = "SELECT id, external_id, type, status, tenant, tags, created_at, updated_at, record FROM notifications"
+
+// findStatement builds the statement and arguments of a query
+func findStatement(query Query) (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.Replace(condition, "?", "$"+strconv.Itoa(len(args)), 1))
+	}
+
+	if query.ExternalID != "" {
+		add("external_id = ?", query.ExternalID)
+	}
+	if query.Status != "" {
+		add("status = ?", query.Status)
+	}
+	if query.Type != "" {
+		add("type = ?", query.Type)
+	}
+	if len(query.Tags) > 0 {
+		tags, err := json.Marshal(query.Tags)
+		if err != nil {
+			return "", nil, err
+		}
+		add("tags @> ?::jsonb", string(tags))
+	}
+
+	statement := selectColumns
+	if len(conditions) > 0 {
+		statement += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	statement += " ORDER BY created_at, id"
+	if query.Limit > 0 {
+		statement += " LIMIT " + strconv.Itoa(query.Limit)
+	}
+	return statement, args, nil
+}
+
+// scanDocuments reads the documents of rows selected with selectColumns and closes them
+func scanDocuments(rows *sql.Rows) ([]Document, error) {
+	defer rows.Close()
+
+	var documents []Document
+	for rows.Next() {
+		var document Document
+		var tags, data string
+		if err := rows.Scan(
+			&document.ID,
+			&document.ExternalID,
+			&document.Type,
+			&document.Status,
+			&document.Tenant,
+			&tags,
+			&document.CreatedAt,
+			&document.UpdatedAt,
+			&data,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(tags), &document.Tags); err != nil {
+			return nil, fmt.Errorf("decode tags of notification %s: %w", document.ID, err)
+		}
+		document.Data = []byte(data)
+		documents = append(documents, document)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return documents, nil
+}
@@ -0,0 +1,9 @@
+package objectstore
+
+import "errors"
+
+// Object store errors
+var (
+	ErrInvalidConfiguration = errors.New("invalid object store configuration")
+	ErrRequestFailed        = errors.New("object store request failed")
+)
@@ -0,0 +1,63 @@
+package objectstore
+
+import (
+	"context"
+	"sync"
+)
+
+// Object is an object kept by a MemoryStore
+type Object struct {
+	ContentType string
+	Content     []byte
+}
+
+// MemoryStore keeps objects in memory. Its URLs are not served, so it is meant for development
+// and tests.
+type MemoryStore struct {
+	mu            sync.RWMutex
+	bucket        string
+	publicBaseURL string
+	objects       map[string]Object
+}
+
+// NewMemoryStore creates an empty memory store; objects are served at <publicBaseURL>/<key> when
+// it is set and at memory://<bucket>/<key> otherwise
+func NewMemoryStore(bucket, publicBaseURL string) *MemoryStore {
+	return &MemoryStore{
+		bucket:        bucket,
+		publicBaseURL: publicBaseURL,
+		objects:       make(map[string]Object),
+	}
+}
+
+// Put stores content under key
+func (s *MemoryStore) Put(ctx context.Context, key, contentType string, content []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = Object{ContentType: contentType, Content: append([]byte(nil), content...)}
+	return nil
+}
+
+// Delete removes the object stored under key
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	return nil
+}
+
+// URL returns the URL of the object stored under key
+func (s *MemoryStore) URL(key string) string {
+	if s.publicBaseURL != "" {
+		return s.publicBaseURL + "/" + key
+	}
+	return "memory://" + s.bucket + "/" + key
+}
+
+// Get returns the object stored under key
+func (s *MemoryStore) Get(key string) (Object, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	object, exists := s.objects[key]
+	return object, exists
+}
@@ -0,0 +1,93 @@
+// Package objectstore stores notification assets, such as the images templates reference, in
+// Amazon S3, Google Cloud Storage or memory
+package objectstore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gaurav2721/notification-service/constants"
+)
+
+// Object store providers
+const (
+	ProviderS3     = "s3"
+	ProviderGCS    = "gcs"
+	ProviderMemory = "memory"
+)
+
+// ObjectStore stores objects under keys and serves them at durable URLs
+type ObjectStore interface {
+	// Put stores content under key, replacing any object stored under it
+	Put(ctx context.Context, key, contentType string, content []byte) error
+	// Delete removes the object stored under key; deleting a missing object succeeds
+	Delete(ctx context.Context, key string) error
+	// URL returns the durable URL the object stored under key is served at
+	URL(key string) string
+}
+
+// Config selects and configures the object store
+type Config struct {
+	// Provider is s3, gcs or memory; empty disables the object store. The memory provider keeps
+	// objects in the process only and its URLs are not served, so it is meant for development.
+	Provider string
+
+	Bucket string
+
+	// Region of the bucket; Google Cloud Storage uses auto
+	Region string
+
+	// Endpoint overrides https://s3.<region>.amazonaws.com for s3, e.g. for MinIO, and
+	// https://storage.googleapis.com for gcs
+	Endpoint string
+
+	// Credentials are AWS access keys for s3 and HMAC keys of a service account for gcs. Without
+	// them, s3 uses the default credential chain of the AWS SDK.
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// PublicBaseURL, when set, serves objects at <PublicBaseURL>/<key>, e.g. from a CDN in front
+	// of the bucket, instead of at the bucket URL
+	PublicBaseURL string
+
+	// Timeout bounds every request to the object store
+	Timeout time.Duration
+}
+
+// LoadConfigFromEnv reads the object store configuration from environment variables
+func LoadConfigFromEnv() Config {
+	config := Config{
+		Provider:        strings.ToLower(strings.TrimSpace(os.Getenv(constants.MediaStoreEnvVar))),
+		Bucket:          os.Getenv(constants.MediaBucketEnvVar),
+		Region:          os.Getenv(constants.MediaStoreRegionEnvVar),
+		Endpoint:        os.Getenv(constants.MediaStoreEndpointEnvVar),
+		AccessKeyID:     os.Getenv(constants.MediaStoreAccessKeyIDEnvVar),
+		SecretAccessKey: os.Getenv(constants.MediaStoreSecretAccessKeyEnvVar),
+		PublicBaseURL:   strings.TrimSuffix(os.Getenv(constants.MediaPublicBaseURLEnvVar), "/"),
+		Timeout:         time.Duration(constants.DefaultMediaStoreTimeoutSeconds) * time.Second,
+	}
+	if config.Region == "" {
+		config.Region = os.Getenv(constants.AWSRegionEnvVar)
+	}
+	if seconds, err := strconv.Atoi(os.Getenv(constants.MediaStoreTimeoutSecondsEnvVar)); err == nil && seconds > 0 {
+		config.Timeout = time.Duration(seconds) * time.Second
+	}
+	return config
+}
+
+// New returns the object store of config, or nil when no provider is configured
+func New(config Config) (ObjectStore, error) {
+	switch config.Provider {
+	case "":
+		return nil, nil
+	case ProviderMemory:
+		return NewMemoryStore(config.Bucket, config.PublicBaseURL), nil
+	case ProviderS3, ProviderGCS:
+		return NewS3Store(config)
+	}
+	return nil, fmt.Errorf("%w: unknown provider %q, expected s3, gcs or memory", ErrInvalidConfiguration, config.Provider)
+}
@@ -0,0 +1,110 @@
+package objectstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestS3Store_PutAndDelete(t *testing.T) {
+	type request struct {
+		method, path, contentType, contentSHA, authorization, body string
+	}
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, request{r.Method, r.URL.EscapedPath(), r.Header.Get("Content-Type"),
+			r.Header.Get("X-Amz-Content-Sha256"), r.Header.Get("Authorization"), string(body)})
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	store, err := NewS3Store(Config{
+		Provider:        ProviderS3,
+		Bucket:          "assets",
+		Region:          "eu-west-1",
+		Endpoint:        server.URL,
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(context.Background(), "media/logo one.png", "image/png", []byte("png")))
+	// Deleting a missing object succeeds
+	require.NoError(t, store.Delete(context.Background(), "media/logo one.png"))
+
+	sum := sha256.Sum256([]byte("png"))
+	require.Len(t, requests, 2)
+	assert.Equal(t, http.MethodPut, requests[0].method)
+	assert.Equal(t, "/assets/media/logo%20one.png", requests[0].path)
+	assert.Equal(t, "image/png", requests[0].contentType)
+	assert.Equal(t, "png", requests[0].body)
+	assert.Equal(t, hex.EncodeToString(sum[:]), requests[0].contentSHA)
+	assert.True(t, strings.HasPrefix(requests[0].authorization, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"))
+	assert.Contains(t, requests[0].authorization, "/eu-west-1/s3/aws4_request")
+	assert.Contains(t, requests[0].authorization, "x-amz-content-sha256")
+	assert.Equal(t, http.MethodDelete, requests[1].method)
+
+	assert.Equal(t, server.URL+"/assets/media/logo%20one.png", store.URL("media/logo one.png"))
+}
+
+func TestS3Store_RequestFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "<Error><Code>AccessDenied</Code></Error>", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	store, err := NewS3Store(Config{Provider: ProviderS3, Bucket: "assets", Endpoint: server.URL, AccessKeyID: "id", SecretAccessKey: "secret"})
+	require.NoError(t, err)
+
+	err = store.Put(context.Background(), "media/logo.png", "image/png", []byte("png"))
+	assert.ErrorIs(t, err, ErrRequestFailed)
+	assert.Contains(t, err.Error(), "AccessDenied")
+}
+
+func TestNew(t *testing.T) {
+	store, err := New(Config{})
+	require.NoError(t, err)
+	assert.Nil(t, store)
+
+	_, err = New(Config{Provider: "ftp"})
+	assert.ErrorIs(t, err, ErrInvalidConfiguration)
+	_, err = New(Config{Provider: ProviderS3, Bucket: "assets", AccessKeyID: "id"})
+	assert.ErrorIs(t, err, ErrInvalidConfiguration)
+	_, err = New(Config{Provider: ProviderGCS, Bucket: "assets"})
+	assert.ErrorIs(t, err, ErrInvalidConfiguration)
+
+	// Amazon S3 falls back to the default credential chain
+	store, err = New(Config{Provider: ProviderS3, Bucket: "assets", Region: "eu-west-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://s3.eu-west-1.amazonaws.com/assets/media/logo.png", store.URL("media/logo.png"))
+
+	// Google Cloud Storage is reached through its XML API with HMAC keys
+	store, err = New(Config{Provider: ProviderGCS, Bucket: "assets", AccessKeyID: "GOOG1", SecretAccessKey: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://storage.googleapis.com/assets/media/logo.png", store.URL("media/logo.png"))
+	assert.Equal(t, "auto", store.(*S3Store).config.Region)
+
+	store, err = New(Config{Provider: ProviderS3, Bucket: "assets", AccessKeyID: "id", SecretAccessKey: "secret", PublicBaseURL: "https://cdn.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/media/logo.png", store.URL("media/logo.png"))
+	assert.Equal(t, "https://s3.us-east-1.amazonaws.com", store.(*S3Store).endpoint)
+
+	store, err = New(Config{Provider: ProviderMemory, Bucket: "assets"})
+	require.NoError(t, err)
+	require.NoError(t, store.Put(context.Background(), "media/logo.png", "image/png", []byte("png")))
+	object, exists := store.(*MemoryStore).Get("media/logo.png")
+	assert.True(t, exists)
+	assert.Equal(t, "image/png", object.ContentType)
+	assert.Equal(t, "memory://assets/media/logo.png", store.URL("media/logo.png"))
+}
@@ -0,0 +1,131 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/gaurav2721/notification-service/constants"
+)
+
+// gcsEndpoint is the XML API of Google Cloud Storage, which accepts requests signed with
+// Signature Version 4 and HMAC keys like Amazon S3 does
+const gcsEndpoint = "https://storage.googleapis.com"
+
+// S3Store stores objects in a bucket of Amazon S3 or of a service compatible with it, such as
+// Google Cloud Storage or MinIO, with path-style requests
+type S3Store struct {
+	config   Config
+	endpoint string
+	client   *s3.Client
+}
+
+// NewS3Store creates an object store for the bucket of config. Without access keys, Amazon S3 is
+// accessed with the credentials of the default credential chain of the AWS SDK.
+func NewS3Store(config Config) (*S3Store, error) {
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("%w: bucket is required", ErrInvalidConfiguration)
+	}
+	if (config.AccessKeyID == "") != (config.SecretAccessKey == "") {
+		return nil, fmt.Errorf("%w: access key ID and secret access key are set together", ErrInvalidConfiguration)
+	}
+	if config.Provider == ProviderGCS && config.AccessKeyID == "" {
+		return nil, fmt.Errorf("%w: HMAC keys are required for gcs", ErrInvalidConfiguration)
+	}
+
+	endpoint := config.Endpoint
+	switch {
+	case endpoint != "":
+	case config.Provider == ProviderGCS:
+		endpoint = gcsEndpoint
+	default:
+		if config.Region == "" {
+			config.Region = constants.DefaultAWSRegion
+		}
+		endpoint = "https://s3." + config.Region + ".amazonaws.com"
+	}
+	if config.Region == "" {
+		config.Region = "auto"
+	}
+	if parsed, err := url.Parse(endpoint); err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("%w: invalid endpoint %q", ErrInvalidConfiguration, endpoint)
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+
+	options := []func(*awsconfig.LoadOptions) error{
+		awsconfig.WithRegion(config.Region),
+		awsconfig.WithHTTPClient(awshttp.NewBuildableClient().WithTimeout(config.Timeout)),
+	}
+	if config.AccessKeyID != "" {
+		options = append(options, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(config.AccessKeyID, config.SecretAccessKey, "")))
+	}
+	awsConfig, err := awsconfig.LoadDefaultConfig(context.Background(), options...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfiguration, err)
+	}
+
+	return &S3Store{
+		config:   config,
+		endpoint: endpoint,
+		client: s3.NewFromConfig(awsConfig, func(o *s3.Options) {
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true
+		}),
+	}, nil
+}
+
+// objectURL is the path-style URL of the object stored under key
+func (s *S3Store) objectURL(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return s.endpoint + "/" + url.PathEscape(s.config.Bucket) + "/" + strings.Join(segments, "/")
+}
+
+// Put uploads content under key. Keys name their content for good, so objects are cached for a year.
+func (s *S3Store) Put(ctx context.Context, key, contentType string, content []byte) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:       aws.String(s.config.Bucket),
+		Key:          aws.String(key),
+		Body:         bytes.NewReader(content),
+		ContentType:  aws.String(contentType),
+		CacheControl: aws.String("public, max-age=31536000, immutable"),
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %s %s: %v", ErrRequestFailed, http.MethodPut, key, err)
+	}
+	return nil
+}
+
+// Delete removes the object stored under key
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(key),
+	})
+	// Deleting a missing object succeeds
+	var responseErr *awshttp.ResponseError
+	if err == nil || (errors.As(err, &responseErr) && responseErr.HTTPStatusCode() == http.StatusNotFound) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s %s: %v", ErrRequestFailed, http.MethodDelete, key, err)
+}
+
+// URL returns the public URL of the object stored under key
+func (s *S3Store) URL(key string) string {
+	if s.config.PublicBaseURL != "" {
+		return s.config.PublicBaseURL + "/" + key
+	}
+	return s.objectURL(key)
+}
@@ -0,0 +1,9 @@
+package pushapps
+
+import "errors"
+
+// Registry errors
+var (
+	ErrInvalidRegistry = errors.New("invalid push app registry")
+	ErrUnknownApp      = errors.New("unknown push app")
+)
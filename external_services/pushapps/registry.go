@@ -0,0 +1,140 @@
+// Package pushapps holds the push credentials of the mobile apps notifications are sent to.
+// Devices name their app with an app ID; the APNS and FCM services look up the app's
+// credentials here and use their environment configuration for devices without one.
+package pushapps
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/gaurav2721/notification-service/constants"
+)
+
+// Registry maps app IDs to their push credentials
+type Registry struct {
+	Apps map[string]*App `json:"apps"`
+}
+
+// App holds the credentials of one mobile app; an app without iOS or Android builds
+// leaves the other platform empty
+type App struct {
+	APNS *APNSCredentials `json:"apns,omitempty"`
+	FCM  *FCMCredentials  `json:"fcm,omitempty"`
+}
+
+// APNSCredentials sign the pushes of an iOS app. Sandbox overrides them for development
+// builds; its empty fields fall back to the production values.
+type APNSCredentials struct {
+	BundleID       string           `json:"bundle_id"`
+	KeyID          string           `json:"key_id"`
+	TeamID         string           `json:"team_id"`
+	PrivateKeyPath string           `json:"private_key_path"`
+	Sandbox        *APNSCredentials `json:"sandbox,omitempty"`
+}
+
+// FCMCredentials authenticate the pushes of an Android app with its Firebase project
+type FCMCredentials struct {
+	ServerKey string `json:"server_key"`
+}
+
+// SandboxCredentials returns the credentials used for development builds of the app
+func (c *APNSCredentials) SandboxCredentials() *APNSCredentials {
+	sandbox := *c
+	sandbox.Sandbox = nil
+	if c.Sandbox == nil {
+		return &sandbox
+	}
+	if c.Sandbox.BundleID != "" {
+		sandbox.BundleID = c.Sandbox.BundleID
+	}
+	if c.Sandbox.KeyID != "" {
+		sandbox.KeyID = c.Sandbox.KeyID
+	}
+	if c.Sandbox.TeamID != "" {
+		sandbox.TeamID = c.Sandbox.TeamID
+	}
+	if c.Sandbox.PrivateKeyPath != "" {
+		sandbox.PrivateKeyPath = c.Sandbox.PrivateKeyPath
+	}
+	return &sandbox
+}
+
+// LoadFromEnv loads the registry file named by PUSH_APPS_PATH. Without it the registry is
+// empty and only the default credentials are used.
+func LoadFromEnv() (*Registry, error) {
+	path := os.Getenv(constants.PUSH_APPS_PATH)
+	if path == "" {
+		return &Registry{}, nil
+	}
+	return Load(path)
+}
+
+// Load reads a JSON registry file
+func Load(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read push app registry: %w", err)
+	}
+
+	registry, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return registry, nil
+}
+
+// Parse parses and validates a JSON registry
+func Parse(data []byte) (*Registry, error) {
+	var registry Registry
+	if err := json.Unmarshal(data, &registry); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRegistry, err)
+	}
+	if err := registry.validate(); err != nil {
+		return nil, err
+	}
+	return &registry, nil
+}
+
+// validate checks that every configured platform has complete credentials
+func (r *Registry) validate() error {
+	for id, app := range r.Apps {
+		if id == "" {
+			return fmt.Errorf("%w: app ID cannot be empty", ErrInvalidRegistry)
+		}
+		if app == nil || (app.APNS == nil && app.FCM == nil) {
+			return fmt.Errorf("%w: app %s has no APNS or FCM credentials", ErrInvalidRegistry, id)
+		}
+		if apns := app.APNS; apns != nil {
+			if apns.BundleID == "" || apns.KeyID == "" || apns.TeamID == "" || apns.PrivateKeyPath == "" {
+				return fmt.Errorf("%w: app %s needs bundle_id, key_id, team_id and private_key_path for APNS", ErrInvalidRegistry, id)
+			}
+		}
+		if app.FCM != nil && app.FCM.ServerKey == "" {
+			return fmt.Errorf("%w: app %s needs a server_key for FCM", ErrInvalidRegistry, id)
+		}
+	}
+	return nil
+}
+
+// APNSApps returns the apps with APNS credentials
+func (r *Registry) APNSApps() map[string]*APNSCredentials {
+	apps := make(map[string]*APNSCredentials)
+	for id, app := range r.Apps {
+		if app.APNS != nil {
+			apps[id] = app.APNS
+		}
+	}
+	return apps
+}
+
+// FCMApps returns the apps with FCM credentials
+func (r *Registry) FCMApps() map[string]*FCMCredentials {
+	apps := make(map[string]*FCMCredentials)
+	for id, app := range r.Apps {
+		if app.FCM != nil {
+			apps[id] = app.FCM
+		}
+	}
+	return apps
+}
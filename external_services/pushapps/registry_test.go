@@ -0,0 +1,81 @@
+package pushapps
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRegistry = `{
+  "apps": {
+    "consumer": {
+      "apns": {
+        "bundle_id": "com.example.consumer",
+        "key_id": "KEY",
+        "team_id": "TEAM",
+        "private_key_path": "/keys/consumer.p8",
+        "sandbox": {"bundle_id": "com.example.consumer.dev"}
+      },
+      "fcm": {"server_key": "consumer-key"}
+    },
+    "driver": {
+      "fcm": {"server_key": "driver-key"}
+    }
+  }
+}`
+
+func TestParse(t *testing.T) {
+	registry, err := Parse([]byte(testRegistry))
+	require.NoError(t, err)
+
+	apnsApps := registry.APNSApps()
+	require.Len(t, apnsApps, 1)
+	assert.Equal(t, "com.example.consumer", apnsApps["consumer"].BundleID)
+
+	fcmApps := registry.FCMApps()
+	require.Len(t, fcmApps, 2)
+	assert.Equal(t, "driver-key", fcmApps["driver"].ServerKey)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"malformed":       `{"apps": [`,
+		"no credentials":  `{"apps": {"consumer": {}}}`,
+		"incomplete apns": `{"apps": {"consumer": {"apns": {"bundle_id": "com.example.consumer"}}}}`,
+		"empty fcm key":   `{"apps": {"consumer": {"fcm": {"server_key": ""}}}}`,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(data))
+			assert.ErrorIs(t, err, ErrInvalidRegistry)
+		})
+	}
+}
+
+func TestSandboxCredentials(t *testing.T) {
+	registry, err := Parse([]byte(testRegistry))
+	require.NoError(t, err)
+
+	sandbox := registry.Apps["consumer"].APNS.SandboxCredentials()
+	assert.Equal(t, "com.example.consumer.dev", sandbox.BundleID)
+	assert.Equal(t, "KEY", sandbox.KeyID)
+	assert.Equal(t, "/keys/consumer.p8", sandbox.PrivateKeyPath)
+	assert.Nil(t, sandbox.Sandbox)
+}
+
+func TestLoadFromEnv(t *testing.T) {
+	t.Setenv("PUSH_APPS_PATH", "")
+	registry, err := LoadFromEnv()
+	require.NoError(t, err)
+	assert.Empty(t, registry.Apps)
+
+	path := filepath.Join(t.TempDir(), "apps.json")
+	require.NoError(t, os.WriteFile(path, []byte(testRegistry), 0600))
+	t.Setenv("PUSH_APPS_PATH", path)
+	registry, err = LoadFromEnv()
+	require.NoError(t, err)
+	assert.Len(t, registry.Apps, 2)
+}
@@ -0,0 +1,13 @@
+package rcs
+
+import "errors"
+
+// RCS service errors
+var (
+	ErrRCSSendFailed = errors.New("failed to send rcs message")
+	ErrRCSAuthFailed = errors.New("rcs authentication failed")
+	// ErrRCSNotSupported is returned for recipients whose handset does not support RCS when no
+	// SMS fallback is configured
+	ErrRCSNotSupported = errors.New("the recipient's handset does not support rcs and no sms fallback is configured")
+	ErrSMSSendFailed   = errors.New("failed to send sms fallback")
+)
@@ -0,0 +1,8 @@
+package rcs
+
+import "context"
+
+// RCSService interface defines methods for RCS notifications
+type RCSService interface {
+	SendRCSMessage(ctx context.Context, notification interface{}) (interface{}, error)
+}
@@ -0,0 +1,86 @@
+package rcs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// MockRCSServiceImpl implements the RCSService interface for testing/mock purposes
+type MockRCSServiceImpl struct {
+	outputPath string
+}
+
+// NewMockRCSService creates a new mock RCS service instance
+func NewMockRCSService() RCSService {
+	// Create output directory if it doesn't exist
+	outputDir := "output"
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		panic(fmt.Sprintf("failed to create output directory: %v", err))
+	}
+
+	return &MockRCSServiceImpl{
+		outputPath: filepath.Join(outputDir, "rcs.txt"),
+	}
+}
+
+// SendRCSMessage writes RCS notification to file instead of sending actual message
+func (rs *MockRCSServiceImpl) SendRCSMessage(ctx context.Context, notification interface{}) (interface{}, error) {
+	// Type assertion to get the notification
+	notif, ok := notification.(*models.RCSNotificationRequest)
+	if !ok {
+		return nil, ErrRCSSendFailed
+	}
+
+	// Validate the RCS notification
+	if err := models.ValidateRCSNotification(notif); err != nil {
+		return nil, fmt.Errorf("rcs validation failed: %w", err)
+	}
+
+	// Create mock response
+	response := &models.RCSResponse{
+		ID:          notif.ID,
+		Status:      "mock_sent",
+		Message:     "RCS notification written to file (mock mode)",
+		SentAt:      time.Now(),
+		Channel:     "rcs",
+		DeliveredAs: models.RCSDeliveredAsRCS,
+	}
+
+	// Prepare notification data for file output
+	notificationData := map[string]interface{}{
+		"timestamp": time.Now().Format(time.RFC3339),
+		"id":        notif.ID,
+		"content":   notif.Content,
+		"recipient": notif.Recipient,
+		"sms_text":  notif.Content.SMSText(),
+		"status":    "mock_sent",
+		"channel":   "rcs",
+	}
+
+	// Convert to JSON
+	jsonData, err := json.MarshalIndent(notificationData, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal notification data: %w", err)
+	}
+
+	// Write to file
+	file, err := os.OpenFile(rs.outputPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output file: %w", err)
+	}
+	defer file.Close()
+
+	// Add separator and newline
+	output := fmt.Sprintf("=== RCS NOTIFICATION ===\n%s\n\n", string(jsonData))
+	if _, err := file.WriteString(output); err != nil {
+		return nil, fmt.Errorf("failed to write to output file: %w", err)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,318 @@
+package rcs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gaurav2721/notification-service/constants"
+	"github.com/gaurav2721/notification-service/external_services/googleauth"
+	"github.com/gaurav2721/notification-service/external_services/httpclient"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultAPIBaseURL is the RCS Business Messaging API agent messages are sent to
+	defaultAPIBaseURL = "https://rcsbusinessmessaging.googleapis.com"
+
+	// rbmScope lets a service account send messages as an RCS agent
+	rbmScope = "https://www.googleapis.com/auth/rcsbusinessmessaging"
+)
+
+// maxErrorBodyBytes bounds the part of an error response read for its message
+const maxErrorBodyBytes = 4096
+
+// errNotRCSCapable is returned for recipients whose handset cannot receive RCS messages
+var errNotRCSCapable = errors.New("recipient is not rcs capable")
+
+// RCSServiceImpl implements the RCSService interface with the RCS Business Messaging API of
+// Google. Recipients whose handset does not support RCS are sent an SMS through Twilio instead,
+// when the SMS fallback is configured.
+type RCSServiceImpl struct {
+	client     *http.Client
+	tokens     *googleauth.TokenSource
+	agentID    string
+	apiBaseURL string
+	// sms sends the fallback of recipients without RCS, nil when it is not configured
+	sms *smsSender
+}
+
+// Enabled reports whether RCS is turned on with RCS_ENABLED
+func Enabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(constants.RCS_ENABLED))
+	return enabled
+}
+
+// NewRCSService creates a new RCS service instance that connects through transport
+// It returns the mock service unless RCS is enabled and the agent is configured, and an error
+// for an invalid transport
+func NewRCSService(transport httpclient.TransportConfig) (RCSService, error) {
+	if !Enabled() {
+		return NewMockRCSService(), nil
+	}
+
+	client, err := httpclient.NewProviderClient("RCS", transport, constants.DefaultRCSTimeout*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	agentID := os.Getenv(constants.RCS_AGENT_ID)
+	path := os.Getenv(constants.RCS_CREDENTIALS_FILE)
+	if agentID == "" || path == "" {
+		logrus.Error("RCS_AGENT_ID and RCS_CREDENTIALS_FILE are required, using mock RCS service")
+		return NewMockRCSService(), nil
+	}
+	tokens, err := googleauth.LoadTokenSource(path, rbmScope, client)
+	if err != nil {
+		logrus.WithError(err).Error("Invalid RCS credentials, using mock RCS service")
+		return NewMockRCSService(), nil
+	}
+
+	service := &RCSServiceImpl{client: client, tokens: tokens, agentID: agentID, apiBaseURL: defaultAPIBaseURL}
+	// RCS recipients are still reached without the fallback, so the service keeps running without it
+	service.sms, err = loadSMSSender(client)
+	if err != nil {
+		logrus.WithError(err).Error("Invalid SMS fallback configuration, recipients without RCS will not be reached")
+	}
+	return service, nil
+}
+
+// SendRCSMessage sends an RCS notification, or its SMS text when the handset of the recipient
+// does not support RCS
+func (rs *RCSServiceImpl) SendRCSMessage(ctx context.Context, notification interface{}) (interface{}, error) {
+	// Type assertion to get the notification
+	notif, ok := notification.(*models.RCSNotificationRequest)
+	if !ok {
+		return nil, ErrRCSSendFailed
+	}
+
+	// Validate the RCS notification
+	if err := models.ValidateRCSNotification(notif); err != nil {
+		return nil, fmt.Errorf("rcs validation failed: %w", err)
+	}
+
+	var messageName string
+	for i, message := range buildMessages(notif) {
+		name, err := rs.sendAgentMessage(ctx, notif, messageID(notif, i), message)
+		if errors.Is(err, errNotRCSCapable) && i == 0 {
+			return rs.sendSMSFallback(ctx, notif)
+		}
+		if err != nil {
+			return nil, err
+		}
+		messageName = name
+	}
+
+	// Return success response
+	return &models.RCSResponse{
+		ID:          notif.ID,
+		Status:      "sent",
+		Message:     "RCS message sent successfully",
+		SentAt:      time.Now(),
+		Channel:     "rcs",
+		DeliveredAs: models.RCSDeliveredAsRCS,
+		MessageID:   messageName,
+	}, nil
+}
+
+// CheckConnection gets an access token for the agent without sending a message
+func (rs *RCSServiceImpl) CheckConnection(ctx context.Context) error {
+	if _, err := rs.tokens.Token(ctx); err != nil {
+		return fmt.Errorf("%w: %v", ErrRCSAuthFailed, err)
+	}
+	return nil
+}
+
+// sendAgentMessage sends one message of a notification and returns the name the API gave it.
+// Message IDs are derived from the notification, so a message that is sent again after a
+// failed attempt is recognized as a duplicate instead of reaching the recipient twice.
+func (rs *RCSServiceImpl) sendAgentMessage(ctx context.Context, notif *models.RCSNotificationRequest, id string, message *contentMessage) (string, error) {
+	body, err := json.Marshal(&agentMessage{ContentMessage: message})
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrRCSSendFailed, err)
+	}
+
+	query := url.Values{"messageId": {id}, "agentId": {rs.agentID}}
+	endpoint := rs.apiBaseURL + "/v1/phones/" + url.PathEscape(notif.Recipient) + "/agentMessages?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrRCSSendFailed, err)
+	}
+	token, err := rs.tokens.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrRCSAuthFailed, err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := rs.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrRCSSendFailed, err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		// The API answers 404 for phone numbers that cannot receive RCS messages
+		return "", errNotRCSCapable
+	case resp.StatusCode == http.StatusConflict:
+		// The message was sent by an earlier attempt
+		return "phones/" + notif.Recipient + "/agentMessages/" + id, nil
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return "", responseError(resp)
+	}
+
+	var sent struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&sent); err != nil {
+		return "", fmt.Errorf("%w: invalid response: %v", ErrRCSSendFailed, err)
+	}
+	return sent.Name, nil
+}
+
+// sendSMSFallback sends the SMS text of a notification to a recipient without RCS
+func (rs *RCSServiceImpl) sendSMSFallback(ctx context.Context, notif *models.RCSNotificationRequest) (interface{}, error) {
+	if rs.sms == nil {
+		return nil, ErrRCSNotSupported
+	}
+
+	sid, err := rs.sms.Send(ctx, notif.Recipient, notif.Content.SMSText())
+	if err != nil {
+		return nil, err
+	}
+	logrus.WithField("notification_id", notif.ID).Debug("Recipient does not support RCS, sent SMS fallback")
+
+	return &models.RCSResponse{
+		ID:          notif.ID,
+		Status:      "sent",
+		Message:     "Recipient does not support RCS, SMS sent instead",
+		SentAt:      time.Now(),
+		Channel:     "rcs",
+		DeliveredAs: models.RCSDeliveredAsSMS,
+		MessageID:   sid,
+	}, nil
+}
+
+// messageID returns the ID of the index-th message of a notification to its recipient
+func messageID(notif *models.RCSNotificationRequest, index int) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(fmt.Sprintf("rcs:%s:%s:%d", notif.ID, notif.Recipient, index))).String()
+}
+
+// responseError describes a failed response of the RCS Business Messaging API
+func responseError(resp *http.Response) error {
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+			Status  string `json:"status"`
+		} `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	message := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &body) == nil && body.Error.Message != "" {
+		message = body.Error.Status + ": " + body.Error.Message
+	}
+
+	cause := ErrRCSSendFailed
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		cause = ErrRCSAuthFailed
+	}
+	return fmt.Errorf("%w: status %d: %s", cause, resp.StatusCode, message)
+}
+
+// agentMessage is the RCS Business Messaging API message sent for a notification
+type agentMessage struct {
+	ContentMessage *contentMessage `json:"contentMessage"`
+}
+
+type contentMessage struct {
+	Text        string       `json:"text,omitempty"`
+	RichCard    *richCard    `json:"richCard,omitempty"`
+	Suggestions []suggestion `json:"suggestions,omitempty"`
+}
+
+type richCard struct {
+	StandaloneCard standaloneCard `json:"standaloneCard"`
+}
+
+type standaloneCard struct {
+	CardOrientation string      `json:"cardOrientation"`
+	CardContent     cardContent `json:"cardContent"`
+}
+
+type cardContent struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Media       *media `json:"media,omitempty"`
+}
+
+type media struct {
+	Height      string      `json:"height"`
+	ContentInfo contentInfo `json:"contentInfo"`
+}
+
+type contentInfo struct {
+	FileURL string `json:"fileUrl"`
+}
+
+type suggestion struct {
+	Reply  *suggestedReply  `json:"reply,omitempty"`
+	Action *suggestedAction `json:"action,omitempty"`
+}
+
+type suggestedReply struct {
+	Text         string `json:"text"`
+	PostbackData string `json:"postbackData"`
+}
+
+type suggestedAction struct {
+	Text          string        `json:"text"`
+	PostbackData  string        `json:"postbackData"`
+	OpenURLAction openURLAction `json:"openUrlAction"`
+}
+
+type openURLAction struct {
+	URL string `json:"url"`
+}
+
+// buildMessages converts a notification into the messages sent to its recipient: the text and
+// the card, each as a message of its own. The suggestions are shown with the last message.
+func buildMessages(notif *models.RCSNotificationRequest) []*contentMessage {
+	var messages []*contentMessage
+	if text := strings.TrimSpace(notif.Content.Text); text != "" {
+		messages = append(messages, &contentMessage{Text: text})
+	}
+	if source := notif.Content.Card; source != nil {
+		content := cardContent{Title: source.Title, Description: source.Description}
+		if source.MediaURL != "" {
+			content.Media = &media{Height: "MEDIUM", ContentInfo: contentInfo{FileURL: source.MediaURL}}
+		}
+		messages = append(messages, &contentMessage{RichCard: &richCard{
+			StandaloneCard: standaloneCard{CardOrientation: "VERTICAL", CardContent: content},
+		}})
+	}
+
+	last := messages[len(messages)-1]
+	for i, s := range notif.Content.Suggestions {
+		// Replies send their postback data to the agent, which tells the notification and the chip apart
+		postback := fmt.Sprintf("%s:%d", notif.ID, i)
+		if s.URL != "" {
+			last.Suggestions = append(last.Suggestions, suggestion{Action: &suggestedAction{
+				Text: s.Text, PostbackData: postback, OpenURLAction: openURLAction{URL: s.URL},
+			}})
+		} else {
+			last.Suggestions = append(last.Suggestions, suggestion{Reply: &suggestedReply{Text: s.Text, PostbackData: postback}})
+		}
+	}
+	return messages
+}
@@ -0,0 +1,176 @@
+package rcs
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/external_services/googleauth"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPhone = "+14155550123"
+
+// newTestService creates an RCS service whose agent API and token endpoint are server
+func newTestService(t *testing.T, server *httptest.Server) *RCSServiceImpl {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	data, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "agent@project.iam.gserviceaccount.com",
+		"private_key":  string(keyPEM),
+		"token_uri":    server.URL + "/token",
+	})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, os.WriteFile(path, data, 0600))
+
+	tokens, err := googleauth.LoadTokenSource(path, rbmScope, server.Client())
+	require.NoError(t, err)
+	return &RCSServiceImpl{client: server.Client(), tokens: tokens, agentID: "acme-agent", apiBaseURL: server.URL}
+}
+
+func testNotification() *models.RCSNotificationRequest {
+	return &models.RCSNotificationRequest{
+		ID:        "notif-1",
+		Type:      "rcs",
+		Recipient: testPhone,
+		Content: models.RCSContent{
+			Text: "Your order has shipped",
+			Card: &models.RCSCard{Title: "Order #1234", Description: "Arrives on Friday", MediaURL: "https://cdn.example.com/parcel.png"},
+			Suggestions: []models.RCSSuggestion{
+				{Text: "Track parcel", URL: "https://example.com/track/1234"},
+				{Text: "Thanks"},
+			},
+		},
+	}
+}
+
+func TestSendRCSMessage(t *testing.T) {
+	var posted []map[string]interface{}
+	var messageIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			w.Write([]byte(`{"access_token":"access-token","expires_in":3600}`))
+		case "/v1/phones/" + testPhone + "/agentMessages":
+			assert.Equal(t, "Bearer access-token", r.Header.Get("Authorization"))
+			assert.Equal(t, "acme-agent", r.URL.Query().Get("agentId"))
+			messageIDs = append(messageIDs, r.URL.Query().Get("messageId"))
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			posted = append(posted, body)
+			w.Write([]byte(`{"name":"phones/` + testPhone + `/agentMessages/` + r.URL.Query().Get("messageId") + `"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	service := newTestService(t, server)
+
+	result, err := service.SendRCSMessage(context.Background(), testNotification())
+	require.NoError(t, err)
+	response := result.(*models.RCSResponse)
+	assert.Equal(t, models.RCSDeliveredAsRCS, response.DeliveredAs)
+	assert.Equal(t, "phones/"+testPhone+"/agentMessages/"+messageIDs[1], response.MessageID)
+
+	// The text and the card are messages of their own, the suggestions come with the card
+	require.Len(t, posted, 2)
+	assert.Equal(t, "Your order has shipped", posted[0]["contentMessage"].(map[string]interface{})["text"])
+	card := posted[1]["contentMessage"].(map[string]interface{})
+	content := card["richCard"].(map[string]interface{})["standaloneCard"].(map[string]interface{})["cardContent"].(map[string]interface{})
+	assert.Equal(t, "Order #1234", content["title"])
+	assert.Equal(t, "https://cdn.example.com/parcel.png", content["media"].(map[string]interface{})["contentInfo"].(map[string]interface{})["fileUrl"])
+	suggestions := card["suggestions"].([]interface{})
+	require.Len(t, suggestions, 2)
+	assert.Equal(t, "https://example.com/track/1234", suggestions[0].(map[string]interface{})["action"].(map[string]interface{})["openUrlAction"].(map[string]interface{})["url"])
+	assert.Equal(t, "Thanks", suggestions[1].(map[string]interface{})["reply"].(map[string]interface{})["text"])
+
+	// Message IDs are the same when the notification is sent again, so duplicates are recognized
+	assert.NotEqual(t, messageIDs[0], messageIDs[1])
+	assert.Equal(t, messageIDs[0], messageID(testNotification(), 0))
+}
+
+func TestSendRCSMessageFallsBackToSMS(t *testing.T) {
+	var form map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			w.Write([]byte(`{"access_token":"access-token","expires_in":3600}`))
+		case strings.HasSuffix(r.URL.Path, "/agentMessages"):
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":404,"message":"Requested entity was not found.","status":"NOT_FOUND"}}`))
+		case r.URL.Path == "/2010-04-01/Accounts/AC123/Messages.json":
+			user, password, ok := r.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "AC123", user)
+			assert.Equal(t, "secret", password)
+			require.NoError(t, r.ParseForm())
+			form = map[string]string{"To": r.Form.Get("To"), "From": r.Form.Get("From"), "Body": r.Form.Get("Body")}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"sid":"SM123","status":"queued"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	service := newTestService(t, server)
+	notification := testNotification()
+
+	// Without the SMS fallback recipients without RCS cannot be reached
+	_, err := service.SendRCSMessage(context.Background(), notification)
+	assert.True(t, errors.Is(err, ErrRCSNotSupported), "got %v", err)
+
+	service.sms = &smsSender{client: server.Client(), apiURL: server.URL, accountSID: "AC123", authToken: "secret", from: "+14155550100"}
+	result, err := service.SendRCSMessage(context.Background(), notification)
+	require.NoError(t, err)
+	response := result.(*models.RCSResponse)
+	assert.Equal(t, models.RCSDeliveredAsSMS, response.DeliveredAs)
+	assert.Equal(t, "SM123", response.MessageID)
+	assert.Equal(t, map[string]string{
+		"To":   testPhone,
+		"From": "+14155550100",
+		"Body": "Your order has shipped\n\nOrder #1234\n\nArrives on Friday\n\nTrack parcel: https://example.com/track/1234",
+	}, form)
+}
+
+func TestSendRCSMessageErrors(t *testing.T) {
+	status := http.StatusConflict
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			w.Write([]byte(`{"access_token":"access-token","expires_in":3600}`))
+			return
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(`{"error":{"code":403,"message":"The caller does not have permission","status":"PERMISSION_DENIED"}}`))
+	}))
+	defer server.Close()
+	service := newTestService(t, server)
+
+	// A message sent by an earlier attempt is not sent again
+	_, err := service.SendRCSMessage(context.Background(), testNotification())
+	require.NoError(t, err)
+
+	status = http.StatusForbidden
+	_, err = service.SendRCSMessage(context.Background(), testNotification())
+	assert.True(t, errors.Is(err, ErrRCSAuthFailed), "got %v", err)
+	assert.Contains(t, err.Error(), "PERMISSION_DENIED")
+
+	notification := testNotification()
+	notification.Recipient = "4155550123"
+	_, err = service.SendRCSMessage(context.Background(), notification)
+	assert.True(t, errors.Is(err, models.ErrInvalidPhoneNumber), "got %v", err)
+}
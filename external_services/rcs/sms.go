@@ -0,0 +1,88 @@
+package rcs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/gaurav2721/notification-service/constants"
+)
+
+// defaultTwilioAPIURL is the Twilio API fallback SMS are sent through
+const defaultTwilioAPIURL = "https://api.twilio.com"
+
+// smsSender sends the SMS fallback of RCS notifications through the Twilio Messages API
+type smsSender struct {
+	client     *http.Client
+	apiURL     string
+	accountSID string
+	authToken  string
+	// from is the sending phone number, or the SID of a Twilio messaging service (MG...)
+	from string
+}
+
+// loadSMSSender reads the Twilio configuration of the SMS fallback. It returns nil without an
+// error when none is set, and an error when only a part of it is.
+func loadSMSSender(client *http.Client) (*smsSender, error) {
+	sender := &smsSender{
+		client:     client,
+		apiURL:     defaultTwilioAPIURL,
+		accountSID: os.Getenv(constants.TWILIO_ACCOUNT_SID),
+		authToken:  os.Getenv(constants.TwilioAuthTokenEnvVar),
+		from:       os.Getenv(constants.TWILIO_FROM_NUMBER),
+	}
+	if sender.accountSID == "" && sender.authToken == "" && sender.from == "" {
+		return nil, nil
+	}
+	if sender.accountSID == "" || sender.authToken == "" || sender.from == "" {
+		return nil, fmt.Errorf("TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and TWILIO_FROM_NUMBER are all required")
+	}
+	return sender, nil
+}
+
+// Send sends an SMS and returns the SID Twilio gave the message
+func (s *smsSender) Send(ctx context.Context, to, body string) (string, error) {
+	form := url.Values{"To": {to}, "Body": {body}}
+	if strings.HasPrefix(s.from, "MG") {
+		form.Set("MessagingServiceSid", s.from)
+	} else {
+		form.Set("From", s.from)
+	}
+
+	endpoint := s.apiURL + "/2010-04-01/Accounts/" + url.PathEscape(s.accountSID) + "/Messages.json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrSMSSendFailed, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(s.accountSID, s.authToken)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrSMSSendFailed, err)
+	}
+	defer resp.Body.Close()
+
+	var message struct {
+		SID     string `json:"sid"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	if err := json.Unmarshal(data, &message); err != nil && resp.StatusCode < 300 {
+		return "", fmt.Errorf("%w: invalid response: %v", ErrSMSSendFailed, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail := strings.TrimSpace(string(data))
+		if message.Message != "" {
+			detail = fmt.Sprintf("%d: %s", message.Code, message.Message)
+		}
+		return "", fmt.Errorf("%w: status %d: %s", ErrSMSSendFailed, resp.StatusCode, detail)
+	}
+	return message.SID, nil
+}
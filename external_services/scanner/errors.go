@@ -0,0 +1,9 @@
+package scanner
+
+import "errors"
+
+// Scanner errors
+var (
+	ErrInvalidScannerURL = errors.New("invalid scanner URL")
+	ErrScanFailed        = errors.New("scan failed")
+)
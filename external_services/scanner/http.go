@@ -0,0 +1,75 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxHTTPResponseBytes bounds the verdict read from an HTTP scanner
+const maxHTTPResponseBytes = 64 << 10
+
+// HTTPScanner posts targets as JSON to a scanning endpoint, which answers with
+// {"infected": true, "threat": "Eicar-Test-Signature"}
+type HTTPScanner struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewHTTPScanner creates a scanner posting to endpoint
+func NewHTTPScanner(endpoint string, timeout time.Duration) *HTTPScanner {
+	return &HTTPScanner{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+// httpScanRequest is the body posted to the scanning endpoint
+type httpScanRequest struct {
+	Kind        string `json:"kind"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type,omitempty"`
+	Content     []byte `json:"content,omitempty"` // base64 encoded
+}
+
+// Scan posts the target and reads the verdict
+func (s *HTTPScanner) Scan(ctx context.Context, target Target) (Verdict, error) {
+	body, err := json.Marshal(httpScanRequest{
+		Kind:        target.Kind,
+		Name:        target.Name,
+		ContentType: target.ContentType,
+		Content:     target.Content,
+	})
+	if err != nil {
+		return Verdict{}, fmt.Errorf("%w: %v", ErrScanFailed, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return Verdict{}, fmt.Errorf("%w: %v", ErrScanFailed, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("%w: %v", ErrScanFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Verdict{}, fmt.Errorf("%w: scanner returned status %d", ErrScanFailed, resp.StatusCode)
+	}
+
+	var verdict struct {
+		Infected bool   `json:"infected"`
+		Threat   string `json:"threat"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxHTTPResponseBytes)).Decode(&verdict); err != nil {
+		return Verdict{}, fmt.Errorf("%w: invalid scanner response: %v", ErrScanFailed, err)
+	}
+	return Verdict{Infected: verdict.Infected, Threat: verdict.Threat}, nil
+}
@@ -0,0 +1,157 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultICAPPort is the well-known ICAP port
+const defaultICAPPort = "1344"
+
+// headerValueReplacer removes line breaks from values written into encapsulated headers
+var headerValueReplacer = strings.NewReplacer("\r", "", "\n", "")
+
+// ICAPScanner scans targets with an ICAP server (RFC 3507) such as c-icap with ClamAV.
+// Attachments are sent for response modification and links for request modification. The
+// server answers 204 for clean targets; any modification or block page counts as a detection.
+type ICAPScanner struct {
+	service string // icap:// URL of the scanning service
+	address string // host:port of the ICAP server
+	timeout time.Duration
+	dialer  net.Dialer
+}
+
+// NewICAPScanner creates a scanner for the ICAP service at serviceURL
+func NewICAPScanner(serviceURL *url.URL, timeout time.Duration) *ICAPScanner {
+	address := serviceURL.Host
+	if serviceURL.Port() == "" {
+		address = net.JoinHostPort(serviceURL.Hostname(), defaultICAPPort)
+	}
+	return &ICAPScanner{
+		service: serviceURL.String(),
+		address: address,
+		timeout: timeout,
+	}
+}
+
+// Scan sends the target to the ICAP server and reads its verdict
+func (s *ICAPScanner) Scan(ctx context.Context, target Target) (Verdict, error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	conn, err := s.dialer.DialContext(ctx, "tcp", s.address)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("%w: %v", ErrScanFailed, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write(s.request(target)); err != nil {
+		return Verdict{}, fmt.Errorf("%w: %v", ErrScanFailed, err)
+	}
+	return readICAPVerdict(bufio.NewReader(conn))
+}
+
+// request encodes the ICAP request for a target. A link is encapsulated as the request for
+// it, an attachment as a response serving it.
+func (s *ICAPScanner) request(target Target) []byte {
+	var buf bytes.Buffer
+
+	if target.Kind == TargetURL {
+		host := ""
+		if link, err := url.Parse(target.Name); err == nil {
+			host = link.Host
+		}
+		reqHdr := fmt.Sprintf("GET %s HTTP/1.1\r\nHost: %s\r\n\r\n",
+			headerValueReplacer.Replace(target.Name), headerValueReplacer.Replace(host))
+		s.writeHeader(&buf, "REQMOD", fmt.Sprintf("req-hdr=0, null-body=%d", len(reqHdr)))
+		buf.WriteString(reqHdr)
+		return buf.Bytes()
+	}
+
+	contentType := headerValueReplacer.Replace(target.ContentType)
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	reqHdr := fmt.Sprintf("GET /%s HTTP/1.1\r\nHost: attachment\r\n\r\n", url.PathEscape(target.Name))
+	resHdr := fmt.Sprintf("HTTP/1.1 200 OK\r\nContent-Type: %s\r\nContent-Length: %d\r\n\r\n", contentType, len(target.Content))
+	s.writeHeader(&buf, "RESPMOD", fmt.Sprintf("req-hdr=0, res-hdr=%d, res-body=%d", len(reqHdr), len(reqHdr)+len(resHdr)))
+	buf.WriteString(reqHdr)
+	buf.WriteString(resHdr)
+
+	// The body is sent as a single chunk
+	if len(target.Content) > 0 {
+		fmt.Fprintf(&buf, "%x\r\n", len(target.Content))
+		buf.Write(target.Content)
+		buf.WriteString("\r\n")
+	}
+	buf.WriteString("0\r\n\r\n")
+	return buf.Bytes()
+}
+
+// writeHeader writes the ICAP request line and headers
+func (s *ICAPScanner) writeHeader(buf *bytes.Buffer, method, encapsulated string) {
+	fmt.Fprintf(buf, "%s %s ICAP/1.0\r\n", method, s.service)
+	fmt.Fprintf(buf, "Host: %s\r\n", s.address)
+	buf.WriteString("Allow: 204\r\n")
+	buf.WriteString("Connection: close\r\n")
+	fmt.Fprintf(buf, "Encapsulated: %s\r\n\r\n", encapsulated)
+}
+
+// readICAPVerdict reads the status and headers of an ICAP response
+func readICAPVerdict(r *bufio.Reader) (Verdict, error) {
+	reader := textproto.NewReader(r)
+	line, err := reader.ReadLine()
+	if err != nil {
+		return Verdict{}, fmt.Errorf("%w: %v", ErrScanFailed, err)
+	}
+
+	parts := strings.SplitN(line, " ", 3)
+	if len(parts) < 2 || !strings.HasPrefix(parts[0], "ICAP/") {
+		return Verdict{}, fmt.Errorf("%w: invalid ICAP status line %q", ErrScanFailed, line)
+	}
+	code, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return Verdict{}, fmt.Errorf("%w: invalid ICAP status line %q", ErrScanFailed, line)
+	}
+
+	header, err := reader.ReadMIMEHeader()
+	if err != nil {
+		return Verdict{}, fmt.Errorf("%w: %v", ErrScanFailed, err)
+	}
+
+	switch code {
+	case 204:
+		return Verdict{}, nil
+	case 200:
+		return Verdict{Infected: true, Threat: icapThreat(header)}, nil
+	}
+	return Verdict{}, fmt.Errorf("%w: ICAP server returned status %d", ErrScanFailed, code)
+}
+
+// icapThreat returns the threat name from the headers ICAP servers report detections in, e.g.
+// "X-Infection-Found: Type=0; Resolution=2; Threat=Eicar-Test-Signature;"
+func icapThreat(header textproto.MIMEHeader) string {
+	if virus := header.Get("X-Virus-ID"); virus != "" {
+		return strings.TrimSpace(virus)
+	}
+	for _, field := range strings.Split(header.Get("X-Infection-Found"), ";") {
+		if name, value, found := strings.Cut(strings.TrimSpace(field), "="); found && name == "Threat" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,51 @@
+// Package scanner checks email attachments and links for malware before they are sent
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Kinds of scan targets
+const (
+	TargetAttachment = "attachment"
+	TargetURL        = "url"
+)
+
+// Target is an attachment or a link to scan
+type Target struct {
+	Kind        string
+	Name        string // Filename of an attachment, or the URL of a link
+	ContentType string
+	Content     []byte // Content of an attachment, empty for links
+}
+
+// Verdict is the result of a scan
+type Verdict struct {
+	Infected bool
+	Threat   string // Name of the detected threat, when the scanner reports one
+}
+
+// Scanner scans attachments and links
+type Scanner interface {
+	Scan(ctx context.Context, target Target) (Verdict, error)
+}
+
+// New returns the scanner at rawURL: an ICAP scanner for icap:// URLs, such as
+// icap://clamav:1344/avscan, and an HTTP scanner for http:// and https:// URLs
+func New(rawURL string, timeout time.Duration) (Scanner, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidScannerURL, rawURL)
+	}
+
+	switch parsed.Scheme {
+	case "icap":
+		return NewICAPScanner(parsed, timeout), nil
+	case "http", "https":
+		return NewHTTPScanner(rawURL, timeout), nil
+	}
+	return nil, fmt.Errorf("%w: unsupported scheme %q, expected icap, http or https", ErrInvalidScannerURL, parsed.Scheme)
+}
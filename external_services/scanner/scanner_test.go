@@ -0,0 +1,149 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	s, err := New("icap://clamav/avscan", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "clamav:1344", s.(*ICAPScanner).address)
+
+	s, err = New("https://scanner.internal/scan", time.Second)
+	require.NoError(t, err)
+	assert.IsType(t, &HTTPScanner{}, s)
+
+	for _, invalid := range []string{"ftp://scanner/scan", "icap://", "not a url"} {
+		_, err := New(invalid, time.Second)
+		assert.ErrorIs(t, err, ErrInvalidScannerURL, invalid)
+	}
+}
+
+func TestHTTPScanner_Scan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req httpScanRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		switch {
+		case req.Name == "broken.pdf":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case strings.Contains(string(req.Content), "EICAR"):
+			_, _ = w.Write([]byte(`{"infected": true, "threat": "Eicar-Test-Signature"}`))
+		default:
+			_, _ = w.Write([]byte(`{"infected": false}`))
+		}
+	}))
+	defer server.Close()
+
+	s := NewHTTPScanner(server.URL, time.Second)
+	ctx := context.Background()
+
+	verdict, err := s.Scan(ctx, Target{Kind: TargetAttachment, Name: "report.pdf", Content: []byte("hello")})
+	require.NoError(t, err)
+	assert.False(t, verdict.Infected)
+
+	verdict, err = s.Scan(ctx, Target{Kind: TargetAttachment, Name: "eicar.com", Content: []byte("X5O!P%@AP EICAR")})
+	require.NoError(t, err)
+	assert.Equal(t, Verdict{Infected: true, Threat: "Eicar-Test-Signature"}, verdict)
+
+	_, err = s.Scan(ctx, Target{Kind: TargetAttachment, Name: "broken.pdf"})
+	assert.ErrorIs(t, err, ErrScanFailed)
+}
+
+// serveICAP answers one ICAP request per connection with the response chosen by respond
+func serveICAP(t *testing.T, respond func(method string, body string) string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := textproto.NewReader(bufio.NewReader(conn))
+				line, err := reader.ReadLine()
+				if err != nil {
+					return
+				}
+				if _, err := reader.ReadMIMEHeader(); err != nil {
+					return
+				}
+				// The client closes its side only after reading the response, so read what was sent so far
+				_ = conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+				rest, _ := io.ReadAll(reader.R)
+				_, _ = io.WriteString(conn, respond(strings.Fields(line)[0], string(rest)))
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestICAPScanner_Scan(t *testing.T) {
+	address := serveICAP(t, func(method string, body string) string {
+		switch {
+		case strings.Contains(body, "EICAR"):
+			return "ICAP/1.0 200 OK\r\nX-Infection-Found: Type=0; Resolution=2; Threat=Eicar-Test-Signature;\r\nEncapsulated: null-body=0\r\n\r\n"
+		case method == "REQMOD" && strings.Contains(body, "malware.example"):
+			return "ICAP/1.0 200 OK\r\nX-Virus-ID: Phishing.URL\r\nEncapsulated: null-body=0\r\n\r\n"
+		case strings.Contains(body, "broken"):
+			return "ICAP/1.0 500 Server Error\r\n\r\n"
+		}
+		return "ICAP/1.0 204 No Content\r\n\r\n"
+	})
+
+	s, err := New("icap://"+address+"/avscan", time.Second)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	verdict, err := s.Scan(ctx, Target{Kind: TargetAttachment, Name: "report.pdf", Content: []byte("hello")})
+	require.NoError(t, err)
+	assert.False(t, verdict.Infected)
+
+	verdict, err = s.Scan(ctx, Target{Kind: TargetAttachment, Name: "eicar.com", Content: []byte("X5O!P%@AP EICAR")})
+	require.NoError(t, err)
+	assert.Equal(t, Verdict{Infected: true, Threat: "Eicar-Test-Signature"}, verdict)
+
+	verdict, err = s.Scan(ctx, Target{Kind: TargetURL, Name: "https://malware.example/login"})
+	require.NoError(t, err)
+	assert.Equal(t, Verdict{Infected: true, Threat: "Phishing.URL"}, verdict)
+
+	_, err = s.Scan(ctx, Target{Kind: TargetAttachment, Name: "broken.pdf", Content: []byte("broken")})
+	assert.ErrorIs(t, err, ErrScanFailed)
+}
+
+func TestICAPScanner_Request(t *testing.T) {
+	s := NewICAPScanner(mustParse(t, "icap://clamav:1344/avscan"), time.Second)
+
+	request := string(s.request(Target{Kind: TargetAttachment, Name: "report.pdf", ContentType: "application/pdf\r\nX-Injected: 1", Content: []byte("hello")}))
+	assert.True(t, strings.HasPrefix(request, "RESPMOD icap://clamav:1344/avscan ICAP/1.0\r\n"))
+	assert.Contains(t, request, "Encapsulated: req-hdr=0, res-hdr=46, res-body=128\r\n")
+	assert.Contains(t, request, "Content-Type: application/pdfX-Injected: 1\r\n")
+	assert.True(t, strings.HasSuffix(request, "\r\n\r\n5\r\nhello\r\n0\r\n\r\n"))
+
+	request = string(s.request(Target{Kind: TargetURL, Name: "https://example.com/report"}))
+	assert.True(t, strings.HasPrefix(request, "REQMOD icap://clamav:1344/avscan ICAP/1.0\r\n"))
+	assert.True(t, strings.HasSuffix(request, "GET https://example.com/report HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+}
+
+func mustParse(t *testing.T, rawURL string) *url.URL {
+	parsed, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	return parsed
+}
@@ -7,4 +7,5 @@ var (
 	ErrSlackSendFailed   = errors.New("failed to send slack message")
 	ErrInvalidChannel    = errors.New("invalid slack channel")
 	ErrSlackTokenMissing = errors.New("slack bot token is missing")
+	ErrSlackAuthFailed   = errors.New("slack authentication failed")
 )
@@ -53,12 +53,13 @@ func (ss *MockSlackServiceImpl) SendSlackMessage(ctx context.Context, notificati
 
 	// Prepare notification data for file output
 	notificationData := map[string]interface{}{
-		"timestamp": time.Now().Format(time.RFC3339),
-		"id":        notif.ID,
-		"content":   notif.Content,
-		"recipient": notif.Recipient,
-		"status":    "mock_sent",
-		"channel":   "slack",
+		"timestamp":  time.Now().Format(time.RFC3339),
+		"id":         notif.ID,
+		"content":    notif.Content,
+		"recipient":  notif.Recipient,
+		"status":     "mock_sent",
+		"thread_key": notif.ThreadKey,
+		"channel":    "slack",
 	}
 
 	// Convert to JSON
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/gaurav2721/notification-service/constants"
+	"github.com/gaurav2721/notification-service/external_services/httpclient"
 	"github.com/gaurav2721/notification-service/models"
 	"github.com/slack-go/slack"
 )
@@ -15,25 +16,41 @@ import (
 type SlackServiceImpl struct {
 	client  *slack.Client
 	channel string
+	threads *threadStore
 }
 
-// NewSlackService creates a new Slack service instance
-// It checks environment variables and returns mock service if config is incomplete
-func NewSlackService() SlackService {
+// NewSlackService creates a new Slack service instance that connects through transport
+// It checks environment variables and returns mock service if config is incomplete. An invalid
+// transport is returned as an error.
+func NewSlackService(transport httpclient.TransportConfig) (SlackService, error) {
 	token := os.Getenv(constants.SLACK_BOT_TOKEN)
 	channel := os.Getenv(constants.SLACK_CHANNEL_ID)
 
 	// Check if all required environment variables are present and non-empty
 	if token == "" || channel == "" {
-		return NewMockSlackService()
+		return NewMockSlackService(), nil
 	}
 
-	client := slack.New(token)
+	client, err := NewClient(token, transport)
+	if err != nil {
+		return nil, err
+	}
 
 	return &SlackServiceImpl{
 		client:  client,
 		channel: channel,
+		threads: newThreadStore(maxThreads),
+	}, nil
+}
+
+// NewClient creates a Slack Web API client for token that connects through the Slack proxy and
+// TLS settings of transport
+func NewClient(token string, transport httpclient.TransportConfig) (*slack.Client, error) {
+	httpClient, err := httpclient.NewProviderClient("Slack", transport, constants.DefaultSlackTimeout*time.Second)
+	if err != nil {
+		return nil, err
 	}
+	return slack.New(token, slack.OptionHTTPClient(httpClient)), nil
 }
 
 // SendSlackMessage sends a Slack notification
@@ -52,21 +69,41 @@ func (ss *SlackServiceImpl) SendSlackMessage(ctx context.Context, notification i
 	// Extract text from content
 	text := notif.Content.Text
 
-	// Create Slack message
-	msg := slack.MsgOptionText(text, false)
+	// Create Slack message, as a reply if its thread was started before
+	options := []slack.MsgOption{slack.MsgOptionText(text, false)}
+	threadTS := ""
+	if notif.ThreadKey != "" {
+		threadTS = ss.threads.Get(ss.channel, notif.ThreadKey, time.Now())
+		if threadTS != "" {
+			options = append(options, slack.MsgOptionTS(threadTS))
+		}
+	}
 
 	// Send message
-	_, _, err := ss.client.PostMessage(ss.channel, msg)
+	_, ts, err := ss.client.PostMessage(ss.channel, options...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send slack message: %w", err)
 	}
+	if notif.ThreadKey != "" && threadTS == "" {
+		ss.threads.Start(ss.channel, notif.ThreadKey, ts, time.Now())
+		threadTS = ts
+	}
 
 	// Return success response
 	return &models.SlackResponse{
-		ID:      notif.ID,
-		Status:  "sent",
-		Message: "Slack message sent successfully",
-		SentAt:  time.Now(),
-		Channel: "slack",
+		ID:       notif.ID,
+		Status:   "sent",
+		Message:  "Slack message sent successfully",
+		SentAt:   time.Now(),
+		Channel:  "slack",
+		ThreadTS: threadTS,
 	}, nil
 }
+
+// CheckConnection verifies the bot token with Slack without posting a message
+func (ss *SlackServiceImpl) CheckConnection(ctx context.Context) error {
+	if _, err := ss.client.AuthTestContext(ctx); err != nil {
+		return fmt.Errorf("%w: %v", ErrSlackAuthFailed, err)
+	}
+	return nil
+}
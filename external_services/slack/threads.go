@@ -0,0 +1,71 @@
+package slack
+
+import (
+	"sync"
+	"time"
+)
+
+// maxThreads bounds the threads remembered; the least recently used are forgotten first
+const maxThreads = 10000
+
+// thread is the Slack thread of a thread key
+type thread struct {
+	ts       string
+	lastUsed time.Time
+}
+
+// threadStore remembers the timestamp of the first message posted for each thread key, so
+// later messages with the key are posted as replies to it
+type threadStore struct {
+	mu      sync.Mutex
+	threads map[string]*thread
+	max     int
+}
+
+// newThreadStore creates an empty thread store remembering up to max threads
+func newThreadStore(max int) *threadStore {
+	return &threadStore{
+		threads: make(map[string]*thread),
+		max:     max,
+	}
+}
+
+// threadID is the key of a thread in a channel
+func threadID(channel, key string) string {
+	return channel + "\x00" + key
+}
+
+// Get returns the timestamp of the thread of a key in a channel, empty if it has none yet
+func (s *threadStore) Get(channel, key string, now time.Time) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.threads[threadID(channel, key)]
+	if !ok {
+		return ""
+	}
+	t.lastUsed = now
+	return t.ts
+}
+
+// Start records the message that starts the thread of a key in a channel. A thread that was
+// started in the meantime is kept.
+func (s *threadStore) Start(channel, key, ts string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := threadID(channel, key)
+	if _, ok := s.threads[id]; ok {
+		return
+	}
+	if len(s.threads) >= s.max {
+		var oldest string
+		for existing, t := range s.threads {
+			if oldest == "" || t.lastUsed.Before(s.threads[oldest].lastUsed) {
+				oldest = existing
+			}
+		}
+		delete(s.threads, oldest)
+	}
+	s.threads[id] = &thread{ts: ts, lastUsed: now}
+}
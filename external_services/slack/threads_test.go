@@ -0,0 +1,27 @@
+package slack
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThreadStore(t *testing.T) {
+	store := newThreadStore(2)
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	assert.Empty(t, store.Get("C1", "order:42", now))
+	store.Start("C1", "order:42", "1700000000.000100", now)
+	store.Start("C1", "order:42", "1700000000.000200", now)
+	assert.Equal(t, "1700000000.000100", store.Get("C1", "order:42", now), "the first message starts the thread")
+	assert.Empty(t, store.Get("C2", "order:42", now), "threads are per channel")
+
+	// The least recently used thread is forgotten
+	store.Start("C1", "order:43", "1700000000.000300", now.Add(time.Minute))
+	store.Get("C1", "order:42", now.Add(2*time.Minute))
+	store.Start("C1", "order:44", "1700000000.000400", now.Add(3*time.Minute))
+	assert.Equal(t, "1700000000.000100", store.Get("C1", "order:42", now))
+	assert.Empty(t, store.Get("C1", "order:43", now))
+	assert.Equal(t, "1700000000.000400", store.Get("C1", "order:44", now))
+}
@@ -0,0 +1,44 @@
+package slackusersync
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gaurav2721/notification-service/constants"
+)
+
+// Config enables the sync and sets how often it runs
+type Config struct {
+	// Enabled turns the sync on; it also needs a bot token
+	Enabled bool
+
+	// Token is the Slack bot token, which needs the users:read and users:read.email scopes
+	Token string
+
+	// Interval between syncs; 0 syncs once at startup
+	Interval time.Duration
+}
+
+// LoadConfigFromEnv reads the sync configuration from environment variables
+func LoadConfigFromEnv() Config {
+	config := Config{
+		Token:    strings.TrimSpace(os.Getenv(constants.SLACK_BOT_TOKEN)),
+		Interval: time.Duration(constants.DefaultSlackUserSyncIntervalMinutes) * time.Minute,
+	}
+	config.Enabled, _ = strconv.ParseBool(os.Getenv(constants.SlackUserSyncEnabledEnvVar))
+	if minutes, err := strconv.Atoi(os.Getenv(constants.SlackUserSyncIntervalMinutesEnvVar)); err == nil && minutes >= 0 {
+		config.Interval = time.Duration(minutes) * time.Minute
+	}
+	return config
+}
+
+// Validate checks the settings a sync needs
+func (c Config) Validate() error {
+	if c.Token == "" {
+		return fmt.Errorf("%w: a Slack bot token is required", ErrInvalidConfiguration)
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+package slackusersync
+
+import "errors"
+
+// Slack user sync errors
+var (
+	ErrInvalidConfiguration = errors.New("invalid Slack user sync configuration")
+	// ErrEmailsHidden stops a sync when Slack returned members without email addresses, which
+	// happens when the bot token lacks the users:read.email scope
+	ErrEmailsHidden = errors.New("slack returned no member email addresses, the bot token needs the users:read.email scope")
+)
@@ -0,0 +1,220 @@
+// Package slackusersync fills in the Slack user IDs of users from the members of the Slack
+// workspace, matching them by email address, so Slack notifications reach users without their
+// IDs being configured one by one
+package slackusersync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaurav2721/notification-service/external_services/user"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/metrics"
+)
+
+var log = logger.Module("slack_user_sync")
+
+// Outcome label values of the sync metrics
+const (
+	outcomeLinked    = "linked"
+	outcomeUpdated   = "updated"
+	outcomeKept      = "kept"
+	outcomeUnmatched = "unmatched"
+	outcomeAmbiguous = "ambiguous"
+)
+
+var (
+	slackUserSyncUsersTotal = metrics.DefaultRegistry.NewCounterVec(
+		"slack_user_sync_users_total",
+		"Users handled by the Slack user sync by outcome.",
+		"outcome",
+	)
+	slackUserSyncLastSuccess = metrics.DefaultRegistry.NewGaugeVec(
+		"slack_user_sync_last_success_timestamp_seconds",
+		"Unix time of the last successful Slack user sync.",
+	)
+)
+
+// Status reports the outcome of a sync
+type Status struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Error      string    `json:"error,omitempty"`
+	// Linked users got a Slack user ID, Updated ones had one of a member that left
+	Linked    int `json:"linked"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	// Kept users have the ID of another active member, which was set on purpose
+	Kept int `json:"kept"`
+	// Unmatched users have no member with their email address, Ambiguous ones several
+	Unmatched int `json:"unmatched"`
+	Ambiguous int `json:"ambiguous"`
+}
+
+// Syncer syncs the Slack user IDs of users from a workspace
+type Syncer struct {
+	users     user.UserService
+	workspace Workspace
+	config    Config
+	now       func() time.Time
+
+	mutex  sync.Mutex
+	status *Status
+}
+
+// NewSyncer creates a syncer; config supplies the interval
+func NewSyncer(users user.UserService, workspace Workspace, config Config) *Syncer {
+	return &Syncer{
+		users:     users,
+		workspace: workspace,
+		config:    config,
+		now:       time.Now,
+	}
+}
+
+// Status returns the outcome of the last sync, nil before the first one
+func (s *Syncer) Status() *Status {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.status
+}
+
+// Run syncs at once and then every interval until ctx is done
+func (s *Syncer) Run(ctx context.Context) {
+	s.syncAndLog(ctx)
+	if s.config.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.syncAndLog(ctx)
+		}
+	}
+}
+
+// syncAndLog runs a sync and logs its outcome
+func (s *Syncer) syncAndLog(ctx context.Context) {
+	status, err := s.Sync(ctx)
+	if err != nil {
+		log.Error("Slack user sync failed", logger.Fields{"error": err.Error()})
+		return
+	}
+	log.Info("Slack user sync finished", logger.Fields{
+		"linked":    status.Linked,
+		"updated":   status.Updated,
+		"kept":      status.Kept,
+		"unmatched": status.Unmatched,
+		"ambiguous": status.Ambiguous,
+	})
+}
+
+// Sync reads the workspace members once and applies their IDs to the users. Syncs do not
+// overlap; a sync started while another one runs waits for it.
+func (s *Syncer) Sync(ctx context.Context) (*Status, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	status := &Status{StartedAt: s.now()}
+	err := s.sync(ctx, status)
+	status.FinishedAt = s.now()
+	if err != nil {
+		status.Error = err.Error()
+	} else {
+		slackUserSyncLastSuccess.Set(float64(status.FinishedAt.Unix()))
+	}
+	s.status = status
+	return status, err
+}
+
+// sync applies the member IDs to the users, recording the outcome in status
+func (s *Syncer) sync(ctx context.Context, status *Status) error {
+	members, err := s.workspace.Members(ctx)
+	if err != nil {
+		return fmt.Errorf("list Slack members: %w", err)
+	}
+
+	// Only active people are matched; bots and deactivated members cannot be messaged
+	active := make(map[string]bool, len(members))
+	byEmail := make(map[string][]string, len(members))
+	for _, member := range members {
+		if member.Deleted || member.Bot {
+			continue
+		}
+		active[member.ID] = true
+		if member.Email != "" {
+			email := strings.ToLower(member.Email)
+			byEmail[email] = append(byEmail[email], member.ID)
+		}
+	}
+	if len(active) > 0 && len(byEmail) == 0 {
+		return ErrEmailsHidden
+	}
+
+	users, err := s.users.ListUsers(user.UserFilter{})
+	if err != nil {
+		return fmt.Errorf("list users: %w", err)
+	}
+	for i := range users {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		u := &users[i]
+		if !u.IsActive || u.Email == "" {
+			continue
+		}
+
+		matches := byEmail[strings.ToLower(u.Email)]
+		switch {
+		case len(matches) == 0:
+			s.record(status, outcomeUnmatched)
+			continue
+		case len(matches) > 1:
+			s.record(status, outcomeAmbiguous)
+			log.Warn("Several Slack members share the email address of a user", logger.Fields{"user_id": u.ID})
+			continue
+		case u.SlackUserID == matches[0]:
+			status.Unchanged++
+			continue
+		case active[u.SlackUserID]:
+			s.record(status, outcomeKept)
+			continue
+		}
+
+		outcome := outcomeLinked
+		if u.SlackUserID != "" {
+			outcome = outcomeUpdated
+		}
+		u.SlackUserID = matches[0]
+		if err := s.users.UpdateUser(u); err != nil {
+			return fmt.Errorf("update user %s: %w", u.ID, err)
+		}
+		s.record(status, outcome)
+	}
+	return nil
+}
+
+// record counts a user towards an outcome
+func (s *Syncer) record(status *Status, outcome string) {
+	switch outcome {
+	case outcomeLinked:
+		status.Linked++
+	case outcomeUpdated:
+		status.Updated++
+	case outcomeKept:
+		status.Kept++
+	case outcomeUnmatched:
+		status.Unmatched++
+	case outcomeAmbiguous:
+		status.Ambiguous++
+	}
+	slackUserSyncUsersTotal.Inc(outcome)
+}
@@ -0,0 +1,97 @@
+package slackusersync
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/constants"
+	"github.com/gaurav2721/notification-service/external_services/user"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWorkspace returns a fixed list of members
+type fakeWorkspace struct {
+	members []Member
+	err     error
+}
+
+func (w *fakeWorkspace) Members(ctx context.Context) ([]Member, error) {
+	return w.members, w.err
+}
+
+func slackUserID(t *testing.T, users user.UserService, userID string) string {
+	t.Helper()
+	u, err := users.GetUserByID(userID)
+	require.NoError(t, err)
+	return u.SlackUserID
+}
+
+func TestSyncer_FillsInSlackUserIDs(t *testing.T) {
+	users := user.NewUserService()
+	newcomer := models.NewUser("new.hire@company.com", "New Hire")
+	require.NoError(t, users.CreateUser(newcomer))
+
+	workspace := &fakeWorkspace{members: []Member{
+		{ID: "U1234567890", Email: "john.doe@company.com"},
+		// Jane's configured ID belongs to an account that was deactivated
+		{ID: "U0987654321", Email: "jane.smith@company.com", Deleted: true},
+		{ID: "UJANE000001", Email: "Jane.Smith@company.com"},
+		// Mike's configured ID is another active member, which is left alone
+		{ID: "U1122334455", Email: "mike.shared@company.com"},
+		{ID: "UMIKE000001", Email: "mike.johnson@company.com"},
+		{ID: "UNEW0000001", Email: "new.hire@company.com"},
+		{ID: "BSARAH00001", Email: "sarah.wilson@company.com", Bot: true},
+		{ID: "UDAVID00001", Email: "david.brown@company.com"},
+		{ID: "UDAVID00002", Email: "david.brown@company.com"},
+	}}
+	syncer := NewSyncer(users, workspace, Config{})
+
+	status, err := syncer.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Linked)
+	assert.Equal(t, 1, status.Updated)
+	assert.Equal(t, 1, status.Unchanged)
+	assert.Equal(t, 1, status.Kept)
+	assert.Equal(t, 1, status.Ambiguous)
+	assert.Equal(t, 4, status.Unmatched)
+
+	assert.Equal(t, "UNEW0000001", slackUserID(t, users, newcomer.ID))
+	assert.Equal(t, "UJANE000001", slackUserID(t, users, "user-002"))
+	assert.Equal(t, "U1122334455", slackUserID(t, users, "user-003"))
+	assert.Equal(t, "U1234567890", slackUserID(t, users, "user-001"))
+
+	// A second sync of the same workspace changes nothing
+	status, err = syncer.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, status.Unchanged)
+	assert.Zero(t, status.Linked+status.Updated)
+	assert.Same(t, status, syncer.Status())
+}
+
+func TestSyncer_FailsWithoutMemberEmails(t *testing.T) {
+	users := user.NewUserService()
+	syncer := NewSyncer(users, &fakeWorkspace{members: []Member{{ID: "U1234567890"}}}, Config{})
+
+	status, err := syncer.Sync(context.Background())
+	assert.ErrorIs(t, err, ErrEmailsHidden)
+	assert.Equal(t, err.Error(), status.Error)
+
+	syncer = NewSyncer(users, &fakeWorkspace{err: errors.New("invalid_auth")}, Config{})
+	_, err = syncer.Sync(context.Background())
+	assert.ErrorContains(t, err, "invalid_auth")
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	t.Setenv(constants.SLACK_BOT_TOKEN, "xoxb-test")
+	t.Setenv(constants.SlackUserSyncEnabledEnvVar, "true")
+	t.Setenv(constants.SlackUserSyncIntervalMinutesEnvVar, "0")
+
+	config := LoadConfigFromEnv()
+	assert.True(t, config.Enabled)
+	assert.Zero(t, config.Interval)
+	assert.NoError(t, config.Validate())
+	assert.ErrorIs(t, Config{Enabled: true}.Validate(), ErrInvalidConfiguration)
+}
@@ -0,0 +1,48 @@
+package slackusersync
+
+import (
+	"context"
+
+	"github.com/slack-go/slack"
+)
+
+// Member is a member of the Slack workspace
+type Member struct {
+	ID      string
+	Email   string
+	Deleted bool
+	Bot     bool
+}
+
+// Workspace lists the members of a Slack workspace
+type Workspace interface {
+	Members(ctx context.Context) ([]Member, error)
+}
+
+// SlackWorkspace reads the members of the workspace of a bot token through the Slack Web API
+type SlackWorkspace struct {
+	client *slack.Client
+}
+
+// NewSlackWorkspace creates a workspace reading members with client
+func NewSlackWorkspace(client *slack.Client) *SlackWorkspace {
+	return &SlackWorkspace{client: client}
+}
+
+// Members implements Workspace; the client pages through users.list and waits out rate limits
+func (w *SlackWorkspace) Members(ctx context.Context) ([]Member, error) {
+	users, err := w.client.GetUsersContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]Member, 0, len(users))
+	for _, u := range users {
+		members = append(members, Member{
+			ID:      u.ID,
+			Email:   u.Profile.Email,
+			Deleted: u.Deleted,
+			Bot:     u.IsBot || u.IsAppUser,
+		})
+	}
+	return members, nil
+}
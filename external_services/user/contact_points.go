@@ -0,0 +1,52 @@
+package user
+
+import (
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// AddContactPoint adds an address to a user and returns the user's contact points. With primary
+// set it becomes the primary address of its type.
+func (s *userService) AddContactPoint(userID, contactType, address string, primary bool) ([]models.ContactPoint, error) {
+	return s.updateContactPoints(userID, func(user *models.User) error {
+		return user.AddContactPoint(contactType, address, primary)
+	})
+}
+
+// SetPrimaryContact makes one of a user's addresses the primary one of its type and returns the
+// user's contact points
+func (s *userService) SetPrimaryContact(userID, contactType, address string) ([]models.ContactPoint, error) {
+	return s.updateContactPoints(userID, func(user *models.User) error {
+		return user.SetPrimaryContact(contactType, address)
+	})
+}
+
+// RemoveContactPoint removes an address from a user and returns the user's contact points
+func (s *userService) RemoveContactPoint(userID, contactType, address string) ([]models.ContactPoint, error) {
+	return s.updateContactPoints(userID, func(user *models.User) error {
+		return user.RemoveContactPoint(contactType, address)
+	})
+}
+
+// updateContactPoints applies update to an active user and reports the change
+func (s *userService) updateContactPoints(userID string, update func(user *models.User) error) ([]models.ContactPoint, error) {
+	points, err := func() ([]models.ContactPoint, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		user, exists := s.users[userID]
+		if !exists || !user.IsActive {
+			return nil, ErrUserNotFound
+		}
+		if err := update(user); err != nil {
+			return nil, err
+		}
+		user.UpdatedAt = s.clock.Now()
+		return user.ContactPoints(), nil
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	s.contactChanged(userID)
+	return points, nil
+}
@@ -0,0 +1,100 @@
+package user
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_ContactPoints(t *testing.T) {
+	service := NewUserService()
+
+	points, err := service.AddContactPoint("user-001", models.ContactEmail, "john.personal@example.com", false)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ContactPoint{
+		{Type: models.ContactEmail, Address: "john.doe@company.com", Primary: true},
+		{Type: models.ContactEmail, Address: "john.personal@example.com"},
+		{Type: models.ContactSlack, Address: "#general", Primary: true},
+		{Type: models.ContactPhone, Address: "+1-555-0101", Primary: true},
+	}, points)
+
+	_, err = service.AddContactPoint("user-001", models.ContactEmail, "john.personal@example.com", false)
+	assert.ErrorIs(t, err, models.ErrContactPointExists)
+	_, err = service.AddContactPoint("user-001", "fax", "+14155550123", false)
+	assert.ErrorIs(t, err, models.ErrInvalidContactType)
+	_, err = service.AddContactPoint("user-001", models.ContactPhone, "555-0199", false)
+	assert.ErrorIs(t, err, models.ErrInvalidPhoneNumber)
+	_, err = service.AddContactPoint("user-999", models.ContactEmail, "nobody@example.com", false)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	// Adding a primary address keeps the previous one as a secondary address
+	_, err = service.AddContactPoint("user-001", models.ContactSlack, "#john-alerts", true)
+	require.NoError(t, err)
+	user, err := service.GetUserByID("user-001")
+	require.NoError(t, err)
+	assert.Equal(t, "#john-alerts", user.SlackChannel)
+	assert.True(t, user.HasContactPoint(models.ContactSlack, "#general"))
+
+	_, err = service.SetPrimaryContact("user-001", models.ContactEmail, "john.personal@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "john.personal@example.com", user.Email)
+	_, err = service.SetPrimaryContact("user-001", models.ContactEmail, "unknown@example.com")
+	assert.ErrorIs(t, err, models.ErrContactPointNotFound)
+
+	// Removing the primary address promotes the next one
+	points, err = service.RemoveContactPoint("user-001", models.ContactEmail, "john.personal@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "john.doe@company.com", user.Email)
+	assert.Contains(t, points, models.ContactPoint{Type: models.ContactEmail, Address: "john.doe@company.com", Primary: true})
+	_, err = service.RemoveContactPoint("user-001", models.ContactEmail, "john.personal@example.com")
+	assert.ErrorIs(t, err, models.ErrContactPointNotFound)
+
+	for i := 0; i < models.MaxContactPointsPerType-1; i++ {
+		_, err = service.AddContactPoint("user-002", models.ContactPhone, fmt.Sprintf("+1415555010%d", i), false)
+		require.NoError(t, err)
+	}
+	_, err = service.AddContactPoint("user-002", models.ContactPhone, "+14155550199", false)
+	assert.ErrorIs(t, err, models.ErrTooManyContactPoints)
+}
+
+func TestUserService_PreferredContact(t *testing.T) {
+	service := NewUserService()
+	_, err := service.AddContactPoint("user-001", models.ContactEmail, "john.personal@example.com", false)
+	require.NoError(t, err)
+
+	// The primary address is used until a secondary address is verified
+	info, err := service.GetUserNotificationInfo("user-001")
+	require.NoError(t, err)
+	assert.Equal(t, "john.doe@company.com", info.Email)
+	assert.False(t, info.EmailVerified)
+
+	_, code, err := service.StartContactVerification("user-001", models.ContactEmail, "john.personal@example.com")
+	require.NoError(t, err)
+	require.NoError(t, service.ConfirmContactVerification("user-001", models.ContactEmail, "john.personal@example.com", code))
+	info, err = service.GetUserNotificationInfo("user-001")
+	require.NoError(t, err)
+	assert.Equal(t, "john.personal@example.com", info.Email)
+	assert.True(t, info.EmailVerified)
+
+	// A verified primary address is preferred
+	_, code, err = service.StartContactVerification("user-001", models.ContactEmail, "")
+	require.NoError(t, err)
+	require.NoError(t, service.ConfirmContactVerification("user-001", models.ContactEmail, "", code))
+	info, err = service.GetUserNotificationInfo("user-001")
+	require.NoError(t, err)
+	assert.Equal(t, "john.doe@company.com", info.Email)
+
+	// Replacing the primary address with a verified secondary one keeps its verification
+	user, err := service.GetUserByID("user-001")
+	require.NoError(t, err)
+	user.ReplacePrimaryContact(models.ContactEmail, "john.personal@example.com")
+	require.NoError(t, service.UpdateUser(user))
+	assert.NotNil(t, user.EmailVerifiedAt)
+	assert.Len(t, user.ContactPoints(), 3, "the previous primary address is removed")
+
+	_, _, err = service.StartContactVerification("user-001", models.ContactEmail, "unknown@example.com")
+	assert.ErrorIs(t, err, ErrNoContactAddress)
+}
@@ -0,0 +1,229 @@
+package user
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// Formats of a device import
+const (
+	DeviceImportCSV    = "csv"
+	DeviceImportNDJSON = "ndjson"
+)
+
+// Outcomes of a device import row
+const (
+	DeviceImportImported = "imported"
+	DeviceImportFailed   = "failed"
+)
+
+// maxDeviceImportLine is the longest NDJSON line a device import accepts
+const maxDeviceImportLine = 64 * 1024
+
+// DeviceImportRow is a device of a bulk import. The lines of a device export are valid rows,
+// so devices can be moved between instances of the service.
+type DeviceImportRow struct {
+	UserID          string `json:"user_id"`
+	DeviceToken     string `json:"device_token"`
+	DeviceType      string `json:"device_type"`
+	AppID           string `json:"app_id,omitempty"`
+	APNSEnvironment string `json:"apns_environment,omitempty"`
+}
+
+// DeviceImportResult is the outcome of one row of a device import
+type DeviceImportResult struct {
+	// Line is the line of the row in the import, counting the CSV header
+	Line     int    `json:"line"`
+	UserID   string `json:"user_id,omitempty"`
+	DeviceID string `json:"device_id,omitempty"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+// DeviceImportReport lists the outcome of every row of a device import
+type DeviceImportReport struct {
+	Results  []DeviceImportResult `json:"results"`
+	Imported int                  `json:"imported"`
+	Failed   int                  `json:"failed"`
+}
+
+// add records the outcome of a row
+func (r *DeviceImportReport) add(result DeviceImportResult) {
+	if result.Status == DeviceImportImported {
+		r.Imported++
+	} else {
+		r.Failed++
+	}
+	r.Results = append(r.Results, result)
+}
+
+// csvColumnAliases maps alternative CSV column names used by other push systems to the
+// column names of a device import
+var csvColumnAliases = map[string]string{
+	"token":    "device_token",
+	"platform": "device_type",
+}
+
+// setColumn sets the field of row named by a CSV column. Unknown columns are ignored.
+func (row *DeviceImportRow) setColumn(column, value string) {
+	switch column {
+	case "user_id":
+		row.UserID = value
+	case "device_token":
+		row.DeviceToken = value
+	case "device_type":
+		row.DeviceType = value
+	case "app_id":
+		row.AppID = value
+	case "apns_environment":
+		row.APNSEnvironment = value
+	}
+}
+
+// ImportDevices registers the devices read from r in CSV or NDJSON format. Every row is
+// validated and registered on its own, so invalid rows are reported without stopping the
+// import. An error is only returned when the input cannot be read, e.g. for a CSV header
+// without the user_id, device_token and device_type columns.
+func ImportDevices(service UserService, r io.Reader, format string) (*DeviceImportReport, error) {
+	report := &DeviceImportReport{Results: []DeviceImportResult{}}
+	importRow := func(line int, row DeviceImportRow) {
+		result := DeviceImportResult{Line: line, UserID: row.UserID, Status: DeviceImportImported}
+		device, err := importDevice(service, row)
+		if err != nil {
+			result.Status = DeviceImportFailed
+			result.Error = err.Error()
+		} else {
+			result.DeviceID = device.ID
+		}
+		report.add(result)
+	}
+
+	var err error
+	switch format {
+	case DeviceImportCSV:
+		err = readCSVDevices(r, importRow, report)
+	case DeviceImportNDJSON:
+		err = readNDJSONDevices(r, importRow, report)
+	default:
+		err = fmt.Errorf("%w: unsupported format %q, expected csv or ndjson", ErrInvalidDeviceImport, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// readCSVDevices reads rows from CSV with a header naming the columns
+func readCSVDevices(r io.Reader, importRow func(line int, row DeviceImportRow), report *DeviceImportReport) error {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidDeviceImport, err)
+	}
+
+	columns := make([]string, len(header))
+	seen := make(map[string]bool)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if alias, ok := csvColumnAliases[name]; ok {
+			name = alias
+		}
+		columns[i] = name
+		seen[name] = true
+	}
+	for _, required := range []string{"user_id", "device_token", "device_type"} {
+		if !seen[required] {
+			return fmt.Errorf("%w: CSV header has no %s column", ErrInvalidDeviceImport, required)
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			report.add(DeviceImportResult{Line: parseErr.StartLine, Status: DeviceImportFailed, Error: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		line, _ := reader.FieldPos(0)
+		var row DeviceImportRow
+		for i, value := range record {
+			row.setColumn(columns[i], strings.TrimSpace(value))
+		}
+		importRow(line, row)
+	}
+}
+
+// readNDJSONDevices reads one JSON row per line, skipping blank lines
+func readNDJSONDevices(r io.Reader, importRow func(line int, row DeviceImportRow), report *DeviceImportReport) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxDeviceImportLine)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var row DeviceImportRow
+		if err := json.Unmarshal([]byte(text), &row); err != nil {
+			report.add(DeviceImportResult{Line: line, Status: DeviceImportFailed, Error: "invalid JSON: " + err.Error()})
+			continue
+		}
+		importRow(line, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%w: line %d: %w", ErrInvalidDeviceImport, line+1, err)
+	}
+	return nil
+}
+
+// importDevice validates a row and registers its device
+func importDevice(service UserService, row DeviceImportRow) (*models.UserDeviceInfo, error) {
+	switch {
+	case row.UserID == "":
+		return nil, ErrInvalidUserID
+	case row.DeviceToken == "":
+		return nil, errors.New("device token cannot be empty")
+	case !models.IsValidDeviceType(row.DeviceType):
+		return nil, ErrInvalidDeviceType
+	case row.APNSEnvironment != "" && !models.IsValidAPNSEnvironment(row.APNSEnvironment):
+		return nil, ErrInvalidAPNSEnv
+	}
+
+	device, err := service.RegisterDevice(row.UserID, row.DeviceToken, row.DeviceType)
+	if err != nil {
+		return nil, err
+	}
+	if row.APNSEnvironment != "" {
+		if err := service.SetDeviceAPNSEnvironment(device.ID, row.APNSEnvironment); err != nil {
+			return nil, err
+		}
+	}
+	if row.AppID != "" {
+		if err := service.SetDeviceAppID(device.ID, row.AppID); err != nil {
+			return nil, err
+		}
+	}
+	return device, nil
+}
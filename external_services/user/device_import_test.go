@@ -0,0 +1,84 @@
+package user
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportDevices_CSV(t *testing.T) {
+	service := NewUserService()
+	input := strings.Join([]string{
+		"user_id,token,platform,apns_environment",
+		"user-002,abc123,ios,sandbox",
+		"user-404,def456,android,",
+		"user-002,,android,",
+		"user-002,ghi789,blackberry,",
+		"user-002,jkl012",
+		"user-004,mno345,android,",
+	}, "\n")
+
+	report, err := ImportDevices(service, strings.NewReader(input), DeviceImportCSV)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, 4, report.Failed)
+	require.Len(t, report.Results, 6)
+
+	assert.Equal(t, DeviceImportResult{Line: 2, UserID: "user-002", DeviceID: report.Results[0].DeviceID, Status: DeviceImportImported}, report.Results[0])
+	assert.Equal(t, ErrUserNotFound.Error(), report.Results[1].Error)
+	assert.Equal(t, "device token cannot be empty", report.Results[2].Error)
+	assert.Equal(t, ErrInvalidDeviceType.Error(), report.Results[3].Error)
+	assert.Equal(t, 6, report.Results[4].Line)
+	assert.Contains(t, report.Results[4].Error, "wrong number of fields")
+	assert.Equal(t, 7, report.Results[5].Line)
+
+	devices, err := service.GetUserDevices("user-002")
+	require.NoError(t, err)
+	var imported bool
+	for _, device := range devices {
+		if device.DeviceToken == "abc123" {
+			imported = true
+			assert.Equal(t, "sandbox", device.APNSEnvironment)
+		}
+	}
+	assert.True(t, imported)
+}
+
+func TestImportDevices_NDJSON(t *testing.T) {
+	service := NewUserService()
+	input := `{"user_id":"user-002","device_token":"abc123","device_type":"android","app_id":"driver"}
+
+{"user_id":"user-002",
+{"id":"device-001","user_id":"user-001","device_token":"exported","device_type":"ios","is_active":true}
+`
+
+	report, err := ImportDevices(service, strings.NewReader(input), DeviceImportNDJSON)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, []int{1, 3, 4}, []int{report.Results[0].Line, report.Results[1].Line, report.Results[2].Line})
+	assert.Contains(t, report.Results[1].Error, "invalid JSON")
+
+	// Importing the same token again keeps the registered device
+	again, err := ImportDevices(service, strings.NewReader(input), DeviceImportNDJSON)
+	require.NoError(t, err)
+	assert.Equal(t, report.Results[0].DeviceID, again.Results[0].DeviceID)
+}
+
+func TestImportDevices_InvalidInput(t *testing.T) {
+	service := NewUserService()
+
+	_, err := ImportDevices(service, strings.NewReader("user_id,device_type\nuser-001,ios"), DeviceImportCSV)
+	assert.True(t, errors.Is(err, ErrInvalidDeviceImport))
+	assert.ErrorContains(t, err, "no device_token column")
+
+	_, err = ImportDevices(service, strings.NewReader(""), "xml")
+	assert.True(t, errors.Is(err, ErrInvalidDeviceImport))
+
+	report, err := ImportDevices(service, strings.NewReader(""), DeviceImportCSV)
+	require.NoError(t, err)
+	assert.Empty(t, report.Results)
+}
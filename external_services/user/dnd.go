@@ -0,0 +1,54 @@
+package user
+
+import (
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// SetDoNotDisturb turns do-not-disturb on for a user until the given time, or until it is
+// turned off when until is nil. It replaces any do-not-disturb already set.
+func (s *userService) SetDoNotDisturb(userID string, until *time.Time) (*models.DoNotDisturb, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists || !user.IsActive {
+		return nil, ErrUserNotFound
+	}
+
+	now := s.clock.Now()
+	if until != nil && !until.After(now) {
+		return nil, ErrInvalidDNDUntil
+	}
+
+	// The state is replaced rather than changed in place, notification info shares it
+	user.DoNotDisturb = &models.DoNotDisturb{Since: now, Until: until}
+	user.UpdatedAt = now
+	return user.DoNotDisturb, nil
+}
+
+// ClearDoNotDisturb turns do-not-disturb off for a user
+func (s *userService) ClearDoNotDisturb(userID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists || !user.IsActive {
+		return ErrUserNotFound
+	}
+
+	if user.DoNotDisturb != nil {
+		user.DoNotDisturb = nil
+		user.UpdatedAt = s.clock.Now()
+	}
+	return nil
+}
+
+// activeDoNotDisturb returns the user's do-not-disturb while it is on, nil once it expired
+func (s *userService) activeDoNotDisturb(user *models.User) *models.DoNotDisturb {
+	if !user.DoNotDisturb.ActiveAt(s.clock.Now()) {
+		return nil
+	}
+	return user.DoNotDisturb
+}
@@ -0,0 +1,45 @@
+package user
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoNotDisturb(t *testing.T) {
+	service := NewUserService().(*userService)
+	fake := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	service.clock = fake
+
+	past := fake.Now().Add(-time.Minute)
+	_, err := service.SetDoNotDisturb("user-001", &past)
+	assert.ErrorIs(t, err, ErrInvalidDNDUntil)
+	_, err = service.SetDoNotDisturb("missing-user", nil)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	until := fake.Now().Add(time.Hour)
+	dnd, err := service.SetDoNotDisturb("user-001", &until)
+	require.NoError(t, err)
+	assert.Equal(t, fake.Now(), dnd.Since)
+
+	info, err := service.GetUserNotificationInfo("user-001")
+	require.NoError(t, err)
+	require.NotNil(t, info.DoNotDisturb)
+	assert.Equal(t, until, *info.DoNotDisturb.Until)
+
+	// Expired do-not-disturb is not reported
+	fake.Advance(time.Hour)
+	infos, err := service.GetUsersNotificationInfo([]string{"user-001"})
+	require.NoError(t, err)
+	assert.Nil(t, infos[0].DoNotDisturb)
+
+	_, err = service.SetDoNotDisturb("user-001", nil)
+	require.NoError(t, err)
+	require.NoError(t, service.ClearDoNotDisturb("user-001"))
+	info, err = service.GetUserNotificationInfo("user-001")
+	require.NoError(t, err)
+	assert.Nil(t, info.DoNotDisturb)
+}
@@ -5,8 +5,24 @@ import "errors"
 // User service errors
 var (
 	ErrUserNotFound      = errors.New("user not found")
+	ErrUserInactive      = errors.New("user is inactive")
 	ErrUserAlreadyExists = errors.New("user already exists")
 	ErrInvalidUserID     = errors.New("invalid user ID")
 	ErrDeviceNotFound    = errors.New("device not found")
 	ErrDeviceInactive    = errors.New("device is inactive")
+	ErrInvalidSeed       = errors.New("invalid seed data")
+	ErrInvalidAPNSEnv    = errors.New("invalid APNS environment, expected sandbox or production")
+	ErrInvalidDNDUntil   = errors.New("do-not-disturb end time must be in the future")
+
+	// Device import errors
+	ErrInvalidDeviceImport = errors.New("invalid device import")
+	ErrInvalidDeviceType   = errors.New("invalid device type, expected ios, android or web")
+
+	// Contact verification errors
+	ErrInvalidContact       = errors.New("invalid contact, expected email or phone")
+	ErrNoContactAddress     = errors.New("user has no address for this contact")
+	ErrVerificationNotFound = errors.New("no pending verification for this contact")
+	ErrVerificationExpired  = errors.New("verification code expired")
+	ErrInvalidCode          = errors.New("invalid verification code")
+	ErrTooManyCodeAttempts  = errors.New("too many verification attempts, request a new code")
 )
@@ -1,6 +1,11 @@
 package user
 
-import "github.com/gaurav2721/notification-service/models"
+import (
+	"strings"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+)
 
 // User service interface and related types can be added here
 // UserService interface defines methods for user management
@@ -11,16 +16,78 @@ type UserService interface {
 	CreateUser(user *models.User) error
 	UpdateUser(user *models.User) error
 	DeleteUser(userID string) error
+	ListUsers(filter UserFilter) ([]models.User, error)
+	OnContactChange(f func(userID string))
 
 	// Device management methods
 	RegisterDevice(userID, deviceToken, deviceType string) (*models.UserDeviceInfo, error)
 	GetUserDevices(userID string) ([]*models.UserDeviceInfo, error)
 	GetActiveUserDevices(userID string) ([]*models.UserDeviceInfo, error)
 	UpdateDeviceInfo(deviceID string, appVersion, osVersion, deviceModel string) error
+	SetDeviceAPNSEnvironment(deviceID, environment string) error
+	SetDeviceAppID(deviceID, appID string) error
 	DeactivateDevice(deviceID string) error
 	RemoveDevice(deviceID string) error
 	UpdateDeviceLastUsed(deviceID string) error
+	ListDevices(filter DeviceFilter) ([]models.UserDeviceInfo, error)
+
+	// Contact point methods
+	AddContactPoint(userID, contactType, address string, primary bool) ([]models.ContactPoint, error)
+	SetPrimaryContact(userID, contactType, address string) ([]models.ContactPoint, error)
+	RemoveContactPoint(userID, contactType, address string) ([]models.ContactPoint, error)
+
+	// Contact verification methods
+	StartContactVerification(userID, contact, address string) (*models.VerificationChallenge, string, error)
+	ConfirmContactVerification(userID, contact, address, code string) error
+
+	// Do-not-disturb methods
+	SetDoNotDisturb(userID string, until *time.Time) (*models.DoNotDisturb, error)
+	ClearDoNotDisturb(userID string) error
 
 	// Notification info methods
 	GetUserNotificationInfo(userID string) (*models.UserNotificationInfo, error)
+	GetUsersNotificationInfo(userIDs []string) ([]*models.UserNotificationInfo, error)
+}
+
+// UserFilter selects users, inactive ones included, by ID, user name, external ID and state.
+// Empty fields match every user; user names are matched case-insensitively, against the email
+// address of users without one, such as those created through the user API.
+type UserFilter struct {
+	ID         string
+	UserName   string
+	ExternalID string
+	Active     *bool
+}
+
+// Matches reports whether user is selected by the filter
+func (f UserFilter) Matches(user *models.User) bool {
+	if f.ID != "" && user.ID != f.ID {
+		return false
+	}
+	userName := user.UserName
+	if userName == "" {
+		userName = user.Email
+	}
+	if f.UserName != "" && !strings.EqualFold(userName, f.UserName) {
+		return false
+	}
+	if f.ExternalID != "" && user.ExternalID != f.ExternalID {
+		return false
+	}
+	return f.Active == nil || user.IsActive == *f.Active
+}
+
+// DeviceFilter selects the devices of every user by type and state. Empty fields match every
+// device.
+type DeviceFilter struct {
+	DeviceType string
+	Active     *bool
+}
+
+// Matches reports whether device is selected by the filter
+func (f DeviceFilter) Matches(device *models.UserDeviceInfo) bool {
+	if f.DeviceType != "" && device.DeviceType != f.DeviceType {
+		return false
+	}
+	return f.Active == nil || device.IsActive == *f.Active
 }
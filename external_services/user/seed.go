@@ -0,0 +1,141 @@
+package user
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultSeed holds the sample users and devices used for development and tests
+//
+//go:embed fixtures/default.json
+var defaultSeed []byte
+
+// Seed is the data a user service starts with
+type Seed struct {
+	Users   []*models.User           `json:"users"`
+	Devices []*models.UserDeviceInfo `json:"devices"`
+}
+
+// DefaultSeed returns the built-in sample users and devices
+func DefaultSeed() *Seed {
+	seed, err := ParseSeed(defaultSeed, ".json")
+	if err != nil {
+		panic("invalid built-in seed data: " + err.Error())
+	}
+	return seed
+}
+
+// LoadSeed reads a seed file. Files ending in .yaml or .yml are parsed as YAML and others as
+// JSON; both use the field names of the JSON API.
+func LoadSeed(path string) (*Seed, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seed file: %w", err)
+	}
+
+	seed, err := ParseSeed(data, filepath.Ext(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return seed, nil
+}
+
+// ParseSeed parses seed data in the format given by a file extension. Missing timestamps are
+// set to the current time.
+func ParseSeed(data []byte, ext string) (*Seed, error) {
+	switch strings.ToLower(ext) {
+	case ".yaml", ".yml":
+		// YAML is converted to JSON so both formats share the JSON field names
+		var document interface{}
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSeed, err)
+		}
+		converted, err := json.Marshal(document)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSeed, err)
+		}
+		data = converted
+	}
+
+	var seed Seed
+	if err := json.Unmarshal(data, &seed); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSeed, err)
+	}
+	if err := seed.validate(); err != nil {
+		return nil, err
+	}
+
+	seed.fillTimestamps(time.Now())
+	return &seed, nil
+}
+
+// validate checks that users and devices have unique IDs and that devices belong to a seeded user
+func (s *Seed) validate() error {
+	users := make(map[string]bool, len(s.Users))
+	for i, user := range s.Users {
+		if user == nil || user.ID == "" {
+			return fmt.Errorf("%w: user %d has no id", ErrInvalidSeed, i+1)
+		}
+		if users[user.ID] {
+			return fmt.Errorf("%w: user %s is listed twice", ErrInvalidSeed, user.ID)
+		}
+		users[user.ID] = true
+
+		if err := normalizeUserSettings(user); err != nil {
+			return fmt.Errorf("%w: user %s: %v", ErrInvalidSeed, user.ID, err)
+		}
+	}
+
+	devices := make(map[string]bool, len(s.Devices))
+	for i, device := range s.Devices {
+		if device == nil || device.ID == "" {
+			return fmt.Errorf("%w: device %d has no id", ErrInvalidSeed, i+1)
+		}
+		if devices[device.ID] {
+			return fmt.Errorf("%w: device %s is listed twice", ErrInvalidSeed, device.ID)
+		}
+		devices[device.ID] = true
+
+		if !users[device.UserID] {
+			return fmt.Errorf("%w: device %s belongs to unknown user %q", ErrInvalidSeed, device.ID, device.UserID)
+		}
+		if device.DeviceToken == "" {
+			return fmt.Errorf("%w: device %s has no device token", ErrInvalidSeed, device.ID)
+		}
+		if device.APNSEnvironment != "" && !models.IsValidAPNSEnvironment(device.APNSEnvironment) {
+			return fmt.Errorf("%w: device %s has unknown APNS environment %q", ErrInvalidSeed, device.ID, device.APNSEnvironment)
+		}
+	}
+	return nil
+}
+
+// fillTimestamps sets timestamps left out of the seed to now
+func (s *Seed) fillTimestamps(now time.Time) {
+	for _, user := range s.Users {
+		if user.CreatedAt.IsZero() {
+			user.CreatedAt = now
+		}
+		if user.UpdatedAt.IsZero() {
+			user.UpdatedAt = user.CreatedAt
+		}
+	}
+	for _, device := range s.Devices {
+		if device.CreatedAt.IsZero() {
+			device.CreatedAt = now
+		}
+		if device.UpdatedAt.IsZero() {
+			device.UpdatedAt = device.CreatedAt
+		}
+		if device.LastUsedAt.IsZero() {
+			device.LastUsedAt = device.UpdatedAt
+		}
+	}
+}
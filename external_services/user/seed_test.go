@@ -0,0 +1,84 @@
+package user
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSeed(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlPath := filepath.Join(dir, "seed.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(`
+users:
+  - id: qa-001
+    email: qa@example.com
+    full_name: QA Tester
+    slack_channel: "#qa"
+    is_active: true
+    created_at: 2024-01-02T03:04:05Z
+devices:
+  - id: qa-device-001
+    user_id: qa-001
+    device_token: ios_token_qa
+    device_type: ios
+    is_active: true
+`), 0o644))
+
+	jsonPath := filepath.Join(dir, "seed.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{
+  "users": [{"id": "qa-001", "email": "qa@example.com", "full_name": "QA Tester", "slack_channel": "#qa", "is_active": true, "created_at": "2024-01-02T03:04:05Z"}],
+  "devices": [{"id": "qa-device-001", "user_id": "qa-001", "device_token": "ios_token_qa", "device_type": "ios", "is_active": true}]
+}`), 0o644))
+
+	for _, path := range []string{yamlPath, jsonPath} {
+		seed, err := LoadSeed(path)
+		require.NoError(t, err, path)
+
+		service := NewUserServiceWithSeed(seed)
+		info, err := service.GetUserNotificationInfo("qa-001")
+		require.NoError(t, err, path)
+		assert.Equal(t, "QA Tester", info.FullName)
+		assert.Equal(t, "#qa", info.SlackChannel)
+		require.Len(t, info.Devices, 1)
+		assert.Equal(t, "ios_token_qa", info.Devices[0].DeviceToken)
+
+		user, err := service.GetUserByID("qa-001")
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), user.CreatedAt.UTC())
+		assert.Equal(t, user.CreatedAt, user.UpdatedAt, "missing timestamps are filled in")
+
+		_, err = service.GetUserByID("user-001")
+		assert.Error(t, err, "fixtures replace the built-in sample data")
+	}
+}
+
+func TestParseSeed_RejectsInvalidData(t *testing.T) {
+	for name, data := range map[string]string{
+		"malformed":      `{"users": [`,
+		"missing id":     `{"users": [{"email": "qa@example.com"}]}`,
+		"duplicate user": `{"users": [{"id": "qa-001"}, {"id": "qa-001"}]}`,
+		"unknown user":   `{"users": [{"id": "qa-001"}], "devices": [{"id": "d-1", "user_id": "qa-002", "device_token": "token"}]}`,
+		"missing token":  `{"users": [{"id": "qa-001"}], "devices": [{"id": "d-1", "user_id": "qa-001"}]}`,
+	} {
+		_, err := ParseSeed([]byte(data), ".json")
+		assert.ErrorIs(t, err, ErrInvalidSeed, name)
+	}
+
+	_, err := ParseSeed([]byte("users: [unclosed"), ".yml")
+	assert.ErrorIs(t, err, ErrInvalidSeed)
+
+	_, err = LoadSeed(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestNewUserServiceWithSeed_Empty(t *testing.T) {
+	users, err := NewUserServiceWithSeed(nil).GetAllUsers()
+	require.NoError(t, err)
+	assert.Empty(t, users)
+}
@@ -2,252 +2,54 @@ package user
 
 import (
 	"errors"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/gaurav2721/notification-service/clock"
 	"github.com/gaurav2721/notification-service/models"
 )
 
 // userService implements UserService interface
 type userService struct {
-	users   map[string]*models.User
-	devices map[string]*models.UserDeviceInfo // deviceID -> UserDeviceInfo
-	mutex   sync.RWMutex
+	users         map[string]*models.User
+	devices       map[string]*models.UserDeviceInfo // deviceID -> UserDeviceInfo
+	verifications map[string]*pendingVerification   // userID|contact -> pending code
+	clock         clock.Clock
+	mutex         sync.RWMutex
+
+	onContactChange func(userID string)
 }
 
-// NewUserService creates a new user service with preloaded data
+// NewUserService creates a new user service with the built-in sample users and devices
 func NewUserService() UserService {
+	return NewUserServiceWithSeed(DefaultSeed())
+}
+
+// NewUserServiceWithSeed creates a new user service with the users and devices of seed.
+// A nil seed starts the service empty.
+func NewUserServiceWithSeed(seed *Seed) UserService {
 	service := &userService{
-		users:   make(map[string]*models.User),
-		devices: make(map[string]*models.UserDeviceInfo),
+		users:         make(map[string]*models.User),
+		devices:       make(map[string]*models.UserDeviceInfo),
+		verifications: make(map[string]*pendingVerification),
+		clock:         clock.Real(),
+	}
+	if seed != nil {
+		service.load(seed)
 	}
-
-	// Preload users with sample data
-	service.preloadUsers()
-	// Preload devices with sample data
-	service.preloadDevices()
-
 	return service
 }
 
-// preloadUsers loads sample user data for testing and development
-func (s *userService) preloadUsers() {
+// load adds the users and devices of a seed to the service
+func (s *userService) load(seed *Seed) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	// Sample users with essential notification information
-	users := []*models.User{
-		{
-			ID:           "user-001",
-			Email:        "john.doe@company.com",
-			FullName:     "John Doe",
-			SlackUserID:  "U1234567890",
-			SlackChannel: "#general",
-			PhoneNumber:  "+1-555-0101",
-			IsActive:     true,
-			CreatedAt:    time.Now().AddDate(0, -6, 0),
-			UpdatedAt:    time.Now(),
-		},
-		{
-			ID:           "user-002",
-			Email:        "jane.smith@company.com",
-			FullName:     "Jane Smith",
-			SlackUserID:  "U0987654321",
-			SlackChannel: "#design",
-			PhoneNumber:  "+1-555-0102",
-			IsActive:     true,
-			CreatedAt:    time.Now().AddDate(0, -4, 0),
-			UpdatedAt:    time.Now(),
-		},
-		{
-			ID:           "user-003",
-			Email:        "mike.johnson@company.com",
-			FullName:     "Mike Johnson",
-			SlackUserID:  "U1122334455",
-			SlackChannel: "#marketing",
-			PhoneNumber:  "+1-555-0103",
-			IsActive:     true,
-			CreatedAt:    time.Now().AddDate(0, -8, 0),
-			UpdatedAt:    time.Now(),
-		},
-		{
-			ID:           "user-004",
-			Email:        "sarah.wilson@company.com",
-			FullName:     "Sarah Wilson",
-			SlackUserID:  "U5566778899",
-			SlackChannel: "#sales",
-			PhoneNumber:  "+1-555-0104",
-			IsActive:     true,
-			CreatedAt:    time.Now().AddDate(0, -2, 0),
-			UpdatedAt:    time.Now(),
-		},
-		{
-			ID:           "user-005",
-			Email:        "david.brown@company.com",
-			FullName:     "David Brown",
-			SlackUserID:  "U9988776655",
-			SlackChannel: "#engineering",
-			PhoneNumber:  "+1-555-0105",
-			IsActive:     true,
-			CreatedAt:    time.Now().AddDate(0, -12, 0),
-			UpdatedAt:    time.Now(),
-		},
-		{
-			ID:           "user-006",
-			Email:        "lisa.garcia@company.com",
-			FullName:     "Lisa Garcia",
-			SlackUserID:  "U4433221100",
-			SlackChannel: "#marketing",
-			PhoneNumber:  "+1-555-0106",
-			IsActive:     true,
-			CreatedAt:    time.Now().AddDate(0, -10, 0),
-			UpdatedAt:    time.Now(),
-		},
-		{
-			ID:           "user-007",
-			Email:        "robert.taylor@company.com",
-			FullName:     "Robert Taylor",
-			SlackUserID:  "U1122334455",
-			SlackChannel: "#sales",
-			PhoneNumber:  "+1-555-0107",
-			IsActive:     true,
-			CreatedAt:    time.Now().AddDate(0, -9, 0),
-			UpdatedAt:    time.Now(),
-		},
-		{
-			ID:           "user-008",
-			Email:        "emma.davis@company.com",
-			FullName:     "Emma Davis",
-			SlackUserID:  "U6677889900",
-			SlackChannel: "#executives",
-			PhoneNumber:  "+1-555-0108",
-			IsActive:     true,
-			CreatedAt:    time.Now().AddDate(0, -15, 0),
-			UpdatedAt:    time.Now(),
-		},
-	}
-
-	// Add users to the map
-	for _, user := range users {
+	for _, user := range seed.Users {
 		s.users[user.ID] = user
 	}
-}
-
-// preloadDevices loads sample device data for testing and development
-func (s *userService) preloadDevices() {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-
-	// Sample devices for InApp notifications
-	devices := []*models.UserDeviceInfo{
-		{
-			ID:          "device-001",
-			UserID:      "user-001",
-			DeviceToken: "ios_token_123456789",
-			DeviceType:  "ios",
-			AppVersion:  "1.2.3",
-			OSVersion:   "iOS 16.0",
-			DeviceModel: "iPhone 14",
-			IsActive:    true,
-			LastUsedAt:  time.Now(),
-			CreatedAt:   time.Now().AddDate(0, -2, 0),
-			UpdatedAt:   time.Now(),
-		},
-		{
-			ID:          "device-002",
-			UserID:      "user-001",
-			DeviceToken: "android_token_987654321",
-			DeviceType:  "android",
-			AppVersion:  "1.2.3",
-			OSVersion:   "Android 13",
-			DeviceModel: "Samsung Galaxy S23",
-			IsActive:    true,
-			LastUsedAt:  time.Now().Add(-time.Hour),
-			CreatedAt:   time.Now().AddDate(0, -1, 0),
-			UpdatedAt:   time.Now().Add(-time.Hour),
-		},
-		{
-			ID:          "device-004",
-			UserID:      "user-003",
-			DeviceToken: "ios_token_789123456",
-			DeviceType:  "ios",
-			AppVersion:  "1.2.2",
-			OSVersion:   "iOS 15.5",
-			DeviceModel: "iPhone 13",
-			IsActive:    false, // Inactive device
-			LastUsedAt:  time.Now().AddDate(0, 0, -7),
-			CreatedAt:   time.Now().AddDate(0, -6, 0),
-			UpdatedAt:   time.Now().AddDate(0, 0, -7),
-		},
-		{
-			ID:          "device-005",
-			UserID:      "user-004",
-			DeviceToken: "android_token_555666777",
-			DeviceType:  "android",
-			AppVersion:  "1.2.4",
-			OSVersion:   "Android 14",
-			DeviceModel: "Google Pixel 8",
-			IsActive:    true,
-			LastUsedAt:  time.Now().Add(-time.Minute * 15),
-			CreatedAt:   time.Now().AddDate(0, -1, 0),
-			UpdatedAt:   time.Now().Add(-time.Minute * 15),
-		},
-		{
-			ID:          "device-006",
-			UserID:      "user-005",
-			DeviceToken: "ios_token_111222333",
-			DeviceType:  "ios",
-			AppVersion:  "1.2.3",
-			OSVersion:   "iOS 17.0",
-			DeviceModel: "iPhone 15 Pro",
-			IsActive:    true,
-			LastUsedAt:  time.Now().Add(-time.Hour * 2),
-			CreatedAt:   time.Now().AddDate(0, -3, 0),
-			UpdatedAt:   time.Now().Add(-time.Hour * 2),
-		},
-		{
-			ID:          "device-008",
-			UserID:      "user-007",
-			DeviceToken: "android_token_777888999",
-			DeviceType:  "android",
-			AppVersion:  "1.2.1",
-			OSVersion:   "Android 12",
-			DeviceModel: "OnePlus 9",
-			IsActive:    true,
-			LastUsedAt:  time.Now().Add(-time.Hour * 3),
-			CreatedAt:   time.Now().AddDate(0, -4, 0),
-			UpdatedAt:   time.Now().Add(-time.Hour * 3),
-		},
-		{
-			ID:          "device-009",
-			UserID:      "user-008",
-			DeviceToken: "ios_token_000111222",
-			DeviceType:  "ios",
-			AppVersion:  "1.2.5",
-			OSVersion:   "iOS 17.2",
-			DeviceModel: "iPad Pro",
-			IsActive:    true,
-			LastUsedAt:  time.Now().Add(-time.Minute * 10),
-			CreatedAt:   time.Now().AddDate(0, -1, 0),
-			UpdatedAt:   time.Now().Add(-time.Minute * 10),
-		},
-		{
-			ID:          "device-010",
-			UserID:      "user-002",
-			DeviceToken: "android_token_333444555",
-			DeviceType:  "android",
-			AppVersion:  "1.2.3",
-			OSVersion:   "Android 13",
-			DeviceModel: "Samsung Galaxy Tab S9",
-			IsActive:    true,
-			LastUsedAt:  time.Now().Add(-time.Hour * 4),
-			CreatedAt:   time.Now().AddDate(0, -2, 0),
-			UpdatedAt:   time.Now().Add(-time.Hour * 4),
-		},
-	}
-
-	// Add devices to the map
-	for _, device := range devices {
+	for _, device := range seed.Devices {
 		s.devices[device.ID] = device
 	}
 }
@@ -259,11 +61,11 @@ func (s *userService) GetUserByID(userID string) (*models.User, error) {
 
 	user, exists := s.users[userID]
 	if !exists {
-		return nil, errors.New("user not found")
+		return nil, ErrUserNotFound
 	}
 
 	if !user.IsActive {
-		return nil, errors.New("user is inactive")
+		return nil, ErrUserInactive
 	}
 
 	return user, nil
@@ -305,7 +107,10 @@ func (s *userService) CreateUser(user *models.User) error {
 	defer s.mutex.Unlock()
 
 	if _, exists := s.users[user.ID]; exists {
-		return errors.New("user already exists")
+		return ErrUserAlreadyExists
+	}
+	if err := normalizeUserSettings(user); err != nil {
+		return err
 	}
 
 	user.CreatedAt = time.Now()
@@ -317,12 +122,24 @@ func (s *userService) CreateUser(user *models.User) error {
 
 // UpdateUser updates an existing user
 func (s *userService) UpdateUser(user *models.User) error {
+	if err := s.replaceUser(user); err != nil {
+		return err
+	}
+	s.contactChanged(user.ID)
+	return nil
+}
+
+// replaceUser stores the updated user in place of the existing one
+func (s *userService) replaceUser(user *models.User) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
 	if _, exists := s.users[user.ID]; !exists {
 		return errors.New("user not found")
 	}
+	if err := normalizeUserSettings(user); err != nil {
+		return err
+	}
 
 	user.UpdatedAt = time.Now()
 	s.users[user.ID] = user
@@ -330,6 +147,43 @@ func (s *userService) UpdateUser(user *models.User) error {
 	return nil
 }
 
+// OnContactChange registers a function called after the contact details of a user were
+// updated; nil removes it
+func (s *userService) OnContactChange(f func(userID string)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.onContactChange = f
+}
+
+// contactChanged calls the registered contact change function, without holding the lock so the
+// function can look the user up
+func (s *userService) contactChanged(userID string) {
+	s.mutex.RLock()
+	f := s.onContactChange
+	s.mutex.RUnlock()
+
+	if f != nil {
+		f(userID)
+	}
+}
+
+// normalizeUserSettings validates the user's timezone and stores the locale in canonical form
+func normalizeUserSettings(user *models.User) error {
+	if user.Timezone != "" {
+		if err := models.ValidateTimezone(user.Timezone); err != nil {
+			return err
+		}
+	}
+	if user.Locale != "" {
+		locale, err := models.NormalizeLocale(user.Locale)
+		if err != nil {
+			return err
+		}
+		user.Locale = locale
+	}
+	return nil
+}
+
 // DeleteUser removes a user from the service (soft delete)
 func (s *userService) DeleteUser(userID string) error {
 	s.mutex.Lock()
@@ -429,6 +283,42 @@ func (s *userService) UpdateDeviceInfo(deviceID string, appVersion, osVersion, d
 	return nil
 }
 
+// SetDeviceAPNSEnvironment sets the APNS environment an iOS device receives pushes from.
+// An empty environment makes the device use the default environment again.
+func (s *userService) SetDeviceAPNSEnvironment(deviceID, environment string) error {
+	if environment != "" && !models.IsValidAPNSEnvironment(environment) {
+		return ErrInvalidAPNSEnv
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	device, exists := s.devices[deviceID]
+	if !exists {
+		return ErrDeviceNotFound
+	}
+
+	device.APNSEnvironment = environment
+	device.UpdatedAt = time.Now()
+	return nil
+}
+
+// SetDeviceAppID sets the mobile app a device belongs to. An empty app ID makes the device
+// use the default push credentials again.
+func (s *userService) SetDeviceAppID(deviceID, appID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	device, exists := s.devices[deviceID]
+	if !exists {
+		return ErrDeviceNotFound
+	}
+
+	device.AppID = appID
+	device.UpdatedAt = time.Now()
+	return nil
+}
+
 // DeactivateDevice marks a device as inactive
 func (s *userService) DeactivateDevice(deviceID string) error {
 	s.mutex.Lock()
@@ -470,6 +360,43 @@ func (s *userService) UpdateDeviceLastUsed(deviceID string) error {
 	return nil
 }
 
+// ListDevices returns copies of the devices of every user that match filter, ordered by ID,
+// so they can be exported without holding the lock
+func (s *userService) ListDevices(filter DeviceFilter) ([]models.UserDeviceInfo, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	devices := make([]models.UserDeviceInfo, 0, len(s.devices))
+	for _, device := range s.devices {
+		if filter.Matches(device) {
+			devices = append(devices, *device)
+		}
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].ID < devices[j].ID
+	})
+
+	return devices, nil
+}
+
+// ListUsers returns copies of the users selected by filter, inactive ones included, ordered by ID
+func (s *userService) ListUsers(filter UserFilter) ([]models.User, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	users := make([]models.User, 0, len(s.users))
+	for _, user := range s.users {
+		if filter.Matches(user) {
+			users = append(users, *user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+
+	return users, nil
+}
+
 // GetUserNotificationInfo retrieves essential user info for notifications
 func (s *userService) GetUserNotificationInfo(userID string) (*models.UserNotificationInfo, error) {
 	user, err := s.GetUserByID(userID)
@@ -484,7 +411,49 @@ func (s *userService) GetUserNotificationInfo(userID string) (*models.UserNotifi
 	}
 
 	notificationInfo := user.ToNotificationInfo()
+	notificationInfo.DoNotDisturb = s.activeDoNotDisturb(user)
 	notificationInfo.Devices = devices
 
 	return notificationInfo, nil
 }
+
+// GetUsersNotificationInfo retrieves notification info for a batch of users.
+// Unknown and inactive users are skipped. Devices are resolved with a single pass over
+// the device store per batch instead of one pass per user, so callers resolving very
+// large recipient lists should call this with bounded batches.
+func (s *userService) GetUsersNotificationInfo(userIDs []string) ([]*models.UserNotificationInfo, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	infos := make([]*models.UserNotificationInfo, 0, len(userIDs))
+	byID := make(map[string]*models.UserNotificationInfo, len(userIDs))
+	for _, userID := range userIDs {
+		user, exists := s.users[userID]
+		if !exists || !user.IsActive {
+			continue
+		}
+		if _, seen := byID[userID]; seen {
+			continue
+		}
+
+		info := user.ToNotificationInfo()
+		info.DoNotDisturb = s.activeDoNotDisturb(user)
+		byID[userID] = info
+		infos = append(infos, info)
+	}
+
+	if len(infos) == 0 {
+		return infos, nil
+	}
+
+	for _, device := range s.devices {
+		if !device.IsActive {
+			continue
+		}
+		if info, ok := byID[device.UserID]; ok {
+			info.Devices = append(info.Devices, device)
+		}
+	}
+
+	return infos, nil
+}
@@ -1,6 +1,7 @@
 package user
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -99,6 +100,67 @@ func TestUserService_UpdateUser(t *testing.T) {
 	assert.Equal(t, "John Doe Updated", updatedUser.FullName)
 }
 
+func TestUserService_OnContactChange(t *testing.T) {
+	service := NewUserService()
+
+	var changed []string
+	service.OnContactChange(func(userID string) {
+		// The user can be looked up from the function
+		_, err := service.GetUserByID(userID)
+		require.NoError(t, err)
+		changed = append(changed, userID)
+	})
+
+	user, err := service.GetUserByID("user-001")
+	require.NoError(t, err)
+	user.Email = "john.doe@newcompany.com"
+	require.NoError(t, service.UpdateUser(user))
+	assert.Equal(t, []string{"user-001"}, changed)
+
+	// Failed updates change nothing
+	assert.Error(t, service.UpdateUser(&models.User{ID: "unknown-user"}))
+	assert.Equal(t, []string{"user-001"}, changed)
+
+	service.OnContactChange(nil)
+	require.NoError(t, service.UpdateUser(user))
+	assert.Len(t, changed, 1)
+}
+
+func TestUserService_TimezoneAndLocale(t *testing.T) {
+	service := NewUserService()
+
+	newUser := &models.User{
+		ID:       "user-new-001",
+		Email:    "newuser@company.com",
+		FullName: "New User",
+		Timezone: "Europe/Berlin",
+		Locale:   "de_de",
+		IsActive: true,
+	}
+	require.NoError(t, service.CreateUser(newUser))
+
+	info, err := service.GetUserNotificationInfo("user-new-001")
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/Berlin", info.Timezone)
+	assert.Equal(t, "de-DE", info.Locale, "locale is stored in canonical form")
+	assert.Equal(t, "Europe/Berlin", info.Location().String())
+
+	invalid := []*models.User{
+		{ID: "user-new-002", Timezone: "Mars/Olympus_Mons"},
+		{ID: "user-new-003", Timezone: "Local"},
+		{ID: "user-new-004", Locale: "not a locale"},
+	}
+	for _, user := range invalid {
+		err := service.CreateUser(user)
+		assert.True(t, errors.Is(err, models.ErrInvalidTimezone) || errors.Is(err, models.ErrInvalidLocale), "%s: %v", user.ID, err)
+	}
+
+	// Users without a timezone are scheduled in UTC
+	info, err = service.GetUserNotificationInfo("user-001")
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC, info.Location())
+}
+
 func TestUserService_DeleteUser(t *testing.T) {
 	service := NewUserService()
 
@@ -112,6 +174,61 @@ func TestUserService_DeleteUser(t *testing.T) {
 	assert.Contains(t, err.Error(), "user is inactive")
 }
 
+func TestUserService_ListUsers(t *testing.T) {
+	service := NewUserService()
+	require.NoError(t, service.CreateUser(&models.User{
+		ID:         "user-scim-001",
+		Email:      "jane.scim@company.com",
+		FullName:   "Jane Scim",
+		UserName:   "Jane.Scim@company.com",
+		ExternalID: "00u1abcd",
+		IsActive:   true,
+	}))
+	require.NoError(t, service.DeleteUser("user-001"))
+
+	// Inactive users are listed, ordered by ID
+	users, err := service.ListUsers(UserFilter{})
+	require.NoError(t, err)
+	assert.Len(t, users, 9)
+	assert.Equal(t, "user-001", users[0].ID)
+	assert.False(t, users[0].IsActive)
+
+	inactive := false
+	users, err = service.ListUsers(UserFilter{Active: &inactive})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "user-001", users[0].ID)
+
+	// User names are matched case-insensitively, external IDs exactly
+	users, err = service.ListUsers(UserFilter{UserName: "jane.scim@COMPANY.com"})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "user-scim-001", users[0].ID)
+
+	// Users without a user name are matched by email address
+	users, err = service.ListUsers(UserFilter{UserName: "john.doe@company.com"})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "user-001", users[0].ID)
+
+	users, err = service.ListUsers(UserFilter{ExternalID: "00U1ABCD"})
+	require.NoError(t, err)
+	assert.Empty(t, users)
+
+	// Listed users are copies
+	users, err = service.ListUsers(UserFilter{ID: "user-scim-001"})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	users[0].FullName = "Changed"
+	stored, err := service.GetUserByID("user-scim-001")
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Scim", stored.FullName)
+
+	// Creating a user with an existing ID fails
+	err = service.CreateUser(&models.User{ID: "user-scim-001"})
+	assert.True(t, errors.Is(err, ErrUserAlreadyExists))
+}
+
 func TestUserService_RegisterDevice(t *testing.T) {
 	service := NewUserService()
 
@@ -152,6 +269,32 @@ func TestUserService_GetActiveUserDevices(t *testing.T) {
 	assert.Len(t, devices, 0) // user-003 has 1 inactive device
 }
 
+func TestUserService_ListDevices(t *testing.T) {
+	service := NewUserService()
+
+	devices, err := service.ListDevices(DeviceFilter{})
+	require.NoError(t, err)
+	require.Len(t, devices, 8) // every sample device, active or not
+	assert.Equal(t, "device-001", devices[0].ID)
+	assert.Equal(t, "device-010", devices[7].ID)
+
+	inactive := false
+	devices, err = service.ListDevices(DeviceFilter{DeviceType: "ios", Active: &inactive})
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	assert.Equal(t, "device-004", devices[0].ID)
+
+	// The export is a snapshot that later changes do not touch
+	devices[0].DeviceToken = "changed"
+	stored, err := service.GetUserDevices("user-003")
+	require.NoError(t, err)
+	assert.NotEqual(t, "changed", stored[0].DeviceToken)
+
+	devices, err = service.ListDevices(DeviceFilter{DeviceType: "web"})
+	require.NoError(t, err)
+	assert.Empty(t, devices)
+}
+
 func TestUserService_UpdateDeviceInfo(t *testing.T) {
 	service := NewUserService()
 
@@ -363,3 +506,23 @@ func TestUserDeviceInfo_HelperFunctions(t *testing.T) {
 	webTokens := models.GetDeviceTokensByType(devices, "web")
 	assert.Len(t, webTokens, 0) // No active web device with token
 }
+
+func TestUserService_GetUsersNotificationInfo(t *testing.T) {
+	service := NewUserService()
+
+	infos, err := service.GetUsersNotificationInfo([]string{"user-001", "non-existent", "user-002", "user-001"})
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	assert.Equal(t, "user-001", infos[0].ID)
+	assert.Equal(t, "user-002", infos[1].ID)
+
+	// Devices must match the single-user lookup
+	single, err := service.GetUserNotificationInfo("user-001")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, single.Devices, infos[0].Devices)
+
+	// Unknown users only
+	infos, err = service.GetUsersNotificationInfo([]string{"non-existent"})
+	require.NoError(t, err)
+	assert.Empty(t, infos)
+}
@@ -0,0 +1,143 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+const (
+	// verificationCodeTTL is how long a verification code can be confirmed
+	verificationCodeTTL = 15 * time.Minute
+
+	// maxVerificationAttempts is how many wrong codes are accepted before a new code is needed
+	maxVerificationAttempts = 5
+
+	// verificationCodeDigits is the length of the numeric verification code
+	verificationCodeDigits = 6
+)
+
+// pendingVerification is a verification code waiting to be confirmed. Only a hash of the
+// code is kept, together with the address it was sent to.
+type pendingVerification struct {
+	codeHash  [sha256.Size]byte
+	address   string
+	expiresAt time.Time
+	attempts  int
+}
+
+// StartContactVerification creates a verification code for one of a user's contact points and
+// returns it together with the challenge. An empty address verifies the primary address of the
+// contact type. A new code replaces any pending one for the address; the caller is responsible
+// for delivering it.
+func (s *userService) StartContactVerification(userID, contact, address string) (*models.VerificationChallenge, string, error) {
+	if !models.IsValidContact(contact) {
+		return nil, "", ErrInvalidContact
+	}
+
+	code, err := newVerificationCode()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate verification code: %w", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists || !user.IsActive {
+		return nil, "", ErrUserNotFound
+	}
+	address = contactAddress(user, contact, address)
+	if address == "" {
+		return nil, "", ErrNoContactAddress
+	}
+
+	expiresAt := s.clock.Now().Add(verificationCodeTTL)
+	s.verifications[verificationKey(userID, contact, address)] = &pendingVerification{
+		codeHash:  sha256.Sum256([]byte(code)),
+		address:   address,
+		expiresAt: expiresAt,
+	}
+
+	return &models.VerificationChallenge{
+		UserID:    userID,
+		Contact:   contact,
+		Address:   address,
+		ExpiresAt: expiresAt,
+	}, code, nil
+}
+
+// ConfirmContactVerification marks a contact point as verified when code matches the pending
+// verification. The code is only valid for the address it was sent to; an empty address is the
+// primary address of the contact type.
+func (s *userService) ConfirmContactVerification(userID, contact, address, code string) error {
+	if !models.IsValidContact(contact) {
+		return ErrInvalidContact
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists || !user.IsActive {
+		return ErrUserNotFound
+	}
+
+	address = contactAddress(user, contact, address)
+	key := verificationKey(userID, contact, address)
+	pending, exists := s.verifications[key]
+	if !exists || address == "" {
+		return ErrVerificationNotFound
+	}
+	now := s.clock.Now()
+	if now.After(pending.expiresAt) {
+		delete(s.verifications, key)
+		return ErrVerificationExpired
+	}
+	if pending.attempts >= maxVerificationAttempts {
+		return ErrTooManyCodeAttempts
+	}
+
+	hash := sha256.Sum256([]byte(code))
+	if subtle.ConstantTimeCompare(hash[:], pending.codeHash[:]) != 1 {
+		pending.attempts++
+		return ErrInvalidCode
+	}
+
+	delete(s.verifications, key)
+	user.MarkContactVerified(contact, address, now)
+	user.UpdatedAt = now
+	return nil
+}
+
+// contactAddress returns the address of a user's contact point to verify: the primary address
+// when address is empty, and empty when the address is not one of the user's
+func contactAddress(user *models.User, contact, address string) string {
+	if address == "" {
+		return user.ContactAddress(contact)
+	}
+	if !user.HasContactPoint(contact, address) {
+		return ""
+	}
+	return address
+}
+
+// verificationKey is the key of the pending verification of an address
+func verificationKey(userID, contact, address string) string {
+	return userID + "|" + contact + "|" + address
+}
+
+// newVerificationCode returns a random numeric code of verificationCodeDigits digits
+func newVerificationCode() (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(verificationCodeDigits), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", verificationCodeDigits, n), nil
+}
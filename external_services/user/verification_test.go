@@ -0,0 +1,71 @@
+package user
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContactVerification(t *testing.T) {
+	service := NewUserService().(*userService)
+	fake := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	service.clock = fake
+
+	challenge, code, err := service.StartContactVerification("user-001", models.ContactEmail, "")
+	require.NoError(t, err)
+	assert.Len(t, code, verificationCodeDigits)
+	assert.Equal(t, "john.doe@company.com", challenge.Address)
+	assert.Equal(t, fake.Now().Add(verificationCodeTTL), challenge.ExpiresAt)
+
+	assert.ErrorIs(t, service.ConfirmContactVerification("user-001", models.ContactEmail, "", "not-the-code"), ErrInvalidCode)
+	require.NoError(t, service.ConfirmContactVerification("user-001", models.ContactEmail, "", code))
+
+	info, err := service.GetUserNotificationInfo("user-001")
+	require.NoError(t, err)
+	assert.True(t, info.EmailVerified)
+	assert.False(t, info.PhoneVerified)
+
+	// A code can only be used once
+	assert.ErrorIs(t, service.ConfirmContactVerification("user-001", models.ContactEmail, "", code), ErrVerificationNotFound)
+}
+
+func TestContactVerification_Rejections(t *testing.T) {
+	service := NewUserService().(*userService)
+	fake := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	service.clock = fake
+
+	_, _, err := service.StartContactVerification("user-001", "fax", "")
+	assert.ErrorIs(t, err, ErrInvalidContact)
+	_, _, err = service.StartContactVerification("user-999", models.ContactEmail, "")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	require.NoError(t, service.CreateUser(&models.User{ID: "user-no-phone", Email: "no.phone@company.com", IsActive: true}))
+	_, _, err = service.StartContactVerification("user-no-phone", models.ContactPhone, "")
+	assert.ErrorIs(t, err, ErrNoContactAddress)
+
+	// Codes expire
+	_, code, err := service.StartContactVerification("user-001", models.ContactPhone, "")
+	require.NoError(t, err)
+	fake.Advance(verificationCodeTTL + time.Second)
+	assert.ErrorIs(t, service.ConfirmContactVerification("user-001", models.ContactPhone, "", code), ErrVerificationExpired)
+
+	// Wrong guesses are limited
+	_, code, err = service.StartContactVerification("user-001", models.ContactPhone, "")
+	require.NoError(t, err)
+	for i := 0; i < maxVerificationAttempts; i++ {
+		assert.ErrorIs(t, service.ConfirmContactVerification("user-001", models.ContactPhone, "", "wrong"), ErrInvalidCode)
+	}
+	assert.ErrorIs(t, service.ConfirmContactVerification("user-001", models.ContactPhone, "", code), ErrTooManyCodeAttempts)
+
+	// A code is only valid for the address it was sent to
+	_, code, err = service.StartContactVerification("user-001", models.ContactEmail, "")
+	require.NoError(t, err)
+	user, err := service.GetUserByID("user-001")
+	require.NoError(t, err)
+	user.Email = "john.new@company.com"
+	require.NoError(t, service.UpdateUser(user))
+	assert.ErrorIs(t, service.ConfirmContactVerification("user-001", models.ContactEmail, "", code), ErrVerificationNotFound)
+}
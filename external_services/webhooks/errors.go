@@ -0,0 +1,12 @@
+package webhooks
+
+import "errors"
+
+// Webhook signature errors
+var (
+	ErrMissingSignature = errors.New("missing webhook signature")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrStaleTimestamp   = errors.New("webhook timestamp outside the tolerance")
+	ErrInvalidPublicKey = errors.New("invalid webhook public key")
+	ErrUnknownProvider  = errors.New("unknown webhook provider")
+)
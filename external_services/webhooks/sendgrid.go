@@ -0,0 +1,69 @@
+package webhooks
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+)
+
+// SendGrid signed event webhook headers
+const (
+	sendGridSignatureHeader = "X-Twilio-Email-Event-Webhook-Signature"
+	sendGridTimestampHeader = "X-Twilio-Email-Event-Webhook-Timestamp"
+)
+
+// sendGridVerifier verifies SendGrid's event webhook signatures, an ECDSA signature of the
+// timestamp followed by the body
+type sendGridVerifier struct {
+	publicKey *ecdsa.PublicKey
+	tolerance time.Duration
+	clock     clock.Clock
+}
+
+// NewSendGridVerifier creates a verifier for the base64 encoded verification key SendGrid
+// shows when signed event webhooks are enabled
+func NewSendGridVerifier(publicKey string, tolerance time.Duration, clk clock.Clock) (Verifier, error) {
+	der, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPublicKey, err)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPublicKey, err)
+	}
+	key, ok := parsed.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: not an ECDSA key", ErrInvalidPublicKey)
+	}
+	return &sendGridVerifier{publicKey: key, tolerance: tolerance, clock: clk}, nil
+}
+
+// Verify checks the X-Twilio-Email-Event-Webhook-Signature header of r
+func (v *sendGridVerifier) Verify(r *http.Request, body []byte) error {
+	timestamp := r.Header.Get(sendGridTimestampHeader)
+	signature := r.Header.Get(sendGridSignatureHeader)
+	if timestamp == "" || signature == "" {
+		return fmt.Errorf("%w: %s and %s are required", ErrMissingSignature, sendGridSignatureHeader, sendGridTimestampHeader)
+	}
+	if err := checkTimestamp(timestamp, v.tolerance, v.clock.Now()); err != nil {
+		return err
+	}
+
+	provided, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: malformed %s", ErrInvalidSignature, sendGridSignatureHeader)
+	}
+	digest := sha256.New()
+	digest.Write([]byte(timestamp))
+	digest.Write(body)
+	if !ecdsa.VerifyASN1(v.publicKey, digest.Sum(nil), provided) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
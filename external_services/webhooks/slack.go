@@ -0,0 +1,58 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+)
+
+// Slack request signing headers
+const (
+	slackSignatureHeader = "X-Slack-Signature"
+	slackTimestampHeader = "X-Slack-Request-Timestamp"
+	slackSignatureScheme = "v0"
+)
+
+// slackVerifier verifies Slack's v0 signatures, an HMAC-SHA256 of the timestamp and body
+// keyed with the app's signing secret
+type slackVerifier struct {
+	secret    []byte
+	tolerance time.Duration
+	clock     clock.Clock
+}
+
+// NewSlackVerifier creates a verifier for requests signed with a Slack app's signing secret
+func NewSlackVerifier(secret string, tolerance time.Duration, clk clock.Clock) Verifier {
+	return &slackVerifier{secret: []byte(secret), tolerance: tolerance, clock: clk}
+}
+
+// Verify checks the X-Slack-Signature header of r
+func (v *slackVerifier) Verify(r *http.Request, body []byte) error {
+	timestamp := r.Header.Get(slackTimestampHeader)
+	signature := r.Header.Get(slackSignatureHeader)
+	if timestamp == "" || signature == "" {
+		return fmt.Errorf("%w: %s and %s are required", ErrMissingSignature, slackSignatureHeader, slackTimestampHeader)
+	}
+	if err := checkTimestamp(timestamp, v.tolerance, v.clock.Now()); err != nil {
+		return err
+	}
+
+	encoded, ok := strings.CutPrefix(signature, slackSignatureScheme+"=")
+	provided, err := hex.DecodeString(encoded)
+	if !ok || err != nil {
+		return fmt.Errorf("%w: malformed %s", ErrInvalidSignature, slackSignatureHeader)
+	}
+	mac := hmac.New(sha256.New, v.secret)
+	mac.Write([]byte(slackSignatureScheme + ":" + timestamp + ":"))
+	mac.Write(body)
+	if !hmac.Equal(provided, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
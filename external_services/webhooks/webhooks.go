@@ -0,0 +1,88 @@
+// Package webhooks verifies the signatures of callbacks that providers send to the service,
+// such as SendGrid events, Slack interactivity and Twilio status callbacks
+package webhooks
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/constants"
+)
+
+// Providers sending signed callbacks
+const (
+	ProviderSendGrid = "sendgrid"
+	ProviderSlack    = "slack"
+	ProviderTwilio   = "twilio"
+)
+
+// Verifier checks that a callback was signed by its provider
+type Verifier interface {
+	// Verify checks the signature of r; body is the raw request body, which r no longer holds
+	Verify(r *http.Request, body []byte) error
+}
+
+// Config holds the secrets the providers sign their callbacks with
+type Config struct {
+	SlackSigningSecret string
+	// SendGridPublicKey is the base64 encoded verification key of the SendGrid event webhook
+	SendGridPublicKey string
+	TwilioAuthToken   string
+	// PublicBaseURL is the scheme and host providers call, e.g. https://notify.example.com,
+	// needed by Twilio whose signature covers the URL; defaults to the request's host
+	PublicBaseURL string
+	// Tolerance is how old a signed timestamp may be
+	Tolerance time.Duration
+}
+
+// LoadConfigFromEnv reads the webhook secrets from environment variables
+func LoadConfigFromEnv() Config {
+	config := Config{
+		SlackSigningSecret: os.Getenv(constants.SlackSigningSecretEnvVar),
+		SendGridPublicKey:  os.Getenv(constants.SendGridWebhookPublicKeyEnvVar),
+		TwilioAuthToken:    os.Getenv(constants.TwilioAuthTokenEnvVar),
+		PublicBaseURL:      strings.TrimSuffix(os.Getenv(constants.WebhookPublicBaseURLEnvVar), "/"),
+		Tolerance:          time.Duration(constants.DefaultWebhookTimestampToleranceSeconds) * time.Second,
+	}
+	if seconds, err := strconv.Atoi(os.Getenv(constants.WebhookTimestampToleranceSecondsEnvVar)); err == nil && seconds > 0 {
+		config.Tolerance = time.Duration(seconds) * time.Second
+	}
+	return config
+}
+
+// NewVerifiers returns a verifier for each provider with a configured secret
+func NewVerifiers(config Config, clk clock.Clock) (map[string]Verifier, error) {
+	verifiers := make(map[string]Verifier)
+	if config.SlackSigningSecret != "" {
+		verifiers[ProviderSlack] = NewSlackVerifier(config.SlackSigningSecret, config.Tolerance, clk)
+	}
+	if config.SendGridPublicKey != "" {
+		verifier, err := NewSendGridVerifier(config.SendGridPublicKey, config.Tolerance, clk)
+		if err != nil {
+			return nil, err
+		}
+		verifiers[ProviderSendGrid] = verifier
+	}
+	if config.TwilioAuthToken != "" {
+		verifiers[ProviderTwilio] = NewTwilioVerifier(config.TwilioAuthToken, config.PublicBaseURL)
+	}
+	return verifiers, nil
+}
+
+// checkTimestamp checks that a unix timestamp in seconds lies within tolerance of now
+func checkTimestamp(value string, tolerance time.Duration, now time.Time) error {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp %q", ErrInvalidSignature, value)
+	}
+	age := now.Sub(time.Unix(seconds, 0))
+	if age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: signed %s ago", ErrStaleTimestamp, age.Round(time.Second))
+	}
+	return nil
+}
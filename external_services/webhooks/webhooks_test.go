@@ -0,0 +1,140 @@
+package webhooks
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+func TestSlackVerifier(t *testing.T) {
+	verifier := NewSlackVerifier("secret", 5*time.Minute, clock.NewFake(testNow))
+	body := []byte("payload=%7B%22type%22%3A%22block_actions%22%7D")
+
+	sign := func(secret string, timestamp time.Time) *http.Request {
+		ts := strconv.FormatInt(timestamp.Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte("v0:" + ts + ":" + string(body)))
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/slack", nil)
+		req.Header.Set("X-Slack-Request-Timestamp", ts)
+		req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+		return req
+	}
+
+	assert.NoError(t, verifier.Verify(sign("secret", testNow.Add(-time.Minute)), body))
+	assert.ErrorIs(t, verifier.Verify(sign("other", testNow), body), ErrInvalidSignature)
+	assert.ErrorIs(t, verifier.Verify(sign("secret", testNow), []byte("tampered")), ErrInvalidSignature)
+	assert.ErrorIs(t, verifier.Verify(sign("secret", testNow.Add(-10*time.Minute)), body), ErrStaleTimestamp)
+	assert.ErrorIs(t, verifier.Verify(httptest.NewRequest(http.MethodPost, "/webhooks/slack", nil), body), ErrMissingSignature)
+
+	req := sign("secret", testNow)
+	req.Header.Set("X-Slack-Signature", "v1=abc")
+	assert.ErrorIs(t, verifier.Verify(req, body), ErrInvalidSignature)
+}
+
+func TestSendGridVerifier(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	verifier, err := NewSendGridVerifier(base64.StdEncoding.EncodeToString(der), 5*time.Minute, clock.NewFake(testNow))
+	require.NoError(t, err)
+	body := []byte(`[{"email":"john.doe@company.com","event":"delivered"}]`)
+
+	sign := func(timestamp time.Time) *http.Request {
+		ts := strconv.FormatInt(timestamp.Unix(), 10)
+		digest := sha256.Sum256(append([]byte(ts), body...))
+		signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/sendgrid", nil)
+		req.Header.Set("X-Twilio-Email-Event-Webhook-Timestamp", ts)
+		req.Header.Set("X-Twilio-Email-Event-Webhook-Signature", base64.StdEncoding.EncodeToString(signature))
+		return req
+	}
+
+	assert.NoError(t, verifier.Verify(sign(testNow), body))
+	assert.ErrorIs(t, verifier.Verify(sign(testNow), []byte(`[]`)), ErrInvalidSignature)
+	assert.ErrorIs(t, verifier.Verify(sign(testNow.Add(time.Hour)), body), ErrStaleTimestamp)
+	assert.ErrorIs(t, verifier.Verify(httptest.NewRequest(http.MethodPost, "/webhooks/sendgrid", nil), body), ErrMissingSignature)
+
+	_, err = NewSendGridVerifier("not base64!", time.Minute, clock.Real())
+	assert.ErrorIs(t, err, ErrInvalidPublicKey)
+}
+
+func TestTwilioVerifier(t *testing.T) {
+	sign := func(data string) string {
+		mac := hmac.New(sha1.New, []byte("token"))
+		mac.Write([]byte(data))
+		return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	t.Run("form body", func(t *testing.T) {
+		verifier := NewTwilioVerifier("token", "https://notify.example.com")
+		body := "MessageStatus=delivered&MessageSid=SM123&To=%2B15551234567"
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/twilio?tenant=a", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Twilio-Signature", sign("https://notify.example.com/webhooks/twilio?tenant=a"+
+			"MessageSidSM123MessageStatusdeliveredTo+15551234567"))
+
+		assert.NoError(t, verifier.Verify(req, []byte(body)))
+		assert.ErrorIs(t, verifier.Verify(req, []byte("MessageStatus=failed&MessageSid=SM123&To=%2B15551234567")), ErrInvalidSignature)
+
+		// The URL is taken from the request without a public base URL
+		assert.ErrorIs(t, NewTwilioVerifier("token", "").Verify(req, []byte(body)), ErrInvalidSignature)
+	})
+
+	t.Run("json body", func(t *testing.T) {
+		verifier := NewTwilioVerifier("token", "")
+		body := []byte(`{"status":"delivered"}`)
+		sum := sha256.Sum256(body)
+		target := "/webhooks/twilio?bodySHA256=" + hex.EncodeToString(sum[:])
+		req := httptest.NewRequest(http.MethodPost, target, nil)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Twilio-Signature", sign("http://example.com"+target))
+
+		assert.NoError(t, verifier.Verify(req, body))
+		assert.ErrorIs(t, verifier.Verify(req, []byte(`{"status":"failed"}`)), ErrInvalidSignature)
+	})
+
+	verifier := NewTwilioVerifier("token", "")
+	assert.ErrorIs(t, verifier.Verify(httptest.NewRequest(http.MethodPost, "/webhooks/twilio", nil), nil), ErrMissingSignature)
+}
+
+func TestNewVerifiers(t *testing.T) {
+	verifiers, err := NewVerifiers(Config{SlackSigningSecret: "secret", TwilioAuthToken: "token"}, clock.Real())
+	require.NoError(t, err)
+	assert.Contains(t, verifiers, ProviderSlack)
+	assert.Contains(t, verifiers, ProviderTwilio)
+	assert.NotContains(t, verifiers, ProviderSendGrid)
+
+	_, err = NewVerifiers(Config{SendGridPublicKey: base64.StdEncoding.EncodeToString([]byte("not a key"))}, clock.Real())
+	assert.ErrorIs(t, err, ErrInvalidPublicKey)
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	t.Setenv("SLACK_SIGNING_SECRET", "secret")
+	t.Setenv("WEBHOOK_PUBLIC_BASE_URL", "https://notify.example.com/")
+	t.Setenv("WEBHOOK_TIMESTAMP_TOLERANCE_SECONDS", "60")
+
+	config := LoadConfigFromEnv()
+	assert.Equal(t, "secret", config.SlackSigningSecret)
+	assert.Equal(t, "https://notify.example.com", config.PublicBaseURL)
+	assert.Equal(t, time.Minute, config.Tolerance)
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gaurav2721/notification-service/auth"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// GetBranding handles GET /branding
+func (h *NotificationHandler) GetBranding(c *gin.Context) {
+	c.JSON(http.StatusOK, h.notificationService.GetBranding(auth.Principal(c)))
+}
+
+// UpdateBranding handles PUT /branding
+func (h *NotificationHandler) UpdateBranding(c *gin.Context) {
+	// Get validated request from middleware
+	validatedRequestInterface, exists := c.Get("validated_branding_request")
+	if !exists {
+		logrus.Error("Validated branding request not found in context")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	request, ok := validatedRequestInterface.(*models.Branding)
+	if !ok {
+		logrus.Error("Failed to cast validated request to Branding")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	tenant := auth.Principal(c)
+	branding := h.notificationService.UpdateBranding(tenant, request, tenant)
+
+	audit(c, "branding.updated", logger.Fields{"tenant": tenant})
+	c.JSON(http.StatusOK, branding)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gaurav2721/notification-service/external_services/consumers"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// ListDeadLetters handles GET /admin/dead-letters. The type query parameter limits the list to
+// the dead letters of one notification type.
+func (h *NotificationHandler) ListDeadLetters(c *gin.Context) {
+	letters := consumers.DefaultDeadLetterQueue.List(consumers.NotificationType(c.Query("type")))
+	c.JSON(http.StatusOK, gin.H{
+		"dead_letters": letters,
+		"count":        len(letters),
+	})
+}
+
+// ReplayDeadLetter handles POST /admin/dead-letters/:deadLetterId/replay
+func (h *NotificationHandler) ReplayDeadLetter(c *gin.Context) {
+	letter, err := consumers.DefaultDeadLetterQueue.Replay(c.Param("deadLetterId"))
+	if err != nil {
+		respondDeadLetterError(c, err)
+		return
+	}
+
+	audit(c, "dead_letter.replayed", logger.Fields{"dead_letter_id": letter.ID, "type": letter.Type})
+	c.JSON(http.StatusOK, gin.H{"message": "Dead letter replayed", "dead_letter": letter})
+}
+
+// DiscardDeadLetter handles DELETE /admin/dead-letters/:deadLetterId
+func (h *NotificationHandler) DiscardDeadLetter(c *gin.Context) {
+	letter, err := consumers.DefaultDeadLetterQueue.Discard(c.Param("deadLetterId"))
+	if err != nil {
+		respondDeadLetterError(c, err)
+		return
+	}
+
+	audit(c, "dead_letter.discarded", logger.Fields{"dead_letter_id": letter.ID, "type": letter.Type})
+	c.JSON(http.StatusOK, gin.H{"message": "Dead letter discarded", "dead_letter_id": letter.ID})
+}
+
+// respondDeadLetterError maps dead-letter queue errors to status codes
+func respondDeadLetterError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, consumers.ErrDeadLetterNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, consumers.ErrDeadLetterChannelFull):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gaurav2721/notification-service/external_services/domaincheck"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// CheckEmailDomain handles GET /admin/email/domain-check?domain=...&selectors=.... DNS lookups
+// that fail are reported as problems of the domain.
+func (h *NotificationHandler) CheckEmailDomain(c *gin.Context) {
+	domain := c.Query("domain")
+	if domain == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "domain is required"})
+		return
+	}
+	var selectors []string
+	for _, selector := range strings.Split(c.Query("selectors"), ",") {
+		if selector = strings.TrimSpace(selector); selector != "" {
+			selectors = append(selectors, selector)
+		}
+	}
+
+	report, err := h.domainChecker.Check(c.Request.Context(), domain, selectors)
+	switch {
+	case errors.Is(err, domaincheck.ErrInvalidDomain), errors.Is(err, domaincheck.ErrInvalidSelector):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		logrus.WithError(err).Error("Failed to check email domain")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gaurav2721/notification-service/health"
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler answers the Kubernetes liveness, readiness and startup probes
+type HealthHandler struct {
+	probes *health.Probes
+}
+
+// NewHealthHandler creates a new health handler. It can serve the probes before the services
+// are initialized.
+func NewHealthHandler(probes *health.Probes) *HealthHandler {
+	return &HealthHandler{probes: probes}
+}
+
+// Livez handles GET /livez. The process is alive as long as it answers.
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "alive"})
+}
+
+// Readyz handles GET /readyz. The service is not ready while it starts, while it drains during
+// shutdown, or while a readiness check fails.
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	readiness := h.probes.Readiness(c.Request.Context())
+	if !readiness.Ready() {
+		c.JSON(http.StatusServiceUnavailable, readiness)
+		return
+	}
+	c.JSON(http.StatusOK, readiness)
+}
+
+// Startupz handles GET /startupz. The service has started once every service was initialized.
+func (h *HealthHandler) Startupz(c *gin.Context) {
+	if !h.probes.Started() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": health.StateStarting})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "started"})
+}
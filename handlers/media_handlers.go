@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gaurav2721/notification-service/auth"
+	"github.com/gaurav2721/notification-service/external_services/objectstore"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/notification_manager"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// UploadMedia handles POST /media, a multipart form with the asset in its file field
+func (h *NotificationHandler) UploadMedia(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "A multipart form with a file field is required",
+			"details": err.Error(),
+		})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		respondMediaError(c, err, "Failed to read media upload")
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		respondMediaError(c, err, "Failed to read media upload")
+		return
+	}
+
+	tenant := auth.Principal(c)
+	asset, err := h.notificationService.UploadMedia(c.Request.Context(), tenant, header.Filename, header.Header.Get("Content-Type"), content, tenant)
+	if err != nil {
+		respondMediaError(c, err, "Failed to upload media asset")
+		return
+	}
+
+	audit(c, "media.uploaded", logger.Fields{"filename": header.Filename, "size": len(content)})
+	c.JSON(http.StatusCreated, asset)
+}
+
+// ListMedia handles GET /media
+func (h *NotificationHandler) ListMedia(c *gin.Context) {
+	assets := h.notificationService.ListMedia(auth.Principal(c))
+	c.JSON(http.StatusOK, gin.H{
+		"media": assets,
+		"count": len(assets),
+	})
+}
+
+// GetMedia handles GET /media/:mediaId
+func (h *NotificationHandler) GetMedia(c *gin.Context) {
+	asset, err := h.notificationService.GetMedia(auth.Principal(c), c.Param("mediaId"))
+	if err != nil {
+		respondMediaError(c, err, "Failed to get media asset")
+		return
+	}
+	c.JSON(http.StatusOK, asset)
+}
+
+// DeleteMedia handles DELETE /media/:mediaId
+func (h *NotificationHandler) DeleteMedia(c *gin.Context) {
+	mediaID := c.Param("mediaId")
+	if err := h.notificationService.DeleteMedia(c.Request.Context(), auth.Principal(c), mediaID); err != nil {
+		respondMediaError(c, err, "Failed to delete media asset")
+		return
+	}
+
+	audit(c, "media.deleted", logger.Fields{"media_id": mediaID})
+	c.JSON(http.StatusOK, gin.H{"message": "Media asset deleted", "media_id": mediaID})
+}
+
+// respondMediaError writes the response of a failed media request
+func respondMediaError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, notification_manager.ErrMediaNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, notification_manager.ErrInvalidMedia):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, notification_manager.ErrMediaTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, notification_manager.ErrMediaInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, notification_manager.ErrMediaStoreNotConfigured):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case errors.Is(err, objectstore.ErrRequestFailed):
+		logrus.WithError(err).Error(message)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		logrus.WithError(err).Error(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,19 +1,43 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/gaurav2721/notification-service/auth"
+	"github.com/gaurav2721/notification-service/constants"
+	"github.com/gaurav2721/notification-service/external_services/concurrency"
+	"github.com/gaurav2721/notification-service/external_services/delivery"
+	"github.com/gaurav2721/notification-service/external_services/domaincheck"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/metrics"
 	"github.com/gaurav2721/notification-service/models"
 	"github.com/gaurav2721/notification-service/notification_manager"
+	"github.com/gaurav2721/notification-service/routes/middleware"
+	"github.com/gaurav2721/notification-service/validation"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
+// Error codes of notification send errors in v2 responses
+const (
+	errorCodeContentLimitExceeded    = "content_limit_exceeded"
+	errorCodeTemplateNotActive       = "template_not_active"
+	errorCodeBudgetExceeded          = "budget_exceeded"
+	errorCodeDuplicateNotification   = "duplicate_notification"
+	errorCodeSenderNotAllowed        = "sender_not_allowed"
+	errorCodeCategoryNotAllowed      = "category_not_allowed"
+	errorCodeTransactionalNotAllowed = "transactional_not_allowed"
+)
+
 // NotificationHandler handles HTTP requests for notifications
 type NotificationHandler struct {
 	notificationService notification_manager.NotificationManager
+	domainChecker       *domaincheck.Checker
 }
 
 // NewNotificationHandler creates a new notification handler
@@ -22,6 +46,7 @@ func NewNotificationHandler(
 ) *NotificationHandler {
 	return &NotificationHandler{
 		notificationService: notificationService,
+		domainChecker:       domaincheck.NewChecker(domaincheck.LoadConfigFromEnv(), nil),
 	}
 }
 
@@ -46,6 +71,9 @@ func (h *NotificationHandler) SendNotification(c *gin.Context) {
 
 	// Dereference the pointer to get the actual request
 	request := *requestPtr
+	request.Tenant = auth.Principal(c)
+	// v2 accepts sends right away; their progress is read from the status endpoint
+	request.AcceptAsync = middleware.APIVersion(c) == middleware.APIVersionV2
 
 	logrus.WithFields(logrus.Fields{
 		"type":        request.Type,
@@ -56,13 +84,66 @@ func (h *NotificationHandler) SendNotification(c *gin.Context) {
 	}).Debug("Processing notification request")
 
 	// Process the notification request through the notification manager
-	response, err := h.notificationService.ProcessNotificationRequest(&request)
+	response, err := h.notificationService.ProcessNotificationRequestWithContext(c.Request.Context(), &request)
 	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			middleware.AbortWithDeadlineExceeded(c)
+			return
+		}
+		if errors.Is(err, notification_manager.ErrContentLimitExceeded) {
+			middleware.SetErrorCode(c, errorCodeContentLimitExceeded)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// Senders outside the allowed domains fail validation, like a malformed from address
+		if errors.Is(err, notification_manager.ErrSenderNotAllowed) {
+			middleware.SetErrorCode(c, errorCodeSenderNotAllowed)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Validation failed",
+				"details": []validation.ValidationError{{Field: "from.email", Message: err.Error()}},
+			})
+			return
+		}
+		if errors.Is(err, notification_manager.ErrTransactionalNotAllowed) {
+			middleware.SetErrorCode(c, errorCodeTransactionalNotAllowed)
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "Forbidden",
+				"details": []validation.ValidationError{{Field: "transactional", Message: err.Error()}},
+			})
+			return
+		}
+		if errors.Is(err, notification_manager.ErrCategoryNotAllowed) {
+			middleware.SetErrorCode(c, errorCodeCategoryNotAllowed)
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, models.ErrTemplateNotActive) {
+			middleware.SetErrorCode(c, errorCodeTemplateNotActive)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, notification_manager.ErrBudgetExceeded) {
+			middleware.SetErrorCode(c, errorCodeBudgetExceeded)
+			c.JSON(http.StatusPaymentRequired, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, notification_manager.ErrDuplicateNotification) {
+			middleware.SetErrorCode(c, errorCodeDuplicateNotification)
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		logrus.WithError(err).Error("Failed to process notification request")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if request.AcceptAsync {
+		if accepted, ok := response.(map[string]interface{}); ok {
+			c.Header("Location", fmt.Sprintf("/api/%s/notifications/%v", middleware.APIVersion(c), accepted["id"]))
+		}
+		c.JSON(http.StatusAccepted, response)
+		return
+	}
 	c.JSON(http.StatusOK, response)
 }
 
@@ -74,6 +155,24 @@ func (h *NotificationHandler) GetNotificationStatus(c *gin.Context) {
 		return
 	}
 
+	// With ?wait=30s the request is held until the notification reaches a terminal status
+	if waitStr := c.Query("wait"); waitStr != "" {
+		wait, err := time.ParseDuration(waitStr)
+		if err != nil || wait <= 0 || wait > constants.MaxNotificationStatusWaitSeconds*time.Second {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "wait must be a duration such as 30s of at most " + strconv.Itoa(constants.MaxNotificationStatusWaitSeconds) + "s"})
+			return
+		}
+
+		response, err := h.notificationService.WaitForNotificationStatus(c.Request.Context(), notificationID, wait)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
 	response, err := h.notificationService.GetNotificationStatus(notificationID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -83,6 +182,190 @@ func (h *NotificationHandler) GetNotificationStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// CancelNotification handles DELETE /notifications/:id
+func (h *NotificationHandler) CancelNotification(c *gin.Context) {
+	notificationID := c.Param("id")
+	response, err := h.notificationService.CancelNotification(notificationID, auth.Principal(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, notification_manager.ErrNotificationNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, notification_manager.ErrNotificationNotCancellable):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			logrus.WithError(err).WithField("notification_id", notificationID).Error("Failed to cancel notification")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	audit(c, "notification.cancelled", logger.Fields{"notification_id": notificationID})
+	c.JSON(http.StatusOK, response)
+}
+
+// ListNotifications handles GET /notifications?external_id=...&tag=...
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	response, err := h.notificationService.ListNotifications(notificationFilterFromQuery(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetNotificationAnalytics handles GET /analytics/notifications
+func (h *NotificationHandler) GetNotificationAnalytics(c *gin.Context) {
+	response, err := h.notificationService.GetNotificationAnalytics(notificationFilterFromQuery(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// audit records a change made through the API in the audit log stream, with the API key name
+// as actor
+func audit(c *gin.Context, action string, fields logger.Fields) {
+	fields["actor"] = auth.Principal(c)
+	fields["client_ip"] = c.ClientIP()
+	logger.Audit(action, fields)
+}
+
+// notificationFilterFromQuery builds a notification filter from the external_id, tag, status and type query parameters
+func notificationFilterFromQuery(c *gin.Context) notification_manager.NotificationFilter {
+	return notification_manager.NotificationFilter{
+		ExternalID: c.Query("external_id"),
+		Tags:       c.QueryArray("tag"),
+		Status:     notification_manager.NotificationStatus(c.Query("status")),
+		Type:       c.Query("type"),
+	}
+}
+
+// GetDeliveryAttempts handles GET /notifications/:id/deliveries/:recipient/attempts
+func (h *NotificationHandler) GetDeliveryAttempts(c *gin.Context) {
+	notificationID := c.Param("id")
+	recipient := c.Param("recipient")
+	if recipient == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "recipient is required"})
+		return
+	}
+
+	response, err := h.notificationService.GetDeliveryAttempts(notificationID, recipient)
+	if err != nil {
+		if errors.Is(err, notification_manager.ErrNotificationNotFound) || errors.Is(err, delivery.ErrAttemptsNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ValidateRecipients handles POST /notifications/validate-recipients
+func (h *NotificationHandler) ValidateRecipients(c *gin.Context) {
+	// Get validated request from middleware
+	validatedRequestInterface, exists := c.Get("validated_recipient_check_request")
+	if !exists {
+		logrus.Error("Validated recipient check request not found in context")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	request, ok := validatedRequestInterface.(*models.RecipientCheckRequest)
+	if !ok {
+		logrus.Error("Failed to cast validated request to RecipientCheckRequest")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	request.Tenant = auth.Principal(c)
+
+	report, err := h.notificationService.CheckRecipients(request)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// RecordEngagementEvent handles POST /notifications/:id/events
+func (h *NotificationHandler) RecordEngagementEvent(c *gin.Context) {
+	// Get validated request from middleware
+	validatedRequestInterface, exists := c.Get("validated_engagement_request")
+	if !exists {
+		logrus.Error("Validated engagement request not found in context")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	request, ok := validatedRequestInterface.(*models.EngagementEventRequest)
+	if !ok {
+		logrus.Error("Failed to cast validated request to EngagementEventRequest")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	event, err := h.notificationService.RecordEngagementEvent(c.Param("id"), request)
+	if err != nil {
+		switch {
+		case errors.Is(err, notification_manager.ErrNotificationNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, notification_manager.ErrRecipientNotFound), errors.Is(err, notification_manager.ErrEngagementNotSupported):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, event)
+}
+
+// RecordDeliveryReceipts handles POST /delivery-receipts
+func (h *NotificationHandler) RecordDeliveryReceipts(c *gin.Context) {
+	// Get validated request from middleware
+	validatedRequestInterface, exists := c.Get("validated_receipt_batch")
+	if !exists {
+		logrus.Error("Validated receipt batch not found in context")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	batch, ok := validatedRequestInterface.(*models.DeliveryReceiptBatch)
+	if !ok {
+		logrus.Error("Failed to cast validated request to DeliveryReceiptBatch")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	response, err := h.notificationService.RecordDeliveryReceipts(batch.Receipts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetEngagementEvents handles GET /notifications/:id/events
+func (h *NotificationHandler) GetEngagementEvents(c *gin.Context) {
+	response, err := h.notificationService.GetEngagementEvents(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, notification_manager.ErrNotificationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // CreateTemplate handles POST /templates
 func (h *NotificationHandler) CreateTemplate(c *gin.Context) {
 	// Get validated request from middleware
@@ -110,17 +393,122 @@ func (h *NotificationHandler) CreateTemplate(c *gin.Context) {
 		Content:           request.Content,
 		RequiredVariables: request.RequiredVariables,
 		Description:       request.Description,
+		RenderMode:        request.RenderMode,
+		Category:          request.Category,
+		CreatedBy:         auth.Principal(c),
+		Locale:            request.Locale,
+		Localizations:     request.Localizations,
+		ColorScheme:       request.ColorScheme,
 	}
 
 	response, err := h.notificationService.CreateTemplate(template)
 	if err != nil {
+		if errors.Is(err, models.ErrInvalidLocale) || errors.Is(err, models.ErrInvalidTemplateContent) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	audit(c, "template.created", logger.Fields{"template_id": template.ID, "name": template.Name})
 	c.JSON(http.StatusCreated, response)
 }
 
+// UpdateTemplate handles PUT /templates/:templateId
+func (h *NotificationHandler) UpdateTemplate(c *gin.Context) {
+	templateID := c.Param("templateId")
+
+	// Get validated request from middleware
+	validatedRequestInterface, exists := c.Get("validated_template_request")
+	if !exists {
+		logrus.Error("Validated template request not found in context")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	request, ok := validatedRequestInterface.(*models.TemplateRequest)
+	if !ok {
+		logrus.Error("Failed to cast validated request to TemplateRequest")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	template := &models.Template{
+		Name:              request.Name,
+		Type:              request.Type,
+		Content:           request.Content,
+		RequiredVariables: request.RequiredVariables,
+		Description:       request.Description,
+		RenderMode:        request.RenderMode,
+		Category:          request.Category,
+		Locale:            request.Locale,
+		Localizations:     request.Localizations,
+		ColorScheme:       request.ColorScheme,
+	}
+
+	response, err := h.notificationService.UpdateTemplate(templateID, template, auth.Principal(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, notification_manager.ErrTemplateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, models.ErrPredefinedTemplate):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, models.ErrTemplateTypeChanged), errors.Is(err, models.ErrInvalidTemplateContent),
+			errors.Is(err, models.ErrInvalidLocale):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			logrus.WithError(err).Error("Failed to update template")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	audit(c, "template.updated", logger.Fields{"template_id": templateID})
+	c.JSON(http.StatusOK, response)
+}
+
+// ActivateTemplate handles POST /templates/:templateId/versions/:version/activate
+func (h *NotificationHandler) ActivateTemplate(c *gin.Context) {
+	templateID := c.Param("templateId")
+
+	// Parameters are already validated by middleware
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		logrus.WithError(err).Error("Failed to parse version parameter")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	response, err := h.notificationService.ActivateTemplate(templateID, version, auth.Principal(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, notification_manager.ErrTemplateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, models.ErrMissingDefaultLocale):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			logrus.WithError(err).Error("Failed to activate template")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	audit(c, "template.activated", logger.Fields{"template_id": templateID, "version": version})
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTemplateAudit handles GET /templates/:templateId/audit
+func (h *NotificationHandler) GetTemplateAudit(c *gin.Context) {
+	audit, err := h.notificationService.GetTemplateAudit(c.Param("templateId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, audit)
+}
+
 // GetPredefinedTemplates handles GET /templates/predefined
 func (h *NotificationHandler) GetPredefinedTemplates(c *gin.Context) {
 	templates := h.notificationService.GetPredefinedTemplates()
@@ -138,6 +526,9 @@ func (h *NotificationHandler) GetPredefinedTemplates(c *gin.Context) {
 			"required_variables": template.RequiredVariables,
 			"status":             template.Status,
 			"created_at":         template.CreatedAt,
+			"created_by":         template.CreatedBy,
+			"updated_at":         template.UpdatedAt,
+			"updated_by":         template.UpdatedBy,
 		})
 	}
 
@@ -170,6 +561,283 @@ func (h *NotificationHandler) GetTemplateVersion(c *gin.Context) {
 	c.JSON(http.StatusOK, template)
 }
 
+// ListTemplates handles GET /templates
+func (h *NotificationHandler) ListTemplates(c *gin.Context) {
+	templates := h.notificationService.ListTemplates()
+
+	c.JSON(http.StatusOK, gin.H{
+		"templates": templates,
+		"count":     len(templates),
+	})
+}
+
+// ExportTemplates handles GET /templates/export
+func (h *NotificationHandler) ExportTemplates(c *gin.Context) {
+	c.JSON(http.StatusOK, h.notificationService.ExportTemplates())
+}
+
+// ImportTemplates handles POST /templates/import
+func (h *NotificationHandler) ImportTemplates(c *gin.Context) {
+	mode, err := models.ParseTemplateConflictMode(c.Query("on_conflict"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var bundle models.TemplateBundle
+	if err := c.ShouldBindJSON(&bundle); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template bundle", "message": err.Error()})
+		return
+	}
+
+	response, err := h.notificationService.ImportTemplates(&bundle, mode, auth.Principal(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidTemplateBundle), errors.Is(err, models.ErrTemplateTypeChanged):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			logrus.WithError(err).Error("Failed to import templates")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	audit(c, "templates.imported", logger.Fields{"templates": len(bundle.Templates), "on_conflict": string(mode)})
+	c.JSON(http.StatusOK, response)
+}
+
+// RenderTemplate handles POST /templates/:templateId/versions/:version/render
+func (h *NotificationHandler) RenderTemplate(c *gin.Context) {
+	templateID := c.Param("templateId")
+
+	// Parameters are already validated by middleware
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		logrus.WithError(err).Error("Failed to parse version parameter")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	var request struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid JSON format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rendered, err := h.notificationService.RenderTemplate(templateID, version, request.Data, auth.Principal(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, notification_manager.ErrTemplateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, notification_manager.ErrMissingRequiredVariable):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			logrus.WithError(err).Error("Failed to render template")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, rendered)
+}
+
+// LintTemplate handles GET /templates/:templateId/versions/:version/lint
+func (h *NotificationHandler) LintTemplate(c *gin.Context) {
+	// Parameters are already validated by middleware
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		logrus.WithError(err).Error("Failed to parse version parameter")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	result, err := h.notificationService.LintTemplate(c.Param("templateId"), version)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// DiffTemplateVersions handles GET /templates/:templateId/versions/:version/diff/:toVersion
+func (h *NotificationHandler) DiffTemplateVersions(c *gin.Context) {
+	// Parameters are already validated by middleware
+	fromVersion, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		logrus.WithError(err).Error("Failed to parse version parameter")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	toVersion, err := strconv.Atoi(c.Param("toVersion"))
+	if err != nil {
+		logrus.WithError(err).Error("Failed to parse toVersion parameter")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	diff, err := h.notificationService.DiffTemplateVersions(c.Param("templateId"), fromVersion, toVersion)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// GetTemplateStats handles GET /templates/:templateId/stats
+func (h *NotificationHandler) GetTemplateStats(c *gin.Context) {
+	templateID := c.Param("templateId")
+
+	stats, err := h.notificationService.GetTemplateStats(templateID)
+	if err != nil {
+		if errors.Is(err, notification_manager.ErrTemplateNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		logrus.WithError(err).Error("Failed to get template stats")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetAdminOverview handles GET /admin/overview
+func (h *NotificationHandler) GetAdminOverview(c *gin.Context) {
+	limit := constants.DefaultAdminRecentNotifications
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > constants.MaxAdminRecentNotifications {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a number between 1 and " + strconv.Itoa(constants.MaxAdminRecentNotifications)})
+			return
+		}
+		limit = parsed
+	}
+
+	overview, err := h.notificationService.GetAdminOverview(limit)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to build admin overview")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, overview)
+}
+
+// concurrencyLimitsUpdate is the body of PUT /admin/concurrency
+type concurrencyLimitsUpdate struct {
+	Limits map[string]int `json:"limits" binding:"required"`
+}
+
+// GetProviderConcurrency handles GET /admin/concurrency
+func (h *NotificationHandler) GetProviderConcurrency(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": concurrency.Default.Status()})
+}
+
+// UpdateProviderConcurrency handles PUT /admin/concurrency
+func (h *NotificationHandler) UpdateProviderConcurrency(c *gin.Context) {
+	var update concurrencyLimitsUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	if err := concurrency.Default.SetLimits(update.Limits); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	logrus.WithField("limits", update.Limits).Info("Provider concurrency limits updated")
+	audit(c, "provider_concurrency.updated", logger.Fields{"limits": update.Limits})
+	c.JSON(http.StatusOK, gin.H{"providers": concurrency.Default.Status()})
+}
+
+// ReplayQueueMessages handles POST /admin/replay
+func (h *NotificationHandler) ReplayQueueMessages(c *gin.Context) {
+	var request models.QueueReplayRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	result, err := h.notificationService.ReplayQueueMessages(&request)
+	switch {
+	case errors.Is(err, notification_manager.ErrQueueArchiveDisabled):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, notification_manager.ErrInvalidQueueReplay):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		logrus.WithError(err).Error("Failed to replay queue messages")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !request.DryRun {
+		replay := result.(*notification_manager.QueueReplayResult)
+		audit(c, "queue.replayed", logger.Fields{
+			"channel":     request.Channel,
+			"from":        request.From,
+			"to":          request.To,
+			"only_failed": request.OnlyFailed,
+			"replayed":    replay.Replayed,
+			"failed":      replay.Failed,
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// Metrics handles GET /metrics
+func (h *NotificationHandler) Metrics(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := metrics.DefaultRegistry.WriteText(c.Writer); err != nil {
+		logrus.WithError(err).Error("Failed to write metrics")
+	}
+}
+
+// GetLoggingSettings handles GET /logging
+func (h *NotificationHandler) GetLoggingSettings(c *gin.Context) {
+	c.JSON(http.StatusOK, logger.GetSettings())
+}
+
+// UpdateLoggingSettings handles PUT /logging
+func (h *NotificationHandler) UpdateLoggingSettings(c *gin.Context) {
+	var update logger.SettingsUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	if err := logger.Apply(update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings := logger.GetSettings()
+	logrus.WithFields(logrus.Fields{
+		"backend":       settings.Backend,
+		"level":         settings.Level,
+		"module_levels": settings.ModuleLevels,
+		"sample_every":  settings.SampleEvery,
+	}).Info("Logging settings updated")
+	audit(c, "logging.updated", logger.Fields{
+		"backend":       settings.Backend,
+		"level":         settings.Level,
+		"module_levels": settings.ModuleLevels,
+		"sample_every":  settings.SampleEvery,
+	})
+
+	c.JSON(http.StatusOK, settings)
+}
+
 // HealthCheck handles GET /health
 func (h *NotificationHandler) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
@@ -178,3 +846,127 @@ func (h *NotificationHandler) HealthCheck(c *gin.Context) {
 		"service":   "notification-service",
 	})
 }
+
+// GetInbox handles GET /inbox/:userId
+func (h *NotificationHandler) GetInbox(c *gin.Context) {
+	filter := models.InboxFilter{ThreadKey: c.Query("thread_key")}
+	filter.UnreadOnly, _ = strconv.ParseBool(c.Query("unread"))
+	filter.Archived, _ = strconv.ParseBool(c.Query("archived"))
+
+	inbox, err := h.notificationService.GetInbox(c.Param("userId"), filter)
+	if err != nil {
+		if errors.Is(err, notification_manager.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, inbox)
+}
+
+// MarkInboxItemRead handles POST /inbox/:userId/items/:itemId/read
+func (h *NotificationHandler) MarkInboxItemRead(c *gin.Context) {
+	item, err := h.notificationService.MarkInboxItemRead(c.Param("userId"), c.Param("itemId"))
+	if err != nil {
+		if errors.Is(err, notification_manager.ErrInboxItemNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// ActOnInboxItem handles POST /inbox/:userId/items/:itemId/actions/:actionId
+func (h *NotificationHandler) ActOnInboxItem(c *gin.Context) {
+	item, err := h.notificationService.ActOnInboxItem(c.Param("userId"), c.Param("itemId"), c.Param("actionId"))
+	if err != nil {
+		if errors.Is(err, notification_manager.ErrInboxItemNotFound) || errors.Is(err, notification_manager.ErrInboxActionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// ArchiveInboxItem handles POST /inbox/:userId/items/:itemId/archive
+func (h *NotificationHandler) ArchiveInboxItem(c *gin.Context) {
+	item, err := h.notificationService.ArchiveInboxItem(c.Param("userId"), c.Param("itemId"))
+	if err != nil {
+		if errors.Is(err, notification_manager.ErrInboxItemNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// UnarchiveInboxItem handles POST /inbox/:userId/items/:itemId/unarchive
+func (h *NotificationHandler) UnarchiveInboxItem(c *gin.Context) {
+	item, err := h.notificationService.UnarchiveInboxItem(c.Param("userId"), c.Param("itemId"))
+	if err != nil {
+		if errors.Is(err, notification_manager.ErrInboxItemNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// GetPreferences handles GET /inbox/:userId/preferences
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	preferences, err := h.notificationService.GetPreferences(c.Param("userId"))
+	if err != nil {
+		if errors.Is(err, notification_manager.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// UpdatePreferences handles PUT /inbox/:userId/preferences
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	// Get validated request from middleware
+	validatedRequestInterface, exists := c.Get("validated_preferences_request")
+	if !exists {
+		logrus.Error("Validated preferences request not found in context")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	request, ok := validatedRequestInterface.(*models.NotificationPreferences)
+	if !ok {
+		logrus.Error("Failed to cast validated request to NotificationPreferences")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	preferences, err := h.notificationService.UpdatePreferences(c.Param("userId"), request)
+	if err != nil {
+		if errors.Is(err, notification_manager.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gaurav2721/notification-service/auth"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/gaurav2721/notification-service/notification_manager"
+	"github.com/gaurav2721/notification-service/policy"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// policyEvaluationRequest is the body of POST /admin/policies/evaluate. Policies, when given,
+// are evaluated instead of the stored policies.
+type policyEvaluationRequest struct {
+	Notification models.NotificationRequest `json:"notification" binding:"required"`
+	Policies     []policy.Policy            `json:"policies"`
+}
+
+// ListRoutingPolicies handles GET /admin/policies
+func (h *NotificationHandler) ListRoutingPolicies(c *gin.Context) {
+	policies := h.notificationService.ListRoutingPolicies()
+	c.JSON(http.StatusOK, gin.H{
+		"policies": policies,
+		"count":    len(policies),
+	})
+}
+
+// GetRoutingPolicy handles GET /admin/policies/:policyId
+func (h *NotificationHandler) GetRoutingPolicy(c *gin.Context) {
+	response, err := h.notificationService.GetRoutingPolicy(c.Param("policyId"))
+	if err != nil {
+		respondPolicyError(c, err, "Failed to get routing policy")
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateRoutingPolicy handles POST /admin/policies
+func (h *NotificationHandler) CreateRoutingPolicy(c *gin.Context) {
+	var request policy.Policy
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	response, err := h.notificationService.CreateRoutingPolicy(&request)
+	if err != nil {
+		respondPolicyError(c, err, "Failed to create routing policy")
+		return
+	}
+
+	created := response.(*policy.Policy)
+	audit(c, "routing_policy.created", logger.Fields{"policy_id": created.ID, "name": created.Name, "action": created.Action.Type})
+	c.JSON(http.StatusCreated, created)
+}
+
+// UpdateRoutingPolicy handles PUT /admin/policies/:policyId
+func (h *NotificationHandler) UpdateRoutingPolicy(c *gin.Context) {
+	var request policy.Policy
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	response, err := h.notificationService.UpdateRoutingPolicy(c.Param("policyId"), &request)
+	if err != nil {
+		respondPolicyError(c, err, "Failed to update routing policy")
+		return
+	}
+
+	updated := response.(*policy.Policy)
+	audit(c, "routing_policy.updated", logger.Fields{"policy_id": updated.ID, "name": updated.Name, "enabled": updated.Enabled})
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteRoutingPolicy handles DELETE /admin/policies/:policyId
+func (h *NotificationHandler) DeleteRoutingPolicy(c *gin.Context) {
+	policyID := c.Param("policyId")
+	if err := h.notificationService.DeleteRoutingPolicy(policyID); err != nil {
+		respondPolicyError(c, err, "Failed to delete routing policy")
+		return
+	}
+
+	audit(c, "routing_policy.deleted", logger.Fields{"policy_id": policyID})
+	c.JSON(http.StatusOK, gin.H{"message": "Routing policy deleted", "policy_id": policyID})
+}
+
+// EvaluateRoutingPolicies handles POST /admin/policies/evaluate. It reports what the policies
+// decide for every recipient of a notification without sending it.
+func (h *NotificationHandler) EvaluateRoutingPolicies(c *gin.Context) {
+	var request policyEvaluationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	request.Notification.Tenant = auth.Principal(c)
+
+	response, err := h.notificationService.EvaluateRoutingPolicies(&request.Notification, request.Policies)
+	if err != nil {
+		respondPolicyError(c, err, "Failed to evaluate routing policies")
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// respondPolicyError maps routing policy errors to status codes
+func respondPolicyError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, policy.ErrPolicyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, policy.ErrInvalidPolicy):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, notification_manager.ErrCategoryNotAllowed):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		logrus.WithError(err).Error(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gaurav2721/notification-service/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// schemaContentType is the media type of JSON Schema documents
+const schemaContentType = "application/schema+json"
+
+// ListSchemas handles GET /schemas
+func (h *NotificationHandler) ListSchemas(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"schemas": validation.SchemaNames()})
+}
+
+// GetSchema handles GET /schemas/:name. The document is served as it is, also under /api/v2,
+// so schema tooling can use it directly.
+func (h *NotificationHandler) GetSchema(c *gin.Context) {
+	document, ok := validation.SchemaDocument(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "schema not found"})
+		return
+	}
+	c.Data(http.StatusOK, schemaContentType, document)
+}
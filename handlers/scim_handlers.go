@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gaurav2721/notification-service/external_services/user"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/gaurav2721/notification-service/scim"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// SCIMHandler handles the SCIM 2.0 requests identity providers provision users with
+type SCIMHandler struct {
+	userService user.UserService
+}
+
+// NewSCIMHandler creates a new SCIM handler
+func NewSCIMHandler(userService user.UserService) *SCIMHandler {
+	return &SCIMHandler{
+		userService: userService,
+	}
+}
+
+// GetServiceProviderConfig handles GET /scim/v2/ServiceProviderConfig
+func (h *SCIMHandler) GetServiceProviderConfig(c *gin.Context) {
+	respondSCIM(c, http.StatusOK, scim.ServiceProviderConfig(scimBaseURL(c)))
+}
+
+// GetResourceTypes handles GET /scim/v2/ResourceTypes
+func (h *SCIMHandler) GetResourceTypes(c *gin.Context) {
+	respondSCIM(c, http.StatusOK, scim.ResourceTypes(scimBaseURL(c)))
+}
+
+// ListUsers handles GET /scim/v2/Users?filter=&startIndex=&count=
+func (h *SCIMHandler) ListUsers(c *gin.Context) {
+	filter, err := scim.ParseFilter(c.Query("filter"))
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+	// startIndex is 1-based; out of range values are clamped as RFC 7644 asks
+	startIndex, _ := strconv.Atoi(c.DefaultQuery("startIndex", "1"))
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	count, err := strconv.Atoi(c.DefaultQuery("count", strconv.Itoa(scim.MaxResults)))
+	if err != nil || count > scim.MaxResults {
+		count = scim.MaxResults
+	}
+	if count < 0 {
+		count = 0
+	}
+
+	users, err := h.userService.ListUsers(filter)
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+
+	response := scim.ListResponse{
+		Schemas:      []string{scim.ListResponseSchema},
+		TotalResults: len(users),
+		StartIndex:   startIndex,
+		Resources:    []scim.User{},
+	}
+	baseURL := scimBaseURL(c)
+	for i := startIndex - 1; i < len(users) && len(response.Resources) < count; i++ {
+		response.Resources = append(response.Resources, scim.FromUser(&users[i], baseURL))
+	}
+	response.ItemsPerPage = len(response.Resources)
+	respondSCIM(c, http.StatusOK, response)
+}
+
+// GetUser handles GET /scim/v2/Users/:id
+func (h *SCIMHandler) GetUser(c *gin.Context) {
+	existing, err := h.findUser(c.Param("id"))
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+	respondSCIM(c, http.StatusOK, scim.FromUser(existing, scimBaseURL(c)))
+}
+
+// CreateUser handles POST /scim/v2/Users
+func (h *SCIMHandler) CreateUser(c *gin.Context) {
+	var resource scim.User
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		respondSCIMError(c, scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidSyntax, "%s", err.Error()))
+		return
+	}
+
+	now := time.Now()
+	newUser := &models.User{
+		ID:        uuid.New().String(),
+		IsActive:  true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := resource.ApplyTo(newUser); err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+	if err := h.checkUserNameAvailable(newUser); err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+	if err := h.userService.CreateUser(newUser); err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"user_id":   newUser.ID,
+		"user_name": newUser.UserName,
+	}).Info("User provisioned through SCIM")
+	respondSCIM(c, http.StatusCreated, scim.FromUser(newUser, scimBaseURL(c)))
+}
+
+// ReplaceUser handles PUT /scim/v2/Users/:id
+func (h *SCIMHandler) ReplaceUser(c *gin.Context) {
+	var resource scim.User
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		respondSCIMError(c, scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidSyntax, "%s", err.Error()))
+		return
+	}
+	h.updateUser(c, resource.ApplyTo)
+}
+
+// PatchUser handles PATCH /scim/v2/Users/:id
+func (h *SCIMHandler) PatchUser(c *gin.Context) {
+	var request scim.PatchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondSCIMError(c, scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidSyntax, "%s", err.Error()))
+		return
+	}
+	h.updateUser(c, func(u *models.User) error {
+		return scim.ApplyPatch(u, request.Operations)
+	})
+}
+
+// DeleteUser handles DELETE /scim/v2/Users/:id. Users are deactivated rather than removed, so
+// the notifications already sent to them stay attributed.
+func (h *SCIMHandler) DeleteUser(c *gin.Context) {
+	userID := c.Param("id")
+	if _, err := h.findUser(userID); err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+	if err := h.userService.DeleteUser(userID); err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+
+	logrus.WithField("user_id", userID).Info("User deprovisioned through SCIM")
+	c.Status(http.StatusNoContent)
+}
+
+// updateUser applies a change to a copy of the user and stores it if it is valid
+func (h *SCIMHandler) updateUser(c *gin.Context, apply func(u *models.User) error) {
+	existing, err := h.findUser(c.Param("id"))
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+	if err := apply(existing); err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+	if err := h.checkUserNameAvailable(existing); err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+	if err := h.userService.UpdateUser(existing); err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"user_id":   existing.ID,
+		"user_name": existing.UserName,
+		"active":    existing.IsActive,
+	}).Info("User updated through SCIM")
+	respondSCIM(c, http.StatusOK, scim.FromUser(existing, scimBaseURL(c)))
+}
+
+// findUser returns a copy of a user, inactive ones included, that can be modified without
+// affecting the stored user
+func (h *SCIMHandler) findUser(userID string) (*models.User, error) {
+	users, err := h.userService.ListUsers(user.UserFilter{ID: userID})
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, scim.ErrNotFound(userID)
+	}
+	found := users[0]
+	found.SecondaryContacts = append([]models.ContactPoint(nil), found.SecondaryContacts...)
+	return &found, nil
+}
+
+// checkUserNameAvailable rejects a user name another user already has
+func (h *SCIMHandler) checkUserNameAvailable(u *models.User) error {
+	if u.UserName == "" {
+		return nil
+	}
+	users, err := h.userService.ListUsers(user.UserFilter{UserName: u.UserName})
+	if err != nil {
+		return err
+	}
+	for _, other := range users {
+		if other.ID != u.ID {
+			return scim.NewError(http.StatusConflict, scim.ErrorTypeUniqueness, "userName %s is already taken", u.UserName)
+		}
+	}
+	return nil
+}
+
+// scimBaseURL returns the URL of the SCIM endpoint the request was sent to, which resource
+// locations are relative to
+func scimBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if forwarded := c.GetHeader("X-Forwarded-Proto"); forwarded != "" {
+		scheme = forwarded
+	}
+	return scheme + "://" + c.Request.Host + "/scim/v2"
+}
+
+// respondSCIM writes a SCIM response
+func respondSCIM(c *gin.Context, status int, body interface{}) {
+	c.Header("Content-Type", scim.MediaType)
+	c.JSON(status, body)
+}
+
+// respondSCIMError writes err as a SCIM error response
+func respondSCIMError(c *gin.Context, err error) {
+	var scimErr *scim.Error
+	switch {
+	case errors.As(err, &scimErr):
+	case isInvalidUserSetting(err):
+		scimErr = scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidValue, "%s", err.Error())
+	case errors.Is(err, user.ErrUserAlreadyExists):
+		scimErr = scim.NewError(http.StatusConflict, scim.ErrorTypeUniqueness, "%s", err.Error())
+	default:
+		logrus.WithError(err).Error("Failed to process SCIM request")
+		scimErr = scim.NewError(http.StatusInternalServerError, "", "%s", err.Error())
+	}
+	respondSCIM(c, scimErr.StatusCode(), scimErr)
+}
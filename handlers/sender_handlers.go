@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gaurav2721/notification-service/auth"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// GetSenderSettings handles GET /sender
+func (h *NotificationHandler) GetSenderSettings(c *gin.Context) {
+	c.JSON(http.StatusOK, h.notificationService.GetSenderSettings(auth.Principal(c)))
+}
+
+// UpdateSenderSettings handles PUT /sender
+func (h *NotificationHandler) UpdateSenderSettings(c *gin.Context) {
+	// Get validated request from middleware
+	validatedRequestInterface, exists := c.Get("validated_sender_request")
+	if !exists {
+		logrus.Error("Validated sender request not found in context")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	request, ok := validatedRequestInterface.(*models.SenderSettings)
+	if !ok {
+		logrus.Error("Failed to cast validated request to SenderSettings")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	tenant := auth.Principal(c)
+	settings := h.notificationService.UpdateSenderSettings(tenant, request, tenant)
+
+	audit(c, "sender.updated", logger.Fields{"tenant": tenant})
+	c.JSON(http.StatusOK, settings)
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/notification_manager"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// GetTemplateDirectoryStatus handles GET /templates/directory
+func (h *NotificationHandler) GetTemplateDirectoryStatus(c *gin.Context) {
+	status, err := h.notificationService.GetTemplateDirectoryStatus()
+	if err != nil {
+		respondTemplateDirectoryError(c, err, "Failed to get template directory status")
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// ReloadTemplates handles POST /templates/directory/reload
+func (h *NotificationHandler) ReloadTemplates(c *gin.Context) {
+	status, err := h.notificationService.ReloadTemplates()
+	if err != nil {
+		respondTemplateDirectoryError(c, err, "Failed to reload templates")
+		return
+	}
+
+	audit(c, "templates.reloaded", logger.Fields{})
+	c.JSON(http.StatusOK, status)
+}
+
+// GetTemplateGitSyncStatus handles GET /templates/git
+func (h *NotificationHandler) GetTemplateGitSyncStatus(c *gin.Context) {
+	status, err := h.notificationService.GetTemplateGitSyncStatus()
+	if err != nil {
+		respondTemplateDirectoryError(c, err, "Failed to get template git sync status")
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// SyncTemplatesFromGit handles POST /templates/git/sync
+func (h *NotificationHandler) SyncTemplatesFromGit(c *gin.Context) {
+	status, err := h.notificationService.SyncTemplatesFromGit(c.Request.Context())
+	if err != nil {
+		respondTemplateDirectoryError(c, err, "Failed to sync templates from git")
+		return
+	}
+
+	audit(c, "templates.git_synced", logger.Fields{})
+	c.JSON(http.StatusOK, status)
+}
+
+// respondTemplateDirectoryError maps template directory and repository errors to HTTP responses
+func respondTemplateDirectoryError(c *gin.Context, err error, message string) {
+	if errors.Is(err, notification_manager.ErrTemplateDirectoryNotConfigured) || errors.Is(err, notification_manager.ErrTemplateRepositoryNotConfigured) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	logrus.WithError(err).Error(message)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
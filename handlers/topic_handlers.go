@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gaurav2721/notification-service/external_services/fcm"
+	"github.com/gaurav2721/notification-service/external_services/pushapps"
+	"github.com/gaurav2721/notification-service/external_services/user"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// TopicHandler handles HTTP requests for FCM topic messaging
+type TopicHandler struct {
+	userService user.UserService
+	fcmService  fcm.FCMService
+}
+
+// NewTopicHandler creates a new topic handler. The user service resolves the Android devices
+// of users subscribed to a topic.
+func NewTopicHandler(userService user.UserService, fcmService fcm.FCMService) *TopicHandler {
+	return &TopicHandler{
+		userService: userService,
+		fcmService:  fcmService,
+	}
+}
+
+// topicSubscriptionRequest is the body of the topic subscriber endpoints. The Android devices of
+// the users and the device tokens, which belong to AppID, are subscribed or unsubscribed.
+type topicSubscriptionRequest struct {
+	UserIDs      []string `json:"user_ids"`
+	DeviceTokens []string `json:"device_tokens"`
+	AppID        string   `json:"app_id"`
+}
+
+// topicMessageRequest is the body of POST /topics/:topic/messages
+type topicMessageRequest struct {
+	Title string `json:"title" binding:"required"`
+	Body  string `json:"body" binding:"required"`
+	AppID string `json:"app_id"`
+}
+
+// SubscribeToTopic handles POST /api/v1/topics/:topic/subscribers
+func (h *TopicHandler) SubscribeToTopic(c *gin.Context) {
+	h.manageSubscribers(c, "topic.subscribed", fcm.TopicService.SubscribeToTopic)
+}
+
+// UnsubscribeFromTopic handles DELETE /api/v1/topics/:topic/subscribers
+func (h *TopicHandler) UnsubscribeFromTopic(c *gin.Context) {
+	h.manageSubscribers(c, "topic.unsubscribed", fcm.TopicService.UnsubscribeFromTopic)
+}
+
+// topicOperation subscribes or unsubscribes device tokens
+type topicOperation func(topics fcm.TopicService, ctx context.Context, appID, topic string, tokens []string) (*fcm.TopicManagementResult, error)
+
+// manageSubscribers runs a subscription operation for the devices of the request, one call per app
+func (h *TopicHandler) manageSubscribers(c *gin.Context, action string, operation topicOperation) {
+	topic := c.Param("topic")
+	topics, err := fcm.AsTopicService(h.fcmService)
+	if err != nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+		return
+	}
+	if err := fcm.ValidateTopic(topic); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var request topicSubscriptionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	if len(request.UserIDs) == 0 && len(request.DeviceTokens) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_ids or device_tokens is required"})
+		return
+	}
+
+	// Tokens of different apps belong to different Firebase projects
+	tokensByApp := make(map[string][]string)
+	tokensByApp[request.AppID] = append(tokensByApp[request.AppID], request.DeviceTokens...)
+	var unknownUsers []string
+	for _, userID := range request.UserIDs {
+		// Missing and inactive users are reported rather than failing the request
+		if _, err := h.userService.GetUserByID(userID); err != nil {
+			unknownUsers = append(unknownUsers, userID)
+			continue
+		}
+		devices, err := h.userService.GetActiveUserDevices(userID)
+		if err != nil {
+			logrus.WithError(err).WithField("user_id", userID).Error("Failed to get devices for topic subscription")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for _, device := range devices {
+			if device.DeviceType == models.DeviceTypeAndroid {
+				tokensByApp[device.AppID] = append(tokensByApp[device.AppID], device.DeviceToken)
+			}
+		}
+	}
+
+	result := &fcm.TopicManagementResult{}
+	for appID, tokens := range tokensByApp {
+		if len(tokens) == 0 {
+			continue
+		}
+		appResult, err := operation(topics, c.Request.Context(), appID, topic, tokens)
+		if err != nil {
+			respondTopicError(c, err, "Failed to update topic subscriptions")
+			return
+		}
+		result.Add(appResult)
+	}
+
+	audit(c, action, logger.Fields{
+		"topic":         topic,
+		"success_count": result.SuccessCount,
+		"failure_count": result.FailureCount,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"topic":         topic,
+		"success_count": result.SuccessCount,
+		"failure_count": result.FailureCount,
+		"errors":        result.Errors,
+		"unknown_users": unknownUsers,
+	})
+}
+
+// SendToTopic handles POST /api/v1/topics/:topic/messages
+func (h *TopicHandler) SendToTopic(c *gin.Context) {
+	topic := c.Param("topic")
+	topics, err := fcm.AsTopicService(h.fcmService)
+	if err != nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+		return
+	}
+	if err := fcm.ValidateTopic(topic); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var request topicMessageRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	notification := &models.FCMNotificationRequest{
+		ID:      uuid.New().String(),
+		Type:    "android_push",
+		Content: models.FCMContent{Title: request.Title, Body: request.Body},
+		AppID:   request.AppID,
+	}
+	response, err := topics.SendToTopic(c.Request.Context(), topic, notification)
+	if err != nil {
+		respondTopicError(c, err, "Failed to send topic message")
+		return
+	}
+
+	audit(c, "topic.message_sent", logger.Fields{"topic": topic, "notification_id": notification.ID})
+	c.JSON(http.StatusOK, response)
+}
+
+// respondTopicError maps FCM topic errors to HTTP responses
+func respondTopicError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, fcm.ErrInvalidTopic), errors.Is(err, pushapps.ErrUnknownApp):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		logrus.WithError(err).Error(message)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	}
+}
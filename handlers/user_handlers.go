@@ -1,23 +1,33 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gaurav2721/notification-service/external_services/user"
+	"github.com/gaurav2721/notification-service/logger"
 	"github.com/gaurav2721/notification-service/models"
+	"github.com/gaurav2721/notification-service/notification_manager"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
 // UserHandler handles HTTP requests for user management
 type UserHandler struct {
-	userService user.UserService
+	userService         user.UserService
+	notificationService notification_manager.NotificationManager
 }
 
-// NewUserHandler creates a new user handler
-func NewUserHandler(userService user.UserService) *UserHandler {
+// NewUserHandler creates a new user handler. The notification service delivers contact
+// verification codes.
+func NewUserHandler(userService user.UserService, notificationService notification_manager.NotificationManager) *UserHandler {
 	return &UserHandler{
-		userService: userService,
+		userService:         userService,
+		notificationService: notificationService,
 	}
 }
 
@@ -70,6 +80,8 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 	var request struct {
 		Email    string `json:"email" binding:"required,email"`
 		FullName string `json:"full_name" binding:"required"`
+		Timezone string `json:"timezone"`
+		Locale   string `json:"locale"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -85,9 +97,15 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 
 	// Create new user using the models.NewUser function
 	newUser := models.NewUser(request.Email, request.FullName)
+	newUser.Timezone = request.Timezone
+	newUser.Locale = request.Locale
 
 	err := h.userService.CreateUser(newUser)
 	if err != nil {
+		if isInvalidUserSetting(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		logrus.WithFields(logrus.Fields{
 			"email": request.Email,
 			"error": err.Error(),
@@ -115,11 +133,14 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	logrus.WithField("user_id", userID).Debug("Received update user request")
 
 	var request struct {
-		Email        string `json:"email"`
-		FullName     string `json:"full_name"`
-		SlackUserID  string `json:"slack_user_id"`
-		SlackChannel string `json:"slack_channel"`
-		PhoneNumber  string `json:"phone_number"`
+		Email           string `json:"email"`
+		FullName        string `json:"full_name"`
+		SlackUserID     string `json:"slack_user_id"`
+		SlackChannel    string `json:"slack_channel"`
+		GoogleChatSpace string `json:"google_chat_space"`
+		PhoneNumber     string `json:"phone_number"`
+		Timezone        string `json:"timezone"`
+		Locale          string `json:"locale"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -127,6 +148,25 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// Validate before applying, the user service hands out the stored user
+	if request.Timezone != "" {
+		if err := models.ValidateTimezone(request.Timezone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if request.Locale != "" {
+		if _, err := models.NormalizeLocale(request.Locale); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if request.GoogleChatSpace != "" {
+		if err := models.ValidateGoogleChatSpace(request.GoogleChatSpace); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
 	// Get existing user first
 	existingUser, err := h.userService.GetUserByID(userID)
@@ -135,9 +175,9 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	// Update fields if provided
+	// Update fields if provided. A new email address or phone number has to be verified again.
 	if request.Email != "" {
-		existingUser.Email = request.Email
+		existingUser.ReplacePrimaryContact(models.ContactEmail, request.Email)
 	}
 	if request.FullName != "" {
 		existingUser.FullName = request.FullName
@@ -146,10 +186,19 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		existingUser.SlackUserID = request.SlackUserID
 	}
 	if request.SlackChannel != "" {
-		existingUser.SlackChannel = request.SlackChannel
+		existingUser.ReplacePrimaryContact(models.ContactSlack, request.SlackChannel)
+	}
+	if request.GoogleChatSpace != "" {
+		existingUser.GoogleChatSpace = request.GoogleChatSpace
 	}
 	if request.PhoneNumber != "" {
-		existingUser.PhoneNumber = request.PhoneNumber
+		existingUser.ReplacePrimaryContact(models.ContactPhone, request.PhoneNumber)
+	}
+	if request.Timezone != "" {
+		existingUser.Timezone = request.Timezone
+	}
+	if request.Locale != "" {
+		existingUser.Locale = request.Locale
 	}
 
 	// Update user
@@ -162,6 +211,219 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	c.JSON(http.StatusOK, existingUser)
 }
 
+// isInvalidUserSetting reports whether err rejects the timezone or locale of a user
+func isInvalidUserSetting(err error) bool {
+	return errors.Is(err, models.ErrInvalidTimezone) || errors.Is(err, models.ErrInvalidLocale)
+}
+
+// SendContactVerification handles POST /api/v1/users/:id/verifications
+func (h *UserHandler) SendContactVerification(c *gin.Context) {
+	var request struct {
+		Contact string `json:"contact" binding:"required"`
+		Address string `json:"address"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.notificationService.SendContactVerification(c.Param("id"), request.Contact, request.Address)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrInvalidContact), errors.Is(err, user.ErrNoContactAddress):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, user.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, notification_manager.ErrContactChannelUnavailable):
+			c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+		default:
+			logrus.WithError(err).WithField("user_id", c.Param("id")).Error("Failed to send contact verification")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, response)
+}
+
+// ConfirmContactVerification handles POST /api/v1/users/:id/verifications/confirm
+func (h *UserHandler) ConfirmContactVerification(c *gin.Context) {
+	var request struct {
+		Contact string `json:"contact" binding:"required"`
+		Address string `json:"address"`
+		Code    string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.Param("id")
+	err := h.userService.ConfirmContactVerification(userID, request.Contact, request.Address, request.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrUserNotFound), errors.Is(err, user.ErrVerificationNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, user.ErrTooManyCodeAttempts):
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		case errors.Is(err, user.ErrInvalidContact), errors.Is(err, user.ErrInvalidCode), errors.Is(err, user.ErrVerificationExpired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Contact verified successfully",
+		"user_id":  userID,
+		"contact":  request.Contact,
+		"verified": true,
+	})
+}
+
+// GetContactPoints handles GET /api/v1/users/:id/contact-points
+func (h *UserHandler) GetContactPoints(c *gin.Context) {
+	userID := c.Param("id")
+	existingUser, err := h.userService.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":        userID,
+		"contact_points": existingUser.ContactPoints(),
+	})
+}
+
+// AddContactPoint handles POST /api/v1/users/:id/contact-points
+func (h *UserHandler) AddContactPoint(c *gin.Context) {
+	var request struct {
+		Type    string `json:"type" binding:"required"`
+		Address string `json:"address" binding:"required"`
+		Primary bool   `json:"primary"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.Param("id")
+	points, err := h.userService.AddContactPoint(userID, request.Type, request.Address, request.Primary)
+	if err != nil {
+		respondContactPointError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"user_id": userID, "contact_points": points})
+}
+
+// SetPrimaryContact handles PUT /api/v1/users/:id/contact-points/primary
+func (h *UserHandler) SetPrimaryContact(c *gin.Context) {
+	var request struct {
+		Type    string `json:"type" binding:"required"`
+		Address string `json:"address" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.Param("id")
+	points, err := h.userService.SetPrimaryContact(userID, request.Type, request.Address)
+	if err != nil {
+		respondContactPointError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user_id": userID, "contact_points": points})
+}
+
+// RemoveContactPoint handles DELETE /api/v1/users/:id/contact-points?type=email&address=...
+func (h *UserHandler) RemoveContactPoint(c *gin.Context) {
+	contactType, address := c.Query("type"), c.Query("address")
+	if contactType == "" || address == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type and address are required"})
+		return
+	}
+
+	userID := c.Param("id")
+	points, err := h.userService.RemoveContactPoint(userID, contactType, address)
+	if err != nil {
+		respondContactPointError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user_id": userID, "contact_points": points})
+}
+
+// respondContactPointError maps the errors of the contact point methods to responses
+func respondContactPointError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, user.ErrUserNotFound), errors.Is(err, models.ErrContactPointNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrContactPointExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrInvalidContactType), errors.Is(err, models.ErrTooManyContactPoints),
+		errors.Is(err, models.ErrInvalidEmail), errors.Is(err, models.ErrInvalidSlackChannel), errors.Is(err, models.ErrInvalidPhoneNumber):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// SetDoNotDisturb turns do-not-disturb on for a user, optionally until a given time
+func (h *UserHandler) SetDoNotDisturb(c *gin.Context) {
+	var request struct {
+		Until *time.Time `json:"until"`
+	}
+
+	// The body is optional, without one do-not-disturb stays on until it is turned off
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.Param("id")
+	dnd, err := h.userService.SetDoNotDisturb(userID, request.Until)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, user.ErrInvalidDNDUntil):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":        userID,
+		"do_not_disturb": dnd,
+	})
+}
+
+// ClearDoNotDisturb turns do-not-disturb off for a user
+func (h *UserHandler) ClearDoNotDisturb(c *gin.Context) {
+	userID := c.Param("id")
+	if err := h.userService.ClearDoNotDisturb(userID); err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Do-not-disturb turned off", "user_id": userID})
+}
+
 // DeleteUser handles DELETE /api/v1/users/:id
 func (h *UserHandler) DeleteUser(c *gin.Context) {
 	userID := c.Param("id")
@@ -210,12 +472,20 @@ func (h *UserHandler) RegisterDevice(c *gin.Context) {
 		AppVersion  string `json:"app_version"`
 		OSVersion   string `json:"os_version"`
 		DeviceModel string `json:"device_model"`
+		// APNSEnvironment is "sandbox" for development builds of the iOS app
+		APNSEnvironment string `json:"apns_environment"`
+		// AppID names the mobile app when the service pushes to several apps
+		AppID string `json:"app_id"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if request.APNSEnvironment != "" && !models.IsValidAPNSEnvironment(request.APNSEnvironment) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": user.ErrInvalidAPNSEnv.Error()})
+		return
+	}
 
 	device, err := h.userService.RegisterDevice(userID, request.DeviceToken, request.DeviceType)
 	if err != nil {
@@ -223,6 +493,19 @@ func (h *UserHandler) RegisterDevice(c *gin.Context) {
 		return
 	}
 
+	if request.APNSEnvironment != "" {
+		if err := h.userService.SetDeviceAPNSEnvironment(device.ID, request.APNSEnvironment); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if request.AppID != "" {
+		if err := h.userService.SetDeviceAppID(device.ID, request.AppID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	// Update additional device info if provided
 	if request.AppVersion != "" || request.OSVersion != "" || request.DeviceModel != "" {
 		err = h.userService.UpdateDeviceInfo(device.ID, request.AppVersion, request.OSVersion, request.DeviceModel)
@@ -298,21 +581,39 @@ func (h *UserHandler) UpdateDeviceInfo(c *gin.Context) {
 	}
 
 	var request struct {
-		AppVersion  string `json:"app_version"`
-		OSVersion   string `json:"os_version"`
-		DeviceModel string `json:"device_model"`
+		AppVersion      string `json:"app_version"`
+		OSVersion       string `json:"os_version"`
+		DeviceModel     string `json:"device_model"`
+		APNSEnvironment string `json:"apns_environment"`
+		AppID           string `json:"app_id"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if request.APNSEnvironment != "" && !models.IsValidAPNSEnvironment(request.APNSEnvironment) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": user.ErrInvalidAPNSEnv.Error()})
+		return
+	}
 
 	err := h.userService.UpdateDeviceInfo(deviceID, request.AppVersion, request.OSVersion, request.DeviceModel)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if request.APNSEnvironment != "" {
+		if err := h.userService.SetDeviceAPNSEnvironment(deviceID, request.APNSEnvironment); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if request.AppID != "" {
+		if err := h.userService.SetDeviceAppID(deviceID, request.AppID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Device information updated successfully"})
 }
@@ -367,3 +668,82 @@ func (h *UserHandler) UpdateDeviceLastUsed(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Device last used timestamp updated successfully"})
 }
+
+// deviceExportFlushInterval is the number of devices written between flushes of an export, so
+// large exports reach the client while they are written
+const deviceExportFlushInterval = 100
+
+// ExportDevices handles GET /api/v1/devices/export. It streams the devices of every user as
+// newline-delimited JSON, one device per line, optionally filtered by type and state.
+func (h *UserHandler) ExportDevices(c *gin.Context) {
+	filter := user.DeviceFilter{DeviceType: c.Query("type")}
+	if activeStr := c.Query("active"); activeStr != "" {
+		active, err := strconv.ParseBool(activeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "active must be true or false"})
+			return
+		}
+		filter.Active = &active
+	}
+
+	devices, err := h.userService.ListDevices(filter)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list devices for export")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="devices.ndjson"`)
+	c.Header("X-Total-Count", strconv.Itoa(len(devices)))
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	for i := range devices {
+		if err := encoder.Encode(&devices[i]); err != nil {
+			// The status is already sent, so the client sees a truncated export
+			logrus.WithError(err).WithField("exported", i).Warn("Device export aborted")
+			return
+		}
+		if (i+1)%deviceExportFlushInterval == 0 {
+			c.Writer.Flush()
+		}
+	}
+
+	logrus.WithField("device_count", len(devices)).Info("Exported devices")
+}
+
+// ImportDevices handles POST /api/v1/devices/import. The body is CSV (text/csv) or
+// newline-delimited JSON (application/x-ndjson), and the response reports the outcome of
+// every row.
+func (h *UserHandler) ImportDevices(c *gin.Context) {
+	var format string
+	switch c.ContentType() {
+	case "text/csv":
+		format = user.DeviceImportCSV
+	case "application/x-ndjson", "application/json":
+		format = user.DeviceImportNDJSON
+	default:
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be text/csv or application/x-ndjson"})
+		return
+	}
+
+	report, err := user.ImportDevices(h.userService, c.Request.Body, format)
+	if err != nil {
+		// Rows read before a body that is cut off at the size limit stay imported
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large", "max_bytes": tooLarge.Limit})
+		case errors.Is(err, user.ErrInvalidDeviceImport):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			logrus.WithError(err).Error("Failed to import devices")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	audit(c, "devices.imported", logger.Fields{"format": format, "imported": report.Imported, "failed": report.Failed})
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,115 @@
+// Package health tracks the lifecycle of the service for the Kubernetes liveness, readiness
+// and startup probes.
+package health
+
+import (
+	"context"
+	"sync"
+)
+
+// Probe states reported by the readiness and startup probes
+const (
+	StateStarting = "starting"
+	StateReady    = "ready"
+	StateNotReady = "not_ready"
+	StateDraining = "draining"
+)
+
+// Check reports why a dependency cannot take traffic, or nil when it can
+type Check func(ctx context.Context) error
+
+// namedCheck is a readiness check and the name it is reported under
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Readiness is the outcome of the readiness probe
+type Readiness struct {
+	State string `json:"status"`
+
+	// Checks maps each check name to "ok" or the reason it failed. Checks only run once the
+	// service has started and until it starts draining.
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Ready reports whether the service can take traffic
+func (r Readiness) Ready() bool {
+	return r.State == StateReady
+}
+
+// Probes tracks whether the service has started and is draining, and runs the readiness
+// checks of its dependencies. The process is live as long as it can answer the probes.
+type Probes struct {
+	mu       sync.RWMutex
+	started  bool
+	draining bool
+	checks   []namedCheck
+}
+
+// NewProbes creates probes of a service that has not started yet
+func NewProbes() *Probes {
+	return &Probes{}
+}
+
+// AddReadinessCheck adds a check that must pass for the service to be ready
+func (p *Probes) AddReadinessCheck(name string, check Check) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.checks = append(p.checks, namedCheck{name: name, check: check})
+}
+
+// MarkStarted records that every service was initialized
+func (p *Probes) MarkStarted() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.started = true
+}
+
+// MarkDraining takes the service out of rotation before it shuts down. It stays started and
+// live, so Kubernetes lets it finish the requests and messages in flight.
+func (p *Probes) MarkDraining() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.draining = true
+}
+
+// Started reports whether every service was initialized
+func (p *Probes) Started() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.started
+}
+
+// Draining reports whether the service is shutting down
+func (p *Probes) Draining() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.draining
+}
+
+// Readiness runs the readiness checks of a started service that is not draining
+func (p *Probes) Readiness(ctx context.Context) Readiness {
+	p.mu.RLock()
+	started, draining := p.started, p.draining
+	checks := append([]namedCheck(nil), p.checks...)
+	p.mu.RUnlock()
+
+	switch {
+	case draining:
+		return Readiness{State: StateDraining}
+	case !started:
+		return Readiness{State: StateStarting}
+	}
+
+	readiness := Readiness{State: StateReady, Checks: make(map[string]string, len(checks))}
+	for _, c := range checks {
+		if err := c.check(ctx); err != nil {
+			readiness.State = StateNotReady
+			readiness.Checks[c.name] = err.Error()
+			continue
+		}
+		readiness.Checks[c.name] = "ok"
+	}
+	return readiness
+}
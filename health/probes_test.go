@@ -0,0 +1,40 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbes_Lifecycle(t *testing.T) {
+	probes := NewProbes()
+	queueFull := false
+	probes.AddReadinessCheck("queues", func(ctx context.Context) error {
+		if queueFull {
+			return errors.New("email queue is full")
+		}
+		return nil
+	})
+
+	assert.False(t, probes.Started())
+	assert.Equal(t, Readiness{State: StateStarting}, probes.Readiness(context.Background()))
+
+	probes.MarkStarted()
+	readiness := probes.Readiness(context.Background())
+	assert.True(t, readiness.Ready())
+	assert.Equal(t, map[string]string{"queues": "ok"}, readiness.Checks)
+
+	queueFull = true
+	readiness = probes.Readiness(context.Background())
+	assert.False(t, readiness.Ready())
+	assert.Equal(t, StateNotReady, readiness.State)
+	assert.Equal(t, "email queue is full", readiness.Checks["queues"])
+
+	queueFull = false
+	probes.MarkDraining()
+	assert.True(t, probes.Draining())
+	assert.True(t, probes.Started(), "a draining service stays started")
+	assert.Equal(t, Readiness{State: StateDraining}, probes.Readiness(context.Background()))
+}
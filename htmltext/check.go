@@ -0,0 +1,193 @@
+package htmltext
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Issue severities; only errors fail a check
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Check rules
+const (
+	RuleImageMissingAlt      = "image_missing_alt"
+	RuleEmptyLink            = "empty_link"
+	RuleNonDescriptiveLink   = "non_descriptive_link"
+	RuleEmptyTextAlternative = "empty_text_alternative"
+	RuleLongSentences        = "long_sentences"
+	RuleAllCaps              = "all_caps"
+)
+
+// Readability thresholds
+const (
+	// MaxAverageSentenceWords is the longest average sentence that is still easy to read
+	MaxAverageSentenceWords = 20
+	// minAllCapsLetters keeps short acronyms and headings from counting as shouting
+	minAllCapsLetters = 20
+)
+
+// nonDescriptiveLinkTexts say nothing about where a link leads when read out of context,
+// as screen readers list links
+var nonDescriptiveLinkTexts = map[string]bool{
+	"click here": true, "here": true, "click": true, "link": true, "this link": true,
+	"more": true, "read more": true, "learn more": true, "this": true, "go": true,
+}
+
+// Issue is a problem found by Check
+type Issue struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// Check checks an email body for accessibility and readability problems: images without alt
+// text, links without descriptive text, markup without any text for the plain text alternative,
+// long sentences and text in capitals. Bodies without markup only get the readability checks.
+func Check(body string) []Issue {
+	var issues []Issue
+	if IsHTML(body) {
+		issues = append(issues, checkMarkup(body)...)
+	}
+	text := ToText(body)
+	if strings.TrimSpace(body) != "" && text == "" {
+		issues = append(issues, Issue{
+			Rule:     RuleEmptyTextAlternative,
+			Severity: SeverityError,
+			Message:  "the body has no text, so its plain text alternative is empty; add text or alt text to images",
+		})
+	}
+	return append(issues, checkReadability(text)...)
+}
+
+// checkMarkup checks images and links
+func checkMarkup(body string) []Issue {
+	var issues []Issue
+	var images, emptyLinks int
+	var vagueLinks []string
+
+	inLink := false
+	var linkText strings.Builder
+	for _, tok := range tokenize(body) {
+		switch {
+		case tok.kind == textToken:
+			if inLink {
+				linkText.WriteString(tok.text)
+			}
+		case tok.name == "img" && tok.kind == startTagToken:
+			alt, hasAlt := tok.attrs["alt"]
+			if !hasAlt {
+				images++
+			}
+			// An image is the text of a link it is in
+			if inLink {
+				linkText.WriteString(alt)
+			}
+		case tok.name == "a" && tok.kind == startTagToken:
+			inLink = true
+			linkText.Reset()
+		case tok.name == "a" && tok.kind == endTagToken && inLink:
+			inLink = false
+			text := strings.Join(strings.Fields(linkText.String()), " ")
+			normalized := strings.ToLower(strings.TrimRight(text, ".!:"))
+			switch {
+			case text == "":
+				emptyLinks++
+			case nonDescriptiveLinkTexts[normalized]:
+				vagueLinks = append(vagueLinks, text)
+			}
+		}
+	}
+
+	if images > 0 {
+		issues = append(issues, Issue{
+			Rule:     RuleImageMissingAlt,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("%d image(s) have no alt attribute; describe them, or use alt=\"\" for decorative images", images),
+		})
+	}
+	if emptyLinks > 0 {
+		issues = append(issues, Issue{
+			Rule:     RuleEmptyLink,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("%d link(s) have no text or image alt text", emptyLinks),
+		})
+	}
+	if len(vagueLinks) > 0 {
+		issues = append(issues, Issue{
+			Rule:     RuleNonDescriptiveLink,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("links with text such as %q do not say where they lead", vagueLinks[0]),
+		})
+	}
+	return issues
+}
+
+// checkReadability checks the sentence length and capitalization of plain text
+func checkReadability(text string) []Issue {
+	var issues []Issue
+
+	sentences, words := 0, 0
+	for _, sentence := range splitSentences(text) {
+		if n := len(strings.Fields(sentence)); n > 0 {
+			sentences++
+			words += n
+		}
+	}
+	if sentences > 0 && words/sentences > MaxAverageSentenceWords {
+		issues = append(issues, Issue{
+			Rule:     RuleLongSentences,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("sentences have %d words on average; keep them under %d", words/sentences, MaxAverageSentenceWords),
+		})
+	}
+
+	upper, letters := 0, 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	if letters >= minAllCapsLetters && upper*2 > letters {
+		issues = append(issues, Issue{
+			Rule:     RuleAllCaps,
+			Severity: SeverityWarning,
+			Message:  "most of the text is in capitals, which is hard to read and read out letter by letter by some screen readers",
+		})
+	}
+	return issues
+}
+
+// splitSentences splits text at sentence punctuation followed by whitespace and at line breaks
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for i, r := range text {
+		end := r == '\n'
+		if (r == '.' || r == '!' || r == '?') && (i+1 == len(text) || unicode.IsSpace(rune(text[i+1]))) {
+			end = true
+		}
+		if end {
+			sentences = append(sentences, text[start:i])
+			start = i + 1
+		}
+	}
+	return append(sentences, text[start:])
+}
+
+// HasErrors reports whether any of issues is an error
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
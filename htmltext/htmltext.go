@@ -0,0 +1,348 @@
+// Package htmltext derives the plain text alternative of HTML email bodies and checks them for
+// accessibility and readability problems.
+//
+// HTML is read with a small tokenizer that understands tags, attributes, comments and character
+// references, which is enough for email bodies; it does not build a document tree.
+package htmltext
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// htmlTagPattern matches an opening, closing or self-closing HTML tag
+var htmlTagPattern = regexp.MustCompile(`</?[a-zA-Z][a-zA-Z0-9]*(\s[^<>]*)?/?>`)
+
+// IsHTML reports whether body contains HTML markup
+func IsHTML(body string) bool {
+	return htmlTagPattern.MatchString(body)
+}
+
+// blockTags start on a new line; paragraphTags are also separated by a blank line
+var (
+	blockTags = map[string]bool{
+		"div": true, "tr": true, "section": true, "article": true, "header": true, "footer": true,
+		"main": true, "nav": true, "aside": true, "center": true, "dl": true, "dt": true, "dd": true,
+		"figure": true, "figcaption": true, "address": true, "form": true,
+	}
+	paragraphTags = map[string]bool{
+		"p": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+		"ul": true, "ol": true, "table": true, "blockquote": true, "pre": true, "hr": true,
+	}
+	// hiddenTags have content that is not shown as text
+	hiddenTags = map[string]bool{"head": true, "title": true, "script": true, "style": true, "template": true}
+)
+
+// ToText renders an HTML body as readable plain text: block elements start new lines, list
+// items are prefixed with "- ", links are followed by their URL and images are replaced by
+// their alt text. Bodies without markup are returned as they are.
+func ToText(body string) string {
+	if !IsHTML(body) {
+		return strings.TrimSpace(body)
+	}
+
+	var w textWriter
+	var hidden, pre int
+	var links []string // href of each open link, empty for links without a URL worth showing
+	var linkStarts []int
+	for _, tok := range tokenize(body) {
+		switch {
+		case tok.kind == textToken:
+			if hidden > 0 {
+				continue
+			}
+			if pre > 0 {
+				w.writePre(tok.text)
+			} else {
+				w.writeText(tok.text)
+			}
+		case hiddenTags[tok.name]:
+			if tok.kind == startTagToken {
+				hidden++
+			} else if tok.kind == endTagToken && hidden > 0 {
+				hidden--
+			}
+		case hidden > 0:
+		case tok.name == "br":
+			w.newline()
+		case tok.name == "img":
+			if alt := strings.TrimSpace(tok.attrs["alt"]); alt != "" {
+				w.writeText(alt)
+			}
+		case tok.name == "li":
+			if tok.kind == startTagToken {
+				w.newline()
+				w.writeRaw("- ")
+			}
+		case tok.name == "td" || tok.name == "th":
+			if tok.kind == startTagToken {
+				w.space()
+			}
+		case tok.name == "a":
+			if tok.kind == startTagToken {
+				links = append(links, linkURL(tok.attrs["href"]))
+				linkStarts = append(linkStarts, w.len())
+			} else if tok.kind == endTagToken && len(links) > 0 {
+				href, start := links[len(links)-1], linkStarts[len(linkStarts)-1]
+				links, linkStarts = links[:len(links)-1], linkStarts[:len(linkStarts)-1]
+				if href != "" && strings.TrimSpace(w.since(start)) != href {
+					w.space()
+					w.writeText("(" + href + ")")
+				}
+			}
+		case paragraphTags[tok.name]:
+			w.paragraph()
+			if tok.name == "pre" {
+				if tok.kind == startTagToken {
+					pre++
+				} else if tok.kind == endTagToken && pre > 0 {
+					pre--
+				}
+			}
+			if tok.name == "hr" {
+				w.writeRaw("----")
+				w.paragraph()
+			}
+		case blockTags[tok.name]:
+			w.newline()
+		}
+	}
+	return w.String()
+}
+
+// linkURL returns the URL of a link shown in the text alternative: anchors within the document
+// and script URLs are left out, and mailto links show the address
+func linkURL(href string) string {
+	href = strings.TrimSpace(href)
+	lower := strings.ToLower(href)
+	switch {
+	case href == "", strings.HasPrefix(href, "#"), strings.HasPrefix(lower, "javascript:"):
+		return ""
+	case strings.HasPrefix(lower, "mailto:"):
+		return href[len("mailto:"):]
+	}
+	return href
+}
+
+// textWriter collects plain text, collapsing whitespace and blank lines
+type textWriter struct {
+	b strings.Builder
+	// pendingSpace and pendingBreaks are written before the next text, so trailing whitespace
+	// and breaks at the end are dropped
+	pendingSpace  bool
+	pendingBreaks int
+}
+
+func (w *textWriter) len() int {
+	return w.b.Len()
+}
+
+func (w *textWriter) since(start int) string {
+	return w.b.String()[start:]
+}
+
+func (w *textWriter) space() {
+	if w.b.Len() > 0 {
+		w.pendingSpace = true
+	}
+}
+
+func (w *textWriter) newline() {
+	if w.b.Len() > 0 && w.pendingBreaks < 1 {
+		w.pendingBreaks = 1
+	}
+}
+
+func (w *textWriter) paragraph() {
+	if w.b.Len() > 0 {
+		w.pendingBreaks = 2
+	}
+}
+
+// flush writes the pending whitespace
+func (w *textWriter) flush() {
+	if w.pendingBreaks > 0 {
+		w.b.WriteString(strings.Repeat("\n", w.pendingBreaks))
+	} else if w.pendingSpace {
+		w.b.WriteByte(' ')
+	}
+	w.pendingBreaks, w.pendingSpace = 0, false
+}
+
+// writeText writes text with its whitespace collapsed, as a browser shows it
+func (w *textWriter) writeText(text string) {
+	if strings.TrimSpace(text) == "" {
+		if text != "" {
+			w.space()
+		}
+		return
+	}
+	if text[0] == ' ' || text[0] == '\n' || text[0] == '\t' || text[0] == '\r' {
+		w.space()
+	}
+	w.flush()
+	w.b.WriteString(strings.Join(strings.Fields(text), " "))
+	if last := text[len(text)-1]; last == ' ' || last == '\n' || last == '\t' || last == '\r' {
+		w.space()
+	}
+}
+
+// writePre writes preformatted text as it is
+func (w *textWriter) writePre(text string) {
+	w.flush()
+	w.b.WriteString(text)
+}
+
+// writeRaw writes text without collapsing it
+func (w *textWriter) writeRaw(text string) {
+	w.flush()
+	w.b.WriteString(text)
+}
+
+func (w *textWriter) String() string {
+	return strings.TrimSpace(w.b.String())
+}
+
+type tokenKind int
+
+const (
+	textToken tokenKind = iota
+	startTagToken
+	endTagToken
+)
+
+// token is a run of text with character references decoded, or a tag with a lower case name
+type token struct {
+	kind  tokenKind
+	text  string
+	name  string
+	attrs map[string]string
+}
+
+// tokenize splits HTML into text and tags; comments, doctypes and processing instructions are
+// dropped, and a "<" that does not start a tag is text
+func tokenize(src string) []token {
+	var tokens []token
+	text := func(s string) {
+		if s != "" {
+			tokens = append(tokens, token{kind: textToken, text: html.UnescapeString(s)})
+		}
+	}
+
+	for len(src) > 0 {
+		i := strings.IndexByte(src, '<')
+		if i < 0 {
+			text(src)
+			break
+		}
+		text(src[:i])
+		src = src[i:]
+
+		switch {
+		case strings.HasPrefix(src, "<!--"):
+			end := strings.Index(src, "-->")
+			if end < 0 {
+				return tokens
+			}
+			src = src[end+len("-->"):]
+		case strings.HasPrefix(src, "<!") || strings.HasPrefix(src, "<?"):
+			end := strings.IndexByte(src, '>')
+			if end < 0 {
+				return tokens
+			}
+			src = src[end+1:]
+		default:
+			tok, rest, ok := parseTag(src)
+			if !ok {
+				text("<")
+				src = src[1:]
+				continue
+			}
+			tokens = append(tokens, tok)
+			src = rest
+		}
+	}
+	return tokens
+}
+
+// parseTag parses the tag at the start of src, which begins with "<"
+func parseTag(src string) (token, string, bool) {
+	i := 1
+	kind := startTagToken
+	if i < len(src) && src[i] == '/' {
+		kind = endTagToken
+		i++
+	}
+	start := i
+	for i < len(src) && isNameByte(src[i]) {
+		i++
+	}
+	if i == start || !isLetter(src[start]) {
+		return token{}, src, false
+	}
+	tok := token{kind: kind, name: strings.ToLower(src[start:i]), attrs: map[string]string{}}
+
+	for i < len(src) {
+		for i < len(src) && isSpace(src[i]) {
+			i++
+		}
+		if i >= len(src) {
+			break
+		}
+		switch src[i] {
+		case '>':
+			return tok, src[i+1:], true
+		case '/':
+			i++
+			continue
+		}
+
+		nameStart := i
+		for i < len(src) && !isSpace(src[i]) && src[i] != '=' && src[i] != '>' && src[i] != '/' {
+			i++
+		}
+		name := strings.ToLower(src[nameStart:i])
+		for i < len(src) && isSpace(src[i]) {
+			i++
+		}
+		value := ""
+		if i < len(src) && src[i] == '=' {
+			i++
+			for i < len(src) && isSpace(src[i]) {
+				i++
+			}
+			if i < len(src) && (src[i] == '"' || src[i] == '\'') {
+				quote := src[i]
+				end := strings.IndexByte(src[i+1:], quote)
+				if end < 0 {
+					return token{}, src, false
+				}
+				value = src[i+1 : i+1+end]
+				i += end + 2
+			} else {
+				valueStart := i
+				for i < len(src) && !isSpace(src[i]) && src[i] != '>' {
+					i++
+				}
+				value = src[valueStart:i]
+			}
+		}
+		if name != "" {
+			tok.attrs[name] = html.UnescapeString(value)
+		}
+	}
+	return token{}, src, false
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isNameByte(c byte) bool {
+	return isLetter(c) || c >= '0' && c <= '9'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
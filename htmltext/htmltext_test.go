@@ -0,0 +1,83 @@
+package htmltext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const orderEmail = `<!DOCTYPE html>
+<html>
+<head><title>Order</title><style>p { color: red; }</style></head>
+<body>
+<!-- header -->
+<h1>Order {{order_id}} confirmed</h1>
+<p>Hi <strong>Jane</strong>,
+   thanks for your order.<br>It ships <em>today</em> &amp; arrives soon.</p>
+<ul>
+  <li>2 x <code>SKU-1</code></li>
+  <li>1 x Gift card</li>
+</ul>
+<table><tr><td>Total</td><td>&euro;42</td></tr></table>
+<p><img src="logo.png" alt="Acme Shop"> <a href="https://example.com/orders/1">Track your order</a></p>
+<p><a href="https://example.com/help">https://example.com/help</a> or <a href="mailto:help@example.com">email us</a></p>
+<hr>
+<pre>  Order  1
+  Total 42</pre>
+</body>
+</html>`
+
+func TestToText(t *testing.T) {
+	expected := "Order {{order_id}} confirmed\n\n" +
+		"Hi Jane, thanks for your order.\n" +
+		"It ships today & arrives soon.\n\n" +
+		"- 2 x SKU-1\n" +
+		"- 1 x Gift card\n\n" +
+		"Total €42\n\n" +
+		"Acme Shop Track your order (https://example.com/orders/1)\n\n" +
+		"https://example.com/help or email us (help@example.com)\n\n" +
+		"----\n\n" +
+		"  Order  1\n  Total 42"
+	assert.Equal(t, expected, ToText(orderEmail))
+}
+
+func TestToText_PlainBody(t *testing.T) {
+	assert.False(t, IsHTML("Hello {{name}},\n\n1 < 2 and 3 > 2"))
+	assert.Equal(t, "Hello {{name}},\n\n1 < 2 and 3 > 2", ToText("  Hello {{name}},\n\n1 < 2 and 3 > 2\n"))
+	assert.True(t, IsHTML("Hello<br/>there"))
+	assert.Equal(t, "Hello\nthere", ToText("Hello<br/>there"))
+}
+
+func TestCheck(t *testing.T) {
+	assert.Empty(t, Check(orderEmail))
+
+	issues := Check(`<p><img src="banner.png"> <a href="https://example.com">Click here</a> <a href="https://example.com/x"></a></p>` +
+		`<p><a href="https://example.com/y"><img src="icon.png" alt="Your account"></a></p>`)
+	rules := map[string]string{}
+	for _, issue := range issues {
+		rules[issue.Rule] = issue.Severity
+	}
+	assert.Equal(t, map[string]string{
+		RuleImageMissingAlt:    SeverityError,
+		RuleEmptyLink:          SeverityError,
+		RuleNonDescriptiveLink: SeverityWarning,
+	}, rules)
+	assert.True(t, HasErrors(issues))
+
+	// Only decorative images leave the text alternative empty
+	issues = Check(`<p><img src="banner.png" alt=""></p>`)
+	assert.Equal(t, []string{RuleEmptyTextAlternative}, issueRules(issues))
+
+	long := "This sentence keeps going on and on with many words that make it really hard to follow for anyone reading it quickly today. "
+	assert.Equal(t, []string{RuleLongSentences}, issueRules(Check(long+long)))
+	assert.Equal(t, []string{RuleAllCaps}, issueRules(Check("<p>YOUR ACCOUNT HAS BEEN SUSPENDED. ACT NOW.</p>")))
+	assert.False(t, HasErrors(Check("<p>YOUR ACCOUNT HAS BEEN SUSPENDED. ACT NOW.</p>")))
+}
+
+func issueRules(issues []Issue) []string {
+	var rules []string
+	for _, issue := range issues {
+		rules = append(rules, issue.Rule)
+	}
+	return rules
+}
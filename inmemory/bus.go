@@ -0,0 +1,86 @@
+package inmemory
+
+import "sync"
+
+// ChannelBus is an in-memory implementation of kafka.KafkaService whose buffers
+// are sized by the caller instead of the environment, so a run never drops
+// messages because a channel was full.
+type ChannelBus struct {
+	emailChannel       chan string
+	slackChannel       chan string
+	googleChatChannel  chan string
+	incidentChannel    chan string
+	rcsChannel         chan string
+	iosPushChannel     chan string
+	androidPushChannel chan string
+	eventsChannel      chan string
+	closeOnce          sync.Once
+}
+
+// NewChannelBus creates a channel bus with the given buffer size for every channel
+func NewChannelBus(bufferSize int) *ChannelBus {
+	return &ChannelBus{
+		emailChannel:       make(chan string, bufferSize),
+		slackChannel:       make(chan string, bufferSize),
+		googleChatChannel:  make(chan string, bufferSize),
+		incidentChannel:    make(chan string, bufferSize),
+		rcsChannel:         make(chan string, bufferSize),
+		iosPushChannel:     make(chan string, bufferSize),
+		androidPushChannel: make(chan string, bufferSize),
+		eventsChannel:      make(chan string, bufferSize),
+	}
+}
+
+// GetEmailChannel returns the email notification channel
+func (b *ChannelBus) GetEmailChannel() chan string {
+	return b.emailChannel
+}
+
+// GetSlackChannel returns the slack notification channel
+func (b *ChannelBus) GetSlackChannel() chan string {
+	return b.slackChannel
+}
+
+// GetGoogleChatChannel returns the Google Chat notification channel
+func (b *ChannelBus) GetGoogleChatChannel() chan string {
+	return b.googleChatChannel
+}
+
+// GetIncidentChannel returns the incident notification channel
+func (b *ChannelBus) GetIncidentChannel() chan string {
+	return b.incidentChannel
+}
+
+// GetRCSChannel returns the RCS notification channel
+func (b *ChannelBus) GetRCSChannel() chan string {
+	return b.rcsChannel
+}
+
+// GetIOSPushNotificationChannel returns the iOS push notification channel
+func (b *ChannelBus) GetIOSPushNotificationChannel() chan string {
+	return b.iosPushChannel
+}
+
+// GetAndroidPushNotificationChannel returns the Android push notification channel
+func (b *ChannelBus) GetAndroidPushNotificationChannel() chan string {
+	return b.androidPushChannel
+}
+
+// GetNotificationEventsChannel returns the channel of the notification-events topic
+func (b *ChannelBus) GetNotificationEventsChannel() chan string {
+	return b.eventsChannel
+}
+
+// Close closes all channels
+func (b *ChannelBus) Close() {
+	b.closeOnce.Do(func() {
+		close(b.emailChannel)
+		close(b.slackChannel)
+		close(b.googleChatChannel)
+		close(b.incidentChannel)
+		close(b.rcsChannel)
+		close(b.iosPushChannel)
+		close(b.androidPushChannel)
+		close(b.eventsChannel)
+	})
+}
@@ -0,0 +1,261 @@
+package inmemory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/external_services/apns"
+	"github.com/gaurav2721/notification-service/external_services/email"
+	"github.com/gaurav2721/notification-service/external_services/fcm"
+	"github.com/gaurav2721/notification-service/external_services/googlechat"
+	"github.com/gaurav2721/notification-service/external_services/incident"
+	"github.com/gaurav2721/notification-service/external_services/rcs"
+	"github.com/gaurav2721/notification-service/external_services/slack"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Provider channels recorded by a Recorder
+const (
+	ChannelEmail       = "email"
+	ChannelSlack       = "slack"
+	ChannelGoogleChat  = "google_chat"
+	ChannelIncident    = "incident"
+	ChannelRCS         = "rcs"
+	ChannelIOSPush     = "apns"
+	ChannelAndroidPush = "fcm"
+)
+
+// MaxRecordedDeliveries bounds the deliveries kept by a Recorder; older ones are dropped
+const MaxRecordedDeliveries = 10000
+
+// Delivery is a notification handed to one of the in-memory providers
+type Delivery struct {
+	Channel        string
+	NotificationID string
+	Recipient      string
+	Request        interface{} // the provider request, e.g. *models.EmailNotificationRequest
+	SentAt         time.Time
+}
+
+// Recorder stands in for the email, Slack, Google Chat, incident, RCS, APNS and FCM providers
+// and keeps every delivery in memory instead of calling an external service or writing files
+type Recorder struct {
+	clock      clock.Clock
+	mutex      sync.Mutex
+	deliveries []Delivery
+	total      int
+	changed    chan struct{}
+}
+
+// NewRecorder creates a recorder that timestamps deliveries with c
+func NewRecorder(c clock.Clock) *Recorder {
+	return &Recorder{
+		clock:   c,
+		changed: make(chan struct{}),
+	}
+}
+
+// EmailService returns the recorder as an email provider
+func (r *Recorder) EmailService() email.EmailService { return emailRecorder{r} }
+
+// SlackService returns the recorder as a Slack provider
+func (r *Recorder) SlackService() slack.SlackService { return slackRecorder{r} }
+
+// GoogleChatService returns the recorder as a Google Chat provider
+func (r *Recorder) GoogleChatService() googlechat.GoogleChatService { return googleChatRecorder{r} }
+
+// IncidentService returns the recorder as an incident provider
+func (r *Recorder) IncidentService() incident.IncidentService { return incidentRecorder{r} }
+
+// RCSService returns the recorder as an RCS provider
+func (r *Recorder) RCSService() rcs.RCSService { return rcsRecorder{r} }
+
+// APNSService returns the recorder as an APNS provider
+func (r *Recorder) APNSService() apns.APNSService { return apnsRecorder{r} }
+
+// FCMService returns the recorder as an FCM provider
+func (r *Recorder) FCMService() fcm.FCMService { return fcmRecorder{r} }
+
+// Deliveries returns a copy of the latest deliveries recorded so far, in arrival order
+func (r *Recorder) Deliveries() []Delivery {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]Delivery(nil), r.deliveries...)
+}
+
+// Wait blocks until at least n deliveries were recorded since the last Reset or ctx is done
+func (r *Recorder) Wait(ctx context.Context, n int) error {
+	for {
+		r.mutex.Lock()
+		count, changed := r.total, r.changed
+		r.mutex.Unlock()
+
+		if count >= n {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Reset forgets all recorded deliveries
+func (r *Recorder) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.deliveries = nil
+	r.total = 0
+}
+
+// record stores a delivery and wakes up waiters
+func (r *Recorder) record(channel, notificationID, recipient string, request interface{}) time.Time {
+	sentAt := r.clock.Now()
+
+	r.mutex.Lock()
+	r.deliveries = append(r.deliveries, Delivery{
+		Channel:        channel,
+		NotificationID: notificationID,
+		Recipient:      recipient,
+		Request:        request,
+		SentAt:         sentAt,
+	})
+	if len(r.deliveries) > MaxRecordedDeliveries {
+		r.deliveries = r.deliveries[len(r.deliveries)-MaxRecordedDeliveries:]
+	}
+	r.total++
+	close(r.changed)
+	r.changed = make(chan struct{})
+	r.mutex.Unlock()
+
+	logrus.WithFields(logrus.Fields{
+		"channel":         channel,
+		"notification_id": notificationID,
+		"recipient":       recipient,
+	}).Debug("Notification recorded by in-memory provider")
+
+	return sentAt
+}
+
+// emailRecorder implements email.EmailService
+type emailRecorder struct{ *Recorder }
+
+// SendEmail records the email notification
+func (r emailRecorder) SendEmail(ctx context.Context, notification interface{}) (interface{}, error) {
+	notif, ok := notification.(*models.EmailNotificationRequest)
+	if !ok {
+		return nil, email.ErrEmailSendFailed
+	}
+	if err := models.ValidateEmailNotification(notif); err != nil {
+		return nil, err
+	}
+
+	sentAt := r.record(ChannelEmail, notif.ID, notif.Recipient, notif)
+	return &models.EmailResponse{ID: notif.ID, Status: "sent", Message: "Email recorded in memory", SentAt: sentAt, Channel: ChannelEmail}, nil
+}
+
+// slackRecorder implements slack.SlackService
+type slackRecorder struct{ *Recorder }
+
+// SendSlackMessage records the Slack notification
+func (r slackRecorder) SendSlackMessage(ctx context.Context, notification interface{}) (interface{}, error) {
+	notif, ok := notification.(*models.SlackNotificationRequest)
+	if !ok {
+		return nil, slack.ErrSlackSendFailed
+	}
+	if err := models.ValidateSlackNotification(notif); err != nil {
+		return nil, err
+	}
+
+	sentAt := r.record(ChannelSlack, notif.ID, notif.Recipient, notif)
+	return &models.SlackResponse{ID: notif.ID, Status: "sent", Message: "Slack message recorded in memory", SentAt: sentAt, Channel: ChannelSlack}, nil
+}
+
+// googleChatRecorder implements googlechat.GoogleChatService
+type googleChatRecorder struct{ *Recorder }
+
+// SendGoogleChatMessage records the Google Chat notification
+func (r googleChatRecorder) SendGoogleChatMessage(ctx context.Context, notification interface{}) (interface{}, error) {
+	notif, ok := notification.(*models.GoogleChatNotificationRequest)
+	if !ok {
+		return nil, googlechat.ErrGoogleChatSendFailed
+	}
+	if err := models.ValidateGoogleChatNotification(notif); err != nil {
+		return nil, err
+	}
+
+	sentAt := r.record(ChannelGoogleChat, notif.ID, notif.Recipient, notif)
+	return &models.GoogleChatResponse{ID: notif.ID, Status: "sent", Message: "Google Chat message recorded in memory", SentAt: sentAt, Channel: ChannelGoogleChat}, nil
+}
+
+// incidentRecorder implements incident.IncidentService
+type incidentRecorder struct{ *Recorder }
+
+// SendIncidentEvent records the incident notification
+func (r incidentRecorder) SendIncidentEvent(ctx context.Context, notification interface{}) (interface{}, error) {
+	notif, ok := notification.(*models.IncidentNotificationRequest)
+	if !ok {
+		return nil, incident.ErrIncidentSendFailed
+	}
+	if err := models.ValidateIncidentNotification(notif); err != nil {
+		return nil, err
+	}
+
+	sentAt := r.record(ChannelIncident, notif.ID, notif.Recipient, notif)
+	return &models.IncidentResponse{ID: notif.ID, Status: "sent", Message: "Incident event recorded in memory", SentAt: sentAt, Channel: ChannelIncident, DedupeKey: notif.DedupeKey}, nil
+}
+
+// rcsRecorder implements rcs.RCSService
+type rcsRecorder struct{ *Recorder }
+
+// SendRCSMessage records the RCS notification
+func (r rcsRecorder) SendRCSMessage(ctx context.Context, notification interface{}) (interface{}, error) {
+	notif, ok := notification.(*models.RCSNotificationRequest)
+	if !ok {
+		return nil, rcs.ErrRCSSendFailed
+	}
+	if err := models.ValidateRCSNotification(notif); err != nil {
+		return nil, err
+	}
+
+	sentAt := r.record(ChannelRCS, notif.ID, notif.Recipient, notif)
+	return &models.RCSResponse{ID: notif.ID, Status: "sent", Message: "RCS message recorded in memory", SentAt: sentAt, Channel: ChannelRCS, DeliveredAs: models.RCSDeliveredAsRCS}, nil
+}
+
+// apnsRecorder implements apns.APNSService
+type apnsRecorder struct{ *Recorder }
+
+// SendPushNotification records the iOS push notification
+func (r apnsRecorder) SendPushNotification(ctx context.Context, notification interface{}) (interface{}, error) {
+	notif, ok := notification.(*models.APNSNotificationRequest)
+	if !ok {
+		return nil, apns.ErrInvalidNotificationPayload
+	}
+	if err := models.ValidateAPNSNotification(notif); err != nil {
+		return nil, err
+	}
+
+	sentAt := r.record(ChannelIOSPush, notif.ID, notif.Recipient, notif)
+	return &models.APNSResponse{ID: notif.ID, Status: "sent", Message: "APNS notification recorded in memory", SentAt: sentAt, Channel: ChannelIOSPush, SuccessCount: 1}, nil
+}
+
+// fcmRecorder implements fcm.FCMService
+type fcmRecorder struct{ *Recorder }
+
+// SendPushNotification records the Android push notification
+func (r fcmRecorder) SendPushNotification(ctx context.Context, notification interface{}) (interface{}, error) {
+	notif, ok := notification.(*models.FCMNotificationRequest)
+	if !ok {
+		return nil, fcm.ErrInvalidNotificationPayload
+	}
+	if err := models.ValidateFCMNotification(notif); err != nil {
+		return nil, err
+	}
+
+	sentAt := r.record(ChannelAndroidPush, notif.ID, notif.Recipient, notif)
+	return &models.FCMResponse{ID: notif.ID, Status: "sent", Message: "FCM notification recorded in memory", SentAt: sentAt, Channel: ChannelAndroidPush, SuccessCount: 1}, nil
+}
@@ -0,0 +1,160 @@
+// Package inmemory wires the complete notification pipeline — manager, in-memory
+// kafka channels, consumer worker pools and recording providers — behind a single
+// constructor. With its fake clock, scheduled sends run deterministically, which
+// makes it suitable for end-to-end tests and for local demos without credentials.
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/external_services/consumers"
+	"github.com/gaurav2721/notification-service/external_services/delivery"
+	"github.com/gaurav2721/notification-service/external_services/user"
+	"github.com/gaurav2721/notification-service/notification_manager"
+)
+
+// ProfileName selects the in-memory providers through the RUNTIME_PROFILE environment variable
+const ProfileName = "inmemory"
+
+// Default runtime settings
+const (
+	DefaultWorkerCount = 2
+	DefaultBufferSize  = 1000
+)
+
+// DefaultStartTime is where the fake clock starts when no clock is configured
+var DefaultStartTime = time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+// Config holds the settings of an in-memory runtime
+type Config struct {
+	// WorkerCount is the number of workers started in every consumer pool
+	WorkerCount int
+
+	// BufferSize is the capacity of every in-memory kafka channel
+	BufferSize int
+
+	// Clock drives scheduling and timestamps; nil uses a fake clock starting at DefaultStartTime
+	Clock clock.Clock
+}
+
+// DefaultConfig returns the settings used by New when fields are left empty
+func DefaultConfig() Config {
+	return Config{
+		WorkerCount: DefaultWorkerCount,
+		BufferSize:  DefaultBufferSize,
+	}
+}
+
+// Runtime is a running in-memory notification pipeline
+type Runtime struct {
+	clock           clock.Clock
+	recorder        *Recorder
+	bus             *ChannelBus
+	userService     user.UserService
+	deliveryService delivery.DeliveryService
+	consumerManager consumers.ConsumerManager
+	manager         *notification_manager.NotificationManagerImpl
+}
+
+// New creates and starts an in-memory runtime. Call Close to stop its workers.
+func New(config Config) (*Runtime, error) {
+	defaults := DefaultConfig()
+	if config.WorkerCount <= 0 {
+		config.WorkerCount = defaults.WorkerCount
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = defaults.BufferSize
+	}
+	if config.Clock == nil {
+		config.Clock = clock.NewFake(DefaultStartTime)
+	}
+
+	rt := &Runtime{
+		clock:           config.Clock,
+		recorder:        NewRecorder(config.Clock),
+		bus:             NewChannelBus(config.BufferSize),
+		userService:     user.NewUserService(),
+		deliveryService: delivery.NewDeliveryService(),
+	}
+	rt.deliveryService.SetClock(config.Clock)
+
+	rt.consumerManager = consumers.NewConsumerManager(consumers.ConsumerConfig{
+		EmailWorkerCount:       config.WorkerCount,
+		SlackWorkerCount:       config.WorkerCount,
+		GoogleChatWorkerCount:  config.WorkerCount,
+		IncidentWorkerCount:    config.WorkerCount,
+		RCSWorkerCount:         config.WorkerCount,
+		IOSPushWorkerCount:     config.WorkerCount,
+		AndroidPushWorkerCount: config.WorkerCount,
+		EmailService:           rt.recorder.EmailService(),
+		SlackService:           rt.recorder.SlackService(),
+		GoogleChatService:      rt.recorder.GoogleChatService(),
+		IncidentService:        rt.recorder.IncidentService(),
+		RCSService:             rt.recorder.RCSService(),
+		APNSService:            rt.recorder.APNSService(),
+		FCMService:             rt.recorder.FCMService(),
+		DeliveryService:        rt.deliveryService,
+		KafkaService:           rt.bus,
+		Middleware:             consumers.DefaultMiddleware(),
+		Clock:                  config.Clock,
+	})
+
+	ctx := context.Background()
+	if err := rt.consumerManager.Initialize(ctx); err != nil {
+		return nil, fmt.Errorf("failed to initialize consumers: %w", err)
+	}
+	if err := rt.consumerManager.Start(ctx); err != nil {
+		return nil, fmt.Errorf("failed to start consumers: %w", err)
+	}
+
+	rt.manager = notification_manager.NewNotificationManagerWithDefaultTemplate(rt.userService, rt.bus, rt.deliveryService)
+	rt.manager.SetClock(config.Clock)
+
+	return rt, nil
+}
+
+// Manager returns the notification manager of the pipeline
+func (rt *Runtime) Manager() *notification_manager.NotificationManagerImpl {
+	return rt.manager
+}
+
+// UserService returns the user service, preloaded with the sample users
+func (rt *Runtime) UserService() user.UserService {
+	return rt.userService
+}
+
+// DeliveryService returns the delivery archive the consumers record attempts in
+func (rt *Runtime) DeliveryService() delivery.DeliveryService {
+	return rt.deliveryService
+}
+
+// Recorder returns the providers' record of deliveries
+func (rt *Runtime) Recorder() *Recorder {
+	return rt.recorder
+}
+
+// Clock returns the clock of the runtime
+func (rt *Runtime) Clock() clock.Clock {
+	return rt.clock
+}
+
+// FakeClock returns the fake clock of the runtime, or nil when it runs on another clock
+func (rt *Runtime) FakeClock() *clock.Fake {
+	fake, _ := rt.clock.(*clock.Fake)
+	return fake
+}
+
+// WaitForDeliveries blocks until the providers received at least n notifications or ctx is done
+func (rt *Runtime) WaitForDeliveries(ctx context.Context, n int) error {
+	return rt.recorder.Wait(ctx, n)
+}
+
+// Close stops the consumer workers and closes the in-memory channels
+func (rt *Runtime) Close() error {
+	err := rt.consumerManager.Stop()
+	rt.bus.Close()
+	return err
+}
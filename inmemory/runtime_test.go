@@ -0,0 +1,83 @@
+package inmemory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRuntime(t *testing.T) *Runtime {
+	t.Helper()
+	rt, err := New(DefaultConfig())
+	require.NoError(t, err)
+	t.Cleanup(func() { rt.Close() })
+	return rt
+}
+
+func waitContext(t *testing.T) context.Context {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestRuntime_DeliversThroughPipeline(t *testing.T) {
+	rt := newTestRuntime(t)
+
+	result, err := rt.Manager().ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       string(models.SlackNotification),
+		Content:    map[string]interface{}{"text": "Deploy finished"},
+		Recipients: []string{"user-001", "user-002"},
+	})
+	require.NoError(t, err)
+	notificationID := result.(map[string]interface{})["id"].(string)
+
+	require.NoError(t, rt.WaitForDeliveries(waitContext(t), 2))
+
+	deliveries := rt.Recorder().Deliveries()
+	require.Len(t, deliveries, 2)
+	for _, d := range deliveries {
+		assert.Equal(t, ChannelSlack, d.Channel)
+		assert.Equal(t, notificationID, d.NotificationID)
+		assert.Equal(t, DefaultStartTime, d.SentAt)
+		assert.Equal(t, "Deploy finished", d.Request.(*models.SlackNotificationRequest).Content.Text)
+	}
+}
+
+func TestRuntime_ScheduledNotificationFollowsFakeClock(t *testing.T) {
+	rt := newTestRuntime(t)
+	fake := rt.FakeClock()
+	require.NotNil(t, fake)
+
+	scheduledAt := DefaultStartTime.Add(time.Hour)
+	result, err := rt.Manager().ProcessNotificationRequest(&models.NotificationRequest{
+		Type:        string(models.EmailNotification),
+		Content:     map[string]interface{}{"subject": "Reminder", "email_body": "Your meeting starts soon"},
+		Recipients:  []string{"user-001"},
+		ScheduledAt: &scheduledAt,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "scheduled", result.(map[string]interface{})["status"])
+
+	fake.Advance(59 * time.Minute)
+	assert.Empty(t, rt.Recorder().Deliveries())
+
+	fake.Advance(time.Minute)
+	require.NoError(t, rt.WaitForDeliveries(waitContext(t), 1))
+
+	delivery := rt.Recorder().Deliveries()[0]
+	assert.Equal(t, ChannelEmail, delivery.Channel)
+	assert.Equal(t, "john.doe@company.com", delivery.Recipient)
+	assert.Equal(t, scheduledAt, delivery.SentAt)
+}
+
+func TestRecorder_WaitHonoursContext(t *testing.T) {
+	recorder := NewRecorder(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, recorder.Wait(ctx, 1), context.Canceled)
+}
@@ -0,0 +1,198 @@
+// Package jsonschema validates JSON documents against JSON Schema (draft 2020-12) documents.
+// It implements the validation keywords of the service's request schemas: type, enum, const,
+// properties, required, additionalProperties, items, minItems, maxItems, uniqueItems,
+// minProperties, minLength, maxLength, pattern, format (date-time and email), minimum,
+// maximum and $ref to the document itself or its $defs. Other keywords are annotations and
+// are ignored.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidSchema is returned by Compile for schemas it cannot validate with
+var ErrInvalidSchema = errors.New("invalid schema")
+
+// Schema is a compiled JSON Schema document or subschema
+type Schema struct {
+	// boolean is set for the true and false schemas, which accept everything and nothing
+	boolean *bool
+
+	Ref                  string             `json:"$ref,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+	Type                 typeList           `json:"type,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Const                *interface{}       `json:"const,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	MinProperties        *int               `json:"minProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	UniqueItems          bool               `json:"uniqueItems,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+
+	pattern *regexp.Regexp
+	ref     *Schema
+}
+
+// typeList is the type keyword, a type name or a list of them
+type typeList []string
+
+// UnmarshalJSON accepts a type name or a list of type names
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*t = typeList{name}
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return fmt.Errorf("type must be a string or an array of strings")
+	}
+	*t = names
+	return nil
+}
+
+// UnmarshalJSON accepts schema objects and the boolean schemas true and false
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("true")) || bytes.Equal(data, []byte("false")) {
+		value := data[0] == 't'
+		*s = Schema{boolean: &value}
+		return nil
+	}
+
+	// schemaFields has the fields of Schema without its UnmarshalJSON method
+	type schemaFields Schema
+	var fields schemaFields
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return err
+	}
+	*s = Schema(fields)
+	return nil
+}
+
+// Compile parses a schema document and checks that its patterns compile and its references resolve
+func Compile(document []byte) (*Schema, error) {
+	var root Schema
+	if err := json.Unmarshal(document, &root); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	if err := root.compile(&root, "#"); err != nil {
+		return nil, err
+	}
+	return &root, nil
+}
+
+// MustCompile is like Compile but panics when the schema is invalid. It is meant for schemas
+// embedded in the binary.
+func MustCompile(document []byte) *Schema {
+	schema, err := Compile(document)
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+// compile prepares s and its subschemas; location names s in errors
+func (s *Schema) compile(root *Schema, location string) error {
+	if s.boolean != nil {
+		return nil
+	}
+
+	for _, name := range s.Type {
+		switch name {
+		case "null", "boolean", "object", "array", "number", "integer", "string":
+		default:
+			return fmt.Errorf("%w: %s: unknown type %q", ErrInvalidSchema, location, name)
+		}
+	}
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("%w: %s: pattern: %v", ErrInvalidSchema, location, err)
+		}
+		s.pattern = pattern
+	}
+	if s.Ref != "" {
+		ref, err := resolve(root, s.Ref)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidSchema, location, err)
+		}
+		s.ref = ref
+	}
+
+	for name, def := range s.Defs {
+		if err := def.compile(root, location+"/$defs/"+name); err != nil {
+			return err
+		}
+	}
+	for name, property := range s.Properties {
+		if err := property.compile(root, location+"/properties/"+name); err != nil {
+			return err
+		}
+	}
+	if s.AdditionalProperties != nil {
+		if err := s.AdditionalProperties.compile(root, location+"/additionalProperties"); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		if err := s.Items.compile(root, location+"/items"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve finds the schema a $ref points to; only the document itself and its $defs can be referenced
+func resolve(root *Schema, ref string) (*Schema, error) {
+	if ref == "#" {
+		return root, nil
+	}
+	name, ok := strings.CutPrefix(ref, "#/$defs/")
+	if !ok {
+		return nil, fmt.Errorf("unsupported $ref %q, only #/$defs/<name> is supported", ref)
+	}
+	def, ok := root.Defs[unescapePointer(name)]
+	if !ok {
+		return nil, fmt.Errorf("$ref %q does not resolve", ref)
+	}
+	return def, nil
+}
+
+// ValidateJSON validates a JSON document. The error is only set when the document is not JSON.
+func (s *Schema) ValidateJSON(document []byte) ([]Error, error) {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+	var instance interface{}
+	if err := decoder.Decode(&instance); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("unexpected data after the JSON document")
+	}
+	return s.Validate(instance), nil
+}
+
+// Validate validates a decoded JSON value: nil, bool, json.Number or float64, string,
+// []interface{} or map[string]interface{}. Errors are ordered by their location in the document.
+func (s *Schema) Validate(instance interface{}) []Error {
+	var errs []Error
+	s.validate(instance, nil, &errs)
+	return errs
+}
@@ -0,0 +1,100 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `{
+  "type": "object",
+  "required": ["name", "items"],
+  "properties": {
+    "name": {"type": "string", "minLength": 1, "maxLength": 5, "pattern": "^[a-z]+$"},
+    "kind": {"type": "string", "enum": ["a", "b"]},
+    "count": {"type": ["integer", "null"], "minimum": 1, "maximum": 10},
+    "at": {"type": "string", "format": "date-time"},
+    "email": {"type": "string", "format": "email"},
+    "items": {"type": "array", "minItems": 1, "maxItems": 3, "uniqueItems": true, "items": {"$ref": "#/$defs/item"}},
+    "labels": {"type": "object", "additionalProperties": {"type": "string"}},
+    "fixed": {"type": "object", "properties": {"x": true}, "additionalProperties": false}
+  },
+  "$defs": {
+    "item": {"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}}
+  }
+}`
+
+func TestValidate(t *testing.T) {
+	schema, err := Compile([]byte(testSchema))
+	require.NoError(t, err)
+
+	errs, err := schema.ValidateJSON([]byte(`{"name": "abc", "kind": "a", "count": 2.0, "at": "2024-01-01T09:00:00+01:00",
+		"email": "alice@example.com", "items": [{"id": 1}, {"id": 2}], "labels": {"x": "y"}, "fixed": {"x": 1}, "extra": true}`))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	errs, err = schema.ValidateJSON([]byte(`{"name": "ABCDEF", "kind": "c", "count": 11, "at": "2024-01-01T09:00:00",
+		"email": "not an email", "items": [{"id": 1.5}, {}, {}, {"id": 1}], "labels": {"a/b": 1}, "fixed": {"y": 1}}`))
+	require.NoError(t, err)
+
+	type found struct{ path, keyword string }
+	var got []found
+	for _, e := range errs {
+		got = append(got, found{e.Path, e.Keyword})
+	}
+	assert.Equal(t, []found{
+		{"/at", "format"},
+		{"/count", "maximum"},
+		{"/email", "format"},
+		{"/fixed/y", "additionalProperties"},
+		{"/items", "maxItems"},
+		{"/items/2", "uniqueItems"},
+		{"/items/0/id", "type"},
+		{"/items/1/id", "required"},
+		{"/items/2/id", "required"},
+		{"/kind", "enum"},
+		{"/labels/a~1b", "type"},
+		{"/name", "maxLength"},
+		{"/name", "pattern"},
+	}, got)
+
+	assert.Equal(t, "items[0].id", errs[6].Field())
+	assert.Equal(t, "items[0].id: must be an integer", errs[6].Error())
+	assert.Equal(t, "must be one of: a, b", errs[9].Message)
+}
+
+func TestValidate_RootAndMissingProperties(t *testing.T) {
+	schema := MustCompile([]byte(testSchema))
+
+	errs := schema.Validate([]interface{}{})
+	require.Len(t, errs, 1)
+	assert.Equal(t, "", errs[0].Path)
+	assert.Equal(t, "must be an object", errs[0].Message)
+
+	errs, err := schema.ValidateJSON([]byte(`{"count": null}`))
+	require.NoError(t, err)
+	require.Len(t, errs, 2)
+	assert.Equal(t, "/name", errs[0].Path)
+	assert.Equal(t, "is required", errs[0].Message)
+	assert.Equal(t, "/items", errs[1].Path)
+
+	_, err = schema.ValidateJSON([]byte(`{"name": `))
+	assert.Error(t, err)
+	_, err = schema.ValidateJSON([]byte(`{} {}`))
+	assert.Error(t, err)
+}
+
+func TestCompile_InvalidSchemas(t *testing.T) {
+	for _, document := range []string{
+		`{"type": "text"}`,
+		`{"pattern": "("}`,
+		`{"$ref": "#/$defs/missing"}`,
+		`{"$ref": "other.json"}`,
+		`{"properties": {"a": {"items": {"type": 1}}}}`,
+		`[]`,
+	} {
+		_, err := Compile([]byte(document))
+		assert.ErrorIs(t, err, ErrInvalidSchema, document)
+	}
+}
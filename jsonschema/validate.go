@@ -0,0 +1,358 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Error is a value that does not match its schema
+type Error struct {
+	// Path is the JSON Pointer of the value, e.g. /recipients/0; the document itself is ""
+	Path string `json:"path"`
+	// Keyword is the schema keyword the value violates, e.g. maxLength
+	Keyword string `json:"keyword"`
+	Message string `json:"message"`
+
+	segments []segment
+}
+
+// segment is a step of the path to a value: an object property or an array index
+type segment struct {
+	name  string
+	index int
+	array bool
+}
+
+// Error describes the error with the field it is about
+func (e Error) Error() string {
+	if field := e.Field(); field != "" {
+		return field + ": " + e.Message
+	}
+	return e.Message
+}
+
+// Field returns the path of the value in dotted notation, e.g. template.recipient_data or
+// recipients[0]
+func (e Error) Field() string {
+	var field strings.Builder
+	for _, step := range e.segments {
+		if step.array {
+			fmt.Fprintf(&field, "[%d]", step.index)
+			continue
+		}
+		if field.Len() > 0 {
+			field.WriteByte('.')
+		}
+		field.WriteString(step.name)
+	}
+	return field.String()
+}
+
+// newError creates an error about the value at path
+func newError(path []segment, keyword, format string, args ...interface{}) Error {
+	var pointer strings.Builder
+	for _, step := range path {
+		pointer.WriteByte('/')
+		if step.array {
+			pointer.WriteString(strconv.Itoa(step.index))
+		} else {
+			pointer.WriteString(escapePointer(step.name))
+		}
+	}
+	return Error{
+		Path:     pointer.String(),
+		Keyword:  keyword,
+		Message:  fmt.Sprintf(format, args...),
+		segments: append([]segment(nil), path...),
+	}
+}
+
+// validate adds the errors of instance, found at path, to errs
+func (s *Schema) validate(instance interface{}, path []segment, errs *[]Error) {
+	if s.boolean != nil {
+		if !*s.boolean {
+			*errs = append(*errs, newError(path, "false", "is not allowed"))
+		}
+		return
+	}
+	if s.ref != nil {
+		s.ref.validate(instance, path, errs)
+	}
+
+	if len(s.Type) > 0 && !matchesType(instance, s.Type) {
+		*errs = append(*errs, newError(path, "type", "must be %s", describeTypes(s.Type)))
+		// The other keywords would only repeat the mismatch
+		return
+	}
+	if len(s.Enum) > 0 && !containsValue(s.Enum, instance) {
+		*errs = append(*errs, newError(path, "enum", "must be one of: %s", describeValues(s.Enum)))
+	}
+	if s.Const != nil && !equalValues(*s.Const, instance) {
+		*errs = append(*errs, newError(path, "const", "must be %s", describeValues([]interface{}{*s.Const})))
+	}
+
+	switch value := instance.(type) {
+	case string:
+		s.validateString(value, path, errs)
+	case json.Number, float64:
+		s.validateNumber(toFloat(value), path, errs)
+	case []interface{}:
+		s.validateArray(value, path, errs)
+	case map[string]interface{}:
+		s.validateObject(value, path, errs)
+	}
+}
+
+// validateString applies the string keywords
+func (s *Schema) validateString(value string, path []segment, errs *[]Error) {
+	length := utf8.RuneCountInString(value)
+	if s.MinLength != nil && length < *s.MinLength {
+		if *s.MinLength == 1 {
+			*errs = append(*errs, newError(path, "minLength", "must not be empty"))
+		} else {
+			*errs = append(*errs, newError(path, "minLength", "must be at least %d characters long", *s.MinLength))
+		}
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		*errs = append(*errs, newError(path, "maxLength", "must be at most %d characters long", *s.MaxLength))
+	}
+	if s.pattern != nil && !s.pattern.MatchString(value) {
+		*errs = append(*errs, newError(path, "pattern", "must match the pattern %s", s.Pattern))
+	}
+	switch s.Format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			*errs = append(*errs, newError(path, "format", "must be an RFC 3339 timestamp with a time zone, such as 2024-01-01T09:00:00Z"))
+		}
+	case "email":
+		if address, err := mail.ParseAddress(value); err != nil || address.Address != value {
+			*errs = append(*errs, newError(path, "format", "must be an email address"))
+		}
+	}
+}
+
+// validateNumber applies the numeric keywords
+func (s *Schema) validateNumber(value float64, path []segment, errs *[]Error) {
+	if s.Minimum != nil && value < *s.Minimum {
+		*errs = append(*errs, newError(path, "minimum", "must be at least %s", formatNumber(*s.Minimum)))
+	}
+	if s.Maximum != nil && value > *s.Maximum {
+		*errs = append(*errs, newError(path, "maximum", "must be at most %s", formatNumber(*s.Maximum)))
+	}
+}
+
+// validateArray applies the array keywords and validates the items
+func (s *Schema) validateArray(items []interface{}, path []segment, errs *[]Error) {
+	if s.MinItems != nil && len(items) < *s.MinItems {
+		if *s.MinItems == 1 {
+			*errs = append(*errs, newError(path, "minItems", "must not be empty"))
+		} else {
+			*errs = append(*errs, newError(path, "minItems", "must have at least %d items", *s.MinItems))
+		}
+	}
+	if s.MaxItems != nil && len(items) > *s.MaxItems {
+		*errs = append(*errs, newError(path, "maxItems", "must have at most %d items", *s.MaxItems))
+	}
+	if s.UniqueItems {
+		for i := range items {
+			for j := 0; j < i; j++ {
+				if equalValues(items[i], items[j]) {
+					*errs = append(*errs, newError(append(path, segment{index: i, array: true}), "uniqueItems", "duplicates item %d", j))
+					break
+				}
+			}
+		}
+	}
+	if s.Items != nil {
+		for i, item := range items {
+			s.Items.validate(item, append(path, segment{index: i, array: true}), errs)
+		}
+	}
+}
+
+// validateObject applies the object keywords and validates the properties in name order
+func (s *Schema) validateObject(object map[string]interface{}, path []segment, errs *[]Error) {
+	if s.MinProperties != nil && len(object) < *s.MinProperties {
+		if *s.MinProperties == 1 {
+			*errs = append(*errs, newError(path, "minProperties", "must not be empty"))
+		} else {
+			*errs = append(*errs, newError(path, "minProperties", "must have at least %d properties", *s.MinProperties))
+		}
+	}
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			*errs = append(*errs, newError(append(path, segment{name: name}), "required", "is required"))
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		propertyPath := append(path, segment{name: name})
+		if property, ok := s.Properties[name]; ok {
+			property.validate(object[name], propertyPath, errs)
+			continue
+		}
+		if s.AdditionalProperties == nil {
+			continue
+		}
+		if additional := s.AdditionalProperties; additional.boolean != nil && !*additional.boolean {
+			*errs = append(*errs, newError(propertyPath, "additionalProperties", "is not a known property"))
+			continue
+		}
+		s.AdditionalProperties.validate(object[name], propertyPath, errs)
+	}
+}
+
+// matchesType reports whether a decoded JSON value has one of the given types
+func matchesType(instance interface{}, types []string) bool {
+	for _, name := range types {
+		switch value := instance.(type) {
+		case nil:
+			if name == "null" {
+				return true
+			}
+		case bool:
+			if name == "boolean" {
+				return true
+			}
+		case string:
+			if name == "string" {
+				return true
+			}
+		case json.Number, float64:
+			number := toFloat(value)
+			if name == "number" || (name == "integer" && number == math.Trunc(number) && !math.IsInf(number, 0)) {
+				return true
+			}
+		case []interface{}:
+			if name == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if name == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// describeTypes names types in messages, e.g. "a string or null"
+func describeTypes(types []string) string {
+	described := make([]string, len(types))
+	for i, name := range types {
+		switch name {
+		case "null":
+			described[i] = "null"
+		case "integer", "object", "array":
+			described[i] = "an " + name
+		default:
+			described[i] = "a " + name
+		}
+	}
+	if len(described) == 1 {
+		return described[0]
+	}
+	return strings.Join(described[:len(described)-1], ", ") + " or " + described[len(described)-1]
+}
+
+// describeValues lists enum values in messages
+func describeValues(values []interface{}) string {
+	described := make([]string, len(values))
+	for i, value := range values {
+		if text, ok := value.(string); ok {
+			described[i] = text
+			continue
+		}
+		encoded, _ := json.Marshal(value)
+		described[i] = string(encoded)
+	}
+	return strings.Join(described, ", ")
+}
+
+// containsValue reports whether values holds value
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, candidate := range values {
+		if equalValues(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalValues compares decoded JSON values; numbers are compared by value, so 1 equals 1.0
+func equalValues(a, b interface{}) bool {
+	switch a.(type) {
+	case json.Number, float64:
+		switch b.(type) {
+		case json.Number, float64:
+			return toFloat(a) == toFloat(b)
+		}
+		return false
+	case []interface{}:
+		bItems, ok := b.([]interface{})
+		aItems := a.([]interface{})
+		if !ok || len(aItems) != len(bItems) {
+			return false
+		}
+		for i := range aItems {
+			if !equalValues(aItems[i], bItems[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		bObject, ok := b.(map[string]interface{})
+		aObject := a.(map[string]interface{})
+		if !ok || len(aObject) != len(bObject) {
+			return false
+		}
+		for name, value := range aObject {
+			other, ok := bObject[name]
+			if !ok || !equalValues(value, other) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// toFloat converts a decoded JSON number
+func toFloat(value interface{}) float64 {
+	switch number := value.(type) {
+	case json.Number:
+		parsed, _ := number.Float64()
+		return parsed
+	case float64:
+		return number
+	}
+	return 0
+}
+
+// formatNumber formats a bound in messages
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// escapePointer escapes a property name for a JSON Pointer
+func escapePointer(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
+
+// unescapePointer reverses escapePointer
+func unescapePointer(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~1", "/"), "~0", "~")
+}
@@ -0,0 +1,10 @@
+package loadtest
+
+import "errors"
+
+// Load-test harness errors
+var (
+	ErrInvalidConfig          = errors.New("invalid load-test configuration")
+	ErrUnexpectedNotification = errors.New("unexpected notification payload")
+	ErrIncompleteRun          = errors.New("load-test run did not deliver every notification")
+)
@@ -0,0 +1,332 @@
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gaurav2721/notification-service/external_services/consumers"
+	"github.com/gaurav2721/notification-service/external_services/user"
+	"github.com/gaurav2721/notification-service/inmemory"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/gaurav2721/notification-service/notification_manager"
+	"github.com/sirupsen/logrus"
+)
+
+// Config holds the shape of a synthetic load-test run
+type Config struct {
+	// Type is the notification type to send: "email", "slack" or "in_app"
+	Type string
+
+	// Notifications is the number of notification requests submitted to the manager
+	Notifications int
+
+	// RecipientsPerNotification is the number of recipients on every request
+	RecipientsPerNotification int
+
+	// Users is the size of the synthetic user pool recipients are drawn from
+	Users int
+
+	// IOSDevicesPerUser and AndroidDevicesPerUser control in_app fan-out
+	IOSDevicesPerUser     int
+	AndroidDevicesPerUser int
+
+	// Concurrency is the number of goroutines submitting requests
+	Concurrency int
+
+	// WorkerCount is the number of workers started in every consumer pool
+	WorkerCount int
+
+	// ProviderLatency is the simulated time spent in every provider call
+	ProviderLatency time.Duration
+
+	// Timeout bounds how long the run waits for all deliveries to complete
+	Timeout time.Duration
+
+	// Verbose keeps the configured log level during the run.
+	// By default logging is raised to error level so it does not dominate the measurements.
+	Verbose bool
+}
+
+// DefaultConfig returns a small email run suitable for a quick smoke test
+func DefaultConfig() Config {
+	return Config{
+		Type:                      string(models.EmailNotification),
+		Notifications:             1000,
+		RecipientsPerNotification: 5,
+		Users:                     100,
+		IOSDevicesPerUser:         1,
+		AndroidDevicesPerUser:     1,
+		Concurrency:               runtime.GOMAXPROCS(0),
+		WorkerCount:               5,
+		Timeout:                   time.Minute,
+	}
+}
+
+// Validate checks that the configuration describes a runnable load
+func (c Config) Validate() error {
+	switch c.Type {
+	case string(models.EmailNotification), string(models.SlackNotification), string(models.InAppNotification):
+	default:
+		return fmt.Errorf("%w: unsupported notification type %q", ErrInvalidConfig, c.Type)
+	}
+
+	if c.Notifications <= 0 {
+		return fmt.Errorf("%w: notifications must be positive", ErrInvalidConfig)
+	}
+	if c.RecipientsPerNotification <= 0 {
+		return fmt.Errorf("%w: recipients per notification must be positive", ErrInvalidConfig)
+	}
+	if c.Users < c.RecipientsPerNotification {
+		return fmt.Errorf("%w: user pool (%d) is smaller than recipients per notification (%d)", ErrInvalidConfig, c.Users, c.RecipientsPerNotification)
+	}
+	if c.Concurrency <= 0 || c.WorkerCount <= 0 {
+		return fmt.Errorf("%w: concurrency and worker count must be positive", ErrInvalidConfig)
+	}
+	if c.Type == string(models.InAppNotification) && c.IOSDevicesPerUser+c.AndroidDevicesPerUser <= 0 {
+		return fmt.Errorf("%w: in_app runs need at least one device per user", ErrInvalidConfig)
+	}
+
+	return nil
+}
+
+// deliveriesPerRecipient returns how many provider calls a single recipient produces
+func (c Config) deliveriesPerRecipient() int {
+	if c.Type == string(models.InAppNotification) {
+		return c.IOSDevicesPerUser + c.AndroidDevicesPerUser
+	}
+	return 1
+}
+
+// ExpectedDeliveries returns the number of provider calls a complete run produces
+func (c Config) ExpectedDeliveries() int {
+	return c.Notifications * c.RecipientsPerNotification * c.deliveriesPerRecipient()
+}
+
+// Harness drives synthetic notification loads through the notification manager
+// and the consumer worker pools, with in-memory providers standing in for the
+// real email, slack, APNS and FCM services.
+type Harness struct {
+	config      Config
+	userService user.UserService
+	userIDs     []string
+}
+
+// NewHarness creates a load-test harness and seeds its synthetic user pool
+func NewHarness(config Config) (*Harness, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	h := &Harness{
+		config:      config,
+		userService: user.NewUserService(),
+		userIDs:     make([]string, 0, config.Users),
+	}
+
+	if err := h.seedUsers(); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// seedUsers registers the synthetic users and their devices
+func (h *Harness) seedUsers() error {
+	for i := 0; i < h.config.Users; i++ {
+		userID := fmt.Sprintf("loadtest-user-%05d", i)
+		err := h.userService.CreateUser(&models.User{
+			ID:           userID,
+			Email:        fmt.Sprintf("loadtest.user%05d@example.com", i),
+			FullName:     fmt.Sprintf("Load Test User %05d", i),
+			SlackUserID:  fmt.Sprintf("U%08d", i),
+			SlackChannel: fmt.Sprintf("#loadtest-%05d", i),
+			IsActive:     true,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to seed user %s: %w", userID, err)
+		}
+
+		if h.config.Type == string(models.InAppNotification) {
+			for d := 0; d < h.config.IOSDevicesPerUser; d++ {
+				if _, err := h.userService.RegisterDevice(userID, fmt.Sprintf("%032x%032x", i, d), "ios"); err != nil {
+					return fmt.Errorf("failed to seed iOS device for %s: %w", userID, err)
+				}
+			}
+			for d := 0; d < h.config.AndroidDevicesPerUser; d++ {
+				if _, err := h.userService.RegisterDevice(userID, fmt.Sprintf("fcm-loadtest-%08d-%04d", i, d), "android"); err != nil {
+					return fmt.Errorf("failed to seed Android device for %s: %w", userID, err)
+				}
+			}
+		}
+
+		h.userIDs = append(h.userIDs, userID)
+	}
+
+	return nil
+}
+
+// Run submits the configured load and waits until every delivery reached a provider.
+// Each run builds a fresh manager, bus and set of worker pools so runs are independent.
+func (h *Harness) Run(ctx context.Context) (*Report, error) {
+	if !h.config.Verbose {
+		previousLevel, _ := logger.ParseLevel(logger.GetSettings().Level)
+		logger.SetDefaultLevel(logger.ErrorLevel)
+		defer logger.SetDefaultLevel(previousLevel)
+	}
+
+	if h.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.config.Timeout)
+		defer cancel()
+	}
+
+	expected := int64(h.config.ExpectedDeliveries())
+	var delivered atomic.Int64
+	done := make(chan struct{})
+	onDeliver := func() {
+		if delivered.Add(1) == expected {
+			close(done)
+		}
+	}
+
+	emailProviderImpl := emailProvider{newCountingProvider(string(models.EmailNotification), h.config.ProviderLatency, onDeliver)}
+	slackProviderImpl := slackProvider{newCountingProvider(string(models.SlackNotification), h.config.ProviderLatency, onDeliver)}
+	apnsProviderImpl := apnsProvider{newCountingProvider("ios_push", h.config.ProviderLatency, onDeliver)}
+	fcmProviderImpl := fcmProvider{newCountingProvider("android_push", h.config.ProviderLatency, onDeliver)}
+
+	bus := inmemory.NewChannelBus(int(expected))
+	manager := notification_manager.NewNotificationManagerWithDefaultTemplate(h.userService, bus, nil)
+
+	consumerManager := consumers.NewConsumerManager(consumers.ConsumerConfig{
+		EmailWorkerCount:       h.config.WorkerCount,
+		SlackWorkerCount:       h.config.WorkerCount,
+		IOSPushWorkerCount:     h.config.WorkerCount,
+		AndroidPushWorkerCount: h.config.WorkerCount,
+		EmailService:           emailProviderImpl,
+		SlackService:           slackProviderImpl,
+		APNSService:            apnsProviderImpl,
+		FCMService:             fcmProviderImpl,
+		KafkaService:           bus,
+		Middleware:             consumers.DefaultMiddleware(),
+	})
+	if err := consumerManager.Initialize(ctx); err != nil {
+		return nil, fmt.Errorf("failed to initialize consumers: %w", err)
+	}
+	if err := consumerManager.Start(ctx); err != nil {
+		return nil, fmt.Errorf("failed to start consumers: %w", err)
+	}
+	defer consumerManager.Stop()
+
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	startedAt := time.Now()
+	submitErrors := h.submit(ctx, manager)
+	submittedIn := time.Since(startedAt)
+
+	var runErr error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		runErr = fmt.Errorf("%w: %d of %d deliveries completed: %v", ErrIncompleteRun, delivered.Load(), expected, ctx.Err())
+	}
+	duration := time.Since(startedAt)
+
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+
+	report := &Report{
+		Type:           h.config.Type,
+		Notifications:  h.config.Notifications,
+		Recipients:     h.config.Notifications * h.config.RecipientsPerNotification,
+		Deliveries:     delivered.Load(),
+		SubmitErrors:   submitErrors,
+		Workers:        h.config.WorkerCount,
+		Concurrency:    h.config.Concurrency,
+		SubmitDuration: submittedIn,
+		Duration:       duration,
+		BytesAllocated: after.TotalAlloc - before.TotalAlloc,
+		Allocations:    after.Mallocs - before.Mallocs,
+		GCCycles:       after.NumGC - before.NumGC,
+		GCPause:        time.Duration(after.PauseTotalNs - before.PauseTotalNs),
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"type":        report.Type,
+		"deliveries":  report.Deliveries,
+		"duration_ms": report.Duration.Milliseconds(),
+	}).Debug("Load-test run completed")
+
+	return report, runErr
+}
+
+// submit fans the notification requests out over the configured number of producers
+// and returns the number of requests the manager rejected
+func (h *Harness) submit(ctx context.Context, manager notification_manager.NotificationManager) int64 {
+	var (
+		next   atomic.Int64
+		failed atomic.Int64
+		wg     sync.WaitGroup
+	)
+
+	for p := 0; p < h.config.Concurrency; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1)) - 1
+				if i >= h.config.Notifications || ctx.Err() != nil {
+					return
+				}
+				if _, err := manager.ProcessNotificationRequest(h.buildRequest(i)); err != nil {
+					failed.Add(1)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	return failed.Load()
+}
+
+// buildRequest creates the i-th notification request, rotating through the user pool
+func (h *Harness) buildRequest(i int) *models.NotificationRequest {
+	recipients := make([]string, h.config.RecipientsPerNotification)
+	offset := i * h.config.RecipientsPerNotification
+	for r := range recipients {
+		recipients[r] = h.userIDs[(offset+r)%len(h.userIDs)]
+	}
+
+	request := &models.NotificationRequest{
+		Type:       h.config.Type,
+		Recipients: recipients,
+	}
+
+	switch h.config.Type {
+	case string(models.EmailNotification):
+		request.Content = map[string]interface{}{
+			"subject":    fmt.Sprintf("Load test notification %d", i),
+			"email_body": "This notification was generated by the load-test harness.",
+		}
+		request.From = &struct {
+			Email string `json:"email"`
+		}{Email: "loadtest@example.com"}
+	case string(models.SlackNotification):
+		request.Content = map[string]interface{}{
+			"text": fmt.Sprintf("Load test notification %d", i),
+		}
+	case string(models.InAppNotification):
+		request.Content = map[string]interface{}{
+			"title": fmt.Sprintf("Load test notification %d", i),
+			"body":  "This notification was generated by the load-test harness.",
+		}
+	}
+
+	return request
+}
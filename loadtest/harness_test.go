@@ -0,0 +1,104 @@
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	config := DefaultConfig()
+	require.NoError(t, config.Validate())
+
+	invalid := config
+	invalid.Type = "sms"
+	assert.ErrorIs(t, invalid.Validate(), ErrInvalidConfig)
+
+	invalid = config
+	invalid.Users = 2
+	invalid.RecipientsPerNotification = 3
+	assert.ErrorIs(t, invalid.Validate(), ErrInvalidConfig)
+
+	invalid = config
+	invalid.Type = "in_app"
+	invalid.IOSDevicesPerUser = 0
+	invalid.AndroidDevicesPerUser = 0
+	assert.ErrorIs(t, invalid.Validate(), ErrInvalidConfig)
+}
+
+func TestHarness_Run(t *testing.T) {
+	tests := []struct {
+		name               string
+		notificationType   string
+		expectedDeliveries int64
+	}{
+		{name: "email", notificationType: "email", expectedDeliveries: 50 * 3},
+		{name: "slack", notificationType: "slack", expectedDeliveries: 50 * 3},
+		{name: "in_app", notificationType: "in_app", expectedDeliveries: 50 * 3 * 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.Type = tt.notificationType
+			config.Notifications = 50
+			config.RecipientsPerNotification = 3
+			config.Users = 10
+			config.Timeout = 10 * time.Second
+
+			harness, err := NewHarness(config)
+			require.NoError(t, err)
+
+			report, err := harness.Run(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedDeliveries, report.Deliveries)
+			assert.Zero(t, report.SubmitErrors)
+			assert.Greater(t, report.DeliveriesPerSecond(), 0.0)
+			assert.NotEmpty(t, report.String())
+		})
+	}
+}
+
+// runBenchmark pushes b.N notifications through the pipeline and reports throughput
+func runBenchmark(b *testing.B, config Config) {
+	config.Notifications = b.N
+	harness, err := NewHarness(config)
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	report, err := harness.Run(context.Background())
+	require.NoError(b, err)
+
+	b.ReportMetric(report.DeliveriesPerSecond(), "deliveries/s")
+	b.ReportMetric(report.AllocationsPerDelivery(), "allocs/delivery")
+}
+
+func BenchmarkPipeline(b *testing.B) {
+	for _, notificationType := range []string{"email", "slack", "in_app"} {
+		for _, recipients := range []int{1, 10, 50} {
+			b.Run(fmt.Sprintf("%s/recipients=%d", notificationType, recipients), func(b *testing.B) {
+				config := DefaultConfig()
+				config.Type = notificationType
+				config.RecipientsPerNotification = recipients
+				runBenchmark(b, config)
+			})
+		}
+	}
+}
+
+func BenchmarkPipeline_Workers(b *testing.B) {
+	for _, workers := range []int{1, 5, 20} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			config := DefaultConfig()
+			config.WorkerCount = workers
+			config.ProviderLatency = time.Millisecond
+			runBenchmark(b, config)
+		})
+	}
+}
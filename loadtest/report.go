@@ -0,0 +1,71 @@
+package loadtest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Report summarises the throughput and allocation profile of a load-test run
+type Report struct {
+	Type           string        `json:"type"`
+	Notifications  int           `json:"notifications"`
+	Recipients     int           `json:"recipients"`
+	Deliveries     int64         `json:"deliveries"`
+	SubmitErrors   int64         `json:"submit_errors"`
+	Workers        int           `json:"workers"`
+	Concurrency    int           `json:"concurrency"`
+	SubmitDuration time.Duration `json:"submit_duration"`
+	Duration       time.Duration `json:"duration"`
+	BytesAllocated uint64        `json:"bytes_allocated"`
+	Allocations    uint64        `json:"allocations"`
+	GCCycles       uint32        `json:"gc_cycles"`
+	GCPause        time.Duration `json:"gc_pause"`
+}
+
+// NotificationsPerSecond returns the rate at which requests were accepted by the manager
+func (r *Report) NotificationsPerSecond() float64 {
+	if r.SubmitDuration <= 0 {
+		return 0
+	}
+	return float64(r.Notifications) / r.SubmitDuration.Seconds()
+}
+
+// DeliveriesPerSecond returns the end-to-end rate at which providers were called
+func (r *Report) DeliveriesPerSecond() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Deliveries) / r.Duration.Seconds()
+}
+
+// BytesPerDelivery returns the average heap allocation per provider call
+func (r *Report) BytesPerDelivery() float64 {
+	if r.Deliveries == 0 {
+		return 0
+	}
+	return float64(r.BytesAllocated) / float64(r.Deliveries)
+}
+
+// AllocationsPerDelivery returns the average number of heap allocations per provider call
+func (r *Report) AllocationsPerDelivery() float64 {
+	if r.Deliveries == 0 {
+		return 0
+	}
+	return float64(r.Allocations) / float64(r.Deliveries)
+}
+
+// String renders the report in a human readable form
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "type:              %s\n", r.Type)
+	fmt.Fprintf(&b, "notifications:     %d (%d recipients, %d submit errors)\n", r.Notifications, r.Recipients, r.SubmitErrors)
+	fmt.Fprintf(&b, "deliveries:        %d\n", r.Deliveries)
+	fmt.Fprintf(&b, "workers per pool:  %d (producers: %d)\n", r.Workers, r.Concurrency)
+	fmt.Fprintf(&b, "submit:            %s (%.0f notifications/s)\n", r.SubmitDuration, r.NotificationsPerSecond())
+	fmt.Fprintf(&b, "end to end:        %s (%.0f deliveries/s)\n", r.Duration, r.DeliveriesPerSecond())
+	fmt.Fprintf(&b, "allocated:         %d bytes in %d allocations\n", r.BytesAllocated, r.Allocations)
+	fmt.Fprintf(&b, "per delivery:      %.0f B, %.1f allocs\n", r.BytesPerDelivery(), r.AllocationsPerDelivery())
+	fmt.Fprintf(&b, "gc:                %d cycles, %s paused\n", r.GCCycles, r.GCPause)
+	return b.String()
+}
@@ -12,21 +12,25 @@ func Configure() {
 	// Configure logrus formatter
 	logrus.SetFormatter(&logrus.JSONFormatter{})
 
-	// Set log level from environment variable or default to InfoLevel
-	logLevel := os.Getenv(constants.LOG_LEVEL)
-	switch logLevel {
-	case "debug":
-		logrus.SetLevel(logrus.DebugLevel)
-	case "info":
-		logrus.SetLevel(logrus.InfoLevel)
-	case "warn":
-		logrus.SetLevel(logrus.WarnLevel)
-	case "error":
-		logrus.SetLevel(logrus.ErrorLevel)
-	default:
-		logrus.SetLevel(logrus.InfoLevel) // Default to InfoLevel to disable debug logs
+	// logrus and zap share one sink: standard output unless log files are configured
+	logrus.SetOutput(Output())
+
+	// Write to the configured log files, falling back to stdout
+	if config, err := LoadOutputConfig(); err != nil {
+		logrus.WithError(err).Error("Invalid log output configuration, logging to stdout")
+	} else if err := ConfigureOutput(config); err != nil {
+		logrus.WithError(err).Error("Failed to open log files, logging to stdout")
+	}
+
+	// Set log level from environment variable or default to InfoLevel to disable debug logs
+	level, err := ParseLevel(os.Getenv(constants.LOG_LEVEL))
+	if err != nil {
+		level = InfoLevel
 	}
 
+	// Apply the backend, per-module levels and sampling of the module loggers
+	configureStructured(level)
+
 	logrus.Debug("Logger configured successfully")
 }
 
@@ -37,14 +41,7 @@ func GetLogger() *logrus.Logger {
 
 // SetLevel allows runtime log level changes
 func SetLevel(level string) {
-	switch level {
-	case "debug":
-		logrus.SetLevel(logrus.DebugLevel)
-	case "info":
-		logrus.SetLevel(logrus.InfoLevel)
-	case "warn":
-		logrus.SetLevel(logrus.WarnLevel)
-	case "error":
-		logrus.SetLevel(logrus.ErrorLevel)
+	if parsed, err := ParseLevel(level); err == nil {
+		SetDefaultLevel(parsed)
 	}
 }
@@ -0,0 +1,240 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaurav2721/notification-service/constants"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// OutputConfig configures where log entries and audit entries are written
+type OutputConfig struct {
+	// Stdout writes log entries to standard output, also when they are written to a file
+	Stdout bool `json:"stdout"`
+
+	// File is the log file; without a path log entries are only written to standard output
+	File FileConfig `json:"file"`
+
+	// Audit is the file of the audit log stream; without a path audit entries are written
+	// together with the log entries
+	Audit FileConfig `json:"audit"`
+}
+
+// DefaultOutputConfig returns the output configuration used when nothing is configured
+func DefaultOutputConfig() OutputConfig {
+	rotation := FileConfig{
+		MaxSizeMB:   constants.DefaultLogFileMaxSizeMB,
+		RotateHours: constants.DefaultLogFileRotateHours,
+		MaxBackups:  constants.DefaultLogFileMaxBackups,
+		Compress:    true,
+	}
+	return OutputConfig{Stdout: true, File: rotation, Audit: rotation}
+}
+
+// LoadOutputConfig reads the output configuration from the LOG_CONFIG_FILE file, if set, and
+// then applies the environment variables, which take precedence. The rotation settings of the
+// environment apply to both the log file and the audit log file.
+func LoadOutputConfig() (OutputConfig, error) {
+	config := DefaultOutputConfig()
+
+	if path := os.Getenv(constants.LogConfigFileEnvVar); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return config, fmt.Errorf("failed to read log config file: %w", err)
+		}
+		if config, err = ParseOutputConfig(data, filepath.Ext(path)); err != nil {
+			return config, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	if value := os.Getenv(constants.LogStdoutEnvVar); value != "" {
+		if stdout, err := strconv.ParseBool(value); err == nil {
+			config.Stdout = stdout
+		}
+	}
+	if path := os.Getenv(constants.LogFileEnvVar); path != "" {
+		config.File.Path = path
+	}
+	if path := os.Getenv(constants.AuditLogFileEnvVar); path != "" {
+		config.Audit.Path = path
+	}
+	for _, file := range []*FileConfig{&config.File, &config.Audit} {
+		if size, ok := getEnvAsInt(constants.LogFileMaxSizeMBEnvVar); ok {
+			file.MaxSizeMB = size
+		}
+		if hours, ok := getEnvAsInt(constants.LogFileRotateHoursEnvVar); ok {
+			file.RotateHours = hours
+		}
+		if backups, ok := getEnvAsInt(constants.LogFileMaxBackupsEnvVar); ok {
+			file.MaxBackups = backups
+		}
+		if value := os.Getenv(constants.LogFileCompressEnvVar); value != "" {
+			if compress, err := strconv.ParseBool(value); err == nil {
+				file.Compress = compress
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// ParseOutputConfig parses an output configuration file in the format given by its extension.
+// Files ending in .yaml or .yml are parsed as YAML and others as JSON. Settings missing from the
+// file keep their defaults.
+func ParseOutputConfig(data []byte, ext string) (OutputConfig, error) {
+	config := DefaultOutputConfig()
+
+	switch strings.ToLower(ext) {
+	case ".yaml", ".yml":
+		// YAML is converted to JSON so both formats share the field names
+		var document interface{}
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return config, fmt.Errorf("invalid log config: %v", err)
+		}
+		converted, err := json.Marshal(document)
+		if err != nil {
+			return config, fmt.Errorf("invalid log config: %v", err)
+		}
+		data = converted
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("invalid log config: %v", err)
+	}
+	return config, nil
+}
+
+// getEnvAsInt reads a non-negative integer environment variable, reporting whether it was set
+func getEnvAsInt(key string) (int, bool) {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value < 0 {
+		return 0, false
+	}
+	return value, true
+}
+
+// outputs holds the writers of the log and audit entries and the files behind them
+var outputs = struct {
+	mu    sync.Mutex
+	log   io.Writer
+	audit io.Writer
+	files []*RotatingFile
+}{log: os.Stdout, audit: os.Stdout}
+
+// ConfigureOutput opens the configured files and writes log and audit entries to them from now
+// on. Previously opened files are closed. Nothing changes when a file cannot be opened.
+func ConfigureOutput(config OutputConfig) error {
+	var files []*RotatingFile
+	closeAll := func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}
+
+	var log io.Writer = os.Stdout
+	if config.File.Path != "" {
+		file, err := OpenRotatingFile(config.File)
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
+		log = file
+		if config.Stdout {
+			log = io.MultiWriter(os.Stdout, file)
+		}
+	}
+
+	audit := log
+	if config.Audit.Path != "" {
+		file, err := OpenRotatingFile(config.Audit)
+		if err != nil {
+			closeAll()
+			return err
+		}
+		files = append(files, file)
+		audit = file
+	}
+
+	outputs.mu.Lock()
+	previous := outputs.files
+	outputs.log, outputs.audit, outputs.files = log, audit, files
+	outputs.mu.Unlock()
+
+	logrus.SetOutput(log)
+	// The zap backend binds its output when it is built
+	current.mutex.RLock()
+	backendName := current.backendName
+	current.mutex.RUnlock()
+	if backendName == BackendZap {
+		if err := SetBackend(BackendZap); err != nil {
+			logrus.WithError(err).Warn("Failed to move the zap backend to the new log output")
+		}
+	}
+
+	for _, file := range previous {
+		file.Close()
+	}
+	return nil
+}
+
+// Output returns the writer log entries are written to
+func Output() io.Writer {
+	outputs.mu.Lock()
+	defer outputs.mu.Unlock()
+	return outputs.log
+}
+
+// Audit writes an entry to the audit log stream, whatever the log levels are. Use it for
+// changes made through the API that operators must be able to trace, with the actor in fields.
+func Audit(action string, fields Fields) {
+	entry := make(map[string]interface{}, len(fields)+3)
+	for key, value := range fields {
+		entry[key] = value
+	}
+	entry["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	entry["audit"] = true
+	entry["action"] = action
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		line, _ = json.Marshal(map[string]interface{}{
+			"time":   entry["time"],
+			"audit":  true,
+			"action": action,
+			"error":  "failed to encode audit fields: " + err.Error(),
+		})
+	}
+
+	outputs.mu.Lock()
+	defer outputs.mu.Unlock()
+	if _, err := outputs.audit.Write(append(line, '\n')); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write audit entry %s: %v\n", action, err)
+	}
+}
+
+// Close flushes the backend and closes the log files; entries logged afterwards go to standard output
+func Close() error {
+	err := Sync()
+
+	outputs.mu.Lock()
+	files := outputs.files
+	outputs.log, outputs.audit, outputs.files = os.Stdout, os.Stdout, nil
+	outputs.mu.Unlock()
+
+	logrus.SetOutput(os.Stdout)
+	for _, file := range files {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/constants"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOutputConfig(t *testing.T) {
+	config, err := ParseOutputConfig([]byte(`
+stdout: false
+file:
+  path: /var/log/notification-service/service.log
+  max_size_mb: 50
+audit:
+  path: /var/log/notification-service/audit.log
+  rotate_hours: 168
+  compress: false
+`), ".yaml")
+	require.NoError(t, err)
+
+	assert.False(t, config.Stdout)
+	assert.Equal(t, FileConfig{
+		Path:        "/var/log/notification-service/service.log",
+		MaxSizeMB:   50,
+		RotateHours: constants.DefaultLogFileRotateHours,
+		MaxBackups:  constants.DefaultLogFileMaxBackups,
+		Compress:    true,
+	}, config.File, "missing settings keep their defaults")
+	assert.Equal(t, 168, config.Audit.RotateHours)
+	assert.False(t, config.Audit.Compress)
+
+	_, err = ParseOutputConfig([]byte(`{"file": "service.log"}`), ".json")
+	assert.Error(t, err)
+}
+
+func TestLoadOutputConfig_EnvironmentOverridesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logging.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"file": {"path": "from-file.log", "max_backups": 3}}`), 0o644))
+	t.Setenv(constants.LogConfigFileEnvVar, path)
+	t.Setenv(constants.LogFileEnvVar, "from-env.log")
+	t.Setenv(constants.LogFileMaxSizeMBEnvVar, "0")
+	t.Setenv(constants.LogStdoutEnvVar, "false")
+
+	config, err := LoadOutputConfig()
+	require.NoError(t, err)
+	assert.False(t, config.Stdout)
+	assert.Equal(t, "from-env.log", config.File.Path)
+	assert.Equal(t, 3, config.File.MaxBackups)
+	assert.Zero(t, config.File.MaxSizeMB, "0 disables size rotation")
+	assert.Zero(t, config.Audit.MaxSizeMB, "rotation settings of the environment apply to the audit log")
+
+	t.Setenv(constants.LogConfigFileEnvVar, filepath.Join(t.TempDir(), "missing.yaml"))
+	_, err = LoadOutputConfig()
+	assert.Error(t, err)
+}
+
+func TestConfigureOutput_WritesLogAndAuditFiles(t *testing.T) {
+	dir := t.TempDir()
+	previousLevel := logrus.GetLevel()
+	defer logrus.SetLevel(previousLevel)
+	defer Close()
+
+	require.NoError(t, ConfigureOutput(OutputConfig{
+		File:  FileConfig{Path: filepath.Join(dir, "service.log")},
+		Audit: FileConfig{Path: filepath.Join(dir, "audit.log")},
+	}))
+
+	// Audit entries are written whatever the level is
+	logrus.SetLevel(logrus.ErrorLevel)
+	logrus.Error("provider unavailable")
+	Audit("logging.updated", Fields{"actor": "ops", "level": "debug"})
+	require.NoError(t, Close())
+
+	logContent, err := os.ReadFile(filepath.Join(dir, "service.log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(logContent), "provider unavailable")
+	assert.NotContains(t, string(logContent), "logging.updated")
+
+	auditContent, err := os.ReadFile(filepath.Join(dir, "audit.log"))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(auditContent)), "\n")
+	require.Len(t, lines, 1)
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "logging.updated", entry["action"])
+	assert.Equal(t, "ops", entry["actor"])
+	assert.Equal(t, true, entry["audit"])
+	assert.NotEmpty(t, entry["time"])
+
+	assert.Equal(t, os.Stdout, Output(), "closing returns to stdout")
+}
+
+func TestConfigureOutput_KeepsOutputWhenFileCannotBeOpened(t *testing.T) {
+	blocker := filepath.Join(t.TempDir(), "not-a-directory")
+	require.NoError(t, os.WriteFile(blocker, nil, 0o644))
+
+	err := ConfigureOutput(OutputConfig{File: FileConfig{Path: filepath.Join(blocker, "service.log")}})
+	assert.Error(t, err)
+	assert.Equal(t, os.Stdout, Output())
+}
@@ -0,0 +1,261 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+)
+
+// backupTimeFormat is the timestamp in the names of rotated log files, sortable by name
+const backupTimeFormat = "20060102T150405.000"
+
+// FileConfig configures a log file and its rotation
+type FileConfig struct {
+	// Path of the log file; empty disables the file
+	Path string `json:"path"`
+
+	// MaxSizeMB rotates the file before it grows beyond this size; 0 disables size rotation
+	MaxSizeMB int `json:"max_size_mb"`
+
+	// RotateHours rotates the file once it is this old; 0 disables time rotation
+	RotateHours int `json:"rotate_hours"`
+
+	// MaxBackups is the number of rotated files kept; 0 keeps all of them
+	MaxBackups int `json:"max_backups"`
+
+	// Compress gzips rotated files
+	Compress bool `json:"compress"`
+}
+
+// RotatingFile is a log file that is rotated by size and age. Rotated files are renamed to
+// <name>-<UTC timestamp><ext>, optionally gzipped, and the oldest are removed beyond MaxBackups.
+type RotatingFile struct {
+	mu       sync.Mutex
+	config   FileConfig
+	clock    clock.Clock
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	// Compressing and pruning rotated files runs in the background, one rotation at a time
+	millMu sync.Mutex
+	milled sync.WaitGroup
+}
+
+// OpenRotatingFile opens the log file for appending, creating it and its directory if needed
+func OpenRotatingFile(config FileConfig) (*RotatingFile, error) {
+	return openRotatingFile(config, clock.Real())
+}
+
+// openRotatingFile opens the log file with the given clock
+func openRotatingFile(config FileConfig, c clock.Clock) (*RotatingFile, error) {
+	if config.Path == "" {
+		return nil, fmt.Errorf("log file path is required")
+	}
+
+	f := &RotatingFile{config: config, clock: c}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// open opens the log file; the caller must hold the lock unless the file is not shared yet
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.config.Path), 0o755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(f.config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = f.clock.Now()
+	return nil
+}
+
+// Write appends p to the log file, rotating it first when p does not fit or the file is too old
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.size > 0 && f.due(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// due reports whether the file must be rotated before writing n more bytes
+func (f *RotatingFile) due(n int64) bool {
+	if f.config.MaxSizeMB > 0 && f.size+n > int64(f.config.MaxSizeMB)<<20 {
+		return true
+	}
+	if f.config.RotateHours > 0 && f.clock.Now().Sub(f.openedAt) >= time.Duration(f.config.RotateHours)*time.Hour {
+		return true
+	}
+	return false
+}
+
+// Rotate moves the current log file aside and starts a new one
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// rotate moves the current log file aside; the caller must hold the lock
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	f.file = nil
+
+	backup := f.backupName(f.clock.Now())
+	if err := os.Rename(f.config.Path, backup); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	f.milled.Add(1)
+	go f.mill(backup)
+	return nil
+}
+
+// backupName returns the name a log file rotated at t is renamed to
+func (f *RotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(f.config.Path)
+	base := strings.TrimSuffix(f.config.Path, ext)
+	return base + "-" + t.UTC().Format(backupTimeFormat) + ext
+}
+
+// mill compresses a rotated file if configured and removes the oldest rotated files
+func (f *RotatingFile) mill(backup string) {
+	defer f.milled.Done()
+	f.millMu.Lock()
+	defer f.millMu.Unlock()
+
+	if f.config.Compress {
+		if err := compressFile(backup); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to compress rotated log file %s: %v\n", backup, err)
+		}
+	}
+	if f.config.MaxBackups > 0 {
+		backups, err := f.backups()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to list rotated log files: %v\n", err)
+			return
+		}
+		for len(backups) > f.config.MaxBackups {
+			if err := os.Remove(backups[0]); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to remove rotated log file %s: %v\n", backups[0], err)
+			}
+			backups = backups[1:]
+		}
+	}
+}
+
+// backups returns the rotated files of the log file, oldest first
+func (f *RotatingFile) backups() ([]string, error) {
+	ext := filepath.Ext(f.config.Path)
+	prefix := filepath.Base(strings.TrimSuffix(f.config.Path, ext)) + "-"
+
+	entries, err := os.ReadDir(filepath.Dir(f.config.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz"), ext)
+		if _, err := time.Parse(backupTimeFormat, stamp); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(filepath.Dir(f.config.Path), name))
+	}
+
+	// The timestamps sort by name, whether or not the file was compressed
+	sort.Slice(backups, func(i, j int) bool {
+		return strings.TrimSuffix(backups[i], ".gz") < strings.TrimSuffix(backups[j], ".gz")
+	})
+	return backups, nil
+}
+
+// compressFile gzips a file to <path>.gz and removes the original
+func compressFile(path string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	target, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	writer := gzip.NewWriter(target)
+	if _, err := io.Copy(writer, source); err != nil {
+		target.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		target.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := target.Close(); err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+// Close closes the log file once rotated files are compressed and pruned
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.mu.Unlock()
+
+	f.milled.Wait()
+	return err
+}
@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logFiles returns the names of the files in dir
+func logFiles(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestRotatingFile_RotatesBySize(t *testing.T) {
+	dir := t.TempDir()
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	file, err := openRotatingFile(FileConfig{Path: filepath.Join(dir, "service.log"), MaxSizeMB: 1}, fakeClock)
+	require.NoError(t, err)
+
+	line := []byte(strings.Repeat("a", 600<<10) + "\n")
+	_, err = file.Write(line)
+	require.NoError(t, err)
+	_, err = file.Write(line)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	assert.ElementsMatch(t, []string{"service.log", "service-20240101T090000.000.log"}, logFiles(t, dir))
+	current, err := os.ReadFile(filepath.Join(dir, "service.log"))
+	require.NoError(t, err)
+	assert.Equal(t, line, current, "the entry that did not fit starts the new file")
+}
+
+func TestRotatingFile_RotatesByAgeAndCompresses(t *testing.T) {
+	dir := t.TempDir()
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	file, err := openRotatingFile(FileConfig{
+		Path:        filepath.Join(dir, "service.log"),
+		RotateHours: 24,
+		MaxBackups:  2,
+		Compress:    true,
+	}, fakeClock)
+	require.NoError(t, err)
+
+	for day := 1; day <= 4; day++ {
+		_, err := file.Write([]byte(fmt.Sprintf("day %d\n", day)))
+		require.NoError(t, err)
+		fakeClock.Advance(12 * time.Hour)
+		_, err = file.Write([]byte(fmt.Sprintf("still day %d\n", day)))
+		require.NoError(t, err)
+		fakeClock.Advance(12 * time.Hour)
+	}
+	require.NoError(t, file.Close())
+
+	// Four days were written, the oldest rotated file was removed
+	assert.ElementsMatch(t, []string{
+		"service.log",
+		"service-20240103T090000.000.log.gz",
+		"service-20240104T090000.000.log.gz",
+	}, logFiles(t, dir))
+
+	compressed, err := os.Open(filepath.Join(dir, "service-20240104T090000.000.log.gz"))
+	require.NoError(t, err)
+	defer compressed.Close()
+	reader, err := gzip.NewReader(compressed)
+	require.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "day 3\nstill day 3\n", string(content))
+}
+
+func TestRotatingFile_AppendsToExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "service.log")
+	for _, entry := range []string{"first\n", "second\n"} {
+		file, err := OpenRotatingFile(FileConfig{Path: path})
+		require.NoError(t, err)
+		_, err = file.Write([]byte(entry))
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "first\nsecond\n", string(content))
+}
@@ -0,0 +1,465 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gaurav2721/notification-service/constants"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Supported backends
+const (
+	BackendLogrus = "logrus"
+	BackendZap    = "zap"
+)
+
+// Fields holds structured log fields
+type Fields map[string]interface{}
+
+// Level is the severity of a log entry
+type Level int8
+
+// Log levels, from most to least verbose
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+// String returns the lower-case name of the level
+func (l Level) String() string {
+	switch l {
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarnLevel:
+		return "warn"
+	case ErrorLevel:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", l)
+	}
+}
+
+// ParseLevel parses a level name
+func ParseLevel(level string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return DebugLevel, nil
+	case "info":
+		return InfoLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	default:
+		return InfoLevel, fmt.Errorf("invalid log level: %s", level)
+	}
+}
+
+// Backend writes log entries that passed level and sampling checks
+type Backend interface {
+	Log(level Level, module string, msg string, fields Fields)
+	Sync() error
+}
+
+// logrusBackend writes entries through the standard logrus logger
+type logrusBackend struct{}
+
+// Log writes the entry with logrus.
+// A module may be more verbose than the global logrus level, in which case the entry is written through a logger with every level enabled.
+func (logrusBackend) Log(level Level, module string, msg string, fields Fields) {
+	logrusLevel := toLogrusLevel(level)
+
+	base := logrus.StandardLogger()
+	if !base.IsLevelEnabled(logrusLevel) {
+		base = logrusBypass()
+	}
+
+	base.WithFields(logrus.Fields(fields)).WithField("module", module).Log(logrusLevel, msg)
+}
+
+// Sync is a no-op for logrus
+func (logrusBackend) Sync() error {
+	return nil
+}
+
+var (
+	bypassOnce   sync.Once
+	bypassLogger *logrus.Logger
+)
+
+// logrusBypass returns a logrus logger sharing the standard output and formatter with every level enabled
+func logrusBypass() *logrus.Logger {
+	bypassOnce.Do(func() {
+		bypassLogger = logrus.New()
+		bypassLogger.SetLevel(logrus.TraceLevel)
+	})
+	std := logrus.StandardLogger()
+	bypassLogger.SetOutput(std.Out)
+	bypassLogger.SetFormatter(std.Formatter)
+	return bypassLogger
+}
+
+// toLogrusLevel converts a level to its logrus counterpart
+func toLogrusLevel(level Level) logrus.Level {
+	switch level {
+	case DebugLevel:
+		return logrus.DebugLevel
+	case WarnLevel:
+		return logrus.WarnLevel
+	case ErrorLevel:
+		return logrus.ErrorLevel
+	default:
+		return logrus.InfoLevel
+	}
+}
+
+// zapBackend writes entries as JSON with zap
+type zapBackend struct {
+	logger *zap.Logger
+}
+
+// newZapBackend creates a zap backend writing JSON to the log output
+func newZapBackend() (*zapBackend, error) {
+	config := zap.NewProductionConfig()
+	// Gating happens per module, so zap itself accepts everything and never samples on its own
+	config.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	config.Sampling = nil
+	config.EncoderConfig.TimeKey = "time"
+	config.EncoderConfig.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+
+	output := zapcore.AddSync(Output())
+	logger, err := config.Build(zap.AddCallerSkip(3), zap.WrapCore(func(zapcore.Core) zapcore.Core {
+		return zapcore.NewCore(zapcore.NewJSONEncoder(config.EncoderConfig), output, zapcore.DebugLevel)
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return &zapBackend{logger: logger}, nil
+}
+
+// Log writes the entry with zap
+func (b *zapBackend) Log(level Level, module string, msg string, fields Fields) {
+	zapFields := make([]zap.Field, 0, len(fields)+1)
+	zapFields = append(zapFields, zap.String("module", module))
+	for key, value := range fields {
+		zapFields = append(zapFields, zap.Any(key, value))
+	}
+
+	switch level {
+	case DebugLevel:
+		b.logger.Debug(msg, zapFields...)
+	case WarnLevel:
+		b.logger.Warn(msg, zapFields...)
+	case ErrorLevel:
+		b.logger.Error(msg, zapFields...)
+	default:
+		b.logger.Info(msg, zapFields...)
+	}
+}
+
+// Sync flushes buffered zap output
+func (b *zapBackend) Sync() error {
+	return b.logger.Sync()
+}
+
+// Settings is a snapshot of the runtime logging configuration
+type Settings struct {
+	Backend      string            `json:"backend"`
+	Level        string            `json:"level"`
+	ModuleLevels map[string]string `json:"module_levels"`
+	SampleEvery  int               `json:"sample_every"`
+}
+
+// state holds the runtime logging configuration shared by all module loggers
+type state struct {
+	mutex        sync.RWMutex
+	backend      Backend
+	backendName  string
+	defaultLevel Level
+	moduleLevels map[string]Level
+	sampleEvery  int
+	counters     sync.Map // module|msg -> *uint64
+}
+
+var current = &state{
+	backend:      logrusBackend{},
+	backendName:  BackendLogrus,
+	defaultLevel: InfoLevel,
+	moduleLevels: make(map[string]Level),
+	sampleEvery:  constants.DefaultLogSampleEvery,
+}
+
+// configureStructured applies LOG_BACKEND, LOG_MODULE_LEVELS and LOG_SAMPLE_EVERY
+func configureStructured(defaultLevel Level) {
+	SetDefaultLevel(defaultLevel)
+
+	if backend := os.Getenv(constants.LOG_BACKEND); backend != "" {
+		if err := SetBackend(backend); err != nil {
+			logrus.WithError(err).Warn("Failed to configure log backend, using logrus")
+		}
+	}
+
+	if moduleLevels := os.Getenv(constants.LOG_MODULE_LEVELS); moduleLevels != "" {
+		for _, pair := range strings.Split(moduleLevels, ",") {
+			module, levelName, found := strings.Cut(strings.TrimSpace(pair), "=")
+			if !found {
+				continue
+			}
+			level, err := ParseLevel(levelName)
+			if err != nil {
+				logrus.WithField("module", module).WithError(err).Warn("Ignoring invalid module log level")
+				continue
+			}
+			SetModuleLevel(strings.TrimSpace(module), level)
+		}
+	}
+
+	if sampleEvery := os.Getenv(constants.LOG_SAMPLE_EVERY); sampleEvery != "" {
+		if n, err := strconv.Atoi(sampleEvery); err == nil {
+			SetSampleEvery(n)
+		}
+	}
+}
+
+// SetBackend switches the backend used by module loggers ("logrus" or "zap")
+func SetBackend(name string) error {
+	var backend Backend
+	switch strings.ToLower(name) {
+	case BackendLogrus:
+		backend = logrusBackend{}
+	case BackendZap:
+		zapBackend, err := newZapBackend()
+		if err != nil {
+			return err
+		}
+		backend = zapBackend
+	default:
+		return fmt.Errorf("unsupported log backend: %s", name)
+	}
+
+	current.mutex.Lock()
+	previous := current.backend
+	current.backend = backend
+	current.backendName = strings.ToLower(name)
+	current.mutex.Unlock()
+
+	_ = previous.Sync()
+	return nil
+}
+
+// SetDefaultLevel sets the level of modules without an override, and of plain logrus calls
+func SetDefaultLevel(level Level) {
+	current.mutex.Lock()
+	current.defaultLevel = level
+	current.mutex.Unlock()
+
+	logrus.SetLevel(toLogrusLevel(level))
+}
+
+// SetModuleLevel overrides the level of a single module
+func SetModuleLevel(module string, level Level) {
+	current.mutex.Lock()
+	defer current.mutex.Unlock()
+	current.moduleLevels[module] = level
+}
+
+// ResetModuleLevel removes the override of a module so it follows the default level again
+func ResetModuleLevel(module string) {
+	current.mutex.Lock()
+	defer current.mutex.Unlock()
+	delete(current.moduleLevels, module)
+}
+
+// SetSampleEvery sets how many occurrences of a sampled event produce one log entry.
+// Values below 1 disable sampling.
+func SetSampleEvery(n int) {
+	if n < 1 {
+		n = 1
+	}
+	current.mutex.Lock()
+	defer current.mutex.Unlock()
+	current.sampleEvery = n
+}
+
+// GetSettings returns a snapshot of the runtime logging configuration
+func GetSettings() Settings {
+	current.mutex.RLock()
+	defer current.mutex.RUnlock()
+
+	moduleLevels := make(map[string]string, len(current.moduleLevels))
+	for module, level := range current.moduleLevels {
+		moduleLevels[module] = level.String()
+	}
+
+	return Settings{
+		Backend:      current.backendName,
+		Level:        current.defaultLevel.String(),
+		ModuleLevels: moduleLevels,
+		SampleEvery:  current.sampleEvery,
+	}
+}
+
+// Sync flushes the active backend
+func Sync() error {
+	current.mutex.RLock()
+	backend := current.backend
+	current.mutex.RUnlock()
+	return backend.Sync()
+}
+
+// ModuleLogger logs on behalf of a module with its own level
+type ModuleLogger struct {
+	name    string
+	sampled bool
+}
+
+// Module returns the logger of a module, e.g. "consumers" or "notification_manager"
+func Module(name string) *ModuleLogger {
+	return &ModuleLogger{name: name}
+}
+
+// Sampled returns a logger that only writes one out of every LOG_SAMPLE_EVERY debug and info
+// entries per message. Warnings and errors are always written. Use it for events logged per
+// recipient or per send.
+func (m *ModuleLogger) Sampled() *ModuleLogger {
+	return &ModuleLogger{name: m.name, sampled: true}
+}
+
+// Enabled checks whether entries at the given level are written for this module
+func (m *ModuleLogger) Enabled(level Level) bool {
+	current.mutex.RLock()
+	defer current.mutex.RUnlock()
+	return level >= m.levelLocked()
+}
+
+// levelLocked returns the effective level of the module; the caller must hold the state lock
+func (m *ModuleLogger) levelLocked() Level {
+	if level, ok := current.moduleLevels[m.name]; ok {
+		return level
+	}
+	return current.defaultLevel
+}
+
+// Debug logs at debug level
+func (m *ModuleLogger) Debug(msg string, fields Fields) {
+	m.log(DebugLevel, msg, fields)
+}
+
+// Info logs at info level
+func (m *ModuleLogger) Info(msg string, fields Fields) {
+	m.log(InfoLevel, msg, fields)
+}
+
+// Warn logs at warn level
+func (m *ModuleLogger) Warn(msg string, fields Fields) {
+	m.log(WarnLevel, msg, fields)
+}
+
+// Error logs at error level
+func (m *ModuleLogger) Error(msg string, fields Fields) {
+	m.log(ErrorLevel, msg, fields)
+}
+
+// log applies the module level and sampling and hands the entry to the backend
+func (m *ModuleLogger) log(level Level, msg string, fields Fields) {
+	current.mutex.RLock()
+	enabled := level >= m.levelLocked()
+	backend := current.backend
+	sampleEvery := current.sampleEvery
+	current.mutex.RUnlock()
+
+	if !enabled {
+		return
+	}
+
+	// Failures are never dropped, however often they happen
+	if m.sampled && level <= InfoLevel && sampleEvery > 1 {
+		counter, _ := current.counters.LoadOrStore(m.name+"|"+msg, new(uint64))
+		occurrence := atomic.AddUint64(counter.(*uint64), 1)
+		if (occurrence-1)%uint64(sampleEvery) != 0 {
+			return
+		}
+
+		sampledFields := make(Fields, len(fields)+2)
+		for key, value := range fields {
+			sampledFields[key] = value
+		}
+		sampledFields["sample_every"] = sampleEvery
+		sampledFields["occurrences"] = occurrence
+		fields = sampledFields
+	}
+
+	backend.Log(level, m.name, msg, fields)
+}
+
+// SettingsUpdate is a partial change of the runtime logging configuration.
+// Nil fields are left unchanged; an empty module level removes the module override.
+type SettingsUpdate struct {
+	Backend      *string           `json:"backend,omitempty"`
+	Level        *string           `json:"level,omitempty"`
+	ModuleLevels map[string]string `json:"module_levels,omitempty"`
+	SampleEvery  *int              `json:"sample_every,omitempty"`
+}
+
+// Apply validates the update as a whole and then applies it, so an invalid update changes nothing
+func Apply(update SettingsUpdate) error {
+	var defaultLevel Level
+	if update.Level != nil {
+		level, err := ParseLevel(*update.Level)
+		if err != nil {
+			return err
+		}
+		defaultLevel = level
+	}
+
+	moduleLevels := make(map[string]Level, len(update.ModuleLevels))
+	for module, levelName := range update.ModuleLevels {
+		if strings.TrimSpace(module) == "" {
+			return fmt.Errorf("module name is required")
+		}
+		if levelName == "" {
+			continue
+		}
+		level, err := ParseLevel(levelName)
+		if err != nil {
+			return fmt.Errorf("module %s: %w", module, err)
+		}
+		moduleLevels[module] = level
+	}
+
+	if update.SampleEvery != nil && *update.SampleEvery < 1 {
+		return fmt.Errorf("sample_every must be at least 1")
+	}
+
+	if update.Backend != nil {
+		if err := SetBackend(*update.Backend); err != nil {
+			return err
+		}
+	}
+	if update.Level != nil {
+		SetDefaultLevel(defaultLevel)
+	}
+	for module, levelName := range update.ModuleLevels {
+		if levelName == "" {
+			ResetModuleLevel(module)
+			continue
+		}
+		SetModuleLevel(module, moduleLevels[module])
+	}
+	if update.SampleEvery != nil {
+		SetSampleEvery(*update.SampleEvery)
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedEntry struct {
+	level  Level
+	module string
+	msg    string
+	fields Fields
+}
+
+type recordingBackend struct {
+	mutex   sync.Mutex
+	entries []recordedEntry
+}
+
+func (b *recordingBackend) Log(level Level, module string, msg string, fields Fields) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.entries = append(b.entries, recordedEntry{level: level, module: module, msg: msg, fields: fields})
+}
+
+func (b *recordingBackend) Sync() error {
+	return nil
+}
+
+// useRecordingBackend resets the logging state and captures entries for the duration of the test
+func useRecordingBackend(t *testing.T) *recordingBackend {
+	t.Helper()
+
+	backend := &recordingBackend{}
+	previous := current
+	current = &state{
+		backend:      backend,
+		backendName:  "recording",
+		defaultLevel: InfoLevel,
+		moduleLevels: make(map[string]Level),
+		sampleEvery:  1,
+	}
+	previousLevel := logrus.GetLevel()
+	t.Cleanup(func() {
+		current = previous
+		logrus.SetLevel(previousLevel)
+	})
+
+	return backend
+}
+
+func TestModuleLevels(t *testing.T) {
+	backend := useRecordingBackend(t)
+	SetModuleLevel("consumers", WarnLevel)
+	SetModuleLevel("notification_manager", DebugLevel)
+
+	Module("consumers").Info("dropped", nil)
+	Module("consumers").Warn("kept", nil)
+	Module("notification_manager").Debug("kept", nil)
+	Module("handlers").Debug("dropped", nil)
+	Module("handlers").Info("kept", Fields{"id": "n-1"})
+
+	require.Len(t, backend.entries, 3)
+	assert.Equal(t, "consumers", backend.entries[0].module)
+	assert.Equal(t, WarnLevel, backend.entries[0].level)
+	assert.Equal(t, "notification_manager", backend.entries[1].module)
+	assert.Equal(t, Fields{"id": "n-1"}, backend.entries[2].fields)
+
+	ResetModuleLevel("consumers")
+	assert.True(t, Module("consumers").Enabled(InfoLevel))
+}
+
+func TestSampledLogger(t *testing.T) {
+	backend := useRecordingBackend(t)
+	SetSampleEvery(10)
+
+	log := Module("consumers").Sampled()
+	for i := 0; i < 25; i++ {
+		log.Info("Email notification sent successfully", Fields{"i": i})
+	}
+	log.Info("Slack notification sent successfully", nil)
+
+	require.Len(t, backend.entries, 4)
+	assert.Equal(t, 0, backend.entries[0].fields["i"])
+	assert.Equal(t, 10, backend.entries[1].fields["i"])
+	assert.Equal(t, 20, backend.entries[2].fields["i"])
+	assert.Equal(t, uint64(21), backend.entries[2].fields["occurrences"])
+	assert.Equal(t, 10, backend.entries[2].fields["sample_every"])
+	assert.Equal(t, "Slack notification sent successfully", backend.entries[3].msg)
+
+	// Unsampled loggers of the same module are not affected
+	Module("consumers").Info("Email notification sent successfully", nil)
+	assert.Len(t, backend.entries, 5)
+
+	// Warnings and errors are never sampled
+	for i := 0; i < 3; i++ {
+		log.Warn("User has no email address", nil)
+		log.Error("Failed to send email notification", nil)
+	}
+	require.Len(t, backend.entries, 11)
+	assert.Nil(t, backend.entries[10].fields["sample_every"])
+}
+
+func TestApply(t *testing.T) {
+	useRecordingBackend(t)
+	SetModuleLevel("consumers", DebugLevel)
+
+	level := "warn"
+	sampleEvery := 50
+	require.NoError(t, Apply(SettingsUpdate{
+		Level:        &level,
+		ModuleLevels: map[string]string{"notification_manager": "debug", "consumers": ""},
+		SampleEvery:  &sampleEvery,
+	}))
+
+	settings := GetSettings()
+	assert.Equal(t, "warn", settings.Level)
+	assert.Equal(t, map[string]string{"notification_manager": "debug"}, settings.ModuleLevels)
+	assert.Equal(t, 50, settings.SampleEvery)
+
+	invalid := "verbose"
+	zero := 0
+	assert.Error(t, Apply(SettingsUpdate{Level: &invalid}))
+	assert.Error(t, Apply(SettingsUpdate{ModuleLevels: map[string]string{"consumers": "loud"}}))
+	assert.Error(t, Apply(SettingsUpdate{SampleEvery: &zero, Level: &level}))
+
+	backend := "syslog"
+	assert.Error(t, Apply(SettingsUpdate{Backend: &backend}))
+	assert.Equal(t, settings, GetSettings())
+}
+
+func TestSetBackend(t *testing.T) {
+	useRecordingBackend(t)
+
+	require.NoError(t, SetBackend(BackendZap))
+	assert.Equal(t, BackendZap, GetSettings().Backend)
+	Module("consumers").Info("written by zap", Fields{"notification_id": "n-1"})
+
+	require.NoError(t, SetBackend(BackendLogrus))
+	assert.Equal(t, BackendLogrus, GetSettings().Backend)
+}
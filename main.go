@@ -2,12 +2,19 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/gaurav2721/notification-service/constants"
 	"github.com/gaurav2721/notification-service/handlers"
+	"github.com/gaurav2721/notification-service/health"
 	"github.com/gaurav2721/notification-service/logger"
 	"github.com/gaurav2721/notification-service/routes"
 	"github.com/gaurav2721/notification-service/services"
@@ -17,9 +24,11 @@ import (
 )
 
 func main() {
+	selfTest := flag.Bool("selftest", false, "validate the configuration, connect to every provider, send a test message to SELFTEST_SINK and exit")
+	flag.Parse()
 
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
+	// Load environment variables. Init containers and pipelines pass them without a .env file.
+	if err := godotenv.Load(); err != nil && !*selfTest {
 		logrus.Error("No .env file found, using system environment variables")
 		return
 	}
@@ -27,20 +36,15 @@ func main() {
 	// Configure logging
 	logger.Configure()
 
-	// Initialize service container (manages all service dependencies)
-	serviceContainer := services.NewServiceContainer()
-
-	// Initialize handlers with required dependencies
-	notificationHandler := handlers.NewNotificationHandler(serviceContainer.GetNotificationService())
-	userHandler := handlers.NewUserHandler(serviceContainer.GetUserService())
-	logrus.Debug("Handlers initialized successfully")
-
-	// Setup Gin router
-	router := gin.Default()
+	if *selfTest {
+		os.Exit(runSelfTest())
+	}
 
-	// Setup all routes using the routes package
-	routes.SetupRoutes(router, notificationHandler, userHandler)
-	logrus.Debug("Routes configured successfully")
+	// Production runs gin in release mode, without debug route logging
+	if services.IsProduction() {
+		gin.SetMode(gin.ReleaseMode)
+		logrus.Info("Running in production mode")
+	}
 
 	// Get port from environment or use default
 	port := os.Getenv(constants.PORT)
@@ -62,18 +66,59 @@ func main() {
 		cancel()
 	}()
 
+	// Serve the Kubernetes probes while the services are initialized
+	probes := health.NewProbes()
+	healthHandler := handlers.NewHealthHandler(probes)
+	startupRouter := gin.New()
+	routes.SetupProbeRoutes(startupRouter, healthHandler)
+	serverHandler := &handlerSwitch{}
+	serverHandler.Set(startupRouter)
+	server := &http.Server{Addr: ":" + port, Handler: serverHandler}
+
 	// Start server in a goroutine
 	go func() {
 		logrus.WithField("port", port).Debug("Starting notification service")
-		if err := router.Run(":" + port); err != nil {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logrus.WithError(err).Error("Server error")
 			cancel()
 		}
 	}()
 
+	// Initialize service container (manages all service dependencies)
+	serviceContainer := services.NewServiceContainerWithProbes(probes)
+
+	// Initialize handlers with required dependencies
+	notificationHandler := handlers.NewNotificationHandler(serviceContainer.GetNotificationService())
+	userHandler := handlers.NewUserHandler(serviceContainer.GetUserService(), serviceContainer.GetNotificationService())
+	topicHandler := handlers.NewTopicHandler(serviceContainer.GetUserService(), serviceContainer.GetFCMService())
+	scimHandler := handlers.NewSCIMHandler(serviceContainer.GetUserService())
+	logrus.Debug("Handlers initialized successfully")
+
+	// Setup Gin router
+	router := gin.New()
+
+	// Setup all routes using the routes package
+	routes.SetupRoutes(router, notificationHandler, userHandler, topicHandler, scimHandler, healthHandler)
+	serverHandler.Set(router)
+	logrus.Debug("Routes configured successfully")
+
 	// Wait for shutdown signal
 	<-ctx.Done()
 
+	// Fail the readiness probe and give Kubernetes time to stop routing requests here
+	probes.MarkDraining()
+	if drain := shutdownDrainPeriod(); drain > 0 {
+		logrus.WithField("drain_period", drain.String()).Info("Draining before shutdown")
+		time.Sleep(drain)
+	}
+
+	// Finish the requests in flight before the consumers stop
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), constants.HTTPShutdownTimeoutSeconds*time.Second)
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logrus.WithError(err).Error("Error shutting down HTTP server")
+	}
+	cancelShutdown()
+
 	// Gracefully shutdown services
 	logrus.Debug("Initiating graceful shutdown of services")
 	if err := serviceContainer.Shutdown(context.Background()); err != nil {
@@ -81,4 +126,45 @@ func main() {
 	}
 
 	logrus.Debug("Notification service stopped gracefully")
+
+	// Flush and close the log files
+	if err := logger.Close(); err != nil {
+		logrus.WithError(err).Error("Failed to close log files")
+	}
+}
+
+// runSelfTest prints the self-test report and returns the exit code of the process
+func runSelfTest() int {
+	report := services.RunSelfTest(context.Background())
+	report.Print(os.Stdout)
+	logger.Close()
+
+	if !report.Passed() {
+		return 1
+	}
+	return 0
+}
+
+// handlerSwitch serves the probe routes while the services start and the full router afterwards
+type handlerSwitch struct {
+	handler atomic.Value
+}
+
+// Set replaces the handler of new requests
+func (s *handlerSwitch) Set(handler http.Handler) {
+	s.handler.Store(handler)
+}
+
+// ServeHTTP serves a request with the current handler
+func (s *handlerSwitch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.Load().(http.Handler).ServeHTTP(w, r)
+}
+
+// shutdownDrainPeriod returns how long the service keeps serving after failing the readiness probe
+func shutdownDrainPeriod() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv(constants.ShutdownDrainSecondsEnvVar))
+	if err != nil || seconds < 0 {
+		seconds = constants.DefaultShutdownDrainSeconds
+	}
+	return time.Duration(seconds) * time.Second
 }
@@ -0,0 +1,444 @@
+// Package markdown converts the Markdown subset used for email templates to HTML and plain text.
+//
+// Supported blocks are paragraphs, ATX headings (#), fenced code blocks (```), block quotes (>),
+// flat ordered and unordered lists and horizontal rules. Supported inline elements are
+// **strong**, *emphasis*, `code`, [links](https://example.com) and backslash escapes.
+// All text is HTML escaped and links are limited to http, https and mailto URLs, so rendering
+// user supplied template variables cannot inject markup.
+package markdown
+
+import (
+	"html"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type blockKind int
+
+const (
+	paragraphBlock blockKind = iota
+	headingBlock
+	codeBlock
+	quoteBlock
+	unorderedListBlock
+	orderedListBlock
+	ruleBlock
+)
+
+// block is a parsed block element. lines holds the paragraph lines, the heading text, the
+// code lines, the quoted source lines or one list item per line.
+type block struct {
+	kind  blockKind
+	level int
+	lines []string
+}
+
+// ToHTML renders Markdown source as HTML
+func ToHTML(src string) string {
+	var b strings.Builder
+	writeHTML(&b, parse(src))
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// ToText renders Markdown source as readable plain text without markup
+func ToText(src string) string {
+	var b strings.Builder
+	writeText(&b, parse(src))
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// inlineMarkup are the characters that start inline Markdown anywhere in a line
+const inlineMarkup = "\\`*_["
+
+// blockMarkup are the characters that start a Markdown block at the beginning of a line
+const blockMarkup = "#>-+"
+
+// Escape backslash-escapes the Markdown syntax in text, so it is rendered literally. Block
+// markers such as "# " or "1. " are only escaped at the start of a line.
+func Escape(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+
+	lineStart, number := true, false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if strings.IndexByte(inlineMarkup, c) >= 0 ||
+			lineStart && strings.IndexByte(blockMarkup, c) >= 0 ||
+			number && (c == '.' || c == ')') {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+
+		switch {
+		case c == '\n':
+			lineStart, number = true, false
+		case lineStart && (c == ' ' || c == '\t'):
+			// Indentation does not end the start of the line
+		case (lineStart || number) && c >= '0' && c <= '9':
+			lineStart, number = false, true
+		default:
+			lineStart, number = false, false
+		}
+	}
+	return b.String()
+}
+
+// parse splits Markdown source into blocks
+func parse(src string) []block {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+
+	var blocks []block
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			blocks = append(blocks, block{kind: paragraphBlock, lines: paragraph})
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flush()
+
+		case strings.HasPrefix(trimmed, "```"):
+			flush()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			blocks = append(blocks, block{kind: codeBlock, lines: code})
+
+		case headingLevel(trimmed) > 0:
+			flush()
+			level := headingLevel(trimmed)
+			text := strings.TrimSpace(strings.TrimRight(trimmed[level:], "#"))
+			blocks = append(blocks, block{kind: headingBlock, level: level, lines: []string{text}})
+
+		case isRule(trimmed):
+			flush()
+			blocks = append(blocks, block{kind: ruleBlock})
+
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				quoted = append(quoted, strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(lines[i]), ">"), " "))
+			}
+			i--
+			blocks = append(blocks, block{kind: quoteBlock, lines: quoted})
+
+		case listItem(trimmed, false) != "" || listItem(trimmed, true) != "":
+			flush()
+			ordered := listItem(trimmed, true) != ""
+			kind := unorderedListBlock
+			if ordered {
+				kind = orderedListBlock
+			}
+			var items []string
+			for ; i < len(lines); i++ {
+				item := listItem(strings.TrimSpace(lines[i]), ordered)
+				if item == "" {
+					break
+				}
+				items = append(items, item)
+			}
+			i--
+			blocks = append(blocks, block{kind: kind, lines: items})
+
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	flush()
+
+	return blocks
+}
+
+// headingLevel returns the level of an ATX heading line, or 0 if the line is not a heading
+func headingLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(line) && line[level] != ' ') {
+		return 0
+	}
+	return level
+}
+
+// isRule reports whether a line is a horizontal rule such as --- or ***
+func isRule(line string) bool {
+	compact := strings.ReplaceAll(line, " ", "")
+	if len(compact) < 3 {
+		return false
+	}
+	marker := compact[0]
+	if marker != '-' && marker != '*' && marker != '_' {
+		return false
+	}
+	return strings.Count(compact, string(marker)) == len(compact)
+}
+
+// listItem returns the text of a list item line, or "" if the line is not an item of that list type
+func listItem(line string, ordered bool) string {
+	if !ordered {
+		if len(line) > 2 && strings.ContainsRune("-*+", rune(line[0])) && line[1] == ' ' {
+			return strings.TrimSpace(line[2:])
+		}
+		return ""
+	}
+
+	digits := 0
+	for digits < len(line) && line[digits] >= '0' && line[digits] <= '9' {
+		digits++
+	}
+	if digits == 0 || digits > 9 || len(line) < digits+3 {
+		return ""
+	}
+	if (line[digits] != '.' && line[digits] != ')') || line[digits+1] != ' ' {
+		return ""
+	}
+	return strings.TrimSpace(line[digits+2:])
+}
+
+// writeHTML renders blocks as HTML
+func writeHTML(b *strings.Builder, blocks []block) {
+	for _, blk := range blocks {
+		switch blk.kind {
+		case paragraphBlock:
+			b.WriteString("<p>")
+			for i, line := range blk.lines {
+				if i > 0 {
+					if hardBreak(blk.lines[i-1]) {
+						b.WriteString("<br>")
+					}
+					b.WriteString("\n")
+				}
+				b.WriteString(inline(strings.TrimRight(strings.TrimSuffix(strings.TrimRight(line, " "), "\\"), " "), true))
+			}
+			b.WriteString("</p>\n")
+		case headingBlock:
+			tag := "h" + strconv.Itoa(blk.level)
+			b.WriteString("<" + tag + ">" + inline(blk.lines[0], true) + "</" + tag + ">\n")
+		case codeBlock:
+			b.WriteString("<pre><code>")
+			b.WriteString(html.EscapeString(strings.Join(blk.lines, "\n")))
+			b.WriteString("</code></pre>\n")
+		case quoteBlock:
+			b.WriteString("<blockquote>\n")
+			writeHTML(b, parse(strings.Join(blk.lines, "\n")))
+			b.WriteString("</blockquote>\n")
+		case unorderedListBlock, orderedListBlock:
+			tag := "ul"
+			if blk.kind == orderedListBlock {
+				tag = "ol"
+			}
+			b.WriteString("<" + tag + ">\n")
+			for _, item := range blk.lines {
+				b.WriteString("<li>" + inline(item, true) + "</li>\n")
+			}
+			b.WriteString("</" + tag + ">\n")
+		case ruleBlock:
+			b.WriteString("<hr>\n")
+		}
+	}
+}
+
+// writeText renders blocks as plain text with blank lines between blocks
+func writeText(b *strings.Builder, blocks []block) {
+	for i, blk := range blocks {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		switch blk.kind {
+		case paragraphBlock:
+			for _, line := range blk.lines {
+				b.WriteString(inline(strings.TrimRight(strings.TrimSuffix(strings.TrimRight(line, " "), "\\"), " "), false) + "\n")
+			}
+		case headingBlock:
+			b.WriteString(inline(blk.lines[0], false) + "\n")
+		case codeBlock:
+			for _, line := range blk.lines {
+				b.WriteString(line + "\n")
+			}
+		case quoteBlock:
+			var quoted strings.Builder
+			writeText(&quoted, parse(strings.Join(blk.lines, "\n")))
+			for _, line := range strings.Split(strings.TrimSuffix(quoted.String(), "\n"), "\n") {
+				b.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+			}
+		case unorderedListBlock:
+			for _, item := range blk.lines {
+				b.WriteString("- " + inline(item, false) + "\n")
+			}
+		case orderedListBlock:
+			for n, item := range blk.lines {
+				b.WriteString(strconv.Itoa(n+1) + ". " + inline(item, false) + "\n")
+			}
+		case ruleBlock:
+			b.WriteString("----\n")
+		}
+	}
+}
+
+// hardBreak reports whether a paragraph line ends with a hard line break (two spaces or a backslash)
+func hardBreak(line string) bool {
+	return strings.HasSuffix(line, "  ") || strings.HasSuffix(line, "\\")
+}
+
+// inline renders inline Markdown as HTML, or as plain text with the markup removed
+func inline(src string, asHTML bool) string {
+	var b strings.Builder
+	text := func(s string) {
+		if asHTML {
+			b.WriteString(html.EscapeString(s))
+		} else {
+			b.WriteString(s)
+		}
+	}
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\\' && i+1 < len(src) && isPunct(src[i+1]):
+			text(src[i+1 : i+2])
+			i += 2
+			continue
+
+		case c == '`':
+			if end := strings.IndexByte(src[i+1:], '`'); end >= 0 {
+				code := src[i+1 : i+1+end]
+				if asHTML {
+					b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				} else {
+					b.WriteString(code)
+				}
+				i += end + 2
+				continue
+			}
+
+		case c == '[':
+			if label, url, n, ok := parseLink(src[i:]); ok {
+				switch {
+				case !asHTML:
+					b.WriteString(inline(label, false))
+					if url != label {
+						b.WriteString(" (" + url + ")")
+					}
+				case safeURL(url):
+					b.WriteString(`<a href="` + html.EscapeString(url) + `">` + inline(label, true) + "</a>")
+				default:
+					b.WriteString(inline(label, true))
+				}
+				i += n
+				continue
+			}
+
+		case c == '*' || c == '_':
+			if inner, n, strong, ok := parseEmphasis(src, i); ok {
+				switch {
+				case !asHTML:
+					b.WriteString(inline(inner, false))
+				case strong:
+					b.WriteString("<strong>" + inline(inner, true) + "</strong>")
+				default:
+					b.WriteString("<em>" + inline(inner, true) + "</em>")
+				}
+				i += n
+				continue
+			}
+		}
+
+		_, size := utf8.DecodeRuneInString(src[i:])
+		text(src[i : i+size])
+		i += size
+	}
+
+	return b.String()
+}
+
+// parseLink parses [label](url) at the start of s and returns the number of bytes consumed.
+// Parentheses inside the URL must be balanced, as in [x](https://en.wikipedia.org/wiki/Go_(game)),
+// so the whole link is consumed.
+func parseLink(s string) (label, url string, n int, ok bool) {
+	closeLabel := strings.Index(s, "](")
+	if closeLabel < 0 {
+		return "", "", 0, false
+	}
+	closeURL := matchingParen(s[closeLabel+2:])
+	if closeURL < 0 {
+		return "", "", 0, false
+	}
+	label = s[1:closeLabel]
+	url = strings.TrimSpace(s[closeLabel+2 : closeLabel+2+closeURL])
+	if label == "" || url == "" || strings.ContainsAny(url, " \n") {
+		return "", "", 0, false
+	}
+	return label, url, closeLabel + 3 + closeURL, true
+}
+
+// matchingParen returns the index of the ')' closing a parenthesis opened just before s,
+// skipping nested pairs, or -1 when it is not closed
+func matchingParen(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// parseEmphasis parses *emphasis* or **strong** (or the _ variants) starting at src[i].
+// Underscores inside words, as in snake_case names, are not treated as emphasis.
+func parseEmphasis(src string, i int) (inner string, n int, strong bool, ok bool) {
+	marker := src[i : i+1]
+	if strings.HasPrefix(src[i:], marker+marker) {
+		marker += marker
+		strong = true
+	}
+	if marker[0] == '_' && i > 0 && isWordByte(src[i-1]) {
+		return "", 0, false, false
+	}
+
+	start := i + len(marker)
+	if start >= len(src) || src[start] == ' ' {
+		return "", 0, false, false
+	}
+	end := strings.Index(src[start:], marker)
+	if end <= 0 || src[start+end-1] == ' ' {
+		return "", 0, false, false
+	}
+	after := start + end + len(marker)
+	if marker[0] == '_' && after < len(src) && isWordByte(src[after]) {
+		return "", 0, false, false
+	}
+
+	return src[start : start+end], after - i, strong, true
+}
+
+// safeURL reports whether a link URL may be used in an href
+func safeURL(url string) bool {
+	lower := strings.ToLower(url)
+	return strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "mailto:")
+}
+
+func isPunct(c byte) bool {
+	return c < utf8.RuneSelf && unicode.IsPunct(rune(c)) || strings.IndexByte("`*_[]()#+-.!\\>", c) >= 0
+}
+
+func isWordByte(c byte) bool {
+	return c >= utf8.RuneSelf || c == '_' || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
@@ -0,0 +1,87 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const orderEmail = "# Order {{order_id}} confirmed\n\n" +
+	"Hi **Jane**, thanks for your order.  \n" +
+	"It ships *today*.\n\n" +
+	"- 2 x `SKU-1`\n" +
+	"- 1 x Gift card\n\n" +
+	"> Questions? [Contact us](https://example.com/help)\n\n" +
+	"---\n\n" +
+	"1. Track\n" +
+	"2. Enjoy"
+
+func TestToHTML(t *testing.T) {
+	expected := "<h1>Order {{order_id}} confirmed</h1>\n" +
+		"<p>Hi <strong>Jane</strong>, thanks for your order.<br>\n" +
+		"It ships <em>today</em>.</p>\n" +
+		"<ul>\n<li>2 x <code>SKU-1</code></li>\n<li>1 x Gift card</li>\n</ul>\n" +
+		"<blockquote>\n<p>Questions? <a href=\"https://example.com/help\">Contact us</a></p>\n</blockquote>\n" +
+		"<hr>\n" +
+		"<ol>\n<li>Track</li>\n<li>Enjoy</li>\n</ol>"
+	assert.Equal(t, expected, ToHTML(orderEmail))
+}
+
+func TestToText(t *testing.T) {
+	expected := "Order {{order_id}} confirmed\n\n" +
+		"Hi Jane, thanks for your order.\n" +
+		"It ships today.\n\n" +
+		"- 2 x SKU-1\n" +
+		"- 1 x Gift card\n\n" +
+		"> Questions? Contact us (https://example.com/help)\n\n" +
+		"----\n\n" +
+		"1. Track\n" +
+		"2. Enjoy"
+	assert.Equal(t, expected, ToText(orderEmail))
+}
+
+func TestToHTML_EscapesMarkup(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{"html is escaped", "Hello <script>alert(1)</script> & bye", "<p>Hello &lt;script&gt;alert(1)&lt;/script&gt; &amp; bye</p>"},
+		{"unsafe link scheme", "[click](javascript:alert(1))", "<p>click</p>"},
+		{"parentheses in url", "[Go](https://en.wikipedia.org/wiki/Go_(game)) rules", `<p><a href="https://en.wikipedia.org/wiki/Go_(game)">Go</a> rules</p>`},
+		{"quotes in url", `[x](https://a.example/"onclick=")`, `<p><a href="https://a.example/&#34;onclick=&#34;">x</a></p>`},
+		{"code block", "```\n<b>{{name}}</b>\n```", "<pre><code>&lt;b&gt;{{name}}&lt;/b&gt;</code></pre>"},
+		{"snake case is not emphasis", "Hello user_first_name", "<p>Hello user_first_name</p>"},
+		{"backslash escape", `\*not emphasis\*`, "<p>*not emphasis*</p>"},
+		{"unclosed markers", "2 * 3 and **open", "<p>2 * 3 and **open</p>"},
+		{"heading needs space", "#hashtag", "<p>#hashtag</p>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ToHTML(tt.src))
+		})
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{"emphasis and links", "**bold** [x](https://evil.example)", "<p>**bold** [x](https://evil.example)</p>"},
+		{"block markers", "# title\n- item\n12. step\n> quote", "<p># title<br>\n- item<br>\n12. step<br>\n&gt; quote</p>"},
+		{"plain text is unchanged", "ORD-1 costs 9.50", "<p>ORD-1 costs 9.50</p>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Hard line breaks keep the escaped lines in one paragraph
+			src := strings.ReplaceAll(Escape(tt.src), "\n", "  \n")
+			assert.Equal(t, tt.expected, ToHTML(src))
+		})
+	}
+	assert.Equal(t, "ORD-1 costs 9.50", Escape("ORD-1 costs 9.50"))
+}
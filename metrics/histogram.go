@@ -0,0 +1,210 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// LatencyBuckets are the upper bounds, in seconds, of the buckets of latency histograms. They
+// span fast provider calls to messages that waited minutes in a backlog.
+var LatencyBuckets = []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600}
+
+// Histogram counts observations in buckets with fixed upper bounds, so quantiles can be
+// estimated in constant memory. It is not safe for concurrent use; HistogramVec guards its own.
+type Histogram struct {
+	bounds []float64
+	// counts holds the observations of every bucket, not cumulative; the last one is +Inf
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram creates an empty histogram with the given bucket upper bounds, which must be sorted
+func NewHistogram(bounds []float64) *Histogram {
+	return &Histogram{bounds: bounds, counts: make([]uint64, len(bounds)+1)}
+}
+
+// Observe adds a value to the histogram
+func (h *Histogram) Observe(value float64) {
+	h.counts[sort.SearchFloat64s(h.bounds, value)]++
+	h.count++
+	h.sum += value
+}
+
+// Merge adds the observations of other, which must have the same buckets
+func (h *Histogram) Merge(other *Histogram) {
+	if other == nil || len(other.counts) != len(h.counts) {
+		return
+	}
+	for i, count := range other.counts {
+		h.counts[i] += count
+	}
+	h.count += other.count
+	h.sum += other.sum
+}
+
+// Count returns the number of observations
+func (h *Histogram) Count() uint64 {
+	return h.count
+}
+
+// Sum returns the sum of the observed values
+func (h *Histogram) Sum() float64 {
+	return h.sum
+}
+
+// Clone returns a copy of the histogram
+func (h *Histogram) Clone() *Histogram {
+	clone := NewHistogram(h.bounds)
+	clone.Merge(h)
+	return clone
+}
+
+// Quantile estimates the q-quantile (0 <= q <= 1) of the observations by linear interpolation
+// within the bucket it falls in, like Prometheus' histogram_quantile. Quantiles in the +Inf
+// bucket return the highest bound. An empty histogram returns 0.
+func (h *Histogram) Quantile(q float64) float64 {
+	if h.count == 0 {
+		return 0
+	}
+	q = math.Max(0, math.Min(1, q))
+	rank := q * float64(h.count)
+
+	var cumulative uint64
+	for i, count := range h.counts {
+		if count == 0 || float64(cumulative+count) < rank {
+			cumulative += count
+			continue
+		}
+		if i == len(h.bounds) {
+			return h.bounds[len(h.bounds)-1]
+		}
+		lower := 0.0
+		if i > 0 {
+			lower = h.bounds[i-1]
+		}
+		return lower + (h.bounds[i]-lower)*(rank-float64(cumulative))/float64(count)
+	}
+	return h.bounds[len(h.bounds)-1]
+}
+
+// HistogramVec is a histogram partitioned by label values
+type HistogramVec struct {
+	name       string
+	help       string
+	bounds     []float64
+	labelNames []string
+	values     map[string]*histogramValue
+	mutex      sync.RWMutex
+}
+
+// histogramValue is the histogram for one combination of label values
+type histogramValue struct {
+	labelValues []string
+	histogram   *Histogram
+}
+
+// NewHistogramVec registers a histogram with the given bucket upper bounds and label names.
+// Registering the same name twice returns the existing histogram.
+func (r *Registry) NewHistogramVec(name, help string, bounds []float64, labelNames ...string) *HistogramVec {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if existing, ok := r.histograms[name]; ok {
+		return existing
+	}
+
+	histogram := &HistogramVec{
+		name:       name,
+		help:       help,
+		bounds:     append([]float64(nil), bounds...),
+		labelNames: labelNames,
+		values:     make(map[string]*histogramValue),
+	}
+	sort.Float64s(histogram.bounds)
+	r.histograms[name] = histogram
+	return histogram
+}
+
+// Observe adds a value to the histogram for the given label values
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	if len(labelValues) != len(h.labelNames) || math.IsNaN(value) {
+		return
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	existing, ok := h.values[key]
+	if !ok {
+		existing = &histogramValue{labelValues: append([]string(nil), labelValues...), histogram: NewHistogram(h.bounds)}
+		h.values[key] = existing
+	}
+	existing.histogram.Observe(value)
+}
+
+// Snapshot returns a copy of the histogram for the given label values; it is empty when nothing
+// was observed for them
+func (h *HistogramVec) Snapshot(labelValues ...string) *Histogram {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	if value, ok := h.values[strings.Join(labelValues, "\xff")]; ok {
+		return value.histogram.Clone()
+	}
+	return NewHistogram(h.bounds)
+}
+
+// writeText writes the histogram in the Prometheus text exposition format: cumulative buckets,
+// then the sum and count of every combination of label values
+func (h *HistogramVec) writeText(w io.Writer) error {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(h.values))
+	for key := range h.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := h.values[key]
+		labels := make([]string, len(h.labelNames))
+		for i, labelName := range h.labelNames {
+			labels[i] = fmt.Sprintf("%s=%q", labelName, value.labelValues[i])
+		}
+		labelText := strings.Join(labels, ",")
+		if labelText != "" {
+			labelText += ","
+		}
+
+		var cumulative uint64
+		for i, count := range value.histogram.counts {
+			cumulative += count
+			le := "+Inf"
+			if i < len(h.bounds) {
+				le = strconv.FormatFloat(h.bounds[i], 'g', -1, 64)
+			}
+			if _, err := fmt.Fprintf(w, "%s_bucket{%sle=%q} %d\n", h.name, labelText, le, cumulative); err != nil {
+				return err
+			}
+		}
+		labelText = strings.TrimSuffix(labelText, ",")
+		if _, err := fmt.Fprintf(w, "%s_sum{%s} %g\n%s_count{%s} %d\n", h.name, labelText, value.histogram.sum, h.name, labelText, value.histogram.count); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
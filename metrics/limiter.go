@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+)
+
+// Label values used when a value cannot be used as-is
+const (
+	LabelValueNone  = "none"
+	LabelValueOther = "other"
+)
+
+// LabelLimiter bounds the cardinality of a free-form label such as notification tags.
+// The first max distinct values are kept; anything seen afterwards is reported as "other".
+type LabelLimiter struct {
+	max    int
+	values map[string]struct{}
+	mutex  sync.Mutex
+}
+
+// NewLabelLimiter creates a limiter that admits at most max distinct values
+func NewLabelLimiter(max int) *LabelLimiter {
+	return &LabelLimiter{
+		max:    max,
+		values: make(map[string]struct{}),
+	}
+}
+
+// Value returns the label value to use for v
+func (l *LabelLimiter) Value(v string) string {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if v == "" {
+		return LabelValueNone
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if _, ok := l.values[v]; ok {
+		return v
+	}
+	if len(l.values) >= l.max {
+		return LabelValueOther
+	}
+
+	l.values[v] = struct{}{}
+	return v
+}
@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounterVec(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounterVec("notifications_total", "Notifications accepted.", "type", "tag")
+
+	counter.Inc("email", "billing")
+	counter.Add(2, "email", "billing")
+	counter.Inc("slack", "none")
+	counter.Add(-1, "slack", "none")
+	counter.Inc("missing-label")
+
+	assert.Equal(t, 3.0, counter.Value("email", "billing"))
+	assert.Equal(t, 1.0, counter.Value("slack", "none"))
+	assert.Same(t, counter, registry.NewCounterVec("notifications_total", "ignored"))
+
+	var buf bytes.Buffer
+	require.NoError(t, registry.WriteText(&buf))
+	assert.Equal(t, `# HELP notifications_total Notifications accepted.
+# TYPE notifications_total counter
+notifications_total{type="email",tag="billing"} 3
+notifications_total{type="slack",tag="none"} 1
+`, buf.String())
+}
+
+func TestLabelLimiter(t *testing.T) {
+	limiter := NewLabelLimiter(2)
+
+	assert.Equal(t, "billing", limiter.Value("Billing"))
+	assert.Equal(t, "q3-campaign", limiter.Value("q3-campaign"))
+	assert.Equal(t, LabelValueOther, limiter.Value("onboarding"))
+	assert.Equal(t, "billing", limiter.Value("billing"))
+	assert.Equal(t, LabelValueNone, limiter.Value(" "))
+}
+
+func TestGaugeVec(t *testing.T) {
+	registry := NewRegistry()
+	registry.NewCounterVec("b_total", "A counter.", "type").Inc("email")
+	gauge := registry.NewGaugeVec("a_age_seconds", "A gauge.", "type")
+
+	gauge.Set(12.5, "email")
+	gauge.Set(3, "email")
+	gauge.Set(-1, "slack")
+	gauge.Set(7)
+
+	assert.Equal(t, 3.0, gauge.Value("email"))
+	assert.Equal(t, -1.0, gauge.Value("slack"))
+	assert.Same(t, gauge, registry.NewGaugeVec("a_age_seconds", "ignored"))
+
+	var buf bytes.Buffer
+	require.NoError(t, registry.WriteText(&buf))
+	assert.Equal(t, `# HELP a_age_seconds A gauge.
+# TYPE a_age_seconds gauge
+a_age_seconds{type="email"} 3
+a_age_seconds{type="slack"} -1
+# HELP b_total A counter.
+# TYPE b_total counter
+b_total{type="email"} 1
+`, buf.String())
+}
+
+func TestHistogramVec(t *testing.T) {
+	registry := NewRegistry()
+	histogram := registry.NewHistogramVec("latency_seconds", "A histogram.", []float64{1, 0.1, 10}, "channel")
+
+	histogram.Observe(0.05, "email")
+	histogram.Observe(0.5, "email")
+	histogram.Observe(20, "email")
+	histogram.Observe(1, "slack")
+	histogram.Observe(1)
+
+	snapshot := histogram.Snapshot("email")
+	assert.Equal(t, uint64(3), snapshot.Count())
+	assert.InDelta(t, 20.55, snapshot.Sum(), 1e-9)
+	assert.Equal(t, uint64(0), histogram.Snapshot("missing").Count())
+	assert.Same(t, histogram, registry.NewHistogramVec("latency_seconds", "ignored", nil))
+
+	var buf bytes.Buffer
+	require.NoError(t, registry.WriteText(&buf))
+	assert.Equal(t, `# HELP latency_seconds A histogram.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{channel="email",le="0.1"} 1
+latency_seconds_bucket{channel="email",le="1"} 2
+latency_seconds_bucket{channel="email",le="10"} 2
+latency_seconds_bucket{channel="email",le="+Inf"} 3
+latency_seconds_sum{channel="email"} 20.55
+latency_seconds_count{channel="email"} 3
+latency_seconds_bucket{channel="slack",le="0.1"} 0
+latency_seconds_bucket{channel="slack",le="1"} 1
+latency_seconds_bucket{channel="slack",le="10"} 1
+latency_seconds_bucket{channel="slack",le="+Inf"} 1
+latency_seconds_sum{channel="slack"} 1
+latency_seconds_count{channel="slack"} 1
+`, buf.String())
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	histogram := NewHistogram([]float64{1, 2, 4})
+	assert.Equal(t, 0.0, histogram.Quantile(0.5))
+
+	for i := 0; i < 50; i++ {
+		histogram.Observe(0.5)
+	}
+	for i := 0; i < 40; i++ {
+		histogram.Observe(1.5)
+	}
+	for i := 0; i < 10; i++ {
+		histogram.Observe(3)
+	}
+
+	// Quantiles are interpolated within their bucket
+	assert.InDelta(t, 1.0, histogram.Quantile(0.5), 1e-9)
+	assert.InDelta(t, 1.25, histogram.Quantile(0.6), 1e-9)
+	assert.InDelta(t, 3.0, histogram.Quantile(0.95), 1e-9)
+
+	// Values above the highest bound report the highest bound
+	other := NewHistogram([]float64{1, 2, 4})
+	other.Observe(100)
+	histogram.Merge(other)
+	assert.Equal(t, uint64(101), histogram.Count())
+	assert.Equal(t, 4.0, histogram.Quantile(1))
+}
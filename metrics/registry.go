@@ -0,0 +1,236 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Registry holds the metrics exposed by the service
+type Registry struct {
+	counters   map[string]*CounterVec
+	gauges     map[string]*GaugeVec
+	histograms map[string]*HistogramVec
+	mutex      sync.RWMutex
+}
+
+// collector is a metric that can write itself in the Prometheus text exposition format
+type collector interface {
+	writeText(w io.Writer) error
+}
+
+// NewRegistry creates an empty metrics registry
+func NewRegistry() *Registry {
+	return &Registry{
+		counters:   make(map[string]*CounterVec),
+		gauges:     make(map[string]*GaugeVec),
+		histograms: make(map[string]*HistogramVec),
+	}
+}
+
+// DefaultRegistry is the registry the service exposes on /metrics
+var DefaultRegistry = NewRegistry()
+
+// NewCounterVec registers a counter with the given label names.
+// Registering the same name twice returns the existing counter.
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if existing, ok := r.counters[name]; ok {
+		return existing
+	}
+
+	counter := &CounterVec{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		values:     make(map[string]*counterValue),
+	}
+	r.counters[name] = counter
+	return counter
+}
+
+// NewGaugeVec registers a gauge with the given label names.
+// Registering the same name twice returns the existing gauge.
+func (r *Registry) NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if existing, ok := r.gauges[name]; ok {
+		return existing
+	}
+
+	gauge := &GaugeVec{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		values:     make(map[string]*counterValue),
+	}
+	r.gauges[name] = gauge
+	return gauge
+}
+
+// WriteText writes all metrics in the Prometheus text exposition format
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mutex.RLock()
+	collectors := make(map[string]collector, len(r.counters)+len(r.gauges)+len(r.histograms))
+	for name, counter := range r.counters {
+		collectors[name] = counter
+	}
+	for name, gauge := range r.gauges {
+		collectors[name] = gauge
+	}
+	for name, histogram := range r.histograms {
+		collectors[name] = histogram
+	}
+	r.mutex.RUnlock()
+
+	names := make([]string, 0, len(collectors))
+	for name := range collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := collectors[name].writeText(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CounterVec is a monotonically increasing counter partitioned by label values
+type CounterVec struct {
+	name       string
+	help       string
+	labelNames []string
+	values     map[string]*counterValue
+	mutex      sync.RWMutex
+}
+
+// counterValue is the value of a counter or gauge for one combination of label values
+type counterValue struct {
+	labelValues []string
+	value       float64
+}
+
+// Inc increments the counter for the given label values by one
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increments the counter for the given label values.
+// Negative deltas are ignored since counters only go up.
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 || len(labelValues) != len(c.labelNames) {
+		return
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	value, ok := c.values[key]
+	if !ok {
+		value = &counterValue{labelValues: append([]string(nil), labelValues...)}
+		c.values[key] = value
+	}
+	value.value += delta
+}
+
+// Value returns the current value of the counter for the given label values
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if value, ok := c.values[strings.Join(labelValues, "\xff")]; ok {
+		return value.value
+	}
+	return 0
+}
+
+// writeText writes the counter in the Prometheus text exposition format
+func (c *CounterVec) writeText(w io.Writer) error {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return writeValues(w, "counter", c.name, c.help, c.labelNames, c.values)
+}
+
+// GaugeVec is a value that can go up and down, partitioned by label values
+type GaugeVec struct {
+	name       string
+	help       string
+	labelNames []string
+	values     map[string]*counterValue
+	mutex      sync.RWMutex
+}
+
+// Set sets the gauge for the given label values
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	if len(labelValues) != len(g.labelNames) {
+		return
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	existing, ok := g.values[key]
+	if !ok {
+		existing = &counterValue{labelValues: append([]string(nil), labelValues...)}
+		g.values[key] = existing
+	}
+	existing.value = value
+}
+
+// Value returns the current value of the gauge for the given label values
+func (g *GaugeVec) Value(labelValues ...string) float64 {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	if value, ok := g.values[strings.Join(labelValues, "\xff")]; ok {
+		return value.value
+	}
+	return 0
+}
+
+// writeText writes the gauge in the Prometheus text exposition format
+func (g *GaugeVec) writeText(w io.Writer) error {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	return writeValues(w, "gauge", g.name, g.help, g.labelNames, g.values)
+}
+
+// writeValues writes the HELP and TYPE lines of a metric followed by its values sorted by labels
+func writeValues(w io.Writer, metricType, name, help string, labelNames []string, values map[string]*counterValue) error {
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := values[key]
+		labels := make([]string, len(labelNames))
+		for i, labelName := range labelNames {
+			labels[i] = fmt.Sprintf("%s=%q", labelName, value.labelValues[i])
+		}
+		if _, err := fmt.Fprintf(w, "%s{%s} %g\n", name, strings.Join(labels, ","), value.value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
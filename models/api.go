@@ -14,4 +14,30 @@ type NotificationRequest struct {
 	From        *struct {
 		Email string `json:"email"`
 	} `json:"from,omitempty"`
+	ExternalID string   `json:"external_id,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Category   string   `json:"category,omitempty"`
+	// Reason tells recipients why they received the notification, e.g. "You received this because
+	// you subscribed to order updates"; it is added to the footer of emails and to inbox items
+	Reason string `json:"reason,omitempty"`
+	// ThreadKey relates notifications about the same thing, e.g. order:42, so users can mute them
+	ThreadKey string `json:"thread_key,omitempty"`
+	// Transactional notifications are sent regardless of the recipients' category preferences
+	Transactional bool `json:"transactional,omitempty"`
+	// AllowDuplicate sends the notification even if an identical one was sent recently
+	AllowDuplicate bool `json:"allow_duplicate,omitempty"`
+	// DedupeKey identifies what an incident notification is about, e.g. checkout:error-rate.
+	// Incident notifications with the same key update, acknowledge or resolve the same alert.
+	DedupeKey string `json:"dedupe_key,omitempty"`
+	// ChannelContent holds content for each channel, keyed by email, slack, google_chat, rcs or push
+	ChannelContent map[string]map[string]interface{} `json:"channel_content,omitempty"`
+	// FallbackChannels are tried in order for recipients who cannot be reached on Type
+	FallbackChannels []string `json:"fallback_channels,omitempty"`
+	// Tenant is the name of the API key the request was sent with; it is set by the server
+	Tenant string `json:"-"`
+	// BatchKey groups scheduled notifications due in the same batching window; it is set by the server
+	BatchKey string `json:"-"`
+	// AcceptAsync fans the notification out after the request was accepted, however many
+	// recipients it has; it is set by the server for API versions that accept sends asynchronously
+	AcceptAsync bool `json:"-"`
 }
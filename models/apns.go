@@ -5,12 +5,38 @@ import (
 	"time"
 )
 
+// APNS environments. Development builds of an app receive pushes from the sandbox only.
+const (
+	APNSEnvironmentProduction = "production"
+	APNSEnvironmentSandbox    = "sandbox"
+)
+
+// IsValidAPNSEnvironment reports whether environment names an APNS environment
+func IsValidAPNSEnvironment(environment string) bool {
+	return environment == APNSEnvironmentProduction || environment == APNSEnvironmentSandbox
+}
+
 // APNSNotificationRequest represents an APNS notification request
 type APNSNotificationRequest struct {
 	ID        string      `json:"id"`
 	Type      string      `json:"type"`
 	Content   APNSContent `json:"content"`
 	Recipient string      `json:"recipient"`
+	UserID    string      `json:"user_id,omitempty"`
+	QueuedAt  int64       `json:"queued_at,omitempty"` // Unix milliseconds when posted to its channel
+	// Environment of the device token; empty uses the service's default environment
+	Environment string `json:"environment,omitempty"`
+	// AppID selects the app's credentials from the push app registry; empty uses the default app
+	AppID string `json:"app_id,omitempty"`
+	// BatchKey groups pushes that may be sent in one batch
+	BatchKey string `json:"batch_key,omitempty"`
+	// ThreadID groups the notification with related ones in Notification Center
+	ThreadID string `json:"thread_id,omitempty"`
+}
+
+// SetQueuedAt records when the notification was posted to its channel
+func (n *APNSNotificationRequest) SetQueuedAt(t time.Time) {
+	n.QueuedAt = t.UnixMilli()
 }
 
 // APNSContent represents the content of an APNS notification
@@ -28,6 +54,11 @@ type APNSResponse struct {
 	Channel      string    `json:"channel"`
 	SuccessCount int       `json:"success_count"`
 	FailureCount int       `json:"failure_count"`
+	StatusCode   int       `json:"status_code,omitempty"`
+	// APNSID is the apns-id APNS assigned to the notification
+	APNSID string `json:"apns_id,omitempty"`
+	// Reason is the APNS reason code of a rejected notification, e.g. BadDeviceToken
+	Reason string `json:"reason,omitempty"`
 }
 
 // ValidateAPNSNotification validates the APNS notification request
@@ -63,5 +94,9 @@ func ValidateAPNSNotification(notification *APNSNotificationRequest) error {
 		return fmt.Errorf("device token cannot be empty")
 	}
 
+	if notification.Environment != "" && !IsValidAPNSEnvironment(notification.Environment) {
+		return fmt.Errorf("unknown APNS environment %q", notification.Environment)
+	}
+
 	return nil
 }
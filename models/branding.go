@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Branding holds the brand of a tenant. Its fields are available to the tenant's templates as
+// brand variables, e.g. {{brand.logo_url}}, without callers supplying them.
+type Branding struct {
+	Tenant       string    `json:"tenant"`
+	LogoURL      string    `json:"logo_url,omitempty"`
+	PrimaryColor string    `json:"primary_color,omitempty"` // Hex color such as #1a73e8
+	CompanyName  string    `json:"company_name,omitempty"`
+	Address      string    `json:"address,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	UpdatedBy    string    `json:"updated_by,omitempty"`
+}
+
+// Brand variables are filled in from the branding of the sending tenant when a template is rendered
+const (
+	BrandLogoURLVariable      = "brand.logo_url"
+	BrandPrimaryColorVariable = "brand.primary_color"
+	BrandCompanyNameVariable  = "brand.company_name"
+	BrandAddressVariable      = "brand.address"
+)
+
+// IsBrandVariable reports whether a template variable is filled in from the tenant's branding
+func IsBrandVariable(name string) bool {
+	switch name {
+	case BrandLogoURLVariable, BrandPrimaryColorVariable, BrandCompanyNameVariable, BrandAddressVariable:
+		return true
+	}
+	return false
+}
+
+// WithBrandVariables returns data with the brand variables of branding added; tenants without
+// branding get empty values. Variables already present in data are kept, so callers can still
+// override them.
+func WithBrandVariables(data map[string]interface{}, branding Branding) map[string]interface{} {
+	brandVariables := map[string]interface{}{
+		BrandLogoURLVariable:      branding.LogoURL,
+		BrandPrimaryColorVariable: branding.PrimaryColor,
+		BrandCompanyNameVariable:  branding.CompanyName,
+		BrandAddressVariable:      branding.Address,
+	}
+
+	merged := make(map[string]interface{}, len(data)+len(brandVariables))
+	for key, value := range brandVariables {
+		merged[key] = value
+	}
+	for key, value := range data {
+		merged[key] = value
+	}
+	return merged
+}
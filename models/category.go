@@ -0,0 +1,45 @@
+package models
+
+// Notification categories. Users can opt out of a category or mute it on some channels, except
+// for urgent notifications, which page on-call responders and are always delivered.
+const (
+	CategoryTransactional = "transactional"
+	CategoryMarketing     = "marketing"
+	CategorySecurity      = "security"
+	CategorySystem        = "system"
+	CategoryUrgent        = "urgent"
+)
+
+// DefaultCategory is the category of notifications and templates that do not set one
+const DefaultCategory = CategoryTransactional
+
+// IsValidCategory checks if a notification category is supported
+func IsValidCategory(category string) bool {
+	switch category {
+	case CategoryTransactional, CategoryMarketing, CategorySecurity, CategorySystem, CategoryUrgent:
+		return true
+	}
+	return false
+}
+
+// CategoryPreference holds a user's choices for one notification category
+type CategoryPreference struct {
+	OptOut        bool     `json:"opt_out,omitempty"`
+	MutedChannels []string `json:"muted_channels,omitempty"`
+}
+
+// Allows reports whether notifications of the category may be sent on the channel
+func (p *CategoryPreference) Allows(channel string) bool {
+	if p == nil {
+		return true
+	}
+	if p.OptOut {
+		return false
+	}
+	for _, muted := range p.MutedChannels {
+		if muted == channel {
+			return false
+		}
+	}
+	return true
+}
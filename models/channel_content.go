@@ -0,0 +1,33 @@
+package models
+
+// Keys of channel content blocks. The push block is used for in_app, ios_push and
+// android_push notifications.
+const (
+	ChannelContentEmail      = "email"
+	ChannelContentSlack      = "slack"
+	ChannelContentGoogleChat = "google_chat"
+	ChannelContentRCS        = "rcs"
+	ChannelContentPush       = "push"
+)
+
+// ChannelContentKey returns the channel content key for a notification type
+func ChannelContentKey(notificationType string) string {
+	switch notificationType {
+	case string(EmailNotification):
+		return ChannelContentEmail
+	case string(SlackNotification):
+		return ChannelContentSlack
+	case string(GoogleChatNotification):
+		return ChannelContentGoogleChat
+	case string(RCSNotification):
+		return ChannelContentRCS
+	case string(InAppNotification), "ios_push", "android_push":
+		return ChannelContentPush
+	}
+	return ""
+}
+
+// ContentFor returns the channel content block for a notification type, or nil
+func (r *NotificationRequest) ContentFor(notificationType string) map[string]interface{} {
+	return r.ChannelContent[ChannelContentKey(notificationType)]
+}
@@ -0,0 +1,225 @@
+package models
+
+import "time"
+
+// ContactSlack is the Slack channel contact point of a user. Slack channels are not verified.
+const ContactSlack = "slack"
+
+// MaxContactPointsPerType is how many addresses, the primary one included, a user can have for
+// one contact type
+const MaxContactPointsPerType = 5
+
+// ContactPoint is an address a user can be reached at. The primary address of each contact type
+// is kept in the user's Email, SlackChannel and PhoneNumber fields, the others in
+// SecondaryContacts.
+type ContactPoint struct {
+	Type       string     `json:"type"`
+	Address    string     `json:"address"`
+	Primary    bool       `json:"primary"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+}
+
+// IsValidContactType checks if contactType names a contact point type
+func IsValidContactType(contactType string) bool {
+	return contactType == ContactEmail || contactType == ContactSlack || contactType == ContactPhone
+}
+
+// contactTypes lists the contact types in the order contact points are listed
+var contactTypes = []string{ContactEmail, ContactSlack, ContactPhone}
+
+// primaryContact returns the primary contact point of a type, nil when the user has none
+func (u *User) primaryContact(contactType string) *ContactPoint {
+	var point ContactPoint
+	switch contactType {
+	case ContactEmail:
+		point = ContactPoint{Address: u.Email, VerifiedAt: u.EmailVerifiedAt}
+	case ContactSlack:
+		point = ContactPoint{Address: u.SlackChannel}
+	case ContactPhone:
+		point = ContactPoint{Address: u.PhoneNumber, VerifiedAt: u.PhoneVerifiedAt}
+	}
+	if point.Address == "" {
+		return nil
+	}
+	point.Type = contactType
+	point.Primary = true
+	return &point
+}
+
+// setPrimaryContact replaces the primary contact point of a type; an empty address clears it
+func (u *User) setPrimaryContact(contactType, address string, verifiedAt *time.Time) {
+	switch contactType {
+	case ContactEmail:
+		u.Email, u.EmailVerifiedAt = address, verifiedAt
+	case ContactSlack:
+		u.SlackChannel = address
+	case ContactPhone:
+		u.PhoneNumber, u.PhoneVerifiedAt = address, verifiedAt
+	}
+}
+
+// secondaryIndex returns the index of a secondary contact point, -1 when there is none
+func (u *User) secondaryIndex(contactType, address string) int {
+	for i, point := range u.SecondaryContacts {
+		if point.Type == contactType && point.Address == address {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeSecondary removes the secondary contact point at index i and returns it
+func (u *User) removeSecondary(i int) ContactPoint {
+	point := u.SecondaryContacts[i]
+	u.SecondaryContacts = append(u.SecondaryContacts[:i:i], u.SecondaryContacts[i+1:]...)
+	return point
+}
+
+// ContactPoints returns all contact points of a user by type, the primary one of each type first
+func (u *User) ContactPoints() []ContactPoint {
+	var points []ContactPoint
+	for _, contactType := range contactTypes {
+		if primary := u.primaryContact(contactType); primary != nil {
+			points = append(points, *primary)
+		}
+		for _, point := range u.SecondaryContacts {
+			if point.Type == contactType {
+				points = append(points, point)
+			}
+		}
+	}
+	return points
+}
+
+// AddContactPoint adds an address to a user. It becomes the primary address of its type when
+// primary is set or the user has none; the previous primary address is kept as a secondary one.
+func (u *User) AddContactPoint(contactType, address string, primary bool) error {
+	if !IsValidContactType(contactType) {
+		return ErrInvalidContactType
+	}
+	if err := ValidateRecipientAddress(contactType, address); err != nil {
+		return err
+	}
+
+	count := 0
+	for _, point := range u.ContactPoints() {
+		if point.Type != contactType {
+			continue
+		}
+		if point.Address == address {
+			return ErrContactPointExists
+		}
+		count++
+	}
+	if count >= MaxContactPointsPerType {
+		return ErrTooManyContactPoints
+	}
+
+	u.SecondaryContacts = append(u.SecondaryContacts, ContactPoint{Type: contactType, Address: address})
+	if primary || u.primaryContact(contactType) == nil {
+		return u.SetPrimaryContact(contactType, address)
+	}
+	return nil
+}
+
+// SetPrimaryContact makes a secondary address of a user the primary one of its type, keeping
+// its verification. The previous primary address becomes a secondary one.
+func (u *User) SetPrimaryContact(contactType, address string) error {
+	if primary := u.primaryContact(contactType); primary != nil && primary.Address == address {
+		return nil
+	}
+	i := u.secondaryIndex(contactType, address)
+	if i < 0 {
+		return ErrContactPointNotFound
+	}
+
+	point := u.removeSecondary(i)
+	if previous := u.primaryContact(contactType); previous != nil {
+		previous.Primary = false
+		u.SecondaryContacts = append(u.SecondaryContacts, *previous)
+	}
+	u.setPrimaryContact(contactType, point.Address, point.VerifiedAt)
+	return nil
+}
+
+// ReplacePrimaryContact changes the primary address of a type, as updating the user's Email,
+// SlackChannel or PhoneNumber does. The previous primary address is removed. A new address has
+// to be verified again; a secondary address keeps its verification.
+func (u *User) ReplacePrimaryContact(contactType, address string) {
+	if primary := u.primaryContact(contactType); primary != nil && primary.Address == address {
+		return
+	}
+	var verifiedAt *time.Time
+	if i := u.secondaryIndex(contactType, address); i >= 0 {
+		verifiedAt = u.removeSecondary(i).VerifiedAt
+	}
+	u.setPrimaryContact(contactType, address, verifiedAt)
+}
+
+// RemoveContactPoint removes an address from a user. When the primary address is removed, the
+// first secondary address of its type becomes the primary one.
+func (u *User) RemoveContactPoint(contactType, address string) error {
+	if i := u.secondaryIndex(contactType, address); i >= 0 {
+		u.removeSecondary(i)
+		return nil
+	}
+	primary := u.primaryContact(contactType)
+	if primary == nil || primary.Address != address {
+		return ErrContactPointNotFound
+	}
+
+	u.setPrimaryContact(contactType, "", nil)
+	for i, point := range u.SecondaryContacts {
+		if point.Type == contactType {
+			u.removeSecondary(i)
+			u.setPrimaryContact(contactType, point.Address, point.VerifiedAt)
+			break
+		}
+	}
+	return nil
+}
+
+// HasContactPoint reports whether an address is one of the user's contact points of a type
+func (u *User) HasContactPoint(contactType, address string) bool {
+	if primary := u.primaryContact(contactType); primary != nil && primary.Address == address {
+		return true
+	}
+	return u.secondaryIndex(contactType, address) >= 0
+}
+
+// MarkContactVerified records that the user confirmed an address at the given time
+func (u *User) MarkContactVerified(contactType, address string, at time.Time) {
+	if primary := u.primaryContact(contactType); primary != nil && primary.Address == address {
+		u.setPrimaryContact(contactType, address, &at)
+		return
+	}
+	if i := u.secondaryIndex(contactType, address); i >= 0 {
+		u.SecondaryContacts[i].VerifiedAt = &at
+	}
+}
+
+// PreferredContact returns the address notifications of a contact type are sent to: the primary
+// address when it is verified, otherwise the first verified secondary address, otherwise the
+// primary or first secondary address. Slack channels are not verified, so the primary channel
+// is preferred.
+func (u *User) PreferredContact(contactType string) (address string, verified bool) {
+	var candidates []ContactPoint
+	if primary := u.primaryContact(contactType); primary != nil {
+		candidates = append(candidates, *primary)
+	}
+	for _, point := range u.SecondaryContacts {
+		if point.Type == contactType {
+			candidates = append(candidates, point)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+
+	for _, point := range candidates {
+		if point.VerifiedAt != nil {
+			return point.Address, true
+		}
+	}
+	return candidates[0].Address, false
+}
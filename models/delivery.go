@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+)
+
+// Delivery attempt statuses
+const (
+	DeliveryStatusSent   = "sent"
+	DeliveryStatusFailed = "failed"
+	// DeliveryStatusDelivered is a sent push whose arrival on the device was confirmed by a receipt
+	DeliveryStatusDelivered = "delivered"
+	// DeliveryStatusQuarantined is an email held back because a scan found a threat in it
+	DeliveryStatusQuarantined = "quarantined"
+)
+
+// DeliveryAttempt represents a single attempt to hand a notification over to a provider
+type DeliveryAttempt struct {
+	NotificationID   string     `json:"notification_id"`
+	UserID           string     `json:"user_id,omitempty"`
+	Recipient        string     `json:"recipient"`
+	Channel          string     `json:"channel"`
+	Attempt          int        `json:"attempt"`
+	Status           string     `json:"status"`
+	StatusCode       int        `json:"status_code,omitempty"`
+	ProviderResponse string     `json:"provider_response,omitempty"`
+	Error            string     `json:"error,omitempty"`
+	DurationMs       int64      `json:"duration_ms"`
+	AttemptedAt      time.Time  `json:"attempted_at"`
+	DeliveredAt      *time.Time `json:"delivered_at,omitempty"`
+	// ProviderMessageID is the provider's ID of the sent message, e.g. the APNS apns-id
+	ProviderMessageID string `json:"provider_message_id,omitempty"`
+	// ProviderReason is the provider's reason code of a rejected message, e.g. BadDeviceToken
+	ProviderReason string `json:"provider_reason,omitempty"`
+	// Provider is the service the message was sent through, e.g. apns
+	Provider string `json:"provider,omitempty"`
+	// QueuedAt is when the message was posted to its channel; LatencyMs is the time from then
+	// to the provider's answer
+	QueuedAt  *time.Time `json:"queued_at,omitempty"`
+	LatencyMs int64      `json:"latency_ms,omitempty"`
+}
+
+// MatchesRecipient checks whether the attempt belongs to the given recipient.
+// A recipient can be referenced either by user ID or by the provider address (email, slack channel, device token).
+func (a *DeliveryAttempt) MatchesRecipient(recipient string) bool {
+	return a.UserID == recipient || a.Recipient == recipient
+}
+
+// DeliveryStats summarises the latest delivery outcome of every recipient of a notification.
+// Delivered recipients are also counted as sent, since only sent pushes can be confirmed.
+type DeliveryStats struct {
+	Sent        int `json:"sent"`
+	Failed      int `json:"failed"`
+	Delivered   int `json:"delivered"`
+	Quarantined int `json:"quarantined"`
+}
+
+// DeliveryLatency summarises the time from enqueueing messages to their acknowledgment by the
+// provider, for messages of one channel sent through one provider. Percentiles are estimated
+// from latency histograms.
+type DeliveryLatency struct {
+	Channel  string  `json:"channel"`
+	Provider string  `json:"provider"`
+	Count    uint64  `json:"count"`
+	MeanMs   float64 `json:"mean_ms"`
+	P50Ms    float64 `json:"p50_ms"`
+	P95Ms    float64 `json:"p95_ms"`
+	P99Ms    float64 `json:"p99_ms"`
+}
+
+// DeliveryReceipt confirms that a push handed to APNS or FCM reached the device. Receipts are
+// reported by the app (e.g. from an iOS notification service extension) or imported from FCM
+// delivery data.
+type DeliveryReceipt struct {
+	NotificationID string     `json:"notification_id"`
+	Channel        string     `json:"channel"`
+	Recipient      string     `json:"recipient"` // device token the push was sent to
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+// DeliveryReceiptBatch is a set of receipts reported together
+type DeliveryReceiptBatch struct {
+	Receipts []DeliveryReceipt `json:"receipts" binding:"required"`
+}
+
+// IsValidReceiptChannel checks if delivery receipts can be reported for a channel
+func IsValidReceiptChannel(channel string) bool {
+	return channel == "ios_push" || channel == "android_push"
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/base64"
 	"fmt"
 	"net/mail"
 	"strings"
@@ -13,13 +14,108 @@ type EmailNotificationRequest struct {
 	Type      string       `json:"type"`
 	Content   EmailContent `json:"content"`
 	Recipient string       `json:"recipient"`
+	UserID    string       `json:"user_id,omitempty"`
 	From      *EmailSender `json:"from,omitempty"`
+	QueuedAt  int64        `json:"queued_at,omitempty"` // Unix milliseconds when posted to its channel
+	BatchKey  string       `json:"batch_key,omitempty"` // Emails with the same key may be sent in one batch
 }
 
+// SetQueuedAt records when the notification was posted to its channel
+func (n *EmailNotificationRequest) SetQueuedAt(t time.Time) {
+	n.QueuedAt = t.UnixMilli()
+}
+
+// Email body render modes
+const (
+	// EmailRenderModeText sends the email body as written
+	EmailRenderModeText = "text"
+	// EmailRenderModeMarkdown converts a Markdown email body to HTML with a plain text alternative
+	EmailRenderModeMarkdown = "markdown"
+)
+
+// Email color schemes, which tell clients how to render an email in dark mode
+const (
+	// EmailColorSchemeLight asks clients to keep the email light instead of inverting its colors
+	EmailColorSchemeLight = "light"
+	// EmailColorSchemeAuto declares support for light and dark mode and adds dark-mode styles
+	EmailColorSchemeAuto = "auto"
+)
+
 // EmailContent represents the content of an email notification
 type EmailContent struct {
-	Subject   string `json:"subject"`
-	EmailBody string `json:"email_body"`
+	Subject     string            `json:"subject"`
+	EmailBody   string            `json:"email_body"`
+	RenderMode  string            `json:"render_mode,omitempty"`
+	Attachments []EmailAttachment `json:"attachments,omitempty"`
+	// TextBody is the plain text alternative of the body; it is generated from the body when empty
+	TextBody string `json:"text_body,omitempty"`
+	// ColorScheme and DarkModeCSS select the dark-mode hints added to the HTML body
+	ColorScheme string `json:"color_scheme,omitempty"`
+	DarkModeCSS string `json:"dark_mode_css,omitempty"`
+	// Reason is added to the footer of both bodies
+	Reason string `json:"reason,omitempty"`
+}
+
+// Limits on the attachments of an email
+const (
+	MaxEmailAttachments     = 10
+	MaxEmailAttachmentBytes = 10 << 20 // Total decoded size of all attachments
+)
+
+// EmailAttachment is a file attached to an email. Content is base64 encoded in JSON.
+type EmailAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type,omitempty"`
+	Content     []byte `json:"content"`
+}
+
+// ParseEmailAttachments reads the attachments of a request's email content, a list of objects
+// with a filename, an optional content type and base64 encoded content. A nil value has none.
+func ParseEmailAttachments(value interface{}) ([]EmailAttachment, error) {
+	if value == nil {
+		return nil, nil
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: attachments must be a list", ErrInvalidAttachment)
+	}
+	if len(items) > MaxEmailAttachments {
+		return nil, fmt.Errorf("%w: at most %d attachments are allowed", ErrInvalidAttachment, MaxEmailAttachments)
+	}
+
+	attachments := make([]EmailAttachment, 0, len(items))
+	total := 0
+	for i, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: attachment %d must be an object", ErrInvalidAttachment, i)
+		}
+		filename, _ := fields["filename"].(string)
+		if strings.TrimSpace(filename) == "" || strings.ContainsAny(filename, "/\\\r\n") {
+			return nil, fmt.Errorf("%w: attachment %d needs a filename without path separators", ErrInvalidAttachment, i)
+		}
+		contentType, _ := fields["content_type"].(string)
+		encoded, _ := fields["content"].(string)
+		content, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(content) == 0 {
+			return nil, fmt.Errorf("%w: attachment %s needs base64 encoded content", ErrInvalidAttachment, filename)
+		}
+		if total += len(content); total > MaxEmailAttachmentBytes {
+			return nil, fmt.Errorf("%w: attachments exceed %d bytes", ErrInvalidAttachment, MaxEmailAttachmentBytes)
+		}
+		attachments = append(attachments, EmailAttachment{Filename: filename, ContentType: contentType, Content: content})
+	}
+	return attachments, nil
+}
+
+// IsValidEmailRenderMode checks whether mode is a supported email render mode; empty means text
+func IsValidEmailRenderMode(mode string) bool {
+	return mode == "" || mode == EmailRenderModeText || mode == EmailRenderModeMarkdown
+}
+
+// IsValidEmailColorScheme checks whether scheme is a supported email color scheme; empty adds no hints
+func IsValidEmailColorScheme(scheme string) bool {
+	return scheme == "" || scheme == EmailColorSchemeLight || scheme == EmailColorSchemeAuto
 }
 
 // EmailSender represents the sender information
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Engagement events reported by clients for in-app and push notifications
+const (
+	EngagementDisplayed = "displayed"
+	EngagementOpened    = "opened"
+)
+
+// Channels an engagement event can be reported from
+const (
+	EngagementChannelInApp       = "in_app"
+	EngagementChannelIOSPush     = "ios_push"
+	EngagementChannelAndroidPush = "android_push"
+)
+
+// IsValidEngagementEvent checks if an engagement event type is supported
+func IsValidEngagementEvent(event string) bool {
+	return event == EngagementDisplayed || event == EngagementOpened
+}
+
+// IsValidEngagementChannel checks if an engagement event may be reported from the channel
+func IsValidEngagementChannel(channel string) bool {
+	switch channel {
+	case EngagementChannelInApp, EngagementChannelIOSPush, EngagementChannelAndroidPush:
+		return true
+	}
+	return false
+}
+
+// EngagementEventRequest is a client report that a notification was displayed or opened
+type EngagementEventRequest struct {
+	Event      string     `json:"event" binding:"required"`
+	Recipient  string     `json:"recipient" binding:"required"`
+	Channel    string     `json:"channel,omitempty"`
+	OccurredAt *time.Time `json:"occurred_at,omitempty"`
+}
+
+// EngagementEvent is a recorded impression or open of a notification by one recipient
+type EngagementEvent struct {
+	NotificationID string    `json:"notification_id"`
+	Recipient      string    `json:"recipient"`
+	Event          string    `json:"event"`
+	Channel        string    `json:"channel,omitempty"`
+	OccurredAt     time.Time `json:"occurred_at"`
+	ReceivedAt     time.Time `json:"received_at"`
+}
+
+// EngagementStats counts the distinct recipients that saw and opened notifications.
+// An open also counts as an impression, since a notification cannot be opened unseen.
+type EngagementStats struct {
+	Displayed int     `json:"displayed"`
+	Opened    int     `json:"opened"`
+	OpenRate  float64 `json:"open_rate"`
+}
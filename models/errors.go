@@ -8,6 +8,13 @@ var (
 	ErrInvalidTemplateType     = errors.New("invalid template type")
 	ErrMissingRequiredVariable = errors.New("missing required variable")
 	ErrTemplateNotFound        = errors.New("template not found")
+	ErrTemplateTypeChanged     = errors.New("template type cannot be changed")
+	ErrPredefinedTemplate      = errors.New("predefined templates cannot be changed")
+	ErrInvalidTemplateBundle   = errors.New("invalid template bundle")
+	ErrInvalidTemplateFile     = errors.New("invalid template file")
+	ErrInvalidConflictMode     = errors.New("invalid conflict mode, expected skip, overwrite or new_version")
+	ErrMissingDefaultLocale    = errors.New("localized template has no content in the default locale")
+	ErrTemplateNotActive       = errors.New("localized template version is a draft, activate it before sending")
 )
 
 // Email-related errors
@@ -21,4 +28,45 @@ var (
 	ErrMissingEmailSubject    = errors.New("email subject is required")
 	ErrMissingEmailBody       = errors.New("email body is required")
 	ErrEmptyEmailRecipients   = errors.New("recipients list cannot be empty")
+	ErrInvalidAttachment      = errors.New("invalid email attachment")
+)
+
+// User-related errors
+var (
+	ErrInvalidTimezone = errors.New("invalid timezone, expected an IANA name such as Europe/Berlin")
+	ErrInvalidLocale   = errors.New("invalid locale, expected a BCP 47 tag such as en-US")
+
+	ErrInvalidContactType   = errors.New("invalid contact type, expected email, slack or phone")
+	ErrContactPointExists   = errors.New("user already has this contact point")
+	ErrContactPointNotFound = errors.New("contact point not found")
+	ErrTooManyContactPoints = errors.New("too many contact points of this type")
+)
+
+// Inbox-related errors
+var (
+	ErrInvalidInboxAction = errors.New("invalid inbox action")
+)
+
+// Google Chat-related errors
+var (
+	ErrInvalidGoogleChatCard = errors.New("invalid google chat card")
+)
+
+// Incident-related errors
+var (
+	ErrInvalidIncidentContent = errors.New("invalid incident content")
+)
+
+// RCS-related errors
+var (
+	ErrInvalidRCSContent = errors.New("invalid rcs content")
+)
+
+// Recipient-related errors
+var (
+	ErrInvalidRecipientKind   = errors.New("invalid recipient kind, expected email, slack, google_chat, incident or phone")
+	ErrInvalidSlackChannel    = errors.New("invalid slack channel, expected a name such as #ops or a channel ID")
+	ErrInvalidGoogleChatSpace = errors.New("invalid google chat space, expected a name such as spaces/AAAAqZ1yV4k or the webhook URL of a space")
+	ErrInvalidIncidentRoute   = errors.New("invalid incident route, expected a PagerDuty integration key or an Opsgenie team name")
+	ErrInvalidPhoneNumber     = errors.New("invalid phone number, expected E.164 format such as +14155550123")
 )
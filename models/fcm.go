@@ -11,6 +11,19 @@ type FCMNotificationRequest struct {
 	Type      string     `json:"type"`
 	Content   FCMContent `json:"content"`
 	Recipient string     `json:"recipient"`
+	UserID    string     `json:"user_id,omitempty"`
+	QueuedAt  int64      `json:"queued_at,omitempty"` // Unix milliseconds when posted to its channel
+	// AppID selects the app's Firebase project from the push app registry; empty uses the default app
+	AppID string `json:"app_id,omitempty"`
+	// BatchKey groups pushes that may be sent in one multicast request
+	BatchKey string `json:"batch_key,omitempty"`
+	// CollapseKey makes the push replace an undelivered or displayed push with the same key
+	CollapseKey string `json:"collapse_key,omitempty"`
+}
+
+// SetQueuedAt records when the notification was posted to its channel
+func (n *FCMNotificationRequest) SetQueuedAt(t time.Time) {
+	n.QueuedAt = t.UnixMilli()
 }
 
 // FCMContent represents the content of an FCM notification
@@ -28,6 +41,7 @@ type FCMResponse struct {
 	Channel      string    `json:"channel"`
 	SuccessCount int       `json:"success_count"`
 	FailureCount int       `json:"failure_count"`
+	StatusCode   int       `json:"status_code,omitempty"`
 }
 
 // ValidateFCMNotification validates the FCM notification request
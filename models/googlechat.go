@@ -0,0 +1,195 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MaxGoogleChatCardButtons is the largest number of buttons a Google Chat card may have
+const MaxGoogleChatCardButtons = 6
+
+// googleChatSpaceRegex matches Google Chat space names such as "spaces/AAAAqZ1yV4k"
+var googleChatSpaceRegex = regexp.MustCompile(`^spaces/[A-Za-z0-9_-]+$`)
+
+// googleChatWebhookPathRegex matches the path of the incoming webhook URL of a space
+var googleChatWebhookPathRegex = regexp.MustCompile(`^/v1/spaces/[A-Za-z0-9_-]+/messages$`)
+
+// GoogleChatNotificationRequest represents a Google Chat notification request
+type GoogleChatNotificationRequest struct {
+	ID      string            `json:"id"`
+	Type    string            `json:"type"`
+	Content GoogleChatContent `json:"content"`
+	// Recipient is a space name such as spaces/AAAAqZ1yV4k, posted to through the Chat API, or
+	// the incoming webhook URL of a space
+	Recipient string `json:"recipient"`
+	UserID    string `json:"user_id,omitempty"`
+	QueuedAt  int64  `json:"queued_at,omitempty"` // Unix milliseconds when posted to its channel
+	// ThreadKey posts the message as a reply in the thread of the first message with the same key
+	ThreadKey string `json:"thread_key,omitempty"`
+}
+
+// SetQueuedAt records when the notification was posted to its channel
+func (n *GoogleChatNotificationRequest) SetQueuedAt(t time.Time) {
+	n.QueuedAt = t.UnixMilli()
+}
+
+// GoogleChatContent represents the content of a Google Chat notification. Text is shown above
+// the card; a message needs text, a card or both.
+type GoogleChatContent struct {
+	Text string          `json:"text,omitempty"`
+	Card *GoogleChatCard `json:"card,omitempty"`
+}
+
+// GoogleChatCard is a card message with a header, text sections and link buttons
+type GoogleChatCard struct {
+	Title    string                  `json:"title"`
+	Subtitle string                  `json:"subtitle,omitempty"`
+	ImageURL string                  `json:"image_url,omitempty"`
+	Sections []GoogleChatCardSection `json:"sections,omitempty"`
+	Buttons  []GoogleChatCardButton  `json:"buttons,omitempty"`
+}
+
+// GoogleChatCardSection is a section of a card. Text may use the HTML subset of Google Chat,
+// such as <b> and <a href>.
+type GoogleChatCardSection struct {
+	Header string `json:"header,omitempty"`
+	Text   string `json:"text"`
+}
+
+// GoogleChatCardButton is a card button opening a URL
+type GoogleChatCardButton struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+// GoogleChatResponse represents the response from Google Chat message sending
+type GoogleChatResponse struct {
+	ID      string    `json:"id"`
+	Status  string    `json:"status"`
+	Message string    `json:"message"`
+	SentAt  time.Time `json:"sent_at"`
+	Channel string    `json:"channel"`
+	// MessageName and ThreadName are the resource names Google Chat gave the message and its thread
+	MessageName string `json:"message_name,omitempty"`
+	ThreadName  string `json:"thread_name,omitempty"`
+}
+
+// ParseGoogleChatCard reads the card of notification content, nil when there is none
+func ParseGoogleChatCard(value interface{}) (*GoogleChatCard, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if _, ok := value.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("%w: card must be an object", ErrInvalidGoogleChatCard)
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGoogleChatCard, err)
+	}
+	var card GoogleChatCard
+	if err := json.Unmarshal(encoded, &card); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGoogleChatCard, err)
+	}
+	if err := card.Validate(); err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+// Validate checks that the card has a title and that its sections and buttons are complete
+func (c *GoogleChatCard) Validate() error {
+	if strings.TrimSpace(c.Title) == "" {
+		return fmt.Errorf("%w: a title is required", ErrInvalidGoogleChatCard)
+	}
+	if c.ImageURL != "" && !isHTTPSURL(c.ImageURL) {
+		return fmt.Errorf("%w: image_url must be an https URL", ErrInvalidGoogleChatCard)
+	}
+	for i, section := range c.Sections {
+		if strings.TrimSpace(section.Text) == "" {
+			return fmt.Errorf("%w: section %d needs text", ErrInvalidGoogleChatCard, i)
+		}
+	}
+	if len(c.Buttons) > MaxGoogleChatCardButtons {
+		return fmt.Errorf("%w: at most %d buttons are allowed", ErrInvalidGoogleChatCard, MaxGoogleChatCardButtons)
+	}
+	for i, button := range c.Buttons {
+		if strings.TrimSpace(button.Text) == "" || !isHTTPSURL(button.URL) {
+			return fmt.Errorf("%w: button %d needs text and an https URL", ErrInvalidGoogleChatCard, i)
+		}
+	}
+	return nil
+}
+
+// texts returns every string of the card, in order
+func (c *GoogleChatCard) texts() []string {
+	texts := []string{c.Title, c.Subtitle, c.ImageURL}
+	for _, section := range c.Sections {
+		texts = append(texts, section.Header, section.Text)
+	}
+	for _, button := range c.Buttons {
+		texts = append(texts, button.Text, button.URL)
+	}
+	return texts
+}
+
+// ValidateGoogleChatNotification validates the Google Chat notification request
+func ValidateGoogleChatNotification(notification *GoogleChatNotificationRequest) error {
+	if notification == nil {
+		return fmt.Errorf("google chat notification cannot be nil")
+	}
+
+	if notification.ID == "" {
+		return fmt.Errorf("google chat notification ID is required")
+	}
+
+	if notification.Type == "" {
+		return fmt.Errorf("google chat notification type is required")
+	}
+
+	// Validate content
+	if notification.Content.Text == "" && notification.Content.Card == nil {
+		return fmt.Errorf("google chat text or card is required")
+	}
+	if notification.Content.Card != nil {
+		if err := notification.Content.Card.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// Validate recipient
+	if err := ValidateGoogleChatSpace(notification.Recipient); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ValidateGoogleChatSpace checks that a Google Chat recipient is a space name or the incoming
+// webhook URL of a space
+func ValidateGoogleChatSpace(space string) error {
+	if googleChatSpaceRegex.MatchString(space) || IsGoogleChatWebhookURL(space) {
+		return nil
+	}
+	return ErrInvalidGoogleChatSpace
+}
+
+// IsGoogleChatWebhookURL reports whether address is the incoming webhook URL of a space, such
+// as https://chat.googleapis.com/v1/spaces/AAAAqZ1yV4k/messages?key=...&token=...
+func IsGoogleChatWebhookURL(address string) bool {
+	parsed, err := url.Parse(address)
+	if err != nil || parsed.Scheme != "https" || parsed.Host != "chat.googleapis.com" {
+		return false
+	}
+	query := parsed.Query()
+	return googleChatWebhookPathRegex.MatchString(parsed.Path) && query.Get("key") != "" && query.Get("token") != ""
+}
+
+// isHTTPSURL reports whether value is an absolute https URL
+func isHTTPSURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && parsed.Scheme == "https" && parsed.Host != ""
+}
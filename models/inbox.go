@@ -0,0 +1,269 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	// Embed the time zone database so quiet hours work in images without tzdata
+	_ "time/tzdata"
+)
+
+// InboxItem is an in-app notification kept in a user's inbox
+type InboxItem struct {
+	ID             string        `json:"id"`
+	UserID         string        `json:"user_id"`
+	NotificationID string        `json:"notification_id"`
+	Title          string        `json:"title"`
+	Body           string        `json:"body"`
+	Reason         string        `json:"reason,omitempty"`
+	ThreadKey      string        `json:"thread_key,omitempty"`
+	Muted          bool          `json:"muted,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+	ReadAt         *time.Time    `json:"read_at,omitempty"`
+	Transactional  bool          `json:"transactional,omitempty"`
+	Actions        []InboxAction `json:"actions,omitempty"`
+	ActedAt        *time.Time    `json:"acted_at,omitempty"`
+	ActionID       string        `json:"action_id,omitempty"`
+	ArchivedAt     *time.Time    `json:"archived_at,omitempty"`
+	UnarchivedAt   *time.Time    `json:"unarchived_at,omitempty"`
+}
+
+// KeptSince returns when the item was last put in the inbox: its creation, or its latest unarchiving
+func (i *InboxItem) KeptSince() time.Time {
+	if i.UnarchivedAt != nil {
+		return *i.UnarchivedAt
+	}
+	return i.CreatedAt
+}
+
+// InboxFilter selects the items listed from a user's inbox
+type InboxFilter struct {
+	UnreadOnly bool
+	// Archived lists the user's archive instead of their inbox
+	Archived  bool
+	ThreadKey string
+}
+
+// InboxThread summarizes the items of a thread in a user's inbox
+type InboxThread struct {
+	ThreadKey    string    `json:"thread_key"`
+	ItemCount    int       `json:"item_count"`
+	UnreadCount  int       `json:"unread_count"`
+	LatestItemID string    `json:"latest_item_id"`
+	LatestAt     time.Time `json:"latest_at"`
+}
+
+// Styles of an inbox action button
+const (
+	InboxActionPrimary     = "primary"
+	InboxActionSecondary   = "secondary"
+	InboxActionDestructive = "destructive"
+)
+
+// Limits on the actions of an inbox item
+const (
+	MaxInboxActions     = 3
+	MaxInboxActionLabel = 40
+	MaxInboxActionURL   = 2048
+)
+
+// InboxAction is a button shown with an inbox item. URL is a web link or a deep link into an app.
+type InboxAction struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	URL   string `json:"url"`
+	Style string `json:"style,omitempty"`
+}
+
+// inboxActionIDPattern matches the IDs clients report when an action is taken
+var inboxActionIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// blockedActionSchemes run code or embed content instead of opening a page or an app
+var blockedActionSchemes = map[string]bool{
+	"javascript": true,
+	"vbscript":   true,
+	"data":       true,
+	"file":       true,
+}
+
+// ParseInboxActions reads the actions of a request's in_app content, a list of objects with an
+// id, a label, a URL and an optional style. A nil value has none.
+func ParseInboxActions(value interface{}) ([]InboxAction, error) {
+	if value == nil {
+		return nil, nil
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: actions must be a list", ErrInvalidInboxAction)
+	}
+	if len(items) > MaxInboxActions {
+		return nil, fmt.Errorf("%w: at most %d actions are allowed", ErrInvalidInboxAction, MaxInboxActions)
+	}
+
+	actions := make([]InboxAction, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	for i, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: action %d must be an object", ErrInvalidInboxAction, i)
+		}
+		id, _ := fields["id"].(string)
+		if !inboxActionIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("%w: action %d needs an id of up to 64 letters, digits, dashes or underscores", ErrInvalidInboxAction, i)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("%w: action id %s is used more than once", ErrInvalidInboxAction, id)
+		}
+		seen[id] = true
+
+		label, _ := fields["label"].(string)
+		if strings.TrimSpace(label) == "" || utf8.RuneCountInString(label) > MaxInboxActionLabel {
+			return nil, fmt.Errorf("%w: action %s needs a label of up to %d characters", ErrInvalidInboxAction, id, MaxInboxActionLabel)
+		}
+		link, _ := fields["url"].(string)
+		if !isValidActionURL(link) {
+			return nil, fmt.Errorf("%w: action %s needs an http(s) URL or an app deep link", ErrInvalidInboxAction, id)
+		}
+		style, _ := fields["style"].(string)
+		if !IsValidInboxActionStyle(style) {
+			return nil, fmt.Errorf("%w: action %s has an unknown style, expected primary, secondary or destructive", ErrInvalidInboxAction, id)
+		}
+		actions = append(actions, InboxAction{ID: id, Label: label, URL: link, Style: style})
+	}
+	return actions, nil
+}
+
+// IsValidInboxActionStyle checks whether style is a supported action style; empty leaves it to the app
+func IsValidInboxActionStyle(style string) bool {
+	switch style {
+	case "", InboxActionPrimary, InboxActionSecondary, InboxActionDestructive:
+		return true
+	}
+	return false
+}
+
+// isValidActionURL accepts absolute http(s) URLs with a host and deep links with a custom scheme
+// such as myapp://orders/42
+func isValidActionURL(link string) bool {
+	if link == "" || len(link) > MaxInboxActionURL || strings.ContainsAny(link, " \t\r\n") {
+		return false
+	}
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Scheme == "" {
+		return false
+	}
+	scheme := strings.ToLower(parsed.Scheme)
+	switch {
+	case blockedActionSchemes[scheme]:
+		return false
+	case scheme == "http" || scheme == "https":
+		return parsed.Host != ""
+	}
+	return parsed.Host != "" || parsed.Opaque != "" || parsed.Path != ""
+}
+
+// Digest frequencies for the email summary of unread inbox items
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// IsValidDigestFrequency checks if a digest frequency is supported
+func IsValidDigestFrequency(frequency string) bool {
+	switch frequency {
+	case DigestOff, DigestDaily, DigestWeekly:
+		return true
+	}
+	return false
+}
+
+// DigestPeriod returns how often a digest with the given frequency is sent, or 0 when digests are off
+func DigestPeriod(frequency string) time.Duration {
+	switch frequency {
+	case DigestDaily:
+		return 24 * time.Hour
+	case DigestWeekly:
+		return 7 * 24 * time.Hour
+	}
+	return 0
+}
+
+// QuietHours is a daily window, in the user's time zone, in which digests are not sent.
+// Start and End use the 24 hour HH:MM format; a window may wrap past midnight.
+type QuietHours struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// NotificationPreferences holds the notification settings a user has opted into
+type NotificationPreferences struct {
+	UserID     string                         `json:"user_id"`
+	Digest     string                         `json:"digest"`
+	QuietHours *QuietHours                    `json:"quiet_hours,omitempty"`
+	Categories map[string]*CategoryPreference `json:"categories,omitempty"`
+	// MutedThreads are the thread keys whose push and in-app notifications the user does not want
+	// to be alerted about
+	MutedThreads []string  `json:"muted_threads,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// DefaultNotificationPreferences returns the preferences of a user who has not set any
+func DefaultNotificationPreferences(userID string) *NotificationPreferences {
+	return &NotificationPreferences{
+		UserID: userID,
+		Digest: DigestOff,
+	}
+}
+
+// Location returns the time zone of the quiet hours, UTC when none is set
+func (q *QuietHours) Location() (*time.Location, error) {
+	if q.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(q.Timezone)
+}
+
+// Contains reports whether t falls within the quiet hours
+func (q *QuietHours) Contains(t time.Time) bool {
+	location, err := q.Location()
+	if err != nil {
+		return false
+	}
+	start, err := parseClockMinutes(q.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClockMinutes(q.End)
+	if err != nil {
+		return false
+	}
+
+	local := t.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	if start <= end {
+		return minute >= start && minute < end
+	}
+	// The window wraps past midnight, e.g. 22:00 to 07:00
+	return minute >= start || minute < end
+}
+
+// ParseClock checks that value is a time of day in the 24 hour HH:MM format
+func ParseClock(value string) error {
+	_, err := parseClockMinutes(value)
+	return err
+}
+
+// parseClockMinutes returns the minutes since midnight of a HH:MM time of day
+func parseClockMinutes(value string) (int, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", value)
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
@@ -0,0 +1,168 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Actions of incident notifications. A trigger opens an alert, or updates the open alert with
+// the same dedupe key; acknowledge and resolve act on the alert of the dedupe key.
+const (
+	IncidentActionTrigger     = "trigger"
+	IncidentActionAcknowledge = "acknowledge"
+	IncidentActionResolve     = "resolve"
+)
+
+// Severities of incident notifications, in the terms of PagerDuty
+const (
+	IncidentSeverityCritical = "critical"
+	IncidentSeverityError    = "error"
+	IncidentSeverityWarning  = "warning"
+	IncidentSeverityInfo     = "info"
+)
+
+const (
+	// MaxDedupeKeyLength is the longest dedupe key of a notification
+	MaxDedupeKeyLength = 128
+
+	// MaxIncidentSummaryLength is the longest summary PagerDuty and Opsgenie accept
+	MaxIncidentSummaryLength = 1024
+)
+
+// dedupeKeyPattern matches dedupe keys such as checkout:error-rate or db-primary/disk
+var dedupeKeyPattern = regexp.MustCompile(`^[A-Za-z0-9._:/@#-]{1,128}$`)
+
+// incidentRouteRegex matches PagerDuty integration keys and Opsgenie team names
+var incidentRouteRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,99}$`)
+
+// IsValidDedupeKey checks whether key can be used as the dedupe key of a notification
+func IsValidDedupeKey(key string) bool {
+	return dedupeKeyPattern.MatchString(key)
+}
+
+// IncidentNotificationRequest represents an incident notification request
+type IncidentNotificationRequest struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Content IncidentContent `json:"content"`
+	// Recipient is the integration key of a PagerDuty service or the name of an Opsgenie team
+	Recipient string `json:"recipient"`
+	UserID    string `json:"user_id,omitempty"`
+	QueuedAt  int64  `json:"queued_at,omitempty"` // Unix milliseconds when posted to its channel
+	// DedupeKey identifies the alert; it is the dedupe key of the request, or the notification
+	// ID for requests without one
+	DedupeKey string `json:"dedupe_key"`
+}
+
+// SetQueuedAt records when the notification was posted to its channel
+func (n *IncidentNotificationRequest) SetQueuedAt(t time.Time) {
+	n.QueuedAt = t.UnixMilli()
+}
+
+// IncidentContent represents the content of an incident notification. Summary and severity
+// are only needed to trigger an alert.
+type IncidentContent struct {
+	Action   string                 `json:"action"`
+	Summary  string                 `json:"summary,omitempty"`
+	Severity string                 `json:"severity,omitempty"`
+	Source   string                 `json:"source,omitempty"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+	Link     string                 `json:"link,omitempty"`
+}
+
+// IncidentResponse represents the response from PagerDuty or Opsgenie
+type IncidentResponse struct {
+	ID      string    `json:"id"`
+	Status  string    `json:"status"`
+	Message string    `json:"message"`
+	SentAt  time.Time `json:"sent_at"`
+	Channel string    `json:"channel"`
+	// Provider is pagerduty or opsgenie, and DedupeKey the key the provider identifies the alert by
+	Provider  string `json:"provider,omitempty"`
+	DedupeKey string `json:"dedupe_key,omitempty"`
+	// RequestID is the ID the provider gave the event, to look it up in its logs
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// ParseIncidentContent reads the content of an incident notification. The action defaults to
+// trigger.
+func ParseIncidentContent(content map[string]interface{}) (IncidentContent, error) {
+	encoded, err := json.Marshal(content)
+	if err != nil {
+		return IncidentContent{}, fmt.Errorf("%w: %v", ErrInvalidIncidentContent, err)
+	}
+	var parsed IncidentContent
+	if err := json.Unmarshal(encoded, &parsed); err != nil {
+		return IncidentContent{}, fmt.Errorf("%w: %v", ErrInvalidIncidentContent, err)
+	}
+	if parsed.Action == "" {
+		parsed.Action = IncidentActionTrigger
+	}
+	return parsed, parsed.Validate()
+}
+
+// Validate checks the action of the content and, for triggers, the summary, severity and link
+func (c *IncidentContent) Validate() error {
+	switch c.Action {
+	case IncidentActionTrigger:
+	case IncidentActionAcknowledge, IncidentActionResolve:
+		return nil
+	default:
+		return fmt.Errorf("%w: action must be trigger, acknowledge or resolve", ErrInvalidIncidentContent)
+	}
+
+	if strings.TrimSpace(c.Summary) == "" {
+		return fmt.Errorf("%w: a summary is required", ErrInvalidIncidentContent)
+	}
+	if len(c.Summary) > MaxIncidentSummaryLength {
+		return fmt.Errorf("%w: summary cannot exceed %d bytes", ErrInvalidIncidentContent, MaxIncidentSummaryLength)
+	}
+	switch c.Severity {
+	case IncidentSeverityCritical, IncidentSeverityError, IncidentSeverityWarning, IncidentSeverityInfo:
+	default:
+		return fmt.Errorf("%w: severity must be critical, error, warning or info", ErrInvalidIncidentContent)
+	}
+	if c.Link != "" && !isHTTPSURL(c.Link) {
+		return fmt.Errorf("%w: link must be an https URL", ErrInvalidIncidentContent)
+	}
+	return nil
+}
+
+// ValidateIncidentNotification validates the incident notification request
+func ValidateIncidentNotification(notification *IncidentNotificationRequest) error {
+	if notification == nil {
+		return fmt.Errorf("incident notification cannot be nil")
+	}
+
+	if notification.ID == "" {
+		return fmt.Errorf("incident notification ID is required")
+	}
+
+	if notification.Type == "" {
+		return fmt.Errorf("incident notification type is required")
+	}
+
+	if !IsValidDedupeKey(notification.DedupeKey) {
+		return fmt.Errorf("incident dedupe key is required")
+	}
+
+	// Validate content
+	if err := notification.Content.Validate(); err != nil {
+		return err
+	}
+
+	// Validate recipient
+	return ValidateIncidentRoute(notification.Recipient)
+}
+
+// ValidateIncidentRoute checks that an incident recipient is a PagerDuty integration key or
+// an Opsgenie team name
+func ValidateIncidentRoute(route string) error {
+	if !incidentRouteRegex.MatchString(route) {
+		return ErrInvalidIncidentRoute
+	}
+	return nil
+}
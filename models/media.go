@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// MediaAsset is an image or document uploaded for use in notifications. It is served at a
+// durable URL that templates reference, e.g. in an email body.
+type MediaAsset struct {
+	ID          string    `json:"id"`
+	Tenant      string    `json:"tenant"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	URL         string    `json:"url"`
+	Key         string    `json:"-"` // Key of the object in the media store
+	CreatedAt   time.Time `json:"created_at"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+}
+
+// mediaExtensions are the extensions media assets are stored with, by the content types that
+// can be uploaded. SVG is not allowed, as it can carry scripts.
+var mediaExtensions = map[string]string{
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// MediaExtension returns the extension media assets of contentType are stored with, and false
+// if assets of contentType cannot be uploaded
+func MediaExtension(contentType string) (string, bool) {
+	extension, ok := mediaExtensions[contentType]
+	return extension, ok
+}
@@ -11,6 +11,12 @@ const (
 	EmailNotification NotificationType = "email"
 	SlackNotification NotificationType = "slack"
 	InAppNotification NotificationType = "in_app"
+	// GoogleChatNotification posts to Google Chat spaces
+	GoogleChatNotification NotificationType = "google_chat"
+	// IncidentNotification creates PagerDuty or Opsgenie alerts for urgent notifications
+	IncidentNotification NotificationType = "incident"
+	// RCSNotification sends RCS business messages to phone numbers, and SMS to handsets without RCS
+	RCSNotification NotificationType = "rcs"
 )
 
 // NotificationResponse represents the response after sending a notification
@@ -21,3 +27,9 @@ type NotificationResponse struct {
 	SentAt  time.Time `json:"sent_at"`
 	Channel string    `json:"channel"`
 }
+
+// QueuedMessage is a channel message that records when it was queued, so consumers can tell
+// how long it waited before being processed
+type QueuedMessage interface {
+	SetQueuedAt(t time.Time)
+}
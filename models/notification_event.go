@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// NotificationEventSchemaVersion is the version of the NotificationEvent schema. It is raised
+// when fields are removed or change meaning; new optional fields keep the version.
+const NotificationEventSchemaVersion = 1
+
+// Notification lifecycle event types
+const (
+	// EventNotificationStatusChanged is published when a notification moves to another status,
+	// including pending when it is created
+	EventNotificationStatusChanged = "notification.status_changed"
+
+	// EventDeliveryStatusChanged is published for every delivery attempt to a recipient and when
+	// a receipt confirms that a push reached the device
+	EventDeliveryStatusChanged = "delivery.status_changed"
+)
+
+// NotificationEvent is a notification state transition published to the notification-events topic
+type NotificationEvent struct {
+	SchemaVersion    int       `json:"schema_version"`
+	EventID          string    `json:"event_id"`
+	EventType        string    `json:"event_type"`
+	OccurredAt       time.Time `json:"occurred_at"`
+	NotificationID   string    `json:"notification_id"`
+	NotificationType string    `json:"notification_type,omitempty"`
+	ExternalID       string    `json:"external_id,omitempty"`
+	Category         string    `json:"category,omitempty"`
+	Tags             []string  `json:"tags,omitempty"`
+	Tenant           string    `json:"tenant,omitempty"`
+	FromStatus       string    `json:"from_status,omitempty"`
+	Status           string    `json:"status"`
+	UserID           string    `json:"user_id,omitempty"`
+	Recipient        string    `json:"recipient,omitempty"`
+	Channel          string    `json:"channel,omitempty"`
+	Attempt          int       `json:"attempt,omitempty"`
+	Error            string    `json:"error,omitempty"`
+}
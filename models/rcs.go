@@ -0,0 +1,189 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gaurav2721/notification-service/textlimit"
+)
+
+// How an RCS notification reached its recipient: as an RCS message, or as an SMS to handsets
+// that do not support RCS
+const (
+	RCSDeliveredAsRCS = "rcs"
+	RCSDeliveredAsSMS = "sms"
+)
+
+const (
+	// MaxRCSSuggestions is the largest number of suggestions an RCS message may have
+	MaxRCSSuggestions = 11
+
+	// MaxRCSSuggestionTextLength is the longest text of a suggestion chip
+	MaxRCSSuggestionTextLength = 25
+
+	// MaxRCSCardTitleLength and MaxRCSCardDescriptionLength are the limits of rich card texts
+	MaxRCSCardTitleLength       = 200
+	MaxRCSCardDescriptionLength = 2000
+)
+
+// RCSNotificationRequest represents an RCS notification request
+type RCSNotificationRequest struct {
+	ID      string     `json:"id"`
+	Type    string     `json:"type"`
+	Content RCSContent `json:"content"`
+	// Recipient is the phone number of the recipient in E.164 format
+	Recipient string `json:"recipient"`
+	UserID    string `json:"user_id,omitempty"`
+	QueuedAt  int64  `json:"queued_at,omitempty"` // Unix milliseconds when posted to its channel
+}
+
+// SetQueuedAt records when the notification was posted to its channel
+func (n *RCSNotificationRequest) SetQueuedAt(t time.Time) {
+	n.QueuedAt = t.UnixMilli()
+}
+
+// RCSContent represents the content of an RCS notification. A message needs text, a card or
+// both; text is sent as a message of its own before the card.
+type RCSContent struct {
+	Text        string          `json:"text,omitempty"`
+	Card        *RCSCard        `json:"card,omitempty"`
+	Suggestions []RCSSuggestion `json:"suggestions,omitempty"`
+	// FallbackText is sent by SMS to handsets without RCS. Without it the SMS is made of the
+	// text, the card and the links of the suggestions.
+	FallbackText string `json:"fallback_text,omitempty"`
+}
+
+// RCSCard is a rich card with a title, a description and an image or video
+type RCSCard struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MediaURL    string `json:"media_url,omitempty"`
+}
+
+// RCSSuggestion is a suggestion chip shown below the message. Chips with a URL open it, the
+// others send their text back as a reply.
+type RCSSuggestion struct {
+	Text string `json:"text"`
+	URL  string `json:"url,omitempty"`
+}
+
+// RCSResponse represents the response from RCS message sending
+type RCSResponse struct {
+	ID      string    `json:"id"`
+	Status  string    `json:"status"`
+	Message string    `json:"message"`
+	SentAt  time.Time `json:"sent_at"`
+	Channel string    `json:"channel"`
+	// DeliveredAs is rcs, or sms when the handset of the recipient does not support RCS
+	DeliveredAs string `json:"delivered_as"`
+	// MessageID is the ID the RCS agent or the SMS provider gave the message
+	MessageID string `json:"message_id,omitempty"`
+}
+
+// ParseRCSContent reads the content of an RCS notification
+func ParseRCSContent(content map[string]interface{}) (RCSContent, error) {
+	encoded, err := json.Marshal(content)
+	if err != nil {
+		return RCSContent{}, fmt.Errorf("%w: %v", ErrInvalidRCSContent, err)
+	}
+	var parsed RCSContent
+	if err := json.Unmarshal(encoded, &parsed); err != nil {
+		return RCSContent{}, fmt.Errorf("%w: %v", ErrInvalidRCSContent, err)
+	}
+	return parsed, parsed.Validate()
+}
+
+// Validate checks that the content has text or a card, and that the card and the suggestions
+// are within the limits of RCS
+func (c *RCSContent) Validate() error {
+	if strings.TrimSpace(c.Text) == "" && c.Card == nil {
+		return fmt.Errorf("%w: text or a card is required", ErrInvalidRCSContent)
+	}
+	if card := c.Card; card != nil {
+		if strings.TrimSpace(card.Title) == "" && strings.TrimSpace(card.Description) == "" && card.MediaURL == "" {
+			return fmt.Errorf("%w: a card needs a title, a description or a media_url", ErrInvalidRCSContent)
+		}
+		if utf8.RuneCountInString(card.Title) > MaxRCSCardTitleLength {
+			return fmt.Errorf("%w: card title cannot exceed %d characters", ErrInvalidRCSContent, MaxRCSCardTitleLength)
+		}
+		if utf8.RuneCountInString(card.Description) > MaxRCSCardDescriptionLength {
+			return fmt.Errorf("%w: card description cannot exceed %d characters", ErrInvalidRCSContent, MaxRCSCardDescriptionLength)
+		}
+		if card.MediaURL != "" && !isHTTPSURL(card.MediaURL) {
+			return fmt.Errorf("%w: media_url must be an https URL", ErrInvalidRCSContent)
+		}
+	}
+	if len(c.Suggestions) > MaxRCSSuggestions {
+		return fmt.Errorf("%w: at most %d suggestions are allowed", ErrInvalidRCSContent, MaxRCSSuggestions)
+	}
+	for i, suggestion := range c.Suggestions {
+		if strings.TrimSpace(suggestion.Text) == "" || utf8.RuneCountInString(suggestion.Text) > MaxRCSSuggestionTextLength {
+			return fmt.Errorf("%w: suggestion %d needs text of up to %d characters", ErrInvalidRCSContent, i, MaxRCSSuggestionTextLength)
+		}
+		if suggestion.URL != "" && !isHTTPSURL(suggestion.URL) {
+			return fmt.Errorf("%w: suggestion %d url must be an https URL", ErrInvalidRCSContent, i)
+		}
+	}
+	return nil
+}
+
+// SMSText returns the body of the SMS sent to handsets without RCS, cut to the longest SMS
+// carriers accept. Suggestions without a URL are left out, since an SMS cannot offer replies.
+func (c *RCSContent) SMSText() string {
+	text := strings.TrimSpace(c.FallbackText)
+	if text == "" {
+		var parts []string
+		add := func(part string) {
+			if part = strings.TrimSpace(part); part != "" {
+				parts = append(parts, part)
+			}
+		}
+		add(c.Text)
+		if c.Card != nil {
+			add(c.Card.Title)
+			add(c.Card.Description)
+		}
+		for _, suggestion := range c.Suggestions {
+			if suggestion.URL != "" {
+				parts = append(parts, suggestion.Text+": "+suggestion.URL)
+			}
+		}
+		text = strings.Join(parts, "\n\n")
+	}
+
+	if utf8.RuneCountInString(text) <= textlimit.MaxSMSTextLength {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:textlimit.MaxSMSTextLength-1]) + "…"
+}
+
+// ValidateRCSNotification validates the RCS notification request
+func ValidateRCSNotification(notification *RCSNotificationRequest) error {
+	if notification == nil {
+		return fmt.Errorf("rcs notification cannot be nil")
+	}
+
+	if notification.ID == "" {
+		return fmt.Errorf("rcs notification ID is required")
+	}
+
+	if notification.Type == "" {
+		return fmt.Errorf("rcs notification type is required")
+	}
+
+	// Validate content
+	if err := notification.Content.Validate(); err != nil {
+		return err
+	}
+
+	// Validate recipient
+	if !phoneNumberRegex.MatchString(notification.Recipient) {
+		return ErrInvalidPhoneNumber
+	}
+
+	return nil
+}
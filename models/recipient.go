@@ -0,0 +1,149 @@
+package models
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+// Kinds of address recipients. Recipients are user IDs unless they start with a kind and a
+// colon, such as "email:alice@example.com", "slack:#ops", "google_chat:spaces/AAAAqZ1yV4k",
+// "incident:platform-oncall" or "phone:+14155550123".
+const (
+	RecipientEmail      = "email"
+	RecipientSlack      = "slack"
+	RecipientGoogleChat = "google_chat"
+	RecipientIncident   = "incident"
+	RecipientPhone      = "phone"
+)
+
+// maxEmailAddressLength is the longest email address that can be delivered to (RFC 5321)
+const maxEmailAddressLength = 254
+
+// slackChannelRegex matches Slack channel names such as "#ops" and channel IDs such as "C0123ABCD"
+var slackChannelRegex = regexp.MustCompile(`^(#[a-z0-9_-]{1,80}|[CGD][A-Z0-9]{8,})$`)
+
+// phoneNumberRegex matches phone numbers in E.164 format
+var phoneNumberRegex = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// ParseRecipientAddress splits an address recipient into its kind and address. ok is false
+// for user IDs and unknown prefixes.
+func ParseRecipientAddress(recipient string) (kind, address string, ok bool) {
+	kind, address, found := strings.Cut(recipient, ":")
+	if !found {
+		return "", "", false
+	}
+	switch kind {
+	case RecipientEmail, RecipientSlack, RecipientGoogleChat, RecipientIncident, RecipientPhone:
+		return kind, address, true
+	}
+	return "", "", false
+}
+
+// ValidateRecipientAddress checks the format of an address of the given kind
+func ValidateRecipientAddress(kind, address string) error {
+	switch kind {
+	case RecipientEmail:
+		parsed, err := mail.ParseAddress(address)
+		// Display names are not allowed, the recipient is the bare address
+		if err != nil || parsed.Address != address || len(address) > maxEmailAddressLength {
+			return ErrInvalidEmail
+		}
+	case RecipientSlack:
+		if !slackChannelRegex.MatchString(address) {
+			return ErrInvalidSlackChannel
+		}
+	case RecipientGoogleChat:
+		return ValidateGoogleChatSpace(address)
+	case RecipientIncident:
+		return ValidateIncidentRoute(address)
+	case RecipientPhone:
+		if !phoneNumberRegex.MatchString(address) {
+			return ErrInvalidPhoneNumber
+		}
+	default:
+		return ErrInvalidRecipientKind
+	}
+	return nil
+}
+
+// RecipientKindForType returns the kind of address recipients a notification type delivers to,
+// empty for types that only deliver to users
+func RecipientKindForType(notificationType string) string {
+	switch NotificationType(notificationType) {
+	case EmailNotification:
+		return RecipientEmail
+	case SlackNotification:
+		return RecipientSlack
+	case GoogleChatNotification:
+		return RecipientGoogleChat
+	case IncidentNotification:
+		return RecipientIncident
+	case RCSNotification:
+		return RecipientPhone
+	}
+	return ""
+}
+
+// AddressRecipientInfo returns the notification info of an address recipient. The recipient
+// itself is used as the ID, since it is not a user of the service.
+func AddressRecipientInfo(recipient string) *UserNotificationInfo {
+	info := &UserNotificationInfo{ID: recipient}
+	kind, address, _ := ParseRecipientAddress(recipient)
+	switch kind {
+	case RecipientEmail:
+		info.Email = address
+	case RecipientSlack:
+		info.SlackChannel = address
+	case RecipientGoogleChat:
+		info.GoogleChatSpace = address
+	case RecipientIncident:
+		info.IncidentRoute = address
+	case RecipientPhone:
+		info.PhoneNumber = address
+	}
+	return info
+}
+
+// RecipientCheckRequest asks which recipients a notification would reach, without sending it
+type RecipientCheckRequest struct {
+	Type          string   `json:"type"`
+	Recipients    []string `json:"recipients"`
+	Category      string   `json:"category,omitempty"`
+	Transactional bool     `json:"transactional,omitempty"`
+	ThreadKey     string   `json:"thread_key,omitempty"`
+	// Tenant is the name of the API key the request was sent with; it is set by the server
+	Tenant string `json:"-"`
+}
+
+// Outcomes of a recipient check
+const (
+	RecipientReachable    = "reachable"
+	RecipientMissing      = "missing"
+	RecipientInactive     = "inactive"
+	RecipientOptedOut     = "opted_out"
+	RecipientThreadMuted  = "thread_muted"
+	RecipientNoContact    = "no_contact"
+	RecipientUnverified   = "unverified"
+	RecipientSuppressed   = "suppressed"
+	RecipientDoNotDisturb = "do_not_disturb"
+)
+
+// RecipientCheck is the outcome of checking one recipient
+type RecipientCheck struct {
+	Recipient string `json:"recipient"`
+	Status    string `json:"status"`
+	// Channel is the channel the recipient is sent on when a routing policy changes it
+	Channel string `json:"channel,omitempty"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// RecipientReport lists the outcome of a recipient check for every recipient, in request order
+type RecipientReport struct {
+	Type       string           `json:"type"`
+	Category   string           `json:"category"`
+	Total      int              `json:"total"`
+	Reachable  int              `json:"reachable"`
+	Counts     map[string]int   `json:"counts"`
+	Recipients []RecipientCheck `json:"recipients"`
+}
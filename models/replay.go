@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// QueueReplayRequest selects the archived channel messages to post to their channel again
+type QueueReplayRequest struct {
+	// Channel is the channel the messages were posted to: email, slack, ios_push or android_push
+	Channel string `json:"channel" binding:"required"`
+	// From and To bound the time the messages were originally queued, From inclusive, To exclusive
+	From time.Time `json:"from" binding:"required"`
+	To   time.Time `json:"to" binding:"required"`
+	// DryRun lists the messages that would be replayed without posting them
+	DryRun bool `json:"dry_run"`
+	// OnlyFailed skips the messages whose latest delivery attempt did not fail, e.g. the ones a
+	// provider accepted before an outage started
+	OnlyFailed bool `json:"only_failed"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// SenderSettings holds the email sender settings of a tenant. Email notifications sent without
+// a from address use DefaultFrom, and from addresses outside AllowedDomains are rejected.
+type SenderSettings struct {
+	Tenant      string `json:"tenant"`
+	DefaultFrom string `json:"default_from,omitempty"`
+	// AllowedDomains lists the domains from addresses may use; "*.example.com" also allows the
+	// subdomains of example.com. Empty allows every domain.
+	AllowedDomains []string  `json:"allowed_domains,omitempty"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	UpdatedBy      string    `json:"updated_by,omitempty"`
+}
+
+// AllowsAddress reports whether an email address is on one of the allowed domains
+func (s SenderSettings) AllowsAddress(address string) bool {
+	if len(s.AllowedDomains) == 0 {
+		return true
+	}
+
+	domain := EmailDomain(address)
+	if domain == "" {
+		return false
+	}
+	for _, allowed := range s.AllowedDomains {
+		allowed = strings.ToLower(allowed)
+		if parent, ok := strings.CutPrefix(allowed, "*."); ok {
+			if domain == parent || strings.HasSuffix(domain, "."+parent) {
+				return true
+			}
+		} else if domain == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// EmailDomain returns the lowercased domain of an email address, which may include a display
+// name; it is empty for invalid addresses
+func EmailDomain(address string) string {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return ""
+	}
+	at := strings.LastIndexByte(parsed.Address, '@')
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(parsed.Address[at+1:])
+}
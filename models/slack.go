@@ -12,6 +12,15 @@ type SlackNotificationRequest struct {
 	Type      string       `json:"type"`
 	Content   SlackContent `json:"content"`
 	Recipient string       `json:"recipient"`
+	UserID    string       `json:"user_id,omitempty"`
+	QueuedAt  int64        `json:"queued_at,omitempty"` // Unix milliseconds when posted to its channel
+	// ThreadKey posts the message as a reply in the thread of the first message with the same key
+	ThreadKey string `json:"thread_key,omitempty"`
+}
+
+// SetQueuedAt records when the notification was posted to its channel
+func (n *SlackNotificationRequest) SetQueuedAt(t time.Time) {
+	n.QueuedAt = t.UnixMilli()
 }
 
 // SlackContent represents the content of a slack notification
@@ -26,6 +35,8 @@ type SlackResponse struct {
 	Message string    `json:"message"`
 	SentAt  time.Time `json:"sent_at"`
 	Channel string    `json:"channel"`
+	// ThreadTS is the timestamp of the thread the message was posted in, if any
+	ThreadTS string `json:"thread_ts,omitempty"`
 }
 
 // ValidateSlackNotification validates the slack notification request
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,6 +13,64 @@ type TemplateData struct {
 	ID      string                 `json:"id"`
 	Version int                    `json:"version"`
 	Data    map[string]interface{} `json:"data"`
+	// RecipientData maps recipient user IDs to variables merged over Data for that recipient only
+	RecipientData map[string]map[string]interface{} `json:"recipient_data,omitempty"`
+}
+
+// DataFor returns the template variables for a recipient: the shared data with the recipient's overrides applied
+func (t *TemplateData) DataFor(recipient string) map[string]interface{} {
+	overrides := t.RecipientData[recipient]
+	if len(overrides) == 0 {
+		return t.Data
+	}
+
+	data := make(map[string]interface{}, len(t.Data)+len(overrides))
+	for key, value := range t.Data {
+		data[key] = value
+	}
+	for key, value := range overrides {
+		data[key] = value
+	}
+	return data
+}
+
+// Recipient variables are filled in from the user record of each recipient when a template is rendered
+const (
+	RecipientNameVariable      = "recipient_name"
+	RecipientFirstNameVariable = "recipient_first_name"
+	RecipientEmailVariable     = "recipient_email"
+)
+
+// IsRecipientVariable reports whether a template variable is filled in from the recipient's user record
+func IsRecipientVariable(name string) bool {
+	switch name {
+	case RecipientNameVariable, RecipientFirstNameVariable, RecipientEmailVariable:
+		return true
+	}
+	return false
+}
+
+// WithRecipientVariables returns data with the recipient variables of user added.
+// Variables already present in data are kept, so callers can still override them.
+func WithRecipientVariables(data map[string]interface{}, user *UserNotificationInfo) map[string]interface{} {
+	firstName := ""
+	if fields := strings.Fields(user.FullName); len(fields) > 0 {
+		firstName = fields[0]
+	}
+	recipientVariables := map[string]interface{}{
+		RecipientNameVariable:      user.FullName,
+		RecipientFirstNameVariable: firstName,
+		RecipientEmailVariable:     user.Email,
+	}
+
+	merged := make(map[string]interface{}, len(data)+len(recipientVariables))
+	for key, value := range recipientVariables {
+		merged[key] = value
+	}
+	for key, value := range data {
+		merged[key] = value
+	}
+	return merged
 }
 
 // TemplateContent represents the content structure for different template types
@@ -18,10 +78,17 @@ type TemplateContent struct {
 	// For Email templates
 	Subject   string `json:"subject,omitempty"`
 	EmailBody string `json:"email_body,omitempty"`
+	// EmailTextBody is the plain text alternative of the email body; it is generated when empty
+	EmailTextBody string `json:"email_text_body,omitempty"`
+	// EmailDarkModeCSS holds the CSS rules of the dark variant of the email body
+	EmailDarkModeCSS string `json:"email_dark_mode_css,omitempty"`
 
-	// For Slack templates
+	// For Slack and Google Chat templates
 	Text string `json:"text,omitempty"`
 
+	// For Google Chat templates, with or without Text; every string of the card is templated
+	Card *GoogleChatCard `json:"card,omitempty"`
+
 	// For In-App templates
 	Title string `json:"title,omitempty"`
 	Body  string `json:"body,omitempty"`
@@ -36,8 +103,22 @@ type Template struct {
 	Content           TemplateContent  `json:"content"`
 	RequiredVariables []string         `json:"required_variables"`
 	Description       string           `json:"description,omitempty"`
+	RenderMode        string           `json:"render_mode,omitempty"`
+	Category          string           `json:"category,omitempty"`
 	Status            string           `json:"status"`
 	CreatedAt         time.Time        `json:"created_at"`
+	CreatedBy         string           `json:"created_by,omitempty"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+	UpdatedBy         string           `json:"updated_by,omitempty"`
+	// Locale is the locale of Content; Localizations holds the content in further locales,
+	// keyed by BCP 47 tag
+	Locale        string                     `json:"locale,omitempty"`
+	Localizations map[string]TemplateContent `json:"localizations,omitempty"`
+	// ColorScheme selects the dark-mode hints added to emails; see EmailColorSchemeLight and
+	// EmailColorSchemeAuto
+	ColorScheme string `json:"color_scheme,omitempty"`
+	// SourceCommit is the SHA of the Git commit the version was synced from
+	SourceCommit string `json:"source_commit,omitempty"`
 }
 
 // TemplateRequest represents the request structure for creating templates
@@ -47,6 +128,13 @@ type TemplateRequest struct {
 	Content           TemplateContent  `json:"content" binding:"required"`
 	RequiredVariables []string         `json:"required_variables" binding:"required"`
 	Description       string           `json:"description,omitempty"`
+	RenderMode        string           `json:"render_mode,omitempty"`
+	Category          string           `json:"category,omitempty"`
+	// Locale is the locale of Content; Localizations holds the content in further locales
+	Locale        string                     `json:"locale,omitempty"`
+	Localizations map[string]TemplateContent `json:"localizations,omitempty"`
+	// ColorScheme selects the dark-mode hints added to emails
+	ColorScheme string `json:"color_scheme,omitempty"`
 }
 
 // TemplateResponse represents the response structure for template operations
@@ -57,6 +145,9 @@ type TemplateResponse struct {
 	Version   int       `json:"version"`
 	Status    string    `json:"status"`
 	CreatedAt time.Time `json:"created_at"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
 }
 
 // TemplateVersion represents a specific version of a template
@@ -68,8 +159,152 @@ type TemplateVersion struct {
 	Content           TemplateContent  `json:"content"`
 	RequiredVariables []string         `json:"required_variables"`
 	Description       string           `json:"description,omitempty"`
+	RenderMode        string           `json:"render_mode,omitempty"`
+	Category          string           `json:"category,omitempty"`
 	Status            string           `json:"status"`
 	CreatedAt         time.Time        `json:"created_at"`
+	CreatedBy         string           `json:"created_by,omitempty"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+	UpdatedBy         string           `json:"updated_by,omitempty"`
+	// Locale is the locale of Content; Localizations holds the content in further locales
+	Locale        string                     `json:"locale,omitempty"`
+	Localizations map[string]TemplateContent `json:"localizations,omitempty"`
+	// ColorScheme selects the dark-mode hints added to emails
+	ColorScheme string `json:"color_scheme,omitempty"`
+	// SourceCommit is the SHA of the Git commit the version was synced from
+	SourceCommit string `json:"source_commit,omitempty"`
+}
+
+// Template statuses. Localized template versions are drafts until they are activated,
+// and drafts cannot be sent.
+const (
+	TemplateStatusCreated = "created"
+	TemplateStatusDraft   = "draft"
+	TemplateStatusActive  = "active"
+)
+
+// Template audit actions
+const (
+	TemplateActionCreated  = "created"
+	TemplateActionUpdated  = "updated"
+	TemplateActionImported = "imported"
+	TemplateActionReloaded = "reloaded"
+	// TemplateActionActivated records the activation of a version rather than a new version
+	TemplateActionActivated = "activated"
+)
+
+// TemplateBundleFormat is the format version of exported template bundles
+const TemplateBundleFormat = 1
+
+// TemplateBundle holds templates with all their versions, to promote them from one environment
+// to another
+type TemplateBundle struct {
+	Format     int                   `json:"format"`
+	ExportedAt time.Time             `json:"exported_at"`
+	Templates  []TemplateBundleEntry `json:"templates"`
+}
+
+// TemplateBundleEntry is a template with its versions, oldest first
+type TemplateBundleEntry struct {
+	ID       string     `json:"id"`
+	Versions []Template `json:"versions"`
+}
+
+// TemplateConflictMode decides how a bundle is imported over a template that already exists
+type TemplateConflictMode string
+
+// Template conflict modes
+const (
+	// TemplateConflictSkip keeps the existing template
+	TemplateConflictSkip TemplateConflictMode = "skip"
+	// TemplateConflictOverwrite replaces the existing template and its versions with the bundle's
+	TemplateConflictOverwrite TemplateConflictMode = "overwrite"
+	// TemplateConflictNewVersion stores the latest version of the bundle as a new version
+	TemplateConflictNewVersion TemplateConflictMode = "new_version"
+)
+
+// ParseTemplateConflictMode parses a conflict mode, defaulting to skip when empty
+func ParseTemplateConflictMode(value string) (TemplateConflictMode, error) {
+	switch mode := TemplateConflictMode(value); mode {
+	case "":
+		return TemplateConflictSkip, nil
+	case TemplateConflictSkip, TemplateConflictOverwrite, TemplateConflictNewVersion:
+		return mode, nil
+	}
+	return "", ErrInvalidConflictMode
+}
+
+// Template import outcomes
+const (
+	TemplateImportCreated     = "created"
+	TemplateImportOverwritten = "overwritten"
+	TemplateImportNewVersion  = "new_version"
+	TemplateImportUnchanged   = "unchanged"
+	TemplateImportSkipped     = "skipped"
+	// TemplateImportConflict is the outcome of a template the import must not change, e.g. a
+	// predefined one
+	TemplateImportConflict = "conflict"
+)
+
+// TemplateImportResult is what importing a bundle did to one template
+type TemplateImportResult struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Outcome string `json:"outcome"`
+	Version int    `json:"version"`         // latest version of the template after the import
+	Error   string `json:"error,omitempty"` // why a conflicting template was left as it is
+}
+
+// TemplateFileInvalid is the outcome of a template file that could not be loaded; the other
+// outcomes of template files are the import outcomes
+const TemplateFileInvalid = "invalid"
+
+// TemplateFileStatus is what the last reload of the template directory did with one file
+type TemplateFileStatus struct {
+	File       string `json:"file"`
+	TemplateID string `json:"template_id,omitempty"`
+	Outcome    string `json:"outcome"`
+	Version    int    `json:"version,omitempty"` // latest version of the template after the reload
+	Error      string `json:"error,omitempty"`
+}
+
+// TemplateDirectoryStatus is the outcome of the last reload of the template directory
+type TemplateDirectoryStatus struct {
+	Directory  string               `json:"directory"`
+	ReloadedAt time.Time            `json:"reloaded_at"`
+	Files      []TemplateFileStatus `json:"files"`
+	// Error is set when the directory could not be read; its templates are left as they are
+	Error string `json:"error,omitempty"`
+}
+
+// TemplateGitSyncStatus is the outcome of the last sync of templates from a Git repository
+type TemplateGitSyncStatus struct {
+	Repository string               `json:"repository"`
+	Branch     string               `json:"branch"`
+	Path       string               `json:"path"`
+	Commit     string               `json:"commit,omitempty"` // commit the templates were last synced from
+	SyncedAt   time.Time            `json:"synced_at"`
+	Files      []TemplateFileStatus `json:"files"`
+	// Error is set when the repository could not be pulled or read; the templates are left as
+	// they are
+	Error string `json:"error,omitempty"`
+}
+
+// TemplateFieldChange is the old and new value of a template field changed by a new version
+type TemplateFieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// TemplateAuditEntry records who created a template version and what changed compared to the previous one
+type TemplateAuditEntry struct {
+	TemplateID string                `json:"template_id"`
+	Version    int                   `json:"version"`
+	Action     string                `json:"action"`
+	Actor      string                `json:"actor"`
+	Changes    []TemplateFieldChange `json:"changes,omitempty"`
+	At         time.Time             `json:"at"`
 }
 
 // NewTemplate creates a new notification template
@@ -113,6 +348,10 @@ func (tc *TemplateContent) ValidateTemplateContent(templateType NotificationType
 		if tc.Text == "" {
 			return ErrInvalidTemplateContent
 		}
+	case GoogleChatNotification:
+		if tc.Text == "" && (tc.Card == nil || tc.Card.Title == "") {
+			return ErrInvalidTemplateContent
+		}
 	case InAppNotification:
 		if tc.Title == "" || tc.Body == "" {
 			return ErrInvalidTemplateContent
@@ -123,9 +362,100 @@ func (tc *TemplateContent) ValidateTemplateContent(templateType NotificationType
 	return nil
 }
 
-// ValidateRequiredVariables checks if all required variables are provided
+// UsesRecipientVariables reports whether the template content, in any locale, references any
+// recipient variable
+func (t *Template) UsesRecipientVariables() bool {
+	contents := []TemplateContent{t.Content}
+	for _, content := range t.Localizations {
+		contents = append(contents, content)
+	}
+	for _, content := range contents {
+		texts := []string{content.Subject, content.EmailBody, content.EmailTextBody, content.Text, content.Title, content.Body}
+		if content.Card != nil {
+			texts = append(texts, content.Card.texts()...)
+		}
+		for _, text := range texts {
+			for _, name := range []string{RecipientNameVariable, RecipientFirstNameVariable, RecipientEmailVariable} {
+				if strings.Contains(text, "{{"+name+"}}") {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// IsLocalized reports whether the template has content in further locales
+func (t *Template) IsLocalized() bool {
+	return len(t.Localizations) > 0
+}
+
+// HasLocale reports whether the template has content in locale
+func (t *Template) HasLocale(locale string) bool {
+	if t.Locale == locale {
+		return true
+	}
+	_, exists := t.Localizations[locale]
+	return exists
+}
+
+// Localize returns the template with the content of the first locale of chain it has content in.
+// Templates without content in any of them are returned unchanged.
+func (t *Template) Localize(chain []string) *Template {
+	for _, locale := range chain {
+		if locale == t.Locale {
+			return t
+		}
+		if content, exists := t.Localizations[locale]; exists {
+			localized := *t
+			localized.Content = content
+			localized.Locale = locale
+			return &localized
+		}
+	}
+	return t
+}
+
+// NormalizeLocales brings the locale and the localization keys of the template into canonical
+// form and checks that every localization has the content its type requires
+func (t *Template) NormalizeLocales() error {
+	if t.Locale != "" {
+		locale, err := NormalizeLocale(t.Locale)
+		if err != nil {
+			return fmt.Errorf("%w: %q", err, t.Locale)
+		}
+		t.Locale = locale
+	}
+	if len(t.Localizations) == 0 {
+		t.Localizations = nil
+		return nil
+	}
+
+	localizations := make(map[string]TemplateContent, len(t.Localizations))
+	for key, content := range t.Localizations {
+		locale, err := NormalizeLocale(key)
+		if err != nil {
+			return fmt.Errorf("%w: %q", err, key)
+		}
+		if err := content.ValidateTemplateContent(t.Type); err != nil {
+			return fmt.Errorf("%w: localization %s", err, locale)
+		}
+		if _, exists := localizations[locale]; exists || locale == t.Locale {
+			return fmt.Errorf("%w: locale %s is given more than once", ErrInvalidTemplateContent, locale)
+		}
+		localizations[locale] = content
+	}
+	t.Localizations = localizations
+	return nil
+}
+
+// ValidateRequiredVariables checks if all required variables are provided.
+// Recipient and brand variables are always available and need not be provided.
 func (t *Template) ValidateRequiredVariables(data map[string]interface{}) error {
 	for _, requiredVar := range t.RequiredVariables {
+		if IsRecipientVariable(requiredVar) || IsBrandVariable(requiredVar) {
+			continue
+		}
 		if _, exists := data[requiredVar]; !exists {
 			return ErrMissingRequiredVariable
 		}
@@ -133,9 +463,13 @@ func (t *Template) ValidateRequiredVariables(data map[string]interface{}) error
 	return nil
 }
 
-// ValidateRequiredVariables checks if all required variables are provided
+// ValidateRequiredVariables checks if all required variables are provided.
+// Recipient and brand variables are always available and need not be provided.
 func (t *TemplateVersion) ValidateRequiredVariables(data map[string]interface{}) error {
 	for _, requiredVar := range t.RequiredVariables {
+		if IsRecipientVariable(requiredVar) || IsBrandVariable(requiredVar) {
+			continue
+		}
 		if _, exists := data[requiredVar]; !exists {
 			return ErrMissingRequiredVariable
 		}
@@ -151,6 +485,9 @@ func PredefinedTemplates() []*Template {
 		passwordResetTemplate(),
 		orderConfirmationTemplate(),
 
+		inboxDigestTemplate(),
+		contactVerificationTemplate(),
+
 		// Slack Templates
 		systemAlertTemplate(),
 		deploymentNotificationTemplate(),
@@ -158,6 +495,10 @@ func PredefinedTemplates() []*Template {
 		// In-App Templates
 		orderStatusUpdateTemplate(),
 		paymentReminderTemplate(),
+
+		// Google Chat Templates
+		googleChatIncidentTemplate(),
+		googleChatApprovalTemplate(),
 	}
 }
 
@@ -173,6 +514,7 @@ func welcomeEmailTemplate() *Template {
 		},
 		RequiredVariables: []string{"name", "platform", "username", "email", "account_type", "activation_link"},
 		Description:       "Welcome email template for new user onboarding",
+		Category:          CategoryTransactional,
 		Version:           1,
 		Status:            "active",
 		CreatedAt:         time.Now(),
@@ -191,6 +533,7 @@ func passwordResetTemplate() *Template {
 		},
 		RequiredVariables: []string{"name", "platform", "reset_link", "expiry_hours"},
 		Description:       "Password reset email template",
+		Category:          CategorySecurity,
 		Version:           1,
 		Status:            "active",
 		CreatedAt:         time.Now(),
@@ -209,6 +552,7 @@ func orderConfirmationTemplate() *Template {
 		},
 		RequiredVariables: []string{"customer_name", "order_id", "order_date", "total_amount", "payment_method", "items_list", "shipping_address", "delivery_date", "tracking_link", "platform"},
 		Description:       "Order confirmation email template",
+		Category:          CategoryTransactional,
 		Version:           1,
 		Status:            "active",
 		CreatedAt:         time.Now(),
@@ -226,6 +570,7 @@ func systemAlertTemplate() *Template {
 		},
 		RequiredVariables: []string{"alert_type", "system_name", "severity", "environment", "message", "timestamp", "action_required", "affected_services", "dashboard_link"},
 		Description:       "Slack alert template for system monitoring",
+		Category:          CategorySystem,
 		Version:           1,
 		Status:            "active",
 		CreatedAt:         time.Now(),
@@ -243,6 +588,7 @@ func deploymentNotificationTemplate() *Template {
 		},
 		RequiredVariables: []string{"status", "service_name", "environment", "version", "deployed_by", "duration", "changes_summary", "rollback_command", "monitoring_link"},
 		Description:       "Slack notification template for deployment events",
+		Category:          CategorySystem,
 		Version:           1,
 		Status:            "active",
 		CreatedAt:         time.Now(),
@@ -261,6 +607,7 @@ func orderStatusUpdateTemplate() *Template {
 		},
 		RequiredVariables: []string{"order_id", "status", "item_count", "total_amount", "status_message", "action_button"},
 		Description:       "In-app notification template for order status updates",
+		Category:          CategoryTransactional,
 		Version:           1,
 		Status:            "active",
 		CreatedAt:         time.Now(),
@@ -279,6 +626,107 @@ func paymentReminderTemplate() *Template {
 		},
 		RequiredVariables: []string{"amount", "due_date", "invoice_id"},
 		Description:       "In-app notification template for payment reminders",
+		Category:          CategoryTransactional,
+		Version:           1,
+		Status:            "active",
+		CreatedAt:         time.Now(),
+	}
+}
+
+// InboxDigestTemplateID is the predefined email template used for inbox digests
+const InboxDigestTemplateID = "550e8400-e29b-41d4-a716-446655440007"
+
+// inboxDigestTemplate creates the email template summarizing unread in-app notifications
+func inboxDigestTemplate() *Template {
+	return &Template{
+		ID:   InboxDigestTemplateID, // Fixed UUID for consistency
+		Name: "Inbox Digest Template",
+		Type: EmailNotification,
+		Content: TemplateContent{
+			Subject:   "Your {{period}} digest: {{item_count}} unread notifications",
+			EmailBody: "Hello {{recipient_first_name}},\n\nYou have {{item_count}} unread notifications:\n\n{{items_list}}\n\nOpen the app to read them.\n\nYou are receiving this {{period}} digest because you opted in. You can turn it off in your notification preferences.",
+		},
+		RequiredVariables: []string{"period", "item_count", "items_list"},
+		Description:       "Email digest summarizing unread in-app notifications",
+		Category:          CategorySystem,
+		Version:           1,
+		Status:            "active",
+		CreatedAt:         time.Now(),
+	}
+}
+
+// ContactVerificationTemplateID is the predefined email template carrying verification codes
+const ContactVerificationTemplateID = "550e8400-e29b-41d4-a716-446655440008"
+
+// contactVerificationTemplate creates the email template used to verify a user's email address
+func contactVerificationTemplate() *Template {
+	return &Template{
+		ID:   ContactVerificationTemplateID, // Fixed UUID for consistency
+		Name: "Contact Verification Template",
+		Type: EmailNotification,
+		Content: TemplateContent{
+			Subject:   "Your verification code: {{code}}",
+			EmailBody: "Hello {{recipient_first_name}},\n\nUse the code {{code}} to verify your email address. It expires in {{expires_in_minutes}} minutes.{{verification_link}}\n\nIf you did not request this, you can ignore this email.",
+		},
+		RequiredVariables: []string{"code", "expires_in_minutes"},
+		Description:       "Email carrying a one-time code to verify the recipient's email address",
+		Category:          CategorySecurity,
+		Version:           1,
+		Status:            "active",
+		CreatedAt:         time.Now(),
+	}
+}
+
+// googleChatIncidentTemplate creates the Google Chat incident card template
+func googleChatIncidentTemplate() *Template {
+	return &Template{
+		ID:   "550e8400-e29b-41d4-a716-446655440009", // Fixed UUID for consistency
+		Name: "Google Chat Incident Template",
+		Type: GoogleChatNotification,
+		Content: TemplateContent{
+			Text: "*{{severity}}* incident on {{service_name}}",
+			Card: &GoogleChatCard{
+				Title:    "{{alert_type}}: {{service_name}}",
+				Subtitle: "{{environment}} · {{severity}}",
+				Sections: []GoogleChatCardSection{
+					{Header: "What happened", Text: "{{message}}"},
+					{Header: "Affected services", Text: "{{affected_services}}"},
+				},
+				Buttons: []GoogleChatCardButton{
+					{Text: "Open dashboard", URL: "{{dashboard_link}}"},
+				},
+			},
+		},
+		RequiredVariables: []string{"alert_type", "service_name", "environment", "severity", "message", "affected_services", "dashboard_link"},
+		Description:       "Google Chat card template for incidents",
+		Category:          CategorySystem,
+		Version:           1,
+		Status:            "active",
+		CreatedAt:         time.Now(),
+	}
+}
+
+// googleChatApprovalTemplate creates the Google Chat approval request card template
+func googleChatApprovalTemplate() *Template {
+	return &Template{
+		ID:   "550e8400-e29b-41d4-a716-446655440010", // Fixed UUID for consistency
+		Name: "Google Chat Approval Request Template",
+		Type: GoogleChatNotification,
+		Content: TemplateContent{
+			Card: &GoogleChatCard{
+				Title:    "Approval needed: {{request_title}}",
+				Subtitle: "Requested by {{requested_by}}",
+				Sections: []GoogleChatCardSection{
+					{Text: "{{request_details}}"},
+				},
+				Buttons: []GoogleChatCardButton{
+					{Text: "Review request", URL: "{{review_link}}"},
+				},
+			},
+		},
+		RequiredVariables: []string{"request_title", "requested_by", "request_details", "review_link"},
+		Description:       "Google Chat card template asking for an approval",
+		Category:          CategoryTransactional,
 		Version:           1,
 		Status:            "active",
 		CreatedAt:         time.Now(),
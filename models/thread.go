@@ -0,0 +1,30 @@
+package models
+
+import "regexp"
+
+// Limits on thread keys
+const (
+	MaxThreadKeyLength = 128
+	MaxMutedThreads    = 100
+)
+
+// threadKeyPattern matches thread keys such as order:42 or ticket-1234
+var threadKeyPattern = regexp.MustCompile(`^[A-Za-z0-9._:/@#-]{1,128}$`)
+
+// IsValidThreadKey checks whether key can be used as the thread key of a notification
+func IsValidThreadKey(key string) bool {
+	return threadKeyPattern.MatchString(key)
+}
+
+// MutesThread reports whether the user muted the notifications of a thread
+func (p *NotificationPreferences) MutesThread(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, muted := range p.MutedThreads {
+		if muted == key {
+			return true
+		}
+	}
+	return false
+}
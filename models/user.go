@@ -1,48 +1,100 @@
 package models
 
 import (
+	"strings"
 	"time"
+	// Embedded zone data keeps timezone validation working in images without /usr/share/zoneinfo
+	_ "time/tzdata"
 
 	"github.com/google/uuid"
+	"golang.org/x/text/language"
 )
 
 // UserDeviceInfo represents device information for InApp notifications
 type UserDeviceInfo struct {
-	ID          string    `json:"id"`
-	UserID      string    `json:"user_id"`
-	DeviceToken string    `json:"device_token"`
-	DeviceType  string    `json:"device_type"` // "ios", "android", "web"
-	AppVersion  string    `json:"app_version,omitempty"`
-	OSVersion   string    `json:"os_version,omitempty"`
-	DeviceModel string    `json:"device_model,omitempty"`
-	IsActive    bool      `json:"is_active"`
-	LastUsedAt  time.Time `json:"last_used_at"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string `json:"id"`
+	UserID      string `json:"user_id"`
+	DeviceToken string `json:"device_token"`
+	DeviceType  string `json:"device_type"` // "ios", "android", "web"
+	AppVersion  string `json:"app_version,omitempty"`
+	OSVersion   string `json:"os_version,omitempty"`
+	DeviceModel string `json:"device_model,omitempty"`
+	IsActive    bool   `json:"is_active"`
+	// APNSEnvironment is "sandbox" for development builds of the iOS app; empty uses the default
+	APNSEnvironment string `json:"apns_environment,omitempty"`
+	// AppID names the mobile app in the push app registry; empty is the default app
+	AppID      string    `json:"app_id,omitempty"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
 
 // User represents a user with essential information for notifications
 type User struct {
-	ID           string    `json:"id"`
-	Email        string    `json:"email"`
-	FullName     string    `json:"full_name"`
-	SlackUserID  string    `json:"slack_user_id,omitempty"`
-	SlackChannel string    `json:"slack_channel,omitempty"`
-	PhoneNumber  string    `json:"phone_number,omitempty"`
-	IsActive     bool      `json:"is_active"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           string `json:"id"`
+	Email        string `json:"email"`
+	FullName     string `json:"full_name"`
+	SlackUserID  string `json:"slack_user_id,omitempty"`
+	SlackChannel string `json:"slack_channel,omitempty"`
+	// GoogleChatSpace is the Google Chat space notifications are posted to, a space name such as
+	// spaces/AAAAqZ1yV4k or the incoming webhook URL of a space
+	GoogleChatSpace string `json:"google_chat_space,omitempty"`
+	PhoneNumber     string `json:"phone_number,omitempty"`
+	Timezone        string `json:"timezone,omitempty"` // IANA name, e.g. "Europe/Berlin"
+	Locale          string `json:"locale,omitempty"`   // BCP 47 tag, e.g. "de-DE"
+	// UserName and ExternalID identify users provisioned through SCIM by the identity provider
+	UserName   string `json:"user_name,omitempty"`
+	ExternalID string `json:"external_id,omitempty"`
+	// DirectoryDN links users synced from an LDAP directory to their entry; Groups are the names
+	// of the directory groups they are a member of
+	DirectoryDN string   `json:"directory_dn,omitempty"`
+	Groups      []string `json:"groups,omitempty"`
+	// EmailVerifiedAt and PhoneVerifiedAt are set once the user confirmed a verification code;
+	// changing the address clears them
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at,omitempty"`
+	// SecondaryContacts are the user's other addresses, see ContactPoint
+	SecondaryContacts []ContactPoint `json:"secondary_contacts,omitempty"`
+	// DoNotDisturb is set while the user has do-not-disturb turned on
+	DoNotDisturb *DoNotDisturb `json:"do_not_disturb,omitempty"`
+	IsActive     bool          `json:"is_active"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
 }
 
 // UserNotificationInfo represents essential user info for notifications
 type UserNotificationInfo struct {
-	ID           string            `json:"id"`
-	Email        string            `json:"email"`
-	FullName     string            `json:"full_name"`
-	SlackUserID  string            `json:"slack_user_id,omitempty"`
-	SlackChannel string            `json:"slack_channel,omitempty"`
-	PhoneNumber  string            `json:"phone_number,omitempty"`
-	Devices      []*UserDeviceInfo `json:"devices,omitempty"`
+	ID           string `json:"id"`
+	Email        string `json:"email"`
+	FullName     string `json:"full_name"`
+	SlackUserID  string `json:"slack_user_id,omitempty"`
+	SlackChannel string `json:"slack_channel,omitempty"`
+	// GoogleChatSpace is the Google Chat space of the user, see User.GoogleChatSpace
+	GoogleChatSpace string `json:"google_chat_space,omitempty"`
+	// IncidentRoute is only set for incident recipients, users are not paged directly
+	IncidentRoute string            `json:"incident_route,omitempty"`
+	PhoneNumber   string            `json:"phone_number,omitempty"`
+	Timezone      string            `json:"timezone,omitempty"`
+	Locale        string            `json:"locale,omitempty"`
+	EmailVerified bool              `json:"email_verified"`
+	PhoneVerified bool              `json:"phone_verified"`
+	DoNotDisturb  *DoNotDisturb     `json:"do_not_disturb,omitempty"`
+	Devices       []*UserDeviceInfo `json:"devices,omitempty"`
+}
+
+// DoNotDisturb holds back non-urgent notifications to a user until Until, or until it is
+// turned off when Until is not set
+type DoNotDisturb struct {
+	Since time.Time  `json:"since"`
+	Until *time.Time `json:"until,omitempty"`
+}
+
+// ActiveAt reports whether do-not-disturb is on at t
+func (d *DoNotDisturb) ActiveAt(t time.Time) bool {
+	if d == nil {
+		return false
+	}
+	return d.Until == nil || t.Before(*d.Until)
 }
 
 // NewUser creates a new user with default values
@@ -58,18 +110,102 @@ func NewUser(email, fullName string) *User {
 	}
 }
 
-// ToNotificationInfo converts User to UserNotificationInfo
+// ToNotificationInfo converts User to UserNotificationInfo, with the preferred address of each
+// contact type
 func (u *User) ToNotificationInfo() *UserNotificationInfo {
+	email, emailVerified := u.PreferredContact(ContactEmail)
+	slackChannel, _ := u.PreferredContact(ContactSlack)
+	phoneNumber, phoneVerified := u.PreferredContact(ContactPhone)
 	return &UserNotificationInfo{
-		ID:           u.ID,
-		Email:        u.Email,
-		FullName:     u.FullName,
-		SlackUserID:  u.SlackUserID,
-		SlackChannel: u.SlackChannel,
-		PhoneNumber:  u.PhoneNumber,
+		ID:              u.ID,
+		Email:           email,
+		FullName:        u.FullName,
+		SlackUserID:     u.SlackUserID,
+		SlackChannel:    slackChannel,
+		GoogleChatSpace: u.GoogleChatSpace,
+		PhoneNumber:     phoneNumber,
+		Timezone:        u.Timezone,
+		Locale:          u.Locale,
+		EmailVerified:   emailVerified,
+		PhoneVerified:   phoneVerified,
+		DoNotDisturb:    u.DoNotDisturb,
 	}
 }
 
+// Types of the devices a user receives push notifications on
+const (
+	DeviceTypeIOS     = "ios"
+	DeviceTypeAndroid = "android"
+	DeviceTypeWeb     = "web"
+)
+
+// IsValidDeviceType reports whether deviceType names a device type
+func IsValidDeviceType(deviceType string) bool {
+	return deviceType == DeviceTypeIOS || deviceType == DeviceTypeAndroid || deviceType == DeviceTypeWeb
+}
+
+// Contact points of a user that can be verified
+const (
+	ContactEmail = "email"
+	ContactPhone = "phone"
+)
+
+// IsValidContact checks if contact names a verifiable contact point
+func IsValidContact(contact string) bool {
+	return contact == ContactEmail || contact == ContactPhone
+}
+
+// ContactAddress returns the user's primary address for a contact point
+func (u *User) ContactAddress(contact string) string {
+	if primary := u.primaryContact(contact); primary != nil {
+		return primary.Address
+	}
+	return ""
+}
+
+// VerificationChallenge is a verification code sent to one of a user's contact points.
+// The code itself is only delivered to the contact point and never returned by the API.
+type VerificationChallenge struct {
+	UserID    string    `json:"user_id"`
+	Contact   string    `json:"contact"`
+	Address   string    `json:"address"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Location returns the user's time zone, or UTC when the user has none
+func (u *UserNotificationInfo) Location() *time.Location {
+	if u.Timezone == "" {
+		return time.UTC
+	}
+	location, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// ValidateTimezone checks that timezone is an IANA time zone name. UTC is accepted but
+// "Local" is not, since it depends on the server.
+func ValidateTimezone(timezone string) error {
+	if timezone == "Local" {
+		return ErrInvalidTimezone
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return ErrInvalidTimezone
+	}
+	return nil
+}
+
+// NormalizeLocale checks that locale is a BCP 47 language tag and returns its canonical
+// form, e.g. "en_us" becomes "en-US"
+func NormalizeLocale(locale string) (string, error) {
+	tag, err := language.Parse(strings.ReplaceAll(locale, "_", "-"))
+	if err != nil || tag == language.Und {
+		return "", ErrInvalidLocale
+	}
+	return tag.String(), nil
+}
+
 // GetNotificationChannels returns enabled notification channels for the user
 func (u *User) GetNotificationChannels() []string {
 	var channels []string
@@ -84,6 +220,16 @@ func (u *User) GetNotificationChannels() []string {
 		channels = append(channels, "slack")
 	}
 
+	// Google Chat is available if user has a Google Chat space
+	if u.GoogleChatSpace != "" {
+		channels = append(channels, "google_chat")
+	}
+
+	// RCS is available if user has a phone number
+	if u.PhoneNumber != "" {
+		channels = append(channels, "rcs")
+	}
+
 	// InApp is always available (devices will be checked separately)
 	channels = append(channels, "in_app")
 
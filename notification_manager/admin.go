@@ -0,0 +1,114 @@
+package notification_manager
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gaurav2721/notification-service/external_services/kafka"
+)
+
+// channelFailureRate summarises notification and delivery failures of a notification type
+type channelFailureRate struct {
+	Type                    string  `json:"type"`
+	Notifications           int     `json:"notifications"`
+	FailedNotifications     int     `json:"failed_notifications"`
+	DeliveriesSent          int     `json:"deliveries_sent"`
+	DeliveriesFailed        int     `json:"deliveries_failed"`
+	DeliveryFailureRate     float64 `json:"delivery_failure_rate"`
+	NotificationFailureRate float64 `json:"notification_failure_rate"`
+}
+
+// templateSummary is the template information shown on the admin dashboard
+type templateSummary struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Version   int       `json:"version"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GetAdminOverview returns the data shown on the admin dashboard: queue depths, failure rates
+// per notification type, the most recent notifications (newest first) and all templates
+func (nm *NotificationManagerImpl) GetAdminOverview(recentLimit int) (interface{}, error) {
+	records := nm.storage.FindNotifications(NotificationFilter{})
+
+	byStatus := make(map[string]int)
+	rates := make(map[string]*channelFailureRate)
+	for _, record := range records {
+		byStatus[string(record.Status)]++
+
+		rate, exists := rates[record.Type]
+		if !exists {
+			rate = &channelFailureRate{Type: record.Type}
+			rates[record.Type] = rate
+		}
+		rate.Notifications++
+		if record.Status == StatusFailed {
+			rate.FailedNotifications++
+		}
+		if nm.deliveryService != nil {
+			stats := nm.deliveryService.GetStats(record.ID)
+			rate.DeliveriesSent += stats.Sent
+			rate.DeliveriesFailed += stats.Failed
+		}
+	}
+
+	failureRates := make([]channelFailureRate, 0, len(rates))
+	for _, rate := range rates {
+		if deliveries := rate.DeliveriesSent + rate.DeliveriesFailed; deliveries > 0 {
+			rate.DeliveryFailureRate = float64(rate.DeliveriesFailed) / float64(deliveries)
+		}
+		rate.NotificationFailureRate = float64(rate.FailedNotifications) / float64(rate.Notifications)
+		failureRates = append(failureRates, *rate)
+	}
+	sort.Slice(failureRates, func(i, j int) bool {
+		return failureRates[i].Type < failureRates[j].Type
+	})
+
+	// Records are sorted oldest first, so walk them backwards for the most recent ones
+	recent := make([]notificationSummary, 0, recentLimit)
+	for i := len(records) - 1; i >= 0 && len(recent) < recentLimit; i-- {
+		record := records[i]
+		recent = append(recent, notificationSummary{
+			ID:         record.ID,
+			ExternalID: record.ExternalID,
+			Tags:       record.Tags,
+			Type:       record.Type,
+			Status:     string(record.Status),
+			CreatedAt:  record.CreatedAt,
+			SentAt:     record.SentAt,
+		})
+	}
+
+	allTemplates := nm.templateManager.ListTemplates()
+	templates := make([]templateSummary, 0, len(allTemplates))
+	for _, template := range allTemplates {
+		templates = append(templates, templateSummary{
+			ID:        template.ID,
+			Name:      template.Name,
+			Type:      string(template.Type),
+			Version:   template.Version,
+			Status:    template.Status,
+			CreatedAt: template.CreatedAt,
+		})
+	}
+
+	return &struct {
+		Queues              []kafka.QueueStats    `json:"queues"`
+		TotalNotifications  int                   `json:"total_notifications"`
+		ByStatus            map[string]int        `json:"by_status"`
+		FailureRates        []channelFailureRate  `json:"failure_rates"`
+		RecentNotifications []notificationSummary `json:"recent_notifications"`
+		Templates           []templateSummary     `json:"templates"`
+		GeneratedAt         time.Time             `json:"generated_at"`
+	}{
+		Queues:              kafka.GetQueueStats(nm.kafkaService),
+		TotalNotifications:  len(records),
+		ByStatus:            byStatus,
+		FailureRates:        failureRates,
+		RecentNotifications: recent,
+		Templates:           templates,
+		GeneratedAt:         nm.clock.Now(),
+	}, nil
+}
@@ -0,0 +1,50 @@
+package notification_manager
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/sirupsen/logrus"
+)
+
+// backgroundSends tracks the notifications fanned out after their request was accepted, so
+// shutdown can wait for them instead of dropping them
+type backgroundSends struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newBackgroundSends() *backgroundSends {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &backgroundSends{ctx: ctx, cancel: cancel}
+}
+
+// sendInBackground fans a notification out on a goroutine tracked for shutdown
+func (nm *NotificationManagerImpl) sendInBackground(request *models.NotificationRequest, notificationID string) {
+	nm.background.wg.Add(1)
+	go func() {
+		defer nm.background.wg.Done()
+		nm.processNotificationInBackground(nm.background.ctx, request, notificationID)
+	}()
+}
+
+// StopBackgroundSends waits for the notifications being fanned out in the background until ctx
+// is done, then cancels the remaining ones and waits for them to stop at their next batch
+func (nm *NotificationManagerImpl) StopBackgroundSends(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		nm.background.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return
+	case <-ctx.Done():
+	}
+	logrus.Warn("Shutdown deadline reached, cancelling background notification sends")
+	nm.background.cancel()
+	<-done
+}
@@ -0,0 +1,26 @@
+package notification_manager
+
+import "time"
+
+// scheduledRunTime returns when a notification scheduled at scheduledAt is sent: the end of its
+// batching window, so it is never sent early, or scheduledAt itself without a batching window
+func (nm *NotificationManagerImpl) scheduledRunTime(scheduledAt time.Time) time.Time {
+	window := nm.config.ScheduledBatchWindow
+	if window <= 0 {
+		return scheduledAt
+	}
+	start := scheduledAt.Truncate(window)
+	if start.Equal(scheduledAt) {
+		return scheduledAt
+	}
+	return start.Add(window)
+}
+
+// scheduledBatchKey names the batching window a notification scheduled at scheduledAt is sent in,
+// or returns an empty key without a batching window
+func (nm *NotificationManagerImpl) scheduledBatchKey(scheduledAt time.Time) string {
+	if nm.config.ScheduledBatchWindow <= 0 {
+		return ""
+	}
+	return "scheduled:" + nm.scheduledRunTime(scheduledAt).UTC().Format(time.RFC3339)
+}
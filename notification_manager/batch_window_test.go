@@ -0,0 +1,77 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// timedScheduler keeps jobs and the times they were scheduled for
+type timedScheduler struct {
+	heldScheduler
+	times map[string]time.Time
+}
+
+func (s *timedScheduler) ScheduleJob(jobID string, scheduledTime time.Time, job func()) error {
+	s.times[jobID] = scheduledTime
+	return s.heldScheduler.ScheduleJob(jobID, scheduledTime, job)
+}
+
+func TestScheduledRunTime(t *testing.T) {
+	nm := &NotificationManagerImpl{config: DefaultConfig()}
+	scheduledAt := time.Date(2024, 1, 1, 9, 10, 20, 0, time.UTC)
+	assert.Equal(t, scheduledAt, nm.scheduledRunTime(scheduledAt))
+	assert.Empty(t, nm.scheduledBatchKey(scheduledAt))
+
+	nm.config.ScheduledBatchWindow = time.Minute
+	// Notifications are never sent before their scheduled time
+	assert.Equal(t, time.Date(2024, 1, 1, 9, 11, 0, 0, time.UTC), nm.scheduledRunTime(scheduledAt))
+	onTheMinute := time.Date(2024, 1, 1, 9, 11, 0, 0, time.UTC)
+	assert.Equal(t, onTheMinute, nm.scheduledRunTime(onTheMinute))
+	assert.Equal(t, "scheduled:2024-01-01T09:11:00Z", nm.scheduledBatchKey(scheduledAt))
+	assert.Equal(t, nm.scheduledBatchKey(scheduledAt), nm.scheduledBatchKey(onTheMinute))
+}
+
+func TestScheduledNotification_BatchingWindow(t *testing.T) {
+	config := DefaultConfig()
+	config.ScheduledBatchWindow = time.Minute
+	nm, kafkaService, recipients := newTestManager(t, 2, config)
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	nm.SetClock(fakeClock)
+	timed := &timedScheduler{
+		heldScheduler: heldScheduler{jobs: make(map[string]func())},
+		times:         make(map[string]time.Time),
+	}
+	nm.scheduler = timed
+
+	// Both notifications are due in the minute before 09:11
+	var ids []string
+	for i, offset := range []time.Duration{10*time.Minute + 5*time.Second, 10*time.Minute + 50*time.Second} {
+		scheduledAt := fakeClock.Now().Add(offset)
+		result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+			Type:        "email",
+			Content:     map[string]interface{}{"subject": "Reminder", "email_body": "Body"},
+			Recipients:  []string{recipients[i]},
+			ScheduledAt: &scheduledAt,
+		})
+		require.NoError(t, err)
+		ids = append(ids, result.(map[string]interface{})["id"].(string))
+	}
+
+	for _, id := range ids {
+		assert.Equal(t, time.Date(2024, 1, 1, 9, 11, 0, 0, time.UTC), timed.times[id])
+		timed.jobs[id]()
+	}
+
+	require.Len(t, kafkaService.GetEmailChannel(), 2)
+	for range ids {
+		var email models.EmailNotificationRequest
+		require.NoError(t, json.Unmarshal([]byte(<-kafkaService.GetEmailChannel()), &email))
+		assert.Equal(t, "scheduled:2024-01-01T09:11:00Z", email.BatchKey)
+	}
+}
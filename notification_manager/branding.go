@@ -0,0 +1,66 @@
+package notification_manager
+
+import (
+	"sync"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/sirupsen/logrus"
+)
+
+// brandingStore keeps the branding tenants have set
+type brandingStore struct {
+	mu       sync.RWMutex
+	branding map[string]models.Branding
+}
+
+// newBrandingStore creates an empty branding store
+func newBrandingStore() *brandingStore {
+	return &brandingStore{
+		branding: make(map[string]models.Branding),
+	}
+}
+
+// Get returns the branding of a tenant, which is empty if none was set
+func (s *brandingStore) Get(tenant string) models.Branding {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if branding, exists := s.branding[tenant]; exists {
+		return branding
+	}
+	return models.Branding{Tenant: tenant}
+}
+
+// Set replaces the branding of a tenant
+func (s *brandingStore) Set(branding models.Branding) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.branding[branding.Tenant] = branding
+}
+
+// withBranding returns template data with the brand variables of tenant added
+func (nm *NotificationManagerImpl) withBranding(data map[string]interface{}, tenant string) map[string]interface{} {
+	return models.WithBrandVariables(data, nm.branding.Get(tenant))
+}
+
+// GetBranding returns the branding of a tenant
+func (nm *NotificationManagerImpl) GetBranding(tenant string) interface{} {
+	branding := nm.branding.Get(tenant)
+	return &branding
+}
+
+// UpdateBranding replaces the branding of a tenant
+func (nm *NotificationManagerImpl) UpdateBranding(tenant string, branding *models.Branding, actor string) interface{} {
+	updated := *branding
+	updated.Tenant = tenant
+	updated.UpdatedAt = nm.clock.Now()
+	updated.UpdatedBy = actor
+	nm.branding.Set(updated)
+
+	logrus.WithFields(logrus.Fields{
+		"tenant": tenant,
+		"actor":  actor,
+	}).Info("Tenant branding updated")
+
+	return &updated
+}
@@ -0,0 +1,58 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderTemplate_BrandVariables(t *testing.T) {
+	nm, _, _ := newTestManager(t, 1, DefaultConfig())
+
+	created, err := nm.CreateTemplate(&models.Template{
+		Name: "Receipt",
+		Type: models.EmailNotification,
+		Content: models.TemplateContent{
+			Subject:   "Your {{brand.company_name}} receipt",
+			EmailBody: `<img src="{{brand.logo_url}}" alt="{{brand.company_name}}"><p>Order {{order_id}}</p><p>{{brand.address}}</p>`,
+		},
+		RequiredVariables: []string{"order_id"},
+	})
+	require.NoError(t, err)
+	templateID := created.(*models.TemplateResponse).ID
+
+	branding := nm.UpdateBranding("acme", &models.Branding{
+		LogoURL:     "https://cdn.acme.com/logo.png",
+		CompanyName: "Acme & Co",
+		Address:     "1 Main Street",
+	}, "acme").(*models.Branding)
+	assert.Equal(t, "acme", branding.Tenant)
+	assert.Equal(t, "acme", branding.UpdatedBy)
+
+	render := func(data map[string]interface{}, tenant string) map[string]string {
+		result, err := nm.RenderTemplate(templateID, 1, data, tenant)
+		require.NoError(t, err)
+		encoded, err := json.Marshal(result)
+		require.NoError(t, err)
+		var rendered struct {
+			Content map[string]string `json:"content"`
+		}
+		require.NoError(t, json.Unmarshal(encoded, &rendered))
+		return rendered.Content
+	}
+
+	// Brand values are escaped like any other variable
+	content := render(map[string]interface{}{"order_id": "ORD-1"}, "acme")
+	assert.Equal(t, "Your Acme & Co receipt", content["subject"])
+	assert.Equal(t, `<img src="https://cdn.acme.com/logo.png" alt="Acme &amp; Co"><p>Order ORD-1</p><p>1 Main Street</p>`, content["email_body"])
+
+	// Callers can override brand variables, and tenants without branding get empty values
+	content = render(map[string]interface{}{"order_id": "ORD-1", "brand.company_name": "Acme Europe"}, "acme")
+	assert.Equal(t, "Your Acme Europe receipt", content["subject"])
+	content = render(map[string]interface{}{"order_id": "ORD-1"}, "globex")
+	assert.Equal(t, "Your  receipt", content["subject"])
+	assert.Equal(t, models.Branding{Tenant: "globex"}, *nm.GetBranding("globex").(*models.Branding))
+}
@@ -0,0 +1,165 @@
+package notification_manager
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/sirupsen/logrus"
+)
+
+// budgetMonthLayout identifies the calendar month, in UTC, that spend is counted for
+const budgetMonthLayout = "2006-01"
+
+// Budget scopes
+const (
+	budgetScopeTenant   = "tenant"
+	budgetScopeCampaign = "campaign"
+)
+
+// notificationBudgetRejectionsTotal counts requests rejected because of an exhausted budget
+var notificationBudgetRejectionsTotal = metrics.DefaultRegistry.NewCounterVec(
+	"notification_budget_rejections_total",
+	"Notification requests rejected because their projected cost exceeds a monthly budget, by budget scope.",
+	"scope",
+)
+
+// budgetKey identifies the budget of a tenant or a campaign
+type budgetKey struct {
+	Scope string
+	Name  string
+}
+
+// budgetLedger keeps the projected spend of the current month for every budget
+type budgetLedger struct {
+	mu      sync.Mutex
+	month   string
+	spent   map[budgetKey]float64
+	alerted map[budgetKey]bool // budgets whose exhaustion was alerted this month
+}
+
+func newBudgetLedger() *budgetLedger {
+	return &budgetLedger{
+		spent:   make(map[budgetKey]float64),
+		alerted: make(map[budgetKey]bool),
+	}
+}
+
+// rollover starts counting from zero when a new month begins. Callers must hold mu.
+func (l *budgetLedger) rollover(now time.Time) {
+	if month := now.UTC().Format(budgetMonthLayout); month != l.month {
+		l.month = month
+		l.spent = make(map[budgetKey]float64)
+		l.alerted = make(map[budgetKey]bool)
+	}
+}
+
+// budgetsFor returns the budgets that apply to a request with their monthly limits
+func (nm *NotificationManagerImpl) budgetsFor(request *models.NotificationRequest) map[budgetKey]float64 {
+	budgets := make(map[budgetKey]float64)
+	if limit, ok := nm.config.TenantBudgets[request.Tenant]; ok && request.Tenant != "" {
+		budgets[budgetKey{Scope: budgetScopeTenant, Name: request.Tenant}] = limit
+	}
+	for _, tag := range request.Tags {
+		if limit, ok := nm.config.CampaignBudgets[tag]; ok {
+			budgets[budgetKey{Scope: budgetScopeCampaign, Name: tag}] = limit
+		}
+	}
+	return budgets
+}
+
+// estimateCost projects the cost of a request, assuming one delivered message per recipient
+func (nm *NotificationManagerImpl) estimateCost(request *models.NotificationRequest) float64 {
+	return nm.config.UnitCosts[request.Type] * float64(len(request.Recipients))
+}
+
+// chargeBudgets adds the estimated cost of a request to the monthly spend of its tenant and
+// campaigns. A non-transactional request that would take any of them over budget is rejected
+// with ErrBudgetExceeded and charges nothing; transactional requests are always charged.
+// The returned refund undoes the charge when the request fails afterwards.
+func (nm *NotificationManagerImpl) chargeBudgets(request *models.NotificationRequest) (refund func(), err error) {
+	refund = func() {}
+
+	budgets := nm.budgetsFor(request)
+	cost := nm.estimateCost(request)
+	if len(budgets) == 0 || cost == 0 {
+		return refund, nil
+	}
+
+	ledger := nm.budgets
+	ledger.mu.Lock()
+	ledger.rollover(nm.clock.Now())
+	month := ledger.month
+
+	if !request.Transactional {
+		for key, limit := range budgets {
+			spent := ledger.spent[key]
+			if spent+cost <= limit {
+				continue
+			}
+
+			alert := !ledger.alerted[key]
+			ledger.alerted[key] = true
+			ledger.mu.Unlock()
+
+			nm.rejectOverBudget(request, key, limit, spent, cost, alert)
+			return refund, fmt.Errorf("%w: the %s %q has spent %s of its %s %s budget for %s and this notification would cost an estimated %s",
+				ErrBudgetExceeded, key.Scope, key.Name, formatAmount(spent), formatAmount(limit), nm.config.CostCurrency, month, formatAmount(cost))
+		}
+	}
+
+	for key := range budgets {
+		ledger.spent[key] += cost
+	}
+	ledger.mu.Unlock()
+
+	return func() {
+		ledger.mu.Lock()
+		defer ledger.mu.Unlock()
+		if ledger.month != month {
+			return
+		}
+		for key := range budgets {
+			ledger.spent[key] -= cost
+		}
+	}, nil
+}
+
+// rejectOverBudget records a request rejected by a budget and, the first time the budget is
+// exhausted in a month, posts an alert to the budget alert Slack channel
+func (nm *NotificationManagerImpl) rejectOverBudget(request *models.NotificationRequest, key budgetKey, limit, spent, cost float64, alert bool) {
+	notificationBudgetRejectionsTotal.Inc(key.Scope)
+	logrus.WithFields(logrus.Fields{
+		"scope":          key.Scope,
+		"name":           key.Name,
+		"budget":         limit,
+		"spent":          roundCost(spent),
+		"estimated_cost": roundCost(cost),
+		"type":           request.Type,
+		"recipients":     len(request.Recipients),
+	}).Warn("Notification rejected, monthly budget exceeded")
+
+	if !alert || nm.config.BudgetAlertSlackChannel == "" {
+		return
+	}
+
+	text := fmt.Sprintf(":money_with_wings: The monthly budget of the %s %q is exhausted: %s of %s %s spent. Non-transactional notifications are rejected until the budget is raised or the month ends.",
+		key.Scope, key.Name, formatAmount(spent), formatAmount(limit), nm.config.CostCurrency)
+	message := &models.SlackNotificationRequest{
+		ID:        nm.generateID(),
+		Type:      "slack",
+		Content:   models.SlackContent{Text: text},
+		Recipient: nm.config.BudgetAlertSlackChannel,
+	}
+	if err := nm.postToKafkaChannel("slack", message, 0); err != nil {
+		logrus.WithError(err).Error("Failed to send budget alert")
+	}
+}
+
+// formatAmount formats an amount of money without floating point noise
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(roundCost(amount), 'f', -1, 64)
+}
@@ -0,0 +1,84 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChargeBudgets_RejectsNonTransactionalSendsOverBudget(t *testing.T) {
+	config := DefaultConfig()
+	config.UnitCosts = map[string]float64{"email": 0.001}
+	config.TenantBudgets = map[string]float64{"growth": 0.005}
+	config.CampaignBudgets = map[string]float64{"q3-campaign": 0.003}
+	config.BudgetAlertSlackChannel = "finops"
+	config.TrustedTenants = []string{"growth"}
+	nm, kafkaService, recipients := newTestManager(t, 2, config)
+	fakeClock := clock.NewFake(time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC))
+	nm.SetClock(fakeClock)
+
+	send := func(tags []string, transactional bool) error {
+		_, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+			Type:          "email",
+			Content:       map[string]interface{}{"subject": "Hello", "email_body": "Body"},
+			Recipients:    recipients,
+			Tags:          tags,
+			Transactional: transactional,
+			Tenant:        "growth",
+		})
+		return err
+	}
+
+	require.NoError(t, send(nil, false))
+	require.NoError(t, send(nil, false))
+
+	err := send(nil, false)
+	require.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.Contains(t, err.Error(), `the tenant "growth" has spent 0.004 of its 0.005 USD budget for 2024-06`)
+	assert.Equal(t, 1.0, notificationBudgetRejectionsTotal.Value("tenant"))
+
+	var alert models.SlackNotificationRequest
+	require.NoError(t, json.Unmarshal([]byte(<-kafkaService.GetSlackChannel()), &alert))
+	assert.Equal(t, "finops", alert.Recipient)
+	assert.Contains(t, alert.Content.Text, `The monthly budget of the tenant "growth" is exhausted`)
+
+	// The alert is posted once a month, transactional sends are never rejected
+	require.ErrorIs(t, send(nil, false), ErrBudgetExceeded)
+	assert.Empty(t, kafkaService.GetSlackChannel())
+	require.NoError(t, send(nil, true))
+
+	// A new month starts with a fresh budget; campaigns have budgets of their own
+	fakeClock.Advance(24 * time.Hour)
+	require.NoError(t, send([]string{"Q3-Campaign"}, false))
+	err = send([]string{"q3-campaign"}, false)
+	require.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.Contains(t, err.Error(), `the campaign "q3-campaign" has spent 0.002 of its 0.003 USD budget for 2024-07`)
+}
+
+func TestChargeBudgets_RefundsFailedRequests(t *testing.T) {
+	config := DefaultConfig()
+	config.UnitCosts = map[string]float64{"email": 0.001}
+	config.TenantBudgets = map[string]float64{"growth": 0.002}
+	nm, _, recipients := newTestManager(t, 2, config)
+
+	request := &models.NotificationRequest{
+		Type:       "email",
+		Content:    map[string]interface{}{"subject": "Hello", "email_body": "Body"},
+		Template:   &models.TemplateData{ID: "missing-template", Version: 1},
+		Recipients: recipients,
+		Tenant:     "growth",
+	}
+	_, err := nm.ProcessNotificationRequest(request)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrBudgetExceeded)
+
+	// The failed request did not use up the budget
+	request.Template = nil
+	_, err = nm.ProcessNotificationRequest(request)
+	require.NoError(t, err)
+}
@@ -0,0 +1,75 @@
+package notification_manager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// notificationsCancelledTotal counts scheduled notifications cancelled before they were sent
+var notificationsCancelledTotal = metrics.DefaultRegistry.NewCounterVec(
+	"notifications_cancelled_total",
+	"Scheduled notifications cancelled before their scheduled time, by notification type.",
+	"type",
+)
+
+// CancellationResult is the response to a cancelled notification
+type CancellationResult struct {
+	ID          string             `json:"id"`
+	Status      NotificationStatus `json:"status"`
+	ScheduledAt *time.Time         `json:"scheduled_at,omitempty"`
+	CancelledAt time.Time          `json:"cancelled_at"`
+}
+
+// CancelNotification cancels a scheduled notification: its job is removed from the scheduler and
+// its status becomes cancelled. Notifications that are no longer scheduled, such as sent or
+// expired ones, cannot be cancelled.
+func (nm *NotificationManagerImpl) CancelNotification(notificationID string, actor string) (interface{}, error) {
+	reason := "cancelled through the API"
+	if actor != "" {
+		reason = "cancelled by " + actor
+	}
+
+	cancelled, err := nm.storage.CancelScheduled(notificationID, reason)
+	if err != nil {
+		return nil, err
+	}
+	record, err := nm.storage.GetNotification(notificationID)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelled {
+		return nil, fmt.Errorf("%w: the notification is %s, only scheduled notifications can be cancelled", ErrNotificationNotCancellable, record.Status)
+	}
+
+	if err := nm.scheduler.CancelJob(notificationID); err != nil {
+		logrus.WithError(err).WithField("notification_id", notificationID).Warn("Failed to remove cancelled notification from the scheduler")
+	}
+	notificationsCancelledTotal.Inc(record.Type)
+	logrus.WithFields(logrus.Fields{
+		"notification_id": notificationID,
+		"scheduled_at":    record.ScheduledAt,
+		"reason":          reason,
+	}).Info("Scheduled notification cancelled")
+
+	return &CancellationResult{
+		ID:          notificationID,
+		Status:      StatusCancelled,
+		ScheduledAt: record.ScheduledAt,
+		CancelledAt: record.UpdatedAt,
+	}, nil
+}
+
+// finishQueued records the outcome of a scheduled job that took its notification. The
+// notification stays as it is when it left queued meanwhile, e.g. because it was deleted.
+func (nm *NotificationManagerImpl) finishQueued(notificationID string, status NotificationStatus, errorMsg string) {
+	finished, err := nm.storage.FinishQueued(notificationID, status, errorMsg)
+	if err != nil || !finished {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"notification_id": notificationID,
+			"status":          status,
+		}).Warn("Failed to record outcome of scheduled notification, it is no longer queued")
+	}
+}
@@ -0,0 +1,103 @@
+package notification_manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/external_services/user"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancelNotification_RemovesScheduledJob(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 1, DefaultConfig())
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	nm.SetClock(fakeClock)
+	notificationID, held := scheduleHeld(t, nm, fakeClock, recipients[0])
+	job := held.jobs[notificationID]
+
+	result, err := nm.CancelNotification(notificationID, "ops-team")
+	require.NoError(t, err)
+	cancellation := result.(*CancellationResult)
+	assert.Equal(t, StatusCancelled, cancellation.Status)
+	assert.Equal(t, fakeClock.Now(), cancellation.CancelledAt)
+	assert.Equal(t, []string{notificationID}, held.cancelled)
+	assert.NotContains(t, held.jobs, notificationID)
+
+	record, err := nm.storage.GetNotification(notificationID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCancelled, record.Status)
+	assert.Empty(t, record.Error)
+	require.Len(t, record.Audit, 1)
+	assert.Equal(t, NotificationAuditEntry{
+		At:         fakeClock.Now(),
+		FromStatus: StatusScheduled,
+		ToStatus:   StatusCancelled,
+		Reason:     "cancelled by ops-team",
+	}, record.Audit[0])
+
+	// A job that was already starting when the notification was cancelled does not send it
+	fakeClock.Set(time.Date(2024, 1, 1, 9, 10, 0, 0, time.UTC))
+	job()
+	assert.Empty(t, kafkaService.GetEmailChannel())
+}
+
+func TestCancelNotification_RejectsNotificationsNoLongerScheduled(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 1, DefaultConfig())
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	nm.SetClock(fakeClock)
+	notificationID, held := scheduleHeld(t, nm, fakeClock, recipients[0])
+
+	fakeClock.Set(time.Date(2024, 1, 1, 9, 10, 0, 0, time.UTC))
+	held.jobs[notificationID]()
+	require.Len(t, kafkaService.GetEmailChannel(), 1)
+
+	_, err := nm.CancelNotification(notificationID, "ops-team")
+	assert.ErrorIs(t, err, ErrNotificationNotCancellable)
+	assert.Contains(t, err.Error(), "the notification is sent")
+
+	record, err := nm.storage.GetNotification(notificationID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusSent, record.Status)
+
+	_, err = nm.CancelNotification("unknown", "ops-team")
+	assert.ErrorIs(t, err, ErrNotificationNotFound)
+}
+
+// lookupHook runs onLookup whenever recipients are looked up, i.e. while a notification is sent
+type lookupHook struct {
+	user.UserService
+	onLookup func()
+}
+
+func (h *lookupHook) GetUsersNotificationInfo(userIDs []string) ([]*models.UserNotificationInfo, error) {
+	h.onLookup()
+	return h.UserService.GetUsersNotificationInfo(userIDs)
+}
+
+func TestCancelNotification_RacesRunningJob(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 1, DefaultConfig())
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	nm.SetClock(fakeClock)
+	notificationID, held := scheduleHeld(t, nm, fakeClock, recipients[0])
+
+	// The notification is cancelled while its job sends it
+	var cancelErr error
+	nm.userService = &lookupHook{UserService: nm.userService, onLookup: func() {
+		_, cancelErr = nm.CancelNotification(notificationID, "ops-team")
+	}}
+	fakeClock.Set(time.Date(2024, 1, 1, 9, 10, 0, 0, time.UTC))
+	held.jobs[notificationID]()
+
+	assert.ErrorIs(t, cancelErr, ErrNotificationNotCancellable)
+	assert.Contains(t, cancelErr.Error(), "the notification is queued")
+	assert.Empty(t, held.cancelled)
+	require.Len(t, kafkaService.GetEmailChannel(), 1)
+
+	record, err := nm.storage.GetNotification(notificationID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusSent, record.Status)
+	assert.Empty(t, record.Audit)
+}
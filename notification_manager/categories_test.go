@@ -0,0 +1,212 @@
+package notification_manager
+
+import (
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessNotificationRequest_CategoryPreferences(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 3, DefaultConfig())
+
+	_, err := nm.UpdatePreferences(recipients[0], &models.NotificationPreferences{
+		Categories: map[string]*models.CategoryPreference{
+			models.CategoryMarketing: {OptOut: true},
+			models.CategorySecurity:  {OptOut: true},
+		},
+	})
+	require.NoError(t, err)
+	_, err = nm.UpdatePreferences(recipients[1], &models.NotificationPreferences{
+		Categories: map[string]*models.CategoryPreference{
+			models.CategoryMarketing: {MutedChannels: []string{"slack"}},
+		},
+	})
+	require.NoError(t, err)
+
+	send := func(category string) string {
+		t.Helper()
+		response, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+			Type:       "email",
+			Category:   category,
+			Content:    map[string]interface{}{"subject": "Hello", "email_body": "Body"},
+			Recipients: recipients,
+		})
+		require.NoError(t, err)
+		return response.(map[string]interface{})["id"].(string)
+	}
+
+	// The opted-out user is skipped, muting another channel does not matter
+	id := send(models.CategoryMarketing)
+	assert.Len(t, kafkaService.GetEmailChannel(), 2)
+	progress, err := nm.storage.GetProgress(id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, progress.Suppressed)
+	assert.Equal(t, 2, progress.Queued)
+
+	// Security notifications are not suppressible by default
+	send(models.CategorySecurity)
+	assert.Len(t, kafkaService.GetEmailChannel(), 5)
+
+	// Requests without a category are transactional
+	id = send("")
+	assert.Len(t, kafkaService.GetEmailChannel(), 8)
+	record, err := nm.storage.GetNotification(id)
+	require.NoError(t, err)
+	assert.Equal(t, models.CategoryTransactional, record.Category)
+}
+
+func TestProcessNotificationRequest_CategoryFromTemplate(t *testing.T) {
+	config := DefaultConfig()
+	config.NonSuppressibleCategories = nil
+	nm, kafkaService, recipients := newTestManager(t, 2, config)
+
+	_, err := nm.UpdatePreferences(recipients[0], &models.NotificationPreferences{
+		Categories: map[string]*models.CategoryPreference{
+			models.CategorySecurity: {MutedChannels: []string{"email"}},
+		},
+	})
+	require.NoError(t, err)
+
+	// The password reset template is a security template
+	response, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type: "email",
+		Template: &models.TemplateData{
+			ID:      "550e8400-e29b-41d4-a716-446655440001",
+			Version: 1,
+			Data: map[string]interface{}{
+				"name":         "Stream",
+				"platform":     "Acme",
+				"reset_link":   "https://acme.example/reset",
+				"expiry_hours": 2,
+			},
+		},
+		Recipients: recipients,
+	})
+	require.NoError(t, err)
+
+	record, err := nm.storage.GetNotification(response.(map[string]interface{})["id"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, models.CategorySecurity, record.Category)
+	assert.Len(t, kafkaService.GetEmailChannel(), 1, "security is suppressible once it is not configured otherwise")
+}
+
+func TestProcessNotificationRequest_CategoryOverrideNeedsTrustedTenant(t *testing.T) {
+	config := DefaultConfig()
+	config.TrustedTenants = []string{"auth"}
+	nm, _, recipients := newTestManager(t, 1, config)
+
+	send := func(tenant string) error {
+		t.Helper()
+		_, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+			Type:     "email",
+			Category: models.CategoryTransactional,
+			Template: &models.TemplateData{
+				ID:      "550e8400-e29b-41d4-a716-446655440001",
+				Version: 1,
+				Data: map[string]interface{}{
+					"name":         "Stream",
+					"platform":     "Acme",
+					"reset_link":   "https://acme.example/reset",
+					"expiry_hours": 2,
+				},
+			},
+			Recipients: recipients,
+			Tenant:     tenant,
+		})
+		return err
+	}
+
+	// The security template cannot be sent under another category by other API keys
+	assert.ErrorIs(t, send("marketing"), ErrCategoryNotAllowed)
+	assert.NoError(t, send("auth"))
+}
+
+func TestProcessNotificationRequest_TransactionalBypassesPreferences(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 2, DefaultConfig())
+
+	_, err := nm.UpdatePreferences(recipients[0], &models.NotificationPreferences{
+		Categories: map[string]*models.CategoryPreference{
+			models.CategoryMarketing: {OptOut: true},
+		},
+	})
+	require.NoError(t, err)
+
+	request := models.NotificationRequest{
+		Type:          "email",
+		Category:      models.CategoryMarketing,
+		Transactional: true,
+		Content:       map[string]interface{}{"subject": "Your plan renews tomorrow", "email_body": "Body"},
+		Recipients:    recipients,
+	}
+	_, err = nm.ProcessNotificationRequest(&request)
+	require.NoError(t, err)
+	assert.Len(t, kafkaService.GetEmailChannel(), 2)
+
+	// API keys that are not trusted cannot set the flag, however the request reaches the manager
+	untrusted := request
+	untrusted.Tenant = "marketing"
+	_, err = nm.ProcessNotificationRequest(&untrusted)
+	assert.ErrorIs(t, err, ErrTransactionalNotAllowed)
+	assert.Len(t, kafkaService.GetEmailChannel(), 2)
+}
+
+func TestProcessNotificationRequest_MutedThreads(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 2, DefaultConfig())
+
+	_, err := nm.UpdatePreferences(recipients[0], &models.NotificationPreferences{MutedThreads: []string{"order:42"}})
+	require.NoError(t, err)
+
+	send := func(request *models.NotificationRequest) string {
+		t.Helper()
+		request.Recipients = recipients
+		response, err := nm.ProcessNotificationRequest(request)
+		require.NoError(t, err)
+		return response.(map[string]interface{})["id"].(string)
+	}
+	inApp := func(threadKey string, transactional bool) *models.NotificationRequest {
+		return &models.NotificationRequest{
+			Type:          "in_app",
+			ThreadKey:     threadKey,
+			Transactional: transactional,
+			Content:       map[string]interface{}{"title": "Order #42", "body": "Your order has shipped."},
+		}
+	}
+
+	// In-app notifications of the muted thread are kept in the inbox as read
+	id := send(inApp("order:42", false))
+	record, err := nm.storage.GetNotification(id)
+	require.NoError(t, err)
+	assert.Equal(t, "order:42", record.ThreadKey)
+	items := nm.inbox.List(recipients[0], false, false)
+	require.Len(t, items, 1)
+	assert.True(t, items[0].Muted)
+	assert.NotNil(t, items[0].ReadAt)
+	assert.Equal(t, "order:42", items[0].ThreadKey)
+	unread := nm.inbox.List(recipients[1], true, false)
+	require.Len(t, unread, 1)
+	assert.False(t, unread[0].Muted)
+
+	// Other threads and transactional notifications are not muted
+	send(inApp("order:43", false))
+	send(inApp("order:42", true))
+	assert.Len(t, nm.inbox.List(recipients[0], true, false), 2)
+
+	// Pushes of the muted thread are suppressed, other channels are not muted
+	id = send(&models.NotificationRequest{
+		Type:      "ios_push",
+		ThreadKey: "order:42",
+		Content:   map[string]interface{}{"title": "Order #42", "body": "Your order has shipped."},
+	})
+	progress, err := nm.storage.GetProgress(id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, progress.Suppressed)
+
+	send(&models.NotificationRequest{
+		Type:      "email",
+		ThreadKey: "order:42",
+		Content:   map[string]interface{}{"subject": "Order #42", "email_body": "Your order has shipped."},
+	})
+	assert.Len(t, kafkaService.GetEmailChannel(), 2)
+}
@@ -0,0 +1,66 @@
+package notification_manager
+
+import (
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// notificationFallbacksTotal counts recipients sent a notification on a fallback channel
+var notificationFallbacksTotal = metrics.DefaultRegistry.NewCounterVec(
+	"notification_fallbacks_total",
+	"Recipients who could not be reached on the notification type and were sent their notification on a fallback channel.",
+	"type", "channel",
+)
+
+// isReachable reports whether a recipient has the contact details a channel needs. In-app
+// notifications always reach the recipient's inbox.
+func isReachable(channel string, userInfo *models.UserNotificationInfo) bool {
+	switch channel {
+	case "email":
+		return userInfo.Email != ""
+	case "slack":
+		return userInfo.SlackChannel != ""
+	case "google_chat":
+		return userInfo.GoogleChatSpace != ""
+	case "incident":
+		return userInfo.IncidentRoute != ""
+	case "rcs":
+		return userInfo.PhoneNumber != ""
+	case "in_app":
+		return true
+	}
+	return false
+}
+
+// routeRequest returns the request to send to a recipient. Recipients who cannot be reached on
+// the notification type are sent the channel content of the first fallback channel they can be
+// reached on; the request itself is returned when there is none.
+func (nm *NotificationManagerImpl) routeRequest(request *models.NotificationRequest, userInfo *models.UserNotificationInfo) *models.NotificationRequest {
+	if len(request.FallbackChannels) == 0 || isReachable(request.Type, userInfo) {
+		return request
+	}
+
+	for _, channel := range request.FallbackChannels {
+		content := request.ContentFor(channel)
+		if content == nil || !isReachable(channel, userInfo) {
+			continue
+		}
+
+		fallback := *request
+		fallback.Type = channel
+		fallback.Content = content
+		fallback.Template = nil
+		fallback.From = nil
+		if channel == string(models.EmailNotification) {
+			fallback.From = defaultSender(nm.senderSettings(request.Tenant))
+		}
+		fallback.FallbackChannels = nil
+		if nm.requiresVerifiedContact(&fallback, userInfo) {
+			continue
+		}
+
+		notificationFallbacksTotal.Inc(request.Type, channel)
+		return &fallback
+	}
+	return request
+}
@@ -0,0 +1,72 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessNotificationRequest_FallbackChannel(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 1, DefaultConfig())
+	require.NoError(t, nm.userService.CreateUser(&models.User{
+		ID:           "slack-user",
+		Email:        "slack.user@company.com",
+		SlackChannel: "@slack.user",
+		IsActive:     true,
+	}))
+
+	result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type: "slack",
+		ChannelContent: map[string]map[string]interface{}{
+			models.ChannelContentSlack: {"text": "Deploy finished"},
+			models.ChannelContentEmail: {"subject": "Deploy finished", "email_body": "The deploy finished."},
+		},
+		FallbackChannels: []string{"email"},
+		Recipients:       []string{recipients[0], "slack-user"},
+	})
+	require.NoError(t, err)
+
+	progress, err := nm.storage.GetProgress(result.(map[string]interface{})["id"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, 2, progress.Queued)
+
+	require.Len(t, kafkaService.GetSlackChannel(), 1)
+	var slack models.SlackNotificationRequest
+	require.NoError(t, json.Unmarshal([]byte(<-kafkaService.GetSlackChannel()), &slack))
+	assert.Equal(t, "Deploy finished", slack.Content.Text)
+
+	require.Len(t, kafkaService.GetEmailChannel(), 1)
+	var email models.EmailNotificationRequest
+	require.NoError(t, json.Unmarshal([]byte(<-kafkaService.GetEmailChannel()), &email))
+	assert.Equal(t, recipients[0], email.UserID)
+	assert.Equal(t, "Deploy finished", email.Content.Subject)
+	assert.Equal(t, "The deploy finished.", email.Content.EmailBody)
+}
+
+func TestProcessNotificationRequest_FallbackChannelOnlyWhenUnreachable(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 1, DefaultConfig())
+
+	// Recipients reachable on the notification type are not sent the fallback content
+	result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:    "email",
+		Content: map[string]interface{}{"subject": "Hello", "email_body": "Body"},
+		From: &struct {
+			Email string `json:"email"`
+		}{Email: "noreply@company.com"},
+		ChannelContent: map[string]map[string]interface{}{
+			models.ChannelContentSlack: {"text": "Hello"},
+		},
+		FallbackChannels: []string{"slack"},
+		Recipients:       []string{recipients[0]},
+	})
+	require.NoError(t, err)
+
+	progress, err := nm.storage.GetProgress(result.(map[string]interface{})["id"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, 1, progress.Queued)
+	assert.Len(t, kafkaService.GetEmailChannel(), 1)
+	assert.Len(t, kafkaService.GetSlackChannel(), 0)
+}
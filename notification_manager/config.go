@@ -0,0 +1,395 @@
+package notification_manager
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gaurav2721/notification-service/constants"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Config holds tuning options for recipient fan-out, the inbox digest job and category suppression
+type Config struct {
+	// RecipientBatchSize is the number of recipients resolved from the user service at a time
+	RecipientBatchSize int
+
+	// AsyncRecipientThreshold is the recipient count above which immediate notifications
+	// are accepted right away and fanned out in the background
+	AsyncRecipientThreshold int
+
+	// EnqueueTimeout is how long a background fan-out waits for room on a full channel.
+	// Synchronous sends never wait and fail fast when a channel is full.
+	EnqueueTimeout time.Duration
+
+	// MaxTagLabelValues bounds the number of distinct tags used as metrics labels
+	MaxTagLabelValues int
+
+	// DigestCheckInterval is how often the inbox digest job looks for digests that are due
+	DigestCheckInterval time.Duration
+
+	// DigestFromEmail is the sender of inbox digest emails; empty uses the email service default
+	DigestFromEmail string
+
+	// InboxArchiveAfter is how long inbox items are kept before they are archived; zero keeps them
+	InboxArchiveAfter time.Duration
+
+	// InboxMaxItems bounds the items in each user's inbox, the oldest are archived first; zero is unlimited
+	InboxMaxItems int
+
+	// InboxRetentionInterval is how often the inbox retention job archives old items
+	InboxRetentionInterval time.Duration
+
+	// InboxMaxStoredItems bounds the items kept for each user, archived ones included; the oldest
+	// are deleted first. Zero is unlimited.
+	InboxMaxStoredItems int
+
+	// DefaultFromEmail is the sender of email notifications sent without a from address by
+	// tenants that did not set their own
+	DefaultFromEmail string
+
+	// AllowedFromDomains are the domains from addresses may use for tenants that did not set
+	// their own; empty allows every domain
+	AllowedFromDomains []string
+
+	// NonSuppressibleCategories are delivered even to users who opted out of or muted them
+	NonSuppressibleCategories []string
+
+	// TrustedTenants are the API keys that may send transactional notifications and send
+	// templates under another category than their own
+	TrustedTenants []string
+
+	// UnitCosts is the estimated cost of one delivered message by notification type
+	UnitCosts map[string]float64
+
+	// CostCurrency is the currency of UnitCosts
+	CostCurrency string
+
+	// TenantBudgets caps the estimated monthly spend by tenant, the name of the API key
+	TenantBudgets map[string]float64
+
+	// CampaignBudgets caps the estimated monthly spend by campaign, the notification tag
+	CampaignBudgets map[string]float64
+
+	// BudgetAlertSlackChannel, when set, receives a Slack alert when a budget is exhausted
+	BudgetAlertSlackChannel string
+
+	// VerifiedContactTypes are the notification types only delivered to verified contacts
+	VerifiedContactTypes map[string]bool
+
+	// VerificationLinkURL, when set, adds a link with the verification code to verification emails
+	VerificationLinkURL string
+
+	// DNDPolicy is what happens to non-urgent notifications for users in do-not-disturb:
+	// DNDPolicyDefer sends them when do-not-disturb ends, DNDPolicyDrop drops them
+	DNDPolicy string
+
+	// UnreachableRetryWindow is how long notifications are held for recipients without the
+	// contact details their type needs, to be sent when the recipient gains them; zero skips
+	// those recipients
+	UnreachableRetryWindow time.Duration
+
+	// DuplicateWindow is how long notifications are remembered to detect identical requests;
+	// zero disables duplicate detection
+	DuplicateWindow time.Duration
+
+	// DuplicatePolicy is DuplicatePolicyWarn to send duplicates with a warning or
+	// DuplicatePolicyBlock to reject them
+	DuplicatePolicy string
+
+	// ScheduleCatchUpGrace is how late a scheduled notification may still be sent, e.g. after
+	// the service was paused; later ones expire. Zero sends them however late they are.
+	ScheduleCatchUpGrace time.Duration
+
+	// ExpirySweepInterval is how often the expiry sweeper looks for scheduled notifications
+	// past the catch-up grace period
+	ExpirySweepInterval time.Duration
+
+	// ScheduledBatchWindow rounds the send time of scheduled notifications up to the next multiple
+	// of the window, so notifications due in the same window are sent together and can be batched
+	// into fewer provider calls; zero sends them at their scheduled time
+	ScheduledBatchWindow time.Duration
+
+	// LifecycleEvents publishes every notification status change to the notification-events topic
+	LifecycleEvents bool
+
+	// DefaultLocale is the locale localized templates fall back to last for tenants without
+	// their own LocaleFallbacks entry
+	DefaultLocale string
+
+	// LocaleFallbacks configures the locale fallback chain of localized templates by tenant
+	LocaleFallbacks map[string]LocaleFallback
+
+	// MediaMaxUploadBytes is the largest media asset that can be uploaded
+	MediaMaxUploadBytes int64
+
+	// MediaUnreferencedTTL is how long after their upload media assets no template references
+	// are kept before the media cleanup deletes them; zero keeps them until they are deleted
+	MediaUnreferencedTTL time.Duration
+
+	// MediaCleanupInterval is how often the media cleanup looks for unreferenced assets
+	MediaCleanupInterval time.Duration
+
+	// TemplatesDir, when set, is a directory of template files that are loaded at startup and
+	// reloaded when they change
+	TemplatesDir string
+
+	// TemplatesReloadInterval is how often the template directory is checked for changes
+	TemplatesReloadInterval time.Duration
+
+	// TemplatesGitSyncInterval is how often the template repository, when one is configured, is
+	// pulled for new commits
+	TemplatesGitSyncInterval time.Duration
+
+	// QueueArchiveRetention is how long messages posted to the channels are archived for replay;
+	// zero disables the archive
+	QueueArchiveRetention time.Duration
+
+	// QueueArchiveMaxMessages bounds the number of archived messages; the oldest are dropped first
+	QueueArchiveMaxMessages int
+}
+
+// DefaultConfig returns the fan-out configuration used when no environment overrides are set
+func DefaultConfig() Config {
+	return Config{
+		RecipientBatchSize:        constants.DefaultRecipientBatchSize,
+		AsyncRecipientThreshold:   constants.DefaultAsyncRecipientThreshold,
+		EnqueueTimeout:            time.Duration(constants.DefaultRecipientEnqueueTimeoutMs) * time.Millisecond,
+		MaxTagLabelValues:         constants.DefaultMetricsMaxTagValues,
+		DigestCheckInterval:       time.Duration(constants.DefaultDigestCheckIntervalMinutes) * time.Minute,
+		InboxArchiveAfter:         time.Duration(constants.DefaultInboxArchiveAfterDays) * 24 * time.Hour,
+		InboxMaxItems:             constants.DefaultInboxMaxItems,
+		InboxRetentionInterval:    time.Duration(constants.DefaultInboxRetentionIntervalMinutes) * time.Minute,
+		InboxMaxStoredItems:       constants.DefaultInboxMaxStoredItems,
+		NonSuppressibleCategories: splitList(constants.DefaultNonSuppressibleCategories),
+		UnitCosts:                 map[string]float64{},
+		CostCurrency:              constants.DefaultCostCurrency,
+		TenantBudgets:             map[string]float64{},
+		CampaignBudgets:           map[string]float64{},
+		VerifiedContactTypes:      map[string]bool{},
+		DNDPolicy:                 constants.DefaultDNDPolicy,
+		DuplicatePolicy:           constants.DefaultDuplicatePolicy,
+		ScheduleCatchUpGrace:      time.Duration(constants.DefaultScheduleCatchUpGraceSeconds) * time.Second,
+		ExpirySweepInterval:       time.Duration(constants.DefaultExpirySweepIntervalSeconds) * time.Second,
+		DefaultLocale:             constants.DefaultLocale,
+		LocaleFallbacks:           map[string]LocaleFallback{},
+		MediaMaxUploadBytes:       constants.DefaultMediaMaxUploadBytes,
+		MediaUnreferencedTTL:      time.Duration(constants.DefaultMediaUnreferencedTTLHours) * time.Hour,
+		MediaCleanupInterval:      time.Duration(constants.DefaultMediaCleanupIntervalMinutes) * time.Minute,
+		TemplatesReloadInterval:   time.Duration(constants.DefaultTemplatesReloadIntervalSeconds) * time.Second,
+		TemplatesGitSyncInterval:  time.Duration(constants.DefaultTemplatesGitSyncIntervalSeconds) * time.Second,
+		QueueArchiveRetention:     time.Duration(constants.DefaultQueueArchiveRetentionHours) * time.Hour,
+		QueueArchiveMaxMessages:   constants.DefaultQueueArchiveMaxMessages,
+	}
+}
+
+// LoadConfigFromEnv reads the fan-out configuration from environment variables
+func LoadConfigFromEnv() Config {
+	config := DefaultConfig()
+
+	if size := getEnvAsInt(constants.RecipientBatchSizeEnvVar); size > 0 {
+		config.RecipientBatchSize = size
+	}
+	if threshold := getEnvAsInt(constants.AsyncRecipientThresholdEnvVar); threshold > 0 {
+		config.AsyncRecipientThreshold = threshold
+	}
+	if timeoutMs := getEnvAsInt(constants.RecipientEnqueueTimeoutMsEnvVar); timeoutMs > 0 {
+		config.EnqueueTimeout = time.Duration(timeoutMs) * time.Millisecond
+	}
+	if maxTags := getEnvAsInt(constants.MetricsMaxTagValuesEnvVar); maxTags > 0 {
+		config.MaxTagLabelValues = maxTags
+	}
+	if minutes := getEnvAsInt(constants.DigestCheckIntervalMinutesEnvVar); minutes > 0 {
+		config.DigestCheckInterval = time.Duration(minutes) * time.Minute
+	}
+	config.DigestFromEmail = os.Getenv(constants.DigestFromEmailEnvVar)
+	if _, ok := os.LookupEnv(constants.InboxArchiveAfterDaysEnvVar); ok {
+		if days := getEnvAsInt(constants.InboxArchiveAfterDaysEnvVar); days >= 0 {
+			config.InboxArchiveAfter = time.Duration(days) * 24 * time.Hour
+		}
+	}
+	if _, ok := os.LookupEnv(constants.InboxMaxItemsEnvVar); ok {
+		if maxItems := getEnvAsInt(constants.InboxMaxItemsEnvVar); maxItems >= 0 {
+			config.InboxMaxItems = maxItems
+		}
+	}
+	if minutes := getEnvAsInt(constants.InboxRetentionIntervalMinutesEnvVar); minutes > 0 {
+		config.InboxRetentionInterval = time.Duration(minutes) * time.Minute
+	}
+	if _, ok := os.LookupEnv(constants.InboxMaxStoredItemsEnvVar); ok {
+		if maxStored := getEnvAsInt(constants.InboxMaxStoredItemsEnvVar); maxStored >= 0 {
+			config.InboxMaxStoredItems = maxStored
+		}
+	}
+	config.DefaultFromEmail = strings.TrimSpace(os.Getenv(constants.DefaultFromEmailEnvVar))
+	config.AllowedFromDomains = splitList(strings.ToLower(os.Getenv(constants.AllowedFromDomainsEnvVar)))
+	// An empty value makes every category suppressible
+	if categories, ok := os.LookupEnv(constants.NonSuppressibleCategoriesEnvVar); ok {
+		config.NonSuppressibleCategories = splitList(categories)
+	}
+	config.TrustedTenants = splitList(os.Getenv(constants.TRANSACTIONAL_API_KEYS))
+	if costs := os.Getenv(constants.ChannelUnitCostsEnvVar); costs != "" {
+		config.UnitCosts = parseUnitCosts(costs)
+	}
+	if currency := os.Getenv(constants.CostCurrencyEnvVar); currency != "" {
+		config.CostCurrency = strings.ToUpper(strings.TrimSpace(currency))
+	}
+	if budgets := os.Getenv(constants.TenantMonthlyBudgetsEnvVar); budgets != "" {
+		config.TenantBudgets = parseAmounts(budgets, "tenant budget")
+	}
+	if budgets := os.Getenv(constants.CampaignMonthlyBudgetsEnvVar); budgets != "" {
+		// Tags are matched in lower case
+		for campaign, budget := range parseAmounts(budgets, "campaign budget") {
+			config.CampaignBudgets[strings.ToLower(campaign)] = budget
+		}
+	}
+	config.BudgetAlertSlackChannel = os.Getenv(constants.BudgetAlertSlackChannelEnvVar)
+	for _, notificationType := range splitList(os.Getenv(constants.VerifiedContactTypesEnvVar)) {
+		// Only email addresses are verifiable contacts of a notification channel
+		if notificationType != string(models.EmailNotification) {
+			logrus.WithField("type", notificationType).Warn("Verified contacts can only be required for email, ignoring")
+			continue
+		}
+		config.VerifiedContactTypes[notificationType] = true
+	}
+	config.VerificationLinkURL = os.Getenv(constants.VerificationLinkURLEnvVar)
+	if policy := strings.ToLower(strings.TrimSpace(os.Getenv(constants.DNDPolicyEnvVar))); policy != "" {
+		if isValidDNDPolicy(policy) {
+			config.DNDPolicy = policy
+		} else {
+			logrus.WithField("policy", policy).Warn("Invalid do-not-disturb policy, using default")
+		}
+	}
+	if hours := getEnvAsInt(constants.UnreachableRetryWindowHoursEnvVar); hours > 0 {
+		config.UnreachableRetryWindow = time.Duration(hours) * time.Hour
+	}
+	if minutes := getEnvAsInt(constants.DuplicateWindowMinutesEnvVar); minutes > 0 {
+		config.DuplicateWindow = time.Duration(minutes) * time.Minute
+	}
+	if policy := strings.ToLower(strings.TrimSpace(os.Getenv(constants.DuplicatePolicyEnvVar))); policy != "" {
+		if isValidDuplicatePolicy(policy) {
+			config.DuplicatePolicy = policy
+		} else {
+			logrus.WithField("policy", policy).Warn("Invalid duplicate policy, using default")
+		}
+	}
+	// Zero disables expiry, so it is only the default when the variable is unset
+	if _, ok := os.LookupEnv(constants.ScheduleCatchUpGraceSecondsEnvVar); ok {
+		if seconds := getEnvAsInt(constants.ScheduleCatchUpGraceSecondsEnvVar); seconds >= 0 {
+			config.ScheduleCatchUpGrace = time.Duration(seconds) * time.Second
+		}
+	}
+	if seconds := getEnvAsInt(constants.ExpirySweepIntervalSecondsEnvVar); seconds > 0 {
+		config.ExpirySweepInterval = time.Duration(seconds) * time.Second
+	}
+	if seconds := getEnvAsInt(constants.ScheduledBatchWindowSecondsEnvVar); seconds > 0 {
+		config.ScheduledBatchWindow = time.Duration(seconds) * time.Second
+	}
+	config.LifecycleEvents, _ = strconv.ParseBool(os.Getenv(constants.NotificationEventsEnabledEnvVar))
+	if locale := os.Getenv(constants.DefaultLocaleEnvVar); locale != "" {
+		if normalized, err := models.NormalizeLocale(locale); err == nil {
+			config.DefaultLocale = normalized
+		} else {
+			logrus.WithField("locale", locale).Warn("Invalid default locale, using default")
+		}
+	}
+	if path := os.Getenv(constants.LocaleFallbacksPathEnvVar); path != "" {
+		if fallbacks, err := loadLocaleFallbacks(path); err == nil {
+			config.LocaleFallbacks = fallbacks
+		} else {
+			logrus.WithError(err).Error("Invalid locale fallbacks, using the default locale for every tenant")
+		}
+	}
+	if maxBytes := getEnvAsInt(constants.MediaMaxUploadBytesEnvVar); maxBytes > 0 {
+		config.MediaMaxUploadBytes = int64(maxBytes)
+	}
+	// Zero keeps unreferenced assets, so it is only the default when the variable is unset
+	if _, ok := os.LookupEnv(constants.MediaUnreferencedTTLHoursEnvVar); ok {
+		if hours := getEnvAsInt(constants.MediaUnreferencedTTLHoursEnvVar); hours >= 0 {
+			config.MediaUnreferencedTTL = time.Duration(hours) * time.Hour
+		}
+	}
+	if minutes := getEnvAsInt(constants.MediaCleanupIntervalMinutesEnvVar); minutes > 0 {
+		config.MediaCleanupInterval = time.Duration(minutes) * time.Minute
+	}
+	config.TemplatesDir = os.Getenv(constants.TemplatesDirEnvVar)
+	// Zero loads the directory at startup only, so it is only the default when the variable is unset
+	if _, ok := os.LookupEnv(constants.TemplatesReloadIntervalSecondsEnvVar); ok {
+		if seconds := getEnvAsInt(constants.TemplatesReloadIntervalSecondsEnvVar); seconds >= 0 {
+			config.TemplatesReloadInterval = time.Duration(seconds) * time.Second
+		}
+	}
+	// Zero syncs the repository at startup only, so it is only the default when the variable is unset
+	if _, ok := os.LookupEnv(constants.TemplatesGitSyncIntervalSecondsEnvVar); ok {
+		if seconds := getEnvAsInt(constants.TemplatesGitSyncIntervalSecondsEnvVar); seconds >= 0 {
+			config.TemplatesGitSyncInterval = time.Duration(seconds) * time.Second
+		}
+	}
+	// Zero disables the archive, so it is only the default when the variable is unset
+	if _, ok := os.LookupEnv(constants.QueueArchiveRetentionHoursEnvVar); ok {
+		if hours := getEnvAsInt(constants.QueueArchiveRetentionHoursEnvVar); hours >= 0 {
+			config.QueueArchiveRetention = time.Duration(hours) * time.Hour
+		}
+	}
+	if maxMessages := getEnvAsInt(constants.QueueArchiveMaxMessagesEnvVar); maxMessages > 0 {
+		config.QueueArchiveMaxMessages = maxMessages
+	}
+
+	return config
+}
+
+// getEnvAsInt reads an integer environment variable, returning 0 when unset or invalid
+func getEnvAsInt(key string) int {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return 0
+	}
+
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"key":   key,
+			"value": valueStr,
+		}).Warn("Invalid integer environment variable, using default")
+		return 0
+	}
+
+	return value
+}
+
+// parseUnitCosts parses comma separated type:cost pairs such as "email:0.0004,ios_push:0.00002",
+// skipping invalid entries
+func parseUnitCosts(value string) map[string]float64 {
+	return parseAmounts(value, "channel unit cost")
+}
+
+// parseAmounts parses comma separated name:amount pairs, skipping entries without a name or
+// with a negative or invalid amount. kind names the setting in warnings.
+func parseAmounts(value string, kind string) map[string]float64 {
+	amounts := make(map[string]float64)
+	for _, entry := range splitList(value) {
+		name, amountStr, found := strings.Cut(entry, ":")
+		name = strings.TrimSpace(name)
+		amount, err := strconv.ParseFloat(strings.TrimSpace(amountStr), 64)
+		if !found || name == "" || err != nil || amount < 0 {
+			logrus.WithField("entry", entry).Warnf("Invalid %s, ignoring it", kind)
+			continue
+		}
+		amounts[name] = amount
+	}
+	return amounts
+}
+
+// splitList splits a comma separated list, trimming spaces and dropping empty entries
+func splitList(value string) []string {
+	parts := strings.Split(value, ",")
+	list := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	return list
+}
@@ -0,0 +1,62 @@
+package notification_manager
+
+import "math"
+
+// costPrecision is the number of decimal places estimated costs are rounded to
+const costPrecision = 1e6
+
+// CostReport is the estimated spend of a set of notifications. Every message delivered by a
+// provider is charged the unit cost of its notification type; failed deliveries are free.
+type CostReport struct {
+	Currency   string             `json:"currency"`
+	Total      float64            `json:"total"`
+	ByType     map[string]float64 `json:"by_type"`
+	ByTag      map[string]float64 `json:"by_tag"`
+	ByTenant   map[string]float64 `json:"by_tenant"`
+	ByTemplate map[string]float64 `json:"by_template"`
+}
+
+func newCostReport(currency string) *CostReport {
+	return &CostReport{
+		Currency:   currency,
+		ByType:     make(map[string]float64),
+		ByTag:      make(map[string]float64),
+		ByTenant:   make(map[string]float64),
+		ByTemplate: make(map[string]float64),
+	}
+}
+
+// add charges the messages of a notification that were delivered
+func (r *CostReport) add(record *NotificationRecord, delivered int, unitCosts map[string]float64) {
+	unitCost, ok := unitCosts[record.Type]
+	if !ok || delivered == 0 {
+		return
+	}
+
+	cost := unitCost * float64(delivered)
+	r.Total += cost
+	r.ByType[record.Type] += cost
+	for _, tag := range record.Tags {
+		r.ByTag[tag] += cost
+	}
+	if record.Tenant != "" {
+		r.ByTenant[record.Tenant] += cost
+	}
+	if record.Template != nil {
+		r.ByTemplate[record.Template.ID] += cost
+	}
+}
+
+// round rounds every amount to costPrecision, hiding floating point noise from the sums
+func (r *CostReport) round() {
+	r.Total = roundCost(r.Total)
+	for _, amounts := range []map[string]float64{r.ByType, r.ByTag, r.ByTenant, r.ByTemplate} {
+		for key, amount := range amounts {
+			amounts[key] = roundCost(amount)
+		}
+	}
+}
+
+func roundCost(amount float64) float64 {
+	return math.Round(amount*costPrecision) / costPrecision
+}
@@ -0,0 +1,80 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/external_services/delivery"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUnitCosts(t *testing.T) {
+	assert.Equal(t, map[string]float64{"email": 0.0004, "ios_push": 0},
+		parseUnitCosts(" email:0.0004, ios_push:0 ,slack,sms:-1,fcm:cheap"))
+}
+
+func TestGetNotificationAnalytics_Cost(t *testing.T) {
+	config := DefaultConfig()
+	config.UnitCosts = map[string]float64{"email": 0.0004, "slack": 0.001}
+	config.CostCurrency = "EUR"
+	nm, _, recipients := newTestManager(t, 1, config)
+	deliveryService := delivery.NewDeliveryService()
+	nm.deliveryService = deliveryService
+
+	send := func(request *models.NotificationRequest, sent, failed int) {
+		request.Recipients = recipients
+		result, err := nm.ProcessNotificationRequest(request)
+		require.NoError(t, err)
+		notificationID := result.(map[string]interface{})["id"].(string)
+
+		for i := 0; i < sent+failed; i++ {
+			status := models.DeliveryStatusSent
+			if i >= sent {
+				status = models.DeliveryStatusFailed
+			}
+			require.NoError(t, deliveryService.RecordAttempt(&models.DeliveryAttempt{
+				NotificationID: notificationID, Recipient: string(rune('a'+i)) + "@company.com", Channel: request.Type, Status: status,
+			}))
+		}
+	}
+
+	emailContent := map[string]interface{}{"subject": "Hello", "email_body": "Body"}
+	send(&models.NotificationRequest{Type: "email", Content: emailContent, Tags: []string{"q3-campaign"}, Tenant: "growth"}, 3, 1)
+	send(&models.NotificationRequest{
+		Type: "email",
+		Template: &models.TemplateData{ID: "550e8400-e29b-41d4-a716-446655440000", Version: 1, Data: map[string]interface{}{
+			"name": "John", "platform": "Acme", "username": "john", "email": "john@company.com",
+			"account_type": "premium", "activation_link": "https://acme.example.com/activate",
+		}},
+		Tenant: "billing",
+	}, 2, 0)
+	send(&models.NotificationRequest{Type: "slack", Content: map[string]interface{}{"text": "Hi"}, Tags: []string{"q3-campaign"}, Tenant: "growth"}, 1, 0)
+
+	result, err := nm.GetNotificationAnalytics(NotificationFilter{})
+	require.NoError(t, err)
+	encoded, err := json.Marshal(result)
+	require.NoError(t, err)
+
+	var analytics struct {
+		Cost CostReport `json:"cost"`
+	}
+	require.NoError(t, json.Unmarshal(encoded, &analytics))
+	assert.Equal(t, CostReport{
+		Currency:   "EUR",
+		Total:      0.003,
+		ByType:     map[string]float64{"email": 0.002, "slack": 0.001},
+		ByTag:      map[string]float64{"q3-campaign": 0.0022},
+		ByTenant:   map[string]float64{"growth": 0.0022, "billing": 0.0008},
+		ByTemplate: map[string]float64{"550e8400-e29b-41d4-a716-446655440000": 0.0008},
+	}, analytics.Cost)
+
+	// The cost follows the analytics filter
+	result, err = nm.GetNotificationAnalytics(NotificationFilter{Type: "slack"})
+	require.NoError(t, err)
+	encoded, err = json.Marshal(result)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(encoded, &analytics))
+	assert.Equal(t, 0.001, analytics.Cost.Total)
+}
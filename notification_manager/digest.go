@@ -0,0 +1,199 @@
+package notification_manager
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/sirupsen/logrus"
+)
+
+// maxDigestItems is the number of unread items listed in a digest; the rest are only counted
+const maxDigestItems = 20
+
+// maxDigestPreviewLength is the number of characters of an item body shown in a digest
+const maxDigestPreviewLength = 140
+
+// digestTracker remembers when each user was last sent an inbox digest
+type digestTracker struct {
+	mu     sync.Mutex
+	sentAt map[string]time.Time
+	timer  clock.Timer
+	// running counts the digest runs in progress, so stopping can wait for them
+	running sync.WaitGroup
+}
+
+// newDigestTracker creates an empty digest tracker
+func newDigestTracker() *digestTracker {
+	return &digestTracker{
+		sentAt: make(map[string]time.Time),
+	}
+}
+
+// LastSent returns when a digest was last sent to the user, zero if never
+func (t *digestTracker) LastSent(userID string) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sentAt[userID]
+}
+
+// RecordSent records that a digest was sent to the user
+func (t *digestTracker) RecordSent(userID string, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sentAt[userID] = at
+}
+
+// StartInboxDigests starts the background job that emails inbox digests to opted-in users.
+// The job checks for due digests every DigestCheckInterval until StopInboxDigests is called.
+func (nm *NotificationManagerImpl) StartInboxDigests() {
+	interval := nm.config.DigestCheckInterval
+	if interval <= 0 {
+		logrus.Warn("Inbox digest check interval is not set, inbox digests are disabled")
+		return
+	}
+
+	var run func()
+	run = func() {
+		nm.digests.mu.Lock()
+		if nm.digests.timer == nil {
+			nm.digests.mu.Unlock()
+			return
+		}
+		nm.digests.running.Add(1)
+		nm.digests.mu.Unlock()
+
+		sent := nm.RunInboxDigests()
+		nm.digests.running.Done()
+		if sent > 0 {
+			logrus.WithField("digests_sent", sent).Info("Inbox digests sent")
+		}
+
+		nm.digests.mu.Lock()
+		defer nm.digests.mu.Unlock()
+		if nm.digests.timer != nil {
+			nm.digests.timer = nm.clock.AfterFunc(interval, run)
+		}
+	}
+
+	nm.digests.mu.Lock()
+	defer nm.digests.mu.Unlock()
+	if nm.digests.timer != nil {
+		return
+	}
+	nm.digests.timer = nm.clock.AfterFunc(interval, run)
+	logrus.WithField("interval", interval).Info("Inbox digest job started")
+}
+
+// StopInboxDigests stops the inbox digest job and waits for a run in progress, so no digest is
+// published after the message bus is closed
+func (nm *NotificationManagerImpl) StopInboxDigests() {
+	nm.digests.mu.Lock()
+	if nm.digests.timer != nil {
+		nm.digests.timer.Stop()
+		nm.digests.timer = nil
+	}
+	nm.digests.mu.Unlock()
+
+	nm.digests.running.Wait()
+}
+
+// RunInboxDigests sends a digest email to every user whose digest is due and returns the number sent.
+// A digest is due once its period has passed since the previous one; it lists the unread in-app
+// notifications received since then, except transactional ones, and is held back while the user
+// is in their quiet hours.
+func (nm *NotificationManagerImpl) RunInboxDigests() int {
+	now := nm.clock.Now()
+	sent := 0
+
+	for _, userID := range nm.inbox.UsersWithUnread() {
+		preferences := nm.preferences.Get(userID)
+		period := models.DigestPeriod(preferences.Digest)
+		if period == 0 {
+			continue
+		}
+
+		lastSent := nm.digests.LastSent(userID)
+		if !lastSent.IsZero() && now.Sub(lastSent) < period {
+			continue
+		}
+		if preferences.QuietHours != nil && preferences.QuietHours.Contains(now) {
+			logrus.WithField("user_id", userID).Debug("Inbox digest held back during quiet hours")
+			continue
+		}
+
+		items := withoutTransactional(nm.inbox.UnreadSince(userID, lastSent))
+		if len(items) == 0 {
+			continue
+		}
+
+		if _, err := nm.ProcessNotificationRequest(nm.digestRequest(userID, preferences.Digest, items)); err != nil {
+			logrus.WithError(err).WithField("user_id", userID).Error("Failed to send inbox digest")
+			continue
+		}
+		nm.digests.RecordSent(userID, now)
+		sent++
+	}
+
+	return sent
+}
+
+// digestRequest builds the email notification summarizing a user's unread inbox items
+func (nm *NotificationManagerImpl) digestRequest(userID string, frequency string, items []models.InboxItem) *models.NotificationRequest {
+	var list strings.Builder
+	for i, item := range items {
+		if i == maxDigestItems {
+			fmt.Fprintf(&list, "- and %d more\n", len(items)-maxDigestItems)
+			break
+		}
+		fmt.Fprintf(&list, "- %s: %s\n", item.Title, digestPreview(item.Body))
+	}
+
+	request := &models.NotificationRequest{
+		Type: string(models.EmailNotification),
+		Template: &models.TemplateData{
+			ID:      models.InboxDigestTemplateID,
+			Version: 1,
+			Data: map[string]interface{}{
+				"period":     frequency,
+				"item_count": len(items),
+				"items_list": strings.TrimSuffix(list.String(), "\n"),
+			},
+		},
+		Recipients: []string{userID},
+		Tags:       []string{"inbox-digest"},
+	}
+	if nm.config.DigestFromEmail != "" {
+		request.From = &struct {
+			Email string `json:"email"`
+		}{Email: nm.config.DigestFromEmail}
+	}
+	return request
+}
+
+// digestPreview shortens an item body to its first line, cut at maxDigestPreviewLength characters
+func digestPreview(body string) string {
+	preview := strings.TrimSpace(body)
+	if newline := strings.IndexByte(preview, '\n'); newline >= 0 {
+		preview = strings.TrimSpace(preview[:newline]) + " ..."
+	}
+	if runes := []rune(preview); len(runes) > maxDigestPreviewLength {
+		preview = strings.TrimSpace(string(runes[:maxDigestPreviewLength])) + " ..."
+	}
+	return preview
+}
+
+// withoutTransactional drops the items of transactional notifications, which were delivered
+// right away and are not repeated in digests
+func withoutTransactional(items []models.InboxItem) []models.InboxItem {
+	kept := items[:0]
+	for _, item := range items {
+		if !item.Transactional {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
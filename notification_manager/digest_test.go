@@ -0,0 +1,130 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sendInApp(t *testing.T, nm *NotificationManagerImpl, title, body string, recipients ...string) {
+	t.Helper()
+	_, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "in_app",
+		Content:    map[string]interface{}{"title": title, "body": body},
+		Recipients: recipients,
+	})
+	require.NoError(t, err)
+}
+
+func TestRunInboxDigests(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 2, DefaultConfig())
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	nm.SetClock(fakeClock)
+	emailChannel := kafkaService.GetEmailChannel()
+
+	sendInApp(t, nm, "Order shipped", "Your order is on its way.\nTrack it in the app.", recipients...)
+
+	_, err := nm.UpdatePreferences(recipients[0], &models.NotificationPreferences{
+		Digest:     models.DigestDaily,
+		QuietHours: &models.QuietHours{Start: "22:00", End: "07:00", Timezone: "Europe/Berlin"},
+	})
+	require.NoError(t, err)
+
+	// Only the opted-in user gets a digest
+	assert.Equal(t, 1, nm.RunInboxDigests())
+	require.Len(t, emailChannel, 1)
+	var message models.EmailNotificationRequest
+	require.NoError(t, json.Unmarshal([]byte(<-emailChannel), &message))
+	assert.Equal(t, recipients[0], message.UserID)
+	assert.Equal(t, "Your daily digest: 1 unread notifications", message.Content.Subject)
+	assert.Contains(t, message.Content.EmailBody, "Hello Stream,")
+	assert.Contains(t, message.Content.EmailBody, "- Order shipped: Your order is on its way. ...")
+
+	// The next digest is not due for a day and only lists new unread items
+	fakeClock.Advance(time.Hour)
+	sendInApp(t, nm, "Payment due", "Your invoice is due tomorrow.", recipients[0])
+	unread := nm.inbox.List(recipients[0], true, false)
+	require.Len(t, unread, 2)
+	assert.Zero(t, nm.RunInboxDigests())
+
+	// 22:30 in Berlin is within the quiet hours
+	fakeClock.Set(time.Date(2024, 1, 2, 21, 30, 0, 0, time.UTC))
+	assert.Zero(t, nm.RunInboxDigests())
+
+	fakeClock.Set(time.Date(2024, 1, 3, 6, 30, 0, 0, time.UTC))
+	assert.Equal(t, 1, nm.RunInboxDigests())
+	require.NoError(t, json.Unmarshal([]byte(<-emailChannel), &message))
+	assert.Equal(t, "Your daily digest: 1 unread notifications", message.Content.Subject)
+	assert.Contains(t, message.Content.EmailBody, "- Payment due: Your invoice is due tomorrow.")
+	assert.False(t, strings.Contains(message.Content.EmailBody, "Order shipped"))
+}
+
+func TestRunInboxDigests_SkipsReadItems(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 1, DefaultConfig())
+	nm.SetClock(clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)))
+
+	sendInApp(t, nm, "Welcome", "Thanks for signing up.", recipients[0])
+	_, err := nm.UpdatePreferences(recipients[0], &models.NotificationPreferences{Digest: models.DigestWeekly})
+	require.NoError(t, err)
+
+	items := nm.inbox.List(recipients[0], false, false)
+	require.Len(t, items, 1)
+	_, err = nm.MarkInboxItemRead(recipients[0], items[0].ID)
+	require.NoError(t, err)
+
+	assert.Zero(t, nm.RunInboxDigests())
+	assert.Empty(t, kafkaService.GetEmailChannel())
+
+	_, err = nm.MarkInboxItemRead(recipients[0], "missing")
+	assert.ErrorIs(t, err, ErrInboxItemNotFound)
+}
+
+func TestRunInboxDigests_SkipsTransactionalItems(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 1, DefaultConfig())
+	nm.SetClock(clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)))
+
+	_, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:          "in_app",
+		Transactional: true,
+		Content:       map[string]interface{}{"title": "Sign-in code", "body": "Your code is 123456."},
+		Recipients:    recipients,
+	})
+	require.NoError(t, err)
+	_, err = nm.UpdatePreferences(recipients[0], &models.NotificationPreferences{Digest: models.DigestDaily})
+	require.NoError(t, err)
+
+	assert.Len(t, nm.inbox.List(recipients[0], true, false), 1)
+	assert.Zero(t, nm.RunInboxDigests())
+	assert.Empty(t, kafkaService.GetEmailChannel())
+}
+
+func TestStartInboxDigests(t *testing.T) {
+	config := DefaultConfig()
+	config.DigestCheckInterval = time.Hour
+	nm, kafkaService, recipients := newTestManager(t, 1, config)
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	nm.SetClock(fakeClock)
+
+	sendInApp(t, nm, "Welcome", "Thanks for signing up.", recipients[0])
+	_, err := nm.UpdatePreferences(recipients[0], &models.NotificationPreferences{Digest: models.DigestDaily})
+	require.NoError(t, err)
+
+	nm.StartInboxDigests()
+	defer nm.StopInboxDigests()
+
+	fakeClock.Advance(time.Hour)
+	assert.Len(t, kafkaService.GetEmailChannel(), 1)
+	assert.Equal(t, 1, fakeClock.PendingTimers(), "the job reschedules itself")
+
+	// Once stopped, the job sends nothing more
+	nm.StopInboxDigests()
+	assert.Zero(t, fakeClock.PendingTimers())
+	fakeClock.Advance(48 * time.Hour)
+	assert.Len(t, kafkaService.GetEmailChannel(), 1)
+}
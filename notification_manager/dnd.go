@@ -0,0 +1,123 @@
+package notification_manager
+
+import (
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Do-not-disturb policies for non-urgent notifications
+const (
+	DNDPolicyDefer = "defer"
+	DNDPolicyDrop  = "drop"
+)
+
+// isValidDNDPolicy checks if policy is a supported do-not-disturb policy
+func isValidDNDPolicy(policy string) bool {
+	return policy == DNDPolicyDefer || policy == DNDPolicyDrop
+}
+
+// dndOutcome is what do-not-disturb did with a notification for one recipient
+type dndOutcome int
+
+const (
+	dndNone dndOutcome = iota
+	dndDeferred
+	dndDropped
+)
+
+// notificationDNDTotal counts recipients held back by do-not-disturb
+var notificationDNDTotal = metrics.DefaultRegistry.NewCounterVec(
+	"notification_dnd_total",
+	"Recipients held back because they are in do-not-disturb, by outcome (deferred or dropped) and notification type.",
+	"outcome", "type",
+)
+
+// isUrgent reports whether a notification is delivered during do-not-disturb. Transactional
+// requests and non-suppressible categories are urgent.
+func (nm *NotificationManagerImpl) isUrgent(request *models.NotificationRequest) bool {
+	return request.Transactional || !nm.isSuppressible(request.Category)
+}
+
+// applyDoNotDisturb holds back a non-urgent notification for a recipient in do-not-disturb.
+// With the defer policy it is sent to the recipient when do-not-disturb ends; it is dropped
+// with the drop policy, and when do-not-disturb has no end time or deferring fails.
+func (nm *NotificationManagerImpl) applyDoNotDisturb(notificationID string, request *models.NotificationRequest, userInfo *models.UserNotificationInfo) dndOutcome {
+	dnd := userInfo.DoNotDisturb
+	if !dnd.ActiveAt(nm.clock.Now()) || nm.isUrgent(request) {
+		return dndNone
+	}
+
+	if nm.config.DNDPolicy == DNDPolicyDefer && dnd.Until != nil {
+		jobID := notificationID + "/dnd/" + userInfo.ID
+		userID := userInfo.ID
+		err := nm.scheduler.ScheduleJob(jobID, *dnd.Until, func() {
+			nm.sendDeferred(notificationID, request, userID)
+		})
+		if err == nil {
+			notificationDNDTotal.Inc("deferred", request.Type)
+			return dndDeferred
+		}
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"notification_id": notificationID,
+			"user_id":         userID,
+		}).Warn("Failed to defer notification until do-not-disturb ends, dropping it")
+	}
+
+	notificationDNDTotal.Inc("dropped", request.Type)
+	return dndDropped
+}
+
+// sendDeferred sends a notification deferred by do-not-disturb to its recipient. The recipient
+// is resolved again, so a do-not-disturb that was extended in the meantime defers it again.
+func (nm *NotificationManagerImpl) sendDeferred(notificationID string, request *models.NotificationRequest, userID string) {
+	progress := NotificationProgress{Deferred: -1}
+	defer func() {
+		if err := nm.storage.AddProgress(notificationID, progress); err != nil {
+			logrus.WithError(err).WithField("notification_id", notificationID).Warn("Failed to record notification progress")
+		}
+		nm.recordQueuedMetric(request, progress.Queued)
+	}()
+
+	fields := logger.Fields{
+		"notification_id": notificationID,
+		"user_id":         userID,
+	}
+	userInfo, err := nm.userService.GetUserNotificationInfo(userID)
+	if err != nil {
+		fields["error"] = err.Error()
+		moduleLog.Warn("Recipient of deferred notification is no longer available", fields)
+		progress.Failed++
+		return
+	}
+
+	switch nm.applyDoNotDisturb(notificationID, request, userInfo) {
+	case dndDeferred:
+		progress.Deferred++
+		return
+	case dndDropped:
+		progress.Suppressed++
+		return
+	}
+	if nm.holdIfUnreachable(notificationID, request, userInfo) {
+		progress.Unreachable++
+		return
+	}
+
+	recipientRequest, err := nm.personalizeRequest(nm.routeRequest(request, userInfo), userInfo)
+	if err != nil {
+		fields["error"] = err.Error()
+		moduleLog.Error("Failed to render template for user", fields)
+		progress.Failed++
+		return
+	}
+
+	count, err := nm.processNotificationByType(notificationID, recipientRequest, userInfo, nm.config.EnqueueTimeout)
+	progress.Queued += count
+	if err != nil {
+		fields["error"] = err.Error()
+		moduleLog.Error("Failed to process deferred notification for user", fields)
+		progress.Failed++
+	}
+}
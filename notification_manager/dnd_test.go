@@ -0,0 +1,83 @@
+package notification_manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessNotificationRequest_DoNotDisturb(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 3, DefaultConfig())
+	fakeClock := clock.NewFake(time.Now())
+	nm.SetClock(fakeClock)
+
+	until := fakeClock.Now().Add(time.Hour)
+	_, err := nm.userService.SetDoNotDisturb(recipients[0], &until)
+	require.NoError(t, err)
+	_, err = nm.userService.SetDoNotDisturb(recipients[1], nil)
+	require.NoError(t, err)
+
+	result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "email",
+		Category:   models.CategoryMarketing,
+		Content:    map[string]interface{}{"subject": "Sale", "email_body": "Body"},
+		Recipients: recipients,
+	})
+	require.NoError(t, err)
+	notificationID := result.(map[string]interface{})["id"].(string)
+
+	progress, err := nm.storage.GetProgress(notificationID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, progress.Queued)
+	assert.Equal(t, 1, progress.Deferred, "deferred until do-not-disturb ends")
+	assert.Equal(t, 1, progress.Suppressed, "do-not-disturb without an end drops the notification")
+	assert.Len(t, kafkaService.GetEmailChannel(), 1)
+
+	// Deferred notifications are sent when do-not-disturb was scheduled to end
+	require.NoError(t, nm.userService.ClearDoNotDisturb(recipients[0]))
+	fakeClock.Advance(time.Hour)
+
+	progress, err = nm.storage.GetProgress(notificationID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, progress.Queued)
+	assert.Equal(t, 0, progress.Deferred)
+	assert.Len(t, kafkaService.GetEmailChannel(), 2)
+
+	// Urgent notifications are delivered during do-not-disturb
+	_, err = nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:          "email",
+		Transactional: true,
+		Content:       map[string]interface{}{"subject": "Receipt", "email_body": "Body"},
+		Recipients:    recipients[1:2],
+	})
+	require.NoError(t, err)
+	assert.Len(t, kafkaService.GetEmailChannel(), 3)
+}
+
+func TestProcessNotificationRequest_DoNotDisturbDropPolicy(t *testing.T) {
+	config := DefaultConfig()
+	config.DNDPolicy = DNDPolicyDrop
+	nm, kafkaService, recipients := newTestManager(t, 1, config)
+
+	until := time.Now().Add(time.Hour)
+	_, err := nm.userService.SetDoNotDisturb(recipients[0], &until)
+	require.NoError(t, err)
+
+	result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "email",
+		Category:   models.CategoryMarketing,
+		Content:    map[string]interface{}{"subject": "Sale", "email_body": "Body"},
+		Recipients: recipients,
+	})
+	require.NoError(t, err)
+
+	progress, err := nm.storage.GetProgress(result.(map[string]interface{})["id"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, 1, progress.Suppressed)
+	assert.Equal(t, 0, progress.Deferred)
+	assert.Len(t, kafkaService.GetEmailChannel(), 0)
+}
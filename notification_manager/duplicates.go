@@ -0,0 +1,174 @@
+package notification_manager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Policies for notifications identical to one sent within the duplicate window
+const (
+	DuplicatePolicyWarn  = "warn"
+	DuplicatePolicyBlock = "block"
+)
+
+// isValidDuplicatePolicy checks if policy is a supported duplicate policy
+func isValidDuplicatePolicy(policy string) bool {
+	return policy == DuplicatePolicyWarn || policy == DuplicatePolicyBlock
+}
+
+// notificationDuplicatesTotal counts requests identical to a recent notification
+var notificationDuplicatesTotal = metrics.DefaultRegistry.NewCounterVec(
+	"notification_duplicates_total",
+	"Notification requests identical to one sent within the duplicate window, by action (warned or blocked).",
+	"action",
+)
+
+// sentFingerprint is a notification remembered by its fingerprint. The ID is empty while
+// the notification is still being accepted.
+type sentFingerprint struct {
+	notificationID string
+	at             time.Time
+}
+
+// fingerprintTracker remembers the fingerprints of recent notifications
+type fingerprintTracker struct {
+	mu         sync.Mutex
+	sent       map[string]*sentFingerprint
+	lastPruned time.Time
+}
+
+func newFingerprintTracker() *fingerprintTracker {
+	return &fingerprintTracker{
+		sent: make(map[string]*sentFingerprint),
+	}
+}
+
+// Claim records a fingerprint unless one was recorded within window, in which case it returns
+// the earlier notification and false
+func (t *fingerprintTracker) Claim(fingerprint string, now time.Time, window time.Duration) (sentFingerprint, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Expired fingerprints are dropped at most once per window
+	if now.Sub(t.lastPruned) >= window {
+		for key, sent := range t.sent {
+			if now.Sub(sent.at) >= window {
+				delete(t.sent, key)
+			}
+		}
+		t.lastPruned = now
+	}
+
+	if earlier, exists := t.sent[fingerprint]; exists && now.Sub(earlier.at) < window {
+		return *earlier, false
+	}
+	t.sent[fingerprint] = &sentFingerprint{at: now}
+	return sentFingerprint{}, true
+}
+
+// Complete records the ID of the notification a claimed fingerprint belongs to
+func (t *fingerprintTracker) Complete(fingerprint string, notificationID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if sent, exists := t.sent[fingerprint]; exists {
+		sent.notificationID = notificationID
+	}
+}
+
+// Release forgets a claimed fingerprint whose notification was not accepted
+func (t *fingerprintTracker) Release(fingerprint string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.sent, fingerprint)
+}
+
+// notificationFingerprint identifies the content and audience of a request. Tags and the
+// external ID are left out, so a batch job triggered twice is caught even if it labels its
+// runs differently.
+func notificationFingerprint(request *models.NotificationRequest) string {
+	recipients := make([]string, 0, len(request.Recipients))
+	seen := make(map[string]bool, len(request.Recipients))
+	for _, recipient := range request.Recipients {
+		if !seen[recipient] {
+			seen[recipient] = true
+			recipients = append(recipients, recipient)
+		}
+	}
+	sort.Strings(recipients)
+
+	var scheduledAt string
+	if request.ScheduledAt != nil {
+		scheduledAt = request.ScheduledAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	// Maps are encoded with sorted keys, so equal requests always encode the same
+	encoded, _ := json.Marshal(struct {
+		Tenant           string
+		Type             string
+		Category         string
+		DedupeKey        string
+		Content          map[string]interface{}
+		Template         *models.TemplateData
+		ChannelContent   map[string]map[string]interface{}
+		FallbackChannels []string
+		From             interface{}
+		ScheduledAt      string
+		Recipients       []string
+	}{request.Tenant, request.Type, request.Category, request.DedupeKey, request.Content, request.Template, request.ChannelContent,
+		request.FallbackChannels, request.From, scheduledAt, recipients})
+
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+// checkDuplicate looks for a notification identical to the request sent within the duplicate
+// window. With the block policy such a request is rejected; with the warn policy it is sent
+// and the earlier notification is returned. The returned function completes the check once
+// the request was accepted, or releases its fingerprint when notificationID is empty.
+func (nm *NotificationManagerImpl) checkDuplicate(request *models.NotificationRequest) (string, func(notificationID string), error) {
+	window := nm.config.DuplicateWindow
+	if window <= 0 || request.AllowDuplicate {
+		return "", func(string) {}, nil
+	}
+
+	fingerprint := notificationFingerprint(request)
+	earlier, claimed := nm.fingerprints.Claim(fingerprint, nm.clock.Now(), window)
+	if claimed {
+		return "", func(notificationID string) {
+			if notificationID == "" {
+				nm.fingerprints.Release(fingerprint)
+				return
+			}
+			nm.fingerprints.Complete(fingerprint, notificationID)
+		}, nil
+	}
+
+	fields := logrus.Fields{
+		"duplicate_of": earlier.notificationID,
+		"sent_at":      earlier.at,
+		"type":         request.Type,
+		"recipients":   len(request.Recipients),
+	}
+	if nm.config.DuplicatePolicy == DuplicatePolicyBlock {
+		notificationDuplicatesTotal.Inc("blocked")
+		logrus.WithFields(fields).Warn("Notification rejected, identical to a recent notification")
+		if earlier.notificationID == "" {
+			return "", nil, fmt.Errorf("%w: an identical notification is being sent", ErrDuplicateNotification)
+		}
+		return "", nil, fmt.Errorf("%w: identical to notification %s sent at %s", ErrDuplicateNotification,
+			earlier.notificationID, earlier.at.UTC().Format(time.RFC3339))
+	}
+
+	notificationDuplicatesTotal.Inc("warned")
+	logrus.WithFields(fields).Warn("Notification is identical to a recent notification")
+	return earlier.notificationID, func(string) {}, nil
+}
@@ -0,0 +1,85 @@
+package notification_manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func duplicateTestRequest(recipients []string) *models.NotificationRequest {
+	return &models.NotificationRequest{
+		Type:       "email",
+		Content:    map[string]interface{}{"subject": "Sale", "email_body": "Body"},
+		Recipients: recipients,
+		Tags:       []string{"q3-campaign"},
+	}
+}
+
+func TestProcessNotificationRequest_DuplicateWarn(t *testing.T) {
+	config := DefaultConfig()
+	config.DuplicateWindow = time.Hour
+	nm, _, recipients := newTestManager(t, 2, config)
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	nm.SetClock(fakeClock)
+
+	first, err := nm.ProcessNotificationRequest(duplicateTestRequest(recipients))
+	require.NoError(t, err)
+	assert.NotContains(t, first.(map[string]interface{}), "duplicate_of")
+
+	// The recipient order and tags do not matter
+	request := duplicateTestRequest([]string{recipients[1], recipients[0]})
+	request.Tags = []string{"rerun"}
+	second, err := nm.ProcessNotificationRequest(request)
+	require.NoError(t, err)
+	assert.Equal(t, first.(map[string]interface{})["id"], second.(map[string]interface{})["duplicate_of"])
+
+	// Different content or recipients are not duplicates
+	other := duplicateTestRequest(recipients[:1])
+	third, err := nm.ProcessNotificationRequest(other)
+	require.NoError(t, err)
+	assert.NotContains(t, third.(map[string]interface{}), "duplicate_of")
+
+	fakeClock.Advance(time.Hour)
+	fourth, err := nm.ProcessNotificationRequest(duplicateTestRequest(recipients))
+	require.NoError(t, err)
+	assert.NotContains(t, fourth.(map[string]interface{}), "duplicate_of", "the window has passed")
+}
+
+func TestProcessNotificationRequest_DuplicateBlock(t *testing.T) {
+	config := DefaultConfig()
+	config.DuplicateWindow = time.Hour
+	config.DuplicatePolicy = DuplicatePolicyBlock
+	nm, kafkaService, recipients := newTestManager(t, 1, config)
+
+	first, err := nm.ProcessNotificationRequest(duplicateTestRequest(recipients))
+	require.NoError(t, err)
+
+	_, err = nm.ProcessNotificationRequest(duplicateTestRequest(recipients))
+	assert.ErrorIs(t, err, ErrDuplicateNotification)
+	assert.Contains(t, err.Error(), first.(map[string]interface{})["id"].(string))
+	assert.Len(t, kafkaService.GetEmailChannel(), 1)
+
+	request := duplicateTestRequest(recipients)
+	request.AllowDuplicate = true
+	_, err = nm.ProcessNotificationRequest(request)
+	require.NoError(t, err)
+	assert.Len(t, kafkaService.GetEmailChannel(), 2)
+}
+
+func TestProcessNotificationRequest_DuplicateOfFailedRequest(t *testing.T) {
+	config := DefaultConfig()
+	config.DuplicateWindow = time.Hour
+	config.DuplicatePolicy = DuplicatePolicyBlock
+	nm, _, _ := newTestManager(t, 0, config)
+
+	// Requests that were not accepted can be retried
+	for i := 0; i < 2; i++ {
+		_, err := nm.ProcessNotificationRequest(duplicateTestRequest([]string{"unknown-user"}))
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrDuplicateNotification)
+	}
+}
@@ -0,0 +1,199 @@
+package notification_manager
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/sirupsen/logrus"
+)
+
+// notificationEngagementEventsTotal counts the first impression and open of each recipient
+var notificationEngagementEventsTotal = metrics.DefaultRegistry.NewCounterVec(
+	"notification_engagement_events_total",
+	"Recipients that saw or opened a notification, by event and the channel it was reported from.",
+	"event", "channel",
+)
+
+// maxEngagementEvents bounds the events listed for a notification; later events still count
+// towards the engagement numbers
+const maxEngagementEvents = 1000
+
+// recipientEngagement is when a recipient first saw and first opened a notification, and the
+// events and channels already listed for them
+type recipientEngagement struct {
+	DisplayedAt time.Time
+	OpenedAt    time.Time
+	listed      map[string]bool
+}
+
+// engagementStore keeps the engagement events of every notification in memory
+type engagementStore struct {
+	mu         sync.RWMutex
+	events     map[string][]models.EngagementEvent
+	recipients map[string]map[string]*recipientEngagement
+}
+
+// newEngagementStore creates an empty engagement store
+func newEngagementStore() *engagementStore {
+	return &engagementStore{
+		events:     make(map[string][]models.EngagementEvent),
+		recipients: make(map[string]map[string]*recipientEngagement),
+	}
+}
+
+// Record stores an engagement event. It reports whether the event was the first of its kind
+// for the recipient, so repeated impressions are not counted twice. Only the first event of a
+// recipient per event type and channel is listed, and at most maxEngagementEvents per notification.
+func (s *engagementStore) Record(event models.EngagementEvent) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byRecipient, exists := s.recipients[event.NotificationID]
+	if !exists {
+		byRecipient = make(map[string]*recipientEngagement)
+		s.recipients[event.NotificationID] = byRecipient
+	}
+	engagement, exists := byRecipient[event.Recipient]
+	if !exists {
+		engagement = &recipientEngagement{listed: make(map[string]bool)}
+		byRecipient[event.Recipient] = engagement
+	}
+
+	key := event.Event + "|" + event.Channel
+	if !engagement.listed[key] && len(s.events[event.NotificationID]) < maxEngagementEvents {
+		engagement.listed[key] = true
+		s.events[event.NotificationID] = append(s.events[event.NotificationID], event)
+	}
+
+	first := false
+	if engagement.DisplayedAt.IsZero() {
+		engagement.DisplayedAt = event.OccurredAt
+		first = event.Event == models.EngagementDisplayed
+	}
+	if event.Event == models.EngagementOpened && engagement.OpenedAt.IsZero() {
+		engagement.OpenedAt = event.OccurredAt
+		first = true
+	}
+	return first
+}
+
+// Stats returns the number of distinct recipients that saw and opened a notification
+func (s *engagementStore) Stats(notificationID string) models.EngagementStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var stats models.EngagementStats
+	for _, engagement := range s.recipients[notificationID] {
+		stats.Displayed++
+		if !engagement.OpenedAt.IsZero() {
+			stats.Opened++
+		}
+	}
+	return stats
+}
+
+// Events returns a copy of the engagement events of a notification in the order they were received
+func (s *engagementStore) Events(notificationID string) []models.EngagementEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.EngagementEvent(nil), s.events[notificationID]...)
+}
+
+// computeOpenRate sets the share of recipients who saw a notification and opened it
+func computeOpenRate(stats *models.EngagementStats) {
+	if stats.Displayed > 0 {
+		stats.OpenRate = float64(stats.Opened) / float64(stats.Displayed)
+	}
+}
+
+// engagementChannel returns the channel an engagement event of a notification of the given type
+// is recorded for. In-app notifications are reported from the inbox or the push sent for them;
+// push notifications, which go to every device of a user, from either push channel.
+func engagementChannel(notificationType, channel string) (string, bool) {
+	switch notificationType {
+	case string(models.InAppNotification):
+		if channel == "" {
+			return models.EngagementChannelInApp, true
+		}
+		return channel, true
+	case models.EngagementChannelIOSPush, models.EngagementChannelAndroidPush:
+		if channel == "" {
+			return notificationType, true
+		}
+		return channel, channel != models.EngagementChannelInApp
+	default:
+		return "", false
+	}
+}
+
+// RecordEngagementEvent records that a recipient saw or opened an in-app notification or a push.
+// Opening an in-app notification also marks it as read in the recipient's inbox.
+func (nm *NotificationManagerImpl) RecordEngagementEvent(notificationID string, request *models.EngagementEventRequest) (interface{}, error) {
+	record, err := nm.storage.GetNotification(notificationID)
+	if err != nil {
+		return nil, ErrNotificationNotFound
+	}
+	channel, ok := engagementChannel(record.Type, request.Channel)
+	if !ok {
+		return nil, ErrEngagementNotSupported
+	}
+	if !record.hasRecipient(request.Recipient) {
+		return nil, ErrRecipientNotFound
+	}
+
+	now := nm.clock.Now()
+	event := models.EngagementEvent{
+		NotificationID: notificationID,
+		Recipient:      request.Recipient,
+		Event:          request.Event,
+		Channel:        channel,
+		OccurredAt:     now,
+		ReceivedAt:     now,
+	}
+	// Clients may report events late, e.g. after being offline, but not from the future
+	if request.OccurredAt != nil && request.OccurredAt.Before(now) {
+		event.OccurredAt = *request.OccurredAt
+	}
+
+	if nm.engagement.Record(event) {
+		notificationEngagementEventsTotal.Inc(event.Event, event.Channel)
+	}
+	if event.Event == models.EngagementOpened && record.Type == string(models.InAppNotification) {
+		nm.inbox.MarkNotificationRead(event.Recipient, notificationID, event.OccurredAt)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"notification_id": notificationID,
+		"recipient":       event.Recipient,
+		"event":           event.Event,
+		"channel":         event.Channel,
+	}).Debug("Engagement event recorded")
+
+	return &event, nil
+}
+
+// GetEngagementEvents returns the engagement events reported for a notification and the
+// number of distinct recipients that saw and opened it
+func (nm *NotificationManagerImpl) GetEngagementEvents(notificationID string) (interface{}, error) {
+	if _, err := nm.storage.GetNotification(notificationID); err != nil {
+		return nil, ErrNotificationNotFound
+	}
+
+	stats := nm.engagement.Stats(notificationID)
+	computeOpenRate(&stats)
+	events := nm.engagement.Events(notificationID)
+
+	return &struct {
+		NotificationID string                   `json:"notification_id"`
+		Engagement     models.EngagementStats   `json:"engagement"`
+		Events         []models.EngagementEvent `json:"events"`
+		Count          int                      `json:"count"`
+	}{
+		NotificationID: notificationID,
+		Engagement:     stats,
+		Events:         events,
+		Count:          len(events),
+	}, nil
+}
@@ -0,0 +1,108 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordEngagementEvent(t *testing.T) {
+	nm, _, recipients := newTestManager(t, 3, DefaultConfig())
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	nm.SetClock(clock.NewFake(start))
+
+	result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "in_app",
+		Content:    map[string]interface{}{"title": "Order shipped", "body": "Your order is on its way."},
+		Recipients: recipients,
+	})
+	require.NoError(t, err)
+	notificationID := result.(map[string]interface{})["id"].(string)
+
+	report := func(recipient, event string) {
+		t.Helper()
+		_, err := nm.RecordEngagementEvent(notificationID, &models.EngagementEventRequest{Event: event, Recipient: recipient, Channel: "ios_push"})
+		require.NoError(t, err)
+	}
+	report(recipients[0], models.EngagementDisplayed)
+	report(recipients[0], models.EngagementDisplayed)
+	report(recipients[0], models.EngagementOpened)
+	report(recipients[1], models.EngagementDisplayed)
+
+	// Repeated impressions count once per recipient
+	status, err := nm.GetNotificationStatus(notificationID)
+	require.NoError(t, err)
+	var statusResponse struct {
+		Engagement *models.EngagementStats `json:"engagement"`
+	}
+	encoded, err := json.Marshal(status)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(encoded, &statusResponse))
+	require.NotNil(t, statusResponse.Engagement)
+	assert.Equal(t, models.EngagementStats{Displayed: 2, Opened: 1, OpenRate: 0.5}, *statusResponse.Engagement)
+
+	events, err := nm.GetEngagementEvents(notificationID)
+	require.NoError(t, err)
+	var eventsResponse struct {
+		Events []models.EngagementEvent `json:"events"`
+	}
+	encoded, err = json.Marshal(events)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(encoded, &eventsResponse))
+	// The repeated impression is listed once
+	require.Len(t, eventsResponse.Events, 3)
+	assert.Equal(t, "ios_push", eventsResponse.Events[0].Channel)
+	assert.Equal(t, start, eventsResponse.Events[0].OccurredAt)
+
+	// Opening marks the inbox item as read
+	assert.Empty(t, nm.inbox.List(recipients[0], true, false))
+	assert.Len(t, nm.inbox.List(recipients[1], true, false), 1)
+
+	_, err = nm.RecordEngagementEvent(notificationID, &models.EngagementEventRequest{Event: models.EngagementOpened, Recipient: "someone-else"})
+	assert.ErrorIs(t, err, ErrRecipientNotFound)
+}
+
+func TestRecordEngagementEvent_UnsupportedType(t *testing.T) {
+	nm, _, recipients := newTestManager(t, 1, DefaultConfig())
+
+	result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "email",
+		Content:    map[string]interface{}{"subject": "Hello", "email_body": "Body"},
+		Recipients: recipients,
+	})
+	require.NoError(t, err)
+	notificationID := result.(map[string]interface{})["id"].(string)
+
+	_, err = nm.RecordEngagementEvent(notificationID, &models.EngagementEventRequest{Event: models.EngagementOpened, Recipient: recipients[0]})
+	assert.ErrorIs(t, err, ErrEngagementNotSupported)
+
+	_, err = nm.RecordEngagementEvent("missing", &models.EngagementEventRequest{Event: models.EngagementOpened, Recipient: recipients[0]})
+	assert.ErrorIs(t, err, ErrNotificationNotFound)
+}
+
+func TestRecordEngagementEvent_Push(t *testing.T) {
+	nm, _, recipients := newTestManager(t, 1, DefaultConfig())
+
+	result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "ios_push",
+		Content:    map[string]interface{}{"title": "Order shipped", "body": "Your order is on its way."},
+		Recipients: recipients,
+	})
+	require.NoError(t, err)
+	notificationID := result.(map[string]interface{})["id"].(string)
+
+	event, err := nm.RecordEngagementEvent(notificationID, &models.EngagementEventRequest{Event: models.EngagementOpened, Recipient: recipients[0]})
+	require.NoError(t, err)
+	assert.Equal(t, "ios_push", event.(*models.EngagementEvent).Channel)
+
+	_, err = nm.RecordEngagementEvent(notificationID, &models.EngagementEventRequest{Event: models.EngagementDisplayed, Recipient: recipients[0], Channel: "android_push"})
+	require.NoError(t, err)
+
+	_, err = nm.RecordEngagementEvent(notificationID, &models.EngagementEventRequest{Event: models.EngagementOpened, Recipient: recipients[0], Channel: "in_app"})
+	assert.ErrorIs(t, err, ErrEngagementNotSupported)
+}
@@ -11,4 +11,40 @@ var (
 	ErrInvalidTemplateContent      = errors.New("invalid template content")
 	ErrInvalidTemplateType         = errors.New("invalid template type")
 	ErrMissingRequiredVariable     = errors.New("missing required variable")
+	ErrNotificationNotFound        = errors.New("notification not found")
+	ErrContentLimitExceeded        = errors.New("content exceeds channel limits")
+	ErrUserNotFound                = errors.New("user not found")
+	ErrInboxItemNotFound           = errors.New("inbox item not found")
+	ErrInboxActionNotFound         = errors.New("inbox item has no such action")
+	ErrRecipientNotFound           = errors.New("recipient is not a recipient of the notification")
+	ErrEngagementNotSupported      = errors.New("engagement events are only recorded for in_app and push notifications, and push notifications are not reported from in_app")
+	ErrBudgetExceeded              = errors.New("monthly budget exceeded")
+	ErrContactChannelUnavailable   = errors.New("phone numbers cannot be verified, no SMS channel is available")
+	ErrDuplicateNotification       = errors.New("duplicate notification")
+	ErrRequestAborted              = errors.New("notification request aborted")
+	ErrSenderNotAllowed            = errors.New("from address not allowed")
+	ErrNotificationNotCancellable  = errors.New("notification cannot be cancelled")
+	ErrCategoryNotAllowed          = errors.New("this API key may not change the category of the template")
+	ErrTransactionalNotAllowed     = errors.New("this API key is not allowed to send transactional notifications")
+)
+
+// Media asset errors
+var (
+	ErrMediaStoreNotConfigured = errors.New("no media store is configured")
+	ErrInvalidMedia            = errors.New("invalid media asset")
+	ErrMediaTooLarge           = errors.New("media asset too large")
+	ErrMediaNotFound           = errors.New("media asset not found")
+	ErrMediaInUse              = errors.New("media asset is referenced by a template")
+)
+
+// Template directory and repository errors
+var (
+	ErrTemplateDirectoryNotConfigured  = errors.New("no template directory is configured")
+	ErrTemplateRepositoryNotConfigured = errors.New("no template repository is configured")
+)
+
+// Queue replay errors
+var (
+	ErrQueueArchiveDisabled = errors.New("the queue archive is disabled")
+	ErrInvalidQueueReplay   = errors.New("invalid queue replay")
 )
@@ -0,0 +1,31 @@
+package notification_manager
+
+import (
+	"github.com/gaurav2721/notification-service/external_services/events"
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// SetEventPublisher publishes every status change of a notification through the publisher;
+// nil stops publishing
+func (nm *NotificationManagerImpl) SetEventPublisher(publisher *events.Publisher) {
+	if publisher == nil {
+		nm.storage.OnStatusChange(nil)
+		return
+	}
+
+	nm.storage.OnStatusChange(func(record *NotificationRecord, from NotificationStatus) {
+		publisher.Publish(models.NotificationEvent{
+			EventType:        models.EventNotificationStatusChanged,
+			OccurredAt:       record.UpdatedAt,
+			NotificationID:   record.ID,
+			NotificationType: record.Type,
+			ExternalID:       record.ExternalID,
+			Category:         record.Category,
+			Tags:             record.Tags,
+			Tenant:           record.Tenant,
+			FromStatus:       string(from),
+			Status:           string(record.Status),
+			Error:            record.Error,
+		})
+	})
+}
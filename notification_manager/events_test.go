@@ -0,0 +1,66 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/external_services/events"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetEventPublisher_PublishesStatusChanges(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 1, DefaultConfig())
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	nm.SetClock(fakeClock)
+	eventsChannel := kafkaService.GetNotificationEventsChannel()
+	nm.SetEventPublisher(events.NewPublisher(eventsChannel))
+
+	result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "email",
+		Content:    map[string]interface{}{"subject": "Receipt", "email_body": "Body"},
+		Recipients: recipients,
+		ExternalID: "order-42",
+		Tags:       []string{"billing"},
+	})
+	require.NoError(t, err)
+	notificationID := result.(map[string]interface{})["id"].(string)
+
+	var published []models.NotificationEvent
+	for len(eventsChannel) > 0 {
+		var event models.NotificationEvent
+		require.NoError(t, json.Unmarshal([]byte(<-eventsChannel), &event))
+		published = append(published, event)
+	}
+
+	require.NotEmpty(t, published)
+	first, last := published[0], published[len(published)-1]
+	assert.Equal(t, "", first.FromStatus)
+	assert.Equal(t, "pending", first.Status)
+	assert.Equal(t, "sent", last.Status)
+	for i, event := range published {
+		assert.Equal(t, models.EventNotificationStatusChanged, event.EventType)
+		assert.Equal(t, notificationID, event.NotificationID)
+		assert.Equal(t, "email", event.NotificationType)
+		assert.Equal(t, "order-42", event.ExternalID)
+		assert.Equal(t, []string{"billing"}, event.Tags)
+		assert.Equal(t, fakeClock.Now(), event.OccurredAt)
+		assert.NotEqual(t, event.FromStatus, event.Status)
+		if i > 0 {
+			assert.Equal(t, published[i-1].Status, event.FromStatus, "transitions are published in order")
+		}
+	}
+
+	// Without a publisher nothing is published
+	nm.SetEventPublisher(nil)
+	_, err = nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "email",
+		Content:    map[string]interface{}{"subject": "Receipt", "email_body": "Body"},
+		Recipients: recipients,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, eventsChannel)
+}
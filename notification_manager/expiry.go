@@ -0,0 +1,127 @@
+package notification_manager
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/sirupsen/logrus"
+)
+
+// notificationsExpiredTotal counts scheduled notifications that expired instead of being sent
+var notificationsExpiredTotal = metrics.DefaultRegistry.NewCounterVec(
+	"notifications_expired_total",
+	"Scheduled notifications expired because their scheduled time passed more than the catch-up grace period ago, by notification type.",
+	"type",
+)
+
+// expirySweeper holds the timer of the expiry sweeper while it runs
+type expirySweeper struct {
+	mu    sync.Mutex
+	timer clock.Timer
+}
+
+// isStale reports whether a notification scheduled at scheduledAt is past the catch-up grace period
+func (nm *NotificationManagerImpl) isStale(scheduledAt time.Time, now time.Time) bool {
+	grace := nm.config.ScheduleCatchUpGrace
+	return grace > 0 && now.Sub(scheduledAt) > grace
+}
+
+// expire moves a scheduled notification to expired and removes its job from the scheduler.
+// It returns false when the notification was no longer scheduled.
+func (nm *NotificationManagerImpl) expire(notificationID string, notificationType string, scheduledAt time.Time, now time.Time) bool {
+	reason := fmt.Sprintf("scheduled time %s passed %s ago, beyond the catch-up grace period of %s",
+		scheduledAt.UTC().Format(time.RFC3339), now.Sub(scheduledAt).Truncate(time.Second), nm.config.ScheduleCatchUpGrace)
+	expired, err := nm.storage.ExpireScheduled(notificationID, reason)
+	if err != nil || !expired {
+		return false
+	}
+
+	if err := nm.scheduler.CancelJob(notificationID); err != nil {
+		logrus.WithError(err).WithField("notification_id", notificationID).Warn("Failed to remove expired notification from the scheduler")
+	}
+	notificationsExpiredTotal.Inc(notificationType)
+	logrus.WithFields(logrus.Fields{
+		"notification_id": notificationID,
+		"scheduled_at":    scheduledAt,
+		"reason":          reason,
+	}).Warn("Scheduled notification expired")
+	return true
+}
+
+// expireIfStale expires a scheduled notification whose job runs past the catch-up grace period
+// and reports whether it must not be sent, also when the sweeper expired it first
+func (nm *NotificationManagerImpl) expireIfStale(notificationID string, request *models.NotificationRequest) bool {
+	if request.ScheduledAt == nil {
+		return false
+	}
+
+	now := nm.clock.Now()
+	if nm.isStale(*request.ScheduledAt, now) {
+		nm.expire(notificationID, request.Type, *request.ScheduledAt, now)
+		return true
+	}
+	record, err := nm.storage.GetNotification(notificationID)
+	return err == nil && record.Status == StatusExpired
+}
+
+// RunExpirySweep expires the scheduled notifications past the catch-up grace period and returns
+// the number expired. These are notifications whose jobs did not run in time, such as when
+// the service was paused.
+func (nm *NotificationManagerImpl) RunExpirySweep() int {
+	now := nm.clock.Now()
+	expired := 0
+	for _, record := range nm.storage.GetNotificationsByStatus(StatusScheduled) {
+		if record.ScheduledAt == nil || !nm.isStale(*record.ScheduledAt, now) {
+			continue
+		}
+		if nm.expire(record.ID, record.Type, *record.ScheduledAt, now) {
+			expired++
+		}
+	}
+	return expired
+}
+
+// StartExpirySweeper starts the background job that expires scheduled notifications past the
+// catch-up grace period. It runs every ExpirySweepInterval until StopExpirySweeper is called.
+func (nm *NotificationManagerImpl) StartExpirySweeper() {
+	interval := nm.config.ExpirySweepInterval
+	if interval <= 0 || nm.config.ScheduleCatchUpGrace <= 0 {
+		logrus.Debug("Schedule catch-up grace period is not set, scheduled notifications never expire")
+		return
+	}
+
+	var run func()
+	run = func() {
+		if expired := nm.RunExpirySweep(); expired > 0 {
+			logrus.WithField("expired", expired).Info("Expired scheduled notifications")
+		}
+
+		nm.expiry.mu.Lock()
+		defer nm.expiry.mu.Unlock()
+		if nm.expiry.timer != nil {
+			nm.expiry.timer = nm.clock.AfterFunc(interval, run)
+		}
+	}
+
+	nm.expiry.mu.Lock()
+	defer nm.expiry.mu.Unlock()
+	if nm.expiry.timer != nil {
+		return
+	}
+	nm.expiry.timer = nm.clock.AfterFunc(interval, run)
+	logrus.WithField("interval", interval).Info("Expiry sweeper started")
+}
+
+// StopExpirySweeper stops the expiry sweeper
+func (nm *NotificationManagerImpl) StopExpirySweeper() {
+	nm.expiry.mu.Lock()
+	defer nm.expiry.mu.Unlock()
+	if nm.expiry.timer != nil {
+		nm.expiry.timer.Stop()
+		nm.expiry.timer = nil
+	}
+}
@@ -0,0 +1,114 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// heldScheduler keeps jobs instead of running them, like a scheduler of a paused service
+type heldScheduler struct {
+	jobs      map[string]func()
+	cancelled []string
+}
+
+func (s *heldScheduler) ScheduleJob(jobID string, scheduledTime time.Time, job func()) error {
+	s.jobs[jobID] = job
+	return nil
+}
+
+func (s *heldScheduler) CancelJob(jobID string) error {
+	s.cancelled = append(s.cancelled, jobID)
+	delete(s.jobs, jobID)
+	return nil
+}
+
+// scheduleHeld schedules an email 10 minutes ahead on a held scheduler and returns its ID
+func scheduleHeld(t *testing.T, nm *NotificationManagerImpl, fakeClock *clock.Fake, recipient string) (string, *heldScheduler) {
+	held := &heldScheduler{jobs: make(map[string]func())}
+	nm.scheduler = held
+
+	scheduledAt := fakeClock.Now().Add(10 * time.Minute)
+	result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:        "email",
+		Content:     map[string]interface{}{"subject": "Reminder", "email_body": "Body"},
+		Recipients:  []string{recipient},
+		ScheduledAt: &scheduledAt,
+	})
+	require.NoError(t, err)
+	return result.(map[string]interface{})["id"].(string), held
+}
+
+func TestScheduledNotification_ExpiresWhenRunPastGrace(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 1, DefaultConfig())
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	nm.SetClock(fakeClock)
+	notificationID, held := scheduleHeld(t, nm, fakeClock, recipients[0])
+
+	// The service resumes two hours later and the overdue job runs
+	fakeClock.Set(time.Date(2024, 1, 1, 11, 10, 0, 0, time.UTC))
+	held.jobs[notificationID]()
+
+	assert.Empty(t, kafkaService.GetEmailChannel())
+	record, err := nm.storage.GetNotification(notificationID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusExpired, record.Status)
+	require.Len(t, record.Audit, 1)
+	assert.Equal(t, NotificationAuditEntry{
+		At:         fakeClock.Now(),
+		FromStatus: StatusScheduled,
+		ToStatus:   StatusExpired,
+		Reason:     "scheduled time 2024-01-01T09:10:00Z passed 2h0m0s ago, beyond the catch-up grace period of 1h0m0s",
+	}, record.Audit[0])
+}
+
+func TestScheduledNotification_SentWithinGrace(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 1, DefaultConfig())
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	nm.SetClock(fakeClock)
+	notificationID, held := scheduleHeld(t, nm, fakeClock, recipients[0])
+
+	fakeClock.Set(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC))
+	held.jobs[notificationID]()
+
+	assert.Len(t, kafkaService.GetEmailChannel(), 1)
+	record, err := nm.storage.GetNotification(notificationID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusSent, record.Status)
+	assert.Empty(t, record.Audit)
+}
+
+func TestStartExpirySweeper(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 1, DefaultConfig())
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	nm.SetClock(fakeClock)
+	notificationID, held := scheduleHeld(t, nm, fakeClock, recipients[0])
+
+	nm.StartExpirySweeper()
+	defer nm.StopExpirySweeper()
+
+	fakeClock.Advance(70 * time.Minute)
+	record, err := nm.storage.GetNotification(notificationID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusScheduled, record.Status, "still within the grace period")
+
+	fakeClock.Advance(time.Minute)
+	record, err = nm.storage.GetNotification(notificationID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusExpired, record.Status)
+	assert.Equal(t, []string{notificationID}, held.cancelled, "the job is removed from the scheduler")
+	assert.Zero(t, nm.RunExpirySweep(), "expired notifications are not expired again")
+	assert.Empty(t, kafkaService.GetEmailChannel())
+
+	status, err := nm.GetNotificationStatus(notificationID)
+	require.NoError(t, err)
+	body, err := json.Marshal(status)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"status":"expired"`)
+	assert.Contains(t, string(body), `"to_status":"expired"`)
+}
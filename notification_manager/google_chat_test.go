@@ -0,0 +1,59 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessNotificationRequest_GoogleChatTemplate(t *testing.T) {
+	nm, kafkaService, _ := newTestManager(t, 0, DefaultConfig())
+	require.NoError(t, nm.userService.CreateUser(&models.User{
+		ID:              "chat-user",
+		Email:           "chat.user@company.com",
+		GoogleChatSpace: "spaces/AAAAqZ1yV4k",
+		IsActive:        true,
+	}))
+
+	_, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type: "google_chat",
+		Template: &models.TemplateData{ID: "550e8400-e29b-41d4-a716-446655440009", Version: 1, Data: map[string]interface{}{
+			"alert_type":        "Outage",
+			"service_name":      "checkout",
+			"environment":       "production",
+			"severity":          "critical",
+			"message":           "Error rate above 5% <b>since</b> 09:00",
+			"affected_services": "payments",
+			"dashboard_link":    "https://grafana.company.com/d/checkout",
+		}},
+		ThreadKey:  "incident-42",
+		Recipients: []string{"chat-user"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, kafkaService.GetGoogleChatChannel(), 1)
+	var chat models.GoogleChatNotificationRequest
+	require.NoError(t, json.Unmarshal([]byte(<-kafkaService.GetGoogleChatChannel()), &chat))
+	assert.Equal(t, "spaces/AAAAqZ1yV4k", chat.Recipient)
+	assert.Equal(t, "incident-42", chat.ThreadKey)
+	assert.Equal(t, "*critical* incident on checkout", chat.Content.Text)
+	require.NotNil(t, chat.Content.Card)
+	assert.Equal(t, "Outage: checkout", chat.Content.Card.Title)
+	assert.Equal(t, "Error rate above 5% &lt;b&gt;since&lt;/b&gt; 09:00", chat.Content.Card.Sections[0].Text, "section text is HTML")
+	assert.Equal(t, "https://grafana.company.com/d/checkout", chat.Content.Card.Buttons[0].URL)
+}
+
+func TestProcessNotificationRequest_GoogleChatRecipientWithoutSpace(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 1, DefaultConfig())
+
+	_, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "google_chat",
+		Content:    map[string]interface{}{"text": "Deploy finished"},
+		Recipients: []string{recipients[0]},
+	})
+	require.NoError(t, err)
+	assert.Len(t, kafkaService.GetGoogleChatChannel(), 0)
+}
@@ -0,0 +1,25 @@
+package notification_manager
+
+import "github.com/google/uuid"
+
+// IDGenerator generates notification IDs.
+// Generated IDs must be UUIDs since the API validates notification IDs as such.
+type IDGenerator interface {
+	GenerateID() string
+}
+
+// IDGeneratorFunc adapts a plain function to the IDGenerator interface
+type IDGeneratorFunc func() string
+
+// GenerateID calls f()
+func (f IDGeneratorFunc) GenerateID() string {
+	return f()
+}
+
+// UUIDGenerator generates random (version 4) UUIDs
+type UUIDGenerator struct{}
+
+// GenerateID returns a new random UUID
+func (UUIDGenerator) GenerateID() string {
+	return uuid.New().String()
+}
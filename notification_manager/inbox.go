@@ -0,0 +1,432 @@
+package notification_manager
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// inboxStore keeps the in-app notifications of every user in memory, newest last
+type inboxStore struct {
+	mu    sync.RWMutex
+	items map[string][]*models.InboxItem
+	// maxStored bounds the items kept per user, archived ones included; zero is unlimited
+	maxStored int
+
+	retentionMu    sync.Mutex
+	retentionTimer clock.Timer
+}
+
+// newInboxStore creates an empty inbox store that keeps at most maxStored items per user
+func newInboxStore(maxStored int) *inboxStore {
+	return &inboxStore{
+		items:     make(map[string][]*models.InboxItem),
+		maxStored: maxStored,
+	}
+}
+
+// Add stores an in-app notification in the inbox of its user under a new ID and returns the stored item.
+// A user holding maxStored items loses the oldest, so a flood of notifications cannot grow the store
+// without bound between retention runs.
+func (s *inboxStore) Add(item models.InboxItem) *models.InboxItem {
+	item.ID = uuid.New().String()
+	stored := &item
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := append(s.items[item.UserID], stored)
+	if s.maxStored > 0 && len(items) > s.maxStored {
+		dropped := len(items) - s.maxStored
+		// Copy so the dropped items are not kept alive by the backing array
+		items = append([]*models.InboxItem(nil), items[dropped:]...)
+		logrus.WithFields(logrus.Fields{"user_id": item.UserID, "dropped": dropped}).Debug("Inbox is full, dropped the oldest items")
+	}
+	s.items[item.UserID] = items
+	return stored
+}
+
+// List returns copies of the items in a user's inbox, newest first. It lists either the archived
+// items or the others.
+func (s *inboxStore) List(userID string, unreadOnly, archived bool) []models.InboxItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := s.items[userID]
+	result := make([]models.InboxItem, 0, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		if unreadOnly && items[i].ReadAt != nil {
+			continue
+		}
+		if archived != (items[i].ArchivedAt != nil) {
+			continue
+		}
+		result = append(result, *items[i])
+	}
+	return result
+}
+
+// UnreadSince returns copies of the unread items created after since that are not archived, oldest first
+func (s *inboxStore) UnreadSince(userID string, since time.Time) []models.InboxItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []models.InboxItem
+	for _, item := range s.items[userID] {
+		if item.ReadAt == nil && item.ArchivedAt == nil && item.CreatedAt.After(since) {
+			result = append(result, *item)
+		}
+	}
+	return result
+}
+
+// MarkRead marks an item as read. Items that are already read keep their original read time.
+func (s *inboxStore) MarkRead(userID, itemID string, at time.Time) (models.InboxItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, item := range s.items[userID] {
+		if item.ID != itemID {
+			continue
+		}
+		if item.ReadAt == nil {
+			readAt := at
+			item.ReadAt = &readAt
+		}
+		return *item, nil
+	}
+	return models.InboxItem{}, ErrInboxItemNotFound
+}
+
+// MarkActed records that the user took one of an item's actions, which also marks the item as read.
+// Items keep the first action taken and its time.
+func (s *inboxStore) MarkActed(userID, itemID, actionID string, at time.Time) (models.InboxItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, item := range s.items[userID] {
+		if item.ID != itemID {
+			continue
+		}
+		if !hasInboxAction(item.Actions, actionID) {
+			return models.InboxItem{}, ErrInboxActionNotFound
+		}
+		if item.ActedAt == nil {
+			actedAt := at
+			item.ActedAt = &actedAt
+			item.ActionID = actionID
+		}
+		if item.ReadAt == nil {
+			readAt := at
+			item.ReadAt = &readAt
+		}
+		return *item, nil
+	}
+	return models.InboxItem{}, ErrInboxItemNotFound
+}
+
+// hasInboxAction checks whether actions contain an action with the given ID
+func hasInboxAction(actions []models.InboxAction, actionID string) bool {
+	for _, action := range actions {
+		if action.ID == actionID {
+			return true
+		}
+	}
+	return false
+}
+
+// Archive moves an item out of the inbox into the user's archive. Archived items keep their
+// original archive time.
+func (s *inboxStore) Archive(userID, itemID string, at time.Time) (models.InboxItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, item := range s.items[userID] {
+		if item.ID != itemID {
+			continue
+		}
+		if item.ArchivedAt == nil {
+			archivedAt := at
+			item.ArchivedAt = &archivedAt
+		}
+		return *item, nil
+	}
+	return models.InboxItem{}, ErrInboxItemNotFound
+}
+
+// Unarchive moves an archived item back into the inbox, where retention counts its age from now
+func (s *inboxStore) Unarchive(userID, itemID string, at time.Time) (models.InboxItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, item := range s.items[userID] {
+		if item.ID != itemID {
+			continue
+		}
+		if item.ArchivedAt != nil {
+			unarchivedAt := at
+			item.ArchivedAt = nil
+			item.UnarchivedAt = &unarchivedAt
+		}
+		return *item, nil
+	}
+	return models.InboxItem{}, ErrInboxItemNotFound
+}
+
+// ArchiveExpired archives the items kept since before cutoff and, in inboxes holding more than
+// maxItems, the items kept the longest. A zero cutoff or maxItems disables that rule. It returns
+// the number of items archived.
+func (s *inboxStore) ArchiveExpired(cutoff time.Time, maxItems int, at time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	archived := 0
+	archive := func(item *models.InboxItem) {
+		archivedAt := at
+		item.ArchivedAt = &archivedAt
+		archived++
+	}
+
+	for _, items := range s.items {
+		var kept []*models.InboxItem
+		for _, item := range items {
+			if item.ArchivedAt != nil {
+				continue
+			}
+			if !cutoff.IsZero() && item.KeptSince().Before(cutoff) {
+				archive(item)
+				continue
+			}
+			kept = append(kept, item)
+		}
+
+		if maxItems <= 0 || len(kept) <= maxItems {
+			continue
+		}
+		sort.SliceStable(kept, func(i, j int) bool {
+			return kept[i].KeptSince().Before(kept[j].KeptSince())
+		})
+		for _, item := range kept[:len(kept)-maxItems] {
+			archive(item)
+		}
+	}
+	return archived
+}
+
+// MarkNotificationRead marks the items a notification added to a user's inbox as read
+func (s *inboxStore) MarkNotificationRead(userID, notificationID string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, item := range s.items[userID] {
+		if item.NotificationID == notificationID && item.ReadAt == nil {
+			readAt := at
+			item.ReadAt = &readAt
+		}
+	}
+}
+
+// UsersWithUnread returns the IDs of users with at least one unread item that is not archived, sorted
+func (s *inboxStore) UsersWithUnread() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var userIDs []string
+	for userID, items := range s.items {
+		for _, item := range items {
+			if item.ReadAt == nil && item.ArchivedAt == nil {
+				userIDs = append(userIDs, userID)
+				break
+			}
+		}
+	}
+	sort.Strings(userIDs)
+	return userIDs
+}
+
+// preferenceStore keeps the notification preferences users have set
+type preferenceStore struct {
+	mu          sync.RWMutex
+	preferences map[string]models.NotificationPreferences
+}
+
+// newPreferenceStore creates an empty preference store
+func newPreferenceStore() *preferenceStore {
+	return &preferenceStore{
+		preferences: make(map[string]models.NotificationPreferences),
+	}
+}
+
+// Get returns the preferences of a user, or the defaults if none were set
+func (s *preferenceStore) Get(userID string) models.NotificationPreferences {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if preferences, exists := s.preferences[userID]; exists {
+		return preferences
+	}
+	return *models.DefaultNotificationPreferences(userID)
+}
+
+// Set replaces the preferences of a user
+func (s *preferenceStore) Set(preferences models.NotificationPreferences) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.preferences[preferences.UserID] = preferences
+}
+
+// checkUserExists returns ErrUserNotFound if the user service does not know the user
+func (nm *NotificationManagerImpl) checkUserExists(userID string) error {
+	if nm.userService == nil {
+		return fmt.Errorf("userService is not available")
+	}
+	if _, err := nm.userService.GetUserByID(userID); err != nil {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// inboxThreads groups items, newest first, by thread key. Threads are ordered by their latest item.
+func inboxThreads(items []models.InboxItem) []models.InboxThread {
+	var threads []models.InboxThread
+	index := make(map[string]int)
+	for _, item := range items {
+		if item.ThreadKey == "" {
+			continue
+		}
+		i, ok := index[item.ThreadKey]
+		if !ok {
+			i = len(threads)
+			index[item.ThreadKey] = i
+			threads = append(threads, models.InboxThread{
+				ThreadKey:    item.ThreadKey,
+				LatestItemID: item.ID,
+				LatestAt:     item.CreatedAt,
+			})
+		}
+		threads[i].ItemCount++
+		if item.ReadAt == nil {
+			threads[i].UnreadCount++
+		}
+	}
+	return threads
+}
+
+// GetInbox returns the in-app notifications in a user's inbox or archive, newest first, and
+// the threads they belong to
+func (nm *NotificationManagerImpl) GetInbox(userID string, filter models.InboxFilter) (interface{}, error) {
+	if err := nm.checkUserExists(userID); err != nil {
+		return nil, err
+	}
+
+	items := nm.inbox.List(userID, filter.UnreadOnly, filter.Archived)
+	if filter.ThreadKey != "" {
+		threadItems := make([]models.InboxItem, 0, len(items))
+		for _, item := range items {
+			if item.ThreadKey == filter.ThreadKey {
+				threadItems = append(threadItems, item)
+			}
+		}
+		items = threadItems
+	}
+	unread := len(items)
+	if !filter.UnreadOnly {
+		unread = 0
+		for _, item := range items {
+			if item.ReadAt == nil {
+				unread++
+			}
+		}
+	}
+
+	return &struct {
+		UserID      string               `json:"user_id"`
+		Items       []models.InboxItem   `json:"items"`
+		Threads     []models.InboxThread `json:"threads,omitempty"`
+		UnreadCount int                  `json:"unread_count"`
+	}{
+		UserID:      userID,
+		Items:       items,
+		Threads:     inboxThreads(items),
+		UnreadCount: unread,
+	}, nil
+}
+
+// MarkInboxItemRead marks an item in a user's inbox as read
+func (nm *NotificationManagerImpl) MarkInboxItemRead(userID, itemID string) (interface{}, error) {
+	item, err := nm.inbox.MarkRead(userID, itemID, nm.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// ActOnInboxItem records that a user took one of the actions of an item in their inbox
+func (nm *NotificationManagerImpl) ActOnInboxItem(userID, itemID, actionID string) (interface{}, error) {
+	item, err := nm.inbox.MarkActed(userID, itemID, actionID, nm.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"user_id":   userID,
+		"item_id":   itemID,
+		"action_id": item.ActionID,
+	}).Debug("Inbox action taken")
+
+	return &item, nil
+}
+
+// ArchiveInboxItem moves an item in a user's inbox into their archive
+func (nm *NotificationManagerImpl) ArchiveInboxItem(userID, itemID string) (interface{}, error) {
+	item, err := nm.inbox.Archive(userID, itemID, nm.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// UnarchiveInboxItem moves an archived item back into a user's inbox
+func (nm *NotificationManagerImpl) UnarchiveInboxItem(userID, itemID string) (interface{}, error) {
+	item, err := nm.inbox.Unarchive(userID, itemID, nm.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// GetPreferences returns the notification preferences of a user
+func (nm *NotificationManagerImpl) GetPreferences(userID string) (interface{}, error) {
+	if err := nm.checkUserExists(userID); err != nil {
+		return nil, err
+	}
+	preferences := nm.preferences.Get(userID)
+	return &preferences, nil
+}
+
+// UpdatePreferences replaces the notification preferences of a user
+func (nm *NotificationManagerImpl) UpdatePreferences(userID string, preferences *models.NotificationPreferences) (interface{}, error) {
+	if err := nm.checkUserExists(userID); err != nil {
+		return nil, err
+	}
+
+	updated := *preferences
+	updated.UserID = userID
+	if updated.Digest == "" {
+		updated.Digest = models.DigestOff
+	}
+	updated.UpdatedAt = nm.clock.Now()
+	nm.preferences.Set(updated)
+
+	logrus.WithFields(logrus.Fields{
+		"user_id": userID,
+		"digest":  updated.Digest,
+	}).Info("Notification preferences updated")
+
+	return &updated, nil
+}
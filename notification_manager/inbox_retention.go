@@ -0,0 +1,65 @@
+package notification_manager
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// StartInboxRetention starts the background job that archives inbox items older than
+// InboxArchiveAfter and the oldest items of inboxes holding more than InboxMaxItems. It runs every
+// InboxRetentionInterval until StopInboxRetention is called.
+func (nm *NotificationManagerImpl) StartInboxRetention() {
+	interval := nm.config.InboxRetentionInterval
+	if interval <= 0 || (nm.config.InboxArchiveAfter <= 0 && nm.config.InboxMaxItems <= 0) {
+		logrus.Debug("Inbox retention is not set, inbox items are kept until archived")
+		return
+	}
+
+	var run func()
+	run = func() {
+		if archived := nm.RunInboxRetention(); archived > 0 {
+			logrus.WithField("archived", archived).Info("Archived inbox items")
+		}
+
+		nm.inbox.retentionMu.Lock()
+		defer nm.inbox.retentionMu.Unlock()
+		if nm.inbox.retentionTimer != nil {
+			nm.inbox.retentionTimer = nm.clock.AfterFunc(interval, run)
+		}
+	}
+
+	nm.inbox.retentionMu.Lock()
+	defer nm.inbox.retentionMu.Unlock()
+	if nm.inbox.retentionTimer != nil {
+		return
+	}
+	nm.inbox.retentionTimer = nm.clock.AfterFunc(interval, run)
+	logrus.WithFields(logrus.Fields{
+		"interval":      interval,
+		"archive_after": nm.config.InboxArchiveAfter,
+		"max_items":     nm.config.InboxMaxItems,
+	}).Info("Inbox retention started")
+}
+
+// StopInboxRetention stops the inbox retention job
+func (nm *NotificationManagerImpl) StopInboxRetention() {
+	nm.inbox.retentionMu.Lock()
+	defer nm.inbox.retentionMu.Unlock()
+	if nm.inbox.retentionTimer != nil {
+		nm.inbox.retentionTimer.Stop()
+		nm.inbox.retentionTimer = nil
+	}
+}
+
+// RunInboxRetention archives the inbox items that are past their retention and returns the
+// number archived. Items moved back out of the archive are counted from the time they were.
+func (nm *NotificationManagerImpl) RunInboxRetention() int {
+	now := nm.clock.Now()
+
+	var cutoff time.Time
+	if nm.config.InboxArchiveAfter > 0 {
+		cutoff = now.Add(-nm.config.InboxArchiveAfter)
+	}
+	return nm.inbox.ArchiveExpired(cutoff, nm.config.InboxMaxItems, now)
+}
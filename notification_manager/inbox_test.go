@@ -0,0 +1,221 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActOnInboxItem(t *testing.T) {
+	nm, _, recipients := newTestManager(t, 1, DefaultConfig())
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	nm.SetClock(fakeClock)
+
+	_, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type: "in_app",
+		Content: map[string]interface{}{
+			"title": "Expense report",
+			"body":  "Alex submitted an expense report for approval.",
+			"actions": []interface{}{
+				map[string]interface{}{"id": "approve", "label": "Approve", "url": "myapp://expenses/7/approve", "style": "primary"},
+				map[string]interface{}{"id": "details", "label": "Details", "url": "https://example.com/expenses/7"},
+			},
+		},
+		Recipients: recipients,
+	})
+	require.NoError(t, err)
+
+	items := nm.inbox.List(recipients[0], false, false)
+	require.Len(t, items, 1)
+	assert.Equal(t, []models.InboxAction{
+		{ID: "approve", Label: "Approve", URL: "myapp://expenses/7/approve", Style: models.InboxActionPrimary},
+		{ID: "details", Label: "Details", URL: "https://example.com/expenses/7"},
+	}, items[0].Actions)
+
+	_, err = nm.ActOnInboxItem(recipients[0], items[0].ID, "reject")
+	assert.ErrorIs(t, err, ErrInboxActionNotFound)
+	_, err = nm.ActOnInboxItem(recipients[0], "missing", "approve")
+	assert.ErrorIs(t, err, ErrInboxItemNotFound)
+
+	fakeClock.Advance(time.Minute)
+	result, err := nm.ActOnInboxItem(recipients[0], items[0].ID, "approve")
+	require.NoError(t, err)
+	acted := result.(*models.InboxItem)
+	require.NotNil(t, acted.ActedAt)
+	assert.Equal(t, fakeClock.Now(), *acted.ActedAt)
+	assert.Equal(t, "approve", acted.ActionID)
+	require.NotNil(t, acted.ReadAt, "taking an action reads the item")
+	assert.Empty(t, nm.inbox.List(recipients[0], true, false))
+
+	// The first action taken is kept
+	fakeClock.Advance(time.Minute)
+	result, err = nm.ActOnInboxItem(recipients[0], items[0].ID, "details")
+	require.NoError(t, err)
+	acted = result.(*models.InboxItem)
+	assert.Equal(t, "approve", acted.ActionID)
+	assert.Equal(t, fakeClock.Now().Add(-time.Minute), *acted.ActedAt)
+}
+
+func TestArchiveInboxItem(t *testing.T) {
+	nm, _, recipients := newTestManager(t, 1, DefaultConfig())
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	nm.SetClock(fakeClock)
+
+	sendInApp(t, nm, "Welcome", "Thanks for signing up.", recipients[0])
+	items := nm.inbox.List(recipients[0], false, false)
+	require.Len(t, items, 1)
+
+	result, err := nm.ArchiveInboxItem(recipients[0], items[0].ID)
+	require.NoError(t, err)
+	require.NotNil(t, result.(*models.InboxItem).ArchivedAt)
+	assert.Empty(t, nm.inbox.List(recipients[0], false, false))
+	assert.Len(t, nm.inbox.List(recipients[0], false, true), 1)
+	assert.Empty(t, nm.inbox.UsersWithUnread(), "archived items are left out of digests")
+
+	fakeClock.Advance(time.Hour)
+	result, err = nm.UnarchiveInboxItem(recipients[0], items[0].ID)
+	require.NoError(t, err)
+	unarchived := result.(*models.InboxItem)
+	assert.Nil(t, unarchived.ArchivedAt)
+	assert.Equal(t, fakeClock.Now(), unarchived.KeptSince())
+	assert.Len(t, nm.inbox.List(recipients[0], false, false), 1)
+
+	_, err = nm.ArchiveInboxItem(recipients[0], "missing")
+	assert.ErrorIs(t, err, ErrInboxItemNotFound)
+	_, err = nm.UnarchiveInboxItem(recipients[0], "missing")
+	assert.ErrorIs(t, err, ErrInboxItemNotFound)
+}
+
+func TestRunInboxRetention(t *testing.T) {
+	config := DefaultConfig()
+	config.InboxArchiveAfter = 30 * 24 * time.Hour
+	config.InboxMaxItems = 2
+	nm, _, recipients := newTestManager(t, 2, config)
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	nm.SetClock(fakeClock)
+
+	sendInApp(t, nm, "Old", "Sent a while ago.", recipients[0])
+	restored := nm.inbox.List(recipients[0], false, false)[0]
+	_, err := nm.ArchiveInboxItem(recipients[0], restored.ID)
+	require.NoError(t, err)
+	sendInApp(t, nm, "Expired", "Sent a while ago.", recipients...)
+
+	fakeClock.Advance(20 * 24 * time.Hour)
+	_, err = nm.UnarchiveInboxItem(recipients[0], restored.ID)
+	require.NoError(t, err)
+	fakeClock.Advance(time.Hour)
+	sendInApp(t, nm, "First", "One.", recipients[0])
+	fakeClock.Advance(time.Hour)
+	sendInApp(t, nm, "Second", "Two.", recipients[0])
+	fakeClock.Advance(10 * 24 * time.Hour)
+
+	// Both users' expired items are archived, then the item unarchived the longest ago is over the limit
+	assert.Equal(t, 3, nm.RunInboxRetention())
+	var titles []string
+	for _, item := range nm.inbox.List(recipients[0], false, false) {
+		titles = append(titles, item.Title)
+	}
+	assert.Equal(t, []string{"Second", "First"}, titles)
+	assert.Len(t, nm.inbox.List(recipients[0], false, true), 2)
+	assert.Empty(t, nm.inbox.List(recipients[1], false, false))
+
+	assert.Zero(t, nm.RunInboxRetention())
+}
+
+func TestInboxStore_DropsOldestItemsOverLimit(t *testing.T) {
+	store := newInboxStore(2)
+	for _, title := range []string{"First", "Second", "Third"} {
+		store.Add(models.InboxItem{UserID: "user-1", Title: title})
+	}
+	store.Add(models.InboxItem{UserID: "user-2", Title: "Other"})
+
+	var titles []string
+	for _, item := range store.List("user-1", false, false) {
+		titles = append(titles, item.Title)
+	}
+	assert.Equal(t, []string{"Third", "Second"}, titles)
+	assert.Len(t, store.List("user-2", false, false), 1)
+}
+
+func TestStartInboxRetention(t *testing.T) {
+	config := DefaultConfig()
+	config.InboxRetentionInterval = time.Hour
+	config.InboxArchiveAfter = 24 * time.Hour
+	nm, _, recipients := newTestManager(t, 1, config)
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	nm.SetClock(fakeClock)
+
+	sendInApp(t, nm, "Welcome", "Thanks for signing up.", recipients[0])
+	nm.StartInboxRetention()
+	defer nm.StopInboxRetention()
+
+	fakeClock.Advance(25 * time.Hour)
+	assert.Len(t, nm.inbox.List(recipients[0], false, true), 1)
+	assert.Equal(t, 1, fakeClock.PendingTimers(), "the job reschedules itself")
+}
+
+func TestGetInbox_Threads(t *testing.T) {
+	nm, _, recipients := newTestManager(t, 1, DefaultConfig())
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	nm.SetClock(fakeClock)
+
+	send := func(threadKey, title string) {
+		t.Helper()
+		_, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+			Type:       "in_app",
+			ThreadKey:  threadKey,
+			Content:    map[string]interface{}{"title": title, "body": "Update"},
+			Recipients: recipients,
+		})
+		require.NoError(t, err)
+		fakeClock.Advance(time.Minute)
+	}
+	send("order:42", "Order #42 confirmed")
+	send("order:43", "Order #43 confirmed")
+	send("", "Welcome")
+	send("order:42", "Order #42 shipped")
+
+	items := nm.inbox.List(recipients[0], false, false)
+	_, err := nm.MarkInboxItemRead(recipients[0], items[len(items)-1].ID)
+	require.NoError(t, err)
+
+	result, err := nm.GetInbox(recipients[0], models.InboxFilter{})
+	require.NoError(t, err)
+	data, err := json.Marshal(result)
+	require.NoError(t, err)
+	var inbox struct {
+		Threads []models.InboxThread `json:"threads"`
+	}
+	require.NoError(t, json.Unmarshal(data, &inbox))
+	assert.Equal(t, []models.InboxThread{
+		{ThreadKey: "order:42", ItemCount: 2, UnreadCount: 1, LatestItemID: items[0].ID, LatestAt: items[0].CreatedAt},
+		{ThreadKey: "order:43", ItemCount: 1, UnreadCount: 1, LatestItemID: items[2].ID, LatestAt: items[2].CreatedAt},
+	}, inbox.Threads)
+
+	result, err = nm.GetInbox(recipients[0], models.InboxFilter{ThreadKey: "order:42"})
+	require.NoError(t, err)
+	data, err = json.Marshal(result)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"unread_count":1`)
+	assert.Contains(t, string(data), "Order #42 shipped")
+	assert.NotContains(t, string(data), "Order #43")
+}
+
+func TestThreadKeyOnChannelMessages(t *testing.T) {
+	nm, _, _ := newTestManager(t, 1, DefaultConfig())
+	request := models.NotificationRequest{
+		ThreadKey: "order:42",
+		Content:   map[string]interface{}{"title": "Order #42", "body": "Shipped", "text": "Order #42 shipped"},
+	}
+	userInfo := &models.UserNotificationInfo{ID: "user-001", SlackChannel: "#orders"}
+	device := &models.UserDeviceInfo{DeviceToken: "token"}
+
+	assert.Equal(t, "order:42", nm.createSlackMessage("n1", request, userInfo).ThreadKey)
+	assert.Equal(t, "order:42", nm.createIndividualPushMessage("n1", request, userInfo, device, "ios_push").(*models.APNSNotificationRequest).ThreadID)
+	assert.Equal(t, "order:42", nm.createIndividualPushMessage("n1", request, userInfo, device, "android_push").(*models.FCMNotificationRequest).CollapseKey)
+}
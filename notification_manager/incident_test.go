@@ -0,0 +1,73 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessNotificationRequest_IncidentTriggerAndResolve(t *testing.T) {
+	nm, kafkaService, _ := newTestManager(t, 0, DefaultConfig())
+
+	_, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type: "incident",
+		Content: map[string]interface{}{
+			"summary":  "Checkout error rate above 5%",
+			"severity": "critical",
+			"details":  map[string]interface{}{"region": "eu-west-1"},
+		},
+		DedupeKey:  "checkout:error-rate",
+		Recipients: []string{"incident:platform-oncall"},
+	})
+	require.NoError(t, err)
+	_, err = nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "incident",
+		Content:    map[string]interface{}{"action": "resolve"},
+		DedupeKey:  "checkout:error-rate",
+		Recipients: []string{"incident:platform-oncall"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, kafkaService.GetIncidentChannel(), 2)
+	var trigger, resolve models.IncidentNotificationRequest
+	require.NoError(t, json.Unmarshal([]byte(<-kafkaService.GetIncidentChannel()), &trigger))
+	require.NoError(t, json.Unmarshal([]byte(<-kafkaService.GetIncidentChannel()), &resolve))
+
+	assert.Equal(t, "platform-oncall", trigger.Recipient)
+	assert.Equal(t, "checkout:error-rate", trigger.DedupeKey)
+	assert.Equal(t, models.IncidentActionTrigger, trigger.Content.Action, "the action defaults to trigger")
+	assert.Equal(t, models.IncidentSeverityCritical, trigger.Content.Severity)
+	assert.Equal(t, map[string]interface{}{"region": "eu-west-1"}, trigger.Content.Details)
+
+	assert.Equal(t, models.IncidentActionResolve, resolve.Content.Action)
+	assert.Equal(t, "checkout:error-rate", resolve.DedupeKey, "follow-ups resolve the alert of their dedupe key")
+}
+
+func TestProcessNotificationRequest_IncidentWithoutDedupeKey(t *testing.T) {
+	nm, kafkaService, _ := newTestManager(t, 0, DefaultConfig())
+
+	_, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "incident",
+		Content:    map[string]interface{}{"summary": "Disk almost full", "severity": "warning"},
+		Recipients: []string{"incident:platform-oncall"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, kafkaService.GetIncidentChannel(), 1)
+	var incident models.IncidentNotificationRequest
+	require.NoError(t, json.Unmarshal([]byte(<-kafkaService.GetIncidentChannel()), &incident))
+	assert.Equal(t, incident.ID, incident.DedupeKey, "notifications without a dedupe key are their own alert")
+}
+
+func TestIsSuppressible_Urgent(t *testing.T) {
+	nm, _, _ := newTestManager(t, 0, DefaultConfig())
+
+	assert.False(t, nm.isSuppressible(models.CategoryUrgent))
+	assert.True(t, nm.isSuppressible(models.CategoryMarketing))
+	category, err := nm.resolveCategory(&models.NotificationRequest{Type: "incident"})
+	require.NoError(t, err)
+	assert.Equal(t, models.CategoryUrgent, category)
+}
@@ -1,16 +1,76 @@
 package notification_manager
 
 import (
+	"context"
+	"time"
+
 	"github.com/gaurav2721/notification-service/models"
+	"github.com/gaurav2721/notification-service/policy"
 )
 
 // NotificationManager interface defines methods for notification management
 type NotificationManager interface {
 	GetNotificationStatus(notificationID string) (interface{}, error)
+	CancelNotification(notificationID string, actor string) (interface{}, error)
+	WaitForNotificationStatus(ctx context.Context, notificationID string, timeout time.Duration) (interface{}, error)
+	GetDeliveryAttempts(notificationID string, recipient string) (interface{}, error)
+	ListNotifications(filter NotificationFilter) (interface{}, error)
+	GetNotificationAnalytics(filter NotificationFilter) (interface{}, error)
+	RecordEngagementEvent(notificationID string, event *models.EngagementEventRequest) (interface{}, error)
+	GetEngagementEvents(notificationID string) (interface{}, error)
+	RecordDeliveryReceipts(receipts []models.DeliveryReceipt) (interface{}, error)
 	CreateTemplate(template *models.Template) (interface{}, error)
+	UpdateTemplate(templateID string, template *models.Template, actor string) (interface{}, error)
+	ActivateTemplate(templateID string, version int, actor string) (interface{}, error)
+	GetTemplateAudit(templateID string) (interface{}, error)
 	GetTemplateVersion(templateID string, version int) (interface{}, error)
 	GetPredefinedTemplates() []*models.Template
+	ListTemplates() []*models.Template
+	RenderTemplate(templateID string, version int, data map[string]interface{}, tenant string) (interface{}, error)
+	GetTemplateStats(templateID string) (interface{}, error)
+	LintTemplate(templateID string, version int) (interface{}, error)
+	DiffTemplateVersions(templateID string, fromVersion, toVersion int) (interface{}, error)
+	ExportTemplates() *models.TemplateBundle
+	ImportTemplates(bundle *models.TemplateBundle, mode models.TemplateConflictMode, actor string) (interface{}, error)
+	GetAdminOverview(recentLimit int) (interface{}, error)
+	ReplayQueueMessages(request *models.QueueReplayRequest) (interface{}, error)
+	CheckRecipients(check *models.RecipientCheckRequest) (interface{}, error)
+	GetInbox(userID string, filter models.InboxFilter) (interface{}, error)
+	MarkInboxItemRead(userID, itemID string) (interface{}, error)
+	ActOnInboxItem(userID, itemID, actionID string) (interface{}, error)
+	ArchiveInboxItem(userID, itemID string) (interface{}, error)
+	UnarchiveInboxItem(userID, itemID string) (interface{}, error)
+	GetPreferences(userID string) (interface{}, error)
+	UpdatePreferences(userID string, preferences *models.NotificationPreferences) (interface{}, error)
+	GetBranding(tenant string) interface{}
+	UpdateBranding(tenant string, branding *models.Branding, actor string) interface{}
+	GetSenderSettings(tenant string) interface{}
+	UpdateSenderSettings(tenant string, settings *models.SenderSettings, actor string) interface{}
+	StartInboxDigests()
+	StopInboxDigests()
+	StartInboxRetention()
+	StartExpirySweeper()
+	StartMediaCleanup()
+	StartTemplateReload()
+	StopBackgroundSends(ctx context.Context)
+	ReloadTemplates() (interface{}, error)
+	GetTemplateDirectoryStatus() (interface{}, error)
+	StartTemplateGitSync()
+	SyncTemplatesFromGit(ctx context.Context) (interface{}, error)
+	GetTemplateGitSyncStatus() (interface{}, error)
+	UploadMedia(ctx context.Context, tenant, filename, declaredType string, content []byte, actor string) (interface{}, error)
+	ListMedia(tenant string) []models.MediaAsset
+	GetMedia(tenant, mediaID string) (interface{}, error)
+	DeleteMedia(ctx context.Context, tenant, mediaID string) error
+	SendContactVerification(userID, contact, address string) (interface{}, error)
+	ListRoutingPolicies() []policy.Policy
+	GetRoutingPolicy(policyID string) (interface{}, error)
+	CreateRoutingPolicy(p *policy.Policy) (interface{}, error)
+	UpdateRoutingPolicy(policyID string, p *policy.Policy) (interface{}, error)
+	DeleteRoutingPolicy(policyID string) error
+	EvaluateRoutingPolicies(request *models.NotificationRequest, candidates []policy.Policy) (interface{}, error)
 
 	// Main method for handling complete notification processing
 	ProcessNotificationRequest(request *models.NotificationRequest) (interface{}, error)
+	ProcessNotificationRequestWithContext(ctx context.Context, request *models.NotificationRequest) (interface{}, error)
 }
@@ -0,0 +1,62 @@
+package notification_manager
+
+import (
+	"math"
+	"sort"
+
+	"github.com/gaurav2721/notification-service/external_services/delivery"
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// latencyReport merges the delivery latency histograms of notifications by channel and provider
+type latencyReport struct {
+	histograms map[delivery.LatencyKey]*metrics.Histogram
+}
+
+func newLatencyReport() *latencyReport {
+	return &latencyReport{histograms: make(map[delivery.LatencyKey]*metrics.Histogram)}
+}
+
+// add merges the latency histograms of one notification
+func (r *latencyReport) add(histograms map[delivery.LatencyKey]*metrics.Histogram) {
+	for key, histogram := range histograms {
+		if merged, ok := r.histograms[key]; ok {
+			merged.Merge(histogram)
+		} else {
+			r.histograms[key] = histogram.Clone()
+		}
+	}
+}
+
+// snapshots returns the mean and percentiles of every channel and provider, sorted by channel
+// and provider
+func (r *latencyReport) snapshots() []models.DeliveryLatency {
+	snapshots := make([]models.DeliveryLatency, 0, len(r.histograms))
+	for key, histogram := range r.histograms {
+		if histogram.Count() == 0 {
+			continue
+		}
+		snapshots = append(snapshots, models.DeliveryLatency{
+			Channel:  key.Channel,
+			Provider: key.Provider,
+			Count:    histogram.Count(),
+			MeanMs:   secondsToMs(histogram.Sum() / float64(histogram.Count())),
+			P50Ms:    secondsToMs(histogram.Quantile(0.5)),
+			P95Ms:    secondsToMs(histogram.Quantile(0.95)),
+			P99Ms:    secondsToMs(histogram.Quantile(0.99)),
+		})
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].Channel != snapshots[j].Channel {
+			return snapshots[i].Channel < snapshots[j].Channel
+		}
+		return snapshots[i].Provider < snapshots[j].Provider
+	})
+	return snapshots
+}
+
+// secondsToMs converts seconds to milliseconds rounded to a tenth of a millisecond
+func secondsToMs(seconds float64) float64 {
+	return math.Round(seconds*10000) / 10
+}
@@ -0,0 +1,84 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/external_services/delivery"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetNotificationAnalytics_Latency(t *testing.T) {
+	nm, _, recipients := newTestManager(t, 1, DefaultConfig())
+	deliveryService := delivery.NewDeliveryService()
+	nm.deliveryService = deliveryService
+	queuedAt := time.Now()
+
+	send := func(request *models.NotificationRequest, provider string, latenciesMs ...int64) {
+		request.Recipients = recipients
+		result, err := nm.ProcessNotificationRequest(request)
+		require.NoError(t, err)
+		notificationID := result.(map[string]interface{})["id"].(string)
+
+		for i, latencyMs := range latenciesMs {
+			require.NoError(t, deliveryService.RecordAttempt(&models.DeliveryAttempt{
+				NotificationID: notificationID,
+				Recipient:      string(rune('a'+i)) + "@company.com",
+				Channel:        request.Type,
+				Provider:       provider,
+				Status:         models.DeliveryStatusSent,
+				QueuedAt:       &queuedAt,
+				LatencyMs:      latencyMs,
+			}))
+		}
+	}
+
+	emailContent := map[string]interface{}{"subject": "Hello", "email_body": "Body"}
+	latencies := make([]int64, 0, 100)
+	for i := 0; i < 90; i++ {
+		latencies = append(latencies, 80)
+	}
+	for i := 0; i < 10; i++ {
+		latencies = append(latencies, 4000)
+	}
+	send(&models.NotificationRequest{Type: "email", Content: emailContent}, "email", latencies[:50]...)
+	send(&models.NotificationRequest{Type: "email", Content: emailContent}, "email", latencies[50:]...)
+	send(&models.NotificationRequest{Type: "slack", Content: map[string]interface{}{"text": "Hi"}}, "slack", 300)
+
+	result, err := nm.GetNotificationAnalytics(NotificationFilter{})
+	require.NoError(t, err)
+	latency := analyticsLatency(t, result)
+	require.Len(t, latency, 2)
+
+	// Histograms of all matching notifications are merged by channel and provider
+	email := latency[0]
+	assert.Equal(t, "email", email.Channel)
+	assert.Equal(t, "email", email.Provider)
+	assert.Equal(t, uint64(100), email.Count)
+	assert.Equal(t, 472.0, email.MeanMs)
+	assert.InDelta(t, 77.8, email.P50Ms, 0.1) // within the 50-100ms bucket
+	assert.InDelta(t, 3750, email.P95Ms, 0.1) // within the 2.5-5s bucket
+	assert.InDelta(t, 4750, email.P99Ms, 0.1)
+	assert.Equal(t, "slack", latency[1].Channel)
+
+	// Latency follows the analytics filter
+	result, err = nm.GetNotificationAnalytics(NotificationFilter{Type: "slack"})
+	require.NoError(t, err)
+	latency = analyticsLatency(t, result)
+	require.Len(t, latency, 1)
+	assert.Equal(t, uint64(1), latency[0].Count)
+}
+
+func analyticsLatency(t *testing.T, result interface{}) []models.DeliveryLatency {
+	t.Helper()
+	var analytics struct {
+		Latency []models.DeliveryLatency `json:"latency"`
+	}
+	encoded, err := json.Marshal(result)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(encoded, &analytics))
+	return analytics.Latency
+}
@@ -0,0 +1,120 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// LocaleFallback configures which template content a tenant's recipients get
+type LocaleFallback struct {
+	// DefaultLocale ends every fallback chain; localized templates need content in it to be activated
+	DefaultLocale string `json:"default_locale"`
+	// Fallbacks lists the locales tried after a locale before its parent locales, e.g. "pt-BR": ["pt-PT"]
+	Fallbacks map[string][]string `json:"fallbacks,omitempty"`
+}
+
+// Chain returns the locales tried for a recipient in locale, most specific first: the locale,
+// its configured fallbacks, its parent locales (fr-CA falls back to fr) and the default locale
+func (f LocaleFallback) Chain(locale string) []string {
+	var chain []string
+	seen := make(map[string]bool)
+	add := func(locale string) {
+		if locale != "" && !seen[locale] {
+			seen[locale] = true
+			chain = append(chain, locale)
+		}
+	}
+
+	if normalized, err := models.NormalizeLocale(locale); err == nil {
+		add(normalized)
+		for _, fallback := range f.Fallbacks[normalized] {
+			add(fallback)
+		}
+		for parent := normalized; strings.Contains(parent, "-"); {
+			parent = parent[:strings.LastIndex(parent, "-")]
+			add(parent)
+		}
+	}
+	add(f.DefaultLocale)
+	return chain
+}
+
+// normalize brings every locale of the configuration into canonical form
+func (f *LocaleFallback) normalize() error {
+	defaultLocale, err := models.NormalizeLocale(f.DefaultLocale)
+	if err != nil {
+		return fmt.Errorf("default_locale %q: %w", f.DefaultLocale, err)
+	}
+	f.DefaultLocale = defaultLocale
+
+	fallbacks := make(map[string][]string, len(f.Fallbacks))
+	for key, locales := range f.Fallbacks {
+		locale, err := models.NormalizeLocale(key)
+		if err != nil {
+			return fmt.Errorf("fallbacks %q: %w", key, err)
+		}
+		for _, fallback := range locales {
+			normalized, err := models.NormalizeLocale(fallback)
+			if err != nil {
+				return fmt.Errorf("fallbacks of %s %q: %w", locale, fallback, err)
+			}
+			fallbacks[locale] = append(fallbacks[locale], normalized)
+		}
+	}
+	f.Fallbacks = fallbacks
+	return nil
+}
+
+// loadLocaleFallbacks reads the fallback configuration of each tenant from a JSON file
+// mapping tenants to their LocaleFallback
+func loadLocaleFallbacks(path string) (map[string]LocaleFallback, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read locale fallbacks: %w", err)
+	}
+
+	var fallbacks map[string]LocaleFallback
+	if err := json.Unmarshal(data, &fallbacks); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for tenant, fallback := range fallbacks {
+		if err := fallback.normalize(); err != nil {
+			return nil, fmt.Errorf("%s: tenant %s: %w", path, tenant, err)
+		}
+		fallbacks[tenant] = fallback
+	}
+	return fallbacks, nil
+}
+
+// localeFallback returns the fallback configuration of a tenant; tenants without one only fall
+// back to parent locales and the default locale
+func (nm *NotificationManagerImpl) localeFallback(tenant string) LocaleFallback {
+	if fallback, exists := nm.config.LocaleFallbacks[tenant]; exists {
+		return fallback
+	}
+	return LocaleFallback{DefaultLocale: nm.config.DefaultLocale}
+}
+
+// localizeTemplate returns the template with the content in the locale a tenant's recipient in
+// locale gets; an empty locale gets the tenant's default locale
+func (nm *NotificationManagerImpl) localizeTemplate(templateObj *models.Template, tenant, locale string) *models.Template {
+	if !templateObj.IsLocalized() {
+		return templateObj
+	}
+	return templateObj.Localize(nm.localeFallback(tenant).Chain(locale))
+}
+
+// ActivateTemplate activates a template version on behalf of actor, the tenant whose default
+// locale localized versions must have content in
+func (nm *NotificationManagerImpl) ActivateTemplate(templateID string, version int, actor string) (interface{}, error) {
+	response, err := nm.templateManager.ActivateTemplate(templateID, version, nm.localeFallback(actor).DefaultLocale, actor)
+	if errors.Is(err, models.ErrTemplateNotFound) {
+		return nil, ErrTemplateNotFound
+	}
+	return response, err
+}
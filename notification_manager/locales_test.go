@@ -0,0 +1,166 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocaleFallback_Chain(t *testing.T) {
+	fallback := LocaleFallback{DefaultLocale: "en", Fallbacks: map[string][]string{"pt-BR": {"pt-PT"}}}
+
+	assert.Equal(t, []string{"fr-CA", "fr", "en"}, fallback.Chain("fr_ca"))
+	assert.Equal(t, []string{"pt-BR", "pt-PT", "pt", "en"}, fallback.Chain("pt-BR"))
+	assert.Equal(t, []string{"en-GB", "en"}, fallback.Chain("en-GB"))
+	assert.Equal(t, []string{"en"}, fallback.Chain(""))
+	assert.Equal(t, []string{"en"}, fallback.Chain("not a locale"))
+}
+
+func TestLoadLocaleFallbacks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "locales.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"acme": {"default_locale": "fr", "fallbacks": {"fr_ca": ["fr_fr"]}}}`), 0o600))
+
+	fallbacks, err := loadLocaleFallbacks(path)
+	require.NoError(t, err)
+	assert.Equal(t, LocaleFallback{DefaultLocale: "fr", Fallbacks: map[string][]string{"fr-CA": {"fr-FR"}}}, fallbacks["acme"])
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"acme": {"default_locale": "???"}}`), 0o600))
+	_, err = loadLocaleFallbacks(path)
+	assert.ErrorIs(t, err, models.ErrInvalidLocale)
+}
+
+func TestActivateTemplate_RequiresDefaultLocaleContent(t *testing.T) {
+	config := DefaultConfig()
+	config.LocaleFallbacks = map[string]LocaleFallback{"acme": {DefaultLocale: "fr"}}
+	nm, _, recipients := newTestManager(t, 1, config)
+
+	created, err := nm.CreateTemplate(&models.Template{
+		Name:    "Welcome",
+		Type:    models.SlackNotification,
+		Content: models.TemplateContent{Text: "Bienvenue"},
+		Locale:  "fr",
+		Localizations: map[string]models.TemplateContent{
+			"fr_CA": {Text: "Bienvenue chez nous"},
+		},
+	})
+	require.NoError(t, err)
+	response := created.(*models.TemplateResponse)
+	assert.Equal(t, models.TemplateStatusDraft, response.Status)
+
+	// Drafts cannot be sent
+	send := func(version int) error {
+		_, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+			Type:       "slack",
+			Template:   &models.TemplateData{ID: response.ID, Version: version, Data: map[string]interface{}{}},
+			Recipients: recipients,
+		})
+		return err
+	}
+	assert.ErrorIs(t, send(1), models.ErrTemplateNotActive)
+
+	// Tenants falling back to English need English content
+	_, err = nm.ActivateTemplate(response.ID, 1, "default")
+	assert.ErrorIs(t, err, models.ErrMissingDefaultLocale)
+	activated, err := nm.ActivateTemplate(response.ID, 1, "acme")
+	require.NoError(t, err)
+	assert.Equal(t, models.TemplateStatusActive, activated.(*models.TemplateResponse).Status)
+	assert.NoError(t, send(1))
+
+	// A new localized version is a draft again, while the active version can still be sent
+	updated, err := nm.UpdateTemplate(response.ID, &models.Template{
+		Name:    "Welcome",
+		Content: models.TemplateContent{Text: "Bienvenue"},
+		Locale:  "fr",
+		Localizations: map[string]models.TemplateContent{
+			"fr-CA": {Text: "Bienvenue chez nous"},
+			"en":    {Text: "Welcome"},
+		},
+	}, "default")
+	require.NoError(t, err)
+	assert.Equal(t, models.TemplateStatusDraft, updated.(*models.TemplateResponse).Status)
+	assert.ErrorIs(t, send(2), models.ErrTemplateNotActive)
+	assert.NoError(t, send(1))
+
+	_, err = nm.ActivateTemplate(response.ID, 2, "default")
+	require.NoError(t, err)
+	assert.NoError(t, send(2))
+
+	result, err := nm.GetTemplateAudit(response.ID)
+	require.NoError(t, err)
+	encoded, err := json.Marshal(result)
+	require.NoError(t, err)
+	var audit struct {
+		Entries []models.TemplateAuditEntry `json:"entries"`
+	}
+	require.NoError(t, json.Unmarshal(encoded, &audit))
+	require.Len(t, audit.Entries, 4)
+	assert.Equal(t, models.TemplateActionActivated, audit.Entries[1].Action)
+	assert.Equal(t, "acme", audit.Entries[1].Actor)
+	assert.Equal(t, 1, audit.Entries[1].Version)
+
+	_, err = nm.ActivateTemplate("unknown-template", 1, "default")
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+}
+
+func TestProcessNotificationRequest_LocalizedTemplate(t *testing.T) {
+	config := DefaultConfig()
+	config.LocaleFallbacks = map[string]LocaleFallback{"acme": {DefaultLocale: "en", Fallbacks: map[string][]string{"pt-BR": {"pt-PT"}}}}
+	nm, kafkaService, _ := newTestManager(t, 0, config)
+
+	locales := map[string]string{"user-fr-ca": "fr-CA", "user-fr-be": "fr-BE", "user-pt-br": "pt-BR", "user-de": "de", "user-none": ""}
+	var recipients []string
+	for userID, locale := range locales {
+		require.NoError(t, nm.userService.CreateUser(&models.User{
+			ID:       userID,
+			Email:    userID + "@company.com",
+			FullName: userID,
+			IsActive: true,
+			Locale:   locale,
+		}))
+		recipients = append(recipients, userID)
+	}
+
+	created, err := nm.CreateTemplate(&models.Template{
+		Name:              "Order Shipped",
+		Type:              models.EmailNotification,
+		Content:           models.TemplateContent{Subject: "Order {{order_id}} shipped", EmailBody: "Your order has shipped"},
+		RequiredVariables: []string{"order_id"},
+		Locale:            "en",
+		Localizations: map[string]models.TemplateContent{
+			"fr":    {Subject: "Commande {{order_id}} expédiée", EmailBody: "Votre commande a été expédiée"},
+			"fr-CA": {Subject: "Commande {{order_id}} envoyée", EmailBody: "Votre commande a été envoyée"},
+			"pt-PT": {Subject: "Encomenda {{order_id}} enviada", EmailBody: "A sua encomenda foi enviada"},
+		},
+	})
+	require.NoError(t, err)
+	templateID := created.(*models.TemplateResponse).ID
+	_, err = nm.ActivateTemplate(templateID, 1, "acme")
+	require.NoError(t, err)
+
+	_, err = nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "email",
+		Tenant:     "acme",
+		Template:   &models.TemplateData{ID: templateID, Version: 1, Data: map[string]interface{}{"order_id": "ORD-1"}},
+		Recipients: recipients,
+	})
+	require.NoError(t, err)
+
+	subjects := make(map[string]string)
+	for range recipients {
+		var message models.EmailNotificationRequest
+		require.NoError(t, json.Unmarshal([]byte(<-kafkaService.GetEmailChannel()), &message))
+		subjects[message.UserID] = message.Content.Subject
+	}
+	assert.Equal(t, map[string]string{
+		"user-fr-ca": "Commande ORD-1 envoyée",
+		"user-fr-be": "Commande ORD-1 expédiée",
+		"user-pt-br": "Encomenda ORD-1 enviada",
+		"user-de":    "Order ORD-1 shipped",
+		"user-none":  "Order ORD-1 shipped",
+	}, subjects)
+}
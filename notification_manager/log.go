@@ -0,0 +1,11 @@
+package notification_manager
+
+import "github.com/gaurav2721/notification-service/logger"
+
+var (
+	// moduleLog carries per-batch fan-out events
+	moduleLog = logger.Module("notification_manager")
+
+	// sampledLog carries per-recipient events, which are logged once every LOG_SAMPLE_EVERY occurrences
+	sampledLog = moduleLog.Sampled()
+)
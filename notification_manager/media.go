@@ -0,0 +1,275 @@
+package notification_manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/external_services/objectstore"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/sirupsen/logrus"
+)
+
+// mediaLibrary keeps the media assets uploaded to the media store
+type mediaLibrary struct {
+	mu     sync.RWMutex
+	store  objectstore.ObjectStore
+	assets map[string]models.MediaAsset
+
+	// cleanupTimer is set while the media cleanup runs
+	cleanupMu    sync.Mutex
+	cleanupTimer clock.Timer
+}
+
+// newMediaLibrary creates an empty media library storing assets in store, which may be nil when
+// no media store is configured
+func newMediaLibrary(store objectstore.ObjectStore) *mediaLibrary {
+	return &mediaLibrary{
+		store:  store,
+		assets: make(map[string]models.MediaAsset),
+	}
+}
+
+// SetObjectStore replaces the store media assets are uploaded to
+func (nm *NotificationManagerImpl) SetObjectStore(store objectstore.ObjectStore) {
+	nm.media.mu.Lock()
+	defer nm.media.mu.Unlock()
+	nm.media.store = store
+	nm.qrCodes.reset()
+}
+
+// objectStore returns the media store, or ErrMediaStoreNotConfigured when there is none
+func (nm *NotificationManagerImpl) objectStore() (objectstore.ObjectStore, error) {
+	nm.media.mu.RLock()
+	defer nm.media.mu.RUnlock()
+	if nm.media.store == nil {
+		return nil, ErrMediaStoreNotConfigured
+	}
+	return nm.media.store, nil
+}
+
+// UploadMedia stores a media asset of tenant and returns it with its durable URL. Its content
+// type is detected from its content, and must match declaredType when one is given.
+func (nm *NotificationManagerImpl) UploadMedia(ctx context.Context, tenant, filename, declaredType string, content []byte, actor string) (interface{}, error) {
+	store, err := nm.objectStore()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(content) == 0 {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidMedia)
+	}
+	if int64(len(content)) > nm.config.MediaMaxUploadBytes {
+		return nil, fmt.Errorf("%w: %d bytes, at most %d bytes are allowed", ErrMediaTooLarge, len(content), nm.config.MediaMaxUploadBytes)
+	}
+
+	filename = strings.TrimSpace(filepath.Base(filename))
+	if filename == "" || filename == "." || len(filename) > 255 || strings.IndexFunc(filename, unicode.IsControl) >= 0 {
+		return nil, fmt.Errorf("%w: a filename of at most 255 characters is required", ErrInvalidMedia)
+	}
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(content))
+	extension, ok := models.MediaExtension(contentType)
+	if !ok {
+		return nil, fmt.Errorf("%w: content type %s is not allowed, expected a PNG, JPEG, GIF or WebP image or a PDF document", ErrInvalidMedia, contentType)
+	}
+	if declared, _, err := mime.ParseMediaType(declaredType); err == nil && declared != "application/octet-stream" && declared != contentType {
+		return nil, fmt.Errorf("%w: the file is declared as %s but its content is %s", ErrInvalidMedia, declared, contentType)
+	}
+
+	id := nm.idGenerator.GenerateID()
+	key := "media/" + id + extension
+	if err := store.Put(ctx, key, contentType, content); err != nil {
+		return nil, fmt.Errorf("failed to store media asset: %w", err)
+	}
+
+	asset := models.MediaAsset{
+		ID:          id,
+		Tenant:      tenant,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        int64(len(content)),
+		URL:         store.URL(key),
+		Key:         key,
+		CreatedAt:   nm.clock.Now(),
+		CreatedBy:   actor,
+	}
+	nm.media.mu.Lock()
+	nm.media.assets[id] = asset
+	nm.media.mu.Unlock()
+
+	logrus.WithFields(logrus.Fields{
+		"media_id":     id,
+		"tenant":       tenant,
+		"content_type": contentType,
+		"size":         asset.Size,
+	}).Info("Media asset uploaded")
+
+	return &asset, nil
+}
+
+// ListMedia returns the media assets of tenant, newest first
+func (nm *NotificationManagerImpl) ListMedia(tenant string) []models.MediaAsset {
+	nm.media.mu.RLock()
+	defer nm.media.mu.RUnlock()
+
+	assets := make([]models.MediaAsset, 0)
+	for _, asset := range nm.media.assets {
+		if asset.Tenant == tenant {
+			assets = append(assets, asset)
+		}
+	}
+	sort.Slice(assets, func(i, j int) bool {
+		if !assets[i].CreatedAt.Equal(assets[j].CreatedAt) {
+			return assets[i].CreatedAt.After(assets[j].CreatedAt)
+		}
+		return assets[i].ID < assets[j].ID
+	})
+	return assets
+}
+
+// GetMedia returns a media asset of tenant
+func (nm *NotificationManagerImpl) GetMedia(tenant, mediaID string) (interface{}, error) {
+	nm.media.mu.RLock()
+	defer nm.media.mu.RUnlock()
+
+	asset, exists := nm.media.assets[mediaID]
+	if !exists || asset.Tenant != tenant {
+		return nil, ErrMediaNotFound
+	}
+	return &asset, nil
+}
+
+// DeleteMedia deletes a media asset of tenant from the media store. Assets a template references
+// are kept, so sent and scheduled notifications do not lose their images.
+func (nm *NotificationManagerImpl) DeleteMedia(ctx context.Context, tenant, mediaID string) error {
+	store, err := nm.objectStore()
+	if err != nil {
+		return err
+	}
+
+	nm.media.mu.RLock()
+	asset, exists := nm.media.assets[mediaID]
+	nm.media.mu.RUnlock()
+	if !exists || asset.Tenant != tenant {
+		return ErrMediaNotFound
+	}
+	if strings.Contains(nm.encodedTemplates(), asset.URL) {
+		return fmt.Errorf("%w: remove %s from the templates first", ErrMediaInUse, asset.URL)
+	}
+
+	return nm.deleteMedia(ctx, store, asset)
+}
+
+// deleteMedia removes an asset from the media store and the library
+func (nm *NotificationManagerImpl) deleteMedia(ctx context.Context, store objectstore.ObjectStore, asset models.MediaAsset) error {
+	if err := store.Delete(ctx, asset.Key); err != nil {
+		return fmt.Errorf("failed to delete media asset: %w", err)
+	}
+
+	nm.media.mu.Lock()
+	delete(nm.media.assets, asset.ID)
+	nm.media.mu.Unlock()
+
+	logrus.WithFields(logrus.Fields{
+		"media_id": asset.ID,
+		"tenant":   asset.Tenant,
+	}).Info("Media asset deleted")
+	return nil
+}
+
+// encodedTemplates returns every version of every template as JSON, to look up the assets they
+// reference by URL
+func (nm *NotificationManagerImpl) encodedTemplates() string {
+	encoded, err := json.Marshal(nm.templateManager.ExportTemplates())
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
+
+// RunMediaCleanup deletes the media assets uploaded more than MediaUnreferencedTTL ago that no
+// version of any template references, and returns the number deleted
+func (nm *NotificationManagerImpl) RunMediaCleanup(ctx context.Context) int {
+	ttl := nm.config.MediaUnreferencedTTL
+	store, err := nm.objectStore()
+	if err != nil || ttl <= 0 {
+		return 0
+	}
+
+	now := nm.clock.Now()
+	var candidates []models.MediaAsset
+	nm.media.mu.RLock()
+	for _, asset := range nm.media.assets {
+		if now.Sub(asset.CreatedAt) > ttl {
+			candidates = append(candidates, asset)
+		}
+	}
+	nm.media.mu.RUnlock()
+	if len(candidates) == 0 {
+		return 0
+	}
+
+	templates := nm.encodedTemplates()
+	deleted := 0
+	for _, asset := range candidates {
+		if strings.Contains(templates, asset.URL) {
+			continue
+		}
+		if err := nm.deleteMedia(ctx, store, asset); err != nil {
+			logrus.WithError(err).WithField("media_id", asset.ID).Warn("Failed to delete unreferenced media asset")
+			continue
+		}
+		deleted++
+	}
+	return deleted
+}
+
+// StartMediaCleanup starts the background job that deletes unreferenced media assets. It runs
+// every MediaCleanupInterval until StopMediaCleanup is called.
+func (nm *NotificationManagerImpl) StartMediaCleanup() {
+	interval := nm.config.MediaCleanupInterval
+	if _, err := nm.objectStore(); err != nil || interval <= 0 || nm.config.MediaUnreferencedTTL <= 0 {
+		logrus.Debug("Media store or unreferenced media TTL is not set, media assets are kept until deleted")
+		return
+	}
+
+	var run func()
+	run = func() {
+		if deleted := nm.RunMediaCleanup(context.Background()); deleted > 0 {
+			logrus.WithField("deleted", deleted).Info("Deleted unreferenced media assets")
+		}
+
+		nm.media.cleanupMu.Lock()
+		defer nm.media.cleanupMu.Unlock()
+		if nm.media.cleanupTimer != nil {
+			nm.media.cleanupTimer = nm.clock.AfterFunc(interval, run)
+		}
+	}
+
+	nm.media.cleanupMu.Lock()
+	defer nm.media.cleanupMu.Unlock()
+	if nm.media.cleanupTimer != nil {
+		return
+	}
+	nm.media.cleanupTimer = nm.clock.AfterFunc(interval, run)
+	logrus.WithField("interval", interval).Info("Media cleanup started")
+}
+
+// StopMediaCleanup stops the media cleanup
+func (nm *NotificationManagerImpl) StopMediaCleanup() {
+	nm.media.cleanupMu.Lock()
+	defer nm.media.cleanupMu.Unlock()
+	if nm.media.cleanupTimer != nil {
+		nm.media.cleanupTimer.Stop()
+		nm.media.cleanupTimer = nil
+	}
+}
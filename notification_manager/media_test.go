@@ -0,0 +1,99 @@
+package notification_manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/external_services/objectstore"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pngContent starts with the PNG signature, which content type detection looks for
+var pngContent = append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 64)...)
+
+func newMediaTestManager(t *testing.T) (*NotificationManagerImpl, *objectstore.MemoryStore, *clock.Fake) {
+	nm, _, _ := newTestManager(t, 1, DefaultConfig())
+	fake := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	nm.SetClock(fake)
+	store := objectstore.NewMemoryStore("assets", "https://cdn.example.com")
+	nm.SetObjectStore(store)
+	return nm, store, fake
+}
+
+func TestUploadMedia(t *testing.T) {
+	nm, store, _ := newMediaTestManager(t)
+	ctx := context.Background()
+
+	uploaded, err := nm.UploadMedia(ctx, "acme", "../logo.png", "image/png", pngContent, "acme")
+	require.NoError(t, err)
+	asset := uploaded.(*models.MediaAsset)
+	assert.Equal(t, "logo.png", asset.Filename)
+	assert.Equal(t, "image/png", asset.ContentType)
+	assert.Equal(t, int64(len(pngContent)), asset.Size)
+	assert.Equal(t, "https://cdn.example.com/media/"+asset.ID+".png", asset.URL)
+	object, exists := store.Get(asset.Key)
+	require.True(t, exists)
+	assert.Equal(t, pngContent, object.Content)
+
+	// Assets belong to their tenant
+	_, err = nm.GetMedia("globex", asset.ID)
+	assert.ErrorIs(t, err, ErrMediaNotFound)
+	assert.Empty(t, nm.ListMedia("globex"))
+	assert.Len(t, nm.ListMedia("acme"), 1)
+
+	// Content types are detected from the content
+	_, err = nm.UploadMedia(ctx, "acme", "logo.svg", "image/svg+xml", []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`), "acme")
+	assert.ErrorIs(t, err, ErrInvalidMedia)
+	_, err = nm.UploadMedia(ctx, "acme", "logo.jpg", "image/jpeg", pngContent, "acme")
+	assert.ErrorIs(t, err, ErrInvalidMedia)
+	_, err = nm.UploadMedia(ctx, "acme", "logo.png", "", nil, "acme")
+	assert.ErrorIs(t, err, ErrInvalidMedia)
+
+	nm.config.MediaMaxUploadBytes = 16
+	_, err = nm.UploadMedia(ctx, "acme", "logo.png", "image/png", pngContent, "acme")
+	assert.ErrorIs(t, err, ErrMediaTooLarge)
+
+	nm.SetObjectStore(nil)
+	_, err = nm.UploadMedia(ctx, "acme", "logo.png", "image/png", pngContent, "acme")
+	assert.ErrorIs(t, err, ErrMediaStoreNotConfigured)
+}
+
+func TestDeleteMedia_KeepsReferencedAssets(t *testing.T) {
+	nm, store, fake := newMediaTestManager(t)
+	ctx := context.Background()
+
+	uploaded, err := nm.UploadMedia(ctx, "acme", "logo.png", "image/png", pngContent, "acme")
+	require.NoError(t, err)
+	referenced := uploaded.(*models.MediaAsset)
+	uploaded, err = nm.UploadMedia(ctx, "acme", "banner.png", "application/octet-stream", pngContent, "acme")
+	require.NoError(t, err)
+	unreferenced := uploaded.(*models.MediaAsset)
+
+	_, err = nm.CreateTemplate(&models.Template{
+		Name: "Newsletter",
+		Type: models.EmailNotification,
+		Content: models.TemplateContent{
+			Subject:   "News",
+			EmailBody: `<img src="` + referenced.URL + `" alt="Acme">`,
+		},
+	})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, nm.DeleteMedia(ctx, "acme", referenced.ID), ErrMediaInUse)
+	assert.ErrorIs(t, nm.DeleteMedia(ctx, "globex", unreferenced.ID), ErrMediaNotFound)
+
+	// The cleanup deletes unreferenced assets once they are older than the TTL
+	assert.Equal(t, 0, nm.RunMediaCleanup(ctx))
+	fake.Advance(nm.config.MediaUnreferencedTTL + time.Minute)
+	assert.Equal(t, 1, nm.RunMediaCleanup(ctx))
+	_, exists := store.Get(unreferenced.Key)
+	assert.False(t, exists)
+	_, err = nm.GetMedia("acme", unreferenced.ID)
+	assert.ErrorIs(t, err, ErrMediaNotFound)
+	_, exists = store.Get(referenced.Key)
+	assert.True(t, exists)
+}
@@ -0,0 +1,78 @@
+package notification_manager
+
+import (
+	"strings"
+
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// Notification manager metrics, labelled by tag with bounded cardinality
+var (
+	notificationRequestsTotal = metrics.DefaultRegistry.NewCounterVec(
+		"notification_requests_total",
+		"Notification requests by type, outcome and tag.",
+		"type", "status", "tag",
+	)
+	notificationMessagesQueuedTotal = metrics.DefaultRegistry.NewCounterVec(
+		"notification_messages_queued_total",
+		"Messages handed to the channel queues by notification type and tag.",
+		"type", "tag",
+	)
+)
+
+// recordRequestMetric counts a processed notification request once per tag
+func (nm *NotificationManagerImpl) recordRequestMetric(request *models.NotificationRequest, status string) {
+	for _, tag := range nm.tagLabelValues(request.Tags) {
+		notificationRequestsTotal.Inc(request.Type, status, tag)
+	}
+}
+
+// recordQueuedMetric counts queued messages once per tag
+func (nm *NotificationManagerImpl) recordQueuedMetric(request *models.NotificationRequest, queued int) {
+	if queued == 0 {
+		return
+	}
+	for _, tag := range nm.tagLabelValues(request.Tags) {
+		notificationMessagesQueuedTotal.Add(float64(queued), request.Type, tag)
+	}
+}
+
+// tagLabelValues maps tags to metrics label values, collapsing tags beyond the cardinality bound into "other"
+func (nm *NotificationManagerImpl) tagLabelValues(tags []string) []string {
+	if len(tags) == 0 || nm.tagLabels == nil {
+		return []string{metrics.LabelValueNone}
+	}
+
+	values := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		value := nm.tagLabels.Value(tag)
+		if !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// normalizeTags lower-cases and trims tags and drops empty and duplicate entries
+func normalizeTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	return normalized
+}
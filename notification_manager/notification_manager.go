@@ -1,17 +1,32 @@
 package notification_manager
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/gaurav2721/notification-service/bufferpool"
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/constants"
+	"github.com/gaurav2721/notification-service/external_services/delivery"
+	"github.com/gaurav2721/notification-service/external_services/events"
+	"github.com/gaurav2721/notification-service/external_services/gitrepo"
 	"github.com/gaurav2721/notification-service/external_services/kafka"
+	"github.com/gaurav2721/notification-service/external_services/notificationstore"
+	"github.com/gaurav2721/notification-service/external_services/objectstore"
 	"github.com/gaurav2721/notification-service/external_services/user"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/markdown"
+	"github.com/gaurav2721/notification-service/metrics"
 	"github.com/gaurav2721/notification-service/models"
 	"github.com/gaurav2721/notification-service/notification_manager/scheduler"
 	"github.com/gaurav2721/notification-service/notification_manager/templates"
-	"github.com/google/uuid"
+	"github.com/gaurav2721/notification-service/policy"
+	"github.com/gaurav2721/notification-service/textlimit"
 	"github.com/sirupsen/logrus"
 )
 
@@ -21,7 +36,30 @@ type NotificationManagerImpl struct {
 	kafkaService    kafka.KafkaService
 	scheduler       scheduler.Scheduler
 	templateManager templates.TemplateManager
-	storage         *InMemoryStorage
+	storage         NotificationStorage
+	deliveryService delivery.DeliveryService
+	idGenerator     IDGenerator
+	config          Config
+	tagLabels       *metrics.LabelLimiter
+	clock           clock.Clock
+	templateUsage   *templateUsageTracker
+	inbox           *inboxStore
+	preferences     *preferenceStore
+	digests         *digestTracker
+	engagement      *engagementStore
+	budgets         *budgetLedger
+	fingerprints    *fingerprintTracker
+	expiry          *expirySweeper
+	policies        *policy.Store
+	branding        *brandingStore
+	senders         *senderStore
+	media           *mediaLibrary
+	qrCodes         *qrCodeCache
+	templateReload  *templateReloader
+	templateGitSync *templateGitSync
+	queueArchive    *queueArchive
+	unreachable     *unreachableStore
+	background      *backgroundSends
 }
 
 // NewNotificationManagerWithDefaultTemplate creates a new notification manager with default template manager
@@ -29,14 +67,95 @@ type NotificationManagerImpl struct {
 func NewNotificationManagerWithDefaultTemplate(
 	userService user.UserService,
 	kafkaService kafka.KafkaService,
+	deliveryService delivery.DeliveryService,
 ) *NotificationManagerImpl {
-	return &NotificationManagerImpl{
+	config := LoadConfigFromEnv()
+
+	// Notifications are also written to the configured notification store, so their status
+	// survives restarts
+	var storage NotificationStorage = NewInMemoryStorage()
+	if store, err := notificationstore.New(notificationstore.LoadConfigFromEnv()); err != nil {
+		logrus.WithError(err).Error("Notification store unavailable, notifications are kept in memory only")
+	} else if store != nil {
+		storage = NewPersistentStorage(store)
+	}
+
+	nm := &NotificationManagerImpl{
 		userService:     userService,
 		kafkaService:    kafkaService,
 		scheduler:       scheduler.NewScheduler(),
 		templateManager: templates.NewTemplateManager(),
-		storage:         NewInMemoryStorage(),
+		storage:         storage,
+		deliveryService: deliveryService,
+		idGenerator:     UUIDGenerator{},
+		config:          config,
+		tagLabels:       metrics.NewLabelLimiter(config.MaxTagLabelValues),
+		clock:           clock.Real(),
+		templateUsage:   newTemplateUsageTracker(),
+		inbox:           newInboxStore(config.InboxMaxStoredItems),
+		preferences:     newPreferenceStore(),
+		digests:         newDigestTracker(),
+		engagement:      newEngagementStore(),
+		budgets:         newBudgetLedger(),
+		fingerprints:    newFingerprintTracker(),
+		expiry:          &expirySweeper{},
+		policies:        policy.NewStore(),
+		branding:        newBrandingStore(),
+		senders:         newSenderStore(),
+		media:           newMediaLibrary(nil),
+		qrCodes:         newQRCodeCache(),
+		templateReload:  &templateReloader{},
+		templateGitSync: &templateGitSync{},
+		queueArchive:    &queueArchive{},
+		unreachable:     newUnreachableStore(),
+		background:      newBackgroundSends(),
+	}
+
+	// Notifications held for recipients without a contact point are retried when they get one
+	if userService != nil {
+		userService.OnContactChange(nm.retryUnreachable)
+	}
+
+	// Media assets are uploaded to the configured media store; without one uploads are rejected
+	if store, err := objectstore.New(objectstore.LoadConfigFromEnv()); err != nil {
+		logrus.WithError(err).Error("Invalid media store configuration, media uploads are disabled")
+	} else if store != nil {
+		nm.SetObjectStore(store)
+	}
+
+	// Templates kept in a Git repository are synced into the template manager
+	if repository, err := gitrepo.New(gitrepo.LoadConfigFromEnv()); err != nil {
+		logrus.WithError(err).Error("Invalid template repository configuration, templates are not synced from git")
+	} else if repository != nil {
+		nm.SetTemplateRepository(repository)
+	}
+
+	// Analytics pipelines consume the status changes from the notification-events topic
+	if config.LifecycleEvents {
+		nm.SetEventPublisher(events.NewPublisher(kafkaService.GetNotificationEventsChannel()))
+	}
+
+	return nm
+}
+
+// SetIDGenerator replaces the generator used for new notification IDs
+func (nm *NotificationManagerImpl) SetIDGenerator(generator IDGenerator) {
+	if generator == nil {
+		generator = UUIDGenerator{}
+	}
+	nm.idGenerator = generator
+}
+
+// SetClock replaces the clock used for scheduling, storage timestamps and progress reporting.
+// It replaces the scheduler, so it must be called before any notification is scheduled.
+func (nm *NotificationManagerImpl) SetClock(c clock.Clock) {
+	if c == nil {
+		c = clock.Real()
 	}
+	nm.clock = c
+	nm.scheduler = scheduler.NewSchedulerWithClock(c)
+	nm.storage.SetClock(c)
+	nm.templateManager.SetClock(c)
 }
 
 // ScheduleNotification schedules a notification for future delivery
@@ -67,7 +186,7 @@ func (nm *NotificationManagerImpl) ScheduleNotification(notificationId string, n
 	}
 
 	// Schedule the job using the scheduler
-	err := nm.scheduler.ScheduleJob(notificationId, *notification.ScheduledAt, schedulerJob)
+	err := nm.scheduler.ScheduleJob(notificationId, nm.scheduledRunTime(*notification.ScheduledAt), schedulerJob)
 	if err != nil {
 		logrus.WithError(err).WithField("notification_id", notificationId).Error("Failed to schedule notification job")
 		return err
@@ -86,12 +205,176 @@ func (nm *NotificationManagerImpl) GetNotificationStatus(notificationID string)
 		return nil, err
 	}
 
+	response := &struct {
+		ID         string                   `json:"id"`
+		ExternalID string                   `json:"external_id,omitempty"`
+		Status     string                   `json:"status"`
+		Reason     string                   `json:"reason,omitempty"`
+		Progress   *ProgressReport          `json:"progress,omitempty"`
+		Engagement *models.EngagementStats  `json:"engagement,omitempty"`
+		Audit      []NotificationAuditEntry `json:"audit,omitempty"`
+	}{
+		ID:         record.ID,
+		ExternalID: record.ExternalID,
+		Status:     string(record.Status),
+		Reason:     record.Reason,
+		Audit:      record.Audit,
+	}
+
+	// In-app and push notifications report how many recipients saw and opened them
+	if _, ok := engagementChannel(record.Type, ""); ok {
+		engagement := nm.engagement.Stats(notificationID)
+		computeOpenRate(&engagement)
+		response.Engagement = &engagement
+	}
+
+	// Attach fan-out and delivery progress once recipients started being processed
+	if progress, err := nm.storage.GetProgress(notificationID); err == nil {
+		var stats models.DeliveryStats
+		if nm.deliveryService != nil {
+			stats = nm.deliveryService.GetStats(notificationID)
+		}
+		response.Progress = buildProgressReport(progress, stats, nm.clock.Now())
+	}
+
+	return response, nil
+}
+
+// GetDeliveryAttempts retrieves the archived provider responses of a notification for a single recipient
+func (nm *NotificationManagerImpl) GetDeliveryAttempts(notificationID string, recipient string) (interface{}, error) {
+	if _, err := nm.storage.GetNotification(notificationID); err != nil {
+		logrus.WithError(err).WithField("notification_id", notificationID).Debug("Notification not found in storage")
+		return nil, ErrNotificationNotFound
+	}
+
+	if nm.deliveryService == nil {
+		return nil, fmt.Errorf("deliveryService is not available")
+	}
+
+	attempts, err := nm.deliveryService.GetAttempts(notificationID, recipient)
+	if err != nil {
+		return nil, err
+	}
+
 	return &struct {
-		ID     string `json:"id"`
-		Status string `json:"status"`
+		NotificationID string                    `json:"notification_id"`
+		Recipient      string                    `json:"recipient"`
+		Attempts       []*models.DeliveryAttempt `json:"attempts"`
+		Count          int                       `json:"count"`
 	}{
-		ID:     record.ID,
-		Status: string(record.Status),
+		NotificationID: notificationID,
+		Recipient:      recipient,
+		Attempts:       attempts,
+		Count:          len(attempts),
+	}, nil
+}
+
+// notificationSummary is the list representation of a stored notification
+type notificationSummary struct {
+	ID         string     `json:"id"`
+	ExternalID string     `json:"external_id,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	Category   string     `json:"category,omitempty"`
+	Type       string     `json:"type"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	SentAt     *time.Time `json:"sent_at,omitempty"`
+}
+
+// ListNotifications retrieves the notifications matching the filter, oldest first
+func (nm *NotificationManagerImpl) ListNotifications(filter NotificationFilter) (interface{}, error) {
+	filter.Tags = normalizeTags(filter.Tags)
+	records := nm.storage.FindNotifications(filter)
+
+	notifications := make([]notificationSummary, 0, len(records))
+	for _, record := range records {
+		notifications = append(notifications, notificationSummary{
+			ID:         record.ID,
+			ExternalID: record.ExternalID,
+			Tags:       record.Tags,
+			Category:   record.Category,
+			Type:       record.Type,
+			Status:     string(record.Status),
+			CreatedAt:  record.CreatedAt,
+			SentAt:     record.SentAt,
+		})
+	}
+
+	return &struct {
+		ExternalID    string                `json:"external_id,omitempty"`
+		Notifications []notificationSummary `json:"notifications"`
+		Count         int                   `json:"count"`
+	}{
+		ExternalID:    filter.ExternalID,
+		Notifications: notifications,
+		Count:         len(notifications),
+	}, nil
+}
+
+// GetNotificationAnalytics aggregates the notifications matching the filter by status, type, category and tag
+// together with their delivery outcomes, engagement and estimated cost
+func (nm *NotificationManagerImpl) GetNotificationAnalytics(filter NotificationFilter) (interface{}, error) {
+	filter.Tags = normalizeTags(filter.Tags)
+	records := nm.storage.FindNotifications(filter)
+
+	byStatus := make(map[string]int)
+	byType := make(map[string]int)
+	byCategory := make(map[string]int)
+	byTag := make(map[string]int)
+	var deliveries models.DeliveryStats
+	var engagement models.EngagementStats
+	cost := newCostReport(nm.config.CostCurrency)
+	latency := newLatencyReport()
+	recipients := 0
+
+	for _, record := range records {
+		byStatus[string(record.Status)]++
+		byType[record.Type]++
+		if record.Category != "" {
+			byCategory[record.Category]++
+		}
+		for _, tag := range record.Tags {
+			byTag[tag]++
+		}
+		recipients += len(record.Recipients)
+
+		if nm.deliveryService != nil {
+			stats := nm.deliveryService.GetStats(record.ID)
+			deliveries.Sent += stats.Sent
+			deliveries.Failed += stats.Failed
+			cost.add(record, stats.Sent, nm.config.UnitCosts)
+			latency.add(nm.deliveryService.GetLatency(record.ID))
+		}
+
+		stats := nm.engagement.Stats(record.ID)
+		engagement.Displayed += stats.Displayed
+		engagement.Opened += stats.Opened
+	}
+	computeOpenRate(&engagement)
+	cost.round()
+
+	return &struct {
+		Total      int                      `json:"total"`
+		Recipients int                      `json:"recipients"`
+		ByStatus   map[string]int           `json:"by_status"`
+		ByType     map[string]int           `json:"by_type"`
+		ByCategory map[string]int           `json:"by_category"`
+		ByTag      map[string]int           `json:"by_tag"`
+		Deliveries models.DeliveryStats     `json:"deliveries"`
+		Engagement models.EngagementStats   `json:"engagement"`
+		Cost       *CostReport              `json:"cost"`
+		Latency    []models.DeliveryLatency `json:"latency"`
+	}{
+		Total:      len(records),
+		Recipients: recipients,
+		ByStatus:   byStatus,
+		ByType:     byType,
+		ByCategory: byCategory,
+		ByTag:      byTag,
+		Deliveries: deliveries,
+		Engagement: engagement,
+		Cost:       cost,
+		Latency:    latency.snapshots(),
 	}, nil
 }
 
@@ -131,6 +414,8 @@ func (nm *NotificationManagerImpl) SetNotificationStatus(notificationId string,
 		notificationStatus = StatusFailed
 	case "cancelled":
 		notificationStatus = StatusCancelled
+	case "expired":
+		notificationStatus = StatusExpired
 	default:
 		return fmt.Errorf("invalid status: %s", status)
 	}
@@ -171,6 +456,33 @@ func (nm *NotificationManagerImpl) CreateTemplate(template *models.Template) (in
 	return nm.templateManager.CreateTemplate(template)
 }
 
+// UpdateTemplate stores a new version of a notification template on behalf of actor
+func (nm *NotificationManagerImpl) UpdateTemplate(templateID string, template *models.Template, actor string) (interface{}, error) {
+	response, err := nm.templateManager.UpdateTemplate(templateID, template, actor)
+	if errors.Is(err, models.ErrTemplateNotFound) {
+		return nil, ErrTemplateNotFound
+	}
+	return response, err
+}
+
+// GetTemplateAudit returns the change log of a notification template, oldest entry first
+func (nm *NotificationManagerImpl) GetTemplateAudit(templateID string) (interface{}, error) {
+	entries, err := nm.templateManager.GetTemplateAudit(templateID)
+	if err != nil {
+		return nil, ErrTemplateNotFound
+	}
+
+	return &struct {
+		TemplateID string                      `json:"template_id"`
+		Entries    []models.TemplateAuditEntry `json:"entries"`
+		Count      int                         `json:"count"`
+	}{
+		TemplateID: templateID,
+		Entries:    entries,
+		Count:      len(entries),
+	}, nil
+}
+
 // GetTemplateVersion retrieves a specific version of a notification template
 func (nm *NotificationManagerImpl) GetTemplateVersion(templateID string, version int) (interface{}, error) {
 	return nm.templateManager.GetTemplateVersion(templateID, version)
@@ -181,20 +493,149 @@ func (nm *NotificationManagerImpl) GetPredefinedTemplates() []*models.Template {
 	return nm.templateManager.GetPredefinedTemplates()
 }
 
+// ListTemplates returns all predefined and custom templates sorted by name
+func (nm *NotificationManagerImpl) ListTemplates() []*models.Template {
+	return nm.templateManager.ListTemplates()
+}
+
+// ExportTemplates returns every template with all its versions
+func (nm *NotificationManagerImpl) ExportTemplates() *models.TemplateBundle {
+	return nm.templateManager.ExportTemplates()
+}
+
+// ImportTemplates stores the templates of an exported bundle on behalf of actor
+func (nm *NotificationManagerImpl) ImportTemplates(bundle *models.TemplateBundle, mode models.TemplateConflictMode, actor string) (interface{}, error) {
+	results, err := nm.templateManager.ImportTemplates(bundle, mode, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"templates": len(results),
+		"mode":      mode,
+		"actor":     actor,
+	}).Info("Templates imported")
+
+	return &struct {
+		Templates []models.TemplateImportResult `json:"templates"`
+		Count     int                           `json:"count"`
+	}{
+		Templates: results,
+		Count:     len(results),
+	}, nil
+}
+
+// RenderTemplate renders a template version with the given data and the branding of tenant without sending anything
+func (nm *NotificationManagerImpl) RenderTemplate(templateID string, version int, data map[string]interface{}, tenant string) (interface{}, error) {
+	templateObj, err := nm.templateManager.GetTemplateByIDAndVersion(templateID, version)
+	if err != nil {
+		return nil, ErrTemplateNotFound
+	}
+
+	if err := templateObj.ValidateRequiredVariables(data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMissingRequiredVariable, err)
+	}
+
+	content, err := nm.renderTemplateContent(templateObj, nm.withBranding(data, tenant))
+	if err != nil {
+		return nil, err
+	}
+
+	return &struct {
+		TemplateID string                 `json:"template_id"`
+		Version    int                    `json:"version"`
+		Type       string                 `json:"type"`
+		Content    map[string]interface{} `json:"content"`
+	}{
+		TemplateID: templateObj.ID,
+		Version:    version,
+		Type:       string(templateObj.Type),
+		Content:    content,
+	}, nil
+}
+
 // ProcessNotificationRequest handles the complete notification request processing
 func (nm *NotificationManagerImpl) ProcessNotificationRequest(request *models.NotificationRequest) (interface{}, error) {
+	return nm.ProcessNotificationRequestWithContext(context.Background(), request)
+}
+
+// ProcessNotificationRequestWithContext processes a notification request until ctx is done.
+// Template rendering and recipient lookups stop once the deadline of an API request passed;
+// scheduled and background sends outlive the request and are not affected.
+func (nm *NotificationManagerImpl) ProcessNotificationRequestWithContext(ctx context.Context, request *models.NotificationRequest) (interface{}, error) {
+	request.Tags = normalizeTags(request.Tags)
+
+	// Checked here rather than only by the API so every way of sending is covered
+	if request.Transactional && !nm.isTrusted(request.Tenant) {
+		nm.recordRequestMetric(request, string(StatusFailed))
+		return nil, ErrTransactionalNotAllowed
+	}
+
+	if err := nm.applySender(request); err != nil {
+		nm.recordRequestMetric(request, string(StatusFailed))
+		return nil, err
+	}
+
+	duplicateOf, completeDuplicateCheck, err := nm.checkDuplicate(request)
+	if err != nil {
+		nm.recordRequestMetric(request, string(StatusFailed))
+		return nil, err
+	}
+
+	refund, err := nm.chargeBudgets(request)
+	if err != nil {
+		completeDuplicateCheck("")
+		nm.recordRequestMetric(request, string(StatusFailed))
+		return nil, err
+	}
+
+	response, err := nm.processNotificationRequest(ctx, request)
+	if err != nil {
+		refund()
+		completeDuplicateCheck("")
+		nm.recordRequestMetric(request, string(StatusFailed))
+		return nil, err
+	}
+	completeDuplicateCheck(response["id"].(string))
+	if duplicateOf != "" {
+		response["duplicate_of"] = duplicateOf
+	}
+
+	nm.recordRequestMetric(request, response["status"].(string))
+	if request.Template != nil {
+		nm.templateUsage.RecordSend(request.Template.ID, request.Template.Version, nm.clock.Now())
+	}
+	return response, nil
+}
+
+// processNotificationRequest generates the notification ID, renders the template and either
+// schedules the notification or fans it out to the recipients
+func (nm *NotificationManagerImpl) processNotificationRequest(ctx context.Context, request *models.NotificationRequest) (map[string]interface{}, error) {
 	logrus.Debug("Processing notification request")
 
 	// Generate notification ID
 	notificationID := nm.generateID()
+	category, err := nm.resolveCategory(request)
+	if err != nil {
+		return nil, err
+	}
+	request.Category = category
+
+	// The channel content block of the notification type is the content when none is given
+	if len(request.Content) == 0 && request.Template == nil {
+		request.Content = request.ContentFor(request.Type)
+	}
 
 	// Process template if provided and generate content
 	if request.Template != nil {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrRequestAborted, err)
+		}
 		logrus.Debug("Processing template to generate content")
-		generatedContent, err := nm.processTemplateToContent(request.Template, request.Type)
+		generatedContent, err := nm.processTemplateToContent(request.Template, request.Type, request.Recipients, request.Tenant)
 		if err != nil {
 			logrus.WithError(err).Error("Failed to process template")
-			return nil, fmt.Errorf("template processing failed: %v", err)
+			return nil, fmt.Errorf("template processing failed: %w", err)
 		}
 
 		// Replace or merge the content with generated content
@@ -208,55 +649,92 @@ func (nm *NotificationManagerImpl) ProcessNotificationRequest(request *models.No
 		}
 
 		logrus.WithField("generated_content", generatedContent).Debug("Template content generated and merged")
+
+		// Rendered variables can push the content past provider limits, so check again before enqueueing
+		if violations := textlimit.CheckContent(request.Type, request.Content); len(violations) > 0 {
+			return nil, fmt.Errorf("%w: %s: %s", ErrContentLimitExceeded, violations[0].Field, violations[0].Message)
+		}
 	}
 
 	// Check if it's a scheduled notification
 	if request.ScheduledAt != nil {
 		logrus.Debug("Processing scheduled notification")
 
+		// The notification is scheduled before its job, which runs right away when its time has passed
+		if err := nm.SetNotificationStatus(notificationID, request, "scheduled"); err != nil {
+			logrus.WithError(err).WithField("notification_id", notificationID).Warn("Failed to set notification status to scheduled")
+		}
+
 		// Schedule notification with a job function
 		err := nm.ScheduleNotification(notificationID, request, func() error {
 			// This job will be executed at the scheduled time
 			logrus.WithField("notification_id", notificationID).Info("Executing scheduled notification job")
 
+			// A job running long after its time, e.g. because the service was paused, is not sent
+			if nm.expireIfStale(notificationID, request) {
+				return nil
+			}
+
+			// The job takes the notification, so it can no longer be cancelled while it is sent. A
+			// notification cancelled or expired while its job was starting is not sent.
+			queued, err := nm.storage.QueueScheduled(notificationID)
+			if err != nil {
+				return err
+			}
+			if !queued {
+				logrus.WithField("notification_id", notificationID).Info("Scheduled notification is no longer scheduled, skipping it")
+				return nil
+			}
+
+			// Notifications of the same batching window may be sent together
+			request.BatchKey = nm.scheduledBatchKey(*request.ScheduledAt)
+
 			// Process notification for recipients
-			_, err := nm.processNotificationForRecipients(request, notificationID)
+			_, err = nm.processNotificationForRecipients(context.Background(), request, notificationID)
 			if err != nil {
 				logrus.WithError(err).Error("Failed to process notification for recipients")
 				// Set status to failed if processing fails
-				if statusErr := nm.SetNotificationStatus(notificationID, request, "failed"); statusErr != nil {
-					logrus.WithError(statusErr).WithField("notification_id", notificationID).Warn("Failed to set notification status to failed")
-				}
+				nm.finishQueued(notificationID, StatusFailed, err.Error())
 				return err
 			}
 
 			// Set notification status to sent after successful processing
-			if err := nm.SetNotificationStatus(notificationID, request, "sent"); err != nil {
-				logrus.WithError(err).WithField("notification_id", notificationID).Warn("Failed to set notification status to sent")
-			}
+			nm.finishQueued(notificationID, StatusSent, "")
 
 			return nil
 		})
 
 		if err != nil {
 			logrus.WithError(err).Error("Failed to schedule notification")
+			if statusErr := nm.SetNotificationStatus(notificationID, request, "failed"); statusErr != nil {
+				logrus.WithError(statusErr).WithField("notification_id", notificationID).Warn("Failed to set notification status to failed")
+			}
 			return nil, err
 		}
 
-		// Set notification status to scheduled
-		if err := nm.SetNotificationStatus(notificationID, request, "scheduled"); err != nil {
-			logrus.WithError(err).WithField("notification_id", notificationID).Warn("Failed to set notification status to scheduled")
+		logrus.WithField("notification_id", notificationID).Debug("Notification scheduled successfully")
+		return nm.acceptedResponse(notificationID, request, "scheduled"), nil
+	}
+
+	// Large sends, and sends of clients that asked for it, are accepted right away and fanned out
+	// in the background
+	if nm.fansOutInBackground(request) {
+		if err := nm.SetNotificationStatus(notificationID, request, "queued"); err != nil {
+			logrus.WithError(err).WithField("notification_id", notificationID).Error("Failed to queue notification")
+			return nil, err
 		}
 
-		logrus.WithField("notification_id", notificationID).Debug("Notification scheduled successfully")
-		return map[string]interface{}{
-			"id":     notificationID,
-			"status": "scheduled",
-		}, nil
+		nm.sendInBackground(request, notificationID)
+
+		logrus.WithFields(logrus.Fields{
+			"notification_id":  notificationID,
+			"total_recipients": len(request.Recipients),
+		}).Info("Large notification accepted for background processing")
+		return nm.acceptedResponse(notificationID, request, "queued"), nil
 	}
 
 	// Process notification for recipients
-	responses, err := nm.processNotificationForRecipients(request, notificationID)
+	queued, err := nm.processNotificationForRecipients(ctx, request, notificationID)
 	if err != nil {
 		logrus.WithError(err).Error("Failed to process notification for recipients")
 		// Set notification status to failed
@@ -269,7 +747,7 @@ func (nm *NotificationManagerImpl) ProcessNotificationRequest(request *models.No
 	logrus.WithFields(logrus.Fields{
 		"notification_id":  notificationID,
 		"total_recipients": len(request.Recipients),
-		"queued_count":     len(responses),
+		"queued_count":     queued,
 	}).Debug("Notification processing completed")
 
 	// Set notification status to sent
@@ -278,14 +756,25 @@ func (nm *NotificationManagerImpl) ProcessNotificationRequest(request *models.No
 	}
 
 	// Return aggregated response
-	return map[string]interface{}{
+	return nm.acceptedResponse(notificationID, request, "sent"), nil
+}
+
+// acceptedResponse builds the response returned when a notification request is accepted
+func (nm *NotificationManagerImpl) acceptedResponse(notificationID string, request *models.NotificationRequest, status string) map[string]interface{} {
+	response := map[string]interface{}{
 		"id":     notificationID,
-		"status": "sent",
-	}, nil
+		"status": status,
+	}
+	if request.ExternalID != "" {
+		response["external_id"] = request.ExternalID
+	}
+	return response
 }
 
-// processTemplateToContent processes a template and returns the generated content
-func (nm *NotificationManagerImpl) processTemplateToContent(template *models.TemplateData, notificationType string) (map[string]interface{}, error) {
+// processTemplateToContent processes a template and returns the content generated from the shared data,
+// in the tenant's default locale for localized templates.
+// Required variables may be left out of the shared data if every recipient overrides them.
+func (nm *NotificationManagerImpl) processTemplateToContent(template *models.TemplateData, notificationType string, recipients []string, tenant string) (map[string]interface{}, error) {
 	if template == nil {
 		return nil, fmt.Errorf("template cannot be nil")
 	}
@@ -301,32 +790,93 @@ func (nm *NotificationManagerImpl) processTemplateToContent(template *models.Tem
 		return nil, fmt.Errorf("template type %s does not match notification type %s", templateObj.Type, notificationType)
 	}
 
+	// Localized versions are only sent once they are activated
+	if templateObj.Status == models.TemplateStatusDraft {
+		return nil, fmt.Errorf("%w: template %s version %d", models.ErrTemplateNotActive, template.ID, template.Version)
+	}
+
 	// Validate required variables
 	if err := templateObj.ValidateRequiredVariables(template.Data); err != nil {
-		return nil, fmt.Errorf("template validation failed: %v", err)
+		if len(template.RecipientData) == 0 {
+			return nil, fmt.Errorf("template validation failed: %v", err)
+		}
+		for _, recipient := range recipients {
+			if err := templateObj.ValidateRequiredVariables(template.DataFor(recipient)); err != nil {
+				return nil, fmt.Errorf("template validation failed for recipient %s: %v", recipient, err)
+			}
+		}
 	}
 
+	content, err := nm.renderTemplateContent(nm.localizeTemplate(templateObj, tenant, ""), nm.withBranding(template.Data, tenant))
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"template_id": template.ID,
+		"type":        notificationType,
+		"content":     content,
+	}).Debug("Template processed successfully")
+
+	return content, nil
+}
+
+// renderTemplateContent fills the template content for its notification type with the given data
+func (nm *NotificationManagerImpl) renderTemplateContent(templateObj *models.Template, data map[string]interface{}) (map[string]interface{}, error) {
+	notificationType := string(templateObj.Type)
+
 	// Process template content based on type
 	content := make(map[string]interface{})
 
 	switch notificationType {
 	case "email":
-		// Process email template
-		subject := nm.processTemplateString(templateObj.Content.Subject, template.Data)
-		emailBody := nm.processTemplateString(templateObj.Content.EmailBody, template.Data)
+		// Process email template. Text bodies are sent as HTML, Markdown bodies are converted to it.
+		bodyEscaper := escapeHTML
+		if templateObj.RenderMode == models.EmailRenderModeMarkdown {
+			bodyEscaper = markdown.Escape
+		}
+		subject := nm.renderTemplateString(templateObj.Content.Subject, data, escapeHeader)
+		emailBody := nm.renderTemplateStringWithImages(templateObj.Content.EmailBody, data, bodyEscaper, templateObj.RenderMode != models.EmailRenderModeMarkdown)
 
 		content["subject"] = subject
 		content["email_body"] = emailBody
+		if templateObj.Content.EmailTextBody != "" {
+			// The plain text alternative is not interpreted, so values are inserted as they are
+			content["text_body"] = nm.processTemplateString(templateObj.Content.EmailTextBody, data)
+		}
+		if templateObj.RenderMode == models.EmailRenderModeMarkdown {
+			content["render_mode"] = models.EmailRenderModeMarkdown
+		}
+		if templateObj.ColorScheme != "" {
+			content["color_scheme"] = templateObj.ColorScheme
+		}
+		if templateObj.Content.EmailDarkModeCSS != "" {
+			// Styles are not templated
+			content["dark_mode_css"] = templateObj.Content.EmailDarkModeCSS
+		}
 
 	case "slack":
 		// Process slack template
-		text := nm.processTemplateString(templateObj.Content.Text, template.Data)
+		text := nm.renderTemplateString(templateObj.Content.Text, data, escapeSlack)
 		content["text"] = text
 
+	case "google_chat":
+		// Message text and card titles are shown as they are, section text is formatted as HTML
+		if templateObj.Content.Text != "" {
+			content["text"] = nm.processTemplateString(templateObj.Content.Text, data)
+		}
+		if templateObj.Content.Card != nil {
+			card, err := nm.renderGoogleChatCard(templateObj.Content.Card, data)
+			if err != nil {
+				return nil, err
+			}
+			content["card"] = card
+		}
+
 	case "in_app":
 		// Process in-app template
-		title := nm.processTemplateString(templateObj.Content.Title, template.Data)
-		body := nm.processTemplateString(templateObj.Content.Body, template.Data)
+		title := nm.processTemplateString(templateObj.Content.Title, data)
+		body := nm.processTemplateString(templateObj.Content.Body, data)
 
 		content["title"] = title
 		content["body"] = body
@@ -335,259 +885,563 @@ func (nm *NotificationManagerImpl) processTemplateToContent(template *models.Tem
 		return nil, fmt.Errorf("unsupported notification type: %s", notificationType)
 	}
 
-	logrus.WithFields(logrus.Fields{
-		"template_id": template.ID,
-		"type":        notificationType,
-		"content":     content,
-	}).Debug("Template processed successfully")
+	nm.templateUsage.RecordRender(templateObj.ID, templateObj.Version, nm.clock.Now())
+	return content, nil
+}
+
+// renderGoogleChatCard fills a Google Chat card template with the given data. The card is
+// returned in the form of request content, so rendered and requested cards are sent alike.
+func (nm *NotificationManagerImpl) renderGoogleChatCard(templateCard *models.GoogleChatCard, data map[string]interface{}) (map[string]interface{}, error) {
+	card := models.GoogleChatCard{
+		Title:    nm.processTemplateString(templateCard.Title, data),
+		Subtitle: nm.processTemplateString(templateCard.Subtitle, data),
+		ImageURL: nm.processTemplateString(templateCard.ImageURL, data),
+	}
+	for _, section := range templateCard.Sections {
+		card.Sections = append(card.Sections, models.GoogleChatCardSection{
+			Header: nm.processTemplateString(section.Header, data),
+			Text:   nm.renderTemplateString(section.Text, data, escapeHTML),
+		})
+	}
+	for _, button := range templateCard.Buttons {
+		card.Buttons = append(card.Buttons, models.GoogleChatCardButton{
+			Text: nm.processTemplateString(button.Text, data),
+			URL:  nm.processTemplateString(button.URL, data),
+		})
+	}
 
+	encoded, err := json.Marshal(card)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render google chat card: %w", err)
+	}
+	var content map[string]interface{}
+	if err := json.Unmarshal(encoded, &content); err != nil {
+		return nil, fmt.Errorf("failed to render google chat card: %w", err)
+	}
 	return content, nil
 }
 
-// processTemplateString replaces template variables with actual values
+// processTemplateString replaces template variables with actual values, inserting them as they
+// are. Content that is interpreted by the channel is rendered with renderTemplateString instead.
 func (nm *NotificationManagerImpl) processTemplateString(templateStr string, data map[string]interface{}) string {
-	result := templateStr
+	return nm.renderTemplateString(templateStr, data, nil)
+}
+
+// renderTemplateString replaces template variables with actual values escaped by escape.
+// Variables in triple braces, {{{name}}}, are trusted and inserted without escaping.
+// The template is scanned once into a pre-sized builder instead of running one
+// ReplaceAll pass per variable; placeholders without data are left untouched.
+func (nm *NotificationManagerImpl) renderTemplateString(templateStr string, data map[string]interface{}, escape templateEscaper) string {
+	return nm.renderTemplateStringWithImages(templateStr, data, escape, false)
+}
 
-	// Replace variables in the format {{variable_name}}
-	for key, value := range data {
-		placeholder := fmt.Sprintf("{{%s}}", key)
-		stringValue := fmt.Sprintf("%v", value)
-		result = strings.ReplaceAll(result, placeholder, stringValue)
+// renderTemplateStringWithImages renders like renderTemplateString; with embedImages, helpers
+// that produce images, such as {{qrcode url}}, insert an HTML image instead of its URL
+func (nm *NotificationManagerImpl) renderTemplateStringWithImages(templateStr string, data map[string]interface{}, escape templateEscaper, embedImages bool) string {
+	if !strings.Contains(templateStr, "{{") || (len(data) == 0 && !strings.Contains(templateStr, "{{"+qrCodeHelper+" ")) {
+		return templateStr
 	}
 
-	return result
+	var builder strings.Builder
+	builder.Grow(len(templateStr) + len(templateStr)/2)
+
+	rest := templateStr
+	for {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			break
+		}
+
+		end := strings.Index(rest[start+2:], "}}")
+		if end < 0 {
+			break
+		}
+		end += start + 2
+
+		// Replace variables in the format {{variable_name}} or {{{variable_name}}}
+		nameStart, placeholderEnd := start+2, end+2
+		raw := strings.HasPrefix(rest[start:], "{{{") && strings.HasPrefix(rest[end:], "}}}")
+		if raw {
+			nameStart, placeholderEnd = start+3, end+3
+		}
+
+		builder.WriteString(rest[:start])
+
+		name := rest[nameStart:end]
+		if helper, argument, isHelper := strings.Cut(name, " "); isHelper && helper == qrCodeHelper {
+			if text, ok := qrCodeArgument(argument, data); ok && raw {
+				builder.WriteString(nm.renderQRCode(text, nil, embedImages))
+			} else if ok {
+				builder.WriteString(nm.renderQRCode(text, escape, embedImages))
+			} else {
+				builder.WriteString(rest[start:placeholderEnd])
+			}
+		} else if value, ok := data[name]; ok {
+			if raw || escape == nil {
+				writeTemplateValue(&builder, value)
+			} else {
+				var formatted strings.Builder
+				writeTemplateValue(&formatted, value)
+				builder.WriteString(escape(formatted.String()))
+			}
+		} else {
+			builder.WriteString(rest[start:placeholderEnd])
+		}
+
+		rest = rest[placeholderEnd:]
+	}
+	builder.WriteString(rest)
+
+	return builder.String()
 }
 
-// processNotificationForRecipients processes notifications for all recipients
-func (nm *NotificationManagerImpl) processNotificationForRecipients(request *models.NotificationRequest, notificationID string) ([]interface{}, error) {
+// writeTemplateValue writes a template variable value, avoiding fmt for the common string case
+func writeTemplateValue(builder *strings.Builder, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		builder.WriteString(v)
+	case int:
+		builder.WriteString(strconv.Itoa(v))
+	case bool:
+		builder.WriteString(strconv.FormatBool(v))
+	default:
+		fmt.Fprintf(builder, "%v", v)
+	}
+}
+
+// processNotificationForRecipients streams the recipients through the user service in batches
+// and enqueues messages as each batch is resolved, so very large sends never hold every user
+// or per-message response in memory at once. Progress counters are kept in storage per batch.
+// It returns the number of messages queued.
+func (nm *NotificationManagerImpl) processNotificationForRecipients(ctx context.Context, request *models.NotificationRequest, notificationID string) (int, error) {
 	// Get recipient information from userService
-	logrus.Debug("Fetching recipient information from user service")
+	logrus.Debug("Streaming recipient information from user service")
 
 	// Check if userService is available
 	if nm.userService == nil {
-		return nil, fmt.Errorf("userService is not available")
+		return 0, fmt.Errorf("userService is not available")
 	}
 
-	users, err := nm.userService.GetUsersByIDs(request.Recipients)
-	if err != nil {
-		logrus.WithError(err).Error("Failed to get recipient information")
-		return nil, fmt.Errorf("failed to get recipient information: %v", err)
+	// Make sure the record exists so progress can be tracked while batches are processed
+	if _, err := nm.storage.GetNotification(notificationID); err != nil {
+		if err := nm.storage.StoreNotification(notificationID, request); err != nil {
+			return 0, err
+		}
 	}
-
-	logrus.WithField("valid_users", len(users)).Debug("Retrieved user information")
-
-	if len(users) == 0 {
-		logrus.Warn("No valid recipients found for notification")
-		return nil, fmt.Errorf("no valid recipients found")
+	if err := nm.storage.StartProgress(notificationID, len(request.Recipients)); err != nil {
+		return 0, err
 	}
 
-	// Process notifications based on type and fetch relevant user information
-	var responses []interface{}
+	batchSize := nm.config.RecipientBatchSize
+	if batchSize <= 0 {
+		batchSize = constants.DefaultRecipientBatchSize
+	}
+	enqueueTimeout := nm.enqueueTimeoutFor(request)
 
 	logrus.WithFields(logrus.Fields{
 		"notification_id":   notificationID,
-		"recipient_count":   len(users),
+		"recipient_count":   len(request.Recipients),
+		"batch_size":        batchSize,
 		"notification_type": request.Type,
 	}).Debug("Processing notification for recipients")
 
-	for _, user := range users {
-		logrus.WithFields(logrus.Fields{
-			"user_id": user.ID,
-			"email":   user.Email,
-		}).Debug("Processing notification for user")
+	resolved, queued := 0, 0
+	for start := 0; start < len(request.Recipients); start += batchSize {
+		end := start + batchSize
+		if end > len(request.Recipients) {
+			end = len(request.Recipients)
+		}
+		batch := request.Recipients[start:end]
 
-		// Get detailed user notification info based on notification type
-		userNotificationInfo, err := nm.userService.GetUserNotificationInfo(user.ID)
-		if err != nil {
-			logrus.WithFields(logrus.Fields{
-				"user_id": user.ID,
-				"error":   err.Error(),
-			}).Error("Failed to get user notification info")
-			continue
+		// Stop before the next user service lookup once the request was abandoned
+		if err := ctx.Err(); err != nil {
+			return queued, fmt.Errorf("%w after %d of %d recipients: %w", ErrRequestAborted, start, len(request.Recipients), err)
 		}
 
-		// Process notification based on type
-		userResponses, err := nm.processNotificationByType(notificationID, *request, userNotificationInfo)
+		users, err := nm.resolveRecipients(batch)
 		if err != nil {
-			logrus.WithFields(logrus.Fields{
-				"user_id": user.ID,
-				"error":   err.Error(),
-			}).Error("Failed to process notification for user")
-			continue
+			logrus.WithError(err).Error("Failed to get recipient information")
+			return queued, fmt.Errorf("failed to get recipient information: %v", err)
+		}
+
+		progress := NotificationProgress{
+			Resolved: len(users),
+			Skipped:  len(batch) - len(users),
+		}
+
+		for _, userInfo := range users {
+			// Routing policies decide first, so preferences apply to the channel they route to
+			routed := nm.applyRoutingPolicies(request, userInfo)
+			if routed == nil {
+				progress.Suppressed++
+				continue
+			}
+			if nm.isSuppressed(routed, userInfo.ID) {
+				progress.Suppressed++
+				continue
+			}
+			// In-app notifications of muted threads still reach the inbox, see processNotificationByType
+			if routed.Type != "in_app" && nm.isThreadMuted(routed, userInfo.ID) {
+				progress.Suppressed++
+				continue
+			}
+			if nm.requiresVerifiedContact(routed, userInfo) {
+				progress.Suppressed++
+				continue
+			}
+			switch nm.applyDoNotDisturb(notificationID, routed, userInfo) {
+			case dndDeferred:
+				progress.Deferred++
+				continue
+			case dndDropped:
+				progress.Suppressed++
+				continue
+			}
+			if nm.holdIfUnreachable(notificationID, routed, userInfo) {
+				progress.Unreachable++
+				continue
+			}
+
+			recipientRequest, err := nm.personalizeRequest(nm.routeRequest(routed, userInfo), userInfo)
+			if err != nil {
+				sampledLog.Error("Failed to render template for user", logger.Fields{
+					"notification_id": notificationID,
+					"user_id":         userInfo.ID,
+					"error":           err.Error(),
+				})
+				progress.Failed++
+				continue
+			}
+
+			// Process notification based on type
+			count, err := nm.processNotificationByType(notificationID, recipientRequest, userInfo, enqueueTimeout)
+			progress.Queued += count
+			if err != nil {
+				sampledLog.Error("Failed to process notification for user", logger.Fields{
+					"notification_id": notificationID,
+					"user_id":         userInfo.ID,
+					"error":           err.Error(),
+				})
+				progress.Failed++
+			}
 		}
 
-		responses = append(responses, userResponses...)
+		if err := nm.storage.AddProgress(notificationID, progress); err != nil {
+			logrus.WithError(err).WithField("notification_id", notificationID).Warn("Failed to record notification progress")
+		}
+
+		resolved += progress.Resolved
+		queued += progress.Queued
+		nm.recordQueuedMetric(request, progress.Queued)
+
+		moduleLog.Debug("Recipient batch processed", logger.Fields{
+			"notification_id": notificationID,
+			"processed":       end,
+			"total":           len(request.Recipients),
+			"queued":          queued,
+		})
+	}
+
+	if err := nm.storage.CompleteProgress(notificationID); err != nil {
+		logrus.WithError(err).WithField("notification_id", notificationID).Warn("Failed to complete notification progress")
 	}
 
-	return responses, nil
+	if resolved == 0 {
+		logrus.Warn("No valid recipients found for notification")
+		return 0, fmt.Errorf("no valid recipients found")
+	}
+
+	return queued, nil
 }
 
-// processNotificationByType processes notifications based on type and user information
-func (nm *NotificationManagerImpl) processNotificationByType(notificationID string, request models.NotificationRequest, userInfo *models.UserNotificationInfo) ([]interface{}, error) {
-	var responses []interface{}
+// personalizeRequest returns the request as sent to a single recipient. The template is rendered
+// again for recipients with variable overrides, for templates that use recipient variables and,
+// in the recipient's locale, for localized templates; otherwise recipients share the content
+// rendered when the request was accepted.
+func (nm *NotificationManagerImpl) personalizeRequest(request *models.NotificationRequest, userInfo *models.UserNotificationInfo) (models.NotificationRequest, error) {
+	personalized := *request
+	if request.Template == nil {
+		return personalized, nil
+	}
+
+	templateObj, err := nm.templateManager.GetTemplateByIDAndVersion(request.Template.ID, request.Template.Version)
+	if err != nil {
+		return personalized, fmt.Errorf("failed to get template: %v", err)
+	}
+	if len(request.Template.RecipientData[userInfo.ID]) == 0 && !templateObj.UsesRecipientVariables() && !templateObj.IsLocalized() {
+		return personalized, nil
+	}
+
+	data := nm.withBranding(models.WithRecipientVariables(request.Template.DataFor(userInfo.ID), userInfo), request.Tenant)
+	generatedContent, err := nm.renderTemplateContent(nm.localizeTemplate(templateObj, request.Tenant, userInfo.Locale), data)
+	if err != nil {
+		return personalized, err
+	}
+
+	// Copy the shared content so other recipients are not affected
+	personalized.Content = make(map[string]interface{}, len(request.Content)+len(generatedContent))
+	for key, value := range request.Content {
+		personalized.Content[key] = value
+	}
+	for key, value := range generatedContent {
+		personalized.Content[key] = value
+	}
+
+	if violations := textlimit.CheckContent(request.Type, personalized.Content); len(violations) > 0 {
+		return personalized, fmt.Errorf("%w: %s: %s", ErrContentLimitExceeded, violations[0].Field, violations[0].Message)
+	}
+	return personalized, nil
+}
+
+// enqueueTimeoutFor returns how long enqueuing may wait for a full channel.
+// Only sends fanned out off the request path apply backpressure.
+func (nm *NotificationManagerImpl) enqueueTimeoutFor(request *models.NotificationRequest) time.Duration {
+	if nm.fansOutInBackground(request) {
+		return nm.config.EnqueueTimeout
+	}
+	return 0
+}
+
+// fansOutInBackground reports whether an immediate notification is accepted before it is fanned
+// out: large ones always are, others when the client asked for it
+func (nm *NotificationManagerImpl) fansOutInBackground(request *models.NotificationRequest) bool {
+	return request.AcceptAsync || len(request.Recipients) > nm.config.AsyncRecipientThreshold
+}
+
+// processNotificationInBackground fans a large immediate notification out after the request was accepted
+func (nm *NotificationManagerImpl) processNotificationInBackground(ctx context.Context, request *models.NotificationRequest, notificationID string) {
+	queued, err := nm.processNotificationForRecipients(ctx, request, notificationID)
+	if err != nil {
+		logrus.WithError(err).WithField("notification_id", notificationID).Error("Failed to process notification for recipients")
+		if statusErr := nm.SetNotificationStatus(notificationID, request, "failed"); statusErr != nil {
+			logrus.WithError(statusErr).WithField("notification_id", notificationID).Warn("Failed to set notification status to failed")
+		}
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"notification_id":  notificationID,
+		"total_recipients": len(request.Recipients),
+		"queued_count":     queued,
+	}).Info("Background notification processing completed")
+
+	if err := nm.SetNotificationStatus(notificationID, request, "sent"); err != nil {
+		logrus.WithError(err).WithField("notification_id", notificationID).Warn("Failed to set notification status to sent")
+	}
+}
 
+// processNotificationByType processes notifications based on type and user information
+// and returns the number of messages queued for the user
+func (nm *NotificationManagerImpl) processNotificationByType(notificationID string, request models.NotificationRequest, userInfo *models.UserNotificationInfo, enqueueTimeout time.Duration) (int, error) {
 	switch request.Type {
 	case "email":
 		// For email notifications, use email as recipient
 		if userInfo.Email == "" {
-			logrus.WithField("user_id", userInfo.ID).Warn("User has no email address")
-			return responses, nil
+			sampledLog.Warn("User has no email address", logger.Fields{"user_id": userInfo.ID})
+			return 0, nil
 		}
 
 		// Create email-specific message
 		emailMessage := nm.createEmailMessage(notificationID, request, userInfo)
 
 		// Post to email channel
-		err := nm.postToKafkaChannel("email", emailMessage)
-		if err != nil {
-			return responses, fmt.Errorf("failed to post email notification: %v", err)
+		if err := nm.postToKafkaChannel("email", emailMessage, enqueueTimeout); err != nil {
+			return 0, fmt.Errorf("failed to post email notification: %v", err)
 		}
-
-		// Create response
-		response := &models.NotificationResponse{
-			ID:      notificationID,
-			Status:  "queued",
-			Message: fmt.Sprintf("Email notification queued for user %s", userInfo.FullName),
-			SentAt:  time.Now(),
-			Channel: "email",
-		}
-		responses = append(responses, response)
+		return 1, nil
 
 	case "slack":
 		// For slack notifications, use slack channel as recipient
 		if userInfo.SlackChannel == "" {
-			logrus.WithField("user_id", userInfo.ID).Warn("User has no slack channel")
-			return responses, nil
+			sampledLog.Warn("User has no slack channel", logger.Fields{"user_id": userInfo.ID})
+			return 0, nil
 		}
 
 		// Create slack-specific message
 		slackMessage := nm.createSlackMessage(notificationID, request, userInfo)
 
 		// Post to slack channel
-		err := nm.postToKafkaChannel("slack", slackMessage)
+		if err := nm.postToKafkaChannel("slack", slackMessage, enqueueTimeout); err != nil {
+			return 0, fmt.Errorf("failed to post slack notification: %v", err)
+		}
+		return 1, nil
+
+	case "google_chat":
+		// For Google Chat notifications, use the user's space as recipient
+		if userInfo.GoogleChatSpace == "" {
+			sampledLog.Warn("User has no google chat space", logger.Fields{"user_id": userInfo.ID})
+			return 0, nil
+		}
+
+		chatMessage, err := nm.createGoogleChatMessage(notificationID, request, userInfo)
 		if err != nil {
-			return responses, fmt.Errorf("failed to post slack notification: %v", err)
+			return 0, err
 		}
 
-		// Create response
-		response := &models.NotificationResponse{
-			ID:      notificationID,
-			Status:  "queued",
-			Message: fmt.Sprintf("Slack notification queued for user %s", userInfo.FullName),
-			SentAt:  time.Now(),
-			Channel: "slack",
+		// Post to google chat channel
+		if err := nm.postToKafkaChannel("google_chat", chatMessage, enqueueTimeout); err != nil {
+			return 0, fmt.Errorf("failed to post google chat notification: %v", err)
 		}
-		responses = append(responses, response)
+		return 1, nil
+
+	case "incident":
+		// Incidents are sent to the route of an incident recipient, users are not paged directly
+		if userInfo.IncidentRoute == "" {
+			sampledLog.Warn("Recipient has no incident route", logger.Fields{"user_id": userInfo.ID})
+			return 0, nil
+		}
+
+		incidentMessage, err := nm.createIncidentMessage(notificationID, request, userInfo)
+		if err != nil {
+			return 0, err
+		}
+
+		// Post to incident channel
+		if err := nm.postToKafkaChannel("incident", incidentMessage, enqueueTimeout); err != nil {
+			return 0, fmt.Errorf("failed to post incident notification: %v", err)
+		}
+		return 1, nil
+
+	case "rcs":
+		// RCS messages are sent to the phone number of the user
+		if userInfo.PhoneNumber == "" {
+			sampledLog.Warn("User has no phone number", logger.Fields{"user_id": userInfo.ID})
+			return 0, nil
+		}
+
+		rcsMessage, err := nm.createRCSMessage(notificationID, request, userInfo)
+		if err != nil {
+			return 0, err
+		}
+
+		// Post to RCS channel
+		if err := nm.postToKafkaChannel("rcs", rcsMessage, enqueueTimeout); err != nil {
+			return 0, fmt.Errorf("failed to post rcs notification: %v", err)
+		}
+		return 1, nil
 
 	case "in_app":
+		// Keep the notification in the user's inbox whether or not it can be pushed
+		title, _ := request.Content["title"].(string)
+		body, _ := request.Content["body"].(string)
+		actions, _ := models.ParseInboxActions(request.Content["actions"])
+		item := models.InboxItem{
+			UserID:         userInfo.ID,
+			NotificationID: notificationID,
+			Title:          title,
+			Body:           body,
+			Reason:         request.Reason,
+			ThreadKey:      request.ThreadKey,
+			CreatedAt:      nm.clock.Now(),
+			Transactional:  request.Transactional,
+			Actions:        actions,
+		}
+
+		// Notifications of muted threads are kept in the inbox as read, without a push
+		if nm.isThreadMuted(&request, userInfo.ID) {
+			readAt := item.CreatedAt
+			item.Muted = true
+			item.ReadAt = &readAt
+			nm.inbox.Add(item)
+			return 0, nil
+		}
+		nm.inbox.Add(item)
+
 		// For in_app notifications, determine push type based on user devices
 		if len(userInfo.Devices) == 0 {
-			logrus.WithField("user_id", userInfo.ID).Warn("User has no active devices")
-			return responses, nil
+			sampledLog.Warn("User has no active devices", logger.Fields{"user_id": userInfo.ID})
+			return 0, nil
 		}
 
-		// Group devices by type
-		iosDevices := make([]*models.UserDeviceInfo, 0)
-		androidDevices := make([]*models.UserDeviceInfo, 0)
-
+		// Send one message per active device token
+		queued, failed := 0, 0
 		for _, device := range userInfo.Devices {
-			if device.IsActive && device.DeviceToken != "" {
-				switch device.DeviceType {
-				case "ios":
-					iosDevices = append(iosDevices, device)
-				case "android":
-					androidDevices = append(androidDevices, device)
-				}
+			if !device.IsActive || device.DeviceToken == "" {
+				continue
 			}
-		}
 
-		// Send to iOS devices - one message per device token
-		for _, device := range iosDevices {
-			if device.IsActive && device.DeviceToken != "" {
-				iosMessage := nm.createIndividualPushMessage(notificationID, request, userInfo, device.DeviceToken, "ios_push")
-				err := nm.postToKafkaChannel("ios_push", iosMessage)
-				if err != nil {
-					logrus.WithError(err).WithField("device_token", device.DeviceToken).Error("Failed to post iOS push notification")
-				} else {
-					response := &models.NotificationResponse{
-						ID:      notificationID,
-						Status:  "queued",
-						Message: fmt.Sprintf("iOS push notification queued for user %s (device: %s)", userInfo.FullName, device.DeviceToken[:8]+"..."),
-						SentAt:  time.Now(),
-						Channel: "ios_push",
-					}
-					responses = append(responses, response)
-				}
+			var pushType string
+			switch device.DeviceType {
+			case "ios":
+				pushType = "ios_push"
+			case "android":
+				pushType = "android_push"
+			default:
+				continue
 			}
-		}
 
-		// Send to Android devices - one message per device token
-		for _, device := range androidDevices {
-			if device.IsActive && device.DeviceToken != "" {
-				androidMessage := nm.createIndividualPushMessage(notificationID, request, userInfo, device.DeviceToken, "android_push")
-				err := nm.postToKafkaChannel("android_push", androidMessage)
-				if err != nil {
-					logrus.WithError(err).WithField("device_token", device.DeviceToken).Error("Failed to post Android push notification")
-				} else {
-					response := &models.NotificationResponse{
-						ID:      notificationID,
-						Status:  "queued",
-						Message: fmt.Sprintf("Android push notification queued for user %s (device: %s)", userInfo.FullName, device.DeviceToken[:8]+"..."),
-						SentAt:  time.Now(),
-						Channel: "android_push",
-					}
-					responses = append(responses, response)
-				}
+			pushMessage := nm.createIndividualPushMessage(notificationID, request, userInfo, device, pushType)
+			if err := nm.postToKafkaChannel(pushType, pushMessage, enqueueTimeout); err != nil {
+				sampledLog.Error("Failed to post push notification", logger.Fields{
+					"device_token": device.DeviceToken,
+					"push_type":    pushType,
+					"error":        err.Error(),
+				})
+				failed++
+				continue
 			}
+			queued++
 		}
+
+		if failed > 0 {
+			return queued, fmt.Errorf("failed to post %d push notifications", failed)
+		}
+		return queued, nil
+
 	default:
-		return responses, fmt.Errorf("unsupported notification type: %s", request.Type)
+		return 0, fmt.Errorf("unsupported notification type: %s", request.Type)
 	}
-
-	return responses, nil
 }
 
-// postToKafkaChannel posts the notification message to the appropriate Kafka channel
-func (nm *NotificationManagerImpl) postToKafkaChannel(notificationType string, message interface{}) error {
-	// Convert message to JSON
-	messageJSON, err := json.Marshal(message)
+// postToKafkaChannel posts the notification message to the appropriate Kafka channel.
+// With a zero timeout a full channel fails immediately; otherwise the send waits up to the timeout.
+func (nm *NotificationManagerImpl) postToKafkaChannel(notificationType string, message interface{}, timeout time.Duration) error {
+	// Stamp the wall clock time, not nm.clock, so consumers can measure how long messages wait
+	queuedAt := time.Now()
+	if queued, ok := message.(models.QueuedMessage); ok {
+		queued.SetQueuedAt(queuedAt)
+	}
+
+	// Convert message to JSON using a pooled encoder
+	messageStr, err := bufferpool.MarshalToString(message)
 	if err != nil {
 		return fmt.Errorf("failed to marshal notification message: %v", err)
 	}
 
-	messageStr := string(messageJSON)
-
 	// Post to appropriate channel based on notification type
 	switch notificationType {
 	case "email":
-		select {
-		case nm.kafkaService.GetEmailChannel() <- messageStr:
-			// Message sent successfully
-		default:
+		if !sendToChannel(nm.kafkaService.GetEmailChannel(), messageStr, timeout) {
 			return fmt.Errorf("email channel is full")
 		}
 
 	case "slack":
-		select {
-		case nm.kafkaService.GetSlackChannel() <- messageStr:
-			// Message sent successfully
-		default:
+		if !sendToChannel(nm.kafkaService.GetSlackChannel(), messageStr, timeout) {
 			return fmt.Errorf("slack channel is full")
 		}
 
+	case "google_chat":
+		if !sendToChannel(nm.kafkaService.GetGoogleChatChannel(), messageStr, timeout) {
+			return fmt.Errorf("google chat channel is full")
+		}
+
+	case "incident":
+		if !sendToChannel(nm.kafkaService.GetIncidentChannel(), messageStr, timeout) {
+			return fmt.Errorf("incident channel is full")
+		}
+
+	case "rcs":
+		if !sendToChannel(nm.kafkaService.GetRCSChannel(), messageStr, timeout) {
+			return fmt.Errorf("rcs channel is full")
+		}
+
 	case "ios_push":
-		select {
-		case nm.kafkaService.GetIOSPushNotificationChannel() <- messageStr:
-			// Message sent successfully
-		default:
+		if !sendToChannel(nm.kafkaService.GetIOSPushNotificationChannel(), messageStr, timeout) {
 			return fmt.Errorf("iOS push notification channel is full")
 		}
 
 	case "android_push":
-		select {
-		case nm.kafkaService.GetAndroidPushNotificationChannel() <- messageStr:
-			// Message sent successfully
-		default:
+		if !sendToChannel(nm.kafkaService.GetAndroidPushNotificationChannel(), messageStr, timeout) {
 			return fmt.Errorf("android push notification channel is full")
 		}
 
@@ -595,13 +1449,39 @@ func (nm *NotificationManagerImpl) postToKafkaChannel(notificationType string, m
 		return fmt.Errorf("unsupported notification type: %s", notificationType)
 	}
 
+	// Posted messages are archived so they can be replayed after a provider outage
+	nm.queueArchive.record(notificationType, messageStr, queuedAt, nm.config.QueueArchiveRetention, nm.config.QueueArchiveMaxMessages)
 	return nil
 }
 
+// sendToChannel sends the message, waiting up to timeout for room on a full channel
+func sendToChannel(channel chan string, message string, timeout time.Duration) bool {
+	select {
+	case channel <- message:
+		return true
+	default:
+	}
+
+	if timeout <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case channel <- message:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
 // createEmailMessage creates an email-specific notification message
 func (nm *NotificationManagerImpl) createEmailMessage(notificationID string, request models.NotificationRequest, userInfo *models.UserNotificationInfo) *models.EmailNotificationRequest {
 	// Extract content from request
-	var subject, emailBody string
+	var subject, emailBody, textBody, renderMode, colorScheme, darkModeCSS string
+	var attachments []models.EmailAttachment
 	if request.Content != nil {
 		if subj, ok := request.Content["subject"].(string); ok {
 			subject = subj
@@ -609,16 +1489,34 @@ func (nm *NotificationManagerImpl) createEmailMessage(notificationID string, req
 		if body, ok := request.Content["email_body"].(string); ok {
 			emailBody = body
 		}
+		if body, ok := request.Content["text_body"].(string); ok {
+			textBody = body
+		}
+		if mode, ok := request.Content["render_mode"].(string); ok {
+			renderMode = mode
+		}
+		colorScheme, _ = request.Content["color_scheme"].(string)
+		darkModeCSS, _ = request.Content["dark_mode_css"].(string)
+		// Attachments were checked when the request was validated
+		attachments, _ = models.ParseEmailAttachments(request.Content["attachments"])
 	}
 
 	emailNotification := &models.EmailNotificationRequest{
 		ID:   notificationID,
 		Type: "email",
 		Content: models.EmailContent{
-			Subject:   subject,
-			EmailBody: emailBody,
+			Subject:     subject,
+			EmailBody:   emailBody,
+			RenderMode:  renderMode,
+			Attachments: attachments,
+			TextBody:    textBody,
+			ColorScheme: colorScheme,
+			DarkModeCSS: darkModeCSS,
+			Reason:      request.Reason,
 		},
 		Recipient: userInfo.Email,
+		UserID:    userInfo.ID,
+		BatchKey:  request.BatchKey,
 	}
 
 	// Add from field if provided
@@ -646,13 +1544,77 @@ func (nm *NotificationManagerImpl) createSlackMessage(notificationID string, req
 		Type:      "slack",
 		Content:   models.SlackContent{Text: text},
 		Recipient: userInfo.SlackChannel,
+		UserID:    userInfo.ID,
+		ThreadKey: request.ThreadKey,
 	}
 
 	return slackNotification
 }
 
-// createIndividualPushMessage creates a push notification message for a single device token
-func (nm *NotificationManagerImpl) createIndividualPushMessage(notificationID string, request models.NotificationRequest, userInfo *models.UserNotificationInfo, deviceToken string, pushType string) interface{} {
+// createGoogleChatMessage creates a Google Chat-specific notification message
+func (nm *NotificationManagerImpl) createGoogleChatMessage(notificationID string, request models.NotificationRequest, userInfo *models.UserNotificationInfo) (*models.GoogleChatNotificationRequest, error) {
+	var content models.GoogleChatContent
+	if request.Content != nil {
+		content.Text, _ = request.Content["text"].(string)
+		card, err := models.ParseGoogleChatCard(request.Content["card"])
+		if err != nil {
+			return nil, err
+		}
+		content.Card = card
+	}
+
+	return &models.GoogleChatNotificationRequest{
+		ID:        notificationID,
+		Type:      "google_chat",
+		Content:   content,
+		Recipient: userInfo.GoogleChatSpace,
+		UserID:    userInfo.ID,
+		ThreadKey: request.ThreadKey,
+	}, nil
+}
+
+// createIncidentMessage creates an incident-specific notification message. Notifications
+// without a dedupe key are their own alert, so they are keyed by their ID.
+func (nm *NotificationManagerImpl) createIncidentMessage(notificationID string, request models.NotificationRequest, userInfo *models.UserNotificationInfo) (*models.IncidentNotificationRequest, error) {
+	content, err := models.ParseIncidentContent(request.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	dedupeKey := request.DedupeKey
+	if dedupeKey == "" {
+		dedupeKey = notificationID
+	}
+	return &models.IncidentNotificationRequest{
+		ID:        notificationID,
+		Type:      "incident",
+		Content:   content,
+		Recipient: userInfo.IncidentRoute,
+		UserID:    userInfo.ID,
+		DedupeKey: dedupeKey,
+	}, nil
+}
+
+// createRCSMessage creates an RCS-specific notification message
+func (nm *NotificationManagerImpl) createRCSMessage(notificationID string, request models.NotificationRequest, userInfo *models.UserNotificationInfo) (*models.RCSNotificationRequest, error) {
+	content, err := models.ParseRCSContent(request.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RCSNotificationRequest{
+		ID:        notificationID,
+		Type:      "rcs",
+		Content:   content,
+		Recipient: userInfo.PhoneNumber,
+		UserID:    userInfo.ID,
+	}, nil
+}
+
+// createIndividualPushMessage creates a push notification message for a single device
+func (nm *NotificationManagerImpl) createIndividualPushMessage(notificationID string, request models.NotificationRequest, userInfo *models.UserNotificationInfo, device *models.UserDeviceInfo, pushType string) interface{} {
+	deviceToken := device.DeviceToken
+
 	// Extract content from request
 	var title, body string
 	if request.Content != nil {
@@ -668,17 +1630,26 @@ func (nm *NotificationManagerImpl) createIndividualPushMessage(notificationID st
 	switch pushType {
 	case "ios_push":
 		return &models.APNSNotificationRequest{
-			ID:        notificationID,
-			Type:      "ios_push",
-			Content:   models.APNSContent{Title: title, Body: body},
-			Recipient: deviceToken,
+			ID:          notificationID,
+			Type:        "ios_push",
+			Content:     models.APNSContent{Title: title, Body: body},
+			Recipient:   deviceToken,
+			UserID:      userInfo.ID,
+			Environment: device.APNSEnvironment,
+			AppID:       device.AppID,
+			BatchKey:    request.BatchKey,
+			ThreadID:    request.ThreadKey,
 		}
 	case "android_push":
 		return &models.FCMNotificationRequest{
-			ID:        notificationID,
-			Type:      "android_push",
-			Content:   models.FCMContent{Title: title, Body: body},
-			Recipient: deviceToken,
+			ID:          notificationID,
+			Type:        "android_push",
+			Content:     models.FCMContent{Title: title, Body: body},
+			Recipient:   deviceToken,
+			UserID:      userInfo.ID,
+			AppID:       device.AppID,
+			BatchKey:    request.BatchKey,
+			CollapseKey: request.ThreadKey,
 		}
 	default:
 		// Fallback to generic map for unsupported types
@@ -691,7 +1662,7 @@ func (nm *NotificationManagerImpl) createIndividualPushMessage(notificationID st
 	}
 }
 
-// generateID generates a UUID for notification IDs
+// generateID generates an ID for a new notification using the configured generator
 func (nm *NotificationManagerImpl) generateID() string {
-	return uuid.New().String()
+	return nm.idGenerator.GenerateID()
 }
@@ -0,0 +1,1058 @@
+package notification_manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/external_services/delivery"
+	"github.com/gaurav2721/notification-service/external_services/kafka"
+	"github.com/gaurav2721/notification-service/external_services/user"
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestManager creates a notification manager with the preloaded users plus count synthetic ones
+func newTestManager(t *testing.T, count int, config Config) (*NotificationManagerImpl, kafka.KafkaService, []string) {
+	userService := user.NewUserService()
+	recipients := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		userID := fmt.Sprintf("stream-user-%03d", i)
+		require.NoError(t, userService.CreateUser(&models.User{
+			ID:       userID,
+			Email:    fmt.Sprintf("stream.user%03d@company.com", i),
+			FullName: fmt.Sprintf("Stream User %03d", i),
+			IsActive: true,
+		}))
+		recipients = append(recipients, userID)
+	}
+
+	kafkaService, err := kafka.NewKafkaService()
+	require.NoError(t, err)
+
+	nm := NewNotificationManagerWithDefaultTemplate(userService, kafkaService, nil)
+	nm.config = config
+	nm.tagLabels = metrics.NewLabelLimiter(config.MaxTagLabelValues)
+	return nm, kafkaService, recipients
+}
+
+func TestProcessNotificationRequest_StreamsRecipientBatches(t *testing.T) {
+	config := DefaultConfig()
+	config.RecipientBatchSize = 2
+	nm, kafkaService, recipients := newTestManager(t, 4, config)
+
+	request := &models.NotificationRequest{
+		Type:       "email",
+		Content:    map[string]interface{}{"subject": "Hello", "email_body": "Body"},
+		Recipients: append(recipients, "non-existent"),
+	}
+
+	result, err := nm.ProcessNotificationRequest(request)
+	require.NoError(t, err)
+	notificationID := result.(map[string]interface{})["id"].(string)
+
+	progress, err := nm.storage.GetProgress(notificationID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, progress.TotalRecipients)
+	assert.Equal(t, 4, progress.Resolved)
+	assert.Equal(t, 1, progress.Skipped)
+	assert.Equal(t, 4, progress.Queued)
+	assert.Zero(t, progress.Failed)
+	assert.NotNil(t, progress.CompletedAt)
+	assert.Len(t, kafkaService.GetEmailChannel(), 4)
+}
+
+func TestProcessNotificationRequest_NoValidRecipients(t *testing.T) {
+	nm, _, _ := newTestManager(t, 0, DefaultConfig())
+
+	_, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "email",
+		Content:    map[string]interface{}{"subject": "Hello", "email_body": "Body"},
+		Recipients: []string{"non-existent"},
+	})
+	assert.Error(t, err)
+}
+
+func TestProcessNotificationRequest_LargeSendIsProcessedInBackground(t *testing.T) {
+	config := DefaultConfig()
+	config.RecipientBatchSize = 3
+	config.AsyncRecipientThreshold = 5
+	nm, kafkaService, recipients := newTestManager(t, 10, config)
+
+	result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "email",
+		Content:    map[string]interface{}{"subject": "Hello", "email_body": "Body"},
+		Recipients: recipients,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "queued", result.(map[string]interface{})["status"])
+	notificationID := result.(map[string]interface{})["id"].(string)
+
+	require.Eventually(t, func() bool {
+		progress, err := nm.storage.GetProgress(notificationID)
+		return err == nil && progress.CompletedAt != nil
+	}, time.Second, 10*time.Millisecond)
+
+	progress, err := nm.storage.GetProgress(notificationID)
+	require.NoError(t, err)
+	assert.Equal(t, 10, progress.Queued)
+	assert.Len(t, kafkaService.GetEmailChannel(), 10)
+}
+
+func TestStopBackgroundSends(t *testing.T) {
+	t.Setenv("EMAIL_CHANNEL_BUFFER_SIZE", "1")
+	config := DefaultConfig()
+	config.RecipientBatchSize = 1
+	config.EnqueueTimeout = 50 * time.Millisecond
+	nm, kafkaService, recipients := newTestManager(t, 4, config)
+
+	result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:        "email",
+		Content:     map[string]interface{}{"subject": "Hello", "email_body": "Body"},
+		Recipients:  recipients,
+		AcceptAsync: true,
+	})
+	require.NoError(t, err)
+	notificationID := result.(map[string]interface{})["id"].(string)
+
+	// The full channel holds the send back until shutdown gives up on it
+	require.Eventually(t, func() bool { return len(kafkaService.GetEmailChannel()) == 1 }, time.Second, time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	nm.StopBackgroundSends(ctx)
+
+	assert.Len(t, kafkaService.GetEmailChannel(), 1)
+	status, err := nm.storage.GetNotification(notificationID)
+	require.NoError(t, err)
+	assert.Equal(t, "failed", string(status.Status))
+}
+
+func TestProcessNotificationRequest_AcceptAsync(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 2, DefaultConfig())
+
+	// Small sends are fanned out in the background too when the client accepts sends asynchronously
+	result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:        "email",
+		Content:     map[string]interface{}{"subject": "Hello", "email_body": "Body"},
+		Recipients:  recipients,
+		AcceptAsync: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "queued", result.(map[string]interface{})["status"])
+	notificationID := result.(map[string]interface{})["id"].(string)
+
+	require.Eventually(t, func() bool {
+		progress, err := nm.storage.GetProgress(notificationID)
+		return err == nil && progress.CompletedAt != nil
+	}, time.Second, 10*time.Millisecond)
+	assert.Len(t, kafkaService.GetEmailChannel(), 2)
+}
+
+func TestProcessNotificationRequestWithContext_StopsAfterDeadline(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 4, DefaultConfig())
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	_, err := nm.ProcessNotificationRequestWithContext(ctx, &models.NotificationRequest{
+		Type:       "email",
+		Content:    map[string]interface{}{"subject": "Hello", "email_body": "Body"},
+		Recipients: recipients,
+	})
+	assert.ErrorIs(t, err, ErrRequestAborted)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Len(t, kafkaService.GetEmailChannel(), 0, "no recipient is looked up after the deadline")
+
+	_, err = nm.ProcessNotificationRequestWithContext(ctx, &models.NotificationRequest{
+		Type:       "email",
+		Template:   &models.TemplateData{ID: "missing", Data: map[string]interface{}{}},
+		Recipients: recipients,
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "the template is not rendered after the deadline")
+}
+
+func TestSendToChannel(t *testing.T) {
+	channel := make(chan string, 1)
+
+	assert.True(t, sendToChannel(channel, "first", 0))
+	assert.False(t, sendToChannel(channel, "second", 0))
+	assert.False(t, sendToChannel(channel, "second", 10*time.Millisecond))
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		<-channel
+	}()
+	assert.True(t, sendToChannel(channel, "second", time.Second))
+}
+
+func TestProcessTemplateString(t *testing.T) {
+	nm := &NotificationManagerImpl{}
+
+	tests := []struct {
+		name     string
+		template string
+		data     map[string]interface{}
+		expected string
+	}{
+		{
+			name:     "replaces every occurrence",
+			template: "Hi {{name}}, welcome to {{platform}}. Bye {{name}}!",
+			data:     map[string]interface{}{"name": "John", "platform": "Acme"},
+			expected: "Hi John, welcome to Acme. Bye John!",
+		},
+		{
+			name:     "formats non string values",
+			template: "Order {{order_id}} has {{count}} items, paid={{paid}}, total={{total}}",
+			data:     map[string]interface{}{"order_id": "ORD-1", "count": 3, "paid": true, "total": 9.5},
+			expected: "Order ORD-1 has 3 items, paid=true, total=9.5",
+		},
+		{
+			name:     "keeps placeholders without data",
+			template: "Hello {{name}} from {{team}}",
+			data:     map[string]interface{}{"name": "John"},
+			expected: "Hello John from {{team}}",
+		},
+		{
+			name:     "does not expand placeholders inside values",
+			template: "{{a}} {{b}}",
+			data:     map[string]interface{}{"a": "{{b}}", "b": "x"},
+			expected: "{{b}} x",
+		},
+		{
+			name:     "unterminated placeholder",
+			template: "Hello {{name",
+			data:     map[string]interface{}{"name": "John"},
+			expected: "Hello {{name",
+		},
+		{
+			name:     "no data",
+			template: "Hello {{name}}",
+			data:     nil,
+			expected: "Hello {{name}}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, nm.processTemplateString(tt.template, tt.data))
+		})
+	}
+}
+
+func TestRenderTemplateContent_EscapesValues(t *testing.T) {
+	nm, _, _ := newTestManager(t, 0, DefaultConfig())
+	data := map[string]interface{}{
+		"name": "<b>Eve</b>\r\nBcc: victim@example.com",
+		"link": "<a href=\"https://example.com\">Open</a>",
+	}
+
+	email := &models.Template{
+		Type:    models.EmailNotification,
+		Content: models.TemplateContent{Subject: "Hi {{name}}", EmailBody: "Hello {{name}}, {{{link}}}"},
+	}
+	content, err := nm.renderTemplateContent(email, data)
+	require.NoError(t, err)
+	assert.Equal(t, "Hi <b>Eve</b> Bcc: victim@example.com", content["subject"])
+	assert.Equal(t, "Hello &lt;b&gt;Eve&lt;/b&gt;\r\nBcc: victim@example.com, <a href=\"https://example.com\">Open</a>", content["email_body"])
+
+	slack := &models.Template{
+		Type:    models.SlackNotification,
+		Content: models.TemplateContent{Text: "Deploy by {{user}}"},
+	}
+	content, err = nm.renderTemplateContent(slack, map[string]interface{}{"user": "<!channel> & co"})
+	require.NoError(t, err)
+	assert.Equal(t, "Deploy by &lt;!channel&gt; &amp; co", content["text"])
+
+	markdownEmail := &models.Template{
+		Type:       models.EmailNotification,
+		Content:    models.TemplateContent{Subject: "Order", EmailBody: "Order **{{order_id}}**"},
+		RenderMode: models.EmailRenderModeMarkdown,
+	}
+	content, err = nm.renderTemplateContent(markdownEmail, map[string]interface{}{"order_id": "[1](https://evil.example)"})
+	require.NoError(t, err)
+	assert.Equal(t, "Order **\\[1](https://evil.example)**", content["email_body"])
+}
+
+func BenchmarkProcessTemplateString(b *testing.B) {
+	nm := &NotificationManagerImpl{}
+	template := "Hello {{name}},\n\nWelcome to {{platform}}! We're excited to have you on board.\n\nYour account has been successfully created with the following details:\n- Username: {{username}}\n- Email: {{email}}\n\nBest regards,\nThe {{platform}} Team"
+	data := map[string]interface{}{
+		"name":     "John Doe",
+		"platform": "Notification Service",
+		"username": "johndoe",
+		"email":    "john.doe@company.com",
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		nm.processTemplateString(template, data)
+	}
+}
+
+func TestProcessNotificationRequest_ExternalID(t *testing.T) {
+	nm, _, recipients := newTestManager(t, 1, DefaultConfig())
+
+	ids := []string{"11111111-1111-4111-8111-111111111111", "22222222-2222-4222-8222-222222222222"}
+	next := 0
+	nm.SetIDGenerator(IDGeneratorFunc(func() string {
+		id := ids[next]
+		next++
+		return id
+	}))
+
+	for i := 0; i < 2; i++ {
+		result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+			Type:       "email",
+			Content:    map[string]interface{}{"subject": "Hello", "email_body": "Body"},
+			Recipients: recipients,
+			ExternalID: "order-42",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, ids[i], result.(map[string]interface{})["id"])
+		assert.Equal(t, "order-42", result.(map[string]interface{})["external_id"])
+	}
+
+	records := nm.storage.GetNotificationsByExternalID("order-42")
+	require.Len(t, records, 2)
+	assert.Equal(t, ids[0], records[0].ID)
+	assert.Equal(t, ids[1], records[1].ID)
+
+	require.NoError(t, nm.storage.DeleteNotification(ids[0]))
+	records = nm.storage.GetNotificationsByExternalID("order-42")
+	require.Len(t, records, 1)
+	assert.Equal(t, ids[1], records[0].ID)
+
+	assert.Empty(t, nm.storage.GetNotificationsByExternalID("order-43"))
+}
+
+func TestProcessNotificationRequest_Tags(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxTagLabelValues = 1
+	nm, _, recipients := newTestManager(t, 1, config)
+
+	send := func(notificationType string, content map[string]interface{}, tags ...string) string {
+		result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+			Type:       notificationType,
+			Content:    content,
+			Recipients: recipients,
+			Tags:       tags,
+		})
+		require.NoError(t, err)
+		return result.(map[string]interface{})["id"].(string)
+	}
+
+	emailContent := map[string]interface{}{"subject": "Hello", "email_body": "Body"}
+	billing := send("email", emailContent, "Billing", "q3-campaign", "billing")
+	send("email", emailContent, "onboarding")
+
+	record, err := nm.storage.GetNotification(billing)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"billing", "q3-campaign"}, record.Tags)
+
+	// List by tag, tags must all match
+	type listResponse = struct {
+		ExternalID    string                `json:"external_id,omitempty"`
+		Notifications []notificationSummary `json:"notifications"`
+		Count         int                   `json:"count"`
+	}
+	result, err := nm.ListNotifications(NotificationFilter{Tags: []string{"BILLING"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.(*listResponse).Count)
+
+	// The external ID filtered by is echoed back
+	result, err = nm.ListNotifications(NotificationFilter{ExternalID: "order-42"})
+	require.NoError(t, err)
+	assert.Equal(t, "order-42", result.(*listResponse).ExternalID)
+
+	records := nm.storage.FindNotifications(NotificationFilter{Tags: []string{"billing", "onboarding"}})
+	assert.Empty(t, records)
+
+	records = nm.storage.FindNotifications(NotificationFilter{Type: "email"})
+	assert.Len(t, records, 2)
+
+	// Tags beyond the label bound are reported as "other"
+	assert.Equal(t, []string{"billing", "other"}, nm.tagLabelValues(record.Tags))
+	assert.Equal(t, []string{"none"}, nm.tagLabelValues(nil))
+}
+
+func TestGetAdminOverview(t *testing.T) {
+	nm, _, recipients := newTestManager(t, 2, DefaultConfig())
+
+	for i := 0; i < 3; i++ {
+		_, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+			Type:       "email",
+			Content:    map[string]interface{}{"subject": "Hello", "email_body": "Body"},
+			Recipients: recipients,
+		})
+		require.NoError(t, err)
+	}
+
+	result, err := nm.GetAdminOverview(2)
+	require.NoError(t, err)
+
+	encoded, err := json.Marshal(result)
+	require.NoError(t, err)
+
+	var overview struct {
+		Queues []struct {
+			Name  string `json:"name"`
+			Depth int    `json:"depth"`
+		} `json:"queues"`
+		TotalNotifications int `json:"total_notifications"`
+		FailureRates       []struct {
+			Type          string `json:"type"`
+			Notifications int    `json:"notifications"`
+		} `json:"failure_rates"`
+		RecentNotifications []notificationSummary `json:"recent_notifications"`
+		Templates           []templateSummary     `json:"templates"`
+	}
+	require.NoError(t, json.Unmarshal(encoded, &overview))
+
+	require.Len(t, overview.Queues, 7)
+	assert.Equal(t, "email", overview.Queues[0].Name)
+	assert.Equal(t, 6, overview.Queues[0].Depth)
+	assert.Equal(t, 3, overview.TotalNotifications)
+	require.Len(t, overview.FailureRates, 1)
+	assert.Equal(t, "email", overview.FailureRates[0].Type)
+	assert.Equal(t, 3, overview.FailureRates[0].Notifications)
+	assert.Len(t, overview.RecentNotifications, 2)
+	assert.NotEmpty(t, overview.Templates)
+}
+
+func TestRenderTemplate(t *testing.T) {
+	nm, _, _ := newTestManager(t, 0, DefaultConfig())
+	const systemAlertTemplateID = "550e8400-e29b-41d4-a716-446655440003"
+
+	data := map[string]interface{}{
+		"alert_type":        "Disk",
+		"system_name":       "db-01",
+		"severity":          "critical",
+		"environment":       "production",
+		"message":           "Disk usage above 95%",
+		"timestamp":         "2024-01-01T12:00:00Z",
+		"action_required":   "Free up space",
+		"affected_services": "orders",
+		"dashboard_link":    "https://grafana.example.com/d/db-01",
+	}
+
+	result, err := nm.RenderTemplate(systemAlertTemplateID, 1, data, "")
+	require.NoError(t, err)
+
+	encoded, err := json.Marshal(result)
+	require.NoError(t, err)
+	var rendered struct {
+		Type    string            `json:"type"`
+		Content map[string]string `json:"content"`
+	}
+	require.NoError(t, json.Unmarshal(encoded, &rendered))
+	assert.Equal(t, "slack", rendered.Type)
+	assert.Contains(t, rendered.Content["text"], "*System:* db-01")
+	assert.NotContains(t, rendered.Content["text"], "{{")
+
+	_, err = nm.RenderTemplate(systemAlertTemplateID, 1, map[string]interface{}{"alert_type": "Disk"}, "")
+	assert.ErrorIs(t, err, ErrMissingRequiredVariable)
+
+	_, err = nm.RenderTemplate(systemAlertTemplateID, 2, data, "")
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+}
+
+func TestProcessNotificationRequest_ScheduledWithFakeClock(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 1, DefaultConfig())
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFake(start)
+	nm.SetClock(fakeClock)
+
+	scheduledAt := start.Add(30 * time.Minute)
+	result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:        "email",
+		Content:     map[string]interface{}{"subject": "Hello", "email_body": "Body"},
+		Recipients:  recipients,
+		ScheduledAt: &scheduledAt,
+	})
+	require.NoError(t, err)
+	notificationID := result.(map[string]interface{})["id"].(string)
+
+	record, err := nm.storage.GetNotification(notificationID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusScheduled, record.Status)
+	assert.Equal(t, start, record.CreatedAt)
+
+	fakeClock.Advance(29 * time.Minute)
+	assert.Empty(t, kafkaService.GetEmailChannel())
+
+	// The scheduled job runs synchronously when the clock reaches its time
+	fakeClock.Advance(time.Minute)
+	assert.Len(t, kafkaService.GetEmailChannel(), 1)
+
+	record, err = nm.storage.GetNotification(notificationID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusSent, record.Status)
+	require.NotNil(t, record.SentAt)
+	assert.Equal(t, scheduledAt, *record.SentAt)
+}
+
+func TestGetTemplateStats(t *testing.T) {
+	nm, _, recipients := newTestManager(t, 1, DefaultConfig())
+	deliveryService := delivery.NewDeliveryService()
+	nm.deliveryService = deliveryService
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFake(start)
+	nm.SetClock(fakeClock)
+	const welcomeTemplateID = "550e8400-e29b-41d4-a716-446655440000"
+
+	data := map[string]interface{}{
+		"name":            "John",
+		"platform":        "Acme",
+		"username":        "john",
+		"email":           "john@company.com",
+		"account_type":    "premium",
+		"activation_link": "https://acme.example.com/activate",
+	}
+
+	// A preview counts as a render but not as a send
+	_, err := nm.RenderTemplate(welcomeTemplateID, 1, data, "")
+	require.NoError(t, err)
+
+	fakeClock.Advance(time.Hour)
+	result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "email",
+		Template:   &models.TemplateData{ID: welcomeTemplateID, Version: 1, Data: data},
+		Recipients: recipients,
+	})
+	require.NoError(t, err)
+	notificationID := result.(map[string]interface{})["id"].(string)
+
+	require.NoError(t, deliveryService.RecordAttempt(&models.DeliveryAttempt{
+		NotificationID: notificationID, Recipient: "a@company.com", Channel: "email", Status: models.DeliveryStatusSent,
+	}))
+	require.NoError(t, deliveryService.RecordAttempt(&models.DeliveryAttempt{
+		NotificationID: notificationID, Recipient: "b@company.com", Channel: "email", Status: models.DeliveryStatusFailed,
+	}))
+
+	stats, err := nm.GetTemplateStats(welcomeTemplateID)
+	require.NoError(t, err)
+
+	encoded, err := json.Marshal(stats)
+	require.NoError(t, err)
+	var decoded struct {
+		TemplateID       string                 `json:"template_id"`
+		Rendered         int                    `json:"rendered"`
+		Sent             int                    `json:"sent"`
+		DeliveriesSent   int                    `json:"deliveries_sent"`
+		DeliveriesFailed int                    `json:"deliveries_failed"`
+		FailureRate      float64                `json:"failure_rate"`
+		LastUsedAt       *time.Time             `json:"last_used_at"`
+		Versions         []templateVersionStats `json:"versions"`
+	}
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+
+	assert.Equal(t, welcomeTemplateID, decoded.TemplateID)
+	assert.Equal(t, 2, decoded.Rendered)
+	assert.Equal(t, 1, decoded.Sent)
+	assert.Equal(t, 1, decoded.DeliveriesSent)
+	assert.Equal(t, 1, decoded.DeliveriesFailed)
+	assert.Equal(t, 0.5, decoded.FailureRate)
+	require.NotNil(t, decoded.LastUsedAt)
+	assert.Equal(t, start.Add(time.Hour), *decoded.LastUsedAt)
+	require.Len(t, decoded.Versions, 1)
+	assert.Equal(t, 1, decoded.Versions[0].Version)
+	assert.Equal(t, 1, decoded.Versions[0].Sent)
+
+	// Unused templates are listed with zero counts and no last-used time
+	stats, err = nm.GetTemplateStats("550e8400-e29b-41d4-a716-446655440001")
+	require.NoError(t, err)
+	encoded, err = json.Marshal(stats)
+	require.NoError(t, err)
+	assert.NotContains(t, string(encoded), "last_used_at")
+	assert.Contains(t, string(encoded), `"rendered":0`)
+
+	_, err = nm.GetTemplateStats("unknown-template")
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+}
+
+func TestUpdateTemplate_RecordsAudit(t *testing.T) {
+	nm, _, _ := newTestManager(t, 0, DefaultConfig())
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFake(start)
+	nm.SetClock(fakeClock)
+
+	created, err := nm.CreateTemplate(&models.Template{
+		Name:              "Shipping Update",
+		Type:              models.SlackNotification,
+		Content:           models.TemplateContent{Text: "Order {{order_id}} shipped"},
+		RequiredVariables: []string{"order_id"},
+		CreatedBy:         "alice",
+	})
+	require.NoError(t, err)
+	templateID := created.(*models.TemplateResponse).ID
+
+	fakeClock.Advance(time.Hour)
+	updated, err := nm.UpdateTemplate(templateID, &models.Template{
+		Name:              "Shipping Update",
+		Type:              models.SlackNotification,
+		Content:           models.TemplateContent{Text: "Order {{order_id}} is on its way via {{carrier}}"},
+		RequiredVariables: []string{"order_id", "carrier"},
+	}, "bob")
+	require.NoError(t, err)
+	response := updated.(*models.TemplateResponse)
+	assert.Equal(t, 2, response.Version)
+	assert.Equal(t, start, response.CreatedAt)
+	assert.Equal(t, start.Add(time.Hour), response.UpdatedAt)
+	assert.Equal(t, "alice", response.CreatedBy)
+	assert.Equal(t, "bob", response.UpdatedBy)
+
+	// Both versions stay available for rendering
+	_, err = nm.RenderTemplate(templateID, 1, map[string]interface{}{"order_id": "ORD-1"}, "")
+	require.NoError(t, err)
+	_, err = nm.RenderTemplate(templateID, 2, map[string]interface{}{"order_id": "ORD-1"}, "")
+	assert.ErrorIs(t, err, ErrMissingRequiredVariable)
+
+	result, err := nm.GetTemplateAudit(templateID)
+	require.NoError(t, err)
+	encoded, err := json.Marshal(result)
+	require.NoError(t, err)
+	var audit struct {
+		Entries []models.TemplateAuditEntry `json:"entries"`
+	}
+	require.NoError(t, json.Unmarshal(encoded, &audit))
+
+	require.Len(t, audit.Entries, 2)
+	assert.Equal(t, models.TemplateActionCreated, audit.Entries[0].Action)
+	assert.Equal(t, "alice", audit.Entries[0].Actor)
+	assert.Empty(t, audit.Entries[0].Changes)
+	assert.Equal(t, models.TemplateActionUpdated, audit.Entries[1].Action)
+	assert.Equal(t, "bob", audit.Entries[1].Actor)
+	assert.Equal(t, 2, audit.Entries[1].Version)
+	assert.Equal(t, []models.TemplateFieldChange{
+		{Field: "content.text", Old: "Order {{order_id}} shipped", New: "Order {{order_id}} is on its way via {{carrier}}"},
+		{Field: "required_variables", Old: "order_id", New: "order_id,carrier"},
+	}, audit.Entries[1].Changes)
+
+	_, err = nm.UpdateTemplate(templateID, &models.Template{Type: models.EmailNotification}, "bob")
+	assert.ErrorIs(t, err, models.ErrTemplateTypeChanged)
+	_, err = nm.UpdateTemplate("unknown-template", &models.Template{}, "bob")
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+	_, err = nm.UpdateTemplate("550e8400-e29b-41d4-a716-446655440000", &models.Template{
+		Type:    models.EmailNotification,
+		Content: models.TemplateContent{Subject: "Hi", EmailBody: "Hello"},
+	}, "bob")
+	assert.ErrorIs(t, err, models.ErrPredefinedTemplate)
+	_, err = nm.GetTemplateAudit("unknown-template")
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+}
+
+func TestImportTemplates_PromotesExportedBundle(t *testing.T) {
+	staging, _, _ := newTestManager(t, 0, DefaultConfig())
+	production, _, _ := newTestManager(t, 0, DefaultConfig())
+
+	created, err := staging.CreateTemplate(&models.Template{
+		Name:              "Shipping Update",
+		Type:              models.SlackNotification,
+		Content:           models.TemplateContent{Text: "Order {{order_id}} shipped"},
+		RequiredVariables: []string{"order_id"},
+		CreatedBy:         "alice",
+	})
+	require.NoError(t, err)
+	templateID := created.(*models.TemplateResponse).ID
+	_, err = staging.UpdateTemplate(templateID, &models.Template{
+		Name:              "Shipping Update",
+		Content:           models.TemplateContent{Text: "Order {{order_id}} is on its way"},
+		RequiredVariables: []string{"order_id"},
+	}, "bob")
+	require.NoError(t, err)
+
+	// Bundles survive a JSON round trip
+	encoded, err := json.Marshal(staging.ExportTemplates())
+	require.NoError(t, err)
+	var bundle models.TemplateBundle
+	require.NoError(t, json.Unmarshal(encoded, &bundle))
+	assert.Len(t, bundle.Templates, len(staging.ListTemplates()))
+
+	importResults := func(result interface{}) map[string]models.TemplateImportResult {
+		results := make(map[string]models.TemplateImportResult)
+		for _, r := range result.(*struct {
+			Templates []models.TemplateImportResult `json:"templates"`
+			Count     int                           `json:"count"`
+		}).Templates {
+			results[r.ID] = r
+		}
+		return results
+	}
+
+	result, err := production.ImportTemplates(&bundle, models.TemplateConflictSkip, "deployer")
+	require.NoError(t, err)
+	results := importResults(result)
+	assert.Equal(t, models.TemplateImportCreated, results[templateID].Outcome)
+	assert.Equal(t, 2, results[templateID].Version)
+	assert.Equal(t, models.TemplateImportSkipped, results["550e8400-e29b-41d4-a716-446655440000"].Outcome)
+
+	// Versions keep their numbers, so notifications referencing them work in production too
+	_, err = production.RenderTemplate(templateID, 1, map[string]interface{}{"order_id": "ORD-1"}, "")
+	require.NoError(t, err)
+	audit, err := production.templateManager.GetTemplateAudit(templateID)
+	require.NoError(t, err)
+	require.Len(t, audit, 2)
+	assert.Equal(t, models.TemplateActionImported, audit[1].Action)
+	assert.Equal(t, "deployer", audit[1].Actor)
+
+	// A changed template is added as a new version; unchanged ones are left alone
+	_, err = staging.UpdateTemplate(templateID, &models.Template{
+		Name:              "Shipping Update",
+		Content:           models.TemplateContent{Text: "Order {{order_id}} ships via {{carrier}}"},
+		RequiredVariables: []string{"order_id", "carrier"},
+	}, "bob")
+	require.NoError(t, err)
+	result, err = production.ImportTemplates(staging.ExportTemplates(), models.TemplateConflictNewVersion, "deployer")
+	require.NoError(t, err)
+	results = importResults(result)
+	assert.Equal(t, models.TemplateImportNewVersion, results[templateID].Outcome)
+	assert.Equal(t, 3, results[templateID].Version)
+	assert.Equal(t, models.TemplateImportUnchanged, results["550e8400-e29b-41d4-a716-446655440000"].Outcome)
+
+	// Overwriting replaces the versions with the bundle's
+	bundle.Templates = bundle.Templates[:0]
+	exported := staging.ExportTemplates()
+	for _, entry := range exported.Templates {
+		if entry.ID == templateID {
+			entry.Versions = entry.Versions[:1]
+			bundle.Templates = append(bundle.Templates, entry)
+		}
+	}
+	result, err = production.ImportTemplates(&bundle, models.TemplateConflictOverwrite, "deployer")
+	require.NoError(t, err)
+	assert.Equal(t, models.TemplateImportOverwritten, importResults(result)[templateID].Outcome)
+	latest, err := production.templateManager.GetTemplateByID(templateID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, latest.Version)
+	assert.Equal(t, "Order {{order_id}} shipped", latest.Content.Text)
+}
+
+func TestImportTemplates_KeepsLocalizations(t *testing.T) {
+	staging, _, _ := newTestManager(t, 0, DefaultConfig())
+	production, _, _ := newTestManager(t, 0, DefaultConfig())
+
+	created, err := staging.CreateTemplate(&models.Template{
+		Name:    "Receipt",
+		Type:    models.EmailNotification,
+		Content: models.TemplateContent{Subject: "Your receipt", EmailBody: "<p>Thanks</p>"},
+		Locale:  "en",
+		Localizations: map[string]models.TemplateContent{
+			"fr": {Subject: "Votre reçu", EmailBody: "<p>Merci</p>"},
+		},
+	})
+	require.NoError(t, err)
+	templateID := created.(*models.TemplateResponse).ID
+
+	// Bundles survive a JSON round trip
+	roundTrip := func() *models.TemplateBundle {
+		encoded, err := json.Marshal(staging.ExportTemplates())
+		require.NoError(t, err)
+		var bundle models.TemplateBundle
+		require.NoError(t, json.Unmarshal(encoded, &bundle))
+		return &bundle
+	}
+
+	_, err = production.templateManager.ImportTemplates(roundTrip(), models.TemplateConflictSkip, "deployer")
+	require.NoError(t, err)
+	imported, err := production.templateManager.GetTemplateByID(templateID)
+	require.NoError(t, err)
+	assert.Equal(t, "en", imported.Locale)
+	assert.Equal(t, "Votre reçu", imported.Localizations["fr"].Subject)
+
+	// A new localization is a change, and the new version is a draft like an updated one
+	_, err = staging.UpdateTemplate(templateID, &models.Template{
+		Name:    "Receipt",
+		Content: models.TemplateContent{Subject: "Your receipt", EmailBody: "<p>Thanks</p>"},
+		Locale:  "en",
+		Localizations: map[string]models.TemplateContent{
+			"fr":    {Subject: "Votre reçu", EmailBody: "<p>Merci</p>"},
+			"de-DE": {Subject: "Ihre Quittung", EmailBody: "<p>Danke</p>"},
+		},
+	}, "alice")
+	require.NoError(t, err)
+	// Locales are normalized as in UpdateTemplate
+	bundle := roundTrip()
+	for _, entry := range bundle.Templates {
+		if entry.ID == templateID {
+			latest := entry.Versions[len(entry.Versions)-1]
+			latest.Localizations["de_de"] = latest.Localizations["de-DE"]
+			delete(latest.Localizations, "de-DE")
+		}
+	}
+	results, err := production.templateManager.ImportTemplates(bundle, models.TemplateConflictNewVersion, "deployer")
+	require.NoError(t, err)
+	outcomes := make(map[string]models.TemplateImportResult)
+	for _, result := range results {
+		outcomes[result.ID] = result
+	}
+	assert.Equal(t, models.TemplateImportNewVersion, outcomes[templateID].Outcome)
+	assert.Equal(t, 2, outcomes[templateID].Version)
+
+	imported, err = production.templateManager.GetTemplateByID(templateID)
+	require.NoError(t, err)
+	assert.Equal(t, "en", imported.Locale)
+	assert.Len(t, imported.Localizations, 2)
+	assert.Equal(t, "Ihre Quittung", imported.Localizations["de-DE"].Subject)
+	assert.Equal(t, models.TemplateStatusDraft, imported.Status)
+	audit, err := production.templateManager.GetTemplateAudit(templateID)
+	require.NoError(t, err)
+	require.Len(t, audit, 2)
+	require.Len(t, audit[1].Changes, 1)
+	assert.Equal(t, "localizations", audit[1].Changes[0].Field)
+
+	results, err = production.templateManager.ImportTemplates(roundTrip(), models.TemplateConflictNewVersion, "deployer")
+	require.NoError(t, err)
+	for _, result := range results {
+		assert.Equal(t, models.TemplateImportUnchanged, result.Outcome, result.Name)
+	}
+}
+
+func TestImportTemplates_LeavesPredefinedTemplates(t *testing.T) {
+	nm, _, _ := newTestManager(t, 0, DefaultConfig())
+	const welcomeID = "550e8400-e29b-41d4-a716-446655440000"
+	welcome, err := nm.templateManager.GetTemplateByID(welcomeID)
+	require.NoError(t, err)
+
+	changed := *welcome
+	changed.Content.Subject = "Welcome aboard"
+	bundle := &models.TemplateBundle{Format: 1, Templates: []models.TemplateBundleEntry{
+		{ID: welcomeID, Versions: []models.Template{changed}},
+	}}
+	unchanged := &models.TemplateBundle{Format: 1, Templates: []models.TemplateBundleEntry{
+		{ID: welcomeID, Versions: []models.Template{*welcome}},
+	}}
+
+	for _, mode := range []models.TemplateConflictMode{models.TemplateConflictOverwrite, models.TemplateConflictNewVersion} {
+		results, err := nm.templateManager.ImportTemplates(bundle, mode, "deployer")
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, models.TemplateImportConflict, results[0].Outcome, mode)
+		assert.Equal(t, models.ErrPredefinedTemplate.Error(), results[0].Error, mode)
+		assert.Equal(t, 1, results[0].Version, mode)
+
+		results, err = nm.templateManager.ImportTemplates(unchanged, mode, "deployer")
+		require.NoError(t, err)
+		assert.Equal(t, models.TemplateImportUnchanged, results[0].Outcome, mode)
+		assert.Empty(t, results[0].Error, mode)
+	}
+
+	latest, err := nm.templateManager.GetTemplateByID(welcomeID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, latest.Version)
+	assert.Equal(t, welcome.Content.Subject, latest.Content.Subject)
+}
+
+func TestImportTemplates_RejectsInvalidBundles(t *testing.T) {
+	nm, _, _ := newTestManager(t, 0, DefaultConfig())
+	before := len(nm.ListTemplates())
+
+	valid := models.Template{Name: "Alert", Type: models.SlackNotification, Version: 1, Content: models.TemplateContent{Text: "Alert"}}
+	emailVersion := valid
+	emailVersion.Version = 2
+	emailVersion.Type = models.EmailNotification
+	emailVersion.Content = models.TemplateContent{Subject: "Alert", EmailBody: "Alert"}
+
+	for name, bundle := range map[string]*models.TemplateBundle{
+		"unknown format":   {Format: 99},
+		"missing id":       {Format: 1, Templates: []models.TemplateBundleEntry{{Versions: []models.Template{valid}}}},
+		"no versions":      {Format: 1, Templates: []models.TemplateBundleEntry{{ID: "t-1"}}},
+		"listed twice":     {Format: 1, Templates: []models.TemplateBundleEntry{{ID: "t-1", Versions: []models.Template{valid}}, {ID: "t-1", Versions: []models.Template{valid}}}},
+		"version order":    {Format: 1, Templates: []models.TemplateBundleEntry{{ID: "t-1", Versions: []models.Template{valid, valid}}}},
+		"type changed":     {Format: 1, Templates: []models.TemplateBundleEntry{{ID: "t-1", Versions: []models.Template{valid, emailVersion}}}},
+		"invalid content":  {Format: 1, Templates: []models.TemplateBundleEntry{{ID: "t-1", Versions: []models.Template{{Type: models.SlackNotification, Version: 1}}}}},
+		"later is invalid": {Format: 1, Templates: []models.TemplateBundleEntry{{ID: "t-1", Versions: []models.Template{valid}}, {ID: "t-2"}}},
+	} {
+		_, err := nm.ImportTemplates(bundle, models.TemplateConflictOverwrite, "deployer")
+		assert.ErrorIs(t, err, models.ErrInvalidTemplateBundle, name)
+	}
+	assert.Len(t, nm.ListTemplates(), before, "invalid bundles import nothing")
+
+	_, err := nm.ImportTemplates(&models.TemplateBundle{Format: 1}, "replace", "deployer")
+	assert.ErrorIs(t, err, models.ErrInvalidConflictMode)
+}
+
+func TestProcessNotificationRequest_MarkdownTemplate(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 1, DefaultConfig())
+
+	created, err := nm.CreateTemplate(&models.Template{
+		Name:              "Order Shipped",
+		Type:              models.EmailNotification,
+		Content:           models.TemplateContent{Subject: "Order {{order_id}}", EmailBody: "Order **{{order_id}}** shipped"},
+		RequiredVariables: []string{"order_id"},
+		RenderMode:        models.EmailRenderModeMarkdown,
+	})
+	require.NoError(t, err)
+
+	_, err = nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "email",
+		Template:   &models.TemplateData{ID: created.(*models.TemplateResponse).ID, Version: 1, Data: map[string]interface{}{"order_id": "ORD-1"}},
+		Recipients: recipients,
+	})
+	require.NoError(t, err)
+
+	// The Markdown body is converted by the email service at send time
+	var message models.EmailNotificationRequest
+	require.NoError(t, json.Unmarshal([]byte(<-kafkaService.GetEmailChannel()), &message))
+	assert.Equal(t, "Order **ORD-1** shipped", message.Content.EmailBody)
+	assert.Equal(t, models.EmailRenderModeMarkdown, message.Content.RenderMode)
+}
+
+func TestProcessNotificationRequest_RenderedContentExceedsLimit(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 1, DefaultConfig())
+
+	created, err := nm.CreateTemplate(&models.Template{
+		Name:              "Celebration",
+		Type:              models.SlackNotification,
+		Content:           models.TemplateContent{Text: "Congrats {{name}}! {{emoji}}"},
+		RequiredVariables: []string{"name", "emoji"},
+	})
+	require.NoError(t, err)
+	templateID := created.(*models.TemplateResponse).ID
+
+	send := func(emoji string) error {
+		_, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+			Type:       "slack",
+			Template:   &models.TemplateData{ID: templateID, Version: 1, Data: map[string]interface{}{"name": "Jane", "emoji": emoji}},
+			Recipients: recipients,
+		})
+		return err
+	}
+
+	// Emoji count as one character each, so 2900 of them still fit in a Slack message
+	require.NoError(t, send(strings.Repeat("🎉", 2900)))
+	queued := len(kafkaService.GetSlackChannel())
+
+	err = send(strings.Repeat("🎉", 3000))
+	assert.ErrorIs(t, err, ErrContentLimitExceeded)
+	assert.Contains(t, err.Error(), "slack text cannot exceed 3000 characters")
+	assert.Len(t, kafkaService.GetSlackChannel(), queued)
+}
+
+func TestProcessNotificationRequest_RecipientDataOverrides(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 2, DefaultConfig())
+
+	created, err := nm.CreateTemplate(&models.Template{
+		Name:              "Invoice",
+		Type:              models.EmailNotification,
+		Content:           models.TemplateContent{Subject: "Your {{company}} invoice", EmailBody: "Hi {{name}}, you owe {{amount}}."},
+		RequiredVariables: []string{"company", "name", "amount"},
+	})
+	require.NoError(t, err)
+	templateID := created.(*models.TemplateResponse).ID
+
+	request := &models.NotificationRequest{
+		Type: "email",
+		Template: &models.TemplateData{
+			ID:      templateID,
+			Version: 1,
+			Data:    map[string]interface{}{"company": "Acme", "name": "there"},
+			RecipientData: map[string]map[string]interface{}{
+				recipients[0]: {"amount": "$10"},
+				recipients[1]: {"amount": "$20", "name": "Bob"},
+			},
+		},
+		Recipients: recipients,
+	}
+	_, err = nm.ProcessNotificationRequest(request)
+	require.NoError(t, err)
+
+	emailChannel := kafkaService.GetEmailChannel()
+	require.Len(t, emailChannel, 2)
+	bodies := make(map[string]string)
+	for i := 0; i < 2; i++ {
+		var message models.EmailNotificationRequest
+		require.NoError(t, json.Unmarshal([]byte(<-emailChannel), &message))
+		assert.Equal(t, "Your Acme invoice", message.Content.Subject)
+		bodies[message.UserID] = message.Content.EmailBody
+	}
+	assert.Equal(t, "Hi there, you owe $10.", bodies[recipients[0]])
+	assert.Equal(t, "Hi Bob, you owe $20.", bodies[recipients[1]])
+
+	// A variable missing from the shared data must be overridden for every recipient
+	delete(request.Template.RecipientData, recipients[1])
+	_, err = nm.ProcessNotificationRequest(request)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "template validation failed for recipient "+recipients[1])
+}
+
+func TestProcessNotificationRequest_RecipientVariables(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 2, DefaultConfig())
+
+	created, err := nm.CreateTemplate(&models.Template{
+		Name:              "Greeting",
+		Type:              models.EmailNotification,
+		Content:           models.TemplateContent{Subject: "Hello {{recipient_first_name}}", EmailBody: "Hi {{recipient_name}}, we sent this to {{recipient_email}}. {{promo}}"},
+		RequiredVariables: []string{"recipient_first_name", "promo"},
+	})
+	require.NoError(t, err)
+	templateID := created.(*models.TemplateResponse).ID
+
+	request := &models.NotificationRequest{
+		Type: "email",
+		Template: &models.TemplateData{
+			ID:      templateID,
+			Version: 1,
+			Data:    map[string]interface{}{"promo": "Enjoy 10% off."},
+			RecipientData: map[string]map[string]interface{}{
+				recipients[1]: {"recipient_first_name": "Robin"},
+			},
+		},
+		Recipients: recipients,
+	}
+	_, err = nm.ProcessNotificationRequest(request)
+	require.NoError(t, err)
+
+	emailChannel := kafkaService.GetEmailChannel()
+	require.Len(t, emailChannel, 2)
+	messages := make(map[string]models.EmailNotificationRequest)
+	for i := 0; i < 2; i++ {
+		var message models.EmailNotificationRequest
+		require.NoError(t, json.Unmarshal([]byte(<-emailChannel), &message))
+		messages[message.UserID] = message
+	}
+	assert.Equal(t, "Hello Stream", messages[recipients[0]].Content.Subject)
+	assert.Equal(t, "Hi Stream User 000, we sent this to stream.user000@company.com. Enjoy 10% off.", messages[recipients[0]].Content.EmailBody)
+	// Caller supplied values take precedence over the user record
+	assert.Equal(t, "Hello Robin", messages[recipients[1]].Content.Subject)
+}
+
+func TestProcessNotificationRequest_Reason(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 1, DefaultConfig())
+	reason := "You received this because you subscribed to order updates"
+
+	result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "email",
+		Content:    map[string]interface{}{"subject": "Order shipped", "email_body": "<p>On its way</p>"},
+		Recipients: recipients,
+		Reason:     reason,
+	})
+	require.NoError(t, err)
+
+	var email models.EmailNotificationRequest
+	require.NoError(t, json.Unmarshal([]byte(<-kafkaService.GetEmailChannel()), &email))
+	assert.Equal(t, reason, email.Content.Reason)
+
+	status, err := nm.GetNotificationStatus(result.(map[string]interface{})["id"].(string))
+	require.NoError(t, err)
+	encoded, err := json.Marshal(status)
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"reason":"`+reason+`"`)
+
+	// In-app notifications keep the reason with the inbox item
+	_, err = nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "in_app",
+		Content:    map[string]interface{}{"title": "Order shipped", "body": "On its way"},
+		Recipients: recipients,
+		Reason:     reason,
+	})
+	require.NoError(t, err)
+	items := nm.inbox.List(recipients[0], false, false)
+	require.Len(t, items, 1)
+	assert.Equal(t, reason, items[0].Reason)
+}
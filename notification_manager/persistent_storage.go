@@ -0,0 +1,363 @@
+package notification_manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/gaurav2721/notification-service/external_services/notificationstore"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// notificationStorageErrorsTotal counts failed operations on the notification store
+var notificationStorageErrorsTotal = metrics.DefaultRegistry.NewCounterVec(
+	"notification_storage_errors_total",
+	"Failed notification store operations by operation.",
+	"operation",
+)
+
+// PersistentStorage keeps notification records in memory, where status changes are watched and
+// observed, and writes every change through to a notification store. Records that are not in
+// memory, such as those stored before a restart, are loaded from the store when they are
+// needed; queries over several records are answered by the store.
+type PersistentStorage struct {
+	*InMemoryStorage
+	store notificationstore.Store
+}
+
+// NewPersistentStorage creates a storage writing through to store
+func NewPersistentStorage(store notificationstore.Store) *PersistentStorage {
+	return &PersistentStorage{InMemoryStorage: NewInMemoryStorage(), store: store}
+}
+
+// StoreNotification stores a notification record; it fails when the record cannot be written
+// to the store
+func (s *PersistentStorage) StoreNotification(notificationID string, notification *models.NotificationRequest) error {
+	if err := s.InMemoryStorage.StoreNotification(notificationID, notification); err != nil {
+		return err
+	}
+	if err := s.persist(notificationID); err != nil {
+		s.InMemoryStorage.DeleteNotification(notificationID)
+		return err
+	}
+	return nil
+}
+
+// GetNotification retrieves a notification record by ID
+func (s *PersistentStorage) GetNotification(notificationID string) (*NotificationRecord, error) {
+	s.load(notificationID)
+	return s.InMemoryStorage.GetNotification(notificationID)
+}
+
+// WatchStatus returns the current status of a notification together with a channel that is
+// closed on its next status change
+func (s *PersistentStorage) WatchStatus(notificationID string) (NotificationStatus, <-chan struct{}, error) {
+	s.load(notificationID)
+	return s.InMemoryStorage.WatchStatus(notificationID)
+}
+
+// UpdateNotificationStatus updates the status of a notification
+func (s *PersistentStorage) UpdateNotificationStatus(notificationID string, status NotificationStatus, errorMsg string) error {
+	s.load(notificationID)
+	if err := s.InMemoryStorage.UpdateNotificationStatus(notificationID, status, errorMsg); err != nil {
+		return err
+	}
+	s.persistLogged(notificationID)
+	return nil
+}
+
+// ExpireScheduled moves a scheduled notification to expired and records the reason in its
+// audit log. It returns false when the notification is no longer scheduled.
+func (s *PersistentStorage) ExpireScheduled(notificationID string, reason string) (bool, error) {
+	s.load(notificationID)
+	expired, err := s.InMemoryStorage.ExpireScheduled(notificationID, reason)
+	if expired {
+		s.persistLogged(notificationID)
+	}
+	return expired, err
+}
+
+// CancelScheduled moves a scheduled notification to cancelled and records the reason in its
+// audit log. It returns false when the notification is no longer scheduled.
+func (s *PersistentStorage) CancelScheduled(notificationID string, reason string) (bool, error) {
+	s.load(notificationID)
+	cancelled, err := s.InMemoryStorage.CancelScheduled(notificationID, reason)
+	if cancelled {
+		s.persistLogged(notificationID)
+	}
+	return cancelled, err
+}
+
+// QueueScheduled moves a scheduled notification to queued once its job takes it. It returns
+// false when the notification is no longer scheduled.
+func (s *PersistentStorage) QueueScheduled(notificationID string) (bool, error) {
+	s.load(notificationID)
+	queued, err := s.InMemoryStorage.QueueScheduled(notificationID)
+	if queued {
+		s.persistLogged(notificationID)
+	}
+	return queued, err
+}
+
+// FinishQueued moves a queued notification to sent or failed. It returns false when the
+// notification is no longer queued.
+func (s *PersistentStorage) FinishQueued(notificationID string, status NotificationStatus, errorMsg string) (bool, error) {
+	s.load(notificationID)
+	finished, err := s.InMemoryStorage.FinishQueued(notificationID, status, errorMsg)
+	if finished {
+		s.persistLogged(notificationID)
+	}
+	return finished, err
+}
+
+// GetAllNotifications retrieves all stored notifications
+func (s *PersistentStorage) GetAllNotifications() []*NotificationRecord {
+	if records, ok := s.find(notificationstore.Query{}); ok {
+		return records
+	}
+	return s.InMemoryStorage.GetAllNotifications()
+}
+
+// GetNotificationsByStatus retrieves notifications by status
+func (s *PersistentStorage) GetNotificationsByStatus(status NotificationStatus) []*NotificationRecord {
+	if records, ok := s.find(notificationstore.Query{Status: string(status)}); ok {
+		return records
+	}
+	return s.InMemoryStorage.GetNotificationsByStatus(status)
+}
+
+// GetNotificationsByExternalID retrieves the notifications created with an external reference ID, oldest first
+func (s *PersistentStorage) GetNotificationsByExternalID(externalID string) []*NotificationRecord {
+	if records, ok := s.find(notificationstore.Query{ExternalID: externalID}); ok {
+		return records
+	}
+	return s.InMemoryStorage.GetNotificationsByExternalID(externalID)
+}
+
+// FindNotifications retrieves the notifications matching the filter, oldest first
+func (s *PersistentStorage) FindNotifications(filter NotificationFilter) []*NotificationRecord {
+	query := notificationstore.Query{
+		ExternalID: filter.ExternalID,
+		Status:     string(filter.Status),
+		Type:       filter.Type,
+		Tags:       filter.Tags,
+	}
+	if records, ok := s.find(query); ok {
+		return records
+	}
+	return s.InMemoryStorage.FindNotifications(filter)
+}
+
+// DeleteNotification removes a notification from memory and from the store
+func (s *PersistentStorage) DeleteNotification(notificationID string) error {
+	s.load(notificationID)
+	if err := s.InMemoryStorage.DeleteNotification(notificationID); err != nil {
+		return err
+	}
+	if err := s.store.Delete(context.Background(), notificationID); err != nil {
+		notificationStorageErrorsTotal.Inc("delete")
+		return err
+	}
+	return nil
+}
+
+// GetStorageStats returns basic statistics about the notifications in the store
+func (s *PersistentStorage) GetStorageStats() map[string]interface{} {
+	counts, err := s.store.CountByStatus(context.Background())
+	if err != nil {
+		notificationStorageErrorsTotal.Inc("count")
+		moduleLog.Error("Failed to count notifications in the notification store", logger.Fields{"error": err.Error()})
+		return s.InMemoryStorage.GetStorageStats()
+	}
+
+	total := 0
+	statusCounts := make(map[NotificationStatus]int, len(counts))
+	for status, count := range counts {
+		statusCounts[NotificationStatus(status)] = count
+		total += count
+	}
+	return map[string]interface{}{
+		"total_notifications": total,
+		"status_counts":       statusCounts,
+	}
+}
+
+// StartProgress resets the fan-out progress of a notification
+func (s *PersistentStorage) StartProgress(notificationID string, totalRecipients int) error {
+	s.load(notificationID)
+	if err := s.InMemoryStorage.StartProgress(notificationID, totalRecipients); err != nil {
+		return err
+	}
+	s.persistLogged(notificationID)
+	return nil
+}
+
+// AddProgress adds the counters of a processed recipient batch to the notification progress
+func (s *PersistentStorage) AddProgress(notificationID string, batch NotificationProgress) error {
+	s.load(notificationID)
+	if err := s.InMemoryStorage.AddProgress(notificationID, batch); err != nil {
+		return err
+	}
+	s.persistLogged(notificationID)
+	return nil
+}
+
+// CompleteProgress marks the fan-out of a notification as finished
+func (s *PersistentStorage) CompleteProgress(notificationID string) error {
+	s.load(notificationID)
+	if err := s.InMemoryStorage.CompleteProgress(notificationID); err != nil {
+		return err
+	}
+	s.persistLogged(notificationID)
+	return nil
+}
+
+// GetProgress returns a snapshot of the fan-out progress of a notification
+func (s *PersistentStorage) GetProgress(notificationID string) (*NotificationProgress, error) {
+	s.load(notificationID)
+	return s.InMemoryStorage.GetProgress(notificationID)
+}
+
+// load brings the record of a notification into memory from the store, unless it is there
+// already. Records the store does not have are reported as missing by the in-memory storage.
+func (s *PersistentStorage) load(notificationID string) {
+	if notificationID == "" || s.InMemoryStorage.has(notificationID) {
+		return
+	}
+	document, err := s.store.Load(context.Background(), notificationID)
+	if err != nil {
+		if !errors.Is(err, notificationstore.ErrNotFound) {
+			notificationStorageErrorsTotal.Inc("load")
+			moduleLog.Error("Failed to load notification from the notification store", logger.Fields{
+				"notification_id": notificationID,
+				"error":           err.Error(),
+			})
+		}
+		return
+	}
+	record, err := decodeRecord(document)
+	if err != nil {
+		notificationStorageErrorsTotal.Inc("load")
+		moduleLog.Error("Failed to decode notification from the notification store", logger.Fields{
+			"notification_id": notificationID,
+			"error":           err.Error(),
+		})
+		return
+	}
+	s.InMemoryStorage.restore(record)
+}
+
+// find returns the records of the documents matching a query, preferring the records in
+// memory, which status watchers hold. It returns false when the store cannot be queried.
+func (s *PersistentStorage) find(query notificationstore.Query) ([]*NotificationRecord, bool) {
+	documents, err := s.store.Find(context.Background(), query)
+	if err != nil {
+		notificationStorageErrorsTotal.Inc("find")
+		moduleLog.Error("Failed to query the notification store, answering from memory", logger.Fields{"error": err.Error()})
+		return nil, false
+	}
+
+	records := make([]*NotificationRecord, 0, len(documents))
+	for i := range documents {
+		if record, err := s.InMemoryStorage.GetNotification(documents[i].ID); err == nil {
+			records = append(records, record)
+			continue
+		}
+		record, err := decodeRecord(&documents[i])
+		if err != nil {
+			notificationStorageErrorsTotal.Inc("find")
+			moduleLog.Error("Failed to decode notification from the notification store", logger.Fields{
+				"notification_id": documents[i].ID,
+				"error":           err.Error(),
+			})
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, true
+}
+
+// persist writes the record of a notification to the store
+func (s *PersistentStorage) persist(notificationID string) error {
+	document, err := s.InMemoryStorage.document(notificationID)
+	if err != nil {
+		return err
+	}
+	if err := s.store.Save(context.Background(), *document); err != nil {
+		notificationStorageErrorsTotal.Inc("save")
+		return err
+	}
+	return nil
+}
+
+// persistLogged writes the record of a notification to the store after its change was applied
+// in memory; failures are logged, as the change cannot be undone for status watchers
+func (s *PersistentStorage) persistLogged(notificationID string) {
+	if err := s.persist(notificationID); err != nil {
+		moduleLog.Error("Failed to write notification to the notification store", logger.Fields{
+			"notification_id": notificationID,
+			"error":           err.Error(),
+		})
+	}
+}
+
+// has reports whether the storage holds the record of a notification
+func (s *InMemoryStorage) has(notificationID string) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	_, exists := s.notifications[notificationID]
+	return exists
+}
+
+// document encodes the record of a notification for the notification store
+func (s *InMemoryStorage) document(notificationID string) (*notificationstore.Document, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	record, exists := s.notifications[notificationID]
+	if !exists {
+		return nil, ErrNotificationNotFound
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	return &notificationstore.Document{
+		ID:         record.ID,
+		ExternalID: record.ExternalID,
+		Type:       record.Type,
+		Status:     string(record.Status),
+		Tenant:     record.Tenant,
+		Tags:       record.Tags,
+		CreatedAt:  record.CreatedAt,
+		UpdatedAt:  record.UpdatedAt,
+		Data:       data,
+	}, nil
+}
+
+// restore adds a record loaded from the notification store, unless the storage got one for the
+// notification in the meantime. Status change observers are not called.
+func (s *InMemoryStorage) restore(record *NotificationRecord) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.notifications[record.ID]; exists {
+		return
+	}
+	record.changed = make(chan struct{})
+	s.notifications[record.ID] = record
+	if record.ExternalID != "" {
+		s.externalIDs[record.ExternalID] = append(s.externalIDs[record.ExternalID], record.ID)
+	}
+}
+
+// decodeRecord decodes the record of a notification store document
+func decodeRecord(document *notificationstore.Document) (*NotificationRecord, error) {
+	var record NotificationRecord
+	if err := json.Unmarshal(document.Data, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
@@ -0,0 +1,173 @@
+package notification_manager
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/external_services/notificationstore"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNotificationStore keeps documents in a map, as a database would across restarts
+type fakeNotificationStore struct {
+	mu        sync.Mutex
+	documents map[string]notificationstore.Document
+	saveErr   error
+	findErr   error
+}
+
+func newFakeNotificationStore() *fakeNotificationStore {
+	return &fakeNotificationStore{documents: make(map[string]notificationstore.Document)}
+}
+
+func (s *fakeNotificationStore) Save(ctx context.Context, document notificationstore.Document) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.saveErr != nil {
+		return s.saveErr
+	}
+	if stored, ok := s.documents[document.ID]; ok && stored.UpdatedAt.After(document.UpdatedAt) {
+		return nil
+	}
+	s.documents[document.ID] = document
+	return nil
+}
+
+func (s *fakeNotificationStore) Load(ctx context.Context, id string) (*notificationstore.Document, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	document, ok := s.documents[id]
+	if !ok {
+		return nil, notificationstore.ErrNotFound
+	}
+	return &document, nil
+}
+
+func (s *fakeNotificationStore) Find(ctx context.Context, query notificationstore.Query) ([]notificationstore.Document, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.findErr != nil {
+		return nil, s.findErr
+	}
+	var documents []notificationstore.Document
+	for _, document := range s.documents {
+		filter := NotificationFilter{ExternalID: query.ExternalID, Status: NotificationStatus(query.Status), Type: query.Type, Tags: query.Tags}
+		if filter.Matches(&NotificationRecord{ExternalID: document.ExternalID, Status: NotificationStatus(document.Status), Type: document.Type, Tags: document.Tags}) {
+			documents = append(documents, document)
+		}
+	}
+	sort.Slice(documents, func(i, j int) bool { return documents[i].CreatedAt.Before(documents[j].CreatedAt) })
+	return documents, nil
+}
+
+func (s *fakeNotificationStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.documents, id)
+	return nil
+}
+
+func (s *fakeNotificationStore) CountByStatus(ctx context.Context) (map[string]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[string]int)
+	for _, document := range s.documents {
+		counts[document.Status]++
+	}
+	return counts, nil
+}
+
+func (s *fakeNotificationStore) Close() error {
+	return nil
+}
+
+func TestPersistentStorage_SurvivesRestart(t *testing.T) {
+	store := newFakeNotificationStore()
+	storage := NewPersistentStorage(store)
+
+	request := &models.NotificationRequest{
+		Type:       "email",
+		Content:    map[string]interface{}{"subject": "Order shipped"},
+		Recipients: []string{"user-001"},
+		ExternalID: "order-42",
+		Tags:       []string{"orders"},
+	}
+	require.NoError(t, storage.StoreNotification("notif-1", request))
+	require.NoError(t, storage.StartProgress("notif-1", 1))
+	require.NoError(t, storage.UpdateNotificationStatus("notif-1", StatusSent, ""))
+
+	// A new process reads the records the previous one wrote
+	restarted := NewPersistentStorage(store)
+	record, err := restarted.GetNotification("notif-1")
+	require.NoError(t, err)
+	assert.Equal(t, StatusSent, record.Status)
+	assert.NotNil(t, record.SentAt)
+	assert.Equal(t, "Order shipped", record.Content["subject"])
+	assert.Equal(t, []string{"orders"}, record.Tags)
+
+	progress, err := restarted.GetProgress("notif-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, progress.TotalRecipients)
+
+	status, changed, err := restarted.WatchStatus("notif-1")
+	require.NoError(t, err)
+	assert.Equal(t, StatusSent, status)
+	require.NoError(t, restarted.UpdateNotificationStatus("notif-1", StatusFailed, "bounced"))
+	<-changed
+
+	document, err := store.Load(context.Background(), "notif-1")
+	require.NoError(t, err)
+	assert.Equal(t, string(StatusFailed), document.Status)
+
+	_, err = restarted.GetNotification("missing")
+	assert.Error(t, err)
+}
+
+func TestPersistentStorage_QueriesHistory(t *testing.T) {
+	store := newFakeNotificationStore()
+	storage := NewPersistentStorage(store)
+	for _, id := range []string{"notif-1", "notif-2"} {
+		require.NoError(t, storage.StoreNotification(id, &models.NotificationRequest{
+			Type:       "email",
+			Recipients: []string{"user-001"},
+			ExternalID: "order-42",
+			Tags:       []string{"orders"},
+		}))
+	}
+	require.NoError(t, storage.StoreNotification("notif-3", &models.NotificationRequest{Type: "slack", Recipients: []string{"user-002"}}))
+
+	restarted := NewPersistentStorage(store)
+	records := restarted.FindNotifications(NotificationFilter{ExternalID: "order-42", Tags: []string{"orders"}})
+	require.Len(t, records, 2)
+	assert.Equal(t, "email", records[0].Type)
+	assert.Len(t, restarted.GetAllNotifications(), 3)
+	assert.Len(t, restarted.GetNotificationsByExternalID("order-42"), 2)
+
+	stats := restarted.GetStorageStats()
+	assert.Equal(t, 3, stats["total_notifications"])
+	assert.Equal(t, map[NotificationStatus]int{StatusPending: 3}, stats["status_counts"])
+
+	require.NoError(t, restarted.DeleteNotification("notif-3"))
+	_, err := store.Load(context.Background(), "notif-3")
+	assert.ErrorIs(t, err, notificationstore.ErrNotFound)
+
+	// Records in memory answer queries while the store is unavailable
+	store.findErr = errors.New("connection refused")
+	assert.Len(t, storage.FindNotifications(NotificationFilter{ExternalID: "order-42"}), 2)
+}
+
+func TestPersistentStorage_StoreFailsWithoutStore(t *testing.T) {
+	store := newFakeNotificationStore()
+	store.saveErr = errors.New("connection refused")
+	storage := NewPersistentStorage(store)
+
+	err := storage.StoreNotification("notif-1", &models.NotificationRequest{Type: "email", Recipients: []string{"user-001"}})
+	assert.Error(t, err)
+	_, err = storage.GetNotification("notif-1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,75 @@
+package notification_manager
+
+import (
+	"math"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// ProgressReport describes how far a notification has progressed through fan-out and delivery
+type ProgressReport struct {
+	TotalRecipients int       `json:"total_recipients"`
+	Resolved        int       `json:"resolved"`
+	Skipped         int       `json:"skipped"`
+	Suppressed      int       `json:"suppressed"`
+	Deferred        int       `json:"deferred"`
+	Unreachable     int       `json:"unreachable"`
+	Queued          int       `json:"queued"`
+	Sent            int       `json:"sent"`
+	Failed          int       `json:"failed"`
+	Delivered       int       `json:"delivered"`
+	Quarantined     int       `json:"quarantined"`
+	PercentComplete float64   `json:"percent_complete"`
+	ETASeconds      *int64    `json:"eta_seconds,omitempty"`
+	StartedAt       time.Time `json:"started_at"`
+}
+
+// buildProgressReport combines the fan-out counters kept in storage with the delivery
+// outcomes recorded by the consumers. While recipients are still being resolved the total
+// number of messages is extrapolated from the batches processed so far.
+func buildProgressReport(progress *NotificationProgress, stats models.DeliveryStats, now time.Time) *ProgressReport {
+	report := &ProgressReport{
+		TotalRecipients: progress.TotalRecipients,
+		Resolved:        progress.Resolved,
+		Skipped:         progress.Skipped,
+		Suppressed:      progress.Suppressed,
+		Deferred:        progress.Deferred,
+		Unreachable:     progress.Unreachable,
+		Queued:          progress.Queued,
+		Sent:            stats.Sent,
+		Failed:          stats.Failed + progress.Failed,
+		Delivered:       stats.Delivered,
+		Quarantined:     stats.Quarantined,
+		StartedAt:       progress.StartedAt,
+	}
+
+	// Every queued message ends up sent, failed or quarantined, recipients that could not be queued count as failed.
+	// Recipients held back by do-not-disturb are expected to receive at least one message later.
+	expected := float64(progress.Queued + progress.Failed + progress.Deferred)
+	processed := progress.Resolved + progress.Skipped
+	if progress.CompletedAt == nil {
+		if processed == 0 {
+			return report
+		}
+		expected = expected * float64(progress.TotalRecipients) / float64(processed)
+	}
+
+	done := float64(report.Sent + report.Failed + report.Quarantined)
+	switch {
+	case expected <= 0:
+		if progress.CompletedAt != nil {
+			report.PercentComplete = 100
+		}
+	default:
+		report.PercentComplete = math.Min(100, math.Round(done/expected*1000)/10)
+	}
+
+	if report.PercentComplete > 0 && report.PercentComplete < 100 {
+		elapsed := now.Sub(progress.StartedAt).Seconds()
+		eta := int64(math.Ceil(elapsed * (100 - report.PercentComplete) / report.PercentComplete))
+		report.ETASeconds = &eta
+	}
+
+	return report
+}
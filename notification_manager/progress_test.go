@@ -0,0 +1,64 @@
+package notification_manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildProgressReport(t *testing.T) {
+	startedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	completedAt := startedAt.Add(time.Minute)
+
+	t.Run("not started resolving", func(t *testing.T) {
+		report := buildProgressReport(&NotificationProgress{TotalRecipients: 1000, StartedAt: startedAt}, models.DeliveryStats{}, startedAt)
+		assert.Zero(t, report.PercentComplete)
+		assert.Nil(t, report.ETASeconds)
+	})
+
+	t.Run("extrapolates while resolving", func(t *testing.T) {
+		progress := &NotificationProgress{
+			TotalRecipients: 1000,
+			Resolved:        250,
+			Queued:          250,
+			StartedAt:       startedAt,
+		}
+		report := buildProgressReport(progress, models.DeliveryStats{Sent: 200}, startedAt.Add(20*time.Second))
+
+		// 200 of an estimated 1000 messages delivered after 20s
+		assert.Equal(t, 20.0, report.PercentComplete)
+		require.NotNil(t, report.ETASeconds)
+		assert.Equal(t, int64(80), *report.ETASeconds)
+	})
+
+	t.Run("counts enqueue failures as failed", func(t *testing.T) {
+		progress := &NotificationProgress{
+			TotalRecipients: 10,
+			Resolved:        10,
+			Queued:          8,
+			Failed:          2,
+			StartedAt:       startedAt,
+			CompletedAt:     &completedAt,
+		}
+		report := buildProgressReport(progress, models.DeliveryStats{Sent: 7, Failed: 1}, completedAt)
+
+		assert.Equal(t, 7, report.Sent)
+		assert.Equal(t, 3, report.Failed)
+		assert.Equal(t, 100.0, report.PercentComplete)
+		assert.Nil(t, report.ETASeconds)
+	})
+
+	t.Run("nothing to deliver", func(t *testing.T) {
+		progress := &NotificationProgress{
+			TotalRecipients: 3,
+			Skipped:         3,
+			StartedAt:       startedAt,
+			CompletedAt:     &completedAt,
+		}
+		report := buildProgressReport(progress, models.DeliveryStats{}, completedAt)
+		assert.Equal(t, 100.0, report.PercentComplete)
+	})
+}
@@ -0,0 +1,156 @@
+package notification_manager
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"sync"
+
+	"github.com/gaurav2721/notification-service/qrcode"
+	"github.com/sirupsen/logrus"
+)
+
+// qrCodeHelper is the template helper that renders a QR code of its argument,
+// e.g. {{qrcode ticket_url}} or {{qrcode "https://example.com"}}
+const qrCodeHelper = "qrcode"
+
+const (
+	// qrCodeModulePixels is the size in pixels of a QR code module in the rendered image
+	qrCodeModulePixels = 4
+	// qrCodeKeyPrefix is the object store prefix of QR code images; they are content addressed
+	// and not part of the media library, so the media cleanup never removes them
+	qrCodeKeyPrefix = "qrcodes/"
+	// maxCachedQRCodes bounds the images remembered as stored; the cache is emptied when full
+	maxCachedQRCodes = 10000
+)
+
+// qrCodeImage is a rendered QR code image
+type qrCodeImage struct {
+	URL   string
+	Width int
+}
+
+// qrCodeCache remembers the QR code images already stored, so repeated renders of the same URL
+// do not upload the image again
+type qrCodeCache struct {
+	mu     sync.RWMutex
+	images map[string]qrCodeImage
+}
+
+// newQRCodeCache creates an empty QR code cache
+func newQRCodeCache() *qrCodeCache {
+	return &qrCodeCache{images: make(map[string]qrCodeImage)}
+}
+
+func (c *qrCodeCache) get(key string) (qrCodeImage, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	image, ok := c.images[key]
+	return image, ok
+}
+
+func (c *qrCodeCache) put(key string, image qrCodeImage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.images) >= maxCachedQRCodes {
+		c.images = make(map[string]qrCodeImage)
+	}
+	c.images[key] = image
+}
+
+// reset forgets the stored images, e.g. when the media store is replaced
+func (c *qrCodeCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.images = make(map[string]qrCodeImage)
+}
+
+// qrCodeArgument resolves the argument of the qrcode helper: a quoted string is used as it is,
+// anything else names a template variable. ok is false when the variable has no data.
+func qrCodeArgument(argument string, data map[string]interface{}) (string, bool) {
+	argument = strings.TrimSpace(argument)
+	if len(argument) >= 2 && (argument[0] == '"' || argument[0] == '\'') && argument[len(argument)-1] == argument[0] {
+		return argument[1 : len(argument)-1], true
+	}
+
+	value, ok := data[argument]
+	if !ok {
+		return "", false
+	}
+	var builder strings.Builder
+	writeTemplateValue(&builder, value)
+	return builder.String(), true
+}
+
+// renderQRCode renders the qrcode helper for text. HTML email bodies embed the image, other
+// content gets the image URL escaped by escape. When the image cannot be created the text
+// itself is inserted, so the recipient still gets the link.
+func (nm *NotificationManagerImpl) renderQRCode(text string, escape templateEscaper, embed bool) string {
+	if escape == nil {
+		escape = func(value string) string { return value }
+	}
+
+	image, err := nm.qrCodeImage(text, embed)
+	if errors.Is(err, ErrMediaStoreNotConfigured) {
+		logrus.Debug("No media store for QR code images, inserting the QR code text instead")
+		return escape(text)
+	}
+	if err != nil {
+		logrus.WithError(err).WithField("length", len(text)).Warn("Failed to create QR code, inserting its text instead")
+		return escape(text)
+	}
+
+	if embed {
+		return fmt.Sprintf(`<img src="%s" alt="QR code" width="%d" height="%d">`, html.EscapeString(image.URL), image.Width, image.Width)
+	}
+	return escape(image.URL)
+}
+
+// qrCodeImage creates the QR code image of text and stores it in the media store under a key
+// derived from its content. Without a media store an inline image is returned as a data URI,
+// otherwise ErrMediaStoreNotConfigured is returned.
+func (nm *NotificationManagerImpl) qrCodeImage(text string, inline bool) (qrCodeImage, error) {
+	sum := sha256.Sum256([]byte(text))
+	key := qrCodeKeyPrefix + hex.EncodeToString(sum[:]) + ".png"
+	if image, ok := nm.qrCodes.get(key); ok {
+		return image, nil
+	}
+
+	store, storeErr := nm.objectStore()
+	if storeErr != nil && !inline {
+		return qrCodeImage{}, storeErr
+	}
+
+	code, err := qrcode.Encode(text)
+	if err != nil {
+		return qrCodeImage{}, err
+	}
+	content, err := code.PNG(qrCodeModulePixels, qrcode.QuietZone)
+	if err != nil {
+		return qrCodeImage{}, fmt.Errorf("failed to encode QR code image: %w", err)
+	}
+	image := qrCodeImage{Width: code.ImageWidth(qrCodeModulePixels, qrcode.QuietZone)}
+
+	if storeErr != nil {
+		image.URL = "data:image/png;base64," + base64.StdEncoding.EncodeToString(content)
+		return image, nil
+	}
+
+	if err := store.Put(context.Background(), key, "image/png", content); err != nil {
+		return qrCodeImage{}, fmt.Errorf("failed to store QR code image: %w", err)
+	}
+	image.URL = store.URL(key)
+	nm.qrCodes.put(key, image)
+
+	logrus.WithFields(logrus.Fields{
+		"key":     key,
+		"version": code.Version(),
+		"bytes":   len(content),
+	}).Debug("QR code image stored")
+	return image, nil
+}
@@ -0,0 +1,113 @@
+package notification_manager
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/external_services/objectstore"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStore counts the objects put into the wrapped store
+type countingStore struct {
+	*objectstore.MemoryStore
+	puts int
+}
+
+func (s *countingStore) Put(ctx context.Context, key, contentType string, content []byte) error {
+	s.puts++
+	return s.MemoryStore.Put(ctx, key, contentType, content)
+}
+
+func TestRenderTemplateContent_QRCode(t *testing.T) {
+	nm, _, _ := newTestManager(t, 0, DefaultConfig())
+	store := &countingStore{MemoryStore: objectstore.NewMemoryStore("assets", "https://cdn.example.com")}
+	nm.SetObjectStore(store)
+
+	ticketURL := "https://tickets.example.com/t/42?seat=A&row=7"
+	sum := sha256.Sum256([]byte(ticketURL))
+	key := "qrcodes/" + hex.EncodeToString(sum[:]) + ".png"
+	imageURL := "https://cdn.example.com/" + key
+
+	email := &models.Template{
+		Type: models.EmailNotification,
+		Content: models.TemplateContent{
+			Subject:       "Your ticket {{qrcode ticket_url}}",
+			EmailBody:     "<p>Show this at the door:</p>{{qrcode ticket_url}}",
+			EmailTextBody: "Your ticket: {{qrcode ticket_url}}",
+		},
+	}
+	data := map[string]interface{}{"ticket_url": ticketURL}
+	content, err := nm.renderTemplateContent(email, data)
+	require.NoError(t, err)
+
+	// The image is 33 modules of version 4 plus the quiet zone, at 4 pixels per module
+	assert.Equal(t, fmt.Sprintf(`<p>Show this at the door:</p><img src="%s" alt="QR code" width="164" height="164">`, imageURL), content["email_body"])
+	assert.Equal(t, "Your ticket "+imageURL, content["subject"])
+	assert.Equal(t, "Your ticket: "+imageURL, content["text_body"])
+
+	object, exists := store.Get(key)
+	require.True(t, exists)
+	assert.Equal(t, "image/png", object.ContentType)
+	assert.True(t, strings.HasPrefix(string(object.Content), "\x89PNG"))
+
+	// The image is stored once and reused by later renders
+	_, err = nm.renderTemplateContent(email, data)
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.puts)
+
+	// Quoted arguments are used as they are, unknown variables are left untouched
+	slack := &models.Template{
+		Type:    models.SlackNotification,
+		Content: models.TemplateContent{Text: `{{qrcode "https://example.com"}} {{qrcode missing}}`},
+	}
+	content, err = nm.renderTemplateContent(slack, nil)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(content["text"].(string), "https://cdn.example.com/qrcodes/"))
+	assert.True(t, strings.HasSuffix(content["text"].(string), ".png {{qrcode missing}}"))
+
+	// Markdown bodies get the image URL
+	markdownEmail := &models.Template{
+		Type:       models.EmailNotification,
+		Content:    models.TemplateContent{Subject: "Receipt", EmailBody: "Scan {{{qrcode ticket_url}}}"},
+		RenderMode: models.EmailRenderModeMarkdown,
+	}
+	content, err = nm.renderTemplateContent(markdownEmail, data)
+	require.NoError(t, err)
+	assert.Equal(t, "Scan "+imageURL, content["email_body"])
+}
+
+func TestRenderTemplateContent_QRCodeWithoutMediaStore(t *testing.T) {
+	nm, _, _ := newTestManager(t, 0, DefaultConfig())
+	nm.SetObjectStore(nil)
+
+	email := &models.Template{
+		Type:    models.EmailNotification,
+		Content: models.TemplateContent{Subject: "Ticket", EmailBody: "{{qrcode ticket_url}}"},
+	}
+	data := map[string]interface{}{"ticket_url": "https://tickets.example.com/t/42"}
+	content, err := nm.renderTemplateContent(email, data)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(content["email_body"].(string), `<img src="data:image/png;base64,`))
+
+	// Channels that cannot show an inline image get the text
+	slack := &models.Template{
+		Type:    models.SlackNotification,
+		Content: models.TemplateContent{Text: "Ticket: {{qrcode ticket_url}}"},
+	}
+	content, err = nm.renderTemplateContent(slack, data)
+	require.NoError(t, err)
+	assert.Equal(t, "Ticket: https://tickets.example.com/t/42", content["text"])
+
+	// Text too long for a QR code is inserted as it is
+	data["ticket_url"] = strings.Repeat("x", 3000)
+	content, err = nm.renderTemplateContent(email, data)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("x", 3000), content["email_body"])
+}
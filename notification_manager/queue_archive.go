@@ -0,0 +1,72 @@
+package notification_manager
+
+import (
+	"sync"
+	"time"
+)
+
+// archivedMessage is a message as it was posted to a channel
+type archivedMessage struct {
+	channel  string
+	payload  string
+	queuedAt time.Time
+}
+
+// queueArchive keeps the messages posted to the channels, oldest first, so they can be replayed
+// after a provider outage
+type queueArchive struct {
+	mu       sync.Mutex
+	messages []archivedMessage
+}
+
+// record archives a message posted to a channel and drops the messages queued more than
+// retention before it or beyond maxMessages. Nothing is kept when either is zero.
+func (a *queueArchive) record(channel, payload string, queuedAt time.Time, retention time.Duration, maxMessages int) {
+	if retention <= 0 || maxMessages <= 0 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.messages = append(a.messages, archivedMessage{channel: channel, payload: payload, queuedAt: queuedAt})
+
+	drop := len(a.messages) - maxMessages
+	if drop < 0 {
+		drop = 0
+	}
+	cutoff := queuedAt.Add(-retention)
+	for drop < len(a.messages) && a.messages[drop].queuedAt.Before(cutoff) {
+		drop++
+	}
+	if drop > 0 {
+		// Clear the dropped messages so their payloads can be collected
+		for i := range a.messages[:drop] {
+			a.messages[i] = archivedMessage{}
+		}
+		a.messages = a.messages[drop:]
+	}
+}
+
+// between returns the archived messages of a channel queued from from up to but excluding to,
+// oldest first, along with the queue time of the channel's oldest archived message
+func (a *queueArchive) between(channel string, from, to time.Time) ([]archivedMessage, *time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var oldest *time.Time
+	var messages []archivedMessage
+	for _, message := range a.messages {
+		if message.channel != channel {
+			continue
+		}
+		if oldest == nil {
+			queuedAt := message.queuedAt
+			oldest = &queuedAt
+		}
+		if !message.queuedAt.Before(from) && message.queuedAt.Before(to) {
+			messages = append(messages, message)
+		}
+	}
+	return messages, oldest
+}
@@ -0,0 +1,154 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/sirupsen/logrus"
+)
+
+// queueReplayListLimit is the number of messages listed in a replay result
+const queueReplayListLimit = 1000
+
+// QueueReplayMessage is an archived message selected for replay
+type QueueReplayMessage struct {
+	NotificationID string    `json:"notification_id"`
+	Recipient      string    `json:"recipient"`
+	QueuedAt       time.Time `json:"queued_at"`
+	// Error is why the message could not be replayed
+	Error string `json:"error,omitempty"`
+}
+
+// QueueReplayResult reports the archived messages of a channel that were, or in a dry run would
+// be, posted to the channel again
+type QueueReplayResult struct {
+	Channel    string    `json:"channel"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+	DryRun     bool      `json:"dry_run"`
+	OnlyFailed bool      `json:"only_failed"`
+	// ArchivedSince is when the oldest archived message of the channel was queued; older
+	// messages are no longer archived and cannot be replayed
+	ArchivedSince *time.Time `json:"archived_since,omitempty"`
+	// Matched counts the messages selected for replay, Skipped the ones in the time range whose
+	// latest delivery attempt did not fail
+	Matched  int `json:"matched"`
+	Skipped  int `json:"skipped"`
+	Replayed int `json:"replayed"`
+	Failed   int `json:"failed"`
+	// Messages lists the selected messages, up to queueReplayListLimit
+	Messages  []QueueReplayMessage `json:"messages"`
+	Truncated bool                 `json:"truncated"`
+}
+
+// ReplayQueueMessages posts the archived messages of a channel queued between two timestamps
+// to the channel again, for instance the messages a provider rejected during an outage.
+// Replayed messages get a new queue time and are archived again.
+func (nm *NotificationManagerImpl) ReplayQueueMessages(request *models.QueueReplayRequest) (interface{}, error) {
+	if nm.config.QueueArchiveRetention <= 0 || nm.config.QueueArchiveMaxMessages <= 0 {
+		return nil, ErrQueueArchiveDisabled
+	}
+	if newQueuedMessage(request.Channel) == nil {
+		return nil, fmt.Errorf("%w: unsupported channel %q", ErrInvalidQueueReplay, request.Channel)
+	}
+	if !request.From.Before(request.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidQueueReplay)
+	}
+	if request.OnlyFailed && nm.deliveryService == nil {
+		return nil, fmt.Errorf("%w: delivery attempts are not tracked, only_failed is unavailable", ErrInvalidQueueReplay)
+	}
+
+	archived, archivedSince := nm.queueArchive.between(request.Channel, request.From, request.To)
+	result := &QueueReplayResult{
+		Channel:       request.Channel,
+		From:          request.From,
+		To:            request.To,
+		DryRun:        request.DryRun,
+		OnlyFailed:    request.OnlyFailed,
+		ArchivedSince: archivedSince,
+		Messages:      []QueueReplayMessage{},
+	}
+
+	for _, message := range archived {
+		var key struct {
+			ID        string `json:"id"`
+			Recipient string `json:"recipient"`
+		}
+		queued := newQueuedMessage(request.Channel)
+		err := json.Unmarshal([]byte(message.payload), queued)
+		if err == nil {
+			err = json.Unmarshal([]byte(message.payload), &key)
+		}
+		if err == nil && request.OnlyFailed && !nm.latestAttemptFailed(key.ID, key.Recipient) {
+			result.Skipped++
+			continue
+		}
+
+		entry := QueueReplayMessage{NotificationID: key.ID, Recipient: key.Recipient, QueuedAt: message.queuedAt}
+		result.Matched++
+		if err == nil && !request.DryRun {
+			err = nm.postToKafkaChannel(request.Channel, queued, nm.config.EnqueueTimeout)
+		}
+		switch {
+		case err != nil:
+			entry.Error = err.Error()
+			result.Failed++
+		case !request.DryRun:
+			result.Replayed++
+		}
+
+		if len(result.Messages) < queueReplayListLimit {
+			result.Messages = append(result.Messages, entry)
+		} else {
+			result.Truncated = true
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"channel":  request.Channel,
+		"from":     request.From,
+		"to":       request.To,
+		"dry_run":  request.DryRun,
+		"matched":  result.Matched,
+		"skipped":  result.Skipped,
+		"replayed": result.Replayed,
+		"failed":   result.Failed,
+	}).Info("Replayed archived queue messages")
+
+	return result, nil
+}
+
+// newQueuedMessage returns an empty message of the type posted to a channel, or nil for an
+// unknown channel
+func newQueuedMessage(channel string) models.QueuedMessage {
+	switch channel {
+	case "email":
+		return &models.EmailNotificationRequest{}
+	case "slack":
+		return &models.SlackNotificationRequest{}
+	case "google_chat":
+		return &models.GoogleChatNotificationRequest{}
+	case "incident":
+		return &models.IncidentNotificationRequest{}
+	case "rcs":
+		return &models.RCSNotificationRequest{}
+	case "ios_push":
+		return &models.APNSNotificationRequest{}
+	case "android_push":
+		return &models.FCMNotificationRequest{}
+	default:
+		return nil
+	}
+}
+
+// latestAttemptFailed reports whether the latest delivery attempt of a notification to a
+// recipient failed; messages that were never attempted have not failed
+func (nm *NotificationManagerImpl) latestAttemptFailed(notificationID, recipient string) bool {
+	attempts, err := nm.deliveryService.GetAttempts(notificationID, recipient)
+	if err != nil || len(attempts) == 0 {
+		return false
+	}
+	return attempts[len(attempts)-1].Status == models.DeliveryStatusFailed
+}
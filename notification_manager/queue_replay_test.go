@@ -0,0 +1,129 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/external_services/delivery"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayQueueMessages(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 3, DefaultConfig())
+	deliveryService := delivery.NewDeliveryService()
+	nm.deliveryService = deliveryService
+
+	start := time.Now().Add(-time.Second)
+	_, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "email",
+		Content:    map[string]interface{}{"subject": "Hello", "email_body": "Body"},
+		Recipients: recipients,
+	})
+	require.NoError(t, err)
+	end := time.Now().Add(time.Second)
+
+	// The provider rejected the first email and accepted the second; the third was not attempted
+	require.Len(t, kafkaService.GetEmailChannel(), 3)
+	var emails []models.EmailNotificationRequest
+	for i := 0; i < 3; i++ {
+		var email models.EmailNotificationRequest
+		require.NoError(t, json.Unmarshal([]byte(<-kafkaService.GetEmailChannel()), &email))
+		emails = append(emails, email)
+	}
+	for i, status := range []string{models.DeliveryStatusFailed, models.DeliveryStatusSent} {
+		require.NoError(t, deliveryService.RecordAttempt(&models.DeliveryAttempt{
+			NotificationID: emails[i].ID,
+			Recipient:      emails[i].Recipient,
+			Channel:        "email",
+			Attempt:        1,
+			Status:         status,
+			AttemptedAt:    time.Now(),
+		}))
+	}
+
+	// A dry run lists the messages without posting them
+	result, err := nm.ReplayQueueMessages(&models.QueueReplayRequest{Channel: "email", From: start, To: end, DryRun: true})
+	require.NoError(t, err)
+	replay := result.(*QueueReplayResult)
+	assert.Equal(t, 3, replay.Matched)
+	assert.Zero(t, replay.Replayed)
+	require.Len(t, replay.Messages, 3)
+	assert.Equal(t, emails[0].Recipient, replay.Messages[0].Recipient)
+	require.NotNil(t, replay.ArchivedSince)
+	assert.Empty(t, kafkaService.GetEmailChannel())
+
+	// Only the rejected email is replayed, with a new queue time
+	time.Sleep(2 * time.Millisecond)
+	result, err = nm.ReplayQueueMessages(&models.QueueReplayRequest{Channel: "email", From: start, To: end, OnlyFailed: true})
+	require.NoError(t, err)
+	replay = result.(*QueueReplayResult)
+	assert.Equal(t, 1, replay.Matched)
+	assert.Equal(t, 2, replay.Skipped)
+	assert.Equal(t, 1, replay.Replayed)
+	require.Len(t, kafkaService.GetEmailChannel(), 1)
+	var replayed models.EmailNotificationRequest
+	require.NoError(t, json.Unmarshal([]byte(<-kafkaService.GetEmailChannel()), &replayed))
+	assert.Equal(t, emails[0].ID, replayed.ID)
+	assert.Equal(t, emails[0].Recipient, replayed.Recipient)
+	assert.Equal(t, emails[0].Content, replayed.Content)
+	assert.Greater(t, replayed.QueuedAt, emails[0].QueuedAt)
+
+	// Other channels and time ranges have nothing to replay
+	result, err = nm.ReplayQueueMessages(&models.QueueReplayRequest{Channel: "slack", From: start, To: end})
+	require.NoError(t, err)
+	assert.Zero(t, result.(*QueueReplayResult).Matched)
+	assert.Nil(t, result.(*QueueReplayResult).ArchivedSince)
+	result, err = nm.ReplayQueueMessages(&models.QueueReplayRequest{Channel: "email", From: start.Add(-time.Hour), To: start})
+	require.NoError(t, err)
+	assert.Zero(t, result.(*QueueReplayResult).Matched)
+}
+
+func TestReplayQueueMessages_InvalidRequests(t *testing.T) {
+	nm, _, _ := newTestManager(t, 0, DefaultConfig())
+	now := time.Now()
+
+	_, err := nm.ReplayQueueMessages(&models.QueueReplayRequest{Channel: "sms", From: now.Add(-time.Hour), To: now})
+	assert.ErrorIs(t, err, ErrInvalidQueueReplay)
+	_, err = nm.ReplayQueueMessages(&models.QueueReplayRequest{Channel: "email", From: now, To: now})
+	assert.ErrorIs(t, err, ErrInvalidQueueReplay)
+	// Without delivery tracking failed messages cannot be told apart
+	_, err = nm.ReplayQueueMessages(&models.QueueReplayRequest{Channel: "email", From: now.Add(-time.Hour), To: now, OnlyFailed: true})
+	assert.ErrorIs(t, err, ErrInvalidQueueReplay)
+
+	nm.config.QueueArchiveRetention = 0
+	_, err = nm.ReplayQueueMessages(&models.QueueReplayRequest{Channel: "email", From: now.Add(-time.Hour), To: now})
+	assert.ErrorIs(t, err, ErrQueueArchiveDisabled)
+}
+
+func TestQueueArchive_Retention(t *testing.T) {
+	archive := &queueArchive{}
+	now := time.Now()
+
+	archive.record("email", "old", now.Add(-2*time.Hour), time.Hour, 10)
+	archive.record("slack", "a", now.Add(-time.Minute), time.Hour, 10)
+	archive.record("email", "b", now, time.Hour, 10)
+
+	// The message queued more than the retention before the latest one is dropped
+	messages, oldest := archive.between("email", now.Add(-3*time.Hour), now.Add(time.Second))
+	require.Len(t, messages, 1)
+	assert.Equal(t, "b", messages[0].payload)
+	assert.Equal(t, now, *oldest)
+
+	// Beyond the maximum the oldest messages are dropped first
+	archive.record("email", "c", now, time.Hour, 2)
+	messages, _ = archive.between("slack", now.Add(-time.Hour), now.Add(time.Second))
+	assert.Empty(t, messages)
+	messages, _ = archive.between("email", now.Add(-time.Hour), now.Add(time.Second))
+	require.Len(t, messages, 2)
+	assert.Equal(t, "c", messages[1].payload)
+
+	// A disabled archive keeps nothing
+	disabled := &queueArchive{}
+	disabled.record("email", "d", now, 0, 10)
+	messages, oldest = disabled.between("email", now.Add(-time.Hour), now.Add(time.Second))
+	assert.Empty(t, messages)
+	assert.Nil(t, oldest)
+}
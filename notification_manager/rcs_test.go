@@ -0,0 +1,52 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessNotificationRequest_RCS(t *testing.T) {
+	nm, kafkaService, _ := newTestManager(t, 0, DefaultConfig())
+	require.NoError(t, nm.userService.CreateUser(&models.User{
+		ID:          "rcs-user",
+		Email:       "rcs.user@company.com",
+		PhoneNumber: "+14155550123",
+		IsActive:    true,
+	}))
+
+	_, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type: "rcs",
+		Content: map[string]interface{}{
+			"text":        "Your order has shipped",
+			"card":        map[string]interface{}{"title": "Order #1234", "media_url": "https://cdn.example.com/parcel.png"},
+			"suggestions": []interface{}{map[string]interface{}{"text": "Track parcel", "url": "https://example.com/track/1234"}},
+		},
+		Recipients: []string{"rcs-user"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, kafkaService.GetRCSChannel(), 1)
+	var message models.RCSNotificationRequest
+	require.NoError(t, json.Unmarshal([]byte(<-kafkaService.GetRCSChannel()), &message))
+	assert.Equal(t, "+14155550123", message.Recipient)
+	assert.Equal(t, "Your order has shipped", message.Content.Text)
+	require.NotNil(t, message.Content.Card)
+	assert.Equal(t, "Order #1234", message.Content.Card.Title)
+	assert.Equal(t, "https://example.com/track/1234", message.Content.Suggestions[0].URL)
+}
+
+func TestProcessNotificationRequest_RCSRecipientWithoutPhone(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 1, DefaultConfig())
+
+	_, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "rcs",
+		Content:    map[string]interface{}{"text": "Your order has shipped"},
+		Recipients: []string{recipients[0]},
+	})
+	require.NoError(t, err)
+	assert.Len(t, kafkaService.GetRCSChannel(), 0)
+}
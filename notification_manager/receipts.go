@@ -0,0 +1,75 @@
+package notification_manager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/sirupsen/logrus"
+)
+
+// notificationDeliveryReceiptsTotal counts the accepted delivery receipts
+var notificationDeliveryReceiptsTotal = metrics.DefaultRegistry.NewCounterVec(
+	"notification_delivery_receipts_total",
+	"Delivery receipts accepted for push notifications, by channel. Repeated receipts of a push are counted again.",
+	"channel",
+)
+
+// rejectedReceipt explains why a receipt of a batch was not recorded
+type rejectedReceipt struct {
+	Index          int    `json:"index"`
+	NotificationID string `json:"notification_id"`
+	Error          string `json:"error"`
+}
+
+// RecordDeliveryReceipts marks the pushes named by the receipts as delivered to their devices.
+// Receipts are recorded independently, so one unknown notification does not reject the batch.
+func (nm *NotificationManagerImpl) RecordDeliveryReceipts(receipts []models.DeliveryReceipt) (interface{}, error) {
+	if nm.deliveryService == nil {
+		return nil, fmt.Errorf("deliveryService is not available")
+	}
+
+	now := nm.clock.Now()
+	accepted := 0
+	rejected := []rejectedReceipt{}
+	for i := range receipts {
+		receipt := receipts[i]
+		err := nm.recordDeliveryReceipt(&receipt, now)
+		if err != nil {
+			rejected = append(rejected, rejectedReceipt{Index: i, NotificationID: receipt.NotificationID, Error: err.Error()})
+			continue
+		}
+		accepted++
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"accepted": accepted,
+		"rejected": len(rejected),
+	}).Debug("Delivery receipts recorded")
+
+	return &struct {
+		Accepted int               `json:"accepted"`
+		Rejected []rejectedReceipt `json:"rejected"`
+	}{
+		Accepted: accepted,
+		Rejected: rejected,
+	}, nil
+}
+
+// recordDeliveryReceipt records a single receipt of a known notification
+func (nm *NotificationManagerImpl) recordDeliveryReceipt(receipt *models.DeliveryReceipt, now time.Time) error {
+	if _, err := nm.storage.GetNotification(receipt.NotificationID); err != nil {
+		return ErrNotificationNotFound
+	}
+	// Devices may report receipts late, e.g. after being offline, but not from the future
+	if receipt.DeliveredAt == nil || receipt.DeliveredAt.After(now) {
+		receipt.DeliveredAt = &now
+	}
+
+	if err := nm.deliveryService.RecordReceipt(receipt); err != nil {
+		return err
+	}
+	notificationDeliveryReceiptsTotal.Inc(receipt.Channel)
+	return nil
+}
@@ -0,0 +1,68 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/external_services/delivery"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordDeliveryReceipts(t *testing.T) {
+	nm, _, recipients := newTestManager(t, 1, DefaultConfig())
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	nm.SetClock(clock.NewFake(now))
+	nm.deliveryService = delivery.NewDeliveryService()
+
+	result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "ios_push",
+		Content:    map[string]interface{}{"title": "Order shipped", "body": "Your order is on its way."},
+		Recipients: recipients,
+	})
+	require.NoError(t, err)
+	notificationID := result.(map[string]interface{})["id"].(string)
+
+	// The consumer records the attempt once APNS accepted the push
+	require.NoError(t, nm.deliveryService.RecordAttempt(&models.DeliveryAttempt{
+		NotificationID: notificationID,
+		UserID:         recipients[0],
+		Recipient:      "ios_token_1",
+		Channel:        "ios_push",
+		Status:         models.DeliveryStatusSent,
+	}))
+
+	future := now.Add(time.Hour)
+	response, err := nm.RecordDeliveryReceipts([]models.DeliveryReceipt{
+		{NotificationID: notificationID, Channel: "ios_push", Recipient: "ios_token_1", DeliveredAt: &future},
+		{NotificationID: "missing", Channel: "ios_push", Recipient: "ios_token_1"},
+		{NotificationID: notificationID, Channel: "ios_push", Recipient: "ios_token_2"},
+	})
+	require.NoError(t, err)
+
+	var decoded struct {
+		Accepted int `json:"accepted"`
+		Rejected []struct {
+			Index int    `json:"index"`
+			Error string `json:"error"`
+		} `json:"rejected"`
+	}
+	encoded, err := json.Marshal(response)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, 1, decoded.Accepted)
+	require.Len(t, decoded.Rejected, 2)
+	assert.Equal(t, ErrNotificationNotFound.Error(), decoded.Rejected[0].Error)
+	assert.Equal(t, 2, decoded.Rejected[1].Index)
+
+	assert.Equal(t, models.DeliveryStats{Sent: 1, Delivered: 1}, nm.deliveryService.GetStats(notificationID))
+
+	// Receipts from the future are clamped to the server time
+	attempts, err := nm.deliveryService.GetAttempts(notificationID, "ios_token_1")
+	require.NoError(t, err)
+	require.NotNil(t, attempts[0].DeliveredAt)
+	assert.Equal(t, now, *attempts[0].DeliveredAt)
+}
@@ -0,0 +1,156 @@
+package notification_manager
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gaurav2721/notification-service/external_services/user"
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// hasPushDevice reports whether the user has an active device of the type to push to
+func hasPushDevice(userInfo *models.UserNotificationInfo, deviceType string) bool {
+	for _, device := range userInfo.Devices {
+		if device.IsActive && device.DeviceToken != "" && device.DeviceType == deviceType {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckRecipients reports which recipients a notification would reach and why the others would
+// be skipped, going through the same checks as a send: the user must exist and be active, routing
+// policies may suppress or reroute the notification, category preferences and muted threads may
+// stop it, the recipient needs a contact point or device for the channel, verified contacts may
+// be required, and do-not-disturb holds back non-urgent notifications. Nothing is sent or counted.
+func (nm *NotificationManagerImpl) CheckRecipients(check *models.RecipientCheckRequest) (interface{}, error) {
+	request := &models.NotificationRequest{
+		Type:          check.Type,
+		Recipients:    check.Recipients,
+		Category:      check.Category,
+		Transactional: check.Transactional,
+		ThreadKey:     check.ThreadKey,
+		Tenant:        check.Tenant,
+	}
+	category, err := nm.resolveCategory(request)
+	if err != nil {
+		return nil, err
+	}
+	request.Category = category
+
+	users, err := nm.resolveRecipients(request.Recipients)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recipient information: %v", err)
+	}
+	byID := make(map[string]*models.UserNotificationInfo, len(users))
+	for _, userInfo := range users {
+		byID[userInfo.ID] = userInfo
+	}
+
+	report := &models.RecipientReport{
+		Type:       request.Type,
+		Category:   request.Category,
+		Total:      len(request.Recipients),
+		Counts:     make(map[string]int),
+		Recipients: make([]models.RecipientCheck, 0, len(request.Recipients)),
+	}
+	for _, recipient := range request.Recipients {
+		result := nm.checkRecipient(request, recipient, byID[recipient])
+		report.Recipients = append(report.Recipients, result)
+		report.Counts[result.Status]++
+		if result.Status == models.RecipientReachable {
+			report.Reachable++
+		}
+	}
+	return report, nil
+}
+
+// checkRecipient checks one recipient of a request; userInfo is nil for recipients the user
+// service did not return
+func (nm *NotificationManagerImpl) checkRecipient(request *models.NotificationRequest, recipient string, userInfo *models.UserNotificationInfo) models.RecipientCheck {
+	result := models.RecipientCheck{Recipient: recipient}
+
+	if userInfo == nil {
+		// Inactive users are left out by the user service like unknown ones
+		if _, err := nm.userService.GetUserByID(recipient); errors.Is(err, user.ErrUserInactive) {
+			result.Status = models.RecipientInactive
+			result.Detail = "user is inactive"
+			return result
+		}
+		result.Status = models.RecipientMissing
+		result.Detail = "user not found"
+		return result
+	}
+
+	routed := request
+	if decision := nm.policies.Evaluate(policyAttributes(request, userInfo)); decision != nil {
+		routed, _ = applyPolicyDecision(request, decision)
+		if routed == nil {
+			result.Status = models.RecipientSuppressed
+			result.Detail = fmt.Sprintf("suppressed by routing policy %s", decision.PolicyName)
+			return result
+		}
+		if routed.Type != request.Type {
+			result.Channel = routed.Type
+		}
+	}
+
+	if nm.isOptedOut(routed, userInfo.ID) {
+		result.Status = models.RecipientOptedOut
+		result.Detail = fmt.Sprintf("opted out of %s notifications on %s", routed.Category, routed.Type)
+		return result
+	}
+	if routed.Type != "in_app" && nm.isThreadMuted(routed, userInfo.ID) {
+		result.Status = models.RecipientThreadMuted
+		result.Detail = fmt.Sprintf("muted thread %s", routed.ThreadKey)
+		return result
+	}
+
+	switch routed.Type {
+	case "ios_push", "android_push":
+		deviceType := "ios"
+		if routed.Type == "android_push" {
+			deviceType = "android"
+		}
+		if !hasPushDevice(userInfo, deviceType) {
+			result.Status = models.RecipientNoContact
+			result.Detail = fmt.Sprintf("no active %s device", deviceType)
+			return result
+		}
+	default:
+		if !isReachable(routed.Type, userInfo) {
+			result.Status = models.RecipientNoContact
+			result.Detail = fmt.Sprintf("no contact point for %s", routed.Type)
+			return result
+		}
+	}
+
+	if nm.lacksVerifiedContact(routed, userInfo) {
+		result.Status = models.RecipientUnverified
+		result.Detail = fmt.Sprintf("%s contact is not verified", routed.Type)
+		return result
+	}
+	if userInfo.DoNotDisturb.ActiveAt(nm.clock.Now()) && !nm.isUrgent(routed) {
+		result.Status = models.RecipientDoNotDisturb
+		result.Detail = fmt.Sprintf("do-not-disturb is on, the notification would be %s", nm.dndOutcomeName(userInfo.DoNotDisturb))
+		return result
+	}
+
+	result.Status = models.RecipientReachable
+	switch {
+	case routed.Type == "in_app" && nm.isThreadMuted(routed, userInfo.ID):
+		result.Detail = "thread is muted, the notification is kept in the inbox without a push"
+	case routed.Type == "in_app" && !hasPushDevice(userInfo, "ios") && !hasPushDevice(userInfo, "android"):
+		result.Detail = "no active device, the notification is kept in the inbox without a push"
+	}
+	return result
+}
+
+// dndOutcomeName describes what do-not-disturb does with a non-urgent notification
+func (nm *NotificationManagerImpl) dndOutcomeName(dnd *models.DoNotDisturb) string {
+	if nm.config.DNDPolicy == DNDPolicyDefer && dnd.Until != nil {
+		return "deferred until " + dnd.Until.UTC().Format(time.RFC3339)
+	}
+	return "dropped"
+}
@@ -0,0 +1,110 @@
+package notification_manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckRecipients(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 5, DefaultConfig())
+
+	// stream-user-000 stays reachable
+	inactive, err := nm.userService.GetUserByID(recipients[1])
+	require.NoError(t, err)
+	inactive.IsActive = false
+	require.NoError(t, nm.userService.UpdateUser(inactive))
+	_, err = nm.UpdatePreferences(recipients[2], &models.NotificationPreferences{
+		Categories: map[string]*models.CategoryPreference{models.CategoryMarketing: {OptOut: true}},
+	})
+	require.NoError(t, err)
+	_, err = nm.userService.SetDoNotDisturb(recipients[3], nil)
+	require.NoError(t, err)
+	noEmail, err := nm.userService.GetUserByID(recipients[4])
+	require.NoError(t, err)
+	noEmail.Email = ""
+	require.NoError(t, nm.userService.UpdateUser(noEmail))
+
+	result, err := nm.CheckRecipients(&models.RecipientCheckRequest{
+		Type:       "email",
+		Category:   models.CategoryMarketing,
+		Recipients: append(recipients, "unknown-user", "email:ops@example.com", "slack:#ops"),
+	})
+	require.NoError(t, err)
+	report := result.(*models.RecipientReport)
+
+	statuses := make(map[string]string)
+	for _, check := range report.Recipients {
+		statuses[check.Recipient] = check.Status
+	}
+	assert.Equal(t, map[string]string{
+		recipients[0]:           models.RecipientReachable,
+		recipients[1]:           models.RecipientInactive,
+		recipients[2]:           models.RecipientOptedOut,
+		recipients[3]:           models.RecipientDoNotDisturb,
+		recipients[4]:           models.RecipientNoContact,
+		"unknown-user":          models.RecipientMissing,
+		"email:ops@example.com": models.RecipientReachable,
+		"slack:#ops":            models.RecipientNoContact,
+	}, statuses)
+	assert.Equal(t, 8, report.Total)
+	assert.Equal(t, 2, report.Reachable)
+	assert.Equal(t, 2, report.Counts[models.RecipientNoContact])
+	assert.Equal(t, recipients[0], report.Recipients[0].Recipient, "recipients are reported in request order")
+
+	// Transactional notifications are sent regardless of opt-outs and do-not-disturb, and nothing was sent
+	result, err = nm.CheckRecipients(&models.RecipientCheckRequest{
+		Type:          "email",
+		Category:      models.CategoryMarketing,
+		Transactional: true,
+		Recipients:    recipients[2:4],
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.(*models.RecipientReport).Reachable)
+	assert.Empty(t, kafkaService.GetEmailChannel())
+}
+
+func TestCheckRecipients_Push(t *testing.T) {
+	nm, _, recipients := newTestManager(t, 3, DefaultConfig())
+
+	_, err := nm.userService.RegisterDevice(recipients[0], "ios-token", "ios")
+	require.NoError(t, err)
+	_, err = nm.UpdatePreferences(recipients[1], &models.NotificationPreferences{MutedThreads: []string{"order:42"}})
+	require.NoError(t, err)
+	_, err = nm.userService.RegisterDevice(recipients[1], "ios-token-2", "ios")
+	require.NoError(t, err)
+
+	result, err := nm.CheckRecipients(&models.RecipientCheckRequest{Type: "ios_push", ThreadKey: "order:42", Recipients: recipients})
+	require.NoError(t, err)
+	report := result.(*models.RecipientReport)
+	assert.Equal(t, models.RecipientReachable, report.Recipients[0].Status)
+	assert.Equal(t, models.RecipientThreadMuted, report.Recipients[1].Status)
+	assert.Equal(t, models.RecipientNoContact, report.Recipients[2].Status)
+	assert.Equal(t, "no active ios device", report.Recipients[2].Detail)
+
+	// In-app notifications always reach the inbox
+	result, err = nm.CheckRecipients(&models.RecipientCheckRequest{Type: "in_app", ThreadKey: "order:42", Recipients: recipients})
+	require.NoError(t, err)
+	report = result.(*models.RecipientReport)
+	assert.Equal(t, 3, report.Reachable)
+	assert.Empty(t, report.Recipients[0].Detail)
+	assert.Contains(t, report.Recipients[1].Detail, "thread is muted")
+	assert.Contains(t, report.Recipients[2].Detail, "no active device")
+	assert.Empty(t, nm.inbox.List(recipients[0], false, false))
+}
+
+func TestCheckRecipients_DoNotDisturbDeferred(t *testing.T) {
+	nm, _, recipients := newTestManager(t, 1, DefaultConfig())
+	until := time.Now().Add(time.Hour)
+	_, err := nm.userService.SetDoNotDisturb(recipients[0], &until)
+	require.NoError(t, err)
+
+	result, err := nm.CheckRecipients(&models.RecipientCheckRequest{Type: "email", Category: models.CategoryMarketing, Recipients: recipients})
+	require.NoError(t, err)
+	check := result.(*models.RecipientReport).Recipients[0]
+	assert.Equal(t, models.RecipientDoNotDisturb, check.Status)
+	assert.Contains(t, check.Detail, "deferred until")
+}
@@ -0,0 +1,31 @@
+package notification_manager
+
+import "github.com/gaurav2721/notification-service/models"
+
+// resolveRecipients returns the notification info of a batch of recipients. User IDs are
+// resolved through the user service, which leaves out unknown and inactive users; address
+// recipients such as "email:alice@example.com" are sent to as given. Duplicates are dropped.
+func (nm *NotificationManagerImpl) resolveRecipients(recipients []string) ([]*models.UserNotificationInfo, error) {
+	userIDs := make([]string, 0, len(recipients))
+	var addresses []*models.UserNotificationInfo
+	seen := make(map[string]bool)
+	for _, recipient := range recipients {
+		if _, _, ok := models.ParseRecipientAddress(recipient); !ok {
+			userIDs = append(userIDs, recipient)
+			continue
+		}
+		if !seen[recipient] {
+			seen[recipient] = true
+			addresses = append(addresses, models.AddressRecipientInfo(recipient))
+		}
+	}
+
+	if len(userIDs) == 0 {
+		return addresses, nil
+	}
+	users, err := nm.userService.GetUsersNotificationInfo(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	return append(users, addresses...), nil
+}
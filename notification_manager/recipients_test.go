@@ -0,0 +1,37 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessNotificationRequest_AddressRecipients(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 1, DefaultConfig())
+
+	result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "email",
+		Content:    map[string]interface{}{"subject": "Hello", "email_body": "Body"},
+		Recipients: []string{recipients[0], "email:guest@example.com", "email:guest@example.com", "unknown-user"},
+	})
+	require.NoError(t, err)
+
+	progress, err := nm.storage.GetProgress(result.(map[string]interface{})["id"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, 2, progress.Resolved)
+	assert.Equal(t, 2, progress.Skipped, "the duplicate address and the unknown user")
+	assert.Equal(t, 2, progress.Queued)
+
+	require.Len(t, kafkaService.GetEmailChannel(), 2)
+	addresses := map[string]string{}
+	for i := 0; i < 2; i++ {
+		var email models.EmailNotificationRequest
+		require.NoError(t, json.Unmarshal([]byte(<-kafkaService.GetEmailChannel()), &email))
+		addresses[email.UserID] = email.Recipient
+	}
+	assert.Equal(t, "guest@example.com", addresses["email:guest@example.com"])
+	assert.Equal(t, "stream.user000@company.com", addresses[recipients[0]])
+}
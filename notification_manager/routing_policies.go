@@ -0,0 +1,231 @@
+package notification_manager
+
+import (
+	"strings"
+
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/gaurav2721/notification-service/policy"
+	"github.com/sirupsen/logrus"
+)
+
+// notificationPolicyDecisionsTotal counts recipients a routing policy applied to
+var notificationPolicyDecisionsTotal = metrics.DefaultRegistry.NewCounterVec(
+	"notification_policy_decisions_total",
+	"Recipients a routing policy suppressed, routed to another channel or allowed, by policy and action.",
+	"policy", "action",
+)
+
+// PolicyEvaluation is the outcome of the routing policies for one recipient of a dry run
+type PolicyEvaluation struct {
+	Recipient string `json:"recipient"`
+	// Outcome is deliver, or the action of the matching policy
+	Outcome string `json:"outcome"`
+	// Channel is the notification type the recipient is sent, empty when suppressed
+	Channel  string           `json:"channel,omitempty"`
+	Decision *policy.Decision `json:"decision,omitempty"`
+	Error    string           `json:"error,omitempty"`
+}
+
+// isPolicyChannel reports whether a route action can send a notification as channel
+func isPolicyChannel(channel string) bool {
+	return models.ChannelContentKey(channel) != ""
+}
+
+// policyAttributes returns the attributes routing policies test for a recipient of a request
+func policyAttributes(request *models.NotificationRequest, userInfo *models.UserNotificationInfo) policy.Attributes {
+	attrs := policy.Attributes{
+		policy.FieldType:              request.Type,
+		policy.FieldCategory:          request.Category,
+		policy.FieldTransactional:     request.Transactional,
+		policy.FieldTags:              request.Tags,
+		policy.FieldTenant:            request.Tenant,
+		policy.FieldRecipientID:       userInfo.ID,
+		policy.FieldRecipientEmail:    userInfo.Email,
+		policy.FieldRecipientLocale:   userInfo.Locale,
+		policy.FieldRecipientTimezone: userInfo.Timezone,
+		policy.FieldRecipientVerified: userInfo.EmailVerified,
+		policy.FieldRecipientSlack:    userInfo.SlackChannel != "",
+	}
+	if request.Template != nil {
+		attrs[policy.FieldTemplateID] = request.Template.ID
+	}
+	if at := strings.LastIndex(userInfo.Email, "@"); at >= 0 {
+		attrs[policy.FieldRecipientEmailDomain] = strings.ToLower(userInfo.Email[at+1:])
+	}
+
+	var deviceTypes []string
+	for _, device := range userInfo.Devices {
+		if device.IsActive {
+			deviceTypes = append(deviceTypes, device.DeviceType)
+		}
+	}
+	attrs[policy.FieldRecipientDeviceTypes] = deviceTypes
+	return attrs
+}
+
+// applyRoutingPolicies returns the request to send to a recipient as decided by the first
+// matching routing policy, or nil when the policy suppresses it
+func (nm *NotificationManagerImpl) applyRoutingPolicies(request *models.NotificationRequest, userInfo *models.UserNotificationInfo) *models.NotificationRequest {
+	decision := nm.policies.Evaluate(policyAttributes(request, userInfo))
+	if decision == nil {
+		return request
+	}
+	notificationPolicyDecisionsTotal.Inc(decision.PolicyName, decision.Action.Type)
+
+	routed, _ := applyPolicyDecision(request, decision)
+	// Notifications routed to email are sent from the tenant's default from address
+	if routed != nil && routed != request && routed.Type == string(models.EmailNotification) {
+		routed.From = defaultSender(nm.senderSettings(request.Tenant))
+	}
+	return routed
+}
+
+// applyPolicyDecision applies a policy decision to a request. It returns nil for suppressed
+// recipients, and the request unchanged with a reason when it cannot be routed because it has
+// no channel content for the channel.
+func applyPolicyDecision(request *models.NotificationRequest, decision *policy.Decision) (*models.NotificationRequest, string) {
+	switch decision.Action.Type {
+	case policy.ActionSuppress:
+		return nil, ""
+	case policy.ActionRoute:
+		channel := decision.Action.Channel
+		if channel == request.Type {
+			return request, ""
+		}
+		content := request.ContentFor(channel)
+		if content == nil {
+			sampledLog.Warn("Routing policy skipped, the notification has no content for the channel", logger.Fields{
+				"policy":  decision.PolicyName,
+				"channel": channel,
+			})
+			return request, "the notification has no channel_content for " + models.ChannelContentKey(channel)
+		}
+
+		routed := *request
+		routed.Type = channel
+		routed.Content = content
+		routed.Template = nil
+		routed.From = nil
+		return &routed, ""
+	}
+	return request, ""
+}
+
+// ListRoutingPolicies returns the routing policies in the order they are evaluated
+func (nm *NotificationManagerImpl) ListRoutingPolicies() []policy.Policy {
+	return nm.policies.List()
+}
+
+// GetRoutingPolicy returns a routing policy
+func (nm *NotificationManagerImpl) GetRoutingPolicy(policyID string) (interface{}, error) {
+	p, err := nm.policies.Get(policyID)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CreateRoutingPolicy validates and stores a new routing policy
+func (nm *NotificationManagerImpl) CreateRoutingPolicy(p *policy.Policy) (interface{}, error) {
+	if err := p.Validate(isPolicyChannel); err != nil {
+		return nil, err
+	}
+	created := nm.policies.Create(*p, nm.clock.Now())
+
+	logrus.WithFields(logrus.Fields{
+		"policy_id": created.ID,
+		"name":      created.Name,
+		"action":    created.Action.Type,
+	}).Info("Routing policy created")
+	return &created, nil
+}
+
+// UpdateRoutingPolicy validates and replaces a routing policy
+func (nm *NotificationManagerImpl) UpdateRoutingPolicy(policyID string, p *policy.Policy) (interface{}, error) {
+	if err := p.Validate(isPolicyChannel); err != nil {
+		return nil, err
+	}
+	updated, err := nm.policies.Update(policyID, *p, nm.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"policy_id": updated.ID,
+		"name":      updated.Name,
+		"enabled":   updated.Enabled,
+	}).Info("Routing policy updated")
+	return &updated, nil
+}
+
+// DeleteRoutingPolicy removes a routing policy
+func (nm *NotificationManagerImpl) DeleteRoutingPolicy(policyID string) error {
+	if err := nm.policies.Delete(policyID); err != nil {
+		return err
+	}
+	logrus.WithField("policy_id", policyID).Info("Routing policy deleted")
+	return nil
+}
+
+// EvaluateRoutingPolicies reports what the routing policies decide for every recipient of a
+// request without sending it. Candidate policies are evaluated instead of the stored ones when
+// given, so a policy can be tried before it is saved.
+func (nm *NotificationManagerImpl) EvaluateRoutingPolicies(request *models.NotificationRequest, candidates []policy.Policy) (interface{}, error) {
+	policies := nm.policies.List()
+	if candidates != nil {
+		for i := range candidates {
+			if err := candidates[i].Validate(isPolicyChannel); err != nil {
+				return nil, err
+			}
+		}
+		policies = append([]policy.Policy(nil), candidates...)
+		policy.Sort(policies)
+	}
+
+	evaluated := *request
+	category, err := nm.resolveCategory(request)
+	if err != nil {
+		return nil, err
+	}
+	evaluated.Category = category
+
+	users, err := nm.resolveRecipients(request.Recipients)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*models.UserNotificationInfo, len(users))
+	for _, userInfo := range users {
+		byID[userInfo.ID] = userInfo
+	}
+
+	results := make([]PolicyEvaluation, 0, len(request.Recipients))
+	for _, recipient := range request.Recipients {
+		result := PolicyEvaluation{Recipient: recipient, Outcome: "deliver", Channel: evaluated.Type}
+		userInfo, ok := byID[recipient]
+		if !ok {
+			result.Channel = ""
+			result.Error = ErrUserNotFound.Error()
+			results = append(results, result)
+			continue
+		}
+
+		if decision := policy.Evaluate(policies, policyAttributes(&evaluated, userInfo)); decision != nil {
+			result.Decision = decision
+			result.Outcome = decision.Action.Type
+			routed, reason := applyPolicyDecision(&evaluated, decision)
+			result.Channel = ""
+			if routed != nil {
+				result.Channel = routed.Type
+			}
+			result.Error = reason
+		}
+		results = append(results, result)
+	}
+
+	return map[string]interface{}{
+		"results": results,
+		"count":   len(results),
+	}, nil
+}
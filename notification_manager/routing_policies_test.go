@@ -0,0 +1,116 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/gaurav2721/notification-service/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessNotificationRequest_RoutingPolicies(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 1, DefaultConfig())
+	require.NoError(t, nm.userService.CreateUser(&models.User{
+		ID:           "partner-user",
+		Email:        "bob@partner.com",
+		SlackChannel: "@bob",
+		IsActive:     true,
+	}))
+	require.NoError(t, nm.userService.CreateUser(&models.User{
+		ID:       "vendor-user",
+		Email:    "carol@vendor.com",
+		IsActive: true,
+	}))
+
+	_, err := nm.CreateRoutingPolicy(&policy.Policy{
+		Name:      "Partners get marketing on Slack",
+		Enabled:   true,
+		Condition: policy.Condition{Field: policy.FieldRecipientEmailDomain, Op: policy.OpEq, Value: "partner.com"},
+		Action:    policy.Action{Type: policy.ActionRoute, Channel: "slack"},
+	})
+	require.NoError(t, err)
+	_, err = nm.CreateRoutingPolicy(&policy.Policy{
+		Name:    "No marketing to vendors",
+		Enabled: true,
+		Condition: policy.Condition{All: []policy.Condition{
+			{Field: policy.FieldCategory, Op: policy.OpEq, Value: models.CategoryMarketing},
+			{Field: policy.FieldRecipientEmailDomain, Op: policy.OpEq, Value: "vendor.com"},
+		}},
+		Action: policy.Action{Type: policy.ActionSuppress},
+	})
+	require.NoError(t, err)
+
+	result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:     "email",
+		Category: models.CategoryMarketing,
+		Content:  map[string]interface{}{"subject": "Spring sale", "email_body": "Everything is 20% off."},
+		ChannelContent: map[string]map[string]interface{}{
+			models.ChannelContentSlack: {"text": "Spring sale: everything is 20% off"},
+		},
+		Recipients: []string{recipients[0], "partner-user", "vendor-user"},
+	})
+	require.NoError(t, err)
+
+	progress, err := nm.storage.GetProgress(result.(map[string]interface{})["id"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, 2, progress.Queued)
+	assert.Equal(t, 1, progress.Suppressed)
+
+	require.Len(t, kafkaService.GetEmailChannel(), 1)
+	var email models.EmailNotificationRequest
+	require.NoError(t, json.Unmarshal([]byte(<-kafkaService.GetEmailChannel()), &email))
+	assert.Equal(t, recipients[0], email.UserID)
+
+	require.Len(t, kafkaService.GetSlackChannel(), 1)
+	var slack models.SlackNotificationRequest
+	require.NoError(t, json.Unmarshal([]byte(<-kafkaService.GetSlackChannel()), &slack))
+	assert.Equal(t, "Spring sale: everything is 20% off", slack.Content.Text)
+}
+
+func TestEvaluateRoutingPolicies(t *testing.T) {
+	nm, _, recipients := newTestManager(t, 2, DefaultConfig())
+	stored, err := nm.CreateRoutingPolicy(&policy.Policy{
+		Name:      "Quiet first user",
+		Enabled:   true,
+		Condition: policy.Condition{Field: policy.FieldRecipientID, Op: policy.OpEq, Value: recipients[0]},
+		Action:    policy.Action{Type: policy.ActionSuppress},
+	})
+	require.NoError(t, err)
+
+	request := &models.NotificationRequest{
+		Type:       "email",
+		Content:    map[string]interface{}{"subject": "Hello", "email_body": "Body"},
+		Recipients: []string{recipients[0], recipients[1], "unknown-user"},
+	}
+	result, err := nm.EvaluateRoutingPolicies(request, nil)
+	require.NoError(t, err)
+	results := result.(map[string]interface{})["results"].([]PolicyEvaluation)
+	require.Len(t, results, 3)
+	assert.Equal(t, PolicyEvaluation{
+		Recipient: recipients[0],
+		Outcome:   policy.ActionSuppress,
+		Decision:  &policy.Decision{PolicyID: stored.(*policy.Policy).ID, PolicyName: "Quiet first user", Action: policy.Action{Type: policy.ActionSuppress}},
+	}, results[0])
+	assert.Equal(t, PolicyEvaluation{Recipient: recipients[1], Outcome: "deliver", Channel: "email"}, results[1])
+	assert.Equal(t, ErrUserNotFound.Error(), results[2].Error)
+
+	// Candidate policies are evaluated instead of the stored ones; a route without content for
+	// the channel keeps the notification type
+	result, err = nm.EvaluateRoutingPolicies(request, []policy.Policy{{
+		Name:    "Everything to Slack",
+		Enabled: true,
+		Action:  policy.Action{Type: policy.ActionRoute, Channel: "slack"},
+	}})
+	require.NoError(t, err)
+	results = result.(map[string]interface{})["results"].([]PolicyEvaluation)
+	assert.Equal(t, policy.ActionRoute, results[0].Outcome)
+	assert.Equal(t, "email", results[0].Channel)
+	assert.Contains(t, results[0].Error, "no channel_content for slack")
+
+	_, err = nm.EvaluateRoutingPolicies(request, []policy.Policy{{Name: "broken", Action: policy.Action{Type: "drop"}}})
+	assert.True(t, errors.Is(err, policy.ErrInvalidPolicy))
+	assert.Len(t, nm.ListRoutingPolicies(), 1, "a dry run does not store candidates")
+}
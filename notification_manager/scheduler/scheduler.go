@@ -4,19 +4,27 @@ import (
 	"sync"
 	"time"
 
+	"github.com/gaurav2721/notification-service/clock"
 	"github.com/sirupsen/logrus"
 )
 
 // SchedulerImpl implements the Scheduler interface
 type SchedulerImpl struct {
-	jobs  map[string]*time.Timer
+	jobs  map[string]clock.Timer
 	mutex sync.RWMutex
+	clock clock.Clock
 }
 
 // NewScheduler creates a new scheduler instance
 func NewScheduler() *SchedulerImpl {
+	return NewSchedulerWithClock(clock.Real())
+}
+
+// NewSchedulerWithClock creates a scheduler whose jobs are timed by the given clock
+func NewSchedulerWithClock(c clock.Clock) *SchedulerImpl {
 	return &SchedulerImpl{
-		jobs: make(map[string]*time.Timer),
+		jobs:  make(map[string]clock.Timer),
+		clock: c,
 	}
 }
 
@@ -26,7 +34,7 @@ func (ss *SchedulerImpl) ScheduleJob(jobID string, scheduledTime time.Time, job
 	defer ss.mutex.Unlock()
 
 	// Log scheduling information for debugging
-	now := time.Now()
+	now := ss.clock.Now()
 	delay := scheduledTime.Sub(now)
 
 	logrus.WithFields(logrus.Fields{
@@ -47,7 +55,7 @@ func (ss *SchedulerImpl) ScheduleJob(jobID string, scheduledTime time.Time, job
 	}
 
 	// Create a timer for the scheduled job
-	timer := time.AfterFunc(delay, func() {
+	timer := ss.clock.AfterFunc(delay, func() {
 		logrus.WithField("job_id", jobID).Info("Executing scheduled job")
 
 		// Execute the original job
@@ -94,5 +102,5 @@ func (ss *SchedulerImpl) Stop() {
 	}
 
 	// Clear the jobs map
-	ss.jobs = make(map[string]*time.Timer)
+	ss.jobs = make(map[string]clock.Timer)
 }
@@ -0,0 +1,32 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_RunsAndCancelsJobsOnClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFake(start)
+	s := NewSchedulerWithClock(fakeClock)
+
+	var ran []string
+	require.NoError(t, s.ScheduleJob("first", start.Add(time.Hour), func() { ran = append(ran, "first") }))
+	require.NoError(t, s.ScheduleJob("cancelled", start.Add(time.Hour), func() { ran = append(ran, "cancelled") }))
+	require.NoError(t, s.CancelJob("cancelled"))
+
+	fakeClock.Advance(time.Hour - time.Second)
+	assert.Empty(t, ran)
+
+	fakeClock.Advance(time.Second)
+	assert.Equal(t, []string{"first"}, ran)
+	assert.Zero(t, fakeClock.PendingTimers())
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	assert.Empty(t, s.jobs)
+}
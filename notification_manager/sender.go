@@ -0,0 +1,121 @@
+package notification_manager
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/sirupsen/logrus"
+)
+
+// senderStore keeps the email sender settings tenants have set
+type senderStore struct {
+	mu       sync.RWMutex
+	settings map[string]models.SenderSettings
+}
+
+// newSenderStore creates an empty sender store
+func newSenderStore() *senderStore {
+	return &senderStore{
+		settings: make(map[string]models.SenderSettings),
+	}
+}
+
+// Get returns the sender settings of a tenant and whether the tenant set any
+func (s *senderStore) Get(tenant string) (models.SenderSettings, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	settings, exists := s.settings[tenant]
+	return settings, exists
+}
+
+// Set replaces the sender settings of a tenant
+func (s *senderStore) Set(settings models.SenderSettings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings[settings.Tenant] = settings
+}
+
+// senderSettings returns the sender settings in effect for a tenant: the ones it set, with the
+// default from address and allowed domains of the configuration filling in those it left empty
+func (nm *NotificationManagerImpl) senderSettings(tenant string) models.SenderSettings {
+	settings, _ := nm.senders.Get(tenant)
+	settings.Tenant = tenant
+	if settings.DefaultFrom == "" {
+		settings.DefaultFrom = nm.config.DefaultFromEmail
+	}
+	if len(settings.AllowedDomains) == 0 {
+		settings.AllowedDomains = nm.config.AllowedFromDomains
+	}
+	return settings
+}
+
+// applySender fills in the default from address of the tenant for email notifications sent
+// without one and rejects from addresses outside the tenant's allowed domains. Without a default
+// from address the email service sends from its SMTP account.
+func (nm *NotificationManagerImpl) applySender(request *models.NotificationRequest) error {
+	if request.Type != string(models.EmailNotification) {
+		return nil
+	}
+
+	settings := nm.senderSettings(request.Tenant)
+	if request.From == nil || strings.TrimSpace(request.From.Email) == "" {
+		request.From = defaultSender(settings)
+	}
+	if request.From == nil {
+		return nil
+	}
+
+	if !settings.AllowsAddress(request.From.Email) {
+		return fmt.Errorf("%w: %s is not on an allowed domain (%s)",
+			ErrSenderNotAllowed, request.From.Email, strings.Join(settings.AllowedDomains, ", "))
+	}
+	return nil
+}
+
+// defaultSender returns the default from address of settings, nil when there is none
+func defaultSender(settings models.SenderSettings) *struct {
+	Email string `json:"email"`
+} {
+	if settings.DefaultFrom == "" {
+		return nil
+	}
+	return &struct {
+		Email string `json:"email"`
+	}{Email: settings.DefaultFrom}
+}
+
+// GetSenderSettings returns the sender settings in effect for a tenant
+func (nm *NotificationManagerImpl) GetSenderSettings(tenant string) interface{} {
+	settings := nm.senderSettings(tenant)
+	if stored, exists := nm.senders.Get(tenant); exists {
+		settings.UpdatedAt = stored.UpdatedAt
+		settings.UpdatedBy = stored.UpdatedBy
+	}
+	return &settings
+}
+
+// UpdateSenderSettings replaces the sender settings of a tenant
+func (nm *NotificationManagerImpl) UpdateSenderSettings(tenant string, settings *models.SenderSettings, actor string) interface{} {
+	updated := *settings
+	updated.Tenant = tenant
+	updated.DefaultFrom = strings.TrimSpace(updated.DefaultFrom)
+	updated.AllowedDomains = make([]string, 0, len(settings.AllowedDomains))
+	for _, domain := range settings.AllowedDomains {
+		updated.AllowedDomains = append(updated.AllowedDomains, strings.ToLower(strings.TrimSpace(domain)))
+	}
+	updated.UpdatedAt = nm.clock.Now()
+	updated.UpdatedBy = actor
+	nm.senders.Set(updated)
+
+	logrus.WithFields(logrus.Fields{
+		"tenant":          tenant,
+		"default_from":    updated.DefaultFrom,
+		"allowed_domains": updated.AllowedDomains,
+		"actor":           actor,
+	}).Info("Tenant sender settings updated")
+
+	return &updated
+}
@@ -0,0 +1,70 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessNotificationRequest_SenderSettings(t *testing.T) {
+	config := DefaultConfig()
+	config.DefaultFromEmail = "noreply@company.com"
+	config.AllowedFromDomains = []string{"company.com"}
+	nm, kafkaService, recipients := newTestManager(t, 1, config)
+
+	send := func(tenant, from string) error {
+		request := &models.NotificationRequest{
+			Type:       "email",
+			Content:    map[string]interface{}{"subject": "Hello", "email_body": "Body"},
+			Recipients: recipients,
+			Tenant:     tenant,
+		}
+		if from != "" {
+			request.From = &struct {
+				Email string `json:"email"`
+			}{Email: from}
+		}
+		_, err := nm.ProcessNotificationRequest(request)
+		return err
+	}
+	sentFrom := func() string {
+		var email models.EmailNotificationRequest
+		require.NoError(t, json.Unmarshal([]byte(<-kafkaService.GetEmailChannel()), &email))
+		require.NotNil(t, email.From)
+		return email.From.Email
+	}
+
+	// Tenants without settings use the configured default and allowlist
+	require.NoError(t, send("bob", ""))
+	assert.Equal(t, "noreply@company.com", sentFrom())
+	require.NoError(t, send("bob", "Billing <billing@company.com>"))
+	assert.Equal(t, "Billing <billing@company.com>", sentFrom())
+	err := send("bob", "billing@company.io")
+	assert.ErrorIs(t, err, ErrSenderNotAllowed)
+	assert.Contains(t, err.Error(), "billing@company.io is not on an allowed domain (company.com)")
+
+	// A tenant's own settings replace them
+	settings := nm.UpdateSenderSettings("acme", &models.SenderSettings{
+		DefaultFrom:    "hello@acme.com",
+		AllowedDomains: []string{"acme.com", "*.acme.com"},
+	}, "acme").(*models.SenderSettings)
+	assert.Equal(t, "acme", settings.UpdatedBy)
+
+	require.NoError(t, send("acme", ""))
+	assert.Equal(t, "hello@acme.com", sentFrom())
+	require.NoError(t, send("acme", "alerts@eu.mail.acme.com"))
+	assert.Equal(t, "alerts@eu.mail.acme.com", sentFrom())
+	assert.ErrorIs(t, send("acme", "noreply@company.com"), ErrSenderNotAllowed)
+	assert.ErrorIs(t, send("acme", "noreply@notacme.com"), ErrSenderNotAllowed)
+
+	// Settings left empty fall back to the configuration
+	nm.UpdateSenderSettings("acme", &models.SenderSettings{AllowedDomains: []string{"acme.com"}}, "acme")
+	effective := nm.GetSenderSettings("acme").(*models.SenderSettings)
+	assert.Equal(t, "noreply@company.com", effective.DefaultFrom)
+	assert.Equal(t, []string{"acme.com"}, effective.AllowedDomains)
+	assert.Equal(t, "acme", effective.UpdatedBy)
+	assert.Empty(t, kafkaService.GetEmailChannel())
+}
@@ -1,9 +1,11 @@
 package notification_manager
 
 import (
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/gaurav2721/notification-service/clock"
 	"github.com/gaurav2721/notification-service/models"
 	"github.com/sirupsen/logrus"
 )
@@ -18,36 +20,212 @@ const (
 	StatusSent      NotificationStatus = "sent"
 	StatusFailed    NotificationStatus = "failed"
 	StatusCancelled NotificationStatus = "cancelled"
+	StatusExpired   NotificationStatus = "expired"
 )
 
+// IsTerminal reports whether a notification in this status does not change status anymore
+func (status NotificationStatus) IsTerminal() bool {
+	switch status {
+	case StatusSent, StatusFailed, StatusCancelled, StatusExpired:
+		return true
+	}
+	return false
+}
+
 // NotificationRecord represents a stored notification record
 type NotificationRecord struct {
-	ID          string                 `json:"id"`
-	Type        string                 `json:"type"`
-	Content     map[string]interface{} `json:"content"`
-	Template    *models.TemplateData   `json:"template,omitempty"`
-	Recipients  []string               `json:"recipients"`
-	ScheduledAt *time.Time             `json:"scheduled_at,omitempty"`
-	From        *struct {
+	ID            string                 `json:"id"`
+	ExternalID    string                 `json:"external_id,omitempty"`
+	Tags          []string               `json:"tags,omitempty"`
+	Category      string                 `json:"category,omitempty"`
+	Transactional bool                   `json:"transactional,omitempty"`
+	Reason        string                 `json:"reason,omitempty"`
+	ThreadKey     string                 `json:"thread_key,omitempty"`
+	Tenant        string                 `json:"tenant,omitempty"`
+	Type          string                 `json:"type"`
+	Content       map[string]interface{} `json:"content"`
+	Template      *models.TemplateData   `json:"template,omitempty"`
+	Recipients    []string               `json:"recipients"`
+	ScheduledAt   *time.Time             `json:"scheduled_at,omitempty"`
+	From          *struct {
 		Email string `json:"email"`
 	} `json:"from,omitempty"`
-	Status    NotificationStatus `json:"status"`
-	CreatedAt time.Time          `json:"created_at"`
-	UpdatedAt time.Time          `json:"updated_at"`
-	SentAt    *time.Time         `json:"sent_at,omitempty"`
-	Error     string             `json:"error,omitempty"`
+	Status    NotificationStatus       `json:"status"`
+	CreatedAt time.Time                `json:"created_at"`
+	UpdatedAt time.Time                `json:"updated_at"`
+	SentAt    *time.Time               `json:"sent_at,omitempty"`
+	Error     string                   `json:"error,omitempty"`
+	Progress  *NotificationProgress    `json:"progress,omitempty"`
+	Audit     []NotificationAuditEntry `json:"audit,omitempty"`
+
+	// changed is closed and replaced whenever the status changes, waking up waiting readers
+	changed chan struct{}
+}
+
+// notifyChanged wakes up the readers waiting for a status change of the record.
+// The caller must hold the storage lock.
+func (r *NotificationRecord) notifyChanged() {
+	if r.changed != nil {
+		close(r.changed)
+	}
+	r.changed = make(chan struct{})
+}
+
+// snapshot returns a copy of the record that callers can read without the storage lock.
+// The caller must hold the storage lock.
+func (r *NotificationRecord) snapshot() *NotificationRecord {
+	copied := *r
+	copied.changed = nil
+	if r.SentAt != nil {
+		sentAt := *r.SentAt
+		copied.SentAt = &sentAt
+	}
+	if r.Progress != nil {
+		progress := *r.Progress
+		copied.Progress = &progress
+	}
+	copied.Audit = append([]NotificationAuditEntry(nil), r.Audit...)
+	return &copied
+}
+
+// NotificationAuditEntry records a status change the service made on its own or on request,
+// such as expiring or cancelling a scheduled notification
+type NotificationAuditEntry struct {
+	At         time.Time          `json:"at"`
+	FromStatus NotificationStatus `json:"from_status"`
+	ToStatus   NotificationStatus `json:"to_status"`
+	Reason     string             `json:"reason"`
+}
+
+// NotificationProgress tracks the fan-out of a notification to its recipients
+type NotificationProgress struct {
+	TotalRecipients int        `json:"total_recipients"`
+	Resolved        int        `json:"resolved"`
+	Skipped         int        `json:"skipped"`
+	Suppressed      int        `json:"suppressed"`
+	Deferred        int        `json:"deferred"`
+	Unreachable     int        `json:"unreachable"`
+	Queued          int        `json:"queued"`
+	Failed          int        `json:"failed"`
+	StartedAt       time.Time  `json:"started_at"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+}
+
+// NotificationFilter selects stored notifications. Empty fields match every notification;
+// a notification must carry all of the given tags to match.
+type NotificationFilter struct {
+	ExternalID string
+	Tags       []string
+	Status     NotificationStatus
+	Type       string
+}
+
+// Matches checks whether a record satisfies the filter
+func (f NotificationFilter) Matches(record *NotificationRecord) bool {
+	if f.ExternalID != "" && record.ExternalID != f.ExternalID {
+		return false
+	}
+	if f.Status != "" && record.Status != f.Status {
+		return false
+	}
+	if f.Type != "" && record.Type != f.Type {
+		return false
+	}
+
+	for _, tag := range f.Tags {
+		if !hasTag(record.Tags, tag) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasRecipient reports whether the notification was sent to recipient
+func (r *NotificationRecord) hasRecipient(recipient string) bool {
+	for _, candidate := range r.Recipients {
+		if candidate == recipient {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTag checks whether tags contains tag
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationStorage stores notification records and their status. InMemoryStorage keeps them
+// in the process; PersistentStorage also writes them to a notification store.
+type NotificationStorage interface {
+	SetClock(c clock.Clock)
+	OnStatusChange(f func(record *NotificationRecord, from NotificationStatus))
+	StoreNotification(notificationID string, notification *models.NotificationRequest) error
+	GetNotification(notificationID string) (*NotificationRecord, error)
+	WatchStatus(notificationID string) (NotificationStatus, <-chan struct{}, error)
+	UpdateNotificationStatus(notificationID string, status NotificationStatus, errorMsg string) error
+	ExpireScheduled(notificationID string, reason string) (bool, error)
+	CancelScheduled(notificationID string, reason string) (bool, error)
+	QueueScheduled(notificationID string) (bool, error)
+	FinishQueued(notificationID string, status NotificationStatus, errorMsg string) (bool, error)
+	GetAllNotifications() []*NotificationRecord
+	GetNotificationsByStatus(status NotificationStatus) []*NotificationRecord
+	GetNotificationsByExternalID(externalID string) []*NotificationRecord
+	FindNotifications(filter NotificationFilter) []*NotificationRecord
+	DeleteNotification(notificationID string) error
+	GetStorageStats() map[string]interface{}
+	StartProgress(notificationID string, totalRecipients int) error
+	AddProgress(notificationID string, batch NotificationProgress) error
+	CompleteProgress(notificationID string) error
+	GetProgress(notificationID string) (*NotificationProgress, error)
 }
 
 // InMemoryStorage provides thread-safe in-memory storage for notifications
 type InMemoryStorage struct {
 	notifications map[string]*NotificationRecord
+	externalIDs   map[string][]string // externalID -> notification IDs in creation order
 	mutex         sync.RWMutex
+	clock         clock.Clock
+
+	// onStatusChange, when set, is called with the storage lock held after a record changed status
+	onStatusChange func(record *NotificationRecord, from NotificationStatus)
 }
 
 // NewInMemoryStorage creates a new in-memory storage instance
 func NewInMemoryStorage() *InMemoryStorage {
 	return &InMemoryStorage{
 		notifications: make(map[string]*NotificationRecord),
+		externalIDs:   make(map[string][]string),
+		clock:         clock.Real(),
+	}
+}
+
+// SetClock replaces the clock used to timestamp records
+func (s *InMemoryStorage) SetClock(c clock.Clock) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.clock = c
+}
+
+// OnStatusChange registers a function called after a record changed status, with an empty
+// previous status for new records. It is called with the storage lock held and must not block
+// or call back into the storage.
+func (s *InMemoryStorage) OnStatusChange(f func(record *NotificationRecord, from NotificationStatus)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.onStatusChange = f
+}
+
+// statusChanged notifies the status change observer. The caller must hold the storage lock.
+func (s *InMemoryStorage) statusChanged(record *NotificationRecord, from NotificationStatus) {
+	if s.onStatusChange != nil && record.Status != from {
+		s.onStatusChange(record, from)
 	}
 }
 
@@ -64,21 +242,37 @@ func (s *InMemoryStorage) StoreNotification(notificationID string, notification
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	now := time.Now()
+	now := s.clock.Now()
 	record := &NotificationRecord{
-		ID:          notificationID,
-		Type:        notification.Type,
-		Content:     notification.Content,
-		Template:    notification.Template,
-		Recipients:  notification.Recipients,
-		ScheduledAt: notification.ScheduledAt,
-		From:        notification.From,
-		Status:      StatusPending,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		ID:            notificationID,
+		ExternalID:    notification.ExternalID,
+		Tags:          notification.Tags,
+		Category:      notification.Category,
+		Transactional: notification.Transactional,
+		Reason:        notification.Reason,
+		ThreadKey:     notification.ThreadKey,
+		Tenant:        notification.Tenant,
+		Type:          notification.Type,
+		Content:       notification.Content,
+		Template:      notification.Template,
+		Recipients:    notification.Recipients,
+		ScheduledAt:   notification.ScheduledAt,
+		From:          notification.From,
+		Status:        StatusPending,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		changed:       make(chan struct{}),
 	}
 
+	existing, exists := s.notifications[notificationID]
+	if !exists && record.ExternalID != "" {
+		s.externalIDs[record.ExternalID] = append(s.externalIDs[record.ExternalID], notificationID)
+	}
+	if exists {
+		existing.notifyChanged()
+	}
 	s.notifications[notificationID] = record
+	s.statusChanged(record, "")
 
 	logrus.WithFields(logrus.Fields{
 		"notification_id": notificationID,
@@ -89,7 +283,7 @@ func (s *InMemoryStorage) StoreNotification(notificationID string, notification
 	return nil
 }
 
-// GetNotification retrieves a notification record by ID
+// GetNotification retrieves a copy of a notification record by ID
 func (s *InMemoryStorage) GetNotification(notificationID string) (*NotificationRecord, error) {
 	if notificationID == "" {
 		return nil, ErrUnsupportedNotificationType
@@ -103,7 +297,24 @@ func (s *InMemoryStorage) GetNotification(notificationID string) (*NotificationR
 		return nil, ErrUnsupportedNotificationType
 	}
 
-	return record, nil
+	return record.snapshot(), nil
+}
+
+// WatchStatus returns the current status of a notification together with a channel that is
+// closed on its next status change
+func (s *InMemoryStorage) WatchStatus(notificationID string) (NotificationStatus, <-chan struct{}, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	record, exists := s.notifications[notificationID]
+	if !exists {
+		return "", nil, ErrNotificationNotFound
+	}
+	if record.changed == nil {
+		record.changed = make(chan struct{})
+	}
+
+	return record.Status, record.changed, nil
 }
 
 // UpdateNotificationStatus updates the status of a notification
@@ -122,14 +333,16 @@ func (s *InMemoryStorage) UpdateNotificationStatus(notificationID string, status
 
 	oldStatus := record.Status
 	record.Status = status
-	record.UpdatedAt = time.Now()
+	record.UpdatedAt = s.clock.Now()
 	record.Error = errorMsg
+	record.notifyChanged()
 
 	// Set SentAt timestamp if status is sent
 	if status == StatusSent {
-		now := time.Now()
+		now := s.clock.Now()
 		record.SentAt = &now
 	}
+	s.statusChanged(record, oldStatus)
 
 	logrus.WithFields(logrus.Fields{
 		"notification_id": notificationID,
@@ -141,6 +354,72 @@ func (s *InMemoryStorage) UpdateNotificationStatus(notificationID string, status
 	return nil
 }
 
+// ExpireScheduled moves a scheduled notification to expired and records the reason in its
+// audit log. It returns false when the notification is no longer scheduled.
+func (s *InMemoryStorage) ExpireScheduled(notificationID string, reason string) (bool, error) {
+	return s.leaveScheduled(notificationID, StatusExpired, reason, reason)
+}
+
+// CancelScheduled moves a scheduled notification to cancelled and records the reason in its
+// audit log. It returns false when the notification is no longer scheduled.
+func (s *InMemoryStorage) CancelScheduled(notificationID string, reason string) (bool, error) {
+	return s.leaveScheduled(notificationID, StatusCancelled, reason, "")
+}
+
+// QueueScheduled moves a scheduled notification to queued once its job takes it, so it can no
+// longer be cancelled or expired. It returns false when the notification is no longer scheduled.
+func (s *InMemoryStorage) QueueScheduled(notificationID string) (bool, error) {
+	return s.transition(notificationID, StatusScheduled, StatusQueued, "", "")
+}
+
+// FinishQueued moves a queued notification to sent or failed. It returns false when the
+// notification is no longer queued.
+func (s *InMemoryStorage) FinishQueued(notificationID string, status NotificationStatus, errorMsg string) (bool, error) {
+	return s.transition(notificationID, StatusQueued, status, "", errorMsg)
+}
+
+// leaveScheduled moves a scheduled notification to status, recording reason in its audit log
+// and errorMsg as its error. It returns false when the notification is no longer scheduled.
+func (s *InMemoryStorage) leaveScheduled(notificationID string, status NotificationStatus, reason string, errorMsg string) (bool, error) {
+	return s.transition(notificationID, StatusScheduled, status, reason, errorMsg)
+}
+
+// transition moves a notification from status from to status to, recording errorMsg as its error
+// and a non-empty reason in its audit log. It returns false when the notification is no longer
+// in status from.
+func (s *InMemoryStorage) transition(notificationID string, from NotificationStatus, to NotificationStatus, reason string, errorMsg string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	record, exists := s.notifications[notificationID]
+	if !exists {
+		return false, ErrNotificationNotFound
+	}
+	if record.Status != from {
+		return false, nil
+	}
+
+	now := s.clock.Now()
+	if reason != "" {
+		record.Audit = append(record.Audit, NotificationAuditEntry{
+			At:         now,
+			FromStatus: from,
+			ToStatus:   to,
+			Reason:     reason,
+		})
+	}
+	record.Status = to
+	record.Error = errorMsg
+	record.UpdatedAt = now
+	if to == StatusSent {
+		record.SentAt = &now
+	}
+	record.notifyChanged()
+	s.statusChanged(record, from)
+
+	return true, nil
+}
+
 // GetAllNotifications retrieves all stored notifications
 func (s *InMemoryStorage) GetAllNotifications() []*NotificationRecord {
 	s.mutex.RLock()
@@ -148,7 +427,7 @@ func (s *InMemoryStorage) GetAllNotifications() []*NotificationRecord {
 
 	notifications := make([]*NotificationRecord, 0, len(s.notifications))
 	for _, record := range s.notifications {
-		notifications = append(notifications, record)
+		notifications = append(notifications, record.snapshot())
 	}
 
 	return notifications
@@ -162,13 +441,59 @@ func (s *InMemoryStorage) GetNotificationsByStatus(status NotificationStatus) []
 	var notifications []*NotificationRecord
 	for _, record := range s.notifications {
 		if record.Status == status {
-			notifications = append(notifications, record)
+			notifications = append(notifications, record.snapshot())
+		}
+	}
+
+	return notifications
+}
+
+// GetNotificationsByExternalID retrieves the notifications created with an external reference ID, oldest first
+func (s *InMemoryStorage) GetNotificationsByExternalID(externalID string) []*NotificationRecord {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	ids := s.externalIDs[externalID]
+	notifications := make([]*NotificationRecord, 0, len(ids))
+	for _, id := range ids {
+		if record, exists := s.notifications[id]; exists {
+			notifications = append(notifications, record.snapshot())
 		}
 	}
 
 	return notifications
 }
 
+// FindNotifications retrieves copies of the notifications matching the filter, oldest first
+func (s *InMemoryStorage) FindNotifications(filter NotificationFilter) []*NotificationRecord {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var notifications []*NotificationRecord
+
+	// The external ID index keeps creation order and avoids a full scan
+	if filter.ExternalID != "" {
+		for _, id := range s.externalIDs[filter.ExternalID] {
+			if record, exists := s.notifications[id]; exists && filter.Matches(record) {
+				notifications = append(notifications, record.snapshot())
+			}
+		}
+		return notifications
+	}
+
+	for _, record := range s.notifications {
+		if filter.Matches(record) {
+			notifications = append(notifications, record.snapshot())
+		}
+	}
+
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].CreatedAt.Before(notifications[j].CreatedAt)
+	})
+
+	return notifications
+}
+
 // DeleteNotification removes a notification from storage
 func (s *InMemoryStorage) DeleteNotification(notificationID string) error {
 	if notificationID == "" {
@@ -178,10 +503,27 @@ func (s *InMemoryStorage) DeleteNotification(notificationID string) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	if _, exists := s.notifications[notificationID]; !exists {
+	record, exists := s.notifications[notificationID]
+	if !exists {
 		return ErrUnsupportedNotificationType
 	}
 
+	if record.ExternalID != "" {
+		ids := s.externalIDs[record.ExternalID]
+		for i, id := range ids {
+			if id == notificationID {
+				ids = append(ids[:i], ids[i+1:]...)
+				break
+			}
+		}
+		if len(ids) == 0 {
+			delete(s.externalIDs, record.ExternalID)
+		} else {
+			s.externalIDs[record.ExternalID] = ids
+		}
+	}
+
+	record.notifyChanged()
 	delete(s.notifications, notificationID)
 
 	logrus.WithField("notification_id", notificationID).Debug("Notification deleted from memory")
@@ -205,3 +547,75 @@ func (s *InMemoryStorage) GetStorageStats() map[string]interface{} {
 
 	return stats
 }
+
+// StartProgress resets the fan-out progress of a notification
+func (s *InMemoryStorage) StartProgress(notificationID string, totalRecipients int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	record, exists := s.notifications[notificationID]
+	if !exists {
+		return ErrNotificationNotFound
+	}
+
+	record.Progress = &NotificationProgress{
+		TotalRecipients: totalRecipients,
+		StartedAt:       s.clock.Now(),
+	}
+	record.UpdatedAt = s.clock.Now()
+
+	return nil
+}
+
+// AddProgress adds the counters of a processed recipient batch to the notification progress
+func (s *InMemoryStorage) AddProgress(notificationID string, batch NotificationProgress) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	record, exists := s.notifications[notificationID]
+	if !exists || record.Progress == nil {
+		return ErrNotificationNotFound
+	}
+
+	record.Progress.Resolved += batch.Resolved
+	record.Progress.Skipped += batch.Skipped
+	record.Progress.Suppressed += batch.Suppressed
+	record.Progress.Deferred += batch.Deferred
+	record.Progress.Unreachable += batch.Unreachable
+	record.Progress.Queued += batch.Queued
+	record.Progress.Failed += batch.Failed
+	record.UpdatedAt = s.clock.Now()
+
+	return nil
+}
+
+// CompleteProgress marks the fan-out of a notification as finished
+func (s *InMemoryStorage) CompleteProgress(notificationID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	record, exists := s.notifications[notificationID]
+	if !exists || record.Progress == nil {
+		return ErrNotificationNotFound
+	}
+
+	now := s.clock.Now()
+	record.Progress.CompletedAt = &now
+	record.UpdatedAt = now
+
+	return nil
+}
+
+// GetProgress returns a snapshot of the fan-out progress of a notification
+func (s *InMemoryStorage) GetProgress(notificationID string) (*NotificationProgress, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	record, exists := s.notifications[notificationID]
+	if !exists || record.Progress == nil {
+		return nil, ErrNotificationNotFound
+	}
+
+	progress := *record.Progress
+	return &progress, nil
+}
@@ -0,0 +1,36 @@
+package notification_manager
+
+import (
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryStorage_ReturnsCopies(t *testing.T) {
+	storage := NewInMemoryStorage()
+	require.NoError(t, storage.StoreNotification("notif-1", &models.NotificationRequest{
+		Type:       "email",
+		Recipients: []string{"user-001"},
+		ExternalID: "order-42",
+	}))
+	require.NoError(t, storage.StartProgress("notif-1", 1))
+
+	found := storage.FindNotifications(NotificationFilter{ExternalID: "order-42"})
+	require.Len(t, found, 1)
+	record, err := storage.GetNotification("notif-1")
+	require.NoError(t, err)
+
+	// Later changes do not reach records handed out before them
+	require.NoError(t, storage.AddProgress("notif-1", NotificationProgress{Resolved: 1, Queued: 1}))
+	require.NoError(t, storage.UpdateNotificationStatus("notif-1", StatusSent, ""))
+	assert.Equal(t, StatusPending, found[0].Status)
+	assert.Equal(t, 0, found[0].Progress.Queued)
+	assert.Nil(t, record.SentAt)
+
+	record, err = storage.GetNotification("notif-1")
+	require.NoError(t, err)
+	assert.Equal(t, StatusSent, record.Status)
+	assert.Equal(t, 1, record.Progress.Queued)
+}
@@ -0,0 +1,155 @@
+package notification_manager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/gaurav2721/notification-service/textdiff"
+)
+
+// templateDiffContext is the number of unchanged lines shown around changes in unified diffs
+const templateDiffContext = 3
+
+// TemplateFieldDiff is a template field that differs between two versions
+type TemplateFieldDiff struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+	// Added and Removed list the variables added to and removed from required_variables
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	// Unified is the line diff of the field
+	Unified string `json:"unified"`
+}
+
+// TemplateDiff lists the fields that differ between two versions of a template, along with a
+// unified diff of all of them
+type TemplateDiff struct {
+	TemplateID  string              `json:"template_id"`
+	FromVersion int                 `json:"from_version"`
+	ToVersion   int                 `json:"to_version"`
+	Changes     []TemplateFieldDiff `json:"changes"`
+	Unified     string              `json:"unified"`
+}
+
+// DiffTemplateVersions compares two versions of a template: the subject, bodies and other
+// fields of the content in every locale, the required variables and the settings
+func (nm *NotificationManagerImpl) DiffTemplateVersions(templateID string, fromVersion, toVersion int) (interface{}, error) {
+	from, err := nm.templateManager.GetTemplateByIDAndVersion(templateID, fromVersion)
+	if err != nil {
+		return nil, fmt.Errorf("%w: version %d", ErrTemplateNotFound, fromVersion)
+	}
+	to, err := nm.templateManager.GetTemplateByIDAndVersion(templateID, toVersion)
+	if err != nil {
+		return nil, fmt.Errorf("%w: version %d", ErrTemplateNotFound, toVersion)
+	}
+
+	diff := &TemplateDiff{TemplateID: templateID, FromVersion: fromVersion, ToVersion: toVersion, Changes: []TemplateFieldDiff{}}
+	fromName := fmt.Sprintf("%s@v%d", templateID, fromVersion)
+	toName := fmt.Sprintf("%s@v%d", templateID, toVersion)
+	var unified strings.Builder
+	for _, field := range templateDiffFields(from, to) {
+		if field.old == field.new {
+			continue
+		}
+		change := TemplateFieldDiff{
+			Field:   field.name,
+			Old:     field.old,
+			New:     field.new,
+			Unified: textdiff.Unified(fromName+"/"+field.name, toName+"/"+field.name, field.old, field.new, templateDiffContext),
+		}
+		if field.name == "required_variables" {
+			change.Old = strings.Join(from.RequiredVariables, ",")
+			change.New = strings.Join(to.RequiredVariables, ",")
+			change.Added, change.Removed = variableChanges(from.RequiredVariables, to.RequiredVariables)
+		}
+		diff.Changes = append(diff.Changes, change)
+		unified.WriteString(change.Unified)
+	}
+	diff.Unified = unified.String()
+	return diff, nil
+}
+
+// templateDiffField is the value of a template field in two versions
+type templateDiffField struct {
+	name     string
+	old, new string
+}
+
+// templateDiffFields returns the comparable fields of two template versions; localizations
+// are compared field by field in every locale of either version, sorted by locale. Required
+// variables are compared one per line, sorted, so reordering them is no change.
+func templateDiffFields(from, to *models.Template) []templateDiffField {
+	fields := []templateDiffField{
+		{"name", from.Name, to.Name},
+		{"description", from.Description, to.Description},
+		{"category", from.Category, to.Category},
+		{"render_mode", from.RenderMode, to.RenderMode},
+		{"color_scheme", from.ColorScheme, to.ColorScheme},
+		{"locale", from.Locale, to.Locale},
+	}
+	fields = append(fields, contentDiffFields("content.", from.Content, to.Content)...)
+	fields = append(fields, templateDiffField{"required_variables", sortedLines(from.RequiredVariables), sortedLines(to.RequiredVariables)})
+
+	locales := make(map[string]bool)
+	for locale := range from.Localizations {
+		locales[locale] = true
+	}
+	for locale := range to.Localizations {
+		locales[locale] = true
+	}
+	sorted := make([]string, 0, len(locales))
+	for locale := range locales {
+		sorted = append(sorted, locale)
+	}
+	sort.Strings(sorted)
+	for _, locale := range sorted {
+		fields = append(fields, contentDiffFields("localizations."+locale+".", from.Localizations[locale], to.Localizations[locale])...)
+	}
+	return fields
+}
+
+// contentDiffFields returns the fields of two template contents; field names get prefix
+func contentDiffFields(prefix string, from, to models.TemplateContent) []templateDiffField {
+	return []templateDiffField{
+		{prefix + "subject", from.Subject, to.Subject},
+		{prefix + "email_body", from.EmailBody, to.EmailBody},
+		{prefix + "email_text_body", from.EmailTextBody, to.EmailTextBody},
+		{prefix + "email_dark_mode_css", from.EmailDarkModeCSS, to.EmailDarkModeCSS},
+		{prefix + "text", from.Text, to.Text},
+		{prefix + "title", from.Title, to.Title},
+		{prefix + "body", from.Body, to.Body},
+	}
+}
+
+// sortedLines returns values sorted, one per line
+func sortedLines(values []string) string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, "\n")
+}
+
+// variableChanges returns the variables only in to and the ones only in from, sorted
+func variableChanges(from, to []string) (added, removed []string) {
+	inFrom := make(map[string]bool, len(from))
+	for _, variable := range from {
+		inFrom[variable] = true
+	}
+	inTo := make(map[string]bool, len(to))
+	for _, variable := range to {
+		inTo[variable] = true
+		if !inFrom[variable] {
+			added = append(added, variable)
+		}
+	}
+	for _, variable := range from {
+		if !inTo[variable] {
+			removed = append(removed, variable)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
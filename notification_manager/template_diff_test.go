@@ -0,0 +1,103 @@
+package notification_manager
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffTemplateVersions(t *testing.T) {
+	nm, _, _ := newTestManager(t, 1, DefaultConfig())
+
+	created, err := nm.CreateTemplate(&models.Template{
+		Name: "Receipt",
+		Type: models.EmailNotification,
+		Content: models.TemplateContent{
+			Subject:   "Your receipt",
+			EmailBody: "<p>Hi {{name}},</p>\n<p>Thanks for your order.</p>\n<p>The team</p>",
+		},
+		RequiredVariables: []string{"name", "order_id"},
+		Locale:            "en",
+		Localizations: map[string]models.TemplateContent{
+			"fr": {Subject: "Votre reçu", EmailBody: "<p>Merci</p>"},
+		},
+	})
+	require.NoError(t, err)
+	templateID := created.(*models.TemplateResponse).ID
+
+	_, err = nm.UpdateTemplate(templateID, &models.Template{
+		Name: "Receipt",
+		Type: models.EmailNotification,
+		Content: models.TemplateContent{
+			Subject:   "Your receipt for {{order_id}}",
+			EmailBody: "<p>Hi {{name}},</p>\n<p>Thanks for your order of {{total}}.</p>\n<p>The team</p>",
+		},
+		RequiredVariables: []string{"total", "order_id", "name"},
+		Locale:            "en",
+		Localizations: map[string]models.TemplateContent{
+			"fr": {Subject: "Votre reçu", EmailBody: "<p>Merci</p>"},
+			"de": {Subject: "Ihre Quittung", EmailBody: "<p>Danke</p>"},
+		},
+	}, "alice")
+	require.NoError(t, err)
+
+	result, err := nm.DiffTemplateVersions(templateID, 1, 2)
+	require.NoError(t, err)
+	diff := result.(*TemplateDiff)
+	assert.Equal(t, 1, diff.FromVersion)
+	assert.Equal(t, 2, diff.ToVersion)
+
+	changes := make(map[string]TemplateFieldDiff)
+	var fields []string
+	for _, change := range diff.Changes {
+		changes[change.Field] = change
+		fields = append(fields, change.Field)
+	}
+	assert.Equal(t, []string{
+		"content.subject",
+		"content.email_body",
+		"required_variables",
+		"localizations.de.subject",
+		"localizations.de.email_body",
+	}, fields)
+
+	assert.Equal(t, "Your receipt", changes["content.subject"].Old)
+	assert.Equal(t, "Your receipt for {{order_id}}", changes["content.subject"].New)
+	assert.Equal(t, []string{"total"}, changes["required_variables"].Added)
+	assert.Empty(t, changes["required_variables"].Removed)
+	assert.Equal(t, "+++ "+templateID+"@v2/content.email_body", splitLine(changes["content.email_body"].Unified, 1))
+	assert.Contains(t, changes["content.email_body"].Unified, "-<p>Thanks for your order.</p>\n+<p>Thanks for your order of {{total}}.</p>\n")
+	assert.Contains(t, diff.Unified, changes["content.email_body"].Unified)
+	assert.Contains(t, diff.Unified, "+Ihre Quittung\n")
+
+	// Diffs go both ways, and a version compared with itself has no changes
+	result, err = nm.DiffTemplateVersions(templateID, 2, 1)
+	require.NoError(t, err)
+	reverse := result.(*TemplateDiff)
+	for _, change := range reverse.Changes {
+		if change.Field == "required_variables" {
+			assert.Equal(t, []string{"total"}, change.Removed)
+		}
+	}
+	result, err = nm.DiffTemplateVersions(templateID, 2, 2)
+	require.NoError(t, err)
+	assert.Empty(t, result.(*TemplateDiff).Changes)
+	assert.Empty(t, result.(*TemplateDiff).Unified)
+
+	_, err = nm.DiffTemplateVersions(templateID, 1, 3)
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+	_, err = nm.DiffTemplateVersions("missing", 1, 2)
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+}
+
+// splitLine returns line i of text
+func splitLine(text string, i int) string {
+	lines := strings.Split(text, "\n")
+	if i >= len(lines) {
+		return ""
+	}
+	return lines[i]
+}
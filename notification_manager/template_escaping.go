@@ -0,0 +1,31 @@
+package notification_manager
+
+import (
+	"html"
+	"strings"
+)
+
+// templateEscaper escapes a template variable value for the content it is inserted into
+type templateEscaper func(string) string
+
+// escapeHTML escapes values inserted into email bodies, which are sent as HTML
+func escapeHTML(value string) string {
+	return html.EscapeString(value)
+}
+
+// headerLineBreaks replaces line breaks, which would end an email header
+var headerLineBreaks = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// escapeHeader escapes values inserted into email subjects
+func escapeHeader(value string) string {
+	return headerLineBreaks.Replace(value)
+}
+
+// slackControlCharacters are the characters Slack requires to be escaped in message text,
+// they would otherwise form mentions such as <!channel> and links
+var slackControlCharacters = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// escapeSlack escapes values inserted into Slack message text
+func escapeSlack(value string) string {
+	return slackControlCharacters.Replace(value)
+}
@@ -0,0 +1,175 @@
+package notification_manager
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/external_services/gitrepo"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/gaurav2721/notification-service/notification_manager/templates"
+	"github.com/sirupsen/logrus"
+)
+
+// templateGitSync keeps the state of the template sync from a Git repository
+type templateGitSync struct {
+	mu         sync.Mutex
+	repository *gitrepo.Repository
+	status     *models.TemplateGitSyncStatus
+
+	// timer is set while the repository is synced periodically
+	timer clock.Timer
+}
+
+// SetTemplateRepository replaces the Git repository templates are synced from; nil disables the sync
+func (nm *NotificationManagerImpl) SetTemplateRepository(repository *gitrepo.Repository) {
+	nm.templateGitSync.mu.Lock()
+	defer nm.templateGitSync.mu.Unlock()
+	nm.templateGitSync.repository = repository
+	nm.templateGitSync.status = nil
+}
+
+// SyncTemplatesFromGit pulls the template repository and publishes the templates that changed,
+// even when the branch has no new commit. It returns the outcome for every file.
+func (nm *NotificationManagerImpl) SyncTemplatesFromGit(ctx context.Context) (interface{}, error) {
+	nm.templateGitSync.mu.Lock()
+	defer nm.templateGitSync.mu.Unlock()
+	if nm.templateGitSync.repository == nil {
+		return nil, ErrTemplateRepositoryNotConfigured
+	}
+	return nm.syncTemplatesFromGitLocked(ctx, true), nil
+}
+
+// GetTemplateGitSyncStatus returns the outcome of the last sync from the template repository
+func (nm *NotificationManagerImpl) GetTemplateGitSyncStatus() (interface{}, error) {
+	nm.templateGitSync.mu.Lock()
+	defer nm.templateGitSync.mu.Unlock()
+	if nm.templateGitSync.repository == nil {
+		return nil, ErrTemplateRepositoryNotConfigured
+	}
+	if nm.templateGitSync.status == nil {
+		return nm.syncTemplatesFromGitLocked(context.Background(), false), nil
+	}
+	return nm.templateGitSync.status, nil
+}
+
+// syncTemplatesFromGitLocked pulls the template repository and, when the branch has a new
+// commit or force is set, stores the templates that changed, recording the commit on every new
+// version, and activates the new versions. Invalid files are reported and logged; the templates
+// they define keep their last valid version. Callers must hold templateGitSync.mu.
+func (nm *NotificationManagerImpl) syncTemplatesFromGitLocked(ctx context.Context, force bool) *models.TemplateGitSyncStatus {
+	gitSync := nm.templateGitSync
+	repository := gitSync.repository
+	status := &models.TemplateGitSyncStatus{
+		Repository: repository.URL(),
+		Branch:     repository.Branch(),
+		Path:       repository.Path(),
+		SyncedAt:   nm.clock.Now(),
+	}
+	logger := logrus.WithFields(logrus.Fields{
+		"repository": status.Repository,
+		"branch":     status.Branch,
+		"path":       status.Path,
+	})
+
+	// Failures keep the templates of the last sync, which the status keeps reporting
+	failed := func(err error) *models.TemplateGitSyncStatus {
+		logger.WithError(err).Error("Failed to sync templates from git, keeping the loaded templates")
+		status.Error = err.Error()
+		if gitSync.status != nil {
+			status.Commit = gitSync.status.Commit
+			status.Files = gitSync.status.Files
+		}
+		gitSync.status = status
+		return status
+	}
+
+	commit, err := repository.Pull(ctx)
+	if err != nil {
+		return failed(err)
+	}
+	if !force && gitSync.status != nil && gitSync.status.Error == "" && gitSync.status.Commit == commit {
+		gitSync.status.SyncedAt = status.SyncedAt
+		return gitSync.status
+	}
+
+	files, err := templates.ReadTemplateDirectory(repository.Dir())
+	if err != nil {
+		return failed(err)
+	}
+	for _, file := range files {
+		if file.Template != nil {
+			file.Template.SourceCommit = commit
+		}
+	}
+
+	statuses := nm.templateManager.SyncTemplateFiles(files, templates.GitSyncActor)
+	for i, fileStatus := range statuses {
+		if fileStatus.Outcome != models.TemplateImportCreated && fileStatus.Outcome != models.TemplateImportNewVersion {
+			continue
+		}
+		// New versions are published right away; localized ones without content in the default
+		// locale stay drafts
+		if _, err := nm.ActivateTemplate(fileStatus.TemplateID, fileStatus.Version, templates.GitSyncActor); err != nil {
+			statuses[i].Error = "stored as a draft: " + err.Error()
+		}
+	}
+	logTemplateFileStatuses(logger.WithField("commit", commit), statuses)
+
+	status.Commit = commit
+	status.Files = statuses
+	gitSync.status = status
+	return status
+}
+
+// StartTemplateGitSync syncs the templates from the template repository and syncs them again
+// every TemplatesGitSyncInterval until StopTemplateGitSync is called. Nothing happens when no
+// template repository is configured.
+func (nm *NotificationManagerImpl) StartTemplateGitSync() {
+	gitSync := nm.templateGitSync
+	gitSync.mu.Lock()
+	defer gitSync.mu.Unlock()
+	if gitSync.repository == nil {
+		logrus.Debug("No template repository is configured, templates are not synced from git")
+		return
+	}
+	if gitSync.timer != nil {
+		return
+	}
+
+	// Templates are synced before the service takes traffic
+	nm.syncTemplatesFromGitLocked(context.Background(), false)
+
+	interval := nm.config.TemplatesGitSyncInterval
+	if interval <= 0 {
+		logrus.Info("Templates synced from git, periodic syncing is disabled")
+		return
+	}
+
+	var run func()
+	run = func() {
+		gitSync.mu.Lock()
+		defer gitSync.mu.Unlock()
+		if gitSync.timer == nil || gitSync.repository == nil {
+			return
+		}
+		nm.syncTemplatesFromGitLocked(context.Background(), false)
+		gitSync.timer = nm.clock.AfterFunc(interval, run)
+	}
+	gitSync.timer = nm.clock.AfterFunc(interval, run)
+	logrus.WithFields(logrus.Fields{
+		"repository": gitSync.repository.URL(),
+		"branch":     gitSync.repository.Branch(),
+		"interval":   interval,
+	}).Info("Template git sync started")
+}
+
+// StopTemplateGitSync stops syncing templates from the template repository
+func (nm *NotificationManagerImpl) StopTemplateGitSync() {
+	nm.templateGitSync.mu.Lock()
+	defer nm.templateGitSync.mu.Unlock()
+	if nm.templateGitSync.timer != nil {
+		nm.templateGitSync.timer.Stop()
+		nm.templateGitSync.timer = nil
+	}
+}
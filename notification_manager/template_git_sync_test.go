@@ -0,0 +1,197 @@
+package notification_manager
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/external_services/gitrepo"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/gaurav2721/notification-service/notification_manager/templates"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// templateRepository is a Git repository templates are synced from in tests
+type templateRepository struct {
+	t   *testing.T
+	dir string
+}
+
+func newTemplateRepository(t *testing.T) *templateRepository {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repository := &templateRepository{t: t, dir: t.TempDir()}
+	repository.git("init", "--quiet", "--initial-branch", "main")
+	return repository
+}
+
+// commit writes the files, commits them and returns the commit SHA
+func (r *templateRepository) commit(files map[string]string) string {
+	r.t.Helper()
+	for name, content := range files {
+		path := filepath.Join(r.dir, "templates", name)
+		require.NoError(r.t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(r.t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	r.git("add", "--all")
+	r.git("-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "--quiet", "--message", "Update templates")
+	return strings.TrimSpace(r.git("rev-parse", "HEAD"))
+}
+
+func (r *templateRepository) git(args ...string) string {
+	r.t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.dir
+	output, err := cmd.CombinedOutput()
+	require.NoError(r.t, err, string(output))
+	return string(output)
+}
+
+func newGitSyncTestManager(t *testing.T, source *templateRepository) (*NotificationManagerImpl, *clock.Fake) {
+	config := DefaultConfig()
+	config.TemplatesGitSyncInterval = time.Minute
+	nm, _, _ := newTestManager(t, 0, config)
+	fake := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	nm.SetClock(fake)
+
+	repository, err := gitrepo.New(gitrepo.Config{
+		URL:         source.dir,
+		Branch:      "main",
+		Path:        "templates",
+		CheckoutDir: filepath.Join(t.TempDir(), "checkout"),
+		Timeout:     time.Minute,
+	})
+	require.NoError(t, err)
+	nm.SetTemplateRepository(repository)
+	return nm, fake
+}
+
+const localizedTemplateYAML = `id: localized-receipt
+name: Localized receipt
+type: email
+locale: de
+content:
+  subject: "Ihre Quittung"
+  email_body: "Danke"
+localizations:
+  fr:
+    subject: "Votre reçu"
+    email_body: "Merci"
+`
+
+func TestStartTemplateGitSync(t *testing.T) {
+	source := newTemplateRepository(t)
+	first := source.commit(map[string]string{"receipt.yaml": receiptTemplateYAML})
+	nm, fake := newGitSyncTestManager(t, source)
+
+	nm.StartTemplateGitSync()
+	defer nm.StopTemplateGitSync()
+
+	// Templates are synced right away, published and traced to their commit
+	receipt, err := nm.templateManager.GetTemplateByID("receipt")
+	require.NoError(t, err)
+	assert.Equal(t, 1, receipt.Version)
+	assert.Equal(t, models.TemplateStatusActive, receipt.Status)
+	assert.Equal(t, first, receipt.SourceCommit)
+	assert.Equal(t, templates.GitSyncActor, receipt.CreatedBy)
+
+	result, err := nm.GetTemplateGitSyncStatus()
+	require.NoError(t, err)
+	status := result.(*models.TemplateGitSyncStatus)
+	assert.Equal(t, source.dir, status.Repository)
+	assert.Equal(t, "main", status.Branch)
+	assert.Equal(t, "templates", status.Path)
+	assert.Equal(t, first, status.Commit)
+	assert.Equal(t, []models.TemplateFileStatus{{File: "receipt.yaml", TemplateID: "receipt", Outcome: models.TemplateImportCreated, Version: 1}}, status.Files)
+
+	// New commits are synced on the next pull; unchanged templates keep their version and commit
+	second := source.commit(map[string]string{
+		"receipt.yaml":   receiptTemplateYAML + "description: Sent after checkout\n",
+		"alert.json":     alertTemplateJSON,
+		"localized.yaml": localizedTemplateYAML,
+	})
+	fake.Advance(time.Minute)
+
+	receipt, err = nm.templateManager.GetTemplateByID("receipt")
+	require.NoError(t, err)
+	assert.Equal(t, 2, receipt.Version)
+	assert.Equal(t, models.TemplateStatusActive, receipt.Status)
+	assert.Equal(t, second, receipt.SourceCommit)
+	version, err := nm.templateManager.GetTemplateVersion("receipt", 1)
+	require.NoError(t, err)
+	assert.Equal(t, first, version.SourceCommit)
+
+	// Localized templates without content in the default locale stay drafts
+	localized, err := nm.templateManager.GetTemplateByID("localized-receipt")
+	require.NoError(t, err)
+	assert.Equal(t, models.TemplateStatusDraft, localized.Status)
+
+	result, err = nm.GetTemplateGitSyncStatus()
+	require.NoError(t, err)
+	status = result.(*models.TemplateGitSyncStatus)
+	assert.Equal(t, second, status.Commit)
+	outcomes := make(map[string]models.TemplateFileStatus)
+	for _, file := range status.Files {
+		outcomes[file.File] = file
+	}
+	assert.Equal(t, models.TemplateImportCreated, outcomes["alert.json"].Outcome)
+	assert.Equal(t, models.TemplateImportNewVersion, outcomes["receipt.yaml"].Outcome)
+	assert.Equal(t, models.TemplateImportCreated, outcomes["localized.yaml"].Outcome)
+	assert.Contains(t, outcomes["localized.yaml"].Error, "stored as a draft")
+
+	// A commit that only touches other templates leaves the receipt alone
+	third := source.commit(map[string]string{"alert.json": strings.Replace(alertTemplateJSON, "Deployed", "Released", 1)})
+	result, err = nm.SyncTemplatesFromGit(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, third, result.(*models.TemplateGitSyncStatus).Commit)
+	receipt, err = nm.templateManager.GetTemplateByID("receipt")
+	require.NoError(t, err)
+	assert.Equal(t, 2, receipt.Version)
+	assert.Equal(t, second, receipt.SourceCommit)
+	alert, err := nm.templateManager.GetTemplateByID("deploy-alert")
+	require.NoError(t, err)
+	assert.Equal(t, third, alert.SourceCommit)
+}
+
+func TestSyncTemplatesFromGit_Failures(t *testing.T) {
+	nm, _, _ := newTestManager(t, 0, DefaultConfig())
+	_, err := nm.SyncTemplatesFromGit(context.Background())
+	assert.ErrorIs(t, err, ErrTemplateRepositoryNotConfigured)
+	_, err = nm.GetTemplateGitSyncStatus()
+	assert.ErrorIs(t, err, ErrTemplateRepositoryNotConfigured)
+
+	source := newTemplateRepository(t)
+	first := source.commit(map[string]string{"receipt.yaml": receiptTemplateYAML})
+	nm, _ = newGitSyncTestManager(t, source)
+	_, err = nm.SyncTemplatesFromGit(context.Background())
+	require.NoError(t, err)
+
+	// Invalid files keep the last valid version
+	source.commit(map[string]string{"receipt.yaml": "id: receipt\nname: Receipt\ntype: email\ncontent: {}\n"})
+	result, err := nm.SyncTemplatesFromGit(context.Background())
+	require.NoError(t, err)
+	status := result.(*models.TemplateGitSyncStatus)
+	assert.Equal(t, models.TemplateFileInvalid, status.Files[0].Outcome)
+	receipt, err := nm.templateManager.GetTemplateByID("receipt")
+	require.NoError(t, err)
+	assert.Equal(t, 1, receipt.Version)
+	assert.Equal(t, first, receipt.SourceCommit)
+
+	// A repository that cannot be pulled keeps the templates and reports the last sync
+	require.NoError(t, os.RemoveAll(source.dir))
+	result, err = nm.SyncTemplatesFromGit(context.Background())
+	require.NoError(t, err)
+	status = result.(*models.TemplateGitSyncStatus)
+	assert.Contains(t, status.Error, gitrepo.ErrCommandFailed.Error())
+	assert.NotEmpty(t, status.Commit)
+	assert.Len(t, status.Files, 1)
+	_, err = nm.templateManager.GetTemplateByID("receipt")
+	assert.NoError(t, err)
+}
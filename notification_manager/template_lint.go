@@ -0,0 +1,96 @@
+package notification_manager
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gaurav2721/notification-service/htmltext"
+	"github.com/gaurav2721/notification-service/markdown"
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// RuleGeneratedTextAlternative notes email bodies whose plain text alternative is generated
+const RuleGeneratedTextAlternative = "generated_text_alternative"
+
+// TemplateLintIssue is a problem found in a field of a template version
+type TemplateLintIssue struct {
+	htmltext.Issue
+	Field  string `json:"field"`
+	Locale string `json:"locale,omitempty"` // Set for issues in a localization
+}
+
+// TemplateLintResult lists the problems found in a template version. A version with error
+// issues does not pass.
+type TemplateLintResult struct {
+	TemplateID string              `json:"template_id"`
+	Version    int                 `json:"version"`
+	Passed     bool                `json:"passed"`
+	Issues     []TemplateLintIssue `json:"issues"`
+}
+
+// LintTemplate checks the content of a template version, in every locale, for accessibility
+// and readability problems
+func (nm *NotificationManagerImpl) LintTemplate(templateID string, version int) (interface{}, error) {
+	templateObj, err := nm.templateManager.GetTemplateByIDAndVersion(templateID, version)
+	if err != nil {
+		return nil, ErrTemplateNotFound
+	}
+
+	issues := lintContent(templateObj, templateObj.Content, "", "content.")
+	locales := make([]string, 0, len(templateObj.Localizations))
+	for locale := range templateObj.Localizations {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	for _, locale := range locales {
+		issues = append(issues, lintContent(templateObj, templateObj.Localizations[locale], locale, "localizations."+locale+".")...)
+	}
+
+	passed := true
+	for _, issue := range issues {
+		if issue.Severity == htmltext.SeverityError {
+			passed = false
+		}
+	}
+	if issues == nil {
+		issues = []TemplateLintIssue{}
+	}
+	return &TemplateLintResult{TemplateID: templateID, Version: version, Passed: passed, Issues: issues}, nil
+}
+
+// lintContent checks the fields of one content of a template; field names get prefix
+func lintContent(templateObj *models.Template, content models.TemplateContent, locale, prefix string) []TemplateLintIssue {
+	var issues []TemplateLintIssue
+	add := func(field string, found []htmltext.Issue) {
+		for _, issue := range found {
+			issues = append(issues, TemplateLintIssue{Issue: issue, Field: prefix + field, Locale: locale})
+		}
+	}
+
+	switch templateObj.Type {
+	case models.EmailNotification:
+		body := content.EmailBody
+		if templateObj.RenderMode == models.EmailRenderModeMarkdown {
+			body = markdown.ToHTML(body)
+		}
+		add("email_body", htmltext.Check(body))
+		if content.EmailTextBody == "" && templateObj.RenderMode != models.EmailRenderModeMarkdown && htmltext.IsHTML(body) {
+			add("email_text_body", []htmltext.Issue{{
+				Rule:     RuleGeneratedTextAlternative,
+				Severity: htmltext.SeverityInfo,
+				Message:  "the plain text alternative is generated from the HTML body; set email_text_body to write it yourself",
+			}})
+		}
+	case models.SlackNotification:
+		add("text", htmltext.Check(content.Text))
+	case models.GoogleChatNotification:
+		if content.Card != nil {
+			for i, section := range content.Card.Sections {
+				add(fmt.Sprintf("card.sections[%d].text", i), htmltext.Check(section.Text))
+			}
+		}
+	case models.InAppNotification:
+		add("body", htmltext.Check(content.Body))
+	}
+	return issues
+}
@@ -0,0 +1,68 @@
+package notification_manager
+
+import (
+	"testing"
+
+	"github.com/gaurav2721/notification-service/htmltext"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintTemplate(t *testing.T) {
+	nm, _, _ := newTestManager(t, 1, DefaultConfig())
+
+	created, err := nm.CreateTemplate(&models.Template{
+		Name: "Newsletter",
+		Type: models.EmailNotification,
+		Content: models.TemplateContent{
+			Subject:   "News",
+			EmailBody: `<p>Read the <a href="https://example.com/news">latest news</a>.</p><img src="https://example.com/logo.png">`,
+		},
+		Locale: "en",
+		Localizations: map[string]models.TemplateContent{
+			"fr": {Subject: "Nouvelles", EmailBody: `<p><a href="https://example.com/fr">click here</a></p>`, EmailTextBody: "Nouvelles: https://example.com/fr"},
+		},
+	})
+	require.NoError(t, err)
+	response := created.(*models.TemplateResponse)
+
+	result, err := nm.LintTemplate(response.ID, response.Version)
+	require.NoError(t, err)
+	lint := result.(*TemplateLintResult)
+	assert.False(t, lint.Passed)
+
+	rules := map[string]TemplateLintIssue{}
+	for _, issue := range lint.Issues {
+		rules[issue.Rule] = issue
+	}
+	require.Contains(t, rules, "image_missing_alt")
+	assert.Equal(t, htmltext.SeverityError, rules["image_missing_alt"].Severity)
+	assert.Equal(t, "content.email_body", rules["image_missing_alt"].Field)
+	require.Contains(t, rules, RuleGeneratedTextAlternative)
+	assert.Equal(t, "content.email_text_body", rules[RuleGeneratedTextAlternative].Field)
+	require.Contains(t, rules, "non_descriptive_link")
+	assert.Equal(t, "fr", rules["non_descriptive_link"].Locale)
+	assert.Equal(t, "localizations.fr.email_body", rules["non_descriptive_link"].Field)
+
+	_, err = nm.LintTemplate("missing", 1)
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+}
+
+func TestLintTemplate_Passes(t *testing.T) {
+	nm, _, _ := newTestManager(t, 1, DefaultConfig())
+
+	created, err := nm.CreateTemplate(&models.Template{
+		Name:    "Deploys",
+		Type:    models.SlackNotification,
+		Content: models.TemplateContent{Text: "The deploy of {{service}} finished."},
+	})
+	require.NoError(t, err)
+	response := created.(*models.TemplateResponse)
+
+	result, err := nm.LintTemplate(response.ID, response.Version)
+	require.NoError(t, err)
+	lint := result.(*TemplateLintResult)
+	assert.True(t, lint.Passed)
+	assert.Empty(t, lint.Issues)
+}
@@ -0,0 +1,166 @@
+package notification_manager
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/gaurav2721/notification-service/notification_manager/templates"
+	"github.com/sirupsen/logrus"
+)
+
+// templateReloader keeps the state of the template directory reload
+type templateReloader struct {
+	mu sync.Mutex
+	// fingerprint identifies the file names and contents of the last reload
+	fingerprint string
+	status      *models.TemplateDirectoryStatus
+
+	// timer is set while the directory is watched
+	timer clock.Timer
+}
+
+// ReloadTemplates reads the template directory and stores the templates whose files changed,
+// even when the directory looks unchanged. It returns the outcome for every file.
+func (nm *NotificationManagerImpl) ReloadTemplates() (interface{}, error) {
+	if nm.config.TemplatesDir == "" {
+		return nil, ErrTemplateDirectoryNotConfigured
+	}
+
+	nm.templateReload.mu.Lock()
+	defer nm.templateReload.mu.Unlock()
+	return nm.reloadTemplatesLocked(true), nil
+}
+
+// GetTemplateDirectoryStatus returns the outcome of the last reload of the template directory
+func (nm *NotificationManagerImpl) GetTemplateDirectoryStatus() (interface{}, error) {
+	if nm.config.TemplatesDir == "" {
+		return nil, ErrTemplateDirectoryNotConfigured
+	}
+
+	nm.templateReload.mu.Lock()
+	defer nm.templateReload.mu.Unlock()
+	if nm.templateReload.status == nil {
+		return nm.reloadTemplatesLocked(false), nil
+	}
+	return nm.templateReload.status, nil
+}
+
+// reloadTemplatesLocked reads the template directory and, when any file was added, changed or
+// removed since the last reload or force is set, stores the templates that changed. Invalid
+// files are reported and logged; the templates they define keep their last valid version.
+// Callers must hold templateReload.mu.
+func (nm *NotificationManagerImpl) reloadTemplatesLocked(force bool) *models.TemplateDirectoryStatus {
+	reloader := nm.templateReload
+	dir := nm.config.TemplatesDir
+
+	files, err := templates.ReadTemplateDirectory(dir)
+	if err != nil {
+		// The directory may be briefly missing while a deployment replaces it
+		logrus.WithError(err).WithField("directory", dir).Error("Failed to reload templates, keeping the loaded templates")
+		status := &models.TemplateDirectoryStatus{Directory: dir, ReloadedAt: nm.clock.Now(), Error: err.Error()}
+		if reloader.status != nil {
+			status.Files = reloader.status.Files
+		}
+		reloader.status = status
+		return status
+	}
+
+	fingerprint := templateDirectoryFingerprint(files)
+	if !force && reloader.status != nil && reloader.status.Error == "" && fingerprint == reloader.fingerprint {
+		return reloader.status
+	}
+
+	statuses := nm.templateManager.SyncTemplateFiles(files, templates.DirectoryActor)
+	logTemplateFileStatuses(logrus.WithField("directory", dir), statuses)
+
+	reloader.fingerprint = fingerprint
+	reloader.status = &models.TemplateDirectoryStatus{
+		Directory:  dir,
+		ReloadedAt: nm.clock.Now(),
+		Files:      statuses,
+	}
+	return reloader.status
+}
+
+// logTemplateFileStatuses logs the invalid files and the stored templates of a reload or sync
+func logTemplateFileStatuses(logger *logrus.Entry, statuses []models.TemplateFileStatus) {
+	for _, status := range statuses {
+		fields := logrus.Fields{
+			"file":        status.File,
+			"template_id": status.TemplateID,
+			"version":     status.Version,
+		}
+		switch {
+		case status.Outcome == models.TemplateFileInvalid:
+			logger.WithFields(fields).WithField("error", status.Error).Warn("Invalid template file, keeping the last valid version")
+		case status.Error != "":
+			logger.WithFields(fields).WithField("error", status.Error).Warn("Template stored with a problem")
+		case status.Outcome == models.TemplateImportCreated, status.Outcome == models.TemplateImportNewVersion:
+			logger.WithFields(fields).WithField("outcome", status.Outcome).Info("Template stored from file")
+		}
+	}
+}
+
+// templateDirectoryFingerprint identifies the names and contents of template files
+func templateDirectoryFingerprint(files []templates.TemplateFile) string {
+	var builder strings.Builder
+	for _, file := range files {
+		builder.WriteString(file.Name)
+		builder.WriteByte(':')
+		builder.WriteString(file.Checksum)
+		builder.WriteByte('\n')
+	}
+	return builder.String()
+}
+
+// StartTemplateReload loads the template directory and checks it for changed files every
+// TemplatesReloadInterval until StopTemplateReload is called. Nothing happens when no template
+// directory is configured.
+func (nm *NotificationManagerImpl) StartTemplateReload() {
+	dir := nm.config.TemplatesDir
+	if dir == "" {
+		logrus.Debug("No template directory is configured, templates are only managed through the API")
+		return
+	}
+
+	reloader := nm.templateReload
+	reloader.mu.Lock()
+	defer reloader.mu.Unlock()
+	if reloader.timer != nil {
+		return
+	}
+
+	// Templates are loaded before the service takes traffic
+	nm.reloadTemplatesLocked(false)
+
+	interval := nm.config.TemplatesReloadInterval
+	if interval <= 0 {
+		logrus.WithField("directory", dir).Info("Templates loaded, reloading is disabled")
+		return
+	}
+
+	var run func()
+	run = func() {
+		reloader.mu.Lock()
+		defer reloader.mu.Unlock()
+		if reloader.timer == nil {
+			return
+		}
+		nm.reloadTemplatesLocked(false)
+		reloader.timer = nm.clock.AfterFunc(interval, run)
+	}
+	reloader.timer = nm.clock.AfterFunc(interval, run)
+	logrus.WithFields(logrus.Fields{"directory": dir, "interval": interval}).Info("Template reload started")
+}
+
+// StopTemplateReload stops checking the template directory for changes
+func (nm *NotificationManagerImpl) StopTemplateReload() {
+	nm.templateReload.mu.Lock()
+	defer nm.templateReload.mu.Unlock()
+	if nm.templateReload.timer != nil {
+		nm.templateReload.timer.Stop()
+		nm.templateReload.timer = nil
+	}
+}
@@ -0,0 +1,174 @@
+package notification_manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/gaurav2721/notification-service/notification_manager/templates"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const receiptTemplateYAML = `id: receipt
+name: Receipt
+type: email
+content:
+  subject: "Your receipt {{order_id}}"
+  email_body: "Thanks for order {{order_id}}"
+required_variables: [order_id]
+`
+
+const alertTemplateJSON = `{
+  "id": "deploy-alert",
+  "name": "Deploy alert",
+  "type": "slack",
+  "content": {"text": "Deployed {{service}}"},
+  "required_variables": ["service"]
+}`
+
+func writeTemplateFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+}
+
+func newTemplateReloadTestManager(t *testing.T) (*NotificationManagerImpl, string, *clock.Fake) {
+	dir := t.TempDir()
+	config := DefaultConfig()
+	config.TemplatesDir = dir
+	config.TemplatesReloadInterval = 2 * time.Second
+	nm, _, _ := newTestManager(t, 0, config)
+	fake := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	nm.SetClock(fake)
+	return nm, dir, fake
+}
+
+func TestStartTemplateReload(t *testing.T) {
+	nm, dir, fake := newTemplateReloadTestManager(t)
+	writeTemplateFile(t, dir, "receipt.yaml", receiptTemplateYAML)
+	writeTemplateFile(t, dir, "deploy-alert.json", alertTemplateJSON)
+	writeTemplateFile(t, dir, "README.md", "not a template")
+
+	nm.StartTemplateReload()
+	defer nm.StopTemplateReload()
+
+	// Templates are loaded right away
+	receipt, err := nm.templateManager.GetTemplateByID("receipt")
+	require.NoError(t, err)
+	assert.Equal(t, 1, receipt.Version)
+	assert.Equal(t, models.EmailNotification, receipt.Type)
+	assert.Equal(t, models.DefaultCategory, receipt.Category)
+	assert.Equal(t, templates.DirectoryActor, receipt.CreatedBy)
+	_, err = nm.templateManager.GetTemplateByID("deploy-alert")
+	require.NoError(t, err)
+
+	result, err := nm.GetTemplateDirectoryStatus()
+	require.NoError(t, err)
+	status := result.(*models.TemplateDirectoryStatus)
+	assert.Equal(t, dir, status.Directory)
+	require.Len(t, status.Files, 2)
+	assert.Equal(t, models.TemplateFileStatus{File: "deploy-alert.json", TemplateID: "deploy-alert", Outcome: models.TemplateImportCreated, Version: 1}, status.Files[0])
+	assert.Equal(t, models.TemplateFileStatus{File: "receipt.yaml", TemplateID: "receipt", Outcome: models.TemplateImportCreated, Version: 1}, status.Files[1])
+
+	// Changed files are picked up by the next check and stored as a new version
+	writeTemplateFile(t, dir, "receipt.yaml", receiptTemplateYAML+"description: Sent after checkout\n")
+	fake.Advance(2 * time.Second)
+
+	receipt, err = nm.templateManager.GetTemplateByID("receipt")
+	require.NoError(t, err)
+	assert.Equal(t, 2, receipt.Version)
+	assert.Equal(t, "Sent after checkout", receipt.Description)
+	audit, err := nm.templateManager.GetTemplateAudit("receipt")
+	require.NoError(t, err)
+	require.Len(t, audit, 2)
+	assert.Equal(t, models.TemplateActionReloaded, audit[1].Action)
+	assert.Equal(t, "description", audit[1].Changes[0].Field)
+
+	result, err = nm.GetTemplateDirectoryStatus()
+	require.NoError(t, err)
+	status = result.(*models.TemplateDirectoryStatus)
+	assert.Equal(t, models.TemplateImportUnchanged, status.Files[0].Outcome)
+	assert.Equal(t, models.TemplateImportNewVersion, status.Files[1].Outcome)
+	assert.Equal(t, 2, status.Files[1].Version)
+}
+
+func TestStartTemplateReload_InvalidFilesKeepLastVersion(t *testing.T) {
+	nm, dir, fake := newTemplateReloadTestManager(t)
+	writeTemplateFile(t, dir, "receipt.yaml", receiptTemplateYAML)
+	nm.StartTemplateReload()
+	defer nm.StopTemplateReload()
+
+	// A broken edit, a misspelled field, a type change, a predefined template and a repeated id
+	// are reported
+	writeTemplateFile(t, dir, "receipt.yaml", "id: receipt\nname: [unclosed\n")
+	writeTemplateFile(t, dir, "typo.json", `{"id": "typo", "name": "Typo", "type": "slack", "content": {"txt": "Hi"}}`)
+	writeTemplateFile(t, dir, "type-change.json", `{"id": "550e8400-e29b-41d4-a716-446655440000", "name": "Welcome", "type": "slack", "content": {"text": "Hi"}}`)
+	writeTemplateFile(t, dir, "incident.json", `{"id": "550e8400-e29b-41d4-a716-446655440009", "name": "Incident", "type": "google_chat", "content": {"text": "Hi"}}`)
+	writeTemplateFile(t, dir, "zz-duplicate.json", alertTemplateJSON)
+	writeTemplateFile(t, dir, "deploy-alert.json", alertTemplateJSON)
+	fake.Advance(2 * time.Second)
+
+	receipt, err := nm.templateManager.GetTemplateByID("receipt")
+	require.NoError(t, err)
+	assert.Equal(t, 1, receipt.Version)
+	assert.Equal(t, "Thanks for order {{order_id}}", receipt.Content.EmailBody)
+	welcome, err := nm.templateManager.GetTemplateByID("550e8400-e29b-41d4-a716-446655440000")
+	require.NoError(t, err)
+	assert.Equal(t, models.EmailNotification, welcome.Type)
+	incident, err := nm.templateManager.GetTemplateByID("550e8400-e29b-41d4-a716-446655440009")
+	require.NoError(t, err)
+	assert.Equal(t, 1, incident.Version)
+	_, err = nm.templateManager.GetTemplateByID("typo")
+	assert.ErrorIs(t, err, models.ErrTemplateNotFound)
+
+	result, err := nm.GetTemplateDirectoryStatus()
+	require.NoError(t, err)
+	outcomes := make(map[string]models.TemplateFileStatus)
+	for _, file := range result.(*models.TemplateDirectoryStatus).Files {
+		outcomes[file.File] = file
+	}
+	assert.Equal(t, models.TemplateImportCreated, outcomes["deploy-alert.json"].Outcome)
+	for _, file := range []string{"receipt.yaml", "typo.json", "type-change.json", "incident.json", "zz-duplicate.json"} {
+		assert.Equal(t, models.TemplateFileInvalid, outcomes[file].Outcome, file)
+		assert.NotEmpty(t, outcomes[file].Error, file)
+	}
+	assert.Contains(t, outcomes["typo.json"].Error, `unknown field "txt"`)
+	assert.Contains(t, outcomes["type-change.json"].Error, models.ErrTemplateTypeChanged.Error())
+	assert.Equal(t, models.ErrPredefinedTemplate.Error(), outcomes["incident.json"].Error)
+	assert.Contains(t, outcomes["zz-duplicate.json"].Error, "already defined in deploy-alert.json")
+
+	// Fixing the file stores it
+	writeTemplateFile(t, dir, "receipt.yaml", receiptTemplateYAML+"category: billing\n")
+	fake.Advance(2 * time.Second)
+	receipt, err = nm.templateManager.GetTemplateByID("receipt")
+	require.NoError(t, err)
+	assert.Equal(t, 2, receipt.Version)
+	assert.Equal(t, "billing", receipt.Category)
+}
+
+func TestReloadTemplates(t *testing.T) {
+	nm, _, _ := newTestManager(t, 0, DefaultConfig())
+	_, err := nm.ReloadTemplates()
+	assert.ErrorIs(t, err, ErrTemplateDirectoryNotConfigured)
+	_, err = nm.GetTemplateDirectoryStatus()
+	assert.ErrorIs(t, err, ErrTemplateDirectoryNotConfigured)
+
+	nm, dir, _ := newTemplateReloadTestManager(t)
+	writeTemplateFile(t, dir, "receipt.yml", receiptTemplateYAML)
+	result, err := nm.ReloadTemplates()
+	require.NoError(t, err)
+	assert.Equal(t, models.TemplateImportCreated, result.(*models.TemplateDirectoryStatus).Files[0].Outcome)
+
+	// A directory that cannot be read keeps the loaded templates
+	require.NoError(t, os.RemoveAll(dir))
+	result, err = nm.ReloadTemplates()
+	require.NoError(t, err)
+	status := result.(*models.TemplateDirectoryStatus)
+	assert.NotEmpty(t, status.Error)
+	assert.Len(t, status.Files, 1)
+	_, err = nm.templateManager.GetTemplateByID("receipt")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,202 @@
+package notification_manager
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// templateVersionKey identifies a single version of a template
+type templateVersionKey struct {
+	ID      string
+	Version int
+}
+
+// templateUsage counts how often a template version has been used
+type templateUsage struct {
+	Rendered   int
+	Sent       int
+	LastUsedAt time.Time
+}
+
+// templateUsageTracker keeps usage counters per template version in memory
+type templateUsageTracker struct {
+	mu    sync.RWMutex
+	usage map[templateVersionKey]*templateUsage
+}
+
+// newTemplateUsageTracker creates an empty usage tracker
+func newTemplateUsageTracker() *templateUsageTracker {
+	return &templateUsageTracker{
+		usage: make(map[templateVersionKey]*templateUsage),
+	}
+}
+
+// entry returns the usage of a template version, creating it if needed. Callers must hold mu.
+func (t *templateUsageTracker) entry(templateID string, version int) *templateUsage {
+	key := templateVersionKey{ID: templateID, Version: version}
+	usage, exists := t.usage[key]
+	if !exists {
+		usage = &templateUsage{}
+		t.usage[key] = usage
+	}
+	return usage
+}
+
+// RecordRender counts a render of a template version, either a preview or for a send
+func (t *templateUsageTracker) RecordRender(templateID string, version int, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	usage := t.entry(templateID, version)
+	usage.Rendered++
+	if at.After(usage.LastUsedAt) {
+		usage.LastUsedAt = at
+	}
+}
+
+// RecordSend counts an accepted notification that uses a template version
+func (t *templateUsageTracker) RecordSend(templateID string, version int, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	usage := t.entry(templateID, version)
+	usage.Sent++
+	if at.After(usage.LastUsedAt) {
+		usage.LastUsedAt = at
+	}
+}
+
+// Versions returns a copy of the usage of every tracked version of a template
+func (t *templateUsageTracker) Versions(templateID string) map[int]templateUsage {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	versions := make(map[int]templateUsage)
+	for key, usage := range t.usage {
+		if key.ID == templateID {
+			versions[key.Version] = *usage
+		}
+	}
+	return versions
+}
+
+// templateVersionStats is the usage of a single template version
+type templateVersionStats struct {
+	Version             int        `json:"version"`
+	Rendered            int        `json:"rendered"`
+	Sent                int        `json:"sent"`
+	FailedNotifications int        `json:"failed_notifications"`
+	DeliveriesSent      int        `json:"deliveries_sent"`
+	DeliveriesFailed    int        `json:"deliveries_failed"`
+	FailureRate         float64    `json:"failure_rate"`
+	LastUsedAt          *time.Time `json:"last_used_at,omitempty"`
+}
+
+// add adds the counters of other to s, keeping the latest last-used time
+func (s *templateVersionStats) add(other templateVersionStats) {
+	s.Rendered += other.Rendered
+	s.Sent += other.Sent
+	s.FailedNotifications += other.FailedNotifications
+	s.DeliveriesSent += other.DeliveriesSent
+	s.DeliveriesFailed += other.DeliveriesFailed
+	if other.LastUsedAt != nil && (s.LastUsedAt == nil || other.LastUsedAt.After(*s.LastUsedAt)) {
+		lastUsed := *other.LastUsedAt
+		s.LastUsedAt = &lastUsed
+	}
+}
+
+// computeFailureRate sets the share of failed deliveries
+func (s *templateVersionStats) computeFailureRate() {
+	if deliveries := s.DeliveriesSent + s.DeliveriesFailed; deliveries > 0 {
+		s.FailureRate = float64(s.DeliveriesFailed) / float64(deliveries)
+	}
+}
+
+// GetTemplateStats returns how often each version of a template has been rendered and sent,
+// when it was last used and the failure rate of its deliveries. Templates that have never
+// been used report zero counts, so owners can tell unused templates apart.
+func (nm *NotificationManagerImpl) GetTemplateStats(templateID string) (interface{}, error) {
+	template, err := nm.templateManager.GetTemplateByID(templateID)
+	if err != nil {
+		return nil, ErrTemplateNotFound
+	}
+
+	versions := make(map[int]*templateVersionStats)
+	versionStats := func(version int) *templateVersionStats {
+		stats, exists := versions[version]
+		if !exists {
+			stats = &templateVersionStats{Version: version}
+			versions[version] = stats
+		}
+		return stats
+	}
+
+	// The current version is always listed, even when it has never been used
+	versionStats(template.Version)
+
+	for version, usage := range nm.templateUsage.Versions(templateID) {
+		stats := versionStats(version)
+		stats.Rendered = usage.Rendered
+		stats.Sent = usage.Sent
+		if !usage.LastUsedAt.IsZero() {
+			lastUsed := usage.LastUsedAt
+			stats.LastUsedAt = &lastUsed
+		}
+	}
+
+	for _, record := range nm.storage.FindNotifications(NotificationFilter{}) {
+		if record.Template == nil || record.Template.ID != templateID {
+			continue
+		}
+		stats := versionStats(record.Template.Version)
+		if record.Status == StatusFailed {
+			stats.FailedNotifications++
+		}
+		if nm.deliveryService != nil {
+			deliveries := nm.deliveryService.GetStats(record.ID)
+			stats.DeliveriesSent += deliveries.Sent
+			stats.DeliveriesFailed += deliveries.Failed
+		}
+	}
+
+	total := templateVersionStats{}
+	byVersion := make([]templateVersionStats, 0, len(versions))
+	for _, stats := range versions {
+		stats.computeFailureRate()
+		total.add(*stats)
+		byVersion = append(byVersion, *stats)
+	}
+	total.computeFailureRate()
+	sort.Slice(byVersion, func(i, j int) bool {
+		return byVersion[i].Version < byVersion[j].Version
+	})
+
+	return &struct {
+		TemplateID          string                 `json:"template_id"`
+		Name                string                 `json:"name"`
+		Type                string                 `json:"type"`
+		Rendered            int                    `json:"rendered"`
+		Sent                int                    `json:"sent"`
+		FailedNotifications int                    `json:"failed_notifications"`
+		DeliveriesSent      int                    `json:"deliveries_sent"`
+		DeliveriesFailed    int                    `json:"deliveries_failed"`
+		FailureRate         float64                `json:"failure_rate"`
+		LastUsedAt          *time.Time             `json:"last_used_at,omitempty"`
+		Versions            []templateVersionStats `json:"versions"`
+		GeneratedAt         time.Time              `json:"generated_at"`
+	}{
+		TemplateID:          template.ID,
+		Name:                template.Name,
+		Type:                string(template.Type),
+		Rendered:            total.Rendered,
+		Sent:                total.Sent,
+		FailedNotifications: total.FailedNotifications,
+		DeliveriesSent:      total.DeliveriesSent,
+		DeliveriesFailed:    total.DeliveriesFailed,
+		FailureRate:         total.FailureRate,
+		LastUsedAt:          total.LastUsedAt,
+		Versions:            byVersion,
+		GeneratedAt:         nm.clock.Now(),
+	}, nil
+}
@@ -0,0 +1,199 @@
+package templates
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// ExportTemplates returns every template with all its versions, sorted by ID
+func (tm *TemplateManagerImpl) ExportTemplates() *models.TemplateBundle {
+	tm.templateMutex.RLock()
+	defer tm.templateMutex.RUnlock()
+
+	bundle := &models.TemplateBundle{
+		Format:     models.TemplateBundleFormat,
+		ExportedAt: tm.clock.Now(),
+		Templates:  make([]models.TemplateBundleEntry, 0, len(tm.versions)),
+	}
+	for id, versions := range tm.versions {
+		entry := models.TemplateBundleEntry{ID: id, Versions: make([]models.Template, len(versions))}
+		for i, version := range versions {
+			entry.Versions[i] = *version
+		}
+		bundle.Templates = append(bundle.Templates, entry)
+	}
+
+	sort.Slice(bundle.Templates, func(i, j int) bool {
+		return bundle.Templates[i].ID < bundle.Templates[j].ID
+	})
+	return bundle
+}
+
+// ImportTemplates stores the templates of a bundle. Templates that do not exist yet are created
+// with their versions numbered as exported, so notifications that reference a version keep
+// working after a promotion. Templates that exist are resolved according to mode, except for
+// predefined templates, which are reported as conflicts when the bundle would change them. The
+// bundle is validated as a whole first; an invalid bundle changes nothing.
+func (tm *TemplateManagerImpl) ImportTemplates(bundle *models.TemplateBundle, mode models.TemplateConflictMode, actor string) ([]models.TemplateImportResult, error) {
+	if _, err := models.ParseTemplateConflictMode(string(mode)); err != nil {
+		return nil, err
+	}
+
+	tm.templateMutex.Lock()
+	defer tm.templateMutex.Unlock()
+
+	if err := tm.validateBundleLocked(bundle, mode); err != nil {
+		return nil, err
+	}
+
+	now := tm.clock.Now()
+	results := make([]models.TemplateImportResult, 0, len(bundle.Templates))
+	for _, entry := range bundle.Templates {
+		latest, exists := tm.templates[entry.ID]
+
+		var outcome, errorMsg string
+		switch {
+		case !exists:
+			tm.importVersionsLocked(entry, actor, now)
+			outcome = models.TemplateImportCreated
+		case mode != models.TemplateConflictSkip && isPredefinedTemplateID(entry.ID):
+			// Predefined templates are shared by every tenant and restored on restart
+			outcome = models.TemplateImportUnchanged
+			if len(templateChanges(latest, bundleVersion(latest, entry, actor, now))) > 0 {
+				outcome = models.TemplateImportConflict
+				errorMsg = models.ErrPredefinedTemplate.Error()
+			}
+		case mode == models.TemplateConflictOverwrite:
+			delete(tm.templates, entry.ID)
+			delete(tm.versions, entry.ID)
+			tm.importVersionsLocked(entry, actor, now)
+			outcome = models.TemplateImportOverwritten
+		case mode == models.TemplateConflictNewVersion:
+			outcome = tm.importNewVersionLocked(latest, entry, actor, now)
+		default:
+			outcome = models.TemplateImportSkipped
+		}
+
+		template := tm.templates[entry.ID]
+		results = append(results, models.TemplateImportResult{
+			ID:      template.ID,
+			Name:    template.Name,
+			Outcome: outcome,
+			Version: template.Version,
+			Error:   errorMsg,
+		})
+	}
+	return results, nil
+}
+
+// validateBundleLocked checks that every template of a bundle can be imported.
+// Callers must hold templateMutex.
+func (tm *TemplateManagerImpl) validateBundleLocked(bundle *models.TemplateBundle, mode models.TemplateConflictMode) error {
+	if bundle == nil || bundle.Format != models.TemplateBundleFormat {
+		return fmt.Errorf("%w: unsupported format, expected %d", models.ErrInvalidTemplateBundle, models.TemplateBundleFormat)
+	}
+
+	seen := make(map[string]bool, len(bundle.Templates))
+	for _, entry := range bundle.Templates {
+		if entry.ID == "" {
+			return fmt.Errorf("%w: a template has no id", models.ErrInvalidTemplateBundle)
+		}
+		if seen[entry.ID] {
+			return fmt.Errorf("%w: template %s is listed twice", models.ErrInvalidTemplateBundle, entry.ID)
+		}
+		seen[entry.ID] = true
+
+		if len(entry.Versions) == 0 {
+			return fmt.Errorf("%w: template %s has no versions", models.ErrInvalidTemplateBundle, entry.ID)
+		}
+		for i, version := range entry.Versions {
+			if version.ID != "" && version.ID != entry.ID {
+				return fmt.Errorf("%w: template %s contains a version of template %s", models.ErrInvalidTemplateBundle, entry.ID, version.ID)
+			}
+			if version.Version <= 0 || (i > 0 && version.Version <= entry.Versions[i-1].Version) {
+				return fmt.Errorf("%w: the versions of template %s must be positive and ascending", models.ErrInvalidTemplateBundle, entry.ID)
+			}
+			if version.Type != entry.Versions[0].Type {
+				return fmt.Errorf("%w: template %s: %v", models.ErrInvalidTemplateBundle, entry.ID, models.ErrTemplateTypeChanged)
+			}
+			if err := version.Content.ValidateTemplateContent(version.Type); err != nil {
+				return fmt.Errorf("%w: template %s version %d: %v", models.ErrInvalidTemplateBundle, entry.ID, version.Version, err)
+			}
+			if err := version.NormalizeLocales(); err != nil {
+				return fmt.Errorf("%w: template %s version %d: %v", models.ErrInvalidTemplateBundle, entry.ID, version.Version, err)
+			}
+		}
+
+		latest, exists := tm.templates[entry.ID]
+		if exists && mode == models.TemplateConflictNewVersion && latest.Type != entry.Versions[0].Type {
+			return fmt.Errorf("%w: template %s", models.ErrTemplateTypeChanged, entry.ID)
+		}
+	}
+	return nil
+}
+
+// importVersionsLocked stores the versions of a bundle entry as they were exported.
+// Callers must hold templateMutex.
+func (tm *TemplateManagerImpl) importVersionsLocked(entry models.TemplateBundleEntry, actor string, now time.Time) {
+	for _, version := range entry.Versions {
+		template := version
+		template.ID = entry.ID
+		// The locales were validated with the bundle
+		template.NormalizeLocales()
+		if template.Category == "" {
+			template.Category = models.DefaultCategory
+		}
+		if template.Status == "" {
+			template.Status = "created"
+		}
+		template.UpdatedAt = now
+		template.UpdatedBy = actor
+		tm.storeVersionLocked(&template, models.TemplateActionImported, nil)
+	}
+}
+
+// importNewVersionLocked stores the latest version of a bundle entry as a new version of an
+// existing template, unless it does not differ from the current one. Callers must hold templateMutex.
+func (tm *TemplateManagerImpl) importNewVersionLocked(latest *models.Template, entry models.TemplateBundleEntry, actor string, now time.Time) string {
+	template := bundleVersion(latest, entry, actor, now)
+	changes := templateChanges(latest, template)
+	if len(changes) == 0 {
+		return models.TemplateImportUnchanged
+	}
+	tm.storeVersionLocked(template, models.TemplateActionImported, changes)
+	return models.TemplateImportNewVersion
+}
+
+// bundleVersion returns the latest version of a bundle entry as the next version of latest
+func bundleVersion(latest *models.Template, entry models.TemplateBundleEntry, actor string, now time.Time) *models.Template {
+	imported := entry.Versions[len(entry.Versions)-1]
+	if imported.Category == "" {
+		imported.Category = latest.Category
+	}
+
+	template := &models.Template{
+		ID:                latest.ID,
+		Name:              imported.Name,
+		Type:              latest.Type,
+		Version:           latest.Version + 1,
+		Content:           imported.Content,
+		RequiredVariables: imported.RequiredVariables,
+		Description:       imported.Description,
+		RenderMode:        imported.RenderMode,
+		Category:          imported.Category,
+		CreatedAt:         latest.CreatedAt,
+		CreatedBy:         latest.CreatedBy,
+		UpdatedAt:         now,
+		UpdatedBy:         actor,
+		Locale:            imported.Locale,
+		Localizations:     imported.Localizations,
+		ColorScheme:       imported.ColorScheme,
+	}
+	// The locales were validated with the bundle
+	template.NormalizeLocales()
+	template.Status = initialStatus(template, latest.Status)
+	return template
+}
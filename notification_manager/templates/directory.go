@@ -0,0 +1,217 @@
+package templates
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Authors recorded for template versions loaded from files
+const (
+	// DirectoryActor is the author of versions loaded from the template directory
+	DirectoryActor = "template-directory"
+	// GitSyncActor is the author of versions synced from a Git repository
+	GitSyncActor = "git-sync"
+)
+
+// TemplateFile is a template read from a file of the template directory. Err is set when the
+// file does not hold a valid template.
+type TemplateFile struct {
+	// Name is the name of the file within the directory
+	Name     string
+	Checksum string
+	Template *models.Template
+	Err      error
+}
+
+// ReadTemplateDirectory reads the template files of dir, sorted by name. Files ending in .yaml
+// or .yml are parsed as YAML and files ending in .json as JSON; each holds one template with
+// the field names of the JSON API. Other files and subdirectories are ignored.
+func ReadTemplateDirectory(dir string) ([]TemplateFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template directory: %w", err)
+	}
+
+	files := make([]TemplateFile, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !isTemplateFile(entry.Name()) {
+			continue
+		}
+
+		file := TemplateFile{Name: entry.Name()}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			file.Err = fmt.Errorf("%w: %v", models.ErrInvalidTemplateFile, err)
+			files = append(files, file)
+			continue
+		}
+
+		sum := sha256.Sum256(data)
+		file.Checksum = hex.EncodeToString(sum[:])
+		file.Template, file.Err = ParseTemplateFile(data, filepath.Ext(entry.Name()))
+		files = append(files, file)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+	return files, nil
+}
+
+// isTemplateFile reports whether a file name has the extension of a template file; hidden
+// files, such as the temporary files of editors, are skipped
+func isTemplateFile(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// ParseTemplateFile parses a template in the format given by a file extension and validates
+// it. The template needs an id, which identifies it across reloads. Versions, statuses,
+// timestamps and source commits are set when the template is stored, so they are ignored.
+func ParseTemplateFile(data []byte, ext string) (*models.Template, error) {
+	switch strings.ToLower(ext) {
+	case ".yaml", ".yml":
+		// YAML is converted to JSON so both formats share the JSON field names
+		var document interface{}
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("%w: %v", models.ErrInvalidTemplateFile, err)
+		}
+		converted, err := json.Marshal(document)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", models.ErrInvalidTemplateFile, err)
+		}
+		data = converted
+	}
+
+	// Unknown fields are rejected, as they are usually misspelled ones
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var template models.Template
+	if err := decoder.Decode(&template); err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidTemplateFile, err)
+	}
+
+	if strings.TrimSpace(template.ID) == "" {
+		return nil, fmt.Errorf("%w: id is required", models.ErrInvalidTemplateFile)
+	}
+	if strings.TrimSpace(template.Name) == "" {
+		return nil, fmt.Errorf("%w: name is required", models.ErrInvalidTemplateFile)
+	}
+	if err := template.Content.ValidateTemplateContent(template.Type); err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidTemplateFile, err)
+	}
+	if err := template.NormalizeLocales(); err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidTemplateFile, err)
+	}
+
+	template.Version = 0
+	template.Status = ""
+	template.CreatedAt = time.Time{}
+	template.UpdatedAt = time.Time{}
+	template.SourceCommit = ""
+	return &template, nil
+}
+
+// SyncTemplateFiles stores the templates read from the template directory on behalf of actor.
+// Templates that do not exist yet are created, templates whose file differs from their latest
+// version get a new version and the others are left alone. All changes are made under one lock,
+// so readers see the templates as they were before or after the reload, never in between.
+// Invalid files, files repeating the id of an earlier file, files changing the type of a
+// template and files of predefined templates are reported and leave their template as it is.
+func (tm *TemplateManagerImpl) SyncTemplateFiles(files []TemplateFile, actor string) []models.TemplateFileStatus {
+	tm.templateMutex.Lock()
+	defer tm.templateMutex.Unlock()
+
+	now := tm.clock.Now()
+	statuses := make([]models.TemplateFileStatus, 0, len(files))
+	definedIn := make(map[string]string, len(files))
+	for _, file := range files {
+		status := models.TemplateFileStatus{File: file.Name}
+		if file.Err != nil {
+			status.Outcome = models.TemplateFileInvalid
+			status.Error = file.Err.Error()
+			statuses = append(statuses, status)
+			continue
+		}
+
+		loaded := file.Template
+		status.TemplateID = loaded.ID
+		if first, defined := definedIn[loaded.ID]; defined {
+			status.Outcome = models.TemplateFileInvalid
+			status.Error = fmt.Sprintf("%v: template %s is already defined in %s", models.ErrInvalidTemplateFile, loaded.ID, first)
+			statuses = append(statuses, status)
+			continue
+		}
+		definedIn[loaded.ID] = file.Name
+
+		latest, exists := tm.templates[loaded.ID]
+		switch {
+		case !exists:
+			template := *loaded
+			template.Version = 1
+			template.Category = categoryOrDefault(template.Category, models.DefaultCategory)
+			template.Status = initialStatus(&template, models.TemplateStatusCreated)
+			template.CreatedAt = now
+			template.CreatedBy = actor
+			template.UpdatedAt = now
+			template.UpdatedBy = actor
+			tm.storeVersionLocked(&template, models.TemplateActionReloaded, nil)
+			status.Outcome = models.TemplateImportCreated
+		case latest.Type != loaded.Type:
+			status.Outcome = models.TemplateFileInvalid
+			status.Error = models.ErrTemplateTypeChanged.Error()
+		case isPredefinedTemplateID(loaded.ID):
+			// Predefined templates are shared by every tenant and restored on restart
+			status.Outcome = models.TemplateFileInvalid
+			status.Error = models.ErrPredefinedTemplate.Error()
+		default:
+			template := *loaded
+			template.Version = latest.Version + 1
+			template.Category = categoryOrDefault(template.Category, latest.Category)
+			template.CreatedAt = latest.CreatedAt
+			template.CreatedBy = latest.CreatedBy
+			template.UpdatedAt = now
+			template.UpdatedBy = actor
+			template.Status = initialStatus(&template, latest.Status)
+
+			changes := templateChanges(latest, &template)
+			if len(changes) == 0 {
+				status.Outcome = models.TemplateImportUnchanged
+				break
+			}
+			tm.storeVersionLocked(&template, models.TemplateActionReloaded, changes)
+			status.Outcome = models.TemplateImportNewVersion
+		}
+
+		if template, exists := tm.templates[loaded.ID]; exists {
+			status.Version = template.Version
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// categoryOrDefault returns category, or fallback when it is empty
+func categoryOrDefault(category, fallback string) string {
+	if category == "" {
+		return fallback
+	}
+	return category
+}
@@ -1,20 +1,50 @@
 package templates
 
 import (
+	"github.com/gaurav2721/notification-service/clock"
 	"github.com/gaurav2721/notification-service/models"
 )
 
 // TemplateManager defines the contract for template management operations
 type TemplateManager interface {
+	// SetClock replaces the clock used to timestamp template versions and audit entries
+	SetClock(c clock.Clock)
+
 	// CreateTemplate creates a new notification template
 	CreateTemplate(template *models.Template) (*models.TemplateResponse, error)
 
+	// UpdateTemplate stores a new version of a template on behalf of actor
+	UpdateTemplate(templateID string, update *models.Template, actor string) (*models.TemplateResponse, error)
+
+	// ActivateTemplate activates a version of a template on behalf of actor; localized versions
+	// must have content in defaultLocale
+	ActivateTemplate(templateID string, version int, defaultLocale string, actor string) (*models.TemplateResponse, error)
+
+	// GetTemplateAudit returns the change log of a template, oldest entry first
+	GetTemplateAudit(templateID string) ([]models.TemplateAuditEntry, error)
+
 	// GetTemplateVersion retrieves a specific version of a notification template
 	GetTemplateVersion(templateID string, version int) (*models.TemplateVersion, error)
 
 	// GetPredefinedTemplates returns all predefined templates
 	GetPredefinedTemplates() []*models.Template
 
+	// ListTemplates returns all predefined and custom templates sorted by name
+	ListTemplates() []*models.Template
+
+	// GetTemplateByID returns the latest version of a template
+	GetTemplateByID(templateID string) (*models.Template, error)
+
 	// GetTemplateByIDAndVersion returns a specific version of a template
 	GetTemplateByIDAndVersion(templateID string, version int) (*models.Template, error)
+
+	// ExportTemplates returns every template with all its versions
+	ExportTemplates() *models.TemplateBundle
+
+	// ImportTemplates stores the templates of a bundle on behalf of actor, resolving conflicts
+	// with existing templates according to mode
+	ImportTemplates(bundle *models.TemplateBundle, mode models.TemplateConflictMode, actor string) ([]models.TemplateImportResult, error)
+
+	// SyncTemplateFiles stores the templates read from the template directory on behalf of actor
+	SyncTemplateFiles(files []TemplateFile, actor string) []models.TemplateFileStatus
 }
@@ -1,18 +1,28 @@
 package templates
 
 import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
-	"time"
 
+	"github.com/gaurav2721/notification-service/clock"
 	"github.com/gaurav2721/notification-service/models"
 	"github.com/google/uuid"
 )
 
+// SystemActor is recorded as the author of predefined templates
+const SystemActor = "system"
+
 // TemplateManagerImpl handles all template-related operations
 type TemplateManagerImpl struct {
 	templates     map[string]*models.Template
+	versions      map[string][]*models.Template
+	audit         map[string][]models.TemplateAuditEntry
 	templateMutex sync.RWMutex
 	initialized   bool
+	clock         clock.Clock
 }
 
 // Ensure TemplateManagerImpl implements TemplateManager
@@ -22,8 +32,11 @@ var _ TemplateManager = (*TemplateManagerImpl)(nil)
 func NewTemplateManager() *TemplateManagerImpl {
 	tm := &TemplateManagerImpl{
 		templates:     make(map[string]*models.Template),
+		versions:      make(map[string][]*models.Template),
+		audit:         make(map[string][]models.TemplateAuditEntry),
 		templateMutex: sync.RWMutex{},
 		initialized:   false,
+		clock:         clock.Real(),
 	}
 
 	// Load predefined templates on startup
@@ -32,6 +45,13 @@ func NewTemplateManager() *TemplateManagerImpl {
 	return tm
 }
 
+// SetClock replaces the clock used to timestamp template versions and audit entries
+func (tm *TemplateManagerImpl) SetClock(c clock.Clock) {
+	tm.templateMutex.Lock()
+	defer tm.templateMutex.Unlock()
+	tm.clock = c
+}
+
 // loadPredefinedTemplates loads all predefined templates into the manager
 func (tm *TemplateManagerImpl) loadPredefinedTemplates() {
 	tm.templateMutex.Lock()
@@ -40,7 +60,10 @@ func (tm *TemplateManagerImpl) loadPredefinedTemplates() {
 	predefinedTemplates := models.PredefinedTemplates()
 
 	for _, template := range predefinedTemplates {
-		tm.templates[template.ID] = template
+		template.CreatedBy = SystemActor
+		template.UpdatedBy = SystemActor
+		template.UpdatedAt = template.CreatedAt
+		tm.storeVersionLocked(template, models.TemplateActionCreated, nil)
 	}
 
 	tm.initialized = true
@@ -55,21 +78,185 @@ func (tm *TemplateManagerImpl) CreateTemplate(template *models.Template) (*model
 	if err := template.Content.ValidateTemplateContent(template.Type); err != nil {
 		return nil, err
 	}
+	if err := template.NormalizeLocales(); err != nil {
+		return nil, err
+	}
 
 	// Generate ID if not provided
 	if template.ID == "" {
 		template.ID = uuid.New().String()
 	}
 
+	if template.Category == "" {
+		template.Category = models.DefaultCategory
+	}
+
 	// Set version and status
 	template.Version = 1
-	template.Status = "created"
-	template.CreatedAt = time.Now()
+	template.Status = initialStatus(template, models.TemplateStatusCreated)
+	template.CreatedAt = tm.clock.Now()
+	template.UpdatedAt = template.CreatedAt
+	template.UpdatedBy = template.CreatedBy
 
 	// Store template
+	tm.storeVersionLocked(template, models.TemplateActionCreated, nil)
+
+	return templateResponse(template), nil
+}
+
+// UpdateTemplate stores the content of update as a new version of a template.
+// Earlier versions stay available and the differences are recorded in the audit log.
+func (tm *TemplateManagerImpl) UpdateTemplate(templateID string, update *models.Template, actor string) (*models.TemplateResponse, error) {
+	tm.templateMutex.Lock()
+	defer tm.templateMutex.Unlock()
+
+	latest, exists := tm.templates[templateID]
+	if !exists {
+		return nil, models.ErrTemplateNotFound
+	}
+	// Predefined templates are shared by every tenant and restored on restart
+	if isPredefinedTemplateID(templateID) {
+		return nil, models.ErrPredefinedTemplate
+	}
+
+	if update.Type != "" && update.Type != latest.Type {
+		return nil, models.ErrTemplateTypeChanged
+	}
+	if err := update.Content.ValidateTemplateContent(latest.Type); err != nil {
+		return nil, err
+	}
+
+	// Templates keep their category unless the update moves them to another one
+	category := update.Category
+	if category == "" {
+		category = latest.Category
+	}
+
+	template := &models.Template{
+		ID:                latest.ID,
+		Name:              update.Name,
+		Type:              latest.Type,
+		Version:           latest.Version + 1,
+		Content:           update.Content,
+		RequiredVariables: update.RequiredVariables,
+		Description:       update.Description,
+		RenderMode:        update.RenderMode,
+		Category:          category,
+		CreatedAt:         latest.CreatedAt,
+		CreatedBy:         latest.CreatedBy,
+		UpdatedAt:         tm.clock.Now(),
+		UpdatedBy:         actor,
+		Locale:            update.Locale,
+		Localizations:     update.Localizations,
+		ColorScheme:       update.ColorScheme,
+	}
+	if err := template.NormalizeLocales(); err != nil {
+		return nil, err
+	}
+	template.Status = initialStatus(template, latest.Status)
+
+	tm.storeVersionLocked(template, models.TemplateActionUpdated, templateChanges(latest, template))
+
+	return templateResponse(template), nil
+}
+
+// initialStatus returns the status of a new template version: localized versions are drafts
+// until they are activated, other versions take the status of the previous one
+func initialStatus(template *models.Template, previous string) string {
+	if template.IsLocalized() {
+		return models.TemplateStatusDraft
+	}
+	if previous == models.TemplateStatusDraft {
+		return models.TemplateStatusCreated
+	}
+	return previous
+}
+
+// ActivateTemplate activates a version of a template on behalf of actor. Localized versions
+// must have content in defaultLocale, the locale their fallback chains end with.
+func (tm *TemplateManagerImpl) ActivateTemplate(templateID string, version int, defaultLocale string, actor string) (*models.TemplateResponse, error) {
+	tm.templateMutex.Lock()
+	defer tm.templateMutex.Unlock()
+
+	template, err := tm.versionLocked(templateID, version)
+	if err != nil {
+		return nil, err
+	}
+	if template.IsLocalized() && !template.HasLocale(defaultLocale) {
+		return nil, fmt.Errorf("%w: add content for %s", models.ErrMissingDefaultLocale, defaultLocale)
+	}
+	if template.Status == models.TemplateStatusActive {
+		return templateResponse(template), nil
+	}
+
+	// Stored versions are shared with readers, so the activated version replaces them
+	activated := *template
+	activated.Status = models.TemplateStatusActive
+	for i, stored := range tm.versions[templateID] {
+		if stored == template {
+			tm.versions[templateID][i] = &activated
+		}
+	}
+	if tm.templates[templateID] == template {
+		tm.templates[templateID] = &activated
+	}
+	tm.audit[templateID] = append(tm.audit[templateID], models.TemplateAuditEntry{
+		TemplateID: templateID,
+		Version:    version,
+		Action:     models.TemplateActionActivated,
+		Actor:      actor,
+		At:         tm.clock.Now(),
+	})
+
+	return templateResponse(&activated), nil
+}
+
+// GetTemplateAudit returns the change log of a template, oldest entry first
+func (tm *TemplateManagerImpl) GetTemplateAudit(templateID string) ([]models.TemplateAuditEntry, error) {
+	tm.templateMutex.RLock()
+	defer tm.templateMutex.RUnlock()
+
+	entries, exists := tm.audit[templateID]
+	if !exists {
+		return nil, models.ErrTemplateNotFound
+	}
+
+	// Entries are copied so callers cannot modify the log
+	audit := make([]models.TemplateAuditEntry, len(entries))
+	for i, entry := range entries {
+		audit[i] = entry
+		audit[i].Changes = append([]models.TemplateFieldChange(nil), entry.Changes...)
+	}
+	return audit, nil
+}
+
+// storeVersionLocked makes template the latest version and appends it to the audit log.
+// Callers must hold templateMutex.
+func (tm *TemplateManagerImpl) storeVersionLocked(template *models.Template, action string, changes []models.TemplateFieldChange) {
 	tm.templates[template.ID] = template
+	tm.versions[template.ID] = append(tm.versions[template.ID], template)
+	tm.audit[template.ID] = append(tm.audit[template.ID], models.TemplateAuditEntry{
+		TemplateID: template.ID,
+		Version:    template.Version,
+		Action:     action,
+		Actor:      template.UpdatedBy,
+		Changes:    changes,
+		At:         template.UpdatedAt,
+	})
+}
 
-	// Return response
+// versionLocked returns a stored version of a template. Callers must hold templateMutex.
+func (tm *TemplateManagerImpl) versionLocked(templateID string, version int) (*models.Template, error) {
+	for _, template := range tm.versions[templateID] {
+		if template.Version == version {
+			return template, nil
+		}
+	}
+	return nil, models.ErrTemplateNotFound
+}
+
+// templateResponse builds the response returned when a template version is stored
+func templateResponse(template *models.Template) *models.TemplateResponse {
 	return &models.TemplateResponse{
 		ID:        template.ID,
 		Name:      template.Name,
@@ -77,7 +264,61 @@ func (tm *TemplateManagerImpl) CreateTemplate(template *models.Template) (*model
 		Version:   template.Version,
 		Status:    template.Status,
 		CreatedAt: template.CreatedAt,
-	}, nil
+		CreatedBy: template.CreatedBy,
+		UpdatedAt: template.UpdatedAt,
+		UpdatedBy: template.UpdatedBy,
+	}
+}
+
+// templateChanges lists the fields that differ between two versions of a template
+func templateChanges(previous, current *models.Template) []models.TemplateFieldChange {
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"name", previous.Name, current.Name},
+		{"description", previous.Description, current.Description},
+		{"render_mode", previous.RenderMode, current.RenderMode},
+		{"color_scheme", previous.ColorScheme, current.ColorScheme},
+		{"category", previous.Category, current.Category},
+		{"content.subject", previous.Content.Subject, current.Content.Subject},
+		{"content.email_body", previous.Content.EmailBody, current.Content.EmailBody},
+		{"content.email_text_body", previous.Content.EmailTextBody, current.Content.EmailTextBody},
+		{"content.email_dark_mode_css", previous.Content.EmailDarkModeCSS, current.Content.EmailDarkModeCSS},
+		{"content.text", previous.Content.Text, current.Content.Text},
+		{"content.title", previous.Content.Title, current.Content.Title},
+		{"content.body", previous.Content.Body, current.Content.Body},
+		{"content.card", googleChatCardString(previous.Content.Card), googleChatCardString(current.Content.Card)},
+		{"required_variables", strings.Join(previous.RequiredVariables, ","), strings.Join(current.RequiredVariables, ",")},
+		{"locale", previous.Locale, current.Locale},
+		{"localizations", localizationsString(previous.Localizations), localizationsString(current.Localizations)},
+	}
+
+	var changes []models.TemplateFieldChange
+	for _, field := range fields {
+		if field.old != field.new {
+			changes = append(changes, models.TemplateFieldChange{Field: field.name, Old: field.old, New: field.new})
+		}
+	}
+	return changes
+}
+
+// googleChatCardString encodes a Google Chat card for the audit log
+func googleChatCardString(card *models.GoogleChatCard) string {
+	if card == nil {
+		return ""
+	}
+	encoded, _ := json.Marshal(card)
+	return string(encoded)
+}
+
+// localizationsString encodes localizations for the audit log, sorted by locale
+func localizationsString(localizations map[string]models.TemplateContent) string {
+	if len(localizations) == 0 {
+		return ""
+	}
+	encoded, _ := json.Marshal(localizations)
+	return string(encoded)
 }
 
 // GetTemplateVersion retrieves a specific version of a notification template
@@ -85,15 +326,9 @@ func (tm *TemplateManagerImpl) GetTemplateVersion(templateID string, version int
 	tm.templateMutex.RLock()
 	defer tm.templateMutex.RUnlock()
 
-	template, exists := tm.templates[templateID]
-	if !exists {
-		return nil, models.ErrTemplateNotFound
-	}
-
-	// For now, we only support version 1
-	// In a real implementation, you would store multiple versions
-	if version != 1 {
-		return nil, models.ErrTemplateNotFound
+	template, err := tm.versionLocked(templateID, version)
+	if err != nil {
+		return nil, err
 	}
 
 	// Return template version
@@ -105,8 +340,17 @@ func (tm *TemplateManagerImpl) GetTemplateVersion(templateID string, version int
 		Content:           template.Content,
 		RequiredVariables: template.RequiredVariables,
 		Description:       template.Description,
+		RenderMode:        template.RenderMode,
+		Category:          template.Category,
 		Status:            template.Status,
 		CreatedAt:         template.CreatedAt,
+		CreatedBy:         template.CreatedBy,
+		UpdatedAt:         template.UpdatedAt,
+		UpdatedBy:         template.UpdatedBy,
+		Locale:            template.Locale,
+		Localizations:     template.Localizations,
+		ColorScheme:       template.ColorScheme,
+		SourceCommit:      template.SourceCommit,
 	}, nil
 }
 
@@ -126,6 +370,26 @@ func (tm *TemplateManagerImpl) GetPredefinedTemplates() []*models.Template {
 	return predefinedTemplates
 }
 
+// ListTemplates returns all predefined and custom templates sorted by name
+func (tm *TemplateManagerImpl) ListTemplates() []*models.Template {
+	tm.templateMutex.RLock()
+	defer tm.templateMutex.RUnlock()
+
+	templates := make([]*models.Template, 0, len(tm.templates))
+	for _, template := range tm.templates {
+		templates = append(templates, template)
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].Name != templates[j].Name {
+			return templates[i].Name < templates[j].Name
+		}
+		return templates[i].ID < templates[j].ID
+	})
+
+	return templates
+}
+
 // GetTemplateByID returns a template by ID (latest version)
 func (tm *TemplateManagerImpl) GetTemplateByID(templateID string) (*models.Template, error) {
 	tm.templateMutex.RLock()
@@ -144,36 +408,19 @@ func (tm *TemplateManagerImpl) GetTemplateByIDAndVersion(templateID string, vers
 	tm.templateMutex.RLock()
 	defer tm.templateMutex.RUnlock()
 
-	template, exists := tm.templates[templateID]
-	if !exists {
-		return nil, models.ErrTemplateNotFound
-	}
+	return tm.versionLocked(templateID, version)
+}
 
-	// For now, we only support version 1 (current implementation)
-	// In a real implementation, you would store multiple versions
-	if version != 1 {
-		return nil, models.ErrTemplateNotFound
+// predefinedTemplateIDs holds the IDs of the templates of models.PredefinedTemplates
+var predefinedTemplateIDs = func() map[string]bool {
+	ids := make(map[string]bool)
+	for _, template := range models.PredefinedTemplates() {
+		ids[template.ID] = true
 	}
-
-	return template, nil
-}
+	return ids
+}()
 
 // isPredefinedTemplateID checks if a template ID is one of the predefined ones
 func isPredefinedTemplateID(templateID string) bool {
-	predefinedIDs := []string{
-		"550e8400-e29b-41d4-a716-446655440000", // Welcome Email
-		"550e8400-e29b-41d4-a716-446655440001", // Password Reset
-		"550e8400-e29b-41d4-a716-446655440002", // Order Confirmation
-		"550e8400-e29b-41d4-a716-446655440003", // System Alert
-		"550e8400-e29b-41d4-a716-446655440004", // Deployment Notification
-		"550e8400-e29b-41d4-a716-446655440005", // Order Status Update
-		"550e8400-e29b-41d4-a716-446655440006", // Payment Reminder
-	}
-
-	for _, id := range predefinedIDs {
-		if templateID == id {
-			return true
-		}
-	}
-	return false
+	return predefinedTemplateIDs[templateID]
 }
@@ -0,0 +1,29 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateTemplate_RejectsEveryPredefinedTemplate(t *testing.T) {
+	tm := NewTemplateManager()
+
+	predefined := models.PredefinedTemplates()
+	for _, template := range predefined {
+		_, err := tm.UpdateTemplate(template.ID, &models.Template{
+			Name:    template.Name,
+			Type:    template.Type,
+			Content: template.Content,
+		}, "alice")
+		assert.ErrorIs(t, err, models.ErrPredefinedTemplate, "template %s", template.Name)
+
+		stored, err := tm.GetTemplateByID(template.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, stored.Version, "template %s", template.Name)
+	}
+
+	assert.Len(t, tm.GetPredefinedTemplates(), len(predefined))
+}
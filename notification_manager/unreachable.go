@@ -0,0 +1,198 @@
+package notification_manager
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// maxHeldPerUser bounds the notifications held for one unreachable user; the oldest are dropped
+const maxHeldPerUser = 100
+
+// notificationUnreachableTotal counts recipients without the contact details a notification needs
+var notificationUnreachableTotal = metrics.DefaultRegistry.NewCounterVec(
+	"notification_unreachable_total",
+	"Recipients without the contact details the notification type needs, by outcome (skipped, held or retried) and notification type.",
+	"outcome", "type",
+)
+
+// lacksContactPoint reports whether a recipient has no address for a channel that needs one.
+// In-app notifications always reach the inbox.
+func lacksContactPoint(channel string, userInfo *models.UserNotificationInfo) bool {
+	switch channel {
+	case "email":
+		return userInfo.Email == ""
+	case "slack":
+		return userInfo.SlackChannel == ""
+	case "google_chat":
+		return userInfo.GoogleChatSpace == ""
+	case "incident":
+		return userInfo.IncidentRoute == ""
+	case "rcs":
+		return userInfo.PhoneNumber == ""
+	}
+	return false
+}
+
+// heldNotification is a notification held for a recipient who could not be reached
+type heldNotification struct {
+	notificationID string
+	request        *models.NotificationRequest
+	until          time.Time
+}
+
+// unreachableStore holds the notifications of unreachable recipients by user ID
+type unreachableStore struct {
+	mu   sync.Mutex
+	held map[string][]heldNotification
+}
+
+func newUnreachableStore() *unreachableStore {
+	return &unreachableStore{held: make(map[string][]heldNotification)}
+}
+
+// Hold keeps a notification for a user, dropping the user's notifications that are no longer
+// held at now
+func (s *unreachableStore) Hold(userID string, notification heldNotification, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	held := s.held[userID][:0]
+	for _, existing := range s.held[userID] {
+		if existing.until.After(now) {
+			held = append(held, existing)
+		}
+	}
+	held = append(held, notification)
+	if len(held) > maxHeldPerUser {
+		held = held[len(held)-maxHeldPerUser:]
+	}
+	s.held[userID] = held
+}
+
+// Take removes the notifications held for a user and returns those still held at now
+func (s *unreachableStore) Take(userID string, now time.Time) []heldNotification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var held []heldNotification
+	for _, notification := range s.held[userID] {
+		if notification.until.After(now) {
+			held = append(held, notification)
+		}
+	}
+	delete(s.held, userID)
+	return held
+}
+
+// holdIfUnreachable reports whether a recipient cannot be reached on the notification type nor
+// on any of its fallback channels. With an unreachable retry window the notification is held,
+// to be sent if the recipient's contact details are updated within the window.
+func (nm *NotificationManagerImpl) holdIfUnreachable(notificationID string, request *models.NotificationRequest, userInfo *models.UserNotificationInfo) bool {
+	if !lacksContactPoint(nm.routeRequest(request, userInfo).Type, userInfo) {
+		return false
+	}
+
+	fields := logger.Fields{
+		"notification_id": notificationID,
+		"user_id":         userInfo.ID,
+		"type":            request.Type,
+	}
+	window := nm.config.UnreachableRetryWindow
+	if window <= 0 {
+		sampledLog.Warn("Recipient has no contact point for the notification type", fields)
+		notificationUnreachableTotal.Inc("skipped", request.Type)
+		return true
+	}
+
+	now := nm.clock.Now()
+	nm.unreachable.Hold(userInfo.ID, heldNotification{
+		notificationID: notificationID,
+		request:        request,
+		until:          now.Add(window),
+	}, now)
+	sampledLog.Info("Recipient has no contact point for the notification type, holding it until they do", fields)
+	notificationUnreachableTotal.Inc("held", request.Type)
+	return true
+}
+
+// retryUnreachable sends the notifications held for a user whose contact details changed. Those
+// the user still cannot be reached for are held again until the end of their window.
+func (nm *NotificationManagerImpl) retryUnreachable(userID string) {
+	now := nm.clock.Now()
+	held := nm.unreachable.Take(userID, now)
+	if len(held) == 0 {
+		return
+	}
+
+	userInfo, err := nm.userService.GetUserNotificationInfo(userID)
+	if err != nil {
+		moduleLog.Warn("Recipient of held notifications is no longer available", logger.Fields{
+			"user_id": userID,
+			"held":    len(held),
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	for _, notification := range held {
+		if lacksContactPoint(nm.routeRequest(notification.request, userInfo).Type, userInfo) {
+			nm.unreachable.Hold(userID, notification, now)
+			continue
+		}
+		notificationUnreachableTotal.Inc("retried", notification.request.Type)
+		nm.sendHeld(notification, userInfo)
+	}
+}
+
+// sendHeld sends a held notification to a recipient who can now be reached. Preferences and
+// do-not-disturb are checked again, as they may have changed since the notification was held.
+func (nm *NotificationManagerImpl) sendHeld(notification heldNotification, userInfo *models.UserNotificationInfo) {
+	notificationID, request := notification.notificationID, notification.request
+	progress := NotificationProgress{Unreachable: -1}
+	defer func() {
+		if err := nm.storage.AddProgress(notificationID, progress); err != nil {
+			moduleLog.Warn("Failed to record notification progress", logger.Fields{
+				"notification_id": notificationID,
+				"error":           err.Error(),
+			})
+		}
+		nm.recordQueuedMetric(request, progress.Queued)
+	}()
+
+	if nm.isSuppressed(request, userInfo.ID) || nm.isThreadMuted(request, userInfo.ID) || nm.requiresVerifiedContact(request, userInfo) {
+		progress.Suppressed++
+		return
+	}
+	switch nm.applyDoNotDisturb(notificationID, request, userInfo) {
+	case dndDeferred:
+		progress.Deferred++
+		return
+	case dndDropped:
+		progress.Suppressed++
+		return
+	}
+
+	fields := logger.Fields{
+		"notification_id": notificationID,
+		"user_id":         userInfo.ID,
+	}
+	recipientRequest, err := nm.personalizeRequest(nm.routeRequest(request, userInfo), userInfo)
+	if err != nil {
+		fields["error"] = err.Error()
+		moduleLog.Error("Failed to render template for user", fields)
+		progress.Failed++
+		return
+	}
+
+	count, err := nm.processNotificationByType(notificationID, recipientRequest, userInfo, nm.config.EnqueueTimeout)
+	progress.Queued += count
+	if err != nil {
+		fields["error"] = err.Error()
+		moduleLog.Error("Failed to process held notification for user", fields)
+		progress.Failed++
+	}
+}
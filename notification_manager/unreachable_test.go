@@ -0,0 +1,149 @@
+package notification_manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clearEmail removes the email address of a user
+func clearEmail(t *testing.T, nm *NotificationManagerImpl, userID string) {
+	t.Helper()
+	user, err := nm.userService.GetUserByID(userID)
+	require.NoError(t, err)
+	user.Email = ""
+	require.NoError(t, nm.userService.UpdateUser(user))
+}
+
+// setEmail gives a user an email address through the user service, as the user API does
+func setEmail(t *testing.T, nm *NotificationManagerImpl, userID, email string) {
+	t.Helper()
+	user, err := nm.userService.GetUserByID(userID)
+	require.NoError(t, err)
+	user.Email = email
+	require.NoError(t, nm.userService.UpdateUser(user))
+}
+
+func sendEmail(t *testing.T, nm *NotificationManagerImpl, recipients ...string) string {
+	t.Helper()
+	result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "email",
+		Content:    map[string]interface{}{"subject": "Invoice", "email_body": "Your invoice is ready."},
+		Recipients: recipients,
+	})
+	require.NoError(t, err)
+	return result.(map[string]interface{})["id"].(string)
+}
+
+func TestProcessNotificationRequest_UnreachableRecipients(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 2, DefaultConfig())
+	clearEmail(t, nm, recipients[1])
+
+	notificationID := sendEmail(t, nm, recipients...)
+	progress, err := nm.storage.GetProgress(notificationID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, progress.Queued)
+	assert.Equal(t, 1, progress.Unreachable)
+	assert.Len(t, kafkaService.GetEmailChannel(), 1)
+
+	// Without a retry window the recipient is skipped for good
+	setEmail(t, nm, recipients[1], "late.user@company.com")
+	progress, err = nm.storage.GetProgress(notificationID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, progress.Unreachable)
+	assert.Len(t, kafkaService.GetEmailChannel(), 1)
+}
+
+func TestRetryUnreachable(t *testing.T) {
+	config := DefaultConfig()
+	config.UnreachableRetryWindow = 24 * time.Hour
+	nm, kafkaService, recipients := newTestManager(t, 3, config)
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	nm.SetClock(fakeClock)
+	clearEmail(t, nm, recipients[1])
+	clearEmail(t, nm, recipients[2])
+
+	notificationID := sendEmail(t, nm, recipients...)
+	progress, err := nm.storage.GetProgress(notificationID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, progress.Unreachable)
+	require.Len(t, kafkaService.GetEmailChannel(), 1)
+	<-kafkaService.GetEmailChannel()
+
+	// Other profile changes leave the notification held
+	user, err := nm.userService.GetUserByID(recipients[1])
+	require.NoError(t, err)
+	user.FullName = "Renamed User"
+	require.NoError(t, nm.userService.UpdateUser(user))
+	assert.Empty(t, kafkaService.GetEmailChannel())
+
+	fakeClock.Advance(time.Hour)
+	setEmail(t, nm, recipients[1], "late.user@company.com")
+	require.Len(t, kafkaService.GetEmailChannel(), 1)
+	assert.Contains(t, <-kafkaService.GetEmailChannel(), "late.user@company.com")
+	progress, err = nm.storage.GetProgress(notificationID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, progress.Queued)
+	assert.Equal(t, 1, progress.Unreachable)
+
+	// A held notification is sent once
+	setEmail(t, nm, recipients[1], "later.user@company.com")
+	assert.Empty(t, kafkaService.GetEmailChannel())
+
+	// Past the window the recipient stays unreachable
+	fakeClock.Advance(24 * time.Hour)
+	setEmail(t, nm, recipients[2], "too.late@company.com")
+	assert.Empty(t, kafkaService.GetEmailChannel())
+	progress, err = nm.storage.GetProgress(notificationID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, progress.Unreachable)
+}
+
+func TestRetryUnreachable_ChecksPreferencesAgain(t *testing.T) {
+	config := DefaultConfig()
+	config.UnreachableRetryWindow = time.Hour
+	nm, kafkaService, recipients := newTestManager(t, 1, config)
+	clearEmail(t, nm, recipients[0])
+
+	result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "email",
+		Category:   models.CategoryMarketing,
+		Content:    map[string]interface{}{"subject": "Sale", "email_body": "Body"},
+		Recipients: recipients,
+	})
+	require.NoError(t, err)
+	notificationID := result.(map[string]interface{})["id"].(string)
+
+	_, err = nm.UpdatePreferences(recipients[0], &models.NotificationPreferences{
+		Categories: map[string]*models.CategoryPreference{models.CategoryMarketing: {OptOut: true}},
+	})
+	require.NoError(t, err)
+	setEmail(t, nm, recipients[0], "opted.out@company.com")
+
+	assert.Empty(t, kafkaService.GetEmailChannel())
+	progress, err := nm.storage.GetProgress(notificationID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, progress.Unreachable)
+	assert.Equal(t, 1, progress.Suppressed)
+}
+
+func TestUnreachableStore_Hold(t *testing.T) {
+	store := newUnreachableStore()
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	store.Hold("user-001", heldNotification{notificationID: "expired", until: now.Add(time.Minute)}, now)
+	for i := 0; i < maxHeldPerUser+1; i++ {
+		store.Hold("user-001", heldNotification{notificationID: "held", until: now.Add(2 * time.Hour)}, now.Add(time.Hour))
+	}
+
+	held := store.Take("user-001", now.Add(time.Hour))
+	assert.Len(t, held, maxHeldPerUser)
+	for _, notification := range held {
+		assert.Equal(t, "held", notification.notificationID)
+	}
+	assert.Empty(t, store.Take("user-001", now.Add(time.Hour)))
+}
@@ -0,0 +1,123 @@
+package notification_manager
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gaurav2721/notification-service/metrics"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/sirupsen/logrus"
+)
+
+// notificationUnverifiedRecipientsTotal counts recipients skipped for lack of a verified contact
+var notificationUnverifiedRecipientsTotal = metrics.DefaultRegistry.NewCounterVec(
+	"notification_unverified_recipients_total",
+	"Recipients skipped because the channel only delivers to verified contacts, by notification type.",
+	"type",
+)
+
+// SendContactVerification sends a verification code to one of a user's contact points, the
+// primary address of the contact type when address is empty. Email codes are sent through the
+// email channel with the contact verification template; there is no SMS channel, so phone
+// numbers cannot be verified yet.
+func (nm *NotificationManagerImpl) SendContactVerification(userID, contact, address string) (interface{}, error) {
+	if contact == models.ContactPhone {
+		return nil, ErrContactChannelUnavailable
+	}
+
+	challenge, code, err := nm.userService.StartContactVerification(userID, contact, address)
+	if err != nil {
+		return nil, err
+	}
+	userInfo, err := nm.userService.GetUserNotificationInfo(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	link := ""
+	if nm.config.VerificationLinkURL != "" {
+		link = "\n\nOr open this link: " + verificationLink(nm.config.VerificationLinkURL, userID, contact, address, code)
+	}
+	data := map[string]interface{}{
+		"code":               code,
+		"expires_in_minutes": strconv.Itoa(int(challenge.ExpiresAt.Sub(nm.clock.Now()).Round(time.Minute).Minutes())),
+		"verification_link":  link,
+	}
+	// Addresses other than the one the user is emailed at are sent the code as address recipients
+	recipient := userID
+	if challenge.Address != userInfo.Email {
+		recipient = models.RecipientEmail + ":" + challenge.Address
+		data = models.WithRecipientVariables(data, userInfo)
+		data[models.RecipientEmailVariable] = challenge.Address
+	}
+	request := &models.NotificationRequest{
+		Type: string(models.EmailNotification),
+		Template: &models.TemplateData{
+			ID:      models.ContactVerificationTemplateID,
+			Version: 1,
+			Data:    data,
+		},
+		Recipients:    []string{recipient},
+		Transactional: true,
+	}
+
+	response, err := nm.ProcessNotificationRequest(request)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"user_id": userID,
+		"contact": contact,
+	}).Debug("Contact verification sent")
+
+	return &struct {
+		*models.VerificationChallenge
+		NotificationID string `json:"notification_id"`
+	}{
+		VerificationChallenge: challenge,
+		NotificationID:        response.(map[string]interface{})["id"].(string),
+	}, nil
+}
+
+// verificationLink appends the verification parameters to the configured link URL
+func verificationLink(base, userID, contact, address, code string) string {
+	separator := "?"
+	if strings.Contains(base, "?") {
+		separator = "&"
+	}
+	query := url.Values{"user_id": {userID}, "contact": {contact}, "code": {code}}
+	if address != "" {
+		query.Set("address", address)
+	}
+	return base + separator + query.Encode()
+}
+
+// requiresVerifiedContact reports whether the recipient is skipped because the notification
+// type only delivers to verified contacts. Verification emails themselves are always sent.
+func (nm *NotificationManagerImpl) requiresVerifiedContact(request *models.NotificationRequest, userInfo *models.UserNotificationInfo) bool {
+	if !nm.lacksVerifiedContact(request, userInfo) {
+		return false
+	}
+	notificationUnverifiedRecipientsTotal.Inc(request.Type)
+	return true
+}
+
+// lacksVerifiedContact is requiresVerifiedContact without counting the recipient
+func (nm *NotificationManagerImpl) lacksVerifiedContact(request *models.NotificationRequest, userInfo *models.UserNotificationInfo) bool {
+	if !nm.config.VerifiedContactTypes[request.Type] {
+		return false
+	}
+	if request.Template != nil && request.Template.ID == models.ContactVerificationTemplateID {
+		return false
+	}
+
+	verified := true
+	switch request.Type {
+	case string(models.EmailNotification):
+		verified = userInfo.EmailVerified
+	}
+	return !verified
+}
@@ -0,0 +1,91 @@
+package notification_manager
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendContactVerification(t *testing.T) {
+	config := DefaultConfig()
+	config.VerificationLinkURL = "https://app.example.com/verify"
+	nm, kafkaService, recipients := newTestManager(t, 1, config)
+
+	response, err := nm.SendContactVerification(recipients[0], models.ContactEmail, "")
+	require.NoError(t, err)
+	encoded, err := json.Marshal(response)
+	require.NoError(t, err)
+	assert.NotContains(t, string(encoded), `"code"`, "the code is only sent to the contact")
+
+	require.Len(t, kafkaService.GetEmailChannel(), 1)
+	var email models.EmailNotificationRequest
+	require.NoError(t, json.Unmarshal([]byte(<-kafkaService.GetEmailChannel()), &email))
+	assert.Contains(t, email.Content.EmailBody, "https://app.example.com/verify?code=")
+	code := regexp.MustCompile(`\d{6}`).FindString(email.Content.Subject)
+	require.NotEmpty(t, code)
+
+	require.NoError(t, nm.userService.ConfirmContactVerification(recipients[0], models.ContactEmail, "", code))
+	info, err := nm.userService.GetUserNotificationInfo(recipients[0])
+	require.NoError(t, err)
+	assert.True(t, info.EmailVerified)
+
+	_, err = nm.SendContactVerification(recipients[0], models.ContactPhone, "")
+	assert.ErrorIs(t, err, ErrContactChannelUnavailable)
+}
+
+func TestSendContactVerification_SecondaryAddress(t *testing.T) {
+	nm, kafkaService, recipients := newTestManager(t, 1, DefaultConfig())
+	_, err := nm.userService.AddContactPoint(recipients[0], models.ContactEmail, "stream.personal@example.com", false)
+	require.NoError(t, err)
+
+	_, err = nm.SendContactVerification(recipients[0], models.ContactEmail, "stream.personal@example.com")
+	require.NoError(t, err)
+	var email models.EmailNotificationRequest
+	require.NoError(t, json.Unmarshal([]byte(<-kafkaService.GetEmailChannel()), &email))
+	assert.Equal(t, "stream.personal@example.com", email.Recipient)
+	assert.Contains(t, email.Content.EmailBody, "Hello Stream,")
+
+	code := regexp.MustCompile(`\d{6}`).FindString(email.Content.Subject)
+	require.NoError(t, nm.userService.ConfirmContactVerification(recipients[0], models.ContactEmail, "stream.personal@example.com", code))
+
+	// Notifications go to the verified address
+	_, err = nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "email",
+		Content:    map[string]interface{}{"subject": "Hello", "email_body": "Body"},
+		Recipients: recipients,
+	})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal([]byte(<-kafkaService.GetEmailChannel()), &email))
+	assert.Equal(t, "stream.personal@example.com", email.Recipient)
+}
+
+func TestProcessNotificationRequest_RequiresVerifiedContacts(t *testing.T) {
+	config := DefaultConfig()
+	config.VerifiedContactTypes = map[string]bool{"email": true}
+	nm, kafkaService, recipients := newTestManager(t, 2, config)
+
+	// Verification emails reach unverified addresses
+	_, err := nm.SendContactVerification(recipients[0], models.ContactEmail, "")
+	require.NoError(t, err)
+	var email models.EmailNotificationRequest
+	require.NoError(t, json.Unmarshal([]byte(<-kafkaService.GetEmailChannel()), &email))
+	code := regexp.MustCompile(`\d{6}`).FindString(email.Content.Subject)
+	require.NoError(t, nm.userService.ConfirmContactVerification(recipients[0], models.ContactEmail, "", code))
+
+	result, err := nm.ProcessNotificationRequest(&models.NotificationRequest{
+		Type:       "email",
+		Content:    map[string]interface{}{"subject": "Hello", "email_body": "Body"},
+		Recipients: recipients,
+	})
+	require.NoError(t, err)
+
+	progress, err := nm.storage.GetProgress(result.(map[string]interface{})["id"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, 1, progress.Queued)
+	assert.Equal(t, 1, progress.Suppressed, "the unverified address is not sent to")
+	assert.Len(t, kafkaService.GetEmailChannel(), 1)
+}
@@ -0,0 +1,35 @@
+package notification_manager
+
+import (
+	"context"
+	"time"
+)
+
+// WaitForNotificationStatus returns the status of a notification once it reached a terminal status,
+// or when the timeout elapsed or ctx was cancelled first
+func (nm *NotificationManagerImpl) WaitForNotificationStatus(ctx context.Context, notificationID string, timeout time.Duration) (interface{}, error) {
+	timedOut := make(chan struct{})
+	timer := nm.clock.AfterFunc(timeout, func() { close(timedOut) })
+	defer timer.Stop()
+
+wait:
+	for {
+		status, changed, err := nm.storage.WatchStatus(notificationID)
+		if err != nil {
+			return nil, err
+		}
+		if status.IsTerminal() {
+			break
+		}
+
+		select {
+		case <-changed:
+		case <-timedOut:
+			break wait
+		case <-ctx.Done():
+			break wait
+		}
+	}
+
+	return nm.GetNotificationStatus(notificationID)
+}
@@ -0,0 +1,110 @@
+package notification_manager
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitResult is the outcome of a WaitForNotificationStatus call running in the background
+type waitResult struct {
+	response interface{}
+	err      error
+}
+
+// startWait waits for the notification in the background once the wait timer is armed
+func startWait(t *testing.T, nm *NotificationManagerImpl, fakeClock *clock.Fake, ctx context.Context, notificationID string) <-chan waitResult {
+	pending := fakeClock.PendingTimers()
+	results := make(chan waitResult, 1)
+	go func() {
+		response, err := nm.WaitForNotificationStatus(ctx, notificationID, 30*time.Second)
+		results <- waitResult{response: response, err: err}
+	}()
+	require.Eventually(t, func() bool { return fakeClock.PendingTimers() > pending }, time.Second, time.Millisecond)
+	return results
+}
+
+// mustStatus returns the status field of a notification status response
+func mustStatus(t *testing.T, response interface{}) string {
+	encoded, err := json.Marshal(response)
+	require.NoError(t, err)
+	var body struct {
+		Status string `json:"status"`
+	}
+	require.NoError(t, json.Unmarshal(encoded, &body))
+	return body.Status
+}
+
+func newWaitTestManager(t *testing.T) (*NotificationManagerImpl, *clock.Fake, string) {
+	nm, _, recipients := newTestManager(t, 1, DefaultConfig())
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	nm.SetClock(fakeClock)
+
+	notificationID := "123e4567-e89b-12d3-a456-426614174000"
+	require.NoError(t, nm.storage.StoreNotification(notificationID, &models.NotificationRequest{
+		Type:       "email",
+		Recipients: recipients,
+	}))
+	return nm, fakeClock, notificationID
+}
+
+func TestWaitForNotificationStatus_ReturnsOnTerminalStatus(t *testing.T) {
+	nm, fakeClock, notificationID := newWaitTestManager(t)
+	results := startWait(t, nm, fakeClock, context.Background(), notificationID)
+
+	require.NoError(t, nm.storage.UpdateNotificationStatus(notificationID, StatusQueued, ""))
+	select {
+	case result := <-results:
+		t.Fatalf("returned on a non-terminal status: %+v", result)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	require.NoError(t, nm.storage.UpdateNotificationStatus(notificationID, StatusSent, ""))
+	result := <-results
+	require.NoError(t, result.err)
+	assert.Equal(t, "sent", mustStatus(t, result.response))
+	assert.Zero(t, fakeClock.PendingTimers(), "the wait timer is stopped")
+}
+
+func TestWaitForNotificationStatus_AlreadyTerminal(t *testing.T) {
+	nm, _, notificationID := newWaitTestManager(t)
+	require.NoError(t, nm.storage.UpdateNotificationStatus(notificationID, StatusFailed, "provider down"))
+
+	response, err := nm.WaitForNotificationStatus(context.Background(), notificationID, 30*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "failed", mustStatus(t, response))
+}
+
+func TestWaitForNotificationStatus_Timeout(t *testing.T) {
+	nm, fakeClock, notificationID := newWaitTestManager(t)
+	results := startWait(t, nm, fakeClock, context.Background(), notificationID)
+
+	fakeClock.Advance(30 * time.Second)
+	result := <-results
+	require.NoError(t, result.err)
+	assert.Equal(t, "pending", mustStatus(t, result.response))
+}
+
+func TestWaitForNotificationStatus_Cancelled(t *testing.T) {
+	nm, fakeClock, notificationID := newWaitTestManager(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	results := startWait(t, nm, fakeClock, ctx, notificationID)
+
+	cancel()
+	result := <-results
+	require.NoError(t, result.err)
+	assert.Equal(t, "pending", mustStatus(t, result.response))
+}
+
+func TestWaitForNotificationStatus_NotFound(t *testing.T) {
+	nm, _, _ := newWaitTestManager(t)
+
+	_, err := nm.WaitForNotificationStatus(context.Background(), "00000000-0000-0000-0000-000000000000", time.Second)
+	assert.ErrorIs(t, err, ErrNotificationNotFound)
+}
@@ -0,0 +1,9 @@
+package policy
+
+import "errors"
+
+// Routing policy errors
+var (
+	ErrInvalidPolicy  = errors.New("invalid routing policy")
+	ErrPolicyNotFound = errors.New("routing policy not found")
+)
@@ -0,0 +1,302 @@
+// Package policy evaluates the routing policies admins define to suppress notifications or
+// send them on another channel, based on attributes of the notification and its recipient.
+//
+// A policy has a condition and an action. Conditions compare attributes with the operators
+// below and combine comparisons with all, any and not:
+//
+//	{"all": [
+//	  {"field": "category", "op": "eq", "value": "marketing"},
+//	  {"field": "recipient.email_domain", "op": "in", "value": ["partner.com", "vendor.com"]}
+//	]}
+//
+// The enabled policies are evaluated in priority order for every recipient, and the first one
+// whose condition matches decides: suppress skips the recipient, route sends the
+// notification on another channel, and allow delivers it unchanged without evaluating the
+// remaining policies.
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Attributes of a notification and its recipient that conditions can test
+const (
+	FieldType                 = "type"
+	FieldCategory             = "category"
+	FieldTransactional        = "transactional"
+	FieldTags                 = "tags"
+	FieldTemplateID           = "template_id"
+	FieldTenant               = "tenant"
+	FieldRecipientID          = "recipient.id"
+	FieldRecipientEmail       = "recipient.email"
+	FieldRecipientEmailDomain = "recipient.email_domain"
+	FieldRecipientLocale      = "recipient.locale"
+	FieldRecipientTimezone    = "recipient.timezone"
+	FieldRecipientVerified    = "recipient.email_verified"
+	FieldRecipientSlack       = "recipient.has_slack"
+	FieldRecipientDeviceTypes = "recipient.device_types"
+)
+
+// fields lists the attributes conditions can test
+var fields = map[string]bool{
+	FieldType: true, FieldCategory: true, FieldTransactional: true, FieldTags: true,
+	FieldTemplateID: true, FieldTenant: true, FieldRecipientID: true, FieldRecipientEmail: true,
+	FieldRecipientEmailDomain: true, FieldRecipientLocale: true, FieldRecipientTimezone: true,
+	FieldRecipientVerified: true, FieldRecipientSlack: true, FieldRecipientDeviceTypes: true,
+}
+
+// Condition operators
+const (
+	OpEq       = "eq"
+	OpNe       = "ne"
+	OpIn       = "in"
+	OpNotIn    = "not_in"
+	OpContains = "contains"
+	OpPrefix   = "prefix"
+	OpSuffix   = "suffix"
+	OpExists   = "exists"
+)
+
+// Policy actions
+const (
+	ActionSuppress = "suppress"
+	ActionRoute    = "route"
+	ActionAllow    = "allow"
+)
+
+// Attributes holds the values of the fields for one recipient of a notification. Values are
+// strings, bools or string slices; a missing or empty value does not exist.
+type Attributes map[string]interface{}
+
+// Condition selects the notifications a policy applies to. It is either a comparison of Field
+// with Value, or combines the conditions in All, Any or Not. The empty condition matches every
+// notification.
+type Condition struct {
+	All []Condition `json:"all,omitempty"`
+	Any []Condition `json:"any,omitempty"`
+	Not *Condition  `json:"not,omitempty"`
+
+	Field string      `json:"field,omitempty"`
+	Op    string      `json:"op,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Action is what happens to the notifications a policy applies to. Channel is the
+// notification type route sends them as.
+type Action struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel,omitempty"`
+}
+
+// Policy is a routing or suppression rule
+type Policy struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Enabled     bool      `json:"enabled"`
+	Priority    int       `json:"priority"` // Lower priorities are evaluated first
+	Condition   Condition `json:"condition"`
+	Action      Action    `json:"action"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Decision is the outcome of the policy that matched a recipient
+type Decision struct {
+	PolicyID   string `json:"policy_id"`
+	PolicyName string `json:"policy_name"`
+	Action     Action `json:"action"`
+}
+
+// Validate checks the name, condition and action of a policy. isChannel reports whether a
+// route action may send notifications as a notification type.
+func (p *Policy) Validate(isChannel func(channel string) bool) error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPolicy)
+	}
+	if err := p.Condition.validate("condition"); err != nil {
+		return err
+	}
+
+	switch p.Action.Type {
+	case ActionSuppress, ActionAllow:
+		if p.Action.Channel != "" {
+			return fmt.Errorf("%w: action %s takes no channel", ErrInvalidPolicy, p.Action.Type)
+		}
+	case ActionRoute:
+		if !isChannel(p.Action.Channel) {
+			return fmt.Errorf("%w: action route needs a channel such as email, slack or in_app, got %q", ErrInvalidPolicy, p.Action.Channel)
+		}
+	default:
+		return fmt.Errorf("%w: action must be suppress, route or allow, got %q", ErrInvalidPolicy, p.Action.Type)
+	}
+	return nil
+}
+
+// validate checks a condition and the conditions it combines; path names it in errors
+func (c *Condition) validate(path string) error {
+	forms := 0
+	if len(c.All) > 0 {
+		forms++
+	}
+	if len(c.Any) > 0 {
+		forms++
+	}
+	if c.Not != nil {
+		forms++
+	}
+	if c.Field != "" || c.Op != "" || c.Value != nil {
+		forms++
+	}
+	if forms > 1 {
+		return fmt.Errorf("%w: %s must have only one of all, any, not or field", ErrInvalidPolicy, path)
+	}
+
+	for i := range c.All {
+		if err := c.All[i].validate(fmt.Sprintf("%s.all[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+	for i := range c.Any {
+		if err := c.Any[i].validate(fmt.Sprintf("%s.any[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+	if c.Not != nil {
+		return c.Not.validate(path + ".not")
+	}
+	if forms == 0 || len(c.All) > 0 || len(c.Any) > 0 {
+		return nil
+	}
+
+	if !fields[c.Field] {
+		return fmt.Errorf("%w: %s has unknown field %q", ErrInvalidPolicy, path, c.Field)
+	}
+	switch c.Op {
+	case OpExists:
+		if c.Value != nil {
+			return fmt.Errorf("%w: %s: exists takes no value", ErrInvalidPolicy, path)
+		}
+	case OpIn, OpNotIn:
+		if _, ok := c.Value.([]interface{}); !ok {
+			return fmt.Errorf("%w: %s: %s needs a list value", ErrInvalidPolicy, path, c.Op)
+		}
+	case OpEq, OpNe, OpContains, OpPrefix, OpSuffix:
+		if c.Value == nil {
+			return fmt.Errorf("%w: %s: %s needs a value", ErrInvalidPolicy, path, c.Op)
+		}
+		if _, ok := c.Value.([]interface{}); ok {
+			return fmt.Errorf("%w: %s: %s needs a single value, use in for lists", ErrInvalidPolicy, path, c.Op)
+		}
+	default:
+		return fmt.Errorf("%w: %s has unknown op %q", ErrInvalidPolicy, path, c.Op)
+	}
+	return nil
+}
+
+// Matches reports whether the attributes satisfy the condition
+func (c *Condition) Matches(attrs Attributes) bool {
+	switch {
+	case len(c.All) > 0:
+		for i := range c.All {
+			if !c.All[i].Matches(attrs) {
+				return false
+			}
+		}
+		return true
+	case len(c.Any) > 0:
+		for i := range c.Any {
+			if c.Any[i].Matches(attrs) {
+				return true
+			}
+		}
+		return false
+	case c.Not != nil:
+		return !c.Not.Matches(attrs)
+	case c.Field == "":
+		return true
+	}
+
+	values := attributeValues(attrs[c.Field])
+	switch c.Op {
+	case OpExists:
+		return len(values) > 0
+	case OpNe:
+		return !anyValue(values, func(v string) bool { return v == valueString(c.Value) })
+	case OpNotIn:
+		return !anyValue(values, func(v string) bool { return inList(v, c.Value) })
+	case OpEq:
+		return anyValue(values, func(v string) bool { return v == valueString(c.Value) })
+	case OpIn:
+		return anyValue(values, func(v string) bool { return inList(v, c.Value) })
+	case OpContains:
+		return anyValue(values, func(v string) bool { return strings.Contains(v, valueString(c.Value)) })
+	case OpPrefix:
+		return anyValue(values, func(v string) bool { return strings.HasPrefix(v, valueString(c.Value)) })
+	case OpSuffix:
+		return anyValue(values, func(v string) bool { return strings.HasSuffix(v, valueString(c.Value)) })
+	}
+	return false
+}
+
+// attributeValues returns the values of an attribute as strings. A list attribute matches a
+// comparison when any of its values does.
+func attributeValues(value interface{}) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []string:
+		return v
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	}
+	return []string{valueString(value)}
+}
+
+// valueString formats a condition or attribute value for comparison, so the JSON value true
+// equals the attribute true
+func valueString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprint(value)
+}
+
+// inList reports whether the list value contains v
+func inList(v string, list interface{}) bool {
+	items, _ := list.([]interface{})
+	for _, item := range items {
+		if valueString(item) == v {
+			return true
+		}
+	}
+	return false
+}
+
+// anyValue reports whether match holds for any of the values
+func anyValue(values []string, match func(v string) bool) bool {
+	for _, v := range values {
+		if match(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate returns the decision of the first enabled policy whose condition matches, or nil
+// when none does. Policies must be sorted by priority.
+func Evaluate(policies []Policy, attrs Attributes) *Decision {
+	for i := range policies {
+		policy := &policies[i]
+		if !policy.Enabled || !policy.Condition.Matches(attrs) {
+			continue
+		}
+		return &Decision{PolicyID: policy.ID, PolicyName: policy.Name, Action: policy.Action}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func isChannel(channel string) bool {
+	return channel == "email" || channel == "slack" || channel == "in_app"
+}
+
+func parsePolicy(t *testing.T, data string) Policy {
+	t.Helper()
+	var policy Policy
+	require.NoError(t, json.Unmarshal([]byte(data), &policy))
+	return policy
+}
+
+func TestCondition_Matches(t *testing.T) {
+	attrs := Attributes{
+		FieldType:                 "email",
+		FieldCategory:             "marketing",
+		FieldTransactional:        false,
+		FieldTags:                 []string{"spring-sale", "eu"},
+		FieldRecipientEmail:       "alice@partner.com",
+		FieldRecipientEmailDomain: "partner.com",
+		FieldRecipientLocale:      "",
+	}
+
+	tests := []struct {
+		name      string
+		condition string
+		expected  bool
+	}{
+		{"empty condition", `{}`, true},
+		{"eq", `{"field":"category","op":"eq","value":"marketing"}`, true},
+		{"eq bool", `{"field":"transactional","op":"eq","value":false}`, true},
+		{"ne", `{"field":"type","op":"ne","value":"email"}`, false},
+		{"in", `{"field":"recipient.email_domain","op":"in","value":["vendor.com","partner.com"]}`, true},
+		{"not_in", `{"field":"recipient.email_domain","op":"not_in","value":["partner.com"]}`, false},
+		{"list attribute", `{"field":"tags","op":"eq","value":"eu"}`, true},
+		{"list attribute ne", `{"field":"tags","op":"ne","value":"eu"}`, false},
+		{"prefix", `{"field":"tags","op":"prefix","value":"spring-"}`, true},
+		{"suffix", `{"field":"recipient.email","op":"suffix","value":"@company.com"}`, false},
+		{"contains", `{"field":"recipient.email","op":"contains","value":"alice"}`, true},
+		{"exists", `{"field":"tags","op":"exists"}`, true},
+		{"empty value does not exist", `{"field":"recipient.locale","op":"exists"}`, false},
+		{"missing value does not exist", `{"field":"template_id","op":"exists"}`, false},
+		{"all", `{"all":[{"field":"type","op":"eq","value":"email"},{"field":"category","op":"eq","value":"security"}]}`, false},
+		{"any", `{"any":[{"field":"type","op":"eq","value":"slack"},{"field":"category","op":"eq","value":"marketing"}]}`, true},
+		{"not", `{"not":{"field":"category","op":"eq","value":"marketing"}}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var condition Condition
+			require.NoError(t, json.Unmarshal([]byte(tt.condition), &condition))
+			require.NoError(t, condition.validate("condition"))
+			assert.Equal(t, tt.expected, condition.Matches(attrs))
+		})
+	}
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		err    string
+	}{
+		{"valid route", `{"name":"p","condition":{"field":"type","op":"eq","value":"email"},"action":{"type":"route","channel":"slack"}}`, ""},
+		{"missing name", `{"action":{"type":"suppress"}}`, "name is required"},
+		{"unknown field", `{"name":"p","condition":{"field":"recipient.age","op":"eq","value":1},"action":{"type":"suppress"}}`, `unknown field "recipient.age"`},
+		{"unknown op", `{"name":"p","condition":{"field":"type","op":"matches","value":"e.*"},"action":{"type":"suppress"}}`, `unknown op "matches"`},
+		{"in without list", `{"name":"p","condition":{"field":"type","op":"in","value":"email"},"action":{"type":"suppress"}}`, "in needs a list value"},
+		{"eq with list", `{"name":"p","condition":{"field":"type","op":"eq","value":["email"]}, "action":{"type":"suppress"}}`, "use in for lists"},
+		{"two forms", `{"name":"p","condition":{"not":{},"field":"type","op":"exists"},"action":{"type":"suppress"}}`, "only one of all, any, not or field"},
+		{"nested error", `{"name":"p","condition":{"all":[{},{"field":"type"}]},"action":{"type":"suppress"}}`, "condition.all[1] has unknown op"},
+		{"route without channel", `{"name":"p","action":{"type":"route"}}`, "action route needs a channel"},
+		{"suppress with channel", `{"name":"p","action":{"type":"suppress","channel":"slack"}}`, "takes no channel"},
+		{"unknown action", `{"name":"p","action":{"type":"drop"}}`, "action must be suppress, route or allow"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := parsePolicy(t, tt.policy)
+			err := policy.Validate(isChannel)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, ErrInvalidPolicy))
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	policies := []Policy{
+		parsePolicy(t, `{"id":"disabled","name":"disabled","priority":0,"action":{"type":"suppress"}}`),
+		parsePolicy(t, `{"id":"security","name":"security","enabled":true,"priority":1,"condition":{"field":"category","op":"eq","value":"security"},"action":{"type":"allow"}}`),
+		parsePolicy(t, `{"id":"partners","name":"partners","enabled":true,"priority":2,"condition":{"field":"recipient.email_domain","op":"eq","value":"partner.com"},"action":{"type":"suppress"}}`),
+	}
+
+	assert.Nil(t, Evaluate(policies, Attributes{FieldCategory: "marketing"}))
+	assert.Equal(t, &Decision{PolicyID: "partners", PolicyName: "partners", Action: Action{Type: ActionSuppress}},
+		Evaluate(policies, Attributes{FieldCategory: "marketing", FieldRecipientEmailDomain: "partner.com"}))
+	assert.Equal(t, "security",
+		Evaluate(policies, Attributes{FieldCategory: "security", FieldRecipientEmailDomain: "partner.com"}).PolicyID,
+		"the first matching policy decides")
+}
@@ -0,0 +1,107 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Store keeps the routing policies in memory, sorted by priority
+type Store struct {
+	mu       sync.RWMutex
+	policies []Policy
+}
+
+// NewStore creates a store without policies
+func NewStore() *Store {
+	return &Store{}
+}
+
+// List returns the policies in the order they are evaluated
+func (s *Store) List() []Policy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Policy(nil), s.policies...)
+}
+
+// Get returns the policy with the given ID
+func (s *Store) Get(id string) (Policy, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if i := s.index(id); i >= 0 {
+		return s.policies[i], nil
+	}
+	return Policy{}, fmt.Errorf("%w: %s", ErrPolicyNotFound, id)
+}
+
+// Create stores a new policy with a generated ID
+func (s *Store) Create(policy Policy, now time.Time) Policy {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	policy.ID = uuid.New().String()
+	policy.CreatedAt = now
+	policy.UpdatedAt = now
+	s.policies = append(s.policies, policy)
+	Sort(s.policies)
+	return policy
+}
+
+// Update replaces the policy with the given ID, keeping its ID and creation time
+func (s *Store) Update(id string, policy Policy, now time.Time) (Policy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(id)
+	if i < 0 {
+		return Policy{}, fmt.Errorf("%w: %s", ErrPolicyNotFound, id)
+	}
+	policy.ID = id
+	policy.CreatedAt = s.policies[i].CreatedAt
+	policy.UpdatedAt = now
+	s.policies[i] = policy
+	Sort(s.policies)
+	return policy, nil
+}
+
+// Delete removes the policy with the given ID
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(id)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrPolicyNotFound, id)
+	}
+	s.policies = append(s.policies[:i], s.policies[i+1:]...)
+	return nil
+}
+
+// Evaluate returns the decision of the stored policies for the attributes, or nil
+func (s *Store) Evaluate(attrs Attributes) *Decision {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return Evaluate(s.policies, attrs)
+}
+
+// index returns the position of the policy with the given ID, or -1
+func (s *Store) index(id string) int {
+	for i := range s.policies {
+		if s.policies[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Sort orders policies by priority the way the store evaluates them. Policies of equal
+// priority keep their order, so the store evaluates them in the order they were created.
+func Sort(policies []Policy) {
+	sort.SliceStable(policies, func(i, j int) bool {
+		return policies[i].Priority < policies[j].Priority
+	})
+}
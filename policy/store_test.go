@@ -0,0 +1,44 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStore()
+	now := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	late := store.Create(Policy{Name: "late", Enabled: true, Priority: 10, Action: Action{Type: ActionAllow}}, now)
+	early := store.Create(Policy{Name: "early", Enabled: true, Priority: 1, Action: Action{Type: ActionSuppress}}, now)
+	require.NotEmpty(t, late.ID)
+	assert.Equal(t, []string{"early", "late"}, names(store.List()))
+	assert.Equal(t, early.ID, store.Evaluate(Attributes{}).PolicyID)
+
+	later := now.Add(time.Hour)
+	updated, err := store.Update(late.ID, Policy{Name: "late", Enabled: true, Priority: 0, Action: Action{Type: ActionAllow}}, later)
+	require.NoError(t, err)
+	assert.Equal(t, now, updated.CreatedAt)
+	assert.Equal(t, later, updated.UpdatedAt)
+	assert.Equal(t, []string{"late", "early"}, names(store.List()))
+
+	require.NoError(t, store.Delete(late.ID))
+	_, err = store.Get(late.ID)
+	assert.True(t, errors.Is(err, ErrPolicyNotFound))
+	assert.True(t, errors.Is(store.Delete(late.ID), ErrPolicyNotFound))
+	_, err = store.Update(late.ID, Policy{}, later)
+	assert.True(t, errors.Is(err, ErrPolicyNotFound))
+	assert.Equal(t, []string{"early"}, names(store.List()))
+}
+
+func names(policies []Policy) []string {
+	var result []string
+	for _, policy := range policies {
+		result = append(result, policy.Name)
+	}
+	return result
+}
@@ -0,0 +1,573 @@
+// Package qrcode encodes text as QR code symbols (ISO/IEC 18004) and renders them as PNG images.
+//
+// Text is encoded in byte mode at error correction level M, which restores up to about 15% of
+// damaged codewords, in the smallest version (1 to 40) it fits into. The mask pattern is chosen
+// by the penalty rules of the standard, so the symbol scans reliably when printed or displayed.
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// QuietZone is the light border, in modules, that scanners require around a symbol
+const QuietZone = 4
+
+const (
+	minVersion = 1
+	maxVersion = 40
+
+	// formatLevelM is the format information value of error correction level M
+	formatLevelM = 0
+)
+
+// ErrTooLong is returned when text exceeds the capacity of the largest QR code version
+var ErrTooLong = errors.New("text is too long for a QR code")
+
+// eccCodewordsPerBlock is the number of error correction codewords in each block at level M,
+// indexed by version
+var eccCodewordsPerBlock = [maxVersion + 1]int{-1,
+	10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26,
+	26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28,
+}
+
+// errorCorrectionBlocks is the number of blocks the codewords are split into at level M,
+// indexed by version
+var errorCorrectionBlocks = [maxVersion + 1]int{-1,
+	1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16,
+	17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49,
+}
+
+// Code is an encoded QR code symbol
+type Code struct {
+	version int
+	size    int
+	// modules holds the symbol row by row, true is a dark module
+	modules    []bool
+	isFunction []bool
+}
+
+// Encode encodes text into the smallest QR code symbol that holds it
+func Encode(text string) (*Code, error) {
+	data := []byte(text)
+
+	version := minVersion
+	for ; version <= maxVersion; version++ {
+		if dataBits(len(data), version) <= numDataCodewords(version)*8 {
+			break
+		}
+	}
+	if version > maxVersion {
+		return nil, ErrTooLong
+	}
+
+	code := newCode(version)
+	code.drawFunctionPatterns()
+	code.drawCodewords(addErrorCorrection(encodeData(data, version), version))
+
+	bestMask, minPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		code.applyMask(mask)
+		code.drawFormatBits(mask)
+		if penalty := code.penalty(); minPenalty < 0 || penalty < minPenalty {
+			bestMask, minPenalty = mask, penalty
+		}
+		// Masking is an XOR, applying it again restores the unmasked symbol
+		code.applyMask(mask)
+	}
+	code.applyMask(bestMask)
+	code.drawFormatBits(bestMask)
+
+	code.isFunction = nil
+	return code, nil
+}
+
+// Version returns the version of the symbol, 1 to 40
+func (c *Code) Version() int {
+	return c.version
+}
+
+// Size returns the width and height of the symbol in modules
+func (c *Code) Size() int {
+	return c.size
+}
+
+// Dark reports whether the module in column x and row y is dark. Modules outside the symbol
+// are light.
+func (c *Code) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.size || y >= c.size {
+		return false
+	}
+	return c.modules[y*c.size+x]
+}
+
+// Image renders the symbol with scale pixels per module and a light border of border modules
+func (c *Code) Image(scale, border int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+	if border < 0 {
+		border = 0
+	}
+
+	width := (c.size + 2*border) * scale
+	img := image.NewPaletted(image.Rect(0, 0, width, width), color.Palette{color.White, color.Black})
+	for y := 0; y < width; y++ {
+		for x := 0; x < width; x++ {
+			if c.Dark(x/scale-border, y/scale-border) {
+				img.Pix[y*img.Stride+x] = 1
+			}
+		}
+	}
+	return img
+}
+
+// PNG renders the symbol as a PNG image with scale pixels per module and a light border of
+// border modules
+func (c *Code) PNG(scale, border int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.Image(scale, border)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ImageWidth returns the width and height in pixels of the image rendered with scale and border
+func (c *Code) ImageWidth(scale, border int) int {
+	if scale < 1 {
+		scale = 1
+	}
+	if border < 0 {
+		border = 0
+	}
+	return (c.size + 2*border) * scale
+}
+
+// newCode creates an empty symbol of version
+func newCode(version int) *Code {
+	size := version*4 + 17
+	return &Code{
+		version:    version,
+		size:       size,
+		modules:    make([]bool, size*size),
+		isFunction: make([]bool, size*size),
+	}
+}
+
+// characterCountBits returns the length of the byte mode character count indicator
+func characterCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// dataBits returns the number of bits needed to encode length bytes in version
+func dataBits(length, version int) int {
+	if length >= 1<<characterCountBits(version) {
+		return int(^uint(0) >> 1)
+	}
+	return 4 + characterCountBits(version) + 8*length
+}
+
+// numRawDataModules returns the number of modules available for codewords in version, which is
+// everything except the function patterns and the format and version information
+func numRawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		result -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+// numDataCodewords returns the number of data codewords version holds at level M
+func numDataCodewords(version int) int {
+	return numRawDataModules(version)/8 - eccCodewordsPerBlock[version]*errorCorrectionBlocks[version]
+}
+
+// encodeData builds the data codewords of version: the byte mode segment, the terminator and
+// the alternating pad codewords
+func encodeData(data []byte, version int) []byte {
+	var bits bitBuffer
+	bits.append(0x4, 4)
+	bits.append(len(data), characterCountBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+
+	capacity := numDataCodewords(version) * 8
+	terminator := capacity - bits.len()
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	bits.append(0, (8-bits.len()%8)%8)
+	for pad := 0xEC; bits.len() < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	return bits.bytes()
+}
+
+// addErrorCorrection splits the data codewords into blocks, appends the error correction
+// codewords of each block and interleaves the blocks
+func addErrorCorrection(data []byte, version int) []byte {
+	numBlocks := errorCorrectionBlocks[version]
+	eccLength := eccCodewordsPerBlock[version]
+	rawCodewords := numRawDataModules(version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLength := rawCodewords / numBlocks
+
+	divisor := reedSolomonDivisor(eccLength)
+	blocks := make([][]byte, numBlocks)
+	offset := 0
+	for i := range blocks {
+		dataLength := shortBlockLength - eccLength
+		if i >= numShortBlocks {
+			dataLength++
+		}
+		blockData := data[offset : offset+dataLength]
+		offset += dataLength
+
+		block := make([]byte, 0, shortBlockLength+1)
+		block = append(block, blockData...)
+		if i < numShortBlocks {
+			// Short blocks are padded so all blocks interleave by index, the padding is skipped
+			block = append(block, 0)
+		}
+		blocks[i] = append(block, reedSolomonRemainder(blockData, divisor)...)
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			if i != shortBlockLength-eccLength || j >= numShortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+func (c *Code) set(x, y int, dark bool) {
+	c.modules[y*c.size+x] = dark
+}
+
+// setFunction sets a module of a function pattern, which masking and data placement skip
+func (c *Code) setFunction(x, y int, dark bool) {
+	c.set(x, y, dark)
+	c.isFunction[y*c.size+x] = true
+}
+
+// drawFunctionPatterns draws the timing, finder and alignment patterns and reserves the format
+// and version information areas
+func (c *Code) drawFunctionPatterns() {
+	for i := 0; i < c.size; i++ {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+
+	c.drawFinderPattern(3, 3)
+	c.drawFinderPattern(c.size-4, 3)
+	c.drawFinderPattern(3, c.size-4)
+
+	positions := alignmentPatternPositions(c.version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// The corners with finder patterns have no alignment pattern
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			c.drawAlignmentPattern(x, y)
+		}
+	}
+
+	// The format bits are drawn with each mask, the placeholder reserves their modules
+	c.drawFormatBits(0)
+	c.drawVersion()
+}
+
+// drawFinderPattern draws a finder pattern and its separator centred on x, y
+func (c *Code) drawFinderPattern(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || yy < 0 || xx >= c.size || yy >= c.size {
+				continue
+			}
+			distance := max(abs(dx), abs(dy))
+			c.setFunction(xx, yy, distance != 2 && distance != 4)
+		}
+	}
+}
+
+// drawAlignmentPattern draws an alignment pattern centred on x, y
+func (c *Code) drawAlignmentPattern(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// alignmentPatternPositions returns the row and column centres of the alignment patterns
+func alignmentPatternPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+
+	numAlign := version/7 + 2
+	step := 26
+	if version != 32 {
+		step = (version*4 + numAlign*2 + 1) / (numAlign*2 - 2) * 2
+	}
+
+	result := make([]int, numAlign)
+	result[0] = 6
+	for i, pos := numAlign-1, version*4+17-7; i >= 1; i, pos = i-1, pos-step {
+		result[i] = pos
+	}
+	return result
+}
+
+// formatBits returns the 15 format information bits of level M and mask
+func formatBits(mask int) int {
+	data := formatLevelM<<3 | mask
+	remainder := data
+	for i := 0; i < 10; i++ {
+		remainder = (remainder << 1) ^ ((remainder >> 9) * 0x537)
+	}
+	return (data<<10 | remainder) ^ 0x5412
+}
+
+// drawFormatBits draws both copies of the format information of mask
+func (c *Code) drawFormatBits(mask int) {
+	bits := formatBits(mask)
+
+	// Next to the top left finder pattern
+	for i := 0; i <= 5; i++ {
+		c.setFunction(8, i, bit(bits, i))
+	}
+	c.setFunction(8, 7, bit(bits, 6))
+	c.setFunction(8, 8, bit(bits, 7))
+	c.setFunction(7, 8, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		c.setFunction(14-i, 8, bit(bits, i))
+	}
+
+	// Split between the top right and bottom left finder patterns
+	for i := 0; i < 8; i++ {
+		c.setFunction(c.size-1-i, 8, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunction(8, c.size-15+i, bit(bits, i))
+	}
+	// The dark module is always set
+	c.setFunction(8, c.size-8, true)
+}
+
+// drawVersion draws both copies of the version information, which versions 7 and up carry
+func (c *Code) drawVersion() {
+	if c.version < 7 {
+		return
+	}
+
+	remainder := c.version
+	for i := 0; i < 12; i++ {
+		remainder = (remainder << 1) ^ ((remainder >> 11) * 0x1F25)
+	}
+	bits := c.version<<12 | remainder
+
+	for i := 0; i < 18; i++ {
+		a, b := c.size-11+i%3, i/3
+		c.setFunction(a, b, bit(bits, i))
+		c.setFunction(b, a, bit(bits, i))
+	}
+}
+
+// drawCodewords places the codewords in the two module wide columns zigzagging up and down
+// from the bottom right corner, skipping function patterns
+func (c *Code) drawCodewords(codewords []byte) {
+	i := 0
+	for right := c.size - 1; right >= 1; right -= 2 {
+		// The vertical timing pattern is skipped as a whole column
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if upward {
+					y = c.size - 1 - vert
+				}
+				if c.isFunction[y*c.size+x] || i >= len(codewords)*8 {
+					continue
+				}
+				c.set(x, y, bit(int(codewords[i>>3]), 7-i&7))
+				i++
+			}
+		}
+	}
+}
+
+// applyMask inverts the data modules selected by mask
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.size; y++ {
+		for x := 0; x < c.size; x++ {
+			if !c.isFunction[y*c.size+x] && maskSelects(mask, x, y) {
+				c.modules[y*c.size+x] = !c.modules[y*c.size+x]
+			}
+		}
+	}
+}
+
+// maskSelects reports whether mask inverts the module in column x and row y
+func maskSelects(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// finderLikePatterns are the dark-light runs that resemble a finder pattern, with four light
+// modules on either side
+var finderLikePatterns = [][]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// penalty scores the symbol by the mask evaluation rules of the standard; lower is better
+func (c *Code) penalty() int {
+	result := 0
+
+	// Runs of five or more modules of the same colour and finder-like patterns
+	for _, line := range c.lines() {
+		run := 1
+		for i := 1; i <= len(line); i++ {
+			if i < len(line) && line[i] == line[i-1] {
+				run++
+				continue
+			}
+			if run >= 5 {
+				result += 3 + run - 5
+			}
+			run = 1
+		}
+		for i := 0; i+11 <= len(line); i++ {
+			for _, pattern := range finderLikePatterns {
+				if equalModules(line[i:i+11], pattern) {
+					result += 40
+				}
+			}
+		}
+	}
+
+	// Blocks of 2x2 modules of the same colour
+	for y := 0; y < c.size-1; y++ {
+		for x := 0; x < c.size-1; x++ {
+			dark := c.Dark(x, y)
+			if dark == c.Dark(x+1, y) && dark == c.Dark(x, y+1) && dark == c.Dark(x+1, y+1) {
+				result += 3
+			}
+		}
+	}
+
+	// Deviation of the proportion of dark modules from half, in steps of 5%
+	dark := 0
+	for _, module := range c.modules {
+		if module {
+			dark++
+		}
+	}
+	total := len(c.modules)
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	result += k * 10
+
+	return result
+}
+
+// lines returns the rows and the columns of the symbol
+func (c *Code) lines() [][]bool {
+	lines := make([][]bool, 0, 2*c.size)
+	for y := 0; y < c.size; y++ {
+		lines = append(lines, c.modules[y*c.size:(y+1)*c.size])
+	}
+	for x := 0; x < c.size; x++ {
+		column := make([]bool, c.size)
+		for y := range column {
+			column[y] = c.modules[y*c.size+x]
+		}
+		lines = append(lines, column)
+	}
+	return lines
+}
+
+func equalModules(a, b []bool) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// bitBuffer collects bits most significant first
+type bitBuffer struct {
+	bits []bool
+}
+
+// append appends the low length bits of value
+func (b *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		b.bits = append(b.bits, bit(value, i))
+	}
+}
+
+func (b *bitBuffer) len() int {
+	return len(b.bits)
+}
+
+// bytes packs the bits, whose length is a multiple of 8, into bytes
+func (b *bitBuffer) bytes() []byte {
+	result := make([]byte, len(b.bits)/8)
+	for i, set := range b.bits {
+		if set {
+			result[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return result
+}
+
+// bit reports whether bit i of value is set
+func bit(value, i int) bool {
+	return (value>>i)&1 != 0
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,238 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReedSolomonRemainder(t *testing.T) {
+	// The version 1-M codewords of "HELLO WORLD" from the worked example of the standard
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	expected := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	assert.Equal(t, expected, reedSolomonRemainder(data, reedSolomonDivisor(10)))
+}
+
+func TestFormatBits(t *testing.T) {
+	expected := []int{
+		0b101010000010010, 0b101000100100101, 0b101111001111100, 0b101101101001011,
+		0b100010111111001, 0b100000011001110, 0b100111110010111, 0b100101010100000,
+	}
+	for mask, bits := range expected {
+		assert.Equal(t, bits, formatBits(mask), "mask %d", mask)
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	code := newCode(7)
+	code.drawVersion()
+
+	// 000111110010010100 is the version information of version 7
+	bits := 0
+	for i := 17; i >= 0; i-- {
+		bits <<= 1
+		if code.Dark(code.size-11+i%3, i/3) {
+			bits |= 1
+		}
+	}
+	assert.Equal(t, 0b000111110010010100, bits)
+}
+
+func TestCapacity(t *testing.T) {
+	assert.Equal(t, 16, numDataCodewords(1))
+	assert.Equal(t, 154, numDataCodewords(8))
+	assert.Equal(t, 2334, numDataCodewords(40))
+
+	tests := []struct {
+		length  int
+		version int
+	}{
+		{1, 1},
+		{14, 1},
+		{15, 2},
+		{180, 9},
+		{2331, 40},
+	}
+	for _, tt := range tests {
+		code, err := Encode(strings.Repeat("a", tt.length))
+		require.NoError(t, err)
+		assert.Equal(t, tt.version, code.Version(), "length %d", tt.length)
+		assert.Equal(t, tt.version*4+17, code.Size())
+	}
+
+	_, err := Encode(strings.Repeat("a", 2332))
+	assert.ErrorIs(t, err, ErrTooLong)
+}
+
+func TestAlignmentPatternPositions(t *testing.T) {
+	assert.Empty(t, alignmentPatternPositions(1))
+	assert.Equal(t, []int{6, 18}, alignmentPatternPositions(2))
+	assert.Equal(t, []int{6, 22, 38}, alignmentPatternPositions(7))
+	assert.Equal(t, []int{6, 34, 60, 86, 112, 138}, alignmentPatternPositions(32))
+	assert.Equal(t, []int{6, 30, 58, 86, 114, 142, 170}, alignmentPatternPositions(40))
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	texts := []string{
+		"https://example.com/tickets/42",
+		"https://example.com/receipts/" + strings.Repeat("x", 120) + "?order=9f8e7d6c&lang=de",
+		"Grüße, 你好 🎟",
+		strings.Repeat("https://example.com/", 40),
+	}
+	for _, text := range texts {
+		code, err := Encode(text)
+		require.NoError(t, err)
+		assert.Equal(t, text, decode(t, code))
+	}
+}
+
+func TestFinderPatterns(t *testing.T) {
+	code, err := Encode("https://example.com")
+	require.NoError(t, err)
+
+	for _, corner := range [][2]int{{0, 0}, {code.Size() - 7, 0}, {0, code.Size() - 7}} {
+		for dy := 0; dy < 7; dy++ {
+			for dx := 0; dx < 7; dx++ {
+				ring := max(abs(dx-3), abs(dy-3))
+				assert.Equal(t, ring != 2, code.Dark(corner[0]+dx, corner[1]+dy))
+			}
+		}
+	}
+}
+
+func TestPNG(t *testing.T) {
+	code, err := Encode("https://example.com/tickets/42")
+	require.NoError(t, err)
+
+	content, err := code.PNG(4, QuietZone)
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(content))
+	require.NoError(t, err)
+	width := code.ImageWidth(4, QuietZone)
+	assert.Equal(t, (code.Size()+2*QuietZone)*4, width)
+	assert.Equal(t, width, img.Bounds().Dx())
+	assert.Equal(t, width, img.Bounds().Dy())
+
+	// The quiet zone is light and the top left finder pattern starts dark
+	r, _, _, _ := img.At(0, 0).RGBA()
+	assert.Equal(t, uint32(0xffff), r)
+	r, _, _, _ = img.At(QuietZone*4, QuietZone*4).RGBA()
+	assert.Equal(t, uint32(0), r)
+}
+
+// decode reads the text back from a symbol: it reads the mask from the format information,
+// removes it, collects the codewords, undoes the interleaving and parses the byte mode segment
+func decode(t *testing.T, code *Code) string {
+	t.Helper()
+
+	format := 0
+	for i := 14; i >= 9; i-- {
+		format = format<<1 | boolBit(code.Dark(14-i, 8))
+	}
+	format = format<<1 | boolBit(code.Dark(7, 8))
+	format = format<<1 | boolBit(code.Dark(8, 8))
+	format = format<<1 | boolBit(code.Dark(8, 7))
+	for i := 5; i >= 0; i-- {
+		format = format<<1 | boolBit(code.Dark(8, i))
+	}
+	mask := -1
+	for candidate := 0; candidate < 8; candidate++ {
+		if formatBits(candidate) == format {
+			mask = candidate
+		}
+	}
+	require.NotEqual(t, -1, mask, "format information %015b", format)
+
+	reference := newCode(code.Version())
+	reference.drawFunctionPatterns()
+
+	var bits []bool
+	size := code.Size()
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = size - 1 - vert
+				}
+				if reference.isFunction[y*size+x] {
+					continue
+				}
+				bits = append(bits, code.Dark(x, y) != maskSelects(mask, x, y))
+			}
+		}
+	}
+
+	version := code.Version()
+	rawCodewords := numRawDataModules(version) / 8
+	require.GreaterOrEqual(t, len(bits), rawCodewords*8)
+	codewords := make([]byte, rawCodewords)
+	for i := range codewords {
+		for j := 0; j < 8; j++ {
+			codewords[i] = codewords[i]<<1 | byte(boolBit(bits[i*8+j]))
+		}
+	}
+
+	numBlocks := errorCorrectionBlocks[version]
+	eccLength := eccCodewordsPerBlock[version]
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortDataLength := rawCodewords/numBlocks - eccLength
+	blocks := make([][]byte, numBlocks)
+	next := 0
+	for i := 0; i <= shortDataLength; i++ {
+		for j := range blocks {
+			if i == shortDataLength && j < numShortBlocks {
+				continue
+			}
+			blocks[j] = append(blocks[j], codewords[next])
+			next++
+		}
+	}
+
+	divisor := reedSolomonDivisor(eccLength)
+	var data []byte
+	for j, block := range blocks {
+		ecc := make([]byte, eccLength)
+		for i := range ecc {
+			ecc[i] = codewords[next+i*numBlocks+j]
+		}
+		require.Equal(t, reedSolomonRemainder(block, divisor), ecc, "block %d", j)
+		data = append(data, block...)
+	}
+
+	reader := bitBuffer{}
+	for _, b := range data {
+		reader.append(int(b), 8)
+	}
+	read := func(offset, length int) int {
+		value := 0
+		for _, set := range reader.bits[offset : offset+length] {
+			value = value<<1 | boolBit(set)
+		}
+		return value
+	}
+	require.Equal(t, 0x4, read(0, 4), "byte mode")
+	countBits := characterCountBits(version)
+	length := read(4, countBits)
+	text := make([]byte, length)
+	for i := range text {
+		text[i] = byte(read(4+countBits+i*8, 8))
+	}
+	return string(text)
+}
+
+func boolBit(set bool) int {
+	if set {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gaurav2721/notification-service/admin"
+	"github.com/gaurav2721/notification-service/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAdminRoutes configures the admin API routes backing the dashboard.
+// The given middleware (e.g. the admin IP filter) runs before the handlers.
+func SetupAdminRoutes(api *gin.RouterGroup, handler *handlers.NotificationHandler, middleware ...gin.HandlerFunc) {
+	admin := api.Group("/admin", middleware...)
+	admin.GET("/overview", handler.GetAdminOverview)
+	admin.GET("/concurrency", handler.GetProviderConcurrency)
+	admin.PUT("/concurrency", handler.UpdateProviderConcurrency)
+	admin.POST("/replay", handler.ReplayQueueMessages)
+	admin.GET("/dead-letters", handler.ListDeadLetters)
+	admin.POST("/dead-letters/:deadLetterId/replay", handler.ReplayDeadLetter)
+	admin.DELETE("/dead-letters/:deadLetterId", handler.DiscardDeadLetter)
+	admin.GET("/email/domain-check", handler.CheckEmailDomain)
+	admin.GET("/policies", handler.ListRoutingPolicies)
+	admin.POST("/policies", handler.CreateRoutingPolicy)
+	admin.POST("/policies/evaluate", handler.EvaluateRoutingPolicies)
+	admin.GET("/policies/:policyId", handler.GetRoutingPolicy)
+	admin.PUT("/policies/:policyId", handler.UpdateRoutingPolicy)
+	admin.DELETE("/policies/:policyId", handler.DeleteRoutingPolicy)
+}
+
+// SetupAdminUIRoutes serves the embedded admin dashboard at /admin.
+// The page itself is public; it asks for the API key and sends it with every API call.
+func SetupAdminUIRoutes(router *gin.Engine, middleware ...gin.HandlerFunc) {
+	ui := router.Group("", middleware...)
+	ui.GET("/admin", func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, "/admin/")
+	})
+	ui.StaticFS("/admin/", admin.FileSystem())
+}
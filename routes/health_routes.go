@@ -6,7 +6,21 @@ import (
 )
 
 // SetupHealthRoutes configures health check routes
-func SetupHealthRoutes(router *gin.Engine, handler *handlers.NotificationHandler) {
+func SetupHealthRoutes(router *gin.Engine, handler *handlers.NotificationHandler, healthHandler *handlers.HealthHandler) {
 	// Health check endpoint
 	router.GET("/health", handler.HealthCheck)
+
+	// Kubernetes probes
+	SetupProbeRoutes(router, healthHandler)
+
+	// Metrics endpoint in the Prometheus text format
+	router.GET("/metrics", handler.Metrics)
+}
+
+// SetupProbeRoutes configures the Kubernetes liveness, readiness and startup probes. They are
+// served on their own while the services start.
+func SetupProbeRoutes(router *gin.Engine, handler *handlers.HealthHandler) {
+	router.GET("/livez", handler.Livez)
+	router.GET("/readyz", handler.Readyz)
+	router.GET("/startupz", handler.Startupz)
 }
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/gaurav2721/notification-service/handlers"
+	"github.com/gaurav2721/notification-service/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupInboxRoutes configures the in-app inbox and notification preference routes
+func SetupInboxRoutes(api *gin.RouterGroup, handler *handlers.NotificationHandler) {
+	// Create validation layer
+	validationLayer := validation.NewValidationLayer()
+
+	// Inbox endpoints
+	api.GET("/inbox/:userId", handler.GetInbox)
+	api.POST("/inbox/:userId/items/:itemId/read", handler.MarkInboxItemRead)
+	api.POST("/inbox/:userId/items/:itemId/actions/:actionId", handler.ActOnInboxItem)
+	api.POST("/inbox/:userId/items/:itemId/archive", handler.ArchiveInboxItem)
+	api.POST("/inbox/:userId/items/:itemId/unarchive", handler.UnarchiveInboxItem)
+
+	// Digest and quiet hour preferences
+	api.GET("/inbox/:userId/preferences", handler.GetPreferences)
+	api.PUT("/inbox/:userId/preferences",
+		validationLayer.ValidatePreferencesRequest(),
+		handler.UpdatePreferences)
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/gaurav2721/notification-service/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupLoggingRoutes configures the runtime logging configuration routes
+// under /admin; the given middleware (the admin IP filter) runs before the handlers.
+func SetupLoggingRoutes(api *gin.RouterGroup, handler *handlers.NotificationHandler, middleware ...gin.HandlerFunc) {
+	admin := api.Group("/admin", middleware...)
+	admin.GET("/logging", handler.GetLoggingSettings)
+	admin.PUT("/logging", handler.UpdateLoggingSettings)
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/gaurav2721/notification-service/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupMediaRoutes configures the routes of the media assets notifications reference
+func SetupMediaRoutes(api *gin.RouterGroup, handler *handlers.NotificationHandler) {
+	media := api.Group("/media")
+	{
+		media.POST("", handler.UploadMedia)
+		media.GET("", handler.ListMedia)
+		media.GET("/:mediaId", handler.GetMedia)
+		media.DELETE("/:mediaId", handler.DeleteMedia)
+	}
+}
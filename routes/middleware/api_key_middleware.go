@@ -5,18 +5,23 @@ import (
 	"os"
 	"strings"
 
+	"github.com/gaurav2721/notification-service/auth"
 	"github.com/gaurav2721/notification-service/constants"
 	"github.com/gin-gonic/gin"
 )
 
-// APIKeyMiddleware validates API keys for protected routes
+// APIKeyMiddleware validates API keys for protected routes.
+// Besides the shared API_KEY, API_KEYS may list named keys as name:key pairs; the name of the
+// matching key is stored as the request's principal (see auth.Principal).
 func APIKeyMiddleware() gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
-		// Get API key from environment variable
+		// Get API keys from environment variables
 		expectedAPIKey := os.Getenv(constants.API_KEY)
+		namedAPIKeys := parseNamedAPIKeys(os.Getenv(constants.API_KEYS))
 
-		if expectedAPIKey == "" {
+		if expectedAPIKey == "" && len(namedAPIKeys) == 0 {
 			// If no API key is configured, allow the request (for development)
+			auth.SetPrincipal(c, auth.AnonymousPrincipal)
 			c.Next()
 			return
 		}
@@ -45,7 +50,11 @@ func APIKeyMiddleware() gin.HandlerFunc {
 		}
 
 		// Validate API key
-		if providedAPIKey != expectedAPIKey {
+		principal, valid := namedAPIKeys[providedAPIKey]
+		if !valid && expectedAPIKey != "" && providedAPIKey == expectedAPIKey {
+			principal, valid = auth.DefaultAPIKeyPrincipal, true
+		}
+		if !valid {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error":   "Invalid API key",
 				"message": "The provided API key is invalid",
@@ -55,6 +64,22 @@ func APIKeyMiddleware() gin.HandlerFunc {
 		}
 
 		// API key is valid, proceed
+		auth.SetPrincipal(c, principal)
 		c.Next()
 	})
 }
+
+// parseNamedAPIKeys parses comma separated name:key pairs into a map from key to name.
+// Malformed pairs are ignored.
+func parseNamedAPIKeys(value string) map[string]string {
+	keys := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		name, key, found := strings.Cut(strings.TrimSpace(pair), ":")
+		name, key = strings.TrimSpace(name), strings.TrimSpace(key)
+		if !found || name == "" || key == "" {
+			continue
+		}
+		keys[key] = name
+	}
+	return keys
+}
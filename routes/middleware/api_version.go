@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// API versions served under /api/<version>
+const (
+	// APIVersionV1 responds with bare bodies and processes sends before responding
+	APIVersionV1 = "v1"
+	// APIVersionV2 wraps every JSON response in an Envelope and accepts sends asynchronously
+	APIVersionV2 = "v2"
+
+	// APIVersionContextKey is the gin context key holding the API version of the request
+	APIVersionContextKey = "api_version"
+	// APIVersionHeader tells clients which API version answered
+	APIVersionHeader = "API-Version"
+
+	// errorCodeContextKey holds the error code a handler chose for its error response
+	errorCodeContextKey = "api_error_code"
+)
+
+// Error codes of v2 error responses derived from the status code. Handlers can report more
+// specific codes with SetErrorCode.
+const (
+	ErrorCodeInvalidRequest   = "invalid_request"
+	ErrorCodeUnauthorized     = "unauthorized"
+	ErrorCodeForbidden        = "forbidden"
+	ErrorCodeNotFound         = "not_found"
+	ErrorCodeConflict         = "conflict"
+	ErrorCodePayloadTooLarge  = "payload_too_large"
+	ErrorCodeUnprocessable    = "unprocessable"
+	ErrorCodeRateLimited      = "rate_limited"
+	ErrorCodeInternal         = "internal_error"
+	ErrorCodeUnavailable      = "unavailable"
+	ErrorCodeDeadlineExceeded = "deadline_exceeded"
+)
+
+// Envelope is the body of every JSON response of the v2 API: the v1 body as data on success,
+// a typed error otherwise
+type Envelope struct {
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error *APIError       `json:"error,omitempty"`
+	Meta  EnvelopeMeta    `json:"meta"`
+}
+
+// APIError is the error of a v2 response. Message is the error of the v1 body; the other fields
+// of the v1 body, such as validation details, are kept in Details.
+type APIError struct {
+	Code    string                     `json:"code"`
+	Message string                     `json:"message"`
+	Details map[string]json.RawMessage `json:"details,omitempty"`
+}
+
+// EnvelopeMeta describes the response
+type EnvelopeMeta struct {
+	APIVersion string `json:"api_version"`
+}
+
+// APIVersionMiddleware records the API version of a route group so shared handlers can tell the
+// versions apart (see APIVersion), and reports it in the API-Version response header
+func APIVersionMiddleware(version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(APIVersionContextKey, version)
+		c.Header(APIVersionHeader, version)
+		c.Next()
+	}
+}
+
+// APIVersion returns the API version of the request; routes outside the versioned API are v1
+func APIVersion(c *gin.Context) string {
+	if version := c.GetString(APIVersionContextKey); version != "" {
+		return version
+	}
+	return APIVersionV1
+}
+
+// SetErrorCode sets the code of the error response of the request, for errors more specific than
+// their status code. v1 responses do not include it.
+func SetErrorCode(c *gin.Context, code string) {
+	c.Set(errorCodeContextKey, code)
+}
+
+// EnvelopeMiddleware wraps the JSON responses of the handlers after it in an Envelope, so v2
+// handlers share the v1 ones. Responses of other content types, such as the NDJSON device
+// export, and empty responses are passed through untouched.
+func EnvelopeMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := &envelopeResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if writer.passThrough || writer.body.Len() == 0 {
+			return
+		}
+
+		status := c.Writer.Status()
+		envelope := Envelope{Meta: EnvelopeMeta{APIVersion: APIVersion(c)}}
+		if status < http.StatusBadRequest {
+			envelope.Data = json.RawMessage(writer.body.Bytes())
+		} else {
+			envelope.Error = newAPIError(c, status, writer.body.Bytes())
+		}
+
+		body, err := json.Marshal(envelope)
+		if err != nil {
+			// The handler wrote invalid JSON; send it as it is rather than failing the request
+			logrus.WithError(err).WithField("path", c.Request.URL.Path).Warn("Failed to wrap response in envelope")
+			body = writer.body.Bytes()
+		}
+		c.Writer.Header().Del("Content-Length")
+		c.Writer.Write(body)
+	}
+}
+
+// newAPIError converts a v1 error body, {"error": "...", ...}, into the error of an envelope
+func newAPIError(c *gin.Context, status int, body []byte) *APIError {
+	apiError := &APIError{Code: c.GetString(errorCodeContextKey)}
+	if apiError.Code == "" {
+		apiError.Code = errorCodeForStatus(status)
+	}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) != nil || json.Unmarshal(fields["error"], &apiError.Message) != nil {
+		apiError.Message = http.StatusText(status)
+	}
+	delete(fields, "error")
+	if len(fields) > 0 {
+		apiError.Details = fields
+	}
+	return apiError
+}
+
+// errorCodeForStatus returns the error code of an error status
+func errorCodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return ErrorCodeInvalidRequest
+	case http.StatusUnauthorized:
+		return ErrorCodeUnauthorized
+	case http.StatusForbidden:
+		return ErrorCodeForbidden
+	case http.StatusNotFound:
+		return ErrorCodeNotFound
+	case http.StatusConflict:
+		return ErrorCodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return ErrorCodePayloadTooLarge
+	case http.StatusUnprocessableEntity:
+		return ErrorCodeUnprocessable
+	case http.StatusTooManyRequests:
+		return ErrorCodeRateLimited
+	case http.StatusServiceUnavailable:
+		return ErrorCodeUnavailable
+	}
+	if status < http.StatusInternalServerError {
+		return ErrorCodeInvalidRequest
+	}
+	return ErrorCodeInternal
+}
+
+// envelopeResponseWriter holds back JSON response bodies until the handler has finished and
+// forwards other bodies as they are written, so streamed responses keep streaming
+type envelopeResponseWriter struct {
+	gin.ResponseWriter
+	body        bytes.Buffer
+	passThrough bool
+	decided     bool
+}
+
+// Write buffers JSON data and forwards anything else
+func (w *envelopeResponseWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.decided = true
+		w.passThrough = !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+	}
+	if w.passThrough {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+// WriteString buffers JSON s and forwards anything else
+func (w *envelopeResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush forwards flushes of passed through responses
+func (w *envelopeResponseWriter) Flush() {
+	if w.passThrough {
+		w.ResponseWriter.Flush()
+	}
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newVersionedTestRouter serves the same handlers under /api/v1 and, in envelopes, /api/v2
+func newVersionedTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	for _, version := range []string{APIVersionV1, APIVersionV2} {
+		api := router.Group("/api/"+version, APIVersionMiddleware(version))
+		if version != APIVersionV1 {
+			api.Use(EnvelopeMiddleware())
+		}
+		api.GET("/items/:id", func(c *gin.Context) {
+			if c.Param("id") == "missing" {
+				c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"id": c.Param("id"), "version": APIVersion(c)})
+		})
+		api.POST("/items", func(c *gin.Context) {
+			SetErrorCode(c, "duplicate_item")
+			c.JSON(http.StatusConflict, gin.H{"error": "duplicate item", "existing_id": "item-1"})
+		})
+		api.GET("/export", func(c *gin.Context) {
+			c.Header("Content-Type", "application/x-ndjson")
+			c.String(http.StatusOK, "{\"id\":\"item-1\"}\n")
+		})
+		api.GET("/etag", ETagMiddleware(), func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"id": "item-1"})
+		})
+	}
+	return router
+}
+
+func TestEnvelopeMiddleware(t *testing.T) {
+	router := newVersionedTestRouter()
+
+	// v1 responses are the bare bodies
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/items/item-1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, APIVersionV1, w.Header().Get(APIVersionHeader))
+	assert.JSONEq(t, `{"id":"item-1","version":"v1"}`, w.Body.String())
+
+	// v2 wraps the same body as data
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/items/item-1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, APIVersionV2, w.Header().Get(APIVersionHeader))
+	assert.JSONEq(t, `{"data":{"id":"item-1","version":"v2"},"meta":{"api_version":"v2"}}`, w.Body.String())
+
+	// Errors get a code derived from the status
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/items/missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"error":{"code":"not_found","message":"item not found"},"meta":{"api_version":"v2"}}`, w.Body.String())
+
+	// or the one set by the handler, with the other fields as details
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v2/items", nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var envelope Envelope
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &envelope))
+	require.NotNil(t, envelope.Error)
+	assert.Equal(t, "duplicate_item", envelope.Error.Code)
+	assert.Equal(t, "duplicate item", envelope.Error.Message)
+	assert.JSONEq(t, `"item-1"`, string(envelope.Error.Details["existing_id"]))
+	assert.Nil(t, envelope.Data)
+
+	// v1 error bodies are unchanged
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/items", nil))
+	assert.JSONEq(t, `{"error":"duplicate item","existing_id":"item-1"}`, w.Body.String())
+
+	// Other content types pass through
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/export", nil))
+	assert.Equal(t, "{\"id\":\"item-1\"}\n", w.Body.String())
+}
+
+func TestEnvelopeMiddleware_ETag(t *testing.T) {
+	router := newVersionedTestRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/etag", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.JSONEq(t, `{"data":{"id":"item-1"},"meta":{"api_version":"v2"}}`, w.Body.String())
+
+	// Not modified responses have no body to wrap
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/etag", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// BodyLimitMiddleware rejects request bodies larger than maxBytes with 413 Request Entity Too Large.
+// Requests announcing a larger Content-Length are rejected before the body is read; bodies without
+// a length are cut off at the limit, which makes reading them fail. Handlers answer such a read
+// error as a bad request, so a 400 written after the limit was hit is turned into a 413.
+func BodyLimitMiddleware(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > maxBytes {
+			rejectTooLarge(c, c.Request.ContentLength, maxBytes)
+			c.Abort()
+			return
+		}
+
+		if c.Request.Body != nil {
+			body := &limitedBody{ReadCloser: http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)}
+			c.Request.Body = body
+			c.Writer = &limitedBodyResponseWriter{ResponseWriter: c.Writer, body: body}
+		}
+
+		c.Next()
+	}
+}
+
+func rejectTooLarge(c *gin.Context, contentLength, maxBytes int64) {
+	logrus.WithFields(logrus.Fields{
+		"path":           c.Request.URL.Path,
+		"content_length": contentLength,
+		"max_bytes":      maxBytes,
+	}).Warn("Request body too large")
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error":     "Request body too large",
+		"message":   "The request body exceeds the maximum allowed size",
+		"max_bytes": maxBytes,
+	})
+}
+
+// limitedBody remembers whether reading the request body ran into the size limit
+type limitedBody struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+// Read reads from the limited body and records a read past the limit
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// limitedBodyResponseWriter answers with 413 instead of 400 once the request body was cut off
+type limitedBodyResponseWriter struct {
+	gin.ResponseWriter
+	body *limitedBody
+}
+
+// WriteHeader replaces a 400 caused by a body cut off at the limit with a 413
+func (w *limitedBodyResponseWriter) WriteHeader(code int) {
+	if code == http.StatusBadRequest && w.body.exceeded {
+		code = http.StatusRequestEntityTooLarge
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
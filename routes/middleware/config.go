@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gaurav2721/notification-service/constants"
+	"github.com/sirupsen/logrus"
+)
+
+// Config holds the toggles of the HTTP middleware chain
+type Config struct {
+	// CORSEnabled adds CORS headers and answers preflight requests
+	CORSEnabled        bool
+	CORSAllowedOrigins []string
+	CORSAllowedMethods []string
+	CORSAllowedHeaders []string
+	CORSMaxAge         time.Duration
+
+	// MaxRequestBodyBytes rejects larger request bodies with 413; 0 disables the limit
+	MaxRequestBodyBytes int64
+
+	// GzipEnabled compresses responses for clients sending Accept-Encoding: gzip
+	GzipEnabled bool
+	GzipLevel   int
+
+	// IdempotencyKeyTTL is how long responses to requests with an Idempotency-Key are replayed
+	IdempotencyKeyTTL time.Duration
+
+	// IP addresses or CIDR ranges allowed to call /api/v1 and the admin routes; empty allows all.
+	// The deny lists take precedence over the allow lists.
+	APIAllowedIPs   []string
+	APIDeniedIPs    []string
+	AdminAllowedIPs []string
+	AdminDeniedIPs  []string
+
+	// TrustedProxies may set X-Forwarded-For/X-Real-IP; with none the connection's address is used
+	TrustedProxies []string
+
+	// RequestTimeout bounds the time a handler may take before it is answered with 503; 0 disables it
+	RequestTimeout time.Duration
+}
+
+// DefaultConfig returns the middleware configuration used when no environment overrides are set
+func DefaultConfig() Config {
+	return Config{
+		CORSEnabled:         false,
+		CORSAllowedOrigins:  splitList(constants.DefaultCORSAllowedOrigins),
+		CORSAllowedMethods:  splitList(constants.DefaultCORSAllowedMethods),
+		CORSAllowedHeaders:  splitList(constants.DefaultCORSAllowedHeaders),
+		CORSMaxAge:          time.Duration(constants.DefaultCORSMaxAgeSeconds) * time.Second,
+		MaxRequestBodyBytes: constants.DefaultMaxRequestBodyBytes,
+		GzipEnabled:         true,
+		GzipLevel:           constants.DefaultGzipLevel,
+		IdempotencyKeyTTL:   time.Duration(constants.DefaultIdempotencyKeyTTL) * time.Second,
+		RequestTimeout:      time.Duration(constants.DefaultRequestTimeoutSeconds) * time.Second,
+	}
+}
+
+// LoadConfigFromEnv reads the middleware configuration from environment variables
+func LoadConfigFromEnv() Config {
+	config := DefaultConfig()
+
+	config.CORSEnabled = getEnvAsBool(constants.CORSEnabledEnvVar, config.CORSEnabled)
+	if origins := os.Getenv(constants.CORSAllowedOriginsEnvVar); origins != "" {
+		config.CORSAllowedOrigins = splitList(origins)
+	}
+	if methods := os.Getenv(constants.CORSAllowedMethodsEnvVar); methods != "" {
+		config.CORSAllowedMethods = splitList(methods)
+	}
+	if headers := os.Getenv(constants.CORSAllowedHeadersEnvVar); headers != "" {
+		config.CORSAllowedHeaders = splitList(headers)
+	}
+	if maxAge, ok := getEnvAsInt64(constants.CORSMaxAgeSecondsEnvVar); ok && maxAge >= 0 {
+		config.CORSMaxAge = time.Duration(maxAge) * time.Second
+	}
+
+	if maxBytes, ok := getEnvAsInt64(constants.MaxRequestBodyBytesEnvVar); ok && maxBytes >= 0 {
+		config.MaxRequestBodyBytes = maxBytes
+	}
+
+	config.GzipEnabled = getEnvAsBool(constants.GzipEnabledEnvVar, config.GzipEnabled)
+	if level, ok := getEnvAsInt64(constants.GzipLevelEnvVar); ok {
+		if level >= gzip.BestSpeed && level <= gzip.BestCompression {
+			config.GzipLevel = int(level)
+		} else {
+			logrus.WithField("level", level).Warn("Invalid gzip level, using default")
+		}
+	}
+
+	if ttl, ok := getEnvAsInt64(constants.IdempotencyKeyTTLEnvVar); ok && ttl > 0 {
+		config.IdempotencyKeyTTL = time.Duration(ttl) * time.Second
+	}
+
+	config.APIAllowedIPs = splitList(os.Getenv(constants.APIAllowedIPsEnvVar))
+	config.APIDeniedIPs = splitList(os.Getenv(constants.APIDeniedIPsEnvVar))
+	config.AdminAllowedIPs = splitList(os.Getenv(constants.AdminAllowedIPsEnvVar))
+	config.AdminDeniedIPs = splitList(os.Getenv(constants.AdminDeniedIPsEnvVar))
+	config.TrustedProxies = splitList(os.Getenv(constants.TrustedProxiesEnvVar))
+
+	if timeout, ok := getEnvAsInt64(constants.RequestTimeoutSecondsEnvVar); ok && timeout >= 0 {
+		config.RequestTimeout = time.Duration(timeout) * time.Second
+	}
+
+	return config
+}
+
+// splitList splits a comma separated list and drops empty entries
+func splitList(value string) []string {
+	parts := strings.Split(value, ",")
+	list := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	return list
+}
+
+// getEnvAsBool reads a boolean environment variable, returning the fallback when unset or invalid
+func getEnvAsBool(key string, fallback bool) bool {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return fallback
+	}
+
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"key":   key,
+			"value": valueStr,
+		}).Warn("Invalid boolean environment variable, using default")
+		return fallback
+	}
+
+	return value
+}
+
+// getEnvAsInt64 reads an integer environment variable; ok is false when unset or invalid
+func getEnvAsInt64(key string) (int64, bool) {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return 0, false
+	}
+
+	value, err := strconv.ParseInt(valueStr, 10, 64)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"key":   key,
+			"value": valueStr,
+		}).Warn("Invalid integer environment variable, using default")
+		return 0, false
+	}
+
+	return value, true
+}
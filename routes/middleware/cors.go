@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSMiddleware adds CORS headers for allowed origins and answers preflight requests
+func CORSMiddleware(config Config) gin.HandlerFunc {
+	allowAll := false
+	allowed := make(map[string]bool, len(config.CORSAllowedOrigins))
+	for _, origin := range config.CORSAllowedOrigins {
+		if origin == "*" {
+			allowAll = true
+		}
+		allowed[strings.ToLower(origin)] = true
+	}
+
+	allowMethods := strings.Join(config.CORSAllowedMethods, ", ")
+	allowHeaders := strings.Join(config.CORSAllowedHeaders, ", ")
+	maxAge := strconv.Itoa(int(config.CORSMaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			// Not a cross-origin request
+			c.Next()
+			return
+		}
+
+		if !allowAll && !allowed[strings.ToLower(origin)] {
+			if c.Request.Method == http.MethodOptions {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"error":   "Origin not allowed",
+					"message": "The request origin is not allowed by the CORS policy",
+				})
+				return
+			}
+			// Let the request through without CORS headers; the browser blocks the response
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		if allowAll {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+			header.Add("Vary", "Origin")
+		}
+
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			header.Set("Access-Control-Allow-Methods", allowMethods)
+			header.Set("Access-Control-Allow-Headers", allowHeaders)
+			header.Set("Access-Control-Max-Age", maxAge)
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GzipMiddleware compresses responses for clients that accept gzip.
+// Compression starts with the first body write, so empty responses such as 204 stay empty.
+func GzipMiddleware(level int) gin.HandlerFunc {
+	pool := &sync.Pool{
+		New: func() interface{} {
+			writer, err := gzip.NewWriterLevel(io.Discard, level)
+			if err != nil {
+				writer = gzip.NewWriter(io.Discard)
+			}
+			return writer
+		},
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead || !acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Next()
+			return
+		}
+
+		writer := &gzipResponseWriter{ResponseWriter: c.Writer, pool: pool}
+		c.Writer = writer
+		defer writer.close()
+
+		c.Header("Vary", "Accept-Encoding")
+		c.Next()
+	}
+}
+
+// acceptsGzip checks the Accept-Encoding header for gzip
+func acceptsGzip(acceptEncoding string) bool {
+	for _, encoding := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if strings.EqualFold(strings.TrimSpace(name), "gzip") {
+			return strings.ReplaceAll(params, " ", "") != "q=0"
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter compresses everything written to the wrapped gin writer
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	pool   *sync.Pool
+	writer *gzip.Writer
+}
+
+// WriteHeader drops Content-Length, which no longer matches the compressed body
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write compresses data, starting the gzip stream on the first call
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	if w.writer == nil {
+		// Responses that are already encoded by the handler are passed through
+		if w.Header().Get("Content-Encoding") != "" {
+			return w.ResponseWriter.Write(data)
+		}
+		w.Header().Del("Content-Length")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.writer = w.pool.Get().(*gzip.Writer)
+		w.writer.Reset(w.ResponseWriter)
+	}
+	return w.writer.Write(data)
+}
+
+// WriteString compresses s
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush flushes the compressed data written so far
+func (w *gzipResponseWriter) Flush() {
+	if w.writer != nil {
+		_ = w.writer.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// close finishes the gzip stream and returns the compressor to the pool
+func (w *gzipResponseWriter) close() {
+	if w.writer == nil {
+		return
+	}
+	_ = w.writer.Close()
+	w.writer.Reset(io.Discard)
+	w.pool.Put(w.writer)
+	w.writer = nil
+}
@@ -0,0 +1,207 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Idempotency headers
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	idempotencySweepThreshold = 100
+)
+
+// idempotencyEntry is the stored outcome of the first request made with a key
+type idempotencyEntry struct {
+	fingerprint string
+	done        bool
+	status      int
+	contentType string
+	body        []byte
+	expiresAt   time.Time
+}
+
+// IdempotencyStore keeps the responses of requests sent with an Idempotency-Key header
+// so retried requests are answered with the original response instead of being processed again
+type IdempotencyStore struct {
+	ttl     time.Duration
+	mutex   sync.Mutex
+	entries map[string]*idempotencyEntry
+	inserts int
+	clock   clock.Clock
+}
+
+// NewIdempotencyStore creates a store that remembers responses for ttl
+func NewIdempotencyStore(ttl time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{
+		ttl:     ttl,
+		entries: make(map[string]*idempotencyEntry),
+		clock:   clock.Real(),
+	}
+}
+
+// SetClock replaces the clock used to expire stored responses
+func (s *IdempotencyStore) SetClock(c clock.Clock) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.clock = c
+}
+
+// begin claims the key for a new request. It returns the existing entry when the key is
+// already known, or nil when the caller now owns the key and must call finish or release.
+func (s *IdempotencyStore) begin(key, fingerprint string) *idempotencyEntry {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.clock.Now()
+	if entry, exists := s.entries[key]; exists && (!entry.done || now.Before(entry.expiresAt)) {
+		copied := *entry
+		return &copied
+	}
+
+	s.entries[key] = &idempotencyEntry{fingerprint: fingerprint}
+
+	// Expired entries are removed every so often instead of running a background sweeper
+	s.inserts++
+	if s.inserts >= idempotencySweepThreshold {
+		s.inserts = 0
+		for k, entry := range s.entries {
+			if entry.done && !now.Before(entry.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+	}
+
+	return nil
+}
+
+// finish stores the response of the request owning the key
+func (s *IdempotencyStore) finish(key string, status int, contentType string, body []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if entry, exists := s.entries[key]; exists {
+		entry.done = true
+		entry.status = status
+		entry.contentType = contentType
+		entry.body = body
+		entry.expiresAt = s.clock.Now().Add(s.ttl)
+	}
+}
+
+// release forgets the key so the request can be retried
+func (s *IdempotencyStore) release(key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.entries, key)
+}
+
+// IdempotencyMiddleware makes POST requests carrying an Idempotency-Key header safe to retry.
+// The first response is stored and replayed for retries with the same key and body; a retry
+// while the first request is still running gets 409, and reusing a key for a different request
+// gets 422. Server errors are not stored so the request can be retried.
+func IdempotencyMiddleware(store *IdempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid idempotency key",
+				"message": "The Idempotency-Key header must not be longer than 255 characters",
+			})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request body",
+				"message": err.Error(),
+			})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Keys are scoped to the caller's credentials and the endpoint
+		scope := sha256.Sum256([]byte(c.GetHeader("Authorization") + "\x00" + c.Request.URL.Path + "\x00" + key))
+		storeKey := hex.EncodeToString(scope[:])
+		bodyHash := sha256.Sum256(body)
+		fingerprint := hex.EncodeToString(bodyHash[:])
+
+		if existing := store.begin(storeKey, fingerprint); existing != nil {
+			switch {
+			case existing.fingerprint != fingerprint:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+					"error":   "Idempotency key reused",
+					"message": "The Idempotency-Key was already used for a different request",
+				})
+			case !existing.done:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+					"error":   "Request in progress",
+					"message": "A request with this Idempotency-Key is still being processed",
+				})
+			default:
+				logrus.WithFields(logrus.Fields{
+					"path":   c.Request.URL.Path,
+					"status": existing.status,
+				}).Debug("Replaying idempotent response")
+				c.Header(IdempotentReplayedHeader, "true")
+				c.Data(existing.status, existing.contentType, existing.body)
+				c.Abort()
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				store.release(storeKey)
+				panic(recovered)
+			}
+
+			status := c.Writer.Status()
+			if status >= http.StatusInternalServerError {
+				store.release(storeKey)
+				return
+			}
+			store.finish(storeKey, status, c.Writer.Header().Get("Content-Type"), recorder.body.Bytes())
+		}()
+
+		c.Next()
+	}
+}
+
+// responseRecorder keeps a copy of the response body written through it
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write records and forwards data
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString records and forwards s
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newIdempotentRouter(store *IdempotencyStore, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(IdempotencyMiddleware(store))
+	router.POST("/notifications", handler)
+	return router
+}
+
+func postWithKey(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/notifications", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyMiddleware_ReplaysResponse(t *testing.T) {
+	calls := 0
+	router := newIdempotentRouter(NewIdempotencyStore(time.Hour), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"call": calls})
+	})
+
+	first := postWithKey(router, "key-1", `{"type":"email"}`)
+	retry := postWithKey(router, "key-1", `{"type":"email"}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusOK, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "true", retry.Header().Get(IdempotentReplayedHeader))
+	assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+
+	// Different keys and requests without a key are processed normally
+	postWithKey(router, "key-2", `{"type":"email"}`)
+	postWithKey(router, "", `{"type":"email"}`)
+	assert.Equal(t, 3, calls)
+
+	mismatch := postWithKey(router, "key-1", `{"type":"slack"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, mismatch.Code)
+	assert.Equal(t, 3, calls)
+}
+
+func TestIdempotencyMiddleware_ServerErrorsAreRetryable(t *testing.T) {
+	calls := 0
+	router := newIdempotentRouter(NewIdempotencyStore(time.Hour), func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "queue full"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"call": calls})
+	})
+
+	assert.Equal(t, http.StatusServiceUnavailable, postWithKey(router, "key-1", `{}`).Code)
+	assert.Equal(t, http.StatusOK, postWithKey(router, "key-1", `{}`).Code)
+	assert.Equal(t, http.StatusOK, postWithKey(router, "key-1", `{}`).Code)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotencyMiddleware_InFlightAndExpiry(t *testing.T) {
+	store := NewIdempotencyStore(time.Minute)
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	store.SetClock(fakeClock)
+
+	var router *gin.Engine
+	nested := 0
+	router = newIdempotentRouter(store, func(c *gin.Context) {
+		if nested == 0 {
+			nested++
+			// A retry arriving while the first request is still running
+			assert.Equal(t, http.StatusConflict, postWithKey(router, "key-1", `{}`).Code)
+		}
+		c.JSON(http.StatusCreated, gin.H{"ok": true})
+	})
+
+	assert.Equal(t, http.StatusCreated, postWithKey(router, "key-1", `{}`).Code)
+	assert.Equal(t, "true", postWithKey(router, "key-1", `{}`).Header().Get(IdempotentReplayedHeader))
+
+	fakeClock.Advance(2 * time.Minute)
+	assert.Empty(t, postWithKey(router, "key-1", `{}`).Header().Get(IdempotentReplayedHeader))
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPFilter_Allowed(t *testing.T) {
+	filter, err := NewIPFilter([]string{"10.0.0.0/8", "192.168.1.10", "2001:db8::/32"}, []string{"10.0.0.13"})
+	require.NoError(t, err)
+
+	assert.True(t, filter.Allowed(net.ParseIP("10.1.2.3")))
+	assert.True(t, filter.Allowed(net.ParseIP("192.168.1.10")))
+	assert.True(t, filter.Allowed(net.ParseIP("2001:db8::1")))
+	assert.False(t, filter.Allowed(net.ParseIP("192.168.1.11")))
+	assert.False(t, filter.Allowed(net.ParseIP("10.0.0.13")), "deny list takes precedence")
+	assert.False(t, filter.Allowed(nil))
+
+	// Only a deny list admits everyone else
+	filter, err = NewIPFilter(nil, []string{"203.0.113.0/24"})
+	require.NoError(t, err)
+	assert.True(t, filter.Allowed(net.ParseIP("198.51.100.1")))
+	assert.False(t, filter.Allowed(net.ParseIP("203.0.113.7")))
+
+	_, err = NewIPFilter([]string{"10.0.0.0/33"}, nil)
+	assert.Error(t, err)
+	_, err = NewIPFilter(nil, []string{"not-an-ip"})
+	assert.Error(t, err)
+}
+
+func TestIPFilterMiddleware(t *testing.T) {
+	filter, err := NewIPFilter([]string{"10.0.0.0/8"}, nil)
+	require.NoError(t, err)
+
+	config := DefaultConfig()
+	config.TrustedProxies = []string{"127.0.0.1"}
+	router := newTestRouter(config)
+	router.GET("/filtered", IPFilterMiddleware(filter), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	request := func(remoteAddr, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/filtered", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusNoContent, request("10.0.0.5:4321", ""))
+	assert.Equal(t, http.StatusForbidden, request("192.0.2.1:4321", ""))
+
+	// Forwarded addresses are honoured from trusted proxies only
+	assert.Equal(t, http.StatusNoContent, request("127.0.0.1:4321", "10.0.0.5"))
+	assert.Equal(t, http.StatusForbidden, request("192.0.2.1:4321", "10.0.0.5"))
+}
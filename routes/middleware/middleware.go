@@ -2,13 +2,52 @@ package middleware
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
-// SetupMiddleware configures all middleware for the application
+// SetupMiddleware configures all middleware for the application from environment variables
 func SetupMiddleware(router *gin.Engine) {
+	SetupMiddlewareWithConfig(router, LoadConfigFromEnv())
+}
+
+// SetupMiddlewareWithConfig configures all middleware for the application.
+// Recovery runs first so panics anywhere in the chain produce a structured error response.
+func SetupMiddlewareWithConfig(router *gin.Engine, config Config) {
+	// Only trusted proxies may override the client address used for logging and IP filtering
+	if err := router.SetTrustedProxies(config.TrustedProxies); err != nil {
+		logrus.WithError(err).Fatal("Invalid trusted proxies configuration")
+	}
+
+	// Add recovery middleware
+	router.Use(RecoveryMiddleware())
+
 	// Add logging middleware
 	router.Use(gin.Logger())
 
-	// Add recovery middleware
-	router.Use(gin.Recovery())
+	// Add CORS middleware before the body limit so preflight requests are answered directly
+	if config.CORSEnabled {
+		router.Use(CORSMiddleware(config))
+	}
+
+	// Add request body size limit
+	if config.MaxRequestBodyBytes > 0 {
+		router.Use(BodyLimitMiddleware(config.MaxRequestBodyBytes))
+	}
+
+	// Add response compression
+	if config.GzipEnabled {
+		router.Use(GzipMiddleware(config.GzipLevel))
+	}
+
+	// Bound the handler duration; the deadline reaches the notification manager through the request context
+	if config.RequestTimeout > 0 {
+		router.Use(TimeoutMiddleware(config.RequestTimeout))
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"cors":           config.CORSEnabled,
+		"max_body_bytes": config.MaxRequestBodyBytes,
+		"gzip":           config.GzipEnabled,
+		"timeout":        config.RequestTimeout.String(),
+	}).Debug("HTTP middleware configured")
 }
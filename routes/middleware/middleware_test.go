@@ -0,0 +1,297 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRouter(config Config) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	SetupMiddlewareWithConfig(router, config)
+
+	router.POST("/echo", func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"size": len(body)})
+	})
+	router.POST("/invalid", func(c *gin.Context) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid"})
+	})
+	router.GET("/payload", func(c *gin.Context) {
+		c.String(http.StatusOK, strings.Repeat("notification ", 100))
+	})
+	router.GET("/empty", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	return router
+}
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxRequestBodyBytes = 16
+	router := newTestRouter(config)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{"ok":true}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(strings.Repeat("x", 17))))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "Request body too large")
+
+	// Without a Content-Length the body is cut off while reading
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(strings.Repeat("x", 17)))
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "request body too large")
+
+	// Other bad requests keep their status
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/invalid", strings.NewReader(`{"ok":true}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGzipMiddleware(t *testing.T) {
+	router := newTestRouter(DefaultConfig())
+
+	req := httptest.NewRequest(http.MethodGet, "/payload", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+
+	reader, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("notification ", 100), string(body))
+
+	// Clients that do not accept gzip get the plain body
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/payload", nil))
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, strings.Repeat("notification ", 100), w.Body.String())
+
+	// Empty responses stay empty
+	req = httptest.NewRequest(http.MethodGet, "/empty", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Zero(t, w.Body.Len())
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	assert.True(t, acceptsGzip("gzip"))
+	assert.True(t, acceptsGzip("deflate, GZIP;q=0.8"))
+	assert.False(t, acceptsGzip("gzip;q=0"))
+	assert.False(t, acceptsGzip("br"))
+	assert.False(t, acceptsGzip(""))
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	config := DefaultConfig()
+	config.CORSEnabled = true
+	config.CORSAllowedOrigins = []string{"https://admin.example.com"}
+	router := newTestRouter(config)
+
+	req := httptest.NewRequest(http.MethodOptions, "/echo", nil)
+	req.Header.Set("Origin", "https://admin.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://admin.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, POST, PUT, DELETE, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+
+	req = httptest.NewRequest(http.MethodOptions, "/echo", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	req = httptest.NewRequest(http.MethodGet, "/payload", nil)
+	req.Header.Set("Origin", "https://admin.example.com")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://admin.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	router := newTestRouter(DefaultConfig())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{
+		"error": "Internal server error",
+		"message": "The server encountered an unexpected error while processing the request"
+	}`, w.Body.String())
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	config := DefaultConfig()
+	config.RequestTimeout = 20 * time.Millisecond
+	router := newTestRouter(config)
+	router.GET("/slow", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+	})
+	router.GET("/aborted", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		AbortWithDeadlineExceeded(c)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/payload", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	for _, path := range []string{"/slow", "/aborted"} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, path)
+		assert.JSONEq(t, `{
+			"error": "Request timeout",
+			"message": "The request did not complete within 20ms",
+			"timeout_seconds": 0.02
+		}`, w.Body.String(), path)
+	}
+}
+
+func TestETagMiddleware(t *testing.T) {
+	router := newTestRouter(DefaultConfig())
+	version := "v1"
+	router.GET("/template", ETagMiddleware(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"version": version})
+	})
+	router.GET("/missing", ETagMiddleware(), func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/template", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.True(t, strings.HasPrefix(etag, `W/"`), etag)
+	assert.JSONEq(t, `{"version":"v1"}`, w.Body.String())
+
+	// An unchanged resource is not sent again, also when the client compares strongly
+	for _, ifNoneMatch := range []string{etag, strings.TrimPrefix(etag, "W/"), `"stale", ` + etag, "*"} {
+		req := httptest.NewRequest(http.MethodGet, "/template", nil)
+		req.Header.Set("If-None-Match", ifNoneMatch)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotModified, w.Code, ifNoneMatch)
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+		assert.Zero(t, w.Body.Len())
+	}
+
+	// A changed resource gets a new ETag, also when it is gzipped
+	version = "v2"
+	req := httptest.NewRequest(http.MethodGet, "/template", nil)
+	req.Header.Set("If-None-Match", etag)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	reader, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"version":"v2"}`, string(body))
+
+	// Errors are passed through without an ETag
+	req = httptest.NewRequest(http.MethodGet, "/missing", nil)
+	req.Header.Set("If-None-Match", "*")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Header().Get("ETag"))
+	assert.JSONEq(t, `{"error":"Template not found"}`, w.Body.String())
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	t.Setenv("CORS_ENABLED", "true")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://a.example.com, https://b.example.com")
+	t.Setenv("MAX_REQUEST_BODY_BYTES", "0")
+	t.Setenv("GZIP_ENABLED", "false")
+	t.Setenv("GZIP_LEVEL", "42")
+	t.Setenv("REQUEST_TIMEOUT_SECONDS", "0")
+
+	config := LoadConfigFromEnv()
+
+	assert.True(t, config.CORSEnabled)
+	assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, config.CORSAllowedOrigins)
+	assert.Equal(t, int64(0), config.MaxRequestBodyBytes)
+	assert.False(t, config.GzipEnabled)
+	assert.Equal(t, DefaultConfig().GzipLevel, config.GzipLevel)
+	assert.Zero(t, config.RequestTimeout)
+}
+
+func TestAPIKeyMiddleware_SetsPrincipal(t *testing.T) {
+	t.Setenv("API_KEY", "shared-key")
+	t.Setenv("API_KEYS", "alice:alice-key, bob:bob-key, malformed")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(APIKeyMiddleware())
+	router.GET("/whoami", func(c *gin.Context) {
+		c.String(http.StatusOK, auth.Principal(c))
+	})
+
+	tests := []struct {
+		header   string
+		code     int
+		expected string
+	}{
+		{"Bearer alice-key", http.StatusOK, "alice"},
+		{"ApiKey bob-key", http.StatusOK, "bob"},
+		{"Bearer shared-key", http.StatusOK, auth.DefaultAPIKeyPrincipal},
+		{"Bearer malformed", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+		req.Header.Set("Authorization", tt.header)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.code, w.Code, tt.header)
+		if tt.code == http.StatusOK {
+			assert.Equal(t, tt.expected, w.Body.String())
+		}
+	}
+}
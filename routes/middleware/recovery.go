@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// RecoveryMiddleware recovers from panics in handlers, logs them with the stack trace
+// and responds with a structured 500 error instead of an empty body
+func RecoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered interface{}) {
+		logrus.WithFields(logrus.Fields{
+			"method": c.Request.Method,
+			"path":   c.Request.URL.Path,
+			"panic":  fmt.Sprint(recovered),
+			"stack":  string(debug.Stack()),
+		}).Error("Recovered from panic while handling request")
+
+		if c.Writer.Written() {
+			// Part of the response is already sent; all that is left is to close the connection
+			c.Abort()
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "The server encountered an unexpected error while processing the request",
+		})
+	})
+}
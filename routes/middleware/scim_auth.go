@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gaurav2721/notification-service/auth"
+	"github.com/gaurav2721/notification-service/scim"
+	"github.com/gin-gonic/gin"
+)
+
+// SCIMAuthMiddleware authenticates identity providers calling the SCIM endpoint with the
+// configured bearer token. Failures are answered with SCIM error responses.
+func SCIMAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="scim"`)
+			c.Header("Content-Type", scim.MediaType)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, scim.NewError(http.StatusUnauthorized, "", "A valid bearer token is required"))
+			return
+		}
+
+		auth.SetPrincipal(c, auth.SCIMPrincipal)
+		c.Next()
+	}
+}
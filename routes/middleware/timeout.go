@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// requestTimeoutKey stores the timeout of the request in the gin context
+const requestTimeoutKey = "request_timeout"
+
+// TimeoutMiddleware bounds the time a handler may take. The deadline is set on the request
+// context, so the notification manager stops rendering templates and looking up recipients
+// once it passed. Handlers that return without a response after the deadline, or that report
+// the deadline through AbortWithDeadlineExceeded, are answered with 503 Service Unavailable.
+func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Set(requestTimeoutKey, timeout)
+		c.Next()
+
+		if ctx.Err() == context.DeadlineExceeded && !c.Writer.Written() {
+			AbortWithDeadlineExceeded(c)
+		}
+	}
+}
+
+// AbortWithDeadlineExceeded responds with a structured 503 error to a request whose deadline
+// passed before the handler could complete it
+func AbortWithDeadlineExceeded(c *gin.Context) {
+	timeout := c.GetDuration(requestTimeoutKey)
+	logrus.WithFields(logrus.Fields{
+		"method":  c.Request.Method,
+		"path":    c.Request.URL.Path,
+		"timeout": timeout.String(),
+	}).Warn("Request deadline exceeded")
+
+	SetErrorCode(c, ErrorCodeDeadlineExceeded)
+	c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+		"error":           "Request timeout",
+		"message":         fmt.Sprintf("The request did not complete within %s", timeout),
+		"timeout_seconds": timeout.Seconds(),
+	})
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/external_services/webhooks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// bodyVerifier accepts requests whose body is "signed"
+type bodyVerifier struct{}
+
+func (bodyVerifier) Verify(r *http.Request, body []byte) error {
+	if string(body) != "signed" {
+		return webhooks.ErrInvalidSignature
+	}
+	return nil
+}
+
+func TestWebhookSignatureMiddleware(t *testing.T) {
+	router := newTestRouter(DefaultConfig())
+	handler := func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	}
+	router.POST("/webhooks/test", WebhookSignatureMiddleware("test", bodyVerifier{}), handler)
+	router.POST("/webhooks/unconfigured", WebhookSignatureMiddleware("unconfigured", nil), handler)
+
+	request := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return w
+	}
+
+	w := request("/webhooks/test", "signed")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "signed", w.Body.String(), "the handler reads the verified body")
+
+	assert.Equal(t, http.StatusUnauthorized, request("/webhooks/test", "forged").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, request("/webhooks/unconfigured", "signed").Code)
+}
@@ -13,5 +13,24 @@ func SetupNotificationRoutes(api *gin.RouterGroup, handler *handlers.Notificatio
 
 	// Notification endpoints with validation
 	api.POST("/notifications", validationLayer.ValidateNotificationRequest(), handler.SendNotification)
+	// Reports which recipients a notification would reach, without sending it
+	api.POST("/notifications/validate-recipients", validationLayer.ValidateRecipientCheckRequest(), handler.ValidateRecipients)
+	api.GET("/notifications", validationLayer.ValidateNotificationListQuery(), handler.ListNotifications)
 	api.GET("/notifications/:id", validationLayer.ValidateNotificationID(), handler.GetNotificationStatus)
+	// Cancels a scheduled notification before it is sent
+	api.DELETE("/notifications/:id", validationLayer.ValidateNotificationID(), handler.CancelNotification)
+	api.GET("/notifications/:id/deliveries/:recipient/attempts", validationLayer.ValidateNotificationID(), handler.GetDeliveryAttempts)
+
+	// Engagement endpoints used by client apps to report impressions and opens
+	api.POST("/notifications/:id/events",
+		validationLayer.ValidateNotificationID(),
+		validationLayer.ValidateEngagementEventRequest(),
+		handler.RecordEngagementEvent)
+	api.GET("/notifications/:id/events", validationLayer.ValidateNotificationID(), handler.GetEngagementEvents)
+
+	// Delivery receipts confirming that pushes reached the device, reported by apps or imported from FCM
+	api.POST("/delivery-receipts", validationLayer.ValidateDeliveryReceiptBatch(), handler.RecordDeliveryReceipts)
+
+	// Analytics endpoints
+	api.GET("/analytics/notifications", validationLayer.ValidateNotificationAnalyticsQuery(), handler.GetNotificationAnalytics)
 }
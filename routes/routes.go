@@ -8,41 +8,104 @@ import (
 	"github.com/gaurav2721/notification-service/handlers"
 	"github.com/gaurav2721/notification-service/routes/middleware"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
 // SetupRoutes configures all the routes for the application
-func SetupRoutes(router *gin.Engine, notificationHandler *handlers.NotificationHandler, userHandler *handlers.UserHandler) {
+func SetupRoutes(router *gin.Engine, notificationHandler *handlers.NotificationHandler, userHandler *handlers.UserHandler, topicHandler *handlers.TopicHandler, scimHandler *handlers.SCIMHandler, healthHandler *handlers.HealthHandler) {
 	// Setup middleware
-	middleware.SetupMiddleware(router)
+	middlewareConfig := middleware.LoadConfigFromEnv()
+	middleware.SetupMiddlewareWithConfig(router, middlewareConfig)
+
+	// Source IP filters for the API and, separately, the admin routes
+	apiFilter, err := middleware.NewIPFilter(middlewareConfig.APIAllowedIPs, middlewareConfig.APIDeniedIPs)
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid API IP filter configuration")
+	}
+	adminFilter, err := middleware.NewIPFilter(middlewareConfig.AdminAllowedIPs, middlewareConfig.AdminDeniedIPs)
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid admin IP filter configuration")
+	}
+	var adminMiddleware []gin.HandlerFunc
+	if adminFilter.Enabled() {
+		adminMiddleware = append(adminMiddleware, middleware.IPFilterMiddleware(adminFilter))
+	}
 
 	// Setup health routes
-	SetupHealthRoutes(router, notificationHandler)
+	SetupHealthRoutes(router, notificationHandler, healthHandler)
 
-	// API routes with API key authentication
-	api := router.Group("/api/v1")
-	api.Use(middleware.APIKeyMiddleware()) // Apply API key middleware to all /api/v1 routes
-	{
-		// Setup notification routes
-		SetupNotificationRoutes(api, notificationHandler)
+	// Setup admin dashboard (controlled by feature flag)
+	if isFeatureEnabled(constants.ENABLE_ADMIN_UI) {
+		SetupAdminUIRoutes(router, adminMiddleware...)
+	}
 
-		// Setup template routes
-		SetupTemplateRoutes(api, notificationHandler)
+	// Setup SCIM provisioning routes (enabled by configuring their bearer token)
+	if token := os.Getenv(constants.SCIM_BEARER_TOKEN); token != "" {
+		var scimMiddleware []gin.HandlerFunc
+		if apiFilter.Enabled() {
+			scimMiddleware = append(scimMiddleware, middleware.IPFilterMiddleware(apiFilter))
+		}
+		SetupSCIMRoutes(router, scimHandler, token, scimMiddleware...)
+	}
 
-		// Setup user routes (controlled by feature flag)
-		if isUserRoutesEnabled() {
-			SetupUserRoutes(api, userHandler)
+	// Every API version serves the same routes through shared handlers. v2 wraps responses in an
+	// envelope with typed errors and accepts notification sends asynchronously.
+	idempotencyStore := middleware.NewIdempotencyStore(middlewareConfig.IdempotencyKeyTTL)
+	for _, version := range []string{middleware.APIVersionV1, middleware.APIVersionV2} {
+		api := router.Group("/api/"+version, middleware.APIVersionMiddleware(version))
+		if version != middleware.APIVersionV1 {
+			api.Use(middleware.EnvelopeMiddleware()) // Wrap every response, authentication errors included
 		}
+		if apiFilter.Enabled() {
+			api.Use(middleware.IPFilterMiddleware(apiFilter)) // Reject disallowed callers before authentication
+		}
+		api.Use(middleware.APIKeyMiddleware()) // Apply API key middleware to all API routes
+		api.Use(middleware.IdempotencyMiddleware(idempotencyStore))
+
+		setupAPIRoutes(api, notificationHandler, userHandler, topicHandler, adminMiddleware)
+	}
+}
+
+// setupAPIRoutes configures the routes of an API version
+func setupAPIRoutes(api *gin.RouterGroup, notificationHandler *handlers.NotificationHandler, userHandler *handlers.UserHandler, topicHandler *handlers.TopicHandler, adminMiddleware []gin.HandlerFunc) {
+	// Setup notification routes
+	SetupNotificationRoutes(api, notificationHandler)
+
+	// Setup template routes
+	SetupTemplateRoutes(api, notificationHandler)
+
+	// Setup the routes publishing the request schemas
+	SetupSchemaRoutes(api, notificationHandler)
+
+	// Setup media asset routes
+	SetupMediaRoutes(api, notificationHandler)
+
+	// Setup in-app inbox and preference routes
+	SetupInboxRoutes(api, notificationHandler)
+
+	// Setup runtime logging routes
+	SetupLoggingRoutes(api, notificationHandler, adminMiddleware...)
+
+	// Setup FCM topic messaging routes
+	SetupTopicRoutes(api, topicHandler)
+
+	// Setup admin API routes used by the dashboard
+	SetupAdminRoutes(api, notificationHandler, adminMiddleware...)
+
+	// Setup user routes (controlled by feature flag)
+	if isFeatureEnabled(constants.ENABLE_USER_ROUTES) {
+		SetupUserRoutes(api, userHandler)
 	}
 }
 
-// isUserRoutesEnabled checks if user routes should be enabled based on environment variable
-func isUserRoutesEnabled() bool {
-	enableUserRoutes := os.Getenv(constants.ENABLE_USER_ROUTES)
-	if enableUserRoutes == "" {
+// isFeatureEnabled checks if an optional set of routes should be enabled based on its environment variable
+func isFeatureEnabled(key string) bool {
+	value := os.Getenv(key)
+	if value == "" {
 		return false // Default to disabled
 	}
 
-	enabled, err := strconv.ParseBool(enableUserRoutes)
+	enabled, err := strconv.ParseBool(value)
 	if err != nil {
 		return false // Default to disabled on parsing error
 	}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/gaurav2721/notification-service/handlers"
+	"github.com/gaurav2721/notification-service/routes/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupSchemaRoutes configures the routes publishing the JSON Schemas requests are validated against
+func SetupSchemaRoutes(api *gin.RouterGroup, handler *handlers.NotificationHandler) {
+	etag := middleware.ETagMiddleware()
+	api.GET("/schemas", etag, handler.ListSchemas)
+	api.GET("/schemas/:name", etag, handler.GetSchema)
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/gaurav2721/notification-service/handlers"
+	"github.com/gaurav2721/notification-service/routes/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupSCIMRoutes configures the SCIM 2.0 routes identity providers provision users through,
+// authenticated with token
+func SetupSCIMRoutes(router *gin.Engine, scimHandler *handlers.SCIMHandler, token string, filters ...gin.HandlerFunc) {
+	scimGroup := router.Group("/scim/v2", filters...)
+	scimGroup.Use(middleware.SCIMAuthMiddleware(token))
+	{
+		scimGroup.GET("/ServiceProviderConfig", scimHandler.GetServiceProviderConfig) // Supported SCIM features
+		scimGroup.GET("/ResourceTypes", scimHandler.GetResourceTypes)                 // Provided resource types
+		scimGroup.GET("/Users", scimHandler.ListUsers)                                // List or filter users
+		scimGroup.POST("/Users", scimHandler.CreateUser)                              // Provision a user
+		scimGroup.GET("/Users/:id", scimHandler.GetUser)                              // Get a user
+		scimGroup.PUT("/Users/:id", scimHandler.ReplaceUser)                          // Replace a user's attributes
+		scimGroup.PATCH("/Users/:id", scimHandler.PatchUser)                          // Update or deactivate a user
+		scimGroup.DELETE("/Users/:id", scimHandler.DeleteUser)                        // Deprovision a user
+	}
+}
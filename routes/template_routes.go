@@ -2,6 +2,7 @@ package routes
 
 import (
 	"github.com/gaurav2721/notification-service/handlers"
+	"github.com/gaurav2721/notification-service/routes/middleware"
 	"github.com/gaurav2721/notification-service/validation"
 	"github.com/gin-gonic/gin"
 )
@@ -11,11 +12,60 @@ func SetupTemplateRoutes(api *gin.RouterGroup, handler *handlers.NotificationHan
 	// Create validation layer
 	validationLayer := validation.NewValidationLayer()
 
+	// Clients that cache templates revalidate them with If-None-Match
+	etag := middleware.ETagMiddleware()
+
 	// Template endpoints with validation
 	api.POST("/templates", validationLayer.ValidateTemplateRequest(), handler.CreateTemplate)
-	api.GET("/templates/predefined", handler.GetPredefinedTemplates)
+	api.GET("/templates", etag, handler.ListTemplates)
+	api.GET("/templates/predefined", etag, handler.GetPredefinedTemplates)
+	api.GET("/templates/export", etag, handler.ExportTemplates)
+	api.POST("/templates/import", handler.ImportTemplates)
+	api.GET("/templates/directory", handler.GetTemplateDirectoryStatus)
+	api.POST("/templates/directory/reload", handler.ReloadTemplates)
+	api.GET("/templates/git", handler.GetTemplateGitSyncStatus)
+	api.POST("/templates/git/sync", handler.SyncTemplatesFromGit)
+	api.PUT("/templates/:templateId",
+		validationLayer.ValidateTemplateID(),
+		validationLayer.ValidateTemplateRequest(),
+		handler.UpdateTemplate)
+	api.GET("/templates/:templateId/audit",
+		validationLayer.ValidateTemplateID(),
+		etag,
+		handler.GetTemplateAudit)
 	api.GET("/templates/:templateId/versions/:version",
 		validationLayer.ValidateTemplateID(),
 		validationLayer.ValidateTemplateVersion(),
+		etag,
 		handler.GetTemplateVersion)
+	api.POST("/templates/:templateId/versions/:version/render",
+		validationLayer.ValidateTemplateID(),
+		validationLayer.ValidateTemplateVersion(),
+		handler.RenderTemplate)
+	api.GET("/templates/:templateId/versions/:version/lint",
+		validationLayer.ValidateTemplateID(),
+		validationLayer.ValidateTemplateVersion(),
+		etag,
+		handler.LintTemplate)
+	api.GET("/templates/:templateId/versions/:version/diff/:toVersion",
+		validationLayer.ValidateTemplateID(),
+		validationLayer.ValidateTemplateVersion(),
+		validationLayer.ValidateTemplateVersionParam("toVersion"),
+		etag,
+		handler.DiffTemplateVersions)
+	api.POST("/templates/:templateId/versions/:version/activate",
+		validationLayer.ValidateTemplateID(),
+		validationLayer.ValidateTemplateVersion(),
+		handler.ActivateTemplate)
+	api.GET("/templates/:templateId/stats",
+		validationLayer.ValidateTemplateID(),
+		handler.GetTemplateStats)
+
+	// Branding of the calling tenant, available to templates as brand variables
+	api.GET("/branding", etag, handler.GetBranding)
+	api.PUT("/branding", validationLayer.ValidateBrandingRequest(), handler.UpdateBranding)
+
+	// Default from address and allowed sender domains of the calling tenant
+	api.GET("/sender", etag, handler.GetSenderSettings)
+	api.PUT("/sender", validationLayer.ValidateSenderRequest(), handler.UpdateSenderSettings)
 }
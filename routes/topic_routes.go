@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gaurav2721/notification-service/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupTopicRoutes configures FCM topic messaging routes
+func SetupTopicRoutes(api *gin.RouterGroup, topicHandler *handlers.TopicHandler) {
+	topics := api.Group("/topics")
+	{
+		topics.POST("/:topic/subscribers", topicHandler.SubscribeToTopic)       // Subscribe devices to a topic
+		topics.DELETE("/:topic/subscribers", topicHandler.UnsubscribeFromTopic) // Unsubscribe devices from a topic
+		topics.POST("/:topic/messages", topicHandler.SendToTopic)               // Send a push to a topic
+	}
+}
@@ -19,6 +19,20 @@ func SetupUserRoutes(api *gin.RouterGroup, userHandler *handlers.UserHandler) {
 		// User notification specific endpoints
 		users.GET("/:id/notification-info", userHandler.GetUserNotificationInfo) // Get user notification info
 
+		// Contact point endpoints
+		users.GET("/:id/contact-points", userHandler.GetContactPoints)          // List contact points
+		users.POST("/:id/contact-points", userHandler.AddContactPoint)          // Add a contact point
+		users.PUT("/:id/contact-points/primary", userHandler.SetPrimaryContact) // Make a contact point primary
+		users.DELETE("/:id/contact-points", userHandler.RemoveContactPoint)     // Remove a contact point
+
+		// Contact verification endpoints
+		users.POST("/:id/verifications", userHandler.SendContactVerification)            // Send a verification code
+		users.POST("/:id/verifications/confirm", userHandler.ConfirmContactVerification) // Confirm a verification code
+
+		// Do-not-disturb endpoints
+		users.POST("/:id/dnd", userHandler.SetDoNotDisturb)     // Turn do-not-disturb on
+		users.DELETE("/:id/dnd", userHandler.ClearDoNotDisturb) // Turn do-not-disturb off
+
 		// Device management endpoints
 		users.POST("/:id/devices", userHandler.RegisterDevice)                        // Register a new device
 		users.GET("/:id/devices", userHandler.GetUserDevices)                         // Get all devices for user
@@ -28,4 +42,8 @@ func SetupUserRoutes(api *gin.RouterGroup, userHandler *handlers.UserHandler) {
 		users.PATCH("/devices/:deviceId/deactivate", userHandler.DeactivateDevice)    // Deactivate device
 		users.PATCH("/devices/:deviceId/last-used", userHandler.UpdateDeviceLastUsed) // Update device last used
 	}
+
+	// Device migration endpoints
+	api.GET("/devices/export", userHandler.ExportDevices)  // Stream all devices as NDJSON
+	api.POST("/devices/import", userHandler.ImportDevices) // Register devices from CSV or NDJSON
 }
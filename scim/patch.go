@@ -0,0 +1,238 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// valueFilterPathRegex matches paths selecting the value of a multi-valued attribute, such as
+// emails[type eq "work"].value, which identity providers use to set the primary address
+var valueFilterPathRegex = regexp.MustCompile(`^(?i)(emails|phoneNumbers)\[[^\]]*\]\.value$`)
+
+// ApplyPatch applies the operations of a PATCH request to a user. Operations are applied in
+// order; the user is left partially modified when one fails, so callers apply them to a copy.
+func ApplyPatch(u *models.User, ops []PatchOperation) error {
+	for _, op := range ops {
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+			if err := applyValue(u, strings.ToLower(op.Op), op.Path, op.Value); err != nil {
+				return err
+			}
+		case "remove":
+			if err := removeValue(u, op.Path); err != nil {
+				return err
+			}
+		default:
+			return NewError(http.StatusBadRequest, ErrorTypeInvalidSyntax, "unsupported operation %q, expected add, replace or remove", op.Op)
+		}
+	}
+	return nil
+}
+
+// applyValue sets the attribute at path. Without a path the value is an object whose keys are
+// the paths to set, as Azure AD and Okta send it.
+func applyValue(u *models.User, op, path string, value interface{}) error {
+	if path == "" {
+		attributes, ok := value.(map[string]interface{})
+		if !ok {
+			return NewError(http.StatusBadRequest, ErrorTypeInvalidValue, "%s without a path needs an object value", op)
+		}
+		for attribute, attributeValue := range attributes {
+			if err := applyValue(u, op, attribute, attributeValue); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if match := valueFilterPathRegex.FindStringSubmatch(path); match != nil {
+		address, err := stringValue(path, value)
+		if err != nil {
+			return err
+		}
+		return replacePrimary(u, contactTypeOf(match[1]), address)
+	}
+
+	switch strings.ToLower(path) {
+	case "username":
+		userName, err := stringValue(path, value)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(userName) == "" {
+			return NewError(http.StatusBadRequest, ErrorTypeInvalidValue, "userName is required")
+		}
+		u.UserName = userName
+	case "externalid":
+		return setString(&u.ExternalID, path, value)
+	case "displayname", "name.formatted":
+		return setString(&u.FullName, path, value)
+	case "name.givenname":
+		given, err := stringValue(path, value)
+		if err != nil {
+			return err
+		}
+		_, family := splitName(u.FullName)
+		u.FullName = joinName(given, family)
+	case "name.familyname":
+		family, err := stringValue(path, value)
+		if err != nil {
+			return err
+		}
+		given, _ := splitName(u.FullName)
+		u.FullName = joinName(given, family)
+	case "name":
+		var name Name
+		if err := decodeValue(path, value, &name); err != nil {
+			return err
+		}
+		u.FullName = name.Formatted
+		if u.FullName == "" {
+			u.FullName = joinName(name.GivenName, name.FamilyName)
+		}
+	case "locale":
+		return setString(&u.Locale, path, value)
+	case "timezone":
+		return setString(&u.Timezone, path, value)
+	case "active":
+		active, err := boolValue(value)
+		if err != nil {
+			return err
+		}
+		u.IsActive = active
+	case "emails", "phonenumbers":
+		var values []MultiValue
+		if err := decodeValue(path, value, &values); err != nil {
+			return err
+		}
+		contactType := contactTypeOf(path)
+		if op == "add" {
+			return addContacts(u, contactType, values)
+		}
+		return setContacts(u, contactType, values)
+	default:
+		return NewError(http.StatusBadRequest, ErrorTypeInvalidPath, "unsupported path %q", path)
+	}
+	return nil
+}
+
+// removeValue clears the attribute at path
+func removeValue(u *models.User, path string) error {
+	if match := valueFilterPathRegex.FindStringSubmatch(path); match != nil {
+		contactType := contactTypeOf(match[1])
+		for _, point := range u.ContactPoints() {
+			if point.Type == contactType && point.Primary {
+				return u.RemoveContactPoint(contactType, point.Address)
+			}
+		}
+		return nil
+	}
+
+	switch strings.ToLower(path) {
+	case "":
+		return NewError(http.StatusBadRequest, ErrorTypeInvalidPath, "remove needs a path")
+	case "username":
+		return NewError(http.StatusBadRequest, ErrorTypeMutability, "userName is required and cannot be removed")
+	case "externalid":
+		u.ExternalID = ""
+	case "displayname", "name", "name.formatted":
+		u.FullName = ""
+	case "name.givenname":
+		_, family := splitName(u.FullName)
+		u.FullName = family
+	case "name.familyname":
+		given, _ := splitName(u.FullName)
+		u.FullName = given
+	case "locale":
+		u.Locale = ""
+	case "timezone":
+		u.Timezone = ""
+	case "emails", "phonenumbers":
+		return setContacts(u, contactTypeOf(path), nil)
+	default:
+		return NewError(http.StatusBadRequest, ErrorTypeInvalidPath, "unsupported path %q", path)
+	}
+	return nil
+}
+
+// addContacts adds values to the contact points of a type; addresses the user has are kept
+func addContacts(u *models.User, contactType string, values []MultiValue) error {
+	for _, value := range values {
+		if u.HasContactPoint(contactType, value.Value) {
+			if value.Primary {
+				if err := u.SetPrimaryContact(contactType, value.Value); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := u.AddContactPoint(contactType, value.Value, value.Primary); err != nil {
+			return NewError(http.StatusBadRequest, ErrorTypeInvalidValue, "%s %q: %v", contactType, value.Value, err)
+		}
+	}
+	return nil
+}
+
+// replacePrimary makes address the primary address of a type, adding it if the user has not
+func replacePrimary(u *models.User, contactType, address string) error {
+	return addContacts(u, contactType, []MultiValue{{Value: address, Primary: true}})
+}
+
+// contactTypeOf returns the contact type of the emails or phoneNumbers attribute
+func contactTypeOf(attribute string) string {
+	if strings.EqualFold(attribute, "emails") {
+		return models.ContactEmail
+	}
+	return models.ContactPhone
+}
+
+// setString sets a string attribute
+func setString(field *string, path string, value interface{}) error {
+	s, err := stringValue(path, value)
+	if err != nil {
+		return err
+	}
+	*field = s
+	return nil
+}
+
+// stringValue returns the value of a string attribute
+func stringValue(path string, value interface{}) (string, error) {
+	s, ok := value.(string)
+	if !ok {
+		return "", NewError(http.StatusBadRequest, ErrorTypeInvalidValue, "%s must be a string", path)
+	}
+	return s, nil
+}
+
+// boolValue returns the value of the active attribute. Azure AD sends it as "True" or "False".
+func boolValue(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		switch strings.ToLower(v) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return false, NewError(http.StatusBadRequest, ErrorTypeInvalidValue, "active must be a boolean")
+}
+
+// decodeValue converts a decoded JSON value to the type of the attribute at path
+func decodeValue(path string, value interface{}, target interface{}) error {
+	data, err := json.Marshal(value)
+	if err == nil {
+		err = json.Unmarshal(data, target)
+	}
+	if err != nil {
+		return NewError(http.StatusBadRequest, ErrorTypeInvalidValue, "invalid value for %s", path)
+	}
+	return nil
+}
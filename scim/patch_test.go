@@ -0,0 +1,99 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parsePatch(t *testing.T, data string) []PatchOperation {
+	t.Helper()
+	var request PatchRequest
+	require.NoError(t, json.Unmarshal([]byte(data), &request))
+	return request.Operations
+}
+
+func newPatchUser(t *testing.T) *models.User {
+	t.Helper()
+	u := &models.User{
+		ID:       "user-001",
+		UserName: "jane.doe@company.com",
+		FullName: "Jane Doe",
+		Email:    "jane.doe@company.com",
+		Locale:   "en-US",
+		IsActive: true,
+	}
+	require.NoError(t, u.AddContactPoint(models.ContactEmail, "jane@home.org", false))
+	return u
+}
+
+func TestApplyPatch(t *testing.T) {
+	u := newPatchUser(t)
+	ops := parsePatch(t, `{"Operations": [
+		{"op": "Replace", "path": "name.givenName", "value": "Janet"},
+		{"op": "replace", "path": "externalId", "value": "00u1abcd"},
+		{"op": "replace", "path": "emails[type eq \"work\"].value", "value": "janet.doe@company.com"},
+		{"op": "add", "path": "phoneNumbers", "value": [{"value": "+14155550123", "type": "mobile"}]},
+		{"op": "remove", "path": "locale"}
+	]}`)
+	require.NoError(t, ApplyPatch(u, ops))
+
+	assert.Equal(t, "Janet Doe", u.FullName)
+	assert.Equal(t, "00u1abcd", u.ExternalID)
+	assert.Equal(t, "janet.doe@company.com", u.Email)
+	assert.True(t, u.HasContactPoint(models.ContactEmail, "jane.doe@company.com"))
+	assert.True(t, u.HasContactPoint(models.ContactEmail, "jane@home.org"))
+	assert.Equal(t, "+14155550123", u.PhoneNumber)
+	assert.Empty(t, u.Locale)
+}
+
+func TestApplyPatch_WithoutPath(t *testing.T) {
+	// Azure AD deactivates users with an object value and the active flag as a string
+	u := newPatchUser(t)
+	ops := parsePatch(t, `{"Operations": [{"op": "Replace", "value": {"active": "False", "displayName": "Jane D."}}]}`)
+	require.NoError(t, ApplyPatch(u, ops))
+	assert.False(t, u.IsActive)
+	assert.Equal(t, "Jane D.", u.FullName)
+
+	ops = parsePatch(t, `{"Operations": [{"op": "replace", "path": "active", "value": true}]}`)
+	require.NoError(t, ApplyPatch(u, ops))
+	assert.True(t, u.IsActive)
+}
+
+func TestApplyPatch_Emails(t *testing.T) {
+	u := newPatchUser(t)
+	ops := parsePatch(t, `{"Operations": [{"op": "replace", "path": "emails", "value": [{"value": "jane@home.org", "primary": true}]}]}`)
+	require.NoError(t, ApplyPatch(u, ops))
+	assert.Equal(t, []models.ContactPoint{{Type: models.ContactEmail, Address: "jane@home.org", Primary: true}}, u.ContactPoints())
+
+	ops = parsePatch(t, `{"Operations": [{"op": "remove", "path": "emails[type eq \"work\"].value"}]}`)
+	require.NoError(t, ApplyPatch(u, ops))
+	assert.Empty(t, u.ContactPoints())
+}
+
+func TestApplyPatch_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		patch    string
+		status   int
+		scimType string
+	}{
+		{"unknown operation", `{"Operations": [{"op": "move", "path": "locale"}]}`, http.StatusBadRequest, ErrorTypeInvalidSyntax},
+		{"unknown path", `{"Operations": [{"op": "replace", "path": "title", "value": "CEO"}]}`, http.StatusBadRequest, ErrorTypeInvalidPath},
+		{"remove userName", `{"Operations": [{"op": "remove", "path": "userName"}]}`, http.StatusBadRequest, ErrorTypeMutability},
+		{"empty userName", `{"Operations": [{"op": "replace", "path": "userName", "value": ""}]}`, http.StatusBadRequest, ErrorTypeInvalidValue},
+		{"invalid active", `{"Operations": [{"op": "replace", "path": "active", "value": "maybe"}]}`, http.StatusBadRequest, ErrorTypeInvalidValue},
+		{"invalid email", `{"Operations": [{"op": "add", "path": "emails", "value": [{"value": "nope"}]}]}`, http.StatusBadRequest, ErrorTypeInvalidValue},
+		{"non-object value", `{"Operations": [{"op": "replace", "value": "jane"}]}`, http.StatusBadRequest, ErrorTypeInvalidValue},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ApplyPatch(newPatchUser(t), parsePatch(t, test.patch))
+			assertSCIMError(t, err, test.status, test.scimType)
+		})
+	}
+}
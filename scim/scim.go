@@ -0,0 +1,177 @@
+// Package scim maps users of the user service to and from SCIM 2.0 resources (RFC 7643) and
+// implements the parts of the SCIM protocol (RFC 7644) identity providers use to provision
+// users: filtering by userName or externalId, paging and PATCH operations.
+//
+// SCIM users map to users as follows: userName, externalId, displayName (or name), locale,
+// timezone and active map to the attributes of the same meaning; emails and phoneNumbers map to
+// the user's contact points, the primary value being the user's primary address.
+package scim
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Schema URNs of the resources and messages
+const (
+	UserSchema                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	ListResponseSchema          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	PatchOpSchema               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	ErrorSchema                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	ServiceProviderConfigSchema = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	ResourceTypeSchema          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+)
+
+// MediaType is the content type of SCIM requests and responses
+const MediaType = "application/scim+json"
+
+// MaxResults is the largest page of a list response
+const MaxResults = 200
+
+// User is a SCIM user resource
+type User struct {
+	Schemas      []string     `json:"schemas"`
+	ID           string       `json:"id,omitempty"`
+	ExternalID   string       `json:"externalId,omitempty"`
+	UserName     string       `json:"userName"`
+	Name         *Name        `json:"name,omitempty"`
+	DisplayName  string       `json:"displayName,omitempty"`
+	Emails       []MultiValue `json:"emails,omitempty"`
+	PhoneNumbers []MultiValue `json:"phoneNumbers,omitempty"`
+	Locale       string       `json:"locale,omitempty"`
+	Timezone     string       `json:"timezone,omitempty"`
+	Active       *bool        `json:"active,omitempty"`
+	Meta         *Meta        `json:"meta,omitempty"`
+}
+
+// Name is the name of a SCIM user
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// MultiValue is a value of a multi-valued attribute such as emails
+type MultiValue struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// Meta is the metadata of a SCIM resource
+type Meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location,omitempty"`
+}
+
+// ListResponse is a page of SCIM resources
+type ListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []User   `json:"Resources"`
+}
+
+// PatchRequest is a SCIM PATCH request
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// PatchOperation is one operation of a PATCH request. Value is decoded JSON, its type depends
+// on the path.
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// SCIM error types (RFC 7644, section 3.12)
+const (
+	ErrorTypeInvalidFilter = "invalidFilter"
+	ErrorTypeUniqueness    = "uniqueness"
+	ErrorTypeInvalidSyntax = "invalidSyntax"
+	ErrorTypeInvalidPath   = "invalidPath"
+	ErrorTypeInvalidValue  = "invalidValue"
+	ErrorTypeMutability    = "mutability"
+)
+
+// Error is a SCIM error response
+type Error struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	SCIMType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+
+	status int
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+// StatusCode returns the HTTP status of the error
+func (e *Error) StatusCode() int {
+	return e.status
+}
+
+// NewError creates a SCIM error with an HTTP status and, for bad requests, a SCIM error type
+func NewError(status int, scimType, format string, args ...interface{}) *Error {
+	return &Error{
+		Schemas:  []string{ErrorSchema},
+		Status:   strconv.Itoa(status),
+		SCIMType: scimType,
+		Detail:   fmt.Sprintf(format, args...),
+		status:   status,
+	}
+}
+
+// ErrNotFound is the error of a request for an unknown user
+func ErrNotFound(id string) *Error {
+	return NewError(http.StatusNotFound, "", "User %s not found", id)
+}
+
+// ServiceProviderConfig describes the SCIM features the service supports
+func ServiceProviderConfig(baseURL string) map[string]interface{} {
+	supported := func(value bool) map[string]interface{} {
+		return map[string]interface{}{"supported": value}
+	}
+	return map[string]interface{}{
+		"schemas":          []string{ServiceProviderConfigSchema},
+		"documentationUri": baseURL,
+		"patch":            supported(true),
+		"bulk":             map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":           map[string]interface{}{"supported": true, "maxResults": MaxResults},
+		"changePassword":   supported(false),
+		"sort":             supported(false),
+		"etag":             supported(false),
+		"authenticationSchemes": []map[string]interface{}{{
+			"type":        "oauthbearertoken",
+			"name":        "OAuth Bearer Token",
+			"description": "Authentication with the bearer token configured in SCIM_BEARER_TOKEN",
+			"primary":     true,
+		}},
+	}
+}
+
+// ResourceTypes lists the resource types the service provides
+func ResourceTypes(baseURL string) map[string]interface{} {
+	return map[string]interface{}{
+		"schemas":      []string{ListResponseSchema},
+		"totalResults": 1,
+		"Resources": []map[string]interface{}{{
+			"schemas":  []string{ResourceTypeSchema},
+			"id":       "User",
+			"name":     "User",
+			"endpoint": "/Users",
+			"schema":   UserSchema,
+			"meta":     map[string]interface{}{"resourceType": "ResourceType", "location": baseURL + "/ResourceTypes/User"},
+		}},
+	}
+}
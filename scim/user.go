@@ -0,0 +1,149 @@
+package scim
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gaurav2721/notification-service/external_services/user"
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// filterRegex matches the filters identity providers send to look users up, such as
+// userName eq "john.doe@company.com"
+var filterRegex = regexp.MustCompile(`^\s*(\w+)\s+(?i:eq)\s+"((?:[^"\\]|\\.)*)"\s*$`)
+
+// FromUser returns the SCIM resource of a user; baseURL is the URL of the SCIM endpoint. Users
+// created through the user API have no user name, their email address is used instead.
+func FromUser(u *models.User, baseURL string) User {
+	userName := u.UserName
+	if userName == "" {
+		userName = u.Email
+	}
+	active := u.IsActive
+	given, family := splitName(u.FullName)
+	resource := User{
+		Schemas:     []string{UserSchema},
+		ID:          u.ID,
+		ExternalID:  u.ExternalID,
+		UserName:    userName,
+		DisplayName: u.FullName,
+		Locale:      u.Locale,
+		Timezone:    u.Timezone,
+		Active:      &active,
+		Meta: &Meta{
+			ResourceType: "User",
+			Created:      u.CreatedAt,
+			LastModified: u.UpdatedAt,
+			Location:     baseURL + "/Users/" + u.ID,
+		},
+	}
+	if u.FullName != "" {
+		resource.Name = &Name{Formatted: u.FullName, GivenName: given, FamilyName: family}
+	}
+	for _, point := range u.ContactPoints() {
+		value := MultiValue{Value: point.Address, Primary: point.Primary}
+		switch point.Type {
+		case models.ContactEmail:
+			resource.Emails = append(resource.Emails, value)
+		case models.ContactPhone:
+			resource.PhoneNumbers = append(resource.PhoneNumbers, value)
+		}
+	}
+	return resource
+}
+
+// ApplyTo replaces the SCIM attributes of a user with those of the resource, as creating a user
+// or replacing it with PUT does. Attributes the resource does not have are cleared, except
+// active, which is left as it is. Slack channels are not SCIM attributes and are kept.
+func (r *User) ApplyTo(u *models.User) error {
+	if strings.TrimSpace(r.UserName) == "" {
+		return NewError(http.StatusBadRequest, ErrorTypeInvalidValue, "userName is required")
+	}
+
+	u.UserName = r.UserName
+	u.ExternalID = r.ExternalID
+	u.Locale = r.Locale
+	u.Timezone = r.Timezone
+	u.FullName = r.DisplayName
+	if u.FullName == "" && r.Name != nil {
+		u.FullName = r.Name.Formatted
+		if u.FullName == "" {
+			u.FullName = joinName(r.Name.GivenName, r.Name.FamilyName)
+		}
+	}
+	if r.Active != nil {
+		u.IsActive = *r.Active
+	}
+
+	if err := setContacts(u, models.ContactEmail, r.Emails); err != nil {
+		return err
+	}
+	return setContacts(u, models.ContactPhone, r.PhoneNumbers)
+}
+
+// setContacts replaces the contact points of a type with values, the one marked primary, or
+// else the first, becoming the primary address. Addresses the user keeps stay verified.
+func setContacts(u *models.User, contactType string, values []MultiValue) error {
+	verified := make(map[string]models.ContactPoint)
+	for _, point := range u.ContactPoints() {
+		if point.Type != contactType {
+			continue
+		}
+		if point.VerifiedAt != nil {
+			verified[point.Address] = point
+		}
+		if err := u.RemoveContactPoint(contactType, point.Address); err != nil {
+			return err
+		}
+	}
+
+	for _, value := range values {
+		err := u.AddContactPoint(contactType, value.Value, value.Primary)
+		if errors.Is(err, models.ErrContactPointExists) {
+			continue
+		}
+		if err != nil {
+			return NewError(http.StatusBadRequest, ErrorTypeInvalidValue, "%s %q: %v", contactType, value.Value, err)
+		}
+		if point, ok := verified[value.Value]; ok {
+			u.MarkContactVerified(contactType, value.Value, *point.VerifiedAt)
+		}
+	}
+	return nil
+}
+
+// ParseFilter converts a SCIM filter to a user filter. Only equality filters on id, userName and
+// externalId are supported, which is what identity providers use to look users up.
+func ParseFilter(filter string) (user.UserFilter, error) {
+	if strings.TrimSpace(filter) == "" {
+		return user.UserFilter{}, nil
+	}
+	match := filterRegex.FindStringSubmatch(filter)
+	if match == nil {
+		return user.UserFilter{}, NewError(http.StatusBadRequest, ErrorTypeInvalidFilter, "unsupported filter %q, expected an attribute eq \"value\"", filter)
+	}
+
+	value := strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(match[2])
+	switch strings.ToLower(match[1]) {
+	case "id":
+		return user.UserFilter{ID: value}, nil
+	case "username":
+		return user.UserFilter{UserName: value}, nil
+	case "externalid":
+		return user.UserFilter{ExternalID: value}, nil
+	}
+	return user.UserFilter{}, NewError(http.StatusBadRequest, ErrorTypeInvalidFilter, "filtering on %s is not supported, use id, userName or externalId", match[1])
+}
+
+// splitName splits a full name into the given name and the family name
+func splitName(fullName string) (given, family string) {
+	given, family, _ = strings.Cut(strings.TrimSpace(fullName), " ")
+	return given, strings.TrimSpace(family)
+}
+
+// joinName joins a given and a family name into a full name
+func joinName(given, family string) string {
+	return strings.TrimSpace(given + " " + family)
+}
@@ -0,0 +1,134 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/external_services/user"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseUser(t *testing.T, data string) User {
+	t.Helper()
+	var resource User
+	require.NoError(t, json.Unmarshal([]byte(data), &resource))
+	return resource
+}
+
+// assertSCIMError checks that err is a SCIM error with the given status and error type
+func assertSCIMError(t *testing.T, err error, status int, scimType string) {
+	t.Helper()
+	var scimErr *Error
+	require.True(t, errors.As(err, &scimErr), "expected a SCIM error, got %v", err)
+	assert.Equal(t, status, scimErr.StatusCode())
+	assert.Equal(t, scimType, scimErr.SCIMType)
+}
+
+func TestUser_ApplyTo(t *testing.T) {
+	resource := parseUser(t, `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"userName": "jane.doe@company.com",
+		"externalId": "00u1abcd",
+		"name": {"givenName": "Jane", "familyName": "Doe"},
+		"emails": [
+			{"value": "jane.doe@company.com", "type": "work"},
+			{"value": "jane@home.org", "type": "home", "primary": true}
+		],
+		"phoneNumbers": [{"value": "+14155550123", "type": "mobile"}],
+		"locale": "en-US",
+		"timezone": "America/Los_Angeles",
+		"active": false
+	}`)
+
+	u := &models.User{ID: "user-001", IsActive: true}
+	require.NoError(t, resource.ApplyTo(u))
+	assert.Equal(t, "jane.doe@company.com", u.UserName)
+	assert.Equal(t, "00u1abcd", u.ExternalID)
+	assert.Equal(t, "Jane Doe", u.FullName)
+	assert.Equal(t, "jane@home.org", u.Email)
+	assert.True(t, u.HasContactPoint(models.ContactEmail, "jane.doe@company.com"))
+	assert.Equal(t, "+14155550123", u.PhoneNumber)
+	assert.Equal(t, "en-US", u.Locale)
+	assert.Equal(t, "America/Los_Angeles", u.Timezone)
+	assert.False(t, u.IsActive)
+
+	resource = parseUser(t, `{"userName": "jane.doe@company.com", "emails": [{"value": "not-an-email"}]}`)
+	assertSCIMError(t, resource.ApplyTo(u), http.StatusBadRequest, ErrorTypeInvalidValue)
+
+	resource = parseUser(t, `{"displayName": "No User Name"}`)
+	assertSCIMError(t, resource.ApplyTo(u), http.StatusBadRequest, ErrorTypeInvalidValue)
+}
+
+func TestUser_ApplyTo_KeepsVerification(t *testing.T) {
+	verifiedAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	u := &models.User{ID: "user-001", Email: "jane.doe@company.com", EmailVerifiedAt: &verifiedAt, SlackChannel: "#jane"}
+	require.NoError(t, u.AddContactPoint(models.ContactEmail, "old@company.com", false))
+
+	resource := parseUser(t, `{"userName": "jane", "emails": [{"value": "new@company.com", "primary": true}, {"value": "jane.doe@company.com"}]}`)
+	require.NoError(t, resource.ApplyTo(u))
+
+	assert.Equal(t, "new@company.com", u.Email)
+	assert.Nil(t, u.EmailVerifiedAt)
+	assert.False(t, u.HasContactPoint(models.ContactEmail, "old@company.com"))
+	address, verified := u.PreferredContact(models.ContactEmail)
+	assert.Equal(t, "jane.doe@company.com", address)
+	assert.True(t, verified)
+	// Slack channels are not SCIM attributes
+	assert.Equal(t, "#jane", u.SlackChannel)
+}
+
+func TestFromUser(t *testing.T) {
+	u := &models.User{
+		ID:          "user-001",
+		UserName:    "jane.doe@company.com",
+		FullName:    "Jane van Doe",
+		Email:       "jane.doe@company.com",
+		PhoneNumber: "+14155550123",
+		IsActive:    true,
+	}
+	require.NoError(t, u.AddContactPoint(models.ContactEmail, "jane@home.org", false))
+
+	resource := FromUser(u, "https://notify.company.com/scim/v2")
+	assert.Equal(t, []string{UserSchema}, resource.Schemas)
+	assert.Equal(t, "user-001", resource.ID)
+	assert.Equal(t, "jane.doe@company.com", resource.UserName)
+	require.NotNil(t, resource.Name)
+	assert.Equal(t, "Jane", resource.Name.GivenName)
+	assert.Equal(t, "van Doe", resource.Name.FamilyName)
+	assert.Equal(t, []MultiValue{{Value: "jane.doe@company.com", Primary: true}, {Value: "jane@home.org"}}, resource.Emails)
+	assert.Equal(t, []MultiValue{{Value: "+14155550123", Primary: true}}, resource.PhoneNumbers)
+	require.NotNil(t, resource.Active)
+	assert.True(t, *resource.Active)
+	assert.Equal(t, "https://notify.company.com/scim/v2/Users/user-001", resource.Meta.Location)
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		filter   string
+		expected user.UserFilter
+	}{
+		{``, user.UserFilter{}},
+		{`userName eq "jane.doe@company.com"`, user.UserFilter{UserName: "jane.doe@company.com"}},
+		{`username EQ "jane"`, user.UserFilter{UserName: "jane"}},
+		{`externalId eq "00u1abcd"`, user.UserFilter{ExternalID: "00u1abcd"}},
+		{`id eq "user-001"`, user.UserFilter{ID: "user-001"}},
+		{`userName eq "say \"hi\""`, user.UserFilter{UserName: `say "hi"`}},
+	}
+	for _, test := range tests {
+		t.Run(test.filter, func(t *testing.T) {
+			filter, err := ParseFilter(test.filter)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, filter)
+		})
+	}
+
+	for _, filter := range []string{`userName co "jane"`, `displayName eq "Jane"`, `userName eq "a" and active eq true`} {
+		_, err := ParseFilter(filter)
+		assertSCIMError(t, err, http.StatusBadRequest, ErrorTypeInvalidFilter)
+	}
+}
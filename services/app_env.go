@@ -0,0 +1,83 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gaurav2721/notification-service/constants"
+	"github.com/gaurav2721/notification-service/external_services/apns"
+	"github.com/gaurav2721/notification-service/external_services/email"
+	"github.com/gaurav2721/notification-service/external_services/fcm"
+	"github.com/gaurav2721/notification-service/external_services/googlechat"
+	"github.com/gaurav2721/notification-service/external_services/incident"
+	"github.com/gaurav2721/notification-service/external_services/rcs"
+	"github.com/gaurav2721/notification-service/external_services/slack"
+	"github.com/gaurav2721/notification-service/inmemory"
+)
+
+// IsProduction reports whether APP_ENV selects production mode. In production gin runs in
+// release mode, no seed data is loaded and the service refuses to start unless every provider
+// is configured with real credentials.
+func IsProduction() bool {
+	return strings.EqualFold(strings.TrimSpace(os.Getenv(constants.APP_ENV)), constants.ProductionAppEnv)
+}
+
+// checkProductionProviders returns an error when a provider would write notifications to a file
+// or record them in memory instead of delivering them
+func (c *ServiceContainer) checkProductionProviders() error {
+	if os.Getenv(constants.RUNTIME_PROFILE) == inmemory.ProfileName {
+		return fmt.Errorf("the %s runtime profile cannot be used in production", inmemory.ProfileName)
+	}
+
+	_, emailMock := c.emailService.(*email.MockEmailServiceImpl)
+	_, slackMock := c.slackService.(*slack.MockSlackServiceImpl)
+	// Google Chat, incidents and RCS are optional, so their mocks only count when the channel was enabled
+	_, googleChatMock := c.googleChatService.(*googlechat.MockGoogleChatServiceImpl)
+	googleChatMock = googleChatMock && googlechat.Enabled()
+	_, incidentMock := c.incidentService.(*incident.MockIncidentServiceImpl)
+	incidentMock = incidentMock && incident.Enabled()
+	_, rcsMock := c.rcsService.(*rcs.MockRCSServiceImpl)
+	rcsMock = rcsMock && rcs.Enabled()
+	_, apnsMock := c.apnsService.(*apns.MockAPNSServiceImpl)
+	_, fcmMock := c.fcmService.(*fcm.MockFCMServiceImpl)
+
+	providers := []struct {
+		name    string
+		mock    bool
+		envVars []string
+	}{
+		{"email", emailMock, []string{constants.SMTP_HOST, constants.SMTP_PORT, constants.SMTP_USERNAME, constants.SMTP_PASSWORD}},
+		{"slack", slackMock, []string{constants.SLACK_BOT_TOKEN, constants.SLACK_CHANNEL_ID}},
+		{"google_chat", googleChatMock, []string{constants.GOOGLE_CHAT_ENABLED}},
+		{"incident", incidentMock, []string{constants.INCIDENT_PROVIDER}},
+		{"rcs", rcsMock, []string{constants.RCS_AGENT_ID, constants.RCS_CREDENTIALS_FILE}},
+		{"apns", apnsMock, []string{constants.APNS_BUNDLE_ID, constants.APNS_KEY_ID, constants.APNS_TEAM_ID, constants.APNS_PRIVATE_KEY_PATH}},
+		{"fcm", fcmMock, []string{constants.FCM_SERVER_KEY, constants.FCM_TIMEOUT, constants.FCM_BATCH_SIZE}},
+	}
+
+	var problems []string
+	for _, provider := range providers {
+		if !provider.mock {
+			continue
+		}
+
+		var missing []string
+		for _, key := range provider.envVars {
+			if os.Getenv(key) == "" {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("%s is missing %s", provider.name, strings.Join(missing, ", ")))
+		} else {
+			// Credentials are set, so the provider fell back to its mock because of the transport
+			problems = append(problems, fmt.Sprintf("%s has an invalid proxy or TLS configuration", provider.name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("real provider credentials are required in production: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/gaurav2721/notification-service/constants"
+	"github.com/gaurav2721/notification-service/external_services/apns"
+	"github.com/gaurav2721/notification-service/external_services/email"
+	"github.com/gaurav2721/notification-service/external_services/fcm"
+	"github.com/gaurav2721/notification-service/external_services/slack"
+	"github.com/gaurav2721/notification-service/inmemory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsProduction(t *testing.T) {
+	t.Setenv(constants.APP_ENV, " Production ")
+	assert.True(t, IsProduction())
+
+	t.Setenv(constants.APP_ENV, "staging")
+	assert.False(t, IsProduction())
+}
+
+func TestCheckProductionProviders(t *testing.T) {
+	for _, key := range []string{
+		constants.SMTP_HOST, constants.SMTP_PORT, constants.SMTP_USERNAME, constants.SMTP_PASSWORD,
+		constants.SLACK_BOT_TOKEN, constants.SLACK_CHANNEL_ID, constants.RUNTIME_PROFILE,
+	} {
+		t.Setenv(key, "")
+	}
+	t.Setenv(constants.SMTP_HOST, "smtp.company.com")
+	t.Setenv(constants.SMTP_PORT, "587")
+
+	container := &ServiceContainer{
+		emailService: &email.MockEmailServiceImpl{},
+		slackService: &slack.MockSlackServiceImpl{},
+		apnsService:  &apns.MockAPNSServiceImpl{},
+		fcmService:   &fcm.MockFCMServiceImpl{},
+	}
+	err := container.checkProductionProviders()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "email is missing SMTP_USERNAME, SMTP_PASSWORD; slack is missing SLACK_BOT_TOKEN, SLACK_CHANNEL_ID")
+
+	// Credentials that are set but still produce a mock point at the transport configuration
+	t.Setenv(constants.SLACK_BOT_TOKEN, "xoxb-token")
+	t.Setenv(constants.SLACK_CHANNEL_ID, "C1234567890")
+	err = container.checkProductionProviders()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "slack has an invalid proxy or TLS configuration")
+
+	t.Setenv(constants.RUNTIME_PROFILE, inmemory.ProfileName)
+	assert.ErrorContains(t, container.checkProductionProviders(), "runtime profile cannot be used in production")
+}
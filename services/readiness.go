@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gaurav2721/notification-service/external_services/consumers"
+	"github.com/gaurav2721/notification-service/external_services/kafka"
+	"github.com/gaurav2721/notification-service/external_services/messagebus"
+)
+
+// addReadinessChecks registers the dependencies that must be ready before the service takes
+// traffic. Providers are not called: an outage of a provider would take every instance out of
+// rotation at once, while the queues and retries absorb it. Run -selftest to verify them.
+func (c *ServiceContainer) addReadinessChecks() {
+	c.probes.AddReadinessCheck("consumers", func(ctx context.Context) error {
+		return checkConsumers(c.consumerManager)
+	})
+	c.probes.AddReadinessCheck("queues", func(ctx context.Context) error {
+		return checkQueues(c.kafkaService)
+	})
+	c.probes.AddReadinessCheck("message_bus", func(ctx context.Context) error {
+		return messagebus.CheckHealth(c.kafkaService)
+	})
+	c.probes.AddReadinessCheck("providers", func(ctx context.Context) error {
+		return c.checkProviders()
+	})
+	c.probes.AddReadinessCheck("storage", func(ctx context.Context) error {
+		if c.userService == nil || c.notificationService == nil {
+			return fmt.Errorf("the user and notification stores are not initialized")
+		}
+		return nil
+	})
+}
+
+// checkConsumers returns an error naming the channels whose workers are not running
+func checkConsumers(manager consumers.ConsumerManager) error {
+	var stopped []string
+	for notificationType, running := range manager.GetStatus() {
+		if !running {
+			stopped = append(stopped, string(notificationType))
+		}
+	}
+	if len(stopped) > 0 {
+		sort.Strings(stopped)
+		return fmt.Errorf("consumers are not running: %s", strings.Join(stopped, ", "))
+	}
+	return nil
+}
+
+// checkQueues returns an error naming the channels whose buffers are full, so new
+// notifications would block until the consumers catch up
+func checkQueues(kafkaService kafka.KafkaService) error {
+	var full []string
+	for _, queue := range kafka.GetQueueStats(kafkaService) {
+		if queue.Capacity > 0 && queue.Depth >= queue.Capacity {
+			full = append(full, fmt.Sprintf("%s (%d/%d)", queue.Name, queue.Depth, queue.Capacity))
+		}
+	}
+	if len(full) > 0 {
+		return fmt.Errorf("queues are full: %s", strings.Join(full, ", "))
+	}
+	return nil
+}
+
+// checkProviders returns an error naming the provider services that are not initialized
+func (c *ServiceContainer) checkProviders() error {
+	var missing []string
+	for _, provider := range []struct {
+		name    string
+		service interface{}
+	}{
+		{"email", c.emailService},
+		{"slack", c.slackService},
+		{"google_chat", c.googleChatService},
+		{"incident", c.incidentService},
+		{"rcs", c.rcsService},
+		{"apns", c.apnsService},
+		{"fcm", c.fcmService},
+	} {
+		if provider.service == nil {
+			missing = append(missing, provider.name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("providers are not initialized: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
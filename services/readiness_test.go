@@ -0,0 +1,28 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/gaurav2721/notification-service/constants"
+	"github.com/gaurav2721/notification-service/external_services/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckQueues(t *testing.T) {
+	t.Setenv(constants.SlackChannelBufferSizeEnvVar, "2")
+	kafkaService, err := kafka.NewKafkaService()
+	require.NoError(t, err)
+	defer kafkaService.Close()
+
+	assert.NoError(t, checkQueues(kafkaService))
+
+	kafkaService.GetSlackChannel() <- "first"
+	kafkaService.GetSlackChannel() <- "second"
+	assert.EqualError(t, checkQueues(kafkaService), "queues are full: slack (2/2)")
+}
+
+func TestCheckProviders(t *testing.T) {
+	container := &ServiceContainer{slackService: &checkedSlackService{}}
+	assert.EqualError(t, container.checkProviders(), "providers are not initialized: email, google_chat, incident, rcs, apns, fcm")
+}
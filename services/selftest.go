@@ -0,0 +1,278 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gaurav2721/notification-service/constants"
+	"github.com/gaurav2721/notification-service/external_services/consumers"
+	"github.com/gaurav2721/notification-service/external_services/messagebus"
+	"github.com/gaurav2721/notification-service/external_services/pushapps"
+	"github.com/gaurav2721/notification-service/external_services/scanner"
+	"github.com/gaurav2721/notification-service/external_services/user"
+	"github.com/gaurav2721/notification-service/logger"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/google/uuid"
+)
+
+// Self-test check outcomes
+const (
+	SelfTestPassed  = "passed"
+	SelfTestFailed  = "failed"
+	SelfTestSkipped = "skipped"
+)
+
+// SelfTestCheck is the outcome of one self-test check
+type SelfTestCheck struct {
+	Name     string
+	Status   string
+	Detail   string
+	Duration time.Duration
+}
+
+// SelfTestReport lists the outcomes of the self-test checks in the order they ran
+type SelfTestReport struct {
+	Checks []SelfTestCheck
+}
+
+// Passed reports whether no check failed
+func (r *SelfTestReport) Passed() bool {
+	for _, check := range r.Checks {
+		if check.Status == SelfTestFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// Print writes one line per check followed by a summary
+func (r *SelfTestReport) Print(w io.Writer) {
+	failed := 0
+	for _, check := range r.Checks {
+		line := fmt.Sprintf("%-7s %s (%s)", strings.ToUpper(check.Status), check.Name, check.Duration.Round(time.Millisecond))
+		if check.Detail != "" {
+			line += ": " + check.Detail
+		}
+		fmt.Fprintln(w, line)
+		if check.Status == SelfTestFailed {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(w, "Self-test failed: %d of %d checks failed\n", failed, len(r.Checks))
+		return
+	}
+	fmt.Fprintf(w, "Self-test passed: %d checks\n", len(r.Checks))
+}
+
+// run runs a check with the self-test timeout and records its outcome
+func (r *SelfTestReport) run(ctx context.Context, timeout time.Duration, name string, check func(ctx context.Context) (string, error)) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	detail, err := check(ctx)
+	result := SelfTestCheck{Name: name, Status: SelfTestPassed, Detail: detail, Duration: time.Since(start)}
+
+	var skipped skipCheck
+	switch {
+	case errors.As(err, &skipped):
+		result.Status = SelfTestSkipped
+		result.Detail = err.Error()
+	case err != nil:
+		result.Status = SelfTestFailed
+		result.Detail = err.Error()
+	}
+	r.Checks = append(r.Checks, result)
+}
+
+// skipCheck is returned by checks that do not apply to the configuration
+type skipCheck string
+
+func (s skipCheck) Error() string {
+	return string(s)
+}
+
+// connectionChecker is implemented by the provider services that can verify their connection
+// and credentials without delivering a notification
+type connectionChecker interface {
+	CheckConnection(ctx context.Context) error
+}
+
+// RunSelfTest validates the configuration, connects to every configured provider and to the
+// message bus broker, and sends a test message to the sink configured by SELFTEST_SINK. It
+// starts no consumers and serves no requests, so it can run in deployment pipelines and init
+// containers next to a running service.
+func RunSelfTest(ctx context.Context) *SelfTestReport {
+	timeout := time.Duration(getEnvAsInt(constants.SelfTestTimeoutSecondsEnvVar, constants.DefaultSelfTestTimeoutSeconds)) * time.Second
+	report := &SelfTestReport{}
+
+	// Configuration that the service would otherwise reject or fall back from at startup
+	report.run(ctx, timeout, "config: log output", func(ctx context.Context) (string, error) {
+		_, err := logger.LoadOutputConfig()
+		return "", err
+	})
+	report.run(ctx, timeout, "config: push apps", func(ctx context.Context) (string, error) {
+		registry, err := pushapps.LoadFromEnv()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d APNS, %d FCM apps", len(registry.APNSApps()), len(registry.FCMApps())), nil
+	})
+	report.run(ctx, timeout, "config: seed fixtures", checkSeedFixtures)
+	report.run(ctx, timeout, "config: email warm-up", func(ctx context.Context) (string, error) {
+		_, err := consumers.ParseWarmupSchedules(os.Getenv(constants.EmailWarmupSchedulesEnvVar))
+		return "", err
+	})
+	report.run(ctx, timeout, "config: attachment scanner", func(ctx context.Context) (string, error) {
+		scannerURL := os.Getenv(constants.AttachmentScannerURLEnvVar)
+		if scannerURL == "" {
+			return "", skipCheck("attachment scanning is disabled")
+		}
+		_, err := scanner.New(scannerURL, timeout)
+		return "", err
+	})
+
+	container := &ServiceContainer{}
+	var providerErr error
+	report.run(ctx, timeout, "config: provider transports", func(ctx context.Context) (string, error) {
+		providerErr = NewServiceFactory().newProviderServices(container)
+		return "", providerErr
+	})
+	// A service that refuses to start has no provider connections to check
+	if providerErr == nil {
+		container.applyRuntimeProfile()
+
+		report.run(ctx, timeout, "config: production providers", func(ctx context.Context) (string, error) {
+			if !IsProduction() {
+				return "", skipCheck("APP_ENV is not production")
+			}
+			return "", container.checkProductionProviders()
+		})
+
+		// Connections to the providers and the message bus broker
+		for _, provider := range []struct {
+			name    string
+			service interface{}
+		}{
+			{"email", container.emailService},
+			{"slack", container.slackService},
+			{"google_chat", container.googleChatService},
+			{"incident", container.incidentService},
+			{"rcs", container.rcsService},
+			{"apns", container.apnsService},
+			{"fcm", container.fcmService},
+		} {
+			service := provider.service
+			report.run(ctx, timeout, "provider: "+provider.name, func(ctx context.Context) (string, error) {
+				checker, ok := service.(connectionChecker)
+				if !ok {
+					return "", skipCheck("no credentials configured, notifications are simulated")
+				}
+				return "", checker.CheckConnection(ctx)
+			})
+		}
+	}
+
+	busConfig := messagebus.LoadConfigFromEnv()
+	report.run(ctx, timeout, "message bus: "+busConfig.Backend, func(ctx context.Context) (string, error) {
+		if busConfig.Backend == "" || busConfig.Backend == messagebus.BackendMemory {
+			return "", skipCheck("messages are kept in process")
+		}
+		return "", messagebus.CheckConnection(busConfig)
+	})
+
+	// End-to-end delivery through a real provider
+	report.run(ctx, timeout, "sink", func(ctx context.Context) (string, error) {
+		if providerErr != nil {
+			return "", skipCheck("the provider services could not be created")
+		}
+		return container.sendSelfTestMessage(ctx, os.Getenv(constants.SelfTestSinkEnvVar))
+	})
+
+	return report
+}
+
+// checkSeedFixtures loads the fixtures at SEED_FIXTURES_PATH, which production never loads
+func checkSeedFixtures(ctx context.Context) (string, error) {
+	path := os.Getenv(constants.SEED_FIXTURES_PATH)
+	if path == "" || IsProduction() {
+		return "", skipCheck("no seed fixtures are loaded")
+	}
+
+	seed, err := user.LoadSeed(path)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d users, %d devices", len(seed.Users), len(seed.Devices)), nil
+}
+
+// parseSelfTestSink splits a SELFTEST_SINK value of the form email:<address> or slack into
+// its channel and recipient. Slack messages are posted to SLACK_CHANNEL_ID.
+func parseSelfTestSink(sink string) (channel, recipient string, err error) {
+	channel, recipient, _ = strings.Cut(strings.TrimSpace(sink), ":")
+	channel = strings.ToLower(channel)
+
+	switch channel {
+	case string(models.EmailNotification):
+		if err := models.ValidateEmailAddress(recipient); err != nil {
+			return "", "", fmt.Errorf("%s must name an email address, e.g. email:ops@company.com", constants.SelfTestSinkEnvVar)
+		}
+	case string(models.SlackNotification):
+		recipient = "selftest"
+	default:
+		return "", "", fmt.Errorf("%s must be email:<address> or slack, got %q", constants.SelfTestSinkEnvVar, sink)
+	}
+	return channel, recipient, nil
+}
+
+// sendSelfTestMessage sends a test message to the sink through its provider service
+func (c *ServiceContainer) sendSelfTestMessage(ctx context.Context, sink string) (string, error) {
+	if sink == "" {
+		return "", skipCheck(constants.SelfTestSinkEnvVar + " is not set")
+	}
+	channel, recipient, err := parseSelfTestSink(sink)
+	if err != nil {
+		return "", err
+	}
+
+	providers := map[string]interface{}{
+		string(models.EmailNotification): c.emailService,
+		string(models.SlackNotification): c.slackService,
+	}
+	if _, ok := providers[channel].(connectionChecker); !ok {
+		// A simulated provider accepts the message without delivering it
+		return "", fmt.Errorf("the %s provider has no credentials configured", channel)
+	}
+
+	hostname, _ := os.Hostname()
+	text := fmt.Sprintf("Self-test of the notification service on %s at %s", hostname, time.Now().UTC().Format(time.RFC3339))
+	id := uuid.New().String()
+
+	switch channel {
+	case string(models.EmailNotification):
+		_, err = c.emailService.SendEmail(ctx, &models.EmailNotificationRequest{
+			ID:        id,
+			Type:      channel,
+			Content:   models.EmailContent{Subject: "Notification service self-test", EmailBody: text},
+			Recipient: recipient,
+		})
+	case string(models.SlackNotification):
+		_, err = c.slackService.SendSlackMessage(ctx, &models.SlackNotificationRequest{
+			ID:        id,
+			Type:      channel,
+			Content:   models.SlackContent{Text: text},
+			Recipient: recipient,
+		})
+	}
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("sent %s message %s", channel, id), nil
+}
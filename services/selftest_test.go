@@ -0,0 +1,141 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/constants"
+	"github.com/gaurav2721/notification-service/external_services/email"
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checkedSlackService is a Slack service with credentials that records the messages it sends
+type checkedSlackService struct {
+	sent    []*models.SlackNotificationRequest
+	sendErr error
+}
+
+func (s *checkedSlackService) SendSlackMessage(ctx context.Context, notification interface{}) (interface{}, error) {
+	if s.sendErr != nil {
+		return nil, s.sendErr
+	}
+	s.sent = append(s.sent, notification.(*models.SlackNotificationRequest))
+	return nil, nil
+}
+
+func (s *checkedSlackService) CheckConnection(ctx context.Context) error {
+	return nil
+}
+
+func TestParseSelfTestSink(t *testing.T) {
+	channel, recipient, err := parseSelfTestSink(" Email:ops@company.com ")
+	require.NoError(t, err)
+	assert.Equal(t, "email", channel)
+	assert.Equal(t, "ops@company.com", recipient)
+
+	channel, _, err = parseSelfTestSink("slack")
+	require.NoError(t, err)
+	assert.Equal(t, "slack", channel)
+
+	_, _, err = parseSelfTestSink("email:ops")
+	assert.ErrorContains(t, err, "must name an email address")
+
+	_, _, err = parseSelfTestSink("sms:+15550100")
+	assert.ErrorContains(t, err, "must be email:<address> or slack")
+}
+
+func TestSendSelfTestMessage(t *testing.T) {
+	slackService := &checkedSlackService{}
+	container := &ServiceContainer{
+		emailService: &email.MockEmailServiceImpl{},
+		slackService: slackService,
+	}
+
+	_, err := container.sendSelfTestMessage(context.Background(), "")
+	assert.IsType(t, skipCheck(""), err, "no sink configured")
+
+	detail, err := container.sendSelfTestMessage(context.Background(), "slack")
+	require.NoError(t, err)
+	require.Len(t, slackService.sent, 1)
+	assert.Contains(t, slackService.sent[0].Content.Text, "Self-test of the notification service")
+	assert.Contains(t, detail, slackService.sent[0].ID)
+
+	slackService.sendErr = errors.New("channel_not_found")
+	_, err = container.sendSelfTestMessage(context.Background(), "slack")
+	assert.ErrorContains(t, err, "channel_not_found")
+
+	_, err = container.sendSelfTestMessage(context.Background(), "email:ops@company.com")
+	assert.ErrorContains(t, err, "the email provider has no credentials configured")
+}
+
+func TestSelfTestReport(t *testing.T) {
+	report := &SelfTestReport{}
+	report.run(context.Background(), time.Second, "passing", func(ctx context.Context) (string, error) {
+		return "ok", nil
+	})
+	report.run(context.Background(), time.Second, "skipped", func(ctx context.Context) (string, error) {
+		return "", skipCheck("not configured")
+	})
+	assert.True(t, report.Passed())
+
+	report.run(context.Background(), time.Millisecond, "timing out", func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	assert.False(t, report.Passed())
+	assert.Equal(t, []string{SelfTestPassed, SelfTestSkipped, SelfTestFailed}, []string{
+		report.Checks[0].Status, report.Checks[1].Status, report.Checks[2].Status,
+	})
+
+	var out bytes.Buffer
+	report.Print(&out)
+	assert.Contains(t, out.String(), "SKIPPED skipped")
+	assert.Contains(t, out.String(), "FAILED  timing out")
+	assert.Contains(t, out.String(), "context deadline exceeded")
+	assert.Contains(t, out.String(), "Self-test failed: 1 of 3 checks failed")
+}
+
+func TestRunSelfTest_InvalidConfiguration(t *testing.T) {
+	t.Setenv(constants.RUNTIME_PROFILE, "inmemory")
+	t.Setenv(constants.APP_ENV, "")
+	t.Setenv(constants.MessageBusEnvVar, "kinesis")
+	t.Setenv(constants.SelfTestSinkEnvVar, "")
+
+	report := RunSelfTest(context.Background())
+	assert.False(t, report.Passed())
+
+	statuses := make(map[string]string)
+	for _, check := range report.Checks {
+		statuses[check.Name] = check.Status
+	}
+	assert.Equal(t, SelfTestSkipped, statuses["provider: email"], "in-memory providers have nothing to connect to")
+	assert.Equal(t, SelfTestFailed, statuses["message bus: kinesis"])
+	assert.Equal(t, SelfTestSkipped, statuses["sink"])
+}
+
+func TestRunSelfTest_InvalidProviderTransport(t *testing.T) {
+	t.Setenv(constants.RUNTIME_PROFILE, "")
+	t.Setenv(constants.APP_ENV, "")
+	t.Setenv(constants.MessageBusEnvVar, "")
+	t.Setenv(constants.SelfTestSinkEnvVar, "slack")
+	t.Setenv(constants.SLACK_BOT_TOKEN, "xoxb-test")
+	t.Setenv(constants.SLACK_CHANNEL_ID, "C0123")
+	t.Setenv(constants.SLACK_HTTP_PROXY, "proxy.internal:3128")
+
+	report := RunSelfTest(context.Background())
+	assert.False(t, report.Passed())
+
+	statuses := make(map[string]string)
+	for _, check := range report.Checks {
+		statuses[check.Name] = check.Status
+	}
+	assert.Equal(t, SelfTestFailed, statuses["config: provider transports"])
+	assert.NotContains(t, statuses, "provider: slack", "the service does not start, so there is no connection to check")
+	assert.Equal(t, SelfTestSkipped, statuses["message bus: memory"])
+	assert.Equal(t, SelfTestSkipped, statuses["sink"])
+}
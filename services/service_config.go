@@ -0,0 +1,61 @@
+package services
+
+import (
+	"github.com/gaurav2721/notification-service/constants"
+	"github.com/gaurav2721/notification-service/external_services/httpclient"
+)
+
+// ServiceConfig holds the outbound proxy and TLS settings of each provider client, so a
+// corporate proxy, CA bundle or client certificate can be set for one provider only
+type ServiceConfig struct {
+	APNS       httpclient.TransportConfig
+	FCM        httpclient.TransportConfig
+	Slack      httpclient.TransportConfig
+	GoogleChat httpclient.TransportConfig
+	Incident   httpclient.TransportConfig
+	RCS        httpclient.TransportConfig
+}
+
+// LoadServiceConfigFromEnv reads the transport settings of every provider from its
+// <PROVIDER>_HTTP_PROXY, <PROVIDER>_CA_BUNDLE, <PROVIDER>_TLS_CERT_FILE and <PROVIDER>_TLS_KEY_FILE
+// environment variables
+func LoadServiceConfigFromEnv() ServiceConfig {
+	return ServiceConfig{
+		APNS: httpclient.LoadTransportConfigFromEnv(httpclient.EnvKeys{
+			ProxyURL:       constants.APNS_HTTP_PROXY,
+			CABundlePath:   constants.APNS_CA_BUNDLE,
+			ClientCertPath: constants.APNS_TLS_CERT_FILE,
+			ClientKeyPath:  constants.APNS_TLS_KEY_FILE,
+		}),
+		FCM: httpclient.LoadTransportConfigFromEnv(httpclient.EnvKeys{
+			ProxyURL:       constants.FCM_HTTP_PROXY,
+			CABundlePath:   constants.FCM_CA_BUNDLE,
+			ClientCertPath: constants.FCM_TLS_CERT_FILE,
+			ClientKeyPath:  constants.FCM_TLS_KEY_FILE,
+		}),
+		Slack: httpclient.LoadTransportConfigFromEnv(httpclient.EnvKeys{
+			ProxyURL:       constants.SLACK_HTTP_PROXY,
+			CABundlePath:   constants.SLACK_CA_BUNDLE,
+			ClientCertPath: constants.SLACK_TLS_CERT_FILE,
+			ClientKeyPath:  constants.SLACK_TLS_KEY_FILE,
+		}),
+		GoogleChat: httpclient.LoadTransportConfigFromEnv(httpclient.EnvKeys{
+			ProxyURL:       constants.GOOGLE_CHAT_HTTP_PROXY,
+			CABundlePath:   constants.GOOGLE_CHAT_CA_BUNDLE,
+			ClientCertPath: constants.GOOGLE_CHAT_TLS_CERT_FILE,
+			ClientKeyPath:  constants.GOOGLE_CHAT_TLS_KEY_FILE,
+		}),
+		Incident: httpclient.LoadTransportConfigFromEnv(httpclient.EnvKeys{
+			ProxyURL:       constants.INCIDENT_HTTP_PROXY,
+			CABundlePath:   constants.INCIDENT_CA_BUNDLE,
+			ClientCertPath: constants.INCIDENT_TLS_CERT_FILE,
+			ClientKeyPath:  constants.INCIDENT_TLS_KEY_FILE,
+		}),
+		RCS: httpclient.LoadTransportConfigFromEnv(httpclient.EnvKeys{
+			ProxyURL:       constants.RCS_HTTP_PROXY,
+			CABundlePath:   constants.RCS_CA_BUNDLE,
+			ClientCertPath: constants.RCS_TLS_CERT_FILE,
+			ClientKeyPath:  constants.RCS_TLS_KEY_FILE,
+		}),
+	}
+}
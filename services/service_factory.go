@@ -4,9 +4,14 @@ package services
 import (
 	"github.com/gaurav2721/notification-service/external_services/apns"
 	"github.com/gaurav2721/notification-service/external_services/consumers"
+	"github.com/gaurav2721/notification-service/external_services/delivery"
 	"github.com/gaurav2721/notification-service/external_services/email"
 	"github.com/gaurav2721/notification-service/external_services/fcm"
+	"github.com/gaurav2721/notification-service/external_services/googlechat"
+	"github.com/gaurav2721/notification-service/external_services/incident"
 	"github.com/gaurav2721/notification-service/external_services/kafka"
+	"github.com/gaurav2721/notification-service/external_services/messagebus"
+	"github.com/gaurav2721/notification-service/external_services/rcs"
 	"github.com/gaurav2721/notification-service/external_services/slack"
 	"github.com/gaurav2721/notification-service/external_services/user"
 	"github.com/gaurav2721/notification-service/models"
@@ -17,9 +22,13 @@ import (
 type (
 	EmailService        = email.EmailService
 	SlackService        = slack.SlackService
+	GoogleChatService   = googlechat.GoogleChatService
+	IncidentService     = incident.IncidentService
+	RCSService          = rcs.RCSService
 	APNSService         = apns.APNSService
 	FCMService          = fcm.FCMService
 	UserService         = user.UserService
+	DeliveryService     = delivery.DeliveryService
 	KafkaService        = kafka.KafkaService
 	ConsumerManager     = consumers.ConsumerManager
 	NotificationManager = notification_manager.NotificationManager
@@ -63,19 +72,31 @@ var (
 	ErrInvalidUserID     = user.ErrInvalidUserID
 	ErrDeviceInactive    = user.ErrDeviceInactive
 
+	// Delivery service errors
+	ErrInvalidDeliveryAttempt   = delivery.ErrInvalidAttempt
+	ErrDeliveryAttemptsNotFound = delivery.ErrAttemptsNotFound
+
 	// Notification service errors
 	ErrUnsupportedNotificationType = notification_manager.ErrUnsupportedNotificationType
 	ErrNoScheduledTime             = notification_manager.ErrNoScheduledTime
 	ErrTemplateNotFound            = notification_manager.ErrTemplateNotFound
 	ErrInvalidRecipients           = notification_manager.ErrInvalidRecipients
+	ErrNotificationNotFound        = notification_manager.ErrNotificationNotFound
 )
 
 // ServiceFactory provides methods to create service instances
-type ServiceFactory struct{}
+type ServiceFactory struct {
+	config ServiceConfig
+}
 
-// NewServiceFactory creates a new service factory
+// NewServiceFactory creates a new service factory with the provider settings from the environment
 func NewServiceFactory() *ServiceFactory {
-	return &ServiceFactory{}
+	return NewServiceFactoryWithConfig(LoadServiceConfigFromEnv())
+}
+
+// NewServiceFactoryWithConfig creates a new service factory with the given provider settings
+func NewServiceFactoryWithConfig(config ServiceConfig) *ServiceFactory {
+	return &ServiceFactory{config: config}
 }
 
 // NewEmailService creates a new email service instance
@@ -84,18 +105,57 @@ func (f *ServiceFactory) NewEmailService() EmailService {
 }
 
 // NewSlackService creates a new slack service instance
-func (f *ServiceFactory) NewSlackService() SlackService {
-	return slack.NewSlackService()
+func (f *ServiceFactory) NewSlackService() (SlackService, error) {
+	return slack.NewSlackService(f.config.Slack)
+}
+
+// NewGoogleChatService creates a new Google Chat service instance
+func (f *ServiceFactory) NewGoogleChatService() (GoogleChatService, error) {
+	return googlechat.NewGoogleChatService(f.config.GoogleChat)
+}
+
+// NewIncidentService creates a new incident service instance
+func (f *ServiceFactory) NewIncidentService() (IncidentService, error) {
+	return incident.NewIncidentService(f.config.Incident)
+}
+
+// NewRCSService creates a new RCS service instance
+func (f *ServiceFactory) NewRCSService() (RCSService, error) {
+	return rcs.NewRCSService(f.config.RCS)
 }
 
 // NewAPNSService creates a new APNS service instance
-func (f *ServiceFactory) NewAPNSService() APNSService {
-	return apns.NewAPNSService()
+func (f *ServiceFactory) NewAPNSService() (APNSService, error) {
+	return apns.NewAPNSService(f.config.APNS)
 }
 
 // NewFCMService creates a new FCM service instance
-func (f *ServiceFactory) NewFCMService() FCMService {
-	return fcm.NewFCMService()
+func (f *ServiceFactory) NewFCMService() (FCMService, error) {
+	return fcm.NewFCMService(f.config.FCM)
+}
+
+// newProviderServices creates the provider services of container. An invalid proxy or TLS
+// configuration of any provider is returned as an error.
+func (f *ServiceFactory) newProviderServices(container *ServiceContainer) error {
+	var err error
+	container.emailService = f.NewEmailService()
+	if container.slackService, err = f.NewSlackService(); err != nil {
+		return err
+	}
+	if container.googleChatService, err = f.NewGoogleChatService(); err != nil {
+		return err
+	}
+	if container.incidentService, err = f.NewIncidentService(); err != nil {
+		return err
+	}
+	if container.rcsService, err = f.NewRCSService(); err != nil {
+		return err
+	}
+	if container.apnsService, err = f.NewAPNSService(); err != nil {
+		return err
+	}
+	container.fcmService, err = f.NewFCMService()
+	return err
 }
 
 // NewUserService creates a new user service instance
@@ -103,9 +163,31 @@ func (f *ServiceFactory) NewUserService() UserService {
 	return user.NewUserService()
 }
 
-// NewKafkaService creates a new kafka service instance
+// NewUserServiceWithSeed creates a new user service instance with the given users and devices
+func (f *ServiceFactory) NewUserServiceWithSeed(seed *user.Seed) UserService {
+	return user.NewUserServiceWithSeed(seed)
+}
+
+// NewDeliveryService creates a new delivery archive service instance
+func (f *ServiceFactory) NewDeliveryService() DeliveryService {
+	return delivery.NewDeliveryService()
+}
+
+// NewKafkaService creates the message bus selected by MESSAGE_BUS: in-process channels by
+// default, or channels routed through NATS JetStream, RabbitMQ, Amazon SQS or Kafka
 func (f *ServiceFactory) NewKafkaService() (KafkaService, error) {
-	return kafka.NewKafkaService()
+	config := messagebus.LoadConfigFromEnv()
+	if config.Backend == messagebus.BackendKafka {
+		config.KafkaConsumerGroups = consumers.NewKafkaGroups(consumers.KafkaGroupConfig{
+			Brokers:       config.KafkaBrokers,
+			TopicPrefix:   config.KafkaTopicPrefix,
+			Group:         config.KafkaConsumerGroup,
+			Prefetch:      config.Prefetch,
+			MaxDeliveries: config.MaxDeliveries,
+			Timeout:       config.Timeout,
+		})
+	}
+	return messagebus.New(config)
 }
 
 // NewConsumerManager creates a new consumer manager instance
@@ -133,8 +215,9 @@ func (f *ServiceFactory) NewConsumerManagerWithServices(
 func (f *ServiceFactory) NewNotificationManager(
 	userService UserService,
 	kafkaService KafkaService,
+	deliveryService DeliveryService,
 ) NotificationManager {
-	return notification_manager.NewNotificationManagerWithDefaultTemplate(userService, kafkaService)
+	return notification_manager.NewNotificationManagerWithDefaultTemplate(userService, kafkaService, deliveryService)
 }
 
 // NewNotificationManagerWithScheduler creates a new notification manager
@@ -142,8 +225,9 @@ func (f *ServiceFactory) NewNotificationManager(
 func (f *ServiceFactory) NewNotificationManagerWithScheduler(
 	userService UserService,
 	kafkaService KafkaService,
+	deliveryService DeliveryService,
 ) NotificationManager {
-	return notification_manager.NewNotificationManagerWithDefaultTemplate(userService, kafkaService)
+	return notification_manager.NewNotificationManagerWithDefaultTemplate(userService, kafkaService, deliveryService)
 }
 
 // Note: Scheduler is now initialized internally within the notification manager
@@ -5,10 +5,23 @@ import (
 	"context"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/gaurav2721/notification-service/clock"
 	"github.com/gaurav2721/notification-service/constants"
+	"github.com/gaurav2721/notification-service/external_services/concurrency"
 	"github.com/gaurav2721/notification-service/external_services/consumers"
+	"github.com/gaurav2721/notification-service/external_services/directorysync"
+	"github.com/gaurav2721/notification-service/external_services/events"
+	"github.com/gaurav2721/notification-service/external_services/faults"
 	"github.com/gaurav2721/notification-service/external_services/kafka"
+	"github.com/gaurav2721/notification-service/external_services/scanner"
+	"github.com/gaurav2721/notification-service/external_services/slack"
+	"github.com/gaurav2721/notification-service/external_services/slackusersync"
+	"github.com/gaurav2721/notification-service/external_services/user"
+	"github.com/gaurav2721/notification-service/health"
+	"github.com/gaurav2721/notification-service/inmemory"
 	"github.com/sirupsen/logrus"
 )
 
@@ -16,18 +29,32 @@ import (
 type ServiceContainer struct {
 	emailService        EmailService
 	slackService        SlackService
+	googleChatService   GoogleChatService
+	incidentService     IncidentService
+	rcsService          RCSService
 	apnsService         APNSService
 	fcmService          FCMService
 	userService         UserService
+	deliveryService     DeliveryService
 	kafkaService        kafka.KafkaService
 	consumerManager     consumers.ConsumerManager
 	notificationService NotificationManager
+	probes              *health.Probes
+	config              ServiceConfig
+	stopDirectorySync   context.CancelFunc
+	stopSlackUserSync   context.CancelFunc
 }
 
 // NewServiceContainer creates a new service container with all dependencies
 func NewServiceContainer() *ServiceContainer {
+	return NewServiceContainerWithProbes(health.NewProbes())
+}
+
+// NewServiceContainerWithProbes creates a new service container that reports its lifecycle to
+// probes, which the caller may already serve while the services are initialized
+func NewServiceContainerWithProbes(probes *health.Probes) *ServiceContainer {
 	logrus.Debug("Creating new service container")
-	container := &ServiceContainer{}
+	container := &ServiceContainer{probes: probes}
 	container.initializeServices()
 	logrus.Debug("Service container created successfully")
 	return container
@@ -38,18 +65,39 @@ func (c *ServiceContainer) initializeServices() {
 	logrus.Debug("Initializing service dependencies")
 
 	// Create service factory
-	factory := NewServiceFactory()
+	c.config = LoadServiceConfigFromEnv()
+	factory := NewServiceFactoryWithConfig(c.config)
 	logrus.Debug("Service factory created")
 
 	// Initialize core services
 	logrus.Debug("Initializing core services")
-	c.emailService = factory.NewEmailService()
-	c.slackService = factory.NewSlackService()
-	c.apnsService = factory.NewAPNSService()
-	c.fcmService = factory.NewFCMService()
-	c.userService = factory.NewUserService()
+	// A provider that cannot use its proxy or TLS settings must not start, since connecting
+	// directly could bypass a mandatory proxy
+	if err := factory.newProviderServices(c); err != nil {
+		logrus.WithError(err).Fatal("Failed to initialize provider services")
+		panic("Failed to initialize provider services: " + err.Error())
+	}
+	c.userService = factory.NewUserServiceWithSeed(loadSeed())
+	c.deliveryService = factory.NewDeliveryService()
 	logrus.Debug("Core services initialized")
 
+	// Replace the providers with in-memory recorders for local demos without credentials
+	c.applyRuntimeProfile()
+
+	// Production must deliver through the real providers, so fail fast instead of using mocks
+	if IsProduction() {
+		if err := c.checkProductionProviders(); err != nil {
+			logrus.WithError(err).Fatal("Invalid production configuration")
+			panic("Invalid production configuration: " + err.Error())
+		}
+	}
+
+	// Wrap provider services with fault injection when enabled (staging only)
+	c.applyFaultInjection()
+
+	// Cap the requests in flight to each provider, independently of the worker counts
+	c.applyConcurrencyLimits()
+
 	// Initialize Kafka service using factory
 	logrus.Debug("Initializing Kafka service")
 	kafkaService, err := factory.NewKafkaService()
@@ -60,14 +108,41 @@ func (c *ServiceContainer) initializeServices() {
 	c.kafkaService = kafkaService
 	logrus.Debug("Kafka service initialized successfully")
 
+	// Publish delivery outcomes to the notification-events topic; the notification manager
+	// publishes the notification status changes
+	if getEnvAsBool(constants.NotificationEventsEnabledEnvVar, false) {
+		publisher := events.NewPublisher(c.kafkaService.GetNotificationEventsChannel())
+		c.deliveryService = events.NewPublishingDeliveryService(c.deliveryService, publisher)
+		logrus.Info("Publishing notification lifecycle events to the notification-events topic")
+	}
+
 	// Initialize consumer manager using factory with environment configuration
 	logrus.Debug("Initializing consumer manager")
 	// Use the new constructor with service dependencies
 	config := consumers.ConsumerConfig{
 		EmailWorkerCount:       getEnvAsInt(constants.EmailWorkerCountEnvVar, constants.DefaultEmailWorkerCount),
 		SlackWorkerCount:       getEnvAsInt(constants.SlackWorkerCountEnvVar, constants.DefaultSlackWorkerCount),
+		GoogleChatWorkerCount:  getEnvAsInt(constants.GoogleChatWorkerCountEnvVar, constants.DefaultGoogleChatWorkerCount),
+		IncidentWorkerCount:    getEnvAsInt(constants.IncidentWorkerCountEnvVar, constants.DefaultIncidentWorkerCount),
+		RCSWorkerCount:         getEnvAsInt(constants.RCSWorkerCountEnvVar, constants.DefaultRCSWorkerCount),
 		IOSPushWorkerCount:     getEnvAsInt(constants.IOSPushWorkerCountEnvVar, constants.DefaultIOSPushWorkerCount),
 		AndroidPushWorkerCount: getEnvAsInt(constants.AndroidPushWorkerCountEnvVar, constants.DefaultAndroidPushWorkerCount),
+		GoogleChatService:      c.googleChatService,
+		IncidentService:        c.incidentService,
+		RCSService:             c.rcsService,
+		DeliveryService:        c.deliveryService,
+		Middleware:             consumers.DefaultMiddleware(),
+		SlowConsumer: consumers.SlowConsumerConfig{
+			Threshold:         time.Duration(getEnvAsInt(constants.SlowConsumerThresholdSecondsEnvVar, constants.DefaultSlowConsumerThresholdSeconds)) * time.Second,
+			CheckInterval:     time.Duration(getEnvAsInt(constants.SlowConsumerCheckIntervalSecondsEnvVar, constants.DefaultSlowConsumerCheckIntervalSeconds)) * time.Second,
+			AlertSlackChannel: os.Getenv(constants.SlowConsumerAlertSlackChannelEnvVar),
+			ScaleUpWorkers:    getEnvAsInt(constants.SlowConsumerScaleUpWorkersEnvVar, constants.DefaultSlowConsumerScaleUpWorkers),
+		},
+		AnomalyDetection: loadAnomalyDetectionConfig(),
+		EmailWarmup:      loadEmailWarmupConfig(),
+		AttachmentScan:   loadAttachmentScanConfig(),
+		Batching:         loadBatchingConfig(),
+		Retry:            loadRetryConfig(),
 	}
 	c.consumerManager = consumers.NewConsumerManagerWithServices(
 		c.emailService,
@@ -95,12 +170,173 @@ func (c *ServiceContainer) initializeServices() {
 	logrus.Debug("Initializing notification service")
 
 	// The scheduler is initialized internally within the notification manager
-	c.notificationService = factory.NewNotificationManagerWithScheduler(c.userService, c.kafkaService)
+	c.notificationService = factory.NewNotificationManagerWithScheduler(c.userService, c.kafkaService, c.deliveryService)
 	logrus.Debug("Notification service initialized")
 
+	// Load the template directory and reload templates when their files change
+	c.notificationService.StartTemplateReload()
+
+	// Sync templates kept in a Git repository and publish their new versions
+	c.notificationService.StartTemplateGitSync()
+
+	// Email opted-in users a digest of their unread in-app notifications
+	c.notificationService.StartInboxDigests()
+
+	// Archive inbox items that are past their retention
+	c.notificationService.StartInboxRetention()
+
+	// Expire scheduled notifications whose time passed while the service was paused
+	c.notificationService.StartExpirySweeper()
+
+	// Delete uploaded media assets that no template references
+	c.notificationService.StartMediaCleanup()
+
+	// Sync users and their groups from an LDAP directory
+	c.startDirectorySync()
+
+	// Fill in the Slack user IDs of users from the members of the Slack workspace
+	c.startSlackUserSync()
+
+	// Take traffic once every service is running
+	c.addReadinessChecks()
+	c.probes.MarkStarted()
+
 	logrus.Debug("All service dependencies initialized successfully")
 }
 
+// startDirectorySync runs the LDAP directory sync in the background when LDAP_URL is set
+func (c *ServiceContainer) startDirectorySync() {
+	config := directorysync.LoadConfigFromEnv()
+	if !config.Enabled() {
+		return
+	}
+	if err := config.Validate(); err != nil {
+		logrus.WithError(err).Error("Invalid LDAP directory sync configuration, directory sync disabled")
+		return
+	}
+
+	syncer := directorysync.NewSyncer(c.userService, directorysync.NewLDAPDirectory(config), config)
+	ctx, cancel := context.WithCancel(context.Background())
+	c.stopDirectorySync = cancel
+	go syncer.Run(ctx)
+
+	logrus.WithFields(logrus.Fields{
+		"base_dn":  config.BaseDN,
+		"interval": config.Interval,
+	}).Info("LDAP directory sync enabled")
+}
+
+// startSlackUserSync runs the Slack user sync in the background when SLACK_USER_SYNC_ENABLED is set
+func (c *ServiceContainer) startSlackUserSync() {
+	config := slackusersync.LoadConfigFromEnv()
+	if !config.Enabled {
+		return
+	}
+	if err := config.Validate(); err != nil {
+		logrus.WithError(err).Error("Invalid Slack user sync configuration, Slack user sync disabled")
+		return
+	}
+	client, err := slack.NewClient(config.Token, c.config.Slack)
+	if err != nil {
+		logrus.WithError(err).Error("Invalid Slack proxy or TLS configuration, Slack user sync disabled")
+		return
+	}
+
+	syncer := slackusersync.NewSyncer(c.userService, slackusersync.NewSlackWorkspace(client), config)
+	ctx, cancel := context.WithCancel(context.Background())
+	c.stopSlackUserSync = cancel
+	go syncer.Run(ctx)
+
+	logrus.WithField("interval", config.Interval).Info("Slack user sync enabled")
+}
+
+// applyRuntimeProfile swaps the provider services for in-memory recorders when RUNTIME_PROFILE=inmemory
+func (c *ServiceContainer) applyRuntimeProfile() {
+	profile := os.Getenv(constants.RUNTIME_PROFILE)
+	if profile == "" {
+		return
+	}
+	if profile != inmemory.ProfileName {
+		logrus.WithField("profile", profile).Warn("Unknown runtime profile, using configured providers")
+		return
+	}
+
+	recorder := inmemory.NewRecorder(clock.Real())
+	c.emailService = recorder.EmailService()
+	c.slackService = recorder.SlackService()
+	c.googleChatService = recorder.GoogleChatService()
+	c.incidentService = recorder.IncidentService()
+	c.rcsService = recorder.RCSService()
+	c.apnsService = recorder.APNSService()
+	c.fcmService = recorder.FCMService()
+
+	logrus.WithField("profile", profile).Warn("In-memory runtime profile enabled, notifications are not delivered to any provider")
+}
+
+// applyFaultInjection wraps the provider services with the fault injection layer if it is enabled
+func (c *ServiceContainer) applyFaultInjection() {
+	config := faults.LoadFaultConfigFromEnv()
+	if !config.Enabled {
+		return
+	}
+
+	injector, err := faults.NewInjector(config)
+	if err != nil {
+		logrus.WithError(err).Error("Invalid fault injection configuration, fault injection disabled")
+		return
+	}
+
+	if config.AppliesTo(faults.ProviderEmail) {
+		c.emailService = faults.NewFaultyEmailService(c.emailService, injector)
+	}
+	if config.AppliesTo(faults.ProviderSlack) {
+		c.slackService = faults.NewFaultySlackService(c.slackService, injector)
+	}
+	if config.AppliesTo(faults.ProviderGoogleChat) {
+		c.googleChatService = faults.NewFaultyGoogleChatService(c.googleChatService, injector)
+	}
+	if config.AppliesTo(faults.ProviderIncident) {
+		c.incidentService = faults.NewFaultyIncidentService(c.incidentService, injector)
+	}
+	if config.AppliesTo(faults.ProviderRCS) {
+		c.rcsService = faults.NewFaultyRCSService(c.rcsService, injector)
+	}
+	if config.AppliesTo(faults.ProviderAPNS) {
+		c.apnsService = faults.NewFaultyAPNSService(c.apnsService, injector)
+	}
+	if config.AppliesTo(faults.ProviderFCM) {
+		c.fcmService = faults.NewFaultyFCMService(c.fcmService, injector)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"failure_rate": config.FailureRate,
+		"latency":      config.Latency,
+		"jitter":       config.LatencyJitter,
+		"providers":    config.Providers,
+	}).Warn("Fault injection enabled for provider services")
+}
+
+// applyConcurrencyLimits wraps the provider services with the default concurrency limiter, so
+// limits can also be set at runtime for providers without a configured limit
+func (c *ServiceContainer) applyConcurrencyLimits() {
+	limits := concurrency.LoadLimitsFromEnv()
+	if err := concurrency.Default.SetLimits(limits); err != nil {
+		logrus.WithError(err).Error("Invalid provider concurrency limits, providers are not limited")
+	}
+
+	c.emailService = concurrency.NewLimitedEmailService(c.emailService, concurrency.Default)
+	c.slackService = concurrency.NewLimitedSlackService(c.slackService, concurrency.Default)
+	c.googleChatService = concurrency.NewLimitedGoogleChatService(c.googleChatService, concurrency.Default)
+	c.incidentService = concurrency.NewLimitedIncidentService(c.incidentService, concurrency.Default)
+	c.rcsService = concurrency.NewLimitedRCSService(c.rcsService, concurrency.Default)
+	c.apnsService = concurrency.NewLimitedAPNSService(c.apnsService, concurrency.Default)
+	c.fcmService = concurrency.NewLimitedFCMService(c.fcmService, concurrency.Default)
+
+	if len(limits) > 0 {
+		logrus.WithField("limits", limits).Info("Provider concurrency limits enabled")
+	}
+}
+
 // GetEmailService returns the email service
 func (c *ServiceContainer) GetEmailService() EmailService {
 	return c.emailService
@@ -111,6 +347,21 @@ func (c *ServiceContainer) GetSlackService() SlackService {
 	return c.slackService
 }
 
+// GetGoogleChatService returns the Google Chat service
+func (c *ServiceContainer) GetGoogleChatService() GoogleChatService {
+	return c.googleChatService
+}
+
+// GetIncidentService returns the incident service
+func (c *ServiceContainer) GetIncidentService() IncidentService {
+	return c.incidentService
+}
+
+// GetRCSService returns the RCS service
+func (c *ServiceContainer) GetRCSService() RCSService {
+	return c.rcsService
+}
+
 // GetAPNSService returns the APNS service
 func (c *ServiceContainer) GetAPNSService() APNSService {
 	return c.apnsService
@@ -126,6 +377,11 @@ func (c *ServiceContainer) GetUserService() UserService {
 	return c.userService
 }
 
+// GetDeliveryService returns the delivery archive service
+func (c *ServiceContainer) GetDeliveryService() DeliveryService {
+	return c.deliveryService
+}
+
 // GetKafkaService returns the kafka service
 func (c *ServiceContainer) GetKafkaService() kafka.KafkaService {
 	return c.kafkaService
@@ -141,10 +397,35 @@ func (c *ServiceContainer) GetNotificationService() NotificationManager {
 	return c.notificationService
 }
 
+// GetProbes returns the liveness, readiness and startup probes
+func (c *ServiceContainer) GetProbes() *health.Probes {
+	return c.probes
+}
+
 // Shutdown gracefully shuts down all services
 func (c *ServiceContainer) Shutdown(ctx context.Context) error {
 	logrus.Debug("Starting graceful shutdown of service container")
 
+	// Stop taking traffic before the consumers stop
+	if c.probes != nil {
+		c.probes.MarkDraining()
+	}
+
+	// Let the notifications accepted for background fan-out finish before the channels close,
+	// and stop the digest job so it does not publish to a closed message bus
+	if c.notificationService != nil {
+		c.notificationService.StopBackgroundSends(ctx)
+		c.notificationService.StopInboxDigests()
+	}
+
+	// Stop syncing users from the directory
+	if c.stopDirectorySync != nil {
+		c.stopDirectorySync()
+	}
+	if c.stopSlackUserSync != nil {
+		c.stopSlackUserSync()
+	}
+
 	// Stop consumer manager
 	if c.consumerManager != nil {
 		logrus.Debug("Stopping consumer manager")
@@ -172,18 +453,150 @@ func (c *ServiceContainer) Shutdown(ctx context.Context) error {
 type ServiceProvider interface {
 	GetEmailService() EmailService
 	GetSlackService() SlackService
+	GetGoogleChatService() GoogleChatService
+	GetIncidentService() IncidentService
+	GetRCSService() RCSService
 	GetAPNSService() APNSService
 	GetFCMService() FCMService
 	GetUserService() UserService
+	GetDeliveryService() DeliveryService
 	GetKafkaService() kafka.KafkaService
 	GetConsumerManager() consumers.ConsumerManager
 	GetNotificationService() NotificationManager
+	GetProbes() *health.Probes
 	Shutdown(ctx context.Context) error
 }
 
 // Ensure ServiceContainer implements ServiceProvider
 var _ ServiceProvider = (*ServiceContainer)(nil)
 
+// loadAnomalyDetectionConfig returns the detection of failure rate and latency spikes, which
+// ANOMALY_WINDOW_SECONDS=0 disables
+func loadAnomalyDetectionConfig() consumers.AnomalyDetectionConfig {
+	threshold := constants.DefaultAnomalyZScoreThreshold
+	if value := os.Getenv(constants.AnomalyZScoreThresholdEnvVar); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil && parsed > 0 {
+			threshold = parsed
+		} else {
+			logrus.WithField("value", value).Warn("Invalid anomaly z-score threshold, using the default")
+		}
+	}
+
+	return consumers.AnomalyDetectionConfig{
+		Window:            time.Duration(getEnvAsInt(constants.AnomalyWindowSecondsEnvVar, constants.DefaultAnomalyWindowSeconds)) * time.Second,
+		BaselineWindows:   getEnvAsInt(constants.AnomalyBaselineWindowsEnvVar, constants.DefaultAnomalyBaselineWindows),
+		ZScoreThreshold:   threshold,
+		MinMessages:       getEnvAsInt(constants.AnomalyMinMessagesEnvVar, constants.DefaultAnomalyMinMessages),
+		AlertSlackChannel: os.Getenv(constants.AnomalyAlertSlackChannelEnvVar),
+	}
+}
+
+// loadEmailWarmupConfig reads the warm-up schedules of new sending domains. Emails without a
+// from address are sent from SMTP_USERNAME.
+func loadEmailWarmupConfig() consumers.EmailWarmupConfig {
+	schedules, err := consumers.ParseWarmupSchedules(os.Getenv(constants.EmailWarmupSchedulesEnvVar))
+	if err != nil {
+		logrus.WithError(err).Warn("Ignoring invalid email warm-up schedules")
+		return consumers.EmailWarmupConfig{}
+	}
+
+	sender := os.Getenv(constants.SMTP_USERNAME)
+	return consumers.EmailWarmupConfig{
+		Schedules:     schedules,
+		DefaultDomain: sender[strings.LastIndex(sender, "@")+1:],
+	}
+}
+
+// loadAttachmentScanConfig returns the scanning of email attachments and links configured by
+// ATTACHMENT_SCANNER_URL, which is disabled when the URL is not set
+func loadAttachmentScanConfig() consumers.AttachmentScanConfig {
+	scannerURL := os.Getenv(constants.AttachmentScannerURLEnvVar)
+	if scannerURL == "" {
+		return consumers.AttachmentScanConfig{}
+	}
+
+	timeout := time.Duration(getEnvAsInt(constants.AttachmentScanTimeoutSecondsEnvVar, constants.DefaultAttachmentScanTimeoutSeconds)) * time.Second
+	attachmentScanner, err := scanner.New(scannerURL, timeout)
+	if err != nil {
+		// Sending unscanned attachments is not an acceptable fallback
+		logrus.WithError(err).Fatal("Invalid attachment scanner configuration")
+		panic("Invalid attachment scanner configuration: " + err.Error())
+	}
+
+	return consumers.AttachmentScanConfig{
+		Scanner:   attachmentScanner,
+		ScanLinks: getEnvAsBool(constants.AttachmentScanLinksEnvVar, true),
+		FailOpen:  getEnvAsBool(constants.AttachmentScanFailOpenEnvVar, false),
+	}
+}
+
+// loadRetryConfig returns the retries of failed messages. Channels without a max attempts
+// variable of their own use RETRY_MAX_ATTEMPTS.
+func loadRetryConfig() consumers.RetryConfig {
+	channelEnvVars := map[consumers.NotificationType]string{
+		consumers.EmailNotification:       constants.EmailMaxAttemptsEnvVar,
+		consumers.SlackNotification:       constants.SlackMaxAttemptsEnvVar,
+		consumers.GoogleChatNotification:  constants.GoogleChatMaxAttemptsEnvVar,
+		consumers.IncidentNotification:    constants.IncidentMaxAttemptsEnvVar,
+		consumers.RCSNotification:         constants.RCSMaxAttemptsEnvVar,
+		consumers.IOSPushNotification:     constants.IOSPushMaxAttemptsEnvVar,
+		consumers.AndroidPushNotification: constants.AndroidPushMaxAttemptsEnvVar,
+	}
+	channelMaxAttempts := make(map[consumers.NotificationType]int)
+	for notificationType, envVar := range channelEnvVars {
+		if attempts := getEnvAsInt(envVar, 0); attempts > 0 {
+			channelMaxAttempts[notificationType] = attempts
+		}
+	}
+
+	return consumers.RetryConfig{
+		MaxAttempts:        getEnvAsInt(constants.RetryMaxAttemptsEnvVar, constants.DefaultRetryMaxAttempts),
+		ChannelMaxAttempts: channelMaxAttempts,
+		InitialBackoff:     time.Duration(getEnvAsInt(constants.RetryInitialBackoffMsEnvVar, constants.DefaultRetryInitialBackoffMs)) * time.Millisecond,
+		MaxBackoff:         time.Duration(getEnvAsInt(constants.RetryMaxBackoffMsEnvVar, constants.DefaultRetryMaxBackoffMs)) * time.Millisecond,
+	}
+}
+
+// loadBatchingConfig returns the batching of provider calls. Scheduled messages are only batched
+// together with the batching window of scheduled notifications.
+func loadBatchingConfig() consumers.BatchingConfig {
+	config := consumers.BatchingConfig{
+		MulticastWait: time.Duration(getEnvAsInt(constants.FCMMulticastWaitMsEnvVar, 0)) * time.Millisecond,
+	}
+	if getEnvAsInt(constants.ScheduledBatchWindowSecondsEnvVar, 0) > 0 {
+		config.MaxWait = time.Duration(getEnvAsInt(constants.ScheduledBatchMaxWaitMsEnvVar, constants.DefaultScheduledBatchMaxWaitMs)) * time.Millisecond
+	}
+	return config
+}
+
+// loadSeed returns the users and devices the user service starts with: none in production,
+// the fixtures at SEED_FIXTURES_PATH when set, and the built-in sample data otherwise
+func loadSeed() *user.Seed {
+	path := os.Getenv(constants.SEED_FIXTURES_PATH)
+	if IsProduction() {
+		if path != "" {
+			logrus.WithField("path", path).Warn("Seed fixtures are not loaded in production")
+		}
+		return nil
+	}
+
+	if path == "" {
+		return user.DefaultSeed()
+	}
+
+	seed, err := user.LoadSeed(path)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load seed fixtures")
+		panic("Failed to load seed fixtures: " + err.Error())
+	}
+	logrus.WithFields(logrus.Fields{
+		"path":    path,
+		"users":   len(seed.Users),
+		"devices": len(seed.Devices),
+	}).Info("Seed fixtures loaded")
+	return seed
+}
+
 // getEnvAsInt gets an environment variable as an integer with a default value
 func getEnvAsInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
@@ -193,3 +606,13 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getEnvAsBool gets an environment variable as a boolean with a default value
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/constants"
+	"github.com/gaurav2721/notification-service/external_services/consumers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadRetryConfig(t *testing.T) {
+	t.Setenv(constants.RetryMaxAttemptsEnvVar, "4")
+	t.Setenv(constants.RetryInitialBackoffMsEnvVar, "250")
+	t.Setenv(constants.RCSMaxAttemptsEnvVar, "6")
+	t.Setenv(constants.EmailMaxAttemptsEnvVar, "0")
+
+	config := loadRetryConfig()
+	assert.Equal(t, 4, config.MaxAttempts)
+	assert.Equal(t, 250*time.Millisecond, config.InitialBackoff)
+	assert.Equal(t, map[consumers.NotificationType]int{consumers.RCSNotification: 6}, config.ChannelMaxAttempts,
+		"channels without a positive override use RETRY_MAX_ATTEMPTS")
+}
@@ -0,0 +1,191 @@
+// Package textdiff compares texts line by line and formats the differences as unified diffs,
+// e.g. to show what changed between two versions of a template.
+//
+// Lines are matched by their longest common subsequence after trimming the common prefix and
+// suffix, which is fast enough for texts of a few thousand lines such as template bodies.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Op is the kind of an edit
+type Op int
+
+const (
+	// Equal lines are in both texts
+	Equal Op = iota
+	// Delete lines are only in the old text
+	Delete
+	// Insert lines are only in the new text
+	Insert
+)
+
+// Edit is one line of a diff
+type Edit struct {
+	Op   Op
+	Line string
+}
+
+// Lines returns the edits that turn the lines of a into the lines of b. Deletions come before
+// insertions where lines were replaced.
+func Lines(a, b []string) []Edit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := make([]Edit, 0, len(a)+len(b)-prefix-suffix)
+	for _, line := range a[:prefix] {
+		edits = append(edits, Edit{Op: Equal, Line: line})
+	}
+	edits = append(edits, middle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, Edit{Op: Equal, Line: line})
+	}
+	return edits
+}
+
+// middle diffs the lines between the common prefix and suffix using a table of the longest
+// common subsequence of every pair of suffixes
+func middle(a, b []string) []Edit {
+	width := len(b) + 1
+	lcs := make([]int, (len(a)+1)*width)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+			} else if down, right := lcs[(i+1)*width+j], lcs[i*width+j+1]; down >= right {
+				lcs[i*width+j] = down
+			} else {
+				lcs[i*width+j] = right
+			}
+		}
+	}
+
+	var edits []Edit
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			edits = append(edits, Edit{Op: Equal, Line: a[i]})
+			i++
+			j++
+		case lcs[(i+1)*width+j] >= lcs[i*width+j+1]:
+			edits = append(edits, Edit{Op: Delete, Line: a[i]})
+			i++
+		default:
+			edits = append(edits, Edit{Op: Insert, Line: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		edits = append(edits, Edit{Op: Delete, Line: a[i]})
+	}
+	for ; j < len(b); j++ {
+		edits = append(edits, Edit{Op: Insert, Line: b[j]})
+	}
+	return edits
+}
+
+// SplitLines splits text into lines; a final line break does not start another line
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Unified formats the differences between a and b as a unified diff with context lines of
+// context around every change, headed by the names of both texts. It returns an empty string
+// when the texts are equal.
+func Unified(fromName, toName, a, b string, context int) string {
+	if a == b {
+		return ""
+	}
+	if context < 0 {
+		context = 0
+	}
+	edits := Lines(SplitLines(a), SplitLines(b))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for start := 0; start < len(edits); {
+		// Find the next change and extend the hunk while changes are close enough to share context
+		first := start
+		for first < len(edits) && edits[first].Op == Equal {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+		end := first
+		for i := first; i < len(edits); i++ {
+			if edits[i].Op != Equal {
+				end = i + 1
+			} else if i-end >= 2*context {
+				break
+			}
+		}
+
+		hunkStart := max(first-context, start)
+		hunkEnd := min(end+context, len(edits))
+		writeHunk(&out, edits, hunkStart, hunkEnd)
+		start = hunkEnd
+	}
+	return out.String()
+}
+
+// writeHunk writes edits[from:to] as one hunk with its line ranges
+func writeHunk(out *strings.Builder, edits []Edit, from, to int) {
+	oldStart, newStart := 1, 1
+	for _, edit := range edits[:from] {
+		if edit.Op != Insert {
+			oldStart++
+		}
+		if edit.Op != Delete {
+			newStart++
+		}
+	}
+	oldCount, newCount := 0, 0
+	for _, edit := range edits[from:to] {
+		if edit.Op != Insert {
+			oldCount++
+		}
+		if edit.Op != Delete {
+			newCount++
+		}
+	}
+
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+	for _, edit := range edits[from:to] {
+		switch edit.Op {
+		case Equal:
+			out.WriteByte(' ')
+		case Delete:
+			out.WriteByte('-')
+		case Insert:
+			out.WriteByte('+')
+		}
+		out.WriteString(edit.Line)
+		out.WriteByte('\n')
+	}
+}
+
+// hunkRange formats the start and length of a hunk; empty ranges start at the line before
+// them, as in GNU diff
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprintf("%d", start)
+	default:
+		return fmt.Sprintf("%d,%d", start, count)
+	}
+}
@@ -0,0 +1,75 @@
+package textdiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLines(t *testing.T) {
+	edits := Lines([]string{"a", "b", "c", "d"}, []string{"a", "c", "x", "d"})
+	assert.Equal(t, []Edit{
+		{Op: Equal, Line: "a"},
+		{Op: Delete, Line: "b"},
+		{Op: Equal, Line: "c"},
+		{Op: Insert, Line: "x"},
+		{Op: Equal, Line: "d"},
+	}, edits)
+
+	// Replaced lines are deleted before they are inserted
+	assert.Equal(t, []Edit{{Op: Delete, Line: "old"}, {Op: Insert, Line: "new"}}, Lines([]string{"old"}, []string{"new"}))
+	assert.Empty(t, Lines(nil, nil))
+}
+
+func TestSplitLines(t *testing.T) {
+	assert.Nil(t, SplitLines(""))
+	assert.Equal(t, []string{"a", "b"}, SplitLines("a\nb"))
+	assert.Equal(t, []string{"a", "b"}, SplitLines("a\nb\n"))
+	assert.Equal(t, []string{"a", ""}, SplitLines("a\n\n"))
+}
+
+func TestUnified(t *testing.T) {
+	assert.Empty(t, Unified("a", "b", "same\n", "same\n", 3))
+
+	old := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	changed := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\neleven\n"
+	assert.Equal(t, strings.Join([]string{
+		"--- v1",
+		"+++ v2",
+		"@@ -2,3 +2,3 @@",
+		" 2",
+		"-3",
+		"+three",
+		" 4",
+		"@@ -10 +10,2 @@",
+		" 10",
+		"+eleven",
+		"",
+	}, "\n"), Unified("v1", "v2", old, changed, 1))
+
+	// Changes with up to twice the context lines between them share a hunk
+	changed = "1\n2\nthree\n4\n5\n6\n7\n8\n9\nten\n"
+	assert.Equal(t, strings.Join([]string{
+		"--- v1",
+		"+++ v2",
+		"@@ -1,10 +1,10 @@",
+		" 1",
+		" 2",
+		"-3",
+		"+three",
+		" 4",
+		" 5",
+		" 6",
+		" 7",
+		" 8",
+		" 9",
+		"-10",
+		"+ten",
+		"",
+	}, "\n"), Unified("v1", "v2", old, changed, 3))
+
+	// Empty texts have empty ranges
+	assert.Equal(t, "--- v1\n+++ v2\n@@ -0,0 +1 @@\n+Hello\n", Unified("v1", "v2", "", "Hello", 3))
+	assert.Equal(t, "--- v1\n+++ v2\n@@ -1 +0,0 @@\n-Hello\n", Unified("v1", "v2", "Hello", "", 3))
+}
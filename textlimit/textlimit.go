@@ -0,0 +1,150 @@
+// Package textlimit checks notification content against the length and encoding limits of each
+// channel before it is enqueued.
+//
+// Lengths are counted in user-perceived characters (grapheme clusters), so an emoji such as
+// "👩‍👩‍👧" or "🇩🇪" counts as one character even though it is several code points and many
+// bytes. Push payloads are additionally limited in bytes, because APNS and FCM reject payloads
+// larger than 4KB regardless of how many characters they contain.
+package textlimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Content limits per channel
+const (
+	MaxEmailSubjectLength   = 255
+	MaxEmailBodyLength      = 10000
+	MaxSlackTextLength      = 3000
+	MaxGoogleChatTextLength = 4096
+	MaxRCSTextLength        = 3072
+	MaxSMSTextLength        = 1600
+	MaxPushTitleLength      = 255
+	MaxPushBodyLength       = 4000
+
+	// MaxPushAlertBytes is the budget for the JSON encoded push title and body. APNS and FCM
+	// accept 4096 bytes per payload; the rest is left for the envelope and custom data.
+	MaxPushAlertBytes = 3584
+)
+
+const zeroWidthJoiner = '\u200d'
+
+// Violation describes a content field that exceeds a channel limit or cannot be encoded
+type Violation struct {
+	Field   string
+	Message string
+}
+
+// CheckContent checks the string fields of notification content against the limits of the
+// notification type. Missing fields are not reported; checking for required fields is left
+// to the caller.
+func CheckContent(notificationType string, content map[string]interface{}) []Violation {
+	var violations []Violation
+	check := func(field, label string, maxLength int) {
+		value, ok := content[field].(string)
+		if !ok {
+			return
+		}
+		field = "content." + field
+		if !utf8.ValidString(value) {
+			violations = append(violations, Violation{Field: field, Message: label + " must be valid UTF-8"})
+			return
+		}
+		if Length(value) > maxLength {
+			violations = append(violations, Violation{
+				Field:   field,
+				Message: fmt.Sprintf("%s cannot exceed %d characters", label, maxLength),
+			})
+		}
+	}
+
+	switch notificationType {
+	case "email":
+		check("subject", "email subject", MaxEmailSubjectLength)
+		check("email_body", "email body", MaxEmailBodyLength)
+		check("text_body", "email text body", MaxEmailBodyLength)
+		if subject, ok := content["subject"].(string); ok && strings.ContainsAny(subject, "\r\n") {
+			violations = append(violations, Violation{Field: "content.subject", Message: "email subject cannot contain line breaks"})
+		}
+	case "slack":
+		check("text", "slack text", MaxSlackTextLength)
+	case "google_chat":
+		check("text", "google chat text", MaxGoogleChatTextLength)
+	case "rcs":
+		check("text", "rcs text", MaxRCSTextLength)
+		check("fallback_text", "rcs fallback text", MaxSMSTextLength)
+	case "ios_push", "android_push", "in_app":
+		check("title", "push notification title", MaxPushTitleLength)
+		check("body", "push notification body", MaxPushBodyLength)
+
+		title, _ := content["title"].(string)
+		body, _ := content["body"].(string)
+		if size := jsonSize(title) + jsonSize(body); size > MaxPushAlertBytes {
+			violations = append(violations, Violation{
+				Field:   "content",
+				Message: fmt.Sprintf("push notification title and body are %d bytes when encoded, the limit is %d bytes", size, MaxPushAlertBytes),
+			})
+		}
+	}
+
+	return violations
+}
+
+// jsonSize returns the size of s encoded as a JSON string, as sent to push providers
+func jsonSize(s string) int {
+	encoded, err := json.Marshal(s)
+	if err != nil {
+		return len(s)
+	}
+	return len(encoded)
+}
+
+// Length returns the number of user-perceived characters in s.
+//
+// It implements the parts of the Unicode extended grapheme cluster rules that matter for
+// notification text: combining marks, variation selectors, emoji modifiers, zero width joiner
+// sequences, flag pairs, tag sequences and CRLF each count as a single character.
+func Length(s string) int {
+	count := 0
+	var previous rune
+	regionalIndicators := 0
+
+	for i, r := range s {
+		joins := i > 0 && (isExtend(r) ||
+			previous == zeroWidthJoiner ||
+			(previous == '\r' && r == '\n') ||
+			(isRegionalIndicator(r) && isRegionalIndicator(previous) && regionalIndicators%2 == 1))
+
+		if !joins {
+			count++
+			regionalIndicators = 0
+		}
+		if isRegionalIndicator(r) {
+			regionalIndicators++
+		}
+		previous = r
+	}
+
+	return count
+}
+
+// isExtend reports whether r attaches to the preceding character
+func isExtend(r rune) bool {
+	switch {
+	case r == zeroWidthJoiner:
+		return true
+	case r >= 0x1F3FB && r <= 0x1F3FF: // emoji skin tone modifiers
+		return true
+	case r >= 0xE0020 && r <= 0xE007F: // tag characters used in subdivision flags
+		return true
+	}
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc)
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
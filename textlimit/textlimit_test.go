@@ -0,0 +1,77 @@
+package textlimit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected int
+	}{
+		{"ascii", "Hello", 5},
+		{"accented letters", "Crème brûlée", 12},
+		{"combining accent", "é", 1},
+		{"emoji", "🚀🚀", 2},
+		{"skin tone modifier", "👍🏽", 1},
+		{"zwj family", "👩‍👩‍👧", 1},
+		{"variation selector", "❤️", 1},
+		{"flags", "🇩🇪🇫🇷", 2},
+		{"keycap", "1️⃣", 1},
+		{"subdivision flag", "🏴󠁧󠁢󠁳󠁣󠁴󠁿", 1},
+		{"crlf", "a\r\nb", 3},
+		{"cjk", "你好世界", 4},
+		{"empty", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Length(tt.text))
+		})
+	}
+}
+
+func TestCheckContent_CountsEmojiAsCharacters(t *testing.T) {
+	// 3000 emoji are 12000 bytes but within the 3000 character Slack limit
+	text := strings.Repeat("🎉", MaxSlackTextLength)
+	assert.Empty(t, CheckContent("slack", map[string]interface{}{"text": text}))
+
+	violations := CheckContent("slack", map[string]interface{}{"text": text + "👍🏽"})
+	require.Len(t, violations, 1)
+	assert.Equal(t, "content.text", violations[0].Field)
+	assert.Equal(t, "slack text cannot exceed 3000 characters", violations[0].Message)
+}
+
+func TestCheckContent_PushPayloadBytes(t *testing.T) {
+	// 1000 emoji are within the body character limit but 4000 bytes on the wire
+	violations := CheckContent("ios_push", map[string]interface{}{
+		"title": "Party",
+		"body":  strings.Repeat("🎉", 1000),
+	})
+	require.Len(t, violations, 1)
+	assert.Equal(t, "content", violations[0].Field)
+	assert.Contains(t, violations[0].Message, "the limit is 3584 bytes")
+
+	assert.Empty(t, CheckContent("android_push", map[string]interface{}{
+		"title": "Party",
+		"body":  strings.Repeat("🎉", 800),
+	}))
+}
+
+func TestCheckContent_Encoding(t *testing.T) {
+	violations := CheckContent("email", map[string]interface{}{
+		"subject":    "Hello\r\nBcc: everyone@example.com",
+		"email_body": "ok \xff",
+	})
+	require.Len(t, violations, 2)
+	assert.Equal(t, Violation{Field: "content.email_body", Message: "email body must be valid UTF-8"}, violations[0])
+	assert.Equal(t, Violation{Field: "content.subject", Message: "email subject cannot contain line breaks"}, violations[1])
+
+	// Missing fields are left to the required field checks
+	assert.Empty(t, CheckContent("email", map[string]interface{}{}))
+}
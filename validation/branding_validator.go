@@ -0,0 +1,64 @@
+package validation
+
+import (
+	"net/url"
+	"regexp"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// hexColorPattern matches colors such as #1a73e8 or #fff
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// BrandingValidator provides validation methods for tenant branding
+type BrandingValidator struct{}
+
+// NewBrandingValidator creates a new branding validator
+func NewBrandingValidator() *BrandingValidator {
+	return &BrandingValidator{}
+}
+
+// ValidateBranding validates a branding update
+func (v *BrandingValidator) ValidateBranding(branding *models.Branding) ValidationResult {
+	var errors []ValidationError
+
+	if branding.LogoURL != "" {
+		if parsed, err := url.Parse(branding.LogoURL); err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			errors = append(errors, ValidationError{
+				Field:   "logo_url",
+				Message: "logo URL must be an absolute http or https URL",
+			})
+		} else if len(branding.LogoURL) > 2048 {
+			errors = append(errors, ValidationError{
+				Field:   "logo_url",
+				Message: "logo URL cannot exceed 2048 characters",
+			})
+		}
+	}
+
+	if branding.PrimaryColor != "" && !hexColorPattern.MatchString(branding.PrimaryColor) {
+		errors = append(errors, ValidationError{
+			Field:   "primary_color",
+			Message: "primary color must be a hex color such as #1a73e8",
+		})
+	}
+
+	if len(branding.CompanyName) > 200 {
+		errors = append(errors, ValidationError{
+			Field:   "company_name",
+			Message: "company name cannot exceed 200 characters",
+		})
+	}
+
+	if len(branding.Address) > 500 {
+		errors = append(errors, ValidationError{
+			Field:   "address",
+			Message: "address cannot exceed 500 characters",
+		})
+	}
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
@@ -0,0 +1,61 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrandingValidator_ValidateBranding(t *testing.T) {
+	validator := NewBrandingValidator()
+
+	tests := []struct {
+		name           string
+		branding       *models.Branding
+		expectedFields []string
+	}{
+		{
+			name: "Valid - full branding",
+			branding: &models.Branding{
+				LogoURL:      "https://cdn.acme.com/logo.png",
+				PrimaryColor: "#1a73e8",
+				CompanyName:  "Acme Inc.",
+				Address:      "1 Main Street, Springfield",
+			},
+		},
+		{
+			name:     "Valid - empty branding",
+			branding: &models.Branding{},
+		},
+		{
+			name:           "Invalid - relative logo URL and named color",
+			branding:       &models.Branding{LogoURL: "/logo.png", PrimaryColor: "blue"},
+			expectedFields: []string{"logo_url", "primary_color"},
+		},
+		{
+			name:           "Invalid - javascript logo URL",
+			branding:       &models.Branding{LogoURL: "javascript:alert(1)"},
+			expectedFields: []string{"logo_url"},
+		},
+		{
+			name:           "Invalid - long company name and address",
+			branding:       &models.Branding{CompanyName: strings.Repeat("a", 201), Address: strings.Repeat("a", 501)},
+			expectedFields: []string{"company_name", "address"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := validator.ValidateBranding(tt.branding)
+			assert.Equal(t, len(tt.expectedFields) == 0, result.IsValid)
+
+			var fields []string
+			for _, err := range result.Errors {
+				fields = append(fields, err.Field)
+			}
+			assert.Equal(t, tt.expectedFields, fields)
+		})
+	}
+}
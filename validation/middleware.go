@@ -1,9 +1,15 @@
 package validation
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"time"
 
+	"github.com/gaurav2721/notification-service/auth"
+	"github.com/gaurav2721/notification-service/clock"
 	"github.com/gaurav2721/notification-service/models"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -13,6 +19,9 @@ import (
 type ValidationLayer struct {
 	notificationValidator *NotificationValidator
 	templateValidator     *TemplateValidator
+	preferencesValidator  *PreferencesValidator
+	brandingValidator     *BrandingValidator
+	senderValidator       *SenderValidator
 }
 
 // NewValidationLayer creates a new validation layer
@@ -20,15 +29,40 @@ func NewValidationLayer() *ValidationLayer {
 	return &ValidationLayer{
 		notificationValidator: NewNotificationValidator(),
 		templateValidator:     NewTemplateValidator(),
+		preferencesValidator:  NewPreferencesValidator(),
+		brandingValidator:     NewBrandingValidator(),
+		senderValidator:       NewSenderValidator(),
 	}
 }
 
+// SetClock replaces the clock used by the validators
+func (vm *ValidationLayer) SetClock(c clock.Clock) {
+	vm.notificationValidator.SetClock(c)
+}
+
 // ValidateNotificationRequest is middleware that validates notification requests
 func (vm *ValidationLayer) ValidateNotificationRequest() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var request models.NotificationRequest
+		if !checkSchema(c, NotificationRequestSchema) {
+			return
+		}
 
+		var request models.NotificationRequest
 		if err := c.ShouldBindJSON(&request); err != nil {
+			// scheduled_at is the only timestamp of the request
+			var timeErr *time.ParseError
+			if errors.As(err, &timeErr) {
+				logrus.WithError(err).Warn("Invalid scheduled time in notification request")
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "Validation failed",
+					"details": []ValidationError{{
+						Field:   "scheduled_at",
+						Message: "scheduled time must be an RFC 3339 timestamp with a time zone, such as 2024-01-01T09:00:00Z or 2024-01-01T10:00:00+01:00",
+					}},
+				})
+				c.Abort()
+				return
+			}
 			logrus.WithError(err).Warn("Invalid JSON in notification request")
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error":   "Invalid JSON format",
@@ -49,6 +83,26 @@ func (vm *ValidationLayer) ValidateNotificationRequest() gin.HandlerFunc {
 			return
 		}
 
+		// Only trusted API keys may bypass the recipients' preferences
+		if request.Transactional && !vm.notificationValidator.AllowsTransactional(auth.Principal(c)) {
+			logrus.WithField("principal", auth.Principal(c)).Warn("API key is not allowed to send transactional notifications")
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Forbidden",
+				"details": []ValidationError{{
+					Field:   "transactional",
+					Message: "this API key is not allowed to send transactional notifications",
+				}},
+			})
+			c.Abort()
+			return
+		}
+
+		// Scheduled times keep their instant but are stored and reported in UTC
+		if request.ScheduledAt != nil {
+			scheduledAt := request.ScheduledAt.UTC()
+			request.ScheduledAt = &scheduledAt
+		}
+
 		// Store validated request in context for later use
 		c.Set("validated_request", &request)
 		c.Next()
@@ -58,8 +112,11 @@ func (vm *ValidationLayer) ValidateNotificationRequest() gin.HandlerFunc {
 // ValidateTemplateRequest is middleware that validates template creation requests
 func (vm *ValidationLayer) ValidateTemplateRequest() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var request models.TemplateRequest
+		if !checkSchema(c, TemplateRequestSchema) {
+			return
+		}
 
+		var request models.TemplateRequest
 		if err := c.ShouldBindJSON(&request); err != nil {
 			logrus.WithError(err).Warn("Invalid JSON in template request")
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -87,6 +144,40 @@ func (vm *ValidationLayer) ValidateTemplateRequest() gin.HandlerFunc {
 	}
 }
 
+// checkSchema validates the request body against the named request schema before it is bound
+// and the hand-written validators run. Invalid requests are answered with 400 and the schema
+// errors; the body is restored for the binding otherwise.
+func checkSchema(c *gin.Context, schema string) bool {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to read request body")
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		c.JSON(status, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		c.Abort()
+		return false
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	if schemaErrors := validateAgainstSchema(schema, body); len(schemaErrors) > 0 {
+		logrus.WithFields(logrus.Fields{"schema": schema, "errors": schemaErrors}).Warn("Request does not match its schema")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validation failed",
+			"schema":  schema,
+			"details": schemaErrors,
+		})
+		c.Abort()
+		return false
+	}
+	return true
+}
+
 // ValidateTemplateID is middleware that validates template ID parameter
 func (vm *ValidationLayer) ValidateTemplateID() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -109,11 +200,16 @@ func (vm *ValidationLayer) ValidateTemplateID() gin.HandlerFunc {
 
 // ValidateTemplateVersion is middleware that validates template version parameter
 func (vm *ValidationLayer) ValidateTemplateVersion() gin.HandlerFunc {
+	return vm.ValidateTemplateVersionParam("version")
+}
+
+// ValidateTemplateVersionParam is middleware that validates the template version in the named parameter
+func (vm *ValidationLayer) ValidateTemplateVersionParam(param string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		versionStr := c.Param("version")
+		versionStr := c.Param(param)
 		if versionStr == "" {
 			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "version parameter is required",
+				"error": param + " parameter is required",
 			})
 			c.Abort()
 			return
@@ -123,7 +219,7 @@ func (vm *ValidationLayer) ValidateTemplateVersion() gin.HandlerFunc {
 		version := 0
 		if _, err := fmt.Sscanf(versionStr, "%d", &version); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "version must be a valid integer",
+				"error": param + " must be a valid integer",
 			})
 			c.Abort()
 			return
@@ -164,6 +260,233 @@ func (vm *ValidationLayer) ValidateNotificationID() gin.HandlerFunc {
 	}
 }
 
+// ValidateEngagementEventRequest is middleware that validates engagement event reports
+func (vm *ValidationLayer) ValidateEngagementEventRequest() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.EngagementEventRequest
+
+		if err := c.ShouldBindJSON(&request); err != nil {
+			logrus.WithError(err).Warn("Invalid JSON in engagement event request")
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid JSON format",
+				"details": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		validationResult := vm.notificationValidator.ValidateEngagementEvent(&request)
+		if !validationResult.IsValid {
+			logrus.WithField("errors", validationResult.Errors).Warn("Validation failed for engagement event request")
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Validation failed",
+				"details": validationResult.Errors,
+			})
+			c.Abort()
+			return
+		}
+
+		// Store validated request in context for later use
+		c.Set("validated_engagement_request", &request)
+		c.Next()
+	}
+}
+
+// ValidateRecipientCheckRequest is middleware that validates a request to check the recipients
+// of a notification
+func (vm *ValidationLayer) ValidateRecipientCheckRequest() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.RecipientCheckRequest
+
+		if err := c.ShouldBindJSON(&request); err != nil {
+			logrus.WithError(err).Warn("Invalid JSON in recipient check request")
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid JSON format",
+				"details": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		validationResult := vm.notificationValidator.ValidateRecipientCheck(&request)
+		if !validationResult.IsValid {
+			logrus.WithField("errors", validationResult.Errors).Warn("Validation failed for recipient check request")
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Validation failed",
+				"details": validationResult.Errors,
+			})
+			c.Abort()
+			return
+		}
+
+		// Store validated request in context for later use
+		c.Set("validated_recipient_check_request", &request)
+		c.Next()
+	}
+}
+
+// ValidateDeliveryReceiptBatch is middleware that validates reported push delivery receipts
+func (vm *ValidationLayer) ValidateDeliveryReceiptBatch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.DeliveryReceiptBatch
+
+		if err := c.ShouldBindJSON(&request); err != nil {
+			logrus.WithError(err).Warn("Invalid JSON in delivery receipt request")
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid JSON format",
+				"details": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		validationResult := vm.notificationValidator.ValidateDeliveryReceipts(&request)
+		if !validationResult.IsValid {
+			logrus.WithField("errors", validationResult.Errors).Warn("Validation failed for delivery receipt request")
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Validation failed",
+				"details": validationResult.Errors,
+			})
+			c.Abort()
+			return
+		}
+
+		// Store validated request in context for later use
+		c.Set("validated_receipt_batch", &request)
+		c.Next()
+	}
+}
+
+// ValidateNotificationListQuery is middleware that validates the filters of a notification lookup
+func (vm *ValidationLayer) ValidateNotificationListQuery() gin.HandlerFunc {
+	return vm.validateNotificationQuery(true)
+}
+
+// ValidateNotificationAnalyticsQuery is middleware that validates the filters of a notification analytics query
+func (vm *ValidationLayer) ValidateNotificationAnalyticsQuery() gin.HandlerFunc {
+	return vm.validateNotificationQuery(false)
+}
+
+// validateNotificationQuery validates the external_id, tag, status and type query parameters
+func (vm *ValidationLayer) validateNotificationQuery(requireFilter bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		validationResult := vm.notificationValidator.ValidateNotificationQuery(
+			c.Query("external_id"),
+			c.QueryArray("tag"),
+			c.Query("status"),
+			c.Query("type"),
+			requireFilter,
+		)
+		if !validationResult.IsValid {
+			logrus.WithField("errors", validationResult.Errors).Warn("Validation failed for notification query")
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Validation failed",
+				"details": validationResult.Errors,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ValidatePreferencesRequest is middleware that validates notification preference updates
+func (vm *ValidationLayer) ValidatePreferencesRequest() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.NotificationPreferences
+
+		if err := c.ShouldBindJSON(&request); err != nil {
+			logrus.WithError(err).Warn("Invalid JSON in preferences request")
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid JSON format",
+				"details": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		validationResult := vm.preferencesValidator.ValidatePreferences(&request)
+		if !validationResult.IsValid {
+			logrus.WithField("errors", validationResult.Errors).Warn("Validation failed for preferences request")
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Validation failed",
+				"details": validationResult.Errors,
+			})
+			c.Abort()
+			return
+		}
+
+		// Store validated request in context for later use
+		c.Set("validated_preferences_request", &request)
+		c.Next()
+	}
+}
+
+// ValidateBrandingRequest is middleware that validates tenant branding updates
+func (vm *ValidationLayer) ValidateBrandingRequest() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.Branding
+
+		if err := c.ShouldBindJSON(&request); err != nil {
+			logrus.WithError(err).Warn("Invalid JSON in branding request")
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid JSON format",
+				"details": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		validationResult := vm.brandingValidator.ValidateBranding(&request)
+		if !validationResult.IsValid {
+			logrus.WithField("errors", validationResult.Errors).Warn("Validation failed for branding request")
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Validation failed",
+				"details": validationResult.Errors,
+			})
+			c.Abort()
+			return
+		}
+
+		// Store validated request in context for later use
+		c.Set("validated_branding_request", &request)
+		c.Next()
+	}
+}
+
+// ValidateSenderRequest is middleware that validates tenant sender settings updates
+func (vm *ValidationLayer) ValidateSenderRequest() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.SenderSettings
+
+		if err := c.ShouldBindJSON(&request); err != nil {
+			logrus.WithError(err).Warn("Invalid JSON in sender settings request")
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid JSON format",
+				"details": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		validationResult := vm.senderValidator.ValidateSenderSettings(&request)
+		if !validationResult.IsValid {
+			logrus.WithField("errors", validationResult.Errors).Warn("Validation failed for sender settings request")
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Validation failed",
+				"details": validationResult.Errors,
+			})
+			c.Abort()
+			return
+		}
+
+		// Store validated request in context for later use
+		c.Set("validated_sender_request", &request)
+		c.Next()
+	}
+}
+
 // ValidateUserRequest is middleware that validates user requests
 func (vm *ValidationLayer) ValidateUserRequest() gin.HandlerFunc {
 	return func(c *gin.Context) {
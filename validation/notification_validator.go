@@ -3,25 +3,112 @@ package validation
 import (
 	"fmt"
 	"net/mail"
+	"os"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/gaurav2721/notification-service/clock"
+	"github.com/gaurav2721/notification-service/constants"
 	"github.com/gaurav2721/notification-service/models"
+	"github.com/gaurav2721/notification-service/textlimit"
+)
+
+// validRecipientRegex matches recipient IDs (alphanumeric, hyphens, underscores)
+var validRecipientRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// validExternalIDRegex matches caller supplied reference IDs such as "order-42", "crm:case/1001" or "evt_01H8X.2"
+var validExternalIDRegex = regexp.MustCompile(`^[a-zA-Z0-9._:/-]+$`)
+
+// validTagRegex matches notification tags such as "billing", "q3-campaign" or "team:growth"
+var validTagRegex = regexp.MustCompile(`^[a-zA-Z0-9_:.-]+$`)
+
+const (
+	// maxExternalIDLength is the maximum length of a caller supplied reference ID
+	maxExternalIDLength = 128
+
+	// maxTagsPerNotification is the maximum number of tags on a single notification
+	maxTagsPerNotification = 10
+
+	// maxTagLength is the maximum length of a single tag
+	maxTagLength = 50
+
+	// maxReasonLength is the maximum length, in characters, of the reason of a notification
+	maxReasonLength = 300
+
+	// maxEngagementClockSkew is how far ahead of the server a client clock may be when reporting events
+	maxEngagementClockSkew = 5 * time.Minute
+
+	// maxReceiptsPerBatch is the maximum number of delivery receipts reported in one request
+	maxReceiptsPerBatch = 1000
 )
 
 // NotificationValidator provides validation methods for notification requests
-type NotificationValidator struct{}
+type NotificationValidator struct {
+	maxRecipients           int
+	minScheduleLead         time.Duration
+	transactionalPrincipals map[string]bool
+	clock                   clock.Clock
+}
 
-// NewNotificationValidator creates a new notification validator
+// NewNotificationValidator creates a new notification validator.
+// The recipient limit can be raised with MAX_RECIPIENTS_PER_NOTIFICATION for large sends,
+// SCHEDULE_MIN_LEAD_SECONDS sets how far ahead notifications must be scheduled, and
+// TRANSACTIONAL_API_KEYS names the API keys that may send transactional notifications.
 func NewNotificationValidator() *NotificationValidator {
-	return &NotificationValidator{}
+	maxRecipients := constants.DefaultMaxRecipientsPerNotification
+	if value := os.Getenv(constants.MaxRecipientsPerNotificationEnvVar); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			maxRecipients = parsed
+		}
+	}
+
+	// Scheduled times closer than the lead time may pass before the request is processed,
+	// for example when the client clock runs behind the server
+	var minScheduleLead time.Duration
+	if value := os.Getenv(constants.ScheduleMinLeadSecondsEnvVar); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			minScheduleLead = time.Duration(parsed) * time.Second
+		}
+	}
+
+	transactionalPrincipals := make(map[string]bool)
+	for _, name := range strings.Split(os.Getenv(constants.TRANSACTIONAL_API_KEYS), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			transactionalPrincipals[name] = true
+		}
+	}
+
+	return &NotificationValidator{
+		maxRecipients:           maxRecipients,
+		minScheduleLead:         minScheduleLead,
+		transactionalPrincipals: transactionalPrincipals,
+		clock:                   clock.Real(),
+	}
+}
+
+// AllowsTransactional reports whether requests authenticated as principal may set the transactional flag
+func (v *NotificationValidator) AllowsTransactional(principal string) bool {
+	return v.transactionalPrincipals[principal]
+}
+
+// SetClock replaces the clock scheduled times are checked against
+func (v *NotificationValidator) SetClock(c clock.Clock) {
+	v.clock = c
 }
 
 // ValidationError represents a validation error
 type ValidationError struct {
 	Field   string `json:"field"`
 	Message string `json:"message"`
+	// Path and Keyword are set for errors found by the request schemas: the JSON Pointer of the
+	// invalid value and the schema keyword it violates
+	Path    string `json:"path,omitempty"`
+	Keyword string `json:"keyword,omitempty"`
 }
 
 // ValidationResult represents the result of validation
@@ -42,9 +129,17 @@ func (v *NotificationValidator) ValidateNotificationRequest(request *models.Noti
 	if errors = append(errors, v.validateRecipients(request.Recipients)...); len(errors) > 0 {
 		return ValidationResult{IsValid: false, Errors: errors}
 	}
+	if errors = append(errors, v.validateRecipientChannels(request.Type, request.Recipients)...); len(errors) > 0 {
+		return ValidationResult{IsValid: false, Errors: errors}
+	}
 
-	// Validate content vs template (mutual exclusivity)
-	if contentErrors := v.validateContentAndTemplate(request.Content, request.Template); len(contentErrors) > 0 {
+	// Validate content vs template (mutual exclusivity). The channel content block of the
+	// notification type stands in for content.
+	content := request.Content
+	if len(content) == 0 && request.Template == nil {
+		content = request.ContentFor(request.Type)
+	}
+	if contentErrors := v.validateContentAndTemplate(content, request.Template); len(contentErrors) > 0 {
 		errors = append(errors, contentErrors...)
 	}
 
@@ -55,11 +150,19 @@ func (v *NotificationValidator) ValidateNotificationRequest(request *models.Noti
 		}
 	}
 
+	// Validate channel content and fallback channels if provided
+	if channelErrors := v.validateChannelContent(request); len(channelErrors) > 0 {
+		errors = append(errors, channelErrors...)
+	}
+
 	// Validate template if provided
 	if request.Template != nil {
 		if templateErrors := v.validateTemplate(request.Template); len(templateErrors) > 0 {
 			errors = append(errors, templateErrors...)
 		}
+		if recipientDataErrors := v.validateRecipientData(request.Template.RecipientData, request.Recipients); len(recipientDataErrors) > 0 {
+			errors = append(errors, recipientDataErrors...)
+		}
 	}
 
 	// Validate from field based on type
@@ -67,6 +170,43 @@ func (v *NotificationValidator) ValidateNotificationRequest(request *models.Noti
 		errors = append(errors, fromErrors...)
 	}
 
+	// Validate external_id if provided
+	if request.ExternalID != "" {
+		if externalIDErrors := v.ValidateExternalID(request.ExternalID).Errors; len(externalIDErrors) > 0 {
+			errors = append(errors, externalIDErrors...)
+		}
+	}
+
+	// Validate tags if provided
+	if len(request.Tags) > 0 {
+		if tagErrors := v.ValidateTags(request.Tags); len(tagErrors) > 0 {
+			errors = append(errors, tagErrors...)
+		}
+	}
+
+	// Validate reason if provided
+	if reasonErrors := validateReason(request.Reason); len(reasonErrors) > 0 {
+		errors = append(errors, reasonErrors...)
+	}
+
+	// Validate thread key if provided
+	if request.ThreadKey != "" && !models.IsValidThreadKey(request.ThreadKey) {
+		errors = append(errors, ValidationError{
+			Field:   "thread_key",
+			Message: fmt.Sprintf("thread key must be up to %d letters, digits or . _ : / @ # - characters", models.MaxThreadKeyLength),
+		})
+	}
+
+	// Validate category if provided
+	if categoryErrors := validateCategory(request.Category); len(categoryErrors) > 0 {
+		errors = append(errors, categoryErrors...)
+	}
+
+	// Validate dedupe key and the fields incidents depend on
+	if incidentErrors := validateIncidentRequest(request); len(incidentErrors) > 0 {
+		errors = append(errors, incidentErrors...)
+	}
+
 	// Validate scheduled_at if provided
 	if request.ScheduledAt != nil {
 		if scheduleErrors := v.validateScheduledAt(*request.ScheduledAt); len(scheduleErrors) > 0 {
@@ -98,12 +238,15 @@ func (v *NotificationValidator) validateType(notificationType string) []Validati
 		"ios_push":     true,
 		"android_push": true,
 		"in_app":       true,
+		"google_chat":  true,
+		"incident":     true,
+		"rcs":          true,
 	}
 
 	if !validTypes[notificationType] {
 		errors = append(errors, ValidationError{
 			Field:   "type",
-			Message: fmt.Sprintf("invalid notification type: %s. Valid types are: email, slack, ios_push, android_push, in_app", notificationType),
+			Message: fmt.Sprintf("invalid notification type: %s. Valid types are: email, slack, ios_push, android_push, in_app, google_chat, incident, rcs", notificationType),
 		})
 	}
 
@@ -123,10 +266,10 @@ func (v *NotificationValidator) validateRecipients(recipients []string) []Valida
 	}
 
 	// Check for maximum recipients limit
-	if len(recipients) > 1000 {
+	if len(recipients) > v.maxRecipients {
 		errors = append(errors, ValidationError{
 			Field:   "recipients",
-			Message: "maximum 1000 recipients allowed per notification",
+			Message: fmt.Sprintf("maximum %d recipients allowed per notification", v.maxRecipients),
 		})
 	}
 
@@ -168,8 +311,18 @@ func (v *NotificationValidator) validateRecipients(recipients []string) []Valida
 			continue
 		}
 
+		// Address recipients are checked against the format of their kind
+		if kind, address, ok := models.ParseRecipientAddress(recipient); ok {
+			if err := models.ValidateRecipientAddress(kind, address); err != nil {
+				errors = append(errors, ValidationError{
+					Field:   fmt.Sprintf("recipients[%d]", i),
+					Message: err.Error(),
+				})
+			}
+			continue
+		}
+
 		// Check for valid characters (alphanumeric, hyphens, underscores)
-		validRecipientRegex := regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
 		if !validRecipientRegex.MatchString(recipient) {
 			errors = append(errors, ValidationError{
 				Field:   fmt.Sprintf("recipients[%d]", i),
@@ -181,6 +334,34 @@ func (v *NotificationValidator) validateRecipients(recipients []string) []Valida
 	return errors
 }
 
+// validateRecipientChannels checks that address recipients can be reached by the notification
+// type: email addresses by email, slack channels by slack, google chat spaces by google_chat,
+// incident routes by incident and phone numbers by rcs. Other types need a user. Incidents page
+// on-call responders through their route and are never sent to users.
+func (v *NotificationValidator) validateRecipientChannels(notificationType string, recipients []string) []ValidationError {
+	var errors []ValidationError
+
+	for i, recipient := range recipients {
+		kind, _, ok := models.ParseRecipientAddress(strings.TrimSpace(recipient))
+		if !ok && notificationType == string(models.IncidentNotification) {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("recipients[%d]", i),
+				Message: "incident notifications are sent to incident:<integration key or team> recipients, not to users",
+			})
+			continue
+		}
+		if !ok || kind == models.RecipientKindForType(notificationType) {
+			continue
+		}
+		errors = append(errors, ValidationError{
+			Field:   fmt.Sprintf("recipients[%d]", i),
+			Message: fmt.Sprintf("%s recipients cannot receive %s notifications", kind, notificationType),
+		})
+	}
+
+	return errors
+}
+
 // validateContentAndTemplate validates that either content or template is provided, but not both
 func (v *NotificationValidator) validateContentAndTemplate(content map[string]interface{}, template *models.TemplateData) []ValidationError {
 	var errors []ValidationError
@@ -214,8 +395,111 @@ func (v *NotificationValidator) validateContentByType(notificationType string, c
 		errors = append(errors, v.validateEmailContent(content)...)
 	case "slack":
 		errors = append(errors, v.validateSlackContent(content)...)
-	case "ios_push", "android_push", "in_app":
+	case "google_chat":
+		errors = append(errors, v.validateGoogleChatContent(content)...)
+	case "incident":
+		if _, err := models.ParseIncidentContent(content); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   "content",
+				Message: err.Error(),
+			})
+		}
+	case "rcs":
+		if _, err := models.ParseRCSContent(content); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   "content",
+				Message: err.Error(),
+			})
+		}
+	case "ios_push", "android_push":
 		errors = append(errors, v.validatePushContent(content)...)
+	case "in_app":
+		errors = append(errors, v.validatePushContent(content)...)
+		if _, err := models.ParseInboxActions(content["actions"]); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   "content.actions",
+				Message: err.Error(),
+			})
+		}
+	}
+
+	// Lengths are counted in user-perceived characters, encoded sizes in bytes
+	for _, violation := range textlimit.CheckContent(notificationType, content) {
+		errors = append(errors, ValidationError{Field: violation.Field, Message: violation.Message})
+	}
+
+	return errors
+}
+
+// channelContentTypes maps channel content keys to the notification type their content is
+// validated as
+var channelContentTypes = map[string]string{
+	models.ChannelContentEmail:      "email",
+	models.ChannelContentSlack:      "slack",
+	models.ChannelContentGoogleChat: "google_chat",
+	models.ChannelContentRCS:        "rcs",
+	models.ChannelContentPush:       "in_app",
+}
+
+// validateChannelContent validates the channel content blocks and the fallback channels that use them
+func (v *NotificationValidator) validateChannelContent(request *models.NotificationRequest) []ValidationError {
+	var errors []ValidationError
+
+	keys := make([]string, 0, len(request.ChannelContent))
+	for key := range request.ChannelContent {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field := "channel_content." + key
+		notificationType, known := channelContentTypes[key]
+		if !known {
+			errors = append(errors, ValidationError{
+				Field:   field,
+				Message: fmt.Sprintf("invalid channel: %s. Valid channels are: email, slack, google_chat, rcs, push", key),
+			})
+			continue
+		}
+		if key == models.ChannelContentKey(request.Type) && (len(request.Content) > 0 || request.Template != nil) {
+			errors = append(errors, ValidationError{
+				Field:   field,
+				Message: fmt.Sprintf("%s content cannot be provided together with content or template", key),
+			})
+			continue
+		}
+		for _, contentError := range v.validateContentByType(notificationType, request.ChannelContent[key]) {
+			contentError.Field = field + strings.TrimPrefix(contentError.Field, "content")
+			errors = append(errors, contentError)
+		}
+	}
+
+	seen := map[string]bool{request.Type: true}
+	for i, channel := range request.FallbackChannels {
+		field := fmt.Sprintf("fallback_channels[%d]", i)
+		switch channel {
+		case "email", "slack", "google_chat", "rcs", "in_app":
+		default:
+			errors = append(errors, ValidationError{
+				Field:   field,
+				Message: fmt.Sprintf("invalid fallback channel: %s. Valid channels are: email, slack, google_chat, rcs, in_app", channel),
+			})
+			continue
+		}
+		if seen[channel] {
+			errors = append(errors, ValidationError{
+				Field:   field,
+				Message: fmt.Sprintf("fallback channel %s is already the notification type or listed before", channel),
+			})
+			continue
+		}
+		seen[channel] = true
+		if request.ContentFor(channel) == nil {
+			errors = append(errors, ValidationError{
+				Field:   field,
+				Message: fmt.Sprintf("fallback channel %s requires channel_content.%s", channel, models.ChannelContentKey(channel)),
+			})
+		}
 	}
 
 	return errors
@@ -231,11 +515,6 @@ func (v *NotificationValidator) validateEmailContent(content map[string]interfac
 			Field:   "content.subject",
 			Message: "email subject is required",
 		})
-	} else if len(subject) > 255 {
-		errors = append(errors, ValidationError{
-			Field:   "content.subject",
-			Message: "email subject cannot exceed 255 characters",
-		})
 	}
 
 	emailBody, hasEmailBody := content["email_body"].(string)
@@ -244,10 +523,48 @@ func (v *NotificationValidator) validateEmailContent(content map[string]interfac
 			Field:   "content.email_body",
 			Message: "email body is required",
 		})
-	} else if len(emailBody) > 10000 {
+	}
+
+	if textBody, exists := content["text_body"]; exists {
+		if _, ok := textBody.(string); !ok {
+			errors = append(errors, ValidationError{
+				Field:   "content.text_body",
+				Message: "email text body must be a string",
+			})
+		}
+	}
+
+	if renderMode, exists := content["render_mode"]; exists {
+		if mode, ok := renderMode.(string); !ok || !models.IsValidEmailRenderMode(mode) {
+			errors = append(errors, ValidationError{
+				Field:   "content.render_mode",
+				Message: "email render mode must be text or markdown",
+			})
+		}
+	}
+
+	if colorScheme, exists := content["color_scheme"]; exists {
+		if scheme, ok := colorScheme.(string); !ok || !models.IsValidEmailColorScheme(scheme) {
+			errors = append(errors, ValidationError{
+				Field:   "content.color_scheme",
+				Message: "email color scheme must be light or auto",
+			})
+		}
+	}
+
+	if darkModeCSS, exists := content["dark_mode_css"]; exists {
+		if css, ok := darkModeCSS.(string); !ok || strings.Contains(css, "<") {
+			errors = append(errors, ValidationError{
+				Field:   "content.dark_mode_css",
+				Message: "email dark mode CSS must be a string without markup",
+			})
+		}
+	}
+
+	if _, err := models.ParseEmailAttachments(content["attachments"]); err != nil {
 		errors = append(errors, ValidationError{
-			Field:   "content.email_body",
-			Message: "email body cannot exceed 10000 characters",
+			Field:   "content.attachments",
+			Message: err.Error(),
 		})
 	}
 
@@ -264,10 +581,27 @@ func (v *NotificationValidator) validateSlackContent(content map[string]interfac
 			Field:   "content.text",
 			Message: "slack text is required",
 		})
-	} else if len(text) > 3000 {
+	}
+
+	return errors
+}
+
+// validateGoogleChatContent validates Google Chat notification content, which needs text, a
+// card or both
+func (v *NotificationValidator) validateGoogleChatContent(content map[string]interface{}) []ValidationError {
+	var errors []ValidationError
+
+	text, _ := content["text"].(string)
+	card, err := models.ParseGoogleChatCard(content["card"])
+	if err != nil {
+		errors = append(errors, ValidationError{
+			Field:   "content.card",
+			Message: err.Error(),
+		})
+	} else if strings.TrimSpace(text) == "" && card == nil {
 		errors = append(errors, ValidationError{
 			Field:   "content.text",
-			Message: "slack text cannot exceed 3000 characters",
+			Message: "google chat text or card is required",
 		})
 	}
 
@@ -284,11 +618,6 @@ func (v *NotificationValidator) validatePushContent(content map[string]interface
 			Field:   "content.title",
 			Message: "push notification title is required",
 		})
-	} else if len(title) > 255 {
-		errors = append(errors, ValidationError{
-			Field:   "content.title",
-			Message: "push notification title cannot exceed 255 characters",
-		})
 	}
 
 	body, hasBody := content["body"].(string)
@@ -297,11 +626,6 @@ func (v *NotificationValidator) validatePushContent(content map[string]interface
 			Field:   "content.body",
 			Message: "push notification body is required",
 		})
-	} else if len(body) > 4000 {
-		errors = append(errors, ValidationError{
-			Field:   "content.body",
-			Message: "push notification body cannot exceed 4000 characters",
-		})
 	}
 
 	return errors
@@ -350,6 +674,37 @@ func (v *NotificationValidator) validateTemplate(template *models.TemplateData)
 	return errors
 }
 
+// validateRecipientData validates per-recipient template variable overrides.
+// Every overridden recipient must be one of the notification's recipients.
+func (v *NotificationValidator) validateRecipientData(recipientData map[string]map[string]interface{}, recipients []string) []ValidationError {
+	var errors []ValidationError
+	if len(recipientData) == 0 {
+		return errors
+	}
+
+	isRecipient := make(map[string]bool, len(recipients))
+	for _, recipient := range recipients {
+		isRecipient[recipient] = true
+	}
+
+	var unknown []string
+	for recipient := range recipientData {
+		if !isRecipient[recipient] {
+			unknown = append(unknown, recipient)
+		}
+	}
+	sort.Strings(unknown)
+
+	for _, recipient := range unknown {
+		errors = append(errors, ValidationError{
+			Field:   "template.recipient_data",
+			Message: fmt.Sprintf("recipient_data contains %s, which is not one of the recipients", recipient),
+		})
+	}
+
+	return errors
+}
+
 // validateFromField validates the from field based on notification type
 func (v *NotificationValidator) validateFromField(notificationType string, from *struct {
 	Email string `json:"email"`
@@ -357,12 +712,8 @@ func (v *NotificationValidator) validateFromField(notificationType string, from
 	var errors []ValidationError
 
 	if notificationType == "email" {
-		if from == nil || strings.TrimSpace(from.Email) == "" {
-			errors = append(errors, ValidationError{
-				Field:   "from.email",
-				Message: "from email is required for email notifications",
-			})
-		} else {
+		// Without a from address the default from address of the tenant is used
+		if from != nil && strings.TrimSpace(from.Email) != "" {
 			// Validate email format
 			if _, err := mail.ParseAddress(from.Email); err != nil {
 				errors = append(errors, ValidationError{
@@ -395,13 +746,21 @@ func (v *NotificationValidator) validateFromField(notificationType string, from
 func (v *NotificationValidator) validateScheduledAt(scheduledAt time.Time) []ValidationError {
 	var errors []ValidationError
 
-	now := time.Now()
+	now := v.clock.Now()
 
-	// Check if scheduled time is in the past
-	if scheduledAt.Before(now) {
+	// Tell times in the past apart from times too close to now, the server time helps
+	// callers spot a skewed clock
+	switch {
+	case scheduledAt.Before(now):
 		errors = append(errors, ValidationError{
 			Field:   "scheduled_at",
-			Message: "scheduled time cannot be in the past",
+			Message: fmt.Sprintf("scheduled time cannot be in the past, server time is %s", now.UTC().Format(time.RFC3339)),
+		})
+	case scheduledAt.Before(now.Add(v.minScheduleLead)):
+		errors = append(errors, ValidationError{
+			Field: "scheduled_at",
+			Message: fmt.Sprintf("scheduled time is too soon, it must be at least %s after the server time %s",
+				v.minScheduleLead, now.UTC().Format(time.RFC3339)),
 		})
 	}
 
@@ -417,6 +776,27 @@ func (v *NotificationValidator) validateScheduledAt(scheduledAt time.Time) []Val
 	return errors
 }
 
+// ValidateRecipientCheck validates a request to check the recipients of a notification before
+// sending it
+func (v *NotificationValidator) ValidateRecipientCheck(check *models.RecipientCheckRequest) ValidationResult {
+	var errors []ValidationError
+
+	errors = append(errors, v.validateType(check.Type)...)
+	errors = append(errors, v.validateRecipients(check.Recipients)...)
+	errors = append(errors, validateCategory(check.Category)...)
+	if check.ThreadKey != "" && !models.IsValidThreadKey(check.ThreadKey) {
+		errors = append(errors, ValidationError{
+			Field:   "thread_key",
+			Message: fmt.Sprintf("thread key must be up to %d letters, digits or . _ : / @ # - characters", models.MaxThreadKeyLength),
+		})
+	}
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
+
 // ValidateNotificationID validates a notification ID parameter
 func (v *NotificationValidator) ValidateNotificationID(notificationID string) ValidationResult {
 	var errors []ValidationError
@@ -451,3 +831,274 @@ func (v *NotificationValidator) ValidateNotificationID(notificationID string) Va
 		Errors:  errors,
 	}
 }
+
+// ValidateEngagementEvent validates a client report that a notification was displayed or opened
+func (v *NotificationValidator) ValidateEngagementEvent(event *models.EngagementEventRequest) ValidationResult {
+	var errors []ValidationError
+
+	if !models.IsValidEngagementEvent(event.Event) {
+		errors = append(errors, ValidationError{
+			Field:   "event",
+			Message: "event must be one of: displayed, opened",
+		})
+	}
+
+	if !validRecipientRegex.MatchString(event.Recipient) {
+		errors = append(errors, ValidationError{
+			Field:   "recipient",
+			Message: "recipient can only contain alphanumeric characters, hyphens, and underscores",
+		})
+	}
+
+	if event.Channel != "" && !models.IsValidEngagementChannel(event.Channel) {
+		errors = append(errors, ValidationError{
+			Field:   "channel",
+			Message: "channel must be one of: in_app, ios_push, android_push",
+		})
+	}
+
+	if event.OccurredAt != nil && event.OccurredAt.After(v.clock.Now().Add(maxEngagementClockSkew)) {
+		errors = append(errors, ValidationError{
+			Field:   "occurred_at",
+			Message: "occurred_at cannot be in the future",
+		})
+	}
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
+
+// ValidateDeliveryReceipts validates a batch of push delivery receipts
+func (v *NotificationValidator) ValidateDeliveryReceipts(batch *models.DeliveryReceiptBatch) ValidationResult {
+	var errors []ValidationError
+
+	if len(batch.Receipts) == 0 || len(batch.Receipts) > maxReceiptsPerBatch {
+		errors = append(errors, ValidationError{
+			Field:   "receipts",
+			Message: fmt.Sprintf("between 1 and %d receipts are required", maxReceiptsPerBatch),
+		})
+	}
+
+	for i, receipt := range batch.Receipts {
+		if receipt.NotificationID == "" {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("receipts[%d].notification_id", i),
+				Message: "notification_id is required",
+			})
+		}
+		if !models.IsValidReceiptChannel(receipt.Channel) {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("receipts[%d].channel", i),
+				Message: "channel must be one of: ios_push, android_push",
+			})
+		}
+		if receipt.Recipient == "" {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("receipts[%d].recipient", i),
+				Message: "recipient device token is required",
+			})
+		}
+	}
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
+
+// ValidateExternalID validates a caller supplied external reference ID
+func (v *NotificationValidator) ValidateExternalID(externalID string) ValidationResult {
+	var errors []ValidationError
+
+	if externalID == "" {
+		errors = append(errors, ValidationError{
+			Field:   "external_id",
+			Message: "external ID is required",
+		})
+		return ValidationResult{IsValid: false, Errors: errors}
+	}
+
+	if len(externalID) > maxExternalIDLength {
+		errors = append(errors, ValidationError{
+			Field:   "external_id",
+			Message: fmt.Sprintf("external ID cannot exceed %d characters", maxExternalIDLength),
+		})
+	}
+
+	if !validExternalIDRegex.MatchString(externalID) {
+		errors = append(errors, ValidationError{
+			Field:   "external_id",
+			Message: "external ID can only contain alphanumeric characters, dots, colons, slashes, hyphens, and underscores",
+		})
+	}
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
+
+// validateReason validates the reason recipients are told they received the notification for:
+// a single line of plain text
+func validateReason(reason string) []ValidationError {
+	if reason == "" {
+		return nil
+	}
+	if utf8.RuneCountInString(reason) > maxReasonLength {
+		return []ValidationError{{
+			Field:   "reason",
+			Message: fmt.Sprintf("reason cannot exceed %d characters", maxReasonLength),
+		}}
+	}
+	if strings.TrimSpace(reason) == "" || strings.IndexFunc(reason, unicode.IsControl) >= 0 {
+		return []ValidationError{{
+			Field:   "reason",
+			Message: "reason must be a single line of text",
+		}}
+	}
+	return nil
+}
+
+// ValidateTags validates the free-form tags of a notification
+func (v *NotificationValidator) ValidateTags(tags []string) []ValidationError {
+	var errors []ValidationError
+
+	if len(tags) > maxTagsPerNotification {
+		errors = append(errors, ValidationError{
+			Field:   "tags",
+			Message: fmt.Sprintf("maximum %d tags allowed per notification", maxTagsPerNotification),
+		})
+		return errors
+	}
+
+	for i, tag := range tags {
+		if tag == "" {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("tags[%d]", i),
+				Message: "tag cannot be empty",
+			})
+			continue
+		}
+
+		if len(tag) > maxTagLength {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("tags[%d]", i),
+				Message: fmt.Sprintf("tag cannot exceed %d characters", maxTagLength),
+			})
+			continue
+		}
+
+		if !validTagRegex.MatchString(tag) {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("tags[%d]", i),
+				Message: "tag can only contain alphanumeric characters, dots, colons, hyphens, and underscores",
+			})
+		}
+	}
+
+	return errors
+}
+
+// validateCategory validates the category of a notification or template; empty uses the default
+func validateCategory(category string) []ValidationError {
+	var errors []ValidationError
+
+	if category != "" && !models.IsValidCategory(category) {
+		errors = append(errors, ValidationError{
+			Field:   "category",
+			Message: fmt.Sprintf("invalid category: %s. Valid categories are: transactional, marketing, security, system, urgent", category),
+		})
+	}
+
+	return errors
+}
+
+// validateIncidentRequest validates the dedupe key of a request and the rules of incident
+// notifications: they are urgent, have no template, and need a dedupe key to acknowledge or
+// resolve the alert a notification with the same key triggered
+func validateIncidentRequest(request *models.NotificationRequest) []ValidationError {
+	var errors []ValidationError
+
+	if request.DedupeKey != "" && !models.IsValidDedupeKey(request.DedupeKey) {
+		errors = append(errors, ValidationError{
+			Field:   "dedupe_key",
+			Message: fmt.Sprintf("dedupe key must be up to %d letters, digits or . _ : / @ # - characters", models.MaxDedupeKeyLength),
+		})
+	}
+	if request.Type != string(models.IncidentNotification) {
+		return errors
+	}
+
+	if request.Category != "" && request.Category != models.CategoryUrgent {
+		errors = append(errors, ValidationError{
+			Field:   "category",
+			Message: "incident notifications must use the urgent category",
+		})
+	}
+	if request.Template != nil {
+		errors = append(errors, ValidationError{
+			Field:   "template",
+			Message: "incident notifications do not support templates",
+		})
+	}
+	action, _ := request.Content["action"].(string)
+	if (action == models.IncidentActionAcknowledge || action == models.IncidentActionResolve) && request.DedupeKey == "" {
+		errors = append(errors, ValidationError{
+			Field:   "dedupe_key",
+			Message: fmt.Sprintf("a dedupe key is required to %s an incident", action),
+		})
+	}
+
+	return errors
+}
+
+// ValidateNotificationQuery validates the filters of a notification list or analytics query.
+// When requireFilter is set at least an external ID or a tag must be given.
+func (v *NotificationValidator) ValidateNotificationQuery(externalID string, tags []string, status string, notificationType string, requireFilter bool) ValidationResult {
+	var errors []ValidationError
+
+	if requireFilter && externalID == "" && len(tags) == 0 {
+		errors = append(errors, ValidationError{
+			Field:   "query",
+			Message: "at least one of external_id or tag is required",
+		})
+		return ValidationResult{IsValid: false, Errors: errors}
+	}
+
+	if externalID != "" {
+		errors = append(errors, v.ValidateExternalID(externalID).Errors...)
+	}
+
+	if len(tags) > 0 {
+		errors = append(errors, v.ValidateTags(tags)...)
+	}
+
+	if status != "" {
+		validStatuses := map[string]bool{
+			"pending":   true,
+			"scheduled": true,
+			"queued":    true,
+			"sent":      true,
+			"failed":    true,
+			"cancelled": true,
+			"expired":   true,
+		}
+		if !validStatuses[status] {
+			errors = append(errors, ValidationError{
+				Field:   "status",
+				Message: fmt.Sprintf("invalid status: %s", status),
+			})
+		}
+	}
+
+	if notificationType != "" {
+		errors = append(errors, v.validateType(notificationType)...)
+	}
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
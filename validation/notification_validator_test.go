@@ -2,15 +2,21 @@ package validation
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gaurav2721/notification-service/clock"
 	"github.com/gaurav2721/notification-service/models"
 	"github.com/stretchr/testify/assert"
 )
 
+// testNow is the time the fake clock of the validator tests shows
+var testNow = time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
 func TestNotificationValidator_ValidateNotificationRequest(t *testing.T) {
 	validator := NewNotificationValidator()
+	validator.SetClock(clock.NewFake(testNow))
 
 	tests := []struct {
 		name     string
@@ -87,7 +93,7 @@ func TestNotificationValidator_ValidateNotificationRequest(t *testing.T) {
 				},
 				Recipients: []string{"user-123"},
 				ScheduledAt: func() *time.Time {
-					t := time.Now().Add(time.Hour)
+					t := testNow.Add(time.Hour)
 					return &t
 				}(),
 				From: &struct {
@@ -131,7 +137,7 @@ func TestNotificationValidator_ValidateNotificationRequest(t *testing.T) {
 			expected: false,
 		},
 		{
-			name: "Invalid - email without from field",
+			name: "Valid - email without from field uses the default from address",
 			request: &models.NotificationRequest{
 				Type: "email",
 				Content: map[string]interface{}{
@@ -140,7 +146,7 @@ func TestNotificationValidator_ValidateNotificationRequest(t *testing.T) {
 				},
 				Recipients: []string{"user-123"},
 			},
-			expected: false,
+			expected: true,
 		},
 		{
 			name: "Invalid - non-email with from field",
@@ -205,7 +211,7 @@ func TestNotificationValidator_ValidateNotificationRequest(t *testing.T) {
 				},
 				Recipients: []string{"user-123"},
 				ScheduledAt: func() *time.Time {
-					t := time.Now().Add(-time.Hour)
+					t := testNow.Add(-time.Hour)
 					return &t
 				}(),
 				From: &struct {
@@ -216,6 +222,62 @@ func TestNotificationValidator_ValidateNotificationRequest(t *testing.T) {
 			},
 			expected: false,
 		},
+		{
+			name: "Valid - recipient data overrides",
+			request: &models.NotificationRequest{
+				Type: "email",
+				Template: &models.TemplateData{
+					ID:            "550e8400-e29b-41d4-a716-446655440002",
+					Version:       1,
+					Data:          map[string]interface{}{"platform": "Acme"},
+					RecipientData: map[string]map[string]interface{}{"user-123": {"total_amount": "$10"}},
+				},
+				Recipients: []string{"user-123", "user-456"},
+				From: &struct {
+					Email string `json:"email"`
+				}{
+					Email: "test@example.com",
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "Invalid - recipient data for unknown recipient",
+			request: &models.NotificationRequest{
+				Type: "email",
+				Template: &models.TemplateData{
+					ID:            "550e8400-e29b-41d4-a716-446655440002",
+					Version:       1,
+					Data:          map[string]interface{}{"platform": "Acme"},
+					RecipientData: map[string]map[string]interface{}{"user-789": {"total_amount": "$10"}},
+				},
+				Recipients: []string{"user-123"},
+			},
+			expected: false,
+		},
+		{
+			name: "Valid - emoji counted as single characters",
+			request: &models.NotificationRequest{
+				Type: "slack",
+				Content: map[string]interface{}{
+					"text": strings.Repeat("👍🏽", 3000),
+				},
+				Recipients: []string{"user-123"},
+			},
+			expected: true,
+		},
+		{
+			name: "Invalid - push payload too large in bytes",
+			request: &models.NotificationRequest{
+				Type: "android_push",
+				Content: map[string]interface{}{
+					"title": "Test Title",
+					"body":  strings.Repeat("🎉", 1000),
+				},
+				Recipients: []string{"user-123"},
+			},
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -291,6 +353,330 @@ func TestNotificationValidator_ValidateRecipients(t *testing.T) {
 	}
 }
 
+func TestNotificationValidator_ValidateAddressRecipients(t *testing.T) {
+	validator := NewNotificationValidator()
+
+	tests := []struct {
+		name             string
+		notificationType string
+		recipients       []string
+		expected         bool
+	}{
+		{name: "Email addresses and users", notificationType: "email", recipients: []string{"user-123", "email:alice@example.com"}, expected: true},
+		{name: "Slack channel name", notificationType: "slack", recipients: []string{"slack:#ops"}, expected: true},
+		{name: "Slack channel ID", notificationType: "slack", recipients: []string{"slack:C0123ABCD"}, expected: true},
+		{name: "Invalid email address", notificationType: "email", recipients: []string{"email:not-an-address"}, expected: false},
+		{name: "Email with display name", notificationType: "email", recipients: []string{"email:Alice <alice@example.com>"}, expected: false},
+		{name: "Invalid slack channel", notificationType: "slack", recipients: []string{"slack:#Ops Team"}, expected: false},
+		{name: "Invalid phone number", notificationType: "email", recipients: []string{"phone:555-0123"}, expected: false},
+		{name: "Unknown prefix", notificationType: "email", recipients: []string{"fax:12345"}, expected: false},
+		{name: "Email address for slack", notificationType: "slack", recipients: []string{"email:alice@example.com"}, expected: false},
+		{name: "Slack channel for push", notificationType: "in_app", recipients: []string{"slack:#ops"}, expected: false},
+		{name: "Phone number without SMS channel", notificationType: "email", recipients: []string{"phone:+14155550123"}, expected: false},
+		{name: "Phone number for rcs", notificationType: "rcs", recipients: []string{"phone:+14155550123"}, expected: true},
+		{name: "Incident route", notificationType: "incident", recipients: []string{"incident:platform-oncall"}, expected: true},
+		{name: "Invalid incident route", notificationType: "incident", recipients: []string{"incident:on call"}, expected: false},
+		{name: "User for incident", notificationType: "incident", recipients: []string{"user-123"}, expected: false},
+		{name: "Incident route for email", notificationType: "email", recipients: []string{"incident:platform-oncall"}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := append(validator.validateRecipients(tt.recipients), validator.validateRecipientChannels(tt.notificationType, tt.recipients)...)
+			isValid := len(errors) == 0
+			if isValid != tt.expected {
+				t.Errorf("address recipients valid = %v, expected %v (errors: %+v)", isValid, tt.expected, errors)
+			}
+		})
+	}
+}
+
+func TestNotificationValidator_ValidateChannelContent(t *testing.T) {
+	validator := NewNotificationValidator()
+
+	slack := map[string]interface{}{"text": "Deploy finished"}
+	email := map[string]interface{}{"subject": "Deploy finished", "email_body": "The deploy finished."}
+
+	tests := []struct {
+		name     string
+		request  models.NotificationRequest
+		expected bool
+	}{
+		{
+			name: "Channel content instead of content",
+			request: models.NotificationRequest{Type: "slack", Recipients: []string{"user-123"},
+				ChannelContent: map[string]map[string]interface{}{"slack": slack}},
+			expected: true,
+		},
+		{
+			name: "Fallback channel with content",
+			request: models.NotificationRequest{Type: "slack", Recipients: []string{"user-123"}, Content: slack,
+				ChannelContent: map[string]map[string]interface{}{"email": email}, FallbackChannels: []string{"email"}},
+			expected: true,
+		},
+		{
+			name: "Push content for in-app fallback",
+			request: models.NotificationRequest{Type: "slack", Recipients: []string{"user-123"}, Content: slack,
+				ChannelContent:   map[string]map[string]interface{}{"push": {"title": "Deploy", "body": "Finished"}},
+				FallbackChannels: []string{"in_app"}},
+			expected: true,
+		},
+		{
+			name: "Fallback channel without content",
+			request: models.NotificationRequest{Type: "slack", Recipients: []string{"user-123"}, Content: slack,
+				FallbackChannels: []string{"email"}},
+			expected: false,
+		},
+		{
+			name: "Fallback to the notification type",
+			request: models.NotificationRequest{Type: "slack", Recipients: []string{"user-123"},
+				ChannelContent: map[string]map[string]interface{}{"slack": slack}, FallbackChannels: []string{"slack"}},
+			expected: false,
+		},
+		{
+			name: "Unknown fallback channel",
+			request: models.NotificationRequest{Type: "slack", Recipients: []string{"user-123"}, Content: slack,
+				FallbackChannels: []string{"sms"}},
+			expected: false,
+		},
+		{
+			name: "Unknown channel content",
+			request: models.NotificationRequest{Type: "slack", Recipients: []string{"user-123"}, Content: slack,
+				ChannelContent: map[string]map[string]interface{}{"fax": {"text": "Hello"}}},
+			expected: false,
+		},
+		{
+			name: "Invalid fallback content",
+			request: models.NotificationRequest{Type: "slack", Recipients: []string{"user-123"}, Content: slack,
+				ChannelContent:   map[string]map[string]interface{}{"email": {"subject": "Deploy finished"}},
+				FallbackChannels: []string{"email"}},
+			expected: false,
+		},
+		{
+			name: "Channel content together with content",
+			request: models.NotificationRequest{Type: "slack", Recipients: []string{"user-123"}, Content: slack,
+				ChannelContent: map[string]map[string]interface{}{"slack": slack}},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := validator.ValidateNotificationRequest(&tt.request)
+			if result.IsValid != tt.expected {
+				t.Errorf("ValidateNotificationRequest() valid = %v, expected %v (errors: %+v)", result.IsValid, tt.expected, result.Errors)
+			}
+		})
+	}
+}
+
+func TestNotificationValidator_ValidateIncident(t *testing.T) {
+	validator := NewNotificationValidator()
+
+	trigger := map[string]interface{}{"summary": "Checkout error rate above 5%", "severity": "critical"}
+	recipients := []string{"incident:platform-oncall"}
+
+	tests := []struct {
+		name     string
+		request  models.NotificationRequest
+		expected bool
+	}{
+		{
+			name:     "Trigger",
+			request:  models.NotificationRequest{Type: "incident", Recipients: recipients, Content: trigger, DedupeKey: "checkout:error-rate"},
+			expected: true,
+		},
+		{
+			name:     "Trigger without dedupe key",
+			request:  models.NotificationRequest{Type: "incident", Recipients: recipients, Content: trigger, Category: "urgent"},
+			expected: true,
+		},
+		{
+			name: "Resolve",
+			request: models.NotificationRequest{Type: "incident", Recipients: recipients, DedupeKey: "checkout:error-rate",
+				Content: map[string]interface{}{"action": "resolve"}},
+			expected: true,
+		},
+		{
+			name:     "Resolve without dedupe key",
+			request:  models.NotificationRequest{Type: "incident", Recipients: recipients, Content: map[string]interface{}{"action": "resolve"}},
+			expected: false,
+		},
+		{
+			name:     "Unknown action",
+			request:  models.NotificationRequest{Type: "incident", Recipients: recipients, Content: map[string]interface{}{"action": "snooze"}},
+			expected: false,
+		},
+		{
+			name: "Trigger without severity",
+			request: models.NotificationRequest{Type: "incident", Recipients: recipients,
+				Content: map[string]interface{}{"summary": "Checkout error rate above 5%"}},
+			expected: false,
+		},
+		{
+			name:     "Non-urgent category",
+			request:  models.NotificationRequest{Type: "incident", Recipients: recipients, Content: trigger, Category: "system"},
+			expected: false,
+		},
+		{
+			name: "Template",
+			request: models.NotificationRequest{Type: "incident", Recipients: recipients,
+				Template: &models.TemplateData{ID: "550e8400-e29b-41d4-a716-446655440000", Version: 1, Data: map[string]interface{}{"name": "x"}}},
+			expected: false,
+		},
+		{
+			name: "Invalid dedupe key",
+			request: models.NotificationRequest{Type: "slack", Recipients: []string{"user-123"}, DedupeKey: "checkout error rate",
+				Content: map[string]interface{}{"text": "Checkout error rate above 5%"}},
+			expected: false,
+		},
+		{
+			name: "Urgent category on another type",
+			request: models.NotificationRequest{Type: "slack", Recipients: []string{"user-123"}, Category: "urgent",
+				Content: map[string]interface{}{"text": "Checkout error rate above 5%"}},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := validator.ValidateNotificationRequest(&tt.request)
+			if result.IsValid != tt.expected {
+				t.Errorf("ValidateNotificationRequest() valid = %v, expected %v (errors: %+v)", result.IsValid, tt.expected, result.Errors)
+			}
+		})
+	}
+}
+
+func TestNotificationValidator_ValidateRCS(t *testing.T) {
+	validator := NewNotificationValidator()
+
+	recipients := []string{"user-123"}
+	card := map[string]interface{}{"title": "Order #1234", "media_url": "https://cdn.example.com/parcel.png"}
+
+	tests := []struct {
+		name     string
+		content  map[string]interface{}
+		expected bool
+	}{
+		{name: "Text", content: map[string]interface{}{"text": "Your order has shipped"}, expected: true},
+		{name: "Card with suggestions", content: map[string]interface{}{"card": card, "suggestions": []interface{}{
+			map[string]interface{}{"text": "Track parcel", "url": "https://example.com/track/1234"},
+			map[string]interface{}{"text": "Thanks"},
+		}}, expected: true},
+		{name: "Neither text nor card", content: map[string]interface{}{"fallback_text": "Your order has shipped"}, expected: false},
+		{name: "Empty card", content: map[string]interface{}{"card": map[string]interface{}{}}, expected: false},
+		{name: "Media over http", content: map[string]interface{}{"card": map[string]interface{}{"media_url": "http://cdn.example.com/parcel.png"}}, expected: false},
+		{name: "Long suggestion", content: map[string]interface{}{"text": "Your order has shipped", "suggestions": []interface{}{
+			map[string]interface{}{"text": "Track the parcel on our website"},
+		}}, expected: false},
+		{name: "Long fallback text", content: map[string]interface{}{"text": "Your order has shipped", "fallback_text": strings.Repeat("a", 1601)}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := validator.ValidateNotificationRequest(&models.NotificationRequest{Type: "rcs", Recipients: recipients, Content: tt.content})
+			if result.IsValid != tt.expected {
+				t.Errorf("ValidateNotificationRequest() valid = %v, expected %v (errors: %+v)", result.IsValid, tt.expected, result.Errors)
+			}
+		})
+	}
+}
+
+func TestNotificationValidator_ValidateEmailAttachments(t *testing.T) {
+	validator := NewNotificationValidator()
+
+	attachment := func(filename, content string) map[string]interface{} {
+		return map[string]interface{}{"filename": filename, "content_type": "application/pdf", "content": content}
+	}
+
+	tests := []struct {
+		name        string
+		attachments interface{}
+		expected    bool
+	}{
+		{name: "Valid attachment", attachments: []interface{}{attachment("report.pdf", "aGVsbG8=")}, expected: true},
+		{name: "Not a list", attachments: "report.pdf", expected: false},
+		{name: "Missing filename", attachments: []interface{}{attachment("", "aGVsbG8=")}, expected: false},
+		{name: "Filename with path", attachments: []interface{}{attachment("../report.pdf", "aGVsbG8=")}, expected: false},
+		{name: "Invalid base64", attachments: []interface{}{attachment("report.pdf", "not base64!")}, expected: false},
+		{name: "Empty content", attachments: []interface{}{attachment("report.pdf", "")}, expected: false},
+		{name: "Too many attachments", attachments: make([]interface{}, models.MaxEmailAttachments+1), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := validator.validateEmailContent(map[string]interface{}{
+				"subject":     "Report",
+				"email_body":  "The report is attached.",
+				"attachments": tt.attachments,
+			})
+			isValid := len(errors) == 0
+			if isValid != tt.expected {
+				t.Errorf("validateEmailContent() valid = %v, expected %v (errors: %+v)", isValid, tt.expected, errors)
+			}
+		})
+	}
+}
+
+func TestNotificationValidator_ValidateInboxActions(t *testing.T) {
+	validator := NewNotificationValidator()
+
+	action := func(id, label, url, style string) map[string]interface{} {
+		return map[string]interface{}{"id": id, "label": label, "url": url, "style": style}
+	}
+
+	tests := []struct {
+		name     string
+		actions  interface{}
+		expected bool
+	}{
+		{name: "No actions", actions: nil, expected: true},
+		{name: "Web link", actions: []interface{}{action("view", "View order", "https://shop.example.com/orders/42", "primary")}, expected: true},
+		{name: "Deep link without style", actions: []interface{}{action("open", "Open", "myapp://orders/42", "")}, expected: true},
+		{name: "Two actions", actions: []interface{}{
+			action("approve", "Approve", "myapp://requests/7/approve", "primary"),
+			action("reject", "Reject", "myapp://requests/7/reject", "destructive"),
+		}, expected: true},
+		{name: "Not a list", actions: "view", expected: false},
+		{name: "Not an object", actions: []interface{}{"view"}, expected: false},
+		{name: "Missing id", actions: []interface{}{action("", "View", "https://example.com", "")}, expected: false},
+		{name: "Id with spaces", actions: []interface{}{action("view order", "View", "https://example.com", "")}, expected: false},
+		{name: "Duplicate id", actions: []interface{}{
+			action("view", "View", "https://example.com/a", ""),
+			action("view", "View again", "https://example.com/b", ""),
+		}, expected: false},
+		{name: "Missing label", actions: []interface{}{action("view", " ", "https://example.com", "")}, expected: false},
+		{name: "Label too long", actions: []interface{}{action("view", strings.Repeat("a", models.MaxInboxActionLabel+1), "https://example.com", "")}, expected: false},
+		{name: "Missing url", actions: []interface{}{action("view", "View", "", "")}, expected: false},
+		{name: "Relative url", actions: []interface{}{action("view", "View", "/orders/42", "")}, expected: false},
+		{name: "Http url without host", actions: []interface{}{action("view", "View", "https:///orders", "")}, expected: false},
+		{name: "Javascript url", actions: []interface{}{action("view", "View", "javascript:alert(1)", "")}, expected: false},
+		{name: "Data url", actions: []interface{}{action("view", "View", "data:text/html,hi", "")}, expected: false},
+		{name: "Unknown style", actions: []interface{}{action("view", "View", "https://example.com", "danger")}, expected: false},
+		{name: "Too many actions", actions: []interface{}{
+			action("a", "A", "myapp://a", ""),
+			action("b", "B", "myapp://b", ""),
+			action("c", "C", "myapp://c", ""),
+			action("d", "D", "myapp://d", ""),
+		}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := validator.validateContentByType("in_app", map[string]interface{}{
+				"title":   "Order shipped",
+				"body":    "Your order is on its way.",
+				"actions": tt.actions,
+			})
+			isValid := len(errors) == 0
+			if isValid != tt.expected {
+				t.Errorf("validateContentByType() valid = %v, expected %v (errors: %+v)", isValid, tt.expected, errors)
+			}
+		})
+	}
+}
+
 func TestNotificationValidator_ValidateTemplateVersion(t *testing.T) {
 	validator := NewNotificationValidator()
 
@@ -423,3 +809,199 @@ func TestNotificationValidator_ValidateNotificationID(t *testing.T) {
 		})
 	}
 }
+
+func TestNotificationValidator_ValidateExternalID(t *testing.T) {
+	validator := NewNotificationValidator()
+
+	tests := []struct {
+		name       string
+		externalID string
+		expected   bool
+	}{
+		{name: "Simple reference", externalID: "order-42", expected: true},
+		{name: "Namespaced reference", externalID: "crm:case/1001", expected: true},
+		{name: "Dotted reference", externalID: "evt_01H8X.2", expected: true},
+		{name: "Empty", externalID: "", expected: false},
+		{name: "Whitespace", externalID: "order 42", expected: false},
+		{name: "Too long", externalID: strings.Repeat("a", 129), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := validator.ValidateExternalID(tt.externalID)
+			if result.IsValid != tt.expected {
+				t.Errorf("ValidateExternalID() = %v, expected %v", result.IsValid, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNotificationValidator_ValidateTags(t *testing.T) {
+	validator := NewNotificationValidator()
+
+	tests := []struct {
+		name     string
+		tags     []string
+		expected bool
+	}{
+		{name: "Valid tags", tags: []string{"billing", "q3-campaign", "team:growth"}, expected: true},
+		{name: "Empty tag", tags: []string{""}, expected: false},
+		{name: "Whitespace in tag", tags: []string{"q3 campaign"}, expected: false},
+		{name: "Tag too long", tags: []string{strings.Repeat("a", 51)}, expected: false},
+		{name: "Too many tags", tags: strings.Split("a,b,c,d,e,f,g,h,i,j,k", ","), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := validator.ValidateTags(tt.tags)
+			if isValid := len(errors) == 0; isValid != tt.expected {
+				t.Errorf("ValidateTags() = %v, expected %v", isValid, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateReason(t *testing.T) {
+	assert.Empty(t, validateReason(""))
+	assert.Empty(t, validateReason("You received this because you subscribed to order updates"))
+	assert.Empty(t, validateReason(strings.Repeat("é", 300)))
+	assert.Equal(t, "reason cannot exceed 300 characters", validateReason(strings.Repeat("a", 301))[0].Message)
+	assert.Equal(t, "reason must be a single line of text", validateReason("Line one\nLine two")[0].Message)
+	assert.Equal(t, "reason must be a single line of text", validateReason("   ")[0].Message)
+}
+
+func TestNotificationValidator_ValidateNotificationQuery(t *testing.T) {
+	validator := NewNotificationValidator()
+
+	assert.True(t, validator.ValidateNotificationQuery("order-42", nil, "", "", true).IsValid)
+	assert.True(t, validator.ValidateNotificationQuery("", []string{"billing"}, "sent", "email", true).IsValid)
+	assert.True(t, validator.ValidateNotificationQuery("", nil, "", "", false).IsValid)
+	assert.True(t, validator.ValidateNotificationQuery("", []string{"billing"}, "expired", "", true).IsValid)
+	assert.False(t, validator.ValidateNotificationQuery("", nil, "", "", true).IsValid)
+	assert.False(t, validator.ValidateNotificationQuery("", []string{"billing"}, "delivered", "", true).IsValid)
+	assert.False(t, validator.ValidateNotificationQuery("", []string{"billing"}, "", "sms", true).IsValid)
+}
+
+func TestNotificationValidator_ScheduledAtFollowsClock(t *testing.T) {
+	fakeClock := clock.NewFake(testNow)
+	validator := NewNotificationValidator()
+	validator.SetClock(fakeClock)
+
+	scheduledAt := testNow.Add(time.Hour)
+	assert.Empty(t, validator.validateScheduledAt(scheduledAt))
+	assert.NotEmpty(t, validator.validateScheduledAt(testNow.AddDate(1, 0, 1)))
+
+	// The same time is rejected once the clock has passed it
+	fakeClock.Advance(2 * time.Hour)
+	errors := validator.validateScheduledAt(scheduledAt)
+	if assert.Len(t, errors, 1) {
+		assert.Equal(t, "scheduled_at", errors[0].Field)
+	}
+}
+
+func TestNotificationValidator_ScheduledAtMinimumLead(t *testing.T) {
+	validator := NewNotificationValidator()
+	validator.SetClock(clock.NewFake(testNow))
+	validator.minScheduleLead = 2 * time.Minute
+
+	assert.Empty(t, validator.validateScheduledAt(testNow.Add(2*time.Minute)))
+
+	// Offsets are honoured, this is 90 seconds after testNow
+	berlin := time.FixedZone("CET", 60*60)
+	errors := validator.validateScheduledAt(testNow.Add(90 * time.Second).In(berlin))
+	if assert.Len(t, errors, 1) {
+		assert.Contains(t, errors[0].Message, "too soon")
+	}
+
+	errors = validator.validateScheduledAt(testNow.Add(-time.Second))
+	if assert.Len(t, errors, 1) {
+		assert.Contains(t, errors[0].Message, "in the past")
+		assert.Contains(t, errors[0].Message, "2024-01-01T09:00:00Z")
+	}
+}
+
+func TestNotificationValidator_ValidateEngagementEvent(t *testing.T) {
+	validator := NewNotificationValidator()
+	validator.SetClock(clock.NewFake(testNow))
+	earlier := testNow.Add(-time.Hour)
+	later := testNow.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		event    models.EngagementEventRequest
+		expected bool
+	}{
+		{name: "Displayed", event: models.EngagementEventRequest{Event: "displayed", Recipient: "user-001"}, expected: true},
+		{name: "Opened from push", event: models.EngagementEventRequest{Event: "opened", Recipient: "user-001", Channel: "ios_push", OccurredAt: &earlier}, expected: true},
+		{name: "Unknown event", event: models.EngagementEventRequest{Event: "clicked", Recipient: "user-001"}, expected: false},
+		{name: "Invalid recipient", event: models.EngagementEventRequest{Event: "opened", Recipient: "user 001"}, expected: false},
+		{name: "Unknown channel", event: models.EngagementEventRequest{Event: "opened", Recipient: "user-001", Channel: "email"}, expected: false},
+		{name: "Future occurred_at", event: models.EngagementEventRequest{Event: "opened", Recipient: "user-001", OccurredAt: &later}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := validator.ValidateEngagementEvent(&tt.event)
+			assert.Equal(t, tt.expected, result.IsValid, result.Errors)
+		})
+	}
+}
+
+func TestNotificationValidator_ValidateRecipientCheck(t *testing.T) {
+	validator := NewNotificationValidator()
+
+	tests := []struct {
+		name     string
+		check    models.RecipientCheckRequest
+		expected bool
+	}{
+		{name: "Email to users", check: models.RecipientCheckRequest{Type: "email", Recipients: []string{"user-001", "user-002"}}, expected: true},
+		{name: "Push in a thread", check: models.RecipientCheckRequest{Type: "ios_push", Recipients: []string{"user-001"}, Category: "marketing", ThreadKey: "order:42"}, expected: true},
+		{name: "Missing type", check: models.RecipientCheckRequest{Recipients: []string{"user-001"}}, expected: false},
+		{name: "No recipients", check: models.RecipientCheckRequest{Type: "email"}, expected: false},
+		{name: "Unknown category", check: models.RecipientCheckRequest{Type: "email", Recipients: []string{"user-001"}, Category: "gossip"}, expected: false},
+		{name: "Invalid thread key", check: models.RecipientCheckRequest{Type: "in_app", Recipients: []string{"user-001"}, ThreadKey: "order 42"}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := validator.ValidateRecipientCheck(&tt.check)
+			assert.Equal(t, tt.expected, result.IsValid, result.Errors)
+		})
+	}
+}
+
+func TestNotificationValidator_AllowsTransactional(t *testing.T) {
+	t.Setenv("TRANSACTIONAL_API_KEYS", "billing, auth")
+	validator := NewNotificationValidator()
+
+	assert.True(t, validator.AllowsTransactional("billing"))
+	assert.True(t, validator.AllowsTransactional("auth"))
+	assert.False(t, validator.AllowsTransactional("marketing"))
+	assert.False(t, validator.AllowsTransactional("anonymous"))
+}
+
+func TestNotificationValidator_ValidateDeliveryReceipts(t *testing.T) {
+	validator := NewNotificationValidator()
+	receipt := models.DeliveryReceipt{NotificationID: "notification-1", Channel: "android_push", Recipient: "android_token_1"}
+
+	tests := []struct {
+		name     string
+		receipts []models.DeliveryReceipt
+		expected bool
+	}{
+		{name: "Valid", receipts: []models.DeliveryReceipt{receipt}, expected: true},
+		{name: "Empty batch", receipts: nil, expected: false},
+		{name: "Unsupported channel", receipts: []models.DeliveryReceipt{{NotificationID: "notification-1", Channel: "email", Recipient: "a@company.com"}}, expected: false},
+		{name: "Missing recipient", receipts: []models.DeliveryReceipt{{NotificationID: "notification-1", Channel: "ios_push"}}, expected: false},
+		{name: "Missing notification ID", receipts: []models.DeliveryReceipt{{Channel: "ios_push", Recipient: "ios_token_1"}}, expected: false},
+		{name: "Too many receipts", receipts: make([]models.DeliveryReceipt, maxReceiptsPerBatch+1), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := validator.ValidateDeliveryReceipts(&models.DeliveryReceiptBatch{Receipts: tt.receipts})
+			assert.Equal(t, tt.expected, result.IsValid, result.Errors)
+		})
+	}
+}
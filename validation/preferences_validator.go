@@ -0,0 +1,113 @@
+package validation
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// PreferencesValidator provides validation methods for notification preferences
+type PreferencesValidator struct{}
+
+// NewPreferencesValidator creates a new preferences validator
+func NewPreferencesValidator() *PreferencesValidator {
+	return &PreferencesValidator{}
+}
+
+// ValidatePreferences validates a notification preferences update
+func (v *PreferencesValidator) ValidatePreferences(preferences *models.NotificationPreferences) ValidationResult {
+	var errors []ValidationError
+
+	if preferences.Digest != "" && !models.IsValidDigestFrequency(preferences.Digest) {
+		errors = append(errors, ValidationError{
+			Field:   "digest",
+			Message: "digest must be one of: off, daily, weekly",
+		})
+	}
+
+	if quietHours := preferences.QuietHours; quietHours != nil {
+		if err := models.ParseClock(quietHours.Start); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   "quiet_hours.start",
+				Message: "quiet hours start must be a time of day in HH:MM format",
+			})
+		}
+		if err := models.ParseClock(quietHours.End); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   "quiet_hours.end",
+				Message: "quiet hours end must be a time of day in HH:MM format",
+			})
+		}
+		if quietHours.Start != "" && quietHours.Start == quietHours.End {
+			errors = append(errors, ValidationError{
+				Field:   "quiet_hours",
+				Message: "quiet hours start and end must be different",
+			})
+		}
+		if _, err := quietHours.Location(); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   "quiet_hours.timezone",
+				Message: "quiet hours timezone must be an IANA time zone such as Europe/Berlin",
+			})
+		}
+	}
+
+	validChannels := map[string]bool{
+		"email":        true,
+		"slack":        true,
+		"ios_push":     true,
+		"android_push": true,
+		"in_app":       true,
+		"google_chat":  true,
+		"rcs":          true,
+	}
+	// Report errors in a stable order
+	categories := make([]string, 0, len(preferences.Categories))
+	for category := range preferences.Categories {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	for _, category := range categories {
+		preference := preferences.Categories[category]
+		if !models.IsValidCategory(category) {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("categories.%s", category),
+				Message: "category must be one of: transactional, marketing, security, system, urgent",
+			})
+			continue
+		}
+		if preference == nil {
+			continue
+		}
+		for i, channel := range preference.MutedChannels {
+			if !validChannels[channel] {
+				errors = append(errors, ValidationError{
+					Field:   fmt.Sprintf("categories.%s.muted_channels[%d]", category, i),
+					Message: fmt.Sprintf("invalid channel: %s. Valid channels are: email, slack, ios_push, android_push, in_app, google_chat, rcs", channel),
+				})
+			}
+		}
+	}
+
+	if len(preferences.MutedThreads) > models.MaxMutedThreads {
+		errors = append(errors, ValidationError{
+			Field:   "muted_threads",
+			Message: fmt.Sprintf("at most %d threads can be muted", models.MaxMutedThreads),
+		})
+	}
+	for i, key := range preferences.MutedThreads {
+		if !models.IsValidThreadKey(key) {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("muted_threads[%d]", i),
+				Message: fmt.Sprintf("thread key must be up to %d letters, digits or . _ : / @ # - characters", models.MaxThreadKeyLength),
+			})
+		}
+	}
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
@@ -0,0 +1,94 @@
+package validation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// mutedThreadFields returns the fields reported for count empty muted thread keys
+func mutedThreadFields(count int) []string {
+	fields := make([]string, count)
+	for i := range fields {
+		fields[i] = fmt.Sprintf("muted_threads[%d]", i)
+	}
+	return fields
+}
+
+func TestPreferencesValidator_ValidatePreferences(t *testing.T) {
+	validator := NewPreferencesValidator()
+
+	tests := []struct {
+		name           string
+		preferences    *models.NotificationPreferences
+		expectedFields []string
+	}{
+		{
+			name:        "Valid - daily digest with quiet hours",
+			preferences: &models.NotificationPreferences{Digest: models.DigestDaily, QuietHours: &models.QuietHours{Start: "22:00", End: "07:00", Timezone: "Europe/Berlin"}},
+		},
+		{
+			name:        "Valid - empty preferences",
+			preferences: &models.NotificationPreferences{},
+		},
+		{
+			name:           "Invalid - unknown digest frequency",
+			preferences:    &models.NotificationPreferences{Digest: "hourly"},
+			expectedFields: []string{"digest"},
+		},
+		{
+			name:           "Invalid - quiet hours",
+			preferences:    &models.NotificationPreferences{QuietHours: &models.QuietHours{Start: "10pm", End: "24:30", Timezone: "Mars/Olympus"}},
+			expectedFields: []string{"quiet_hours.start", "quiet_hours.end", "quiet_hours.timezone"},
+		},
+		{
+			name:           "Invalid - empty quiet hour window",
+			preferences:    &models.NotificationPreferences{QuietHours: &models.QuietHours{Start: "08:00", End: "08:00"}},
+			expectedFields: []string{"quiet_hours"},
+		},
+		{
+			name: "Valid - category preferences",
+			preferences: &models.NotificationPreferences{Categories: map[string]*models.CategoryPreference{
+				models.CategoryMarketing: {OptOut: true},
+				models.CategorySystem:    {MutedChannels: []string{"email", "ios_push"}},
+			}},
+		},
+		{
+			name: "Invalid - unknown category and channel",
+			preferences: &models.NotificationPreferences{Categories: map[string]*models.CategoryPreference{
+				"newsletter":             {OptOut: true},
+				models.CategoryMarketing: {MutedChannels: []string{"sms"}},
+			}},
+			expectedFields: []string{"categories.marketing.muted_channels[0]", "categories.newsletter"},
+		},
+		{
+			name:        "Valid - muted threads",
+			preferences: &models.NotificationPreferences{MutedThreads: []string{"order:42", "ticket-1234"}},
+		},
+		{
+			name:           "Invalid - muted thread key",
+			preferences:    &models.NotificationPreferences{MutedThreads: []string{"order:42", "order 43"}},
+			expectedFields: []string{"muted_threads[1]"},
+		},
+		{
+			name:           "Invalid - too many muted threads",
+			preferences:    &models.NotificationPreferences{MutedThreads: make([]string, models.MaxMutedThreads+1)},
+			expectedFields: append([]string{"muted_threads"}, mutedThreadFields(models.MaxMutedThreads+1)...),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := validator.ValidatePreferences(tt.preferences)
+			assert.Equal(t, len(tt.expectedFields) == 0, result.IsValid)
+
+			var fields []string
+			for _, err := range result.Errors {
+				fields = append(fields, err.Field)
+			}
+			assert.Equal(t, tt.expectedFields, fields)
+		})
+	}
+}
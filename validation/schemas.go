@@ -0,0 +1,81 @@
+package validation
+
+import (
+	"embed"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gaurav2721/notification-service/jsonschema"
+)
+
+// Names of the published request schemas
+const (
+	NotificationRequestSchema = "notification-request"
+	TemplateRequestSchema     = "template-request"
+)
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// requestSchemas are the compiled request schemas by name
+var requestSchemas = compileSchemas()
+
+// compileSchemas compiles the embedded schemas; an invalid schema is a build mistake and panics
+func compileSchemas() map[string]*jsonschema.Schema {
+	entries, err := schemaFiles.ReadDir("schemas")
+	if err != nil {
+		panic(err)
+	}
+	schemas := make(map[string]*jsonschema.Schema, len(entries))
+	for _, entry := range entries {
+		document, err := schemaFiles.ReadFile(path.Join("schemas", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		schemas[strings.TrimSuffix(entry.Name(), ".json")] = jsonschema.MustCompile(document)
+	}
+	return schemas
+}
+
+// SchemaNames returns the names of the published request schemas, sorted
+func SchemaNames() []string {
+	names := make([]string, 0, len(requestSchemas))
+	for name := range requestSchemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SchemaDocument returns the JSON Schema document with the given name
+func SchemaDocument(name string) ([]byte, bool) {
+	if _, ok := requestSchemas[name]; !ok {
+		return nil, false
+	}
+	document, err := schemaFiles.ReadFile(path.Join("schemas", name+".json"))
+	return document, err == nil
+}
+
+// validateAgainstSchema checks a request body against the named schema. Bodies that are not
+// JSON pass, so the binding reports them as before.
+func validateAgainstSchema(name string, body []byte) []ValidationError {
+	schemaErrors, err := requestSchemas[name].ValidateJSON(body)
+	if err != nil {
+		return nil
+	}
+	errors := make([]ValidationError, 0, len(schemaErrors))
+	for _, schemaError := range schemaErrors {
+		field := schemaError.Field()
+		if field == "" {
+			field = "body"
+		}
+		errors = append(errors, ValidationError{
+			Field:   field,
+			Message: schemaError.Message,
+			Path:    schemaError.Path,
+			Keyword: schemaError.Keyword,
+		})
+	}
+	return errors
+}
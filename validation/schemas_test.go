@@ -0,0 +1,121 @@
+package validation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemas_AcceptValidRequests(t *testing.T) {
+	assert.Equal(t, []string{NotificationRequestSchema, TemplateRequestSchema}, SchemaNames())
+
+	scheduledAt := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	notification, err := json.Marshal(&models.NotificationRequest{
+		Type:        "email",
+		Recipients:  []string{"user-001", "email:alice@example.com"},
+		ScheduledAt: &scheduledAt,
+		From: &struct {
+			Email string `json:"email"`
+		}{Email: "noreply@company.com"},
+		Template: &models.TemplateData{
+			ID:            "550e8400-e29b-41d4-a716-446655440000",
+			Version:       1,
+			Data:          map[string]interface{}{"name": "Alice"},
+			RecipientData: map[string]map[string]interface{}{"user-001": {"name": "Bob"}},
+		},
+		Tags:             []string{"billing", "team:growth"},
+		Category:         models.CategoryTransactional,
+		ChannelContent:   map[string]map[string]interface{}{"slack": {"text": "Hi"}},
+		FallbackChannels: []string{"slack"},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, validateAgainstSchema(NotificationRequestSchema, notification))
+
+	incident, err := json.Marshal(&models.NotificationRequest{
+		Type:       "incident",
+		Recipients: []string{"incident:platform-oncall"},
+		Content:    map[string]interface{}{"action": "resolve"},
+		Category:   models.CategoryUrgent,
+		DedupeKey:  "checkout:error-rate",
+	})
+	require.NoError(t, err)
+	assert.Empty(t, validateAgainstSchema(NotificationRequestSchema, incident))
+
+	template, err := json.Marshal(&models.TemplateRequest{
+		Name:              "Welcome Email",
+		Type:              models.EmailNotification,
+		Content:           models.TemplateContent{Subject: "Welcome", EmailBody: "Hello {{name}}"},
+		RequiredVariables: []string{"name"},
+		RenderMode:        models.EmailRenderModeMarkdown,
+		Localizations:     map[string]models.TemplateContent{"fr": {Subject: "Bienvenue", EmailBody: "Bonjour {{name}}"}},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, validateAgainstSchema(TemplateRequestSchema, template))
+
+	document, ok := SchemaDocument(TemplateRequestSchema)
+	require.True(t, ok)
+	assert.Contains(t, string(document), `"title": "TemplateRequest"`)
+	_, ok = SchemaDocument("missing")
+	assert.False(t, ok)
+}
+
+func TestSchemas_ReportPaths(t *testing.T) {
+	errors := validateAgainstSchema(NotificationRequestSchema, []byte(`{
+		"type": "sms",
+		"recipients": ["user-001", 42],
+		"template": {"id": "550e8400-e29b-41d4-a716-446655440000", "data": {}},
+		"channel_content": {"fax": {}}
+	}`))
+	assert.Equal(t, []ValidationError{
+		{Field: "channel_content.fax", Message: "is not a known property", Path: "/channel_content/fax", Keyword: "additionalProperties"},
+		{Field: "recipients[1]", Message: "must be a string", Path: "/recipients/1", Keyword: "type"},
+		{Field: "template.version", Message: "is required", Path: "/template/version", Keyword: "required"},
+		{Field: "template.data", Message: "must not be empty", Path: "/template/data", Keyword: "minProperties"},
+		{Field: "type", Message: "must be one of: email, slack, ios_push, android_push, in_app, google_chat, incident, rcs", Path: "/type", Keyword: "enum"},
+	}, errors)
+
+	errors = validateAgainstSchema(TemplateRequestSchema, []byte(`[]`))
+	require.Len(t, errors, 1)
+	assert.Equal(t, "body", errors[0].Field)
+
+	// Bodies that are not JSON are left to the binding
+	assert.Empty(t, validateAgainstSchema(TemplateRequestSchema, []byte(`{"name": `)))
+}
+
+func TestValidateTemplateRequest_ChecksSchemaFirst(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/templates", NewValidationLayer().ValidateTemplateRequest(), func(c *gin.Context) {
+		request, _ := c.Get("validated_template_request")
+		c.JSON(http.StatusOK, request)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/templates", strings.NewReader(
+		`{"name": "Alert", "type": "slack", "content": {"text": 42}, "required_variables": ["a", "a"]}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response struct {
+		Schema  string            `json:"schema"`
+		Details []ValidationError `json:"details"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, TemplateRequestSchema, response.Schema)
+	require.Len(t, response.Details, 2)
+	assert.Equal(t, "/content/text", response.Details[0].Path)
+	assert.Equal(t, "required_variables[1]", response.Details[1].Field)
+
+	// Valid bodies are still bound after the schema read them
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/templates", strings.NewReader(
+		`{"name": "Alert", "type": "slack", "content": {"text": "Alert: {{message}}"}, "required_variables": ["message"]}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"Alert: {{message}}"`)
+}
@@ -0,0 +1,69 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"github.com/gaurav2721/notification-service/models"
+)
+
+// maxAllowedFromDomains bounds the number of domains of a sender allowlist
+const maxAllowedFromDomains = 50
+
+// domainPattern matches domain names such as example.com, optionally prefixed with "*." to
+// include their subdomains
+var domainPattern = regexp.MustCompile(`^(\*\.)?([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+
+// SenderValidator provides validation methods for tenant sender settings
+type SenderValidator struct{}
+
+// NewSenderValidator creates a new sender validator
+func NewSenderValidator() *SenderValidator {
+	return &SenderValidator{}
+}
+
+// ValidateSenderSettings validates a sender settings update
+func (v *SenderValidator) ValidateSenderSettings(settings *models.SenderSettings) ValidationResult {
+	var errors []ValidationError
+
+	if len(settings.AllowedDomains) > maxAllowedFromDomains {
+		errors = append(errors, ValidationError{
+			Field:   "allowed_domains",
+			Message: fmt.Sprintf("cannot have more than %d allowed domains", maxAllowedFromDomains),
+		})
+	}
+	for i, domain := range settings.AllowedDomains {
+		if !domainPattern.MatchString(strings.ToLower(strings.TrimSpace(domain))) {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("allowed_domains[%d]", i),
+				Message: fmt.Sprintf("invalid domain: %s. Use a domain such as example.com or *.example.com", domain),
+			})
+		}
+	}
+
+	if defaultFrom := strings.TrimSpace(settings.DefaultFrom); defaultFrom != "" {
+		if _, err := mail.ParseAddress(defaultFrom); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   "default_from",
+				Message: "invalid email format",
+			})
+		} else if len(defaultFrom) > 254 {
+			errors = append(errors, ValidationError{
+				Field:   "default_from",
+				Message: "email address cannot exceed 254 characters",
+			})
+		} else if len(errors) == 0 && !settings.AllowsAddress(defaultFrom) {
+			errors = append(errors, ValidationError{
+				Field:   "default_from",
+				Message: "default from address must be on one of the allowed domains",
+			})
+		}
+	}
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
@@ -0,0 +1,53 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/gaurav2721/notification-service/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSenderValidator_ValidateSenderSettings(t *testing.T) {
+	validator := NewSenderValidator()
+
+	tests := []struct {
+		name           string
+		settings       *models.SenderSettings
+		expectedFields []string
+	}{
+		{
+			name: "Valid - default from on an allowed subdomain",
+			settings: &models.SenderSettings{
+				DefaultFrom:    "Acme <noreply@mail.acme.com>",
+				AllowedDomains: []string{"acme.com", "*.acme.com"},
+			},
+		},
+		{
+			name:     "Valid - empty settings",
+			settings: &models.SenderSettings{},
+		},
+		{
+			name:           "Invalid - malformed default from and domains",
+			settings:       &models.SenderSettings{DefaultFrom: "noreply", AllowedDomains: []string{"acme", "https://acme.com", "acme.*"}},
+			expectedFields: []string{"allowed_domains[0]", "allowed_domains[1]", "allowed_domains[2]", "default_from"},
+		},
+		{
+			name:           "Invalid - default from outside the allowed domains",
+			settings:       &models.SenderSettings{DefaultFrom: "noreply@acme.io", AllowedDomains: []string{"acme.com"}},
+			expectedFields: []string{"default_from"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := validator.ValidateSenderSettings(tt.settings)
+			assert.Equal(t, len(tt.expectedFields) == 0, result.IsValid)
+
+			var fields []string
+			for _, err := range result.Errors {
+				fields = append(fields, err.Field)
+			}
+			assert.Equal(t, tt.expectedFields, fields)
+		})
+	}
+}
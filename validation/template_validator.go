@@ -3,6 +3,7 @@ package validation
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/gaurav2721/notification-service/models"
@@ -41,6 +42,22 @@ func (v *TemplateValidator) ValidateTemplateRequest(request *models.TemplateRequ
 		errors = append(errors, descriptionErrors...)
 	}
 
+	if renderModeErrors := v.validateTemplateRenderMode(request.RenderMode, request.Type); len(renderModeErrors) > 0 {
+		errors = append(errors, renderModeErrors...)
+	}
+
+	if colorSchemeErrors := v.validateTemplateColorScheme(request.ColorScheme, request.Type); len(colorSchemeErrors) > 0 {
+		errors = append(errors, colorSchemeErrors...)
+	}
+
+	if categoryErrors := validateCategory(request.Category); len(categoryErrors) > 0 {
+		errors = append(errors, categoryErrors...)
+	}
+
+	if localeErrors := v.validateTemplateLocales(request); len(localeErrors) > 0 {
+		errors = append(errors, localeErrors...)
+	}
+
 	return ValidationResult{
 		IsValid: len(errors) == 0,
 		Errors:  errors,
@@ -181,9 +198,10 @@ func (v *TemplateValidator) validateTemplateType(templateType models.Notificatio
 	}
 
 	validTypes := map[models.NotificationType]bool{
-		models.EmailNotification: true,
-		models.SlackNotification: true,
-		models.InAppNotification: true,
+		models.EmailNotification:      true,
+		models.SlackNotification:      true,
+		models.InAppNotification:      true,
+		models.GoogleChatNotification: true,
 	}
 
 	if !validTypes[templateType] {
@@ -226,6 +244,23 @@ func (v *TemplateValidator) validateTemplateContent(content models.TemplateConte
 				Message: "email body cannot exceed 10000 characters",
 			})
 		}
+		if len(content.EmailTextBody) > 10000 {
+			errors = append(errors, ValidationError{
+				Field:   "content.email_text_body",
+				Message: "email text body cannot exceed 10000 characters",
+			})
+		}
+		if len(content.EmailDarkModeCSS) > 10000 {
+			errors = append(errors, ValidationError{
+				Field:   "content.email_dark_mode_css",
+				Message: "email dark mode CSS cannot exceed 10000 characters",
+			})
+		} else if strings.Contains(content.EmailDarkModeCSS, "<") {
+			errors = append(errors, ValidationError{
+				Field:   "content.email_dark_mode_css",
+				Message: "email dark mode CSS cannot contain markup",
+			})
+		}
 
 	case models.SlackNotification:
 		if content.Text == "" {
@@ -241,6 +276,23 @@ func (v *TemplateValidator) validateTemplateContent(content models.TemplateConte
 			})
 		}
 
+	case models.GoogleChatNotification:
+		if content.Text == "" && content.Card == nil {
+			errors = append(errors, ValidationError{
+				Field:   "content",
+				Message: "google chat template text or card is required",
+			})
+		}
+		if len(content.Text) > 4096 {
+			errors = append(errors, ValidationError{
+				Field:   "content.text",
+				Message: "google chat text cannot exceed 4096 characters",
+			})
+		}
+		if content.Card != nil {
+			errors = append(errors, v.validateGoogleChatCard(content.Card)...)
+		}
+
 	case models.InAppNotification:
 		if content.Title == "" {
 			errors = append(errors, ValidationError{
@@ -271,6 +323,43 @@ func (v *TemplateValidator) validateTemplateContent(content models.TemplateConte
 	return errors
 }
 
+// validateGoogleChatCard validates the card of a Google Chat template. URLs may be template
+// variables, so they are checked once the template is rendered.
+func (v *TemplateValidator) validateGoogleChatCard(card *models.GoogleChatCard) []ValidationError {
+	var errors []ValidationError
+
+	if strings.TrimSpace(card.Title) == "" {
+		errors = append(errors, ValidationError{
+			Field:   "content.card.title",
+			Message: "google chat card title is required",
+		})
+	}
+	for i, section := range card.Sections {
+		if strings.TrimSpace(section.Text) == "" {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("content.card.sections[%d].text", i),
+				Message: "google chat card section text is required",
+			})
+		}
+	}
+	if len(card.Buttons) > models.MaxGoogleChatCardButtons {
+		errors = append(errors, ValidationError{
+			Field:   "content.card.buttons",
+			Message: fmt.Sprintf("google chat cards cannot have more than %d buttons", models.MaxGoogleChatCardButtons),
+		})
+	}
+	for i, button := range card.Buttons {
+		if strings.TrimSpace(button.Text) == "" || strings.TrimSpace(button.URL) == "" {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("content.card.buttons[%d]", i),
+				Message: "google chat card buttons need text and a url",
+			})
+		}
+	}
+
+	return errors
+}
+
 // validateRequiredVariables validates the required variables list
 func (v *TemplateValidator) validateRequiredVariables(variables []string) []ValidationError {
 	var errors []ValidationError
@@ -339,7 +428,81 @@ func (v *TemplateValidator) validateTemplateDescription(description string) []Va
 	return errors
 }
 
+// validateTemplateLocales validates the locale of the content and the content of each localization
+func (v *TemplateValidator) validateTemplateLocales(request *models.TemplateRequest) []ValidationError {
+	var errors []ValidationError
+
+	if request.Locale != "" {
+		if _, err := models.NormalizeLocale(request.Locale); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   "locale",
+				Message: fmt.Sprintf("invalid locale: %s, expected a BCP 47 tag such as en-US", request.Locale),
+			})
+		}
+	}
+
+	// Localizations are checked in sorted order so errors are reported in a stable order
+	locales := make([]string, 0, len(request.Localizations))
+	for locale := range request.Localizations {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	for _, locale := range locales {
+		if _, err := models.NormalizeLocale(locale); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   "localizations",
+				Message: fmt.Sprintf("invalid locale: %s, expected a BCP 47 tag such as en-US", locale),
+			})
+			continue
+		}
+		for _, contentError := range v.validateTemplateContent(request.Localizations[locale], request.Type) {
+			contentError.Field = "localizations." + locale + "." + strings.TrimPrefix(contentError.Field, "content.")
+			errors = append(errors, contentError)
+		}
+	}
+
+	return errors
+}
+
+// validateTemplateRenderMode validates the render mode, which only applies to email templates
+func (v *TemplateValidator) validateTemplateRenderMode(renderMode string, templateType models.NotificationType) []ValidationError {
+	var errors []ValidationError
+
+	if !models.IsValidEmailRenderMode(renderMode) {
+		errors = append(errors, ValidationError{
+			Field:   "render_mode",
+			Message: fmt.Sprintf("invalid render mode: %s. Valid modes are: text, markdown", renderMode),
+		})
+	} else if renderMode == models.EmailRenderModeMarkdown && templateType != models.EmailNotification {
+		errors = append(errors, ValidationError{
+			Field:   "render_mode",
+			Message: "markdown render mode is only supported for email templates",
+		})
+	}
+
+	return errors
+}
+
+// validateTemplateColorScheme validates the color scheme, which only applies to email templates
+func (v *TemplateValidator) validateTemplateColorScheme(colorScheme string, templateType models.NotificationType) []ValidationError {
+	var errors []ValidationError
+
+	if !models.IsValidEmailColorScheme(colorScheme) {
+		errors = append(errors, ValidationError{
+			Field:   "color_scheme",
+			Message: fmt.Sprintf("invalid color scheme: %s. Valid color schemes are: light, auto", colorScheme),
+		})
+	} else if colorScheme != "" && templateType != models.EmailNotification {
+		errors = append(errors, ValidationError{
+			Field:   "color_scheme",
+			Message: "color schemes are only supported for email templates",
+		})
+	}
+
+	return errors
+}
+
 // getValidTemplateTypes returns a comma-separated list of valid template types
 func getValidTemplateTypes() string {
-	return "email, slack, in_app"
+	return "email, slack, in_app, google_chat"
 }
@@ -153,6 +153,149 @@ func TestTemplateValidator_ValidateTemplateRequest(t *testing.T) {
 			},
 			expected: false,
 		},
+		{
+			name: "markdown email template",
+			request: &models.TemplateRequest{
+				Name: "Order Shipped",
+				Type: models.EmailNotification,
+				Content: models.TemplateContent{
+					Subject:   "Your order shipped",
+					EmailBody: "# Hi {{name}}\n\nYour order is **on its way**.",
+				},
+				RequiredVariables: []string{"name"},
+				RenderMode:        models.EmailRenderModeMarkdown,
+			},
+			expected: true,
+		},
+		{
+			name: "unknown render mode",
+			request: &models.TemplateRequest{
+				Name: "Order Shipped",
+				Type: models.EmailNotification,
+				Content: models.TemplateContent{
+					Subject:   "Your order shipped",
+					EmailBody: "Hello",
+				},
+				RequiredVariables: []string{},
+				RenderMode:        "rst",
+			},
+			expected: false,
+		},
+		{
+			name: "markdown slack template",
+			request: &models.TemplateRequest{
+				Name: "Alert",
+				Type: models.SlackNotification,
+				Content: models.TemplateContent{
+					Text: "*Alert*",
+				},
+				RequiredVariables: []string{},
+				RenderMode:        models.EmailRenderModeMarkdown,
+			},
+			expected: false,
+		},
+		{
+			name: "dark mode email template",
+			request: &models.TemplateRequest{
+				Name: "Newsletter",
+				Type: models.EmailNotification,
+				Content: models.TemplateContent{
+					Subject:          "News",
+					EmailBody:        "<p class=\"brand\">News for {{name}}</p>",
+					EmailDarkModeCSS: ".brand { color: #ffffff; }",
+				},
+				RequiredVariables: []string{"name"},
+				ColorScheme:       models.EmailColorSchemeAuto,
+			},
+			expected: true,
+		},
+		{
+			name: "dark mode CSS with markup",
+			request: &models.TemplateRequest{
+				Name: "Newsletter",
+				Type: models.EmailNotification,
+				Content: models.TemplateContent{
+					Subject:          "News",
+					EmailBody:        "<p>News</p>",
+					EmailDarkModeCSS: "</style><script>alert(1)</script>",
+				},
+				RequiredVariables: []string{},
+				ColorScheme:       models.EmailColorSchemeAuto,
+			},
+			expected: false,
+		},
+		{
+			name: "color scheme on slack template",
+			request: &models.TemplateRequest{
+				Name: "Alert",
+				Type: models.SlackNotification,
+				Content: models.TemplateContent{
+					Text: "*Alert*",
+				},
+				RequiredVariables: []string{},
+				ColorScheme:       models.EmailColorSchemeLight,
+			},
+			expected: false,
+		},
+		{
+			name: "unknown category",
+			request: &models.TemplateRequest{
+				Name: "Alert",
+				Type: models.SlackNotification,
+				Content: models.TemplateContent{
+					Text: "*Alert*",
+				},
+				RequiredVariables: []string{},
+				Category:          "newsletter",
+			},
+			expected: false,
+		},
+		{
+			name: "localized slack template",
+			request: &models.TemplateRequest{
+				Name: "Alert",
+				Type: models.SlackNotification,
+				Content: models.TemplateContent{
+					Text: "Alert: {{message}}",
+				},
+				RequiredVariables: []string{"message"},
+				Locale:            "en",
+				Localizations: map[string]models.TemplateContent{
+					"fr_CA": {Text: "Alerte : {{message}}"},
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "invalid locale",
+			request: &models.TemplateRequest{
+				Name: "Alert",
+				Type: models.SlackNotification,
+				Content: models.TemplateContent{
+					Text: "*Alert*",
+				},
+				RequiredVariables: []string{},
+				Localizations: map[string]models.TemplateContent{
+					"not a locale": {Text: "*Alerte*"},
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "localization without content",
+			request: &models.TemplateRequest{
+				Name: "Alert",
+				Type: models.SlackNotification,
+				Content: models.TemplateContent{
+					Text: "*Alert*",
+				},
+				RequiredVariables: []string{},
+				Localizations: map[string]models.TemplateContent{
+					"fr": {Title: "Alerte"},
+				},
+			},
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {